@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+)
+
+const (
+	// defaultEventsMinRetryWait and defaultEventsMaxRetryWait bound the
+	// exponential backoff used between reconnection attempts.
+	defaultEventsMinRetryWait = 1 * time.Second
+	defaultEventsMaxRetryWait = 30 * time.Second
+
+	// defaultEventsBufferSize is the number of events that can be queued
+	// before the subscription stops reading from the stream.
+	defaultEventsBufferSize = 64
+)
+
+// Events is used to subscribe to the events published by Vault.
+type Events struct {
+	c *Client
+}
+
+// Events is used to return the client for event subscriptions.
+func (c *Client) Events() *Events {
+	return &Events{c: c}
+}
+
+// Event is a single event received from the event stream.
+type Event struct {
+	// ID uniquely identifies the event in the stream. It is used to resume
+	// the subscription after the event.
+	ID string
+
+	// Type is the type of the event.
+	Type string
+
+	// Data is the raw payload of the event.
+	Data json.RawMessage
+}
+
+// Decode decodes the payload of the event into out.
+func (e *Event) Decode(out interface{}) error {
+	if len(e.Data) == 0 {
+		return errors.New("event has no data")
+	}
+	return jsonutil.DecodeJSON(e.Data, out)
+}
+
+// EventSubscribeOptions holds the optional settings of an event subscription.
+type EventSubscribeOptions struct {
+	// LastEventID resumes the subscription after the event with this ID
+	// instead of starting with new events.
+	LastEventID string
+
+	// MinRetryWait and MaxRetryWait bound the exponential backoff between
+	// reconnection attempts. They default to 1 and 30 seconds.
+	MinRetryWait time.Duration
+	MaxRetryWait time.Duration
+
+	// MaxRetries is the number of consecutive failed reconnection attempts
+	// after which the subscription gives up. Zero retries forever.
+	MaxRetries int
+
+	// BufferSize is the number of events that can be queued on the channel
+	// returned by EventSubscription.Events. It defaults to 64.
+	BufferSize int
+}
+
+// EventSubscription is a subscription to the event stream. Connection errors
+// are handled by reconnecting and resuming after the last event received, so
+// events are neither missed nor delivered twice as long as the server still
+// holds them.
+type EventSubscription struct {
+	c         *Client
+	eventType string
+	opts      EventSubscribeOptions
+	eventCh   chan *Event
+
+	l           sync.RWMutex
+	lastEventID string
+	retryWait   time.Duration
+	err         error
+}
+
+// Subscribe subscribes to events of the given type. The initial connection is
+// made before returning, so that errors such as missing permissions are
+// reported right away. The subscription ends when the context is canceled or
+// when reconnecting fails, at which point the events channel is closed and
+// Err reports the reason.
+func (e *Events) Subscribe(ctx context.Context, eventType string, opts *EventSubscribeOptions) (*EventSubscription, error) {
+	if eventType == "" {
+		return nil, errors.New("missing event type")
+	}
+
+	s := &EventSubscription{
+		c:         e.c,
+		eventType: eventType,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MinRetryWait <= 0 {
+		s.opts.MinRetryWait = defaultEventsMinRetryWait
+	}
+	if s.opts.MaxRetryWait <= 0 {
+		s.opts.MaxRetryWait = defaultEventsMaxRetryWait
+	}
+	if s.opts.MaxRetryWait < s.opts.MinRetryWait {
+		s.opts.MaxRetryWait = s.opts.MinRetryWait
+	}
+	if s.opts.BufferSize <= 0 {
+		s.opts.BufferSize = defaultEventsBufferSize
+	}
+	s.lastEventID = s.opts.LastEventID
+	s.eventCh = make(chan *Event, s.opts.BufferSize)
+
+	body, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.run(ctx, body)
+
+	return s, nil
+}
+
+// Events returns the channel on which events are delivered. It is closed when
+// the subscription ends.
+func (s *EventSubscription) Events() <-chan *Event {
+	return s.eventCh
+}
+
+// LastEventID returns the ID of the last event received, which can be used
+// to resume the subscription later on.
+func (s *EventSubscription) LastEventID() string {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.lastEventID
+}
+
+// Err returns the error that ended the subscription, once the events channel
+// has been closed. It returns nil if the subscription ended because its
+// context was canceled.
+func (s *EventSubscription) Err() error {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.err
+}
+
+// connect opens the event stream, resuming after the last event received.
+func (s *EventSubscription) connect(ctx context.Context) (io.ReadCloser, error) {
+	r := s.c.NewRequest("GET", fmt.Sprintf("/v1/sys/events/subscribe/%s", s.eventType))
+	r.Headers.Set("Accept", "text/event-stream")
+	if lastEventID := s.LastEventID(); lastEventID != "" {
+		r.Headers.Set("Last-Event-ID", lastEventID)
+	}
+
+	req, err := r.toRetryableHTTP()
+	if err != nil {
+		return nil, err
+	}
+
+	// The stream is expected to stay open indefinitely, so the client's
+	// request timeout must not apply to it. Retries are handled by the
+	// subscription itself.
+	s.c.config.modifyLock.RLock()
+	httpClient := *s.c.config.HttpClient
+	s.c.config.modifyLock.RUnlock()
+	httpClient.Timeout = 0
+
+	resp, err := httpClient.Do(req.Request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Response{Response: resp}
+	if err := result.Error(); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// run reads events from the stream, reconnecting whenever it is interrupted.
+func (s *EventSubscription) run(ctx context.Context, body io.ReadCloser) {
+	defer close(s.eventCh)
+
+	failures := 0
+	for {
+		received, err := s.read(ctx, body)
+		body.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			failures = 0
+		}
+
+		for {
+			failures++
+			if s.opts.MaxRetries > 0 && failures > s.opts.MaxRetries {
+				s.setErr(fmt.Errorf("giving up on event stream after %d attempts: %w", s.opts.MaxRetries, err))
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.backoff(failures)):
+			}
+
+			body, err = s.connect(ctx)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			// Client errors such as a revoked token will not go away by
+			// reconnecting
+			var respErr *ResponseError
+			if errors.As(err, &respErr) && respErr.StatusCode >= 400 && respErr.StatusCode < 500 && respErr.StatusCode != http.StatusTooManyRequests {
+				s.setErr(err)
+				return
+			}
+		}
+	}
+}
+
+// read parses the event stream and delivers the events it contains until the
+// stream ends. It reports whether any event was received.
+func (s *EventSubscription) read(ctx context.Context, body io.Reader) (bool, error) {
+	received := false
+	scanner := bufio.NewScanner(body)
+
+	var eventType string
+	var data strings.Builder
+	hasData := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// An empty line dispatches the event built up so far
+		if line == "" {
+			if !hasData {
+				eventType = ""
+				continue
+			}
+
+			event := &Event{
+				ID:   s.LastEventID(),
+				Type: eventType,
+				Data: json.RawMessage(data.String()),
+			}
+			if event.Type == "" {
+				event.Type = s.eventType
+			}
+			eventType = ""
+			data.Reset()
+			hasData = false
+
+			select {
+			case s.eventCh <- event:
+				received = true
+			case <-ctx.Done():
+				return received, ctx.Err()
+			}
+			continue
+		}
+
+		// Lines starting with a colon are comments, used as keepalives
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.l.Lock()
+				s.lastEventID = value
+				s.l.Unlock()
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 64); err == nil {
+				s.l.Lock()
+				s.retryWait = time.Duration(ms) * time.Millisecond
+				s.l.Unlock()
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return received, err
+	}
+	return received, io.EOF
+}
+
+// backoff returns how long to wait before the given reconnection attempt.
+// A retry interval sent by the server takes precedence over the configured
+// minimum for the first attempt.
+func (s *EventSubscription) backoff(attempt int) time.Duration {
+	s.l.RLock()
+	wait := s.retryWait
+	s.l.RUnlock()
+
+	if wait <= 0 {
+		wait = s.opts.MinRetryWait
+	}
+	for i := 1; i < attempt && wait < s.opts.MaxRetryWait; i++ {
+		wait *= 2
+	}
+	if wait > s.opts.MaxRetryWait {
+		wait = s.opts.MaxRetryWait
+	}
+	return wait
+}
+
+func (s *EventSubscription) setErr(err error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.err = err
+}
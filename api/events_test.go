@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventSubscription_Resume(t *testing.T) {
+	var l sync.Mutex
+	var lastEventIDs []string
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/sys/events/subscribe/kv-write" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		l.Lock()
+		lastEventIDs = append(lastEventIDs, req.Header.Get("Last-Event-ID"))
+		attempt := len(lastEventIDs)
+		l.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch attempt {
+		case 1:
+			fmt.Fprint(w, ": keepalive\n\n")
+			fmt.Fprint(w, "retry: 10\n\n")
+			fmt.Fprint(w, "id: 1\nevent: kv-write\ndata: {\"path\":\n")
+			fmt.Fprint(w, "data: \"secret/foo\"}\n\n")
+			fmt.Fprint(w, "id: 2\ndata: {\"path\":\"secret/bar\"}\n\n")
+		case 2:
+			fmt.Fprint(w, "id: 3\ndata: {\"path\":\"secret/baz\"}\n\n")
+		default:
+			// The token has since been revoked
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sub, err := client.Events().Subscribe(ctx, "kv-write", &EventSubscribeOptions{
+		MinRetryWait: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for event := range sub.Events() {
+		if event.Type != "kv-write" {
+			t.Fatalf("bad type: %q", event.Type)
+		}
+		var data struct {
+			Path string `json:"path"`
+		}
+		if err := event.Decode(&data); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, event.ID+":"+data.Path)
+	}
+
+	expected := []string{"1:secret/foo", "2:secret/bar", "3:secret/baz"}
+	if fmt.Sprint(paths) != fmt.Sprint(expected) {
+		t.Fatalf("expected events %v, got %v", expected, paths)
+	}
+
+	respErr, ok := sub.Err().(*ResponseError)
+	if !ok || respErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected permission denied error, got: %v", sub.Err())
+	}
+	if sub.LastEventID() != "3" {
+		t.Fatalf("bad last event ID: %q", sub.LastEventID())
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if fmt.Sprint(lastEventIDs) != fmt.Sprint([]string{"", "2", "3"}) {
+		t.Fatalf("bad Last-Event-ID headers: %v", lastEventIDs)
+	}
+}
+
+func TestEventSubscription_InitialError(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":["permission denied"]}`)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Events().Subscribe(context.Background(), "kv-write", nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -269,6 +269,7 @@ func (r *LifetimeWatcher) doRenew() error {
 		}
 
 		var leaseDuration time.Duration
+		var renewAt *time.Time
 		fallbackLeaseDuration := initialTime.Add(priorDuration).Sub(time.Now())
 
 		switch {
@@ -306,10 +307,12 @@ func (r *LifetimeWatcher) doRenew() error {
 				return r.errLifetimeWatcherNotRenewable
 			}
 
-			// Grab the lease duration
+			// Grab the lease duration and the recommended renewal time
 			newDuration := renewal.LeaseDuration
+			renewAt = renewal.RenewAt
 			if tokenMode {
 				newDuration = renewal.Auth.LeaseDuration
+				renewAt = renewal.Auth.RenewAt
 			}
 
 			leaseDuration = time.Duration(newDuration) * time.Second
@@ -337,6 +340,15 @@ func (r *LifetimeWatcher) doRenew() error {
 			return nil
 		}
 
+		// Prefer the renewal time recommended by Vault, which spreads the
+		// renewals of clients over time, as long as it leaves the grace
+		// period to renew.
+		if renewAt != nil {
+			if hint := time.Until(*renewAt); hint > 0 && leaseDuration-hint > r.grace {
+				sleepDuration = hint
+			}
+		}
+
 		select {
 		case <-r.stopCh:
 			return nil
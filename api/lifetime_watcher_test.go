@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLifetimeWatcher_renewHint(t *testing.T) {
+	t.Parallel()
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/auth/token/renew-self" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		renewAt := time.Now().Add(2 * time.Second).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"auth":{"client_token":"foo","lease_duration":60,"renewable":true,"renew_at":%q}}`, renewAt)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := client.NewLifetimeWatcher(&LifetimeWatcherInput{
+		Secret: &Secret{
+			Auth: &SecretAuth{
+				ClientToken:   "foo",
+				LeaseDuration: 60,
+				Renewable:     true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	// Without the hint, the second renewal would only happen after 40
+	// seconds
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case renewal := <-watcher.RenewCh():
+			if renewal.Secret.Auth.RenewAt == nil {
+				t.Fatal("expected renewal hint to be parsed")
+			}
+		case err := <-watcher.DoneCh():
+			t.Fatalf("watcher exited: %v", err)
+		case <-timeout:
+			t.Fatalf("timed out waiting for renewal %d", i+1)
+		}
+	}
+}
@@ -20,6 +20,11 @@ type Secret struct {
 	LeaseDuration int    `json:"lease_duration"`
 	Renewable     bool   `json:"renewable"`
 
+	// RenewAt, if non-nil, is the time at which Vault recommends renewing
+	// the lease. Recommended times are spread out by the server so that
+	// clients do not all renew at the same moment.
+	RenewAt *time.Time `json:"renew_at,omitempty"`
+
 	// Data is the actual contents of the secret. The format of the data
 	// is arbitrary and up to the secret backend.
 	Data map[string]interface{} `json:"data"`
@@ -295,8 +300,9 @@ type SecretAuth struct {
 	Orphan           bool              `json:"orphan"`
 	EntityID         string            `json:"entity_id"`
 
-	LeaseDuration int  `json:"lease_duration"`
-	Renewable     bool `json:"renewable"`
+	LeaseDuration int        `json:"lease_duration"`
+	RenewAt       *time.Time `json:"renew_at,omitempty"`
+	Renewable     bool       `json:"renewable"`
 }
 
 // ParseSecret is used to parse a secret value from JSON from an io.Reader.
@@ -46,6 +46,37 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 	return hashStr, nil
 }
 
+func (c *Sys) AuditSalt(path string) (*AuditSaltOutput, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/audit-salt/%s", path))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result AuditSaltOutput
+	err = mapstructure.Decode(secret.Data, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Salt == "" {
+		return nil, errors.New("salt not found in response data")
+	}
+
+	return &result, nil
+}
+
 func (c *Sys) ListAudit() (map[string]*Audit, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/audit")
 
@@ -134,3 +165,8 @@ type Audit struct {
 	Local       bool              `json:"local" mapstructure:"local"`
 	Path        string            `json:"path" mapstructure:"path"`
 }
+
+type AuditSaltOutput struct {
+	Salt     string `json:"salt" mapstructure:"salt"`
+	HMACType string `json:"hmac_type" mapstructure:"hmac_type"`
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// EventWebhookInput is the configuration of an event webhook sink. HMACKey is
+// never returned once set.
+type EventWebhookInput struct {
+	URL        string   `json:"url,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+	HMACKey    string   `json:"hmac_key,omitempty"`
+	MaxRetries *int     `json:"max_retries,omitempty"`
+	Timeout    string   `json:"timeout,omitempty"`
+}
+
+// EventWebhookOutput is an event webhook sink, whose timeout is in seconds.
+type EventWebhookOutput struct {
+	Name       string   `mapstructure:"name"`
+	URL        string   `mapstructure:"url"`
+	EventTypes []string `mapstructure:"event_types"`
+	HMACKeySet bool     `mapstructure:"hmac_key_set"`
+	MaxRetries int      `mapstructure:"max_retries"`
+	Timeout    int      `mapstructure:"timeout"`
+}
+
+// EventDeadLetter is an event which could not be delivered to a webhook sink.
+type EventDeadLetter struct {
+	Event struct {
+		ID   string                 `json:"id"`
+		Type string                 `json:"type"`
+		Time time.Time              `json:"time"`
+		Data map[string]interface{} `json:"data"`
+	} `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+func (c *Sys) ListEventWebhooks() ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/events/webhooks")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result []string
+	err = mapstructure.Decode(secret.Data["keys"], &result)
+	return result, err
+}
+
+func (c *Sys) GetEventWebhook(name string) (*EventWebhookOutput, error) {
+	r := c.c.NewRequest(http.MethodGet, fmt.Sprintf("/v1/sys/events/webhooks/%s", name))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result EventWebhookOutput
+	err = mapstructure.Decode(secret.Data, &result)
+	return &result, err
+}
+
+// PutEventWebhook creates or updates the event webhook sink. Unset fields of
+// an existing sink are left unchanged.
+func (c *Sys) PutEventWebhook(name string, input *EventWebhookInput) error {
+	r := c.c.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sys/events/webhooks/%s", name))
+	if err := r.SetJSONBody(input); err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) DeleteEventWebhook(name string) error {
+	r := c.c.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/sys/events/webhooks/%s", name))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+// EventWebhookDeadLetters returns the most recent events which could not be
+// delivered to the sink, oldest first.
+func (c *Sys) EventWebhookDeadLetters(name string) ([]*EventDeadLetter, error) {
+	r := c.c.NewRequest(http.MethodGet, fmt.Sprintf("/v1/sys/events/webhooks/%s/dead-letters", name))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			DeadLetters []*EventDeadLetter `json:"dead_letters"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return result.Data.DeadLetters, nil
+}
+
+func (c *Sys) ClearEventWebhookDeadLetters(name string) error {
+	r := c.c.NewRequest(http.MethodDelete, fmt.Sprintf("/v1/sys/events/webhooks/%s/dead-letters", name))
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// HAStatus returns the nodes of the HA cluster and the recent changes of the
+// active node.
+func (c *Sys) HAStatus() (*HAStatusResponse, error) {
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/ha-status")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result HAStatusResponse
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339),
+		Result:     &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = d.Decode(secret.Data)
+	return &result, err
+}
+
+type HAStatusResponse struct {
+	Nodes             []HANode           `mapstructure:"nodes"`
+	LeadershipHistory []LeadershipChange `mapstructure:"leadership_history"`
+}
+
+// HANode is a node of the HA cluster. LastEcho is the time of the last
+// heartbeat of a standby, and is nil for the active node.
+type HANode struct {
+	APIAddress     string     `mapstructure:"api_address"`
+	ClusterAddress string     `mapstructure:"cluster_address"`
+	ActiveNode     bool       `mapstructure:"active_node"`
+	LastEcho       *time.Time `mapstructure:"last_echo"`
+	Version        string     `mapstructure:"version"`
+}
+
+// LeadershipChange is a change of the active node. Event is either acquired
+// or released.
+type LeadershipChange struct {
+	Time           time.Time `mapstructure:"time"`
+	Event          string    `mapstructure:"event"`
+	APIAddress     string    `mapstructure:"api_address"`
+	ClusterAddress string    `mapstructure:"cluster_address"`
+	Reason         string    `mapstructure:"reason"`
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// MigrateState moves the data of the mount at input.From to the mount at
+// input.To. Both backends must support exporting and importing their state.
+func (c *Sys) MigrateState(input *MigrateStateInput) (*MigrateStateOutput, error) {
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/migrate-state")
+	if err := r.SetJSONBody(input); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result MigrateStateOutput
+	err = mapstructure.Decode(secret.Data, &result)
+	return &result, err
+}
+
+// MigrateStateInput is the input of a state migration. Each transform is an
+// object whose type field is the type of the transform, rename_keys or
+// exclude, and whose other fields are its parameters.
+type MigrateStateInput struct {
+	From       string                   `json:"from"`
+	To         string                   `json:"to"`
+	Transforms []map[string]interface{} `json:"transforms,omitempty"`
+	DryRun     bool                     `json:"dry_run,omitempty"`
+}
+
+// MigrateStateOutput is the outcome of a state migration. Exported and
+// Imported are the number of objects of each kind exported from the source
+// mount and imported into the destination mount, after the transforms.
+type MigrateStateOutput struct {
+	Format   string         `mapstructure:"format"`
+	Exported map[string]int `mapstructure:"exported"`
+	Imported map[string]int `mapstructure:"imported"`
+	DryRun   bool           `mapstructure:"dry_run"`
+}
@@ -128,6 +128,70 @@ func (c *Sys) MountConfig(path string) (*MountConfigOutput, error) {
 	return &result, err
 }
 
+// UpgradeKVMount upgrades the KV mount at path from version 1 to version 2
+// in place, or resumes its failed upgrade. The keys are upgraded in the
+// background; use KVUpgradeStatus to follow the progress.
+func (c *Sys) UpgradeKVMount(path string) (*KVUpgradeStatus, error) {
+	return c.kvUpgradeStatusRequest("POST", path, nil)
+}
+
+// DowngradeKVMount downgrades the KV mount at path from version 2 to version
+// 1 in place, keeping only the current data of the secrets which are not
+// deleted.
+func (c *Sys) DowngradeKVMount(path string) (*KVUpgradeStatus, error) {
+	return c.kvUpgradeStatusRequest("POST", path, map[string]interface{}{"version": 1})
+}
+
+// KVUpgradeStatus returns the progress of the upgrade of the KV mount at path
+// to version 2.
+func (c *Sys) KVUpgradeStatus(path string) (*KVUpgradeStatus, error) {
+	return c.kvUpgradeStatusRequest("GET", path, nil)
+}
+
+func (c *Sys) kvUpgradeStatusRequest(method, path string, body map[string]interface{}) (*KVUpgradeStatus, error) {
+	r := c.c.NewRequest(method, fmt.Sprintf("/v1/sys/mounts/%s/upgrade-kv", path))
+	if body != nil {
+		if err := r.SetJSONBody(body); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result KVUpgradeStatus
+	err = mapstructure.Decode(secret.Data, &result)
+	return &result, err
+}
+
+// KVUpgradeStatus is the progress of the upgrade of a KV mount to version 2.
+// Status is not_started for a version 1 mount, and then running, failed or
+// done.
+type KVUpgradeStatus struct {
+	Version       int    `mapstructure:"version"`
+	Status        string `mapstructure:"status"`
+	KeysTotal     int    `mapstructure:"keys_total"`
+	KeysUpgraded  int    `mapstructure:"keys_upgraded"`
+	Checkpoint    string `mapstructure:"checkpoint"`
+	StartedTime   string `mapstructure:"started_time"`
+	UpdatedTime   string `mapstructure:"updated_time"`
+	CompletedTime string `mapstructure:"completed_time"`
+	Error         string `mapstructure:"error"`
+}
+
 type MountInput struct {
 	Type                  string            `json:"type"`
 	Description           string            `json:"description"`
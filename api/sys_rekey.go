@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -133,6 +134,54 @@ func (c *Sys) RekeyRecoveryKeyCancel() error {
 	return err
 }
 
+func (c *Sys) RekeyPause() error {
+	r := c.c.NewRequest("PUT", "/v1/sys/rekey/pause")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) RekeyResume() error {
+	r := c.c.NewRequest("DELETE", "/v1/sys/rekey/pause")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) RekeyRecoveryKeyPause() error {
+	r := c.c.NewRequest("PUT", "/v1/sys/rekey-recovery-key/pause")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) RekeyRecoveryKeyResume() error {
+	r := c.c.NewRequest("DELETE", "/v1/sys/rekey-recovery-key/pause")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
 func (c *Sys) RekeyVerificationCancel() error {
 	r := c.c.NewRequest("DELETE", "/v1/sys/rekey/verify")
 
@@ -345,16 +394,26 @@ type RekeyInitRequest struct {
 }
 
 type RekeyStatusResponse struct {
-	Nonce                string   `json:"nonce"`
-	Started              bool     `json:"started"`
-	T                    int      `json:"t"`
-	N                    int      `json:"n"`
-	Progress             int      `json:"progress"`
-	Required             int      `json:"required"`
-	PGPFingerprints      []string `json:"pgp_fingerprints"`
-	Backup               bool     `json:"backup"`
-	VerificationRequired bool     `json:"verification_required"`
-	VerificationNonce    string   `json:"verification_nonce"`
+	Nonce                string                 `json:"nonce"`
+	Started              bool                   `json:"started"`
+	T                    int                    `json:"t"`
+	N                    int                    `json:"n"`
+	Progress             int                    `json:"progress"`
+	Required             int                    `json:"required"`
+	PGPFingerprints      []string               `json:"pgp_fingerprints"`
+	Backup               bool                   `json:"backup"`
+	VerificationRequired bool                   `json:"verification_required"`
+	VerificationNonce    string                 `json:"verification_nonce"`
+	Paused               bool                   `json:"paused"`
+	SubmittedShares      []*RekeySubmittedShare `json:"submitted_shares"`
+}
+
+// RekeySubmittedShare identifies a key share submitted during a rekey
+// operation by its SHA-256 hash.
+type RekeySubmittedShare struct {
+	KeyHash      string    `json:"key_hash"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+	Verification bool      `json:"verification"`
 }
 
 type RekeyUpdateResponse struct {
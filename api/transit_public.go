@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// transitECIESInfo is the HKDF info of the ECIES scheme of transit.
+const transitECIESInfo = "vault-transit-ecies"
+
+// TransitEncryptWithPublicKey encrypts the plaintext locally with the PEM
+// encoded public key of the given version of an asymmetric transit key, as
+// returned when reading the key. This lets producers encrypt data without
+// access to Vault, and only the holders of a token allowed to decrypt with
+// the key can read it. The returned ciphertext is in the format accepted by
+// the decrypt endpoint of transit.
+//
+// RSA keys use RSA-OAEP with SHA-256. ECDSA keys use ECIES: the x-coordinate
+// of the ECDH of an ephemeral key and the public key is expanded with
+// HKDF-SHA256, salted with the uncompressed ephemeral public key and with
+// "vault-transit-ecies" as info, into an AES-256-GCM key. The ciphertext is
+// then the ephemeral public key, the 12 byte nonce and the sealed plaintext.
+func TransitEncryptWithPublicKey(publicKeyPEM string, keyVersion int, plaintext []byte) (string, error) {
+	if keyVersion <= 0 {
+		return "", errors.New("key version must be positive")
+	}
+
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return "", errors.New("public key is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", errwrap.Wrapf("error parsing public key: {{err}}", err)
+	}
+
+	var ciphertext []byte
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, plaintext, nil)
+	case *ecdsa.PublicKey:
+		ciphertext, err = transitECIESEncrypt(pub, plaintext)
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vault:v%d:%s", keyVersion, base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+func transitECIESEncrypt(pub *ecdsa.PublicKey, plaintext []byte) ([]byte, error) {
+	ephemeral, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := elliptic.Marshal(pub.Curve, ephemeral.X, ephemeral.Y)
+
+	sx, _ := pub.Curve.ScalarMult(pub.X, pub.Y, ephemeral.D.Bytes())
+	shared := make([]byte, (pub.Curve.Params().BitSize+7)/8)
+	sxBytes := sx.Bytes()
+	copy(shared[len(shared)-len(sxBytes):], sxBytes)
+
+	// HKDF-SHA256 of a single block, which is all the AES-256 key needs
+	extract := hmac.New(sha256.New, ephemeralPub)
+	extract.Write(shared)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(transitECIESInfo))
+	expand.Write([]byte{1})
+	key := expand.Sum(nil)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(ephemeralPub, nonce...)
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
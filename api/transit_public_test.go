@@ -0,0 +1,71 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+)
+
+func TestTransitEncryptWithPublicKey(t *testing.T) {
+	plaintext := []byte("the quick brown fox")
+
+	encodePublicKey := func(pub interface{}) string {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+	decode := func(ciphertext string) []byte {
+		if !strings.HasPrefix(ciphertext, "vault:v2:") {
+			t.Fatalf("bad prefix: %s", ciphertext)
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "vault:v2:"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := TransitEncryptWithPublicKey(encodePublicKey(rsaKey.Public()), 2, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, rsaKey, decode(ciphertext), nil)
+	if err != nil || string(decrypted) != string(plaintext) {
+		t.Fatalf("bad RSA decryption: %q %v", decrypted, err)
+	}
+
+	// The ECIES ciphertexts must be decryptable by transit
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		ecKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := TransitEncryptWithPublicKey(encodePublicKey(ecKey.Public()), 2, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := keysutil.ECIESDecrypt(ecKey, decode(ciphertext))
+		if err != nil || string(decrypted) != string(plaintext) {
+			t.Fatalf("bad ECIES decryption on %s: %q %v", curve.Params().Name, decrypted, err)
+		}
+	}
+
+	if _, err := TransitEncryptWithPublicKey("not a key", 1, plaintext); err == nil {
+		t.Fatal("expected error for invalid public key")
+	}
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// TransitDefaultMountPoint is the default path at which the transit
+	// secrets engine is mounted.
+	TransitDefaultMountPoint = "transit"
+
+	// DefaultTransitStreamChunkSize is the amount of plaintext sealed in each
+	// chunk of a stream encrypted by EncryptStream.
+	DefaultTransitStreamChunkSize = 64 * 1024
+
+	// maxTransitStreamChunkSize bounds the chunk size accepted when
+	// decrypting, so that a corrupt header cannot cause a huge allocation.
+	maxTransitStreamChunkSize = 16 * 1024 * 1024
+
+	transitStreamNoncePrefixSize = 7
+)
+
+// transitStreamMagic identifies the format of streams written by
+// EncryptStream.
+var transitStreamMagic = []byte("VTS1")
+
+var (
+	// ErrTransitStreamInvalid is returned when decrypting a stream that was
+	// not written by EncryptStream or whose header is corrupt.
+	ErrTransitStreamInvalid = errors.New("invalid transit stream header")
+
+	// ErrTransitStreamTruncated is returned when a stream ends before its
+	// final chunk.
+	ErrTransitStreamTruncated = errors.New("transit stream is truncated")
+
+	// ErrTransitStreamAuth is returned when a chunk of a stream fails to
+	// authenticate.
+	ErrTransitStreamAuth = errors.New("transit stream chunk failed authentication")
+)
+
+// Transit is used to return a client to invoke operations on a transit
+// secrets engine.
+type Transit struct {
+	c          *Client
+	MountPoint string
+}
+
+// Transit returns the client for the transit secrets engine mounted at the
+// default mount point.
+func (c *Client) Transit() *Transit {
+	return c.TransitWithMountPoint(TransitDefaultMountPoint)
+}
+
+// TransitWithMountPoint returns the client for the transit secrets engine
+// mounted at the given mount point.
+func (c *Client) TransitWithMountPoint(mountPoint string) *Transit {
+	return &Transit{
+		c:          c,
+		MountPoint: mountPoint,
+	}
+}
+
+// TransitStreamOptions holds the optional settings of EncryptStream and
+// DecryptStream.
+type TransitStreamOptions struct {
+	// Context is the key derivation context. It is required for derived
+	// keys, and the same context must be given when decrypting.
+	Context []byte
+
+	// KeyVersion is the version of the transit key that wraps the data key.
+	// Zero uses the latest version.
+	KeyVersion int
+
+	// ChunkSize is the amount of plaintext sealed in each chunk when
+	// encrypting. It defaults to DefaultTransitStreamChunkSize.
+	ChunkSize int
+}
+
+// EncryptStream encrypts everything read from src and writes the result to
+// dst. The transit key only wraps a freshly generated data key, which is
+// stored in the header of the output; the payload is sealed locally in
+// fixed-size AES-256-GCM chunks. The payload never passes through Vault and
+// memory use is bounded by the chunk size, so payloads of any size can be
+// protected.
+func (c *Transit) EncryptStream(ctx context.Context, name string, dst io.Writer, src io.Reader, opts *TransitStreamOptions) error {
+	if opts == nil {
+		opts = &TransitStreamOptions{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultTransitStreamChunkSize
+	}
+	if chunkSize < 0 || chunkSize > maxTransitStreamChunkSize {
+		return fmt.Errorf("chunk size must be between 1 and %d bytes", maxTransitStreamChunkSize)
+	}
+
+	data := map[string]interface{}{
+		"bits": 256,
+	}
+	if opts.KeyVersion != 0 {
+		data["key_version"] = opts.KeyVersion
+	}
+	if len(opts.Context) != 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(opts.Context)
+	}
+	secret, err := c.write(ctx, fmt.Sprintf("/v1/%s/datakey/plaintext/%s", c.MountPoint, name), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("no data key returned")
+	}
+	wrappedKey, _ := secret.Data["ciphertext"].(string)
+	plaintextKey, _ := secret.Data["plaintext"].(string)
+	if wrappedKey == "" || plaintextKey == "" {
+		return errors.New("no data key returned")
+	}
+	key, err := base64.StdEncoding.DecodeString(plaintextKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode data key: %w", err)
+	}
+
+	noncePrefix := make([]byte, transitStreamNoncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, noncePrefix); err != nil {
+		return err
+	}
+
+	header := encodeTransitStreamHeader(wrappedKey, chunkSize, noncePrefix)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	aead, err := newTransitStreamAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	// Each chunk is only sealed once the next one has been read, so that the
+	// final chunk can be marked as such.
+	buf := make([]byte, chunkSize)
+	next := make([]byte, chunkSize)
+	n, err := io.ReadFull(src, buf)
+	for counter := uint32(0); ; counter++ {
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			_, err = dst.Write(aead.Seal(nil, transitStreamNonce(noncePrefix, counter, true), buf[:n], header))
+			return err
+		default:
+			return err
+		}
+
+		m, nextErr := io.ReadFull(src, next)
+		if nextErr == io.EOF {
+			_, err = dst.Write(aead.Seal(nil, transitStreamNonce(noncePrefix, counter, true), buf[:n], header))
+			return err
+		}
+		if counter == ^uint32(0) {
+			return errors.New("payload exceeds the maximum number of chunks")
+		}
+		if _, err := dst.Write(aead.Seal(nil, transitStreamNonce(noncePrefix, counter, false), buf[:n], header)); err != nil {
+			return err
+		}
+		buf, next = next, buf
+		n, err = m, nextErr
+	}
+}
+
+// DecryptStream decrypts a stream written by EncryptStream from src and writes
+// the plaintext to dst. The data key in the header of the stream is unwrapped
+// by Vault. Every chunk is authenticated before it is written to dst; if an
+// error is returned, dst may hold a prefix of the plaintext.
+func (c *Transit) DecryptStream(ctx context.Context, name string, dst io.Writer, src io.Reader, opts *TransitStreamOptions) error {
+	if opts == nil {
+		opts = &TransitStreamOptions{}
+	}
+
+	header, wrappedKey, chunkSize, noncePrefix, err := readTransitStreamHeader(src)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"ciphertext": wrappedKey,
+	}
+	if len(opts.Context) != 0 {
+		data["context"] = base64.StdEncoding.EncodeToString(opts.Context)
+	}
+	secret, err := c.write(ctx, fmt.Sprintf("/v1/%s/decrypt/%s", c.MountPoint, name), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return errors.New("no data key returned")
+	}
+	plaintextKey, _ := secret.Data["plaintext"].(string)
+	key, err := base64.StdEncoding.DecodeString(plaintextKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode data key: %w", err)
+	}
+
+	aead, err := newTransitStreamAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	sealedSize := chunkSize + aead.Overhead()
+	buf := make([]byte, sealedSize)
+	next := make([]byte, sealedSize)
+	n, err := io.ReadFull(src, buf)
+	for counter := uint32(0); ; counter++ {
+		switch err {
+		case nil:
+		case io.ErrUnexpectedEOF:
+			return openTransitStreamChunk(aead, dst, noncePrefix, counter, true, buf[:n], header)
+		case io.EOF:
+			return ErrTransitStreamTruncated
+		default:
+			return err
+		}
+
+		m, nextErr := io.ReadFull(src, next)
+		if nextErr == io.EOF {
+			return openTransitStreamChunk(aead, dst, noncePrefix, counter, true, buf[:n], header)
+		}
+		if err := openTransitStreamChunk(aead, dst, noncePrefix, counter, false, buf[:n], header); err != nil {
+			return err
+		}
+		buf, next = next, buf
+		n, err = m, nextErr
+	}
+}
+
+func (c *Transit) write(ctx context.Context, path string, data map[string]interface{}) (*Secret, error) {
+	r := c.c.NewRequest("PUT", path)
+	if err := r.SetJSONBody(data); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+func newTransitStreamAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func openTransitStreamChunk(aead cipher.AEAD, dst io.Writer, noncePrefix []byte, counter uint32, last bool, sealed, header []byte) error {
+	if len(sealed) < aead.Overhead() {
+		return ErrTransitStreamTruncated
+	}
+	plaintext, err := aead.Open(nil, transitStreamNonce(noncePrefix, counter, last), sealed, header)
+	if err != nil {
+		if !last {
+			return ErrTransitStreamAuth
+		}
+		// A full-size chunk that is not marked as final was probably
+		// followed by chunks that have been cut off.
+		if _, err := aead.Open(nil, transitStreamNonce(noncePrefix, counter, false), sealed, header); err == nil {
+			return ErrTransitStreamTruncated
+		}
+		return ErrTransitStreamAuth
+	}
+	_, err = dst.Write(plaintext)
+	return err
+}
+
+// transitStreamNonce returns the nonce of a chunk: the random prefix of the
+// stream, the big-endian chunk counter and a flag marking the final chunk.
+// Binding the position into the nonce prevents chunks from being reordered,
+// dropped or the stream from being truncated without detection.
+func transitStreamNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = append(nonce, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(nonce[transitStreamNoncePrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encodeTransitStreamHeader returns the header of a stream: the magic bytes,
+// the length-prefixed wrapped data key, the chunk size and the nonce prefix.
+// The header is authenticated as additional data of every chunk.
+func encodeTransitStreamHeader(wrappedKey string, chunkSize int, noncePrefix []byte) []byte {
+	var header bytes.Buffer
+	header.Write(transitStreamMagic)
+	binary.Write(&header, binary.BigEndian, uint16(len(wrappedKey)))
+	header.WriteString(wrappedKey)
+	binary.Write(&header, binary.BigEndian, uint32(chunkSize))
+	header.Write(noncePrefix)
+	return header.Bytes()
+}
+
+func readTransitStreamHeader(r io.Reader) (header []byte, wrappedKey string, chunkSize int, noncePrefix []byte, err error) {
+	prefix := make([]byte, len(transitStreamMagic)+2)
+	if _, err = io.ReadFull(r, prefix); err != nil {
+		return nil, "", 0, nil, ErrTransitStreamInvalid
+	}
+	if !bytes.Equal(prefix[:len(transitStreamMagic)], transitStreamMagic) {
+		return nil, "", 0, nil, ErrTransitStreamInvalid
+	}
+	keyLen := int(binary.BigEndian.Uint16(prefix[len(transitStreamMagic):]))
+
+	rest := make([]byte, keyLen+4+transitStreamNoncePrefixSize)
+	if _, err = io.ReadFull(r, rest); err != nil {
+		return nil, "", 0, nil, ErrTransitStreamInvalid
+	}
+	chunkSize = int(binary.BigEndian.Uint32(rest[keyLen:]))
+	if chunkSize <= 0 || chunkSize > maxTransitStreamChunkSize {
+		return nil, "", 0, nil, ErrTransitStreamInvalid
+	}
+
+	header = append(prefix, rest...)
+	return header, string(rest[:keyLen]), chunkSize, rest[keyLen+4:], nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTransit_Stream(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		var data map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/v1/transit/datakey/plaintext/foo":
+			fmt.Fprintf(w, `{"data":{"ciphertext":"vault:v1:wrapped","plaintext":%q,"key_version":1}}`, base64.StdEncoding.EncodeToString(key))
+		case "/v1/transit/decrypt/foo":
+			if data["ciphertext"] != "vault:v1:wrapped" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors":["invalid ciphertext"]}`)
+				return
+			}
+			fmt.Fprintf(w, `{"data":{"plaintext":%q}}`, base64.StdEncoding.EncodeToString(key))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transit := client.Transit()
+	opts := &TransitStreamOptions{ChunkSize: 16}
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100} {
+		plaintext := bytes.Repeat([]byte("a"), size)
+
+		var ciphertext bytes.Buffer
+		if err := transit.EncryptStream(context.Background(), "foo", &ciphertext, bytes.NewReader(plaintext), opts); err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+
+		var decrypted bytes.Buffer
+		if err := transit.DecryptStream(context.Background(), "foo", &decrypted, bytes.NewReader(ciphertext.Bytes()), nil); err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Fatalf("size %d: bad plaintext: %q", size, decrypted.Bytes())
+		}
+	}
+
+	var ciphertext bytes.Buffer
+	if err := transit.EncryptStream(context.Background(), "foo", &ciphertext, bytes.NewReader(bytes.Repeat([]byte("a"), 40)), opts); err != nil {
+		t.Fatal(err)
+	}
+	headerSize := len(ciphertext.Bytes()) - (16+16)*2 - (8 + 16)
+
+	// Dropping whole chunks from the end must be detected
+	truncated := ciphertext.Bytes()[:headerSize+32]
+	err = transit.DecryptStream(context.Background(), "foo", &bytes.Buffer{}, bytes.NewReader(truncated), nil)
+	if err != ErrTransitStreamTruncated {
+		t.Fatalf("expected truncation error, got: %v", err)
+	}
+
+	// Tampering with a chunk must be detected
+	tampered := append([]byte(nil), ciphertext.Bytes()...)
+	tampered[headerSize+40]++
+	err = transit.DecryptStream(context.Background(), "foo", &bytes.Buffer{}, bytes.NewReader(tampered), nil)
+	if err != ErrTransitStreamAuth {
+		t.Fatalf("expected authentication error, got: %v", err)
+	}
+
+	err = transit.DecryptStream(context.Background(), "foo", &bytes.Buffer{}, bytes.NewReader([]byte("not a stream")), nil)
+	if err != ErrTransitStreamInvalid {
+		t.Fatalf("expected invalid header error, got: %v", err)
+	}
+}
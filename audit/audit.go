@@ -29,6 +29,9 @@ type Backend interface {
 	// an expected plaintext value
 	GetHash(context.Context, string) (string, error)
 
+	// Salt returns the salt of the backend, used to compute the hashes
+	Salt(context.Context) (*salt.Salt, error)
+
 	// Reload is called on SIGHUP for supporting backends.
 	Reload(context.Context) error
 
@@ -0,0 +1,231 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+// DefaultCEFFieldMapping maps CEF extension keys to the fields of audit
+// entries they are populated from. Fields are addressed by the dotted path of
+// their name in the JSON format.
+var DefaultCEFFieldMapping = map[string]string{
+	"rt":         "time",
+	"cat":        "type",
+	"suser":      "auth.display_name",
+	"suid":       "auth.entity_id",
+	"src":        "request.remote_address",
+	"request":    "request.path",
+	"act":        "request.operation",
+	"externalId": "request.id",
+	"reason":     "error",
+}
+
+var fieldMappingKeyRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ParseFieldMapping returns the field mapping of a CEF or LEEF audit device
+// given its defaults and the comma-separated list of key=field pairs of its
+// field_mapping option. The pairs add to or override the defaults; a pair
+// with an empty field removes the key from the output.
+func ParseFieldMapping(defaults map[string]string, raw string) (map[string]string, error) {
+	mapping := make(map[string]string, len(defaults))
+	for key, field := range defaults {
+		mapping[key] = field
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field mapping %q, expected key=field", pair)
+		}
+		key, field := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if !fieldMappingKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("invalid field mapping key %q", key)
+		}
+		if field == "" {
+			delete(mapping, key)
+			continue
+		}
+		mapping[key] = field
+	}
+
+	return mapping, nil
+}
+
+// CEFFormatWriter is an AuditFormatWriter implementation that structures data
+// into the ArcSight Common Event Format.
+type CEFFormatWriter struct {
+	Prefix   string
+	SaltFunc func(context.Context) (*salt.Salt, error)
+
+	// FieldMapping maps extension keys to the fields of the entry. It
+	// defaults to DefaultCEFFieldMapping.
+	FieldMapping map[string]string
+}
+
+func (f *CEFFormatWriter) WriteRequest(w io.Writer, req *AuditRequestEntry) error {
+	if req == nil {
+		return fmt.Errorf("request entry was nil, cannot encode")
+	}
+
+	return f.write(w, req, req.Type, req.Request, req.Error)
+}
+
+func (f *CEFFormatWriter) WriteResponse(w io.Writer, resp *AuditResponseEntry) error {
+	if resp == nil {
+		return fmt.Errorf("response entry was nil, cannot encode")
+	}
+
+	return f.write(w, resp, resp.Type, resp.Request, resp.Error)
+}
+
+func (f *CEFFormatWriter) write(w io.Writer, entry interface{}, entryType string, req *AuditRequest, entryErr string) error {
+	mapping := f.FieldMapping
+	if mapping == nil {
+		mapping = DefaultCEFFieldMapping
+	}
+	values, err := mappedFieldValues(entry, mapping, func(t time.Time) string {
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	})
+	if err != nil {
+		return err
+	}
+
+	severity := "3"
+	if entryErr != "" {
+		severity = "6"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(f.Prefix)
+	buf.WriteString("CEF:0")
+	for _, field := range []string{"HashiCorp", "Vault", version.GetVersion().Version, auditEventID(req), "Vault " + entryType, severity} {
+		buf.WriteByte('|')
+		buf.WriteString(cefHeaderEscaper.Replace(field))
+	}
+	buf.WriteByte('|')
+	for i, kv := range values {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(kv[0])
+		buf.WriteByte('=')
+		buf.WriteString(cefExtensionEscaper.Replace(kv[1]))
+	}
+	buf.WriteByte('\n')
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (f *CEFFormatWriter) Salt(ctx context.Context) (*salt.Salt, error) {
+	return f.SaltFunc(ctx)
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// auditEventID identifies the kind of event of an entry by the operation of
+// its request.
+func auditEventID(req *AuditRequest) string {
+	if req == nil || req.Operation == "" {
+		return "unknown"
+	}
+	return string(req.Operation)
+}
+
+// mappedFieldValues returns the key and value of every key of the mapping
+// whose field is set in the entry, sorted by key. Lists are joined by commas,
+// objects are encoded as JSON and the time of the entry is formatted by
+// formatTime.
+func mappedFieldValues(entry interface{}, mapping map[string]string, formatTime func(time.Time) string) ([][2]string, error) {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		field := mapping[key]
+		value, ok := lookupField(fields, field)
+		if !ok {
+			continue
+		}
+
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+			if field == "time" {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					str = formatTime(t)
+				}
+			}
+		case json.Number:
+			str = v.String()
+		case bool:
+			str = strconv.FormatBool(v)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			str = strings.Join(items, ",")
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			str = string(b)
+		}
+		if str == "" {
+			continue
+		}
+		values = append(values, [2]string{key, str})
+	}
+
+	return values, nil
+}
+
+func lookupField(fields map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = fields
+	for _, part := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[part]
+		if !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
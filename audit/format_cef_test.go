@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+func TestFormatCEF_formatRequest(t *testing.T) {
+	salter, err := salt.NewSalt(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltFunc := func(context.Context) (*salt.Salt, error) {
+		return salter, nil
+	}
+
+	mapping, err := ParseFieldMapping(DefaultCEFFieldMapping, "cs1=request.mount_type, cs2=auth.policies, reason=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := &logical.LogInput{
+		Auth: &logical.Auth{
+			ClientToken: "foo",
+			DisplayName: "test|token",
+			Policies:    []string{"default", "dev"},
+		},
+		Request: &logical.Request{
+			ID:        "123",
+			Operation: logical.UpdateOperation,
+			Path:      "secret/a=b",
+			MountType: "kv",
+			Connection: &logical.Connection{
+				RemoteAddr: "127.0.0.1",
+			},
+		},
+		OuterErr: errors.New("this is an error"),
+	}
+
+	cases := map[string]struct {
+		Writer   AuditFormatWriter
+		Expected string
+	}{
+		"cef": {
+			&CEFFormatWriter{
+				Prefix:   "@cef: ",
+				SaltFunc: saltFunc,
+			},
+			"@cef: CEF:0|HashiCorp|Vault|" + version.GetVersion().Version + `|update|Vault request|6|act=update cat=request externalId=123 reason=this is an error request=secret/a\=b src=127.0.0.1 suser=test|token` + "\n",
+		},
+		"cef with field mapping": {
+			&CEFFormatWriter{
+				SaltFunc:     saltFunc,
+				FieldMapping: mapping,
+			},
+			"CEF:0|HashiCorp|Vault|" + version.GetVersion().Version + `|update|Vault request|6|act=update cat=request cs1=kv cs2=default,dev externalId=123 request=secret/a\=b src=127.0.0.1 suser=test|token` + "\n",
+		},
+		"leef": {
+			&LEEFFormatWriter{
+				SaltFunc: saltFunc,
+			},
+			"LEEF:1.0|HashiCorp|Vault|" + version.GetVersion().Version + "|update|action=update\tcat=request\terror=this is an error\tmountType=kv\tpolicies=default,dev\trequestId=123\tresource=secret/a=b\tsrc=127.0.0.1\tusrName=test|token\n",
+		},
+	}
+
+	for name, tc := range cases {
+		var buf bytes.Buffer
+		formatter := AuditFormatter{
+			AuditFormatWriter: tc.Writer,
+		}
+		config := FormatterConfig{
+			OmitTime: true,
+		}
+		if err := formatter.FormatRequest(namespace.RootContext(nil), &buf, config, in); err != nil {
+			t.Fatalf("bad: %s\nerr: %s", name, err)
+		}
+
+		if buf.String() != tc.Expected {
+			t.Fatalf("bad: %s\nResult:\n\n%q\n\nExpected:\n\n%q", name, buf.String(), tc.Expected)
+		}
+	}
+}
+
+func TestParseFieldMapping(t *testing.T) {
+	if _, err := ParseFieldMapping(DefaultCEFFieldMapping, "cs1"); err == nil || !strings.Contains(err.Error(), "expected key=field") {
+		t.Fatalf("expected error for missing field, got: %v", err)
+	}
+	if _, err := ParseFieldMapping(DefaultCEFFieldMapping, "c s1=request.path"); err == nil {
+		t.Fatal("expected error for invalid key")
+	}
+
+	mapping, err := ParseFieldMapping(DefaultCEFFieldMapping, "suser=auth.entity_id,rt=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping["suser"] != "auth.entity_id" {
+		t.Fatalf("bad mapping: %#v", mapping)
+	}
+	if _, ok := mapping["rt"]; ok {
+		t.Fatalf("bad mapping: %#v", mapping)
+	}
+	if DefaultCEFFieldMapping["suser"] != "auth.display_name" {
+		t.Fatal("defaults were modified")
+	}
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+// DefaultLEEFFieldMapping maps LEEF attribute keys to the fields of audit
+// entries they are populated from. Fields are addressed by the dotted path of
+// their name in the JSON format.
+var DefaultLEEFFieldMapping = map[string]string{
+	"devTime":   "time",
+	"cat":       "type",
+	"usrName":   "auth.display_name",
+	"src":       "request.remote_address",
+	"resource":  "request.path",
+	"action":    "request.operation",
+	"mountType": "request.mount_type",
+	"requestId": "request.id",
+	"entityId":  "auth.entity_id",
+	"policies":  "auth.policies",
+	"error":     "error",
+}
+
+// leefTimeFormat is the default format of the devTime attribute.
+const leefTimeFormat = "Jan 02 2006 15:04:05.000 MST"
+
+// LEEFFormatWriter is an AuditFormatWriter implementation that structures
+// data into the QRadar Log Event Extended Format.
+type LEEFFormatWriter struct {
+	Prefix   string
+	SaltFunc func(context.Context) (*salt.Salt, error)
+
+	// FieldMapping maps attribute keys to the fields of the entry. It
+	// defaults to DefaultLEEFFieldMapping.
+	FieldMapping map[string]string
+}
+
+func (f *LEEFFormatWriter) WriteRequest(w io.Writer, req *AuditRequestEntry) error {
+	if req == nil {
+		return fmt.Errorf("request entry was nil, cannot encode")
+	}
+
+	return f.write(w, req, req.Request)
+}
+
+func (f *LEEFFormatWriter) WriteResponse(w io.Writer, resp *AuditResponseEntry) error {
+	if resp == nil {
+		return fmt.Errorf("response entry was nil, cannot encode")
+	}
+
+	return f.write(w, resp, resp.Request)
+}
+
+func (f *LEEFFormatWriter) write(w io.Writer, entry interface{}, req *AuditRequest) error {
+	mapping := f.FieldMapping
+	if mapping == nil {
+		mapping = DefaultLEEFFieldMapping
+	}
+	values, err := mappedFieldValues(entry, mapping, func(t time.Time) string {
+		return t.UTC().Format(leefTimeFormat)
+	})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(f.Prefix)
+	buf.WriteString("LEEF:1.0")
+	for _, field := range []string{"HashiCorp", "Vault", version.GetVersion().Version, auditEventID(req)} {
+		buf.WriteByte('|')
+		buf.WriteString(leefHeaderEscaper.Replace(field))
+	}
+	buf.WriteByte('|')
+	for i, kv := range values {
+		if i > 0 {
+			buf.WriteByte('\t')
+		}
+		buf.WriteString(kv[0])
+		buf.WriteByte('=')
+		buf.WriteString(leefAttributeEscaper.Replace(kv[1]))
+	}
+	buf.WriteByte('\n')
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (f *LEEFFormatWriter) Salt(ctx context.Context) (*salt.Salt, error) {
+	return f.SaltFunc(ctx)
+}
+
+var (
+	leefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	leefAttributeEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+)
@@ -37,8 +37,19 @@ func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, err
 	if !ok {
 		format = "json"
 	}
+	var fieldMapping map[string]string
 	switch format {
 	case "json", "jsonx":
+	case "cef", "leef":
+		defaults := audit.DefaultCEFFieldMapping
+		if format == "leef" {
+			defaults = audit.DefaultLEEFFieldMapping
+		}
+		mapping, err := audit.ParseFieldMapping(defaults, conf.Config["field_mapping"])
+		if err != nil {
+			return nil, err
+		}
+		fieldMapping = mapping
 	default:
 		return nil, fmt.Errorf("unknown format type %q", format)
 	}
@@ -90,6 +101,18 @@ func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, err
 			Prefix:   conf.Config["prefix"],
 			SaltFunc: b.Salt,
 		}
+	case "cef":
+		b.formatter.AuditFormatWriter = &audit.CEFFormatWriter{
+			Prefix:       conf.Config["prefix"],
+			SaltFunc:     b.Salt,
+			FieldMapping: fieldMapping,
+		}
+	case "leef":
+		b.formatter.AuditFormatWriter = &audit.LEEFFormatWriter{
+			Prefix:       conf.Config["prefix"],
+			SaltFunc:     b.Salt,
+			FieldMapping: fieldMapping,
+		}
 	}
 
 	return b, nil
@@ -102,6 +102,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			LocalStorage: []string{
 				secretIDLocalPrefix,
 				secretIDAccessorLocalPrefix,
+				rotatedSecretIDLocalPrefix,
 			},
 		},
 		Paths: framework.PathAppend(
@@ -109,6 +110,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			[]*framework.Path{
 				pathLogin(b),
 				pathTidySecretID(b),
+				pathRoleRotatedSecretID(b),
 			},
 		),
 		Invalidate:  b.invalidate,
@@ -153,12 +155,17 @@ func (b *backend) invalidate(_ context.Context, key string) {
 // RoleRole backend utilizes this function to delete expired SecretID entries.
 // This could mean that the SecretID may live in the backend upto 1 min after its
 // expiration. The deletion of SecretIDs are not security sensitive and it is okay
-// to delay the removal of SecretIDs by a minute.
+// to delay the removal of SecretIDs by a minute. It also rotates the SecretIDs of
+// the roles with a rotation period, which may likewise be delayed by a minute.
 func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
 	// Initiate clean-up of expired SecretID entries
 	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby) {
 		b.tidySecretID(ctx, req)
 	}
+
+	if !b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return b.rotateSecretIDs(ctx, req.Storage)
+	}
 	return nil
 }
 
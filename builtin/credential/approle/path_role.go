@@ -76,6 +76,15 @@ type roleStorageEntry struct {
 	// SecretIDPrefix is the storage prefix for persisting secret IDs. This
 	// differs based on whether the secret IDs are cluster local or not.
 	SecretIDPrefix string `json:"secret_id_prefix" mapstructure:"secret_id_prefix"`
+
+	// Period after which the backend issues a new SecretID for the role,
+	// which clients can read from the rotated-secret-id endpoint. Zero
+	// disables the rotation.
+	SecretIDRotationPeriod time.Duration `json:"secret_id_rotation_period" mapstructure:"secret_id_rotation_period"`
+
+	// Duration during which a rotated SecretID keeps validating after the
+	// next one is issued
+	SecretIDRotationOverlap time.Duration `json:"secret_id_rotation_overlap" mapstructure:"secret_id_rotation_overlap"`
 }
 
 // roleIDStorageEntry represents the reverse mapping from RoleID to Role
@@ -104,6 +113,7 @@ type roleIDStorageEntry struct {
 // role/<role_name>/secret-id/destroy - For deleting a secret_id
 // role/<role_name>/secret-id-accessor/lookup - For reading secret_id using accessor
 // role/<role_name>/secret-id-accessor/destroy - For deleting secret_id using accessor
+// role/<role_name>/rotated-secret-id - For fetching the secret_id issued by the rotation of an role
 func rolePaths(b *backend) []*framework.Path {
 	defTokenFields := tokenutil.TokenFields()
 
@@ -121,9 +131,10 @@ func rolePaths(b *backend) []*framework.Path {
 			},
 
 			"bound_cidr_list": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: `Use "secret_id_bound_cidrs" instead.`,
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    `Use "secret_id_bound_cidrs" instead.`,
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"secret_id_bound_cidrs": &framework.FieldSchema{
@@ -133,9 +144,10 @@ IP addresses which can perform the login operation.`,
 			},
 
 			"policies": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_policies"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_policies"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"secret_id_num_uses": &framework.FieldSchema{
@@ -151,9 +163,10 @@ to 0, meaning no expiration.`,
 			},
 
 			"period": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_period"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_period"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"role_id": &framework.FieldSchema{
@@ -166,6 +179,19 @@ to 0, meaning no expiration.`,
 				Description: `If set, the secret IDs generated using this role will be cluster local. This
 can only be set during role creation and once set, it can't be reset later.`,
 			},
+
+			"secret_id_rotation_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds after which a new SecretID is issued for the role, which
+can be read from the 'rotated-secret-id' endpoint. Defaults to 0, meaning no
+rotation.`,
+			},
+
+			"secret_id_rotation_overlap": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds during which the previous SecretID keeps validating after
+a rotation. Defaults to 0.`,
+			},
 		},
 		ExistenceCheck: b.pathRoleExistenceCheck,
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -212,9 +238,10 @@ can only be set during role creation and once set, it can't be reset later.`,
 					Description: "Name of the role.",
 				},
 				"policies": &framework.FieldSchema{
-					Type:        framework.TypeCommaStringSlice,
-					Description: tokenutil.DeprecationText("token_policies"),
-					Deprecated:  true,
+					Type:           framework.TypeCommaStringSlice,
+					Description:    tokenutil.DeprecationText("token_policies"),
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"token_policies": &framework.FieldSchema{
 					Type:        framework.TypeCommaStringSlice,
@@ -247,6 +274,8 @@ of CIDR blocks. If set, specifies the blocks of IP addresses which can perform t
 				logical.ReadOperation:   b.pathRoleBoundCIDRListRead,
 				logical.DeleteOperation: b.pathRoleBoundCIDRListDelete,
 			},
+			Deprecated:      true,
+			RemovalVersion:  "1.8",
 			HelpSynopsis:    strings.TrimSpace(roleHelp["role-bound-cidr-list"][0]),
 			HelpDescription: strings.TrimSpace(roleHelp["role-bound-cidr-list"][1]),
 		},
@@ -361,9 +390,10 @@ to 0, meaning no expiration.`,
 					Description: "Name of the role.",
 				},
 				"period": &framework.FieldSchema{
-					Type:        framework.TypeDurationSecond,
-					Description: tokenutil.DeprecationText("token_period"),
-					Deprecated:  true,
+					Type:           framework.TypeDurationSecond,
+					Description:    tokenutil.DeprecationText("token_period"),
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"token_period": &framework.FieldSchema{
 					Type:        framework.TypeDurationSecond,
@@ -961,6 +991,19 @@ func (b *backend) pathRoleCreateUpdate(ctx context.Context, req *logical.Request
 		role.SecretIDTTL = time.Second * time.Duration(data.Get("secret_id_ttl").(int))
 	}
 
+	if rotationPeriodRaw, ok := data.GetOk("secret_id_rotation_period"); ok {
+		role.SecretIDRotationPeriod = time.Second * time.Duration(rotationPeriodRaw.(int))
+	}
+	if rotationOverlapRaw, ok := data.GetOk("secret_id_rotation_overlap"); ok {
+		role.SecretIDRotationOverlap = time.Second * time.Duration(rotationOverlapRaw.(int))
+	}
+	switch {
+	case role.SecretIDRotationPeriod > 0 && !role.BindSecretID:
+		return logical.ErrorResponse("secret_id_rotation_period requires bind_secret_id to be set"), nil
+	case role.SecretIDRotationPeriod+role.SecretIDRotationOverlap > b.System().MaxLeaseTTL():
+		return logical.ErrorResponse(fmt.Sprintf("secret_id_rotation_period and secret_id_rotation_overlap exceed the backend's maximum lease TTL of %q", b.System().MaxLeaseTTL().String())), nil
+	}
+
 	// handle upgrade cases
 	{
 		if err := tokenutil.UpgradeValue(data, "policies", "token_policies", &role.Policies, &role.TokenPolicies); err != nil {
@@ -1013,6 +1056,9 @@ func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *
 		"secret_id_num_uses":    role.SecretIDNumUses,
 		"secret_id_ttl":         role.SecretIDTTL / time.Second,
 		"local_secret_ids":      false,
+
+		"secret_id_rotation_period":  role.SecretIDRotationPeriod / time.Second,
+		"secret_id_rotation_overlap": role.SecretIDRotationOverlap / time.Second,
 	}
 	role.PopulateTokenData(respData)
 
@@ -1104,6 +1150,11 @@ func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data
 		return nil, errwrap.Wrapf(fmt.Sprintf("failed to delete the mapping from RoleID to role %q: {{err}}", role.name), err)
 	}
 
+	// The rotated SecretID was flushed along with the others
+	if err = req.Storage.Delete(ctx, rotatedSecretIDStoragePath(role)); err != nil {
+		return nil, err
+	}
+
 	// After deleting the SecretIDs and the RoleID, delete the role itself
 	if err = req.Storage.Delete(ctx, "role/"+strings.ToLower(role.name)); err != nil {
 		return nil, err
@@ -1479,13 +1530,11 @@ func (b *backend) pathRoleFieldRead(ctx context.Context, req *logical.Request, d
 				},
 			}, nil
 		case "bound_cidr_list":
-			resp := &logical.Response{
+			return &logical.Response{
 				Data: map[string]interface{}{
 					"bound_cidr_list": role.BoundCIDRList,
 				},
-			}
-			resp.AddWarning(`The "bound_cidr_list" parameter is deprecated and will be removed. Please use "secret_id_bound_cidrs" instead.`)
-			return resp, nil
+			}, nil
 		default:
 			// shouldn't occur IRL
 			return nil, errors.New("unrecognized field provided: " + fieldName)
@@ -2577,6 +2626,17 @@ the role. This SecretID will behave similarly to the SecretIDs generated by
 the backend. The properties of this SecretID will be based on the options
 set on the role. It will expire after a period defined by the 'secret_id_ttl'
 option on the role and/or the backend mount's maximum TTL value.`,
+	},
+	"role-rotated-secret-id": {
+		"Read the SecretID issued by the rotation of the role.",
+		`If 'secret_id_rotation_period' is set on the role, the backend issues a
+new SecretID for the role at every period, and this endpoint returns the
+current one, so that clients such as agents can poll it instead of being
+handed long-lived SecretIDs. Each SecretID stays valid for the rotation
+period plus 'secret_id_rotation_overlap', so that the previous SecretID keeps
+validating while clients pick up the new one. Destroying the current SecretID
+causes a new one to be issued on the next read. Access to this endpoint
+should be restricted by policy to the clients of the role.`,
 	},
 	"role-period": {
 		"Updates the value of 'period' on the role",
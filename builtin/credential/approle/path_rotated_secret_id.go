@@ -0,0 +1,251 @@
+package approle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	rotatedSecretIDPrefix      = "rotated_secret_id/"
+	rotatedSecretIDLocalPrefix = "rotated_secret_id_local/"
+)
+
+// rotatedSecretIDStorageEntry tracks the SecretID currently issued by the
+// server-side rotation of a role. Like other SecretIDs, the SecretID itself is
+// not stored: it is the HMAC of the nonce with the HMAC key of the role, so
+// that it can be handed out to the clients of the role again.
+type rotatedSecretIDStorageEntry struct {
+	Nonce            string    `json:"nonce"`
+	SecretIDAccessor string    `json:"secret_id_accessor"`
+	RotationTime     time.Time `json:"rotation_time"`
+	ExpirationTime   time.Time `json:"expiration_time"`
+}
+
+func pathRoleRotatedSecretID(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("role_name") + "/rotated-secret-id$",
+		Fields: map[string]*framework.FieldSchema{
+			"role_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathRoleRotatedSecretIDRead,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role-rotated-secret-id"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role-rotated-secret-id"][1]),
+	}
+}
+
+// rotatedSecretIDStoragePath returns the storage path of the rotated SecretID
+// of the role, which is cluster local if the SecretIDs of the role are.
+func rotatedSecretIDStoragePath(role *roleStorageEntry) string {
+	prefix := rotatedSecretIDPrefix
+	if role.SecretIDPrefix == secretIDLocalPrefix {
+		prefix = rotatedSecretIDLocalPrefix
+	}
+	return prefix + strings.ToLower(role.name)
+}
+
+func (b *backend) rotatedSecretIDEntry(ctx context.Context, s logical.Storage, role *roleStorageEntry) (*rotatedSecretIDStorageEntry, error) {
+	entry, err := s.Get(ctx, rotatedSecretIDStoragePath(role))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result rotatedSecretIDStorageEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// rotatedSecretID returns the SecretID derived from the nonce of the entry.
+func rotatedSecretID(role *roleStorageEntry, entry *rotatedSecretIDStorageEntry) (string, error) {
+	return createHMAC(role.HMACKey, entry.Nonce)
+}
+
+// rotationDue returns whether a new SecretID must be issued for the role. This
+// is the case when the rotation period has elapsed, or when the current
+// SecretID was destroyed or has no uses left.
+func (b *backend) rotationDue(ctx context.Context, s logical.Storage, role *roleStorageEntry, entry *rotatedSecretIDStorageEntry) (bool, error) {
+	if entry == nil || entry.Nonce == "" || !time.Now().Before(entry.RotationTime.Add(role.SecretIDRotationPeriod)) {
+		return true, nil
+	}
+
+	accessorEntry, err := b.secretIDAccessorEntry(ctx, s, entry.SecretIDAccessor, role.SecretIDPrefix)
+	if err != nil {
+		return false, err
+	}
+	return accessorEntry == nil, nil
+}
+
+// rotateSecretID issues a new SecretID for the role, valid for the rotation
+// period and the overlap, so that the previous SecretID keeps validating
+// until the end of its own overlap. The caller must hold the write lock of the
+// role.
+func (b *backend) rotateSecretID(ctx context.Context, s logical.Storage, role *roleStorageEntry) (*rotatedSecretIDStorageEntry, error) {
+	nonce, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate secret_id: {{err}}", err)
+	}
+	rotated := &rotatedSecretIDStorageEntry{
+		Nonce: nonce,
+	}
+	secretID, err := rotatedSecretID(role, rotated)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate secret_id: {{err}}", err)
+	}
+
+	secretIDStorage, err := b.registerSecretIDEntry(ctx, s, role.name, secretID, role.HMACKey, role.SecretIDPrefix, &secretIDStorageEntry{
+		SecretIDNumUses: role.SecretIDNumUses,
+		SecretIDTTL:     role.SecretIDRotationPeriod + role.SecretIDRotationOverlap,
+		Metadata:        make(map[string]string),
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to store secret_id: {{err}}", err)
+	}
+
+	rotated.SecretIDAccessor = secretIDStorage.SecretIDAccessor
+	rotated.RotationTime = secretIDStorage.CreationTime
+	rotated.ExpirationTime = secretIDStorage.ExpirationTime
+	entry, err := logical.StorageEntryJSON(rotatedSecretIDStoragePath(role), rotated)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return rotated, nil
+}
+
+// rotateSecretIDs is invoked by the periodic function of the backend to
+// rotate the SecretIDs of the roles whose rotation period has elapsed.
+func (b *backend) rotateSecretIDs(ctx context.Context, s logical.Storage) error {
+	// Performance secondaries can only write the cluster local SecretIDs
+	localOnly := !b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary)
+
+	roleNames, err := s.List(ctx, "role/")
+	if err != nil {
+		return err
+	}
+
+	var rotated int
+	for _, roleName := range roleNames {
+		ok, err := b.rotateRoleSecretID(ctx, s, roleName, localOnly)
+		if err != nil {
+			b.Logger().Error("failed to rotate secret ID", "role", roleName, "error", err)
+			continue
+		}
+		if ok {
+			rotated++
+		}
+	}
+
+	if rotated > 0 {
+		b.Logger().Debug("rotated secret IDs", "count", rotated)
+	}
+	return nil
+}
+
+// rotateRoleSecretID rotates the SecretID of the role if it is due, and
+// returns whether it was rotated.
+func (b *backend) rotateRoleSecretID(ctx context.Context, s logical.Storage, roleName string, localOnly bool) (bool, error) {
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, s, roleName)
+	if err != nil {
+		return false, err
+	}
+	if role == nil || role.SecretIDRotationPeriod == 0 || !role.BindSecretID {
+		return false, nil
+	}
+	if localOnly && role.SecretIDPrefix != secretIDLocalPrefix {
+		return false, nil
+	}
+
+	entry, err := b.rotatedSecretIDEntry(ctx, s, role)
+	if err != nil {
+		return false, err
+	}
+	due, err := b.rotationDue(ctx, s, role, entry)
+	if err != nil || !due {
+		return false, err
+	}
+
+	if _, err := b.rotateSecretID(ctx, s, role); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pathRoleRotatedSecretIDRead returns the SecretID currently issued by the
+// rotation of the role, rotating it first if it is due.
+func (b *backend) pathRoleRotatedSecretIDRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), logical.ErrUnsupportedPath
+	}
+
+	if !role.BindSecretID {
+		return logical.ErrorResponse("bind_secret_id is not set on the role"), nil
+	}
+	if role.SecretIDRotationPeriod == 0 {
+		return logical.ErrorResponse("secret_id_rotation_period is not set on the role"), nil
+	}
+
+	entry, err := b.rotatedSecretIDEntry(ctx, req.Storage, role)
+	if err != nil {
+		return nil, err
+	}
+	due, err := b.rotationDue(ctx, req.Storage, role, entry)
+	if err != nil {
+		return nil, err
+	}
+	if due {
+		if entry, err = b.rotateSecretID(ctx, req.Storage, role); err != nil {
+			return nil, err
+		}
+	}
+	secretID, err := rotatedSecretID(role, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"secret_id":          secretID,
+			"secret_id_accessor": entry.SecretIDAccessor,
+			"rotation_time":      entry.RotationTime,
+			"next_rotation_time": entry.RotationTime.Add(role.SecretIDRotationPeriod),
+			"expiration_time":    entry.ExpirationTime,
+		},
+	}, nil
+}
@@ -0,0 +1,232 @@
+package approle
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestAppRole_RotatedSecretID(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+			Connection: &logical.Connection{
+				RemoteAddr: "127.0.0.1",
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	login := func(roleID, secretID interface{}) error {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"role_id":   roleID,
+				"secret_id": secretID,
+			},
+			Connection: &logical.Connection{
+				RemoteAddr: "127.0.0.1",
+			},
+		})
+		if err == nil && resp.IsError() {
+			err = resp.Error()
+		}
+		return err
+	}
+
+	handle(logical.CreateOperation, "role/role1", map[string]interface{}{
+		"secret_id_rotation_period":  3600,
+		"secret_id_rotation_overlap": 600,
+	})
+	resp := handle(logical.ReadOperation, "role/role1", nil)
+	if resp.Data["secret_id_rotation_period"] != time.Duration(3600) || resp.Data["secret_id_rotation_overlap"] != time.Duration(600) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	roleID := handle(logical.ReadOperation, "role/role1/role-id", nil).Data["role_id"]
+
+	// The first read issues a SecretID valid for the period and the overlap
+	resp = handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil)
+	first := resp.Data["secret_id"]
+	rotationTime := resp.Data["rotation_time"].(time.Time)
+	if ttl := resp.Data["expiration_time"].(time.Time).Sub(rotationTime); ttl != 70*time.Minute {
+		t.Fatalf("bad secret ID TTL: %s", ttl)
+	}
+	if next := resp.Data["next_rotation_time"].(time.Time); !next.Equal(rotationTime.Add(time.Hour)) {
+		t.Fatalf("bad next rotation time: %s", next)
+	}
+	if err := login(roleID, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// The SecretID itself is not stored
+	role, err := b.roleEntry(context.Background(), storage, "role1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := storage.Get(context.Background(), rotatedSecretIDStoragePath(role))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw == nil || strings.Contains(string(raw.Value), first.(string)) {
+		t.Fatalf("bad storage entry: %#v", raw)
+	}
+
+	// The SecretID is not rotated until the period has elapsed
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if secretID := handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil).Data["secret_id"]; secretID != first {
+		t.Fatalf("expected SecretID %q, got %q", first, secretID)
+	}
+
+	// Move the last rotation back by a period
+	entry, err := b.rotatedSecretIDEntry(context.Background(), storage, role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.RotationTime = entry.RotationTime.Add(-time.Hour)
+	storageEntry, err := logical.StorageEntryJSON(rotatedSecretIDStoragePath(role), entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(context.Background(), storageEntry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	resp = handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil)
+	second := resp.Data["secret_id"]
+	if second == first {
+		t.Fatal("expected SecretID to be rotated")
+	}
+
+	// Both SecretIDs validate during the overlap
+	for _, secretID := range []interface{}{first, second} {
+		if err := login(roleID, secretID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Destroying the current SecretID issues a new one
+	handle(logical.UpdateOperation, "role/role1/secret-id-accessor/destroy", map[string]interface{}{
+		"secret_id_accessor": resp.Data["secret_id_accessor"],
+	})
+	if secretID := handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil).Data["secret_id"]; secretID == second {
+		t.Fatal("expected SecretID to be rotated after being destroyed")
+	}
+
+	// Deleting the role removes the rotated SecretID
+	handle(logical.DeleteOperation, "role/role1", nil)
+	if entry, err := b.rotatedSecretIDEntry(context.Background(), storage, role); err != nil || entry != nil {
+		t.Fatalf("expected rotated SecretID to be deleted, got err:%v entry:%#v", err, entry)
+	}
+}
+
+func TestAppRole_RotatedSecretID_NumUses(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+			Connection: &logical.Connection{
+				RemoteAddr: "127.0.0.1",
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+
+	handle(logical.CreateOperation, "role/role1", map[string]interface{}{
+		"secret_id_num_uses":         1,
+		"secret_id_rotation_period":  3600,
+		"secret_id_rotation_overlap": 600,
+	})
+	roleID := handle(logical.ReadOperation, "role/role1/role-id", nil).Data["role_id"]
+
+	resp := handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil)
+	first := resp.Data["secret_id"]
+	lookup := handle(logical.UpdateOperation, "role/role1/secret-id/lookup", map[string]interface{}{
+		"secret_id": first,
+	})
+	if lookup.Data["secret_id_num_uses"] != 1 {
+		t.Fatalf("bad num uses: %#v", lookup.Data)
+	}
+
+	// The SecretID can be used only once
+	handle(logical.UpdateOperation, "login", map[string]interface{}{
+		"role_id":   roleID,
+		"secret_id": first,
+	})
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "login",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": first,
+		},
+		Connection: &logical.Connection{
+			RemoteAddr: "127.0.0.1",
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatalf("expected the used SecretID to be rejected, got resp:%#v", resp)
+	}
+
+	// A new SecretID is issued once the uses are exhausted
+	if secretID := handle(logical.ReadOperation, "role/role1/rotated-secret-id", nil).Data["secret_id"]; secretID == first {
+		t.Fatal("expected SecretID to be rotated after its uses are exhausted")
+	}
+}
+
+func TestAppRole_RotatedSecretID_Validation(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	for _, data := range []map[string]interface{}{
+		{"secret_id_rotation_period": 3600, "bind_secret_id": false, "secret_id_bound_cidrs": "127.0.0.1/32"},
+		{"secret_id_rotation_period": int(b.System().MaxLeaseTTL().Seconds())},
+	} {
+		data["secret_id_rotation_overlap"] = 60
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "role/role1",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected error response for %v, got err:%v resp:%#v", data, err, resp)
+		}
+	}
+
+	// Roles without a rotation period have no rotated SecretID
+	createRole(t, b, storage, "role1", "a,b,c")
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "role/role1/rotated-secret-id",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+}
@@ -133,24 +133,28 @@ Defaults to an empty string, meaning that role tags are disabled. This
 is only allowed if auth_type is ec2.`,
 			},
 			"period": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_period"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_period"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"ttl": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"max_ttl": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_max_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_max_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"policies": {
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_policies"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_policies"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"allow_instance_migration": {
 				Type:    framework.TypeBool,
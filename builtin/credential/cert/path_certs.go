@@ -121,39 +121,45 @@ certificate.`,
 			},
 
 			"policies": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_policies"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_policies"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"lease": &framework.FieldSchema{
-				Type:        framework.TypeInt,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeInt,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"max_ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_max_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_max_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"period": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_period"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_period"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"bound_cidrs": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_bound_cidrs"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_bound_cidrs"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 		},
 
@@ -3,6 +3,9 @@ package cert
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/asn1"
@@ -105,6 +108,7 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 		Alias: &logical.Alias{
 			Name: clientCerts[0].Subject.CommonName,
 		},
+		Session: clientKeySession(clientCerts[0]),
 	}
 	matched.Entry.PopulateTokenAuth(auth)
 
@@ -113,6 +117,24 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 	}, nil
 }
 
+// clientKeySession returns the session of a login with the client
+// certificate, describing the type and the size of its public key.
+func clientKeySession(clientCert *x509.Certificate) *logical.AuthSession {
+	session := new(logical.AuthSession)
+	switch key := clientCert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		session.KeyType = "rsa"
+		session.KeyBits = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		session.KeyType = "ec"
+		session.KeyBits = key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		session.KeyType = "ed25519"
+		session.KeyBits = 256
+	}
+	return session
+}
+
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	config, err := b.Config(ctx, req.Storage)
 	if err != nil {
@@ -33,14 +33,16 @@ API-compatible authentication server.`,
 				},
 			},
 			"ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"max_ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_max_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_max_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 		},
 
@@ -61,6 +61,7 @@ func backend() *jwtAuthBackend {
 			},
 			SealWrapStorage: []string{
 				"config",
+				localIssuerKeyPath,
 			},
 		},
 		Paths: framework.PathAppend(
@@ -69,6 +70,7 @@ func backend() *jwtAuthBackend {
 				pathRoleList(b),
 				pathRole(b),
 				pathConfig(b),
+				pathIssue(b),
 
 				// Uncomment to mount simple UI handler for local development
 				// pathUI(b),
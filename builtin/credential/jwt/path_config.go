@@ -89,6 +89,10 @@ func pathConfig(b *jwtAuthBackend) *framework.Path {
 					Name: "Provider Config",
 				},
 			},
+			"local_issuance": {
+				Type:        framework.TypeBool,
+				Description: `Enables the issuance of JWTs signed by a key managed by Vault, with the "issue" endpoint. Locally issued JWTs are validated with that key, whether another validation method is configured or not.`,
+			},
 			"namespace_in_state": {
 				Type:        framework.TypeBool,
 				Description: "Pass namespace in the OIDC state parameter instead of as a separate query parameter. With this setting, the allowed redirect URL(s) in Vault and on the provider side should not contain a namespace query parameter. This means only one redirect URL entry needs to be maintained on the provider side for all vault namespaces that will be authenticating against it. Defaults to true for new configs.",
@@ -146,6 +150,13 @@ func (b *jwtAuthBackend) config(ctx context.Context, s logical.Storage) (*jwtCon
 		config.ParsedJWTPubKeys = append(config.ParsedJWTPubKeys, key)
 	}
 
+	if config.LocalIssuance {
+		config.localIssuerKey, err = loadLocalIssuerKey(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	b.cachedConfig = config
 
 	return config, nil
@@ -175,9 +186,19 @@ func (b *jwtAuthBackend) pathConfigRead(ctx context.Context, req *logical.Reques
 			"bound_issuer":           config.BoundIssuer,
 			"provider_config":        config.ProviderConfig,
 			"namespace_in_state":     config.NamespaceInState,
+			"local_issuance":         config.LocalIssuance,
 		},
 	}
 
+	if config.localIssuerKey != nil {
+		publicKey, err := config.localIssuerKey.publicKeyPEM()
+		if err != nil {
+			return nil, err
+		}
+		resp.Data["local_issuer"] = localIssuer
+		resp.Data["local_issuer_public_key"] = publicKey
+	}
+
 	return resp, nil
 }
 
@@ -196,6 +217,7 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 		JWTSupportedAlgs:     d.Get("jwt_supported_algs").([]string),
 		BoundIssuer:          d.Get("bound_issuer").(string),
 		ProviderConfig:       d.Get("provider_config").(map[string]interface{}),
+		LocalIssuance:        d.Get("local_issuance").(bool),
 	}
 
 	// Check if the config already exists, to determine if this is a create or
@@ -228,8 +250,8 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 	}
 
 	switch {
-	case methodCount != 1:
-		return logical.ErrorResponse("exactly one of 'jwt_validation_pubkeys', 'jwks_url' or 'oidc_discovery_url' must be set"), nil
+	case methodCount > 1 || methodCount == 0 && !config.LocalIssuance:
+		return logical.ErrorResponse("exactly one of 'jwt_validation_pubkeys', 'jwks_url' or 'oidc_discovery_url' must be set, unless 'local_issuance' is enabled"), nil
 
 	case config.OIDCClientID != "" && config.OIDCClientSecret == "",
 		config.OIDCClientID == "" && config.OIDCClientSecret != "":
@@ -271,6 +293,9 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 			}
 		}
 
+	case methodCount == 0:
+		// Only locally issued JWTs are accepted
+
 	default:
 		return nil, errors.New("unknown condition")
 	}
@@ -307,6 +332,12 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 		return logical.ErrorResponse("invalid provider_config: %s", err), nil
 	}
 
+	if config.LocalIssuance {
+		if err := ensureLocalIssuerKey(ctx, req.Storage); err != nil {
+			return nil, err
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON(configPath, config)
 	if err != nil {
 		return nil, err
@@ -376,8 +407,10 @@ type jwtConfig struct {
 	DefaultRole          string                 `json:"default_role"`
 	ProviderConfig       map[string]interface{} `json:"provider_config"`
 	NamespaceInState     bool                   `json:"namespace_in_state"`
+	LocalIssuance        bool                   `json:"local_issuance"`
 
-	ParsedJWTPubKeys []interface{} `json:"-"`
+	ParsedJWTPubKeys []interface{}   `json:"-"`
+	localIssuerKey   *localIssuerKey `json:"-"`
 }
 
 const (
@@ -423,6 +456,7 @@ Configures the JWT authentication backend.
 The JWT authentication backend validates JWTs (or OIDC) using the configured
 credentials. If using OIDC Discovery, the URL must be provided, along
 with (optionally) the CA cert to use for the connection. If performing JWT
-validation locally, a set of public keys must be provided.
+validation locally, a set of public keys must be provided. If local issuance
+is enabled, JWTs issued by the mount are validated with its own key.
 `
 )
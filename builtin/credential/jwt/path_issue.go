@@ -0,0 +1,293 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	localIssuerKeyPath string = "local_issuer_key"
+
+	// localIssuer is the issuer of the JWTs issued by the mount, which are
+	// validated with the key of the mount rather than the configured keys
+	localIssuer = "vault-jwt-auth-local-issuer"
+
+	defaultLocalIssuanceTTL = 5 * time.Minute
+	maxLocalIssuanceTTL     = time.Hour
+)
+
+// reservedLocalIssuanceClaims are the claims of locally issued JWTs that are
+// set by Vault and cannot be requested
+var reservedLocalIssuanceClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+func pathIssue(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issue/" + framework.GenericNameRegex("role"),
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "The role the JWT is issued for.",
+			},
+			"subject": {
+				Type:        framework.TypeString,
+				Description: `The "sub" claim of the JWT. Defaults to the bound subject of the role.`,
+			},
+			"claims": {
+				Type:        framework.TypeMap,
+				Description: `Additional claims of the JWT. The claims must satisfy the bound claims of the role. The "iss", "sub", "aud", "exp", "nbf", "iat" and "jti" claims are set by Vault.`,
+			},
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The time after which the JWT expires. Defaults to 5 minutes, and cannot exceed 1 hour.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathIssue,
+				Summary:  "Issue a JWT signed by the key of the mount.",
+			},
+		},
+
+		HelpSynopsis:    pathIssueHelpSyn,
+		HelpDescription: pathIssueHelpDesc,
+	}
+}
+
+func (b *jwtAuthBackend) pathIssue(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil || !config.LocalIssuance || config.localIssuerKey == nil {
+		return logical.ErrorResponse("local issuance is not enabled"), nil
+	}
+
+	roleName := d.Get("role").(string)
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("role %q could not be found", roleName), nil
+	}
+	if !role.AllowLocalIssuance {
+		return logical.ErrorResponse("role %q does not allow local issuance", roleName), nil
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	switch {
+	case ttl == 0:
+		ttl = defaultLocalIssuanceTTL
+	case ttl < 0 || ttl > maxLocalIssuanceTTL:
+		return logical.ErrorResponse("ttl must be between 1s and %s", maxLocalIssuanceTTL), nil
+	}
+
+	subject := d.Get("subject").(string)
+	switch {
+	case subject == "":
+		subject = role.BoundSubject
+	case role.BoundSubject != "" && subject != role.BoundSubject:
+		return logical.ErrorResponse("subject does not match the bound subject of the role"), nil
+	}
+
+	allClaims := make(map[string]interface{})
+	for k, v := range d.Get("claims").(map[string]interface{}) {
+		for _, reserved := range reservedLocalIssuanceClaims {
+			if k == reserved {
+				return logical.ErrorResponse("claim %q is set by Vault and cannot be requested", k), nil
+			}
+		}
+		allClaims[k] = v
+	}
+
+	jti, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	allClaims["iss"] = localIssuer
+	allClaims["iat"] = now.Unix()
+	allClaims["nbf"] = now.Unix()
+	allClaims["exp"] = now.Add(ttl).Unix()
+	allClaims["jti"] = jti
+	if subject != "" {
+		allClaims["sub"] = subject
+	}
+	if len(role.BoundAudiences) > 0 {
+		allClaims["aud"] = role.BoundAudiences
+	}
+
+	// Only issue JWTs that can be used to log in with the role
+	if err := validateBoundClaims(b.Logger(), role.BoundClaimsType, role.BoundClaims, allClaims); err != nil {
+		return logical.ErrorResponse("claims do not satisfy the bound claims of the role: %s", err), nil
+	}
+	if _, _, err := b.createIdentity(allClaims, role); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	token, err := config.localIssuerKey.sign(allClaims)
+	if err != nil {
+		return nil, errwrap.Wrapf("error signing JWT: {{err}}", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"jwt":         token,
+			"jti":         jti,
+			"expire_time": now.Add(ttl),
+		},
+	}, nil
+}
+
+// localIssuerKey is the Vault-managed key signing the JWTs issued by the mount
+type localIssuerKey struct {
+	KeyID      string `json:"key_id"`
+	PrivateKey []byte `json:"private_key"`
+
+	privateKey *ecdsa.PrivateKey
+}
+
+func generateLocalIssuerKey() (*localIssuerKey, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &localIssuerKey{
+		KeyID:      keyID,
+		PrivateKey: der,
+		privateKey: privateKey,
+	}, nil
+}
+
+// loadLocalIssuerKey returns the key of the mount, or nil if it has not been
+// generated.
+func loadLocalIssuerKey(ctx context.Context, s logical.Storage) (*localIssuerKey, error) {
+	entry, err := s.Get(ctx, localIssuerKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	key := &localIssuerKey{}
+	if err := entry.DecodeJSON(key); err != nil {
+		return nil, err
+	}
+	key.privateKey, err = x509.ParseECPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing local issuer key: {{err}}", err)
+	}
+	return key, nil
+}
+
+// ensureLocalIssuerKey generates the key of the mount if it has not been
+// generated yet. The key is kept when local issuance is disabled, so that it
+// does not change if local issuance is enabled again.
+func ensureLocalIssuerKey(ctx context.Context, s logical.Storage) error {
+	key, err := loadLocalIssuerKey(ctx, s)
+	if err != nil || key != nil {
+		return err
+	}
+
+	key, err = generateLocalIssuerKey()
+	if err != nil {
+		return errwrap.Wrapf("error generating local issuer key: {{err}}", err)
+	}
+	entry, err := logical.StorageEntryJSON(localIssuerKeyPath, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (k *localIssuerKey) sign(claims map[string]interface{}) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: k.privateKey},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", k.KeyID),
+	)
+	if err != nil {
+		return "", err
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+// publicKeyPEM returns the public key of the key, so that the JWTs issued by
+// the mount can be verified outside of Vault.
+func (k *localIssuerKey) publicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(k.privateKey.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// isLocallyIssued returns whether the issuer of the unverified token is the
+// local issuer of the mount.
+func isLocallyIssued(token string) bool {
+	parsedJWT, err := jwt.ParseSigned(token)
+	if err != nil {
+		return false
+	}
+	claims := jwt.Claims{}
+	if err := parsedJWT.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return false
+	}
+	return claims.Issuer == localIssuer
+}
+
+// verifyLocallyIssued verifies the signature of a locally issued token, and
+// returns its claims.
+func verifyLocallyIssued(key *localIssuerKey, token string, claims *jwt.Claims, allClaims *map[string]interface{}) error {
+	if key == nil {
+		return errors.New("local issuance is not enabled")
+	}
+	parsedJWT, err := jwt.ParseSigned(token)
+	if err != nil {
+		return errwrap.Wrapf("error parsing token: {{err}}", err)
+	}
+	if err := parsedJWT.Claims(key.privateKey.Public(), claims, allClaims); err != nil {
+		return fmt.Errorf("the token signature is not valid for the local issuer key: %w", err)
+	}
+	return nil
+}
+
+const (
+	pathIssueHelpSyn = `
+Issue a JWT for a role, signed by the key of the mount.
+`
+	pathIssueHelpDesc = `
+This path issues a JWT signed by a key generated and managed by Vault, which
+can be used to log in with the given role. It is meant for trusted
+orchestrators to hand off bootstrap credentials to the workloads they start,
+without an external identity provider. Local issuance must be enabled in the
+configuration, and allowed by the role.
+
+The claims of the JWT must satisfy the bound claims of the role, and include
+its user claim.
+`
+)
@@ -0,0 +1,252 @@
+package jwtauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func getBackend(t *testing.T) (*jwtAuthBackend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.(*jwtAuthBackend), config.StorageView
+}
+
+func request(b *jwtAuthBackend, s logical.Storage, path string, data map[string]interface{}) (*logical.Response, error) {
+	var op logical.Operation = logical.UpdateOperation
+	if strings.HasPrefix(path, rolePrefix) {
+		op = logical.CreateOperation
+	}
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+	})
+}
+
+func setupLocalIssuance(t *testing.T) (*jwtAuthBackend, logical.Storage) {
+	b, s := getBackend(t)
+
+	resp, err := request(b, s, configPath, map[string]interface{}{
+		"local_issuance": true,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	for name, allow := range map[string]bool{"workload": true, "other": false} {
+		resp, err = request(b, s, rolePrefix+name, map[string]interface{}{
+			"role_type":            "jwt",
+			"user_claim":           "sub",
+			"bound_audiences":      "vault",
+			"bound_claims":         map[string]interface{}{"team": "ops"},
+			"allow_local_issuance": allow,
+			"token_policies":       "workload",
+		})
+		if err != nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+	}
+	return b, s
+}
+
+func TestLocalIssuance_Login(t *testing.T) {
+	b, s := setupLocalIssuance(t)
+
+	resp, err := request(b, s, "issue/workload", map[string]interface{}{
+		"subject": "workload-1",
+		"claims":  map[string]interface{}{"team": "ops"},
+		"ttl":     "1m",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	token := resp.Data["jwt"].(string)
+	if expireTime := resp.Data["expire_time"].(time.Time); expireTime.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("bad expire_time: %s", expireTime)
+	}
+
+	resp, err = request(b, s, "login", map[string]interface{}{
+		"role": "workload",
+		"jwt":  token,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Auth.Alias.Name != "workload-1" {
+		t.Fatalf("bad alias name: %q", resp.Auth.Alias.Name)
+	}
+	if len(resp.Auth.Policies) != 1 || resp.Auth.Policies[0] != "workload" {
+		t.Fatalf("bad policies: %v", resp.Auth.Policies)
+	}
+
+	// Locally issued tokens cannot be used with roles that do not allow them
+	resp, err = request(b, s, "login", map[string]interface{}{
+		"role": "other",
+		"jwt":  token,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected error response")
+	}
+}
+
+func TestLocalIssuance_Issue(t *testing.T) {
+	b, s := setupLocalIssuance(t)
+
+	for name, tc := range map[string]struct {
+		role string
+		data map[string]interface{}
+		err  string
+	}{
+		"role does not allow it": {
+			role: "other",
+			data: map[string]interface{}{"subject": "workload-1", "claims": map[string]interface{}{"team": "ops"}},
+			err:  "does not allow local issuance",
+		},
+		"unbound claims": {
+			role: "workload",
+			data: map[string]interface{}{"subject": "workload-1", "claims": map[string]interface{}{"team": "dev"}},
+			err:  "bound claims",
+		},
+		"reserved claim": {
+			role: "workload",
+			data: map[string]interface{}{"subject": "workload-1", "claims": map[string]interface{}{"team": "ops", "aud": "other"}},
+			err:  "set by Vault",
+		},
+		"missing user claim": {
+			role: "workload",
+			data: map[string]interface{}{"claims": map[string]interface{}{"team": "ops"}},
+			err:  "claim \"sub\" not found",
+		},
+		"ttl too long": {
+			role: "workload",
+			data: map[string]interface{}{"subject": "workload-1", "claims": map[string]interface{}{"team": "ops"}, "ttl": "2h"},
+			err:  "ttl",
+		},
+		"missing role": {
+			role: "missing",
+			data: map[string]interface{}{"subject": "workload-1"},
+			err:  "could not be found",
+		},
+	} {
+		resp, err := request(b, s, "issue/"+tc.role, tc.data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !resp.IsError() || !strings.Contains(resp.Error().Error(), tc.err) {
+			t.Fatalf("%s: expected error containing %q, got %#v", name, tc.err, resp)
+		}
+	}
+}
+
+func TestLocalIssuance_ForgedToken(t *testing.T) {
+	b, s := setupLocalIssuance(t)
+
+	// A token claiming to be locally issued but signed by another key
+	key, err := generateLocalIssuerKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := key.sign(map[string]interface{}{
+		"iss":  localIssuer,
+		"sub":  "workload-1",
+		"aud":  []string{"vault"},
+		"team": "ops",
+		"iat":  time.Now().Unix(),
+		"exp":  time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := request(b, s, "login", map[string]interface{}{
+		"role": "workload",
+		"jwt":  token,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected error response")
+	}
+}
+
+func TestLocalIssuance_Config(t *testing.T) {
+	b, s := getBackend(t)
+
+	// A validation method is required unless local issuance is enabled
+	resp, err := request(b, s, configPath, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected error response")
+	}
+
+	resp, err = request(b, s, "issue/workload", map[string]interface{}{"subject": "workload-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() || !strings.Contains(resp.Error().Error(), "not enabled") {
+		t.Fatalf("expected error response, got %#v", resp)
+	}
+
+	resp, err = request(b, s, configPath, map[string]interface{}{"local_issuance": true})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      configPath,
+		Storage:   s,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	publicKey := resp.Data["local_issuer_public_key"].(string)
+	if !strings.HasPrefix(publicKey, "-----BEGIN PUBLIC KEY-----") {
+		t.Fatalf("bad public key: %q", publicKey)
+	}
+
+	// The key is kept when the config is updated
+	resp, err = request(b, s, configPath, map[string]interface{}{"local_issuance": true, "bound_issuer": "foo"})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      configPath,
+		Storage:   s,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["local_issuer_public_key"] != publicKey {
+		t.Fatalf("the local issuer key changed")
+	}
+
+	// OIDC roles cannot allow local issuance
+	resp, err = request(b, s, rolePrefix+"oidc", map[string]interface{}{
+		"role_type":             "oidc",
+		"user_claim":            "sub",
+		"allowed_redirect_uris": "https://example.com",
+		"allow_local_issuance":  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected error response")
+	}
+}
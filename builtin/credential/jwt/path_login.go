@@ -94,10 +94,21 @@ func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d
 	allClaims := map[string]interface{}{}
 	configType := config.authType()
 
+	// Tokens issued by the mount are validated with its own key, whatever
+	// the validation method of other tokens
+	locallyIssued := config.LocalIssuance && isLocallyIssued(token)
+	if locallyIssued && !role.AllowLocalIssuance {
+		return logical.ErrorResponse("role %q does not allow locally issued tokens", roleName), nil
+	}
+
 	switch {
-	case configType == StaticKeys || configType == JWKS:
+	case locallyIssued || configType == StaticKeys || configType == JWKS:
 		claims := jwt.Claims{}
-		if configType == JWKS {
+		if locallyIssued {
+			if err := verifyLocallyIssued(config.localIssuerKey, token, &claims, &allClaims); err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+		} else if configType == JWKS {
 			keySet, err := b.getKeySet(config)
 			if err != nil {
 				return logical.ErrorResponse(errwrap.Wrapf("error fetching jwks keyset: {{err}}", err).Error()), nil
@@ -187,6 +198,9 @@ func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d
 			Subject: role.BoundSubject,
 			Time:    time.Now(),
 		}
+		if locallyIssued {
+			expected.Issuer = localIssuer
+		}
 
 		cksLeeway := role.ClockSkewLeeway
 		if role.ClockSkewLeeway.Seconds() < 0 {
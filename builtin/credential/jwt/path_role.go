@@ -54,34 +54,40 @@ func pathRole(b *jwtAuthBackend) *framework.Path {
 			},
 
 			"policies": {
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_policies"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_policies"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"num_uses": {
-				Type:        framework.TypeInt,
-				Description: tokenutil.DeprecationText("token_num_uses"),
-				Deprecated:  true,
+				Type:           framework.TypeInt,
+				Description:    tokenutil.DeprecationText("token_num_uses"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"ttl": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"max_ttl": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_max_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_max_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"period": {
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_period"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_period"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"bound_cidrs": {
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_bound_cidrs"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_bound_cidrs"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"expiration_leeway": {
 				Type: framework.TypeSignedDurationSecond,
@@ -138,6 +144,10 @@ Defaults to 60 (1 minute) if set to 0 and can be disabled if set to -1.`,
 				Type:        framework.TypeCommaStringSlice,
 				Description: `Comma-separated list of allowed values for redirect_uri`,
 			},
+			"allow_local_issuance": {
+				Type:        framework.TypeBool,
+				Description: `If true, JWTs for this role can be issued with the "issue" endpoint, and locally issued JWTs can be used to log in with this role. Requires "local_issuance" to be enabled in the config. Not allowed for "oidc" roles.`,
+			},
 			"verbose_oidc_logging": {
 				Type: framework.TypeBool,
 				Description: `Log received OIDC tokens and claims when debug-level logging is active. 
@@ -202,6 +212,7 @@ type jwtRole struct {
 	OIDCScopes          []string               `json:"oidc_scopes"`
 	AllowedRedirectURIs []string               `json:"allowed_redirect_uris"`
 	VerboseOIDCLogging  bool                   `json:"verbose_oidc_logging"`
+	AllowLocalIssuance  bool                   `json:"allow_local_issuance"`
 
 	// Deprecated by TokenParams
 	Policies   []string                      `json:"policies"`
@@ -308,6 +319,7 @@ func (b *jwtAuthBackend) pathRoleRead(ctx context.Context, req *logical.Request,
 		"allowed_redirect_uris": role.AllowedRedirectURIs,
 		"oidc_scopes":           role.OIDCScopes,
 		"verbose_oidc_logging":  role.VerboseOIDCLogging,
+		"allow_local_issuance":  role.AllowLocalIssuance,
 	}
 
 	role.PopulateTokenData(d)
@@ -508,6 +520,14 @@ func (b *jwtAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.
 		role.AllowedRedirectURIs = allowedRedirectURIs.([]string)
 	}
 
+	if allowLocalIssuance, ok := data.GetOk("allow_local_issuance"); ok {
+		role.AllowLocalIssuance = allowLocalIssuance.(bool)
+	}
+
+	if role.RoleType == "oidc" && role.AllowLocalIssuance {
+		return logical.ErrorResponse("'allow_local_issuance' cannot be set if 'role_type' is 'oidc'"), nil
+	}
+
 	if role.RoleType == "oidc" && len(role.AllowedRedirectURIs) == 0 {
 		return logical.ErrorResponse(
 			"'allowed_redirect_uris' must be set if 'role_type' is 'oidc' or unspecified."), nil
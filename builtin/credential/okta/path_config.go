@@ -26,9 +26,10 @@ func pathConfig(b *backend) *framework.Path {
 		Pattern: `config`,
 		Fields: map[string]*framework.FieldSchema{
 			"organization": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: "Use org_name instead.",
-				Deprecated:  true,
+				Type:           framework.TypeString,
+				Description:    "Use org_name instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"org_name": &framework.FieldSchema{
 				Type:        framework.TypeString,
@@ -38,9 +39,10 @@ func pathConfig(b *backend) *framework.Path {
 				},
 			},
 			"token": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: "Use api_token instead.",
-				Deprecated:  true,
+				Type:           framework.TypeString,
+				Description:    "Use api_token instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"api_token": &framework.FieldSchema{
 				Type:        framework.TypeString,
@@ -57,19 +59,22 @@ func pathConfig(b *backend) *framework.Path {
 				},
 			},
 			"production": &framework.FieldSchema{
-				Type:        framework.TypeBool,
-				Description: `Use base_url instead.`,
-				Deprecated:  true,
+				Type:           framework.TypeBool,
+				Description:    `Use base_url instead.`,
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"max_ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: tokenutil.DeprecationText("token_max_ttl"),
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    tokenutil.DeprecationText("token_max_ttl"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"bypass_okta_mfa": &framework.FieldSchema{
 				Type:        framework.TypeBool,
@@ -19,9 +19,10 @@ func pathUserPolicies(b *backend) *framework.Path {
 				Description: "Username for this user.",
 			},
 			"policies": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: tokenutil.DeprecationText("token_policies"),
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    tokenutil.DeprecationText("token_policies"),
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 			"token_policies": &framework.FieldSchema{
 				Type:        framework.TypeCommaStringSlice,
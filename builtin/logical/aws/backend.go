@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -15,6 +16,9 @@ import (
 const (
 	rootConfigPath        = "config/root"
 	minAwsUserRollbackAge = 5 * time.Minute
+
+	// rotationRoot names the root credentials in the rotation paths
+	rotationRoot = "root"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -36,6 +40,7 @@ func Backend() *backend {
 			},
 			SealWrapStorage: []string{
 				"config/root",
+				staticRolePath,
 			},
 		},
 
@@ -46,12 +51,35 @@ func Backend() *backend {
 			pathRoles(&b),
 			pathListRoles(&b),
 			pathUser(&b),
+			pathStaticRoles(&b),
+			pathListStaticRoles(&b),
+			pathStaticCreds(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretAccessKeys(&b),
 		},
 
+		RotationManager: &framework.RotationManager{
+			Rotations: []*framework.Rotation{
+				{
+					Name: rotationRoot,
+					Rotate: func(ctx context.Context, req *logical.Request, _ string) error {
+						_, err := b.rotateRoot(ctx, req.Storage)
+						return err
+					},
+				},
+				{
+					Name:    rotationStatic,
+					Targets: b.staticRoles,
+					Rotate: func(ctx context.Context, req *logical.Request, name string) error {
+						return b.rotateStaticRole(ctx, req.Storage, name)
+					},
+				},
+			},
+			MetricsPrefix: []string{"secrets", "aws"},
+		},
+
 		Invalidate:        b.invalidate,
 		WALRollback:       b.walRollback,
 		WALRollbackMinAge: minAwsUserRollbackAge,
@@ -67,6 +95,9 @@ type backend struct {
 	// Mutex to protect access to reading and writing policies
 	roleMutex sync.RWMutex
 
+	// Mutex to protect access to reading and writing static roles
+	staticRoleMutex sync.RWMutex
+
 	// Mutex to protect access to iam/sts clients and client configs
 	clientMutex sync.RWMutex
 
@@ -84,6 +115,10 @@ are automatically revoked at the end of the lease.
 After mounting this backend, credentials to generate IAM keys must
 be configured with the "root" path and policies must be written using
 the "roles/" endpoints before any access keys can be generated.
+
+Existing IAM users can also be adopted with the "static-roles/" endpoints,
+which rotate their access keys on a schedule and serve the current access
+key from the "static-creds/" endpoints.
 `
 
 func (b *backend) invalidate(ctx context.Context, key string) {
@@ -156,3 +191,11 @@ func (b *backend) clientSTS(ctx context.Context, s logical.Storage) (stsiface.ST
 
 	return b.stsClient, nil
 }
+
+// clientSTSWithCredentials returns an STS client using the given temporary
+// credentials, which is not cached.
+func (b *backend) clientSTSWithCredentials(ctx context.Context, s logical.Storage, creds *sts.Credentials) (stsiface.STSAPI, error) {
+	b.clientMutex.RLock()
+	defer b.clientMutex.RUnlock()
+	return nonCachedClientSTSWithCredentials(ctx, s, b.Logger(), creds)
+}
@@ -871,6 +871,10 @@ func testAccStepReadPolicy(t *testing.T, name string, value string) logicaltest.
 				"max_sts_ttl":              int64(0),
 				"user_path":                "",
 				"permissions_boundary_arn": "",
+				"role_chain":               []string(nil),
+				"session_tags":             map[string]string(nil),
+				"transitive_tag_keys":      []string(nil),
+				"source_identity":          "",
 				"iam_groups":               []string(nil),
 			}
 			if !reflect.DeepEqual(resp.Data, expected) {
@@ -991,6 +995,10 @@ func TestBackend_iamUserManagedInlinePoliciesGroups(t *testing.T) {
 		"max_sts_ttl":              int64(0),
 		"user_path":                "/path/",
 		"permissions_boundary_arn": "",
+		"role_chain":               []string(nil),
+		"session_tags":             map[string]string(nil),
+		"transitive_tag_keys":      []string(nil),
+		"source_identity":          "",
 		"iam_groups":               []string{groupName},
 	}
 
@@ -1034,6 +1042,10 @@ func TestBackend_iamUserGroups(t *testing.T) {
 		"max_sts_ttl":              int64(0),
 		"user_path":                "/path/",
 		"permissions_boundary_arn": "",
+		"role_chain":               []string(nil),
+		"session_tags":             map[string]string(nil),
+		"transitive_tag_keys":      []string(nil),
+		"source_identity":          "",
 		"iam_groups":               []string{group1Name, group2Name},
 	}
 
@@ -1357,6 +1369,10 @@ func testAccStepReadArnPolicy(t *testing.T, name string, value string) logicalte
 				"max_sts_ttl":              int64(0),
 				"user_path":                "",
 				"permissions_boundary_arn": "",
+				"role_chain":               []string(nil),
+				"session_tags":             map[string]string(nil),
+				"transitive_tag_keys":      []string(nil),
+				"source_identity":          "",
 				"iam_groups":               []string(nil),
 			}
 			if !reflect.DeepEqual(resp.Data, expected) {
@@ -1426,6 +1442,10 @@ func testAccStepReadIamGroups(t *testing.T, name string, groups []string) logica
 				"max_sts_ttl":              int64(0),
 				"user_path":                "",
 				"permissions_boundary_arn": "",
+				"role_chain":               []string(nil),
+				"session_tags":             map[string]string(nil),
+				"transitive_tag_keys":      []string(nil),
+				"source_identity":          "",
 				"iam_groups":               groups,
 			}
 			if !reflect.DeepEqual(resp.Data, expected) {
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -89,10 +90,19 @@ func nonCachedClientIAM(ctx context.Context, s logical.Storage, logger hclog.Log
 }
 
 func nonCachedClientSTS(ctx context.Context, s logical.Storage, logger hclog.Logger) (*sts.STS, error) {
+	return nonCachedClientSTSWithCredentials(ctx, s, logger, nil)
+}
+
+// nonCachedClientSTSWithCredentials returns an STS client configured like the
+// root one, using the given temporary credentials if not nil.
+func nonCachedClientSTSWithCredentials(ctx context.Context, s logical.Storage, logger hclog.Logger, creds *sts.Credentials) (*sts.STS, error) {
 	awsConfig, err := getRootConfig(ctx, s, "sts", logger)
 	if err != nil {
 		return nil, err
 	}
+	if creds != nil {
+		awsConfig.Credentials = credentials.NewStaticCredentials(aws.StringValue(creds.AccessKeyId), aws.StringValue(creds.SecretAccessKey), aws.StringValue(creds.SessionToken))
+	}
 	sess, err := session.NewSession(awsConfig)
 	if err != nil {
 		return nil, err
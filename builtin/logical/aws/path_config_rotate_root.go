@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -27,48 +28,73 @@ func pathConfigRotateRoot(b *backend) *framework.Path {
 	}
 }
 
+// errRotateRootNoKeys is returned when rotating the root credentials of a mount
+// not configured with IAM credentials.
+var errRotateRootNoKeys = errors.New("Cannot call config/rotate-root when either access_key or secret_key is empty")
+
 func (b *backend) pathConfigRotateRootUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	// have to get the client config first because that takes out a read lock
-	client, err := b.clientIAM(ctx, req.Storage)
+	accessKey, err := b.rotateRoot(ctx, req.Storage)
+	if recordErr := b.RotationManager.Record(ctx, req.Storage, rotationRoot, err); recordErr != nil {
+		b.Logger().Warn("failed to record the rotation of the root credentials", "error", recordErr)
+	}
+	if err == errRotateRootNoKeys {
+		return logical.ErrorResponse(err.Error()), nil
+	}
 	if err != nil {
 		return nil, err
 	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"access_key": accessKey,
+		},
+	}, nil
+}
+
+// rotateRoot replaces the access key configured for the mount with a new
+// access key of the same IAM user, and returns the new access key ID.
+func (b *backend) rotateRoot(ctx context.Context, s logical.Storage) (string, error) {
+	// have to get the client config first because that takes out a read lock
+	client, err := b.clientIAM(ctx, s)
+	if err != nil {
+		return "", err
+	}
 	if client == nil {
-		return nil, fmt.Errorf("nil IAM client")
+		return "", fmt.Errorf("nil IAM client")
 	}
 
 	b.clientMutex.Lock()
 	defer b.clientMutex.Unlock()
 
-	rawRootConfig, err := req.Storage.Get(ctx, "config/root")
+	rawRootConfig, err := s.Get(ctx, "config/root")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if rawRootConfig == nil {
-		return nil, fmt.Errorf("no configuration found for config/root")
+		return "", fmt.Errorf("no configuration found for config/root")
 	}
 	var config rootConfig
 	if err := rawRootConfig.DecodeJSON(&config); err != nil {
-		return nil, errwrap.Wrapf("error reading root configuration: {{err}}", err)
+		return "", errwrap.Wrapf("error reading root configuration: {{err}}", err)
 	}
 
 	if config.AccessKey == "" || config.SecretKey == "" {
-		return logical.ErrorResponse("Cannot call config/rotate-root when either access_key or secret_key is empty"), nil
+		return "", errRotateRootNoKeys
 	}
 
 	var getUserInput iam.GetUserInput // empty input means get current user
 	getUserRes, err := client.GetUser(&getUserInput)
 	if err != nil {
-		return nil, errwrap.Wrapf("error calling GetUser: {{err}}", err)
+		return "", errwrap.Wrapf("error calling GetUser: {{err}}", err)
 	}
 	if getUserRes == nil {
-		return nil, fmt.Errorf("nil response from GetUser")
+		return "", fmt.Errorf("nil response from GetUser")
 	}
 	if getUserRes.User == nil {
-		return nil, fmt.Errorf("nil user returned from GetUser")
+		return "", fmt.Errorf("nil user returned from GetUser")
 	}
 	if getUserRes.User.UserName == nil {
-		return nil, fmt.Errorf("nil UserName returned from GetUser")
+		return "", fmt.Errorf("nil UserName returned from GetUser")
 	}
 
 	createAccessKeyInput := iam.CreateAccessKeyInput{
@@ -76,13 +102,13 @@ func (b *backend) pathConfigRotateRootUpdate(ctx context.Context, req *logical.R
 	}
 	createAccessKeyRes, err := client.CreateAccessKey(&createAccessKeyInput)
 	if err != nil {
-		return nil, errwrap.Wrapf("error calling CreateAccessKey: {{err}}", err)
+		return "", errwrap.Wrapf("error calling CreateAccessKey: {{err}}", err)
 	}
 	if createAccessKeyRes.AccessKey == nil {
-		return nil, fmt.Errorf("nil response from CreateAccessKey")
+		return "", fmt.Errorf("nil response from CreateAccessKey")
 	}
 	if createAccessKeyRes.AccessKey.AccessKeyId == nil || createAccessKeyRes.AccessKey.SecretAccessKey == nil {
-		return nil, fmt.Errorf("nil AccessKeyId or SecretAccessKey returned from CreateAccessKey")
+		return "", fmt.Errorf("nil AccessKeyId or SecretAccessKey returned from CreateAccessKey")
 	}
 
 	oldAccessKey := config.AccessKey
@@ -92,10 +118,10 @@ func (b *backend) pathConfigRotateRootUpdate(ctx context.Context, req *logical.R
 
 	newEntry, err := logical.StorageEntryJSON("config/root", config)
 	if err != nil {
-		return nil, errwrap.Wrapf("error generating new config/root JSON: {{err}}", err)
+		return "", errwrap.Wrapf("error generating new config/root JSON: {{err}}", err)
 	}
-	if err := req.Storage.Put(ctx, newEntry); err != nil {
-		return nil, errwrap.Wrapf("error saving new config/root: {{err}}", err)
+	if err := s.Put(ctx, newEntry); err != nil {
+		return "", errwrap.Wrapf("error saving new config/root: {{err}}", err)
 	}
 
 	b.iamClient = nil
@@ -107,14 +133,10 @@ func (b *backend) pathConfigRotateRootUpdate(ctx context.Context, req *logical.R
 	}
 	_, err = client.DeleteAccessKey(&deleteAccessKeyInput)
 	if err != nil {
-		return nil, errwrap.Wrapf("error deleting old access key: {{err}}", err)
+		return "", errwrap.Wrapf("error deleting old access key: {{err}}", err)
 	}
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"access_key": config.AccessKey,
-		},
-	}, nil
+	return config.AccessKey, nil
 }
 
 const pathConfigRotateRootHelpSyn = `
@@ -19,9 +19,13 @@ import (
 )
 
 var (
-	userPathRegex = regexp.MustCompile(`^\/([\x21-\x7F]{0,510}\/)?$`)
+	userPathRegex       = regexp.MustCompile(`^\/([\x21-\x7F]{0,510}\/)?$`)
+	sourceIdentityRegex = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
 )
 
+// maxSessionTags is the maximum number of tags AWS accepts on a session
+const maxSessionTags = 50
+
 func pathListRoles(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "roles/?$",
@@ -60,6 +64,38 @@ func pathRoles(b *backend) *framework.Path {
 				},
 			},
 
+			"role_chain": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "ARNs of AWS roles to assume in order before assuming the role ARN, each with the credentials of the previous one. Only valid when credential_type is " + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Role Chain",
+				},
+			},
+
+			"session_tags": &framework.FieldSchema{
+				Type:        framework.TypeKVPairs,
+				Description: "Session tags to set on the assumed role session, as key=value pairs. Only valid when credential_type is " + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Session Tags",
+				},
+			},
+
+			"transitive_tag_keys": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Keys of the session tags which persist when the assumed role session is used to assume another role. Only valid when credential_type is " + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Transitive Tag Keys",
+				},
+			},
+
+			"source_identity": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Source identity to set on the assumed role session, which persists across role chaining. Only valid when credential_type is " + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Source Identity",
+				},
+			},
+
 			"policy_arns": &framework.FieldSchema{
 				Type: framework.TypeCommaStringSlice,
 				Description: fmt.Sprintf(`ARNs of AWS policies. Behavior varies by credential_type. When credential_type is
@@ -118,15 +154,17 @@ and policy_arns parameters.`,
 			},
 
 			"arn": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: `Use role_arns or policy_arns instead.`,
-				Deprecated:  true,
+				Type:           framework.TypeString,
+				Description:    `Use role_arns or policy_arns instead.`,
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"policy": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: "Use policy_document instead.",
-				Deprecated:  true,
+				Type:           framework.TypeString,
+				Description:    "Use policy_document instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"user_path": &framework.FieldSchema{
@@ -247,6 +285,34 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		roleEntry.RoleArns = roleArnsRaw.([]string)
 	}
 
+	if roleChainRaw, ok := d.GetOk("role_chain"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with role_chain"), nil
+		}
+		roleEntry.RoleChain = roleChainRaw.([]string)
+	}
+
+	if sessionTagsRaw, ok := d.GetOk("session_tags"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with session_tags"), nil
+		}
+		roleEntry.SessionTags = sessionTagsRaw.(map[string]string)
+	}
+
+	if transitiveTagKeysRaw, ok := d.GetOk("transitive_tag_keys"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with transitive_tag_keys"), nil
+		}
+		roleEntry.TransitiveTagKeys = transitiveTagKeysRaw.([]string)
+	}
+
+	if sourceIdentityRaw, ok := d.GetOk("source_identity"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with source_identity"), nil
+		}
+		roleEntry.SourceIdentity = sourceIdentityRaw.(string)
+	}
+
 	if policyArnsRaw, ok := d.GetOk("policy_arns"); ok {
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with policy_arns"), nil
@@ -460,6 +526,20 @@ func validateAWSManagedPolicy(policyARN string) error {
 	return nil
 }
 
+func validateAWSRole(roleARN string) error {
+	parsedARN, err := arn.Parse(roleARN)
+	if err != nil {
+		return err
+	}
+	if parsedARN.Service != "iam" {
+		return fmt.Errorf("expected a service of iam but got %s", parsedARN.Service)
+	}
+	if !strings.HasPrefix(parsedARN.Resource, "role/") {
+		return fmt.Errorf("expected a resource type of role but got %s", parsedARN.Resource)
+	}
+	return nil
+}
+
 func setAwsRole(ctx context.Context, s logical.Storage, roleName string, roleEntry *awsRoleEntry) error {
 	if roleName == "" {
 		return fmt.Errorf("empty role name")
@@ -481,18 +561,22 @@ func setAwsRole(ctx context.Context, s logical.Storage, roleName string, roleEnt
 }
 
 type awsRoleEntry struct {
-	CredentialTypes          []string      `json:"credential_types"`                      // Entries must all be in the set of ("iam_user", "assumed_role", "federation_token")
-	PolicyArns               []string      `json:"policy_arns"`                           // ARNs of managed policies to attach to an IAM user
-	RoleArns                 []string      `json:"role_arns"`                             // ARNs of roles to assume for AssumedRole credentials
-	PolicyDocument           string        `json:"policy_document"`                       // JSON-serialized inline policy to attach to IAM users and/or to specify as the Policy parameter in AssumeRole calls
-	IAMGroups                []string      `json:"iam_groups"`                            // Names of IAM groups that generated IAM users will be added to
-	InvalidData              string        `json:"invalid_data,omitempty"`                // Invalid role data. Exists to support converting the legacy role data into the new format
-	ProhibitFlexibleCredPath bool          `json:"prohibit_flexible_cred_path,omitempty"` // Disallow accessing STS credentials via the creds path and vice verse
-	Version                  int           `json:"version"`                               // Version number of the role format
-	DefaultSTSTTL            time.Duration `json:"default_sts_ttl"`                       // Default TTL for STS credentials
-	MaxSTSTTL                time.Duration `json:"max_sts_ttl"`                           // Max allowed TTL for STS credentials
-	UserPath                 string        `json:"user_path"`                             // The path for the IAM user when using "iam_user" credential type
-	PermissionsBoundaryARN   string        `json:"permissions_boundary_arn"`              // ARN of an IAM policy to attach as a permissions boundary
+	CredentialTypes          []string          `json:"credential_types"`                      // Entries must all be in the set of ("iam_user", "assumed_role", "federation_token")
+	PolicyArns               []string          `json:"policy_arns"`                           // ARNs of managed policies to attach to an IAM user
+	RoleArns                 []string          `json:"role_arns"`                             // ARNs of roles to assume for AssumedRole credentials
+	PolicyDocument           string            `json:"policy_document"`                       // JSON-serialized inline policy to attach to IAM users and/or to specify as the Policy parameter in AssumeRole calls
+	IAMGroups                []string          `json:"iam_groups"`                            // Names of IAM groups that generated IAM users will be added to
+	InvalidData              string            `json:"invalid_data,omitempty"`                // Invalid role data. Exists to support converting the legacy role data into the new format
+	ProhibitFlexibleCredPath bool              `json:"prohibit_flexible_cred_path,omitempty"` // Disallow accessing STS credentials via the creds path and vice verse
+	Version                  int               `json:"version"`                               // Version number of the role format
+	DefaultSTSTTL            time.Duration     `json:"default_sts_ttl"`                       // Default TTL for STS credentials
+	MaxSTSTTL                time.Duration     `json:"max_sts_ttl"`                           // Max allowed TTL for STS credentials
+	UserPath                 string            `json:"user_path"`                             // The path for the IAM user when using "iam_user" credential type
+	PermissionsBoundaryARN   string            `json:"permissions_boundary_arn"`              // ARN of an IAM policy to attach as a permissions boundary
+	RoleChain                []string          `json:"role_chain,omitempty"`                  // ARNs of roles to assume in order before the role ARN for AssumedRole credentials
+	SessionTags              map[string]string `json:"session_tags,omitempty"`                // Tags to set on the session of AssumedRole credentials
+	TransitiveTagKeys        []string          `json:"transitive_tag_keys,omitempty"`         // Keys of the session tags which persist across role chaining
+	SourceIdentity           string            `json:"source_identity,omitempty"`             // Source identity to set on the session of AssumedRole credentials
 }
 
 func (r *awsRoleEntry) toResponseData() map[string]interface{} {
@@ -506,6 +590,10 @@ func (r *awsRoleEntry) toResponseData() map[string]interface{} {
 		"max_sts_ttl":              int64(r.MaxSTSTTL.Seconds()),
 		"user_path":                r.UserPath,
 		"permissions_boundary_arn": r.PermissionsBoundaryARN,
+		"role_chain":               r.RoleChain,
+		"session_tags":             r.SessionTags,
+		"transitive_tag_keys":      r.TransitiveTagKeys,
+		"source_identity":          r.SourceIdentity,
 	}
 
 	if r.InvalidData != "" {
@@ -564,6 +652,41 @@ func (r *awsRoleEntry) validate() error {
 		errors = multierror.Append(errors, fmt.Errorf("cannot supply role_arns when credential_type isn't %s", assumedRoleCred))
 	}
 
+	if len(r.RoleChain) > 0 {
+		if !strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+			errors = multierror.Append(errors, fmt.Errorf("cannot supply role_chain when credential_type isn't %s", assumedRoleCred))
+		}
+		for _, roleARN := range r.RoleChain {
+			if err := validateAWSRole(roleARN); err != nil {
+				errors = multierror.Append(errors, fmt.Errorf("invalid role_chain parameter: %v", err))
+			}
+		}
+	}
+
+	if len(r.SessionTags) > 0 {
+		if !strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+			errors = multierror.Append(errors, fmt.Errorf("cannot supply session_tags when credential_type isn't %s", assumedRoleCred))
+		}
+		if len(r.SessionTags) > maxSessionTags {
+			errors = multierror.Append(errors, fmt.Errorf("cannot supply more than %d session_tags", maxSessionTags))
+		}
+	}
+
+	for _, key := range r.TransitiveTagKeys {
+		if _, ok := r.SessionTags[key]; !ok {
+			errors = multierror.Append(errors, fmt.Errorf("transitive tag key %q is not a key of session_tags", key))
+		}
+	}
+
+	if r.SourceIdentity != "" {
+		if !strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+			errors = multierror.Append(errors, fmt.Errorf("cannot supply source_identity when credential_type isn't %s", assumedRoleCred))
+		}
+		if !sourceIdentityRegex.MatchString(r.SourceIdentity) {
+			errors = multierror.Append(errors, fmt.Errorf("the specified value for source_identity is invalid. It must match '%s' regexp", sourceIdentityRegex.String()))
+		}
+	}
+
 	return errors.ErrorOrNil()
 }
 
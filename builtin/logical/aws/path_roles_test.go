@@ -448,3 +448,72 @@ func TestRoleEntryValidationFederationTokenCred(t *testing.T) {
 	}
 
 }
+
+func TestRoleSessionOptionsValidity(t *testing.T) {
+	testCases := []struct {
+		description string
+		role        awsRoleEntry
+		isValid     bool
+	}{
+		{
+			description: "Valid",
+			role: awsRoleEntry{
+				CredentialTypes:   []string{assumedRoleCred},
+				RoleChain:         []string{"arn:aws:iam::123456789012:role/Hop"},
+				SessionTags:       map[string]string{"team": "payments"},
+				TransitiveTagKeys: []string{"team"},
+				SourceIdentity:    "alice@example.com",
+			},
+			isValid: true,
+		},
+		{
+			description: "Session tags on IAM users",
+			role: awsRoleEntry{
+				CredentialTypes: []string{iamUserCred},
+				SessionTags:     map[string]string{"team": "payments"},
+			},
+			isValid: false,
+		},
+		{
+			description: "Role chain on federation tokens",
+			role: awsRoleEntry{
+				CredentialTypes: []string{federationTokenCred},
+				RoleChain:       []string{"arn:aws:iam::123456789012:role/Hop"},
+			},
+			isValid: false,
+		},
+		{
+			description: "Role chain with a policy ARN",
+			role: awsRoleEntry{
+				CredentialTypes: []string{assumedRoleCred},
+				RoleChain:       []string{adminAccessPolicyARN},
+			},
+			isValid: false,
+		},
+		{
+			description: "Transitive tag key without a session tag",
+			role: awsRoleEntry{
+				CredentialTypes:   []string{assumedRoleCred},
+				SessionTags:       map[string]string{"team": "payments"},
+				TransitiveTagKeys: []string{"env"},
+			},
+			isValid: false,
+		},
+		{
+			description: "Invalid source identity",
+			role: awsRoleEntry{
+				CredentialTypes: []string{assumedRoleCred},
+				SourceIdentity:  "alice smith",
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if err := tc.role.validate(); tc.isValid != (err == nil) {
+				t.Fatalf("bad: expected %s, got error %v", strconv.FormatBool(tc.isValid), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathStaticCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-creds/" + framework.GenericNameWithAtRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the static role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathStaticCredsRead,
+		},
+
+		HelpSynopsis:    pathStaticCredsHelpSyn,
+		HelpDescription: pathStaticCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathStaticCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleMutex.RLock()
+	defer b.staticRoleMutex.RUnlock()
+	role, err := staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown static role: " + name), nil
+	}
+
+	schedule, status, err := b.RotationManager.Status(ctx, req.Storage, rotationStatic+"/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"access_key":      role.AccessKeyID,
+		"secret_key":      role.SecretAccessKey,
+		"security_token":  nil,
+		"rotation_period": int64(schedule.Period.Seconds()),
+		"ttl":             int64(0),
+	}
+	if !status.LastRotation.IsZero() {
+		respData["last_vault_rotation"] = status.LastRotation.UTC().Format(time.RFC3339Nano)
+	}
+	if next := framework.NextRotation(schedule, status); !next.IsZero() {
+		if ttl := time.Until(next); ttl > 0 {
+			respData["ttl"] = int64(ttl.Seconds())
+		}
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+const pathStaticCredsHelpSyn = `
+Read the current access key of a static role.
+`
+
+const pathStaticCredsHelpDesc = `
+This path reads the access key currently managed by Vault for the IAM user of
+the static role with the same name. The access key is not leased: it remains
+valid until it is rotated, which happens once "ttl" seconds have elapsed.
+`
@@ -0,0 +1,321 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	staticRolePath = "static-roles/"
+
+	// rotationStatic names the access keys of the static roles in the
+	// rotation paths, as "static/<role name>"
+	rotationStatic = "static"
+)
+
+func pathListStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathStaticRoleList,
+		},
+
+		HelpSynopsis:    pathListStaticRolesHelpSyn,
+		HelpDescription: pathListStaticRolesHelpDesc,
+	}
+}
+
+func pathStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/" + framework.GenericNameWithAtRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the static role",
+			},
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the existing IAM user whose access keys are managed by the role. Cannot be changed once the role is created.",
+			},
+			"rotation_period": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: fmt.Sprintf("Time between two rotations of the access key of the IAM user, at least %s", framework.MinRotationPeriod),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathStaticRolesRead,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback:                    b.pathStaticRolesWrite,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback:                    b.pathStaticRolesDelete,
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathStaticRolesHelpSyn,
+		HelpDescription: pathStaticRolesHelpDesc,
+	}
+}
+
+func (b *backend) pathStaticRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.staticRoleMutex.RLock()
+	defer b.staticRoleMutex.RUnlock()
+	entries, err := req.Storage.List(ctx, staticRolePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathStaticRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleMutex.RLock()
+	defer b.staticRoleMutex.RUnlock()
+	role, err := staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	schedule, _, err := b.RotationManager.Status(ctx, req.Storage, rotationStatic+"/"+name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"username":        role.Username,
+			"access_key":      role.AccessKeyID,
+			"rotation_period": int64(schedule.Period.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathStaticRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	rotationName := rotationStatic + "/" + name
+
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+	role, err := staticRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, _, err := b.RotationManager.Status(ctx, req.Storage, rotationName)
+	if err != nil {
+		return nil, err
+	}
+	if periodRaw, ok := d.GetOk("rotation_period"); ok {
+		schedule.Period = time.Duration(periodRaw.(int)) * time.Second
+	}
+	if schedule.Period < framework.MinRotationPeriod {
+		return logical.ErrorResponse(fmt.Sprintf("rotation_period must be at least %s", framework.MinRotationPeriod)), nil
+	}
+
+	username := d.Get("username").(string)
+	switch {
+	case role != nil && username != "" && username != role.Username:
+		return logical.ErrorResponse("username cannot be changed once the role is created"), nil
+	case role == nil && username == "":
+		return logical.ErrorResponse("missing username"), nil
+	}
+
+	if role == nil {
+		// Adopt the IAM user with an access key of its own, which is then
+		// rotated on the schedule of the role
+		accessKey, err := b.createStaticAccessKey(ctx, req.Storage, username)
+		if err != nil {
+			return nil, err
+		}
+		role = &awsStaticRoleEntry{
+			Username:        username,
+			AccessKeyID:     *accessKey.AccessKeyId,
+			SecretAccessKey: *accessKey.SecretAccessKey,
+		}
+		if err := setStaticRole(ctx, req.Storage, name, role); err != nil {
+			if deleteErr := b.deleteStaticAccessKey(ctx, req.Storage, username, role.AccessKeyID); deleteErr != nil {
+				b.Logger().Warn("failed to delete the access key of the role not created", "role", name, "error", deleteErr)
+			}
+			return nil, err
+		}
+		if err := b.RotationManager.Record(ctx, req.Storage, rotationName, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.RotationManager.SetSchedule(ctx, req.Storage, rotationName, schedule); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathStaticRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+	if err := req.Storage.Delete(ctx, staticRolePath+name); err != nil {
+		return nil, err
+	}
+	if err := b.RotationManager.Remove(ctx, req.Storage, rotationStatic+"/"+name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// staticRoles lists the names of the static roles, which are the targets of
+// their rotations.
+func (b *backend) staticRoles(ctx context.Context, s logical.Storage) ([]string, error) {
+	b.staticRoleMutex.RLock()
+	defer b.staticRoleMutex.RUnlock()
+	return s.List(ctx, staticRolePath)
+}
+
+// rotateStaticRole replaces the access key of the IAM user of the named static
+// role with a new one, and deletes the previous access key.
+func (b *backend) rotateStaticRole(ctx context.Context, s logical.Storage, name string) error {
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+	role, err := staticRole(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return fmt.Errorf("static role %q not found", name)
+	}
+
+	accessKey, err := b.createStaticAccessKey(ctx, s, role.Username)
+	if err != nil {
+		return err
+	}
+
+	oldAccessKeyID := role.AccessKeyID
+	role.AccessKeyID = *accessKey.AccessKeyId
+	role.SecretAccessKey = *accessKey.SecretAccessKey
+	if err := setStaticRole(ctx, s, name, role); err != nil {
+		if deleteErr := b.deleteStaticAccessKey(ctx, s, role.Username, role.AccessKeyID); deleteErr != nil {
+			b.Logger().Warn("failed to delete the access key not saved", "role", name, "error", deleteErr)
+		}
+		return errwrap.Wrapf("error saving the new access key: {{err}}", err)
+	}
+
+	if err := b.deleteStaticAccessKey(ctx, s, role.Username, oldAccessKeyID); err != nil {
+		return errwrap.Wrapf("error deleting the previous access key: {{err}}", err)
+	}
+
+	return nil
+}
+
+func (b *backend) createStaticAccessKey(ctx context.Context, s logical.Storage, username string) (*iam.AccessKey, error) {
+	client, err := b.clientIAM(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.CreateAccessKey(&iam.CreateAccessKeyInput{
+		UserName: aws.String(username),
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("error calling CreateAccessKey: {{err}}", err)
+	}
+	if resp.AccessKey == nil || resp.AccessKey.AccessKeyId == nil || resp.AccessKey.SecretAccessKey == nil {
+		return nil, fmt.Errorf("nil AccessKeyId or SecretAccessKey returned from CreateAccessKey")
+	}
+
+	return resp.AccessKey, nil
+}
+
+func (b *backend) deleteStaticAccessKey(ctx context.Context, s logical.Storage, username, accessKeyID string) error {
+	client, err := b.clientIAM(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+		AccessKeyId: aws.String(accessKeyID),
+		UserName:    aws.String(username),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+		// The access key is already gone
+		return nil
+	}
+	return err
+}
+
+func staticRole(ctx context.Context, s logical.Storage, name string) (*awsStaticRoleEntry, error) {
+	entry, err := s.Get(ctx, staticRolePath+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role awsStaticRoleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func setStaticRole(ctx context.Context, s logical.Storage, name string, role *awsStaticRoleEntry) error {
+	entry, err := logical.StorageEntryJSON(staticRolePath+name, role)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// awsStaticRoleEntry is an existing IAM user adopted by Vault, along with the
+// access key of the user currently managed by Vault.
+type awsStaticRoleEntry struct {
+	Username        string `json:"username"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+const pathListStaticRolesHelpSyn = `List the existing static roles in this backend`
+
+const pathListStaticRolesHelpDesc = `Static roles will be listed by the role name.`
+
+const pathStaticRolesHelpSyn = `
+Manage the static roles rotating the access keys of existing IAM users.
+`
+
+const pathStaticRolesHelpDesc = `
+This path allows you to read and write static roles, which adopt an existing
+IAM user and rotate its access key on a schedule. Creating a static role
+creates a new access key for the IAM user, which is then served from the
+"static-creds/" path with the same name until it is rotated. Rotating the
+access key creates a new access key and deletes the previous one, so any other
+access key of the IAM user must be deleted before the first rotation.
+
+The rotations of the access keys can also be managed through the "rotation/"
+paths, where the access key of a static role is named "static/<role name>".
+
+Deleting a static role leaves the IAM user and its current access key in
+place.
+`
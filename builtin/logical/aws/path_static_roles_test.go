@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type mockAccessKeysIAMClient struct {
+	iamiface.IAMAPI
+
+	created int
+	keys    map[string][]string
+}
+
+func (m *mockAccessKeysIAMClient) CreateAccessKey(in *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
+	username := *in.UserName
+	if len(m.keys[username]) >= 2 {
+		return nil, awserr.New(iam.ErrCodeLimitExceededException, "Cannot exceed quota for AccessKeysPerUser: 2", nil)
+	}
+
+	m.created++
+	id := fmt.Sprintf("AKIA%d", m.created)
+	m.keys[username] = append(m.keys[username], id)
+	return &iam.CreateAccessKeyOutput{
+		AccessKey: &iam.AccessKey{
+			UserName:        in.UserName,
+			AccessKeyId:     aws.String(id),
+			SecretAccessKey: aws.String("secret" + id),
+		},
+	}, nil
+}
+
+func (m *mockAccessKeysIAMClient) DeleteAccessKey(in *iam.DeleteAccessKeyInput) (*iam.DeleteAccessKeyOutput, error) {
+	username := *in.UserName
+	for i, id := range m.keys[username] {
+		if id == *in.AccessKeyId {
+			m.keys[username] = append(m.keys[username][:i], m.keys[username][i+1:]...)
+			return &iam.DeleteAccessKeyOutput{}, nil
+		}
+	}
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "", nil)
+}
+
+func TestBackend_StaticRoles(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	client := &mockAccessKeysIAMClient{
+		keys: map[string][]string{
+			"legacy": []string{"AKIALEGACY"},
+		},
+	}
+	b.iamClient = client
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "static-roles/app", map[string]interface{}{
+		"username":        "legacy",
+		"rotation_period": "30m",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "static-roles/app", map[string]interface{}{
+		"username":        "legacy",
+		"rotation_period": "24h",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !reflect.DeepEqual(client.keys["legacy"], []string{"AKIALEGACY", "AKIA1"}) {
+		t.Fatalf("bad access keys: %v", client.keys["legacy"])
+	}
+
+	resp = request(logical.ReadOperation, "static-creds/app", nil)
+	if resp == nil || resp.Data["access_key"] != "AKIA1" || resp.Data["secret_key"] != "secretAKIA1" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if ttl := resp.Data["ttl"].(int64); ttl <= 0 || ttl > 24*60*60 {
+		t.Fatalf("bad ttl: %d", ttl)
+	}
+
+	resp = request(logical.UpdateOperation, "static-roles/app", map[string]interface{}{
+		"username": "other",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+
+	// The rotation needs room for a second access key
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotation/rotate/static/app",
+		Storage:   config.StorageView,
+	}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	client.keys["legacy"] = []string{"AKIA1"}
+	resp = request(logical.UpdateOperation, "rotation/rotate/static/app", nil)
+	if resp == nil || resp.Data["failures"] != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !reflect.DeepEqual(client.keys["legacy"], []string{"AKIA2"}) {
+		t.Fatalf("bad access keys: %v", client.keys["legacy"])
+	}
+	resp = request(logical.ReadOperation, "static-creds/app", nil)
+	if resp == nil || resp.Data["access_key"] != "AKIA2" || resp.Data["secret_key"] != "secretAKIA2" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = request(logical.ReadOperation, "static-roles/app", nil)
+	if resp == nil || resp.Data["username"] != "legacy" || resp.Data["rotation_period"] != int64(24*60*60) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	request(logical.DeleteOperation, "static-roles/app", nil)
+	if resp := request(logical.ReadOperation, "rotation/static/app", nil); resp != nil {
+		t.Fatalf("expected no credential, got %#v", resp)
+	}
+	if resp := request(logical.ReadOperation, "static-creds/app", nil); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+}
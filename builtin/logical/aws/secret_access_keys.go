@@ -3,11 +3,15 @@ package aws
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/url"
 	"regexp"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/hashicorp/errwrap"
@@ -18,6 +22,16 @@ import (
 
 const secretAccessKeyType = "access_keys"
 
+const (
+	// roleChainMaxTTL is the maximum lifetime AWS allows for the session of
+	// a role assumed through role chaining
+	roleChainMaxTTL = int64(time.Hour / time.Second)
+
+	// roleChainHopTTL is the lifetime of the sessions of the intermediate
+	// roles of a role chain, which are only used to assume the next role
+	roleChainHopTTL = int64(15 * time.Minute / time.Second)
+)
+
 func secretAccessKeys(b *backend) *framework.Secret {
 	return &framework.Secret{
 		Type: secretAccessKeyType,
@@ -140,12 +154,14 @@ func (b *backend) getFederationToken(ctx context.Context, s logical.Storage,
 }
 
 func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
-	displayName, roleName, roleArn, policy string, policyARNs []string,
-	iamGroups []string, lifeTimeInSeconds int64) (*logical.Response, error) {
+	displayName, roleName, roleArn string, role *awsRoleEntry,
+	lifeTimeInSeconds int64) (*logical.Response, error) {
+
+	policy, policyARNs := role.PolicyDocument, role.PolicyArns
 
 	// grab any IAM group policies associated with the vault role, both inline
 	// and managed
-	groupPolicies, groupPolicyARNs, err := b.getGroupPolicies(ctx, s, iamGroups)
+	groupPolicies, groupPolicyARNs, err := b.getGroupPolicies(ctx, s, role.IAMGroups)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
@@ -167,6 +183,30 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 
 	username, usernameWarning := genUsername(displayName, roleName, "iam_user")
 
+	// Assume the roles of the chain in order, each with the credentials of
+	// the previous one. The source identity is set on the first session and
+	// persists across the chain.
+	sourceIdentity := role.SourceIdentity
+	for _, chainedRoleArn := range role.RoleChain {
+		chainResp, err := stsClient.AssumeRoleWithContext(ctx, &sts.AssumeRoleInput{
+			RoleSessionName: aws.String(username),
+			RoleArn:         aws.String(chainedRoleArn),
+			DurationSeconds: aws.Int64(roleChainHopTTL),
+		}, withSourceIdentity(sourceIdentity))
+		if err != nil {
+			return logical.ErrorResponse("Error assuming role %q of the role chain: %s", chainedRoleArn, err), awsutil.CheckAWSError(err)
+		}
+		sourceIdentity = ""
+
+		stsClient, err = b.clientSTSWithCredentials(ctx, s, chainResp.Credentials)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+	if len(role.RoleChain) > 0 && lifeTimeInSeconds > roleChainMaxTTL {
+		lifeTimeInSeconds = roleChainMaxTTL
+	}
+
 	assumeRoleInput := &sts.AssumeRoleInput{
 		RoleSessionName: aws.String(username),
 		RoleArn:         aws.String(roleArn),
@@ -178,7 +218,13 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 	if len(policyARNs) > 0 {
 		assumeRoleInput.SetPolicyArns(convertPolicyARNs(policyARNs))
 	}
-	tokenResp, err := stsClient.AssumeRole(assumeRoleInput)
+	if len(role.SessionTags) > 0 {
+		assumeRoleInput.SetTags(convertSessionTags(role.SessionTags))
+	}
+	if len(role.TransitiveTagKeys) > 0 {
+		assumeRoleInput.SetTransitiveTagKeys(aws.StringSlice(role.TransitiveTagKeys))
+	}
+	tokenResp, err := stsClient.AssumeRoleWithContext(ctx, assumeRoleInput, withSourceIdentity(sourceIdentity))
 
 	if err != nil {
 		return logical.ErrorResponse("Error assuming role: %s", err), awsutil.CheckAWSError(err)
@@ -207,6 +253,48 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 	return resp, nil
 }
 
+// convertSessionTags converts the session tags of a role to STS tags, sorted by
+// key.
+func convertSessionTags(sessionTags map[string]string) []*sts.Tag {
+	keys := make([]string, 0, len(sessionTags))
+	for key := range sessionTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]*sts.Tag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, &sts.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(sessionTags[key]),
+		})
+	}
+	return tags
+}
+
+// withSourceIdentity sets the SourceIdentity parameter of an AssumeRole
+// request, which the vendored SDK predates, if sourceIdentity is not empty.
+func withSourceIdentity(sourceIdentity string) request.Option {
+	return func(r *request.Request) {
+		if sourceIdentity == "" {
+			return
+		}
+		// The parameters are form encoded in the body by the query protocol
+		// build handler, which runs first
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			if r.Error != nil {
+				return
+			}
+			body, err := ioutil.ReadAll(r.GetBody())
+			if err != nil {
+				r.Error = err
+				return
+			}
+			r.SetBufferBody(append(body, "&SourceIdentity="+url.QueryEscape(sourceIdentity)...))
+		})
+	}
+}
+
 func (b *backend) secretAccessKeysCreate(
 	ctx context.Context,
 	s logical.Storage,
@@ -1,7 +1,16 @@
 package aws
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
 )
 
 func TestNormalizeDisplayName_NormRequired(t *testing.T) {
@@ -42,3 +51,126 @@ func TestNormalizeDisplayName_NormNotRequired(t *testing.T) {
 		}
 	}
 }
+
+func TestBackend_AssumeRoleChain(t *testing.T) {
+	type assumeRoleCall struct {
+		AccessKeyID string
+		Form        url.Values
+	}
+	var calls []assumeRoleCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the request: %v", err)
+		}
+		// The access key is the first part of the credential scope
+		credential := strings.SplitN(r.Header.Get("Authorization"), "Credential=", 2)[1]
+		calls = append(calls, assumeRoleCall{
+			AccessKeyID: strings.SplitN(credential, "/", 2)[0],
+			Form:        r.PostForm,
+		})
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIA%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, len(calls), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+			Storage:   config.StorageView,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+
+	request("config/root", map[string]interface{}{
+		"access_key":   "AKIAROOT",
+		"secret_key":   "secret",
+		"region":       "us-east-1",
+		"sts_endpoint": server.URL,
+	})
+	request("roles/chained", map[string]interface{}{
+		"credential_type":     assumedRoleCred,
+		"role_arns":           []string{"arn:aws:iam::123456789012:role/Target"},
+		"role_chain":          []string{"arn:aws:iam::123456789012:role/First", "arn:aws:iam::123456789012:role/Second"},
+		"session_tags":        []string{"team=payments", "env=prod"},
+		"transitive_tag_keys": []string{"team"},
+		"source_identity":     "alice",
+	})
+	resp := request("sts/chained", map[string]interface{}{
+		"ttl": "2h",
+	})
+	if resp.Data["access_key"] != "ASIA3" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 AssumeRole calls, got %d", len(calls))
+	}
+	expected := []struct {
+		accessKeyID string
+		form        map[string]string
+	}{
+		{
+			accessKeyID: "AKIAROOT",
+			form: map[string]string{
+				"RoleArn":         "arn:aws:iam::123456789012:role/First",
+				"DurationSeconds": "900",
+				"SourceIdentity":  "alice",
+			},
+		},
+		{
+			accessKeyID: "ASIA1",
+			form: map[string]string{
+				"RoleArn":         "arn:aws:iam::123456789012:role/Second",
+				"DurationSeconds": "900",
+				"SourceIdentity":  "",
+			},
+		},
+		{
+			accessKeyID: "ASIA2",
+			form: map[string]string{
+				"RoleArn":                    "arn:aws:iam::123456789012:role/Target",
+				"DurationSeconds":            "3600",
+				"SourceIdentity":             "",
+				"Tags.member.1.Key":          "env",
+				"Tags.member.1.Value":        "prod",
+				"Tags.member.2.Key":          "team",
+				"Tags.member.2.Value":        "payments",
+				"TransitiveTagKeys.member.1": "team",
+			},
+		},
+	}
+	for i, call := range calls {
+		if call.AccessKeyID != expected[i].accessKeyID {
+			t.Fatalf("call %d: expected access key %q, got %q", i, expected[i].accessKeyID, call.AccessKeyID)
+		}
+		for key, value := range expected[i].form {
+			if call.Form.Get(key) != value {
+				t.Fatalf("call %d: expected %s=%q, got %q", i, key, value, call.Form.Get(key))
+			}
+		}
+	}
+	if calls[1].Form.Get("Tags.member.1.Key") != "" {
+		t.Fatalf("expected no session tags on the intermediate roles, got %v", calls[1].Form)
+	}
+}
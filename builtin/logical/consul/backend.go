@@ -34,6 +34,17 @@ func Backend() *backend {
 		Secrets: []*framework.Secret{
 			secretToken(&b),
 		},
+		RotationManager: &framework.RotationManager{
+			Rotations: []*framework.Rotation{
+				{
+					Name: rotationRoot,
+					Rotate: func(ctx context.Context, req *logical.Request, _ string) error {
+						return b.rotateRootToken(ctx, req.Storage)
+					},
+				},
+			},
+			MetricsPrefix: []string{"secrets", "consul"},
+		},
 		BackendType: logical.TypeLogical,
 	}
 
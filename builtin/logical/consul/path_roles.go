@@ -67,9 +67,10 @@ Defaults to 'client'.`,
 			},
 
 			"lease": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: "Use ttl instead.",
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    "Use ttl instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 		},
 
@@ -0,0 +1,71 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// rotationRoot names the token of the mount in the rotation paths
+const rotationRoot = "root"
+
+// rotateRootToken replaces the token configured for the mount with a clone of
+// it, having the same policies and roles, and deletes the previous token.
+func (b *backend) rotateRootToken(ctx context.Context, s logical.Storage) error {
+	conf, userErr, intErr := b.readConfigAccess(ctx, s)
+	if intErr != nil {
+		return intErr
+	}
+	if userErr != nil {
+		return userErr
+	}
+	if conf == nil {
+		return fmt.Errorf("no user error reported but consul access configuration not found")
+	}
+	if conf.Token == "" {
+		return fmt.Errorf("no token configured in the consul access configuration")
+	}
+
+	client, userErr, intErr := b.client(ctx, s)
+	if intErr != nil {
+		return intErr
+	}
+	if userErr != nil {
+		return userErr
+	}
+
+	self, _, err := client.ACL().TokenReadSelf(nil)
+	if err != nil {
+		return errwrap.Wrapf("error reading the configured token: {{err}}", err)
+	}
+
+	clone, _, err := client.ACL().TokenClone(self.AccessorID, self.Description, nil)
+	if err != nil {
+		return errwrap.Wrapf("error cloning the configured token: {{err}}", err)
+	}
+
+	conf.Token = clone.SecretID
+	entry, err := logical.StorageEntryJSON("config/access", conf)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return errwrap.Wrapf("error saving the new token: {{err}}", err)
+	}
+
+	// Delete the previous token using the new one
+	client, userErr, intErr = b.client(ctx, s)
+	if intErr != nil {
+		return intErr
+	}
+	if userErr != nil {
+		return userErr
+	}
+	if _, err := client.ACL().TokenDelete(self.AccessorID, nil); err != nil {
+		return errwrap.Wrapf("error deleting the previous token: {{err}}", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/rpc"
 	"strings"
 	"sync"
@@ -10,7 +11,6 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
-	"github.com/hashicorp/go-uuid"
 	v4 "github.com/hashicorp/vault/sdk/database/dbplugin"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
@@ -26,6 +26,10 @@ const (
 	databaseRolePath       = "role/"
 	databaseStaticRolePath = "static-role/"
 	minRootCredRollbackAge = 1 * time.Minute
+
+	// rotationRoot names the root credentials of the connections in the
+	// rotation paths
+	rotationRoot = "root"
 )
 
 type dbPluginInstance struct {
@@ -35,6 +39,17 @@ type dbPluginInstance struct {
 	id     string
 	name   string
 	closed bool
+
+	// connectionURL is the URL the instance is connected to for connections
+	// with failover URLs. canFailover is set unless the URL is pinned, and
+	// preferred holds the URLs ahead of it, probed to fail back to them.
+	connectionURL string
+	canFailover   bool
+	preferred     []string
+
+	probeLock sync.Mutex
+	probing   bool
+	lastProbe time.Time
 }
 
 func (dbi *dbPluginInstance) Close() error {
@@ -74,6 +89,7 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 		PathsSpecial: &logical.Paths{
 			LocalStorage: []string{
 				framework.WALPrefix,
+				databaseCredentialPath,
 			},
 			SealWrapStorage: []string{
 				"config/*",
@@ -85,9 +101,11 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 				pathListPluginConnection(&b),
 				pathConfigurePluginConnection(&b),
 				pathResetConnection(&b),
+				pathFailover(&b),
 			},
 			pathListRoles(&b),
 			pathRoles(&b),
+			pathRoleCredentials(&b),
 			pathCredsCreate(&b),
 			pathRotateRootCredentials(&b),
 		),
@@ -95,11 +113,35 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 		Secrets: []*framework.Secret{
 			secretCreds(&b),
 		},
+		PeriodicJobs: []*framework.PeriodicJob{
+			{
+				Name:     "credential-tidy",
+				Interval: credentialTidyInterval,
+				Jitter:   credentialTidyInterval / 10,
+				Func:     b.tidyCredentials,
+			},
+		},
+		RotationManager: &framework.RotationManager{
+			Rotations: []*framework.Rotation{
+				{
+					Name: rotationRoot,
+					Targets: func(ctx context.Context, s logical.Storage) ([]string, error) {
+						return s.List(ctx, databaseConfigPath)
+					},
+					Rotate: func(ctx context.Context, req *logical.Request, name string) error {
+						return b.rotateRootCredentials(ctx, req.Storage, name)
+					},
+				},
+			},
+			MetricsPrefix: []string{"secrets", "database"},
+		},
 		Clean:             b.clean,
 		Invalidate:        b.invalidate,
 		WALRollback:       b.walRollback,
 		WALRollbackMinAge: minRootCredRollbackAge,
 		BackendType:       logical.TypeLogical,
+		ExportStateFunc:   b.exportState,
+		ImportStateFunc:   b.importState,
 	}
 
 	b.logger = conf.Logger
@@ -242,6 +284,7 @@ func (b *databaseBackend) GetConnectionWithConfig(ctx context.Context, name stri
 
 	dbi, ok := b.connections[name]
 	if ok {
+		b.probeFailback(name, config, dbi)
 		return dbi, nil
 	}
 
@@ -255,31 +298,10 @@ func (b *databaseBackend) GetConnectionWithConfig(ctx context.Context, name stri
 		return dbi, nil
 	}
 
-	id, err := uuid.GenerateUUID()
-	if err != nil {
-		return nil, err
-	}
-
-	dbw, err := newDatabaseWrapper(ctx, config.PluginName, b.System(), b.logger)
+	dbi, _, err := b.newConnection(ctx, name, config, config.candidateConnectionURLs(), true)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create database instance: %w", err)
-	}
-
-	initReq := v5.InitializeRequest{
-		Config:           config.ConnectionDetails,
-		VerifyConnection: true,
-	}
-	_, err = dbw.Initialize(ctx, initReq)
-	if err != nil {
-		dbw.Close()
 		return nil, err
 	}
-
-	dbi = &dbPluginInstance{
-		database: dbw,
-		id:       id,
-		name:     name,
-	}
 	b.connections[name] = dbi
 	return dbi, nil
 }
@@ -314,9 +336,14 @@ func (b *databaseBackend) clearConnection(name string) error {
 }
 
 func (b *databaseBackend) CloseIfShutdown(db *dbPluginInstance, err error) {
-	// Plugin has shutdown, close it so next call can reconnect.
-	switch err {
-	case rpc.ErrShutdown, v4.ErrPluginShutdown:
+	// Plugin has shutdown, or the database rejected the credentials of its
+	// connection, close it so next call can reconnect. Connections that can
+	// fail over are also closed on transient errors, such as a dropped
+	// connection, so that the next call selects a healthy connection URL.
+	// Other errors, such as a rejected statement, are no reason to fail over.
+	switch {
+	case err == rpc.ErrShutdown, err == v4.ErrPluginShutdown, v5.ErrorKindOf(err) == v5.ErrorKindAuth,
+		db.canFailover && v5.IsTransientError(err):
 		// Put this in a goroutine so that requests can run with the read or write lock
 		// and simply defer the unlock.  Since we are attaching the instance and matching
 		// the id in the connection map, we can safely do this.
@@ -334,6 +361,20 @@ func (b *databaseBackend) CloseIfShutdown(db *dbPluginInstance, err error) {
 	}
 }
 
+// pluginErrorResponse returns the response for an error returned by a
+// database plugin. Errors that the plugin classified as caused by the request
+// are returned to the client with a 4xx status code rather than a 500.
+func pluginErrorResponse(err error) (*logical.Response, error) {
+	switch v5.ErrorKindOf(err) {
+	case v5.ErrorKindNotFound:
+		return nil, logical.CodedError(http.StatusNotFound, err.Error())
+	case v5.ErrorKindPermanent:
+		return logical.ErrorResponse(err.Error()), nil
+	default:
+		return nil, err
+	}
+}
+
 // clean closes all connections from all database types
 // and cancels any rotation queue loading operation.
 func (b *databaseBackend) clean(ctx context.Context) {
@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"reflect"
 	"strings"
@@ -209,6 +212,11 @@ func TestBackend_config_connection(t *testing.T) {
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
 			"password_policy":                    "",
+			"max_retries":                        0,
+			"retry_backoff":                      "",
+			"retry_max_backoff":                  "",
+			"failover_connection_urls":           []string(nil),
+			"pinned_connection_url":              "",
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(namespace.RootContext(nil), configReq)
@@ -262,6 +270,11 @@ func TestBackend_config_connection(t *testing.T) {
 			"allowed_roles":                      []string{"*"},
 			"root_credentials_rotate_statements": []string{},
 			"password_policy":                    "",
+			"max_retries":                        0,
+			"retry_backoff":                      "",
+			"retry_max_backoff":                  "",
+			"failover_connection_urls":           []string(nil),
+			"pinned_connection_url":              "",
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(namespace.RootContext(nil), configReq)
@@ -280,6 +293,8 @@ func TestBackend_config_connection(t *testing.T) {
 		configData := map[string]interface{}{
 			"verify_connection": false,
 			"allowed_roles":     []string{"flu", "barre"},
+			"max_retries":       3,
+			"retry_backoff":     "250ms",
 			"name":              "plugin-test",
 		}
 
@@ -304,6 +319,11 @@ func TestBackend_config_connection(t *testing.T) {
 			"allowed_roles":                      []string{"flu", "barre"},
 			"root_credentials_rotate_statements": []string{},
 			"password_policy":                    "",
+			"max_retries":                        3,
+			"retry_backoff":                      "250ms",
+			"retry_max_backoff":                  "",
+			"failover_connection_urls":           []string(nil),
+			"pinned_connection_url":              "",
 		}
 		configReq.Operation = logical.ReadOperation
 		resp, err = b.HandleRequest(namespace.RootContext(nil), configReq)
@@ -701,6 +721,11 @@ func TestBackend_connectionCrud(t *testing.T) {
 		"allowed_roles":                      []string{"plugin-role-test"},
 		"root_credentials_rotate_statements": []string(nil),
 		"password_policy":                    "",
+		"max_retries":                        0,
+		"retry_backoff":                      "",
+		"retry_max_backoff":                  "",
+		"failover_connection_urls":           []string(nil),
+		"pinned_connection_url":              "",
 	}
 	req.Operation = logical.ReadOperation
 	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
@@ -1384,3 +1409,31 @@ REVOKE USAGE ON SCHEMA public FROM {{name}};
 
 DROP ROLE IF EXISTS {{name}};
 `
+
+func TestPluginErrorResponse(t *testing.T) {
+	cause := errors.New("plugin error")
+	tests := map[string]struct {
+		err          error
+		expectedCode int
+	}{
+		"unclassified": {cause, http.StatusInternalServerError},
+		"auth":         {v5.NewError(v5.ErrorKindAuth, cause), http.StatusInternalServerError},
+		"transient":    {v5.NewError(v5.ErrorKindTransient, cause), http.StatusInternalServerError},
+		"not found":    {v5.NewError(v5.ErrorKindNotFound, cause), http.StatusNotFound},
+		"permanent":    {fmt.Errorf("wrapped: %w", v5.NewError(v5.ErrorKindPermanent, cause)), http.StatusBadRequest},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := pluginErrorResponse(test.err)
+			code, _ := logical.RespondErrorCommon(&logical.Request{}, resp, err)
+			if code == 0 {
+				code = http.StatusInternalServerError
+			}
+			logical.AdjustErrorStatusCode(&code, err)
+			if code != test.expectedCode {
+				t.Fatalf("Actual code: %d Expected code: %d", code, test.expectedCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	uuid "github.com/hashicorp/go-uuid"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+// failoverProbeInterval is the minimum time between two probes of the
+// connection URLs preferred over the one a connection failed over to.
+const failoverProbeInterval = 30 * time.Second
+
+// newConnection creates and initializes an instance of the plugin of the
+// connection. For connections with failover URLs, the instance is connected
+// to the first of the candidate URLs that is healthy, which is probed by
+// initializing the plugin with it and verifying the connection.
+func (b *databaseBackend) newConnection(ctx context.Context, name string, config *DatabaseConfig, candidates []string, verifyConnection bool) (*dbPluginInstance, v5.InitializeResponse, error) {
+	if len(candidates) == 0 {
+		return b.initializeConnection(ctx, name, config, config.ConnectionDetails, verifyConnection)
+	}
+
+	var merr *multierror.Error
+	for _, connURL := range candidates {
+		details := make(map[string]interface{}, len(config.ConnectionDetails))
+		for k, v := range config.ConnectionDetails {
+			details[k] = v
+		}
+		details["connection_url"] = connURL
+
+		dbi, initResp, err := b.initializeConnection(ctx, name, config, details, verifyConnection)
+		if err != nil {
+			b.logger.Warn("connection URL is unhealthy", "name", name, "connection_url", maskConnectionURL(connURL), "error", err)
+			merr = multierror.Append(merr, fmt.Errorf("%s: %w", maskConnectionURL(connURL), err))
+			continue
+		}
+
+		dbi.connectionURL = connURL
+		dbi.lastProbe = time.Now()
+		if config.PinnedConnectionURL == "" {
+			dbi.canFailover = true
+			for _, preferred := range config.connectionURLs() {
+				if preferred == connURL {
+					break
+				}
+				dbi.preferred = append(dbi.preferred, preferred)
+			}
+		}
+		return dbi, initResp, nil
+	}
+
+	return nil, v5.InitializeResponse{}, fmt.Errorf("no healthy connection URL: %w", merr.ErrorOrNil())
+}
+
+// initializeConnection creates an instance of the plugin of the connection
+// and initializes it with the given connection details.
+func (b *databaseBackend) initializeConnection(ctx context.Context, name string, config *DatabaseConfig, details map[string]interface{}, verifyConnection bool) (*dbPluginInstance, v5.InitializeResponse, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, v5.InitializeResponse{}, err
+	}
+
+	dbw, err := newDatabaseWrapper(ctx, config.PluginName, id, b.System(), b.logger)
+	if err != nil {
+		return nil, v5.InitializeResponse{}, fmt.Errorf("unable to create database instance: %w", err)
+	}
+
+	initReq := v5.InitializeRequest{
+		Config:           details,
+		VerifyConnection: verifyConnection,
+	}
+	initResp, err := dbw.Initialize(ctx, initReq)
+	if err != nil {
+		dbw.Close()
+		return nil, v5.InitializeResponse{}, err
+	}
+	dbw = dbw.withRetries(config.retryConfig()).withMetrics(config.PluginName, name)
+
+	dbi := &dbPluginInstance{
+		database: dbw,
+		id:       id,
+		name:     name,
+	}
+	return dbi, initResp, nil
+}
+
+// probeFailback probes in the background whether the connection URLs
+// preferred over the one a connection failed over to are healthy again, and
+// fails back to the first one that is.
+func (b *databaseBackend) probeFailback(name string, config *DatabaseConfig, dbi *dbPluginInstance) {
+	if len(dbi.preferred) == 0 {
+		return
+	}
+
+	dbi.probeLock.Lock()
+	defer dbi.probeLock.Unlock()
+	if dbi.probing || time.Since(dbi.lastProbe) < failoverProbeInterval {
+		return
+	}
+	dbi.probing = true
+	dbi.lastProbe = time.Now()
+
+	go func() {
+		defer func() {
+			dbi.probeLock.Lock()
+			dbi.probing = false
+			dbi.probeLock.Unlock()
+		}()
+
+		newDBI, _, err := b.newConnection(context.Background(), name, config, dbi.preferred, true)
+		if err != nil {
+			return
+		}
+
+		b.Lock()
+		defer b.Unlock()
+
+		// Ensure the connection was not replaced while probing
+		mapDB, ok := b.connections[name]
+		if !ok || mapDB.id != dbi.id {
+			newDBI.Close()
+			return
+		}
+
+		b.logger.Info("failing back connection", "name", name, "connection_url", maskConnectionURL(newDBI.connectionURL))
+		dbi.Close()
+		b.connections[name] = newDBI
+	}()
+}
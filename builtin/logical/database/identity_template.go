@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var (
+	// identityTemplateRegex matches the identity templates of statements,
+	// leaving those rendered by the database plugins, such as {{name}}, as is
+	identityTemplateRegex = regexp.MustCompile(`{{\s*identity\.[^{}]*}}`)
+
+	// identityValueRegex restricts the values substituted in statements.
+	// Identity values can be set by auth methods from external sources, so
+	// they must not be able to alter the statements they are substituted in.
+	// Values must also not contain "--", which starts a SQL comment.
+	identityValueRegex = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+)
+
+// renderIdentityTemplates substitutes the identity templates of the
+// statements, such as {{identity.entity.name}}, with the values of the entity
+// making the request.
+func (b *databaseBackend) renderIdentityTemplates(req *logical.Request, statements []string) ([]string, error) {
+	var (
+		entity *logical.Entity
+		groups []*logical.Group
+		loaded bool
+	)
+
+	rendered := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		var renderErr error
+		stmt = identityTemplateRegex.ReplaceAllStringFunc(stmt, func(tmpl string) string {
+			if renderErr != nil {
+				return tmpl
+			}
+
+			if !loaded {
+				if req.EntityID == "" {
+					renderErr = fmt.Errorf("statements reference identity templates but the request has no entity")
+					return tmpl
+				}
+				var err error
+				if entity, err = b.System().EntityInfo(req.EntityID); err != nil {
+					renderErr = err
+					return tmpl
+				}
+				if entity == nil {
+					renderErr = fmt.Errorf("statements reference identity templates but the entity of the request was not found")
+					return tmpl
+				}
+				if groups, err = b.System().GroupsForEntity(req.EntityID); err != nil {
+					renderErr = err
+					return tmpl
+				}
+				loaded = true
+			}
+
+			_, value, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+				String: tmpl,
+				Entity: entity,
+				Groups: groups,
+				Mode:   identitytpl.ACLTemplating,
+			})
+			if err != nil {
+				renderErr = fmt.Errorf("unable to render %s: %w", tmpl, err)
+				return tmpl
+			}
+			if !identityValueRegex.MatchString(value) || strings.Contains(value, "--") {
+				renderErr = fmt.Errorf("the value of %s contains characters not allowed in statements", tmpl)
+				return tmpl
+			}
+			return value
+		})
+		if renderErr != nil {
+			return nil, renderErr
+		}
+		rendered = append(rendered, stmt)
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestBackend_RenderIdentityTemplates(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &logical.StaticSystemView{
+		EntityVal: &logical.Entity{
+			ID:   "entity-id",
+			Name: "alice",
+			Metadata: map[string]string{
+				"team":   "payments",
+				"title":  "'; DROP TABLE users; --",
+				"schema": "payments--",
+			},
+		},
+		GroupsVal: []*logical.Group{
+			{ID: "group-id", Name: "dba"},
+		},
+	}
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{EntityID: "entity-id"}
+
+	statements := []string{
+		`CREATE USER [{{name}}] WITH PASSWORD = '{{password}}';`,
+		`EXEC sp_addextendedproperty 'requester', '{{identity.entity.name}}', 'USER', '{{name}}';`,
+		`GRANT SELECT ON SCHEMA::{{ identity.entity.metadata.team }} TO [{{name}}];`,
+		`ALTER ROLE [{{identity.groups.names.dba.name}}] ADD MEMBER [{{name}}];`,
+	}
+	expected := []string{
+		`CREATE USER [{{name}}] WITH PASSWORD = '{{password}}';`,
+		`EXEC sp_addextendedproperty 'requester', 'alice', 'USER', '{{name}}';`,
+		`GRANT SELECT ON SCHEMA::payments TO [{{name}}];`,
+		`ALTER ROLE [dba] ADD MEMBER [{{name}}];`,
+	}
+	rendered, err := b.renderIdentityTemplates(req, statements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Fatalf("expected %q, got %q", expected, rendered)
+	}
+
+	// Values which could alter the statements are rejected
+	_, err = b.renderIdentityTemplates(req, []string{`{{identity.entity.metadata.title}}`})
+	if err == nil {
+		t.Fatal("expected an error for a value with disallowed characters")
+	}
+	_, err = b.renderIdentityTemplates(req, []string{`{{identity.entity.metadata.schema}}`})
+	if err == nil {
+		t.Fatal("expected an error for a value starting a comment")
+	}
+
+	// Templates which can't be resolved are rejected
+	_, err = b.renderIdentityTemplates(req, []string{`{{identity.entity.metadata.missing}}`})
+	if err == nil {
+		t.Fatal("expected an error for a missing value")
+	}
+
+	// Templates require the request to have an entity
+	_, err = b.renderIdentityTemplates(&logical.Request{}, []string{`{{identity.entity.name}}`})
+	if err == nil {
+		t.Fatal("expected an error for a request without an entity")
+	}
+
+	// Statements without identity templates don't require an entity
+	rendered, err = b.renderIdentityTemplates(&logical.Request{}, statements[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rendered, statements[:1]) {
+		t.Fatalf("expected %q, got %q", statements[:1], rendered)
+	}
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// rootKeyPairBits is the size of the RSA keys generated when rotating the
+// key pair of a connection.
+const rootKeyPairBits = 2048
+
+// generateKeyPair returns a new RSA private key, PEM encoded in PKCS #8 form,
+// and its PEM encoded public key.
+func generateKeyPair() (string, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rootKeyPairBits)
+	if err != nil {
+		return "", nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	publicKey, err := encodePublicKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), publicKey, nil
+}
+
+// publicKeyFromPrivateKey returns the PEM encoded public key of a PEM encoded
+// RSA private key, in PKCS #1 or PKCS #8 form.
+func publicKeyFromPrivateKey(privateKey string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not an RSA key")
+	}
+	return encodePublicKey(rsaKey)
+}
+
+func encodePublicKey(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
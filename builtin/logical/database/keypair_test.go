@@ -0,0 +1,58 @@
+package database
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestKeyPair(t *testing.T) {
+	privateKey, publicKey, err := generateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(publicKey)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("bad public key: %s", publicKey)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.(*rsa.PublicKey).N.BitLen() != rootKeyPairBits {
+		t.Fatalf("bad key size: %d", key.(*rsa.PublicKey).N.BitLen())
+	}
+
+	derived, err := publicKeyFromPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(derived) != string(publicKey) {
+		t.Fatalf("bad public key: %s", derived)
+	}
+
+	tests := map[string]struct {
+		privateKey interface{}
+		keyPair    bool
+	}{
+		"pem key":     {privateKey, true},
+		"api key":     {"0a1b2c3d-4e5f", false},
+		"no key":      {"", false},
+		"not strings": {42, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &DatabaseConfig{
+				ConnectionDetails: map[string]interface{}{
+					"username":    "vault",
+					"private_key": test.privateKey,
+				},
+			}
+			if hasRootKeyPair(config) != test.keyPair {
+				t.Fatalf("expected key pair %t", test.keyPair)
+			}
+		})
+	}
+}
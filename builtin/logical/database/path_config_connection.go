@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/errwrap"
-	uuid "github.com/hashicorp/go-uuid"
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -32,6 +34,67 @@ type DatabaseConfig struct {
 	RootCredentialsRotateStatements []string `json:"root_credentials_rotate_statements" structs:"root_credentials_rotate_statements" mapstructure:"root_credentials_rotate_statements"`
 
 	PasswordPolicy string `json:"password_policy" structs:"password_policy" mapstructure:"password_policy"`
+
+	// MaxRetries is the number of times user operations failing with a
+	// transient error are retried, waiting RetryBackoff before the first
+	// retry and doubling the wait up to RetryMaxBackoff.
+	MaxRetries      int           `json:"max_retries" structs:"max_retries" mapstructure:"max_retries"`
+	RetryBackoff    time.Duration `json:"retry_backoff" structs:"-" mapstructure:"retry_backoff"`
+	RetryMaxBackoff time.Duration `json:"retry_max_backoff" structs:"-" mapstructure:"retry_max_backoff"`
+
+	// FailoverConnectionURLs are the URLs tried in order when the database at
+	// the connection_url of ConnectionDetails is unhealthy, unless
+	// PinnedConnectionURL selects one of the connection URLs.
+	FailoverConnectionURLs []string `json:"failover_connection_urls" structs:"failover_connection_urls" mapstructure:"failover_connection_urls"`
+	PinnedConnectionURL    string   `json:"pinned_connection_url" structs:"pinned_connection_url" mapstructure:"pinned_connection_url"`
+}
+
+// retryConfig returns the configuration of the retries of user operations.
+func (c *DatabaseConfig) retryConfig() v5.RetryConfig {
+	return v5.RetryConfig{
+		MaxRetries:     c.MaxRetries,
+		InitialBackoff: c.RetryBackoff,
+		MaxBackoff:     c.RetryMaxBackoff,
+	}
+}
+
+// connectionURLs returns the connection URLs in order of preference, or nil
+// if the connection has no failover URLs.
+func (c *DatabaseConfig) connectionURLs() []string {
+	if len(c.FailoverConnectionURLs) == 0 {
+		return nil
+	}
+	primary, _ := c.ConnectionDetails["connection_url"].(string)
+	return append([]string{primary}, c.FailoverConnectionURLs...)
+}
+
+// candidateConnectionURLs returns the connection URLs a connection is
+// established with, which is only the pinned one if any.
+func (c *DatabaseConfig) candidateConnectionURLs() []string {
+	if c.PinnedConnectionURL != "" {
+		return []string{c.PinnedConnectionURL}
+	}
+	return c.connectionURLs()
+}
+
+// setConnectionDetails replaces the connection details with the ones returned
+// by the plugin, keeping the primary connection_url of connections with
+// failover URLs rather than the one the plugin is connected to.
+func (c *DatabaseConfig) setConnectionDetails(details map[string]interface{}) {
+	if len(c.FailoverConnectionURLs) > 0 && details != nil {
+		details["connection_url"] = c.ConnectionDetails["connection_url"]
+	}
+	c.ConnectionDetails = details
+}
+
+// maskConnectionURL masks the password of a connection URL, if it has one.
+func maskConnectionURL(connURL string) string {
+	if conn, err := url.Parse(connURL); err == nil {
+		if password, ok := conn.User.Password(); ok {
+			return strings.Replace(connURL, password, "*****", -1)
+		}
+	}
+	return connURL
 }
 
 // pathResetConnection configures a path to reset a plugin.
@@ -120,6 +183,32 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `Password policy to use when generating passwords.`,
 			},
+
+			"max_retries": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `Number of times creating, updating or deleting a
+				user is retried when it fails with a transient error, such as a
+				deadlock or a reset connection. Defaults to 0, which disables
+				retries.`,
+			},
+
+			"retry_backoff": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Time to wait before the first retry. The wait is
+				doubled for every following retry. Defaults to 100ms.`,
+			},
+
+			"retry_max_backoff": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Maximum time to wait between retries. Defaults to 2s.`,
+			},
+
+			"failover_connection_urls": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or array of connection URLs
+				tried in order when the database at connection_url is
+				unhealthy, for example the URLs of disaster recovery replicas.`,
+			},
 		},
 
 		ExistenceCheck: b.connectionExistenceCheck(),
@@ -198,20 +287,32 @@ func (b *databaseBackend) connectionReadHandler() framework.OperationFunc {
 
 		// Mask the password if it is in the url
 		if connURLRaw, ok := config.ConnectionDetails["connection_url"]; ok {
-			connURL := connURLRaw.(string)
-			if conn, err := url.Parse(connURL); err == nil {
-				if password, ok := conn.User.Password(); ok {
-					config.ConnectionDetails["connection_url"] = strings.Replace(connURL, password, "*****", -1)
-				}
-			}
+			config.ConnectionDetails["connection_url"] = maskConnectionURL(connURLRaw.(string))
+		}
+		for i, connURL := range config.FailoverConnectionURLs {
+			config.FailoverConnectionURLs[i] = maskConnectionURL(connURL)
 		}
+		config.PinnedConnectionURL = maskConnectionURL(config.PinnedConnectionURL)
 
+		delete(config.ConnectionDetails, "api_key")
 		delete(config.ConnectionDetails, "password")
 		delete(config.ConnectionDetails, "private_key")
+		delete(config.ConnectionDetails, "secret_key")
+		delete(config.ConnectionDetails, "tls_certificate_key")
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: structs.New(config).Map(),
-		}, nil
+		}
+		resp.Data["retry_backoff"] = ""
+		if config.RetryBackoff > 0 {
+			resp.Data["retry_backoff"] = config.RetryBackoff.String()
+		}
+		resp.Data["retry_max_backoff"] = ""
+		if config.RetryMaxBackoff > 0 {
+			resp.Data["retry_max_backoff"] = config.RetryMaxBackoff.String()
+		}
+
+		return resp, nil
 	}
 }
 
@@ -232,6 +333,10 @@ func (b *databaseBackend) connectionDeleteHandler() framework.OperationFunc {
 			return nil, err
 		}
 
+		if err := b.RotationManager.Remove(ctx, req.Storage, rotationRoot+"/"+name); err != nil {
+			return nil, err
+		}
+
 		return nil, nil
 	}
 }
@@ -285,6 +390,30 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			config.PasswordPolicy = passwordPolicyRaw.(string)
 		}
 
+		if maxRetriesRaw, ok := data.GetOk("max_retries"); ok {
+			config.MaxRetries = maxRetriesRaw.(int)
+		}
+		if config.MaxRetries < 0 {
+			return logical.ErrorResponse("max_retries must not be negative"), nil
+		}
+
+		if retryBackoffRaw, ok := data.GetOk("retry_backoff"); ok {
+			config.RetryBackoff, err = parseutil.ParseDurationSecond(retryBackoffRaw)
+			if err != nil {
+				return logical.ErrorResponse("invalid retry_backoff: %s", err), nil
+			}
+		}
+		if retryMaxBackoffRaw, ok := data.GetOk("retry_max_backoff"); ok {
+			config.RetryMaxBackoff, err = parseutil.ParseDurationSecond(retryMaxBackoffRaw)
+			if err != nil {
+				return logical.ErrorResponse("invalid retry_max_backoff: %s", err), nil
+			}
+		}
+
+		if failoverURLsRaw, ok := data.GetOk("failover_connection_urls"); ok {
+			config.FailoverConnectionURLs = failoverURLsRaw.([]string)
+		}
+
 		// Remove these entries from the data before we store it keyed under
 		// ConnectionDetails.
 		delete(data.Raw, "name")
@@ -293,11 +422,10 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		delete(data.Raw, "verify_connection")
 		delete(data.Raw, "root_rotation_statements")
 		delete(data.Raw, "password_policy")
-
-		id, err := uuid.GenerateUUID()
-		if err != nil {
-			return nil, err
-		}
+		delete(data.Raw, "max_retries")
+		delete(data.Raw, "retry_backoff")
+		delete(data.Raw, "retry_max_backoff")
+		delete(data.Raw, "failover_connection_urls")
 
 		// If this is an update, take any new values, overwrite what was there
 		// before, and pass that in as the "new" set of values to the plugin,
@@ -313,22 +441,25 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			}
 		}
 
-		// Create a database plugin and initialize it.
-		dbw, err := newDatabaseWrapper(ctx, config.PluginName, b.System(), b.logger)
-		if err != nil {
-			return logical.ErrorResponse("error creating database object: %s", err), nil
+		if len(config.FailoverConnectionURLs) > 0 {
+			if _, ok := config.ConnectionDetails["connection_url"].(string); !ok {
+				return logical.ErrorResponse("failover_connection_urls requires a connection_url"), nil
+			}
 		}
 
-		initReq := v5.InitializeRequest{
-			Config:           config.ConnectionDetails,
-			VerifyConnection: verifyConnection,
+		// A pinned connection URL no longer in the list is unpinned
+		resp := &logical.Response{}
+		if config.PinnedConnectionURL != "" && !strutil.StrListContains(config.connectionURLs(), config.PinnedConnectionURL) {
+			config.PinnedConnectionURL = ""
+			resp.AddWarning("The pinned connection URL was removed, the connection is unpinned.")
 		}
-		initResp, err := dbw.Initialize(ctx, initReq)
+
+		// Create a database plugin and initialize it.
+		dbi, initResp, err := b.newConnection(ctx, name, config, config.candidateConnectionURLs(), verifyConnection)
 		if err != nil {
-			dbw.Close()
 			return logical.ErrorResponse("error creating database object: %s", err), nil
 		}
-		config.ConnectionDetails = initResp.Config
+		config.setConnectionDetails(initResp.Config)
 
 		b.Lock()
 		defer b.Unlock()
@@ -336,19 +467,13 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		// Close and remove the old connection
 		b.clearConnection(name)
 
-		b.connections[name] = &dbPluginInstance{
-			database: dbw,
-			name:     name,
-			id:       id,
-		}
+		b.connections[name] = dbi
 
 		err = storeConfig(ctx, req.Storage, name, config)
 		if err != nil {
 			return nil, err
 		}
 
-		resp := &logical.Response{}
-
 		// This is a simple test to to check for passwords in the connection_url paramater. If one exists,
 		// warn the user to use templated url string
 		if connURLRaw, ok := config.ConnectionDetails["connection_url"]; ok {
@@ -361,7 +486,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 
 		// If using a legacy DB plugin and set the `password_policy` field, send a warning to the user indicating
 		// the `password_policy` will not be used
-		if dbw.isV4() && config.PasswordPolicy != "" {
+		if dbi.database.isV4() && config.PasswordPolicy != "" {
 			resp.AddWarning(fmt.Sprintf("%s does not support password policies - upgrade to the latest version of "+
 				"Vault (or the sdk if using a custom plugin) to gain password policy support", config.PluginName))
 		}
@@ -403,6 +528,12 @@ accepts:
 	* "verify_connection" (default: true) - A boolean value denoting if the plugin should verify
 	   it is able to connect to the database using the provided connection
        details.
+
+	* "max_retries" (default: 0) - The number of times creating, updating or
+	   deleting a user is retried when it fails with a transient error.
+
+	* "failover_connection_urls" - The connection URLs tried in order when the
+	   database at "connection_url" is unhealthy.
 `
 
 const pathResetConnectionHelpSyn = `
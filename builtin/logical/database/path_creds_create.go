@@ -6,11 +6,17 @@ import (
 	"time"
 
 	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+// dryRunExpirationFormat is the format of the expiration rendered in the
+// statements of dry runs, the one used by most SQL database plugins.
+const dryRunExpirationFormat = "2006-01-02 15:04:05-0700"
+
 func pathCredsCreate(b *databaseBackend) []*framework.Path {
 	return []*framework.Path{
 		&framework.Path{
@@ -20,6 +26,11 @@ func pathCredsCreate(b *databaseBackend) []*framework.Path {
 					Type:        framework.TypeString,
 					Description: "Name of the role.",
 				},
+				"dry_run": &framework.FieldSchema{
+					Type: framework.TypeBool,
+					Description: `If true, return the statements of the role rendered with a
+sample username and password instead of creating the user.`,
+				},
 			},
 
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -61,6 +72,11 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 			return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", name)), nil
 		}
 
+		sunsetWarning, err := role.CheckSunset(name, time.Now())
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
 		dbConfig, err := b.DatabaseConfig(ctx, req.Storage, role.DBName)
 		if err != nil {
 			return nil, err
@@ -95,16 +111,36 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 			return nil, fmt.Errorf("unable to generate password: %w", err)
 		}
 
+		creationStatements, err := b.renderIdentityTemplates(req, role.Statements.Creation)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("unable to render creation statements: %s", err)), nil
+		}
+		rollbackStatements, err := b.renderIdentityTemplates(req, role.Statements.Rollback)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("unable to render rollback statements: %s", err)), nil
+		}
+
+		if data.Get("dry_run").(bool) {
+			resp, err := b.credsDryRunResponse(req, name, role, dbConfig, password, expiration, creationStatements, rollbackStatements)
+			if err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+			if sunsetWarning != "" {
+				resp.AddWarning(sunsetWarning)
+			}
+			return resp, nil
+		}
+
 		newUserReq := v5.NewUserRequest{
 			UsernameConfig: v5.UsernameMetadata{
 				DisplayName: req.DisplayName,
 				RoleName:    name,
 			},
 			Statements: v5.Statements{
-				Commands: role.Statements.Creation,
+				Commands: creationStatements,
 			},
 			RollbackStatements: v5.Statements{
-				Commands: role.Statements.Rollback,
+				Commands: rollbackStatements,
 			},
 			Password:   password,
 			Expiration: expiration,
@@ -114,7 +150,20 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		newUserResp, password, err := dbi.database.NewUser(ctx, newUserReq)
 		if err != nil {
 			b.CloseIfShutdown(dbi, err)
-			return nil, err
+			return pluginErrorResponse(err)
+		}
+
+		cred := &credentialEntry{
+			Username:   newUserResp.Username,
+			Role:       name,
+			DBName:     role.DBName,
+			IssueTime:  time.Now(),
+			Expiration: expiration,
+		}
+		// The user already exists in the database at this point, so failing to
+		// track it should not prevent the lease from being returned
+		if err := b.storeCredentialEntry(ctx, req.Storage, cred); err != nil {
+			b.Logger().Warn("failed to store credential entry", "role", name, "error", err)
 		}
 
 		respData := map[string]interface{}{
@@ -130,10 +179,80 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		resp := b.Secret(SecretCredsType).Response(respData, internal)
 		resp.Secret.TTL = role.DefaultTTL
 		resp.Secret.MaxTTL = role.MaxTTL
+		if sunsetWarning != "" {
+			resp.AddWarning(sunsetWarning)
+		}
+		// The user already exists in the database at this point, so failing to
+		// render the output should not prevent the lease from being returned
+		if role.OutputTemplate != "" {
+			output, err := role.renderOutput(outputTemplateData{
+				Username: newUserResp.Username,
+				Password: password,
+				RoleName: name,
+				DBName:   role.DBName,
+			})
+			if err != nil {
+				resp.AddWarning(fmt.Sprintf("unable to render output_template: %s", err))
+			} else {
+				resp.Data["output"] = output
+			}
+		}
 		return resp, nil
 	}
 }
 
+// credsDryRunResponse returns the statements of the role rendered the way
+// the database plugins render them, with a sample username and password, so
+// that they can be reviewed without being executed.
+func (b *databaseBackend) credsDryRunResponse(req *logical.Request, name string, role *roleEntry, dbConfig *DatabaseConfig, password string, expiration time.Time, creationStatements, rollbackStatements []string) (*logical.Response, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(dbConfig.ConnectionDetails)
+	if err != nil {
+		return nil, err
+	}
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.DisplayName, 8),
+		credsutil.RoleName(name, 8),
+		credsutil.Separator("-"),
+		credsutil.MaxLength(63),
+		credsutil.Template(usernameTemplate, v5.UsernameMetadata{
+			DisplayName: req.DisplayName,
+			RoleName:    name,
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate sample username: %w", err)
+	}
+
+	values := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   password,
+		"expiration": expiration.Format(dryRunExpirationFormat),
+	}
+	render := func(statements []string) []string {
+		rendered := make([]string, 0, len(statements))
+		for _, stmt := range statements {
+			rendered = append(rendered, dbutil.QueryHelper(stmt, values))
+		}
+		return rendered
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"dry_run":               true,
+			"username":              username,
+			"password":              password,
+			"expiration":            expiration.Format(time.RFC3339),
+			"creation_statements":   render(creationStatements),
+			"rollback_statements":   render(rollbackStatements),
+			"revocation_statements": render(role.Statements.Revocation),
+			"renew_statements":      render(role.Statements.Renewal),
+		},
+	}
+	resp.AddWarning("No user was created. The username, password and expiration are samples, and plugins may format them differently when creating users.")
+	return resp, nil
+}
+
 func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
@@ -146,6 +265,11 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 			return logical.ErrorResponse("unknown role: %s", name), nil
 		}
 
+		sunsetWarning, err := role.CheckSunset(name, time.Now())
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
 		dbConfig, err := b.DatabaseConfig(ctx, req.Storage, role.DBName)
 		if err != nil {
 			return nil, err
@@ -157,7 +281,7 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 			return nil, fmt.Errorf("%q is not an allowed role", name)
 		}
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: map[string]interface{}{
 				"username":            role.StaticAccount.Username,
 				"password":            role.StaticAccount.Password,
@@ -165,7 +289,23 @@ func (b *databaseBackend) pathStaticCredsRead() framework.OperationFunc {
 				"rotation_period":     role.StaticAccount.RotationPeriod.Seconds(),
 				"last_vault_rotation": role.StaticAccount.LastVaultRotation,
 			},
-		}, nil
+		}
+		if sunsetWarning != "" {
+			resp.AddWarning(sunsetWarning)
+		}
+		if role.OutputTemplate != "" {
+			output, err := role.renderOutput(outputTemplateData{
+				Username: role.StaticAccount.Username,
+				Password: role.StaticAccount.Password,
+				RoleName: name,
+				DBName:   role.DBName,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("unable to render output_template: %w", err)
+			}
+			resp.Data["output"] = output
+		}
+		return resp, nil
 	}
 }
 
@@ -177,6 +317,10 @@ const pathCredsCreateReadHelpDesc = `
 This path reads database credentials for a certain role. The
 database credentials will be generated on demand and will be automatically
 revoked when the lease is up.
+
+With dry_run set, no user is created. Instead, the statements of the role are
+returned rendered with a sample username and password, so that they can be
+reviewed before the role is used.
 `
 
 const pathStaticCredsReadHelpSyn = `
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pathFailover configures a path to pin the connection URL of a connection
+// with failover URLs.
+func pathFailover(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: fmt.Sprintf("failover/%s", framework.GenericNameRegex("name")),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of this database connection",
+			},
+
+			"connection_url": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The connection URL to pin, either connection_url
+				or one of failover_connection_urls. Connection URLs can be
+				given masked, as they are returned when read.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathFailoverRead(),
+			logical.UpdateOperation: b.pathFailoverPin(),
+			logical.DeleteOperation: b.pathFailoverUnpin(),
+		},
+
+		HelpSynopsis:    pathFailoverHelpSyn,
+		HelpDescription: pathFailoverHelpDesc,
+	}
+}
+
+func (b *databaseBackend) pathFailoverRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, err := b.DatabaseConfig(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+
+		connURLs := []string{}
+		for _, connURL := range config.connectionURLs() {
+			connURLs = append(connURLs, maskConnectionURL(connURL))
+		}
+
+		var activeURL string
+		b.RLock()
+		if dbi, ok := b.connections[name]; ok {
+			activeURL = maskConnectionURL(dbi.connectionURL)
+		}
+		b.RUnlock()
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"connection_urls":       connURLs,
+				"active_connection_url": activeURL,
+				"pinned_connection_url": maskConnectionURL(config.PinnedConnectionURL),
+			},
+		}, nil
+	}
+}
+
+func (b *databaseBackend) pathFailoverPin() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, err := b.DatabaseConfig(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(config.FailoverConnectionURLs) == 0 {
+			return logical.ErrorResponse("connection has no failover_connection_urls"), nil
+		}
+
+		pinURL := data.Get("connection_url").(string)
+		if pinURL == "" {
+			return logical.ErrorResponse("missing connection_url"), nil
+		}
+		var found bool
+		for _, connURL := range config.connectionURLs() {
+			if pinURL == connURL || pinURL == maskConnectionURL(connURL) {
+				pinURL, found = connURL, true
+				break
+			}
+		}
+		if !found {
+			return logical.ErrorResponse("connection_url is not one of the connection URLs of the connection"), nil
+		}
+
+		// Only pin healthy connection URLs
+		config.PinnedConnectionURL = pinURL
+		dbi, _, err := b.newConnection(ctx, name, config, []string{pinURL}, true)
+		if err != nil {
+			return logical.ErrorResponse("error pinning connection URL: %s", err), nil
+		}
+
+		b.Lock()
+		defer b.Unlock()
+
+		if err := storeConfig(ctx, req.Storage, name, config); err != nil {
+			dbi.Close()
+			return nil, err
+		}
+
+		b.clearConnection(name)
+		b.connections[name] = dbi
+
+		b.logger.Info("pinned connection URL", "name", name, "connection_url", maskConnectionURL(pinURL))
+		return nil, nil
+	}
+}
+
+func (b *databaseBackend) pathFailoverUnpin() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
+		}
+
+		config, err := b.DatabaseConfig(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if config.PinnedConnectionURL == "" {
+			return nil, nil
+		}
+
+		config.PinnedConnectionURL = ""
+		if err := storeConfig(ctx, req.Storage, name, config); err != nil {
+			return nil, err
+		}
+
+		// The next call selects the first healthy connection URL
+		if err := b.ClearConnection(name); err != nil {
+			return nil, err
+		}
+
+		b.logger.Info("unpinned connection URL", "name", name)
+		return nil, nil
+	}
+}
+
+const pathFailoverHelpSyn = `
+Pin or unpin the connection URL of a database connection.
+`
+
+const pathFailoverHelpDesc = `
+Connections configured with "failover_connection_urls" are established with
+the first of their connection URLs whose database is healthy, starting with
+"connection_url". When an operation fails because the database may be
+unreachable, the next one selects a healthy connection URL again, and
+connections that failed over periodically probe the URLs ahead of theirs to
+fail back once they recover.
+
+Reading this path returns the connection URLs, the one the connection is
+established with and the pinned one. Writing a connection URL to this path
+pins it, which disables the selection and the failover until it is unpinned
+by deleting this path, for example to keep using a disaster recovery replica
+while the primary database is being recovered.
+`
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// failoverDatabase is a database whose connection URLs are files, which are
+// healthy as long as they exist
+type failoverDatabase struct {
+	MockDatabaseV5
+	connURL string
+}
+
+func (f *failoverDatabase) Initialize(ctx context.Context, req v5.InitializeRequest) (v5.InitializeResponse, error) {
+	f.connURL, _ = req.Config["connection_url"].(string)
+	if req.VerifyConnection {
+		if _, err := os.Stat(f.connURL); err != nil {
+			return v5.InitializeResponse{}, err
+		}
+	}
+	return v5.InitializeResponse{Config: req.Config}, nil
+}
+
+func (f *failoverDatabase) NewUser(ctx context.Context, req v5.NewUserRequest) (v5.NewUserResponse, error) {
+	if _, err := os.Stat(f.connURL); err != nil {
+		return v5.NewUserResponse{}, errors.New("connection refused")
+	}
+	return f.MockDatabaseV5.NewUser(ctx, req)
+}
+
+func TestBackend_PluginMain_Failover(t *testing.T) {
+	if os.Getenv(pluginutil.PluginVaultVersionEnv) == "" {
+		return
+	}
+
+	v5.Serve(&failoverDatabase{})
+}
+
+func TestBackend_CloseIfShutdown_Failover(t *testing.T) {
+	b := &databaseBackend{
+		connections: make(map[string]*dbPluginInstance),
+	}
+
+	closed := func(err error) bool {
+		t.Helper()
+		dbi := &dbPluginInstance{
+			database:    databaseVersionWrapper{v5: &MockDatabaseV5{}},
+			id:          "id",
+			name:        "conn",
+			canFailover: true,
+		}
+		b.Lock()
+		b.connections["conn"] = dbi
+		b.Unlock()
+
+		b.CloseIfShutdown(dbi, err)
+
+		// The connection is closed in the background
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			b.RLock()
+			_, ok := b.connections["conn"]
+			b.RUnlock()
+			if !ok {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	if !closed(errors.New("dial tcp 10.0.0.1:5432: connect: connection refused")) {
+		t.Fatal("expected the connection to fail over on a transient error")
+	}
+	if !closed(v5.NewError(v5.ErrorKindTransient, errors.New("unavailable"))) {
+		t.Fatal("expected the connection to fail over on a transient error")
+	}
+	if closed(errors.New(`pq: syntax error at or near "CREAT"`)) {
+		t.Fatal("expected the connection not to fail over on an unclassified error")
+	}
+	if closed(v5.NewError(v5.ErrorKindPermanent, errors.New("role already exists"))) {
+		t.Fatal("expected the connection not to fail over on a permanent error")
+	}
+}
+
+func TestBackend_Failover(t *testing.T) {
+	cluster, sys := getCluster(t)
+	defer cluster.Cleanup()
+	vault.TestAddTestPlugin(t, cluster.Cores[0].Core, "failover-database-plugin", consts.PluginTypeDatabase, "TestBackend_PluginMain_Failover", []string{}, "")
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = sys
+	lb, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*databaseBackend)
+	defer b.Cleanup(context.Background())
+
+	dir, err := ioutil.TempDir("", "failover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	primary := filepath.Join(dir, "primary")
+	dr := filepath.Join(dir, "dr")
+	setHealthy := func(connURL string, healthy bool) {
+		t.Helper()
+		if !healthy {
+			if err := os.Remove(connURL); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+		if err := ioutil.WriteFile(connURL, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setHealthy(primary, true)
+	setHealthy(dr, true)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	// waitActive issues credentials until the connection is established with
+	// the expected URL, as connections are closed and failed back in the
+	// background
+	waitActive := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			request(logical.ReadOperation, "creds/test", nil)
+			resp := handle(logical.ReadOperation, "failover/db", nil)
+			if resp.Data["active_connection_url"] == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected active connection URL %q, got %#v", expected, resp.Data)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	expireProbe := func() {
+		b.RLock()
+		defer b.RUnlock()
+		dbi := b.connections["db"]
+		dbi.probeLock.Lock()
+		dbi.lastProbe = time.Time{}
+		dbi.probeLock.Unlock()
+	}
+
+	// A connection URL is required
+	resp, err := request(logical.UpdateOperation, "config/db", map[string]interface{}{
+		"plugin_name":              "failover-database-plugin",
+		"failover_connection_urls": dr,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+
+	// The first healthy connection URL is selected
+	setHealthy(primary, false)
+	handle(logical.UpdateOperation, "config/db", map[string]interface{}{
+		"plugin_name":              "failover-database-plugin",
+		"connection_url":           primary,
+		"failover_connection_urls": dr,
+		"allowed_roles":            "*",
+	})
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"db_name":             "db",
+		"creation_statements": "CREATE USER {{name}}",
+	})
+	resp = handle(logical.ReadOperation, "failover/db", nil)
+	if resp.Data["active_connection_url"] != dr || len(resp.Data["connection_urls"].([]string)) != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = handle(logical.ReadOperation, "config/db", nil)
+	if resp.Data["connection_details"].(map[string]interface{})["connection_url"] != primary {
+		t.Fatalf("primary connection URL was not stored: %#v", resp.Data)
+	}
+
+	// The connection fails back once the primary is healthy again
+	setHealthy(primary, true)
+	expireProbe()
+	waitActive(primary)
+
+	// The connection fails over when the database is unreachable
+	setHealthy(primary, false)
+	if resp, err := request(logical.ReadOperation, "creds/test", nil); err == nil && !resp.IsError() {
+		t.Fatalf("expected error")
+	}
+	waitActive(dr)
+
+	// Unhealthy or unknown connection URLs can't be pinned
+	resp, err = request(logical.UpdateOperation, "failover/db", map[string]interface{}{"connection_url": primary})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+	resp, err = request(logical.UpdateOperation, "failover/db", map[string]interface{}{"connection_url": filepath.Join(dir, "other")})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+
+	// Pinned connection URLs don't fail back
+	handle(logical.UpdateOperation, "failover/db", map[string]interface{}{"connection_url": dr})
+	setHealthy(primary, true)
+	expireProbe()
+	handle(logical.ReadOperation, "creds/test", nil)
+	time.Sleep(500 * time.Millisecond)
+	resp = handle(logical.ReadOperation, "failover/db", nil)
+	if resp.Data["active_connection_url"] != dr || resp.Data["pinned_connection_url"] != dr {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Unpinning selects the first healthy connection URL again
+	handle(logical.DeleteOperation, "failover/db", nil)
+	waitActive(primary)
+	if resp := handle(logical.ReadOperation, "failover/db", nil); resp.Data["pinned_connection_url"] != "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Removing the pinned connection URL unpins it
+	handle(logical.UpdateOperation, "failover/db", map[string]interface{}{"connection_url": dr})
+	resp = handle(logical.UpdateOperation, "config/db", map[string]interface{}{
+		"failover_connection_urls": filepath.Join(dir, "other"),
+	})
+	if len(resp.Warnings) == 0 {
+		t.Fatalf("expected warning")
+	}
+	resp = handle(logical.ReadOperation, "failover/db", nil)
+	if resp.Data["active_connection_url"] != primary || resp.Data["pinned_connection_url"] != "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
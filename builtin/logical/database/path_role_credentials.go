@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const databaseCredentialPath = "credential/"
+
+const (
+	// credentialTidyInterval is the time between two runs of the tidy of the
+	// credential entries
+	credentialTidyInterval = time.Hour
+
+	// credentialTidyBuffer is how long after their expiration the entries of
+	// the credentials whose lease was not revoked by the backend are deleted,
+	// leaving time for the revocation of expired leases to be retried.
+	credentialTidyBuffer = time.Hour
+)
+
+// credentialEntry tracks a dynamic credential issued for a role so operators
+// can correlate database users with the Vault leases that own them.
+type credentialEntry struct {
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	DBName     string    `json:"db_name"`
+	IssueTime  time.Time `json:"issue_time"`
+	Expiration time.Time `json:"expiration"`
+}
+
+func pathRoleCredentials(b *databaseBackend) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "roles/" + framework.GenericNameRegex("name") + "/credentials/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: "Name of the role.",
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.pathRoleCredentialsList,
+			},
+
+			HelpSynopsis:    pathRoleCredentialsHelpSyn,
+			HelpDescription: pathRoleCredentialsHelpDesc,
+		},
+	}
+}
+
+func (b *databaseBackend) pathRoleCredentialsList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	// Credentials outlive the role they were issued from, so this is not
+	// restricted to roles that still exist
+	keys, err := req.Storage.List(ctx, databaseCredentialPath+name+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, 0, len(keys))
+	keyInfo := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		entry, err := b.credentialEntryAtKey(ctx, req.Storage, databaseCredentialPath+name+"/"+key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		info := map[string]interface{}{
+			"username":   entry.Username,
+			"db_name":    entry.DBName,
+			"issue_time": entry.IssueTime,
+			"expiration": entry.Expiration,
+		}
+		usernames = append(usernames, entry.Username)
+		keyInfo[entry.Username] = info
+	}
+
+	return logical.ListResponseWithInfo(usernames, keyInfo), nil
+}
+
+// credentialKey returns the storage key of the entry of a credential. Entries
+// are keyed by the role and username of the credential, which the lease keeps
+// in its internal data, so that they can be found when the lease is renewed or
+// revoked.
+func credentialKey(roleName, username string) string {
+	return databaseCredentialPath + roleName + "/" + url.PathEscape(username)
+}
+
+func (b *databaseBackend) credentialEntry(ctx context.Context, s logical.Storage, roleName, username string) (*credentialEntry, error) {
+	return b.credentialEntryAtKey(ctx, s, credentialKey(roleName, username))
+}
+
+func (b *databaseBackend) credentialEntryAtKey(ctx context.Context, s logical.Storage, key string) (*credentialEntry, error) {
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential entry: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var cred credentialEntry
+	if err := entry.DecodeJSON(&cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (b *databaseBackend) storeCredentialEntry(ctx context.Context, s logical.Storage, cred *credentialEntry) error {
+	entry, err := logical.StorageEntryJSON(credentialKey(cred.Role, cred.Username), cred)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// updateCredentialExpiration records the new expiration of a renewed
+// credential. Leases issued before credentials were tracked are ignored.
+func (b *databaseBackend) updateCredentialExpiration(ctx context.Context, s logical.Storage, roleName, username string, expiration time.Time) error {
+	cred, err := b.credentialEntry(ctx, s, roleName, username)
+	if err != nil {
+		return err
+	}
+	if cred == nil {
+		return nil
+	}
+	cred.Expiration = expiration
+	return b.storeCredentialEntry(ctx, s, cred)
+}
+
+func (b *databaseBackend) deleteCredentialEntry(ctx context.Context, s logical.Storage, roleName, username string) error {
+	return s.Delete(ctx, credentialKey(roleName, username))
+}
+
+// tidyCredentials deletes the entries of the credentials that expired more
+// than credentialTidyBuffer ago. The entries of the credentials are deleted
+// when their lease is revoked, so these are left behind by leases that were
+// force revoked or deleted without calling the backend.
+func (b *databaseBackend) tidyCredentials(ctx context.Context, req *logical.Request) error {
+	s := req.Storage
+	roles, err := s.List(ctx, databaseCredentialPath)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-credentialTidyBuffer)
+	for _, role := range roles {
+		prefix := databaseCredentialPath + role
+		keys, err := s.List(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			cred, err := b.credentialEntryAtKey(ctx, s, prefix+key)
+			if err != nil {
+				return err
+			}
+			if cred == nil || cred.Expiration.After(cutoff) {
+				continue
+			}
+			b.Logger().Debug("deleting orphaned credential entry", "role", cred.Role, "username", cred.Username)
+			if err := s.Delete(ctx, prefix+key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const pathRoleCredentialsHelpSyn = `
+List the active credentials issued for a role.
+`
+
+const pathRoleCredentialsHelpDesc = `
+This path lists the dynamic credentials that are currently outstanding for a
+role, keyed by their generated database username. Each entry reports the time
+the credential was issued and when it is expected to expire. The issue
+time closely matches the one reported for the lease by "sys/leases/lookup", which
+allows correlating database sessions with the Vault lease that owns them.
+`
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestBackend_RoleCredentials_List(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	creds := map[string]*credentialEntry{
+		"v-token-web-1":   {Username: "v-token-web-1", Role: "web", DBName: "pg", IssueTime: now, Expiration: now.Add(time.Hour)},
+		"v-token/web-2":   {Username: "v-token/web-2", Role: "web", DBName: "pg", IssueTime: now, Expiration: now.Add(time.Hour)},
+		"v-token-batch-1": {Username: "v-token-batch-1", Role: "batch", DBName: "pg", IssueTime: now, Expiration: now.Add(time.Hour)},
+	}
+	for _, cred := range creds {
+		if err := b.storeCredentialEntry(context.Background(), config.StorageView, cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "roles/web/credentials",
+		Storage:   config.StorageView,
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	// The credentials are keyed by username
+	keys := resp.Data["keys"].([]string)
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 credentials, got %v", keys)
+	}
+	keyInfo := resp.Data["key_info"].(map[string]interface{})
+	for _, username := range keys {
+		info := keyInfo[username].(map[string]interface{})
+		if creds[username] == nil || info["username"] != username {
+			t.Fatalf("bad credential %q: %#v", username, info)
+		}
+	}
+
+	// Revocation removes the entry from the listing
+	if err := b.deleteCredentialEntry(context.Background(), config.StorageView, "web", "v-token-web-1"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	keys = resp.Data["keys"].([]string)
+	if len(keys) != 1 || keys[0] != "v-token/web-2" {
+		t.Fatalf("expected only v-token/web-2, got %v", keys)
+	}
+}
+
+func TestBackend_RoleCredentials_Tidy(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	creds := []*credentialEntry{
+		{Username: "v-token-web-1", Role: "web", DBName: "pg", IssueTime: now, Expiration: now.Add(time.Hour)},
+		// Expired, but its revocation may still be retried
+		{Username: "v-token-web-2", Role: "web", DBName: "pg", IssueTime: now, Expiration: now.Add(-time.Minute)},
+		// Orphaned by a lease that was not revoked by the backend
+		{Username: "v-token-web-3", Role: "web", DBName: "pg", IssueTime: now, Expiration: now.Add(-credentialTidyBuffer - time.Minute)},
+		{Username: "v-token-gone-1", Role: "gone", DBName: "pg", IssueTime: now, Expiration: now.Add(-credentialTidyBuffer - time.Minute)},
+	}
+	for _, cred := range creds {
+		if err := b.storeCredentialEntry(context.Background(), config.StorageView, cred); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := b.tidyCredentials(context.Background(), &logical.Request{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, cred := range creds {
+		entry, err := b.credentialEntry(context.Background(), config.StorageView, cred.Role, cred.Username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept := i < 2; kept != (entry != nil) {
+			t.Fatalf("expected the entry of %q to be kept: %t", cred.Username, kept)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"time"
 
 	v4 "github.com/hashicorp/vault/sdk/database/dbplugin"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/helper/sunsetutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/queue"
 )
@@ -87,6 +89,12 @@ func fieldsForType(roleType string) map[string]*framework.FieldSchema {
 			Type:        framework.TypeString,
 			Description: "Name of the database this role acts on.",
 		},
+		"output_template": {
+			Type: framework.TypeString,
+			Description: `Template rendering the credentials into the "output"
+	field of credential responses, such as a connection string. The fields
+	.Username, .Password, .RoleName and .DBName are available.`,
+		},
 	}
 
 	// Get the fields that are specific to the type of role, and add them to the
@@ -103,6 +111,8 @@ func fieldsForType(roleType string) map[string]*framework.FieldSchema {
 		fields[k] = v
 	}
 
+	sunsetutil.AddSunsetFields(fields)
+
 	return fields
 }
 
@@ -122,7 +132,9 @@ func dynamicFields() map[string]*framework.FieldSchema {
 			Type: framework.TypeStringSlice,
 			Description: `Specifies the database statements executed to
 	create and configure a user. See the plugin's API page for more
-	information on support and formatting for this parameter.`,
+	information on support and formatting for this parameter. Identity
+	templates, such as {{identity.entity.name}}, are replaced with the
+	values of the entity requesting the credentials.`,
 		},
 		"revocation_statements": {
 			Type: framework.TypeStringSlice,
@@ -245,6 +257,10 @@ func (b *databaseBackend) pathStaticRoleRead(ctx context.Context, req *logical.R
 	if len(role.Statements.Rotation) == 0 {
 		data["rotation_statements"] = []string{}
 	}
+	if role.OutputTemplate != "" {
+		data["output_template"] = role.OutputTemplate
+	}
+	role.PopulateSunsetData(data)
 
 	return &logical.Response{
 		Data: data,
@@ -281,6 +297,10 @@ func (b *databaseBackend) pathRoleRead(ctx context.Context, req *logical.Request
 	if len(role.Statements.Renewal) == 0 {
 		data["renew_statements"] = []string{}
 	}
+	if role.OutputTemplate != "" {
+		data["output_template"] = role.OutputTemplate
+	}
+	role.PopulateSunsetData(data)
 
 	return &logical.Response{
 		Data: data,
@@ -385,6 +405,14 @@ func (b *databaseBackend) pathRoleCreateUpdate(ctx context.Context, req *logical
 		}
 	}
 
+	if err := role.parseOutputTemplate(data); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := role.ParseSunsetFields(req, data); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	// Store it
 	entry, err := logical.StorageEntryJSON(databaseRolePath+name, role)
 	if err != nil {
@@ -477,6 +505,14 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 		role.Statements.Rotation = data.Get("rotation_statements").([]string)
 	}
 
+	if err := role.parseOutputTemplate(data); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := role.ParseSunsetFields(req, data); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	// lvr represents the roles' LastVaultRotation
 	lvr := role.StaticAccount.LastVaultRotation
 
@@ -527,6 +563,55 @@ type roleEntry struct {
 	DefaultTTL    time.Duration  `json:"default_ttl"`
 	MaxTTL        time.Duration  `json:"max_ttl"`
 	StaticAccount *staticAccount `json:"static_account" mapstructure:"static_account"`
+
+	// OutputTemplate renders the credentials into the output field of
+	// credential responses. Empty if the role has no output template.
+	OutputTemplate string `json:"output_template,omitempty"`
+
+	sunsetutil.SunsetParams
+}
+
+// outputTemplateData is the data available to the output templates of roles
+type outputTemplateData struct {
+	Username string
+	Password string
+	RoleName string
+	DBName   string
+}
+
+// parseOutputTemplate sets the output template of the role if one is given,
+// checking that it renders. An empty template removes it.
+func (r *roleEntry) parseOutputTemplate(d *framework.FieldData) error {
+	rawTemplate, ok := d.GetOk("output_template")
+	if !ok {
+		return nil
+	}
+	r.OutputTemplate = rawTemplate.(string)
+	if r.OutputTemplate == "" {
+		return nil
+	}
+
+	// Render with placeholder values so that templates referencing unknown
+	// fields are rejected now rather than when credentials are issued
+	if _, err := r.renderOutput(outputTemplateData{
+		Username: "username",
+		Password: "password",
+		RoleName: "role",
+		DBName:   r.DBName,
+	}); err != nil {
+		return fmt.Errorf("invalid output_template: %w", err)
+	}
+	return nil
+}
+
+// renderOutput renders the output template of the role with the given
+// credentials
+func (r *roleEntry) renderOutput(data outputTemplateData) (string, error) {
+	tmpl, err := template.NewTemplate(template.Template(r.OutputTemplate))
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Generate(data)
 }
 
 type staticAccount struct {
@@ -3,13 +3,16 @@ package database
 import (
 	"context"
 	"errors"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/go-test/deep"
 	"github.com/hashicorp/vault/helper/namespace"
 	postgreshelper "github.com/hashicorp/vault/helper/testhelpers/postgresql"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
 )
 
 var dataKeys = []string{"username", "password", "last_vault_rotation", "rotation_period"}
@@ -377,6 +380,273 @@ func TestBackend_StaticRole_Updates(t *testing.T) {
 	}
 }
 
+func TestBackend_Role_Sunset(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	sunset := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	req := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/sunset",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"db_name":               "plugin-test",
+			"creation_statements":   testRole,
+			"sunset":                sunset,
+			"sunset_warning_period": "1h",
+		},
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	req.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	if resp.Data["sunset"] != sunset {
+		t.Fatalf("expected sunset %q, got %v", sunset, resp.Data["sunset"])
+	}
+	if resp.Data["sunset_warning_period"] != int64(3600) {
+		t.Fatalf("expected sunset_warning_period 3600, got %v", resp.Data["sunset_warning_period"])
+	}
+
+	// Credentials are denied before the database is even looked up
+	resp, err = b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/sunset",
+		Storage:   config.StorageView,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a sunset role, got: %#v", resp)
+	}
+
+	// An invalid timestamp is rejected
+	req.Operation = logical.UpdateOperation
+	req.Data = map[string]interface{}{
+		"sunset": "tomorrow",
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an invalid sunset, got: %#v", resp)
+	}
+}
+
+func TestBackend_Role_OutputTemplate(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	db := &mockNewDatabase{}
+	db.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{Username: "v-token-app-1"}, nil)
+	b.connections["mockdb"] = &dbPluginInstance{
+		database: databaseVersionWrapper{v5: db},
+		name:     "mockdb",
+	}
+	if err := storeConfig(context.Background(), config.StorageView, "mockdb", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputTemplate := `postgres://{{ .Username }}:{{ .Password | urlquery }}@db.example.com:5432/{{ .DBName }}`
+	req := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/app",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"db_name":             "mockdb",
+			"creation_statements": testRole,
+			"output_template":     outputTemplate,
+		},
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	req.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	if resp.Data["output_template"] != outputTemplate {
+		t.Fatalf("expected output_template %q, got %v", outputTemplate, resp.Data["output_template"])
+	}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/app",
+		Storage:   config.StorageView,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	expected := "postgres://v-token-app-1:" + url.QueryEscape(resp.Data["password"].(string)) + "@db.example.com:5432/mockdb"
+	if resp.Data["output"] != expected {
+		t.Fatalf("expected output %q, got %v", expected, resp.Data["output"])
+	}
+
+	// Static roles render the current credentials
+	staticRole := &roleEntry{
+		DBName:         "mockdb",
+		OutputTemplate: `{{ .RoleName }} {{ .Username }} {{ .Password }}`,
+		StaticAccount: &staticAccount{
+			Username:       "app",
+			Password:       "secret",
+			RotationPeriod: time.Hour,
+		},
+	}
+	entry, err := logical.StorageEntryJSON(databaseStaticRolePath+"static", staticRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.StorageView.Put(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "static-creds/static",
+		Storage:   config.StorageView,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	if resp.Data["output"] != "static app secret" {
+		t.Fatalf("expected output %q, got %v", "static app secret", resp.Data["output"])
+	}
+
+	// Templates referencing unknown fields are rejected
+	for _, tmpl := range []string{"{{ .Unknown }}", "{{ .Username "} {
+		req.Operation = logical.UpdateOperation
+		req.Data = map[string]interface{}{
+			"output_template": tmpl,
+		}
+		resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error for output_template %q, got: %#v", tmpl, resp)
+		}
+	}
+
+	// An empty template removes it
+	req.Data = map[string]interface{}{
+		"output_template": "",
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	role, err := b.Role(context.Background(), config.StorageView, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.OutputTemplate != "" {
+		t.Fatalf("expected output_template to be removed, got %q", role.OutputTemplate)
+	}
+}
+
+func TestBackend_Creds_DryRun(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	db := &mockNewDatabase{}
+	b.connections["mockdb"] = &dbPluginInstance{
+		database: databaseVersionWrapper{v5: db},
+		name:     "mockdb",
+	}
+	if err := storeConfig(context.Background(), config.StorageView, "mockdb", &DatabaseConfig{
+		AllowedRoles: []string{"*"},
+		ConnectionDetails: map[string]interface{}{
+			"username_template": "{{ .RoleName }}-sample",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/app",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"db_name":               "mockdb",
+			"creation_statements":   `CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}';`,
+			"revocation_statements": `DROP ROLE "{{name}}";`,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/app",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"dry_run": true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	if resp.Secret != nil {
+		t.Fatalf("expected no lease, got: %#v", resp.Secret)
+	}
+	if resp.Data["username"] != "app-sample" {
+		t.Fatalf("expected username %q, got %v", "app-sample", resp.Data["username"])
+	}
+
+	expiration, err := time.Parse(time.RFC3339, resp.Data["expiration"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`CREATE ROLE "app-sample" WITH LOGIN PASSWORD '` + resp.Data["password"].(string) +
+			`' VALID UNTIL '` + expiration.Format(dryRunExpirationFormat) + `';`,
+	}
+	if diff := deep.Equal(resp.Data["creation_statements"], expected); diff != nil {
+		t.Fatal(diff)
+	}
+	if diff := deep.Equal(resp.Data["revocation_statements"], []string{`DROP ROLE "app-sample";`}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// No user is created and no credential is tracked
+	db.AssertNotCalled(t, "NewUser", mock.Anything, mock.Anything)
+	entries, err := config.StorageView.List(context.Background(), databaseCredentialPath+"app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no credential entries, got %v", entries)
+	}
+}
+
 func TestBackend_StaticRole_Role_name_check(t *testing.T) {
 	cluster, sys := getCluster(t)
 	defer cluster.Cleanup()
@@ -63,86 +63,130 @@ func (b *databaseBackend) pathRotateRootCredentialsUpdate() framework.OperationF
 			return logical.ErrorResponse(respErrEmptyName), nil
 		}
 
-		config, err := b.DatabaseConfig(ctx, req.Storage, name)
-		if err != nil {
+		if _, err := b.DatabaseConfig(ctx, req.Storage, name); err != nil {
 			return nil, err
 		}
 
-		rootUsername, ok := config.ConnectionDetails["username"].(string)
-		if !ok || rootUsername == "" {
-			return nil, fmt.Errorf("unable to rotate root credentials: no username in configuration")
+		err := b.rotateRootCredentials(ctx, req.Storage, name)
+		if recordErr := b.RotationManager.Record(ctx, req.Storage, rotationRoot+"/"+name, err); recordErr != nil {
+			b.Logger().Warn("failed to record the rotation of the root credentials", "error", recordErr, "name", name)
 		}
+		return nil, err
+	}
+}
 
-		dbi, err := b.GetConnection(ctx, req.Storage, name)
-		if err != nil {
-			return nil, err
+// rotateRootCredentials replaces the password of the user configured for the
+// named connection with a new one, or its key pair if the connection
+// authenticates with a PEM encoded RSA private key.
+func (b *databaseBackend) rotateRootCredentials(ctx context.Context, s logical.Storage, name string) error {
+	config, err := b.DatabaseConfig(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	rootUsername, ok := config.ConnectionDetails["username"].(string)
+	if !ok || rootUsername == "" {
+		return fmt.Errorf("unable to rotate root credentials: no username in configuration")
+	}
+
+	dbi, err := b.GetConnection(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Close the plugin
+		dbi.closed = true
+		if err := dbi.database.Close(); err != nil {
+			b.Logger().Error("error closing the database plugin connection", "err", err)
 		}
+		// Even on error, still remove the connection
+		delete(b.connections, name)
+	}()
 
-		defer func() {
-			// Close the plugin
-			dbi.closed = true
-			if err := dbi.database.Close(); err != nil {
-				b.Logger().Error("error closing the database plugin connection", "err", err)
-			}
-			// Even on error, still remove the connection
-			delete(b.connections, name)
-		}()
+	// Take out the backend lock since we are swapping out the connection
+	b.Lock()
+	defer b.Unlock()
 
-		// Take out the backend lock since we are swapping out the connection
-		b.Lock()
-		defer b.Unlock()
+	// Take the write lock on the instance
+	dbi.Lock()
+	defer dbi.Unlock()
 
-		// Take the write lock on the instance
-		dbi.Lock()
-		defer dbi.Unlock()
+	// Generate new credentials
+	wal := &rotateRootCredentialsWAL{
+		ConnectionName: name,
+		UserName:       rootUsername,
+	}
+	updateReq := v5.UpdateUserRequest{
+		Username: rootUsername,
+	}
+	if hasRootKeyPair(config) {
+		newPrivateKey, newPublicKey, err := generateKeyPair()
+		if err != nil {
+			return err
+		}
+		wal.OldPrivateKey = config.ConnectionDetails["private_key"].(string)
+		wal.NewPrivateKey = newPrivateKey
+		config.ConnectionDetails["private_key"] = newPrivateKey
 
-		// Generate new credentials
+		// The root rotation statements change passwords, the plugin's
+		// default statements are used
+		updateReq.PublicKey = &v5.ChangePublicKey{
+			NewPublicKey: newPublicKey,
+		}
+	} else {
 		oldPassword := config.ConnectionDetails["password"].(string)
 		newPassword, err := dbi.database.GeneratePassword(ctx, b.System(), config.PasswordPolicy)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		wal.OldPassword = oldPassword
+		wal.NewPassword = newPassword
 		config.ConnectionDetails["password"] = newPassword
 
-		// Write a WAL entry
-		walID, err := framework.PutWAL(ctx, req.Storage, rotateRootWALKey, &rotateRootCredentialsWAL{
-			ConnectionName: name,
-			UserName:       rootUsername,
-			OldPassword:    oldPassword,
-			NewPassword:    newPassword,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		updateReq := v5.UpdateUserRequest{
-			Username: rootUsername,
-			Password: &v5.ChangePassword{
-				NewPassword: newPassword,
-				Statements: v5.Statements{
-					Commands: config.RootCredentialsRotateStatements,
-				},
+		updateReq.Password = &v5.ChangePassword{
+			NewPassword: newPassword,
+			Statements: v5.Statements{
+				Commands: config.RootCredentialsRotateStatements,
 			},
 		}
-		newConfigDetails, err := dbi.database.UpdateUser(ctx, updateReq, true)
-		if err != nil {
-			return nil, fmt.Errorf("failed to update user: %w", err)
-		}
-		if newConfigDetails != nil {
-			config.ConnectionDetails = newConfigDetails
-		}
+	}
 
-		err = storeConfig(ctx, req.Storage, name, config)
-		if err != nil {
-			return nil, err
-		}
+	// Write a WAL entry
+	walID, err := framework.PutWAL(ctx, s, rotateRootWALKey, wal)
+	if err != nil {
+		return err
+	}
+	newConfigDetails, err := dbi.database.UpdateUser(ctx, updateReq, true)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if newConfigDetails != nil {
+		config.setConnectionDetails(newConfigDetails)
+	}
 
-		err = framework.DeleteWAL(ctx, req.Storage, walID)
-		if err != nil {
-			b.Logger().Warn("unable to delete WAL", "error", err, "WAL ID", walID)
-		}
-		return nil, nil
+	err = storeConfig(ctx, s, name, config)
+	if err != nil {
+		return err
+	}
+
+	err = framework.DeleteWAL(ctx, s, walID)
+	if err != nil {
+		b.Logger().Warn("unable to delete WAL", "error", err, "WAL ID", walID)
+	}
+	return nil
+}
+
+// hasRootKeyPair returns whether the connection authenticates with a PEM
+// encoded RSA private key, whose key pair is rotated instead of the password.
+// Other plugins may use a "private_key" of their own, such as an API key.
+func hasRootKeyPair(config *DatabaseConfig) bool {
+	privateKey, ok := config.ConnectionDetails["private_key"].(string)
+	if !ok || privateKey == "" {
+		return false
 	}
+	_, err := publicKeyFromPrivateKey(privateKey)
+	return err == nil
 }
 
 func (b *databaseBackend) pathRotateRoleCredentialsUpdate() framework.OperationFunc {
@@ -20,6 +20,11 @@ type rotateRootCredentialsWAL struct {
 	UserName       string
 	NewPassword    string
 	OldPassword    string
+
+	// The private keys are set instead of the passwords when the key pair
+	// of the connection is rotated
+	NewPrivateKey string
+	OldPrivateKey string
 }
 
 // walRollback handles WAL entries that result from partial failures
@@ -43,10 +48,15 @@ func (b *databaseBackend) walRollback(ctx context.Context, req *logical.Request,
 		return err
 	}
 
+	stored, rotated := config.ConnectionDetails["password"], entry.NewPassword
+	if entry.NewPrivateKey != "" {
+		stored, rotated = config.ConnectionDetails["private_key"], entry.NewPrivateKey
+	}
+
 	// The password in storage doesn't match the new password
 	// in the WAL entry. This means there was a partial failure
 	// to update either the database or storage.
-	if config.ConnectionDetails["password"] != entry.NewPassword {
+	if stored != rotated {
 		// Clear any cached connection to inform the rollback decision
 		err := b.ClearConnection(entry.ConnectionName)
 		if err != nil {
@@ -74,11 +84,33 @@ func (b *databaseBackend) walRollback(ctx context.Context, req *logical.Request,
 
 // rollbackDatabaseCredentials rolls back root database credentials for
 // the connection associated with the passed WAL entry. It will create
-// a connection to the database using the WAL entry new password in
-// order to alter the password to be the WAL entry old password.
+// a connection to the database using the WAL entry new password, or
+// private key, in order to alter the password to be the WAL entry old
+// password, or the public key to be that of the old private key.
 func (b *databaseBackend) rollbackDatabaseCredentials(ctx context.Context, config *DatabaseConfig, entry rotateRootCredentialsWAL) error {
-	// Attempt to get a connection with the WAL entry new password.
-	config.ConnectionDetails["password"] = entry.NewPassword
+	updateReq := v5.UpdateUserRequest{
+		Username: entry.UserName,
+	}
+	if entry.NewPrivateKey != "" {
+		oldPublicKey, err := publicKeyFromPrivateKey(entry.OldPrivateKey)
+		if err != nil {
+			return err
+		}
+		config.ConnectionDetails["private_key"] = entry.NewPrivateKey
+		updateReq.PublicKey = &v5.ChangePublicKey{
+			NewPublicKey: oldPublicKey,
+		}
+	} else {
+		config.ConnectionDetails["password"] = entry.NewPassword
+		updateReq.Password = &v5.ChangePassword{
+			NewPassword: entry.OldPassword,
+			Statements: v5.Statements{
+				Commands: config.RootCredentialsRotateStatements,
+			},
+		}
+	}
+
+	// Attempt to get a connection with the WAL entry new credentials.
 	dbi, err := b.GetConnectionWithConfig(ctx, entry.ConnectionName, config)
 	if err != nil {
 		return err
@@ -91,16 +123,6 @@ func (b *databaseBackend) rollbackDatabaseCredentials(ctx context.Context, confi
 		}
 	}()
 
-	updateReq := v5.UpdateUserRequest{
-		Username: entry.UserName,
-		Password: &v5.ChangePassword{
-			NewPassword: entry.OldPassword,
-			Statements: v5.Statements{
-				Commands: config.RootCredentialsRotateStatements,
-			},
-		},
-	}
-
 	// It actually is the root user here, but we only want to use SetCredentials since
 	// RotateRootCredentials doesn't give any control over what password is used
 	_, err = dbi.database.UpdateUser(ctx, updateReq, false)
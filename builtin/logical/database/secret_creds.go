@@ -77,7 +77,11 @@ func (b *databaseBackend) secretCredsRenew() framework.OperationFunc {
 			_, err := dbi.database.UpdateUser(ctx, updateReq, false)
 			if err != nil {
 				b.CloseIfShutdown(dbi, err)
-				return nil, err
+				return pluginErrorResponse(err)
+			}
+
+			if err := b.updateCredentialExpiration(ctx, req.Storage, roleNameRaw.(string), username, expireTime); err != nil {
+				b.Logger().Warn("failed to update credential entry", "role", roleNameRaw, "error", err)
 			}
 		}
 		resp := &logical.Response{Secret: req.Secret}
@@ -155,8 +159,16 @@ func (b *databaseBackend) secretCredsRevoke() framework.OperationFunc {
 			},
 		}
 		_, err = dbi.database.DeleteUser(ctx, deleteReq)
-		if err != nil {
+		switch {
+		case v5.ErrorKindOf(err) == v5.ErrorKindNotFound:
+			// The user is already gone, so the lease can be revoked
+			b.Logger().Debug("user to revoke does not exist", "username", username, "error", err)
+		case err != nil:
 			b.CloseIfShutdown(dbi, err)
+			return pluginErrorResponse(err)
+		}
+
+		if err := b.deleteCredentialEntry(ctx, req.Storage, roleNameRaw.(string), username); err != nil {
 			return nil, err
 		}
 		return resp, nil
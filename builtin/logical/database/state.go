@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/queue"
+)
+
+// stateFormat identifies the layout of the exported state: the connections,
+// roles and static roles keyed by their name, whose data is their storage
+// entry.
+const stateFormat = "database/1"
+
+const (
+	stateKindConnection = "connection"
+	stateKindRole       = "role"
+	stateKindStaticRole = "static-role"
+)
+
+func encodeStateData(v interface{}) (map[string]interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := jsonutil.DecodeJSON(buf, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeStateData(data map[string]interface{}, out interface{}) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return jsonutil.DecodeJSON(buf, out)
+}
+
+// exportState returns the connections, roles and static roles of the
+// backend. The static roles keep their password and last rotation time.
+func (b *databaseBackend) exportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	state := &logical.BackendState{Format: stateFormat}
+
+	connections, err := s.List(ctx, databaseConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range connections {
+		config, err := b.DatabaseConfig(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encodeStateData(config)
+		if err != nil {
+			return nil, err
+		}
+		state.Entries = append(state.Entries, &logical.StateEntry{
+			Kind: stateKindConnection,
+			Key:  name,
+			Data: data,
+		})
+	}
+
+	for _, kind := range []struct{ name, prefix string }{
+		{stateKindRole, databaseRolePath},
+		{stateKindStaticRole, databaseStaticRolePath},
+	} {
+		roles, err := s.List(ctx, kind.prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range roles {
+			role, err := b.roleAtPath(ctx, s, name, kind.prefix)
+			if err != nil {
+				return nil, err
+			}
+			if role == nil {
+				continue
+			}
+			data, err := encodeStateData(role)
+			if err != nil {
+				return nil, err
+			}
+			state.Entries = append(state.Entries, &logical.StateEntry{
+				Kind: kind.name,
+				Key:  name,
+				Data: data,
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// importState writes the connections, roles and static roles, replacing the
+// existing ones with the same name. The connections are not verified, and the
+// static roles are scheduled for rotation from their last rotation time.
+func (b *databaseBackend) importState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	if state.Format != stateFormat && !(state.Format == "" && len(state.Entries) == 0) {
+		return fmt.Errorf("unsupported state format %q", state.Format)
+	}
+
+	for _, e := range state.Entries {
+		var err error
+		switch e.Kind {
+		case stateKindConnection:
+			err = b.importConnection(ctx, s, e)
+		case stateKindRole:
+			err = b.importRole(ctx, s, e, databaseRolePath, databaseStaticRolePath)
+		case stateKindStaticRole:
+			err = b.importRole(ctx, s, e, databaseStaticRolePath, databaseRolePath)
+		default:
+			err = fmt.Errorf("unsupported state entry kind %q", e.Kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *databaseBackend) importConnection(ctx context.Context, s logical.Storage, e *logical.StateEntry) error {
+	var config DatabaseConfig
+	if err := decodeStateData(e.Data, &config); err != nil {
+		return fmt.Errorf("failed to decode connection %q: %w", e.Key, err)
+	}
+	if config.PluginName == "" {
+		return fmt.Errorf("connection %q has no plugin name", e.Key)
+	}
+
+	entry, err := logical.StorageEntryJSON(databaseConfigPath+e.Key, &config)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	// The next request connects with the imported configuration
+	return b.ClearConnection(e.Key)
+}
+
+// importRole writes the role at prefix, failing if a role of the other kind,
+// whose roles are at otherPrefix, has the same name.
+func (b *databaseBackend) importRole(ctx context.Context, s logical.Storage, e *logical.StateEntry, prefix, otherPrefix string) error {
+	var role roleEntry
+	if err := decodeStateData(e.Data, &role); err != nil {
+		return fmt.Errorf("failed to decode %s %q: %w", e.Kind, e.Key, err)
+	}
+	static := prefix == databaseStaticRolePath
+	if static != (role.StaticAccount != nil) {
+		return fmt.Errorf("%s %q has a bad static account", e.Kind, e.Key)
+	}
+
+	lock := locksutil.LockForKey(b.roleLocks, e.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	other, err := s.Get(ctx, otherPrefix+e.Key)
+	if err != nil {
+		return err
+	}
+	if other != nil {
+		return fmt.Errorf("role and static role names must be unique: %q", e.Key)
+	}
+
+	entry, err := logical.StorageEntryJSON(prefix+e.Key, &role)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	if !static {
+		return nil
+	}
+	_, _ = b.popFromRotationQueueByKey(e.Key)
+	return b.pushItem(&queue.Item{
+		Key:      e.Key,
+		Priority: role.StaticAccount.NextRotationTime().Unix(),
+	})
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/queue"
+)
+
+func TestBackend_State(t *testing.T) {
+	ctx := context.Background()
+	newBackend := func() (*databaseBackend, logical.Storage) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b := Backend(config)
+		if err := b.Setup(ctx, config); err != nil {
+			t.Fatal(err)
+		}
+		b.credRotationQueue = queue.New()
+		return b, config.StorageView
+	}
+
+	from, fromStorage := newBackend()
+	lastRotation := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	entries := map[string]interface{}{
+		"config/pg": &DatabaseConfig{
+			PluginName:        "postgresql-database-plugin",
+			ConnectionDetails: map[string]interface{}{"connection_url": "postgres://db", "password": "root"},
+			AllowedRoles:      []string{"*"},
+			MaxRetries:        3,
+		},
+		"role/web": &roleEntry{
+			DBName:     "pg",
+			DefaultTTL: time.Hour,
+		},
+		"static-role/app": &roleEntry{
+			DBName: "pg",
+			StaticAccount: &staticAccount{
+				Username:          "app",
+				Password:          "secret",
+				LastVaultRotation: lastRotation,
+				RotationPeriod:    time.Hour,
+			},
+		},
+	}
+	for key, value := range entries {
+		entry, err := logical.StorageEntryJSON(key, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fromStorage.Put(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := logical.ExportBackendState(ctx, from, fromStorage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Format != stateFormat || len(state.Entries) != 3 {
+		t.Fatalf("bad state: %#v", state)
+	}
+
+	to, toStorage := newBackend()
+	if err := logical.ImportBackendState(ctx, to, toStorage, state); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := to.DatabaseConfig(ctx, toStorage, "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.PluginName != "postgresql-database-plugin" || config.ConnectionDetails["password"] != "root" || config.MaxRetries != 3 {
+		t.Fatalf("bad connection: %#v", config)
+	}
+	role, err := to.Role(ctx, toStorage, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role == nil || role.DBName != "pg" || role.DefaultTTL != time.Hour {
+		t.Fatalf("bad role: %#v", role)
+	}
+	staticRole, err := to.StaticRole(ctx, toStorage, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staticRole == nil || staticRole.StaticAccount.Password != "secret" || !staticRole.StaticAccount.LastVaultRotation.Equal(lastRotation) {
+		t.Fatalf("bad static role: %#v", staticRole)
+	}
+
+	// The static role is scheduled from its last rotation
+	item, err := to.popFromRotationQueueByKey("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Priority != lastRotation.Add(time.Hour).Unix() {
+		t.Fatalf("bad priority: %d", item.Priority)
+	}
+
+	// Role and static role names stay unique
+	state.Entries = []*logical.StateEntry{{
+		Kind: stateKindRole,
+		Key:  "app",
+		Data: map[string]interface{}{"db_name": "pg"},
+	}}
+	err = logical.ImportBackendState(ctx, to, toStorage, state)
+	if err == nil || !strings.Contains(err.Error(), "must be unique") {
+		t.Fatalf("expected the role to be rejected, got %v", err)
+	}
+
+	// The state of other backends is rejected
+	err = logical.ImportBackendState(ctx, to, toStorage, &logical.BackendState{Format: "kv/1", Entries: state.Entries})
+	if err == nil {
+		t.Fatal("expected the state to be rejected")
+	}
+}
@@ -21,9 +21,10 @@ type databaseVersionWrapper struct {
 }
 
 // newDatabaseWrapper figures out which version of the database the pluginName is referring to and returns a wrapper object
-// that can be used to make operations on the underlying database plugin.
-func newDatabaseWrapper(ctx context.Context, pluginName string, sys pluginutil.LookRunnerUtil, logger log.Logger) (dbw databaseVersionWrapper, err error) {
-	newDB, err := v5.PluginFactory(ctx, pluginName, sys, logger)
+// that can be used to make operations on the underlying database plugin. The id uniquely identifies the connection so
+// that v5 plugins supporting multiplexing can serve it from a process shared with other connections.
+func newDatabaseWrapper(ctx context.Context, pluginName, id string, sys pluginutil.LookRunnerUtil, logger log.Logger) (dbw databaseVersionWrapper, err error) {
+	newDB, err := v5.MultiplexedPluginFactory(ctx, pluginName, id, sys, logger)
 	if err == nil {
 		dbw = databaseVersionWrapper{
 			v5: newDB,
@@ -46,6 +47,28 @@ func newDatabaseWrapper(ctx context.Context, pluginName string, sys pluginutil.L
 	return dbw, fmt.Errorf("invalid database version: %s", merr)
 }
 
+// withRetries returns a wrapper that retries user operations failing with a
+// transient error, according to the given configuration. Legacy databases are
+// returned unchanged.
+func (d databaseVersionWrapper) withRetries(config v5.RetryConfig) databaseVersionWrapper {
+	if !d.isV5() || config.MaxRetries <= 0 {
+		return d
+	}
+	d.v5 = v5.NewDatabaseRetryMiddleware(d.v5, config)
+	return d
+}
+
+// withMetrics returns a wrapper that emits per-operation metrics labeled with
+// the plugin type and the connection name to the host's telemetry sink.
+// Legacy databases are returned unchanged.
+func (d databaseVersionWrapper) withMetrics(pluginType, connectionName string) databaseVersionWrapper {
+	if !d.isV5() {
+		return d
+	}
+	d.v5 = v5.NewDatabaseLabeledMetricsMiddleware(d.v5, nil, pluginType, connectionName)
+	return d
+}
+
 // Initialize the underlying database. This is analogous to a constructor on the database plugin object.
 // Errors if the wrapper does not contain an underlying database.
 func (d databaseVersionWrapper) Initialize(ctx context.Context, req v5.InitializeRequest) (v5.InitializeResponse, error) {
@@ -120,6 +143,9 @@ func (d databaseVersionWrapper) UpdateUser(ctx context.Context, req v5.UpdateUse
 	}
 
 	// v4 Database
+	if req.PublicKey != nil {
+		return nil, fmt.Errorf("key pair rotation is not supported by the database plugin")
+	}
 	if req.Password == nil && req.Expiration == nil {
 		return nil, fmt.Errorf("missing change to be sent to the database")
 	}
@@ -127,6 +127,8 @@ func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logic
 				pathData(b),
 				pathMetadata(b),
 				pathDestroy(b),
+				pathSearch(b),
+				pathUpgradeStatus(b),
 			},
 			pathsDelete(b),
 
@@ -134,6 +136,13 @@ func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logic
 			// processed first.
 			pathInvalid(b),
 		),
+
+		PeriodicJobs: []*framework.PeriodicJob{
+			tidyJob(b),
+		},
+
+		ExportStateFunc: b.exportState,
+		ImportStateFunc: b.importState,
 	}
 
 	b.locks = locksutil.CreateLocks()
@@ -460,6 +469,9 @@ you may or may not be able to access certain paths.
     ^metadata/.*$
         Configures settings for the KV store
 
+    ^search/?$
+        Searches the keys of the KV store by their custom metadata.
+
     ^undelete/.*$
         Undeletes one or more versions from the KV store.
 `
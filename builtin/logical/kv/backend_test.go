@@ -0,0 +1,79 @@
+package kv
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func getBackend(t *testing.T) (logical.Backend, logical.Storage) {
+	t.Helper()
+
+	config := &logical.BackendConfig{
+		Logger:      logging.NewVaultLogger(hclog.Trace),
+		System:      &testSystemView{},
+		StorageView: &logical.InmemStorage{},
+		BackendUUID: "test",
+	}
+
+	b, err := VersionedKVFactory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unable to create backend: %v", err)
+	}
+
+	// Wait for the upgrade to finish
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadUint32(b.(*versionedKVBackend).upgrading) == 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the upgrade")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return b, config.StorageView
+}
+
+// testSystemView grants every token read on every path, except the token with
+// the "limited" accessor on the paths under the mount's infra/ folders.
+type testSystemView struct {
+	logical.StaticSystemView
+}
+
+func (v *testSystemView) Capabilities(ctx context.Context, accessor string, paths []string) (map[string][]string, error) {
+	capabilities := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		if accessor == "limited" && strings.Contains(path, "/infra/") {
+			capabilities[path] = []string{"deny"}
+			continue
+		}
+		capabilities[path] = []string{"read"}
+	}
+	return capabilities, nil
+}
+
+func doRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+	})
+}
+
+func mustRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := doRequest(t, b, s, op, path, data)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("%s %s: err: %v, resp: %#v", op, path, err, resp)
+	}
+	return resp
+}
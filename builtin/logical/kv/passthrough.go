@@ -86,6 +86,9 @@ func LeaseSwitchedPassthroughBackend(ctx context.Context, conf *logical.BackendC
 				},
 			},
 		},
+
+		ExportStateFunc: b.exportState,
+		ImportStateFunc: b.importState,
 	}
 
 	if conf == nil {
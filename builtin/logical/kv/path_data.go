@@ -14,6 +14,7 @@ import (
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
 )
@@ -39,7 +40,13 @@ func pathData(b *versionedKVBackend) *framework.Path {
 Set the "cas" value to use a Check-And-Set operation. If not set the write will
 be allowed. If set to 0 a write will only be allowed if the key doesn’t exist.
 If the index is non-zero the write will only be allowed if the key’s current
-version matches the version specified in the cas parameter.`,
+version matches the version specified in the cas parameter.
+
+Set the "delete_version_after" value to a duration to set the
+delete_version_after of the key, as with the metadata endpoint.
+
+Set the "expires_at" value to an RFC 3339 timestamp to have the new version
+destroyed once that time has passed.`,
 			},
 			"data": {
 				Type:        framework.TypeMap,
@@ -113,10 +120,12 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 			Data: map[string]interface{}{
 				"data": nil,
 				"metadata": map[string]interface{}{
-					"version":       verNum,
-					"created_time":  ptypesTimestampToString(vm.CreatedTime),
-					"deletion_time": ptypesTimestampToString(vm.DeletionTime),
-					"destroyed":     vm.Destroyed,
+					"version":         verNum,
+					"created_time":    ptypesTimestampToString(vm.CreatedTime),
+					"deletion_time":   ptypesTimestampToString(vm.DeletionTime),
+					"expiration_time": ptypesTimestampToString(vm.ExpirationTime),
+					"destroyed":       vm.Destroyed,
+					"custom_metadata": meta.CustomMetadata,
 				},
 			},
 		}
@@ -134,8 +143,13 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 			}
 		}
 
-		// If the version has been destroyed return metadata with a 404
-		if vm.Destroyed {
+		// If the version has been destroyed, or has expired and is waiting to
+		// be destroyed by the tidy, return metadata with a 404
+		expired, err := versionExpired(vm, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if vm.Destroyed || expired {
 			return logical.RespondWithStatusCode(resp, req, http.StatusNotFound)
 
 		}
@@ -212,15 +226,41 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 		}
 
 		// Parse options
+		var expirationTime *timestamp.Timestamp
 		{
-			var casRaw interface{}
-			var casOk bool
+			var casRaw, dvaRaw, expiresAtRaw interface{}
+			var casOk, dvaOk, expiresAtOk bool
 			optionsRaw, ok := data.GetOk("options")
 			if ok {
 				options := optionsRaw.(map[string]interface{})
 
 				// Verify the CAS parameter is valid.
 				casRaw, casOk = options["cas"]
+				dvaRaw, dvaOk = options["delete_version_after"]
+				expiresAtRaw, expiresAtOk = options["expires_at"]
+			}
+
+			if dvaOk {
+				dva, err := parseutil.ParseDurationSecond(dvaRaw)
+				if err != nil || dva < 0 {
+					return logical.ErrorResponse("error parsing delete_version_after parameter"), logical.ErrInvalidRequest
+				}
+				meta.DeleteVersionAfter = ptypes.DurationProto(dva)
+			}
+
+			if expiresAtOk {
+				expiresAtStr, _ := expiresAtRaw.(string)
+				expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+				if err != nil {
+					return logical.ErrorResponse("error parsing expires_at parameter: must be an RFC 3339 timestamp"), logical.ErrInvalidRequest
+				}
+				if !expiresAt.After(time.Now()) {
+					return logical.ErrorResponse("expires_at parameter must be in the future"), logical.ErrInvalidRequest
+				}
+				expirationTime, err = ptypes.TimestampProto(expiresAt)
+				if err != nil {
+					return logical.ErrorResponse("error parsing expires_at parameter: %v", err), logical.ErrInvalidRequest
+				}
 			}
 
 			switch {
@@ -276,6 +316,7 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 		}
 
 		vm, versionToDelete := meta.AddVersion(version.CreatedTime, version.DeletionTime, config.MaxVersions)
+		vm.ExpirationTime = expirationTime
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		if err != nil {
 			return nil, err
@@ -284,10 +325,11 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 		// We create the response here so we can add warnings to it below.
 		resp := &logical.Response{
 			Data: map[string]interface{}{
-				"version":       meta.CurrentVersion,
-				"created_time":  ptypesTimestampToString(vm.CreatedTime),
-				"deletion_time": ptypesTimestampToString(vm.DeletionTime),
-				"destroyed":     vm.Destroyed,
+				"version":         meta.CurrentVersion,
+				"created_time":    ptypesTimestampToString(vm.CreatedTime),
+				"deletion_time":   ptypesTimestampToString(vm.DeletionTime),
+				"expiration_time": ptypesTimestampToString(vm.ExpirationTime),
+				"destroyed":       vm.Destroyed,
 			},
 		}
 
@@ -2,6 +2,7 @@ package kv
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,6 +13,17 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	// maxCustomMetadataKeys is the maximum number of custom metadata pairs
+	// of a key
+	maxCustomMetadataKeys = 64
+
+	// maxCustomMetadataKeyLength and maxCustomMetadataValueLength are the
+	// maximum lengths, in bytes, of custom metadata keys and values
+	maxCustomMetadataKeyLength   = 128
+	maxCustomMetadataValueLength = 512
+)
+
 // pathMetadata returns the path configuration for CRUD operations on the
 // metadata endpoint
 func pathMetadata(b *versionedKVBackend) *framework.Path {
@@ -43,6 +55,13 @@ backend's delete_version_after. A zero duration clears the current setting.
 A negative duration will cause an error.
 `,
 			},
+			"custom_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `
+User-provided key-value pairs that are used to describe arbitrary and
+version-agnostic information about a secret, such as its owner or
+classification. Replaces the current custom metadata; an empty map clears it.`,
+			},
 		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.upgradeCheck(b.pathMetadataWrite()),
@@ -112,9 +131,10 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 		versions := make(map[string]interface{}, len(meta.Versions))
 		for i, v := range meta.Versions {
 			versions[fmt.Sprintf("%d", i)] = map[string]interface{}{
-				"created_time":  ptypesTimestampToString(v.CreatedTime),
-				"deletion_time": ptypesTimestampToString(v.DeletionTime),
-				"destroyed":     v.Destroyed,
+				"created_time":    ptypesTimestampToString(v.CreatedTime),
+				"deletion_time":   ptypesTimestampToString(v.DeletionTime),
+				"expiration_time": ptypesTimestampToString(v.ExpirationTime),
+				"destroyed":       v.Destroyed,
 			}
 		}
 
@@ -136,6 +156,7 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 				"max_versions":         meta.MaxVersions,
 				"cas_required":         meta.CasRequired,
 				"delete_version_after": deleteVersionAfter.String(),
+				"custom_metadata":      meta.CustomMetadata,
 			},
 		}, nil
 	}
@@ -151,12 +172,19 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 		maxRaw, mOk := data.GetOk("max_versions")
 		casRaw, cOk := data.GetOk("cas_required")
 		deleteVersionAfterRaw, dvaOk := data.GetOk("delete_version_after")
+		customMetadataRaw, cmOk := data.GetOk("custom_metadata")
 
 		// Fast path validation
-		if !mOk && !cOk && !dvaOk {
+		if !mOk && !cOk && !dvaOk && !cmOk {
 			return nil, nil
 		}
 
+		if cmOk {
+			if err := validateCustomMetadata(customMetadataRaw.(map[string]string)); err != nil {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
+		}
+
 		config, err := b.config(ctx, req.Storage)
 		if err != nil {
 			return nil, err
@@ -195,6 +223,9 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 		if dvaOk {
 			meta.DeleteVersionAfter = ptypes.DurationProto(time.Duration(deleteVersionAfterRaw.(int)) * time.Second)
 		}
+		if cmOk {
+			meta.CustomMetadata = customMetadataRaw.(map[string]string)
+		}
 
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		return resp, err
@@ -244,6 +275,26 @@ func (b *versionedKVBackend) pathMetadataDelete() framework.OperationFunc {
 	}
 }
 
+// validateCustomMetadata checks the custom metadata of a key is within the
+// limits on the number of pairs and the length of keys and values.
+func validateCustomMetadata(customMetadata map[string]string) error {
+	if len(customMetadata) > maxCustomMetadataKeys {
+		return fmt.Errorf("custom_metadata can have at most %d keys", maxCustomMetadataKeys)
+	}
+	for k, v := range customMetadata {
+		if k == "" {
+			return errors.New("custom_metadata keys cannot be empty")
+		}
+		if len(k) > maxCustomMetadataKeyLength {
+			return fmt.Errorf("custom_metadata key %q is longer than %d bytes", k, maxCustomMetadataKeyLength)
+		}
+		if len(v) > maxCustomMetadataValueLength {
+			return fmt.Errorf("custom_metadata value of key %q is longer than %d bytes", k, maxCustomMetadataValueLength)
+		}
+	}
+	return nil
+}
+
 const metadataHelpSyn = `Allows interaction with key metadata and settings in the KV store.`
 const metadataHelpDesc = `
 This endpoint allows for reading, information about a key in the key-value
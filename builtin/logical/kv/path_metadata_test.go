@@ -0,0 +1,74 @@
+package kv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestVersionedKV_CustomMetadata(t *testing.T) {
+	b, storage := getBackend(t)
+
+	mustRequest(t, b, storage, logical.CreateOperation, "data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "secret"},
+	})
+	mustRequest(t, b, storage, logical.UpdateOperation, "metadata/app/db", map[string]interface{}{
+		"custom_metadata": map[string]interface{}{
+			"owner":          "payments",
+			"classification": "confidential",
+		},
+	})
+
+	expected := map[string]string{
+		"owner":          "payments",
+		"classification": "confidential",
+	}
+	resp := mustRequest(t, b, storage, logical.ReadOperation, "metadata/app/db", nil)
+	if !reflect.DeepEqual(resp.Data["custom_metadata"], expected) {
+		t.Fatalf("bad custom metadata: %#v", resp.Data["custom_metadata"])
+	}
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "data/app/db", nil)
+	if !reflect.DeepEqual(resp.Data["metadata"].(map[string]interface{})["custom_metadata"], expected) {
+		t.Fatalf("bad custom metadata: %#v", resp.Data["metadata"])
+	}
+
+	// Writing other settings keeps the custom metadata
+	mustRequest(t, b, storage, logical.UpdateOperation, "metadata/app/db", map[string]interface{}{
+		"max_versions": 5,
+	})
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "metadata/app/db", nil)
+	if !reflect.DeepEqual(resp.Data["custom_metadata"], expected) {
+		t.Fatalf("bad custom metadata: %#v", resp.Data["custom_metadata"])
+	}
+
+	// Custom metadata is replaced as a whole, and can be written as pairs
+	mustRequest(t, b, storage, logical.UpdateOperation, "metadata/app/db", map[string]interface{}{
+		"custom_metadata": []string{"owner=billing"},
+	})
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "metadata/app/db", nil)
+	if !reflect.DeepEqual(resp.Data["custom_metadata"], map[string]string{"owner": "billing"}) {
+		t.Fatalf("bad custom metadata: %#v", resp.Data["custom_metadata"])
+	}
+
+	// Limits are enforced
+	tooMany := map[string]interface{}{}
+	for i := 0; i <= maxCustomMetadataKeys; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	for name, customMetadata := range map[string]interface{}{
+		"too many keys":  tooMany,
+		"long key":       map[string]interface{}{strings.Repeat("k", maxCustomMetadataKeyLength+1): "value"},
+		"long value":     map[string]interface{}{"key": strings.Repeat("v", maxCustomMetadataValueLength+1)},
+		"empty key pair": []string{"=value"},
+	} {
+		resp, err := doRequest(t, b, storage, logical.UpdateOperation, "metadata/app/db", map[string]interface{}{
+			"custom_metadata": customMetadata,
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package kv
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pathSearch returns the path configuration for searching keys by their
+// custom metadata
+func pathSearch(b *versionedKVBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "search/?$",
+		Fields: map[string]*framework.FieldSchema{
+			"path": {
+				Type:        framework.TypeString,
+				Description: "The folder to search in. If not set, the whole mount is searched.",
+			},
+			"custom_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `
+Key-value pairs the custom metadata of the keys must match. A key matches if
+its custom metadata has all the pairs. If not set, all the keys are returned.`,
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.upgradeCheck(b.pathSearchRead()),
+			logical.UpdateOperation: b.upgradeCheck(b.pathSearchRead()),
+		},
+
+		HelpSynopsis:    searchHelpSyn,
+		HelpDescription: searchHelpDesc,
+	}
+}
+
+func (b *versionedKVBackend) pathSearchRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		prefix := strings.TrimPrefix(data.Get("path").(string), "/")
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		filter := data.Get("custom_metadata").(map[string]string)
+
+		// The results are filtered by the policies of the caller, which only
+		// Vault's process can check
+		capView, ok := b.System().(logical.CapabilitiesView)
+		if !ok {
+			return logical.ErrorResponse("searching is not supported when the plugin runs in its own process"), logical.ErrInvalidRequest
+		}
+		if req.ClientTokenAccessor == "" {
+			return logical.ErrorResponse("searching requires a token with an accessor"), logical.ErrInvalidRequest
+		}
+
+		// Get an encrypted key storage object
+		wrapper, err := b.getKeyEncryptor(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		es := wrapper.Wrap(req.Storage)
+
+		keys := []string{}
+		keyInfo := map[string]interface{}{}
+		err = b.walkKeys(ctx, es, prefix, func(key string) error {
+			meta, err := b.getKeyMetadata(ctx, req.Storage, key)
+			if err != nil {
+				return err
+			}
+			if meta == nil || !matchCustomMetadata(meta.CustomMetadata, filter) {
+				return nil
+			}
+
+			keys = append(keys, key)
+			keyInfo[key] = map[string]interface{}{
+				"current_version": meta.CurrentVersion,
+				"updated_time":    ptypesTimestampToString(meta.UpdatedTime),
+				"custom_metadata": meta.CustomMetadata,
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The key info of the keys filtered out is dropped with them
+		keys, err = readableKeys(ctx, capView, req, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(keys)
+		return logical.ListResponseWithInfo(keys, keyInfo), nil
+	}
+}
+
+// walkKeys calls fn with every key under prefix, recursing into folders.
+func (b *versionedKVBackend) walkKeys(ctx context.Context, es logical.Storage, prefix string, fn func(key string) error) error {
+	children, err := es.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(child, "/") {
+			if err := b.walkKeys(ctx, es, prefix+child, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(prefix + child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readableKeys returns the keys whose metadata the caller can read.
+func readableKeys(ctx context.Context, capView logical.CapabilitiesView, req *logical.Request, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return keys, nil
+	}
+
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = req.MountPoint + "metadata/" + key
+	}
+	capabilities, err := capView.Capabilities(ctx, req.ClientTokenAccessor, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make([]string, 0, len(keys))
+	for i, key := range keys {
+		for _, capability := range capabilities[paths[i]] {
+			if capability == "read" || capability == "root" {
+				readable = append(readable, key)
+				break
+			}
+		}
+	}
+	return readable, nil
+}
+
+// matchCustomMetadata returns whether the custom metadata has all the pairs
+// of the filter.
+func matchCustomMetadata(customMetadata, filter map[string]string) bool {
+	for k, v := range filter {
+		actual, ok := customMetadata[k]
+		if !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+const searchHelpSyn = `Searches the keys of the KV store by their custom metadata.`
+const searchHelpDesc = `
+This endpoint returns the keys under a folder, or the whole mount, whose
+custom metadata has all the given key-value pairs, along with their custom
+metadata. Only the keys whose metadata the caller can read are returned.
+Every key under the folder is read, so searching large mounts is expensive.
+Searching is not supported when the plugin runs in its own process, or with
+batch tokens, which have no accessor.
+`
@@ -0,0 +1,111 @@
+package kv
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestVersionedKV_Search(t *testing.T) {
+	b, storage := getBackend(t)
+
+	for path, customMetadata := range map[string]map[string]interface{}{
+		"app/db":            {"owner": "payments", "classification": "confidential"},
+		"app/api/token":     {"owner": "payments", "classification": "internal"},
+		"app/api/cert":      {"owner": "platform"},
+		"infra/db":          {"owner": "payments", "classification": "confidential"},
+		"infra/no-metadata": nil,
+	} {
+		mustRequest(t, b, storage, logical.CreateOperation, "data/"+path, map[string]interface{}{
+			"data": map[string]interface{}{"foo": "bar"},
+		})
+		if customMetadata != nil {
+			mustRequest(t, b, storage, logical.UpdateOperation, "metadata/"+path, map[string]interface{}{
+				"custom_metadata": customMetadata,
+			})
+		}
+	}
+
+	tests := map[string]struct {
+		data     map[string]interface{}
+		expected []string
+	}{
+		"all keys": {
+			expected: []string{"app/api/cert", "app/api/token", "app/db", "infra/db", "infra/no-metadata"},
+		},
+		"one pair": {
+			data:     map[string]interface{}{"custom_metadata": "owner=payments"},
+			expected: []string{"app/api/token", "app/db", "infra/db"},
+		},
+		"all pairs": {
+			data: map[string]interface{}{
+				"custom_metadata": map[string]interface{}{"owner": "payments", "classification": "confidential"},
+			},
+			expected: []string{"app/db", "infra/db"},
+		},
+		"in folder": {
+			data:     map[string]interface{}{"path": "app", "custom_metadata": "owner=payments"},
+			expected: []string{"app/api/token", "app/db"},
+		},
+		"no match": {
+			data: map[string]interface{}{"custom_metadata": "owner=nobody"},
+		},
+	}
+
+	search := func(accessor string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation:           logical.ReadOperation,
+			Path:                "search",
+			Storage:             storage,
+			Data:                data,
+			MountPoint:          "secret/",
+			ClientTokenAccessor: accessor,
+		})
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := search("accessor", test.data)
+			if err != nil || resp.IsError() {
+				t.Fatalf("err: %v, resp: %#v", err, resp)
+			}
+			keys, _ := resp.Data["keys"].([]string)
+			if !reflect.DeepEqual(keys, test.expected) {
+				t.Fatalf("expected keys %v, got %v", test.expected, keys)
+			}
+		})
+	}
+
+	resp, err := search("accessor", map[string]interface{}{
+		"custom_metadata": "owner=platform",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	info := resp.Data["key_info"].(map[string]interface{})["app/api/cert"].(map[string]interface{})
+	if !reflect.DeepEqual(info["custom_metadata"], map[string]string{"owner": "platform"}) || info["current_version"] != uint64(1) {
+		t.Fatalf("bad key info: %#v", info)
+	}
+
+	// The keys whose metadata the caller cannot read are filtered out
+	resp, err = search("limited", map[string]interface{}{
+		"custom_metadata": "owner=payments",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if keys := resp.Data["keys"]; !reflect.DeepEqual(keys, []string{"app/api/token", "app/db"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if _, ok := resp.Data["key_info"].(map[string]interface{})["infra/db"]; ok {
+		t.Fatal("expected the key info of the filtered keys to be dropped")
+	}
+
+	// Tokens without an accessor cannot search
+	resp, err = search("", nil)
+	if err == nil && !resp.IsError() {
+		t.Fatalf("expected an error, got resp: %#v", resp)
+	}
+}
@@ -0,0 +1,510 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// stateFormat identifies the layout of the state exported by both versions of
+// the backend. Every secret is a "secret" entry keyed by its path whose data
+// holds its current data, so that each version can import the state exported
+// by the other. The versioned backend adds the metadata and the versions of
+// the secrets, and a "config" entry.
+const stateFormat = "kv/1"
+
+const (
+	stateKindConfig = "config"
+	stateKindSecret = "secret"
+)
+
+// stateConfig is the data of the config entry. DeleteVersionAfter is in
+// seconds, -1 meaning disabled.
+type stateConfig struct {
+	MaxVersions        uint32 `json:"max_versions"`
+	CasRequired        bool   `json:"cas_required"`
+	DeleteVersionAfter int64  `json:"delete_version_after"`
+}
+
+// stateSecret is the data of a secret entry. Data is nil if the current
+// version is deleted or destroyed.
+type stateSecret struct {
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata *stateMetadata         `json:"metadata,omitempty"`
+	Versions []*stateVersion        `json:"versions,omitempty"`
+}
+
+type stateMetadata struct {
+	CurrentVersion     uint64            `json:"current_version"`
+	OldestVersion      uint64            `json:"oldest_version"`
+	CreatedTime        string            `json:"created_time"`
+	UpdatedTime        string            `json:"updated_time"`
+	MaxVersions        uint32            `json:"max_versions"`
+	CasRequired        bool              `json:"cas_required"`
+	DeleteVersionAfter int64             `json:"delete_version_after"`
+	CustomMetadata     map[string]string `json:"custom_metadata,omitempty"`
+}
+
+// stateVersion is a version of a secret. Data is nil if the version is
+// destroyed.
+type stateVersion struct {
+	Version        uint64                 `json:"version"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	CreatedTime    string                 `json:"created_time"`
+	DeletionTime   string                 `json:"deletion_time,omitempty"`
+	ExpirationTime string                 `json:"expiration_time,omitempty"`
+	Destroyed      bool                   `json:"destroyed,omitempty"`
+}
+
+// checkStateFormat fails if the state was not exported by this backend.
+func checkStateFormat(state *logical.BackendState) error {
+	if state.Format == "" && len(state.Entries) == 0 {
+		return nil
+	}
+	if state.Format != stateFormat {
+		return fmt.Errorf("unsupported state format %q", state.Format)
+	}
+	return nil
+}
+
+func encodeStateData(v interface{}) (map[string]interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := jsonutil.DecodeJSON(buf, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func decodeStateData(data map[string]interface{}, out interface{}) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return jsonutil.DecodeJSON(buf, out)
+}
+
+func parseStateTimestamp(s string) (*timestamp.Timestamp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, err
+	}
+	return ptypes.TimestampProto(t)
+}
+
+// exportState returns the secrets of the passthrough backend.
+func (b *PassthroughBackend) exportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	keys, err := logical.CollectKeys(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &logical.BackendState{Format: stateFormat}
+	for _, key := range keys {
+		entry, err := s.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		var data map[string]interface{}
+		if err := jsonutil.DecodeJSON(entry.Value, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode secret %q: %w", key, err)
+		}
+		state.Entries = append(state.Entries, &logical.StateEntry{
+			Kind: stateKindSecret,
+			Key:  key,
+			Data: map[string]interface{}{"data": data},
+		})
+	}
+
+	return state, nil
+}
+
+// importState writes the current data of the secrets. The config, and the
+// secrets whose current version is deleted, are skipped as the passthrough
+// backend has no equivalent.
+func (b *PassthroughBackend) importState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	if err := checkStateFormat(state); err != nil {
+		return err
+	}
+
+	for _, e := range state.Entries {
+		switch e.Kind {
+		case stateKindConfig:
+			continue
+		case stateKindSecret:
+		default:
+			return fmt.Errorf("unsupported state entry kind %q", e.Kind)
+		}
+
+		var secret stateSecret
+		if err := decodeStateData(e.Data, &secret); err != nil {
+			return fmt.Errorf("failed to decode secret %q: %w", e.Key, err)
+		}
+		if secret.Data == nil {
+			continue
+		}
+
+		buf, err := json.Marshal(secret.Data)
+		if err != nil {
+			return err
+		}
+		if err := s.Put(ctx, &logical.StorageEntry{
+			Key:   e.Key,
+			Value: buf,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportState returns the config and the secrets of the versioned backend,
+// with all their versions.
+func (b *versionedKVBackend) exportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	if atomic.LoadUint32(b.upgrading) == 1 {
+		return nil, errors.New("the backend is upgrading")
+	}
+
+	config, err := b.config(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	configData, err := encodeStateData(&stateConfig{
+		MaxVersions:        config.MaxVersions,
+		CasRequired:        config.CasRequired,
+		DeleteVersionAfter: int64(deleteVersionAfter(config).Seconds()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	state := &logical.BackendState{
+		Format: stateFormat,
+		Entries: []*logical.StateEntry{
+			{Kind: stateKindConfig, Data: configData},
+		},
+	}
+
+	wrapper, err := b.getKeyEncryptor(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	err = b.walkKeys(ctx, wrapper.Wrap(s), "", func(key string) error {
+		lock := locksutil.LockForKey(b.locks, key)
+		lock.RLock()
+		defer lock.RUnlock()
+
+		secret, err := b.exportSecret(ctx, s, key, now)
+		if err != nil {
+			return fmt.Errorf("failed to export secret %q: %w", key, err)
+		}
+		if secret == nil {
+			return nil
+		}
+		data, err := encodeStateData(secret)
+		if err != nil {
+			return err
+		}
+		state.Entries = append(state.Entries, &logical.StateEntry{
+			Kind: stateKindSecret,
+			Key:  key,
+			Data: data,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// exportSecret returns the metadata and the versions of the key. The caller
+// must hold the lock of the key.
+func (b *versionedKVBackend) exportSecret(ctx context.Context, s logical.Storage, key string, now time.Time) (*stateSecret, error) {
+	meta, err := b.getKeyMetadata(ctx, s, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, nil
+	}
+
+	secret := &stateSecret{
+		Metadata: &stateMetadata{
+			CurrentVersion:     meta.CurrentVersion,
+			OldestVersion:      meta.OldestVersion,
+			CreatedTime:        ptypesTimestampToString(meta.CreatedTime),
+			UpdatedTime:        ptypesTimestampToString(meta.UpdatedTime),
+			MaxVersions:        meta.MaxVersions,
+			CasRequired:        meta.CasRequired,
+			DeleteVersionAfter: int64(deleteVersionAfter(meta).Seconds()),
+			CustomMetadata:     meta.CustomMetadata,
+		},
+	}
+
+	versions := make([]uint64, 0, len(meta.Versions))
+	for n := range meta.Versions {
+		versions = append(versions, n)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, n := range versions {
+		vm := meta.Versions[n]
+		sv := &stateVersion{
+			Version:        n,
+			CreatedTime:    ptypesTimestampToString(vm.CreatedTime),
+			DeletionTime:   ptypesTimestampToString(vm.DeletionTime),
+			ExpirationTime: ptypesTimestampToString(vm.ExpirationTime),
+			Destroyed:      vm.Destroyed,
+		}
+
+		if !vm.Destroyed {
+			versionKey, err := b.getVersionKey(ctx, key, n, s)
+			if err != nil {
+				return nil, err
+			}
+			raw, err := s.Get(ctx, versionKey)
+			if err != nil {
+				return nil, err
+			}
+			if raw != nil {
+				version := &Version{}
+				if err := proto.Unmarshal(raw.Value, version); err != nil {
+					return nil, err
+				}
+				if err := jsonutil.DecodeJSON(version.Data, &sv.Data); err != nil {
+					return nil, err
+				}
+			}
+		}
+		secret.Versions = append(secret.Versions, sv)
+
+		if n != meta.CurrentVersion {
+			continue
+		}
+		live, err := versionLive(vm, now)
+		if err != nil {
+			return nil, err
+		}
+		if live {
+			secret.Data = sv.Data
+		}
+	}
+
+	return secret, nil
+}
+
+// versionLive returns whether the version is readable at now.
+func versionLive(vm *VersionMetadata, now time.Time) (bool, error) {
+	if vm.Destroyed {
+		return false, nil
+	}
+	if vm.DeletionTime != nil {
+		deletionTime, err := ptypes.Timestamp(vm.DeletionTime)
+		if err != nil {
+			return false, err
+		}
+		if deletionTime.Before(now) {
+			return false, nil
+		}
+	}
+	expired, err := versionExpired(vm, now)
+	if err != nil {
+		return false, err
+	}
+	return !expired, nil
+}
+
+// importState writes the config and the secrets, replacing all the versions
+// of the existing secrets with the same path. The secrets exported by the
+// passthrough backend become the first version of the secret.
+func (b *versionedKVBackend) importState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	if err := checkStateFormat(state); err != nil {
+		return err
+	}
+	if atomic.LoadUint32(b.upgrading) == 1 {
+		return errors.New("the backend is upgrading")
+	}
+
+	for _, e := range state.Entries {
+		switch e.Kind {
+		case stateKindConfig:
+			var config stateConfig
+			if err := decodeStateData(e.Data, &config); err != nil {
+				return fmt.Errorf("failed to decode the config: %w", err)
+			}
+			if err := b.importConfig(ctx, s, &config); err != nil {
+				return err
+			}
+		case stateKindSecret:
+			var secret stateSecret
+			if err := decodeStateData(e.Data, &secret); err != nil {
+				return fmt.Errorf("failed to decode secret %q: %w", e.Key, err)
+			}
+			if err := b.importSecret(ctx, s, e.Key, &secret); err != nil {
+				return fmt.Errorf("failed to import secret %q: %w", e.Key, err)
+			}
+		default:
+			return fmt.Errorf("unsupported state entry kind %q", e.Kind)
+		}
+	}
+
+	return nil
+}
+
+func (b *versionedKVBackend) importConfig(ctx context.Context, s logical.Storage, sc *stateConfig) error {
+	config := &Configuration{
+		MaxVersions: sc.MaxVersions,
+		CasRequired: sc.CasRequired,
+	}
+	switch {
+	case sc.DeleteVersionAfter < 0:
+		config.DisableDeleteVersionAfter()
+	case sc.DeleteVersionAfter > 0:
+		config.DeleteVersionAfter = ptypes.DurationProto(time.Duration(sc.DeleteVersionAfter) * time.Second)
+	}
+
+	bytes, err := proto.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, &logical.StorageEntry{
+		Key:   path.Join(b.storagePrefix, configPath),
+		Value: bytes,
+	}); err != nil {
+		return err
+	}
+
+	b.globalConfigLock.Lock()
+	defer b.globalConfigLock.Unlock()
+	b.globalConfig = config
+	return nil
+}
+
+func (b *versionedKVBackend) importSecret(ctx context.Context, s logical.Storage, key string, secret *stateSecret) error {
+	if secret.Metadata == nil {
+		if secret.Data == nil {
+			return nil
+		}
+		// The secret was exported by the passthrough backend
+		now := ptypesTimestampToString(ptypes.TimestampNow())
+		secret.Metadata = &stateMetadata{
+			CurrentVersion: 1,
+			CreatedTime:    now,
+			UpdatedTime:    now,
+		}
+		secret.Versions = []*stateVersion{{
+			Version:     1,
+			Data:        secret.Data,
+			CreatedTime: now,
+		}}
+	}
+
+	meta := &KeyMetadata{
+		Key:            key,
+		Versions:       make(map[uint64]*VersionMetadata, len(secret.Versions)),
+		CurrentVersion: secret.Metadata.CurrentVersion,
+		OldestVersion:  secret.Metadata.OldestVersion,
+		MaxVersions:    secret.Metadata.MaxVersions,
+		CasRequired:    secret.Metadata.CasRequired,
+		CustomMetadata: secret.Metadata.CustomMetadata,
+	}
+	var err error
+	if meta.CreatedTime, err = parseStateTimestamp(secret.Metadata.CreatedTime); err != nil {
+		return err
+	}
+	if meta.UpdatedTime, err = parseStateTimestamp(secret.Metadata.UpdatedTime); err != nil {
+		return err
+	}
+	if secret.Metadata.DeleteVersionAfter > 0 {
+		meta.DeleteVersionAfter = ptypes.DurationProto(time.Duration(secret.Metadata.DeleteVersionAfter) * time.Second)
+	}
+
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// The versions of the existing secret are replaced
+	existing, err := b.getKeyMetadata(ctx, s, key)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		for n := range existing.Versions {
+			versionKey, err := b.getVersionKey(ctx, key, n, s)
+			if err != nil {
+				return err
+			}
+			if err := s.Delete(ctx, versionKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, sv := range secret.Versions {
+		vm := &VersionMetadata{Destroyed: sv.Destroyed}
+		if vm.CreatedTime, err = parseStateTimestamp(sv.CreatedTime); err != nil {
+			return err
+		}
+		if vm.DeletionTime, err = parseStateTimestamp(sv.DeletionTime); err != nil {
+			return err
+		}
+		if vm.ExpirationTime, err = parseStateTimestamp(sv.ExpirationTime); err != nil {
+			return err
+		}
+		meta.Versions[sv.Version] = vm
+
+		if sv.Destroyed || sv.Data == nil {
+			continue
+		}
+		data, err := json.Marshal(sv.Data)
+		if err != nil {
+			return err
+		}
+		buf, err := proto.Marshal(&Version{
+			Data:         data,
+			CreatedTime:  vm.CreatedTime,
+			DeletionTime: vm.DeletionTime,
+		})
+		if err != nil {
+			return err
+		}
+		versionKey, err := b.getVersionKey(ctx, key, sv.Version, s)
+		if err != nil {
+			return err
+		}
+		if err := s.Put(ctx, &logical.StorageEntry{
+			Key:   versionKey,
+			Value: buf,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return b.writeKeyMetadata(ctx, s, meta)
+}
@@ -0,0 +1,133 @@
+package kv
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// tidyInterval is the minimum time between two runs of the tidy of
+	// expired versions
+	tidyInterval = time.Hour
+
+	// tidyJitter spreads out the runs of the tidy of many mounts
+	tidyJitter = 10 * time.Minute
+)
+
+// tidyJob returns the periodic job destroying the expired versions.
+func tidyJob(b *versionedKVBackend) *framework.PeriodicJob {
+	return &framework.PeriodicJob{
+		Name:     "tidy-expired-versions",
+		Interval: tidyInterval,
+		Jitter:   tidyJitter,
+		Func: func(ctx context.Context, req *logical.Request) error {
+			_, err := b.tidyExpiredVersions(ctx, req.Storage, time.Now())
+			return err
+		},
+	}
+}
+
+// versionExpired returns whether the version has an expiration time which
+// has passed.
+func versionExpired(vm *VersionMetadata, now time.Time) (bool, error) {
+	if vm.ExpirationTime == nil {
+		return false, nil
+	}
+	expirationTime, err := ptypes.Timestamp(vm.ExpirationTime)
+	if err != nil {
+		return false, err
+	}
+	return !expirationTime.After(now), nil
+}
+
+// tidyExpiredVersions destroys the versions of all the keys which expired
+// before now, returning the number of versions destroyed. Errors on a key do
+// not stop the tidy of the other keys.
+func (b *versionedKVBackend) tidyExpiredVersions(ctx context.Context, s logical.Storage, now time.Time) (int, error) {
+	// The data is not readable until the upgrade is done
+	if atomic.LoadUint32(b.upgrading) == 1 {
+		return 0, nil
+	}
+
+	wrapper, err := b.getKeyEncryptor(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+
+	var destroyed int
+	var result *multierror.Error
+	err = b.walkKeys(ctx, wrapper.Wrap(s), "", func(key string) error {
+		n, err := b.destroyExpiredVersions(ctx, s, key, now)
+		destroyed += n
+		if err != nil {
+			result = multierror.Append(result, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return destroyed, err
+	}
+
+	if destroyed > 0 {
+		b.Logger().Info("destroyed expired versions", "count", destroyed)
+	}
+	return destroyed, result.ErrorOrNil()
+}
+
+// destroyExpiredVersions destroys the versions of the key which expired
+// before now.
+func (b *versionedKVBackend) destroyExpiredVersions(ctx context.Context, s logical.Storage, key string, now time.Time) (int, error) {
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.getKeyMetadata(ctx, s, key)
+	if err != nil {
+		return 0, err
+	}
+	if meta == nil {
+		return 0, nil
+	}
+
+	var expired []uint64
+	for verNum, vm := range meta.Versions {
+		if vm.Destroyed {
+			continue
+		}
+		ok, err := versionExpired(vm, now)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			vm.Destroyed = true
+			expired = append(expired, verNum)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	// Write the metadata key before deleting the versions
+	if err := b.writeKeyMetadata(ctx, s, meta); err != nil {
+		return 0, err
+	}
+
+	for _, verNum := range expired {
+		versionKey, err := b.getVersionKey(ctx, key, verNum, s)
+		if err != nil {
+			return 0, err
+		}
+		if err := s.Delete(ctx, versionKey); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expired), nil
+}
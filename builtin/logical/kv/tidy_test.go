@@ -0,0 +1,159 @@
+package kv
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestVersionedKV_ExpiresAt(t *testing.T) {
+	b, storage := getBackend(t)
+	kvb := b.(*versionedKVBackend)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	resp := mustRequest(t, b, storage, logical.CreateOperation, "data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "v1"},
+		"options": map[string]interface{}{
+			"expires_at":           expiresAt.Format(time.RFC3339),
+			"delete_version_after": "2h",
+		},
+	})
+	if resp.Data["expiration_time"] != expiresAt.Format(time.RFC3339Nano) {
+		t.Fatalf("bad expiration time: %#v", resp.Data)
+	}
+	mustRequest(t, b, storage, logical.UpdateOperation, "data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "v2"},
+	})
+
+	// The delete_version_after of the key is set by the write
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "metadata/app/db", nil)
+	if resp.Data["delete_version_after"] != "2h0m0s" {
+		t.Fatalf("bad delete_version_after: %#v", resp.Data["delete_version_after"])
+	}
+
+	// Nothing has expired yet
+	destroyed, err := kvb.tidyExpiredVersions(context.Background(), storage, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if destroyed != 0 {
+		t.Fatalf("expected no destroyed versions, got %d", destroyed)
+	}
+
+	// Once expired, the tidy destroys the version
+	destroyed, err = kvb.tidyExpiredVersions(context.Background(), storage, expiresAt.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if destroyed != 1 {
+		t.Fatalf("expected 1 destroyed version, got %d", destroyed)
+	}
+
+	resp, err = doRequest(t, b, storage, logical.ReadOperation, "data/app/db", map[string]interface{}{"version": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != http.StatusNotFound {
+		t.Fatalf("expected a 404 reading an expired version, got: %#v", resp)
+	}
+	versionKey, err := kvb.getVersionKey(context.Background(), "app/db", 1, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := storage.Get(context.Background(), versionKey); err != nil || entry != nil {
+		t.Fatalf("expected the version data to be deleted, got: %#v, %v", entry, err)
+	}
+
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "data/app/db", nil)
+	if resp.Data["data"].(map[string]interface{})["password"] != "v2" {
+		t.Fatalf("bad data: %#v", resp.Data)
+	}
+
+	// Invalid options are rejected
+	for _, options := range []map[string]interface{}{
+		{"expires_at": "tomorrow"},
+		{"expires_at": time.Now().Add(-time.Minute).Format(time.RFC3339)},
+		{"delete_version_after": "-1h"},
+	} {
+		resp, err := doRequest(t, b, storage, logical.UpdateOperation, "data/app/db", map[string]interface{}{
+			"data":    map[string]interface{}{"password": "v3"},
+			"options": options,
+		})
+		if err == nil || !resp.IsError() {
+			t.Fatalf("expected an error writing with options %#v", options)
+		}
+	}
+}
+
+func TestVersionedKV_ExpiredVersionsHidden(t *testing.T) {
+	b, storage := getBackend(t)
+	kvb := b.(*versionedKVBackend)
+	ctx := context.Background()
+
+	resp := mustRequest(t, b, storage, logical.CreateOperation, "data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "v1"},
+		"options": map[string]interface{}{
+			"expires_at":           time.Now().Add(time.Hour).Format(time.RFC3339),
+			"delete_version_after": "2h",
+		},
+	})
+
+	// delete_version_after sets the deletion time of the version
+	created, err := time.Parse(time.RFC3339Nano, resp.Data["created_time"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletion, err := time.Parse(time.RFC3339Nano, resp.Data["deletion_time"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deletion.Sub(created) != 2*time.Hour {
+		t.Fatalf("bad deletion time: %#v", resp.Data)
+	}
+
+	// Move the expiration time of the version to the past
+	meta, err := kvb.getKeyMetadata(ctx, storage, "app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := ptypes.TimestampProto(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta.Versions[1].ExpirationTime = expired
+	if err := kvb.writeKeyMetadata(ctx, storage, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	// The expired version is hidden before the tidy destroys it
+	resp, err = doRequest(t, b, storage, logical.ReadOperation, "data/app/db", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != http.StatusNotFound {
+		t.Fatalf("expected a 404 reading an expired version, got: %#v", resp)
+	}
+	versionKey, err := kvb.getVersionKey(ctx, "app/db", 1, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := storage.Get(ctx, versionKey); err != nil || entry == nil {
+		t.Fatalf("expected the version data to be kept until the tidy, got: %#v, %v", entry, err)
+	}
+
+	// The periodic tidy purges it
+	if err := tidyJob(kvb).Func(ctx, &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if entry, err := storage.Get(ctx, versionKey); err != nil || entry != nil {
+		t.Fatalf("expected the version data to be deleted, got: %#v, %v", entry, err)
+	}
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "metadata/app/db", nil)
+	if !resp.Data["versions"].(map[string]interface{})["1"].(map[string]interface{})["destroyed"].(bool) {
+		t.Fatalf("expected the version to be destroyed: %#v", resp.Data)
+	}
+}
@@ -87,10 +87,13 @@ type VersionMetadata struct {
 	DeletionTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=deletion_time,json=deletionTime,proto3" json:"deletion_time,omitempty"`
 	// Destroyed is used to specify this version is
 	// a has been removed and the underlying data deleted.
-	Destroyed            bool     `protobuf:"varint,3,opt,name=destroyed,proto3" json:"destroyed,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Destroyed bool `protobuf:"varint,3,opt,name=destroyed,proto3" json:"destroyed,omitempty"`
+	// ExpirationTime is the time after which this version is
+	// destroyed. If empty, the version does not expire.
+	ExpirationTime       *timestamp.Timestamp `protobuf:"bytes,4,opt,name=expiration_time,json=expirationTime,proto3" json:"expiration_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *VersionMetadata) Reset()         { *m = VersionMetadata{} }
@@ -139,6 +142,13 @@ func (m *VersionMetadata) GetDestroyed() bool {
 	return false
 }
 
+func (m *VersionMetadata) GetExpirationTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.ExpirationTime
+	}
+	return nil
+}
+
 type KeyMetadata struct {
 	// Key is the key for this entry
 	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -164,10 +174,13 @@ type KeyMetadata struct {
 	// DeleteVersionAfter specifies how long to keep versions around. If
 	// empty value, defaults to the configured delete_version_after for the
 	// mount.
-	DeleteVersionAfter   *duration.Duration `protobuf:"bytes,9,opt,name=delete_version_after,json=deleteVersionAfter,proto3" json:"delete_version_after,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
-	XXX_unrecognized     []byte             `json:"-"`
-	XXX_sizecache        int32              `json:"-"`
+	DeleteVersionAfter *duration.Duration `protobuf:"bytes,9,opt,name=delete_version_after,json=deleteVersionAfter,proto3" json:"delete_version_after,omitempty"`
+	// CustomMetadata is a map of arbitrary user-supplied key/value pairs
+	// describing the secret, such as its owner or classification.
+	CustomMetadata       map[string]string `protobuf:"bytes,10,rep,name=custom_metadata,json=customMetadata,proto3" json:"custom_metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *KeyMetadata) Reset()         { *m = KeyMetadata{} }
@@ -258,6 +271,13 @@ func (m *KeyMetadata) GetDeleteVersionAfter() *duration.Duration {
 	return nil
 }
 
+func (m *KeyMetadata) GetCustomMetadata() map[string]string {
+	if m != nil {
+		return m.CustomMetadata
+	}
+	return nil
+}
+
 type Version struct {
 	// Data is a JSON object with string keys that
 	// represents the user supplied data.
@@ -373,6 +393,7 @@ func init() {
 	proto.RegisterType((*Configuration)(nil), "kv.Configuration")
 	proto.RegisterType((*VersionMetadata)(nil), "kv.VersionMetadata")
 	proto.RegisterType((*KeyMetadata)(nil), "kv.KeyMetadata")
+	proto.RegisterMapType((map[string]string)(nil), "kv.KeyMetadata.CustomMetadataEntry")
 	proto.RegisterMapType((map[uint64]*VersionMetadata)(nil), "kv.KeyMetadata.VersionsEntry")
 	proto.RegisterType((*Version)(nil), "kv.Version")
 	proto.RegisterType((*UpgradeInfo)(nil), "kv.UpgradeInfo")
@@ -381,36 +402,39 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 484 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xcd, 0x8e, 0xd3, 0x30,
-	0x18, 0x54, 0xd2, 0xec, 0x6e, 0xfb, 0x39, 0xd9, 0x45, 0x86, 0x43, 0xa9, 0xf8, 0x29, 0x91, 0x10,
-	0xe5, 0x92, 0x95, 0xca, 0x05, 0x90, 0x56, 0x08, 0x01, 0x07, 0xb4, 0x42, 0x42, 0x16, 0x70, 0x2d,
-	0xde, 0xfa, 0x6b, 0x15, 0xb5, 0x8d, 0x83, 0xe3, 0x54, 0x9b, 0x87, 0xe0, 0x11, 0xb8, 0xf0, 0x02,
-	0xbc, 0x22, 0xb2, 0x63, 0x77, 0x97, 0x82, 0x54, 0x0a, 0x37, 0x6b, 0x3c, 0xf3, 0x79, 0x3c, 0x9e,
-	0x04, 0x88, 0x6e, 0x4a, 0xac, 0xb2, 0x52, 0x49, 0x2d, 0x69, 0xb8, 0x58, 0x0f, 0xee, 0xcf, 0xa5,
-	0x9c, 0x2f, 0xf1, 0xd4, 0x22, 0x17, 0xf5, 0xec, 0x54, 0xe7, 0x2b, 0xac, 0x34, 0x5f, 0x95, 0x2d,
-	0x69, 0x70, 0x6f, 0x9b, 0x20, 0x6a, 0xc5, 0x75, 0x2e, 0x8b, 0x76, 0x3f, 0xfd, 0x1e, 0x40, 0xf2,
-	0x4a, 0x16, 0xb3, 0x7c, 0xee, 0x70, 0xfa, 0x00, 0xe2, 0x15, 0xbf, 0x9c, 0xac, 0x51, 0x55, 0xb9,
-	0x2c, 0xaa, 0x7e, 0x30, 0x0c, 0x46, 0x09, 0x23, 0x2b, 0x7e, 0xf9, 0xc9, 0x41, 0x86, 0x32, 0xe5,
-	0xd5, 0x44, 0xe1, 0x97, 0x3a, 0x57, 0x28, 0xfa, 0xe1, 0x30, 0x18, 0x75, 0x19, 0x99, 0xf2, 0x8a,
-	0x39, 0x88, 0x9e, 0xc3, 0x2d, 0x81, 0x4b, 0xd4, 0xe8, 0x07, 0x4d, 0xf8, 0x4c, 0xa3, 0xea, 0x77,
-	0x86, 0xc1, 0x88, 0x8c, 0x6f, 0x67, 0xad, 0xad, 0xcc, 0xdb, 0xca, 0x5e, 0xbb, 0xe3, 0x19, 0x6d,
-	0x65, 0xee, 0xac, 0x97, 0x46, 0x94, 0xfe, 0x08, 0xe0, 0xc4, 0x01, 0xef, 0x50, 0x73, 0xc1, 0x35,
-	0xa7, 0x67, 0x10, 0x4f, 0x15, 0x72, 0x8d, 0x62, 0x62, 0xee, 0x6c, 0x6d, 0x92, 0xf1, 0xe0, 0xb7,
-	0xc1, 0x1f, 0x7c, 0x20, 0x8c, 0x38, 0xbe, 0x41, 0xe8, 0x0b, 0x48, 0xec, 0x41, 0xc6, 0x99, 0xd5,
-	0x87, 0x3b, 0xf5, 0xb1, 0x17, 0xd8, 0x01, 0x77, 0xa0, 0x27, 0xb0, 0xd2, 0x4a, 0x36, 0x28, 0xec,
-	0xad, 0xba, 0xec, 0x0a, 0x48, 0xbf, 0x46, 0x40, 0xce, 0xb1, 0xd9, 0xb8, 0xbd, 0x01, 0x9d, 0x05,
-	0x36, 0xd6, 0x64, 0x8f, 0x99, 0x25, 0x7d, 0x06, 0xdd, 0x4d, 0xc4, 0xe1, 0xb0, 0x33, 0x22, 0xe3,
-	0xbb, 0xd9, 0x62, 0x9d, 0x5d, 0x13, 0x65, 0x3e, 0xef, 0x37, 0x85, 0x56, 0x0d, 0xdb, 0xd0, 0xe9,
-	0x23, 0x38, 0x99, 0xd6, 0x4a, 0x61, 0xa1, 0x7d, 0xb8, 0xd6, 0x40, 0xc4, 0x8e, 0x1d, 0xec, 0x84,
-	0xf4, 0x21, 0x1c, 0xcb, 0xa5, 0x31, 0xb5, 0xe1, 0x45, 0x96, 0x97, 0xb4, 0xa8, 0xa7, 0x6d, 0x47,
-	0x79, 0xb0, 0x5f, 0x94, 0x67, 0x10, 0xd7, 0xa5, 0xb8, 0x92, 0x1f, 0xee, 0x96, 0x3b, 0xbe, 0x95,
-	0x6f, 0xf7, 0xed, 0x68, 0x77, 0xdf, 0xba, 0x7f, 0xdf, 0xb7, 0xde, 0x3f, 0xf4, 0x6d, 0xf0, 0x1e,
-	0x92, 0x5f, 0xb2, 0xbf, 0xfe, 0x7c, 0x51, 0xfb, 0x7c, 0x8f, 0xe1, 0x60, 0xcd, 0x97, 0xb5, 0xef,
-	0xcd, 0x4d, 0xf3, 0x76, 0x5b, 0x15, 0x65, 0x2d, 0xe3, 0x79, 0xf8, 0x34, 0x48, 0xbf, 0x05, 0x70,
-	0xe4, 0xe3, 0xa6, 0x10, 0x99, 0x6d, 0x3b, 0x2d, 0x66, 0xd1, 0x1f, 0xdb, 0x1c, 0xfe, 0x67, 0x9b,
-	0x3b, 0xfb, 0xb5, 0x39, 0xfd, 0x0c, 0xe4, 0x63, 0x39, 0x57, 0x5c, 0xe0, 0xdb, 0x62, 0x26, 0x8d,
-	0x9d, 0x4a, 0x73, 0xb5, 0xcf, 0xc7, 0xe5, 0xf8, 0xd6, 0x8e, 0xb9, 0xa1, 0x2c, 0xd0, 0xfd, 0x17,
-	0xec, 0xfa, 0xe2, 0xd0, 0x8a, 0x9e, 0xfc, 0x0c, 0x00, 0x00, 0xff, 0xff, 0x81, 0xe5, 0xbe, 0x7d,
-	0xc3, 0x04, 0x00, 0x00,
+	// 538 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x5d, 0x8f, 0xd2, 0x40,
+	0x14, 0x4d, 0x4b, 0xd9, 0x85, 0x5b, 0x3e, 0xcc, 0xec, 0x3e, 0x20, 0xf1, 0x03, 0x31, 0x46, 0x7c,
+	0xe9, 0x26, 0xf8, 0xa2, 0x26, 0x1b, 0xb3, 0x41, 0x1f, 0xcc, 0x6a, 0x62, 0x26, 0xea, 0x2b, 0xce,
+	0xd2, 0x0b, 0x69, 0xa0, 0x9d, 0x3a, 0x9d, 0x12, 0xf8, 0x31, 0xfa, 0xe0, 0x6f, 0xf4, 0x07, 0x98,
+	0x99, 0xce, 0x00, 0x5b, 0x49, 0x10, 0x7d, 0x2b, 0x87, 0x73, 0xee, 0x47, 0xcf, 0xb9, 0x05, 0x5f,
+	0xae, 0x53, 0xcc, 0x82, 0x54, 0x70, 0xc9, 0x89, 0x3b, 0x5f, 0x76, 0x1f, 0xce, 0x38, 0x9f, 0x2d,
+	0xf0, 0x42, 0x23, 0x37, 0xf9, 0xf4, 0x42, 0x46, 0x31, 0x66, 0x92, 0xc5, 0x69, 0x41, 0xea, 0x3e,
+	0x28, 0x13, 0xc2, 0x5c, 0x30, 0x19, 0xf1, 0xa4, 0xf8, 0xbf, 0xff, 0xd3, 0x81, 0xe6, 0x88, 0x27,
+	0xd3, 0x68, 0x66, 0x70, 0xf2, 0x08, 0x1a, 0x31, 0x5b, 0x8d, 0x97, 0x28, 0xb2, 0x88, 0x27, 0x59,
+	0xc7, 0xe9, 0x39, 0x83, 0x26, 0xf5, 0x63, 0xb6, 0xfa, 0x62, 0x20, 0x45, 0x99, 0xb0, 0x6c, 0x2c,
+	0xf0, 0x5b, 0x1e, 0x09, 0x0c, 0x3b, 0x6e, 0xcf, 0x19, 0xd4, 0xa8, 0x3f, 0x61, 0x19, 0x35, 0x10,
+	0xb9, 0x86, 0xf3, 0x10, 0x17, 0x28, 0xd1, 0x16, 0x1a, 0xb3, 0xa9, 0x44, 0xd1, 0xa9, 0xf4, 0x9c,
+	0x81, 0x3f, 0xbc, 0x1b, 0x14, 0x63, 0x05, 0x76, 0xac, 0xe0, 0x8d, 0x69, 0x4f, 0x49, 0x21, 0x33,
+	0xbd, 0xae, 0x94, 0xa8, 0xff, 0xcb, 0x81, 0xb6, 0x01, 0x3e, 0xa0, 0x64, 0x21, 0x93, 0x8c, 0x5c,
+	0x42, 0x63, 0x22, 0x90, 0x49, 0x0c, 0xc7, 0x6a, 0x67, 0x3d, 0xa6, 0x3f, 0xec, 0xfe, 0x51, 0xf8,
+	0x93, 0x7d, 0x21, 0xd4, 0x37, 0x7c, 0x85, 0x90, 0xd7, 0xd0, 0xd4, 0x8d, 0xd4, 0x64, 0x5a, 0xef,
+	0x1e, 0xd4, 0x37, 0xac, 0x40, 0x17, 0xb8, 0x07, 0xf5, 0x10, 0x33, 0x29, 0xf8, 0x1a, 0x43, 0xbd,
+	0x55, 0x8d, 0x6e, 0x01, 0x32, 0x82, 0x36, 0xae, 0xd2, 0x48, 0xb0, 0x6d, 0x03, 0xef, 0x60, 0x83,
+	0xd6, 0x56, 0xa2, 0xc0, 0xfe, 0x8f, 0x2a, 0xf8, 0xd7, 0xb8, 0xde, 0xac, 0x7c, 0x07, 0x2a, 0x73,
+	0x5c, 0xeb, 0x4d, 0xeb, 0x54, 0x3d, 0x92, 0x97, 0x50, 0xdb, 0xf8, 0xe4, 0xf6, 0x2a, 0x03, 0x7f,
+	0x78, 0x3f, 0x98, 0x2f, 0x83, 0x1d, 0x51, 0x60, 0x4d, 0x7b, 0x9b, 0x48, 0xb1, 0xa6, 0x1b, 0x3a,
+	0x79, 0x0a, 0xed, 0x49, 0x2e, 0x04, 0x26, 0xd2, 0x3a, 0xa4, 0xb7, 0xf0, 0x68, 0xcb, 0xc0, 0x46,
+	0x48, 0x9e, 0x40, 0x8b, 0x2f, 0xd4, 0x66, 0x1b, 0x9e, 0xa7, 0x79, 0xcd, 0x02, 0xb5, 0xb4, 0xb2,
+	0x1f, 0xd5, 0xe3, 0xfc, 0xb8, 0x84, 0x46, 0x9e, 0x86, 0x5b, 0xf9, 0xc9, 0x61, 0xb9, 0xe1, 0x6b,
+	0x79, 0x39, 0xb4, 0xa7, 0x87, 0x43, 0x5b, 0xfb, 0xfb, 0xd0, 0xd6, 0xff, 0x21, 0xb4, 0xe4, 0xbd,
+	0x7a, 0xc1, 0x99, 0xe4, 0xf1, 0x38, 0x36, 0x5e, 0x74, 0x40, 0x5b, 0xf4, 0xb8, 0x6c, 0xd1, 0x48,
+	0xd3, 0xec, 0xcf, 0xc2, 0xa8, 0xd6, 0xe4, 0x16, 0xd8, 0xfd, 0x08, 0xcd, 0x5b, 0x4e, 0xee, 0x86,
+	0xc1, 0x2b, 0xc2, 0xf0, 0x0c, 0xaa, 0x4b, 0xb6, 0xc8, 0x6d, 0x94, 0xcf, 0x54, 0x9b, 0xd2, 0xd5,
+	0xd0, 0x82, 0xf1, 0xca, 0x7d, 0xe1, 0x74, 0xaf, 0xe0, 0x6c, 0x4f, 0xe3, 0x3d, 0x21, 0x3b, 0xdf,
+	0xad, 0x5b, 0xdf, 0x29, 0xd1, 0xff, 0xee, 0xc0, 0xa9, 0xf5, 0x9f, 0x80, 0xa7, 0x77, 0x54, 0xc2,
+	0x06, 0xf5, 0xf6, 0xde, 0xa8, 0xfb, 0x9f, 0x37, 0x5a, 0x39, 0xee, 0x46, 0xfb, 0x5f, 0xc1, 0xff,
+	0x9c, 0xce, 0x04, 0x0b, 0xf1, 0x5d, 0x32, 0xe5, 0x6a, 0x9c, 0x4c, 0x32, 0x71, 0xcc, 0x27, 0xc3,
+	0xf0, 0xf5, 0x38, 0x6a, 0x43, 0x9e, 0xa0, 0xf9, 0xda, 0xe9, 0xe7, 0x9b, 0x13, 0x2d, 0x7a, 0xfe,
+	0x7b, 0x00, 0x6f, 0xd0, 0x94, 0x0a, 0x99, 0x05, 0x00, 0x00,
 }
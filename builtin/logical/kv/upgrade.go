@@ -18,6 +18,92 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	// upgradeProgressPath is the path of the progress of the upgrade, relative
+	// to the storage prefix
+	upgradeProgressPath = "upgrade-progress"
+
+	// upgradeCheckpointInterval is the number of keys upgraded between two
+	// writes of the upgrade progress
+	upgradeCheckpointInterval = 100
+
+	upgradeStatusPending = "pending"
+	upgradeStatusRunning = "running"
+	upgradeStatusFailed  = "failed"
+	upgradeStatusDone    = "done"
+)
+
+// UpgradeProgress is the progress of the upgrade of a mount from
+// non-versioned to versioned data. An interrupted or failed upgrade resumes
+// when the backend is loaded again, with the keys which were not upgraded
+// yet. Checkpoint is the last key upgraded.
+type UpgradeProgress struct {
+	Status        string     `json:"status"`
+	KeysTotal     int        `json:"keys_total"`
+	KeysUpgraded  int        `json:"keys_upgraded"`
+	Checkpoint    string     `json:"checkpoint"`
+	StartedTime   time.Time  `json:"started_time"`
+	UpdatedTime   time.Time  `json:"updated_time"`
+	CompletedTime *time.Time `json:"completed_time,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// pathUpgradeStatus returns the path reporting the progress of the upgrade. It
+// is available while the upgrade is running.
+func pathUpgradeStatus(b *versionedKVBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "upgrade-status$",
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathUpgradeStatusRead(),
+				Summary:  "Read the progress of the upgrade from non-versioned to versioned data.",
+			},
+		},
+
+		HelpSynopsis:    upgradeStatusHelpSyn,
+		HelpDescription: upgradeStatusHelpDesc,
+	}
+}
+
+func (b *versionedKVBackend) pathUpgradeStatusRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		progress, err := b.upgradeProgress(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		if progress == nil {
+			// Upgrades done before the progress was recorded
+			done, err := b.upgradeDone(ctx, req.Storage)
+			if err != nil {
+				return nil, err
+			}
+			progress = &UpgradeProgress{Status: upgradeStatusPending}
+			if done {
+				progress.Status = upgradeStatusDone
+			}
+		}
+
+		rdata := map[string]interface{}{
+			"status":        progress.Status,
+			"keys_total":    progress.KeysTotal,
+			"keys_upgraded": progress.KeysUpgraded,
+			"checkpoint":    progress.Checkpoint,
+			"error":         progress.Error,
+		}
+		if !progress.StartedTime.IsZero() {
+			rdata["started_time"] = progress.StartedTime.Format(time.RFC3339Nano)
+			rdata["updated_time"] = progress.UpdatedTime.Format(time.RFC3339Nano)
+		}
+		if progress.CompletedTime != nil {
+			rdata["completed_time"] = progress.CompletedTime.Format(time.RFC3339Nano)
+		}
+
+		return &logical.Response{
+			Data: rdata,
+		}, nil
+	}
+}
+
 func (b *versionedKVBackend) perfSecondaryCheck() bool {
 	replState := b.System().ReplicationState()
 	if (!b.System().LocalMount() && replState.HasState(consts.ReplicationPerformanceSecondary)) ||
@@ -212,10 +298,48 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 			}
 		}
 
+		// Resume the progress of an interrupted upgrade. The keys it upgraded
+		// were deleted, so only the remaining ones are collected.
+		progress, err := b.upgradeProgress(ctx, s)
+		if err != nil {
+			b.Logger().Error("reading upgrade progress resulted in an error", "error", err)
+			return
+		}
+		if progress == nil {
+			progress = &UpgradeProgress{
+				StartedTime: time.Now().UTC(),
+			}
+		}
+		progress.Status = upgradeStatusRunning
+		progress.Error = ""
+		failed := func(err error) {
+			progress.Status = upgradeStatusFailed
+			progress.Error = err.Error()
+			if err := b.writeUpgradeProgress(ctx, s, progress); err != nil {
+				b.Logger().Error("writing upgrade progress resulted in an error", "error", err)
+			}
+		}
+
 		b.Logger().Info("collecting keys to upgrade")
 		keys, err := logical.CollectKeys(ctx, s)
 		if err != nil {
 			b.Logger().Error("upgrading resulted in error", "error", err)
+			failed(err)
+			return
+		}
+
+		var remaining int
+		for _, key := range keys {
+			if !strings.HasPrefix(key, b.storagePrefix) {
+				remaining++
+			}
+		}
+		if progress.KeysTotal < progress.KeysUpgraded+remaining {
+			progress.KeysTotal = progress.KeysUpgraded + remaining
+		}
+		progress.KeysUpgraded = progress.KeysTotal - remaining
+		if err := b.writeUpgradeProgress(ctx, s, progress); err != nil {
+			b.Logger().Error("writing upgrade progress resulted in an error", "error", err)
 			return
 		}
 
@@ -224,11 +348,23 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 			if b.Logger().IsDebug() && i%500 == 0 {
 				b.Logger().Debug("upgrading keys", "progress", fmt.Sprintf("%d/%d", i, len(keys)))
 			}
+			if strings.HasPrefix(key, b.storagePrefix) {
+				continue
+			}
 			err := upgradeKey(key)
 			if err != nil {
 				b.Logger().Error("upgrading resulted in error", "error", err, "progress", fmt.Sprintf("%d/%d", i+1, len(keys)))
+				failed(err)
 				return
 			}
+
+			progress.KeysUpgraded++
+			progress.Checkpoint = key
+			if progress.KeysUpgraded%upgradeCheckpointInterval == 0 {
+				if err := b.writeUpgradeProgress(ctx, s, progress); err != nil {
+					b.Logger().Error("writing upgrade progress resulted in an error", "error", err)
+				}
+			}
 		}
 
 		b.Logger().Info("upgrading keys finished")
@@ -256,8 +392,52 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 			b.Logger().Error("writing upgrade done resulted in an error", "error", err)
 		}
 
+		completedTime := time.Now().UTC()
+		progress.Status = upgradeStatusDone
+		progress.CompletedTime = &completedTime
+		if err := b.writeUpgradeProgress(ctx, s, progress); err != nil {
+			b.Logger().Error("writing upgrade progress resulted in an error", "error", err)
+		}
+
 		atomic.StoreUint32(b.upgrading, 0)
 	}()
 
 	return nil
 }
+
+// upgradeProgress returns the progress of the upgrade from non-versioned to
+// versioned data, or nil if it has not started or was done before progress
+// was recorded.
+func (b *versionedKVBackend) upgradeProgress(ctx context.Context, s logical.Storage) (*UpgradeProgress, error) {
+	entry, err := s.Get(ctx, path.Join(b.storagePrefix, upgradeProgressPath))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var progress UpgradeProgress
+	if err := entry.DecodeJSON(&progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (b *versionedKVBackend) writeUpgradeProgress(ctx context.Context, s logical.Storage, progress *UpgradeProgress) error {
+	progress.UpdatedTime = time.Now().UTC()
+	entry, err := logical.StorageEntryJSON(path.Join(b.storagePrefix, upgradeProgressPath), progress)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+const upgradeStatusHelpSyn = `Reports the progress of the upgrade from non-versioned to versioned data.`
+const upgradeStatusHelpDesc = `
+The mount is unavailable while its non-versioned keys are upgraded to
+versioned ones. This path returns the status of the upgrade, the number of
+keys to upgrade and upgraded so far, and the last key upgraded. An interrupted
+or failed upgrade resumes with the remaining keys when the mount is loaded
+again.
+`
@@ -0,0 +1,76 @@
+package kv
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestVersionedKV_UpgradeProgress(t *testing.T) {
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+
+	// Non-versioned keys left by an upgrade interrupted after upgrading one
+	// key out of three
+	for _, key := range []string{"a", "b/c"} {
+		if err := storage.Put(ctx, &logical.StorageEntry{Key: key, Value: []byte(`{"foo":"bar"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	startedTime := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	entry, err := logical.StorageEntryJSON("test/"+upgradeProgressPath, &UpgradeProgress{
+		Status:       upgradeStatusRunning,
+		KeysTotal:    3,
+		KeysUpgraded: 1,
+		Checkpoint:   "0",
+		StartedTime:  startedTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Put(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := VersionedKVFactory(ctx, &logical.BackendConfig{
+		Logger:      logging.NewVaultLogger(hclog.Trace),
+		System:      &logical.StaticSystemView{},
+		StorageView: storage,
+		BackendUUID: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadUint32(b.(*versionedKVBackend).upgrading) == 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the upgrade")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp := mustRequest(t, b, storage, logical.ReadOperation, "upgrade-status", nil)
+	if resp.Data["status"] != upgradeStatusDone || resp.Data["keys_total"] != 3 || resp.Data["keys_upgraded"] != 3 || resp.Data["checkpoint"] != "b/c" {
+		t.Fatalf("bad status: %#v", resp.Data)
+	}
+	if resp.Data["started_time"] != startedTime.Format(time.RFC3339Nano) || resp.Data["completed_time"] == nil {
+		t.Fatalf("bad times: %#v", resp.Data)
+	}
+
+	resp = mustRequest(t, b, storage, logical.ReadOperation, "data/b/c", nil)
+	if resp.Data["data"].(map[string]interface{})["foo"] != "bar" {
+		t.Fatalf("bad data: %#v", resp.Data)
+	}
+
+	// A fresh mount has nothing to upgrade
+	b, s := getBackend(t)
+	resp = mustRequest(t, b, s, logical.ReadOperation, "upgrade-status", nil)
+	if resp.Data["status"] != upgradeStatusDone || resp.Data["keys_total"] != 0 {
+		t.Fatalf("bad status: %#v", resp.Data)
+	}
+}
@@ -22,6 +22,8 @@ func pathConfigLease(b *backend) *framework.Path {
 				Type: framework.TypeString,
 				Description: `Deprecated: use "max_ttl" instead.  Maximum
 time a credential is valid for.`,
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"max_ttl": &framework.FieldSchema{
@@ -83,16 +85,13 @@ func (b *backend) pathConfigLeaseRead(ctx context.Context, req *logical.Request,
 		return nil, nil
 	}
 
-	resp := &logical.Response{
+	return &logical.Response{
 		Data: map[string]interface{}{
 			"ttl":     leaseConfig.TTL.String(),
 			"ttl_max": leaseConfig.TTLMax.String(),
 			"max_ttl": leaseConfig.TTLMax.String(),
 		},
-	}
-	resp.AddWarning("The field ttl_max is deprecated and will be removed in a future release. Use max_ttl instead.")
-
-	return resp, nil
+	}, nil
 }
 
 type configLease struct {
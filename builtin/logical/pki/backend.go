@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -33,12 +34,18 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/delta/pem",
+				"crl/delta",
+				"ocsp",
+				"ocsp/*",
+				".well-known/est/*",
 			},
 
 			LocalStorage: []string{
 				"revoked/",
 				"crl",
 				"certs/",
+				"config/ocsp_responder",
 			},
 
 			Root: []string{
@@ -48,6 +55,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 			SealWrapStorage: []string{
 				"config/ca_bundle",
+				"config/ocsp_responder",
 			},
 		},
 
@@ -60,33 +68,62 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathDeleteRoot(&b),
 			pathGenerateIntermediate(&b),
 			pathSetSignedIntermediate(&b),
+			pathGenerateCrossSignCSR(&b),
+			pathSetCrossSigned(&b),
+			pathCrossSigned(&b),
 			pathConfigCA(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigOCSP(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
+			pathReissue(&b),
 			pathRotateCRL(&b),
 			pathFetchCA(&b),
 			pathFetchCAChain(&b),
 			pathFetchCRL(&b),
+			pathFetchDeltaCRL(&b),
 			pathFetchCRLViaCertPath(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
 			pathRevoke(&b),
 			pathTidy(&b),
+			pathTidyStatus(&b),
+			pathConfigAutoTidy(&b),
+			pathQuarantine(&b),
+			pathOCSP(&b),
+			pathOCSPGet(&b),
+			pathConfigEST(&b),
+			pathESTCACerts(&b),
+			pathESTEnroll(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
 
+		PeriodicJobs: []*framework.PeriodicJob{
+			{
+				Name: "crl-rebuild",
+				Func: b.periodicRebuildCRL,
+			},
+			{
+				Name: "auto-tidy",
+				Func: b.periodicAutoTidy,
+			},
+		},
+
+		Invalidate: b.invalidate,
+
 		BackendType: logical.TypeLogical,
 	}
 
 	b.crlLifetime = time.Hour * 72
 	b.tidyCASGuard = new(uint32)
+	b.lastAutoTidy = time.Now()
 	b.storage = conf.StorageView
+	b.ocspCache, _ = lru.New(ocspCacheSize)
 
 	return &b
 }
@@ -98,6 +135,24 @@ type backend struct {
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
 	tidyCASGuard      *uint32
+
+	// tidyStatusLock protects the status of the last tidy operation and the
+	// start time of the last automatic one
+	tidyStatusLock sync.RWMutex
+	tidyStatus     *tidyStatus
+	lastAutoTidy   time.Time
+
+	// ocspLock protects the issuance of the delegated OCSP responder, and
+	// ocspCache holds the signed OCSP responses
+	ocspLock  sync.Mutex
+	ocspCache *lru.Cache
+}
+
+func (b *backend) invalidate(ctx context.Context, key string) {
+	switch {
+	case strings.HasPrefix(key, "revoked/"), key == "config/ocsp":
+		b.ocspCache.Purge()
+	}
 }
 
 const backendHelp = `
@@ -261,6 +261,9 @@ func runSteps(t *testing.T, rootB, intB *backend, client *api.Client, rootName,
 			if resp.Data["http_content_type"].(string) != "application/pkix-cert" {
 				t.Fatal("wrong content type")
 			}
+			if resp.ETag() != logical.NewETag([]byte(caCert)) {
+				t.Fatalf("bad ETag: %q", resp.ETag())
+			}
 		}
 
 		// ca (raw DER bytes)
@@ -9,7 +9,9 @@ import (
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
@@ -22,6 +24,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/ryanuber/go-glob"
@@ -130,6 +133,8 @@ func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial
 		path = "ca"
 	case serial == "crl":
 		path = "crl"
+	case serial == "delta-crl":
+		path = deltaCRLStoragePath
 	default:
 		legacyPath = "certs/" + colonSerial
 		path = "certs/" + hyphenSerial
@@ -423,6 +428,112 @@ func parseOtherSANs(others []string) (map[string][]string, error) {
 	return result, nil
 }
 
+var (
+	// Extensions under these OIDs are set by Vault from the role and the
+	// request, so they can't be given as custom extensions
+	oidCertificateExtensions = asn1.ObjectIdentifier{2, 5, 29}
+	oidAuthorityInfoAccess   = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 1}
+)
+
+// parseCustomExtensions parses custom extensions given as
+// "<oid>[;critical];<type>:<value>", where the type is utf8 or ia5 for a string
+// value, or hex for a hex-encoded DER value.
+func parseCustomExtensions(customs []string) ([]pkix.Extension, error) {
+	var result []pkix.Extension
+	for _, custom := range customs {
+		split := strings.Split(custom, ";")
+		if len(split) < 2 || len(split) > 3 {
+			return nil, fmt.Errorf("expected an OID and a value separated by a semicolon in custom extension %q", custom)
+		}
+
+		oid, err := stringToOid(split[0])
+		if err != nil {
+			return nil, fmt.Errorf("%q could not be parsed as a valid oid in custom extension %q", split[0], custom)
+		}
+		if (len(oid) > len(oidCertificateExtensions) && oid[:len(oidCertificateExtensions)].Equal(oidCertificateExtensions)) ||
+			oid.Equal(oidAuthorityInfoAccess) {
+			return nil, fmt.Errorf("extension %s is set by Vault and can't be given as custom extension %q", split[0], custom)
+		}
+		for _, ext := range result {
+			if ext.Id.Equal(oid) {
+				return nil, fmt.Errorf("duplicate custom extension %s", split[0])
+			}
+		}
+
+		ext := pkix.Extension{Id: oid}
+		if len(split) == 3 {
+			if !strings.EqualFold(split[1], "critical") {
+				return nil, fmt.Errorf("expected \"critical\" but found %q in custom extension %q", split[1], custom)
+			}
+			ext.Critical = true
+		}
+
+		splitType := strings.SplitN(split[len(split)-1], ":", 2)
+		if len(splitType) != 2 {
+			return nil, fmt.Errorf("expected a colon in custom extension %q", custom)
+		}
+		switch strings.ToLower(splitType[0]) {
+		case "utf8", "utf-8":
+			ext.Value, err = asn1.MarshalWithParams(splitType[1], "utf8")
+		case "ia5":
+			ext.Value, err = asn1.MarshalWithParams(splitType[1], "ia5")
+		case "hex":
+			ext.Value, err = hex.DecodeString(splitType[1])
+			if err == nil {
+				var raw asn1.RawValue
+				var rest []byte
+				rest, err = asn1.Unmarshal(ext.Value, &raw)
+				if err == nil && len(rest) > 0 {
+					err = errors.New("trailing data after DER value")
+				}
+			}
+		default:
+			return nil, fmt.Errorf("only utf8, ia5 and hex custom extensions are supported; found non-supported type in custom extension %q", custom)
+		}
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid value in custom extension %q: {{err}}", custom), err)
+		}
+
+		result = append(result, ext)
+	}
+
+	return result, nil
+}
+
+// parseIPRanges parses the CIDR blocks of IP range name constraints.
+func parseIPRanges(ranges []string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, r := range ranges {
+		_, ipNet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("%q could not be parsed as a CIDR block", r)
+		}
+		result = append(result, ipNet)
+	}
+	return result, nil
+}
+
+// setNameConstraints sets the name constraints of a CA certificate from the
+// request. Name constraints are only meaningful in CA certificates, so they are
+// not role options.
+func setNameConstraints(params *certutil.CreationParameters, apiData *framework.FieldData) error {
+	params.PermittedDNSDomains = apiData.Get("permitted_dns_domains").([]string)
+	params.ExcludedDNSDomains = apiData.Get("excluded_dns_domains").([]string)
+	params.PermittedEmailAddresses = apiData.Get("permitted_email_addresses").([]string)
+	params.ExcludedEmailAddresses = apiData.Get("excluded_email_addresses").([]string)
+	params.PermittedURIDomains = apiData.Get("permitted_uri_domains").([]string)
+	params.ExcludedURIDomains = apiData.Get("excluded_uri_domains").([]string)
+
+	var err error
+	if params.PermittedIPRanges, err = parseIPRanges(apiData.Get("permitted_ip_ranges").([]string)); err != nil {
+		return errutil.UserError{Err: errwrap.Wrapf("error parsing permitted_ip_ranges: {{err}}", err).Error()}
+	}
+	if params.ExcludedIPRanges, err = parseIPRanges(apiData.Get("excluded_ip_ranges").([]string)); err != nil {
+		return errutil.UserError{Err: errwrap.Wrapf("error parsing excluded_ip_ranges: {{err}}", err).Error()}
+	}
+	return nil
+}
+
 func validateSerialNumber(data *inputBundle, serialNumber string) string {
 	valid := false
 	if len(data.role.AllowedSerialNumbers) > 0 {
@@ -470,7 +581,9 @@ func generateCert(ctx context.Context,
 
 	if isCA {
 		data.Params.IsCA = isCA
-		data.Params.PermittedDNSDomains = input.apiData.Get("permitted_dns_domains").([]string)
+		if err := setNameConstraints(data.Params, input.apiData); err != nil {
+			return nil, err
+		}
 
 		if data.SigningBundle == nil {
 			// Generating a self-signed root certificate
@@ -505,6 +618,18 @@ func generateCert(ctx context.Context,
 
 // N.B.: This is only meant to be used for generating intermediate CAs.
 // It skips some sanity checks.
+// validateAltKeyType checks that the alternative key type of hybrid
+// certificates is valid and that ML-DSA is enabled, if it is set.
+func validateAltKeyType(altKeyType string) error {
+	if altKeyType == "" {
+		return nil
+	}
+	if !mldsautil.ValidParameterSet(altKeyType) {
+		return fmt.Errorf("unknown alternative key type %q", altKeyType)
+	}
+	return mldsautil.CheckEnabled()
+}
+
 func generateIntermediateCSR(b *backend, input *inputBundle) (*certutil.ParsedCSRBundle, error) {
 	creation, err := generateCreationBundle(b, input, nil, nil)
 	if err != nil {
@@ -594,6 +719,14 @@ func signCert(b *backend,
 				pubKey.Params().BitSize)}
 		}
 
+	case "ed25519":
+		// Verify that the key matches the role type
+		if csr.PublicKeyAlgorithm != x509.Ed25519 {
+			return nil, errutil.UserError{Err: fmt.Sprintf(
+				"role requires keys of type %s",
+				data.role.KeyType)}
+		}
+
 	case "any":
 		// We only care about running RSA < 2048 bit checks, so if not RSA
 		// break out
@@ -624,7 +757,9 @@ func signCert(b *backend,
 	creation.Params.UseCSRValues = useCSRValues
 
 	if isCA {
-		creation.Params.PermittedDNSDomains = data.apiData.Get("permitted_dns_domains").([]string)
+		if err := setNameConstraints(creation.Params, data.apiData); err != nil {
+			return nil, err
+		}
 	}
 
 	parsedBundle, err := certutil.SignCertificate(creation)
@@ -1063,11 +1198,18 @@ func generateCreationBundle(b *backend, data *inputBundle, caSign *certutil.CAIn
 			PolicyIdentifiers:             data.role.PolicyIdentifiers,
 			BasicConstraintsValidForNonCA: data.role.BasicConstraintsValidForNonCA,
 			NotBeforeDuration:             data.role.NotBeforeDuration,
+			AltKeyType:                    data.role.AltKeyType,
 		},
 		SigningBundle: caSign,
 		CSR:           csr,
 	}
 
+	// These were validated when the role was written
+	var err error
+	if creation.Params.CustomExtensions, err = parseCustomExtensions(data.role.CustomExtensions); err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
 	// Don't deal with URLs or max path length if it's self-signed, as these
 	// normally come from the signing bundle
 	if caSign == nil {
@@ -1140,6 +1282,9 @@ func convertRespToPKCS8(resp *logical.Response) error {
 		signer, err = x509.ParsePKCS1PrivateKey(keyData)
 	case certutil.ECPrivateKey:
 		signer, err = x509.ParseECPrivateKey(keyData)
+	case certutil.Ed25519PrivateKey:
+		// Ed25519 keys are always PKCS#8 encoded already
+		return nil
 	default:
 		return fmt.Errorf("unknown private key type %q", privKeyType)
 	}
@@ -1,11 +1,17 @@
 package pki
 
 import (
+	"context"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -122,3 +128,175 @@ func TestBackend_CRL_EnableDisable(t *testing.T) {
 	toggle(false)
 	test(6)
 }
+
+func TestBackend_CRL_DeltaAutoRebuild(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	// fetchCRL returns the CRL or delta CRL along with its CRL number and,
+	// for a delta CRL, the number of its base CRL
+	fetchCRL := func(path string) (*pkix.CertificateList, int64, int64) {
+		t.Helper()
+		resp := handle(logical.ReadOperation, path, nil)
+		crl, err := x509.ParseCRL(resp.Data[logical.HTTPRawBody].([]byte))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var number, base int64
+		for _, ext := range crl.TBSCertList.Extensions {
+			var value *big.Int
+			switch {
+			case ext.Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 20}):
+				if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+					t.Fatal(err)
+				}
+				number = value.Int64()
+			case ext.Id.Equal(oidDeltaCRLIndicator):
+				if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+					t.Fatal(err)
+				}
+				base = value.Int64()
+			}
+		}
+		return crl, number, base
+	}
+	// age moves the last builds of the CRLs back in time so that they are
+	// due for rebuilding
+	age := func(d time.Duration) {
+		t.Helper()
+		state, err := fetchCRLState(context.Background(), storage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.BaseNextUpdate = state.BaseNextUpdate.Add(-d)
+		state.DeltaThisUpdate = state.DeltaThisUpdate.Add(-d)
+		if err := storeCRLState(context.Background(), storage, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	periodic := func() {
+		t.Helper()
+		if err := b.periodicRebuildCRL(context.Background(), &logical.Request{Storage: storage}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing is rebuilt before a CA is configured
+	handle(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"auto_rebuild": true,
+	})
+	periodic()
+
+	handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+	})
+	handle(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": true,
+	})
+
+	resp := handle(logical.ReadOperation, "config/crl", nil)
+	if resp.Data["auto_rebuild"] != true || resp.Data["enable_delta"] != true ||
+		resp.Data["auto_rebuild_grace_period"] != "12h" || resp.Data["delta_rebuild_interval"] != "15m" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	crl, number, _ := fetchCRL("crl")
+	if len(crl.TBSCertList.RevokedCertificates) != 0 || number == 0 {
+		t.Fatalf("bad CRL: number %d, %d entries", number, len(crl.TBSCertList.RevokedCertificates))
+	}
+	delta, deltaNumber, base := fetchCRL("crl/delta")
+	if len(delta.TBSCertList.RevokedCertificates) != 0 || base != number || deltaNumber <= number {
+		t.Fatalf("bad delta CRL: number %d, base %d", deltaNumber, base)
+	}
+
+	revoke := func() {
+		t.Helper()
+		cert := handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+			"common_name": "foo.myvault.com",
+		}).Data["certificate"].(string)
+		parsed, err := certutil.ParsePEMBundle(cert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handle(logical.UpdateOperation, "revoke", map[string]interface{}{
+			"serial_number": certutil.GetHexFormatted(parsed.Certificate.SerialNumber.Bytes(), ":"),
+		})
+	}
+
+	// Revocations don't rebuild the CRLs until they are due
+	revoke()
+	periodic()
+	if crl, _, _ := fetchCRL("crl"); len(crl.TBSCertList.RevokedCertificates) != 0 {
+		t.Fatalf("expected the CRL not to be rebuilt")
+	}
+	if delta, _, _ := fetchCRL("crl/delta"); len(delta.TBSCertList.RevokedCertificates) != 0 {
+		t.Fatalf("expected the delta CRL not to be rebuilt")
+	}
+
+	age(15 * time.Minute)
+	periodic()
+	if crl, _, _ := fetchCRL("crl"); len(crl.TBSCertList.RevokedCertificates) != 0 {
+		t.Fatalf("expected the CRL not to be rebuilt")
+	}
+	delta, newDeltaNumber, base := fetchCRL("crl/delta")
+	if len(delta.TBSCertList.RevokedCertificates) != 1 || base != number || newDeltaNumber <= deltaNumber {
+		t.Fatalf("bad delta CRL: number %d, base %d, %d entries", newDeltaNumber, base, len(delta.TBSCertList.RevokedCertificates))
+	}
+
+	// The CRL is rebuilt within the grace period of its expiry, emptying the
+	// delta CRL
+	age(61 * time.Hour)
+	periodic()
+	crl, newNumber, _ := fetchCRL("crl")
+	if len(crl.TBSCertList.RevokedCertificates) != 1 || newNumber <= newDeltaNumber {
+		t.Fatalf("bad CRL: number %d, %d entries", newNumber, len(crl.TBSCertList.RevokedCertificates))
+	}
+	if delta, _, base := fetchCRL("crl/delta"); len(delta.TBSCertList.RevokedCertificates) != 0 || base != newNumber {
+		t.Fatalf("bad delta CRL: base %d", base)
+	}
+	resp = handle(logical.ReadOperation, "cert/delta-crl", nil)
+	if resp.Data["certificate"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Disabling the delta CRL removes it
+	handle(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"enable_delta": false,
+	})
+	resp = handle(logical.ReadOperation, "crl/delta", nil)
+	if resp.Data[logical.HTTPStatusCode] != 204 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"auto_rebuild": false, "enable_delta": true},
+		{"auto_rebuild_grace_period": "72h"},
+		{"delta_rebuild_interval": "0s"},
+	} {
+		resp, err := request(logical.UpdateOperation, "config/crl", data)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected error for %v, got err:%v resp:%#v", data, err, resp)
+		}
+	}
+}
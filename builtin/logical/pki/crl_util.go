@@ -5,8 +5,10 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -16,12 +18,33 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	// deltaCRLStoragePath holds the delta CRL, and crlStateStoragePath the
+	// numbering and build times of the CRLs
+	deltaCRLStoragePath = "crl/delta"
+	crlStateStoragePath = "crl/state"
+)
+
+// oidDeltaCRLIndicator is the extension marking a CRL as a delta CRL of the
+// complete CRL with the given CRL number, see RFC 5280 section 5.2.4
+var oidDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+
 type revocationInfo struct {
 	CertificateBytes  []byte    `json:"certificate_bytes"`
 	RevocationTime    int64     `json:"revocation_time"`
 	RevocationTimeUTC time.Time `json:"revocation_time_utc"`
 }
 
+// crlState tracks the CRL numbers, which are shared between the complete and
+// delta CRLs, and when the CRLs were last built.
+type crlState struct {
+	Number          int64     `json:"number"`
+	BaseNumber      int64     `json:"base_number"`
+	BaseThisUpdate  time.Time `json:"base_this_update"`
+	BaseNextUpdate  time.Time `json:"base_next_update"`
+	DeltaThisUpdate time.Time `json:"delta_this_update"`
+}
+
 // Revokes a cert, and tries to be smart about error recovery
 func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial string, fromLease bool) (*logical.Response, error) {
 	// As this backend is self-contained and this function does not hook into
@@ -125,14 +148,25 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 			return nil, fmt.Errorf("error saving revoked certificate to new location")
 		}
 
+		b.ocspCache.Purge()
+
 	}
 
-	crlErr := buildCRL(ctx, b, req, false)
-	switch crlErr.(type) {
-	case errutil.UserError:
-		return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
-	case errutil.InternalError:
-		return nil, errwrap.Wrapf("error encountered during CRL building: {{err}}", crlErr)
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return nil, errwrap.Wrapf("error fetching CRL config information: {{err}}", err)
+	}
+
+	// With automatic rebuilding, the revocation is picked up by the next
+	// scheduled build of the delta or complete CRL instead
+	if crlInfo == nil || !crlInfo.AutoRebuild {
+		crlErr := buildCRL(ctx, b, req, false)
+		switch crlErr.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
+		case errutil.InternalError:
+			return nil, errwrap.Wrapf("error encountered during CRL building: {{err}}", crlErr)
+		}
 	}
 
 	resp := &logical.Response{
@@ -154,56 +188,184 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bo
 		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
 	}
 
-	crlLifetime := b.crlLifetime
+	crlLifetime, err := b.crlExpiry(crlInfo)
+	if err != nil {
+		return err
+	}
+
 	var revokedCerts []pkix.RevokedCertificate
-	var revInfo revocationInfo
-	var revokedSerials []string
+	var state *crlState
+	now := time.Now()
 
-	if crlInfo != nil {
-		if crlInfo.Expiry != "" {
-			crlDur, err := time.ParseDuration(crlInfo.Expiry)
-			if err != nil {
-				return errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
-			}
-			crlLifetime = crlDur
+	if crlInfo != nil && crlInfo.Disable {
+		if !forceNew {
+			return nil
 		}
+		goto WRITE
+	}
 
-		if crlInfo.Disable {
-			if !forceNew {
-				return nil
-			}
-			goto WRITE
+	revokedCerts, err = fetchRevokedCerts(ctx, req, time.Time{})
+	if err != nil {
+		return err
+	}
+
+WRITE:
+	signingBundle, caErr := fetchCAInfo(ctx, req)
+	switch caErr.(type) {
+	case errutil.UserError:
+		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
+	case errutil.InternalError:
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+	}
+
+	state, err = fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+	state.Number++
+
+	crlBytes, err := createCRL(signingBundle, revokedCerts, state.Number, nil, now, now.Add(crlLifetime))
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+	}
+
+	err = req.Storage.Put(ctx, &logical.StorageEntry{
+		Key:   "crl",
+		Value: crlBytes,
+	})
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+	}
+
+	state.BaseNumber = state.Number
+	state.BaseThisUpdate = now
+	state.BaseNextUpdate = now.Add(crlLifetime)
+	if err := storeCRLState(ctx, req.Storage, state); err != nil {
+		return err
+	}
+
+	// The delta CRL is relative to the complete CRL, so replace it as well
+	if crlInfo != nil && crlInfo.EnableDelta && !crlInfo.Disable {
+		return buildDeltaCRL(ctx, b, req)
+	}
+	if err := req.Storage.Delete(ctx, deltaCRLStoragePath); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error deleting delta CRL: %s", err)}
+	}
+
+	return nil
+}
+
+// Builds a delta CRL listing the certificates revoked since the last complete
+// CRL was built.
+func buildDeltaCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+	}
+	if crlInfo == nil || !crlInfo.EnableDelta || crlInfo.Disable {
+		return nil
+	}
+
+	crlLifetime, err := b.crlExpiry(crlInfo)
+	if err != nil {
+		return err
+	}
+
+	signingBundle, caErr := fetchCAInfo(ctx, req)
+	switch caErr.(type) {
+	case errutil.UserError:
+		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
+	case errutil.InternalError:
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+	}
+
+	if !canSignNumberedCRL(signingBundle.Certificate) {
+		b.Logger().Warn("not building delta CRL as the CA certificate lacks the crlSign key usage or a subject key identifier")
+		if err := req.Storage.Delete(ctx, deltaCRLStoragePath); err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error deleting delta CRL: %s", err)}
 		}
+		return nil
 	}
 
-	revokedSerials, err = req.Storage.List(ctx, "revoked/")
+	state, err := fetchCRLState(ctx, req.Storage)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+	// The complete CRL predates CRL numbering, so there is nothing for a
+	// delta to refer to; rebuilding it builds the delta as well
+	if state.BaseNumber == 0 {
+		return buildCRL(ctx, b, req, false)
+	}
+
+	now := time.Now()
+	revokedCerts, err := fetchRevokedCerts(ctx, req, state.BaseThisUpdate)
+	if err != nil {
+		return err
+	}
+
+	baseNumber, err := asn1.Marshal(big.NewInt(state.BaseNumber))
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error encoding base CRL number: %s", err)}
+	}
+	state.Number++
+
+	crlBytes, err := createCRL(signingBundle, revokedCerts, state.Number, []pkix.Extension{
+		{
+			Id:       oidDeltaCRLIndicator,
+			Critical: true,
+			Value:    baseNumber,
+		},
+	}, now, now.Add(crlLifetime))
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error creating new delta CRL: %s", err)}
+	}
+
+	err = req.Storage.Put(ctx, &logical.StorageEntry{
+		Key:   deltaCRLStoragePath,
+		Value: crlBytes,
+	})
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing delta CRL: %s", err)}
+	}
+
+	state.DeltaThisUpdate = now
+	return storeCRLState(ctx, req.Storage, state)
+}
+
+// fetchRevokedCerts returns the CRL entries of the revoked certificates. If
+// since is set, only the certificates revoked since then are returned.
+func fetchRevokedCerts(ctx context.Context, req *logical.Request, since time.Time) ([]pkix.RevokedCertificate, error) {
+	var revokedCerts []pkix.RevokedCertificate
+	var revInfo revocationInfo
+
+	revokedSerials, err := req.Storage.List(ctx, "revoked/")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching list of revoked certs: %s", err)}
 	}
 
 	for _, serial := range revokedSerials {
 		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
 		}
 		if revokedEntry == nil {
-			return errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
 		}
 		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
 			// TODO: In this case, remove it and continue? How likely is this to
 			// happen? Alternately, could skip it entirely, or could implement a
 			// delete function so that there is a way to remove these
-			return errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
 		}
 
 		err = revokedEntry.DecodeJSON(&revInfo)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
 		}
 
 		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
 		}
 
 		// NOTE: We have to change this to UTC time because the CRL standard
@@ -211,34 +373,129 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bo
 		newRevCert := pkix.RevokedCertificate{
 			SerialNumber: revokedCert.SerialNumber,
 		}
+		revokedSince := since
 		if !revInfo.RevocationTimeUTC.IsZero() {
 			newRevCert.RevocationTime = revInfo.RevocationTimeUTC
 		} else {
 			newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+			// Legacy revocation times only have a second granularity
+			revokedSince = since.Truncate(time.Second)
+		}
+		if newRevCert.RevocationTime.Before(revokedSince) {
+			continue
 		}
 		revokedCerts = append(revokedCerts, newRevCert)
 	}
 
-WRITE:
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
-	case errutil.UserError:
-		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
-	case errutil.InternalError:
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+	return revokedCerts, nil
+}
+
+// canSignNumberedCRL returns whether the CA certificate can sign CRLs carrying
+// extensions, which requires the crlSign key usage and a subject key
+// identifier.
+func canSignNumberedCRL(cert *x509.Certificate) bool {
+	return cert.KeyUsage&x509.KeyUsageCRLSign != 0 && len(cert.SubjectKeyId) != 0
+}
+
+// createCRL signs a CRL with the given CRL number and extensions. CAs which
+// can't sign numbered CRLs get a plain CRL, as before numbering was added.
+func createCRL(signingBundle *certutil.CAInfoBundle, revokedCerts []pkix.RevokedCertificate, number int64, extensions []pkix.Extension, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	if !canSignNumberedCRL(signingBundle.Certificate) {
+		if len(extensions) != 0 {
+			return nil, errors.New("CA certificate cannot sign CRL extensions")
+		}
+		return signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, thisUpdate, nextUpdate)
 	}
 
-	crlBytes, err := signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, time.Now(), time.Now().Add(crlLifetime))
+	return x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificates: revokedCerts,
+		Number:              big.NewInt(number),
+		ThisUpdate:          thisUpdate,
+		NextUpdate:          nextUpdate,
+		ExtraExtensions:     extensions,
+	}, signingBundle.Certificate, signingBundle.PrivateKey)
+}
+
+// fetchCRLState returns the numbering and build times of the CRLs, or an empty
+// state if no numbered CRL has been built yet.
+func fetchCRLState(ctx context.Context, s logical.Storage) (*crlState, error) {
+	entry, err := s.Get(ctx, crlStateStoragePath)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+		return nil, err
 	}
 
-	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "crl",
-		Value: crlBytes,
-	})
+	var state crlState
+	if entry == nil {
+		return &state, nil
+	}
+	if err := entry.DecodeJSON(&state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func storeCRLState(ctx context.Context, s logical.Storage, state *crlState) error {
+	entry, err := logical.StorageEntryJSON(crlStateStoragePath, state)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("error encoding CRL state: %s", err)}
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL state: %s", err)}
+	}
+	return nil
+}
+
+// periodicRebuildCRL rebuilds the complete CRL ahead of its expiry, and the
+// delta CRL on its interval, when automatic rebuilding is configured.
+func (b *backend) periodicRebuildCRL(ctx context.Context, req *logical.Request) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return errwrap.Wrapf("error fetching CRL config information: {{err}}", err)
+	}
+	if crlInfo == nil || !crlInfo.AutoRebuild || crlInfo.Disable {
+		return nil
+	}
+
+	gracePeriod, err := time.ParseDuration(crlInfo.autoRebuildGracePeriod())
+	if err != nil {
+		return errwrap.Wrapf("error parsing CRL auto rebuild grace period: {{err}}", err)
+	}
+	deltaInterval, err := time.ParseDuration(crlInfo.deltaRebuildInterval())
+	if err != nil {
+		return errwrap.Wrapf("error parsing delta CRL rebuild interval: {{err}}", err)
+	}
+
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	state, err := fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return errwrap.Wrapf("error fetching CRL state: {{err}}", err)
+	}
+
+	now := time.Now()
+	rebuild := now.Add(gracePeriod).After(state.BaseNextUpdate)
+	rebuildDelta := crlInfo.EnableDelta && !now.Before(state.DeltaThisUpdate.Add(deltaInterval))
+	if !rebuild && !rebuildDelta {
+		return nil
+	}
+
+	// There is nothing to rebuild until a CA is configured
+	if _, err := fetchCAInfo(ctx, req); err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return nil
+		}
+		return err
+	}
+
+	if rebuild {
+		err = buildCRL(ctx, b, req, false)
+	} else {
+		err = buildDeltaCRL(ctx, b, req)
+	}
+	if err != nil {
+		return errwrap.Wrapf("error encountered during CRL building: {{err}}", err)
 	}
 
 	return nil
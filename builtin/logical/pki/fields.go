@@ -248,7 +248,7 @@ the private key!`,
 		Default: 2048,
 		Description: `The number of bits to use. You will almost
 certainly want to change this if you adjust
-the key_type.`,
+the key_type. Ignored for ed25519.`,
 		DisplayAttrs: &framework.DisplayAttributes{
 			Value: 2048,
 		},
@@ -257,9 +257,9 @@ the key_type.`,
 	fields["key_type"] = &framework.FieldSchema{
 		Type:    framework.TypeString,
 		Default: "rsa",
-		Description: `The type of key to use; defaults to RSA. "rsa"
-and "ec" are the only valid values.`,
-		AllowedValues: []interface{}{"rsa", "ec"},
+		Description: `The type of key to use; defaults to RSA. "rsa",
+"ec" and "ed25519" are the only valid values.`,
+		AllowedValues: []interface{}{"rsa", "ec", "ed25519"},
 		DisplayAttrs: &framework.DisplayAttributes{
 			Value: "rsa",
 		},
@@ -284,5 +284,61 @@ func addCAIssueFields(fields map[string]*framework.FieldSchema) map[string]*fram
 		},
 	}
 
+	fields["excluded_dns_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Domains for which this certificate is not allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded DNS Domains",
+		},
+	}
+
+	fields["permitted_ip_ranges"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `CIDR blocks for which this certificate is allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted IP Ranges",
+		},
+	}
+
+	fields["excluded_ip_ranges"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `CIDR blocks for which this certificate is not allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded IP Ranges",
+		},
+	}
+
+	fields["permitted_email_addresses"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Email addresses, or domains of email addresses, for which this certificate is allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted Email Addresses",
+		},
+	}
+
+	fields["excluded_email_addresses"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `Email addresses, or domains of email addresses, for which this certificate is not allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded Email Addresses",
+		},
+	}
+
+	fields["permitted_uri_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `URI domains for which this certificate is allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Permitted URI Domains",
+		},
+	}
+
+	fields["excluded_uri_domains"] = &framework.FieldSchema{
+		Type:        framework.TypeCommaStringSlice,
+		Description: `URI domains for which this certificate is not allowed to sign or issue child certificates.`,
+		DisplayAttrs: &framework.DisplayAttributes{
+			Name: "Excluded URI Domains",
+		},
+	}
+
 	return fields
 }
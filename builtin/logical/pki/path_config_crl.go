@@ -11,10 +11,33 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+const (
+	defaultCRLAutoRebuildGracePeriod = "12h"
+	defaultCRLDeltaRebuildInterval   = "15m"
+)
+
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry  string `json:"expiry" mapstructure:"expiry"`
-	Disable bool   `json:"disable"`
+	Expiry                 string `json:"expiry" mapstructure:"expiry"`
+	Disable                bool   `json:"disable"`
+	AutoRebuild            bool   `json:"auto_rebuild"`
+	AutoRebuildGracePeriod string `json:"auto_rebuild_grace_period"`
+	EnableDelta            bool   `json:"enable_delta"`
+	DeltaRebuildInterval   string `json:"delta_rebuild_interval"`
+}
+
+func (c *crlConfig) autoRebuildGracePeriod() string {
+	if c.AutoRebuildGracePeriod == "" {
+		return defaultCRLAutoRebuildGracePeriod
+	}
+	return c.AutoRebuildGracePeriod
+}
+
+func (c *crlConfig) deltaRebuildInterval() string {
+	if c.DeltaRebuildInterval == "" {
+		return defaultCRLDeltaRebuildInterval
+	}
+	return c.DeltaRebuildInterval
 }
 
 func pathConfigCRL(b *backend) *framework.Path {
@@ -31,6 +54,28 @@ valid; defaults to 72 hours`,
 				Type:        framework.TypeBool,
 				Description: `If set to true, disables generating the CRL entirely.`,
 			},
+			"auto_rebuild": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, the CRL is rebuilt periodically ahead
+of its expiry instead of on every revocation`,
+			},
+			"auto_rebuild_grace_period": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The amount of time before the CRL expires at which
+it is rebuilt; defaults to 12 hours`,
+				Default: defaultCRLAutoRebuildGracePeriod,
+			},
+			"enable_delta": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, a delta CRL listing the certificates
+revoked since the last CRL is built periodically. Requires auto_rebuild.`,
+			},
+			"delta_rebuild_interval": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The interval at which the delta CRL is rebuilt;
+defaults to 15 minutes`,
+				Default: defaultCRLDeltaRebuildInterval,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -60,6 +105,19 @@ func (b *backend) CRL(ctx context.Context, s logical.Storage) (*crlConfig, error
 	return &result, nil
 }
 
+// crlExpiry returns the configured lifetime of the CRLs.
+func (b *backend) crlExpiry(crlInfo *crlConfig) (time.Duration, error) {
+	if crlInfo == nil || crlInfo.Expiry == "" {
+		return b.crlLifetime, nil
+	}
+
+	crlDur, err := time.ParseDuration(crlInfo.Expiry)
+	if err != nil {
+		return 0, errutil.InternalError{Err: fmt.Sprintf("error parsing CRL duration of %s", crlInfo.Expiry)}
+	}
+	return crlDur, nil
+}
+
 func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	config, err := b.CRL(ctx, req.Storage)
 	if err != nil {
@@ -71,8 +129,12 @@ func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, data *f
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"expiry":  config.Expiry,
-			"disable": config.Disable,
+			"expiry":                    config.Expiry,
+			"disable":                   config.Disable,
+			"auto_rebuild":              config.AutoRebuild,
+			"auto_rebuild_grace_period": config.autoRebuildGracePeriod(),
+			"enable_delta":              config.EnableDelta,
+			"delta_rebuild_interval":    config.deltaRebuildInterval(),
 		},
 	}, nil
 }
@@ -101,6 +163,43 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		config.Disable = disableRaw.(bool)
 	}
 
+	if autoRebuildRaw, ok := d.GetOk("auto_rebuild"); ok {
+		config.AutoRebuild = autoRebuildRaw.(bool)
+	}
+	if gracePeriodRaw, ok := d.GetOk("auto_rebuild_grace_period"); ok {
+		config.AutoRebuildGracePeriod = gracePeriodRaw.(string)
+	}
+
+	oldEnableDelta := config.EnableDelta
+	if enableDeltaRaw, ok := d.GetOk("enable_delta"); ok {
+		config.EnableDelta = enableDeltaRaw.(bool)
+	}
+	if intervalRaw, ok := d.GetOk("delta_rebuild_interval"); ok {
+		config.DeltaRebuildInterval = intervalRaw.(string)
+	}
+
+	expiry, err := b.crlExpiry(config)
+	if err != nil {
+		return nil, err
+	}
+	gracePeriod, err := time.ParseDuration(config.autoRebuildGracePeriod())
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("given auto_rebuild_grace_period could not be decoded: %s", err)), nil
+	}
+	if config.AutoRebuild && (gracePeriod <= 0 || gracePeriod >= expiry) {
+		return logical.ErrorResponse("auto_rebuild_grace_period must be positive and less than the CRL expiry"), nil
+	}
+	deltaInterval, err := time.ParseDuration(config.deltaRebuildInterval())
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("given delta_rebuild_interval could not be decoded: %s", err)), nil
+	}
+	if deltaInterval <= 0 {
+		return logical.ErrorResponse("delta_rebuild_interval must be positive"), nil
+	}
+	if config.EnableDelta && !config.AutoRebuild {
+		return logical.ErrorResponse("enable_delta requires auto_rebuild"), nil
+	}
+
 	entry, err := logical.StorageEntryJSON("config/crl", config)
 	if err != nil {
 		return nil, err
@@ -110,8 +209,9 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		return nil, err
 	}
 
-	if oldDisable != config.Disable {
-		// It wasn't disabled but now it is, rotate
+	if oldDisable != config.Disable || oldEnableDelta != config.EnableDelta {
+		// It wasn't disabled but now it is, or the delta CRL was toggled,
+		// rotate
 		crlErr := buildCRL(ctx, b, req, true)
 		switch crlErr.(type) {
 		case errutil.UserError:
@@ -129,5 +229,6 @@ Configure the CRL expiration.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, and of the periodic
+rebuilding of the CRL and of the delta CRL.
 `
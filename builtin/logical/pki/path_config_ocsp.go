@@ -0,0 +1,143 @@
+package pki
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	defaultOCSPResponderTTL = 30 * 24 * time.Hour
+	defaultOCSPNextUpdate   = time.Hour
+)
+
+// ocspConfig holds the configuration of the OCSP responder
+type ocspConfig struct {
+	Disable      bool          `json:"disable"`
+	ResponderTTL time.Duration `json:"responder_ttl"`
+	NextUpdate   time.Duration `json:"next_update"`
+}
+
+func pathConfigOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ocsp",
+		Fields: map[string]*framework.FieldSchema{
+			"disable": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `If set to true, disables the OCSP responder.`,
+			},
+			"responder_ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The lifetime of the delegated certificate signing
+OCSP responses; defaults to 30 days`,
+				Default: "720h",
+			},
+			"next_update": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of time OCSP responses are valid and
+cached for; defaults to 1 hour`,
+				Default: "1h",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathOCSPConfigRead,
+			logical.UpdateOperation: b.pathOCSPConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigOCSPHelpSyn,
+		HelpDescription: pathConfigOCSPHelpDesc,
+	}
+}
+
+// OCSP returns the configuration of the OCSP responder, or the default
+// configuration if it isn't configured.
+func (b *backend) OCSP(ctx context.Context, s logical.Storage) (*ocspConfig, error) {
+	entry, err := s.Get(ctx, "config/ocsp")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ocspConfig{
+		ResponderTTL: defaultOCSPResponderTTL,
+		NextUpdate:   defaultOCSPNextUpdate,
+	}
+	if entry == nil {
+		return result, nil
+	}
+
+	if err := entry.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (b *backend) pathOCSPConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"disable":       config.Disable,
+			"responder_ttl": int64(config.ResponderTTL.Seconds()),
+			"next_update":   int64(config.NextUpdate.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathOCSPConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if disableRaw, ok := d.GetOk("disable"); ok {
+		config.Disable = disableRaw.(bool)
+	}
+	if ttlRaw, ok := d.GetOk("responder_ttl"); ok {
+		config.ResponderTTL = time.Duration(ttlRaw.(int)) * time.Second
+	}
+	if nextUpdateRaw, ok := d.GetOk("next_update"); ok {
+		config.NextUpdate = time.Duration(nextUpdateRaw.(int)) * time.Second
+	}
+
+	if config.ResponderTTL <= 0 || config.NextUpdate <= 0 {
+		return logical.ErrorResponse("responder_ttl and next_update must be positive"), nil
+	}
+	if config.NextUpdate > config.ResponderTTL {
+		return logical.ErrorResponse("next_update must not be greater than responder_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/ocsp", config)
+	if err != nil {
+		return nil, err
+	}
+	err = req.Storage.Put(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// Issue a new responder certificate with the new lifetime on the next
+	// request, and drop the responses signed with the previous settings
+	if err := req.Storage.Delete(ctx, ocspResponderStoragePath); err != nil {
+		return nil, err
+	}
+	b.ocspCache.Purge()
+
+	return nil, nil
+}
+
+const pathConfigOCSPHelpSyn = `
+Configure the OCSP responder.
+`
+
+const pathConfigOCSPHelpDesc = `
+This endpoint allows configuration of the OCSP responder serving the status of
+the certificates issued by this backend at the "ocsp" endpoint. Updating the
+configuration issues a new delegated responder certificate.
+`
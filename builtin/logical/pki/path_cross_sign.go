@@ -0,0 +1,393 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// crossSignedStorageKey holds the certificates issued for the CA key by other
+// CAs, along with their chains.
+const crossSignedStorageKey = "config/cross_signed"
+
+// crossSignedChain is a certificate with the subject and key of the CA issued
+// by another CA, followed by the chain of that CA.
+type crossSignedChain struct {
+	Certificate string   `json:"certificate"`
+	Chain       []string `json:"chain"`
+}
+
+func pathGenerateCrossSignCSR(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "intermediate/cross-sign",
+
+		Fields: map[string]*framework.FieldSchema{
+			"format": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "pem",
+				Description: `Format for the returned CSR.
+Can be "pem" or "der"; defaults to "pem".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathGenerateCrossSignCSR,
+		},
+
+		HelpSynopsis:    pathGenerateCrossSignCSRHelpSyn,
+		HelpDescription: pathGenerateCrossSignCSRHelpDesc,
+	}
+}
+
+func pathSetCrossSigned(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "intermediate/set-cross-signed",
+
+		Fields: map[string]*framework.FieldSchema{
+			"certificate": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-format cross-signed certificate, optionally
+followed by the chain of the CA which issued it.
+The certificate must have the subject and public
+key of the CA certificate.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathSetCrossSigned,
+		},
+
+		HelpSynopsis:    pathSetCrossSignedHelpSyn,
+		HelpDescription: pathSetCrossSignedHelpDesc,
+	}
+}
+
+func pathCrossSigned(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "intermediate/cross-signed",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathCrossSignedRead,
+			logical.DeleteOperation: b.pathCrossSignedDelete,
+		},
+
+		HelpSynopsis:    pathCrossSignedHelpSyn,
+		HelpDescription: pathCrossSignedHelpDesc,
+	}
+}
+
+// pathGenerateCrossSignCSR returns a CSR for the CA certificate, signed with
+// its existing key, to be cross-signed by another CA.
+func (b *backend) pathGenerateCrossSignCSR(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	format := data.Get("format").(string)
+	if format != "pem" && format != "der" {
+		return logical.ErrorResponse(`the "format" parameter must be "pem" or "der"`), nil
+	}
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate (was one set?): %s", err)), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+	if caInfo.PrivateKey == nil {
+		return logical.ErrorResponse("the CA has no private key"), nil
+	}
+
+	caCert := caInfo.Certificate
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		RawSubject:     caCert.RawSubject,
+		DNSNames:       caCert.DNSNames,
+		EmailAddresses: caCert.EmailAddresses,
+		IPAddresses:    caCert.IPAddresses,
+		URIs:           caCert.URIs,
+	}, caInfo.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the CSR: %w", err)
+	}
+
+	csr := base64.StdEncoding.EncodeToString(csrBytes)
+	if format == "pem" {
+		csr = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE REQUEST",
+			Bytes: csrBytes,
+		})))
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"csr": csr,
+		},
+	}, nil
+}
+
+// pathSetCrossSigned stores a cross-signed certificate of the CA, so that it
+// and the chain of the CA which issued it are served in ca_chain.
+func (b *backend) pathSetCrossSigned(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	certs, err := parsePEMCertificates(data.Get("certificate").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if len(certs) == 0 {
+		return logical.ErrorResponse(`no certificate provided in the "certificate" parameter`), nil
+	}
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate (was one set?): %s", err)), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+
+	crossSigned := certs[0]
+	if err := checkCrossSigned(caInfo.Certificate, crossSigned); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if time.Now().After(crossSigned.NotAfter) {
+		return logical.ErrorResponse("the cross-signed certificate has expired"), nil
+	}
+	for i := 1; i < len(certs); i++ {
+		if err := certs[i-1].CheckSignatureFrom(certs[i]); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("certificate %d of the chain was not issued by the next one: %v", i, err)), nil
+		}
+	}
+
+	chains, err := fetchCrossSignedChains(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &crossSignedChain{
+		Certificate: encodeCertificatePEM(crossSigned),
+	}
+	for _, cert := range certs[1:] {
+		entry.Chain = append(entry.Chain, encodeCertificatePEM(cert))
+	}
+
+	// Importing the same certificate again replaces its chain
+	replaced := false
+	for i, existing := range chains {
+		if existing.Certificate == entry.Certificate {
+			chains[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		chains = append(chains, entry)
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(crossSignedStorageKey, chains)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, storageEntry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathCrossSignedRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	chains, err := fetchCrossSignedChains(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var caCert *x509.Certificate
+	if caInfo, err := fetchCAInfo(ctx, req); err == nil {
+		caCert = caInfo.Certificate
+	}
+
+	certs := make([]map[string]interface{}, 0, len(chains))
+	for _, chain := range chains {
+		cert, err := parsePEMCertificate(chain.Certificate)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, map[string]interface{}{
+			"certificate":   chain.Certificate,
+			"chain":         chain.Chain,
+			"serial_number": certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"),
+			"issuer":        cert.Issuer.String(),
+			"expiration":    cert.NotAfter.Unix(),
+			"in_use":        caCert != nil && crossSignedInUse(caCert, cert),
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"cross_signed": certs,
+		},
+	}, nil
+}
+
+func (b *backend) pathCrossSignedDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, crossSignedStorageKey)
+}
+
+// checkCrossSigned checks that the certificate is a CA certificate with the
+// subject and public key of the CA certificate, issued by another CA.
+func checkCrossSigned(caCert, cert *x509.Certificate) error {
+	if bytes.Equal(cert.Raw, caCert.Raw) {
+		return fmt.Errorf("the certificate is the CA certificate itself")
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("the cross-signed certificate is not marked for CA use")
+	}
+	if !bytes.Equal(cert.RawSubject, caCert.RawSubject) {
+		return fmt.Errorf("the cross-signed certificate must have the subject of the CA certificate")
+	}
+	equal, err := certutil.ComparePublicKeys(cert.PublicKey, caCert.PublicKey)
+	if err != nil || !equal {
+		return fmt.Errorf("the cross-signed certificate must have the public key of the CA certificate")
+	}
+	return nil
+}
+
+// crossSignedInUse returns whether the cross-signed certificate applies to the
+// current CA certificate and is still valid. Cross-signed certificates of a
+// replaced CA key are kept but no longer served.
+func crossSignedInUse(caCert, cert *x509.Certificate) bool {
+	return checkCrossSigned(caCert, cert) == nil && time.Now().Before(cert.NotAfter)
+}
+
+func fetchCrossSignedChains(ctx context.Context, s logical.Storage) ([]*crossSignedChain, error) {
+	entry, err := s.Get(ctx, crossSignedStorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var chains []*crossSignedChain
+	if err := entry.DecodeJSON(&chains); err != nil {
+		return nil, err
+	}
+	return chains, nil
+}
+
+// fetchCAChain returns the chain served for the CA: the chain it was imported
+// or signed with, followed by each of its cross-signed certificates and the
+// chain of the CA which issued it. This way clients can build a path to any
+// of the roots which trust the CA.
+func fetchCAChain(ctx context.Context, req *logical.Request, caInfo *certutil.CAInfoBundle) ([]*certutil.CertBlock, error) {
+	chain := caInfo.GetCAChain()
+
+	crossSigned, err := fetchCrossSignedChains(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch cross-signed certificates: %v", err)}
+	}
+
+	seen := make(map[string]bool, len(chain))
+	for _, block := range chain {
+		seen[string(block.Bytes)] = true
+	}
+	for _, entry := range crossSigned {
+		cert, err := parsePEMCertificate(entry.Certificate)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored cross-signed certificate: %v", err)}
+		}
+		if !crossSignedInUse(caInfo.Certificate, cert) {
+			continue
+		}
+
+		certs := []*x509.Certificate{cert}
+		for _, certPEM := range entry.Chain {
+			chainCert, err := parsePEMCertificate(certPEM)
+			if err != nil {
+				return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored cross-signed chain: %v", err)}
+			}
+			certs = append(certs, chainCert)
+		}
+		for _, c := range certs {
+			if seen[string(c.Raw)] {
+				continue
+			}
+			seen[string(c.Raw)] = true
+			chain = append(chain, &certutil.CertBlock{
+				Certificate: c,
+				Bytes:       c.Raw,
+			})
+		}
+	}
+
+	return chain, nil
+}
+
+func parsePEMCertificates(certsPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(certsPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate could not be parsed: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func encodeCertificatePEM(cert *x509.Certificate) string {
+	return strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: cert.Raw,
+	})))
+}
+
+const pathGenerateCrossSignCSRHelpSyn = `
+Generate a CSR for the CA certificate, signed with its existing key, to be
+cross-signed by another CA.
+`
+
+const pathGenerateCrossSignCSRHelpDesc = `
+The CSR has the subject and alternative names of the CA certificate. Once
+signed by another CA, the resulting certificate can be imported through the
+"intermediate/set-cross-signed" endpoint.
+`
+
+const pathSetCrossSignedHelpSyn = `
+Import a cross-signed certificate of the CA, and the chain of the CA which
+issued it.
+`
+
+const pathSetCrossSignedHelpDesc = `
+The certificate must be a CA certificate with the subject and public key of the
+CA certificate. It is served, followed by the given chain, in the ca_chain of
+issued certificates and of the "cert/ca_chain" endpoint, in addition to the
+chain of the CA certificate itself, so that clients trusting either root can
+validate the issued certificates. Several cross-signed certificates can be
+imported; importing the same certificate again replaces its chain.
+`
+
+const pathCrossSignedHelpSyn = `
+Read or remove the cross-signed certificates of the CA.
+`
+
+const pathCrossSignedHelpDesc = `
+Reading returns the imported cross-signed certificates and their chains, and
+whether they are in use: cross-signed certificates are only served while they
+are valid and have the subject and public key of the current CA certificate.
+Deleting removes all of them.
+`
@@ -0,0 +1,183 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_CrossSign(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectError := func(certPEM string) {
+		t.Helper()
+		resp, err := request(logical.UpdateOperation, "intermediate/set-cross-signed", map[string]interface{}{
+			"certificate": certPEM,
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected the import to fail, got %#v", resp)
+		}
+	}
+
+	resp := handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	caCert, err := parsePEMCertificate(resp.Data["certificate"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+	})
+
+	// An external root cross-signs the CA from a CSR made with its key
+	externalKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "External Root"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(48 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	externalDER, err := x509.CreateCertificate(rand.Reader, externalTemplate, externalTemplate, externalKey.Public(), externalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	externalRoot, err := x509.ParseCertificate(externalDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = handle(logical.UpdateOperation, "intermediate/cross-sign", nil)
+	block, _ := pem.Decode([]byte(resp.Data["csr"].(string)))
+	if block == nil {
+		t.Fatalf("expected a PEM CSR, got %v", resp.Data["csr"])
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(csr.RawSubject, caCert.RawSubject) {
+		t.Fatalf("expected the CSR to have the subject of the CA, got %v", csr.Subject)
+	}
+
+	crossSign := func(serial int64, pub interface{}) string {
+		t.Helper()
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			RawSubject:            csr.RawSubject,
+			NotBefore:             time.Now().Add(-time.Minute),
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			SubjectKeyId:          caCert.SubjectKeyId,
+		}, externalRoot, pub, externalKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	crossSignedPEM := crossSign(2, csr.PublicKey)
+	externalPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: externalDER}))
+
+	// The key must be the CA's, and the chain must be in order
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(crossSign(3, otherKey.Public()) + externalPEM)
+	expectError(resp.Data["csr"].(string))
+	expectError(crossSignedPEM + crossSignedPEM)
+	expectError(externalPEM)
+
+	handle(logical.UpdateOperation, "intermediate/set-cross-signed", map[string]interface{}{
+		"certificate": crossSignedPEM + externalPEM,
+	})
+
+	resp = handle(logical.ReadOperation, "intermediate/cross-signed", nil)
+	crossSigned := resp.Data["cross_signed"].([]map[string]interface{})
+	if len(crossSigned) != 1 || crossSigned[0]["in_use"] != true || crossSigned[0]["issuer"] != "CN=External Root" {
+		t.Fatalf("unexpected cross-signed certificates: %#v", crossSigned)
+	}
+
+	// Issued certificates chain up to both roots
+	resp = handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	leaf, err := parsePEMCertificate(resp.Data["certificate"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	caChain := resp.Data["ca_chain"].([]string)
+	if len(caChain) != 2 {
+		t.Fatalf("expected the cross-signed certificate and the external root in ca_chain, got %d certificates", len(caChain))
+	}
+	intermediates := x509.NewCertPool()
+	for _, certPEM := range caChain {
+		intermediates.AppendCertsFromPEM([]byte(certPEM))
+	}
+	for _, root := range []*x509.Certificate{caCert, externalRoot} {
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+			t.Fatalf("expected the certificate to chain up to %q: %v", root.Subject.CommonName, err)
+		}
+	}
+
+	resp = handle(logical.ReadOperation, "cert/ca_chain", nil)
+	chainCerts, err := parsePEMCertificates(resp.Data["certificate"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chainCerts) != 2 || !chainCerts[1].Equal(externalRoot) {
+		t.Fatalf("unexpected cert/ca_chain: %v", resp.Data["certificate"])
+	}
+
+	// Once removed, the chain is the CA's own again
+	handle(logical.DeleteOperation, "intermediate/cross-signed", nil)
+	resp = handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	if _, ok := resp.Data["ca_chain"]; ok {
+		t.Fatalf("expected no ca_chain for a root CA, got %v", resp.Data["ca_chain"])
+	}
+}
@@ -0,0 +1,506 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	estStoragePath = "config/est"
+
+	estCertsOnlyContentType = "application/pkcs7-mime; smime-type=certs-only"
+)
+
+// estConfig holds the configuration of the EST (RFC 7030) endpoints
+type estConfig struct {
+	Enabled     bool   `json:"enabled"`
+	DefaultRole string `json:"default_role"`
+
+	// BasicAuthUsers maps the users allowed to enroll with HTTP Basic
+	// credentials to the bcrypt hash of their password
+	BasicAuthUsers map[string]string `json:"basic_auth_users"`
+
+	// ClientCACertificates is the PEM bundle of the CAs whose TLS client
+	// certificates are allowed to enroll, in addition to this CA
+	ClientCACertificates string `json:"client_ca_certificates"`
+}
+
+func pathConfigEST(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/est",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `If set to true, enables the EST endpoints.`,
+			},
+			"default_role": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The role used by EST requests made without a label.
+Requests with a label use the role named by the label.`,
+			},
+			"basic_auth_users": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `The users allowed to enroll with HTTP Basic credentials,
+as a map of usernames to passwords. Replaces the existing users. The Authorization
+header must be passed through to the mount.`,
+			},
+			"client_ca_certificates": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM bundle of the CAs whose TLS client certificates are
+allowed to enroll, in addition to the certificates issued by this CA.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigESTRead,
+			logical.UpdateOperation: b.pathConfigESTWrite,
+		},
+
+		HelpSynopsis:    pathConfigESTHelpSyn,
+		HelpDescription: pathConfigESTHelpDesc,
+	}
+}
+
+func pathESTCACerts(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `\.well-known/est/(` + framework.GenericNameRegex("label") + `/)?cacerts$`,
+		Fields: map[string]*framework.FieldSchema{
+			"label": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The EST label, naming the role to use.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathESTCACerts,
+		},
+
+		HelpSynopsis:    pathESTHelpSyn,
+		HelpDescription: pathESTHelpDesc,
+	}
+}
+
+func pathESTEnroll(b *backend) *framework.Path {
+	ret := &framework.Path{
+		Pattern: `\.well-known/est/(` + framework.GenericNameRegex("label") + `/)?(?P<operation>simpleenroll|simplereenroll)$`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathESTEnroll,
+		},
+
+		HelpSynopsis:    pathESTHelpSyn,
+		HelpDescription: pathESTHelpDesc,
+	}
+
+	ret.Fields = addNonCACommonFields(map[string]*framework.FieldSchema{})
+
+	ret.Fields["label"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `The EST label, naming the role to use.`,
+	}
+
+	ret.Fields["operation"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Either simpleenroll or simplereenroll.`,
+	}
+
+	ret.Fields["csr"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Base64 encoded DER PKCS#10 CSR to be signed.`,
+	}
+
+	return ret
+}
+
+func (b *backend) estConfig(ctx context.Context, s logical.Storage) (*estConfig, error) {
+	entry, err := s.Get(ctx, estStoragePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result estConfig
+	if entry == nil {
+		return &result, nil
+	}
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathConfigESTRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.estConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0, len(config.BasicAuthUsers))
+	for username := range config.BasicAuthUsers {
+		users = append(users, username)
+	}
+	sort.Strings(users)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":                config.Enabled,
+			"default_role":           config.DefaultRole,
+			"basic_auth_users":       users,
+			"client_ca_certificates": config.ClientCACertificates,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigESTWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.estConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := data.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if defaultRoleRaw, ok := data.GetOk("default_role"); ok {
+		config.DefaultRole = defaultRoleRaw.(string)
+	}
+	if usersRaw, ok := data.GetOk("basic_auth_users"); ok {
+		config.BasicAuthUsers = make(map[string]string)
+		for username, password := range usersRaw.(map[string]string) {
+			if username == "" || strings.Contains(username, ":") || password == "" {
+				return logical.ErrorResponse("basic_auth_users must map non-empty usernames without colons to non-empty passwords"), nil
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			config.BasicAuthUsers[username] = string(hash)
+		}
+	}
+	if clientCAsRaw, ok := data.GetOk("client_ca_certificates"); ok {
+		config.ClientCACertificates = clientCAsRaw.(string)
+		if config.ClientCACertificates != "" {
+			if _, err := estClientCAPool(config.ClientCACertificates); err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+		}
+	}
+
+	if config.DefaultRole != "" {
+		role, err := b.getRole(ctx, req.Storage, config.DefaultRole)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", config.DefaultRole)), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(estStoragePath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// pathESTCACerts returns the CA certificate and its chain, as described in
+// RFC 7030, section 4.1.
+func (b *backend) pathESTCACerts(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.estConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return logical.ErrorResponse("EST is disabled"), nil
+	}
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate (was one set?): %s", err)), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+
+	caChain, err := fetchCAChain(ctx, req, caInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs bytes.Buffer
+	certs.Write(caInfo.CertificateBytes)
+	for _, caCert := range caChain {
+		if !bytes.Equal(caCert.Bytes, caInfo.CertificateBytes) {
+			certs.Write(caCert.Bytes)
+		}
+	}
+
+	return estCertsOnlyResponse(certs.Bytes())
+}
+
+// pathESTEnroll signs the CSR of a simple enrollment or re-enrollment request,
+// as described in RFC 7030, section 4.2. The endpoints are unauthenticated in
+// Vault, as EST clients do not send Vault tokens: enrollment requires the
+// client to authenticate as described by authenticateESTClient, and
+// re-enrollment requires it to authenticate over TLS with the certificate
+// being renewed, and the CSR to have the same subject and alternative names.
+func (b *backend) pathESTEnroll(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.estConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return logical.ErrorResponse("EST is disabled"), nil
+	}
+
+	roleName := data.Get("label").(string)
+	if roleName == "" {
+		roleName = config.DefaultRole
+	}
+	if roleName == "" {
+		return logical.ErrorResponse("an EST label is required as no default role is configured"), nil
+	}
+
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
+	}
+	if _, err := role.CheckSunset(roleName, time.Now()); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// EST clients send the DER CSR base64 encoded, possibly with line breaks
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data.Get("csr").(string)), ""))
+	if err != nil {
+		return logical.ErrorResponse("the CSR must be base64 encoded DER"), nil
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("certificate request could not be parsed: %v", err)), nil
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid CSR signature: %v", err)), nil
+	}
+
+	if data.Get("operation").(string) == "simplereenroll" {
+		cert := estClientCertificate(req)
+		if cert == nil {
+			return logical.ErrorResponse("re-enrollment requires a TLS client certificate"), nil
+		}
+		if resp, err := b.checkReissuable(ctx, req, cert); resp != nil || err != nil {
+			return resp, err
+		}
+		if !sameSubjectAndSANs(cert, csr) {
+			return logical.ErrorResponse("the subject and alternative names of the CSR must match the certificate being renewed"), nil
+		}
+	} else if err := b.authenticateESTClient(ctx, req, config); err != nil {
+		return nil, err
+	}
+
+	// The names are taken from the CSR, as EST has no other way to request
+	// them, and validated against the role
+	estRole := *role
+	estRole.UseCSRCommonName = true
+	estRole.UseCSRSANs = true
+
+	estData := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"csr":    string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})),
+			"format": "der",
+		},
+		Schema: data.Schema,
+	}
+
+	resp, err := b.pathIssueSignCert(ctx, req, estData, &estRole, true, false)
+	if err != nil || resp == nil || resp.IsError() {
+		return resp, err
+	}
+
+	certDER, err := base64.StdEncoding.DecodeString(resp.Data["certificate"].(string))
+	if err != nil {
+		return nil, errwrap.Wrapf("error decoding issued certificate: {{err}}", err)
+	}
+
+	return estCertsOnlyResponse(certDER)
+}
+
+// estClientCertificate returns the TLS client certificate of the request, if
+// any.
+func estClientCertificate(req *logical.Request) *x509.Certificate {
+	if req.Connection == nil || req.Connection.ConnState == nil || len(req.Connection.ConnState.PeerCertificates) == 0 {
+		return nil
+	}
+	return req.Connection.ConnState.PeerCertificates[0]
+}
+
+// authenticateESTClient checks that the client of an enrollment request
+// authenticated over TLS with a valid certificate issued by this CA, and not
+// revoked, or by one of the client CAs, or with the HTTP Basic credentials of
+// one of the configured users.
+func (b *backend) authenticateESTClient(ctx context.Context, req *logical.Request, config *estConfig) error {
+	if cert := estClientCertificate(req); cert != nil {
+		resp, err := b.checkReissuable(ctx, req, cert)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return nil
+		}
+
+		if config.ClientCACertificates != "" {
+			pool, err := estClientCAPool(config.ClientCACertificates)
+			if err != nil {
+				return err
+			}
+			intermediates := x509.NewCertPool()
+			for _, intermediate := range req.Connection.ConnState.PeerCertificates[1:] {
+				intermediates.AddCert(intermediate)
+			}
+			_, err = cert.Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			if err == nil {
+				return nil
+			}
+		}
+	}
+
+	// The Authorization header is only passed to the backend if the mount is
+	// tuned to pass it through
+	username, password, ok := (&http.Request{Header: http.Header(req.Headers)}).BasicAuth()
+	if ok {
+		if hash, found := config.BasicAuthUsers[username]; found && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return nil
+		}
+	}
+
+	return logical.ErrPermissionDenied
+}
+
+// estClientCAPool parses the PEM bundle of the client CAs.
+func estClientCAPool(bundle string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(bundle)) {
+		return nil, fmt.Errorf("client_ca_certificates holds no PEM certificate")
+	}
+	return pool, nil
+}
+
+// sameSubjectAndSANs returns whether the CSR has the subject and alternative
+// names of the certificate.
+func sameSubjectAndSANs(cert *x509.Certificate, csr *x509.CertificateRequest) bool {
+	if cert.Subject.String() != csr.Subject.String() {
+		return false
+	}
+
+	var certIPs, csrIPs, certURIs, csrURIs []string
+	for _, ip := range cert.IPAddresses {
+		certIPs = append(certIPs, ip.String())
+	}
+	for _, ip := range csr.IPAddresses {
+		csrIPs = append(csrIPs, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		certURIs = append(certURIs, uri.String())
+	}
+	for _, uri := range csr.URIs {
+		csrURIs = append(csrURIs, uri.String())
+	}
+
+	return sameStrings(cert.DNSNames, csr.DNSNames) &&
+		sameStrings(cert.EmailAddresses, csr.EmailAddresses) &&
+		sameStrings(certIPs, csrIPs) &&
+		sameStrings(certURIs, csrURIs)
+}
+
+// sameStrings returns whether the lists hold the same strings, in any order.
+func sameStrings(a, b []string) bool {
+	count := make(map[string]int, len(a))
+	for _, s := range a {
+		count[s]++
+	}
+	for _, s := range b {
+		count[s]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// estCertsOnlyResponse returns the DER certificates as a base64 encoded
+// certs-only PKCS#7 message, the format of EST responses.
+func estCertsOnlyResponse(certs []byte) (*logical.Response, error) {
+	p7, err := pkcs7.DegenerateCertificate(certs)
+	if err != nil {
+		return nil, errwrap.Wrapf("error encoding PKCS#7 response: {{err}}", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType:                estCertsOnlyContentType,
+			logical.HTTPRawContentTransferEncoding: "base64",
+			logical.HTTPRawBody:                    []byte(base64.StdEncoding.EncodeToString(p7)),
+			logical.HTTPStatusCode:                 http.StatusOK,
+		},
+	}, nil
+}
+
+const pathConfigESTHelpSyn = `
+Configure the EST enrollment endpoints.
+`
+
+const pathConfigESTHelpDesc = `
+This endpoint enables the EST (RFC 7030) endpoints under .well-known/est, and
+sets the role used by requests made without a label. Requests with a label,
+such as .well-known/est/<label>/simpleenroll, use the role named by the label.
+
+It also sets how enrollment clients authenticate: with the HTTP Basic
+credentials of basic_auth_users, or over TLS with a certificate issued by this
+CA or by one of client_ca_certificates.
+`
+
+const pathESTHelpSyn = `
+EST (RFC 7030) enrollment endpoints.
+`
+
+const pathESTHelpDesc = `
+These endpoints let clients with built-in EST support fetch the CA certificates
+through cacerts, enroll through simpleenroll and renew their certificates
+through simplereenroll. Requests are subject to the policy of the role named by
+the label, or of the configured default role; the names are taken from the CSR.
+
+The endpoints do not take Vault tokens. Enrollment requires the client to
+authenticate over TLS with a certificate issued by this CA, and not revoked, or
+by one of the configured client CAs, or with the HTTP Basic credentials of one
+of the configured users.
+
+Re-enrollment requires the client to authenticate over TLS with the certificate
+being renewed, which must have been issued by this CA and not be revoked. The
+CSR must have the same subject and alternative names as the certificate.
+`
@@ -0,0 +1,232 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_EST(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	var headers map[string][]string
+	request := func(op logical.Operation, path string, data map[string]interface{}, conn *logical.Connection) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  op,
+			Path:       path,
+			Storage:    storage,
+			Data:       data,
+			Connection: conn,
+			Headers:    headers,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}, conn *logical.Connection) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data, conn)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectError := func(op logical.Operation, path string, data map[string]interface{}, conn *logical.Connection) {
+		t.Helper()
+		resp, err := request(op, path, data, conn)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected %s to fail, got %#v", path, resp)
+		}
+	}
+	parseCerts := func(resp *logical.Response) []*x509.Certificate {
+		t.Helper()
+		if resp.Data[logical.HTTPContentType] != estCertsOnlyContentType {
+			t.Fatalf("bad content type: %v", resp.Data[logical.HTTPContentType])
+		}
+		der, err := base64.StdEncoding.DecodeString(string(resp.Data[logical.HTTPRawBody].([]byte)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p7, err := pkcs7.Parse(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p7.Certificates
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrFor := func(cn string, dnsNames ...string) string {
+		t.Helper()
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: cn},
+			DNSNames: dnsNames,
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(csr)
+	}
+
+	handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	}, nil)
+	handle(logical.UpdateOperation, "roles/devices", map[string]interface{}{
+		"allowed_domains":  "devices.myvault.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+		"ttl":              "1h",
+	}, nil)
+
+	// EST is disabled by default
+	expectError(logical.ReadOperation, ".well-known/est/cacerts", nil, nil)
+
+	expectError(logical.UpdateOperation, "config/est", map[string]interface{}{
+		"enabled":      true,
+		"default_role": "unknown",
+	}, nil)
+	handle(logical.UpdateOperation, "config/est", map[string]interface{}{
+		"enabled":          true,
+		"basic_auth_users": map[string]interface{}{"device": "s3cret"},
+	}, nil)
+	resp := handle(logical.ReadOperation, "config/est", nil, nil)
+	if users := resp.Data["basic_auth_users"].([]string); len(users) != 1 || users[0] != "device" {
+		t.Fatalf("bad users: %#v", resp.Data)
+	}
+
+	certs := parseCerts(handle(logical.ReadOperation, ".well-known/est/cacerts", nil, nil))
+	if len(certs) != 1 || certs[0].Subject.CommonName != "myvault.com" {
+		t.Fatalf("bad CA certificates: %v", certs)
+	}
+
+	// Enrollment requires the client to authenticate
+	_, err = request(logical.UpdateOperation, ".well-known/est/devices/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com", "a1.devices.myvault.com"),
+	}, nil)
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	basicAuth := func(username, password string) map[string][]string {
+		return map[string][]string{
+			"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))},
+		}
+	}
+	headers = basicAuth("device", "wrong")
+	_, err = request(logical.UpdateOperation, ".well-known/est/devices/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com", "a1.devices.myvault.com"),
+	}, nil)
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	headers = basicAuth("device", "s3cret")
+
+	// Without a default role, a label is required
+	expectError(logical.UpdateOperation, ".well-known/est/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com"),
+	}, nil)
+	certs = parseCerts(handle(logical.UpdateOperation, ".well-known/est/devices/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com", "a1.devices.myvault.com"),
+	}, nil))
+	if len(certs) != 1 || certs[0].Subject.CommonName != "a1.devices.myvault.com" {
+		t.Fatalf("bad certificate: %v", certs)
+	}
+	enrolled := certs[0]
+
+	handle(logical.UpdateOperation, "config/est", map[string]interface{}{
+		"default_role": "devices",
+	}, nil)
+	expectError(logical.UpdateOperation, ".well-known/est/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a1.example.com"),
+	}, nil)
+
+	// Re-enrollment requires the certificate being renewed over TLS, and the
+	// same names
+	expectError(logical.UpdateOperation, ".well-known/est/simplereenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com", "a1.devices.myvault.com"),
+	}, nil)
+	conn := &logical.Connection{
+		ConnState: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{enrolled}},
+	}
+	expectError(logical.UpdateOperation, ".well-known/est/simplereenroll", map[string]interface{}{
+		"csr": csrFor("a2.devices.myvault.com", "a2.devices.myvault.com"),
+	}, conn)
+	certs = parseCerts(handle(logical.UpdateOperation, ".well-known/est/simplereenroll", map[string]interface{}{
+		"csr": csrFor("a1.devices.myvault.com", "a1.devices.myvault.com"),
+	}, conn))
+	if len(certs) != 1 || certs[0].SerialNumber.Cmp(enrolled.SerialNumber) == 0 {
+		t.Fatalf("bad certificate: %v", certs)
+	}
+
+	// Enrollment also accepts a certificate issued by this CA
+	headers = nil
+	certs = parseCerts(handle(logical.UpdateOperation, ".well-known/est/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a3.devices.myvault.com", "a3.devices.myvault.com"),
+	}, conn))
+	if len(certs) != 1 || certs[0].Subject.CommonName != "a3.devices.myvault.com" {
+		t.Fatalf("bad certificate: %v", certs)
+	}
+
+	// or by one of the client CAs
+	clientCAKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCATemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "manufacturer"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	clientCADER, err := x509.CreateCertificate(rand.Reader, clientCATemplate, clientCATemplate, &clientCAKey.PublicKey, clientCAKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCA, err := x509.ParseCertificate(clientCADER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "device-1234"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, clientCA, &key.PublicKey, clientCAKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	device, err := x509.ParseCertificate(deviceDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deviceConn := &logical.Connection{
+		ConnState: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{device}},
+	}
+	_, err = request(logical.UpdateOperation, ".well-known/est/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a4.devices.myvault.com", "a4.devices.myvault.com"),
+	}, deviceConn)
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	handle(logical.UpdateOperation, "config/est", map[string]interface{}{
+		"client_ca_certificates": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCADER})),
+	}, nil)
+	handle(logical.UpdateOperation, ".well-known/est/simpleenroll", map[string]interface{}{
+		"csr": csrFor("a4.devices.myvault.com", "a4.devices.myvault.com"),
+	}, deviceConn)
+}
@@ -53,6 +53,20 @@ func pathFetchCRL(b *backend) *framework.Path {
 	}
 }
 
+// Returns the delta CRL in raw format
+func pathFetchDeltaCRL(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `crl/delta(/pem)?`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchRead,
+		},
+
+		HelpSynopsis:    pathFetchHelpSyn,
+		HelpDescription: pathFetchHelpDesc,
+	}
+}
+
 // Returns any valid (non-revoked) cert. Since "ca" fits the pattern, this path
 // also handles returning the CA cert in a non-raw format.
 func pathFetchValid(b *backend) *framework.Path {
@@ -75,10 +89,10 @@ hyphen-separated octal`,
 	}
 }
 
-// This returns the CRL in a non-raw format
+// This returns the CRL or delta CRL in a non-raw format
 func pathFetchCRLViaCertPath(b *backend) *framework.Path {
 	return &framework.Path{
-		Pattern: `cert/crl`,
+		Pattern: `cert/(delta-)?crl`,
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation: b.pathFetchRead,
@@ -148,6 +162,15 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 	case req.Path == "cert/crl":
 		serial = "crl"
 		pemType = "X509 CRL"
+	case req.Path == "crl/delta" || req.Path == "crl/delta/pem":
+		serial = "delta-crl"
+		contentType = "application/pkix-crl"
+		if req.Path == "crl/delta/pem" {
+			pemType = "X509 CRL"
+		}
+	case req.Path == "cert/delta-crl":
+		serial = "delta-crl"
+		pemType = "X509 CRL"
 	default:
 		serial = data.Get("serial").(string)
 		pemType = "CERTIFICATE"
@@ -168,7 +191,11 @@ func (b *backend) pathFetchRead(ctx context.Context, req *logical.Request, data
 			goto reply
 		}
 
-		caChain := caInfo.GetCAChain()
+		caChain, err := fetchCAChain(ctx, req, caInfo)
+		if err != nil {
+			retErr = err
+			goto reply
+		}
 		var certStr string
 		for _, ca := range caChain {
 			block := pem.Block{
@@ -245,6 +272,7 @@ reply:
 		retErr = nil
 		if len(certificate) > 0 {
 			response.Data[logical.HTTPStatusCode] = 200
+			response.SetETag(logical.NewETag(certificate))
 		} else {
 			response.Data[logical.HTTPStatusCode] = 204
 		}
@@ -258,6 +286,13 @@ reply:
 	default:
 		response.Data["certificate"] = string(certificate)
 		response.Data["revocation_time"] = revocationTime
+
+		// Certificates can change their revocation time, so only the CA,
+		// chain and CRL are tagged
+		switch serial {
+		case "ca", "ca_chain", "crl", "delta-crl":
+			response.SetETag(logical.NewETag(certificate))
+		}
 	}
 
 	return
@@ -272,5 +307,7 @@ This allows certificates to be fetched. If using the fetch/ prefix any non-revok
 
 Using "ca" or "crl" as the value fetches the appropriate information in DER encoding. Add "/pem" to either to get PEM encoding.
 
+Using "crl/delta" fetches the delta CRL in DER encoding, if delta CRLs are enabled. Add "/pem" to get PEM encoding.
+
 Using "ca_chain" as the value fetches the certificate authority trust chain in PEM encoding.
 `
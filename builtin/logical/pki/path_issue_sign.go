@@ -118,11 +118,20 @@ func (b *backend) pathIssue(ctx context.Context, req *logical.Request, data *fra
 		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
 	}
 
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	if role.KeyType == "any" {
 		return logical.ErrorResponse("role key type \"any\" not allowed for issuing certificates, only signing"), nil
 	}
 
-	return b.pathIssueSignCert(ctx, req, data, role, false, false)
+	resp, err := b.pathIssueSignCert(ctx, req, data, role, false, false)
+	if resp != nil && sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, err
 }
 
 // pathSign issues a certificate from a submitted CSR, subject to role
@@ -139,7 +148,16 @@ func (b *backend) pathSign(ctx context.Context, req *logical.Request, data *fram
 		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
 	}
 
-	return b.pathIssueSignCert(ctx, req, data, role, true, false)
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	resp, err := b.pathIssueSignCert(ctx, req, data, role, true, false)
+	if resp != nil && sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, err
 }
 
 // pathSignVerbatim issues a certificate from a submitted CSR, *not* subject to
@@ -154,6 +172,14 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 		return nil, err
 	}
 
+	var sunsetWarning string
+	if role != nil {
+		sunsetWarning, err = role.CheckSunset(roleName, time.Now())
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	entry := &roleEntry{
 		AllowLocalhost:       true,
 		AllowAnyName:         true,
@@ -185,7 +211,11 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 		entry.NoStore = role.NoStore
 	}
 
-	return b.pathIssueSignCert(ctx, req, data, entry, true, true)
+	resp, err := b.pathIssueSignCert(ctx, req, data, entry, true, true)
+	if resp != nil && sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, err
 }
 
 func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, data *framework.FieldData, role *roleEntry, useCSR, useCSRValues bool) (*logical.Response, error) {
@@ -211,6 +241,10 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 			"error fetching CA certificate: %s", caErr)}
 	}
 
+	if resp, err := b.checkQuarantine(ctx, req, signingBundle); resp != nil || err != nil {
+		return resp, err
+	}
+
 	input := &inputBundle{
 		req:     req,
 		apiData: data,
@@ -234,6 +268,11 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	parsedBundle.CAChain, err = fetchCAChain(ctx, req, signingBundle)
+	if err != nil {
+		return nil, err
+	}
+
 	signingCB, err := signingBundle.ToCertBundle()
 	if err != nil {
 		return nil, errwrap.Wrapf("error converting raw signing bundle to cert bundle: {{err}}", err)
@@ -259,6 +298,9 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if !useCSR {
 			respData["private_key"] = cb.PrivateKey
 			respData["private_key_type"] = cb.PrivateKeyType
+			if cb.AltPrivateKey != "" {
+				respData["alt_private_key"] = cb.AltPrivateKey
+			}
 		}
 
 	case "pem_bundle":
@@ -270,6 +312,9 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if !useCSR {
 			respData["private_key"] = cb.PrivateKey
 			respData["private_key_type"] = cb.PrivateKeyType
+			if cb.AltPrivateKey != "" {
+				respData["alt_private_key"] = cb.AltPrivateKey
+			}
 		}
 
 	case "der":
@@ -287,6 +332,9 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		if !useCSR {
 			respData["private_key"] = base64.StdEncoding.EncodeToString(parsedBundle.PrivateKeyBytes)
 			respData["private_key_type"] = cb.PrivateKeyType
+			if len(parsedBundle.AltPrivateKeyBytes) > 0 {
+				respData["alt_private_key"] = base64.StdEncoding.EncodeToString(parsedBundle.AltPrivateKeyBytes)
+			}
 		}
 	}
 
@@ -0,0 +1,396 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspResponderStoragePath = "config/ocsp_responder"
+
+	ocspRequestContentType  = "application/ocsp-request"
+	ocspResponseContentType = "application/ocsp-response"
+
+	// ocspCacheSize is the maximum number of responses cached
+	ocspCacheSize = 4096
+)
+
+// oidOCSPNoCheck is the id-pkix-ocsp-nocheck extension, telling clients not
+// to check the revocation status of the delegated responder certificate
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// ocspResponder holds the delegated certificate signing OCSP responses on
+// behalf of the CA whose serial number it holds.
+type ocspResponder struct {
+	Certificate []byte `json:"certificate"`
+	PrivateKey  []byte `json:"private_key"`
+	CASerial    string `json:"ca_serial"`
+
+	certificate *x509.Certificate
+	signer      crypto.Signer
+}
+
+// ocspCacheEntry is a signed OCSP response and the time it is served until
+type ocspCacheEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+func pathOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ocsp",
+		Fields: map[string]*framework.FieldSchema{
+			"req": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The base64 encoded DER OCSP request.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOCSPRequest,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+func pathOCSPGet(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp/(?P<req>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"req": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The base64 encoded DER OCSP request.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathOCSPRequest,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+func (b *backend) pathOCSPRequest(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config.Disable {
+		return logical.ErrorResponse("the OCSP responder is disabled"), nil
+	}
+
+	der, err := base64.StdEncoding.DecodeString(data.Get("req").(string))
+	if err != nil {
+		return ocspErrorResponse(ocsp.MalformedRequestErrorResponse), nil
+	}
+	ocspReq, err := ocsp.ParseRequest(der)
+	if err != nil || !ocspReq.HashAlgorithm.Available() {
+		return ocspErrorResponse(ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case errutil.UserError:
+		// Without a CA this backend is not authoritative for any certificate
+		return ocspErrorResponse(ocsp.UnauthorizedErrorResponse), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+
+	ok, err := ocspRequestMatchesIssuer(ocspReq, caInfo.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return ocspErrorResponse(ocsp.UnauthorizedErrorResponse), nil
+	}
+
+	caSerial := certutil.GetHexFormatted(caInfo.Certificate.SerialNumber.Bytes(), ":")
+	serial := certutil.GetHexFormatted(ocspReq.SerialNumber.Bytes(), ":")
+	cacheKey := fmt.Sprintf("%s/%d/%s", caSerial, ocspReq.HashAlgorithm, serial)
+
+	now := time.Now()
+	if cached, ok := b.ocspCache.Get(cacheKey); ok {
+		entry := cached.(*ocspCacheEntry)
+		if now.Before(entry.expires) {
+			return ocspResponse(entry.response, entry.expires.Sub(now)), nil
+		}
+	}
+
+	responder, err := b.ocspResponder(ctx, req, caInfo, config)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := b.ocspStatus(ctx, req, ocspReq.SerialNumber, serial)
+	if err != nil {
+		return nil, err
+	}
+	template.IssuerHash = ocspReq.HashAlgorithm
+	template.Certificate = responder.certificate
+	template.ThisUpdate = now
+	template.NextUpdate = now.Add(config.NextUpdate)
+	if template.NextUpdate.After(responder.certificate.NotAfter) {
+		template.NextUpdate = responder.certificate.NotAfter
+	}
+
+	response, err := ocsp.CreateResponse(caInfo.Certificate, responder.certificate, template, responder.signer)
+	if err != nil {
+		return nil, errwrap.Wrapf("error signing OCSP response: {{err}}", err)
+	}
+
+	// Serve the response from the cache until halfway to its next update, so
+	// that clients caching it in turn still get a reasonably fresh response
+	expires := now.Add(template.NextUpdate.Sub(now) / 2)
+	b.ocspCache.Add(cacheKey, &ocspCacheEntry{
+		response: response,
+		expires:  expires,
+	})
+
+	return ocspResponse(response, expires.Sub(now)), nil
+}
+
+// ocspStatus returns the template of the response for the certificate with
+// the given serial number.
+func (b *backend) ocspStatus(ctx context.Context, req *logical.Request, serialNumber *big.Int, serial string) (ocsp.Response, error) {
+	template := ocsp.Response{
+		SerialNumber: serialNumber,
+		Status:       ocsp.Unknown,
+	}
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	revokedEntry, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+	if err != nil {
+		return template, err
+	}
+	if revokedEntry != nil {
+		var revInfo revocationInfo
+		if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+			return template, errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %s: {{err}}", serial), err)
+		}
+		template.Status = ocsp.Revoked
+		template.RevokedAt = time.Unix(revInfo.RevocationTime, 0)
+		template.RevocationReason = ocsp.Unspecified
+		return template, nil
+	}
+
+	// Certificates issued without being stored are unknown
+	certEntry, err := fetchCertBySerial(ctx, req, "certs/", serial)
+	if err != nil {
+		return template, err
+	}
+	if certEntry != nil {
+		template.Status = ocsp.Good
+	}
+
+	return template, nil
+}
+
+// ocspResponder returns the delegated responder of the CA, issuing a new one
+// if there is none, it was issued by a previous CA, or less than a third of
+// its lifetime remains.
+func (b *backend) ocspResponder(ctx context.Context, req *logical.Request, caInfo *certutil.CAInfoBundle, config *ocspConfig) (*ocspResponder, error) {
+	b.ocspLock.Lock()
+	defer b.ocspLock.Unlock()
+
+	caSerial := certutil.GetHexFormatted(caInfo.Certificate.SerialNumber.Bytes(), ":")
+
+	entry, err := req.Storage.Get(ctx, ocspResponderStoragePath)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		var responder ocspResponder
+		if err := entry.DecodeJSON(&responder); err != nil {
+			return nil, err
+		}
+		if err := responder.parse(); err != nil {
+			return nil, err
+		}
+
+		cert := responder.certificate
+		renewAt := cert.NotAfter.Add(-cert.NotAfter.Sub(cert.NotBefore) / 3)
+		if responder.CASerial == caSerial && time.Now().Before(renewAt) {
+			return &responder, nil
+		}
+	}
+
+	responder, err := issueOCSPResponder(caInfo, config.ResponderTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err = logical.StorageEntryJSON(ocspResponderStoragePath, responder)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return responder, nil
+}
+
+func (r *ocspResponder) parse() error {
+	cert, err := x509.ParseCertificate(r.Certificate)
+	if err != nil {
+		return errwrap.Wrapf("error parsing OCSP responder certificate: {{err}}", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(r.PrivateKey)
+	if err != nil {
+		return errwrap.Wrapf("error parsing OCSP responder key: {{err}}", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("OCSP responder key is not a signer")
+	}
+
+	r.certificate = cert
+	r.signer = signer
+	return nil
+}
+
+// issueOCSPResponder issues a delegated responder certificate from the CA,
+// with an RSA key for RSA CAs and an EC key otherwise, as OCSP responses can't
+// be signed with Ed25519 keys.
+func issueOCSPResponder(caInfo *certutil.CAInfoBundle, ttl time.Duration) (*ocspResponder, error) {
+	var signer crypto.Signer
+	var err error
+	switch caInfo.PrivateKeyType {
+	case certutil.RSAPrivateKey:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf("error generating OCSP responder key: {{err}}", err)
+	}
+
+	serialNumber, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := time.Now().Add(ttl)
+	if notAfter.After(caInfo.Certificate.NotAfter) {
+		notAfter = caInfo.Certificate.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s OCSP Responder", caInfo.Certificate.Subject.CommonName),
+		},
+		NotBefore:   time.Now().Add(-30 * time.Second),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidOCSPNoCheck, Value: asn1.NullBytes},
+		},
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, caInfo.Certificate, signer.Public(), caInfo.PrivateKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("error issuing OCSP responder certificate: {{err}}", err)
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	responder := &ocspResponder{
+		Certificate: certBytes,
+		PrivateKey:  keyBytes,
+		CASerial:    certutil.GetHexFormatted(caInfo.Certificate.SerialNumber.Bytes(), ":"),
+	}
+	if err := responder.parse(); err != nil {
+		return nil, err
+	}
+	return responder, nil
+}
+
+// ocspRequestMatchesIssuer returns whether the certificate the OCSP request
+// is about was issued by the CA.
+func ocspRequestMatchesIssuer(ocspReq *ocsp.Request, ca *x509.Certificate) (bool, error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(ca.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return false, err
+	}
+
+	h := ocspReq.HashAlgorithm.New()
+	h.Write(publicKeyInfo.PublicKey.RightAlign())
+	issuerKeyHash := h.Sum(nil)
+
+	h.Reset()
+	h.Write(ca.RawSubject)
+	issuerNameHash := h.Sum(nil)
+
+	return bytes.Equal(issuerKeyHash, ocspReq.IssuerKeyHash) && bytes.Equal(issuerNameHash, ocspReq.IssuerNameHash), nil
+}
+
+func ocspResponse(response []byte, maxAge time.Duration) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType:     ocspResponseContentType,
+			logical.HTTPRawBody:         response,
+			logical.HTTPStatusCode:      200,
+			logical.HTTPRawCacheControl: fmt.Sprintf("max-age=%d, public, no-transform, must-revalidate", int64(maxAge.Seconds())),
+		},
+	}
+}
+
+// ocspErrorResponse returns an unsigned OCSP error response. As required by
+// RFC 6960, errors are returned as OCSP responses with a 200 status code.
+func ocspErrorResponse(response []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: ocspResponseContentType,
+			logical.HTTPRawBody:     response,
+			logical.HTTPStatusCode:  200,
+		},
+	}
+}
+
+const pathOCSPHelpSyn = `
+Query the revocation status of a certificate using OCSP.
+`
+
+const pathOCSPHelpDesc = `
+This endpoint is an OCSP responder for the certificates issued by this
+backend, as described in RFC 6960. Requests can be sent either with POST, with
+the DER encoded request as the body and the "application/ocsp-request" content
+type, or with GET, with the base64 encoded request appended to the path.
+
+Responses are signed by a delegated responder certificate issued by the CA,
+and are cached until halfway to their next update. Certificates which were not
+stored when issued are reported as unknown.
+`
@@ -0,0 +1,166 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestPki_OCSP(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	parseCert := func(certPEM string) *x509.Certificate {
+		t.Helper()
+		block, _ := pem.Decode([]byte(certPEM))
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	caCert := parseCert(handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	}).Data["certificate"].(string))
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+	})
+	issue := func(name string) *x509.Certificate {
+		t.Helper()
+		return parseCert(handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+			"common_name": name,
+		}).Data["certificate"].(string))
+	}
+	good := issue("good.myvault.com")
+	revoked := issue("revoked.myvault.com")
+
+	query := func(op logical.Operation, cert *x509.Certificate) *ocsp.Response {
+		t.Helper()
+		der, err := ocsp.CreateRequest(cert, caCert, &ocsp.RequestOptions{Hash: crypto.SHA256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := "ocsp"
+		data := map[string]interface{}{"req": base64.StdEncoding.EncodeToString(der)}
+		if op == logical.ReadOperation {
+			path = "ocsp/" + base64.StdEncoding.EncodeToString(der)
+			data = nil
+		}
+		resp := handle(op, path, data)
+		if resp.Data[logical.HTTPContentType] != ocspResponseContentType {
+			t.Fatalf("bad: %#v", resp.Data)
+		}
+		ocspResp, err := ocsp.ParseResponseForCert(resp.Data[logical.HTTPRawBody].([]byte), cert, caCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ocspResp
+	}
+
+	ocspResp := query(logical.UpdateOperation, good)
+	if ocspResp.Status != ocsp.Good {
+		t.Fatalf("bad status: %d", ocspResp.Status)
+	}
+	// The response is signed by a delegated responder
+	responder := ocspResp.Certificate
+	if responder == nil || responder.Equal(caCert) || len(responder.ExtKeyUsage) != 1 || responder.ExtKeyUsage[0] != x509.ExtKeyUsageOCSPSigning {
+		t.Fatalf("bad responder certificate: %#v", responder)
+	}
+	if d := ocspResp.NextUpdate.Sub(ocspResp.ThisUpdate); d != time.Hour {
+		t.Fatalf("bad next update: %s", d)
+	}
+
+	if ocspResp := query(logical.ReadOperation, revoked); ocspResp.Status != ocsp.Good {
+		t.Fatalf("bad status: %d", ocspResp.Status)
+	}
+	// Revocation is reflected despite the cached response
+	handle(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": certutil.GetHexFormatted(revoked.SerialNumber.Bytes(), ":"),
+	})
+	ocspResp = query(logical.ReadOperation, revoked)
+	if ocspResp.Status != ocsp.Revoked || ocspResp.RevokedAt.IsZero() {
+		t.Fatalf("bad status: %d", ocspResp.Status)
+	}
+	if !ocspResp.Certificate.Equal(responder) {
+		t.Fatalf("expected the responder to be reused")
+	}
+
+	// Certificates of other CAs are not answered for
+	other, otherStorage := createBackendWithStorage(t)
+	resp, err := other.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "root/generate/internal",
+		Storage:   otherStorage,
+		Data: map[string]interface{}{
+			"common_name": "other.com",
+		},
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	otherCA := parseCert(resp.Data["certificate"].(string))
+	der, err := ocsp.CreateRequest(good, otherCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = handle(logical.UpdateOperation, "ocsp", map[string]interface{}{"req": base64.StdEncoding.EncodeToString(der)})
+	if _, err := ocsp.ParseResponse(resp.Data[logical.HTTPRawBody].([]byte), nil); err != (ocsp.ResponseError{Status: ocsp.Unauthorized}) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+
+	resp = handle(logical.UpdateOperation, "ocsp", map[string]interface{}{"req": base64.StdEncoding.EncodeToString([]byte("foo"))})
+	if _, err := ocsp.ParseResponse(resp.Data[logical.HTTPRawBody].([]byte), nil); err != (ocsp.ResponseError{Status: ocsp.Malformed}) {
+		t.Fatalf("expected malformed, got %v", err)
+	}
+
+	// Updating the configuration issues a new responder
+	handle(logical.UpdateOperation, "config/ocsp", map[string]interface{}{
+		"next_update": "10m",
+	})
+	resp = handle(logical.ReadOperation, "config/ocsp", nil)
+	if resp.Data["next_update"] != int64(600) || resp.Data["responder_ttl"] != int64(720*3600) || resp.Data["disable"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	ocspResp = query(logical.UpdateOperation, good)
+	if ocspResp.Certificate.Equal(responder) {
+		t.Fatalf("expected a new responder")
+	}
+	if d := ocspResp.NextUpdate.Sub(ocspResp.ThisUpdate); d != 10*time.Minute {
+		t.Fatalf("bad next update: %s", d)
+	}
+
+	handle(logical.UpdateOperation, "config/ocsp", map[string]interface{}{
+		"disable": true,
+	})
+	resp, err = request(logical.UpdateOperation, "ocsp", map[string]interface{}{"req": base64.StdEncoding.EncodeToString(der)})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+}
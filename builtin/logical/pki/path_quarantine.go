@@ -0,0 +1,177 @@
+package pki
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const quarantineStoragePath = "config/quarantine"
+
+// quarantineEntry marks the CA of the mount as suspected compromised. The
+// quarantine only applies to the CA whose serial number it holds, so that
+// replacing the CA lifts it.
+type quarantineEntry struct {
+	SerialNumber   string    `json:"serial_number"`
+	Reason         string    `json:"reason"`
+	QuarantineTime time.Time `json:"quarantine_time"`
+}
+
+func pathQuarantine(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "quarantine",
+		Fields: map[string]*framework.FieldSchema{
+			"reason": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The reason the CA is quarantined, returned to the clients whose issuance requests are blocked.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathQuarantineRead,
+			logical.UpdateOperation: b.pathQuarantineWrite,
+			logical.DeleteOperation: b.pathQuarantineDelete,
+		},
+
+		HelpSynopsis:    pathQuarantineHelpSyn,
+		HelpDescription: pathQuarantineHelpDesc,
+	}
+}
+
+func (b *backend) quarantine(ctx context.Context, s logical.Storage) (*quarantineEntry, error) {
+	entry, err := s.Get(ctx, quarantineStoragePath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result quarantineEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// checkQuarantine returns an error response if the CA signing a new
+// certificate is quarantined. Blocked requests are logged in addition to being
+// audited, so that attempts to use a compromised CA stand out.
+func (b *backend) checkQuarantine(ctx context.Context, req *logical.Request, signingBundle *certutil.CAInfoBundle) (*logical.Response, error) {
+	quarantine, err := b.quarantine(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	serial := certutil.GetHexFormatted(signingBundle.Certificate.SerialNumber.Bytes(), ":")
+	if quarantine == nil || quarantine.SerialNumber != serial {
+		return nil, nil
+	}
+
+	b.Logger().Warn("blocked issuance from quarantined CA", "path", req.Path, "serial_number", serial, "reason", quarantine.Reason)
+	return logical.ErrorResponse(fmt.Sprintf("issuance is blocked: the CA %s is quarantined: %s", serial, quarantine.Reason)), nil
+}
+
+func (b *backend) pathQuarantineRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	quarantine, err := b.quarantine(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if quarantine == nil {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"quarantined": false,
+			},
+		}, nil
+	}
+
+	// A quarantine no longer applies once the CA is replaced, including by
+	// the key of a pending intermediate CSR
+	var quarantined bool
+	if caInfo, err := fetchCAInfo(ctx, req); err == nil {
+		quarantined = quarantine.SerialNumber == certutil.GetHexFormatted(caInfo.Certificate.SerialNumber.Bytes(), ":")
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"quarantined":     quarantined,
+			"serial_number":   quarantine.SerialNumber,
+			"reason":          quarantine.Reason,
+			"quarantine_time": quarantine.QuarantineTime,
+		},
+	}, nil
+}
+
+func (b *backend) pathQuarantineWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	reason := data.Get("reason").(string)
+	if reason == "" {
+		return logical.ErrorResponse("missing reason"), nil
+	}
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+
+	quarantine := &quarantineEntry{
+		SerialNumber:   certutil.GetHexFormatted(caInfo.Certificate.SerialNumber.Bytes(), ":"),
+		Reason:         reason,
+		QuarantineTime: time.Now().UTC(),
+	}
+	entry, err := logical.StorageEntryJSON(quarantineStoragePath, quarantine)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.Logger().Warn("quarantined CA", "serial_number", quarantine.SerialNumber, "reason", reason)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"quarantined":     true,
+			"serial_number":   quarantine.SerialNumber,
+			"reason":          quarantine.Reason,
+			"quarantine_time": quarantine.QuarantineTime,
+		},
+	}, nil
+}
+
+func (b *backend) pathQuarantineDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	quarantine, err := b.quarantine(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if quarantine == nil {
+		return nil, nil
+	}
+
+	if err := req.Storage.Delete(ctx, quarantineStoragePath); err != nil {
+		return nil, err
+	}
+
+	b.Logger().Warn("lifted quarantine of CA", "serial_number", quarantine.SerialNumber)
+	return nil, nil
+}
+
+const pathQuarantineHelpSyn = `
+Quarantine the CA on suspected compromise.
+`
+
+const pathQuarantineHelpDesc = `
+Writing to this endpoint quarantines the CA of the mount: all new issuance
+from the CA, through the issue, sign, sign-verbatim, root/sign-intermediate
+and root/sign-self-issued endpoints, is blocked and logged, while the CRL
+keeps being built and served so that existing certificates can still be
+validated and revoked. Deleting the endpoint lifts the quarantine, which also
+stops applying once the CA is replaced.
+`
@@ -0,0 +1,123 @@
+package pki
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_Quarantine(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectBlocked := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(logical.UpdateOperation, path, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "quarantined: key leaked") {
+			t.Fatalf("expected issuance to be blocked on %s, got %#v", path, resp)
+		}
+	}
+
+	// A CA is required to quarantine
+	resp, err := request(logical.UpdateOperation, "quarantine", map[string]interface{}{"reason": "key leaked"})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+
+	resp = handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	caSerial := resp.Data["serial_number"].(string)
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"ttl":              "1h",
+	})
+	issued := handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	}).Data["serial_number"].(string)
+
+	if resp := handle(logical.ReadOperation, "quarantine", nil); resp.Data["quarantined"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// A reason is required
+	resp, err = request(logical.UpdateOperation, "quarantine", nil)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+
+	handle(logical.UpdateOperation, "quarantine", map[string]interface{}{"reason": "key leaked"})
+	resp = handle(logical.ReadOperation, "quarantine", nil)
+	if resp.Data["quarantined"] != true || resp.Data["serial_number"] != caSerial || resp.Data["reason"] != "key leaked" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// All issuance from the CA is blocked
+	expectBlocked("issue/test", map[string]interface{}{"common_name": "bar.myvault.com"})
+	// Generating a CSR replaces the CA of a mount, so use another one
+	intermediate, intermediateStorage := createBackendWithStorage(t)
+	resp, err = intermediate.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "intermediate/generate/internal",
+		Storage:   intermediateStorage,
+		Data: map[string]interface{}{
+			"common_name": "intermediate.myvault.com",
+		},
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	csr := resp.Data["csr"]
+	expectBlocked("sign/test", map[string]interface{}{"csr": csr, "common_name": "bar.myvault.com"})
+	expectBlocked("sign-verbatim", map[string]interface{}{"csr": csr})
+	expectBlocked("root/sign-intermediate", map[string]interface{}{"csr": csr})
+
+	// Certificates can still be revoked and the CRL is still built
+	handle(logical.UpdateOperation, "revoke", map[string]interface{}{"serial_number": issued})
+	handle(logical.ReadOperation, "crl/rotate", nil)
+	if resp := handle(logical.ReadOperation, "cert/crl", nil); resp.Data["certificate"] == "" {
+		t.Fatalf("expected CRL to be served: %#v", resp.Data)
+	}
+
+	// Lifting the quarantine allows issuance again
+	handle(logical.DeleteOperation, "quarantine", nil)
+	handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "bar.myvault.com",
+	})
+
+	// Replacing the CA lifts the quarantine
+	handle(logical.UpdateOperation, "quarantine", map[string]interface{}{"reason": "key leaked"})
+	handle(logical.DeleteOperation, "root", nil)
+	handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if resp := handle(logical.ReadOperation, "quarantine", nil); resp.Data["quarantined"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "bar.myvault.com",
+	})
+}
@@ -0,0 +1,218 @@
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathReissue(b *backend) *framework.Path {
+	ret := &framework.Path{
+		Pattern: "reissue/" + framework.GenericNameRegex("role"),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathReissue,
+		},
+
+		HelpSynopsis:    pathReissueHelpSyn,
+		HelpDescription: pathReissueHelpDesc,
+	}
+
+	ret.Fields = addNonCACommonFields(map[string]*framework.FieldSchema{})
+
+	ret.Fields["certificate"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `PEM-format certificate to be re-issued.`,
+	}
+
+	ret.Fields["csr"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `PEM-format CSR signed with the private key of the
+certificate, proving its possession.`,
+	}
+
+	return ret
+}
+
+// pathReissue issues a certificate with the names of a still-valid certificate
+// issued by this CA, subject to role restrictions. The CSR proves that the
+// client holds the private key of the certificate.
+func (b *backend) pathReissue(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role").(string)
+
+	// Get the role
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", roleName)), nil
+	}
+
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	cert, err := parsePEMCertificate(data.Get("certificate").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	csr, err := parsePEMCSR(data.Get("csr").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid CSR signature: %v", err)), nil
+	}
+	equal, err := certutil.ComparePublicKeys(csr.PublicKey, cert.PublicKey)
+	if err != nil || !equal {
+		return logical.ErrorResponse("the CSR must be signed with the private key of the certificate"), nil
+	}
+
+	if resp, err := b.checkReissuable(ctx, req, cert); resp != nil || err != nil {
+		return resp, err
+	}
+
+	// The names are taken from the certificate, rather than from the request
+	// or the CSR, and validated against the role as for a new certificate
+	reissueRole := *role
+	reissueRole.UseCSRCommonName = false
+	reissueRole.UseCSRSANs = false
+
+	reissueData, err := reissueFieldData(data, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.pathIssueSignCert(ctx, req, reissueData, &reissueRole, true, false)
+	if resp != nil && sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, err
+}
+
+// checkReissuable checks that the certificate was issued by the CA, and is
+// neither expired nor revoked.
+func (b *backend) checkReissuable(ctx context.Context, req *logical.Request, cert *x509.Certificate) (*logical.Response, error) {
+	signingBundle, caErr := fetchCAInfo(ctx, req)
+	switch caErr.(type) {
+	case errutil.UserError:
+		return nil, errutil.UserError{Err: fmt.Sprintf(
+			"could not fetch the CA certificate (was one set?): %s", caErr)}
+	case errutil.InternalError:
+		return nil, errutil.InternalError{Err: fmt.Sprintf(
+			"error fetching CA certificate: %s", caErr)}
+	}
+
+	if err := cert.CheckSignatureFrom(signingBundle.Certificate); err != nil {
+		return logical.ErrorResponse("the certificate was not issued by this CA"), nil
+	}
+
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return logical.ErrorResponse("the certificate is not currently valid"), nil
+	}
+
+	serial := certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":")
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	revokedEntry, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if revokedEntry != nil {
+		return logical.ErrorResponse(fmt.Sprintf("the certificate with serial %s is revoked", serial)), nil
+	}
+
+	return nil, nil
+}
+
+// reissueFieldData returns a copy of the request data with the subject and
+// alternative names replaced by those of the certificate.
+func reissueFieldData(data *framework.FieldData, cert *x509.Certificate) (*framework.FieldData, error) {
+	raw := make(map[string]interface{}, len(data.Raw))
+	for k, v := range data.Raw {
+		raw[k] = v
+	}
+
+	altNames := append(append([]string{}, cert.DNSNames...), cert.EmailAddresses...)
+	var ipSANs, uriSANs []string
+	for _, ip := range cert.IPAddresses {
+		ipSANs = append(ipSANs, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		uriSANs = append(uriSANs, uri.String())
+	}
+	others, err := getOtherSANsFromX509Extensions(cert.Extensions)
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("could not parse the other SANs of the certificate: %v", err)}
+	}
+	var otherSANs []string
+	for _, other := range others {
+		otherSANs = append(otherSANs, other.String())
+	}
+
+	raw["common_name"] = cert.Subject.CommonName
+	raw["serial_number"] = cert.Subject.SerialNumber
+	raw["alt_names"] = strings.Join(altNames, ",")
+	raw["ip_sans"] = ipSANs
+	raw["uri_sans"] = uriSANs
+	raw["other_sans"] = otherSANs
+	raw["exclude_cn_from_sans"] = true
+
+	return &framework.FieldData{
+		Raw:    raw,
+		Schema: data.Schema,
+	}, nil
+}
+
+func parsePEMCertificate(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("certificate contains no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certificate could not be parsed: %v", err)
+	}
+	return cert, nil
+}
+
+func parsePEMCSR(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		return nil, fmt.Errorf("csr contains no data")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("certificate request could not be parsed: %v", err)
+	}
+	return csr, nil
+}
+
+const pathReissueHelpSyn = `
+Re-issue a certificate using a certain role, given the certificate and proof of
+possession of its private key.
+`
+
+const pathReissueHelpDesc = `
+This path allows renewing a still-valid, non-revoked certificate issued by this
+CA without knowing the parameters it was issued with. The new certificate has
+the common name and alternative names of the given certificate, which must
+still be allowed by the policy of the role, and the public key of the CSR.
+
+The CSR must be signed with the private key of the certificate, proving its
+possession. The names in the request and in the CSR are ignored.
+`
@@ -0,0 +1,172 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_Reissue(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectError := func(data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(logical.UpdateOperation, "reissue/test", data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected re-issuance to fail, got %#v", resp)
+		}
+	}
+	csrFor := func(key crypto.Signer) string {
+		t.Helper()
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: "other.example.com"},
+			DNSNames: []string{"other.example.com"},
+		}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))
+	}
+
+	handle(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+		"ttl":              "1h",
+	})
+
+	resp := handle(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+		"alt_names":   "bar.myvault.com",
+		"ip_sans":     "10.0.0.1",
+	})
+	certPEM := resp.Data["certificate"].(string)
+	bundle, err := certutil.ParsePEMBundle(resp.Data["private_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := parsePEMCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The names of the certificate are kept, rather than those of the CSR
+	resp = handle(logical.UpdateOperation, "reissue/test", map[string]interface{}{
+		"certificate": certPEM,
+		"csr":         csrFor(bundle.PrivateKey),
+		"common_name": "ignored.myvault.com",
+	})
+	reissued, err := parsePEMCertificate(resp.Data["certificate"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reissued.SerialNumber.Cmp(original.SerialNumber) == 0 {
+		t.Fatal("expected a new serial number")
+	}
+	if reissued.Subject.CommonName != "foo.myvault.com" {
+		t.Fatalf("bad common name: %s", reissued.Subject.CommonName)
+	}
+	sort.Strings(original.DNSNames)
+	sort.Strings(reissued.DNSNames)
+	if !reflect.DeepEqual(reissued.DNSNames, original.DNSNames) {
+		t.Fatalf("bad DNS names: %v, expected %v", reissued.DNSNames, original.DNSNames)
+	}
+	if len(reissued.IPAddresses) != 1 || !reissued.IPAddresses[0].Equal(original.IPAddresses[0]) {
+		t.Fatalf("bad IP addresses: %v", reissued.IPAddresses)
+	}
+	if equal, err := certutil.ComparePublicKeys(reissued.PublicKey, original.PublicKey); err != nil || !equal {
+		t.Fatal("expected the public key of the certificate")
+	}
+
+	// Possession of the private key is required
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(map[string]interface{}{
+		"certificate": certPEM,
+		"csr":         csrFor(otherKey),
+	})
+
+	// Certificates from other CAs aren't re-issued
+	selfSigned, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "foo.myvault.com"},
+		DNSNames:     []string{"foo.myvault.com"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "foo.myvault.com"},
+	}, otherKey.Public(), otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(map[string]interface{}{
+		"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: selfSigned})),
+		"csr":         csrFor(otherKey),
+	})
+
+	// The names must still be allowed by the role
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+		"ttl":              "1h",
+	})
+	expectError(map[string]interface{}{
+		"certificate": certPEM,
+		"csr":         csrFor(bundle.PrivateKey),
+	})
+	handle(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"key_type":         "ec",
+		"key_bits":         256,
+		"ttl":              "1h",
+	})
+
+	// Revoked certificates aren't re-issued
+	handle(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": certutil.GetHexFormatted(original.SerialNumber.Bytes(), ":"),
+	})
+	expectError(map[string]interface{}{
+		"certificate": certPEM,
+		"csr":         csrFor(bundle.PrivateKey),
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/sunsetutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -29,7 +30,7 @@ func pathListRoles(b *backend) *framework.Path {
 }
 
 func pathRoles(b *backend) *framework.Path {
-	return &framework.Path{
+	ret := &framework.Path{
 		Pattern: "roles/" + framework.GenericNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
 			"backend": &framework.FieldSchema{
@@ -193,9 +194,9 @@ protection use. Defaults to false.`,
 			"key_type": &framework.FieldSchema{
 				Type:    framework.TypeString,
 				Default: "rsa",
-				Description: `The type of key to use; defaults to RSA. "rsa"
-and "ec" are the only valid values.`,
-				AllowedValues: []interface{}{"rsa", "ec"},
+				Description: `The type of key to use; defaults to RSA. "rsa",
+"ec" and "ed25519" are the only valid values.`,
+				AllowedValues: []interface{}{"rsa", "ec", "ed25519"},
 			},
 
 			"key_bits": &framework.FieldSchema{
@@ -203,7 +204,18 @@ and "ec" are the only valid values.`,
 				Default: 2048,
 				Description: `The number of bits to use. You will almost
 certainly want to change this if you adjust
-the key_type.`,
+the key_type. Ignored for ed25519.`,
+			},
+
+			"alt_key_type": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `If set, issued certificates are hybrid
+certificates carrying an alternative post-quantum
+public key of this type, whose private key is
+returned with the certificate. "ml-dsa-44",
+"ml-dsa-65" and "ml-dsa-87" are the only valid
+values. Experimental; requires the
+VAULT_EXPERIMENTAL_ML_DSA environment variable.`,
 			},
 
 			"key_usage": &framework.FieldSchema{
@@ -355,6 +367,14 @@ for "generate_lease".`,
 				Description: `A comma-separated string or list of policy oids.`,
 			},
 
+			"custom_extensions": &framework.FieldSchema{
+				Type: framework.TypeStringSlice,
+				Description: `A list of extensions to add to issued certificates, in the
+format "<oid>[;critical];<type>:<value>". The type can be "utf8" or "ia5" for a
+string value, or "hex" for a hex-encoded DER value. Extensions under 2.5.29,
+such as key usages and name constraints, are set by Vault and can't be given.`,
+			},
+
 			"basic_constraints_valid_for_non_ca": &framework.FieldSchema{
 				Type:        framework.TypeBool,
 				Description: `Mark Basic Constraints valid when issuing non-CA certificates.`,
@@ -381,6 +401,10 @@ for "generate_lease".`,
 		HelpSynopsis:    pathRoleHelpSyn,
 		HelpDescription: pathRoleHelpDesc,
 	}
+
+	sunsetutil.AddSunsetFields(ret.Fields)
+
+	return ret
 }
 
 func (b *backend) getRole(ctx context.Context, s logical.Storage, n string) (*roleEntry, error) {
@@ -579,6 +603,12 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		PolicyIdentifiers:             data.Get("policy_identifiers").([]string),
 		BasicConstraintsValidForNonCA: data.Get("basic_constraints_valid_for_non_ca").(bool),
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
+		CustomExtensions:              data.Get("custom_extensions").([]string),
+		AltKeyType:                    data.Get("alt_key_type").(string),
+	}
+
+	if err := entry.ParseSunsetFields(req, data); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
 	}
 
 	allowedOtherSANs := data.Get("allowed_other_sans").([]string)
@@ -614,6 +644,10 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
+	if err := validateAltKeyType(entry.AltKeyType); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	if len(entry.ExtKeyUsageOIDs) > 0 {
 		for _, oidstr := range entry.ExtKeyUsageOIDs {
 			_, err := certutil.StringToOid(oidstr)
@@ -632,6 +666,10 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		}
 	}
 
+	if _, err := parseCustomExtensions(entry.CustomExtensions); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	// Store it
 	jsonEntry, err := logical.StorageEntryJSON("role/"+name, entry)
 	if err != nil {
@@ -774,6 +812,10 @@ type roleEntry struct {
 	ExtKeyUsageOIDs               []string      `json:"ext_key_usage_oids" mapstructure:"ext_key_usage_oids"`
 	BasicConstraintsValidForNonCA bool          `json:"basic_constraints_valid_for_non_ca" mapstructure:"basic_constraints_valid_for_non_ca"`
 	NotBeforeDuration             time.Duration `json:"not_before_duration" mapstructure:"not_before_duration"`
+	CustomExtensions              []string      `json:"custom_extensions" mapstructure:"custom_extensions"`
+	AltKeyType                    string        `json:"alt_key_type" mapstructure:"alt_key_type"`
+
+	sunsetutil.SunsetParams
 
 	// Used internally for signing intermediates
 	AllowExpirationPastCA bool
@@ -819,6 +861,8 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"policy_identifiers":                 r.PolicyIdentifiers,
 		"basic_constraints_valid_for_non_ca": r.BasicConstraintsValidForNonCA,
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
+		"custom_extensions":                  r.CustomExtensions,
+		"alt_key_type":                       r.AltKeyType,
 	}
 	if r.MaxPathLength != nil {
 		responseData["max_path_length"] = r.MaxPathLength
@@ -826,6 +870,7 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 	if r.GenerateLease != nil {
 		responseData["generate_lease"] = r.GenerateLease
 	}
+	r.PopulateSunsetData(responseData)
 	return responseData
 }
 
@@ -34,6 +34,15 @@ func pathGenerateRoot(b *backend) *framework.Path {
 	ret.Fields = addCACommonFields(map[string]*framework.FieldSchema{})
 	ret.Fields = addCAKeyGenerationFields(ret.Fields)
 	ret.Fields = addCAIssueFields(ret.Fields)
+	ret.Fields["alt_key_type"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `If set, the root is a hybrid certificate carrying
+an alternative post-quantum public key of this type,
+and the certificates it issues carry an alternative
+signature. "ml-dsa-44", "ml-dsa-65" and "ml-dsa-87"
+are the only valid values. Experimental; requires the
+VAULT_EXPERIMENTAL_ML_DSA environment variable.`,
+	}
 
 	return ret
 }
@@ -114,6 +123,9 @@ func pathSignSelfIssued(b *backend) *framework.Path {
 }
 
 func (b *backend) pathCADeleteRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, quarantineStoragePath); err != nil {
+		return nil, err
+	}
 	return nil, req.Storage.Delete(ctx, "config/ca_bundle")
 }
 
@@ -135,6 +147,11 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		return errorResp, nil
 	}
 
+	role.AltKeyType = data.Get("alt_key_type").(string)
+	if err := validateAltKeyType(role.AltKeyType); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	maxPathLengthIface, ok := data.GetOk("max_path_length")
 	if ok {
 		maxPathLength := maxPathLengthIface.(int)
@@ -175,6 +192,9 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		if exported {
 			resp.Data["private_key"] = cb.PrivateKey
 			resp.Data["private_key_type"] = cb.PrivateKeyType
+			if cb.AltPrivateKey != "" {
+				resp.Data["alt_private_key"] = cb.AltPrivateKey
+			}
 		}
 
 	case "pem_bundle":
@@ -184,6 +204,9 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 			resp.Data["private_key"] = cb.PrivateKey
 			resp.Data["private_key_type"] = cb.PrivateKeyType
 			resp.Data["certificate"] = fmt.Sprintf("%s\n%s", cb.PrivateKey, cb.Certificate)
+			if cb.AltPrivateKey != "" {
+				resp.Data["alt_private_key"] = cb.AltPrivateKey
+			}
 		} else {
 			resp.Data["certificate"] = cb.Certificate
 		}
@@ -194,6 +217,9 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 		if exported {
 			resp.Data["private_key"] = base64.StdEncoding.EncodeToString(parsedBundle.PrivateKeyBytes)
 			resp.Data["private_key_type"] = cb.PrivateKeyType
+			if len(parsedBundle.AltPrivateKeyBytes) > 0 {
+				resp.Data["alt_private_key"] = base64.StdEncoding.EncodeToString(parsedBundle.AltPrivateKeyBytes)
+			}
 		}
 	}
 
@@ -290,6 +316,10 @@ func (b *backend) pathCASignIntermediate(ctx context.Context, req *logical.Reque
 			"error fetching CA certificate: %s", caErr)}
 	}
 
+	if resp, err := b.checkQuarantine(ctx, req, signingBundle); resp != nil || err != nil {
+		return resp, err
+	}
+
 	useCSRValues := data.Get("use_csr_values").(bool)
 
 	maxPathLengthIface, ok := data.GetOk("max_path_length")
@@ -317,6 +347,13 @@ func (b *backend) pathCASignIntermediate(ctx context.Context, req *logical.Reque
 		return nil, errwrap.Wrapf("verification of parsed bundle failed: {{err}}", err)
 	}
 
+	// Cross-signed chains are added once the chain of the CA itself has been
+	// verified, as they are alternative paths rather than a continuation of it
+	parsedBundle.CAChain, err = fetchCAChain(ctx, req, signingBundle)
+	if err != nil {
+		return nil, err
+	}
+
 	signingCB, err := signingBundle.ToCertBundle()
 	if err != nil {
 		return nil, errwrap.Wrapf("error converting raw signing bundle to cert bundle: {{err}}", err)
@@ -416,6 +453,10 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 			"error fetching CA certificate: %s", caErr)}
 	}
 
+	if resp, err := b.checkQuarantine(ctx, req, signingBundle); resp != nil || err != nil {
+		return resp, err
+	}
+
 	signingCB, err := signingBundle.ToCertBundle()
 	if err != nil {
 		return nil, errwrap.Wrapf("error converting raw signing bundle to cert bundle: {{err}}", err)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -55,6 +56,138 @@ Defaults to 72 hours.`,
 	}
 }
 
+func pathTidyStatus(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-status$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathTidyStatusRead,
+		},
+
+		HelpSynopsis:    pathTidyStatusHelpSyn,
+		HelpDescription: pathTidyStatusHelpDesc,
+	}
+}
+
+func pathConfigAutoTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/auto-tidy",
+		Fields: map[string]*framework.FieldSchema{
+			"enabled": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Set to true to enable tidying up the backend periodically.`,
+			},
+
+			"interval_duration": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of time between the start of two
+automatic tidy operations. Defaults to 12 hours.`,
+				Default: int(defaultTidyConfig.Interval / time.Second),
+			},
+
+			"tidy_cert_store": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to true to enable tidying up
+the certificate store`,
+			},
+
+			"tidy_revoked_certs": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to true to expire all revoked
+and expired certificates, removing them both from the CRL and from storage.`,
+			},
+
+			"safety_buffer": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of extra time that must have passed
+beyond certificate expiration before it is removed
+from the backend storage and/or revocation list.
+Defaults to 72 hours.`,
+				Default: int(defaultTidyConfig.SafetyBuffer / time.Second),
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigAutoTidyRead,
+			logical.UpdateOperation: b.pathConfigAutoTidyWrite,
+		},
+
+		HelpSynopsis:    pathConfigAutoTidyHelpSyn,
+		HelpDescription: pathConfigAutoTidyHelpDesc,
+	}
+}
+
+// tidyConfig holds the parameters of a tidy operation, and the schedule of
+// the automatic ones
+type tidyConfig struct {
+	Enabled      bool          `json:"enabled"`
+	Interval     time.Duration `json:"interval_duration"`
+	CertStore    bool          `json:"tidy_cert_store"`
+	RevokedCerts bool          `json:"tidy_revoked_certs"`
+	SafetyBuffer time.Duration `json:"safety_buffer"`
+}
+
+var defaultTidyConfig = tidyConfig{
+	Enabled:      false,
+	Interval:     12 * time.Hour,
+	CertStore:    false,
+	RevokedCerts: false,
+	SafetyBuffer: 72 * time.Hour,
+}
+
+type tidyStatusState int
+
+const (
+	tidyStatusInactive tidyStatusState = iota
+	tidyStatusStarted
+	tidyStatusFinished
+	tidyStatusError
+)
+
+func (s tidyStatusState) String() string {
+	switch s {
+	case tidyStatusStarted:
+		return "Running"
+	case tidyStatusFinished:
+		return "Finished"
+	case tidyStatusError:
+		return "Error"
+	default:
+		return "Inactive"
+	}
+}
+
+// tidyStatus tracks the progress of the last tidy operation run on this node
+type tidyStatus struct {
+	config      tidyConfig
+	automatic   bool
+	state       tidyStatusState
+	err         error
+	timeStarted time.Time
+	timeEnded   time.Time
+	message     string
+
+	certStoreDeletedCount   uint
+	revokedCertDeletedCount uint
+}
+
+func (b *backend) autoTidyConfig(ctx context.Context, s logical.Storage) (*tidyConfig, error) {
+	entry, err := s.Get(ctx, "config/auto_tidy")
+	if err != nil {
+		return nil, err
+	}
+
+	config := defaultTidyConfig
+	if entry == nil {
+		return &config, nil
+	}
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
 func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	// If we are a performance standby forward the request to the active node
 	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
@@ -62,158 +195,350 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 	}
 
 	safetyBuffer := d.Get("safety_buffer").(int)
-	tidyCertStore := d.Get("tidy_cert_store").(bool)
-	tidyRevokedCerts := d.Get("tidy_revoked_certs").(bool)
-	tidyRevocationList := d.Get("tidy_revocation_list").(bool)
-
 	if safetyBuffer < 1 {
 		return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
 	}
 
-	bufferDuration := time.Duration(safetyBuffer) * time.Second
+	config := &tidyConfig{
+		CertStore:    d.Get("tidy_cert_store").(bool),
+		RevokedCerts: d.Get("tidy_revoked_certs").(bool) || d.Get("tidy_revocation_list").(bool),
+		SafetyBuffer: time.Duration(safetyBuffer) * time.Second,
+	}
 
-	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
+	if !b.startTidy(req.Storage, config, false) {
 		resp := &logical.Response{}
 		resp.AddWarning("Tidy operation already in progress.")
 		return resp, nil
 	}
 
+	resp := &logical.Response{}
+	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs and reported by the tidy-status endpoint.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+// periodicAutoTidy starts a tidy operation when automatic tidying is enabled
+// and the configured interval has passed since the last one started.
+func (b *backend) periodicAutoTidy(ctx context.Context, req *logical.Request) error {
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil
+	}
+
+	config, err := b.autoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return errwrap.Wrapf("error fetching auto tidy config: {{err}}", err)
+	}
+	if !config.Enabled {
+		return nil
+	}
+
+	b.tidyStatusLock.RLock()
+	lastAutoTidy := b.lastAutoTidy
+	b.tidyStatusLock.RUnlock()
+	if time.Now().Before(lastAutoTidy.Add(config.Interval)) {
+		return nil
+	}
+
+	b.startTidy(req.Storage, config, true)
+	return nil
+}
+
+// startTidy runs a tidy operation in the background, returning false if one
+// is already running.
+func (b *backend) startTidy(s logical.Storage, config *tidyConfig, automatic bool) bool {
+	if !atomic.CompareAndSwapUint32(b.tidyCASGuard, 0, 1) {
+		return false
+	}
+
+	b.tidyStatusLock.Lock()
+	b.tidyStatus = &tidyStatus{
+		config:      *config,
+		automatic:   automatic,
+		state:       tidyStatusStarted,
+		timeStarted: time.Now(),
+	}
+	if automatic {
+		b.lastAutoTidy = b.tidyStatus.timeStarted
+	}
+	b.tidyStatusLock.Unlock()
+
 	// Tests using framework will screw up the storage so make a locally
 	// scoped req to hold a reference
-	req = &logical.Request{
-		Storage: req.Storage,
+	req := &logical.Request{
+		Storage: s,
 	}
 
 	go func() {
 		defer atomic.StoreUint32(b.tidyCASGuard, 0)
 
 		// Don't cancel when the original client request goes away
-		ctx = context.Background()
+		ctx := context.Background()
 
 		logger := b.Logger().Named("tidy")
 
-		doTidy := func() error {
-			if tidyCertStore {
-				serials, err := req.Storage.List(ctx, "certs/")
-				if err != nil {
-					return errwrap.Wrapf("error fetching list of certs: {{err}}", err)
+		if err := b.doTidy(ctx, req, logger, config); err != nil {
+			logger.Error("error running tidy", "error", err)
+			b.tidyStatusLock.Lock()
+			b.tidyStatus.state = tidyStatusError
+			b.tidyStatus.err = err
+			b.tidyStatus.timeEnded = time.Now()
+			b.tidyStatusLock.Unlock()
+			return
+		}
+
+		b.tidyStatusLock.Lock()
+		b.tidyStatus.state = tidyStatusFinished
+		b.tidyStatus.message = ""
+		b.tidyStatus.timeEnded = time.Now()
+		b.tidyStatusLock.Unlock()
+	}()
+
+	return true
+}
+
+// updateTidyStatus applies the update to the status of the running tidy
+// operation.
+func (b *backend) updateTidyStatus(update func(*tidyStatus)) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+	update(b.tidyStatus)
+}
+
+func (b *backend) doTidy(ctx context.Context, req *logical.Request, logger hclog.Logger, config *tidyConfig) error {
+	bufferDuration := config.SafetyBuffer
+
+	if config.CertStore {
+		serials, err := req.Storage.List(ctx, "certs/")
+		if err != nil {
+			return errwrap.Wrapf("error fetching list of certs: {{err}}", err)
+		}
+
+		for i, serial := range serials {
+			b.updateTidyStatus(func(s *tidyStatus) {
+				s.message = fmt.Sprintf("Tidying certificate store: checking entry %d of %d", i+1, len(serials))
+			})
+
+			certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
+			}
+
+			if certEntry == nil {
+				logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting nil entry with serial %s: {{err}}", serial), err)
 				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.certStoreDeletedCount++ })
+				continue
+			}
 
-				for _, serial := range serials {
-					certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
-					}
-
-					if certEntry == nil {
-						logger.Warn("certificate entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting nil entry with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					if certEntry.Value == nil || len(certEntry.Value) == 0 {
-						logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting entry with nil value with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					cert, err := x509.ParseCertificate(certEntry.Value)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to parse stored certificate with serial %q: {{err}}", serial), err)
-					}
-
-					if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from storage: {{err}}", serial), err)
-						}
-					}
+			if certEntry.Value == nil || len(certEntry.Value) == 0 {
+				logger.Warn("certificate entry has no value; tidying up since it is no longer useful for any server operations", "serial", serial)
+				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting entry with nil value with serial %s: {{err}}", serial), err)
 				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.certStoreDeletedCount++ })
+				continue
+			}
+
+			cert, err := x509.ParseCertificate(certEntry.Value)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("unable to parse stored certificate with serial %q: {{err}}", serial), err)
 			}
 
-			if tidyRevokedCerts || tidyRevocationList {
-				b.revokeStorageLock.Lock()
-				defer b.revokeStorageLock.Unlock()
+			if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
+				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from storage: {{err}}", serial), err)
+				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.certStoreDeletedCount++ })
+			}
+		}
+	}
+
+	if config.RevokedCerts {
+		b.revokeStorageLock.Lock()
+		defer b.revokeStorageLock.Unlock()
 
-				tidiedRevoked := false
+		tidiedRevoked := false
 
-				revokedSerials, err := req.Storage.List(ctx, "revoked/")
-				if err != nil {
-					return errwrap.Wrapf("error fetching list of revoked certs: {{err}}", err)
+		revokedSerials, err := req.Storage.List(ctx, "revoked/")
+		if err != nil {
+			return errwrap.Wrapf("error fetching list of revoked certs: {{err}}", err)
+		}
+
+		var revInfo revocationInfo
+		for i, serial := range revokedSerials {
+			b.updateTidyStatus(func(s *tidyStatus) {
+				s.message = fmt.Sprintf("Tidying revoked certificates: checking certificate %d of %d", i+1, len(revokedSerials))
+			})
+
+			revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("unable to fetch revoked cert with serial %q: {{err}}", serial), err)
+			}
+
+			if revokedEntry == nil {
+				logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
+				if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting nil revoked entry with serial %s: {{err}}", serial), err)
 				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.revokedCertDeletedCount++ })
+				continue
+			}
 
-				var revInfo revocationInfo
-				for _, serial := range revokedSerials {
-					revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to fetch revoked cert with serial %q: {{err}}", serial), err)
-					}
-
-					if revokedEntry == nil {
-						logger.Warn("revoked entry is nil; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting nil revoked entry with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
-						logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting revoked entry with nil value with serial %s: {{err}}", serial), err)
-						}
-						continue
-					}
-
-					err = revokedEntry.DecodeJSON(&revInfo)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %q: {{err}}", serial), err)
-					}
-
-					revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
-					if err != nil {
-						return errwrap.Wrapf(fmt.Sprintf("unable to parse stored revoked certificate with serial %q: {{err}}", serial), err)
-					}
-
-					// Remove the matched certificate entries from revoked/ and
-					// cert/ paths. We compare against both the NotAfter time
-					// within the cert itself and the time from the revocation
-					// entry, and perform tidy if either one tells us that the
-					// certificate has already been revoked.
-					now := time.Now()
-					if now.After(revokedCert.NotAfter.Add(bufferDuration)) || now.After(revInfo.RevocationTimeUTC.Add(bufferDuration)) {
-						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from revoked list: {{err}}", serial), err)
-						}
-						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
-							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from store when tidying revoked: {{err}}", serial), err)
-						}
-						tidiedRevoked = true
-					}
+			if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
+				logger.Warn("revoked entry has nil value; tidying up since it is no longer useful for any server operations", "serial", serial)
+				if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting revoked entry with nil value with serial %s: {{err}}", serial), err)
 				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.revokedCertDeletedCount++ })
+				continue
+			}
+
+			err = revokedEntry.DecodeJSON(&revInfo)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("error decoding revocation entry for serial %q: {{err}}", serial), err)
+			}
+
+			revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("unable to parse stored revoked certificate with serial %q: {{err}}", serial), err)
+			}
 
-				if tidiedRevoked {
-					if err := buildCRL(ctx, b, req, false); err != nil {
-						return err
-					}
+			// Remove the matched certificate entries from revoked/ and
+			// cert/ paths. We compare against both the NotAfter time
+			// within the cert itself and the time from the revocation
+			// entry, and perform tidy if either one tells us that the
+			// certificate has already been revoked.
+			now := time.Now()
+			if now.After(revokedCert.NotAfter.Add(bufferDuration)) || now.After(revInfo.RevocationTimeUTC.Add(bufferDuration)) {
+				if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from revoked list: {{err}}", serial), err)
 				}
+				if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
+					return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from store when tidying revoked: {{err}}", serial), err)
+				}
+				b.updateTidyStatus(func(s *tidyStatus) { s.revokedCertDeletedCount++ })
+				tidiedRevoked = true
 			}
+		}
 
-			return nil
+		if tidiedRevoked {
+			b.updateTidyStatus(func(s *tidyStatus) { s.message = "Rebuilding the CRL" })
+			if err := buildCRL(ctx, b, req, false); err != nil {
+				return err
+			}
 		}
+	}
 
-		if err := doTidy(); err != nil {
-			logger.Error("error running tidy", "error", err)
-			return
+	return nil
+}
+
+func (b *backend) pathTidyStatusRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// If we are a performance standby forward the request to the active node
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	b.tidyStatusLock.RLock()
+	defer b.tidyStatusLock.RUnlock()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"state":                      tidyStatusInactive.String(),
+			"automatic":                  nil,
+			"safety_buffer":              nil,
+			"tidy_cert_store":            nil,
+			"tidy_revoked_certs":         nil,
+			"error":                      nil,
+			"time_started":               nil,
+			"time_finished":              nil,
+			"message":                    nil,
+			"cert_store_deleted_count":   nil,
+			"revoked_cert_deleted_count": nil,
+		},
+	}
+	if b.tidyStatus == nil {
+		return resp, nil
+	}
+
+	status := b.tidyStatus
+	resp.Data["state"] = status.state.String()
+	resp.Data["automatic"] = status.automatic
+	resp.Data["safety_buffer"] = int64(status.config.SafetyBuffer.Seconds())
+	resp.Data["tidy_cert_store"] = status.config.CertStore
+	resp.Data["tidy_revoked_certs"] = status.config.RevokedCerts
+	resp.Data["time_started"] = status.timeStarted.Format(time.RFC3339Nano)
+	resp.Data["message"] = status.message
+	resp.Data["cert_store_deleted_count"] = status.certStoreDeletedCount
+	resp.Data["revoked_cert_deleted_count"] = status.revokedCertDeletedCount
+	if !status.timeEnded.IsZero() {
+		resp.Data["time_finished"] = status.timeEnded.Format(time.RFC3339Nano)
+	}
+	if status.err != nil {
+		resp.Data["error"] = status.err.Error()
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathConfigAutoTidyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.autoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":            config.Enabled,
+			"interval_duration":  int64(config.Interval.Seconds()),
+			"tidy_cert_store":    config.CertStore,
+			"tidy_revoked_certs": config.RevokedCerts,
+			"safety_buffer":      int64(config.SafetyBuffer.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigAutoTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.autoTidyConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledRaw, ok := d.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if intervalRaw, ok := d.GetOk("interval_duration"); ok {
+		config.Interval = time.Duration(intervalRaw.(int)) * time.Second
+		if config.Interval <= 0 {
+			return logical.ErrorResponse("interval_duration must be greater than zero"), nil
 		}
-	}()
+	}
+	if certStoreRaw, ok := d.GetOk("tidy_cert_store"); ok {
+		config.CertStore = certStoreRaw.(bool)
+	}
+	if revokedCertsRaw, ok := d.GetOk("tidy_revoked_certs"); ok {
+		config.RevokedCerts = revokedCertsRaw.(bool)
+	}
+	if safetyBufferRaw, ok := d.GetOk("safety_buffer"); ok {
+		config.SafetyBuffer = time.Duration(safetyBufferRaw.(int)) * time.Second
+		if config.SafetyBuffer < time.Second {
+			return logical.ErrorResponse("safety_buffer must be greater than zero"), nil
+		}
+	}
 
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+	if config.Enabled && !config.CertStore && !config.RevokedCerts {
+		return logical.ErrorResponse("auto tidy requires tidy_cert_store or tidy_revoked_certs to be enabled"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/auto_tidy", config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
 }
 
 const pathTidyHelpSyn = `
@@ -242,3 +567,29 @@ certificate storage or in revocation information will then be checked. If the
 current time, minus the value of 'safety_buffer', is greater than the
 expiration, it will be removed.
 `
+
+const pathTidyStatusHelpSyn = `
+Returns the status of the last tidy operation run on this node.
+`
+
+const pathTidyStatusHelpDesc = `
+This endpoint reports whether a tidy operation, started either manually or
+automatically, is running, its progress, and the number of certificates and
+revocation entries it removed. The status is kept in memory by the node which
+ran the operation, so it is reset when Vault restarts.
+`
+
+const pathConfigAutoTidyHelpSyn = `
+Configure the automatic tidying of the backend.
+`
+
+const pathConfigAutoTidyHelpDesc = `
+When enabled, a tidy operation with the configured parameters is started in the
+background every 'interval_duration', instead of relying on the tidy endpoint
+being called externally. The parameters are the same as those of the tidy
+endpoint; see its help for details.
+
+The interval is counted from the start of the last automatic tidy operation,
+or from the time the backend was loaded. Its progress can be followed through
+the tidy-status endpoint.
+`
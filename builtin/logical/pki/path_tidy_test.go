@@ -0,0 +1,102 @@
+package pki
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPki_TidyStatusAndAutoTidy(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	waitFinished := func() *logical.Response {
+		t.Helper()
+		for i := 0; i < 100; i++ {
+			resp := handle(logical.ReadOperation, "tidy-status", nil)
+			if resp.Data["state"] == "Finished" {
+				return resp
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatal("tidy did not finish")
+		return nil
+	}
+	addEmptyCert := func() {
+		t.Helper()
+		if err := storage.Put(context.Background(), &logical.StorageEntry{Key: "certs/01-02"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := handle(logical.ReadOperation, "tidy-status", nil)
+	if resp.Data["state"] != "Inactive" {
+		t.Fatalf("bad state: %v", resp.Data["state"])
+	}
+
+	addEmptyCert()
+	handle(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_cert_store": true,
+		"safety_buffer":   "1s",
+	})
+	resp = waitFinished()
+	if resp.Data["automatic"] != false || resp.Data["tidy_cert_store"] != true ||
+		resp.Data["cert_store_deleted_count"] != uint(1) || resp.Data["time_finished"] == nil {
+		t.Fatalf("bad status: %#v", resp.Data)
+	}
+
+	// Auto tidy needs something to tidy
+	resp, err := request(logical.UpdateOperation, "config/auto-tidy", map[string]interface{}{
+		"enabled": true,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err:%v resp:%#v", err, resp)
+	}
+	handle(logical.UpdateOperation, "config/auto-tidy", map[string]interface{}{
+		"enabled":           true,
+		"interval_duration": "1h",
+		"tidy_cert_store":   true,
+	})
+	resp = handle(logical.ReadOperation, "config/auto-tidy", nil)
+	if resp.Data["interval_duration"] != int64(3600) || resp.Data["safety_buffer"] != int64(259200) {
+		t.Fatalf("bad config: %#v", resp.Data)
+	}
+
+	// Nothing runs until the interval has passed since the backend was loaded
+	addEmptyCert()
+	req := &logical.Request{Storage: storage}
+	if err := b.periodicAutoTidy(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if resp := handle(logical.ReadOperation, "tidy-status", nil); resp.Data["automatic"] != false {
+		t.Fatalf("unexpected automatic tidy: %#v", resp.Data)
+	}
+
+	b.tidyStatusLock.Lock()
+	b.lastAutoTidy = time.Now().Add(-2 * time.Hour)
+	b.tidyStatusLock.Unlock()
+	if err := b.periodicAutoTidy(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	resp = waitFinished()
+	if resp.Data["automatic"] != true || resp.Data["cert_store_deleted_count"] != uint(1) {
+		t.Fatalf("bad status: %#v", resp.Data)
+	}
+}
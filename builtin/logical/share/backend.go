@@ -0,0 +1,112 @@
+package share
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const sharePrefix = "share/"
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend(conf)
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend(conf *logical.BackendConfig) *backend {
+	b := &backend{
+		view:       conf.StorageView,
+		shareLocks: locksutil.CreateLocks(),
+	}
+
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			// Shares are fetched by the people they are handed off to, who
+			// authenticate with the share token rather than a Vault token
+			Unauthenticated: []string{
+				"fetch",
+			},
+			SealWrapStorage: []string{
+				sharePrefix,
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathCreate(b),
+			pathFetch(b),
+			pathLookup(b),
+			pathRevoke(b),
+		},
+
+		// Register a periodic function that deletes the expired shares
+		PeriodicFunc: b.tidyShares,
+		Invalidate:   b.invalidate,
+		BackendType:  logical.TypeLogical,
+	}
+
+	return b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// The salt used to hash the share tokens before storing them
+	salt      *salt.Salt
+	saltMutex sync.RWMutex
+
+	// The view to use when creating the salt
+	view logical.Storage
+
+	// Locks to read and update shares, indexed based on the salted share
+	// tokens, so that a share is never fetched more times than allowed
+	shareLocks []*locksutil.LockEntry
+}
+
+func (b *backend) Salt(ctx context.Context) (*salt.Salt, error) {
+	b.saltMutex.RLock()
+	if b.salt != nil {
+		defer b.saltMutex.RUnlock()
+		return b.salt, nil
+	}
+	b.saltMutex.RUnlock()
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	if b.salt != nil {
+		return b.salt, nil
+	}
+	salt, err := salt.NewSalt(ctx, b.view, &salt.Config{
+		HashFunc: salt.SHA256Hash,
+		Location: salt.DefaultLocation,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.salt = salt
+	return salt, nil
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	switch key {
+	case salt.DefaultLocation:
+		b.saltMutex.Lock()
+		defer b.saltMutex.Unlock()
+		b.salt = nil
+	}
+}
+
+const backendHelp = `
+The share backend turns secret payloads into single-use, time-bound share
+tokens, so that credentials can be handed off to people who have no Vault
+token. Each share can be fetched a limited number of times before it expires,
+after which it is deleted.
+`
@@ -0,0 +1,213 @@
+package share
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func request(t *testing.T, b *backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    s,
+		Data:       data,
+		MountPoint: "share/",
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+}
+
+func createShare(t *testing.T, b *backend, s logical.Storage, data map[string]interface{}) string {
+	resp, err := request(t, b, s, logical.UpdateOperation, "create", data)
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	token := resp.Data["token"].(string)
+	if resp.Data["fetch_path"] != "/v1/share/fetch" {
+		t.Fatalf("bad fetch_path: %v", resp.Data["fetch_path"])
+	}
+	return token
+}
+
+func TestShare_Fetch(t *testing.T) {
+	b, s := getBackend(t)
+
+	secret := map[string]interface{}{"username": "admin", "password": "hunter2"}
+	token := createShare(t, b, s, map[string]interface{}{
+		"data":      secret,
+		"max_views": 2,
+	})
+
+	// Looking up the share does not count as a view
+	resp, err := request(t, b, s, logical.UpdateOperation, "lookup", map[string]interface{}{"token": token})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if resp.Data["remaining_views"] != 2 || resp.Data["max_views"] != 2 {
+		t.Fatalf("bad lookup: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["data"]; ok {
+		t.Fatalf("lookup returned the data of the share")
+	}
+
+	// The token cannot be passed in the URL
+	_, err = request(t, b, s, logical.ReadOperation, "fetch", map[string]interface{}{"token": token})
+	if err != logical.ErrUnsupportedOperation {
+		t.Fatalf("expected unsupported operation, got %v", err)
+	}
+
+	for _, remaining := range []int{1, 0} {
+		resp, err = request(t, b, s, logical.UpdateOperation, "fetch", map[string]interface{}{"token": token})
+		if err != nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+		if !reflect.DeepEqual(resp.Data["data"], secret) {
+			t.Fatalf("Actual data: %#v\nExpected data: %#v", resp.Data["data"], secret)
+		}
+		if resp.Data["remaining_views"] != remaining {
+			t.Fatalf("expected %d remaining views, got %v", remaining, resp.Data["remaining_views"])
+		}
+	}
+
+	// The share was deleted after its last view
+	resp, err = request(t, b, s, logical.UpdateOperation, "fetch", map[string]interface{}{"token": token})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got resp: %#v\nerr: %v", resp, err)
+	}
+	keys, err := s.List(context.Background(), sharePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("share was not deleted: %v", keys)
+	}
+}
+
+func TestShare_Create(t *testing.T) {
+	b, s := getBackend(t)
+
+	for name, data := range map[string]map[string]interface{}{
+		"missing data":  {},
+		"no views":      {"data": map[string]interface{}{"foo": "bar"}, "max_views": 0},
+		"invalid cidrs": {"data": map[string]interface{}{"foo": "bar"}, "bound_cidrs": "not-a-cidr"},
+	} {
+		resp, err := request(t, b, s, logical.UpdateOperation, "create", data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !resp.IsError() {
+			t.Fatalf("%s: expected error response, got %#v", name, resp)
+		}
+	}
+
+	// The TTL is capped by the max lease TTL of the mount
+	resp, err := request(t, b, s, logical.UpdateOperation, "create", map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+		"ttl":  "100000h",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	if len(resp.Warnings) != 1 {
+		t.Fatalf("expected a warning, got %v", resp.Warnings)
+	}
+	if expireTime := resp.Data["expire_time"].(time.Time); expireTime.After(time.Now().Add(b.System().MaxLeaseTTL())) {
+		t.Fatalf("ttl was not capped: %s", expireTime)
+	}
+}
+
+func TestShare_BoundCIDRs(t *testing.T) {
+	b, s := getBackend(t)
+
+	token := createShare(t, b, s, map[string]interface{}{
+		"data":        map[string]interface{}{"foo": "bar"},
+		"bound_cidrs": "10.0.0.0/8",
+	})
+
+	_, err := request(t, b, s, logical.UpdateOperation, "fetch", map[string]interface{}{"token": token})
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+
+	// Denied fetches do not count as views
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       "fetch",
+		Storage:    s,
+		Data:       map[string]interface{}{"token": token},
+		Connection: &logical.Connection{RemoteAddr: "10.1.2.3"},
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+}
+
+func TestShare_Revoke(t *testing.T) {
+	b, s := getBackend(t)
+
+	token := createShare(t, b, s, map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := request(t, b, s, logical.UpdateOperation, "revoke", map[string]interface{}{"token": token})
+		if err != nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+	}
+
+	_, err := request(t, b, s, logical.UpdateOperation, "fetch", map[string]interface{}{"token": token})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+}
+
+func TestShare_Tidy(t *testing.T) {
+	b, s := getBackend(t)
+
+	expired := createShare(t, b, s, map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+	})
+	createShare(t, b, s, map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+	})
+
+	// Expire the first share
+	saltedToken, err := b.saltToken(context.Background(), expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := b.share(context.Background(), s, saltedToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.ExpireTime = time.Now().Add(-time.Second)
+	if err := b.putShare(context.Background(), s, saltedToken, entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.tidyShares(context.Background(), &logical.Request{Storage: s}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := s.List(context.Background(), sharePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || strings.Contains(keys[0], saltedToken) {
+		t.Fatalf("bad shares after tidy: %v", keys)
+	}
+}
@@ -0,0 +1,418 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sockaddr "github.com/hashicorp/go-sockaddr"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/base62"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// defaultTTL is the TTL of shares created without a TTL. The default
+	// lease TTL of the mount is not used since it is usually far too long
+	// for handing off secrets.
+	defaultTTL = time.Hour
+
+	shareTokenLength = 32
+)
+
+var errShareNotFound = errors.New("share not found or expired")
+
+// shareEntry is the storage entry of a share, stored under the salted share
+// token
+type shareEntry struct {
+	Data         map[string]interface{}        `json:"data"`
+	CreationTime time.Time                     `json:"creation_time"`
+	ExpireTime   time.Time                     `json:"expire_time"`
+	MaxViews     int                           `json:"max_views"`
+	Views        int                           `json:"views"`
+	BoundCIDRs   []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
+}
+
+func (e *shareEntry) expired() bool {
+	return time.Now().After(e.ExpireTime) || e.Views >= e.MaxViews
+}
+
+func (e *shareEntry) boundCIDRs() []string {
+	cidrs := []string{}
+	for _, cidr := range e.BoundCIDRs {
+		cidrs = append(cidrs, cidr.String())
+	}
+	return cidrs
+}
+
+func tokenField() *framework.FieldSchema {
+	return &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: "The share token returned when the share was created.",
+	}
+}
+
+func pathCreate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "create$",
+		Fields: map[string]*framework.FieldSchema{
+			"data": {
+				Type:        framework.TypeMap,
+				Description: "The secret payload to share.",
+			},
+
+			"ttl": {
+				Type:        framework.TypeDurationSecond,
+				Description: "The time after which the share expires, whether it was fetched or not. Defaults to 1 hour, and is capped by the max lease TTL of the mount.",
+			},
+
+			"max_views": {
+				Type:        framework.TypeInt,
+				Default:     1,
+				Description: "The number of times the share can be fetched before it is deleted.",
+			},
+
+			"bound_cidrs": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma separated string or list of CIDR blocks. If set, the share can only be fetched from addresses in these blocks.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCreateWrite,
+		},
+
+		HelpSynopsis:    pathCreateHelpSyn,
+		HelpDescription: pathCreateHelpDesc,
+	}
+}
+
+func pathFetch(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "fetch$",
+		Fields: map[string]*framework.FieldSchema{
+			"token": tokenField(),
+		},
+
+		// Fetching is not allowed with a read, so that the token is never
+		// passed in the URL, where it would be logged by proxies and kept in
+		// browser histories
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathFetchWrite,
+		},
+
+		HelpSynopsis:    pathFetchHelpSyn,
+		HelpDescription: pathFetchHelpDesc,
+	}
+}
+
+func pathLookup(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "lookup$",
+		Fields: map[string]*framework.FieldSchema{
+			"token": tokenField(),
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathLookupWrite,
+		},
+
+		HelpSynopsis:    pathLookupHelpSyn,
+		HelpDescription: pathLookupHelpDesc,
+	}
+}
+
+func pathRevoke(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "revoke$",
+		Fields: map[string]*framework.FieldSchema{
+			"token": tokenField(),
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathRevokeWrite,
+		},
+
+		HelpSynopsis:    pathRevokeHelpSyn,
+		HelpDescription: pathRevokeHelpDesc,
+	}
+}
+
+func (b *backend) pathCreateWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	data := d.Get("data").(map[string]interface{})
+	if len(data) == 0 {
+		return logical.ErrorResponse("missing data"), nil
+	}
+
+	maxViews := d.Get("max_views").(int)
+	if maxViews < 1 {
+		return logical.ErrorResponse("max_views must be at least 1"), nil
+	}
+
+	boundCIDRs, err := parseutil.ParseAddrs(d.Get("bound_cidrs"))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	var warnings []string
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	if maxTTL := b.System().MaxLeaseTTL(); ttl > maxTTL {
+		warnings = append(warnings, fmt.Sprintf("ttl of %q exceeds the max lease TTL of the mount, capping to %q", ttl, maxTTL))
+		ttl = maxTTL
+	}
+
+	token, err := base62.Random(shareTokenLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	saltedToken, err := b.saltToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &shareEntry{
+		Data:         data,
+		CreationTime: now,
+		ExpireTime:   now.Add(ttl),
+		MaxViews:     maxViews,
+		BoundCIDRs:   boundCIDRs,
+	}
+
+	lock := locksutil.LockForKey(b.shareLocks, saltedToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.putShare(ctx, req.Storage, saltedToken, entry); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"token":       token,
+			"fetch_path":  "/v1/" + req.MountPoint + "fetch",
+			"expire_time": entry.ExpireTime,
+			"max_views":   entry.MaxViews,
+		},
+		Warnings: warnings,
+	}, nil
+}
+
+func (b *backend) pathFetchWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	token := d.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing token"), nil
+	}
+	saltedToken, err := b.saltToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := locksutil.LockForKey(b.shareLocks, saltedToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	entry, err := b.share(ctx, req.Storage, saltedToken)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return logical.ErrorResponse(errShareNotFound.Error()), logical.ErrInvalidRequest
+	}
+
+	var remoteAddr string
+	if req.Connection != nil {
+		remoteAddr = req.Connection.RemoteAddr
+	}
+	if !cidrutil.RemoteAddrIsOk(remoteAddr, entry.BoundCIDRs) {
+		return nil, logical.ErrPermissionDenied
+	}
+
+	// Count the view before returning the data, so that a failure to
+	// update the share never allows an additional view
+	entry.Views++
+	if entry.Views >= entry.MaxViews {
+		err = req.Storage.Delete(ctx, sharePrefix+saltedToken)
+	} else {
+		err = b.putShare(ctx, req.Storage, saltedToken, entry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"data":            entry.Data,
+			"expire_time":     entry.ExpireTime,
+			"remaining_views": entry.MaxViews - entry.Views,
+		},
+	}, nil
+}
+
+func (b *backend) pathLookupWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	token := d.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing token"), nil
+	}
+	saltedToken, err := b.saltToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := locksutil.LockForKey(b.shareLocks, saltedToken)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	entry, err := b.share(ctx, req.Storage, saltedToken)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return logical.ErrorResponse(errShareNotFound.Error()), logical.ErrInvalidRequest
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"creation_time":   entry.CreationTime,
+			"expire_time":     entry.ExpireTime,
+			"max_views":       entry.MaxViews,
+			"remaining_views": entry.MaxViews - entry.Views,
+			"bound_cidrs":     entry.boundCIDRs(),
+		},
+	}, nil
+}
+
+func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	token := d.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing token"), nil
+	}
+	saltedToken, err := b.saltToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := locksutil.LockForKey(b.shareLocks, saltedToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return nil, req.Storage.Delete(ctx, sharePrefix+saltedToken)
+}
+
+// tidyShares deletes the shares that expired without being fetched as many
+// times as allowed
+func (b *backend) tidyShares(ctx context.Context, req *logical.Request) error {
+	saltedTokens, err := req.Storage.List(ctx, sharePrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, saltedToken := range saltedTokens {
+		if err := b.tidyShare(ctx, req.Storage, saltedToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *backend) tidyShare(ctx context.Context, s logical.Storage, saltedToken string) error {
+	lock := locksutil.LockForKey(b.shareLocks, saltedToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	entry, err := s.Get(ctx, sharePrefix+saltedToken)
+	if err != nil || entry == nil {
+		return err
+	}
+	var result shareEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return err
+	}
+	if !result.expired() {
+		return nil
+	}
+	return s.Delete(ctx, sharePrefix+saltedToken)
+}
+
+func (b *backend) saltToken(ctx context.Context, token string) (string, error) {
+	salt, err := b.Salt(ctx)
+	if err != nil {
+		return "", err
+	}
+	return salt.SaltID(token), nil
+}
+
+// share returns the share of the salted token, or nil if it does not exist
+// or has expired. The caller must hold the lock of the share.
+func (b *backend) share(ctx context.Context, s logical.Storage, saltedToken string) (*shareEntry, error) {
+	entry, err := s.Get(ctx, sharePrefix+saltedToken)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result shareEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.expired() {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+func (b *backend) putShare(ctx context.Context, s logical.Storage, saltedToken string, share *shareEntry) error {
+	entry, err := logical.StorageEntryJSON(sharePrefix+saltedToken, share)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+const pathCreateHelpSyn = `
+Create a share of a secret payload.
+`
+
+const pathCreateHelpDesc = `
+This path creates a share of the given data and returns its share token. The
+share can be fetched with the token, without a Vault token, until it has been
+fetched max_views times or its TTL has elapsed.
+`
+
+const pathFetchHelpSyn = `
+Fetch the secret payload of a share.
+`
+
+const pathFetchHelpDesc = `
+This path returns the data of the share of the given token, and counts the
+view. The share is deleted once it has been fetched max_views times. This path
+does not require a Vault token, so the token can be handed off to people who
+do not have access to Vault.
+`
+
+const pathLookupHelpSyn = `
+Look up the properties of a share.
+`
+
+const pathLookupHelpDesc = `
+This path returns the expiration and remaining views of the share of the given
+token, without returning its data or counting a view.
+`
+
+const pathRevokeHelpSyn = `
+Revoke a share.
+`
+
+const pathRevokeHelpDesc = `
+This path deletes the share of the given token, so that it can no longer be
+fetched. It is not an error to revoke a share that does not exist.
+`
@@ -38,6 +38,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			Unauthenticated: []string{
 				"verify",
 				"public_key",
+				"config/host_ca",
 			},
 
 			LocalStorage: []string{
@@ -60,13 +61,16 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			pathLookup(&b),
 			pathVerify(&b),
 			pathConfigCA(&b),
+			pathConfigHostCA(&b),
 			pathSign(&b),
+			pathIssue(&b),
 			pathFetchPublicKey(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretDynamicKey(&b),
 			secretOTP(&b),
+			secretHostCertificate(&b),
 		},
 
 		Invalidate:  b.invalidate,
@@ -1323,6 +1323,66 @@ func TestBackend_DisallowUserProvidedKeyIDs(t *testing.T) {
 	logicaltest.Test(t, testCase)
 }
 
+func TestBackend_RoleSunset(t *testing.T) {
+	config := logical.TestBackendConfig()
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	signStep := func(expectWarning, expectError bool) logicaltest.TestStep {
+		return logicaltest.TestStep{
+			Operation: logical.UpdateOperation,
+			Path:      "sign/testing",
+			Data: map[string]interface{}{
+				"public_key": publicKey2,
+			},
+			ErrorOk: expectError,
+			Check: func(resp *logical.Response) error {
+				if resp.IsError() != expectError {
+					return fmt.Errorf("expected error: %t, got: %#v", expectError, resp)
+				}
+				if hasWarning := len(resp.Warnings) > 0; hasWarning != expectWarning {
+					return fmt.Errorf("expected warning: %t, got: %#v", expectWarning, resp.Warnings)
+				}
+				return nil
+			},
+		}
+	}
+
+	testCase := logicaltest.TestCase{
+		LogicalBackend: b,
+		Steps: []logicaltest.TestStep{
+			configCaStep(testCAPublicKey, testCAPrivateKey),
+
+			createRoleStep("testing", map[string]interface{}{
+				"key_type":                "ca",
+				"allow_user_certificates": true,
+				"sunset":                  time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+			}),
+			signStep(true, false),
+
+			createRoleStep("testing", map[string]interface{}{
+				"key_type":                "ca",
+				"allow_user_certificates": true,
+				"sunset":                  time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+				"sunset_warning_period":   "1h",
+			}),
+			signStep(false, false),
+
+			createRoleStep("testing", map[string]interface{}{
+				"key_type":                "ca",
+				"allow_user_certificates": true,
+				"sunset":                  time.Now().Add(-time.Minute).Format(time.RFC3339),
+			}),
+			signStep(false, true),
+		},
+	}
+
+	logicaltest.Test(t, testCase)
+}
+
 func configCaStep(caPublicKey, caPrivateKey string) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.UpdateOperation,
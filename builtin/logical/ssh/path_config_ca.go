@@ -2,6 +2,9 @@ package ssh
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -22,6 +25,10 @@ const (
 	caPublicKeyStoragePathDeprecated  = "public_key"
 	caPrivateKeyStoragePath           = "config/ca_private_key"
 	caPrivateKeyStoragePathDeprecated = "config/ca_bundle"
+
+	sshKeyTypeRSA     = "rsa"
+	sshKeyTypeEC      = "ec"
+	sshKeyTypeEd25519 = "ed25519"
 )
 
 type keyStorageEntry struct {
@@ -45,6 +52,11 @@ func pathConfigCA(b *backend) *framework.Path {
 				Description: `Generate SSH key pair internally rather than use the private_key and public_key fields.`,
 				Default:     true,
 			},
+			"key_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Type of the SSH key pair generated when generate_signing_key is set; either "rsa" or "ed25519".`,
+				Default:     sshKeyTypeRSA,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -191,7 +203,12 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 	}
 
 	if generateSigningKey {
-		publicKey, privateKey, err = generateSSHKeyPair()
+		keyType := data.Get("key_type").(string)
+		if keyType != sshKeyTypeRSA && keyType != sshKeyTypeEd25519 {
+			return logical.ErrorResponse(fmt.Sprintf("unknown key_type %q", keyType)), nil
+		}
+
+		publicKey, privateKey, err = generateSSHKeyPair(keyType, 0)
 		if err != nil {
 			return nil, err
 		}
@@ -265,21 +282,90 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
-func generateSSHKeyPair() (string, string, error) {
-	privateSeed, err := rsa.GenerateKey(rand.Reader, 4096)
-	if err != nil {
-		return "", "", err
-	}
+// generateSSHKeyPair generates a key pair of the given type, returning the
+// public key in the authorized_keys format and the PEM encoded private key. A
+// zero keyBits selects the default size of RSA and EC keys, which is ignored
+// for Ed25519 keys.
+func generateSSHKeyPair(keyType string, keyBits int) (string, string, error) {
+	var privateBlock *pem.Block
+	var public ssh.PublicKey
+	switch keyType {
+	case sshKeyTypeRSA:
+		switch keyBits {
+		case 0:
+			keyBits = 4096
+		case 2048, 3072, 4096:
+		default:
+			return "", "", fmt.Errorf("unsupported RSA key bits %d; must be 2048, 3072 or 4096", keyBits)
+		}
+		privateSeed, err := rsa.GenerateKey(rand.Reader, keyBits)
+		if err != nil {
+			return "", "", err
+		}
 
-	privateBlock := &pem.Block{
-		Type:    "RSA PRIVATE KEY",
-		Headers: nil,
-		Bytes:   x509.MarshalPKCS1PrivateKey(privateSeed),
-	}
+		privateBlock = &pem.Block{
+			Type:    "RSA PRIVATE KEY",
+			Headers: nil,
+			Bytes:   x509.MarshalPKCS1PrivateKey(privateSeed),
+		}
 
-	public, err := ssh.NewPublicKey(&privateSeed.PublicKey)
-	if err != nil {
-		return "", "", err
+		public, err = ssh.NewPublicKey(&privateSeed.PublicKey)
+		if err != nil {
+			return "", "", err
+		}
+	case sshKeyTypeEC:
+		var curve elliptic.Curve
+		switch keyBits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return "", "", fmt.Errorf("unsupported EC key bits %d; must be 256, 384 or 521", keyBits)
+		}
+		privateSeed, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return "", "", err
+		}
+
+		privateBytes, err := x509.MarshalECPrivateKey(privateSeed)
+		if err != nil {
+			return "", "", err
+		}
+		privateBlock = &pem.Block{
+			Type:    "EC PRIVATE KEY",
+			Headers: nil,
+			Bytes:   privateBytes,
+		}
+
+		public, err = ssh.NewPublicKey(&privateSeed.PublicKey)
+		if err != nil {
+			return "", "", err
+		}
+	case sshKeyTypeEd25519:
+		publicSeed, privateSeed, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", err
+		}
+
+		privateBytes, err := x509.MarshalPKCS8PrivateKey(privateSeed)
+		if err != nil {
+			return "", "", err
+		}
+		privateBlock = &pem.Block{
+			Type:    "PRIVATE KEY",
+			Headers: nil,
+			Bytes:   privateBytes,
+		}
+
+		public, err = ssh.NewPublicKey(publicSeed)
+		if err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", fmt.Errorf("unknown key type %q", keyType)
 	}
 
 	return string(ssh.MarshalAuthorizedKey(public)), string(pem.EncodeToMemory(privateBlock)), nil
@@ -2,9 +2,11 @@ package ssh
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestSSH_ConfigCAStorageUpgrade(t *testing.T) {
@@ -168,3 +170,100 @@ func TestSSH_ConfigCAUpdateDelete(t *testing.T) {
 		t.Fatalf("bad: err: %v, resp:%v", err, resp)
 	}
 }
+
+func TestSSH_ConfigCAEd25519SigningAlgorithms(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "config/ca", map[string]interface{}{
+		"key_type": "ed25519",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !strings.HasPrefix(resp.Data["public_key"].(string), ssh.KeyAlgoED25519+" ") {
+		t.Fatalf("expected an Ed25519 public key, got %q", resp.Data["public_key"])
+	}
+
+	resp = request(logical.UpdateOperation, "roles/invalid", map[string]interface{}{
+		"key_type":                   "ca",
+		"allow_user_certificates":    true,
+		"allowed_signing_algorithms": "ssh-dss",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+
+	resp = request(logical.UpdateOperation, "roles/modern", map[string]interface{}{
+		"key_type":                   "ca",
+		"allow_user_certificates":    true,
+		"allowed_users":              "*",
+		"allowed_signing_algorithms": "rsa-sha2-512,ssh-ed25519",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "roles/rsa", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "*",
+		"algorithm_signer":        ssh.SigAlgoRSASHA2512,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	sign := func(role string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		data["public_key"] = testCAPublicKey
+		data["valid_principals"] = "tester"
+		return request(logical.UpdateOperation, "sign/"+role, data)
+	}
+
+	// The first allowed algorithm supported by the CA key is used
+	resp = sign("modern", map[string]interface{}{})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format := parsed.(*ssh.Certificate).Signature.Format; format != ssh.KeyAlgoED25519 {
+		t.Fatalf("expected an %s signature, got %s", ssh.KeyAlgoED25519, format)
+	}
+
+	// Algorithms which are not allowed by the role, or not supported by the
+	// CA key, are rejected
+	resp = sign("modern", map[string]interface{}{"algorithm_signer": ssh.SigAlgoRSA})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+	resp = sign("modern", map[string]interface{}{"algorithm_signer": ssh.SigAlgoRSASHA2512})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+	resp = sign("rsa", map[string]interface{}{})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+}
@@ -0,0 +1,56 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathConfigHostCA(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/host_ca",
+		Fields: map[string]*framework.FieldSchema{
+			"hosts": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Comma separated host name patterns for which the CA is trusted in the returned known_hosts entry.`,
+				Default:     "*",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathConfigHostCARead,
+		},
+
+		HelpSynopsis: `Retrieve the public key of the CA signing host certificates.`,
+		HelpDescription: `This returns the public key of the CA which signs the host certificates of
+this mount, along with a known_hosts entry trusting it for the given hosts, so
+that clients can verify hosts presenting certificates signed by this mount.`,
+	}
+}
+
+func (b *backend) pathConfigHostCARead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read CA public key: {{err}}", err)
+	}
+	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+		return logical.ErrorResponse("keys haven't been configured yet"), nil
+	}
+
+	hosts := strings.Join(strings.Fields(data.Get("hosts").(string)), "")
+	if hosts == "" {
+		return logical.ErrorResponse("hosts cannot be empty"), nil
+	}
+
+	publicKey := strings.TrimSpace(publicKeyEntry.Key)
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key":  publicKey,
+			"known_hosts": fmt.Sprintf("@cert-authority %s %s", hosts, publicKey),
+		},
+	}, nil
+}
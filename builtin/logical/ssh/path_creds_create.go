@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	uuid "github.com/hashicorp/go-uuid"
@@ -62,6 +63,11 @@ func (b *backend) pathCredsCreateWrite(ctx context.Context, req *logical.Request
 		return logical.ErrorResponse(fmt.Sprintf("Role %q not found", roleName)), nil
 	}
 
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	// username is an optional parameter.
 	username := d.Get("username").(string)
 
@@ -164,6 +170,10 @@ func (b *backend) pathCredsCreateWrite(ctx context.Context, req *logical.Request
 		return nil, fmt.Errorf("key type unknown")
 	}
 
+	if sunsetWarning != "" {
+		result.AddWarning(sunsetWarning)
+	}
+
 	return result, nil
 }
 
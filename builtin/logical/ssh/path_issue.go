@@ -0,0 +1,91 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathIssue(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issue/" + framework.GenericNameWithAtRegex("role"),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathIssue,
+		},
+
+		Fields: addSignCommonFields(map[string]*framework.FieldSchema{
+			"role": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The desired role with configuration for this request.`,
+			},
+			"key_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Type of the generated key pair; either "rsa", "ec" or "ed25519".`,
+				Default:     sshKeyTypeRSA,
+			},
+			"key_bits": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `Size of the generated RSA or EC key. Defaults to 4096 for RSA keys,
+which also accept 2048 and 3072, and 256 for EC keys, which also accept 384 and 521.
+Ignored for Ed25519 keys.`,
+			},
+		}),
+
+		HelpSynopsis:    `Request a key pair along with a certificate signed using a certain role with the provided details.`,
+		HelpDescription: `This path generates a key pair and signs its public key according to the policy of the given role, returning the private key along with the certificate.`,
+	}
+}
+
+func (b *backend) pathIssue(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role").(string)
+
+	// Get the role
+	role, err := b.getRole(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
+	}
+	if role.KeyType != KeyTypeCA {
+		return logical.ErrorResponse("role key type must be 'ca'"), nil
+	}
+
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	keyType := data.Get("key_type").(string)
+	switch keyType {
+	case sshKeyTypeRSA, sshKeyTypeEC, sshKeyTypeEd25519:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unknown key_type %q", keyType)), nil
+	}
+
+	publicKey, privateKey, err := generateSSHKeyPair(keyType, data.Get("key_bits").(int))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to generate key pair: %s", err)), nil
+	}
+
+	userPublicKey, err := parsePublicSSHKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.pathSignIssueCertificateHelper(ctx, req, data, role, userPublicKey)
+	if err != nil || resp == nil || resp.IsError() {
+		return resp, err
+	}
+
+	resp.Data["private_key"] = privateKey
+	resp.Data["private_key_type"] = keyType
+	if sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, nil
+}
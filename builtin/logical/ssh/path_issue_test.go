@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_Issue(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Path:      path,
+			Operation: logical.UpdateOperation,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request("config/ca", map[string]interface{}{
+		"public_key":  testCAPublicKey,
+		"private_key": testCAPrivateKey,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	caPublicKey, err := parsePublicSSHKey(testCAPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = request("roles/issuer", map[string]interface{}{
+		"key_type":                 "ca",
+		"allow_user_certificates":  true,
+		"allowed_users":            "*",
+		"algorithm_signer":         ssh.SigAlgoRSASHA2256,
+		"allowed_user_key_lengths": map[string]interface{}{"rsa": 4096, "ecdsa": 384, "ed25519": 0},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	for _, tc := range []struct {
+		keyType string
+		keyBits int
+		algo    string
+	}{
+		{"rsa", 0, ssh.KeyAlgoRSA},
+		{"ec", 384, ssh.KeyAlgoECDSA384},
+		{"ed25519", 0, ssh.KeyAlgoED25519},
+	} {
+		resp = request("issue/issuer", map[string]interface{}{
+			"key_type":         tc.keyType,
+			"key_bits":         tc.keyBits,
+			"valid_principals": "tester",
+		})
+		if resp == nil || resp.IsError() {
+			t.Fatalf("%s: bad: %#v", tc.keyType, resp)
+		}
+		if resp.Data["private_key_type"] != tc.keyType || resp.Data["serial_number"] == "" {
+			t.Fatalf("%s: bad: %#v", tc.keyType, resp.Data)
+		}
+
+		signer, err := ssh.ParsePrivateKey([]byte(resp.Data["private_key"].(string)))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.keyType, err)
+		}
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.keyType, err)
+		}
+		cert := parsed.(*ssh.Certificate)
+		if cert.Key.Type() != tc.algo || !bytes.Equal(cert.Key.Marshal(), signer.PublicKey().Marshal()) {
+			t.Fatalf("%s: the certificate is not for the issued key", tc.keyType)
+		}
+		if !bytes.Equal(cert.SignatureKey.Marshal(), caPublicKey.Marshal()) || cert.Signature.Format != ssh.SigAlgoRSASHA2256 {
+			t.Fatalf("%s: the certificate is not signed by the CA with the role algorithm", tc.keyType)
+		}
+		if len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "tester" {
+			t.Fatalf("%s: bad principals: %v", tc.keyType, cert.ValidPrincipals)
+		}
+	}
+
+	// The generated key must meet the requirements of the role
+	resp = request("issue/issuer", map[string]interface{}{
+		"key_type":         "rsa",
+		"key_bits":         2048,
+		"valid_principals": "tester",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+
+	resp = request("issue/issuer", map[string]interface{}{
+		"key_type": "dsa",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+
+	// Only the standard RSA key sizes are generated
+	for _, keyBits := range []int{1024, 4000, 8192} {
+		resp = request("issue/issuer", map[string]interface{}{
+			"key_type":         "rsa",
+			"key_bits":         keyBits,
+			"valid_principals": "tester",
+		})
+		if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "unsupported RSA key bits") {
+			t.Fatalf("%d: expected an error, got %#v", keyBits, resp)
+		}
+	}
+}
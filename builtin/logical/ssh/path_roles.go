@@ -10,6 +10,8 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/cidrutil"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/helper/sunsetutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"golang.org/x/crypto/ssh"
 )
@@ -54,6 +56,10 @@ type sshRole struct {
 	KeyIDFormat            string            `mapstructure:"key_id_format" json:"key_id_format"`
 	AllowedUserKeyLengths  map[string]int    `mapstructure:"allowed_user_key_lengths" json:"allowed_user_key_lengths"`
 	AlgorithmSigner        string            `mapstructure:"algorithm_signer" json:"algorithm_signer"`
+	AllowedSigningAlgos    []string          `mapstructure:"allowed_signing_algorithms" json:"allowed_signing_algorithms"`
+	GenerateLease          bool              `mapstructure:"generate_lease" json:"generate_lease"`
+
+	sunsetutil.SunsetParams
 }
 
 func pathListRoles(b *backend) *framework.Path {
@@ -70,7 +76,7 @@ func pathListRoles(b *backend) *framework.Path {
 }
 
 func pathRoles(b *backend) *framework.Path {
-	return &framework.Path{
+	ret := &framework.Path{
 		Pattern: "roles/" + framework.GenericNameWithAtRegex("role"),
 		Fields: map[string]*framework.FieldSchema{
 			"role": &framework.FieldSchema{
@@ -336,12 +342,34 @@ func pathRoles(b *backend) *framework.Path {
 				Type: framework.TypeString,
 				Description: `
 				When supplied, this value specifies a signing algorithm for the key. Possible values: 
-				ssh-rsa, rsa-sha2-256, rsa-sha2-512.
+				ssh-rsa, rsa-sha2-256, rsa-sha2-512, ssh-ed25519. The algorithm must be supported by
+				the CA key.
 				`,
 				DisplayAttrs: &framework.DisplayAttributes{
 					Name: "Signing Algorithm",
 				},
 			},
+			"allowed_signing_algorithms": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type] [Optional for CA type]
+				If set, the signing algorithms which may be used for the certificates issued by this role,
+				among ssh-rsa, rsa-sha2-256, rsa-sha2-512 and ssh-ed25519. Sign requests may select one of
+				them. If algorithm_signer is not set, the first of them supported by the CA key is used.
+				`,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Allowed Signing Algorithms",
+				},
+			},
+			"generate_lease": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type] [Optional for CA type]
+				If set, host certificates signed or issued against this role will have Vault leases
+				attached to them. Renewing the lease re-signs the host certificate with a new validity
+				period. Defaults to "false".
+				`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -353,6 +381,9 @@ func pathRoles(b *backend) *framework.Path {
 		HelpSynopsis:    pathRoleHelpSyn,
 		HelpDescription: pathRoleHelpDesc,
 	}
+	sunsetutil.AddSunsetFields(ret.Fields)
+
+	return ret
 }
 
 func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -482,7 +513,7 @@ func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *fr
 		if ok {
 			algorithmSigner = algorithmSignerRaw.(string)
 			switch algorithmSigner {
-			case ssh.SigAlgoRSA, ssh.SigAlgoRSASHA2256, ssh.SigAlgoRSASHA2512:
+			case ssh.SigAlgoRSA, ssh.SigAlgoRSASHA2256, ssh.SigAlgoRSASHA2512, ssh.KeyAlgoED25519:
 			case "":
 				// This case is valid, and the sign operation will use the signer's
 				// default algorithm.
@@ -491,15 +522,31 @@ func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *fr
 			}
 		}
 
+		allowedSigningAlgos := d.Get("allowed_signing_algorithms").([]string)
+		for _, algo := range allowedSigningAlgos {
+			if !strutil.StrListContains(signingAlgorithms, algo) {
+				return logical.ErrorResponse(fmt.Sprintf("unknown signing algorithm %q in allowed_signing_algorithms", algo)), nil
+			}
+		}
+		if algorithmSigner != "" && len(allowedSigningAlgos) > 0 && !strutil.StrListContains(allowedSigningAlgos, algorithmSigner) {
+			return logical.ErrorResponse("algorithm_signer must be one of allowed_signing_algorithms"), nil
+		}
+
 		role, errorResponse := b.createCARole(allowedUsers, d.Get("default_user").(string), algorithmSigner, d)
 		if errorResponse != nil {
 			return errorResponse, nil
 		}
+		role.AllowedSigningAlgos = allowedSigningAlgos
+		role.GenerateLease = d.Get("generate_lease").(bool)
 		roleEntry = *role
 	} else {
 		return logical.ErrorResponse("invalid key type"), nil
 	}
 
+	if err := roleEntry.ParseSunsetFields(req, d); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	entry, err := logical.StorageEntryJSON(fmt.Sprintf("roles/%s", roleName), roleEntry)
 	if err != nil {
 		return nil, err
@@ -601,26 +648,28 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 		}
 
 		result = map[string]interface{}{
-			"allowed_users":            role.AllowedUsers,
-			"allowed_users_template":   role.AllowedUsersTemplate,
-			"allowed_domains":          role.AllowedDomains,
-			"default_user":             role.DefaultUser,
-			"ttl":                      int64(ttl.Seconds()),
-			"max_ttl":                  int64(maxTTL.Seconds()),
-			"allowed_critical_options": role.AllowedCriticalOptions,
-			"allowed_extensions":       role.AllowedExtensions,
-			"allow_user_certificates":  role.AllowUserCertificates,
-			"allow_host_certificates":  role.AllowHostCertificates,
-			"allow_bare_domains":       role.AllowBareDomains,
-			"allow_subdomains":         role.AllowSubdomains,
-			"allow_user_key_ids":       role.AllowUserKeyIDs,
-			"key_id_format":            role.KeyIDFormat,
-			"key_type":                 role.KeyType,
-			"key_bits":                 role.KeyBits,
-			"default_critical_options": role.DefaultCriticalOptions,
-			"default_extensions":       role.DefaultExtensions,
-			"allowed_user_key_lengths": role.AllowedUserKeyLengths,
-			"algorithm_signer":         role.AlgorithmSigner,
+			"allowed_users":              role.AllowedUsers,
+			"allowed_users_template":     role.AllowedUsersTemplate,
+			"allowed_domains":            role.AllowedDomains,
+			"default_user":               role.DefaultUser,
+			"ttl":                        int64(ttl.Seconds()),
+			"max_ttl":                    int64(maxTTL.Seconds()),
+			"allowed_critical_options":   role.AllowedCriticalOptions,
+			"allowed_extensions":         role.AllowedExtensions,
+			"allow_user_certificates":    role.AllowUserCertificates,
+			"allow_host_certificates":    role.AllowHostCertificates,
+			"allow_bare_domains":         role.AllowBareDomains,
+			"allow_subdomains":           role.AllowSubdomains,
+			"allow_user_key_ids":         role.AllowUserKeyIDs,
+			"key_id_format":              role.KeyIDFormat,
+			"key_type":                   role.KeyType,
+			"key_bits":                   role.KeyBits,
+			"default_critical_options":   role.DefaultCriticalOptions,
+			"default_extensions":         role.DefaultExtensions,
+			"allowed_user_key_lengths":   role.AllowedUserKeyLengths,
+			"algorithm_signer":           role.AlgorithmSigner,
+			"allowed_signing_algorithms": role.AllowedSigningAlgos,
+			"generate_lease":             role.GenerateLease,
 		}
 	case KeyTypeDynamic:
 		result = map[string]interface{}{
@@ -643,6 +692,7 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 	default:
 		return nil, fmt.Errorf("invalid key type: %v", role.KeyType)
 	}
+	role.PopulateSunsetData(result)
 
 	return result, nil
 }
@@ -25,16 +25,26 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// signingAlgorithms are the signature algorithms which roles may force or
+// allow for the certificates they issue.
+var signingAlgorithms = []string{
+	ssh.SigAlgoRSA,
+	ssh.SigAlgoRSASHA2256,
+	ssh.SigAlgoRSASHA2512,
+	ssh.KeyAlgoED25519,
+}
+
 type creationBundle struct {
-	KeyID           string
-	ValidPrincipals []string
-	PublicKey       ssh.PublicKey
-	CertificateType uint32
-	TTL             time.Duration
-	Signer          ssh.Signer
-	Role            *sshRole
-	CriticalOptions map[string]string
-	Extensions      map[string]string
+	KeyID            string
+	ValidPrincipals  []string
+	PublicKey        ssh.PublicKey
+	CertificateType  uint32
+	TTL              time.Duration
+	Signer           ssh.Signer
+	SigningAlgorithm string
+	Role             *sshRole
+	CriticalOptions  map[string]string
+	Extensions       map[string]string
 }
 
 func pathSign(b *backend) *framework.Path {
@@ -45,51 +55,62 @@ func pathSign(b *backend) *framework.Path {
 			logical.UpdateOperation: b.pathSign,
 		},
 
-		Fields: map[string]*framework.FieldSchema{
+		Fields: addSignCommonFields(map[string]*framework.FieldSchema{
 			"role": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: `The desired role with configuration for this request.`,
 			},
-			"ttl": &framework.FieldSchema{
-				Type: framework.TypeDurationSecond,
-				Description: `The requested Time To Live for the SSH certificate;
-sets the expiration date. If not specified
-the role default, backend default, or system
-default TTL is used, in that order. Cannot
-be later than the role max TTL.`,
-			},
 			"public_key": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: `SSH public key that should be signed.`,
 			},
-			"valid_principals": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: `Valid principals, either usernames or hostnames, that the certificate should be signed for.`,
-			},
-			"cert_type": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: `Type of certificate to be created; either "user" or "host".`,
-				Default:     "user",
-			},
-			"key_id": &framework.FieldSchema{
-				Type:        framework.TypeString,
-				Description: `Key id that the created certificate should have. If not specified, the display name of the token will be used.`,
-			},
-			"critical_options": &framework.FieldSchema{
-				Type:        framework.TypeMap,
-				Description: `Critical options that the certificate should be signed for.`,
-			},
-			"extensions": &framework.FieldSchema{
-				Type:        framework.TypeMap,
-				Description: `Extensions that the certificate should be signed for.`,
-			},
-		},
+		}),
 
 		HelpSynopsis:    `Request signing an SSH key using a certain role with the provided details.`,
 		HelpDescription: `This path allows SSH keys to be signed according to the policy of the given role.`,
 	}
 }
 
+// addSignCommonFields adds the fields common to signing a public key and
+// issuing a certificate along with a generated key pair
+func addSignCommonFields(fields map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+	fields["ttl"] = &framework.FieldSchema{
+		Type: framework.TypeDurationSecond,
+		Description: `The requested Time To Live for the SSH certificate;
+sets the expiration date. If not specified
+the role default, backend default, or system
+default TTL is used, in that order. Cannot
+be later than the role max TTL.`,
+	}
+	fields["valid_principals"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Valid principals, either usernames or hostnames, that the certificate should be signed for.`,
+	}
+	fields["cert_type"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Type of certificate to be created; either "user" or "host".`,
+		Default:     "user",
+	}
+	fields["key_id"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Key id that the created certificate should have. If not specified, the display name of the token will be used.`,
+	}
+	fields["critical_options"] = &framework.FieldSchema{
+		Type:        framework.TypeMap,
+		Description: `Critical options that the certificate should be signed for.`,
+	}
+	fields["extensions"] = &framework.FieldSchema{
+		Type:        framework.TypeMap,
+		Description: `Extensions that the certificate should be signed for.`,
+	}
+	fields["algorithm_signer"] = &framework.FieldSchema{
+		Type:        framework.TypeString,
+		Description: `Signing algorithm of the certificate, among the allowed_signing_algorithms of the role.`,
+	}
+
+	return fields
+}
+
 func (b *backend) pathSign(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	roleName := data.Get("role").(string)
 
@@ -102,7 +123,16 @@ func (b *backend) pathSign(ctx context.Context, req *logical.Request, data *fram
 		return logical.ErrorResponse(fmt.Sprintf("Unknown role: %s", roleName)), nil
 	}
 
-	return b.pathSignCertificate(ctx, req, data, role)
+	sunsetWarning, err := role.CheckSunset(roleName, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	resp, err := b.pathSignCertificate(ctx, req, data, role)
+	if resp != nil && sunsetWarning != "" {
+		resp.AddWarning(sunsetWarning)
+	}
+	return resp, err
 }
 
 func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request, data *framework.FieldData, role *sshRole) (*logical.Response, error) {
@@ -116,7 +146,13 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(fmt.Sprintf("failed to parse public_key as SSH key: %s", err)), nil
 	}
 
-	err = b.validateSignedKeyRequirements(userPublicKey, role)
+	return b.pathSignIssueCertificateHelper(ctx, req, data, role, userPublicKey)
+}
+
+// pathSignIssueCertificateHelper signs the public key according to the role
+// and the request, returning the certificate and its serial number
+func (b *backend) pathSignIssueCertificateHelper(ctx context.Context, req *logical.Request, data *framework.FieldData, role *sshRole, userPublicKey ssh.PublicKey) (*logical.Response, error) {
+	err := b.validateSignedKeyRequirements(userPublicKey, role)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("public_key failed to meet the key requirements: %s", err)), nil
 	}
@@ -161,29 +197,27 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	privateKeyEntry, err := caKey(ctx, req.Storage, caPrivateKey)
+	signer, err := caSigner(ctx, req.Storage)
 	if err != nil {
-		return nil, errwrap.Wrapf("failed to read CA private key: {{err}}", err)
-	}
-	if privateKeyEntry == nil || privateKeyEntry.Key == "" {
-		return nil, fmt.Errorf("failed to read CA private key")
+		return nil, err
 	}
 
-	signer, err := ssh.ParsePrivateKey([]byte(privateKeyEntry.Key))
+	signingAlgorithm, err := b.calculateSigningAlgorithm(data, role, signer.PublicKey())
 	if err != nil {
-		return nil, errwrap.Wrapf("failed to parse stored CA private key: {{err}}", err)
+		return logical.ErrorResponse(err.Error()), nil
 	}
 
 	cBundle := creationBundle{
-		KeyID:           keyID,
-		PublicKey:       userPublicKey,
-		Signer:          signer,
-		ValidPrincipals: parsedPrincipals,
-		TTL:             ttl,
-		CertificateType: certificateType,
-		Role:            role,
-		CriticalOptions: criticalOptions,
-		Extensions:      extensions,
+		KeyID:            keyID,
+		PublicKey:        userPublicKey,
+		Signer:           signer,
+		SigningAlgorithm: signingAlgorithm,
+		ValidPrincipals:  parsedPrincipals,
+		TTL:              ttl,
+		CertificateType:  certificateType,
+		Role:             role,
+		CriticalOptions:  criticalOptions,
+		Extensions:       extensions,
 	}
 
 	certificate, err := cBundle.sign()
@@ -203,9 +237,42 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		},
 	}
 
+	// Host certificates of roles generating leases are re-signed on renewal
+	// of the lease, using the parameters of the request
+	if certificateType == ssh.HostCert && role.GenerateLease {
+		response = b.Secret(SecretHostCertificateType).Response(response.Data, map[string]interface{}{
+			"role":             data.Get("role").(string),
+			"public_key":       string(ssh.MarshalAuthorizedKey(userPublicKey)),
+			"key_id":           keyID,
+			"valid_principals": data.Get("valid_principals").(string),
+			"critical_options": data.Get("critical_options").(map[string]interface{}),
+			"extensions":       data.Get("extensions").(map[string]interface{}),
+			"algorithm_signer": data.Get("algorithm_signer").(string),
+		})
+		response.Data["ca_public_key"] = string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+		response.Secret.TTL = ttl
+	}
+
 	return response, nil
 }
 
+// caSigner returns the signer of the CA key pair configured for the mount
+func caSigner(ctx context.Context, s logical.Storage) (ssh.Signer, error) {
+	privateKeyEntry, err := caKey(ctx, s, caPrivateKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read CA private key: {{err}}", err)
+	}
+	if privateKeyEntry == nil || privateKeyEntry.Key == "" {
+		return nil, fmt.Errorf("failed to read CA private key")
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(privateKeyEntry.Key))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse stored CA private key: {{err}}", err)
+	}
+	return signer, nil
+}
+
 func (b *backend) calculateValidPrincipals(data *framework.FieldData, req *logical.Request, role *sshRole, defaultPrincipal, principalsAllowedByRole string, validatePrincipal func([]string, string) bool) ([]string, error) {
 	validPrincipals := ""
 	validPrincipalsRaw, ok := data.GetOk("valid_principals")
@@ -303,6 +370,45 @@ func (b *backend) calculateCertificateType(data *framework.FieldData, role *sshR
 	return certificateType, nil
 }
 
+// calculateSigningAlgorithm returns the signature algorithm of the certificate,
+// which is the requested one, the one forced by the role, or else the default
+// algorithm of the CA key if the role allows it. An empty algorithm is left to
+// the signer, for roles which predate signing algorithm selection.
+func (b *backend) calculateSigningAlgorithm(data *framework.FieldData, role *sshRole, caPublicKey ssh.PublicKey) (string, error) {
+	// An RSA key can sign with any of the RSA algorithms; other keys can only
+	// sign with the algorithm named after their type
+	supported := []string{caPublicKey.Type()}
+	if caPublicKey.Type() == ssh.KeyAlgoRSA {
+		supported = []string{ssh.SigAlgoRSA, ssh.SigAlgoRSASHA2256, ssh.SigAlgoRSASHA2512}
+	}
+
+	algorithm := role.AlgorithmSigner
+	if requested := data.Get("algorithm_signer").(string); requested != "" {
+		if !strutil.StrListContains(role.AllowedSigningAlgos, requested) {
+			return "", fmt.Errorf("algorithm_signer %q is not allowed by role", requested)
+		}
+		algorithm = requested
+	}
+
+	if algorithm == "" {
+		if len(role.AllowedSigningAlgos) == 0 {
+			return "", nil
+		}
+		for _, allowed := range role.AllowedSigningAlgos {
+			if strutil.StrListContains(supported, allowed) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("none of the signing algorithms allowed by role are supported by the %s CA key", caPublicKey.Type())
+	}
+
+	if !strutil.StrListContains(supported, algorithm) {
+		return "", fmt.Errorf("signing algorithm %q is not supported by the %s CA key", algorithm, caPublicKey.Type())
+	}
+
+	return algorithm, nil
+}
+
 func (b *backend) calculateKeyID(data *framework.FieldData, req *logical.Request, role *sshRole, pubKey ssh.PublicKey) (string, error) {
 	reqID := data.Get("key_id").(string)
 
@@ -529,8 +635,7 @@ func (b *creationBundle) sign() (retCert *ssh.Certificate, retErr error) {
 	// Drop trailing signature length.
 	certificateBytes := out[:len(out)-4]
 
-	algo := b.Role.AlgorithmSigner
-	sig, err := sshAlgorithmSigner.SignWithAlgorithm(rand.Reader, certificateBytes, algo)
+	sig, err := sshAlgorithmSigner.SignWithAlgorithm(rand.Reader, certificateBytes, b.SigningAlgorithm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate signed SSH key: sign error")
 	}
@@ -0,0 +1,163 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+	"golang.org/x/crypto/ssh"
+)
+
+const SecretHostCertificateType = "secret_host_certificate_type"
+
+func secretHostCertificate(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretHostCertificateType,
+		Fields: map[string]*framework.FieldSchema{
+			"signed_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Host certificate",
+			},
+			"serial_number": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Serial number of the host certificate",
+			},
+			"ca_public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Public key of the CA which signed the host certificate",
+			},
+		},
+
+		Renew:  b.secretHostCertificateRenew,
+		Revoke: b.secretHostCertificateRevoke,
+	}
+}
+
+// secretHostCertificateRenew signs the host public key again with the
+// parameters of the original request, validated against the current role, and
+// returns the new certificate.
+func (b *backend) secretHostCertificateRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	type sec struct {
+		Role            string                 `mapstructure:"role"`
+		PublicKey       string                 `mapstructure:"public_key"`
+		KeyID           string                 `mapstructure:"key_id"`
+		ValidPrincipals string                 `mapstructure:"valid_principals"`
+		CriticalOptions map[string]interface{} `mapstructure:"critical_options"`
+		Extensions      map[string]interface{} `mapstructure:"extensions"`
+		AlgorithmSigner string                 `mapstructure:"algorithm_signer"`
+	}
+
+	intSec := &sec{}
+	err := mapstructure.Decode(req.Secret.InternalData, intSec)
+	if err != nil {
+		return nil, errwrap.Wrapf("secret internal data could not be decoded: {{err}}", err)
+	}
+
+	role, err := b.getRole(ctx, req.Storage, intSec.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q no longer exists", intSec.Role)), nil
+	}
+	if role.KeyType != KeyTypeCA || !role.AllowHostCertificates || !role.GenerateLease {
+		return logical.ErrorResponse(fmt.Sprintf("role %q no longer allows renewing host certificates", intSec.Role)), nil
+	}
+	if _, err := role.CheckSunset(intSec.Role, time.Now()); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	publicKey, err := parsePublicSSHKey(intSec.PublicKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse stored host public key: {{err}}", err)
+	}
+	if err := b.validateSignedKeyRequirements(publicKey, role); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("public_key failed to meet the key requirements: %s", err)), nil
+	}
+
+	// Run the request parameters through the same checks as the sign request
+	raw := map[string]interface{}{
+		"valid_principals": intSec.ValidPrincipals,
+		"critical_options": intSec.CriticalOptions,
+		"extensions":       intSec.Extensions,
+		"algorithm_signer": intSec.AlgorithmSigner,
+	}
+	if req.Secret.Increment > 0 {
+		raw["ttl"] = int(req.Secret.Increment.Seconds())
+	}
+	data := &framework.FieldData{
+		Raw:    raw,
+		Schema: addSignCommonFields(map[string]*framework.FieldSchema{}),
+	}
+
+	validPrincipals, err := b.calculateValidPrincipals(data, req, role, "", role.AllowedDomains, validateValidPrincipalForHosts(role))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	ttl, err := b.calculateTTL(data, role)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	criticalOptions, err := b.calculateCriticalOptions(data, role)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	extensions, err := b.calculateExtensions(data, role)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	signer, err := caSigner(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	signingAlgorithm, err := b.calculateSigningAlgorithm(data, role, signer.PublicKey())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	cBundle := creationBundle{
+		KeyID:            intSec.KeyID,
+		PublicKey:        publicKey,
+		Signer:           signer,
+		SigningAlgorithm: signingAlgorithm,
+		ValidPrincipals:  validPrincipals,
+		TTL:              ttl,
+		CertificateType:  ssh.HostCert,
+		Role:             role,
+		CriticalOptions:  criticalOptions,
+		Extensions:       extensions,
+	}
+
+	certificate, err := cBundle.sign()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Secret: req.Secret,
+		Data: map[string]interface{}{
+			"serial_number": strconv.FormatUint(certificate.Serial, 16),
+			"signed_key":    string(ssh.MarshalAuthorizedKey(certificate)),
+			"ca_public_key": string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+		},
+	}
+	resp.Secret.TTL = ttl
+
+	return resp, nil
+}
+
+// secretHostCertificateRevoke has nothing to do as SSH certificates cannot be
+// revoked by the CA; the certificate expires along with the lease.
+func (b *backend) secretHostCertificateRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, nil
+}
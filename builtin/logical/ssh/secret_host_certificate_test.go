@@ -0,0 +1,165 @@
+package ssh
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSH_HostCertificateLease(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	request := func(req *logical.Request) *logical.Response {
+		t.Helper()
+		req.Storage = config.StorageView
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/ca",
+		Data: map[string]interface{}{
+			"public_key":  testCAPublicKey,
+			"private_key": testCAPrivateKey,
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	caPublicKey, err := parsePublicSSHKey(testCAPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = request(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/host_ca",
+		Data: map[string]interface{}{
+			"hosts": "*.example.com",
+		},
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data["known_hosts"] != "@cert-authority *.example.com "+strings.TrimSpace(testCAPublicKey) {
+		t.Fatalf("bad known_hosts entry: %q", resp.Data["known_hosts"])
+	}
+
+	resp = request(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/host",
+		Data: map[string]interface{}{
+			"key_type":                "ca",
+			"allow_host_certificates": true,
+			"allowed_domains":         "example.com",
+			"allow_subdomains":        true,
+			"ttl":                     "1h",
+			"generate_lease":          true,
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	parseCertificate := func(resp *logical.Response) *ssh.Certificate {
+		t.Helper()
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert := parsed.(*ssh.Certificate)
+		if cert.CertType != ssh.HostCert || len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "web.example.com" {
+			t.Fatalf("bad certificate: %#v", cert)
+		}
+		if resp.Data["ca_public_key"] != string(ssh.MarshalAuthorizedKey(caPublicKey)) {
+			t.Fatalf("bad CA public key: %q", resp.Data["ca_public_key"])
+		}
+		return cert
+	}
+
+	resp = request(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/host",
+		Data: map[string]interface{}{
+			"public_key":       publicKey2,
+			"cert_type":        "host",
+			"valid_principals": "web.example.com",
+		},
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Secret == nil || resp.Secret.TTL != time.Hour {
+		t.Fatalf("expected a lease of an hour, got %#v", resp.Secret)
+	}
+	cert := parseCertificate(resp)
+
+	secret := resp.Secret
+	secret.Increment = 2 * time.Hour
+	resp = request(&logical.Request{
+		Operation: logical.RenewOperation,
+		Secret:    secret,
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Secret.TTL != 2*time.Hour {
+		t.Fatalf("expected a lease of two hours, got %s", resp.Secret.TTL)
+	}
+	renewed := parseCertificate(resp)
+	if renewed.Serial == cert.Serial || renewed.ValidBefore <= cert.ValidBefore {
+		t.Fatal("expected a new certificate with a later expiration")
+	}
+	if string(renewed.Key.Marshal()) != string(cert.Key.Marshal()) {
+		t.Fatal("expected the renewed certificate to be for the same host key")
+	}
+
+	// User certificates never have leases
+	resp = request(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/host",
+		Data: map[string]interface{}{
+			"key_type":                "ca",
+			"allow_user_certificates": true,
+			"allowed_users":           "*",
+			"generate_lease":          true,
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/host",
+		Data: map[string]interface{}{
+			"public_key":       publicKey2,
+			"valid_principals": "tester",
+		},
+	})
+	if resp == nil || resp.IsError() || resp.Secret != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The role no longer allows host certificates
+	resp = request(&logical.Request{
+		Operation: logical.RenewOperation,
+		Secret:    secret,
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+}
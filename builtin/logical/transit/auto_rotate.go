@@ -0,0 +1,82 @@
+package transit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// minAutoRotatePeriod is the shortest period allowed for automatic key
+// rotation, so that key rings don't grow unbounded.
+const minAutoRotatePeriod = time.Hour
+
+// validateAutoRotatePeriod checks that the automatic rotation period is
+// either zero, disabling automatic rotation, or at least the minimum period.
+func validateAutoRotatePeriod(period time.Duration) error {
+	switch {
+	case period < 0:
+		return fmt.Errorf("auto_rotate_period cannot be negative")
+	case period > 0 && period < minAutoRotatePeriod:
+		return fmt.Errorf("auto_rotate_period must be 0 to disable automatic rotation, or at least %s", minAutoRotatePeriod)
+	}
+	return nil
+}
+
+// autoRotateKeys rotates the keys whose automatic rotation period elapsed
+// since their latest version was created. It runs periodically.
+func (b *backend) autoRotateKeys(ctx context.Context, req *logical.Request) error {
+	// Keys bound to an external KMS key are read and written through it
+	storage := &kmsStorage{
+		Storage: req.Storage,
+		b:       b,
+	}
+
+	names, err := storage.List(ctx, "policy/")
+	if err != nil {
+		return err
+	}
+
+	var errs *multierror.Error
+	for _, name := range names {
+		if err := b.autoRotateKey(ctx, storage, name); err != nil {
+			metrics.IncrCounter([]string{"secrets", "transit", "auto_rotate", "failure"}, 1)
+			errs = multierror.Append(errs, fmt.Errorf("failed to rotate key %q: %w", name, err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func (b *backend) autoRotateKey(ctx context.Context, s logical.Storage, name string) error {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: s,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if !p.AutoRotationDue(time.Now()) {
+		return nil
+	}
+
+	if err := p.Rotate(ctx, s, b.GetRandomReader()); err != nil {
+		return err
+	}
+
+	b.Logger().Info("automatically rotated key", "name", name, "version", p.LatestVersion)
+	metrics.IncrCounter([]string{"secrets", "transit", "auto_rotate", "success"}, 1)
+	return nil
+}
@@ -0,0 +1,106 @@
+package transit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_AutoRotate(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+	ctx := context.Background()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	latestVersion := func(name string) int {
+		t.Helper()
+		resp := handle(logical.ReadOperation, "keys/"+name, nil)
+		return resp.Data["latest_version"].(int)
+	}
+
+	// The period must be at least an hour
+	resp, _ := request(logical.UpdateOperation, "keys/short", map[string]interface{}{
+		"auto_rotate_period": "10m",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a period under an hour, got %#v", resp)
+	}
+
+	handle(logical.UpdateOperation, "keys/auto", map[string]interface{}{
+		"auto_rotate_period": "24h",
+	})
+	handle(logical.UpdateOperation, "keys/manual", nil)
+	resp = handle(logical.ReadOperation, "keys/auto", nil)
+	if resp.Data["auto_rotate_period"] != int64(86400) {
+		t.Fatalf("unexpected auto_rotate_period: %v", resp.Data["auto_rotate_period"])
+	}
+
+	// Nothing is due yet
+	if err := b.autoRotateKeys(ctx, &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 1 {
+		t.Fatal("expected the key not to be rotated before its period elapsed")
+	}
+
+	// Age the latest version of both keys past the period
+	for _, name := range []string{"auto", "manual"} {
+		p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: storage,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry := p.Keys["1"]
+		entry.CreationTime = time.Now().Add(-25 * time.Hour)
+		p.Keys["1"] = entry
+		if err := p.Persist(ctx, storage); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := b.autoRotateKeys(ctx, &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 2 {
+		t.Fatal("expected the key to be rotated once its period elapsed")
+	}
+	if latestVersion("manual") != 1 {
+		t.Fatal("expected the key without a period not to be rotated")
+	}
+
+	// The new version restarts the period
+	if err := b.autoRotateKeys(ctx, &logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if latestVersion("auto") != 2 {
+		t.Fatal("expected the key not to be rotated again")
+	}
+
+	// Automatic rotation can be disabled
+	handle(logical.UpdateOperation, "keys/auto/config", map[string]interface{}{
+		"auto_rotate_period": 0,
+	})
+	resp = handle(logical.ReadOperation, "keys/auto", nil)
+	if resp.Data["auto_rotate_period"] != int64(0) {
+		t.Fatalf("unexpected auto_rotate_period: %v", resp.Data["auto_rotate_period"])
+	}
+}
@@ -3,8 +3,10 @@ package transit
 import (
 	"context"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/errwrap"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -23,11 +25,21 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 }
 
 func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error) {
-	var b backend
+	b := backend{
+		kmsWrappers:        make(map[string]wrapping.Wrapper),
+		managedKeyBackends: make(map[string]managedKeyBackend),
+	}
 	b.Backend = &framework.Backend{
 		PathsSpecial: &logical.Paths{
+			Root: []string{
+				"lookup-key/*",
+			},
+
 			SealWrapStorage: []string{
 				"archive/",
+				"import/",
+				"kms/",
+				"managed-keys/",
 				"policy/",
 			},
 		},
@@ -37,27 +49,46 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			// as the handler is greedy
 			b.pathConfig(),
 			b.pathRotate(),
+			b.pathUsagePolicy(),
+			b.pathImport(),
+			b.pathListKeyAliases(),
+			b.pathKeyAliases(),
 			b.pathRewrap(),
 			b.pathKeys(),
 			b.pathListKeys(),
 			b.pathExportKeys(),
+			b.pathBYOKExportKeys(),
+			b.pathWrappingKey(),
+			b.pathLookupKey(),
 			b.pathEncrypt(),
 			b.pathDecrypt(),
 			b.pathDatakey(),
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
+			b.pathCMACVerify(),
+			b.pathCMAC(),
+			b.pathDerive(),
+			b.pathFPEEncrypt(),
+			b.pathFPEDecrypt(),
 			b.pathSign(),
 			b.pathVerify(),
 			b.pathBackup(),
 			b.pathRestore(),
 			b.pathTrim(),
 			b.pathCacheConfig(),
+			b.pathListKMS(),
+			b.pathKMSRewrap(),
+			b.pathKMS(),
+			b.pathListManagedKeys(),
+			b.pathManagedKeys(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:      []*framework.Secret{},
+		Invalidate:   b.invalidate,
+		Clean:        b.cleanup,
+		BackendType:  logical.TypeLogical,
+		PeriodicFunc: b.autoRotateKeys,
 	}
 
 	// determine cacheSize to use. Defaults to 0 which means unlimited
@@ -83,6 +114,37 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 type backend struct {
 	*framework.Backend
 	lm *keysutil.LockManager
+
+	// kmsWrappers caches the wrappers of the external KMS keys protecting
+	// transit keys, by name
+	kmsWrappers map[string]wrapping.Wrapper
+	kmsLock     sync.RWMutex
+
+	// managedKeyBackends caches the backends of the HSMs and cloud KMSs
+	// holding managed keys, by name
+	managedKeyBackends map[string]managedKeyBackend
+	managedKeyLock     sync.RWMutex
+
+	// wrappingKeyLock serializes the generation of the key wrapping the key
+	// material of imported keys
+	wrappingKeyLock sync.Mutex
+}
+
+// HandleRequest handles requests with a storage that protects the keys bound
+// to an external KMS key, so that every path reading or writing keys goes
+// through it.
+func (b *backend) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if req.Storage != nil {
+		storage := req.Storage
+		req.Storage = &kmsStorage{
+			Storage: storage,
+			b:       b,
+		}
+		defer func() {
+			req.Storage = storage
+		}()
+	}
+	return b.Backend.HandleRequest(ctx, req)
 }
 
 func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error) {
@@ -101,7 +163,7 @@ func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error
 	return size, nil
 }
 
-func (b *backend) invalidate(_ context.Context, key string) {
+func (b *backend) invalidate(ctx context.Context, key string) {
 	if b.Logger().IsDebug() {
 		b.Logger().Debug("invalidating key", "key", key)
 	}
@@ -109,5 +171,11 @@ func (b *backend) invalidate(_ context.Context, key string) {
 	case strings.HasPrefix(key, "policy/"):
 		name := strings.TrimPrefix(key, "policy/")
 		b.lm.InvalidatePolicy(name)
+	case strings.HasPrefix(key, kmsConfigPrefix):
+		name := strings.TrimPrefix(key, kmsConfigPrefix)
+		b.resetKMSWrapper(ctx, name)
+	case strings.HasPrefix(key, managedKeyConfigPrefix):
+		name := strings.TrimPrefix(key, managedKeyConfigPrefix)
+		b.resetManagedKeyBackend(name)
 	}
 }
@@ -0,0 +1,115 @@
+package transit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// kwpAIV is the alternative initial value of the AES key wrap with padding
+// algorithm (RFC 5649), followed by the length of the wrapped key.
+var kwpAIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+// wrapKeyWithPadding wraps the key with the AES key encryption key using the
+// AES key wrap with padding algorithm (RFC 5649).
+func wrapKeyWithPadding(kek, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("no key to wrap")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	padded := make([]byte, 8+(len(key)+7)/8*8)
+	copy(padded, kwpAIV)
+	binary.BigEndian.PutUint32(padded[4:8], uint32(len(key)))
+	copy(padded[8:], key)
+
+	// A single block is encrypted directly
+	if len(padded) == 16 {
+		block.Encrypt(padded, padded)
+		return padded, nil
+	}
+
+	return wrapBlocks(block, padded), nil
+}
+
+// unwrapKeyWithPadding unwraps the key wrapped with the AES key encryption
+// key using the AES key wrap with padding algorithm (RFC 5649).
+func unwrapKeyWithPadding(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 16 || len(wrapped)%8 != 0 {
+		return nil, errors.New("invalid wrapped key length")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	var padded []byte
+	if len(wrapped) == 16 {
+		padded = make([]byte, 16)
+		block.Decrypt(padded, wrapped)
+	} else {
+		padded = unwrapBlocks(block, wrapped)
+	}
+
+	// Check the initial value, the length and the padding of the key
+	keyLen := int64(binary.BigEndian.Uint32(padded[4:8]))
+	if subtle.ConstantTimeCompare(padded[:4], kwpAIV) != 1 ||
+		keyLen <= int64(len(padded)-16) || keyLen > int64(len(padded)-8) {
+		return nil, errors.New("key unwrapping failed")
+	}
+	for _, b := range padded[8+keyLen:] {
+		if b != 0 {
+			return nil, errors.New("key unwrapping failed")
+		}
+	}
+
+	return padded[8 : 8+keyLen], nil
+}
+
+// wrapBlocks implements the wrapping process of RFC 3394 on the data, whose
+// first block is the initial value, in place.
+func wrapBlocks(block cipher.Block, data []byte) []byte {
+	n := len(data)/8 - 1
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], data[:8])
+			copy(buf[8:], data[i*8:(i+1)*8])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(data[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(data[i*8:(i+1)*8], buf[8:])
+		}
+	}
+	return data
+}
+
+// unwrapBlocks implements the unwrapping process of RFC 3394, returning the
+// initial value followed by the unwrapped data.
+func unwrapBlocks(block cipher.Block, wrapped []byte) []byte {
+	data := make([]byte, len(wrapped))
+	copy(data, wrapped)
+
+	n := len(data)/8 - 1
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(data[:8])^t)
+			copy(buf[8:], data[i*8:(i+1)*8])
+			block.Decrypt(buf, buf)
+
+			copy(data[:8], buf[:8])
+			copy(data[i*8:(i+1)*8], buf[8:])
+		}
+	}
+	return data
+}
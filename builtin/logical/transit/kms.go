@@ -0,0 +1,290 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// kmsConfigPrefix is where the configuration of external KMS keys is
+	// stored
+	kmsConfigPrefix = "kms/"
+
+	// kmsBindingPrefix is where the name of the external KMS key protecting
+	// a transit key is stored
+	kmsBindingPrefix = "kms-binding/"
+)
+
+// kmsEnvelopePrefix starts every storage entry encrypted with an external KMS
+// key. It cannot start a policy or an archive, which are JSON objects whose
+// first field is different.
+var kmsEnvelopePrefix = []byte(`{"kms_envelope":`)
+
+// kmsAllowedTypes are the KMS types which can protect keys. The seal types
+// using resources of the host of the server, such as the TPM, are not
+// configurable through the API.
+var kmsAllowedTypes = []string{
+	wrapping.AEAD,
+	wrapping.AliCloudKMS,
+	wrapping.AWSKMS,
+	wrapping.AzureKeyVault,
+	wrapping.GCPCKMS,
+	wrapping.OCIKMS,
+	wrapping.Transit,
+}
+
+// kmsConfig is the configuration of a named external KMS key.
+type kmsConfig struct {
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+}
+
+// kmsBinding records the external KMS key protecting a transit key.
+type kmsBinding struct {
+	KMSName string `json:"kms_name"`
+}
+
+// kmsEnvelope is a storage entry encrypted with an external KMS key.
+type kmsEnvelope struct {
+	KMSName string `json:"kms_envelope"`
+	Blob    []byte `json:"blob"`
+}
+
+// kmsStorage protects the keys bound to an external KMS key: their policy and
+// archive are encrypted with the KMS key before being written to storage,
+// within the barrier, and decrypted when read.
+type kmsStorage struct {
+	logical.Storage
+	b *backend
+}
+
+func (s *kmsStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	entry, err := s.Storage.Get(ctx, key)
+	if err != nil || entry == nil || !bytes.HasPrefix(entry.Value, kmsEnvelopePrefix) {
+		return entry, err
+	}
+
+	var envelope kmsEnvelope
+	if err := json.Unmarshal(entry.Value, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode KMS envelope of %q: %w", key, err)
+	}
+	wrapper, err := s.b.kmsWrapper(ctx, s.Storage, envelope.KMSName)
+	if err != nil {
+		return nil, err
+	}
+	if wrapper == nil {
+		return nil, fmt.Errorf("KMS key %q protecting %q does not exist", envelope.KMSName, key)
+	}
+
+	value, err := openKMSEnvelope(ctx, wrapper, key, &envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.StorageEntry{
+		Key:      entry.Key,
+		Value:    value,
+		SealWrap: entry.SealWrap,
+	}, nil
+}
+
+func (s *kmsStorage) Put(ctx context.Context, entry *logical.StorageEntry) error {
+	keyName := protectedKeyName(entry.Key)
+	if keyName == "" {
+		return s.Storage.Put(ctx, entry)
+	}
+
+	binding, err := s.b.kmsBinding(ctx, s.Storage, keyName)
+	if err != nil {
+		return err
+	}
+	if binding == nil {
+		return s.Storage.Put(ctx, entry)
+	}
+
+	wrapper, err := s.b.kmsWrapper(ctx, s.Storage, binding.KMSName)
+	if err != nil {
+		return err
+	}
+	if wrapper == nil {
+		return fmt.Errorf("KMS key %q protecting %q does not exist", binding.KMSName, keyName)
+	}
+
+	blobInfo, err := wrapper.Encrypt(ctx, entry.Value, []byte(entry.Key))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %q with KMS key %q: %w", entry.Key, binding.KMSName, err)
+	}
+	blob, err := proto.Marshal(blobInfo)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(&kmsEnvelope{
+		KMSName: binding.KMSName,
+		Blob:    blob,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Storage.Put(ctx, &logical.StorageEntry{
+		Key:      entry.Key,
+		Value:    value,
+		SealWrap: entry.SealWrap,
+	})
+}
+
+// openKMSEnvelope decrypts the envelope of the storage entry with the given
+// key using the wrapper of its KMS key.
+func openKMSEnvelope(ctx context.Context, wrapper wrapping.Wrapper, key string, envelope *kmsEnvelope) ([]byte, error) {
+	blobInfo := &wrapping.EncryptedBlobInfo{}
+	if err := proto.Unmarshal(envelope.Blob, blobInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode KMS envelope of %q: %w", key, err)
+	}
+	value, err := wrapper.Decrypt(ctx, blobInfo, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %q with KMS key %q: %w", key, envelope.KMSName, err)
+	}
+	return value, nil
+}
+
+// protectedKeyName returns the name of the transit key whose material is held
+// in the given storage entry, or an empty string if it holds no key material.
+func protectedKeyName(storageKey string) string {
+	for _, prefix := range []string{"policy/", "archive/"} {
+		if strings.HasPrefix(storageKey, prefix) {
+			return strings.TrimPrefix(storageKey, prefix)
+		}
+	}
+	return ""
+}
+
+func (b *backend) kmsBinding(ctx context.Context, s logical.Storage, keyName string) (*kmsBinding, error) {
+	entry, err := s.Get(ctx, kmsBindingPrefix+keyName)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var binding kmsBinding
+	if err := entry.DecodeJSON(&binding); err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+func (b *backend) kmsConfig(ctx context.Context, s logical.Storage, name string) (*kmsConfig, error) {
+	entry, err := s.Get(ctx, kmsConfigPrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config kmsConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// kmsWrapper returns the wrapper of the named external KMS key, or nil if it
+// is not configured. Wrappers are cached until their configuration changes.
+func (b *backend) kmsWrapper(ctx context.Context, s logical.Storage, name string) (wrapping.Wrapper, error) {
+	b.kmsLock.RLock()
+	wrapper, ok := b.kmsWrappers[name]
+	b.kmsLock.RUnlock()
+	if ok {
+		return wrapper, nil
+	}
+
+	b.kmsLock.Lock()
+	defer b.kmsLock.Unlock()
+	if wrapper, ok := b.kmsWrappers[name]; ok {
+		return wrapper, nil
+	}
+
+	config, err := b.kmsConfig(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	wrapper, err = newKMSWrapper(ctx, config, b.Logger())
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure KMS key %q: %w", name, err)
+	}
+	b.kmsWrappers[name] = wrapper
+	return wrapper, nil
+}
+
+func newKMSWrapper(ctx context.Context, config *kmsConfig, logger hclog.Logger) (wrapping.Wrapper, error) {
+	if !strutil.StrListContains(kmsAllowedTypes, config.Type) {
+		return nil, fmt.Errorf("KMS type %q cannot protect keys", config.Type)
+	}
+
+	wrapper, err := configutil.ConfigureWrapper(&configutil.KMS{
+		Type:   config.Type,
+		Config: config.Config,
+	}, nil, nil, logger)
+	if err != nil {
+		return nil, err
+	}
+	if wrapper == nil {
+		return nil, fmt.Errorf("KMS type %q cannot protect keys", config.Type)
+	}
+	if err := wrapper.Init(ctx); err != nil {
+		return nil, err
+	}
+	return wrapper, nil
+}
+
+// resetKMSWrapper discards the cached wrapper of the named KMS key, so that
+// it is configured again on next use.
+func (b *backend) resetKMSWrapper(ctx context.Context, name string) {
+	b.kmsLock.Lock()
+	defer b.kmsLock.Unlock()
+
+	if wrapper, ok := b.kmsWrappers[name]; ok {
+		if err := wrapper.Finalize(ctx); err != nil {
+			b.Logger().Warn("failed to finalize KMS wrapper", "name", name, "error", err)
+		}
+		delete(b.kmsWrappers, name)
+	}
+}
+
+func (b *backend) cleanup(ctx context.Context) {
+	b.kmsLock.Lock()
+	defer b.kmsLock.Unlock()
+
+	for name, wrapper := range b.kmsWrappers {
+		if err := wrapper.Finalize(ctx); err != nil {
+			b.Logger().Warn("failed to finalize KMS wrapper", "name", name, "error", err)
+		}
+	}
+	b.kmsWrappers = make(map[string]wrapping.Wrapper)
+
+	b.managedKeyLock.Lock()
+	defer b.managedKeyLock.Unlock()
+
+	for name, mkb := range b.managedKeyBackends {
+		if err := mkb.Close(); err != nil {
+			b.Logger().Warn("failed to close managed key backend", "name", name, "error", err)
+		}
+	}
+	b.managedKeyBackends = make(map[string]managedKeyBackend)
+}
@@ -0,0 +1,169 @@
+package transit
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// managedKeyConfigPrefix is where the configuration of the HSMs and cloud
+// KMSs holding managed keys is stored
+const managedKeyConfigPrefix = "managed-keys/"
+
+// managedKeyConfig is the configuration of a named HSM or cloud KMS holding
+// the private keys of managed keys.
+type managedKeyConfig struct {
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+}
+
+// managedKeyBackend gives access to the keys of an HSM or a cloud KMS, which
+// perform the private key operations of managed keys.
+type managedKeyBackend interface {
+	// PublicKey returns the public key of the external key with the given ID.
+	PublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error)
+
+	// Signer returns the signer of the external key with the given ID, used
+	// as a transit key of the given type. The signers of keys supporting
+	// decryption also implement crypto.Decrypter.
+	Signer(ctx context.Context, keyID string, keyType keysutil.KeyType) (crypto.Signer, error)
+
+	// Close releases the resources of the backend.
+	Close() error
+}
+
+// managedKeyBackendFactories creates the managed key backends by type.
+var managedKeyBackendFactories = map[string]func(ctx context.Context, config map[string]string, logger hclog.Logger) (managedKeyBackend, error){
+	"awskms":  newAWSKMSManagedKeyBackend,
+	"gcpckms": newGCPCKMSManagedKeyBackend,
+}
+
+func newManagedKeyBackend(ctx context.Context, config *managedKeyConfig, logger hclog.Logger) (managedKeyBackend, error) {
+	factory, ok := managedKeyBackendFactories[config.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported managed key type %q", config.Type)
+	}
+	return factory(ctx, config.Config, logger)
+}
+
+func (b *backend) managedKeyConfig(ctx context.Context, s logical.Storage, name string) (*managedKeyConfig, error) {
+	entry, err := s.Get(ctx, managedKeyConfigPrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config managedKeyConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// managedKeyBackend returns the backend of the named HSM or cloud KMS, or nil
+// if it is not configured. Backends are cached until their configuration
+// changes.
+func (b *backend) managedKeyBackend(ctx context.Context, s logical.Storage, name string) (managedKeyBackend, error) {
+	b.managedKeyLock.RLock()
+	mkb, ok := b.managedKeyBackends[name]
+	b.managedKeyLock.RUnlock()
+	if ok {
+		return mkb, nil
+	}
+
+	b.managedKeyLock.Lock()
+	defer b.managedKeyLock.Unlock()
+	if mkb, ok := b.managedKeyBackends[name]; ok {
+		return mkb, nil
+	}
+
+	config, err := b.managedKeyConfig(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	mkb, err = newManagedKeyBackend(ctx, config, b.Logger())
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure managed key backend %q: %w", name, err)
+	}
+	b.managedKeyBackends[name] = mkb
+	return mkb, nil
+}
+
+// resetManagedKeyBackend closes the cached backend of the named HSM or cloud
+// KMS, so that it is configured again on next use.
+func (b *backend) resetManagedKeyBackend(name string) {
+	b.managedKeyLock.Lock()
+	defer b.managedKeyLock.Unlock()
+
+	if mkb, ok := b.managedKeyBackends[name]; ok {
+		if err := mkb.Close(); err != nil {
+			b.Logger().Warn("failed to close managed key backend", "name", name, "error", err)
+		}
+		delete(b.managedKeyBackends, name)
+	}
+}
+
+// managedKeyProvider returns the provider performing the private key
+// operations of the policy if it is a managed key, and nil otherwise.
+func (b *backend) managedKeyProvider(ctx context.Context, s logical.Storage, p *keysutil.Policy) keysutil.ManagedKeyProvider {
+	if p.ManagedKeyName == "" {
+		return nil
+	}
+
+	return func(keyID string) (crypto.Signer, error) {
+		mkb, err := b.managedKeyBackend(ctx, s, p.ManagedKeyName)
+		if err != nil {
+			return nil, err
+		}
+		if mkb == nil {
+			return nil, fmt.Errorf("managed key backend %q does not exist", p.ManagedKeyName)
+		}
+		return mkb.Signer(ctx, keyID, p.Type)
+	}
+}
+
+// managedKeyPublicKey returns the public key of the external key with the
+// given ID in the named HSM or cloud KMS.
+func (b *backend) managedKeyPublicKey(ctx context.Context, s logical.Storage, name, keyID string) (crypto.PublicKey, error) {
+	mkb, err := b.managedKeyBackend(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+	if mkb == nil {
+		return nil, fmt.Errorf("managed key backend %q is not configured", name)
+	}
+	return mkb.PublicKey(ctx, keyID)
+}
+
+// managedKeyUsers returns the sorted names of the keys whose private keys are
+// held by the named HSM or cloud KMS.
+func (b *backend) managedKeyUsers(ctx context.Context, s logical.Storage, name string) ([]string, error) {
+	keys, err := s.List(ctx, "policy/")
+	if err != nil {
+		return nil, err
+	}
+
+	users := []string{}
+	for _, key := range keys {
+		p, err := keysutil.LoadPolicy(ctx, s, "policy/"+key)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil && p.ManagedKeyName == name {
+			users = append(users, key)
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
@@ -0,0 +1,172 @@
+package transit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/awsutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+)
+
+// awsKMSManagedKeyBackend holds managed keys in AWS KMS. The IDs of the keys
+// are the IDs, ARNs or alias names of asymmetric KMS keys.
+type awsKMSManagedKeyBackend struct {
+	client kmsiface.KMSAPI
+}
+
+func newAWSKMSManagedKeyBackend(ctx context.Context, config map[string]string, logger hclog.Logger) (managedKeyBackend, error) {
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    config["access_key"],
+		SecretKey:    config["secret_key"],
+		SessionToken: config["session_token"],
+		Region:       config["region"],
+		HTTPClient:   cleanhttp.DefaultClient(),
+		Logger:       logger,
+	}
+	if credsConfig.Region == "" {
+		credsConfig.Region = os.Getenv("AWS_REGION")
+		if credsConfig.Region == "" {
+			credsConfig.Region = os.Getenv("AWS_DEFAULT_REGION")
+			if credsConfig.Region == "" {
+				credsConfig.Region = "us-east-1"
+			}
+		}
+	}
+
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		Region:      aws.String(credsConfig.Region),
+		HTTPClient:  cleanhttp.DefaultClient(),
+	}
+	if endpoint := config["endpoint"]; endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsKMSManagedKeyBackend{
+		client: kms.New(sess),
+	}, nil
+}
+
+func (m *awsKMSManagedKeyBackend) PublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	out, err := m.client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{
+		KeyId: aws.String(keyID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParsePKIXPublicKey(out.PublicKey)
+}
+
+func (m *awsKMSManagedKeyBackend) Signer(ctx context.Context, keyID string, keyType keysutil.KeyType) (crypto.Signer, error) {
+	return &awsKMSSigner{
+		ctx:     ctx,
+		backend: m,
+		keyID:   keyID,
+		keyType: keyType,
+	}, nil
+}
+
+func (m *awsKMSManagedKeyBackend) Close() error {
+	return nil
+}
+
+// awsKMSSigner signs and decrypts with an AWS KMS key.
+type awsKMSSigner struct {
+	ctx     context.Context
+	backend *awsKMSManagedKeyBackend
+	keyID   string
+	keyType keysutil.KeyType
+}
+
+// Public returns the public key of the KMS key, or nil if it cannot be
+// fetched.
+func (s *awsKMSSigner) Public() crypto.PublicKey {
+	pub, err := s.backend.PublicKey(s.ctx, s.keyID)
+	if err != nil {
+		return nil
+	}
+	return pub
+}
+
+func (s *awsKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := awsKMSSigningAlgorithm(s.keyType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.backend.client.SignWithContext(s.ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Signature, nil
+}
+
+func (s *awsKMSSigner) Decrypt(_ io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	if oaepOpts, ok := opts.(*rsa.OAEPOptions); !ok || oaepOpts.Hash != crypto.SHA256 || len(oaepOpts.Label) != 0 {
+		return nil, fmt.Errorf("AWS KMS only supports RSA-OAEP decryption with SHA-256 without label")
+	}
+
+	out, err := s.backend.client.DecryptWithContext(s.ctx, &kms.DecryptInput{
+		KeyId:               aws.String(s.keyID),
+		CiphertextBlob:      ciphertext,
+		EncryptionAlgorithm: aws.String(kms.EncryptionAlgorithmSpecRsaesOaepSha256),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+// awsKMSSigningAlgorithm returns the AWS KMS signing algorithm of a transit
+// key type, given the signing options.
+func awsKMSSigningAlgorithm(keyType keysutil.KeyType, opts crypto.SignerOpts) (string, error) {
+	var suffix string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		suffix = "SHA_256"
+	case crypto.SHA384:
+		suffix = "SHA_384"
+	case crypto.SHA512:
+		suffix = "SHA_512"
+	default:
+		return "", fmt.Errorf("AWS KMS does not support signing with hash %v", opts.HashFunc())
+	}
+
+	switch keyType {
+	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521:
+		return "ECDSA_" + suffix, nil
+	case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			return "RSASSA_PSS_" + suffix, nil
+		}
+		return "RSASSA_PKCS1_V1_5_" + suffix, nil
+	default:
+		return "", fmt.Errorf("AWS KMS does not support signing with keys of type %v", keyType)
+	}
+}
@@ -0,0 +1,178 @@
+package transit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	cloudkms "cloud.google.com/go/kms/apiv1"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// gcpCKMSManagedKeyBackend holds managed keys in Google Cloud KMS. The IDs of
+// the keys are the resource names of asymmetric crypto key versions.
+type gcpCKMSManagedKeyBackend struct {
+	client *cloudkms.KeyManagementClient
+
+	// algorithms caches the algorithms of the crypto key versions, which
+	// cannot change, by resource name
+	algorithms     map[string]kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	algorithmsLock sync.RWMutex
+}
+
+func newGCPCKMSManagedKeyBackend(ctx context.Context, config map[string]string, logger hclog.Logger) (managedKeyBackend, error) {
+	var opts []option.ClientOption
+	if credentials := config["credentials"]; credentials != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentials)))
+	}
+
+	client, err := cloudkms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcpCKMSManagedKeyBackend{
+		client:     client,
+		algorithms: make(map[string]kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm),
+	}, nil
+}
+
+func (m *gcpCKMSManagedKeyBackend) PublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	resp, err := m.client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{
+		Name: keyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("public key of %q is not PEM encoded", keyID)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func (m *gcpCKMSManagedKeyBackend) Signer(ctx context.Context, keyID string, keyType keysutil.KeyType) (crypto.Signer, error) {
+	algorithm, err := m.algorithm(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcpCKMSSigner{
+		ctx:       ctx,
+		backend:   m,
+		keyID:     keyID,
+		algorithm: algorithm.String(),
+	}, nil
+}
+
+func (m *gcpCKMSManagedKeyBackend) Close() error {
+	return m.client.Close()
+}
+
+func (m *gcpCKMSManagedKeyBackend) algorithm(ctx context.Context, keyID string) (kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm, error) {
+	m.algorithmsLock.RLock()
+	algorithm, ok := m.algorithms[keyID]
+	m.algorithmsLock.RUnlock()
+	if ok {
+		return algorithm, nil
+	}
+
+	version, err := m.client.GetCryptoKeyVersion(ctx, &kmspb.GetCryptoKeyVersionRequest{
+		Name: keyID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.algorithmsLock.Lock()
+	m.algorithms[keyID] = version.GetAlgorithm()
+	m.algorithmsLock.Unlock()
+
+	return version.GetAlgorithm(), nil
+}
+
+// gcpCKMSSigner signs or decrypts with a Cloud KMS crypto key version,
+// depending on its purpose. As the algorithm of a version is fixed, the
+// requested one must match it.
+type gcpCKMSSigner struct {
+	ctx       context.Context
+	backend   *gcpCKMSManagedKeyBackend
+	keyID     string
+	algorithm string
+}
+
+// Public returns the public key of the crypto key version, or nil if it
+// cannot be fetched.
+func (s *gcpCKMSSigner) Public() crypto.PublicKey {
+	pub, err := s.backend.PublicKey(s.ctx, s.keyID)
+	if err != nil {
+		return nil
+	}
+	return pub
+}
+
+func (s *gcpCKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	pbDigest := &kmspb.Digest{}
+	var suffix string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		pbDigest.Digest = &kmspb.Digest_Sha256{Sha256: digest}
+		suffix = "_SHA256"
+	case crypto.SHA384:
+		pbDigest.Digest = &kmspb.Digest_Sha384{Sha384: digest}
+		suffix = "_SHA384"
+	case crypto.SHA512:
+		pbDigest.Digest = &kmspb.Digest_Sha512{Sha512: digest}
+		suffix = "_SHA512"
+	default:
+		return nil, fmt.Errorf("Cloud KMS does not support signing with hash %v", opts.HashFunc())
+	}
+
+	prefix := "EC_SIGN_"
+	if strings.HasPrefix(s.algorithm, "RSA_") {
+		prefix = "RSA_SIGN_PKCS1_"
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			prefix = "RSA_SIGN_PSS_"
+		}
+	}
+	if !strings.HasPrefix(s.algorithm, prefix) || !strings.HasSuffix(s.algorithm, suffix) {
+		return nil, fmt.Errorf("the algorithm of crypto key version %q is %s, which does not match the requested signature and hash algorithms", s.keyID, s.algorithm)
+	}
+
+	resp, err := s.backend.client.AsymmetricSign(s.ctx, &kmspb.AsymmetricSignRequest{
+		Name:   s.keyID,
+		Digest: pbDigest,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s *gcpCKMSSigner) Decrypt(_ io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	if oaepOpts, ok := opts.(*rsa.OAEPOptions); !ok || oaepOpts.Hash != crypto.SHA256 || len(oaepOpts.Label) != 0 {
+		return nil, fmt.Errorf("Cloud KMS managed keys only support RSA-OAEP decryption with SHA-256 without label")
+	}
+	if !strings.HasPrefix(s.algorithm, "RSA_DECRYPT_OAEP_") || !strings.HasSuffix(s.algorithm, "_SHA256") {
+		return nil, fmt.Errorf("the algorithm of crypto key version %q is %s, which does not support RSA-OAEP decryption with SHA-256", s.keyID, s.algorithm)
+	}
+
+	resp, err := s.backend.client.AsymmetricDecrypt(s.ctx, &kmspb.AsymmetricDecryptRequest{
+		Name:       s.keyID,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,239 @@
+package transit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// keyAliasPrefix is where the key version pinned by each alias of a key is
+// stored, under the name of the key and then the name of the application.
+const keyAliasPrefix = "alias/"
+
+// keyAlias pins the version of a key used by an application to encrypt.
+type keyAlias struct {
+	Version int `json:"version"`
+}
+
+// keyOrAliasNameRegex matches either the name of a key or the name of a key
+// alias, in the form "<app>/<key>", so that each alias has its own ACL path.
+func keyOrAliasNameRegex(name string) string {
+	return fmt.Sprintf(`(?P<%s>\w(([\w-.]+)?\w)?(/\w(([\w-.]+)?\w)?)?)`, name)
+}
+
+func keyAliasPath(keyName, app string) string {
+	return keyAliasPrefix + keyName + "/" + app
+}
+
+func (b *backend) pathListKeyAliases() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/aliases/?$",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKeyAliasList,
+		},
+
+		HelpSynopsis:    pathKeyAliasHelpSyn,
+		HelpDescription: pathKeyAliasHelpDesc,
+	}
+}
+
+func (b *backend) pathKeyAliases() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/aliases/" + framework.GenericNameRegex("app"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"app": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the application using the alias",
+			},
+
+			"version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key used to encrypt through
+the alias. Must be greater than or equal to the
+min_encryption_version configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKeyAliasWrite,
+			logical.ReadOperation:   b.pathKeyAliasRead,
+			logical.DeleteOperation: b.pathKeyAliasDelete,
+		},
+
+		HelpSynopsis:    pathKeyAliasHelpSyn,
+		HelpDescription: pathKeyAliasHelpDesc,
+	}
+}
+
+func (b *backend) pathKeyAliasList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, keyAliasPrefix+d.Get("name").(string)+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKeyAliasWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	app := d.Get("app").(string)
+	version := d.Get("version").(int)
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if !p.Type.EncryptionSupported() {
+		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support encryption", p.Type)), logical.ErrInvalidRequest
+	}
+	switch {
+	case version <= 0:
+		return logical.ErrorResponse("version must be a positive key version"), logical.ErrInvalidRequest
+	case version > p.LatestVersion:
+		return logical.ErrorResponse("version cannot be greater than the latest key version"), logical.ErrInvalidRequest
+	case version < p.MinEncryptionVersion:
+		return logical.ErrorResponse("version cannot be less than the minimum encryption version"), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(keyAliasPath(name, app), &keyAlias{
+		Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, req.Storage.Put(ctx, entry)
+}
+
+func (b *backend) pathKeyAliasRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	app := d.Get("app").(string)
+
+	alias, err := getKeyAlias(ctx, req.Storage, name, app)
+	if err != nil {
+		return nil, err
+	}
+	if alias == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":    name,
+			"app":     app,
+			"alias":   app + "/" + name,
+			"version": alias.Version,
+		},
+	}, nil
+}
+
+func (b *backend) pathKeyAliasDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ctx, keyAliasPath(d.Get("name").(string), d.Get("app").(string)))
+}
+
+func getKeyAlias(ctx context.Context, s logical.Storage, keyName, app string) (*keyAlias, error) {
+	entry, err := s.Get(ctx, keyAliasPath(keyName, app))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var alias keyAlias
+	if err := entry.DecodeJSON(&alias); err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// resolveKeyName returns the name of the key designated by the given name,
+// which is either the name of a key or of a key alias, and the key version
+// pinned by the alias, or 0 for a key.
+func resolveKeyName(ctx context.Context, s logical.Storage, name string) (string, int, error) {
+	app, keyName, ok := splitKeyAliasName(name)
+	if !ok {
+		return name, 0, nil
+	}
+
+	alias, err := getKeyAlias(ctx, s, keyName, app)
+	if err != nil {
+		return "", 0, err
+	}
+	if alias == nil {
+		return "", 0, errutil.UserError{Err: fmt.Sprintf("key alias %q not found", name)}
+	}
+	return keyName, alias.Version, nil
+}
+
+func splitKeyAliasName(name string) (app, keyName string, ok bool) {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "", name, false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// pinKeyVersion returns the key version to encrypt with when the version
+// requested is combined with the one pinned by a key alias.
+func pinKeyVersion(requested, pinned int) (int, error) {
+	switch {
+	case pinned == 0:
+		return requested, nil
+	case requested == 0, requested == pinned:
+		return pinned, nil
+	}
+	return 0, errutil.UserError{Err: fmt.Sprintf("key_version %d does not match the version %d pinned by the key alias", requested, pinned)}
+}
+
+// deleteKeyAliases removes the aliases of the deleted key.
+func deleteKeyAliases(ctx context.Context, s logical.Storage, keyName string) error {
+	apps, err := s.List(ctx, keyAliasPrefix+keyName+"/")
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if err := s.Delete(ctx, keyAliasPath(keyName, app)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const pathKeyAliasHelpSyn = `Pin the key version used by an application`
+
+const pathKeyAliasHelpDesc = `
+This path is used to manage the aliases of a named key, each pinning the
+version of the key used by an application to encrypt. The encrypt, decrypt,
+rewrap and datakey endpoints accept the alias "<app>/<name>" in place of the
+name of the key, so that each application has its own ACL path and can be
+migrated to a new key version independently of the others, rather than by
+changing the min_encryption_version of the key.
+`
@@ -0,0 +1,129 @@
+package transit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_KeyAliases(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectError := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected an error, got %#v", resp)
+		}
+	}
+
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+
+	handle(logical.UpdateOperation, "keys/foo", nil)
+	handle(logical.UpdateOperation, "keys/foo/rotate", nil)
+	handle(logical.UpdateOperation, "keys/foo/rotate", nil)
+
+	handle(logical.UpdateOperation, "keys/foo/aliases/app1", map[string]interface{}{
+		"version": 1,
+	})
+	handle(logical.UpdateOperation, "keys/foo/aliases/app2", map[string]interface{}{
+		"version": 2,
+	})
+	expectError(logical.UpdateOperation, "keys/foo/aliases/app3", map[string]interface{}{
+		"version": 4,
+	})
+	expectError(logical.UpdateOperation, "keys/bar/aliases/app1", map[string]interface{}{
+		"version": 1,
+	})
+
+	resp := handle(logical.ReadOperation, "keys/foo/aliases/app2", nil)
+	if resp.Data["version"] != 2 || resp.Data["alias"] != "app2/foo" {
+		t.Fatalf("unexpected alias: %#v", resp.Data)
+	}
+	resp = handle(logical.ListOperation, "keys/foo/aliases/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"app1", "app2"}) {
+		t.Fatalf("unexpected aliases: %#v", resp.Data["keys"])
+	}
+
+	// Each alias encrypts with its pinned version, while the key itself uses
+	// the latest one
+	encrypt := func(name string, version int) string {
+		t.Helper()
+		resp := handle(logical.UpdateOperation, "encrypt/"+name, map[string]interface{}{
+			"plaintext": plaintext,
+		})
+		if resp.Data["key_version"] != version {
+			t.Fatalf("%s: expected key version %d, got %v", name, version, resp.Data["key_version"])
+		}
+		return resp.Data["ciphertext"].(string)
+	}
+	encrypt("foo", 3)
+	ciphertext := encrypt("app1/foo", 1)
+	encrypt("app2/foo", 2)
+
+	expectError(logical.UpdateOperation, "encrypt/app1/foo", map[string]interface{}{
+		"plaintext":   plaintext,
+		"key_version": 2,
+	})
+	expectError(logical.UpdateOperation, "encrypt/unknown/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+
+	resp = handle(logical.UpdateOperation, "decrypt/app2/foo", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("unexpected plaintext: %v", resp.Data["plaintext"])
+	}
+
+	resp = handle(logical.UpdateOperation, "rewrap/app2/foo", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if resp.Data["key_version"] != 2 {
+		t.Fatalf("expected rewrapping with key version 2, got %v", resp.Data["key_version"])
+	}
+
+	resp = handle(logical.UpdateOperation, "datakey/wrapped/app1/foo", nil)
+	if resp.Data["key_version"] != 1 {
+		t.Fatalf("expected a data key encrypted with key version 1, got %v", resp.Data["key_version"])
+	}
+
+	// Migrating an application to a new version
+	handle(logical.UpdateOperation, "keys/foo/aliases/app1", map[string]interface{}{
+		"version": 3,
+	})
+	encrypt("app1/foo", 3)
+
+	handle(logical.DeleteOperation, "keys/foo/aliases/app1", nil)
+	expectError(logical.UpdateOperation, "encrypt/app1/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+
+	// Deleting the key deletes its aliases
+	handle(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	handle(logical.DeleteOperation, "keys/foo", nil)
+	resp = handle(logical.ListOperation, "keys/foo/aliases/", nil)
+	if len(resp.Data) != 0 {
+		t.Fatalf("expected no aliases, got %#v", resp.Data)
+	}
+}
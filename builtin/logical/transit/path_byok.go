@@ -0,0 +1,219 @@
+package transit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *backend) pathBYOKExportKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "byok-export/" + framework.GenericNameRegex("name") + framework.OptionalParamRegex("version"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+			"version": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Version of the key",
+			},
+			"wrapping_key": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-encoded RSA public key of the destination,
+such as the one returned by the "wrapping_key"
+endpoint of another transit backend, used to
+encrypt the ephemeral key wrapping the key
+material.`,
+			},
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function used with RSA-OAEP to
+encrypt the ephemeral key. Can be "SHA1", "SHA256",
+"SHA384" or "SHA512". Defaults to "SHA256".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathPolicyBYOKExportWrite,
+		},
+
+		HelpSynopsis:    pathBYOKExportHelpSyn,
+		HelpDescription: pathBYOKExportHelpDesc,
+	}
+}
+
+func (b *backend) pathPolicyBYOKExportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	version := d.Get("version").(string)
+
+	hashFn, err := parseHashFunction(d.Get("hash_function").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	wrappingKey, err := parseRSAPublicKey(d.Get("wrapping_key").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationExport); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if !p.Exportable {
+		return logical.ErrorResponse("key is not exportable"), nil
+	}
+
+	versions := map[string]keysutil.KeyEntry{}
+	switch version {
+	case "":
+		for k, v := range p.Keys {
+			versions[k] = v
+		}
+
+	default:
+		var versionValue int
+		if version == "latest" {
+			versionValue = p.LatestVersion
+		} else {
+			version = strings.TrimPrefix(version, "v")
+			versionValue, err = strconv.Atoi(version)
+			if err != nil {
+				return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+			}
+		}
+
+		if versionValue < p.MinDecryptionVersion {
+			return logical.ErrorResponse("version for export is below minimum decryption version"), logical.ErrInvalidRequest
+		}
+		key, ok := p.Keys[strconv.Itoa(versionValue)]
+		if !ok {
+			return logical.ErrorResponse("version does not exist or cannot be found"), logical.ErrInvalidRequest
+		}
+		versions[strconv.Itoa(versionValue)] = key
+	}
+
+	retKeys := map[string]string{}
+	for k, v := range versions {
+		keyMaterial, err := getKeyMaterial(p, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		hashFn.Reset()
+		wrapped, err := wrapKeyMaterial(wrappingKey, hashFn, keyMaterial, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		retKeys[k] = base64.StdEncoding.EncodeToString(wrapped)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name": p.Name,
+			"type": p.Type.String(),
+			"keys": retKeys,
+		},
+	}, nil
+}
+
+// getKeyMaterial returns the key material of the key entry in the format
+// accepted by the import endpoint: the raw key for symmetric key types, and a
+// PKCS #8 DER-encoded private key for asymmetric key types.
+func getKeyMaterial(p *keysutil.Policy, key *keysutil.KeyEntry) ([]byte, error) {
+	switch p.Type {
+	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305, keysutil.KeyType_AES128_CMAC, keysutil.KeyType_AES256_CMAC, keysutil.KeyType_AES256_FF3_1:
+		return key.Key, nil
+
+	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521:
+		curve := elliptic.P256()
+		switch p.Type {
+		case keysutil.KeyType_ECDSA_P384:
+			curve = elliptic.P384()
+		case keysutil.KeyType_ECDSA_P521:
+			curve = elliptic.P521()
+		}
+		return x509.MarshalPKCS8PrivateKey(&ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: curve,
+				X:     key.EC_X,
+				Y:     key.EC_Y,
+			},
+			D: key.EC_D,
+		})
+
+	case keysutil.KeyType_ED25519:
+		return x509.MarshalPKCS8PrivateKey(ed25519.PrivateKey(key.Key))
+
+	case keysutil.KeyType_MLDSA44, keysutil.KeyType_MLDSA65, keysutil.KeyType_MLDSA87:
+		signer, err := mldsautil.NewSigner(p.Type.String(), key.Key)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(signer)
+
+	case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		return x509.MarshalPKCS8PrivateKey(key.RSAKey)
+	}
+
+	return nil, fmt.Errorf("unknown key type %v", p.Type)
+}
+
+func parseRSAPublicKey(keyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("wrapping key is not a PEM-encoded public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wrapping key: %v", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("wrapping key must be an RSA public key")
+	}
+	return rsaPub, nil
+}
+
+const pathBYOKExportHelpSyn = `Securely export named keys wrapped for a destination`
+
+const pathBYOKExportHelpDesc = `
+This path is used to export the named keys that are configured as exportable,
+wrapped for the given RSA public key in the format accepted by the
+"keys/<name>/import" endpoint. The key material of each version is wrapped with
+a new ephemeral AES-256 key using the AES key wrap with padding algorithm
+(RFC 5649), preceded by the ephemeral key encrypted with the public key using
+RSA-OAEP. This allows migrating keys to another transit backend, KMS or HSM
+without exposing them in plaintext.
+`
@@ -0,0 +1,347 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// batchResponseCMACItem represents a response item for batch processing
+type batchResponseCMACItem struct {
+	// CMAC for the input present in the corresponding batch request item
+	CMAC string `json:"cmac,omitempty" mapstructure:"cmac"`
+
+	// Valid indicates whether the CMAC matches the CMAC of the input
+	Valid bool `json:"valid,omitempty" mapstructure:"valid"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	err error
+}
+
+func (b *backend) pathCMAC() *framework.Path {
+	return &framework.Path{
+		Pattern: "cmac/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The key to use for the CMAC function",
+			},
+
+			"input": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The base64-encoded input data",
+			},
+
+			"key_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key to use for generating the CMAC.
+Must be 0 (for latest) or a value greater than or equal
+to the min_encryption_version configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCMACWrite,
+		},
+
+		HelpSynopsis:    pathCMACHelpSyn,
+		HelpDescription: pathCMACHelpDesc,
+	}
+}
+
+func (b *backend) pathCMACVerify() *framework.Path {
+	return &framework.Path{
+		Pattern: "cmac/verify/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The key to use for verifying the CMAC",
+			},
+
+			"input": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The base64-encoded input data",
+			},
+
+			"cmac": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The CMAC to verify, as returned by the cmac endpoint",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCMACVerifyWrite,
+		},
+
+		HelpSynopsis:    pathCMACVerifyHelpSyn,
+		HelpDescription: pathCMACVerifyHelpDesc,
+	}
+}
+
+func (b *backend) pathCMACWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationCMAC); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if !p.Type.CMACSupported() {
+		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support CMAC", p.Type)), logical.ErrInvalidRequest
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < 0 || ver > p.LatestVersion:
+		return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		return logical.ErrorResponse("cannot generate CMAC: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestHMACItem
+	if batchInputRaw != nil {
+		if err := mapstructure.Decode(batchInputRaw, &batchInputItems); err != nil {
+			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		valueRaw, ok := d.GetOk("input")
+		if !ok {
+			return logical.ErrorResponse("missing input for CMAC"), logical.ErrInvalidRequest
+		}
+
+		batchInputItems = []batchRequestHMACItem{
+			{"input": valueRaw.(string)},
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		rawInput, ok := item["input"]
+		if !ok {
+			response[i].Error = "missing input for CMAC"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		input, err := base64.StdEncoding.DecodeString(rawInput)
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		mac, err := p.CMAC(ver, input)
+		if err != nil {
+			response[i].err = err
+			continue
+		}
+
+		response[i].CMAC = fmt.Sprintf("vault:v%d:%s", ver, base64.StdEncoding.EncodeToString(mac))
+	}
+
+	if batchInputRaw != nil {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"batch_results": response,
+			},
+		}, nil
+	}
+
+	if response[0].Error != "" {
+		return logical.ErrorResponse(response[0].Error), response[0].err
+	}
+	if response[0].err != nil {
+		return nil, response[0].err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"cmac": response[0].CMAC,
+		},
+	}, nil
+}
+
+func (b *backend) pathCMACVerifyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationVerify); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if !p.Type.CMACSupported() {
+		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support CMAC", p.Type)), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestHMACItem
+	if batchInputRaw != nil {
+		if err := mapstructure.Decode(batchInputRaw, &batchInputItems); err != nil {
+			return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+		}
+
+		if len(batchInputItems) == 0 {
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		batchInputItems = []batchRequestHMACItem{
+			{
+				"input": d.Get("input").(string),
+				"cmac":  d.Get("cmac").(string),
+			},
+		}
+	}
+
+	response := make([]batchResponseCMACItem, len(batchInputItems))
+
+	for i, item := range batchInputItems {
+		input, err := base64.StdEncoding.DecodeString(item["input"])
+		if err != nil {
+			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		ver, mac, err := parseVersionedValue(item["cmac"])
+		if err != nil {
+			response[i].Error = fmt.Sprintf("invalid CMAC: %s", err)
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if ver <= 0 || ver > p.LatestVersion {
+			response[i].Error = "invalid CMAC: version does not exist"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		if p.MinDecryptionVersion > 0 && ver < p.MinDecryptionVersion {
+			response[i].Error = "cannot verify CMAC: version is too old (disallowed by policy)"
+			response[i].err = logical.ErrInvalidRequest
+			continue
+		}
+
+		valid, err := p.VerifyCMAC(ver, input, mac)
+		if err != nil {
+			switch err.(type) {
+			case errutil.UserError:
+				response[i].Error = err.Error()
+				response[i].err = logical.ErrInvalidRequest
+			default:
+				response[i].err = err
+			}
+			continue
+		}
+		response[i].Valid = valid
+	}
+
+	if batchInputRaw != nil {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"batch_results": response,
+			},
+		}, nil
+	}
+
+	if response[0].Error != "" {
+		return logical.ErrorResponse(response[0].Error), response[0].err
+	}
+	if response[0].err != nil {
+		return nil, response[0].err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": response[0].Valid,
+		},
+	}, nil
+}
+
+// parseVersionedValue splits a value of the form vault:v<version>:<base64>
+// into its key version and decoded bytes.
+func parseVersionedValue(value string) (int, []byte, error) {
+	if !strings.HasPrefix(value, "vault:v") {
+		return 0, nil, fmt.Errorf("no prefix")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, "vault:v"), ":", 2)
+	if len(parts) != 2 {
+		return 0, nil, fmt.Errorf("wrong number of fields")
+	}
+
+	ver, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, nil, fmt.Errorf("version number could not be decoded")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to decode value as base64: %s", err)
+	}
+
+	return ver, decoded, nil
+}
+
+const pathCMACHelpSyn = `Generate a CMAC for input data using the named key`
+
+const pathCMACHelpDesc = `
+Generates an AES-CMAC (RFC 4493) of the given input data with a key of type
+"aes128-cmac" or "aes256-cmac".
+`
+
+const pathCMACVerifyHelpSyn = `Verify a CMAC for input data using the named key`
+
+const pathCMACVerifyHelpDesc = `
+Verifies an AES-CMAC generated by the cmac endpoint against the given input
+data.
+`
@@ -0,0 +1,164 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_CMAC(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	// First create a key
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": "aes128-cmac",
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	// Now, change the key value to the key of the RFC 4493 test vectors
+	p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "foo",
+	}, b.GetRandomReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestVersion := strconv.Itoa(p.LatestVersion)
+	keyEntry := p.Keys[latestVersion]
+	keyEntry.Key, _ = hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	p.Keys[latestVersion] = keyEntry
+	if err = p.Persist(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+
+	input, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a")
+	expected, _ := hex.DecodeString("070a16b46b4d4144f79bdd9dd04a287c")
+	expectedCMAC := "vault:v1:" + base64.StdEncoding.EncodeToString(expected)
+
+	req.Path = "cmac/foo"
+	req.Data = map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["cmac"] != expectedCMAC {
+		t.Fatalf("bad CMAC: %#v", resp.Data)
+	}
+
+	req.Path = "cmac/verify/foo"
+	req.Data["cmac"] = expectedCMAC
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["valid"] != true {
+		t.Fatalf("expected CMAC to verify: %#v", resp.Data)
+	}
+
+	req.Data["input"] = base64.StdEncoding.EncodeToString([]byte("tampered"))
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["valid"] != false {
+		t.Fatalf("expected CMAC not to verify: %#v", resp.Data)
+	}
+
+	// Batch generation
+	req.Path = "cmac/foo"
+	req.Data = map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": base64.StdEncoding.EncodeToString(input)},
+			map[string]interface{}{"input": "not base64"},
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	results := resp.Data["batch_results"].([]batchResponseCMACItem)
+	if results[0].CMAC != expectedCMAC || results[1].Error == "" {
+		t.Fatalf("bad batch results: %#v", results)
+	}
+
+	// Keys of other types cannot be used for CMAC
+	req.Path = "keys/bar"
+	req.Data = nil
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req.Path = "cmac/bar"
+	req.Data = map[string]interface{}{
+		"input": base64.StdEncoding.EncodeToString(input),
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+}
+
+func TestTransit_Derive(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+	}
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	derive := func(info string, bytes int) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "derive/foo",
+			Data: map[string]interface{}{
+				"salt":  base64.StdEncoding.EncodeToString([]byte("salt")),
+				"info":  base64.StdEncoding.EncodeToString([]byte(info)),
+				"bytes": bytes,
+			},
+		})
+	}
+
+	resp, err := derive("device-1", 16)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	derived := resp.Data["derived_key"].(string)
+	raw, _ := base64.StdEncoding.DecodeString(derived)
+	if len(raw) != 16 || resp.Data["key_version"] != 1 {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+
+	resp, err = derive("device-1", 16)
+	if err != nil || resp.Data["derived_key"] != derived {
+		t.Fatalf("derivation is not deterministic: %#v", resp.Data)
+	}
+
+	resp, err = derive("device-2", 16)
+	if err != nil || resp.Data["derived_key"] == derived {
+		t.Fatalf("expected different key for different info: %#v", resp.Data)
+	}
+
+	resp, err = derive("device-1", keysutil.MaxHKDFBytes+1)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+}
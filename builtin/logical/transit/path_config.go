@@ -3,6 +3,7 @@ package transit
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
@@ -47,6 +48,19 @@ the latest version of the key is allowed.`,
 				Type:        framework.TypeBool,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
 			},
+
+			"allow_lookup_export": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Enables export of the convergent encryption lookup keys of the named key. Once set, this cannot be disabled.`,
+			},
+
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time the key should live before
+being automatically rotated. A value of 0
+disables automatic rotation for the key.
+Must be at least one hour otherwise.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -84,6 +98,8 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAllowLookupExport := p.AllowLookupExport
+	originalAutoRotatePeriod := p.AutoRotatePeriod
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -92,6 +108,8 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AllowLookupExport = originalAllowLookupExport
+			p.AutoRotatePeriod = originalAutoRotatePeriod
 		}
 	}()
 
@@ -170,6 +188,9 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		exportable := exportableRaw.(bool)
 		// Don't unset the already set value
 		if exportable && !p.Exportable {
+			if p.ManagedKeyName != "" {
+				return logical.ErrorResponse("managed keys cannot be exportable"), nil
+			}
 			p.Exportable = exportable
 			persistNeeded = true
 		}
@@ -185,6 +206,37 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	allowLookupExportRaw, ok := d.GetOk("allow_lookup_export")
+	if ok {
+		allowLookupExport := allowLookupExportRaw.(bool)
+		// Don't unset the already set value
+		if allowLookupExport && !p.AllowLookupExport {
+			if !p.ConvergentEncryption {
+				return logical.ErrorResponse("lookup key export requires convergent encryption"), nil
+			}
+			p.AllowLookupExport = allowLookupExport
+			persistNeeded = true
+		}
+	}
+
+	autoRotatePeriodRaw, ok := d.GetOk("auto_rotate_period")
+	if ok {
+		autoRotatePeriod := time.Second * time.Duration(autoRotatePeriodRaw.(int))
+		if err := validateAutoRotatePeriod(autoRotatePeriod); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if autoRotatePeriod != p.AutoRotatePeriod {
+			if autoRotatePeriod > 0 && p.Imported && !p.AllowImportedKeyRotation {
+				return logical.ErrorResponse("automatic rotation requires rotation to be allowed for this imported key"), nil
+			}
+			if autoRotatePeriod > 0 && p.ManagedKeyName != "" {
+				return logical.ErrorResponse("managed keys cannot be rotated automatically"), nil
+			}
+			p.AutoRotatePeriod = autoRotatePeriod
+			persistNeeded = true
+		}
+	}
+
 	if !persistNeeded {
 		return nil, nil
 	}
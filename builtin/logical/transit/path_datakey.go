@@ -14,11 +14,11 @@ import (
 
 func (b *backend) pathDatakey() *framework.Path {
 	return &framework.Path{
-		Pattern: "datakey/" + framework.GenericNameRegex("plaintext") + "/" + framework.GenericNameRegex("name"),
+		Pattern: "datakey/" + framework.GenericNameRegex("plaintext") + "/" + keyOrAliasNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
 			"name": &framework.FieldSchema{
 				Type:        framework.TypeString,
-				Description: "The backend key used for encrypting the data key",
+				Description: "The backend key, or key alias in the form <app>/<name>, used for encrypting the data key",
 			},
 
 			"plaintext": &framework.FieldSchema{
@@ -63,8 +63,19 @@ min_encryption_version configured on the key.`,
 }
 
 func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	name := d.Get("name").(string)
-	ver := d.Get("key_version").(int)
+	name, pinnedVersion, err := resolveKeyName(ctx, req.Storage, d.Get("name").(string))
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
+	ver, err := pinKeyVersion(d.Get("key_version").(int), pinnedVersion)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
 
 	plaintext := d.Get("plaintext").(string)
 	plaintextAllowed := false
@@ -76,8 +87,6 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 		return logical.ErrorResponse("Invalid path, must be 'plaintext' or 'wrapped'"), logical.ErrInvalidRequest
 	}
 
-	var err error
-
 	// Decode the context if any
 	contextRaw := d.Get("context").(string)
 	var context []byte
@@ -114,6 +123,10 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationDatakey); resp != nil || err != nil {
+		return resp, err
+	}
+
 	newKey := make([]byte, 32)
 	bits := d.Get("bits").(int)
 	switch bits {
@@ -23,11 +23,11 @@ type DecryptBatchResponseItem struct {
 
 func (b *backend) pathDecrypt() *framework.Path {
 	return &framework.Path{
-		Pattern: "decrypt/" + framework.GenericNameRegex("name"),
+		Pattern: "decrypt/" + keyOrAliasNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
 			"name": &framework.FieldSchema{
 				Type:        framework.TypeString,
-				Description: "Name of the policy",
+				Description: "Name of the policy, or of a key alias in the form <app>/<name>",
 			},
 
 			"ciphertext": &framework.FieldSchema{
@@ -120,10 +120,19 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	name, _, err := resolveKeyName(ctx, req.Storage, d.Get("name").(string))
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
 		Storage: req.Storage,
-		Name:    d.Get("name").(string),
+		Name:    name,
 	}, b.GetRandomReader())
 	if err != nil {
 		return nil, err
@@ -135,12 +144,19 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationDecrypt); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
+	managed := b.managedKeyProvider(ctx, req.Storage, p)
+
 	for i, item := range batchInputItems {
 		if batchResponseItems[i].Error != "" {
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptManaged(managed, item.DecodedContext, item.DecodedNonce, item.Ciphertext)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
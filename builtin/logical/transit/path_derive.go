@@ -0,0 +1,121 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *backend) pathDerive() *framework.Path {
+	return &framework.Path{
+		Pattern: "derive/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The key to derive from",
+			},
+
+			"salt": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The base64-encoded HKDF salt. Optional.",
+			},
+
+			"info": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The base64-encoded HKDF info binding the derived
+key to its purpose. Optional.`,
+			},
+
+			"bytes": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     32,
+				Description: "The number of bytes to derive. Defaults to 32.",
+			},
+
+			"key_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key to derive from.
+Must be 0 (for latest) or a value greater than or equal
+to the min_encryption_version configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDeriveWrite,
+		},
+
+		HelpSynopsis:    pathDeriveHelpSyn,
+		HelpDescription: pathDeriveHelpDesc,
+	}
+}
+
+func (b *backend) pathDeriveWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+
+	salt, err := base64.StdEncoding.DecodeString(d.Get("salt").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode salt"), logical.ErrInvalidRequest
+	}
+	info, err := base64.StdEncoding.DecodeString(d.Get("info").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode info"), logical.ErrInvalidRequest
+	}
+
+	// Get the policy
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationDerive); resp != nil || err != nil {
+		return resp, err
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+		return logical.ErrorResponse("cannot derive key: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+	}
+
+	derived, err := p.HKDF(ver, salt, info, d.Get("bytes").(int))
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"derived_key": base64.StdEncoding.EncodeToString(derived),
+			"key_version": ver,
+		},
+	}, nil
+}
+
+const pathDeriveHelpSyn = `Derive key material from the named key with HKDF`
+
+const pathDeriveHelpDesc = `
+Derives key material with HKDF-SHA256 (RFC 5869) from the HMAC key of the
+given version of the named key. The same key version, salt and info always
+derive the same key material, so that e.g. per-device or per-session keys can
+be derived on demand without exporting the named key.
+`
@@ -54,11 +54,11 @@ type EncryptBatchResponseItem struct {
 
 func (b *backend) pathEncrypt() *framework.Path {
 	return &framework.Path{
-		Pattern: "encrypt/" + framework.GenericNameRegex("name"),
+		Pattern: "encrypt/" + keyOrAliasNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
 			"name": {
 				Type:        framework.TypeString,
-				Description: "Name of the policy",
+				Description: "Name of the policy, or of a key alias in the form <app>/<name>",
 			},
 
 			"plaintext": {
@@ -209,6 +209,12 @@ func decodeBatchRequestItems(src interface{}, dst *[]BatchRequestItem) error {
 
 func (b *backend) pathEncryptExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
 	name := d.Get("name").(string)
+
+	// Keys are never created through an alias
+	if _, _, ok := splitKeyAliasName(name); ok {
+		return true, nil
+	}
+
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
 		Storage: req.Storage,
 		Name:    name,
@@ -224,8 +230,15 @@ func (b *backend) pathEncryptExistenceCheck(ctx context.Context, req *logical.Re
 }
 
 func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	name := d.Get("name").(string)
-	var err error
+	name, pinnedVersion, err := resolveKeyName(ctx, req.Storage, d.Get("name").(string))
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []BatchRequestItem
 	if batchInputRaw != nil {
@@ -270,6 +283,13 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 			continue
 		}
 
+		// Encrypt with the version pinned by the key alias, if any
+		batchInputItems[i].KeyVersion, err = pinKeyVersion(item.KeyVersion, pinnedVersion)
+		if err != nil {
+			batchResponseItems[i].Error = err.Error()
+			continue
+		}
+
 		// Decode the context
 		if len(item.Context) != 0 {
 			batchInputItems[i].DecodedContext, err = base64.StdEncoding.DecodeString(item.Context)
@@ -339,6 +359,11 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationEncrypt); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
 	// Process batch request items. If encryption of any request
 	// item fails, respectively mark the error in the response
 	// collection and continue to process other items.
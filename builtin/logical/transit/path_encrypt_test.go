@@ -2,9 +2,11 @@ package transit
 
 import (
 	"context"
+	"encoding/base64"
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
 )
@@ -705,3 +707,67 @@ func TestTransit_decodeBatchRequestItems(t *testing.T) {
 		})
 	}
 }
+
+// Ensure that data encrypted locally with the public key of an EC or RSA key
+// can be decrypted by transit, and that transit can encrypt with such keys.
+func TestTransit_AsymmetricEncryption(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+
+	for _, keyType := range []string{"ecdsa-p256", "ecdsa-p521", "rsa-2048"} {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "keys/" + keyType,
+			Storage:   s,
+			Data: map[string]interface{}{
+				"type": keyType,
+			},
+		}
+		if resp, err := b.HandleRequest(context.Background(), req); err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+
+		req.Operation = logical.ReadOperation
+		req.Data = nil
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		if resp.Data["supports_encryption"] != true {
+			t.Fatalf("%s: expected encryption support", keyType)
+		}
+		keys := resp.Data["keys"].(map[string]map[string]interface{})
+		publicKey := keys["1"]["public_key"].(string)
+
+		raw, _ := base64.StdEncoding.DecodeString(plaintext)
+		local, err := api.TransitEncryptWithPublicKey(publicKey, 1, raw)
+		if err != nil {
+			t.Fatalf("%s: %s", keyType, err)
+		}
+
+		req.Operation = logical.UpdateOperation
+		req.Path = "encrypt/" + keyType
+		req.Data = map[string]interface{}{
+			"plaintext": plaintext,
+		}
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+
+		for _, ciphertext := range []string{local, resp.Data["ciphertext"].(string)} {
+			req.Path = "decrypt/" + keyType
+			req.Data = map[string]interface{}{
+				"ciphertext": ciphertext,
+			}
+			resp, err = b.HandleRequest(context.Background(), req)
+			if err != nil || resp.IsError() {
+				t.Fatalf("err: %v resp: %#v", err, resp)
+			}
+			if resp.Data["plaintext"] != plaintext {
+				t.Fatalf("%s: bad plaintext: %#v", keyType, resp.Data)
+			}
+		}
+	}
+}
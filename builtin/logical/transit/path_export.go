@@ -79,6 +79,10 @@ func (b *backend) pathPolicyExportRead(ctx context.Context, req *logical.Request
 	}
 	defer p.Unlock()
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationExport); resp != nil || err != nil {
+		return resp, err
+	}
+
 	if !p.Exportable {
 		return logical.ErrorResponse("key is not exportable"), nil
 	}
@@ -160,6 +164,10 @@ func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType st
 
 		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
 			return encodeRSAPrivateKey(key.RSAKey), nil
+
+		case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521:
+			// The same key is used for signing and ECIES encryption
+			return getExportKey(policy, key, exportTypeSigningKey)
 		}
 
 	case exportTypeSigningKey:
@@ -183,6 +191,10 @@ func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType st
 		case keysutil.KeyType_ED25519:
 			return strings.TrimSpace(base64.StdEncoding.EncodeToString(key.Key)), nil
 
+		case keysutil.KeyType_MLDSA44, keysutil.KeyType_MLDSA65, keysutil.KeyType_MLDSA87:
+			// The private key is exported as its seed
+			return base64.StdEncoding.EncodeToString(key.Key), nil
+
 		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
 			return encodeRSAPrivateKey(key.RSAKey), nil
 		}
@@ -0,0 +1,273 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// batchResponseFPEItem represents a response item for batch processing
+type batchResponseFPEItem struct {
+	// Ciphertext for the value present in the corresponding batch request
+	// item
+	Ciphertext string `json:"ciphertext,omitempty" mapstructure:"ciphertext"`
+
+	// Plaintext for the value present in the corresponding batch request
+	// item
+	Plaintext string `json:"plaintext,omitempty" mapstructure:"plaintext"`
+
+	// KeyVersion defines the key version used to encrypt or decrypt the value
+	KeyVersion int `json:"key_version,omitempty" mapstructure:"key_version"`
+
+	// Error, if set represents a failure encountered while processing a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" mapstructure:"error"`
+
+	err error
+}
+
+func (b *backend) pathFPEEncrypt() *framework.Path {
+	return &framework.Path{
+		Pattern: "fpe/encrypt/" + framework.GenericNameRegex("name"),
+		Fields: fpeFields(`The value to encrypt. Characters that are not part
+of the alphabet are left in place.`, `The version of the key to use for encryption.
+Must be 0 (for latest) or a value greater than or equal
+to the min_encryption_version configured on the key.`),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathFPEWrite(true),
+		},
+
+		HelpSynopsis:    pathFPEEncryptHelpSyn,
+		HelpDescription: pathFPEEncryptHelpDesc,
+	}
+}
+
+func (b *backend) pathFPEDecrypt() *framework.Path {
+	return &framework.Path{
+		Pattern: "fpe/decrypt/" + framework.GenericNameRegex("name"),
+		Fields: fpeFields(`The ciphertext to decrypt.`, `The version of the key the value was encrypted
+with. Defaults to the latest version, as the ciphertext does
+not record it.`),
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathFPEWrite(false),
+		},
+
+		HelpSynopsis:    pathFPEDecryptHelpSyn,
+		HelpDescription: pathFPEDecryptHelpDesc,
+	}
+}
+
+func fpeFields(valueDesc, versionDesc string) map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the key",
+		},
+
+		"value": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: valueDesc,
+		},
+
+		"tweak": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: fmt.Sprintf(`The base64-encoded %d byte tweak. The same tweak must be
+given for decryption. Defaults to all zeros.`, keysutil.FPETweakSize),
+		},
+
+		"alphabet": &framework.FieldSchema{
+			Type:    framework.TypeString,
+			Default: "numeric",
+			Description: `The alphabet of the value: "numeric", "alphanumeric",
+"alphanumeric-lower" or "alphanumeric-upper". Defaults to "numeric".`,
+		},
+
+		"custom_alphabet": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `The characters of a custom alphabet, overriding the
+alphabet parameter.`,
+		},
+
+		"key_version": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Description: versionDesc,
+		},
+	}
+}
+
+func (b *backend) pathFPEWrite(encrypt bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		ver := d.Get("key_version").(int)
+
+		alphabet := d.Get("custom_alphabet").(string)
+		if alphabet == "" {
+			var ok bool
+			alphabet, ok = keysutil.FPEAlphabets[d.Get("alphabet").(string)]
+			if !ok {
+				return logical.ErrorResponse(fmt.Sprintf("unknown alphabet %q", d.Get("alphabet").(string))), logical.ErrInvalidRequest
+			}
+		}
+
+		// Get the policy
+		p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    name,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+		}
+		if !b.System().CachingDisabled() {
+			p.Lock(false)
+		}
+		defer p.Unlock()
+
+		operation := keysutil.KeyOperationDecrypt
+		if encrypt {
+			operation = keysutil.KeyOperationEncrypt
+		}
+		if resp, err := b.checkKeyUsage(req, p, operation); resp != nil || err != nil {
+			return resp, err
+		}
+
+		if !p.Type.FPESupported() {
+			return logical.ErrorResponse(fmt.Sprintf("key type %v does not support format-preserving encryption", p.Type)), logical.ErrInvalidRequest
+		}
+
+		switch {
+		case ver == 0:
+			ver = p.LatestVersion
+		case ver < 0 || ver > p.LatestVersion:
+			return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+		case encrypt && p.MinEncryptionVersion > 0 && ver < p.MinEncryptionVersion:
+			return logical.ErrorResponse("cannot encrypt: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+		case !encrypt && p.MinDecryptionVersion > 0 && ver < p.MinDecryptionVersion:
+			return logical.ErrorResponse("cannot decrypt: version is too old (disallowed by policy)"), logical.ErrInvalidRequest
+		}
+
+		batchInputRaw := d.Raw["batch_input"]
+		var batchInputItems []batchRequestHMACItem
+		if batchInputRaw != nil {
+			if err := mapstructure.Decode(batchInputRaw, &batchInputItems); err != nil {
+				return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+			}
+
+			if len(batchInputItems) == 0 {
+				return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+			}
+		} else {
+			valueRaw, ok := d.GetOk("value")
+			if !ok {
+				return logical.ErrorResponse("missing value"), logical.ErrInvalidRequest
+			}
+
+			batchInputItems = []batchRequestHMACItem{
+				{
+					"value": valueRaw.(string),
+					"tweak": d.Get("tweak").(string),
+				},
+			}
+		}
+
+		response := make([]batchResponseFPEItem, len(batchInputItems))
+
+		for i, item := range batchInputItems {
+			value, ok := item["value"]
+			if !ok {
+				response[i].Error = "missing value"
+				response[i].err = logical.ErrInvalidRequest
+				continue
+			}
+
+			tweak := make([]byte, keysutil.FPETweakSize)
+			if item["tweak"] != "" {
+				tweak, err = base64.StdEncoding.DecodeString(item["tweak"])
+				if err != nil {
+					response[i].Error = fmt.Sprintf("unable to decode tweak as base64: %s", err)
+					response[i].err = logical.ErrInvalidRequest
+					continue
+				}
+			}
+
+			var result string
+			if encrypt {
+				result, err = p.EncryptFPE(ver, tweak, alphabet, value)
+			} else {
+				result, err = p.DecryptFPE(ver, tweak, alphabet, value)
+			}
+			if err != nil {
+				switch err.(type) {
+				case errutil.UserError:
+					response[i].Error = err.Error()
+					response[i].err = logical.ErrInvalidRequest
+				default:
+					response[i].err = err
+				}
+				continue
+			}
+
+			if encrypt {
+				response[i].Ciphertext = result
+			} else {
+				response[i].Plaintext = result
+			}
+			response[i].KeyVersion = ver
+		}
+
+		if batchInputRaw != nil {
+			return &logical.Response{
+				Data: map[string]interface{}{
+					"batch_results": response,
+				},
+			}, nil
+		}
+
+		if response[0].Error != "" {
+			return logical.ErrorResponse(response[0].Error), response[0].err
+		}
+		if response[0].err != nil {
+			return nil, response[0].err
+		}
+
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"key_version": ver,
+			},
+		}
+		if encrypt {
+			resp.Data["ciphertext"] = response[0].Ciphertext
+		} else {
+			resp.Data["plaintext"] = response[0].Plaintext
+		}
+		return resp, nil
+	}
+}
+
+const pathFPEEncryptHelpSyn = `Encrypt a value with format-preserving encryption using the named key`
+
+const pathFPEEncryptHelpDesc = `
+Encrypts a value such as a credit card number with FF3-1 (NIST SP 800-38G
+Revision 1) using a key of type "aes256-ff3-1". The ciphertext has the same
+length and characters as the value, so that it fits into existing schemas.
+As the ciphertext does not record the key version, it must be stored by the
+caller if the key is rotated.
+`
+
+const pathFPEDecryptHelpSyn = `Decrypt a value encrypted with format-preserving encryption`
+
+const pathFPEDecryptHelpDesc = `
+Decrypts a value encrypted by the fpe/encrypt endpoint. The tweak, alphabet
+and key version must be the ones used for encryption.
+`
@@ -0,0 +1,85 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_FPE(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/cards",
+		Data: map[string]interface{}{
+			"type": "aes256-ff3-1",
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	tweak := base64.StdEncoding.EncodeToString([]byte("tweak56"))
+
+	req.Path = "fpe/encrypt/cards"
+	req.Data = map[string]interface{}{
+		"value": "4111-1111-1111-1111",
+		"tweak": tweak,
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	ciphertext := resp.Data["ciphertext"].(string)
+	if len(ciphertext) != 19 || ciphertext == "4111-1111-1111-1111" || resp.Data["key_version"] != 1 {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+
+	req.Path = "fpe/decrypt/cards"
+	req.Data["value"] = ciphertext
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["plaintext"] != "4111-1111-1111-1111" {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+
+	// Batch encryption
+	req.Path = "fpe/encrypt/cards"
+	req.Data = map[string]interface{}{
+		"alphabet": "alphanumeric-upper",
+		"batch_input": []interface{}{
+			map[string]interface{}{"value": "AB1234567", "tweak": tweak},
+			map[string]interface{}{"value": "AB12"},
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	results := resp.Data["batch_results"].([]batchResponseFPEItem)
+	if len(results[0].Ciphertext) != 9 || results[1].Error == "" {
+		t.Fatalf("bad batch results: %#v", results)
+	}
+
+	// Keys of other types cannot be used for format-preserving encryption
+	req.Path = "keys/other"
+	req.Data = nil
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req.Path = "fpe/encrypt/other"
+	req.Data = map[string]interface{}{
+		"value": "4111111111111111",
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+}
@@ -111,6 +111,11 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationHMAC); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
 	switch {
 	case ver == 0:
 		// Allowed, will use latest; set explicitly here to ensure the string
@@ -240,6 +245,11 @@ func (b *backend) pathHMACVerify(ctx context.Context, req *logical.Request, d *f
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationVerify); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
 	hashAlgorithm, ok := keysutil.HashTypeMap[algorithm]
 	if !ok {
 		p.Unlock()
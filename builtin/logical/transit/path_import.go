@@ -0,0 +1,229 @@
+package transit
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// ephemeralKeySize is the size in bytes of the AES key wrapping the key
+// material, itself encrypted with the RSA wrapping key.
+const ephemeralKeySize = 32
+
+func (b *backend) pathImport() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded key material to import, wrapped
+with an ephemeral AES-256 key using the AES key
+wrap with padding algorithm (RFC 5649), preceded
+by the ephemeral key encrypted with the public key
+returned by the "wrapping_key" endpoint using
+RSA-OAEP. The key material is the raw key for
+symmetric key types, and a PKCS #8 DER-encoded
+private key for asymmetric key types.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "SHA256",
+				Description: `The hash function used with RSA-OAEP to
+encrypt the ephemeral key. Can be "SHA1", "SHA256",
+"SHA384" or "SHA512". Defaults to "SHA256".`,
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "aes256-gcm96",
+				Description: `The type of the imported key. Supports the
+same types as key creation. Defaults to
+"aes256-gcm96".`,
+			},
+
+			"derived": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables key derivation mode. This
+allows for per-transaction unique
+keys for encryption operations.`,
+			},
+
+			"exportable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables keys to be exportable.
+This allows for all the valid keys
+in the key ring to be exported.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables taking a backup of the named
+key in plaintext format. Once set,
+this cannot be disabled.`,
+			},
+
+			"allow_rotation": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Allows rotating the imported key, the new
+versions being generated by Vault. Defaults
+to false.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportWrite,
+		},
+
+		HelpSynopsis:    pathImportHelpSyn,
+		HelpDescription: pathImportHelpDesc,
+	}
+}
+
+func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	keyType := d.Get("type").(string)
+
+	polReq := keysutil.PolicyRequest{
+		Storage:                  req.Storage,
+		Name:                     name,
+		Derived:                  d.Get("derived").(bool),
+		Exportable:               d.Get("exportable").(bool),
+		AllowPlaintextBackup:     d.Get("allow_plaintext_backup").(bool),
+		AllowImportedKeyRotation: d.Get("allow_rotation").(bool),
+	}
+	var ok bool
+	polReq.KeyType, ok = parseKeyType(keyType)
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
+	}
+	if polReq.KeyType.MLDSASupported() {
+		if err := mldsautil.CheckEnabled(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	hashFn, err := parseHashFunction(d.Get("hash_function").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(d.Get("ciphertext").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode ciphertext"), logical.ErrInvalidRequest
+	}
+
+	wrappingKey, err := b.getWrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := unwrapKeyMaterial(wrappingKey, hashFn, ciphertext)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	err = b.lm.ImportPolicy(ctx, polReq, key, b.GetRandomReader())
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// parseHashFunction returns the hash function of the given name used with
+// RSA-OAEP.
+func parseHashFunction(name string) (hash.Hash, error) {
+	switch name {
+	case "SHA1":
+		return sha1.New(), nil
+	case "SHA256":
+		return sha256.New(), nil
+	case "SHA384":
+		return sha512.New384(), nil
+	case "SHA512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash function %q", name)
+	}
+}
+
+// wrapKeyMaterial wraps the key material with a new ephemeral AES key, and
+// returns it preceded by the ephemeral key encrypted with the RSA public key.
+func wrapKeyMaterial(pub *rsa.PublicKey, hashFn hash.Hash, key []byte, randReader io.Reader) ([]byte, error) {
+	ephemeralKey := make([]byte, ephemeralKeySize)
+	if _, err := io.ReadFull(randReader, ephemeralKey); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(hashFn, randReader, pub, ephemeralKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt ephemeral key: %w", err)
+	}
+
+	wrappedKey, err := wrapKeyWithPadding(ephemeralKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key material: %w", err)
+	}
+
+	return append(encryptedKey, wrappedKey...), nil
+}
+
+// unwrapKeyMaterial returns the key material wrapped by wrapKeyMaterial with
+// the public part of the RSA key.
+func unwrapKeyMaterial(priv *rsa.PrivateKey, hashFn hash.Hash, ciphertext []byte) ([]byte, error) {
+	keySize := priv.Size()
+	if len(ciphertext) <= keySize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	ephemeralKey, err := rsa.DecryptOAEP(hashFn, nil, priv, ciphertext[:keySize], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ephemeral key")
+	}
+	if len(ephemeralKey) != ephemeralKeySize {
+		return nil, fmt.Errorf("ephemeral key must be an AES-256 key")
+	}
+
+	key, err := unwrapKeyWithPadding(ephemeralKey, ciphertext[keySize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key material: %w", err)
+	}
+
+	return key, nil
+}
+
+const pathImportHelpSyn = `Imports the key material of a new named key`
+
+const pathImportHelpDesc = `
+This path is used to create a named key from existing key material, for
+instance when migrating keys from an external KMS or HSM. The key material must
+be wrapped for the backend: fetch the public key from the "wrapping_key"
+endpoint, wrap the key material with an ephemeral AES-256 key using the AES key
+wrap with padding algorithm (RFC 5649), and encrypt the ephemeral key with the
+public key using RSA-OAEP. The ciphertext is the base64 encoding of the
+encrypted ephemeral key followed by the wrapped key material.
+
+Imported keys cannot be rotated unless "allow_rotation" is set.
+`
@@ -0,0 +1,220 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_KeyWrapWithPadding(t *testing.T) {
+	// Test vectors from RFC 5649
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	testCases := []struct {
+		key     string
+		wrapped string
+	}{
+		{"c37b7e6492584340bed12207808941155068f738", "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a"},
+		{"466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f"},
+	}
+
+	for _, tc := range testCases {
+		key, _ := hex.DecodeString(tc.key)
+		wrapped, err := wrapKeyWithPadding(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(wrapped) != tc.wrapped {
+			t.Fatalf("bad wrapped key: expected %s, got %x", tc.wrapped, wrapped)
+		}
+
+		unwrapped, err := unwrapKeyWithPadding(kek, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unwrapped, key) {
+			t.Fatalf("bad unwrapped key: expected %s, got %x", tc.key, unwrapped)
+		}
+
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := unwrapKeyWithPadding(kek, wrapped); err == nil {
+			t.Fatal("expected unwrapping a tampered key to fail")
+		}
+	}
+}
+
+func TestTransit_Import(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	handle := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp
+	}
+	expectError := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected an error, got %#v", resp)
+		}
+	}
+
+	resp := handle(logical.ReadOperation, "wrapping_key", nil)
+	wrappingKeyPEM := resp.Data["public_key"].(string)
+	wrappingKey, err := parseRSAPublicKey(wrappingKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrappingKey.N.BitLen() != wrappingKeySize {
+		t.Fatalf("expected a %d-bit wrapping key, got %d bits", wrappingKeySize, wrappingKey.N.BitLen())
+	}
+	resp = handle(logical.ReadOperation, "wrapping_key", nil)
+	if resp.Data["public_key"] != wrappingKeyPEM {
+		t.Fatal("expected the wrapping key to be stable")
+	}
+
+	wrap := func(pub *rsa.PublicKey, key []byte) string {
+		t.Helper()
+		wrapped, err := wrapKeyMaterial(pub, sha256.New(), key, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(wrapped)
+	}
+
+	// A destination key for exports
+	destKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	destDER, err := x509.MarshalPKIXPublicKey(destKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	destPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: destDER}))
+
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		keyType string
+		key     []byte
+	}{
+		{"aes", "aes256-gcm96", aesKey},
+		{"ecdsa", "ecdsa-p256", ecDER},
+		{"ed25519", "ed25519", edDER},
+	}
+
+	for _, tc := range testCases {
+		handle(logical.UpdateOperation, "keys/"+tc.name+"/import", map[string]interface{}{
+			"ciphertext": wrap(wrappingKey, tc.key),
+			"type":       tc.keyType,
+			"exportable": true,
+		})
+
+		resp = handle(logical.ReadOperation, "keys/"+tc.name, nil)
+		if resp.Data["imported_key"] != true || resp.Data["allow_imported_key_rotation"] != false || resp.Data["latest_version"] != 1 {
+			t.Fatalf("unexpected key: %#v", resp.Data)
+		}
+
+		// The exported key material is the imported one
+		resp = handle(logical.UpdateOperation, "byok-export/"+tc.name+"/latest", map[string]interface{}{
+			"wrapping_key": destPEM,
+		})
+		wrapped, err := base64.StdEncoding.DecodeString(resp.Data["keys"].(map[string]string)["1"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		exported, err := unwrapKeyMaterial(destKey, sha256.New(), wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(exported, tc.key) {
+			t.Fatalf("%s: exported key material differs from the imported one", tc.name)
+		}
+	}
+
+	// The imported key is usable
+	resp = handle(logical.UpdateOperation, "sign/ecdsa", map[string]interface{}{
+		"input": "dGhlIHF1aWNrIGJyb3duIGZveA==",
+	})
+	resp = handle(logical.UpdateOperation, "verify/ecdsa", map[string]interface{}{
+		"input":     "dGhlIHF1aWNrIGJyb3duIGZveA==",
+		"signature": resp.Data["signature"],
+	})
+	if resp.Data["valid"] != true {
+		t.Fatal("expected the signature of the imported key to be valid")
+	}
+
+	// Imported keys are not rotated unless allowed
+	expectError(logical.UpdateOperation, "keys/aes/rotate", nil)
+	handle(logical.UpdateOperation, "keys/rotatable/import", map[string]interface{}{
+		"ciphertext":     wrap(wrappingKey, aesKey),
+		"allow_rotation": true,
+	})
+	handle(logical.UpdateOperation, "keys/rotatable/rotate", nil)
+
+	// Existing keys, mismatched key material and other wrapping keys are
+	// rejected
+	expectError(logical.UpdateOperation, "keys/aes/import", map[string]interface{}{
+		"ciphertext": wrap(wrappingKey, aesKey),
+	})
+	expectError(logical.UpdateOperation, "keys/mismatch/import", map[string]interface{}{
+		"ciphertext": wrap(wrappingKey, ecDER),
+		"type":       "ed25519",
+	})
+	expectError(logical.UpdateOperation, "keys/short/import", map[string]interface{}{
+		"ciphertext": wrap(wrappingKey, aesKey[:16]),
+	})
+	expectError(logical.UpdateOperation, "keys/other/import", map[string]interface{}{
+		"ciphertext": wrap(&destKey.PublicKey, aesKey),
+	})
+
+	// Keys which are not exportable are not exported either way
+	handle(logical.UpdateOperation, "keys/private", nil)
+	expectError(logical.UpdateOperation, "byok-export/private", map[string]interface{}{
+		"wrapping_key": destPEM,
+	})
+}
@@ -15,7 +15,9 @@ import (
 	"github.com/fatih/structs"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -47,7 +49,10 @@ func (b *backend) pathKeys() *framework.Path {
 				Description: `
 The type of key to create. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric), "aes128-cmac" (CMAC), "aes256-cmac" (CMAC), "aes256-ff3-1"
+(format-preserving encryption) are supported. The experimental "ml-dsa-44", "ml-dsa-65" and "ml-dsa-87"
+(asymmetric, post-quantum) are supported when enabled by the VAULT_EXPERIMENTAL_ML_DSA environment variable.
+Defaults to "aes256-gcm96".
 `,
 			},
 
@@ -95,6 +100,37 @@ When reading a key with key derivation enabled,
 if the key type supports public keys, this will
 return the public key for the given context.`,
 			},
+
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time the key should live before
+being automatically rotated. A value of 0
+disables automatic rotation for the key.
+Must be at least one hour otherwise.`,
+			},
+
+			"kms_key": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Name of the external KMS key, configured
+at kms/<name>, used to encrypt the key ring in
+storage. Can only be set when the key is created.`,
+			},
+
+			"managed_key_name": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Name of the HSM or cloud KMS, configured at
+managed-keys/<name>, holding the private key of
+the key. Can only be set when the key is created,
+with an asymmetric key type.`,
+			},
+
+			"managed_key_id": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `ID of the external key used as first version
+of a managed key, such as the ARN of an AWS KMS key
+or the resource name of a Cloud KMS crypto key
+version.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -124,11 +160,18 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	keyType := d.Get("type").(string)
 	exportable := d.Get("exportable").(bool)
 	allowPlaintextBackup := d.Get("allow_plaintext_backup").(bool)
+	kmsKey := d.Get("kms_key").(string)
+	managedKeyName := d.Get("managed_key_name").(string)
+	autoRotatePeriod := time.Second * time.Duration(d.Get("auto_rotate_period").(int))
 
 	if !derived && convergent {
 		return logical.ErrorResponse("convergent encryption requires derivation to be enabled"), nil
 	}
 
+	if err := validateAutoRotatePeriod(autoRotatePeriod); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	polReq := keysutil.PolicyRequest{
 		Upsert:               true,
 		Storage:              req.Storage,
@@ -137,34 +180,43 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		Convergent:           convergent,
 		Exportable:           exportable,
 		AllowPlaintextBackup: allowPlaintextBackup,
+		AutoRotatePeriod:     autoRotatePeriod,
 	}
-	switch keyType {
-	case "aes128-gcm96":
-		polReq.KeyType = keysutil.KeyType_AES128_GCM96
-	case "aes256-gcm96":
-		polReq.KeyType = keysutil.KeyType_AES256_GCM96
-	case "chacha20-poly1305":
-		polReq.KeyType = keysutil.KeyType_ChaCha20_Poly1305
-	case "ecdsa-p256":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P256
-	case "ecdsa-p384":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P384
-	case "ecdsa-p521":
-		polReq.KeyType = keysutil.KeyType_ECDSA_P521
-	case "ed25519":
-		polReq.KeyType = keysutil.KeyType_ED25519
-	case "rsa-2048":
-		polReq.KeyType = keysutil.KeyType_RSA2048
-	case "rsa-3072":
-		polReq.KeyType = keysutil.KeyType_RSA3072
-	case "rsa-4096":
-		polReq.KeyType = keysutil.KeyType_RSA4096
-	default:
+	var ok bool
+	polReq.KeyType, ok = parseKeyType(keyType)
+	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
+	if polReq.KeyType.MLDSASupported() {
+		if err := mldsautil.CheckEnabled(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	if kmsKey != "" {
+		resp, err := b.bindKMSKey(ctx, req.Storage, name, kmsKey)
+		if resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	if managedKeyName != "" {
+		resp, err := b.createManagedKey(ctx, req.Storage, polReq, managedKeyName, d.Get("managed_key_id").(string))
+		if (resp != nil || err != nil) && kmsKey != "" {
+			if err := req.Storage.Delete(ctx, kmsBindingPrefix+name); err != nil {
+				b.Logger().Error("failed to delete KMS binding of key", "name", name, "error", err)
+			}
+		}
+		return resp, err
+	}
 
 	p, upserted, err := b.lm.GetPolicy(ctx, polReq, b.GetRandomReader())
 	if err != nil {
+		if kmsKey != "" {
+			if err := req.Storage.Delete(ctx, kmsBindingPrefix+name); err != nil {
+				b.Logger().Error("failed to delete KMS binding of key", "name", name, "error", err)
+			}
+		}
 		return nil, err
 	}
 	if p == nil {
@@ -182,11 +234,53 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	return nil, nil
 }
 
+// parseKeyType returns the key type of the given name, and whether it is
+// known.
+func parseKeyType(keyType string) (keysutil.KeyType, bool) {
+	switch keyType {
+	case "aes128-gcm96":
+		return keysutil.KeyType_AES128_GCM96, true
+	case "aes256-gcm96":
+		return keysutil.KeyType_AES256_GCM96, true
+	case "chacha20-poly1305":
+		return keysutil.KeyType_ChaCha20_Poly1305, true
+	case "ecdsa-p256":
+		return keysutil.KeyType_ECDSA_P256, true
+	case "ecdsa-p384":
+		return keysutil.KeyType_ECDSA_P384, true
+	case "ecdsa-p521":
+		return keysutil.KeyType_ECDSA_P521, true
+	case "ed25519":
+		return keysutil.KeyType_ED25519, true
+	case "rsa-2048":
+		return keysutil.KeyType_RSA2048, true
+	case "rsa-3072":
+		return keysutil.KeyType_RSA3072, true
+	case "rsa-4096":
+		return keysutil.KeyType_RSA4096, true
+	case "aes128-cmac":
+		return keysutil.KeyType_AES128_CMAC, true
+	case "aes256-cmac":
+		return keysutil.KeyType_AES256_CMAC, true
+	case "aes256-ff3-1":
+		return keysutil.KeyType_AES256_FF3_1, true
+	case mldsautil.MLDSA44:
+		return keysutil.KeyType_MLDSA44, true
+	case mldsautil.MLDSA65:
+		return keysutil.KeyType_MLDSA65, true
+	case mldsautil.MLDSA87:
+		return keysutil.KeyType_MLDSA87, true
+	default:
+		return 0, false
+	}
+}
+
 // Built-in helper type for returning asymmetric keys
 type asymKey struct {
 	Name         string    `json:"name" structs:"name" mapstructure:"name"`
 	PublicKey    string    `json:"public_key" structs:"public_key" mapstructure:"public_key"`
 	CreationTime time.Time `json:"creation_time" structs:"creation_time" mapstructure:"creation_time"`
+	ManagedKeyID string    `json:"managed_key_id,omitempty" structs:"managed_key_id,omitempty" mapstructure:"managed_key_id"`
 }
 
 func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -220,13 +314,30 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 			"latest_version":         p.LatestVersion,
 			"exportable":             p.Exportable,
 			"allow_plaintext_backup": p.AllowPlaintextBackup,
+			"allow_lookup_export":    p.AllowLookupExport,
+			"auto_rotate_period":     int64(p.AutoRotatePeriod.Seconds()),
 			"supports_encryption":    p.Type.EncryptionSupported(),
 			"supports_decryption":    p.Type.DecryptionSupported(),
 			"supports_signing":       p.Type.SigningSupported(),
 			"supports_derivation":    p.Type.DerivationSupported(),
+			"supports_cmac":          p.Type.CMACSupported(),
+			"supports_fpe":           p.Type.FPESupported(),
 		},
 	}
 
+	if p.ManagedKeyName != "" {
+		resp.Data["managed_key_name"] = p.ManagedKeyName
+	}
+
+	if p.UsagePolicy != nil {
+		resp.Data["usage_policy"] = usagePolicyResponseData(p.UsagePolicy)
+	}
+
+	if p.Imported {
+		resp.Data["imported_key"] = true
+		resp.Data["allow_imported_key_rotation"] = p.AllowImportedKeyRotation
+	}
+
 	if p.BackupInfo != nil {
 		resp.Data["backup_info"] = map[string]interface{}{
 			"time":    p.BackupInfo.Time,
@@ -240,6 +351,17 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 		}
 	}
 
+	// Cached keys can be read without storage
+	if req.Storage != nil {
+		binding, err := b.kmsBinding(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil {
+			resp.Data["kms_key"] = binding.KMSName
+		}
+	}
+
 	if p.Derived {
 		switch p.KDF {
 		case keysutil.Kdf_hmac_sha256_counter:
@@ -264,19 +386,21 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 	}
 
 	switch p.Type {
-	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305:
+	case keysutil.KeyType_AES128_GCM96, keysutil.KeyType_AES256_GCM96, keysutil.KeyType_ChaCha20_Poly1305, keysutil.KeyType_AES128_CMAC, keysutil.KeyType_AES256_CMAC, keysutil.KeyType_AES256_FF3_1:
 		retKeys := map[string]int64{}
 		for k, v := range p.Keys {
 			retKeys[k] = v.DeprecatedCreationTime
 		}
 		resp.Data["keys"] = retKeys
 
-	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521, keysutil.KeyType_ED25519, keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ECDSA_P384, keysutil.KeyType_ECDSA_P521, keysutil.KeyType_ED25519, keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096,
+		keysutil.KeyType_MLDSA44, keysutil.KeyType_MLDSA65, keysutil.KeyType_MLDSA87:
 		retKeys := map[string]map[string]interface{}{}
 		for k, v := range p.Keys {
 			key := asymKey{
 				PublicKey:    v.FormattedPublicKey,
 				CreationTime: v.CreationTime,
+				ManagedKeyID: v.ManagedKeyID,
 			}
 			if key.CreationTime.IsZero() {
 				key.CreationTime = time.Unix(v.DeprecatedCreationTime, 0)
@@ -307,6 +431,8 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 					}
 				}
 				key.Name = "ed25519"
+			case keysutil.KeyType_MLDSA44, keysutil.KeyType_MLDSA65, keysutil.KeyType_MLDSA87:
+				key.Name = p.Type.String()
 			case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
 				key.Name = "rsa-2048"
 				if p.Type == keysutil.KeyType_RSA3072 {
@@ -317,6 +443,11 @@ func (b *backend) pathPolicyRead(ctx context.Context, req *logical.Request, d *f
 					key.Name = "rsa-4096"
 				}
 
+				// The public key of managed versions is already encoded
+				if v.ManagedKeyID != "" {
+					break
+				}
+
 				// Encode the RSA public key in PEM format to return over the
 				// API
 				derBytes, err := x509.MarshalPKIXPublicKey(v.RSAKey.Public())
@@ -351,6 +482,68 @@ func (b *backend) pathPolicyDelete(ctx context.Context, req *logical.Request, d
 		return logical.ErrorResponse(fmt.Sprintf("error deleting policy %s: %s", name, err)), err
 	}
 
+	if err := req.Storage.Delete(ctx, kmsBindingPrefix+name); err != nil {
+		return nil, err
+	}
+
+	if err := deleteKeyAliases(ctx, req.Storage, name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// bindKMSKey records that the new key with the given name is protected by the
+// named external KMS key. It returns an error response if the key already
+// exists or the KMS key is not configured.
+func (b *backend) bindKMSKey(ctx context.Context, s logical.Storage, name, kmsKey string) (*logical.Response, error) {
+	entry, err := s.Get(ctx, "policy/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return logical.ErrorResponse("kms_key can only be set when the key is created"), logical.ErrInvalidRequest
+	}
+
+	config, err := b.kmsConfig(ctx, s, kmsKey)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse(fmt.Sprintf("KMS key %q is not configured", kmsKey)), logical.ErrInvalidRequest
+	}
+
+	entry, err = logical.StorageEntryJSON(kmsBindingPrefix+name, &kmsBinding{
+		KMSName: kmsKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.Put(ctx, entry)
+}
+
+// createManagedKey creates a key whose private key is the external key with
+// the given ID, held by the named HSM or cloud KMS.
+func (b *backend) createManagedKey(ctx context.Context, s logical.Storage, polReq keysutil.PolicyRequest, managedKeyName, managedKeyID string) (*logical.Response, error) {
+	if managedKeyID == "" {
+		return logical.ErrorResponse("missing managed_key_id"), logical.ErrInvalidRequest
+	}
+
+	pub, err := b.managedKeyPublicKey(ctx, s, managedKeyName, managedKeyID)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to get the public key of managed key %q: %s", managedKeyID, err)), logical.ErrInvalidRequest
+	}
+
+	polReq.ManagedKeyName = managedKeyName
+	err = b.lm.CreateManagedPolicy(ctx, polReq, managedKeyID, pub, b.GetRandomReader())
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
 	return nil, nil
 }
 
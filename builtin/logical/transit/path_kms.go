@@ -0,0 +1,333 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// kmsSensitiveConfig holds the parameters of KMS configurations that are never
+// returned on read.
+var kmsSensitiveConfig = []string{
+	"client_secret",
+	"credentials",
+	"key",
+	"private_key",
+	"secret_key",
+	"session_token",
+	"token",
+}
+
+func (b *backend) pathListKMS() *framework.Path {
+	return &framework.Path{
+		Pattern: "kms/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKMSList,
+		},
+
+		HelpSynopsis:    pathKMSHelpSyn,
+		HelpDescription: pathKMSHelpDesc,
+	}
+}
+
+func (b *backend) pathKMS() *framework.Path {
+	return &framework.Path{
+		Pattern: "kms/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the KMS key",
+			},
+
+			"type": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The type of KMS, as for seals. Currently,
+"aead", "alicloudkms", "awskms", "azurekeyvault", "gcpckms",
+"ocikms" and "transit" are supported.`,
+			},
+
+			"config": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `The configuration of the KMS key, taking
+the same parameters as the seal of the same type.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKMSWrite,
+			logical.ReadOperation:   b.pathKMSRead,
+			logical.DeleteOperation: b.pathKMSDelete,
+		},
+
+		HelpSynopsis:    pathKMSHelpSyn,
+		HelpDescription: pathKMSHelpDesc,
+	}
+}
+
+func (b *backend) pathKMSRewrap() *framework.Path {
+	return &framework.Path{
+		Pattern: "kms/" + framework.GenericNameRegex("name") + "/rewrap",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the KMS key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKMSRewrapWrite,
+		},
+
+		HelpSynopsis:    pathKMSRewrapHelpSyn,
+		HelpDescription: pathKMSRewrapHelpDesc,
+	}
+}
+
+func (b *backend) pathKMSList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, kmsConfigPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathKMSWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config := &kmsConfig{
+		Type:   d.Get("type").(string),
+		Config: d.Get("config").(map[string]string),
+	}
+	if config.Type == "" {
+		return logical.ErrorResponse("missing type"), logical.ErrInvalidRequest
+	}
+
+	// Make sure the KMS key is usable before protecting keys with it
+	wrapper, err := newKMSWrapper(ctx, config, b.Logger())
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid KMS configuration: %s", err)), logical.ErrInvalidRequest
+	}
+	defer func() {
+		if err := wrapper.Finalize(ctx); err != nil {
+			b.Logger().Warn("failed to finalize KMS wrapper", "name", name, "error", err)
+		}
+	}()
+
+	// The keys already protected by the KMS key must stay readable, so its
+	// configuration can only be changed for one which decrypts them, such as
+	// new credentials for the same KMS key
+	keys, err := b.kmsBoundKeys(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := b.checkKMSDecrypts(ctx, req.Storage, wrapper, key); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("the new configuration of KMS key %q cannot decrypt key %q, which it protects: %s", name, key, err)), logical.ErrInvalidRequest
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(kmsConfigPrefix+name, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.resetKMSWrapper(ctx, name)
+
+	return nil, nil
+}
+
+func (b *backend) pathKMSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config, err := b.kmsConfig(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	redacted := make(map[string]string, len(config.Config))
+	for k, v := range config.Config {
+		redacted[k] = v
+	}
+	for _, k := range kmsSensitiveConfig {
+		if _, ok := redacted[k]; ok {
+			redacted[k] = "<redacted>"
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":   name,
+			"type":   config.Type,
+			"config": redacted,
+		},
+	}, nil
+}
+
+func (b *backend) pathKMSDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	keys, err := b.kmsBoundKeys(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("KMS key %q protects keys %s", name, strings.Join(keys, ", "))), logical.ErrInvalidRequest
+	}
+
+	if err := req.Storage.Delete(ctx, kmsConfigPrefix+name); err != nil {
+		return nil, err
+	}
+	b.resetKMSWrapper(ctx, name)
+
+	return nil, nil
+}
+
+func (b *backend) pathKMSRewrapWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config, err := b.kmsConfig(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("KMS key not found"), logical.ErrInvalidRequest
+	}
+
+	// Use a fresh wrapper, since the KMS key may have been rotated or its
+	// configuration changed on another node
+	b.resetKMSWrapper(ctx, name)
+
+	keys, err := b.kmsBoundKeys(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := b.rewrapKey(ctx, req.Storage, key); err != nil {
+			return nil, fmt.Errorf("failed to rewrap key %q: %w", key, err)
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"keys": keys,
+		},
+	}, nil
+}
+
+// rewrapKey encrypts the policy and archive of the named key again with the
+// current version of the KMS key protecting it.
+func (b *backend) rewrapKey(ctx context.Context, s logical.Storage, name string) error {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: s,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	for _, prefix := range []string{"policy/", "archive/"} {
+		entry, err := s.Get(ctx, prefix+name)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if err := s.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKMSDecrypts returns an error if the wrapper cannot decrypt the policy
+// or the archive of the named key.
+func (b *backend) checkKMSDecrypts(ctx context.Context, s logical.Storage, wrapper wrapping.Wrapper, name string) error {
+	// Read the envelopes as they are stored, rather than decrypted with the
+	// current configuration of the KMS key
+	if ks, ok := s.(*kmsStorage); ok {
+		s = ks.Storage
+	}
+
+	for _, prefix := range []string{"policy/", "archive/"} {
+		entry, err := s.Get(ctx, prefix+name)
+		if err != nil {
+			return err
+		}
+		if entry == nil || !bytes.HasPrefix(entry.Value, kmsEnvelopePrefix) {
+			continue
+		}
+
+		var envelope kmsEnvelope
+		if err := json.Unmarshal(entry.Value, &envelope); err != nil {
+			return fmt.Errorf("failed to decode KMS envelope of %q: %w", entry.Key, err)
+		}
+		if _, err := openKMSEnvelope(ctx, wrapper, entry.Key, &envelope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kmsBoundKeys returns the sorted names of the keys protected by the named
+// KMS key.
+func (b *backend) kmsBoundKeys(ctx context.Context, s logical.Storage, name string) ([]string, error) {
+	keys, err := s.List(ctx, kmsBindingPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := []string{}
+	for _, key := range keys {
+		binding, err := b.kmsBinding(ctx, s, key)
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil && binding.KMSName == name {
+			bound = append(bound, key)
+		}
+	}
+	sort.Strings(bound)
+	return bound, nil
+}
+
+const pathKMSHelpSyn = `Manage the external KMS keys protecting transit keys`
+
+const pathKMSHelpDesc = `
+This path is used to configure named keys of an external KMS. Transit keys
+created with the "kms_key" parameter have their key ring encrypted with the
+named KMS key before being written to storage, in addition to the barrier and
+seal. A KMS key cannot be deleted while it protects keys, and its configuration
+can then only be changed for one which decrypts them, such as new credentials
+for the same KMS key.
+`
+
+const pathKMSRewrapHelpSyn = `Rewrap the keys protected by an external KMS key`
+
+const pathKMSRewrapHelpDesc = `
+This path is used to encrypt the key rings protected by the named KMS key
+again, with the current version of the KMS key. It should be called after the
+KMS key is rotated, before older versions are disabled.
+`
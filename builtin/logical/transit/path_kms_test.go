@@ -0,0 +1,251 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_KMS(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	doReq := func(t *testing.T, b *backend, req *logical.Request) *logical.Response {
+		t.Helper()
+		req.Storage = storage
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+		}
+		return resp
+	}
+	doErrReq := func(t *testing.T, b *backend, req *logical.Request) {
+		t.Helper()
+		req.Storage = storage
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error; resp:\n%#v\n", resp)
+		}
+	}
+	assertWrapped := func(t *testing.T, key string) {
+		t.Helper()
+		entry, err := storage.Get(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil {
+			t.Fatalf("missing %q", key)
+		}
+		if !bytes.HasPrefix(entry.Value, kmsEnvelopePrefix) {
+			t.Fatalf("%q is not encrypted with the KMS key: %s", key, entry.Value)
+		}
+	}
+
+	// Keys cannot be bound to KMS keys that are not configured
+	doErrReq(t, b, &logical.Request{
+		Path:      "keys/protected",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"kms_key": "tenant",
+		},
+	})
+
+	// Invalid configurations are rejected
+	doErrReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "aead",
+			"config": map[string]interface{}{
+				"aead_type": "aes-gcm",
+				"key":       "invalid",
+			},
+		},
+	})
+
+	// Seal types using resources of the host are not allowed
+	doErrReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "tpm",
+			"config": map[string]interface{}{
+				"sealed_key_path": "/etc/vault/tenant.sealed",
+			},
+		},
+	})
+
+	kmsKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	doReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "aead",
+			"config": map[string]interface{}{
+				"aead_type": "aes-gcm",
+				"key":       kmsKey,
+			},
+		},
+	})
+
+	resp := doReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["type"] != "aead" {
+		t.Fatalf("bad type: %#v", resp.Data)
+	}
+	if config := resp.Data["config"].(map[string]string); config["key"] != "<redacted>" || config["aead_type"] != "aes-gcm" {
+		t.Fatalf("bad config: %#v", config)
+	}
+
+	resp = doReq(t, b, &logical.Request{
+		Path:      "kms/",
+		Operation: logical.ListOperation,
+	})
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "tenant" {
+		t.Fatalf("bad keys: %#v", resp.Data)
+	}
+
+	doReq(t, b, &logical.Request{
+		Path:      "keys/protected",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"kms_key": "tenant",
+		},
+	})
+	doReq(t, b, &logical.Request{
+		Path:      "keys/plain",
+		Operation: logical.UpdateOperation,
+	})
+	doReq(t, b, &logical.Request{
+		Path:      "keys/protected/rotate",
+		Operation: logical.UpdateOperation,
+	})
+	assertWrapped(t, "policy/protected")
+	assertWrapped(t, "archive/protected")
+
+	entry, err := storage.Get(context.Background(), "policy/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.HasPrefix(entry.Value, kmsEnvelopePrefix) {
+		t.Fatalf("unprotected key is encrypted with a KMS key")
+	}
+
+	// The KMS key cannot be changed once the key exists
+	doErrReq(t, b, &logical.Request{
+		Path:      "keys/plain",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"kms_key": "tenant",
+		},
+	})
+
+	// The configuration of the KMS key can only be changed for one which
+	// decrypts the keys it protects
+	doErrReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "aead",
+			"config": map[string]interface{}{
+				"aead_type": "aes-gcm",
+				"key":       base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)),
+			},
+		},
+	})
+	doReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "aead",
+			"config": map[string]interface{}{
+				"aead_type": "aes-gcm",
+				"key":       kmsKey,
+			},
+		},
+	})
+
+	resp = doReq(t, b, &logical.Request{
+		Path:      "keys/protected",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["kms_key"] != "tenant" || resp.Data["latest_version"] != 2 {
+		t.Fatalf("bad key: %#v", resp.Data)
+	}
+
+	resp = doReq(t, b, &logical.Request{
+		Path:      "encrypt/protected",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte(testPlaintext)),
+		},
+	})
+	ciphertext := resp.Data["ciphertext"].(string)
+
+	// A backend without cached keys must decrypt them from storage
+	b2 := createBackendWithSysViewWithStorage(t, storage)
+	resp = doReq(t, b2, &logical.Request{
+		Path:      "decrypt/protected",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"ciphertext": ciphertext,
+		},
+	})
+	if resp.Data["plaintext"] != base64.StdEncoding.EncodeToString([]byte(testPlaintext)) {
+		t.Fatalf("bad plaintext: %#v", resp.Data)
+	}
+
+	before, err := storage.Get(context.Background(), "policy/protected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = doReq(t, b2, &logical.Request{
+		Path:      "kms/tenant/rewrap",
+		Operation: logical.UpdateOperation,
+	})
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "protected" {
+		t.Fatalf("bad rewrapped keys: %#v", resp.Data)
+	}
+	after, err := storage.Get(context.Background(), "policy/protected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before.Value, after.Value) {
+		t.Fatalf("key was not rewrapped")
+	}
+	assertWrapped(t, "policy/protected")
+
+	// The KMS key cannot be deleted while it protects keys
+	doErrReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.DeleteOperation,
+	})
+
+	doReq(t, b, &logical.Request{
+		Path:      "keys/protected/config",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"deletion_allowed": true,
+		},
+	})
+	doReq(t, b, &logical.Request{
+		Path:      "keys/protected",
+		Operation: logical.DeleteOperation,
+	})
+	doReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.DeleteOperation,
+	})
+	resp = doReq(t, b, &logical.Request{
+		Path:      "kms/tenant",
+		Operation: logical.ReadOperation,
+	})
+	if resp != nil {
+		t.Fatalf("KMS key was not deleted: %#v", resp)
+	}
+}
@@ -0,0 +1,122 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// convergentNonceSize is the nonce size of the AEADs supporting convergent
+// encryption, AES-GCM and ChaCha20-Poly1305.
+const convergentNonceSize = 12
+
+func (b *backend) pathLookupKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "lookup-key/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"context": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Base64 encoded context for which to export the lookup key",
+			},
+
+			"key_version": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The version of the key for which to export the lookup
+key. Defaults to the latest version.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathLookupKeyWrite,
+		},
+
+		HelpSynopsis:    pathLookupKeyHelpSyn,
+		HelpDescription: pathLookupKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathLookupKeyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	ver := d.Get("key_version").(int)
+
+	contextRaw := d.Get("context").(string)
+	if contextRaw == "" {
+		return logical.ErrorResponse("missing context"), logical.ErrInvalidRequest
+	}
+	keyContext, err := base64.StdEncoding.DecodeString(contextRaw)
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode context"), logical.ErrInvalidRequest
+	}
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationExport); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if !p.AllowLookupExport {
+		return logical.ErrorResponse("lookup key export is not allowed for this key"), logical.ErrInvalidRequest
+	}
+
+	if ver == 0 {
+		ver = p.LatestVersion
+	}
+	if ver < p.MinDecryptionVersion {
+		return logical.ErrorResponse("version for export is below minimum decryption version"), logical.ErrInvalidRequest
+	}
+
+	lookupKey, err := p.ConvergentNonceKey(keyContext, ver)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":        p.Name,
+			"key_version": ver,
+			"lookup_key":  base64.StdEncoding.EncodeToString(lookupKey),
+			"token_size":  convergentNonceSize,
+		},
+	}, nil
+}
+
+const pathLookupKeyHelpSyn = `Export the convergent encryption lookup key for a context`
+
+const pathLookupKeyHelpDesc = `
+This path exports the key from which the nonces of convergent encryption are
+derived for the given context and key version. The first 12 bytes of a
+ciphertext, after base64-decoding the part following the version prefix, are
+the HMAC-SHA256 of the plaintext with this key, truncated to 12 bytes.
+
+This allows tokenized data to be joined offline against known values without
+granting decrypt rights, but it also allows offline guessing of plaintexts, so
+the key must have allow_lookup_export set and this path requires sudo
+capability.
+`
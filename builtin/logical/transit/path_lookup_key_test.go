@@ -0,0 +1,153 @@
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_LookupKey(t *testing.T) {
+	for _, keyType := range []string{"aes128-gcm96", "aes256-gcm96", "chacha20-poly1305"} {
+		t.Run(keyType, func(t *testing.T) {
+			testTransitLookupKey(t, keyType)
+		})
+	}
+}
+
+func testTransitLookupKey(t *testing.T, keyType string) {
+	b, storage := createBackendWithSysView(t)
+
+	keyContext := base64.StdEncoding.EncodeToString([]byte("customers"))
+	handle := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+
+	resp, err := handle(logical.UpdateOperation, "keys/foo", map[string]interface{}{
+		"type":                  keyType,
+		"derived":               true,
+		"convergent_encryption": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	// Lookup keys cannot be exported without allow_lookup_export
+	resp, err = handle(logical.UpdateOperation, "lookup-key/foo", map[string]interface{}{
+		"context": keyContext,
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = handle(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"allow_lookup_export": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	resp, err = handle(logical.ReadOperation, "keys/foo", nil)
+	if err != nil || resp.Data["allow_lookup_export"] != true {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	// Context is required
+	_, err = handle(logical.UpdateOperation, "lookup-key/foo", nil)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+
+	resp, err = handle(logical.UpdateOperation, "lookup-key/foo", map[string]interface{}{
+		"context": keyContext,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["key_version"] != 1 || resp.Data["token_size"] != convergentNonceSize {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	lookupKey, err := base64.StdEncoding.DecodeString(resp.Data["lookup_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lookup key computes the token of a plaintext, which is the prefix of
+	// its convergent ciphertext
+	for _, plaintext := range []string{"alice@example.com", "bob@example.com"} {
+		resp, err = handle(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext)),
+			"context":   keyContext,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(resp.Data["ciphertext"].(string), "vault:v1:"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mac := hmac.New(sha256.New, lookupKey)
+		mac.Write([]byte(plaintext))
+		if token := mac.Sum(nil)[:convergentNonceSize]; !bytes.Equal(token, decoded[:convergentNonceSize]) {
+			t.Fatalf("token of %q does not match its ciphertext", plaintext)
+		}
+	}
+
+	// Lookup keys differ by context
+	resp, err = handle(logical.UpdateOperation, "lookup-key/foo", map[string]interface{}{
+		"context": base64.StdEncoding.EncodeToString([]byte("orders")),
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["lookup_key"] == base64.StdEncoding.EncodeToString(lookupKey) {
+		t.Fatal("expected lookup keys to differ by context")
+	}
+
+	// The key version must exist
+	_, err = handle(logical.UpdateOperation, "lookup-key/foo", map[string]interface{}{
+		"context":     keyContext,
+		"key_version": 2,
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+}
+
+func TestTransit_LookupKey_NotConvergent(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"derived": true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo/config",
+		Data: map[string]interface{}{
+			"allow_lookup_export": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v resp: %#v", err, resp)
+	}
+}
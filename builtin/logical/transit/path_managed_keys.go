@@ -0,0 +1,157 @@
+package transit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *backend) pathListManagedKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "managed-keys/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathManagedKeysList,
+		},
+
+		HelpSynopsis:    pathManagedKeysHelpSyn,
+		HelpDescription: pathManagedKeysHelpDesc,
+	}
+}
+
+func (b *backend) pathManagedKeys() *framework.Path {
+	return &framework.Path{
+		Pattern: "managed-keys/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the managed key backend",
+			},
+
+			"type": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The type of HSM or cloud KMS holding the
+managed keys. Currently, "awskms" and "gcpckms"
+are supported.`,
+			},
+
+			"config": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `The configuration of the HSM or cloud KMS,
+such as its credentials.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathManagedKeysWrite,
+			logical.ReadOperation:   b.pathManagedKeysRead,
+			logical.DeleteOperation: b.pathManagedKeysDelete,
+		},
+
+		HelpSynopsis:    pathManagedKeysHelpSyn,
+		HelpDescription: pathManagedKeysHelpDesc,
+	}
+}
+
+func (b *backend) pathManagedKeysList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, managedKeyConfigPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathManagedKeysWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config := &managedKeyConfig{
+		Type:   d.Get("type").(string),
+		Config: d.Get("config").(map[string]string),
+	}
+	if config.Type == "" {
+		return logical.ErrorResponse("missing type"), logical.ErrInvalidRequest
+	}
+
+	// Make sure the configuration is valid before keys use it
+	mkb, err := newManagedKeyBackend(ctx, config, b.Logger())
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid managed key configuration: %s", err)), logical.ErrInvalidRequest
+	}
+	if err := mkb.Close(); err != nil {
+		b.Logger().Warn("failed to close managed key backend", "name", name, "error", err)
+	}
+
+	entry, err := logical.StorageEntryJSON(managedKeyConfigPrefix+name, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.resetManagedKeyBackend(name)
+
+	return nil, nil
+}
+
+func (b *backend) pathManagedKeysRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	config, err := b.managedKeyConfig(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	redacted := make(map[string]string, len(config.Config))
+	for k, v := range config.Config {
+		redacted[k] = v
+	}
+	for _, k := range kmsSensitiveConfig {
+		if _, ok := redacted[k]; ok {
+			redacted[k] = "<redacted>"
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":   name,
+			"type":   config.Type,
+			"config": redacted,
+		},
+	}, nil
+}
+
+func (b *backend) pathManagedKeysDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	keys, err := b.managedKeyUsers(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("managed key backend %q holds keys %s", name, strings.Join(keys, ", "))), logical.ErrInvalidRequest
+	}
+
+	if err := req.Storage.Delete(ctx, managedKeyConfigPrefix+name); err != nil {
+		return nil, err
+	}
+	b.resetManagedKeyBackend(name)
+
+	return nil, nil
+}
+
+const pathManagedKeysHelpSyn = `Manage the HSMs and cloud KMSs holding managed keys`
+
+const pathManagedKeysHelpDesc = `
+This path is used to configure named HSMs or cloud KMSs holding the private
+keys of managed transit keys. Managed keys are created with the
+"managed_key_name" and "managed_key_id" parameters, and their private keys
+never leave the HSM or cloud KMS: transit delegates signing and decryption to
+it. A managed key backend cannot be deleted while it holds keys.
+`
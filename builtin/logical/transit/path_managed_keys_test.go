@@ -0,0 +1,322 @@
+package transit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testManagedKeyBackend holds software keys standing in for the keys of an
+// HSM or a cloud KMS.
+type testManagedKeyBackend struct {
+	keys map[string]crypto.Signer
+}
+
+func (m *testManagedKeyBackend) PublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	key, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("no key %q", keyID)
+	}
+	return key.Public(), nil
+}
+
+func (m *testManagedKeyBackend) Signer(ctx context.Context, keyID string, keyType keysutil.KeyType) (crypto.Signer, error) {
+	key, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("no key %q", keyID)
+	}
+	return key, nil
+}
+
+func (m *testManagedKeyBackend) Close() error {
+	return nil
+}
+
+func TestTransit_ManagedKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mkb := &testManagedKeyBackend{
+		keys: map[string]crypto.Signer{
+			"ec":   ecKey,
+			"rsa1": rsaKey1,
+			"rsa2": rsaKey2,
+		},
+	}
+	managedKeyBackendFactories["test"] = func(ctx context.Context, config map[string]string, logger hclog.Logger) (managedKeyBackend, error) {
+		return mkb, nil
+	}
+	defer delete(managedKeyBackendFactories, "test")
+
+	b, storage := createBackendWithSysView(t)
+
+	doReq := func(t *testing.T, req *logical.Request) *logical.Response {
+		t.Helper()
+		req.Storage = storage
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+		}
+		return resp
+	}
+	doErrReq := func(t *testing.T, req *logical.Request) {
+		t.Helper()
+		req.Storage = storage
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error; resp:\n%#v\n", resp)
+		}
+	}
+
+	// Keys cannot use managed key backends that are not configured
+	doErrReq(t, &logical.Request{
+		Path:      "keys/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type":             "ecdsa-p256",
+			"managed_key_name": "hsm",
+			"managed_key_id":   "ec",
+		},
+	})
+
+	// Unsupported types are rejected
+	doErrReq(t, &logical.Request{
+		Path:      "managed-keys/hsm",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "unknown",
+		},
+	})
+
+	doReq(t, &logical.Request{
+		Path:      "managed-keys/hsm",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "test",
+			"config": map[string]interface{}{
+				"secret_key": "secret",
+			},
+		},
+	})
+
+	resp := doReq(t, &logical.Request{
+		Path:      "managed-keys/hsm",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["config"].(map[string]string)["secret_key"] != "<redacted>" {
+		t.Fatalf("sensitive configuration not redacted: %#v", resp.Data["config"])
+	}
+
+	// Managed keys must be of the type of the external key, and are never
+	// exportable
+	doErrReq(t, &logical.Request{
+		Path:      "keys/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type":             "ecdsa-p384",
+			"managed_key_name": "hsm",
+			"managed_key_id":   "ec",
+		},
+	})
+	doErrReq(t, &logical.Request{
+		Path:      "keys/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type":             "ecdsa-p256",
+			"managed_key_name": "hsm",
+			"managed_key_id":   "ec",
+			"exportable":       true,
+		},
+	})
+
+	doReq(t, &logical.Request{
+		Path:      "keys/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type":             "ecdsa-p256",
+			"managed_key_name": "hsm",
+			"managed_key_id":   "ec",
+		},
+	})
+	resp = doReq(t, &logical.Request{
+		Path:      "keys/signing",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["managed_key_name"] != "hsm" {
+		t.Fatalf("bad managed_key_name: %#v", resp.Data)
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	resp = doReq(t, &logical.Request{
+		Path:      "sign/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"input": input,
+		},
+	})
+	signature := resp.Data["signature"].(string)
+	resp = doReq(t, &logical.Request{
+		Path:      "verify/signing",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"input":     input,
+			"signature": signature,
+		},
+	})
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("signature of managed ECDSA key did not verify")
+	}
+
+	doReq(t, &logical.Request{
+		Path:      "keys/encryption",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type":             "rsa-2048",
+			"managed_key_name": "hsm",
+			"managed_key_id":   "rsa1",
+		},
+	})
+	resp = doReq(t, &logical.Request{
+		Path:      "encrypt/encryption",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"plaintext": input,
+		},
+	})
+	ciphertext1 := resp.Data["ciphertext"].(string)
+
+	for _, sigAlgorithm := range []string{"pss", "pkcs1v15"} {
+		resp = doReq(t, &logical.Request{
+			Path:      "sign/encryption",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"input":               input,
+				"signature_algorithm": sigAlgorithm,
+			},
+		})
+		resp = doReq(t, &logical.Request{
+			Path:      "verify/encryption",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"input":               input,
+				"signature":           resp.Data["signature"].(string),
+				"signature_algorithm": sigAlgorithm,
+			},
+		})
+		if !resp.Data["valid"].(bool) {
+			t.Fatalf("%s signature of managed RSA key did not verify", sigAlgorithm)
+		}
+	}
+
+	// Vault cannot generate external keys, so rotations name the new one
+	doErrReq(t, &logical.Request{
+		Path:      "keys/encryption/rotate",
+		Operation: logical.UpdateOperation,
+	})
+	doErrReq(t, &logical.Request{
+		Path:      "keys/encryption/rotate",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"managed_key_id": "ec",
+		},
+	})
+	doReq(t, &logical.Request{
+		Path:      "keys/encryption/rotate",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"managed_key_id": "rsa2",
+		},
+	})
+	resp = doReq(t, &logical.Request{
+		Path:      "keys/encryption",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["latest_version"].(int) != 2 {
+		t.Fatalf("bad latest_version: %#v", resp.Data["latest_version"])
+	}
+
+	resp = doReq(t, &logical.Request{
+		Path:      "encrypt/encryption",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"plaintext": input,
+		},
+	})
+	ciphertext2 := resp.Data["ciphertext"].(string)
+
+	for _, ciphertext := range []string{ciphertext1, ciphertext2} {
+		resp = doReq(t, &logical.Request{
+			Path:      "decrypt/encryption",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"ciphertext": ciphertext,
+			},
+		})
+		if resp.Data["plaintext"] != input {
+			t.Fatalf("bad plaintext: %#v", resp.Data["plaintext"])
+		}
+	}
+
+	// Managed key material cannot be exported, nor the keys made exportable
+	doErrReq(t, &logical.Request{
+		Path:      "export/encryption-key/encryption",
+		Operation: logical.ReadOperation,
+	})
+	doErrReq(t, &logical.Request{
+		Path:      "keys/encryption/config",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"exportable": true,
+		},
+	})
+
+	// The backend cannot be deleted while it holds keys
+	doErrReq(t, &logical.Request{
+		Path:      "managed-keys/hsm",
+		Operation: logical.DeleteOperation,
+	})
+	for _, name := range []string{"signing", "encryption"} {
+		doReq(t, &logical.Request{
+			Path:      "keys/" + name + "/config",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"deletion_allowed": true,
+			},
+		})
+		doReq(t, &logical.Request{
+			Path:      "keys/" + name,
+			Operation: logical.DeleteOperation,
+		})
+	}
+	doReq(t, &logical.Request{
+		Path:      "managed-keys/hsm",
+		Operation: logical.DeleteOperation,
+	})
+	resp = doReq(t, &logical.Request{
+		Path:      "managed-keys/",
+		Operation: logical.ListOperation,
+	})
+	if keys, _ := resp.Data["keys"].([]string); len(keys) != 0 {
+		t.Fatalf("bad list: %#v", resp.Data)
+	}
+}
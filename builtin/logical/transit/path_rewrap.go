@@ -15,11 +15,11 @@ import (
 
 func (b *backend) pathRewrap() *framework.Path {
 	return &framework.Path{
-		Pattern: "rewrap/" + framework.GenericNameRegex("name"),
+		Pattern: "rewrap/" + keyOrAliasNameRegex("name"),
 		Fields: map[string]*framework.FieldSchema{
 			"name": &framework.FieldSchema{
 				Type:        framework.TypeString,
-				Description: "Name of the key",
+				Description: "Name of the key, or of a key alias in the form <app>/<name>",
 			},
 
 			"ciphertext": &framework.FieldSchema{
@@ -55,9 +55,17 @@ to the min_encryption_version configured on the key.`,
 }
 
 func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name, pinnedVersion, err := resolveKeyName(ctx, req.Storage, d.Get("name").(string))
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
 	batchInputRaw := d.Raw["batch_input"]
 	var batchInputItems []BatchRequestItem
-	var err error
 	if batchInputRaw != nil {
 		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
 		if err != nil {
@@ -95,6 +103,13 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 			continue
 		}
 
+		// Encrypt with the version pinned by the key alias, if any
+		batchInputItems[i].KeyVersion, err = pinKeyVersion(item.KeyVersion, pinnedVersion)
+		if err != nil {
+			batchResponseItems[i].Error = err.Error()
+			continue
+		}
+
 		// Decode the context
 		if len(item.Context) != 0 {
 			batchInputItems[i].DecodedContext, err = base64.StdEncoding.DecodeString(item.Context)
@@ -117,7 +132,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
 		Storage: req.Storage,
-		Name:    d.Get("name").(string),
+		Name:    name,
 	}, b.GetRandomReader())
 	if err != nil {
 		return nil, err
@@ -129,12 +144,19 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationRewrap); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
+	managed := b.managedKeyProvider(ctx, req.Storage, p)
+
 	for i, item := range batchInputItems {
 		if batchResponseItems[i].Error != "" {
 			continue
 		}
 
-		plaintext, err := p.Decrypt(item.DecodedContext, item.DecodedNonce, item.Ciphertext)
+		plaintext, err := p.DecryptManaged(managed, item.DecodedContext, item.DecodedNonce, item.Ciphertext)
 		if err != nil {
 			switch err.(type) {
 			case errutil.UserError:
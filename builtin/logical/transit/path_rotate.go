@@ -2,8 +2,10 @@ package transit
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -16,6 +18,13 @@ func (b *backend) pathRotate() *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Name of the key",
 			},
+
+			"managed_key_id": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `ID of the external key used as new version
+of a managed key. Required for managed keys, as
+Vault cannot generate their private keys.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -44,6 +53,15 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 	if !b.System().CachingDisabled() {
 		p.Lock(true)
 	}
+	if p.Imported && !p.AllowImportedKeyRotation {
+		p.Unlock()
+		return logical.ErrorResponse("rotation is not allowed for this imported key"), logical.ErrInvalidRequest
+	}
+
+	if p.ManagedKeyName != "" {
+		defer p.Unlock()
+		return b.rotateManagedKey(ctx, req.Storage, p, d.Get("managed_key_id").(string))
+	}
 
 	// Rotate the policy
 	err = p.Rotate(ctx, req.Storage, b.GetRandomReader())
@@ -52,10 +70,37 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 	return nil, err
 }
 
+// rotateManagedKey adds the external key with the given ID as new version of
+// the managed key.
+func (b *backend) rotateManagedKey(ctx context.Context, s logical.Storage, p *keysutil.Policy, managedKeyID string) (*logical.Response, error) {
+	if managedKeyID == "" {
+		return logical.ErrorResponse("managed_key_id is required to rotate a managed key"), logical.ErrInvalidRequest
+	}
+
+	pub, err := b.managedKeyPublicKey(ctx, s, p.ManagedKeyName, managedKeyID)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("failed to get the public key of managed key %q: %s", managedKeyID, err)), logical.ErrInvalidRequest
+	}
+
+	err = p.AddManagedKeyVersion(ctx, s, managedKeyID, pub, b.GetRandomReader())
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	default:
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 const pathRotateHelpSyn = `Rotate named encryption key`
 
 const pathRotateHelpDesc = `
 This path is used to rotate the named key. After rotation,
 new encryption requests using this name will use the new key,
 but decryption will still be supported for older versions.
+
+Managed keys are rotated by giving the ID of a new external key
+in "managed_key_id".
 `
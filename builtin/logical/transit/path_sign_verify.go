@@ -261,6 +261,11 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationSign); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
 	if !p.Type.SigningSupported() {
 		p.Unlock()
 		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support signing", p.Type)), logical.ErrInvalidRequest
@@ -289,6 +294,7 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 	}
 
 	response := make([]batchResponseSignItem, len(batchInputItems))
+	managed := b.managedKeyProvider(ctx, req.Storage, p)
 
 	for i, item := range batchInputItems {
 
@@ -323,7 +329,7 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 			}
 		}
 
-		sig, err := p.Sign(ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
+		sig, err := p.SignManaged(managed, ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
 		if err != nil {
 			if batchInputRaw != nil {
 				response[i].Error = err.Error()
@@ -479,6 +485,11 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(false)
 	}
 
+	if resp, err := b.checkKeyUsage(req, p, keysutil.KeyOperationVerify); resp != nil || err != nil {
+		p.Unlock()
+		return resp, err
+	}
+
 	if !p.Type.SigningSupported() {
 		p.Unlock()
 		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support verification", p.Type)), logical.ErrInvalidRequest
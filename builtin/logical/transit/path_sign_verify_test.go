@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"golang.org/x/crypto/ed25519"
 
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
 )
@@ -680,3 +682,79 @@ func TestTransit_SignVerify_ED25519(t *testing.T) {
 	outcome[1].valid = false
 	verifyRequest(req, false, outcome, "bar", goodsig, true)
 }
+
+func TestTransit_SignVerify_MLDSA(t *testing.T) {
+	if !mldsautil.Supported {
+		t.Skip("ML-DSA is not supported by this build")
+	}
+	b, storage := createBackendWithSysView(t)
+
+	// ML-DSA keys cannot be created unless enabled
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/pq",
+		Data: map[string]interface{}{
+			"type": "ml-dsa-65",
+		},
+	}
+	os.Unsetenv(mldsautil.EnvExperimental)
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error creating an ML-DSA key while disabled, got: %#v", resp)
+	}
+
+	os.Setenv(mldsautil.EnvExperimental, "1")
+	defer os.Unsetenv(mldsautil.EnvExperimental)
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	req.Path = "sign/pq"
+	req.Data = map[string]interface{}{
+		"input": input,
+	}
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	signature := resp.Data["signature"].(string)
+
+	req.Path = "verify/pq"
+	req.Data["signature"] = signature
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if !resp.Data["valid"].(bool) {
+		t.Fatal("expected the signature to be valid")
+	}
+
+	req.Data["input"] = base64.StdEncoding.EncodeToString([]byte("another input"))
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["valid"].(bool) {
+		t.Fatal("expected the signature of another input to be invalid")
+	}
+
+	// The public key is returned by the key read
+	req.Operation = logical.ReadOperation
+	req.Path = "keys/pq"
+	req.Data = nil
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	key := resp.Data["keys"].(map[string]map[string]interface{})["1"]
+	pubKey, err := base64.StdEncoding.DecodeString(key["public_key"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key["name"] != "ml-dsa-65" || len(pubKey) != 1952 {
+		t.Fatalf("bad key: %#v", key)
+	}
+}
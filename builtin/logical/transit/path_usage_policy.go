@@ -0,0 +1,183 @@
+package transit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *backend) pathUsagePolicy() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/usage-policy",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"allowed_operations": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `The operations that can be performed with the
+key, among encrypt, decrypt, rewrap, datakey, sign, verify,
+hmac, cmac, derive and export. If empty, all operations
+are allowed.`,
+			},
+
+			"allowed_entity_ids": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `The IDs of the entities allowed to use the key.
+If this and allowed_groups are empty, any entity is
+allowed.`,
+			},
+
+			"allowed_groups": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `The IDs or names of the groups whose members are
+allowed to use the key, including through inherited
+membership.`,
+			},
+
+			"allowed_time_windows": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `The times of day at which the key can be used,
+as HH:MM-HH:MM ranges. A range ending before it starts
+spans midnight. If empty, the key can be used at any time.`,
+			},
+
+			"time_zone": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "UTC",
+				Description: `The IANA time zone of allowed_time_windows.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathUsagePolicyWrite,
+			logical.ReadOperation:   b.pathUsagePolicyRead,
+			logical.DeleteOperation: b.pathUsagePolicyDelete,
+		},
+
+		HelpSynopsis:    pathUsagePolicyHelpSyn,
+		HelpDescription: pathUsagePolicyHelpDesc,
+	}
+}
+
+func (b *backend) pathUsagePolicyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	usagePolicy := &keysutil.KeyUsagePolicy{
+		AllowedOperations:  d.Get("allowed_operations").([]string),
+		AllowedEntityIDs:   d.Get("allowed_entity_ids").([]string),
+		AllowedGroups:      d.Get("allowed_groups").([]string),
+		AllowedTimeWindows: d.Get("allowed_time_windows").([]string),
+		TimeZone:           d.Get("time_zone").(string),
+	}
+	if err := usagePolicy.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return b.updateUsagePolicy(ctx, req, d.Get("name").(string), usagePolicy)
+}
+
+func (b *backend) pathUsagePolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    d.Get("name").(string),
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if p.UsagePolicy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: usagePolicyResponseData(p.UsagePolicy),
+	}, nil
+}
+
+func (b *backend) pathUsagePolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.updateUsagePolicy(ctx, req, d.Get("name").(string), nil)
+}
+
+func (b *backend) updateUsagePolicy(ctx context.Context, req *logical.Request, name string, usagePolicy *keysutil.KeyUsagePolicy) (*logical.Response, error) {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse(
+				fmt.Sprintf("no existing key named %s could be found", name)),
+			logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	original := p.UsagePolicy
+	p.UsagePolicy = usagePolicy
+	if err := p.Persist(ctx, req.Storage); err != nil {
+		p.UsagePolicy = original
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func usagePolicyResponseData(usagePolicy *keysutil.KeyUsagePolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"allowed_operations":   usagePolicy.AllowedOperations,
+		"allowed_entity_ids":   usagePolicy.AllowedEntityIDs,
+		"allowed_groups":       usagePolicy.AllowedGroups,
+		"allowed_time_windows": usagePolicy.AllowedTimeWindows,
+		"time_zone":            usagePolicy.TimeZone,
+	}
+}
+
+// checkKeyUsage returns a permission denied error if the usage policy of the
+// key does not allow the request to perform the operation. The policy must
+// be locked.
+func (b *backend) checkKeyUsage(req *logical.Request, p *keysutil.Policy, operation string) (*logical.Response, error) {
+	if p.UsagePolicy == nil {
+		return nil, nil
+	}
+
+	groups := func() ([]*logical.Group, error) {
+		return b.System().GroupsForEntity(req.EntityID)
+	}
+	err := p.UsagePolicy.Check(operation, req.EntityID, groups, time.Now())
+	switch err.(type) {
+	case nil:
+		return nil, nil
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), logical.ErrPermissionDenied
+	default:
+		return nil, err
+	}
+}
+
+const pathUsagePolicyHelpSyn = `Configure the usage policy of a named key`
+
+const pathUsagePolicyHelpDesc = `
+This path is used to restrict the operations performed with a key, the
+entities and groups allowed to perform them, and the times of day at which
+they can be performed. Usage policies are enforced in addition to the ACL
+policies of the paths using the key, and apply to each key separately.
+Requests without an entity, such as those of root tokens, are denied when
+entities or groups are restricted.
+`
@@ -0,0 +1,156 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTransit_UsagePolicy(t *testing.T) {
+	sysView := logical.TestSystemView()
+	sysView.GroupsVal = []*logical.Group{{ID: "group-id", Name: "payments"}}
+	storage := &logical.InmemStorage{}
+	conf := &logical.BackendConfig{
+		StorageView: storage,
+		System:      sysView,
+	}
+	b, _ := Backend(context.Background(), conf)
+	if b == nil {
+		t.Fatal("failed to create backend")
+	}
+	if err := b.Backend.Setup(context.Background(), conf); err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(t *testing.T, req *logical.Request) (*logical.Response, error) {
+		t.Helper()
+		req.Storage = storage
+		return b.HandleRequest(namespace.RootContext(nil), req)
+	}
+	mustReq := func(t *testing.T, req *logical.Request) *logical.Response {
+		t.Helper()
+		resp, err := doReq(t, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+		}
+		return resp
+	}
+	encrypt := func(t *testing.T, entityID string) (*logical.Response, error) {
+		t.Helper()
+		return doReq(t, &logical.Request{
+			Path:      "encrypt/payments",
+			Operation: logical.UpdateOperation,
+			EntityID:  entityID,
+			Data: map[string]interface{}{
+				"plaintext": base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+			},
+		})
+	}
+
+	mustReq(t, &logical.Request{
+		Path:      "keys/payments",
+		Operation: logical.UpdateOperation,
+	})
+
+	// Invalid usage policies are rejected
+	resp, err := doReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"allowed_operations": "encrypt,delete",
+		},
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got err: %v, resp: %#v", err, resp)
+	}
+
+	mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"allowed_operations": "encrypt",
+			"allowed_entity_ids": "entity-id",
+		},
+	})
+	resp = mustReq(t, &logical.Request{
+		Path:      "keys/payments",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["usage_policy"] == nil {
+		t.Fatalf("usage policy missing from the key: %#v", resp.Data)
+	}
+
+	resp, err = encrypt(t, "entity-id")
+	if err != nil || resp.IsError() {
+		t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+	}
+	ciphertext := resp.Data["ciphertext"]
+
+	// Other entities and requests without an entity are denied
+	for _, entityID := range []string{"other-id", ""} {
+		if _, err := encrypt(t, entityID); err != logical.ErrPermissionDenied {
+			t.Fatalf("expected permission denied for entity %q, got: %v", entityID, err)
+		}
+	}
+
+	// Operations that are not allowed are denied
+	_, err = doReq(t, &logical.Request{
+		Path:      "decrypt/payments",
+		Operation: logical.UpdateOperation,
+		EntityID:  "entity-id",
+		Data: map[string]interface{}{
+			"ciphertext": ciphertext,
+		},
+	})
+	if err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+
+	// Members of the allowed groups can use the key
+	mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"allowed_groups": "payments",
+		},
+	})
+	if resp, err := encrypt(t, "other-id"); err != nil || resp.IsError() {
+		t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+	}
+
+	// Time windows are evaluated in their time zone
+	mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"allowed_time_windows": "00:00-00:01",
+			"time_zone":            "Pacific/Kiritimati",
+		},
+	})
+	resp = mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.ReadOperation,
+	})
+	if resp.Data["time_zone"] != "Pacific/Kiritimati" {
+		t.Fatalf("bad usage policy: %#v", resp.Data)
+	}
+
+	// Deleting the usage policy allows any use again
+	mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.DeleteOperation,
+	})
+	resp = mustReq(t, &logical.Request{
+		Path:      "keys/payments/usage-policy",
+		Operation: logical.ReadOperation,
+	})
+	if resp != nil {
+		t.Fatalf("expected no usage policy, got: %#v", resp.Data)
+	}
+	if resp, err := encrypt(t, ""); err != nil || resp.IsError() {
+		t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+	}
+}
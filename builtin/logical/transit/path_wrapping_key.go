@@ -0,0 +1,94 @@
+package transit
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// wrappingKeyPath is where the RSA key used to wrap the key material of
+// imported keys is stored.
+const wrappingKeyPath = "import/wrapping_key"
+
+// wrappingKeySize is the size in bits of the wrapping key.
+const wrappingKeySize = 4096
+
+func (b *backend) pathWrappingKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "wrapping_key",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathWrappingKeyRead,
+		},
+
+		HelpSynopsis:    pathWrappingKeyHelpSyn,
+		HelpDescription: pathWrappingKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathWrappingKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key, err := b.getWrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling wrapping key: %w", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	})
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pemBytes),
+		},
+	}, nil
+}
+
+// getWrappingKey returns the RSA key used to wrap the key material of
+// imported keys, generating it on first use.
+func (b *backend) getWrappingKey(ctx context.Context, s logical.Storage) (*rsa.PrivateKey, error) {
+	b.wrappingKeyLock.Lock()
+	defer b.wrappingKeyLock.Unlock()
+
+	entry, err := s.Get(ctx, wrappingKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		key, err := x509.ParsePKCS1PrivateKey(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing wrapping key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := rsa.GenerateKey(b.GetRandomReader(), wrappingKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("error generating wrapping key: %w", err)
+	}
+	if err := s.Put(ctx, &logical.StorageEntry{
+		Key:   wrappingKeyPath,
+		Value: x509.MarshalPKCS1PrivateKey(key),
+	}); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+const pathWrappingKeyHelpSyn = `Returns the public key to wrap key material for import`
+
+const pathWrappingKeyHelpDesc = `
+This path returns the PEM-encoded public part of the 4096-bit RSA key used to
+wrap the key material of keys imported through the "keys/<name>/import"
+endpoint. The key is generated on first use.
+`
@@ -222,3 +222,23 @@ func (b *PluginBackend) HandleExistenceCheck(ctx context.Context, req *logical.R
 func (b *PluginBackend) Initialize(ctx context.Context, req *logical.InitializationRequest) error {
 	return nil
 }
+
+// ExportState is a thin wrapper implementation of ExportState that includes
+// automatic plugin reload.
+func (b *PluginBackend) ExportState(ctx context.Context, s logical.Storage) (state *logical.BackendState, err error) {
+	err = b.lazyLoadBackend(ctx, s, func() error {
+		var merr error
+		state, merr = logical.ExportBackendState(ctx, b.Backend, s)
+		return merr
+	})
+
+	return
+}
+
+// ImportState is a thin wrapper implementation of ImportState that includes
+// automatic plugin reload.
+func (b *PluginBackend) ImportState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	return b.lazyLoadBackend(ctx, s, func() error {
+		return logical.ImportBackendState(ctx, b.Backend, s, state)
+	})
+}
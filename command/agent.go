@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/agent/auth"
 	"github.com/hashicorp/vault/command/agent/auth/alicloud"
@@ -36,9 +39,13 @@ import (
 	"github.com/hashicorp/vault/command/agent/sink/inmem"
 	"github.com/hashicorp/vault/command/agent/template"
 	"github.com/hashicorp/vault/command/agent/winsvc"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/internalshared/gatedwriter"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/useragent"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/version"
 	"github.com/kr/pretty"
@@ -64,6 +71,21 @@ type AgentCommand struct {
 
 	startedCh chan (struct{}) // for tests
 
+	metricsHelper  *metricsutil.MetricsHelper
+	authHandler    *auth.AuthHandler
+	templateServer *template.Server
+
+	// reloadCh receives the reloads requested through the API, which are
+	// run by the same goroutine as the reloads triggered by a SIGHUP
+	reloadCh chan chan error
+
+	// listenerHandler builds the handler served on each API listener. It is
+	// only set when caching is enabled, in which case listeners can be added
+	// and removed on reload.
+	listenerHandler func(*configutil.Listener) http.Handler
+	listenerLock    sync.Mutex
+	listeners       []*agentListener
+
 	flagConfigs       []string
 	flagLogLevel      string
 	flagExitAfterAuth bool
@@ -106,7 +128,8 @@ func (c *AgentCommand) Flags() *FlagSets {
 			complete.PredictFiles("*.json"),
 		),
 		Usage: "Path to a configuration file. This configuration file should " +
-			"contain only agent directives.",
+			"contain only agent directives. This can be specified multiple " +
+			"times, in which case the files are merged.",
 	})
 
 	f.StringVar(&StringVar{
@@ -202,15 +225,15 @@ func (c *AgentCommand) Run(args []string) int {
 	}
 
 	// Validation
-	if len(c.flagConfigs) != 1 {
-		c.UI.Error("Must specify exactly one config path using -config")
+	if len(c.flagConfigs) == 0 {
+		c.UI.Error("Must specify at least one config path using -config")
 		return 1
 	}
 
 	// Load the configuration
-	config, err := agentConfig.LoadConfig(c.flagConfigs[0])
+	config, err := agentConfig.LoadConfigs(c.flagConfigs)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err))
+		c.UI.Error(fmt.Sprintf("Error loading configuration: %s", err))
 		return 1
 	}
 
@@ -322,6 +345,20 @@ func (c *AgentCommand) Run(args []string) int {
 		return 0
 	}
 
+	inmemMetrics, _, prometheusEnabled, err := configutil.SetupTelemetry(&configutil.SetupTelemetryOpts{
+		Config:      config.Telemetry,
+		Ui:          c.UI,
+		ServiceName: "vault",
+		DisplayName: "Vault",
+		UserAgent:   useragent.String(),
+		ClusterName: config.ClusterName,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
+		return 1
+	}
+	c.metricsHelper = metricsutil.NewMetricsHelper(inmemMetrics, prometheusEnabled)
+
 	// Ignore any setting of agent's address. This client is used by the agent
 	// to reach out to Vault. This should never loop back to agent.
 	c.flagAgentAddress = ""
@@ -411,6 +448,16 @@ func (c *AgentCommand) Run(args []string) int {
 			c.UI.Error(errwrap.Wrapf(fmt.Sprintf("Error creating %s auth method: {{err}}", config.AutoAuth.Method.Type), err).Error())
 			return 1
 		}
+
+		c.authHandler = auth.NewAuthHandler(&auth.AuthHandlerConfig{
+			Logger:                       c.logger.Named("auth.handler"),
+			Client:                       c.client,
+			WrapTTL:                      config.AutoAuth.Method.WrapTTL,
+			EnableReauthOnNewCredentials: config.AutoAuth.EnableReauthOnNewCredentials,
+			// Templates may be added on reload, so the template server
+			// always needs to know about the current token
+			EnableTemplateTokenCh: true,
+		})
 	}
 
 	// Warn if cache _and_ cert auto-auth is enabled but certificates were not
@@ -440,6 +487,11 @@ func (c *AgentCommand) Run(args []string) int {
 	default:
 	}
 
+	// Reloads may be requested through the API as soon as the listeners are
+	// up, but they are only run once all reloadable components are set up
+	// and the run group is started.
+	c.reloadCh = make(chan chan error)
+
 	// Parse agent listener configurations
 	if config.Cache != nil && len(config.Listeners) != 0 {
 		cacheLogger := c.logger.Named("cache")
@@ -488,16 +540,7 @@ func (c *AgentCommand) Run(args []string) int {
 		// Create the request handler
 		cacheHandler := cache.Handler(ctx, cacheLogger, leaseCache, inmemSink, proxyVaultToken)
 
-		var listeners []net.Listener
-		for i, lnConfig := range config.Listeners {
-			ln, tlsConf, err := cache.StartListener(lnConfig)
-			if err != nil {
-				c.UI.Error(fmt.Sprintf("Error starting listener: %v", err))
-				return 1
-			}
-
-			listeners = append(listeners, ln)
-
+		c.listenerHandler = func(lnConfig *configutil.Listener) http.Handler {
 			// Parse 'require_request_header' listener config option, and wrap
 			// the request handler if necessary
 			muxHandler := cacheHandler
@@ -508,55 +551,61 @@ func (c *AgentCommand) Run(args []string) int {
 			// Create a muxer and add paths relevant for the lease cache layer
 			mux := http.NewServeMux()
 			mux.Handle(consts.AgentPathCacheClear, leaseCache.HandleCacheClear(ctx))
+			mux.Handle(consts.AgentPathMetrics, c.handleMetrics())
+			mux.Handle(consts.AgentPathHealth, c.handleHealth())
+			if lnConfig.AgentAPI != nil && lnConfig.AgentAPI.EnableReload {
+				mux.Handle(consts.AgentPathReload, verifyRequestHeader(c.handleReload()))
+			}
 			mux.Handle("/", muxHandler)
+			return mux
+		}
 
-			scheme := "https://"
-			if tlsConf == nil {
-				scheme = "http://"
-			}
-			if ln.Addr().Network() == "unix" {
-				scheme = "unix://"
+		for i, lnConfig := range config.Listeners {
+			al, err := c.startListener(lnConfig)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error starting listener: %v", err))
+				return 1
 			}
 
+			c.listeners = append(c.listeners, al)
+
 			infoKey := fmt.Sprintf("api address %d", i+1)
-			info[infoKey] = scheme + ln.Addr().String()
+			info[infoKey] = al.address
 			infoKeys = append(infoKeys, infoKey)
-
-			server := &http.Server{
-				Addr:              ln.Addr().String(),
-				TLSConfig:         tlsConf,
-				Handler:           mux,
-				ReadHeaderTimeout: 10 * time.Second,
-				ReadTimeout:       30 * time.Second,
-				IdleTimeout:       5 * time.Minute,
-				ErrorLog:          cacheLogger.StandardLogger(nil),
-			}
-
-			go server.Serve(ln)
 		}
 
 		// Ensure that listeners are closed at all the exits
 		listenerCloseFunc := func() {
-			for _, ln := range listeners {
-				ln.Close()
+			c.listenerLock.Lock()
+			defer c.listenerLock.Unlock()
+			for _, al := range c.listeners {
+				al.ln.Close()
 			}
 		}
 		defer c.cleanupGuard.Do(listenerCloseFunc)
 	}
 
-	// Listen for signals
-	// TODO: implement support for SIGHUP reloading of configuration
-	// signal.Notify(c.signalCh)
-
 	var g run.Group
 
-	// This run group watches for signal termination
+	// This run group watches for signal termination and reloads
 	g.Add(func() error {
 		for {
 			select {
 			case <-c.ShutdownCh:
 				c.UI.Output("==> Vault agent shutdown triggered")
 				return nil
+			case <-c.SighupCh:
+				c.UI.Output("==> Vault agent reload triggered")
+				if err := c.reloadConfig(); err != nil {
+					c.logger.Error("failed to reload configuration", "error", err)
+				}
+			case errCh := <-c.reloadCh:
+				c.logger.Info("reload triggered through the API")
+				err := c.reloadConfig()
+				if err != nil {
+					c.logger.Error("failed to reload configuration", "error", err)
+				}
+				errCh <- err
 			case <-ctx.Done():
 				return nil
 			case <-winsvc.ShutdownChannel():
@@ -567,14 +616,7 @@ func (c *AgentCommand) Run(args []string) int {
 
 	// Start auto-auth and sink servers
 	if method != nil {
-		enableTokenCh := len(config.Templates) > 0
-		ah := auth.NewAuthHandler(&auth.AuthHandlerConfig{
-			Logger:                       c.logger.Named("auth.handler"),
-			Client:                       c.client,
-			WrapTTL:                      config.AutoAuth.Method.WrapTTL,
-			EnableReauthOnNewCredentials: config.AutoAuth.EnableReauthOnNewCredentials,
-			EnableTemplateTokenCh:        enableTokenCh,
-		})
+		ah := c.authHandler
 
 		ss := sink.NewSinkServer(&sink.SinkServerConfig{
 			Logger:        c.logger.Named("sink.server"),
@@ -582,14 +624,16 @@ func (c *AgentCommand) Run(args []string) int {
 			ExitAfterAuth: exitAfterAuth,
 		})
 
-		ts := template.NewServer(&template.ServerConfig{
-			Logger:        c.logger.Named("template.server"),
-			LogLevel:      level,
-			LogWriter:     c.logWriter,
-			VaultConf:     config.Vault,
-			Namespace:     namespace,
-			ExitAfterAuth: exitAfterAuth,
+		c.templateServer = template.NewServer(&template.ServerConfig{
+			Logger:          c.logger.Named("template.server"),
+			LogLevel:        level,
+			LogWriter:       c.logWriter,
+			VaultConf:       config.Vault,
+			Namespace:       namespace,
+			ExitAfterAuth:   exitAfterAuth,
+			TemplateSources: config.TemplateSources,
 		})
+		ts := c.templateServer
 
 		g.Add(func() error {
 			return ah.Run(ctx, method)
@@ -685,6 +729,275 @@ func verifyRequestHeader(handler http.Handler) http.Handler {
 	})
 }
 
+// agentListener is an API listener started by the agent along with the
+// configuration it was started from.
+type agentListener struct {
+	config  *configutil.Listener
+	ln      net.Listener
+	server  *http.Server
+	address string
+}
+
+// startListener starts serving the agent API on the given listener
+// configuration.
+func (c *AgentCommand) startListener(lnConfig *configutil.Listener) (*agentListener, error) {
+	ln, tlsConf, err := cache.StartListener(lnConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "https://"
+	if tlsConf == nil {
+		scheme = "http://"
+	}
+	if ln.Addr().Network() == "unix" {
+		scheme = "unix://"
+	}
+
+	server := &http.Server{
+		Addr:              ln.Addr().String(),
+		TLSConfig:         tlsConf,
+		Handler:           c.listenerHandler(lnConfig),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       5 * time.Minute,
+		ErrorLog:          c.logger.Named("cache").StandardLogger(nil),
+	}
+
+	go server.Serve(ln)
+
+	return &agentListener{
+		config:  lnConfig,
+		ln:      ln,
+		server:  server,
+		address: scheme + ln.Addr().String(),
+	}, nil
+}
+
+// reloadListeners brings the running API listeners in line with the given
+// configurations. Listeners whose configuration is unchanged keep running, so
+// that clients connected to them are not interrupted.
+func (c *AgentCommand) reloadListeners(lnConfigs []*configutil.Listener) error {
+	c.listenerLock.Lock()
+	defer c.listenerLock.Unlock()
+
+	var listeners []*agentListener
+	var added []*configutil.Listener
+	removed := make([]*agentListener, len(c.listeners))
+	copy(removed, c.listeners)
+
+	for _, lnConfig := range lnConfigs {
+		found := false
+		for i, al := range removed {
+			if al != nil && reflect.DeepEqual(al.config, lnConfig) {
+				listeners = append(listeners, al)
+				removed[i] = nil
+				found = true
+				break
+			}
+		}
+		if !found {
+			added = append(added, lnConfig)
+		}
+	}
+
+	// Removed listeners are closed first, since a changed listener may bind
+	// the same address as before.
+	for _, al := range removed {
+		if al == nil {
+			continue
+		}
+		c.logger.Info("stopping listener", "address", al.address)
+		al.server.Close()
+	}
+
+	var retErr *multierror.Error
+	for _, lnConfig := range added {
+		al, err := c.startListener(lnConfig)
+		if err != nil {
+			retErr = multierror.Append(retErr, errwrap.Wrapf(fmt.Sprintf("error starting listener on %q: {{err}}", lnConfig.Address), err))
+			continue
+		}
+		c.logger.Info("started listener", "address", al.address)
+		listeners = append(listeners, al)
+	}
+
+	c.listeners = listeners
+
+	return retErr.ErrorOrNil()
+}
+
+// reloadConfig reads the configuration files again and applies the changes
+// that can be made without restarting the agent: templates and listeners.
+// Auto-auth keeps running throughout, so no new login is performed. Changes
+// to any other part of the configuration require a restart. It is only called
+// by the goroutine watching for reloads.
+func (c *AgentCommand) reloadConfig() error {
+	config, err := agentConfig.LoadConfigs(c.flagConfigs)
+	if err != nil {
+		return err
+	}
+
+	var retErr *multierror.Error
+
+	switch {
+	case c.listenerHandler != nil:
+		if err := c.reloadListeners(config.Listeners); err != nil {
+			retErr = multierror.Append(retErr, err)
+		}
+	case len(config.Listeners) > 0:
+		c.logger.Warn("listeners can only be reloaded when caching was enabled at startup")
+	}
+
+	switch {
+	case c.templateServer != nil:
+		c.templateServer.Reload(config.Templates)
+	case len(config.Templates) > 0:
+		c.logger.Warn("templates can only be reloaded when auto-auth was enabled at startup")
+	}
+
+	return retErr.ErrorOrNil()
+}
+
+// handleReload reloads the agent's configuration, the same way a SIGHUP
+// does. It is only served by the listeners whose agent_api stanza enables it.
+func (c *AgentCommand) handleReload() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			logical.RespondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		errCh := make(chan error, 1)
+		select {
+		case c.reloadCh <- errCh:
+		case <-r.Context().Done():
+			return
+		}
+
+		select {
+		case err := <-errCh:
+			if err != nil {
+				logical.RespondError(w, http.StatusInternalServerError, err)
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// handleMetrics serves the agent's own telemetry, in the same formats as the
+// server's sys/metrics endpoint.
+func (c *AgentCommand) handleMetrics() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			logical.RespondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			logical.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		format := r.Form.Get("format")
+		if format == "" {
+			format = metricsutil.FormatFromRequest(&logical.Request{
+				Headers: r.Header,
+			})
+		}
+
+		resp := c.metricsHelper.ResponseForFormat(format)
+
+		status := resp.Data[logical.HTTPStatusCode].(int)
+		w.Header().Set("Content-Type", resp.Data[logical.HTTPContentType].(string))
+		switch v := resp.Data[logical.HTTPRawBody].(type) {
+		case string:
+			w.WriteHeader(status)
+			w.Write([]byte(v))
+		case []byte:
+			w.WriteHeader(status)
+			w.Write(v)
+		default:
+			logical.RespondError(w, http.StatusInternalServerError, fmt.Errorf("wrong response returned"))
+		}
+	})
+}
+
+// handleHealth reports whether the agent is able to serve requests on behalf
+// of its clients. When auto-auth is configured the agent is only healthy while
+// it holds a token which has not expired, and as long as its last attempt to
+// authenticate or to renew the token did not fail. The optional
+// "min_token_ttl" query parameter additionally marks the agent unhealthy when
+// its token is about to expire, so that monitoring can catch agents before
+// they lose access.
+func (c *AgentCommand) handleHealth() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			logical.RespondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			logical.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var minTTL time.Duration
+		if raw := r.Form.Get("min_token_ttl"); raw != "" {
+			var err error
+			minTTL, err = parseutil.ParseDurationSecond(raw)
+			if err != nil {
+				logical.RespondError(w, http.StatusBadRequest, fmt.Errorf("invalid min_token_ttl: %w", err))
+				return
+			}
+		}
+
+		healthy := true
+		body := map[string]interface{}{
+			"version": version.GetVersion().VersionNumber(),
+		}
+
+		if c.authHandler != nil {
+			ttl, ok := c.authHandler.TokenTTL()
+			lastErr := c.authHandler.LastError()
+			switch {
+			case !ok:
+				healthy = false
+			case lastErr != nil:
+				healthy = false
+			case ttl > 0 && ttl < minTTL:
+				healthy = false
+			}
+			autoAuth := map[string]interface{}{
+				"authenticated": ok,
+				"token_ttl":     int64(ttl.Seconds()),
+			}
+			if lastErr != nil {
+				autoAuth["last_error"] = lastErr.Error()
+			}
+			body["auto_auth"] = autoAuth
+		}
+		body["healthy"] = healthy
+
+		status := http.StatusOK
+		if !healthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if r.Method == http.MethodHead {
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.Encode(body)
+	})
+}
+
 func (c *AgentCommand) setStringFlag(f *FlagSets, configVal string, fVar *StringVar) {
 	var isFlagSet bool
 	f.Visit(func(f *flag.Flag) {
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"math/rand"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 )
 
+// tokenTTLGaugeInterval is how often the remaining lifetime of the auto-auth
+// token is reported while it is being renewed
+const tokenTTLGaugeInterval = 10 * time.Second
+
 // AuthMethod is the interface that auto-auth methods implement for the agent
 // to use.
 type AuthMethod interface {
@@ -48,6 +54,13 @@ type AuthHandler struct {
 	wrapTTL                      time.Duration
 	enableReauthOnNewCredentials bool
 	enableTemplateTokenCh        bool
+
+	// tokenLock protects the state of the current auto-auth token, which is
+	// reported by the agent's health endpoint
+	tokenLock       sync.RWMutex
+	hasToken        bool
+	tokenExpiration time.Time
+	lastErr         error
 }
 
 type AuthHandlerConfig struct {
@@ -75,6 +88,65 @@ func NewAuthHandler(conf *AuthHandlerConfig) *AuthHandler {
 	return ah
 }
 
+// TokenTTL returns the time remaining before the current auto-auth token
+// expires, and whether the handler holds a token which has not expired. A
+// token that does not expire is reported with a zero TTL.
+func (ah *AuthHandler) TokenTTL() (time.Duration, bool) {
+	ah.tokenLock.RLock()
+	defer ah.tokenLock.RUnlock()
+
+	if !ah.hasToken {
+		return 0, false
+	}
+	if ah.tokenExpiration.IsZero() {
+		return 0, true
+	}
+
+	ttl := time.Until(ah.tokenExpiration)
+	if ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// LastError returns the error of the last attempt to authenticate or to renew
+// the token, if it failed. It is cleared once a token is obtained or renewed.
+func (ah *AuthHandler) LastError() error {
+	ah.tokenLock.RLock()
+	defer ah.tokenLock.RUnlock()
+
+	return ah.lastErr
+}
+
+// setTokenTTL records the lifetime of a newly obtained or renewed token
+func (ah *AuthHandler) setTokenTTL(ttl time.Duration) {
+	ah.tokenLock.Lock()
+	defer ah.tokenLock.Unlock()
+
+	ah.hasToken = true
+	ah.lastErr = nil
+	ah.tokenExpiration = time.Time{}
+	if ttl > 0 {
+		ah.tokenExpiration = time.Now().Add(ttl)
+	}
+}
+
+// setLastError records the failure of an attempt to authenticate or to renew
+// the token
+func (ah *AuthHandler) setLastError(err error) {
+	ah.tokenLock.Lock()
+	defer ah.tokenLock.Unlock()
+
+	ah.lastErr = err
+}
+
+// emitTokenTTL reports the remaining lifetime of the current token
+func (ah *AuthHandler) emitTokenTTL() {
+	if ttl, ok := ah.TokenTTL(); ok {
+		metrics.SetGauge([]string{"agent", "auth", "token", "ttl"}, float32(ttl.Seconds()))
+	}
+}
+
 func backoffOrQuit(ctx context.Context, backoff time.Duration) {
 	select {
 	case <-time.After(backoff):
@@ -133,6 +205,8 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 		path, header, data, err := am.Authenticate(ctx, ah.client)
 		if err != nil {
 			ah.logger.Error("error getting path or data from method", "error", err, "backoff", backoff.Seconds())
+			metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
+			ah.setLastError(err)
 			backoffOrQuit(ctx, backoff)
 			continue
 		}
@@ -144,6 +218,7 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 			clientToUse, err = am.(AuthMethodWithClient).AuthClient(ah.client)
 			if err != nil {
 				ah.logger.Error("error creating client for authentication call", "error", err, "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
@@ -155,6 +230,7 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 			wrapClient, err := clientToUse.Clone()
 			if err != nil {
 				ah.logger.Error("error creating client for wrapped call", "error", err, "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
@@ -173,6 +249,8 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 		// Check errors/sanity
 		if err != nil {
 			ah.logger.Error("error authenticating", "error", err, "backoff", backoff.Seconds())
+			metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
+			ah.setLastError(err)
 			backoffOrQuit(ctx, backoff)
 			continue
 		}
@@ -181,21 +259,27 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 		case ah.wrapTTL > 0:
 			if secret.WrapInfo == nil {
 				ah.logger.Error("authentication returned nil wrap info", "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
 			if secret.WrapInfo.Token == "" {
 				ah.logger.Error("authentication returned empty wrapped client token", "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
 			wrappedResp, err := jsonutil.EncodeJSON(secret.WrapInfo)
 			if err != nil {
 				ah.logger.Error("failed to encode wrapinfo", "error", err, "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
 			ah.logger.Info("authentication successful, sending wrapped token to sinks and pausing")
+			metrics.IncrCounter([]string{"agent", "auth", "success"}, 1)
+			ah.setTokenTTL(time.Duration(secret.WrapInfo.TTL) * time.Second)
+			ah.emitTokenTTL()
 			ah.OutputCh <- string(wrappedResp)
 			if ah.enableTemplateTokenCh {
 				ah.TemplateTokenCh <- string(wrappedResp)
@@ -216,15 +300,20 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 		default:
 			if secret == nil || secret.Auth == nil {
 				ah.logger.Error("authentication returned nil auth info", "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
 			if secret.Auth.ClientToken == "" {
 				ah.logger.Error("authentication returned empty client token", "backoff", backoff.Seconds())
+				metrics.IncrCounter([]string{"agent", "auth", "failure"}, 1)
 				backoffOrQuit(ctx, backoff)
 				continue
 			}
 			ah.logger.Info("authentication successful, sending token to sinks")
+			metrics.IncrCounter([]string{"agent", "auth", "success"}, 1)
+			ah.setTokenTTL(time.Duration(secret.Auth.LeaseDuration) * time.Second)
+			ah.emitTokenTTL()
 			ah.OutputCh <- secret.Auth.ClientToken
 			if ah.enableTemplateTokenCh {
 				ah.TemplateTokenCh <- secret.Auth.ClientToken
@@ -250,6 +339,8 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 		ah.logger.Info("starting renewal process")
 		go watcher.Renew()
 
+		ttlTicker := time.NewTicker(tokenTTLGaugeInterval)
+
 	LifetimeWatcherLoop:
 		for {
 			select {
@@ -262,16 +353,26 @@ func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) error {
 				ah.logger.Info("lifetime watcher done channel triggered")
 				if err != nil {
 					ah.logger.Error("error renewing token", "error", err)
+					ah.setLastError(err)
 				}
 				break LifetimeWatcherLoop
 
-			case <-watcher.RenewCh():
+			case renewal := <-watcher.RenewCh():
 				ah.logger.Info("renewed auth token")
+				if renewal != nil && renewal.Secret != nil && renewal.Secret.Auth != nil {
+					ah.setTokenTTL(time.Duration(renewal.Secret.Auth.LeaseDuration) * time.Second)
+					ah.emitTokenTTL()
+				}
+
+			case <-ttlTicker.C:
+				ah.emitTokenTTL()
 
 			case <-credCh:
 				ah.logger.Info("auth method found new credentials, re-authenticating")
 				break LifetimeWatcherLoop
 			}
 		}
+
+		ttlTicker.Stop()
 	}
 }
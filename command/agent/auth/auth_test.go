@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -54,6 +55,46 @@ func (u *userpassTestMethod) CredSuccess() {
 func (u *userpassTestMethod) Shutdown() {
 }
 
+func TestAuthHandler_TokenStatus(t *testing.T) {
+	ah := NewAuthHandler(&AuthHandlerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+	})
+
+	if _, ok := ah.TokenTTL(); ok {
+		t.Fatal("expected no token before authenticating")
+	}
+
+	ah.setTokenTTL(time.Hour)
+	if ttl, ok := ah.TokenTTL(); !ok || ttl <= 0 {
+		t.Fatalf("expected a valid token, got %s %t", ttl, ok)
+	}
+
+	// A token which does not expire has no TTL
+	ah.setTokenTTL(0)
+	if ttl, ok := ah.TokenTTL(); !ok || ttl != 0 {
+		t.Fatalf("expected a token without TTL, got %s %t", ttl, ok)
+	}
+
+	// An expired token is not reported as valid
+	ah.setTokenTTL(time.Hour)
+	ah.tokenLock.Lock()
+	ah.tokenExpiration = time.Now().Add(-time.Second)
+	ah.tokenLock.Unlock()
+	if _, ok := ah.TokenTTL(); ok {
+		t.Fatal("expected the expired token not to be valid")
+	}
+
+	// Failures are reported until a token is obtained or renewed
+	ah.setLastError(errors.New("permission denied"))
+	if err := ah.LastError(); err == nil || err.Error() != "permission denied" {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	ah.setTokenTTL(time.Hour)
+	if err := ah.LastError(); err != nil {
+		t.Fatalf("expected the last error to be cleared, got %v", err)
+	}
+}
+
 func TestAuthHandler(t *testing.T) {
 	logger := logging.NewVaultLogger(hclog.Trace)
 	coreConfig := &vault.CoreConfig{
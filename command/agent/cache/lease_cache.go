@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/api"
@@ -184,6 +185,7 @@ func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse,
 	}
 	if sendResp != nil {
 		c.logger.Debug("returning cached response", "path", req.Request.URL.Path)
+		metrics.IncrCounter([]string{"agent", "cache", "hit"}, 1)
 		return sendResp, nil
 	}
 
@@ -203,9 +205,11 @@ func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse,
 	// will be the one performing the cache write.
 	if sendResp != nil {
 		c.logger.Debug("returning cached response", "method", req.Request.Method, "path", req.Request.URL.Path)
+		metrics.IncrCounter([]string{"agent", "cache", "hit"}, 1)
 		return sendResp, nil
 	}
 
+	metrics.IncrCounter([]string{"agent", "cache", "miss"}, 1)
 	c.logger.Debug("forwarding request", "method", req.Request.Method, "path", req.Request.URL.Path)
 
 	// Pass the request down and get a response
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -28,6 +29,8 @@ type Config struct {
 	Cache         *Cache                     `hcl:"cache"`
 	Vault         *Vault                     `hcl:"vault"`
 	Templates     []*ctconfig.TemplateConfig `hcl:"templates"`
+
+	TemplateSources *TemplateSources `hcl:"template_sources"`
 }
 
 // Vault contains configuration for connecting to Vault servers
@@ -49,6 +52,14 @@ type Cache struct {
 	ForceAutoAuthToken  bool        `hcl:"-"`
 }
 
+// TemplateSources is the allowlist of the local files and commands whose
+// output templates can merge with Vault secrets, through the file and plugin
+// template functions. If set, templates can't read or run anything else.
+type TemplateSources struct {
+	AllowedFiles    []string `hcl:"allowed_files"`
+	AllowedCommands []string `hcl:"allowed_commands"`
+}
+
 // AutoAuth is the configured authentication method and sinks
 type AutoAuth struct {
 	Method *Method `hcl:"-"`
@@ -91,6 +102,89 @@ func NewConfig() *Config {
 // LoadConfig loads the configuration at the given path, regardless if
 // its a file or directory.
 func LoadConfig(path string) (*Config, error) {
+	result, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadConfigs loads and merges the configuration files at the given paths.
+// Listeners and templates are combined, while the other stanzas can only be
+// defined by one of the files. The merged configuration is validated as a
+// whole, so that a file may for instance only hold templates.
+func LoadConfigs(paths []string) (*Config, error) {
+	var result *Config
+	for _, path := range paths {
+		config, err := loadConfigFile(path)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error loading configuration from %s: {{err}}", path), err)
+		}
+		if result == nil {
+			result = config
+			continue
+		}
+		if result, err = result.merge(config); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error merging configuration from %s: {{err}}", path), err)
+		}
+	}
+	if result == nil {
+		return nil, errors.New("no configuration path given")
+	}
+	if err := result.validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// merge returns the configuration of c and c2 combined.
+func (c *Config) merge(c2 *Config) (*Config, error) {
+	result := &Config{
+		SharedConfig:    c.SharedConfig.Merge(c2.SharedConfig),
+		AutoAuth:        c.AutoAuth,
+		ExitAfterAuth:   c.ExitAfterAuth || c2.ExitAfterAuth,
+		Cache:           c.Cache,
+		Vault:           c.Vault,
+		TemplateSources: c.TemplateSources,
+	}
+	result.Templates = append(result.Templates, c.Templates...)
+	result.Templates = append(result.Templates, c2.Templates...)
+
+	var errs *multierror.Error
+	if c2.AutoAuth != nil {
+		if result.AutoAuth != nil {
+			errs = multierror.Append(errs, errors.New("auto_auth is defined more than once"))
+		}
+		result.AutoAuth = c2.AutoAuth
+	}
+	if c2.Cache != nil {
+		if result.Cache != nil {
+			errs = multierror.Append(errs, errors.New("cache is defined more than once"))
+		}
+		result.Cache = c2.Cache
+	}
+	if c2.Vault != nil {
+		if result.Vault != nil {
+			errs = multierror.Append(errs, errors.New("vault is defined more than once"))
+		}
+		result.Vault = c2.Vault
+	}
+	if c2.TemplateSources != nil {
+		if result.TemplateSources != nil {
+			errs = multierror.Append(errs, errors.New("template_sources is defined more than once"))
+		}
+		result.TemplateSources = c2.TemplateSources
+	}
+
+	return result, errs.ErrorOrNil()
+}
+
+// loadConfigFile parses the configuration file at the given path, without
+// validating it.
+func loadConfigFile(path string) (*Config, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
@@ -141,35 +235,79 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, errwrap.Wrapf("error parsing 'template': {{err}}", err)
 	}
 
-	if result.Cache != nil {
-		if len(result.Listeners) < 1 {
-			return nil, fmt.Errorf("at least one listener required when cache enabled")
+	if err := parseTemplateSources(result, list); err != nil {
+		return nil, errwrap.Wrapf("error parsing 'template_sources': {{err}}", err)
+	}
+
+	err = parseVault(result, list)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing 'vault':{{err}}", err)
+	}
+
+	return result, nil
+}
+
+// validate checks the consistency of the stanzas of the configuration.
+func (c *Config) validate() error {
+	if c.Cache != nil {
+		if len(c.Listeners) < 1 {
+			return fmt.Errorf("at least one listener required when cache enabled")
 		}
 
-		if result.Cache.UseAutoAuthToken {
-			if result.AutoAuth == nil {
-				return nil, fmt.Errorf("cache.use_auto_auth_token is true but auto_auth not configured")
+		if c.Cache.UseAutoAuthToken {
+			if c.AutoAuth == nil {
+				return fmt.Errorf("cache.use_auto_auth_token is true but auto_auth not configured")
 			}
-			if result.AutoAuth.Method.WrapTTL > 0 {
-				return nil, fmt.Errorf("cache.use_auto_auth_token is true and auto_auth uses wrapping")
+			if c.AutoAuth.Method.WrapTTL > 0 {
+				return fmt.Errorf("cache.use_auto_auth_token is true and auto_auth uses wrapping")
 			}
 		}
 	}
 
-	if result.AutoAuth != nil {
-		if len(result.AutoAuth.Sinks) == 0 &&
-			(result.Cache == nil || !result.Cache.UseAutoAuthToken) &&
-			len(result.Templates) == 0 {
-			return nil, fmt.Errorf("auto_auth requires at least one sink or at least one template or cache.use_auto_auth_token=true")
+	if c.AutoAuth != nil {
+		if len(c.AutoAuth.Sinks) == 0 &&
+			(c.Cache == nil || !c.Cache.UseAutoAuthToken) &&
+			len(c.Templates) == 0 {
+			return fmt.Errorf("auto_auth requires at least one sink or at least one template or cache.use_auto_auth_token=true")
 		}
 	}
 
-	err = parseVault(result, list)
+	return nil
+}
+
+func parseTemplateSources(result *Config, list *ast.ObjectList) error {
+	name := "template_sources"
+
+	sourcesList := list.Filter(name)
+	if len(sourcesList.Items) == 0 {
+		return nil
+	}
+
+	if len(sourcesList.Items) > 1 {
+		return fmt.Errorf("one and only one %q block is required", name)
+	}
+
+	item := sourcesList.Items[0]
+
+	var ts TemplateSources
+	err := hcl.DecodeObject(&ts, item.Val)
 	if err != nil {
-		return nil, errwrap.Wrapf("error parsing 'vault':{{err}}", err)
+		return err
 	}
 
-	return result, nil
+	// Templates must refer to the files and commands by the same absolute
+	// paths, so that relative paths can't resolve to something else
+	for _, paths := range [][]string{ts.AllowedFiles, ts.AllowedCommands} {
+		for i, path := range paths {
+			if !filepath.IsAbs(path) {
+				return fmt.Errorf("path %q is not absolute", path)
+			}
+			paths[i] = filepath.Clean(path)
+		}
+	}
+
+	result.TemplateSources = &ts
+	return nil
 }
 
 func parseVault(result *Config, list *ast.ObjectList) error {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -642,3 +643,60 @@ func TestLoadConfigFile_Template_NoSinks(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigFile_TemplateSources(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-template-sources.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &TemplateSources{
+		AllowedFiles:    []string{"/etc/vault-agent/machine.json"},
+		AllowedCommands: []string{"/usr/local/bin/hostinfo"},
+	}
+	if diff := deep.Equal(config.TemplateSources, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLoadConfigFile_Bad_TemplateSources_Relative(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-template-sources-relative.hcl")
+	if err == nil {
+		t.Fatal("LoadConfig should return an error when template_sources has a relative path")
+	}
+}
+
+func TestLoadConfigs(t *testing.T) {
+	// Files are validated once merged: the cache stanza requires a listener
+	// and the auto_auth stanza requires a sink, a template or the cache
+	if _, err := LoadConfig("./test-fixtures/config-split-auto_auth.hcl"); err == nil {
+		t.Fatal("expected the file alone to be invalid")
+	}
+
+	config, err := LoadConfigs([]string{
+		"./test-fixtures/config-split-auto_auth.hcl",
+		"./test-fixtures/config-split-listener.hcl",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config.PidFile != "./pidfile" || config.AutoAuth == nil || config.Cache == nil || !config.Cache.UseAutoAuthToken {
+		t.Fatalf("bad config: %#v", config)
+	}
+	if len(config.Listeners) != 1 || config.Listeners[0].AgentAPI == nil || !config.Listeners[0].AgentAPI.EnableReload {
+		t.Fatalf("bad listeners: %#v", config.Listeners)
+	}
+	if len(config.Templates) != 1 {
+		t.Fatalf("bad templates: %#v", config.Templates)
+	}
+
+	// Stanzas other than listeners and templates can only be defined once
+	_, err = LoadConfigs([]string{
+		"./test-fixtures/config-split-auto_auth.hcl",
+		"./test-fixtures/config-split-listener.hcl",
+		"./test-fixtures/config-split-auto_auth.hcl",
+	})
+	if err == nil || !strings.Contains(err.Error(), "auto_auth is defined more than once") {
+		t.Fatalf("expected a merge error, got: %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+pid_file = "./pidfile"
+
+auto_auth {
+  method {
+    type = "aws"
+
+    config = {
+      role = "foobar"
+    }
+  }
+}
+
+cache {
+  use_auto_auth_token = true
+}
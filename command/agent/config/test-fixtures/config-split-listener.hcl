@@ -0,0 +1,13 @@
+listener "tcp" {
+  address     = "127.0.0.1:8300"
+  tls_disable = true
+
+  agent_api {
+    enable_reload = true
+  }
+}
+
+template {
+  source      = "/path/on/disk/to/template.ctmpl"
+  destination = "/path/on/disk/where/template/will/render.txt"
+}
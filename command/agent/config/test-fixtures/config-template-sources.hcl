@@ -0,0 +1,22 @@
+pid_file = "./pidfile"
+
+auto_auth {
+  method {
+    type      = "aws"
+    namespace = "/my-namespace"
+
+    config = {
+      role = "foobar"
+    }
+  }
+}
+
+template {
+  source      = "/path/on/disk/to/template.ctmpl"
+  destination = "/path/on/disk/where/template/will/render.txt"
+}
+
+template_sources {
+  allowed_files    = ["/etc/vault-agent/machine.json"]
+  allowed_commands = ["/usr/local/bin/../bin/hostinfo"]
+}
@@ -0,0 +1,183 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+	"text/template/parse"
+
+	ctconfig "github.com/hashicorp/consul-template/config"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+)
+
+// consulTemplateFuncs are the names of the functions provided by Consul
+// Template, which must be known to parse templates. It mirrors the funcMap of
+// the vendored Consul Template.
+var consulTemplateFuncs = []string{
+	"datacenters", "file", "key", "keyExists", "keyOrDefault", "ls", "safeLs",
+	"node", "nodes", "secret", "secrets", "service", "connect", "services",
+	"tree", "safeTree", "caRoots", "caLeaf", "scratch", "base64Decode",
+	"base64Encode", "base64URLDecode", "base64URLEncode", "byKey", "byTag",
+	"contains", "containsAll", "containsAny", "containsNone", "containsNotAll",
+	"env", "executeTemplate", "explode", "explodeMap", "in", "indent", "loop",
+	"join", "trimSpace", "parseBool", "parseFloat", "parseInt", "parseJSON",
+	"parseUint", "parseYAML", "plugin", "regexReplaceAll", "regexMatch",
+	"replaceAll", "sha256Hex", "timestamp", "toLower", "toJSON", "toJSONPretty",
+	"toTitle", "toTOML", "toUpper", "toYAML", "split", "byMeta", "sockaddr",
+	"add", "subtract", "multiply", "divide", "modulo", "minimum", "maximum",
+}
+
+// validateTemplateSources checks that the templates only read the local files
+// and run the commands allowed by sources, through the file and plugin
+// functions. Those must be called with a literal path so that it can be
+// checked before rendering. Without sources, templates are not restricted.
+func validateTemplateSources(sources *config.TemplateSources, templates []*ctconfig.TemplateConfig) error {
+	if sources == nil {
+		return nil
+	}
+
+	funcs := make(template.FuncMap, len(consulTemplateFuncs))
+	for _, name := range consulTemplateFuncs {
+		funcs[name] = func(...interface{}) (interface{}, error) { return nil, nil }
+	}
+
+	for _, tc := range templates {
+		var name, contents string
+		switch {
+		case tc.Contents != nil && *tc.Contents != "":
+			name, contents = "contents", *tc.Contents
+		case tc.Source != nil && *tc.Source != "":
+			name = *tc.Source
+			raw, err := ioutil.ReadFile(name)
+			if err != nil {
+				return fmt.Errorf("failed reading template %q: %w", name, err)
+			}
+			contents = string(raw)
+		default:
+			continue
+		}
+
+		var leftDelim, rightDelim string
+		if tc.LeftDelim != nil {
+			leftDelim = *tc.LeftDelim
+		}
+		if tc.RightDelim != nil {
+			rightDelim = *tc.RightDelim
+		}
+
+		tmpl, err := template.New(name).Delims(leftDelim, rightDelim).Funcs(funcs).Parse(contents)
+		if err != nil {
+			return fmt.Errorf("failed parsing template %q: %w", name, err)
+		}
+
+		for _, t := range tmpl.Templates() {
+			if t.Tree == nil {
+				continue
+			}
+			if err := checkTemplateSources(sources, t.Tree.Root); err != nil {
+				return fmt.Errorf("template %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkTemplateSources walks the parsed template looking for calls to the
+// file and plugin functions.
+func checkTemplateSources(sources *config.TemplateSources, node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateSources(sources, child); err != nil {
+				return err
+			}
+		}
+
+	case *parse.ActionNode:
+		return checkTemplateSources(sources, n.Pipe)
+
+	case *parse.IfNode:
+		return checkBranchSources(sources, &n.BranchNode)
+
+	case *parse.RangeNode:
+		return checkBranchSources(sources, &n.BranchNode)
+
+	case *parse.WithNode:
+		return checkBranchSources(sources, &n.BranchNode)
+
+	case *parse.TemplateNode:
+		return checkTemplateSources(sources, n.Pipe)
+
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkTemplateSources(sources, cmd); err != nil {
+				return err
+			}
+		}
+
+	case *parse.ChainNode:
+		return checkTemplateSources(sources, n.Node)
+
+	case *parse.CommandNode:
+		args := n.Args
+		if ident, ok := args[0].(*parse.IdentifierNode); ok {
+			if allowed, restricted := restrictedSources(sources, ident.Ident); restricted {
+				if len(args) < 2 {
+					return fmt.Errorf("%s must be called with a literal path", ident.Ident)
+				}
+				path, ok := args[1].(*parse.StringNode)
+				if !ok {
+					return fmt.Errorf("%s must be called with a literal path", ident.Ident)
+				}
+				if !strutil.StrListContains(allowed, filepath.Clean(path.Text)) {
+					return fmt.Errorf("%s %q is not allowed by template_sources", ident.Ident, path.Text)
+				}
+				args = args[2:]
+			}
+		}
+		for _, arg := range args {
+			if err := checkTemplateSources(sources, arg); err != nil {
+				return err
+			}
+		}
+
+	case *parse.IdentifierNode:
+		// A restricted function used other than as the first word of a
+		// command, e.g. at the end of a pipeline, can't be checked
+		if _, restricted := restrictedSources(sources, n.Ident); restricted {
+			return fmt.Errorf("%s must be called with a literal path", n.Ident)
+		}
+	}
+
+	return nil
+}
+
+func checkBranchSources(sources *config.TemplateSources, n *parse.BranchNode) error {
+	for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+		if err := checkTemplateSources(sources, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restrictedSources returns the allowlist of the template function, and
+// whether the function is restricted at all.
+func restrictedSources(sources *config.TemplateSources, function string) ([]string, bool) {
+	switch function {
+	case "file":
+		return sources.AllowedFiles, true
+	case "plugin":
+		return sources.AllowedCommands, true
+	}
+	return nil, false
+}
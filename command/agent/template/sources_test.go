@@ -0,0 +1,102 @@
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ctconfig "github.com/hashicorp/consul-template/config"
+	"github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/sdk/helper/pointerutil"
+)
+
+func TestValidateTemplateSources(t *testing.T) {
+	sources := &config.TemplateSources{
+		AllowedFiles:    []string{"/etc/vault-agent/machine.json"},
+		AllowedCommands: []string{"/usr/local/bin/hostinfo"},
+	}
+
+	testCases := map[string]struct {
+		contents string
+		valid    bool
+	}{
+		"vault only": {
+			contents: `{{ with secret "kv/myapp/config" }}{{ .Data.password }}{{ end }}`,
+			valid:    true,
+		},
+		"allowed file": {
+			contents: `{{ with secret "kv/myapp/config" }}{{ .Data.password }}{{ end }}
+{{ with file "/etc/vault-agent/machine.json" | parseJSON }}{{ .region }}{{ end }}`,
+			valid: true,
+		},
+		"allowed command": {
+			contents: `{{ if true }}{{ (plugin "/usr/local/bin/hostinfo" "--json" | parseJSON).hostname }}{{ end }}`,
+			valid:    true,
+		},
+		"uncleaned path": {
+			contents: `{{ file "/etc/vault-agent/../vault-agent/machine.json" }}`,
+			valid:    true,
+		},
+		"other file": {
+			contents: `{{ file "/etc/shadow" }}`,
+		},
+		"other command": {
+			contents: `{{ range $i := loop 3 }}{{ plugin "/bin/sh" "-c" "id" }}{{ end }}`,
+		},
+		"dynamic path": {
+			contents: `{{ file (printf "/etc/%s" "shadow") }}`,
+		},
+		"piped path": {
+			contents: `{{ "/etc/vault-agent/machine.json" | file }}`,
+		},
+		"defined template": {
+			contents: `{{ define "x" }}{{ plugin "/bin/sh" }}{{ end }}{{ executeTemplate "x" }}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateTemplateSources(sources, []*ctconfig.TemplateConfig{
+				{Contents: pointerutil.StringPtr(tc.contents)},
+			})
+			if tc.valid && err != nil {
+				t.Fatalf("expected template to be valid, got: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatal("expected template to be rejected")
+			}
+
+			// Templates aren't restricted without template_sources
+			if err := validateTemplateSources(nil, []*ctconfig.TemplateConfig{
+				{Contents: pointerutil.StringPtr(tc.contents)},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestValidateTemplateSources_SourceFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "agent-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	source := filepath.Join(tmpDir, "template.ctmpl")
+	if err := ioutil.WriteFile(source, []byte(`<< file "/etc/shadow" >>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = validateTemplateSources(&config.TemplateSources{}, []*ctconfig.TemplateConfig{
+		{
+			Source:     pointerutil.StringPtr(source),
+			LeftDelim:  pointerutil.StringPtr("<<"),
+			RightDelim: pointerutil.StringPtr(">>"),
+		},
+	})
+	if err == nil {
+		t.Fatal("expected template to be rejected")
+	}
+}
@@ -11,9 +11,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"go.uber.org/atomic"
 
+	"github.com/armon/go-metrics"
 	ctconfig "github.com/hashicorp/consul-template/config"
 	ctlogging "github.com/hashicorp/consul-template/logging"
 	"github.com/hashicorp/consul-template/manager"
@@ -32,6 +34,10 @@ type ServerConfig struct {
 
 	Namespace string
 
+	// TemplateSources, if set, restricts the local files and commands that
+	// templates can read and run
+	TemplateSources *config.TemplateSources
+
 	// LogLevel is needed to set the internal Consul Template Runner's log level
 	// to match the log level of Vault Agent. The internal Runner creates it's own
 	// logger and can't be set externally or copied from the Template Server.
@@ -63,6 +69,9 @@ type Server struct {
 	DoneCh  chan struct{}
 	stopped *atomic.Bool
 
+	// reloadCh receives the new set of templates on reload
+	reloadCh chan []*ctconfig.TemplateConfig
+
 	logger        hclog.Logger
 	exitAfterAuth bool
 
@@ -76,6 +85,7 @@ func NewServer(conf *ServerConfig) *Server {
 	ts := Server{
 		DoneCh:        make(chan struct{}),
 		stopped:       atomic.NewBool(false),
+		reloadCh:      make(chan []*ctconfig.TemplateConfig),
 		runnerStarted: atomic.NewBool(false),
 
 		logger:        conf.Logger,
@@ -100,50 +110,70 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 		ts.logger.Info("template server stopped")
 	}()
 
-	// If there are no templates, we wait for context cancellation and then return
+	// If there are no templates there is nothing to render until templates
+	// are added by a reload, but we keep track of the latest token so that
+	// they can be rendered right away.
+	var runnerConfig *ctconfig.Config
 	if len(templates) == 0 {
 		ts.logger.Info("no templates found")
-		<-ctx.Done()
-		return nil
-	}
-
-	// construct a consul template vault config based the agents vault
-	// configuration
-	var runnerConfig *ctconfig.Config
-	var runnerConfigErr error
-	if runnerConfig, runnerConfigErr = newRunnerConfig(ts.config, templates); runnerConfigErr != nil {
-		return fmt.Errorf("template server failed to runner generate config: %w", runnerConfigErr)
+	} else {
+		var err error
+		if runnerConfig, err = ts.loadTemplates(templates); err != nil {
+			return err
+		}
 	}
 
-	var err error
-	ts.runner, err = manager.NewRunner(runnerConfig, false)
-	if err != nil {
-		return fmt.Errorf("template server failed to create: %w", err)
-	}
+	// lastRendered tracks the last time each template was written so that
+	// render events are only counted once
+	lastRendered := make(map[string]time.Time)
 
-	// Build the lookup map using the id mapping from the Template runner. This is
-	// used to check the template rendering against the expected templates. This
-	// returns a map with a generated ID and a slice of templates for that id. The
-	// slice is determined by the source or contents of the template, so if a
-	// configuration has multiple templates specified, but are the same source /
-	// contents, they will be identified by the same key.
-	idMap := ts.runner.TemplateConfigMapping()
-	lookupMap := make(map[string][]*ctconfig.TemplateConfig, len(idMap))
-	for id, ctmpls := range idMap {
-		for _, ctmpl := range ctmpls {
-			tl := lookupMap[id]
-			tl = append(tl, ctmpl)
-			lookupMap[id] = tl
+	for {
+		// Without a runner these channels are nil and never selected
+		var runnerErrCh chan error
+		var renderedCh <-chan struct{}
+		if ts.runner != nil {
+			runnerErrCh = ts.runner.ErrCh
+			renderedCh = ts.runner.TemplateRenderedCh()
 		}
-	}
-	ts.lookupMap = lookupMap
 
-	for {
 		select {
 		case <-ctx.Done():
-			ts.runner.Stop()
+			if ts.runner != nil {
+				ts.runner.Stop()
+			}
 			return nil
 
+		case templates := <-ts.reloadCh:
+			ts.logger.Info("template server reloading templates")
+
+			if ts.runner != nil {
+				ts.runner.Stop()
+			}
+			ts.runner = nil
+			ts.lookupMap = nil
+			runnerConfig = nil
+
+			if len(templates) == 0 {
+				ts.logger.Info("no templates found")
+				continue
+			}
+
+			var err error
+			if runnerConfig, err = ts.loadTemplates(templates); err != nil {
+				ts.logger.Error("template server failed to reload templates", "error", err)
+				metrics.IncrCounter([]string{"agent", "template", "render", "failure"}, 1)
+				continue
+			}
+
+			// Render the new templates with the token we already hold
+			if *latestToken != "" {
+				var runnerErr error
+				if runnerConfig, runnerErr = ts.startRunner(runnerConfig, latestToken); runnerErr != nil {
+					ts.logger.Error("template server failed to start with reloaded templates", "error", runnerErr)
+					metrics.IncrCounter([]string{"agent", "template", "render", "failure"}, 1)
+				}
+			}
+
 		case token := <-incoming:
 			if token != *latestToken {
 				ts.logger.Info("template server received new token")
@@ -156,39 +186,39 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 					continue
 				}
 
-				ts.runner.Stop()
-				*latestToken = token
-				ctv := ctconfig.Config{
-					Vault: &ctconfig.VaultConfig{
-						Token: latestToken,
-					},
+				if ts.runner != nil {
+					ts.runner.Stop()
 				}
+				*latestToken = token
 
-				// If we're testing, limit retries to 3 attempts to avoid
-				// long test runs from exponential back-offs
-				if ts.testingLimitRetry != 0 {
-					ctv.Vault.Retry = &ctconfig.RetryConfig{Attempts: &ts.testingLimitRetry}
+				if runnerConfig == nil {
+					continue
 				}
 
-				runnerConfig = runnerConfig.Merge(&ctv)
 				var runnerErr error
-				ts.runner, runnerErr = manager.NewRunner(runnerConfig, false)
-				if runnerErr != nil {
+				if runnerConfig, runnerErr = ts.startRunner(runnerConfig, latestToken); runnerErr != nil {
 					ts.logger.Error("template server failed with new Vault token", "error", runnerErr)
+					metrics.IncrCounter([]string{"agent", "template", "render", "failure"}, 1)
 					continue
 				}
-				ts.runnerStarted.CAS(false, true)
-				go ts.runner.Start()
 			}
 
-		case err := <-ts.runner.ErrCh:
+		case err := <-runnerErrCh:
+			metrics.IncrCounter([]string{"agent", "template", "render", "failure"}, 1)
 			ts.runner.StopImmediately()
 			return fmt.Errorf("template server: %w", err)
 
-		case <-ts.runner.TemplateRenderedCh():
+		case <-renderedCh:
 			// A template has been rendered, figure out what to do
 			events := ts.runner.RenderEvents()
 
+			for id, event := range events {
+				if event.DidRender && event.LastDidRender.After(lastRendered[id]) {
+					lastRendered[id] = event.LastDidRender
+					metrics.IncrCounter([]string{"agent", "template", "render", "success"}, 1)
+				}
+			}
+
 			// events are keyed by template ID, and can be matched up to the id's from
 			// the lookupMap
 			if len(events) < len(ts.lookupMap) {
@@ -216,6 +246,86 @@ func (ts *Server) Run(ctx context.Context, incoming chan string, templates []*ct
 	}
 }
 
+// Reload replaces the set of templates rendered by the server. The templates
+// are rendered with the token the server already holds, so no new
+// authentication is needed.
+func (ts *Server) Reload(templates []*ctconfig.TemplateConfig) {
+	select {
+	case ts.reloadCh <- templates:
+	case <-ts.DoneCh:
+	}
+}
+
+// loadTemplates creates a runner for the given templates and builds the lookup
+// map used to check that all of them have been rendered. The runner is not
+// started. It returns the runner configuration so that new runners can be
+// created whenever the token changes.
+func (ts *Server) loadTemplates(templates []*ctconfig.TemplateConfig) (*ctconfig.Config, error) {
+	if err := validateTemplateSources(ts.config.TemplateSources, templates); err != nil {
+		return nil, fmt.Errorf("template server failed to validate template sources: %w", err)
+	}
+
+	// construct a consul template vault config based the agents vault
+	// configuration
+	runnerConfig, err := newRunnerConfig(ts.config, templates)
+	if err != nil {
+		return nil, fmt.Errorf("template server failed to runner generate config: %w", err)
+	}
+
+	runner, err := manager.NewRunner(runnerConfig, false)
+	if err != nil {
+		return nil, fmt.Errorf("template server failed to create: %w", err)
+	}
+
+	// Build the lookup map using the id mapping from the Template runner. This is
+	// used to check the template rendering against the expected templates. This
+	// returns a map with a generated ID and a slice of templates for that id. The
+	// slice is determined by the source or contents of the template, so if a
+	// configuration has multiple templates specified, but are the same source /
+	// contents, they will be identified by the same key.
+	idMap := runner.TemplateConfigMapping()
+	lookupMap := make(map[string][]*ctconfig.TemplateConfig, len(idMap))
+	for id, ctmpls := range idMap {
+		for _, ctmpl := range ctmpls {
+			tl := lookupMap[id]
+			tl = append(tl, ctmpl)
+			lookupMap[id] = tl
+		}
+	}
+
+	ts.runner = runner
+	ts.lookupMap = lookupMap
+
+	return runnerConfig, nil
+}
+
+// startRunner creates and starts a new runner that authenticates to Vault
+// with the given token. The returned configuration includes the token.
+func (ts *Server) startRunner(runnerConfig *ctconfig.Config, token *string) (*ctconfig.Config, error) {
+	ctv := ctconfig.Config{
+		Vault: &ctconfig.VaultConfig{
+			Token: token,
+		},
+	}
+
+	// If we're testing, limit retries to 3 attempts to avoid
+	// long test runs from exponential back-offs
+	if ts.testingLimitRetry != 0 {
+		ctv.Vault.Retry = &ctconfig.RetryConfig{Attempts: &ts.testingLimitRetry}
+	}
+
+	runnerConfig = runnerConfig.Merge(&ctv)
+	var err error
+	ts.runner, err = manager.NewRunner(runnerConfig, false)
+	if err != nil {
+		return runnerConfig, err
+	}
+	ts.runnerStarted.CAS(false, true)
+	go ts.runner.Start()
+
+	return runnerConfig, nil
+}
+
 func (ts *Server) Stop() {
 	if ts.stopped.CAS(false, true) {
 		close(ts.DoneCh)
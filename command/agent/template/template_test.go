@@ -226,6 +226,72 @@ func TestServerRun(t *testing.T) {
 	}
 }
 
+// TestServerRun_Reload verifies that templates added on reload are rendered
+// with the token the server already holds.
+func TestServerRun_Reload(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/myapp/config", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, jsonResponse)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	tmpDir, err := ioutil.TempDir("", "agent-tests")
+	defer os.RemoveAll(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	sc := ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		VaultConf: &config.Vault{
+			Address: ts.URL,
+		},
+		LogLevel:  hclog.Trace,
+		LogWriter: hclog.DefaultOutput,
+	}
+	server := NewServer(&sc)
+	server.testingLimitRetry = 3
+
+	templateTokenCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx, templateTokenCh, nil)
+	}()
+
+	// The token is only sent once, before any template exists
+	templateTokenCh <- "test"
+
+	dstFile := fmt.Sprintf("%s/render_01", tmpDir)
+	server.Reload([]*ctconfig.TemplateConfig{
+		&ctconfig.TemplateConfig{
+			Contents:    pointerutil.StringPtr(templateContents),
+			Destination: pointerutil.StringPtr(dstFile),
+		},
+	})
+
+	for {
+		if _, err := os.Stat(dstFile); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("timeout reached before reloaded template was rendered")
+		case err := <-errCh:
+			t.Fatalf("template server stopped unexpectedly: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("did not expect error, got: %v", err)
+	}
+}
+
 var jsonResponse = `
 {
   "request_id": "8af096e9-518c-7351-eff5-5ba20554b21f",
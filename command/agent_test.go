@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -14,12 +15,14 @@ import (
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
-	vaultjwt "github.com/hashicorp/vault-plugin-auth-jwt"
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/api"
 	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	vaultjwt "github.com/hashicorp/vault/builtin/credential/jwt"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 	"github.com/hashicorp/vault/command/agent"
+	"github.com/hashicorp/vault/command/agent/auth"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -922,6 +925,135 @@ auto_auth {
 	}
 }
 
+func TestAgent_HandleHealth(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	_, cmd := testAgentCommand(t, logger)
+
+	check := func(target string, expectedStatus int, expectedHealthy bool) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		cmd.handleHealth().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != expectedStatus {
+			t.Fatalf("expected status %d, got %d: %s", expectedStatus, rec.Code, rec.Body.String())
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body["healthy"] != expectedHealthy {
+			t.Fatalf("expected healthy to be %t, got %v", expectedHealthy, body["healthy"])
+		}
+	}
+
+	// Without auto-auth there is nothing that can be unhealthy
+	check(consts.AgentPathHealth, http.StatusOK, true)
+
+	// An auth handler that has not yet authenticated is not healthy
+	cmd.authHandler = auth.NewAuthHandler(&auth.AuthHandlerConfig{
+		Logger: logger,
+	})
+	check(consts.AgentPathHealth, http.StatusServiceUnavailable, false)
+
+	rec := httptest.NewRecorder()
+	cmd.handleHealth().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, consts.AgentPathHealth+"?min_token_ttl=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestAgent_HandleReload(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	_, cmd := testAgentCommand(t, logger)
+	cmd.flagConfigs = []string{"./agent/config/test-fixtures/config-cache-no-auto_auth.hcl"}
+	cmd.reloadCh = make(chan chan error)
+	handler := verifyRequestHeader(cmd.handleReload())
+
+	// Reloads are run by the goroutine watching for them
+	go func() {
+		for errCh := range cmd.reloadCh {
+			errCh <- cmd.reloadConfig()
+		}
+	}()
+	defer close(cmd.reloadCh)
+
+	reload := func(withHeader bool) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, consts.AgentPathReload, nil)
+		if withHeader {
+			req.Header.Set(consts.RequestHeaderName, "true")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := reload(false); code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d", http.StatusPreconditionFailed, code)
+	}
+	if code := reload(true); code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+	}
+
+	// Every configuration file is reloaded
+	cmd.flagConfigs = append(cmd.flagConfigs, "./agent/config/test-fixtures/missing.hcl")
+	if code := reload(true); code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, code)
+	}
+}
+
+func TestAgent_ReloadListeners(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace)
+	_, cmd := testAgentCommand(t, logger)
+	cmd.listenerHandler = func(*configutil.Listener) http.Handler {
+		return http.NotFoundHandler()
+	}
+	defer func() {
+		for _, al := range cmd.listeners {
+			al.ln.Close()
+		}
+	}()
+
+	first := &configutil.Listener{Type: "tcp", Address: "127.0.0.1:0", TLSDisable: true}
+	second := &configutil.Listener{Type: "tcp", Address: "127.0.0.1:0", TLSDisable: true, RequireRequestHeader: true}
+
+	if err := cmd.reloadListeners([]*configutil.Listener{first}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmd.listeners) != 1 {
+		t.Fatalf("expected 1 listener, got %d", len(cmd.listeners))
+	}
+	orig := cmd.listeners[0]
+
+	// Unchanged listeners are kept as they are
+	if err := cmd.reloadListeners([]*configutil.Listener{first, second}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmd.listeners) != 2 {
+		t.Fatalf("expected 2 listeners, got %d", len(cmd.listeners))
+	}
+	if cmd.listeners[0] != orig {
+		t.Fatal("expected unchanged listener to be kept")
+	}
+
+	// Removed listeners stop serving
+	if err := cmd.reloadListeners([]*configutil.Listener{second}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cmd.listeners) != 1 {
+		t.Fatalf("expected 1 listener, got %d", len(cmd.listeners))
+	}
+	if _, err := http.Get("http://" + orig.ln.Addr().String()); err == nil {
+		t.Fatal("expected removed listener to be closed")
+	}
+	resp, err := http.Get("http://" + cmd.listeners[0].ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
 func testListFiles(t *testing.T, dir, extension string) int {
 	t.Helper()
 
@@ -0,0 +1,260 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*AuditVerifyCommand)(nil)
+var _ cli.CommandAutocomplete = (*AuditVerifyCommand)(nil)
+
+type AuditVerifyCommand struct {
+	*BaseCommand
+
+	flagValues []string
+	flagSalt   string
+
+	testStdin io.Reader // For tests
+}
+
+// auditMatch is a hashed field of an audit log entry matching a candidate
+// value.
+type auditMatch struct {
+	Line  int    `json:"line"`
+	Time  string `json:"time"`
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+func (c *AuditVerifyCommand) Synopsis() string {
+	return "Verifies hashed audit log fields against candidate values"
+}
+
+func (c *AuditVerifyCommand) Help() string {
+	helpText := `
+Usage: vault audit verify [options] PATH FILE
+
+  Verifies whether the hashed fields of the audit log FILE, written by the
+  audit device enabled at PATH, match any of the candidate values given with
+  -value. If FILE is "-", the audit log is read from stdin.
+
+  The values are hashed with the salt of the audit device, which is read from
+  Vault and requires sudo capability on "sys/audit-salt/PATH", unless it is
+  given with -salt. The comparison is done locally, so the audit log never
+  leaves the machine.
+
+  Find the entries of the file audit device in which a token appears:
+
+      $ vault audit verify -value=s.abcd1234 file/ /var/log/vault_audit.log
+
+  Check several values against an audit log read from stdin:
+
+      $ zcat audit.log.gz | vault audit verify -value=foo -value=bar file/ -
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditVerifyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "value",
+		Target:     &c.flagValues,
+		Completion: complete.PredictAnything,
+		Usage: "Candidate value to look for in the hashed fields of the audit " +
+			"log. This can be specified multiple times.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "salt",
+		Target:     &c.flagSalt,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "Salt of the audit device. If not given, it is read from the " +
+			"\"sys/audit-salt\" endpoint of Vault.",
+	})
+
+	return set
+}
+
+func (c *AuditVerifyCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultAudits()
+}
+
+func (c *AuditVerifyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditVerifyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	if len(c.flagValues) == 0 {
+		c.UI.Error("At least one -value must be specified")
+		return 1
+	}
+
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+	file := strings.TrimSpace(args[1])
+
+	// Audit devices always hash with HMAC-SHA256
+	saltValue, hmacType := c.flagSalt, "hmac-sha256"
+	if saltValue == "" {
+		client, err := c.Client()
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+
+		auditSalt, err := client.Sys().AuditSalt(path)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading salt of audit device %s: %s", path, err))
+			return 2
+		}
+		saltValue, hmacType = auditSalt.Salt, auditSalt.HMACType
+	}
+
+	hashes := make(map[string]string, len(c.flagValues))
+	for _, value := range c.flagValues {
+		hashes[salt.HMACIdentifiedValue(saltValue, value, hmacType, sha256.New)] = value
+	}
+
+	var r io.Reader
+	switch file {
+	case "-":
+		r = os.Stdin
+		if c.testStdin != nil {
+			r = c.testStdin
+		}
+	default:
+		fd, err := os.Open(file)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error opening audit log: %s", err))
+			return 2
+		}
+		defer fd.Close()
+		r = fd
+	}
+
+	matches, err := verifyAuditLog(r, hashes)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading audit log: %s", err))
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		if len(matches) == 0 {
+			c.UI.Output("No hashed field matches the given values.")
+			return 0
+		}
+
+		out := []string{"Line | Time | Type | Field | Value"}
+		for _, m := range matches {
+			out = append(out, fmt.Sprintf("%d | %s | %s | %s | %s", m.Line, m.Time, m.Type, m.Field, m.Value))
+		}
+		c.UI.Output(tableOutput(out, nil))
+		return 0
+	default:
+		if matches == nil {
+			matches = []auditMatch{}
+		}
+		return OutputData(c.UI, matches)
+	}
+}
+
+// verifyAuditLog returns the hashed fields of the JSON audit log entries read
+// from r which match the given hashes, in order.
+func verifyAuditLog(r io.Reader, hashes map[string]string) ([]auditMatch, error) {
+	var matches []auditMatch
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if raw = bytes.TrimSpace(raw); len(raw) > 0 {
+			var entry map[string]interface{}
+			if jsonErr := json.Unmarshal(raw, &entry); jsonErr != nil {
+				return nil, fmt.Errorf("line %d is not a JSON audit log entry: %w", line, jsonErr)
+			}
+
+			entryTime, _ := entry["time"].(string)
+			entryType, _ := entry["type"].(string)
+			walkAuditEntry("", entry, func(field, value string) {
+				if candidate, ok := hashes[value]; ok {
+					matches = append(matches, auditMatch{
+						Line:  line,
+						Time:  entryTime,
+						Type:  entryType,
+						Field: field,
+						Value: candidate,
+					})
+				}
+			})
+		}
+
+		if err == io.EOF {
+			return matches, nil
+		}
+	}
+}
+
+// walkAuditEntry calls fn with the path and value of every string in the
+// entry, visiting the keys of the objects in order.
+func walkAuditEntry(path string, v interface{}, fn func(field, value string)) {
+	switch v := v.(type) {
+	case string:
+		fn(path, v)
+	case []interface{}:
+		for i, item := range v {
+			walkAuditEntry(fmt.Sprintf("%s[%d]", path, i), item, fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			walkAuditEntry(field, v[k], fn)
+		}
+	}
+}
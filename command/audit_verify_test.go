@@ -0,0 +1,185 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testAuditVerifyCommand(tb testing.TB) (*cli.MockUi, *AuditVerifyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditVerifyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestAuditVerifyCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-value=foo", "file/"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-value=foo", "file/", "audit.log", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_values",
+			[]string{"file/", "audit.log"},
+			"At least one -value must be specified",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testAuditVerifyCommand(t)
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		dir, err := ioutil.TempDir("", "vault-audit-verify")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		logPath := filepath.Join(dir, "audit.log")
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().EnableAuditWithOptions("file", &api.EnableAuditOptions{
+			Type: "file",
+			Options: map[string]string{
+				"file_path": logPath,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Logical().Write("secret/verify", map[string]interface{}{
+			"password": "correct-horse-battery-staple",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-value=correct-horse-battery-staple",
+			"-value=not-in-the-logs",
+			"file/", logPath,
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "request.data.password") {
+			t.Errorf("expected %q to contain the hashed field", combined)
+		}
+		if strings.Contains(combined, "not-in-the-logs") {
+			t.Errorf("expected %q to only contain matching values", combined)
+		}
+
+		// The salt can be given to verify logs offline
+		auditSalt, err := client.Sys().AuditSalt("file/")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd = testAuditVerifyCommand(t)
+		cmd.client, _ = api.NewClient(nil)
+		cmd.client.SetAddress("http://127.0.0.1:0")
+		cmd.testStdin = strings.NewReader(readFileString(t, logPath))
+
+		code = cmd.Run([]string{
+			"-salt=" + auditSalt.Salt,
+			"-value=" + client.Token(),
+			"file/", "-",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined = ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "auth.client_token") {
+			t.Errorf("expected %q to contain the hashed client token", combined)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-value=foo", "file/", "audit.log"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error reading salt of audit device file/: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditVerifyCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func readFileString(tb testing.TB, path string) string {
+	tb.Helper()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return string(b)
+}
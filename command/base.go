@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/command/token"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/mitchellh/cli"
@@ -44,6 +45,7 @@ type BaseCommand struct {
 	flagCAPath         string
 	flagClientCert     string
 	flagClientKey      string
+	flagContext        string
 	flagNamespace      string
 	flagNS             string
 	flagPolicyOverride bool
@@ -70,6 +72,10 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		return c.client, nil
 	}
 
+	if err := c.applyContext(); err != nil {
+		return nil, err
+	}
+
 	config := api.DefaultConfig()
 
 	if err := config.ReadEnvironment(); err != nil {
@@ -174,6 +180,14 @@ func (c *BaseCommand) TokenHelper() (token.TokenHelper, error) {
 		return c.tokenHelper, nil
 	}
 
+	name, vaultContext, _, err := c.resolveContext()
+	if err != nil {
+		return nil, err
+	}
+	if vaultContext != nil {
+		return config.ContextTokenHelper(name, vaultContext)
+	}
+
 	helper, err := DefaultTokenHelper()
 	if err != nil {
 		return nil, err
@@ -181,6 +195,95 @@ func (c *BaseCommand) TokenHelper() (token.TokenHelper, error) {
 	return helper, nil
 }
 
+// resolveContext returns the name and settings of the context selected for
+// the command, and whether it was selected explicitly with -context or
+// VAULT_CONTEXT rather than with "vault context use". The returned context is
+// nil if none is selected.
+func (c *BaseCommand) resolveContext() (string, *config.Context, bool, error) {
+	name := c.flagContext
+	explicit := name != ""
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		return "", nil, false, err
+	}
+	if !explicit {
+		name = contexts.CurrentContext
+	}
+	if name == "" {
+		return "", nil, false, nil
+	}
+
+	vaultContext, ok := contexts.Contexts[name]
+	if !ok {
+		return "", nil, false, fmt.Errorf("context %q does not exist", name)
+	}
+	return name, vaultContext, explicit, nil
+}
+
+// applyContext sets the connection flags of the command that were not given
+// on the command line to the settings of the selected context. The settings
+// of a context selected with "vault context use" do not override environment
+// variables; those of a context selected with -context or VAULT_CONTEXT do.
+func (c *BaseCommand) applyContext() error {
+	_, vaultContext, explicit, err := c.resolveContext()
+	if err != nil {
+		return err
+	}
+	if vaultContext == nil {
+		return nil
+	}
+
+	setFlags := make(map[string]bool)
+	if c.flags != nil {
+		c.flags.Visit(func(f *flag.Flag) {
+			setFlags[f.Name] = true
+		})
+	}
+	apply := func(value string, envVar string, flagNames ...string) bool {
+		if value == "" {
+			return false
+		}
+		for _, name := range flagNames {
+			if setFlags[name] {
+				return false
+			}
+		}
+		if _, ok := os.LookupEnv(envVar); ok && !explicit {
+			return false
+		}
+		return true
+	}
+
+	if apply(vaultContext.Address, api.EnvVaultAddress, flagNameAddress) {
+		c.flagAddress = vaultContext.Address
+	}
+	if apply(vaultContext.Namespace, api.EnvVaultNamespace, "namespace", "ns") {
+		c.flagNamespace = vaultContext.Namespace
+		c.flagNS = notSetValue
+	}
+	if apply(vaultContext.CACert, api.EnvVaultCACert, flagNameCACert) {
+		c.flagCACert = vaultContext.CACert
+	}
+	if apply(vaultContext.CAPath, api.EnvVaultCAPath, flagNameCAPath) {
+		c.flagCAPath = vaultContext.CAPath
+	}
+	if apply(vaultContext.ClientCert, api.EnvVaultClientCert, flagNameClientCert) {
+		c.flagClientCert = vaultContext.ClientCert
+	}
+	if apply(vaultContext.ClientKey, api.EnvVaultClientKey, flagNameClientKey) {
+		c.flagClientKey = vaultContext.ClientKey
+	}
+	if apply(vaultContext.TLSServerName, api.EnvVaultTLSServerName, flagTLSServerName) {
+		c.flagTLSServerName = vaultContext.TLSServerName
+	}
+	if vaultContext.TLSSkipVerify && apply("true", api.EnvVaultSkipVerify, flagNameTLSSkipVerify) {
+		c.flagTLSSkipVerify = true
+	}
+
+	return nil
+}
+
 // DefaultWrappingLookupFunc is the default wrapping function based on the
 // CLI flag.
 func (c *BaseCommand) DefaultWrappingLookupFunc(operation, path string) string {
@@ -228,6 +331,18 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 			}
 			f.StringVar(addrStringVar)
 
+			f.StringVar(&StringVar{
+				Name:       "context",
+				Target:     &c.flagContext,
+				Default:    "",
+				EnvVar:     config.ContextEnv,
+				Completion: c.PredictVaultContexts(),
+				Usage: "Name of the context, as managed by \"vault context\", " +
+					"providing the address, namespace, TLS settings and token " +
+					"helper to use. This overrides the current context and the " +
+					"corresponding environment variables.",
+			})
+
 			agentAddrStringVar := &StringVar{
 				Name:       "agent-address",
 				Target:     &c.flagAgentAddress,
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/posener/complete"
 )
@@ -152,6 +153,18 @@ func (b *BaseCommand) PredictVaultAuths() complete.Predictor {
 	return NewPredict().VaultAuths()
 }
 
+// PredictVaultContexts returns a predictor for the names of the contexts
+// managed by "vault context".
+func (b *BaseCommand) PredictVaultContexts() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		contexts, err := config.LoadContexts("")
+		if err != nil {
+			return nil
+		}
+		return contexts.Names()
+	})
+}
+
 // PredictVaultPlugins returns a predictor for installed plugins.
 func (b *BaseCommand) PredictVaultPlugins(pluginTypes ...consts.PluginType) complete.Predictor {
 	return NewPredict().VaultPlugins(pluginTypes...)
@@ -389,12 +389,17 @@ func TestPredict_Plugins(t *testing.T) {
 				"postgresql-database-plugin",
 				"rabbitmq",
 				"radius",
+				"redis-database-plugin",
 				"redshift-database-plugin",
+				"share",
+				"snowflake-database-plugin",
 				"ssh",
 				"totp",
 				"transform",
 				"transit",
+				"trino-database-plugin",
 				"userpass",
+				"yugabytedb-database-plugin",
 			},
 		},
 	}
@@ -27,19 +27,19 @@ import (
 	credCentrify "github.com/hashicorp/vault-plugin-auth-centrify"
 	credCF "github.com/hashicorp/vault-plugin-auth-cf"
 	credGcp "github.com/hashicorp/vault-plugin-auth-gcp/plugin"
-	credOIDC "github.com/hashicorp/vault-plugin-auth-jwt"
 	credKerb "github.com/hashicorp/vault-plugin-auth-kerberos"
 	credOCI "github.com/hashicorp/vault-plugin-auth-oci"
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
+	credOIDC "github.com/hashicorp/vault/builtin/credential/jwt"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credToken "github.com/hashicorp/vault/builtin/credential/token"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	logicalDb "github.com/hashicorp/vault/builtin/logical/database"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 
 	physAliCloudOSS "github.com/hashicorp/vault/physical/alicloudoss"
 	physAzure "github.com/hashicorp/vault/physical/azure"
@@ -210,6 +210,7 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 					UI: serverCmdUi,
 				},
 				ShutdownCh: MakeShutdownCh(),
+				SighupCh:   MakeSighupCh(),
 			}, nil
 		},
 		"audit": func() (cli.Command, error) {
@@ -232,6 +233,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit verify": func() (cli.Command, error) {
+			return &AuditVerifyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -263,6 +269,31 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"context": func() (cli.Command, error) {
+			return &ContextCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"context delete": func() (cli.Command, error) {
+			return &ContextDeleteCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"context list": func() (cli.Command, error) {
+			return &ContextListCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"context set": func() (cli.Command, error) {
+			return &ContextSetCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"context use": func() (cli.Command, error) {
+			return &ContextUseCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"debug": func() (cli.Command, error) {
 			return &DebugCommand{
 				BaseCommand: getBaseCommand(),
@@ -340,6 +371,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator inventory": func() (cli.Command, error) {
+			return &OperatorInventoryCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator key-status": func() (cli.Command, error) {
 			return &OperatorKeyStatusCommand{
 				BaseCommand: getBaseCommand(),
@@ -652,6 +688,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv prune": func() (cli.Command, error) {
+			return &KVPruneCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv enable-versioning": func() (cli.Command, error) {
 			return &KVEnableVersioningCommand{
 				BaseCommand: getBaseCommand(),
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/errwrap"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/natefinch/atomic"
+)
+
+const (
+	// DefaultContextsPath is the default path to the file storing the named
+	// contexts of the CLI.
+	DefaultContextsPath = "~/.vault-contexts"
+
+	// ContextsPathEnv is the environment variable that can be used to
+	// override where the contexts are stored.
+	ContextsPathEnv = "VAULT_CONTEXTS_PATH"
+
+	// ContextEnv is the environment variable that can be used to select the
+	// context of a command.
+	ContextEnv = "VAULT_CONTEXT"
+)
+
+var contextNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Context holds the settings used to connect to a Vault cluster. Settings
+// that are empty are not applied.
+type Context struct {
+	Address       string `json:"address,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	CACert        string `json:"ca_cert,omitempty"`
+	CAPath        string `json:"ca_path,omitempty"`
+	ClientCert    string `json:"client_cert,omitempty"`
+	ClientKey     string `json:"client_key,omitempty"`
+	TLSServerName string `json:"tls_server_name,omitempty"`
+	TLSSkipVerify bool   `json:"tls_skip_verify,omitempty"`
+
+	// TokenHelper is the token helper used with the context. If empty, the
+	// token is stored by the internal token helper in a file specific to the
+	// context.
+	TokenHelper string `json:"token_helper,omitempty"`
+}
+
+// Contexts is the set of named contexts of the CLI and the name of the one
+// currently in use.
+type Contexts struct {
+	CurrentContext string              `json:"current_context,omitempty"`
+	Contexts       map[string]*Context `json:"contexts"`
+}
+
+// ValidateContextName returns an error if the name cannot be used for a
+// context.
+func ValidateContextName(name string) error {
+	if !contextNameRe.MatchString(name) {
+		return fmt.Errorf("invalid context name %q: must start with a letter or digit and contain only letters, digits, '_', '.' and '-'", name)
+	}
+	return nil
+}
+
+// ContextsPath returns the path of the contexts file: the given path, the
+// environment variable if set, or the default path.
+func ContextsPath(path string) (string, error) {
+	if path == "" {
+		path = DefaultContextsPath
+	}
+	if v := os.Getenv(ContextsPathEnv); v != "" {
+		path = v
+	}
+
+	// NOTE: requires HOME env var to be set
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("error expanding contexts path %q: {{err}}", path), err)
+	}
+	return expanded, nil
+}
+
+// LoadContexts reads the contexts from the given path. If path is empty, then
+// the default path will be used, or the environment variable if set. A
+// missing file holds no contexts.
+func LoadContexts(path string) (*Contexts, error) {
+	path, err := ContextsPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := &Contexts{
+		Contexts: make(map[string]*Context),
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return contexts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contents, contexts); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error parsing contexts file at %q: {{err}}", path), err)
+	}
+	if contexts.Contexts == nil {
+		contexts.Contexts = make(map[string]*Context)
+	}
+
+	return contexts, nil
+}
+
+// Save writes the contexts to the given path, or the default path if empty.
+// As contexts may reference credentials, the file is only readable by the
+// user.
+func (c *Contexts) Save(path string) error {
+	path, err := ContextsPath(path)
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := atomic.WriteFile(path, bytes.NewReader(contents)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("error writing contexts file at %q: {{err}}", path), err)
+	}
+	return os.Chmod(path, 0600)
+}
+
+// Names returns the sorted names of the contexts.
+func (c *Contexts) Names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContexts_saveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "contexts")
+
+	contexts, err := LoadContexts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contexts.Contexts) != 0 || contexts.CurrentContext != "" {
+		t.Fatalf("expected no contexts, got: %#v", contexts)
+	}
+
+	contexts.CurrentContext = "prod"
+	contexts.Contexts["prod"] = &Context{
+		Address:       "https://vault.prod:8200",
+		Namespace:     "ns1",
+		CACert:        "/etc/vault/ca.pem",
+		TLSSkipVerify: true,
+	}
+	contexts.Contexts["dev"] = &Context{
+		Address:     "http://127.0.0.1:8200",
+		TokenHelper: "/usr/local/bin/helper",
+	}
+	if err := contexts.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("bad file mode: %v", mode)
+	}
+
+	loaded, err := LoadContexts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(contexts, loaded) {
+		t.Fatalf("expected %#v, got %#v", contexts, loaded)
+	}
+	if names := loaded.Names(); !reflect.DeepEqual(names, []string{"dev", "prod"}) {
+		t.Fatalf("bad names: %v", names)
+	}
+}
+
+func TestContexts_env(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "contexts")
+
+	os.Setenv(ContextsPathEnv, path)
+	defer os.Unsetenv(ContextsPathEnv)
+
+	resolved, err := ContextsPath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != path {
+		t.Fatalf("expected %q, got %q", path, resolved)
+	}
+}
+
+func TestValidateContextName(t *testing.T) {
+	for _, name := range []string{"prod", "us-east-1", "team_a.dev"} {
+		if err := ValidateContextName(name); err != nil {
+			t.Errorf("expected %q to be valid: %s", name, err)
+		}
+	}
+	for _, name := range []string{"", "-prod", "../prod", "a b"} {
+		if err := ValidateContextName(name); err == nil {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
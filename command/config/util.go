@@ -22,3 +22,19 @@ func DefaultTokenHelper() (token.TokenHelper, error) {
 	}
 	return &token.ExternalTokenHelper{BinaryPath: path}, nil
 }
+
+// ContextTokenHelper returns the token helper to use with the named context:
+// the token helper configured for the context, or the internal token helper
+// storing the token in a file specific to the context, so that tokens of
+// different clusters are never mixed up.
+func ContextTokenHelper(name string, context *Context) (token.TokenHelper, error) {
+	if context.TokenHelper == "" {
+		return token.NewInternalTokenHelperWithFile(".vault-token-" + name)
+	}
+
+	path, err := token.ExternalTokenHelperPath(context.TokenHelper)
+	if err != nil {
+		return nil, err
+	}
+	return &token.ExternalTokenHelper{BinaryPath: path}, nil
+}
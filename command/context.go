@@ -0,0 +1,51 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*ContextCommand)(nil)
+
+type ContextCommand struct {
+	*BaseCommand
+}
+
+func (c *ContextCommand) Synopsis() string {
+	return "Manage named connections to Vault clusters"
+}
+
+func (c *ContextCommand) Help() string {
+	helpText := `
+Usage: vault context <subcommand> [options] [args]
+
+  This command groups subcommands for managing contexts. A context is a named
+  set of connection settings (address, namespace, TLS settings and token
+  helper) for a Vault cluster. Commands use the current context, or the one
+  given with the -context flag or the VAULT_CONTEXT environment variable.
+
+  Contexts are stored in ~/.vault-contexts, or the file given by the
+  VAULT_CONTEXTS_PATH environment variable.
+
+  Create a context for a cluster:
+
+      $ vault context set -address=https://vault.prod:8200 -namespace=ns1 prod
+
+  Make it the current context:
+
+      $ vault context use prod
+
+  Run a single command against another context:
+
+      $ vault kv get -context=staging secret/foo
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
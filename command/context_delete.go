@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*ContextDeleteCommand)(nil)
+var _ cli.CommandAutocomplete = (*ContextDeleteCommand)(nil)
+
+type ContextDeleteCommand struct {
+	*BaseCommand
+}
+
+func (c *ContextDeleteCommand) Synopsis() string {
+	return "Deletes a context"
+}
+
+func (c *ContextDeleteCommand) Help() string {
+	helpText := `
+Usage: vault context delete [options] NAME
+
+  Deletes the context with the given name. If it is the current context, no
+  context is current afterwards. Tokens stored for the context by the token
+  helper are not removed.
+
+  Delete the context "staging":
+
+      $ vault context delete staging
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextDeleteCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetNone)
+}
+
+func (c *ContextDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultContexts()
+}
+
+func (c *ContextDeleteCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ContextDeleteCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	name := args[0]
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading contexts: %s", err))
+		return 2
+	}
+
+	if _, ok := contexts.Contexts[name]; !ok {
+		c.UI.Error(fmt.Sprintf("Context %q does not exist", name))
+		return 2
+	}
+
+	delete(contexts.Contexts, name)
+	if contexts.CurrentContext == name {
+		contexts.CurrentContext = ""
+	}
+
+	if err := contexts.Save(""); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving contexts: %s", err))
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Deleted context: %s", name))
+	return 0
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*ContextListCommand)(nil)
+var _ cli.CommandAutocomplete = (*ContextListCommand)(nil)
+
+type ContextListCommand struct {
+	*BaseCommand
+}
+
+func (c *ContextListCommand) Synopsis() string {
+	return "Lists contexts"
+}
+
+func (c *ContextListCommand) Help() string {
+	helpText := `
+Usage: vault context list [options]
+
+  Lists the contexts and their settings. The current context is marked with
+  an asterisk.
+
+  List all contexts:
+
+      $ vault context list
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextListCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetOutputFormat)
+}
+
+func (c *ContextListCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *ContextListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ContextListCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading contexts: %s", err))
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		if len(contexts.Contexts) == 0 {
+			c.UI.Output("No contexts are configured.")
+			return 2
+		}
+
+		columns := []string{"Current | Name | Address | Namespace | Token Helper"}
+		for _, name := range contexts.Names() {
+			vaultContext := contexts.Contexts[name]
+
+			current := ""
+			if name == contexts.CurrentContext {
+				current = "*"
+			}
+			tokenHelper := vaultContext.TokenHelper
+			if tokenHelper == "" {
+				tokenHelper = "n/a"
+			}
+
+			columns = append(columns, fmt.Sprintf("%s | %s | %s | %s | %s",
+				current,
+				name,
+				vaultContext.Address,
+				vaultContext.Namespace,
+				tokenHelper,
+			))
+		}
+		c.UI.Output(tableOutput(columns, nil))
+		return 0
+	default:
+		return OutputData(c.UI, contexts)
+	}
+}
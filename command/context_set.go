@@ -0,0 +1,167 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*ContextSetCommand)(nil)
+var _ cli.CommandAutocomplete = (*ContextSetCommand)(nil)
+
+type ContextSetCommand struct {
+	*BaseCommand
+
+	flagTokenHelper string
+	flagUse         bool
+}
+
+func (c *ContextSetCommand) Synopsis() string {
+	return "Creates or updates a context"
+}
+
+func (c *ContextSetCommand) Help() string {
+	helpText := `
+Usage: vault context set [options] NAME
+
+  Creates or updates the context with the given name. The context takes the
+  address, namespace and TLS settings given with the HTTP options of this
+  command; environment variables are not stored. When updating a context, only
+  the given settings are changed.
+
+  Unless a token helper is given, tokens of the context are stored by the
+  internal token helper in ~/.vault-token-NAME, so that logging in with one
+  context does not replace the token of another.
+
+  Create the context "prod" and make it the current context:
+
+      $ vault context set -address=https://vault.prod:8200 \
+          -ca-cert=/etc/vault/prod-ca.pem -use prod
+
+  Change the namespace of the context "prod":
+
+      $ vault context set -namespace=team-a prod
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextSetCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "token-helper",
+		Target:     &c.flagTokenHelper,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the token helper used with the context. If empty, " +
+			"the internal token helper is used with a token file specific " +
+			"to the context.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "use",
+		Target:  &c.flagUse,
+		Default: false,
+		Usage:   "Make the context the current context.",
+	})
+
+	return set
+}
+
+func (c *ContextSetCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultContexts()
+}
+
+func (c *ContextSetCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ContextSetCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	name := args[0]
+	if err := config.ValidateContextName(name); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading contexts: %s", err))
+		return 2
+	}
+
+	vaultContext, ok := contexts.Contexts[name]
+	if !ok {
+		vaultContext = &config.Context{}
+		contexts.Contexts[name] = vaultContext
+	}
+
+	// Only the flags given on the command line are stored, as the defaults
+	// of the HTTP options are taken from the environment
+	f.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case flagNameAddress:
+			vaultContext.Address = c.flagAddress
+		case "namespace":
+			if c.flagNS == notSetValue {
+				vaultContext.Namespace = c.flagNamespace
+			}
+		case "ns":
+			vaultContext.Namespace = c.flagNS
+		case flagNameCACert:
+			vaultContext.CACert = c.flagCACert
+		case flagNameCAPath:
+			vaultContext.CAPath = c.flagCAPath
+		case flagNameClientCert:
+			vaultContext.ClientCert = c.flagClientCert
+		case flagNameClientKey:
+			vaultContext.ClientKey = c.flagClientKey
+		case flagTLSServerName:
+			vaultContext.TLSServerName = c.flagTLSServerName
+		case flagNameTLSSkipVerify:
+			vaultContext.TLSSkipVerify = c.flagTLSSkipVerify
+		case "token-helper":
+			vaultContext.TokenHelper = c.flagTokenHelper
+		}
+	})
+
+	if c.flagUse {
+		contexts.CurrentContext = name
+	}
+
+	if err := contexts.Save(""); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving contexts: %s", err))
+		return 2
+	}
+
+	verb := "Updated"
+	if !ok {
+		verb = "Created"
+	}
+	c.UI.Output(fmt.Sprintf("Success! %s context: %s", verb, name))
+	return 0
+}
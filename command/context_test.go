@@ -0,0 +1,119 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/mitchellh/cli"
+)
+
+func testContextCommands(tb testing.TB) (*cli.MockUi, *BaseCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &BaseCommand{
+		UI: ui,
+	}
+}
+
+// TestContextCommands does not run in parallel as it sets the path of the
+// contexts file through the environment.
+func TestContextCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-contexts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(config.ContextsPathEnv, filepath.Join(dir, "contexts"))
+	defer os.Unsetenv(config.ContextsPathEnv)
+
+	run := func(cmd cli.Command, ui *cli.MockUi, args ...string) (int, string) {
+		code := cmd.Run(args)
+		return code, ui.OutputWriter.String() + ui.ErrorWriter.String()
+	}
+
+	ui, base := testContextCommands(t)
+	code, out := run(&ContextSetCommand{BaseCommand: base}, ui,
+		"-address=https://vault.prod:8200", "-namespace=ns1", "-use", "prod")
+	if code != 0 || !strings.Contains(out, "Created context: prod") {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	ui, base = testContextCommands(t)
+	code, out = run(&ContextSetCommand{BaseCommand: base}, ui, "-tls-skip-verify", "prod")
+	if code != 0 || !strings.Contains(out, "Updated context: prod") {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	ui, base = testContextCommands(t)
+	code, out = run(&ContextSetCommand{BaseCommand: base}, ui, "-address=http://127.0.0.1:8200", "dev")
+	if code != 0 {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod := contexts.Contexts["prod"]
+	if contexts.CurrentContext != "prod" || prod.Address != "https://vault.prod:8200" ||
+		prod.Namespace != "ns1" || !prod.TLSSkipVerify {
+		t.Fatalf("bad contexts: %#v %#v", contexts, prod)
+	}
+
+	ui, base = testContextCommands(t)
+	code, out = run(&ContextListCommand{BaseCommand: base}, ui)
+	if code != 0 || !strings.Contains(out, "*") || !strings.Contains(out, "dev") {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	// The current context provides the address unless it is given
+	os.Unsetenv("VAULT_ADDR")
+	_, base = testContextCommands(t)
+	if err := base.applyContext(); err != nil {
+		t.Fatal(err)
+	}
+	if base.flagAddress != "https://vault.prod:8200" || base.flagNamespace != "ns1" {
+		t.Fatalf("context not applied: %q %q", base.flagAddress, base.flagNamespace)
+	}
+
+	_, base = testContextCommands(t)
+	base.flagContext = "dev"
+	if err := base.applyContext(); err != nil {
+		t.Fatal(err)
+	}
+	if base.flagAddress != "http://127.0.0.1:8200" {
+		t.Fatalf("context not applied: %q", base.flagAddress)
+	}
+
+	_, base = testContextCommands(t)
+	base.flagContext = "nope"
+	if err := base.applyContext(); err == nil {
+		t.Fatal("expected error for missing context")
+	}
+
+	ui, base = testContextCommands(t)
+	code, out = run(&ContextUseCommand{BaseCommand: base}, ui, "nope")
+	if code != 2 || !strings.Contains(out, "does not exist") {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	ui, base = testContextCommands(t)
+	code, out = run(&ContextDeleteCommand{BaseCommand: base}, ui, "prod")
+	if code != 0 {
+		t.Fatalf("bad: %d %q", code, out)
+	}
+
+	contexts, err = config.LoadContexts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contexts.CurrentContext != "" || len(contexts.Contexts) != 1 {
+		t.Fatalf("bad contexts after delete: %#v", contexts)
+	}
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*ContextUseCommand)(nil)
+var _ cli.CommandAutocomplete = (*ContextUseCommand)(nil)
+
+type ContextUseCommand struct {
+	*BaseCommand
+
+	flagUnset bool
+}
+
+func (c *ContextUseCommand) Synopsis() string {
+	return "Sets the current context"
+}
+
+func (c *ContextUseCommand) Help() string {
+	helpText := `
+Usage: vault context use [options] [NAME]
+
+  Sets the context used by commands that are not given a context with the
+  -context flag or the VAULT_CONTEXT environment variable. The settings of the
+  current context do not override those given with environment variables.
+
+  Use the context "prod":
+
+      $ vault context use prod
+
+  Stop using a context:
+
+      $ vault context use -unset
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextUseCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "unset",
+		Target:  &c.flagUnset,
+		Default: false,
+		Usage:   "Unset the current context instead of setting it.",
+	})
+
+	return set
+}
+
+func (c *ContextUseCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultContexts()
+}
+
+func (c *ContextUseCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ContextUseCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	expected := 1
+	if c.flagUnset {
+		expected = 0
+	}
+	switch {
+	case len(args) < expected:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	case len(args) > expected:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	}
+
+	contexts, err := config.LoadContexts("")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error loading contexts: %s", err))
+		return 2
+	}
+
+	name := ""
+	if !c.flagUnset {
+		name = args[0]
+		if _, ok := contexts.Contexts[name]; !ok {
+			c.UI.Error(fmt.Sprintf("Context %q does not exist", name))
+			return 2
+		}
+	}
+
+	contexts.CurrentContext = name
+	if err := contexts.Save(""); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving contexts: %s", err))
+		return 2
+	}
+
+	if c.flagUnset {
+		c.UI.Output("Success! Unset the current context")
+		return 0
+	}
+	c.UI.Output(fmt.Sprintf("Success! Switched to context: %s", name))
+	return 0
+}
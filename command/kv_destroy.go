@@ -14,7 +14,8 @@ var _ cli.CommandAutocomplete = (*KVDestroyCommand)(nil)
 type KVDestroyCommand struct {
 	*BaseCommand
 
-	flagVersions []string
+	flagVersions  []string
+	flagRecursive bool
 }
 
 func (c *KVDestroyCommand) Synopsis() string {
@@ -32,6 +33,10 @@ Usage: vault kv destroy [options] KEY
 
       $ vault kv destroy -versions=3 secret/foo
 
+  To destroy versions 1 and 2 of every key under "app":
+
+      $ vault kv destroy -r -versions=1,2 secret/app
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -51,6 +56,15 @@ func (c *KVDestroyCommand) Flags() *FlagSets {
 		Usage:   `Specifies the version numbers to destroy.`,
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: `Destroy the given versions of every key at or under the given path.
+		Versions which are already destroyed are skipped.`,
+	})
+
 	return set
 }
 
@@ -102,6 +116,24 @@ func (c *KVDestroyCommand) Run(args []string) int {
 		c.UI.Error("Destroy not supported on KV Version 1")
 		return 1
 	}
+
+	if c.flagRecursive {
+		filter, err := kvVersionsFilter(c.flagVersions)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		written, err := kvWriteVersionsRecursive(client, mountPath, path, "destroy", func(m *kvVersionMetadata) bool {
+			return !m.Destroyed && filter(m.Version)
+		})
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		return kvOutputVersionsRecursive(c.UI, "Destroyed", path, written)
+	}
+
 	path = addPrefixToVKVPath(path, mountPath, "destroy")
 	if err != nil {
 		c.UI.Error(err.Error())
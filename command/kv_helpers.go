@@ -1,14 +1,19 @@
 package command
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/cli"
 )
 
 func kvReadRequest(client *api.Client, path string, params map[string]string) (*api.Secret, error) {
@@ -150,3 +155,185 @@ func kvParseVersionsFlags(versions []string) []string {
 
 	return versionsOut
 }
+
+// kvWalkKeys returns the keys of a KV Version 2 mount at or under the given
+// path, relative to the mount, by listing their metadata recursively.
+func kvWalkKeys(client *api.Client, mountPath, p string) ([]string, error) {
+	prefix := strings.TrimPrefix(strings.TrimPrefix(p, strings.TrimSuffix(mountPath, "/")), "/")
+
+	var keys []string
+	if prefix != "" {
+		secret, err := kvReadRequest(client, path.Join(mountPath, "metadata", prefix), nil)
+		if err != nil {
+			return nil, err
+		}
+		if secret != nil && secret.Data["versions"] != nil {
+			keys = append(keys, prefix)
+		}
+		prefix += "/"
+	}
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		secret, err := client.Logical().List(path.Join(mountPath, "metadata", dir))
+		if err != nil {
+			return err
+		}
+		entries, ok := extractListData(secret)
+		if !ok {
+			return nil
+		}
+		for _, raw := range entries {
+			entry, ok := raw.(string)
+			if !ok {
+				continue
+			}
+			if strings.HasSuffix(entry, "/") {
+				if err := walk(dir + entry); err != nil {
+					return err
+				}
+				continue
+			}
+			keys = append(keys, dir+entry)
+		}
+		return nil
+	}
+	if err := walk(prefix); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// kvVersionMetadata is the metadata of a version of a KV Version 2 key.
+type kvVersionMetadata struct {
+	Version      int
+	CreatedTime  time.Time
+	DeletionTime time.Time
+	Destroyed    bool
+}
+
+// deleted returns whether the version is deleted, but not destroyed.
+func (m *kvVersionMetadata) deleted() bool {
+	return !m.Destroyed && !m.DeletionTime.IsZero() && !m.DeletionTime.After(time.Now())
+}
+
+// kvReadVersions returns the current version of the key of a KV Version 2
+// mount along with the metadata of its versions, oldest first. A nil slice is
+// returned if the key does not exist.
+func kvReadVersions(client *api.Client, mountPath, key string) (int, []*kvVersionMetadata, error) {
+	secret, err := kvReadRequest(client, path.Join(mountPath, "metadata", key), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return 0, nil, nil
+	}
+
+	var current int
+	if raw, ok := secret.Data["current_version"].(json.Number); ok {
+		n, err := raw.Int64()
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid current version of %s: %w", key, err)
+		}
+		current = int(n)
+	}
+
+	rawVersions, _ := secret.Data["versions"].(map[string]interface{})
+	versions := make([]*kvVersionMetadata, 0, len(rawVersions))
+	for v, raw := range rawVersions {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid version %q of %s", v, key)
+		}
+		fields, _ := raw.(map[string]interface{})
+		m := &kvVersionMetadata{Version: n}
+		m.Destroyed, _ = fields["destroyed"].(bool)
+		if t, _ := fields["created_time"].(string); t != "" {
+			if m.CreatedTime, err = time.Parse(time.RFC3339Nano, t); err != nil {
+				return 0, nil, fmt.Errorf("invalid created time of version %d of %s: %w", n, key, err)
+			}
+		}
+		if t, _ := fields["deletion_time"].(string); t != "" {
+			if m.DeletionTime, err = time.Parse(time.RFC3339Nano, t); err != nil {
+				return 0, nil, fmt.Errorf("invalid deletion time of version %d of %s: %w", n, key, err)
+			}
+		}
+		versions = append(versions, m)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	return current, versions, nil
+}
+
+// kvWriteVersionsRecursive writes the versions selected among those of each
+// key at or under the given path to the API prefix of a KV Version 2 mount,
+// such as "undelete" or "destroy". It returns the written versions by key.
+func kvWriteVersionsRecursive(client *api.Client, mountPath, p, apiPrefix string, selectVersion func(*kvVersionMetadata) bool) (map[string][]int, error) {
+	keys, err := kvWalkKeys(client, mountPath, p)
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys under %s: %w", p, err)
+	}
+
+	written := make(map[string][]int)
+	for _, key := range keys {
+		_, versions, err := kvReadVersions(client, mountPath, key)
+		if err != nil {
+			return written, fmt.Errorf("error reading metadata of %s: %w", key, err)
+		}
+
+		var selected []int
+		for _, m := range versions {
+			if selectVersion(m) {
+				selected = append(selected, m.Version)
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+
+		writePath := path.Join(mountPath, apiPrefix, key)
+		if _, err := client.Logical().Write(writePath, map[string]interface{}{
+			"versions": selected,
+		}); err != nil {
+			return written, fmt.Errorf("error writing data to %s: %w", writePath, err)
+		}
+		written[key] = selected
+	}
+
+	return written, nil
+}
+
+// kvVersionsFilter returns a function reporting whether a version is among
+// those given to the "-versions" flag, or always true if none were given.
+func kvVersionsFilter(flagVersions []string) (func(int) bool, error) {
+	if len(flagVersions) == 0 {
+		return func(int) bool { return true }, nil
+	}
+
+	filter := make(map[int]bool)
+	for _, v := range kvParseVersionsFlags(flagVersions) {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		filter[n] = true
+	}
+	return func(n int) bool { return filter[n] }, nil
+}
+
+// kvOutputVersionsRecursive outputs the versions written to each key by a
+// recursive command.
+func kvOutputVersionsRecursive(ui cli.Ui, action, p string, written map[string][]int) int {
+	if Format(ui) != "table" {
+		return OutputData(ui, written)
+	}
+
+	var count int
+	for _, versions := range written {
+		count += len(versions)
+	}
+	ui.Info(fmt.Sprintf("Success! %s %d version(s) of %d key(s) under: %s", action, count, len(written), p))
+	return 0
+}
@@ -19,6 +19,7 @@ type KVMetadataPutCommand struct {
 	flagMaxVersions        int
 	flagCASRequired        bool
 	flagDeleteVersionAfter time.Duration
+	flagCustomMetadata     map[string]string
 	testStdin              io.Reader // for tests
 }
 
@@ -49,6 +50,10 @@ Usage: vault metadata kv put [options] KEY
 
       $ vault kv metadata put -cas-required secret/foo
 
+  Set custom metadata on the key, replacing any previous custom metadata:
+
+      $ vault kv metadata put -custom-metadata=owner=payments -custom-metadata=classification=confidential secret/foo
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -88,6 +93,15 @@ func (c *KVMetadataPutCommand) Flags() *FlagSets {
 		"3h25m19s".`,
 	})
 
+	f.StringMapVar(&StringMapVar{
+		Name:       "custom-metadata",
+		Target:     &c.flagCustomMetadata,
+		Completion: complete.PredictAnything,
+		Usage: "Specifies a key-value pair for the custom metadata of the key, " +
+			"provided as key=value. This can be specified multiple times to set " +
+			"multiple pairs. If set, it replaces any previous custom metadata.",
+	})
+
 	return set
 }
 
@@ -145,6 +159,10 @@ func (c *KVMetadataPutCommand) Run(args []string) int {
 		data["delete_version_after"] = c.flagDeleteVersionAfter.String()
 	}
 
+	if len(c.flagCustomMetadata) > 0 {
+		data["custom_metadata"] = c.flagCustomMetadata
+	}
+
 	secret, err := client.Logical().Write(path, data)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
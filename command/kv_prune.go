@@ -0,0 +1,295 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*KVPruneCommand)(nil)
+var _ cli.CommandAutocomplete = (*KVPruneCommand)(nil)
+
+type KVPruneCommand struct {
+	*BaseCommand
+
+	flagKeepVersions int
+	flagOlderThan    time.Duration
+	flagMaxSize      int
+	flagDryRun       bool
+}
+
+// kvPrunedVersion is a version destroyed, or to be destroyed, by the prune
+// command. Size is -1 if it was not measured.
+type kvPrunedVersion struct {
+	Key         string    `json:"key"`
+	Version     int       `json:"version"`
+	CreatedTime time.Time `json:"created_time"`
+	Size        int       `json:"size"`
+	Reason      string    `json:"reason"`
+}
+
+func (c *KVPruneCommand) Synopsis() string {
+	return "Destroys old or large versions under a path in the KV store"
+}
+
+func (c *KVPruneCommand) Help() string {
+	helpText := `
+Usage: vault kv prune [options] PATH
+
+  Permanently removes the versions of every key at or under the given path
+  which are older than the most recent versions to keep, were created before
+  the given duration, or whose data is larger than the given size. The current
+  version of a key is never destroyed.
+
+  To list the versions which would be destroyed if only the 5 most recent
+  versions of every key under "app" were kept:
+
+      $ vault kv prune -keep-versions=5 -dry-run secret/app
+
+  To destroy the versions of every key under "app" older than 30 days or
+  larger than 64 KiB:
+
+      $ vault kv prune -older-than=720h -max-size=65536 secret/app
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVPruneCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.IntVar(&IntVar{
+		Name:    "keep-versions",
+		Target:  &c.flagKeepVersions,
+		Default: 0,
+		Usage: `Destroy the versions of each key which are older than the given number
+		of most recent versions.`,
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "older-than",
+		Target:     &c.flagOlderThan,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage: `Destroy the versions created before the given duration, specified as a
+		numeric string with a suffix like "30s" or "720h".`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "max-size",
+		Target:  &c.flagMaxSize,
+		Default: 0,
+		Usage: `Destroy the versions whose JSON-encoded data is larger than the given
+		number of bytes. Measuring the size requires reading every version which is
+		not deleted.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "Report the versions which would be destroyed without destroying them.",
+	})
+
+	return set
+}
+
+func (c *KVPruneCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVPruneCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVPruneCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	switch {
+	case c.flagKeepVersions < 0, c.flagOlderThan < 0, c.flagMaxSize < 0:
+		c.UI.Error("The \"-keep-versions\", \"-older-than\" and \"-max-size\" flags must not be negative.")
+		return 1
+	case c.flagKeepVersions == 0 && c.flagOlderThan == 0 && c.flagMaxSize == 0:
+		c.UI.Error("No criteria provided, use the \"-keep-versions\", \"-older-than\" or \"-max-size\" flags to specify the versions to destroy.")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	p := sanitizePath(args[0])
+	mountPath, v2, err := isKVv2(p, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if !v2 {
+		c.UI.Error("Prune not supported on KV Version 1")
+		return 1
+	}
+
+	keys, err := kvWalkKeys(client, mountPath, p)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing keys under %s: %s", p, err))
+		return 2
+	}
+
+	var pruned []*kvPrunedVersion
+	var prunedKeys int
+	for _, key := range keys {
+		keyPruned, err := c.selectVersions(client, mountPath, key)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		if len(keyPruned) == 0 {
+			continue
+		}
+
+		if !c.flagDryRun {
+			versions := make([]int, 0, len(keyPruned))
+			for _, v := range keyPruned {
+				versions = append(versions, v.Version)
+			}
+			destroyPath := path.Join(mountPath, "destroy", key)
+			if _, err := client.Logical().Write(destroyPath, map[string]interface{}{
+				"versions": versions,
+			}); err != nil {
+				c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", destroyPath, err))
+				return 2
+			}
+		}
+
+		pruned = append(pruned, keyPruned...)
+		prunedKeys++
+	}
+
+	if Format(c.UI) != "table" {
+		if pruned == nil {
+			pruned = []*kvPrunedVersion{}
+		}
+		return OutputData(c.UI, pruned)
+	}
+
+	if len(pruned) > 0 {
+		out := []string{"Key | Version | Created Time | Size | Reason"}
+		for _, v := range pruned {
+			size := "n/a"
+			if v.Size >= 0 {
+				size = strconv.Itoa(v.Size)
+			}
+			out = append(out, fmt.Sprintf("%s | %d | %s | %s | %s",
+				v.Key, v.Version, v.CreatedTime.Format(time.RFC3339), size, v.Reason))
+		}
+		c.UI.Output(tableOutput(out, nil))
+		c.UI.Output("")
+	}
+
+	if c.flagDryRun {
+		c.UI.Info(fmt.Sprintf("Dry run: %d version(s) of %d key(s) under %s would be destroyed", len(pruned), prunedKeys, p))
+		return 0
+	}
+	c.UI.Info(fmt.Sprintf("Success! Destroyed %d version(s) of %d key(s) under: %s", len(pruned), prunedKeys, p))
+	return 0
+}
+
+// selectVersions returns the versions of the key matching any of the prune
+// criteria, oldest first. Destroyed versions and the current version are
+// never selected.
+func (c *KVPruneCommand) selectVersions(client *api.Client, mountPath, key string) ([]*kvPrunedVersion, error) {
+	current, versions, err := kvReadVersions(client, mountPath, key)
+	if err != nil {
+		return nil, fmt.Errorf("error reading metadata of %s: %w", key, err)
+	}
+
+	var cutoff time.Time
+	if c.flagOlderThan > 0 {
+		cutoff = time.Now().Add(-c.flagOlderThan)
+	}
+
+	var selected []*kvPrunedVersion
+	for i, m := range versions {
+		if m.Destroyed || m.Version == current {
+			continue
+		}
+
+		v := &kvPrunedVersion{
+			Key:         key,
+			Version:     m.Version,
+			CreatedTime: m.CreatedTime,
+			Size:        -1,
+		}
+		switch {
+		case c.flagKeepVersions > 0 && len(versions)-i > c.flagKeepVersions:
+			v.Reason = fmt.Sprintf("older than the %d most recent versions", c.flagKeepVersions)
+		case c.flagOlderThan > 0 && m.CreatedTime.Before(cutoff):
+			v.Reason = fmt.Sprintf("created more than %s ago", c.flagOlderThan)
+		case c.flagMaxSize > 0 && !m.deleted():
+			size, err := c.versionSize(client, mountPath, key, m.Version)
+			if err != nil {
+				return nil, err
+			}
+			v.Size = size
+			if size <= c.flagMaxSize {
+				continue
+			}
+			v.Reason = fmt.Sprintf("larger than %d bytes", c.flagMaxSize)
+		default:
+			continue
+		}
+		selected = append(selected, v)
+	}
+
+	return selected, nil
+}
+
+// versionSize returns the size of the JSON-encoded data of the version, or
+// -1 if its data cannot be read.
+func (c *KVPruneCommand) versionSize(client *api.Client, mountPath, key string, version int) (int, error) {
+	readPath := path.Join(mountPath, "data", key)
+	secret, err := kvReadRequest(client, readPath, map[string]string{
+		"version": strconv.Itoa(version),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading version %d of %s: %w", version, key, err)
+	}
+	if secret == nil || secret.Data["data"] == nil {
+		return -1, nil
+	}
+
+	encoded, err := json.Marshal(secret.Data["data"])
+	if err != nil {
+		return 0, err
+	}
+	return len(encoded), nil
+}
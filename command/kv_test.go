@@ -553,3 +553,162 @@ func TestKVMetadataGetCommand(t *testing.T) {
 		assertNoTabs(t, cmd)
 	})
 }
+
+func testKVUndeleteCommand(tb testing.TB) (*cli.MockUi, *KVUndeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVUndeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVPruneCommand(tb testing.TB) (*cli.MockUi, *KVPruneCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVPruneCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testKVVersionedTree mounts a KV Version 2 store at "kv/" and writes three
+// versions of "app/a", "app/nested/b" and "other".
+func testKVVersionedTree(t *testing.T, client *api.Client) {
+	t.Helper()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Give time for the upgrade code to run/finish
+	time.Sleep(time.Second)
+
+	for _, key := range []string{"app/a", "app/nested/b", "other"} {
+		for i := 0; i < 3; i++ {
+			if _, err := client.Logical().Write("kv/data/"+key, map[string]interface{}{
+				"data": map[string]interface{}{
+					"foo": strings.Repeat("x", 100*i),
+				},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestKVUndeleteCommand_Recursive(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+	testKVVersionedTree(t, client)
+
+	for _, key := range []string{"app/a", "app/nested/b", "other"} {
+		if _, err := client.Logical().Write("kv/delete/"+key, map[string]interface{}{
+			"versions": []int{1, 2},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui, cmd := testKVUndeleteCommand(t)
+	cmd.client = client
+
+	code := cmd.Run([]string{"-r", "kv/app"})
+	if code != 0 {
+		t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+	}
+	combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+	if expected := "Undeleted 4 version(s) of 2 key(s)"; !strings.Contains(combined, expected) {
+		t.Errorf("expected %q to contain %q", combined, expected)
+	}
+
+	for key, deleted := range map[string]bool{"app/a": false, "app/nested/b": false, "other": true} {
+		secret, err := kvReadRequest(client, "kv/data/"+key, map[string]string{"version": "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (secret.Data["data"] == nil) != deleted {
+			t.Errorf("expected version 1 of %s deleted to be %t", key, deleted)
+		}
+	}
+}
+
+func TestKVPruneCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"no_criteria",
+			[]string{"kv/app"},
+			"No criteria provided",
+			1,
+		},
+		{
+			"dry_run",
+			[]string{"-keep-versions=2", "-dry-run", "kv/app"},
+			"2 version(s) of 2 key(s) under kv/app would be destroyed",
+			0,
+		},
+		{
+			"keep_versions",
+			[]string{"-keep-versions=2", "kv/app"},
+			"Destroyed 2 version(s) of 2 key(s)",
+			0,
+		},
+		{
+			"max_size",
+			[]string{"-max-size=50", "kv/"},
+			"Destroyed 3 version(s) of 3 key(s)",
+			0,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+				testKVVersionedTree(t, client)
+
+				ui, cmd := testKVPruneCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVPruneCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
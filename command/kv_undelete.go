@@ -14,7 +14,8 @@ var _ cli.CommandAutocomplete = (*KVUndeleteCommand)(nil)
 type KVUndeleteCommand struct {
 	*BaseCommand
 
-	flagVersions []string
+	flagVersions  []string
+	flagRecursive bool
 }
 
 func (c *KVUndeleteCommand) Synopsis() string {
@@ -32,6 +33,10 @@ Usage: vault kv undelete [options] KEY
   
       $ vault kv undelete -versions=3 secret/foo
 
+  To undelete all the deleted versions of every key under "app":
+
+      $ vault kv undelete -r secret/app
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -51,6 +56,15 @@ func (c *KVUndeleteCommand) Flags() *FlagSets {
 		Usage:   `Specifies the version numbers to undelete.`,
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: `Undelete the versions of every key at or under the given path. Without
+		"-versions", all the deleted versions of each key are undeleted.`,
+	})
+
 	return set
 }
 
@@ -80,7 +94,7 @@ func (c *KVUndeleteCommand) Run(args []string) int {
 		return 1
 	}
 
-	if len(c.flagVersions) == 0 {
+	if len(c.flagVersions) == 0 && !c.flagRecursive {
 		c.UI.Error("No versions provided, use the \"-versions\" flag to specify the version to undelete.")
 		return 1
 	}
@@ -102,6 +116,23 @@ func (c *KVUndeleteCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagRecursive {
+		filter, err := kvVersionsFilter(c.flagVersions)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+
+		written, err := kvWriteVersionsRecursive(client, mountPath, path, "undelete", func(m *kvVersionMetadata) bool {
+			return m.deleted() && filter(m.Version)
+		})
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		return kvOutputVersionsRecursive(c.UI, "Undeleted", path, written)
+	}
+
 	path = addPrefixToVKVPath(path, mountPath, "undelete")
 	data := map[string]interface{}{
 		"versions": kvParseVersionsFlags(c.flagVersions),
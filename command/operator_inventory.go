@@ -0,0 +1,314 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*OperatorInventoryCommand)(nil)
+var _ cli.CommandAutocomplete = (*OperatorInventoryCommand)(nil)
+
+// inventoryRolePaths are the paths, relative to a mount, on which the roles
+// of most secrets engines and auth methods are listed.
+var inventoryRolePaths = []string{"roles", "role"}
+
+// inventoryNoRoleTypes are the types of mounts that have no roles. Their role
+// paths may hold arbitrary data, such as KV secrets, so they are not listed.
+var inventoryNoRoleTypes = map[string]bool{
+	"cubbyhole": true,
+	"generic":   true,
+	"identity":  true,
+	"kv":        true,
+	"system":    true,
+}
+
+type OperatorInventoryCommand struct {
+	*BaseCommand
+
+	flagRoles bool
+}
+
+// inventory is the sanitized configuration exported by the command. It only
+// holds names and shapes, never secret values, and is sorted so that exports
+// of the same configuration are identical.
+type inventory struct {
+	SecretsEngines []*inventoryMount  `json:"secrets_engines"`
+	AuthMethods    []*inventoryMount  `json:"auth_methods"`
+	Policies       []*inventoryPolicy `json:"policies"`
+}
+
+type inventoryMount struct {
+	Path                  string                `json:"path"`
+	Type                  string                `json:"type"`
+	Description           string                `json:"description,omitempty"`
+	Local                 bool                  `json:"local"`
+	SealWrap              bool                  `json:"seal_wrap"`
+	ExternalEntropyAccess bool                  `json:"external_entropy_access"`
+	Options               map[string]string     `json:"options,omitempty"`
+	Config                api.MountConfigOutput `json:"config"`
+	Roles                 []*inventoryRole      `json:"roles,omitempty"`
+}
+
+type inventoryRole struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+type inventoryPolicy struct {
+	Name        string                 `json:"name"`
+	RulesSHA256 string                 `json:"rules_sha256"`
+	Paths       []*inventoryPolicyPath `json:"paths"`
+}
+
+type inventoryPolicyPath struct {
+	Path         string   `json:"path"`
+	Capabilities []string `json:"capabilities"`
+}
+
+func (c *OperatorInventoryCommand) Synopsis() string {
+	return "Exports a sanitized inventory of the configuration"
+}
+
+func (c *OperatorInventoryCommand) Help() string {
+	helpText := `
+Usage: vault operator inventory [options]
+
+  Exports the secrets engines, auth methods, roles and policies of Vault as
+  JSON. Only names and shapes are exported: roles are described by the names
+  of their fields and policies by the capabilities they grant on each path,
+  without any value that could be secret. The output is sorted so that it can
+  be compared against infrastructure as code to detect drift.
+
+  Paths the token is not allowed to read are skipped with a warning.
+
+  Export the inventory to a file:
+
+      $ vault operator inventory > inventory.json
+
+  Export the inventory without listing roles:
+
+      $ vault operator inventory -roles=false
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorInventoryCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "roles",
+		Target:  &c.flagRoles,
+		Default: true,
+		Usage: "List the roles of each secrets engine and auth method, and " +
+			"the names of their fields.",
+	})
+
+	return set
+}
+
+func (c *OperatorInventoryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorInventoryCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorInventoryCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	inv := &inventory{
+		SecretsEngines: []*inventoryMount{},
+		AuthMethods:    []*inventoryMount{},
+		Policies:       []*inventoryPolicy{},
+	}
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing secrets engines: %s", err))
+		return 2
+	}
+	for path, mount := range mounts {
+		m := newInventoryMount(path, mount)
+		if c.flagRoles && !inventoryNoRoleTypes[mount.Type] {
+			m.Roles = c.listRoles(client, path)
+		}
+		inv.SecretsEngines = append(inv.SecretsEngines, m)
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing auth methods: %s", err))
+		return 2
+	}
+	for path, auth := range auths {
+		m := newInventoryMount(path, auth)
+		if c.flagRoles {
+			m.Roles = c.listRoles(client, "auth/"+path)
+		}
+		inv.AuthMethods = append(inv.AuthMethods, m)
+	}
+
+	policies, err := client.Sys().ListPolicies()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing policies: %s", err))
+		return 2
+	}
+	for _, name := range policies {
+		rules, err := client.Sys().GetPolicy(name)
+		if err != nil {
+			c.UI.Warn(fmt.Sprintf("Skipping policy %q: %s", name, err))
+			continue
+		}
+		p, err := newInventoryPolicy(name, rules)
+		if err != nil {
+			c.UI.Warn(fmt.Sprintf("Skipping policy %q: %s", name, err))
+			continue
+		}
+		inv.Policies = append(inv.Policies, p)
+	}
+
+	sort.Slice(inv.SecretsEngines, func(i, j int) bool {
+		return inv.SecretsEngines[i].Path < inv.SecretsEngines[j].Path
+	})
+	sort.Slice(inv.AuthMethods, func(i, j int) bool {
+		return inv.AuthMethods[i].Path < inv.AuthMethods[j].Path
+	})
+	sort.Slice(inv.Policies, func(i, j int) bool {
+		return inv.Policies[i].Name < inv.Policies[j].Name
+	})
+
+	out, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding inventory: %s", err))
+		return 2
+	}
+	c.UI.Output(string(out))
+	return 0
+}
+
+// listRoles returns the roles found under the mount at the given path,
+// described by the names of their fields. Mounts without roles return nil.
+func (c *OperatorInventoryCommand) listRoles(client *api.Client, mountPath string) []*inventoryRole {
+	for _, rolePath := range inventoryRolePaths {
+		path := mountPath + rolePath
+		secret, err := client.Logical().List(path)
+		if err != nil || secret == nil || secret.Data == nil {
+			continue
+		}
+		keys, ok := secret.Data["keys"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		roles := make([]*inventoryRole, 0, len(keys))
+		for _, key := range keys {
+			name, ok := key.(string)
+			if !ok || strings.HasSuffix(name, "/") {
+				continue
+			}
+			role := &inventoryRole{
+				Name:   name,
+				Fields: []string{},
+			}
+
+			secret, err := client.Logical().Read(path + "/" + name)
+			if err != nil {
+				c.UI.Warn(fmt.Sprintf("Unable to read role %q: %s", path+"/"+name, err))
+			}
+			if secret != nil {
+				for field := range secret.Data {
+					role.Fields = append(role.Fields, field)
+				}
+				sort.Strings(role.Fields)
+			}
+			roles = append(roles, role)
+		}
+		sort.Slice(roles, func(i, j int) bool {
+			return roles[i].Name < roles[j].Name
+		})
+		return roles
+	}
+	return nil
+}
+
+func newInventoryMount(path string, mount *api.MountOutput) *inventoryMount {
+	return &inventoryMount{
+		Path:                  path,
+		Type:                  mount.Type,
+		Description:           mount.Description,
+		Local:                 mount.Local,
+		SealWrap:              mount.SealWrap,
+		ExternalEntropyAccess: mount.ExternalEntropyAccess,
+		Options:               mount.Options,
+		Config:                mount.Config,
+	}
+}
+
+// newInventoryPolicy describes a policy by the capabilities it grants on each
+// path, along with a hash of its rules so that any change is detected.
+func newInventoryPolicy(name, rules string) (*inventoryPolicy, error) {
+	sum := sha256.Sum256([]byte(rules))
+	p := &inventoryPolicy{
+		Name:        name,
+		RulesSHA256: hex.EncodeToString(sum[:]),
+		Paths:       []*inventoryPolicyPath{},
+	}
+
+	// The root policy has no rules
+	if rules == "" {
+		return p, nil
+	}
+
+	policy, err := vault.ParseACLPolicy(namespace.RootNamespace, rules)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range policy.Paths {
+		path := pr.Path
+		if pr.IsPrefix {
+			path += "*"
+		}
+		capabilities := append([]string{}, pr.Capabilities...)
+		sort.Strings(capabilities)
+		p.Paths = append(p.Paths, &inventoryPolicyPath{
+			Path:         path,
+			Capabilities: capabilities,
+		})
+	}
+	sort.Slice(p.Paths, func(i, j int) bool {
+		return p.Paths[i].Path < p.Paths[j].Path
+	})
+	return p, nil
+}
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorInventoryCommand(tb testing.TB) (*cli.MockUi, *OperatorInventoryCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorInventoryCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorInventoryCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testOperatorInventoryCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"foo"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Too many arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		policy := `path "secret/*" { capabilities = ["update", "read"] }`
+		if err := client.Sys().PutPolicy("app", policy); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("auth/token/roles/app", map[string]interface{}{
+			"allowed_policies": "app",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("secret/roles/db", map[string]interface{}{
+			"password": "hunter2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorInventoryCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		output := ui.OutputWriter.String()
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "password") {
+			t.Fatalf("inventory contains secret data: %s", output)
+		}
+
+		var inv inventory
+		if err := json.Unmarshal([]byte(output), &inv); err != nil {
+			t.Fatal(err)
+		}
+
+		var tokenRoles []*inventoryRole
+		for _, auth := range inv.AuthMethods {
+			if auth.Path == "token/" {
+				tokenRoles = auth.Roles
+			}
+		}
+		if len(tokenRoles) != 1 || tokenRoles[0].Name != "app" {
+			t.Fatalf("bad token roles: %#v", tokenRoles)
+		}
+		if !strutil.StrListContains(tokenRoles[0].Fields, "allowed_policies") {
+			t.Fatalf("bad token role fields: %v", tokenRoles[0].Fields)
+		}
+
+		var found bool
+		for _, p := range inv.Policies {
+			if p.Name != "app" {
+				continue
+			}
+			found = true
+			if len(p.Paths) != 1 || p.Paths[0].Path != "secret/*" ||
+				strings.Join(p.Paths[0].Capabilities, ",") != "read,update" {
+				t.Fatalf("bad policy paths: %#v", p.Paths)
+			}
+		}
+		if !found {
+			t.Fatalf("policy app missing from inventory: %s", output)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testOperatorInventoryCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error listing secrets engines: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorInventoryCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/hashicorp/vault/sdk/version"
 	sr "github.com/hashicorp/vault/serviceregistration"
 	"github.com/hashicorp/vault/vault"
+	"github.com/hashicorp/vault/vault/maintenance"
 	vaultseal "github.com/hashicorp/vault/vault/seal"
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/go-testing-interface"
@@ -524,7 +525,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 		}),
 	})
 	sealLogger := c.logger.ResetNamed(fmt.Sprintf("seal.%s", sealType))
-	wrapper, sealConfigError = configutil.ConfigureWrapper(configSeal, &infoKeys, &info, sealLogger)
+	wrapper, sealConfigError = configutil.ConfigureSealWrapper(configSeal, &infoKeys, &info, sealLogger)
 	if sealConfigError != nil {
 		if !errwrap.ContainsType(sealConfigError, new(logical.KeyNotFoundError)) {
 			c.UI.Error(fmt.Sprintf(
@@ -1102,7 +1103,7 @@ func (c *ServerCommand) Run(args []string) int {
 			})
 			var sealInfoKeys []string
 			var sealInfoMap = map[string]string{}
-			wrapper, sealConfigError = configutil.ConfigureWrapper(configSeal, &sealInfoKeys, &sealInfoMap, sealLogger)
+			wrapper, sealConfigError = configutil.ConfigureSealWrapper(configSeal, &sealInfoKeys, &sealInfoMap, sealLogger)
 			if sealConfigError != nil {
 				if !errwrap.ContainsType(sealConfigError, new(logical.KeyNotFoundError)) {
 					c.UI.Error(fmt.Sprintf(
@@ -1154,6 +1155,16 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	var maintenanceWindows []*maintenance.Window
+	for _, w := range config.MaintenanceWindows {
+		window, err := maintenance.NewWindow(w.Schedule, w.Duration)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error parsing maintenance window: %s", err))
+			return 1
+		}
+		maintenanceWindows = append(maintenanceWindows, window)
+	}
+
 	coreConfig := &vault.CoreConfig{
 		RawConfig:                 config,
 		Physical:                  backend,
@@ -1168,12 +1179,18 @@ func (c *ServerCommand) Run(args []string) int {
 		LogicalBackends:           c.LogicalBackends,
 		Logger:                    c.logger,
 		DisableSentinelTrace:      config.DisableSentinelTrace,
+		ExcludedUnauthPaths:       config.ExcludedUnauthenticatedPaths,
+		CubbyholeMaxSize:          config.CubbyholeMaxSize,
+		MaintenanceWindows:        maintenanceWindows,
 		DisableCache:              config.DisableCache,
 		DisableMlock:              config.DisableMlock,
 		MaxLeaseTTL:               config.MaxLeaseTTL,
 		DefaultLeaseTTL:           config.DefaultLeaseTTL,
 		ClusterName:               config.ClusterName,
 		CacheSize:                 config.CacheSize,
+		CacheSizeBytes:            config.CacheSizeBytes,
+		CacheEvictionPolicy:       config.CacheEvictionPolicy,
+		CacheMetricsPrefixes:      config.CacheMetricsPrefixes,
 		PluginDirectory:           config.PluginDirectory,
 		EnableUI:                  config.EnableUI,
 		EnableRaw:                 config.EnableRawEndpoint,
@@ -18,6 +18,8 @@ import (
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/maintenance"
 )
 
 // Config is the configuration for the vault server.
@@ -32,6 +34,9 @@ type Config struct {
 	ServiceRegistration *ServiceRegistration `hcl:"-"`
 
 	CacheSize                int         `hcl:"cache_size"`
+	CacheSizeBytes           int         `hcl:"cache_size_bytes"`
+	CacheEvictionPolicy      string      `hcl:"cache_eviction_policy"`
+	CacheMetricsPrefixes     []string    `hcl:"cache_metrics_prefixes"`
 	DisableCache             bool        `hcl:"-"`
 	DisableCacheRaw          interface{} `hcl:"disable_cache"`
 	DisablePrintableCheck    bool        `hcl:"-"`
@@ -68,6 +73,12 @@ type Config struct {
 
 	DisableSentinelTrace    bool        `hcl:"-"`
 	DisableSentinelTraceRaw interface{} `hcl:"disable_sentinel_trace"`
+
+	ExcludedUnauthenticatedPaths []string `hcl:"excluded_unauthenticated_paths"`
+
+	CubbyholeMaxSize int64 `hcl:"cubbyhole_max_size"`
+
+	MaintenanceWindows []*MaintenanceWindow `hcl:"-"`
 }
 
 // DevConfig is a Config that is used for dev mode of Vault.
@@ -126,6 +137,18 @@ func (b *ServiceRegistration) GoString() string {
 	return fmt.Sprintf("*%#v", *b)
 }
 
+// MaintenanceWindow is a recurring period of time during which the token and
+// lease tidy jobs are allowed to run.
+type MaintenanceWindow struct {
+	Schedule    string        `hcl:"schedule"`
+	Duration    time.Duration `hcl:"-"`
+	DurationRaw interface{}   `hcl:"duration"`
+}
+
+func (w *MaintenanceWindow) GoString() string {
+	return fmt.Sprintf("*%#v", *w)
+}
+
 func NewConfig() *Config {
 	return &Config{
 		SharedConfig: new(configutil.SharedConfig),
@@ -165,6 +188,21 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.CacheSize = c2.CacheSize
 	}
 
+	result.CacheSizeBytes = c.CacheSizeBytes
+	if c2.CacheSizeBytes != 0 {
+		result.CacheSizeBytes = c2.CacheSizeBytes
+	}
+
+	result.CacheEvictionPolicy = c.CacheEvictionPolicy
+	if c2.CacheEvictionPolicy != "" {
+		result.CacheEvictionPolicy = c2.CacheEvictionPolicy
+	}
+
+	result.CacheMetricsPrefixes = c.CacheMetricsPrefixes
+	if len(c2.CacheMetricsPrefixes) > 0 {
+		result.CacheMetricsPrefixes = c2.CacheMetricsPrefixes
+	}
+
 	// merging these booleans via an OR operation
 	result.DisableCache = c.DisableCache
 	if c2.DisableCache {
@@ -230,6 +268,21 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.PluginDirectory = c2.PluginDirectory
 	}
 
+	result.ExcludedUnauthenticatedPaths = c.ExcludedUnauthenticatedPaths
+	if len(c2.ExcludedUnauthenticatedPaths) > 0 {
+		result.ExcludedUnauthenticatedPaths = c2.ExcludedUnauthenticatedPaths
+	}
+
+	result.CubbyholeMaxSize = c.CubbyholeMaxSize
+	if c2.CubbyholeMaxSize != 0 {
+		result.CubbyholeMaxSize = c2.CubbyholeMaxSize
+	}
+
+	result.MaintenanceWindows = c.MaintenanceWindows
+	if len(c2.MaintenanceWindows) > 0 {
+		result.MaintenanceWindows = c2.MaintenanceWindows
+	}
+
 	result.DisablePerformanceStandby = c.DisablePerformanceStandby
 	if c2.DisablePerformanceStandby {
 		result.DisablePerformanceStandby = c2.DisablePerformanceStandby
@@ -406,6 +459,19 @@ func ParseConfig(d string) (*Config, error) {
 		}
 	}
 
+	if result.CubbyholeMaxSize < 0 {
+		return nil, fmt.Errorf("cubbyhole_max_size cannot be negative")
+	}
+
+	cacheConfig := &physical.CacheConfig{
+		Size:           result.CacheSize,
+		SizeBytes:      result.CacheSizeBytes,
+		EvictionPolicy: result.CacheEvictionPolicy,
+	}
+	if err := cacheConfig.Validate(); err != nil {
+		return nil, errwrap.Wrapf("error validating cache configuration: {{err}}", err)
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -443,6 +509,12 @@ func ParseConfig(d string) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("maintenance_window"); len(o.Items) > 0 {
+		if err := parseMaintenanceWindows(result, o); err != nil {
+			return nil, errwrap.Wrapf("error parsing 'maintenance_window': {{err}}", err)
+		}
+	}
+
 	entConfig := &(result.entConfig)
 	if err := entConfig.parseConfig(list); err != nil {
 		return nil, errwrap.Wrapf("error parsing enterprise config: {{err}}", err)
@@ -700,6 +772,30 @@ func parseServiceRegistration(result *Config, list *ast.ObjectList, name string)
 	return nil
 }
 
+func parseMaintenanceWindows(result *Config, list *ast.ObjectList) error {
+	for i, item := range list.Items {
+		var w MaintenanceWindow
+		if err := hcl.DecodeObject(&w, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("maintenance_window[%d]:", i))
+		}
+
+		if w.DurationRaw != nil {
+			var err error
+			if w.Duration, err = parseutil.ParseDurationSecond(w.DurationRaw); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("maintenance_window[%d]:", i))
+			}
+			w.DurationRaw = nil
+		}
+
+		if _, err := maintenance.NewWindow(w.Schedule, w.Duration); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("maintenance_window[%d]:", i))
+		}
+
+		result.MaintenanceWindows = append(result.MaintenanceWindows, &w)
+	}
+	return nil
+}
+
 // Sanitized returns a copy of the config with all values that are considered
 // sensitive stripped. It also strips all `*Raw` values that are mainly
 // used for parsing.
@@ -718,6 +814,9 @@ func (c *Config) Sanitized() map[string]interface{} {
 	sharedResult := c.SharedConfig.Sanitized()
 	result := map[string]interface{}{
 		"cache_size":              c.CacheSize,
+		"cache_size_bytes":        c.CacheSizeBytes,
+		"cache_eviction_policy":   c.CacheEvictionPolicy,
+		"cache_metrics_prefixes":  c.CacheMetricsPrefixes,
 		"disable_sentinel_trace":  c.DisableSentinelTrace,
 		"disable_cache":           c.DisableCache,
 		"disable_printable_check": c.DisablePrintableCheck,
@@ -742,6 +841,10 @@ func (c *Config) Sanitized() map[string]interface{} {
 		"disable_sealwrap": c.DisableSealWrap,
 
 		"disable_indexing": c.DisableIndexing,
+
+		"excluded_unauthenticated_paths": c.ExcludedUnauthenticatedPaths,
+
+		"cubbyhole_max_size": c.CubbyholeMaxSize,
 	}
 	for k, v := range sharedResult {
 		result[k] = v
@@ -777,6 +880,18 @@ func (c *Config) Sanitized() map[string]interface{} {
 		result["service_registration"] = sanitizedServiceRegistration
 	}
 
+	// Sanitize maintenance_window stanzas
+	if len(c.MaintenanceWindows) > 0 {
+		sanitizedMaintenanceWindows := make([]interface{}, 0, len(c.MaintenanceWindows))
+		for _, w := range c.MaintenanceWindows {
+			sanitizedMaintenanceWindows = append(sanitizedMaintenanceWindows, map[string]interface{}{
+				"schedule": w.Schedule,
+				"duration": w.Duration,
+			})
+		}
+		result["maintenance_windows"] = sanitizedMaintenanceWindows
+	}
+
 	entConfigResult := c.entConfig.Sanitized()
 	for k, v := range entConfigResult {
 		result[k] = v
@@ -57,3 +57,11 @@ func TestConfigRaftRetryJoin(t *testing.T) {
 func TestParseSeals(t *testing.T) {
 	testParseSeals(t)
 }
+
+func TestParseMaintenanceWindows(t *testing.T) {
+	testParseMaintenanceWindows(t)
+}
+
+func TestParseCacheConfig(t *testing.T) {
+	testParseCacheConfig(t)
+}
@@ -19,7 +19,7 @@ func testConfigRaftRetryJoin(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	retryJoinConfig := `[{"leader_api_addr":"http://127.0.0.1:8200"},{"leader_api_addr":"http://127.0.0.2:8200"},{"leader_api_addr":"http://127.0.0.3:8200"}]`
+	retryJoinConfig := `[{"leader_api_addr":"http://127.0.0.1:8200"},{"leader_api_addr":"http://127.0.0.2:8200"},{"leader_api_addr":"http://127.0.0.3:8200"},{"auto_join":"provider=aws region=eu-west-1 tag_key=vault tag_value=prod","auto_join_port":8300,"auto_join_scheme":"http"}]`
 	expected := &Config{
 		SharedConfig: &configutil.SharedConfig{
 			Listeners: []*configutil.Listener{
@@ -62,17 +62,18 @@ func testLoadConfigFile_topLevel(t *testing.T, entropy *configutil.Entropy) {
 			},
 
 			Telemetry: &configutil.Telemetry{
-				StatsdAddr:                  "bar",
-				StatsiteAddr:                "foo",
-				DisableHostname:             false,
-				DogStatsDAddr:               "127.0.0.1:7254",
-				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
-				PrometheusRetentionTime:     30 * time.Second,
-				UsageGaugePeriod:            5 * time.Minute,
-				MaximumGaugeCardinality:     125,
-				LeaseMetricsEpsilon:         time.Hour,
-				NumLeaseMetricsTimeBuckets:  168,
-				LeaseMetricsNameSpaceLabels: false,
+				StatsdAddr:                     "bar",
+				StatsiteAddr:                   "foo",
+				DisableHostname:                false,
+				DogStatsDAddr:                  "127.0.0.1:7254",
+				DogStatsDTags:                  []string{"tag_1:val_1", "tag_2:val_2"},
+				PrometheusRetentionTime:        30 * time.Second,
+				UsageGaugePeriod:               5 * time.Minute,
+				MaximumGaugeCardinality:        125,
+				LeaseMetricsEpsilon:            time.Hour,
+				NumLeaseMetricsTimeBuckets:     168,
+				LeaseMetricsNameSpaceLabels:    false,
+				MaximumUsageMetricsCardinality: 500,
 			},
 
 			DisableMlock: true,
@@ -198,6 +199,7 @@ func testLoadConfigFile_json2(t *testing.T, entropy *configutil.Entropy) {
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
+				MaximumUsageMetricsCardinality:     500,
 			},
 		},
 
@@ -377,18 +379,19 @@ func testLoadConfigFile(t *testing.T) {
 			},
 
 			Telemetry: &configutil.Telemetry{
-				StatsdAddr:                  "bar",
-				StatsiteAddr:                "foo",
-				DisableHostname:             false,
-				UsageGaugePeriod:            5 * time.Minute,
-				MaximumGaugeCardinality:     100,
-				DogStatsDAddr:               "127.0.0.1:7254",
-				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
-				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
-				MetricsPrefix:               "myprefix",
-				LeaseMetricsEpsilon:         time.Hour,
-				NumLeaseMetricsTimeBuckets:  168,
-				LeaseMetricsNameSpaceLabels: false,
+				StatsdAddr:                     "bar",
+				StatsiteAddr:                   "foo",
+				DisableHostname:                false,
+				UsageGaugePeriod:               5 * time.Minute,
+				MaximumGaugeCardinality:        100,
+				DogStatsDAddr:                  "127.0.0.1:7254",
+				DogStatsDTags:                  []string{"tag_1:val_1", "tag_2:val_2"},
+				PrometheusRetentionTime:        configutil.PrometheusDefaultRetentionTime,
+				MetricsPrefix:                  "myprefix",
+				LeaseMetricsEpsilon:            time.Hour,
+				NumLeaseMetricsTimeBuckets:     168,
+				LeaseMetricsNameSpaceLabels:    false,
+				MaximumUsageMetricsCardinality: 500,
 			},
 
 			DisableMlock: true,
@@ -489,6 +492,7 @@ func testLoadConfigFile_json(t *testing.T) {
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
+				MaximumUsageMetricsCardinality:     500,
 			},
 
 			PidFile:     "./pidfile",
@@ -552,15 +556,16 @@ func testLoadConfigDir(t *testing.T) {
 			},
 
 			Telemetry: &configutil.Telemetry{
-				StatsiteAddr:                "qux",
-				StatsdAddr:                  "baz",
-				DisableHostname:             true,
-				UsageGaugePeriod:            5 * time.Minute,
-				MaximumGaugeCardinality:     100,
-				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
-				LeaseMetricsEpsilon:         time.Hour,
-				NumLeaseMetricsTimeBuckets:  168,
-				LeaseMetricsNameSpaceLabels: false,
+				StatsiteAddr:                   "qux",
+				StatsdAddr:                     "baz",
+				DisableHostname:                true,
+				UsageGaugePeriod:               5 * time.Minute,
+				MaximumGaugeCardinality:        100,
+				PrometheusRetentionTime:        configutil.PrometheusDefaultRetentionTime,
+				LeaseMetricsEpsilon:            time.Hour,
+				NumLeaseMetricsTimeBuckets:     168,
+				LeaseMetricsNameSpaceLabels:    false,
+				MaximumUsageMetricsCardinality: 500,
 			},
 			ClusterName: "testcluster",
 		},
@@ -606,23 +611,38 @@ func testConfig_Sanitized(t *testing.T) {
 	sanitizedConfig := config.Sanitized()
 
 	expected := map[string]interface{}{
-		"api_addr":                     "top_level_api_addr",
-		"cache_size":                   0,
-		"cluster_addr":                 "top_level_cluster_addr",
-		"cluster_cipher_suites":        "",
-		"cluster_name":                 "testcluster",
-		"default_lease_ttl":            10 * time.Hour,
-		"default_max_request_duration": 0 * time.Second,
-		"disable_cache":                true,
-		"disable_clustering":           false,
-		"disable_indexing":             false,
-		"disable_mlock":                true,
-		"disable_performance_standby":  false,
-		"disable_printable_check":      false,
-		"disable_sealwrap":             true,
-		"raw_storage_endpoint":         true,
-		"disable_sentinel_trace":       true,
-		"enable_ui":                    true,
+		"api_addr":                       "top_level_api_addr",
+		"cache_size":                     0,
+		"cache_size_bytes":               67108864,
+		"cache_eviction_policy":          "",
+		"cache_metrics_prefixes":         []string{"logical/", "sys/token/"},
+		"cluster_addr":                   "top_level_cluster_addr",
+		"cluster_cipher_suites":          "",
+		"cluster_name":                   "testcluster",
+		"cubbyhole_max_size":             int64(1048576),
+		"default_lease_ttl":              10 * time.Hour,
+		"default_max_request_duration":   0 * time.Second,
+		"disable_cache":                  true,
+		"disable_clustering":             false,
+		"disable_indexing":               false,
+		"disable_mlock":                  true,
+		"disable_performance_standby":    false,
+		"disable_printable_check":        false,
+		"disable_sealwrap":               true,
+		"raw_storage_endpoint":           true,
+		"disable_sentinel_trace":         true,
+		"enable_ui":                      true,
+		"excluded_unauthenticated_paths": []string{"sys/health", "sys/seal-status"},
+		"maintenance_windows": []interface{}{
+			map[string]interface{}{
+				"schedule": "0 2 * * *",
+				"duration": 4 * time.Hour,
+			},
+			map[string]interface{}{
+				"schedule": "0 12 * * SAT,SUN",
+				"duration": 6 * time.Hour,
+			},
+		},
 		"ha_storage": map[string]interface{}{
 			"cluster_addr":       "top_level_cluster_addr",
 			"disable_clustering": true,
@@ -686,6 +706,9 @@ func testConfig_Sanitized(t *testing.T) {
 			"lease_metrics_epsilon":                  time.Hour,
 			"num_lease_metrics_buckets":              168,
 			"add_lease_metrics_namespace_labels":     false,
+			"policy_usage_metrics":                   false,
+			"approle_usage_metrics":                  false,
+			"maximum_usage_metrics_cardinality":      500,
 		},
 	}
 
@@ -806,6 +829,103 @@ func testParseSeals(t *testing.T) {
 	require.Equal(t, config, expected)
 }
 
+func testParseMaintenanceWindows(t *testing.T) {
+	testCases := map[string]struct {
+		hcl       string
+		expected  []*MaintenanceWindow
+		expectErr bool
+	}{
+		"valid": {
+			hcl: `
+maintenance_window {
+	schedule = "30 1 * * *"
+	duration = "90m"
+}`,
+			expected: []*MaintenanceWindow{
+				{Schedule: "30 1 * * *", Duration: 90 * time.Minute},
+			},
+		},
+		"missing duration": {
+			hcl: `
+maintenance_window {
+	schedule = "30 1 * * *"
+}`,
+			expectErr: true,
+		},
+		"invalid schedule": {
+			hcl: `
+maintenance_window {
+	schedule = "nightly"
+	duration = "1h"
+}`,
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config, err := ParseConfig(tc.hcl)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(config.MaintenanceWindows, tc.expected); diff != nil {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func testParseCacheConfig(t *testing.T) {
+	testCases := map[string]struct {
+		hcl       string
+		expectErr bool
+	}{
+		"entry count with lru": {
+			hcl: `
+cache_size = 1024
+cache_eviction_policy = "lru"`,
+		},
+		"size in bytes": {
+			hcl: `
+cache_size_bytes = 67108864`,
+		},
+		"size in bytes with 2q": {
+			hcl: `
+cache_size_bytes = 67108864
+cache_eviction_policy = "2q"`,
+			expectErr: true,
+		},
+		"unknown policy": {
+			hcl: `
+cache_eviction_policy = "fifo"`,
+			expectErr: true,
+		},
+		"negative size in bytes": {
+			hcl: `
+cache_size_bytes = -1`,
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig(tc.hcl)
+			if tc.expectErr && err == nil {
+				t.Fatal("expected error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func testLoadConfigFileLeaseMetrics(t *testing.T) {
 	config, err := LoadConfigFile("./test-fixtures/config5.hcl")
 	if err != nil {
@@ -822,18 +942,21 @@ func testLoadConfigFileLeaseMetrics(t *testing.T) {
 			},
 
 			Telemetry: &configutil.Telemetry{
-				StatsdAddr:                  "bar",
-				StatsiteAddr:                "foo",
-				DisableHostname:             false,
-				UsageGaugePeriod:            5 * time.Minute,
-				MaximumGaugeCardinality:     100,
-				DogStatsDAddr:               "127.0.0.1:7254",
-				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
-				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
-				MetricsPrefix:               "myprefix",
-				LeaseMetricsEpsilon:         time.Hour,
-				NumLeaseMetricsTimeBuckets:  2,
-				LeaseMetricsNameSpaceLabels: true,
+				StatsdAddr:                     "bar",
+				StatsiteAddr:                   "foo",
+				DisableHostname:                false,
+				UsageGaugePeriod:               5 * time.Minute,
+				MaximumGaugeCardinality:        100,
+				DogStatsDAddr:                  "127.0.0.1:7254",
+				DogStatsDTags:                  []string{"tag_1:val_1", "tag_2:val_2"},
+				PrometheusRetentionTime:        configutil.PrometheusDefaultRetentionTime,
+				MetricsPrefix:                  "myprefix",
+				LeaseMetricsEpsilon:            time.Hour,
+				NumLeaseMetricsTimeBuckets:     2,
+				LeaseMetricsNameSpaceLabels:    true,
+				PolicyUsageMetrics:             true,
+				AppRoleUsageMetrics:            true,
+				MaximumUsageMetricsCardinality: 50,
 			},
 
 			DisableMlock: true,
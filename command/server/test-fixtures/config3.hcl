@@ -51,3 +51,17 @@ pid_file = "./pidfile"
 raw_storage_endpoint = true
 disable_sealwrap = true
 disable_sentinel_trace = true
+excluded_unauthenticated_paths = ["sys/health", "sys/seal-status"]
+cubbyhole_max_size = 1048576
+cache_size_bytes = 67108864
+cache_metrics_prefixes = ["logical/", "sys/token/"]
+
+maintenance_window {
+  schedule = "0 2 * * *"
+  duration = "4h"
+}
+
+maintenance_window {
+  schedule = "0 12 * * SAT,SUN"
+  duration = 21600
+}
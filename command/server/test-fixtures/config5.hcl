@@ -36,6 +36,10 @@ disable_cache = true
      lease_metrics_epsilon = "1h"
      num_lease_metrics_buckets = 2
      add_lease_metrics_namespace_labels = true 
+
+     policy_usage_metrics = true
+     approle_usage_metrics = true
+     maximum_usage_metrics_cardinality = 50
  }
 
  sentinel {
@@ -10,6 +10,11 @@ storage "raft" {
     },
     {
     "leader_api_addr" = "http://127.0.0.3:8200"
+    },
+    {
+    "auto_join" = "provider=aws region=eu-west-1 tag_key=vault tag_value=prod"
+    "auto_join_scheme" = "http"
+    "auto_join_port" = 8300
     }
   ]
 }
@@ -19,6 +19,7 @@ var _ TokenHelper = (*InternalTokenHelper)(nil)
 type InternalTokenHelper struct {
 	tokenPath string
 	homeDir   string
+	fileName  string
 }
 
 func NewInternalTokenHelper() (*InternalTokenHelper, error) {
@@ -29,10 +30,26 @@ func NewInternalTokenHelper() (*InternalTokenHelper, error) {
 	return &InternalTokenHelper{homeDir: homeDir}, err
 }
 
+// NewInternalTokenHelperWithFile returns an internal token helper storing the
+// token in the file of the given name in the user's home directory, instead
+// of .vault-token.
+func NewInternalTokenHelperWithFile(fileName string) (*InternalTokenHelper, error) {
+	helper, err := NewInternalTokenHelper()
+	if err != nil {
+		return nil, err
+	}
+	helper.fileName = fileName
+	return helper, nil
+}
+
 // populateTokenPath figures out the token path using homedir to get the user's
 // home directory
 func (i *InternalTokenHelper) populateTokenPath() {
-	i.tokenPath = filepath.Join(i.homeDir, ".vault-token")
+	fileName := i.fileName
+	if fileName == "" {
+		fileName = ".vault-token"
+	}
+	i.tokenPath = filepath.Join(i.homeDir, fileName)
 }
 
 func (i *InternalTokenHelper) Path() string {
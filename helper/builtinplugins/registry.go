@@ -6,32 +6,35 @@ import (
 	credCentrify "github.com/hashicorp/vault-plugin-auth-centrify"
 	credCF "github.com/hashicorp/vault-plugin-auth-cf"
 	credGcp "github.com/hashicorp/vault-plugin-auth-gcp/plugin"
-	credJWT "github.com/hashicorp/vault-plugin-auth-jwt"
 	credKerb "github.com/hashicorp/vault-plugin-auth-kerberos"
 	credKube "github.com/hashicorp/vault-plugin-auth-kubernetes"
 	credOCI "github.com/hashicorp/vault-plugin-auth-oci"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
 
-	dbCouchbase "github.com/hashicorp/vault-plugin-database-couchbase"
-	dbElastic "github.com/hashicorp/vault-plugin-database-elasticsearch"
 	dbMongoAtlas "github.com/hashicorp/vault-plugin-database-mongodbatlas"
 	credAppId "github.com/hashicorp/vault/builtin/credential/app-id"
 	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
+	credJWT "github.com/hashicorp/vault/builtin/credential/jwt"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	dbCass "github.com/hashicorp/vault/plugins/database/cassandra"
+	dbCouchbase "github.com/hashicorp/vault/plugins/database/couchbase"
+	dbElastic "github.com/hashicorp/vault/plugins/database/elasticsearch"
 	dbHana "github.com/hashicorp/vault/plugins/database/hana"
 	dbInflux "github.com/hashicorp/vault/plugins/database/influxdb"
 	dbMongo "github.com/hashicorp/vault/plugins/database/mongodb"
 	dbMssql "github.com/hashicorp/vault/plugins/database/mssql"
 	dbMysql "github.com/hashicorp/vault/plugins/database/mysql"
 	dbPostgres "github.com/hashicorp/vault/plugins/database/postgresql"
+	dbRedis "github.com/hashicorp/vault/plugins/database/redis"
 	dbRedshift "github.com/hashicorp/vault/plugins/database/redshift"
+	dbSnowflake "github.com/hashicorp/vault/plugins/database/snowflake"
+	dbTrino "github.com/hashicorp/vault/plugins/database/trino"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 
@@ -40,12 +43,12 @@ import (
 	logicalAzure "github.com/hashicorp/vault-plugin-secrets-azure"
 	logicalGcp "github.com/hashicorp/vault-plugin-secrets-gcp/plugin"
 	logicalGcpKms "github.com/hashicorp/vault-plugin-secrets-gcpkms"
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	logicalMongoAtlas "github.com/hashicorp/vault-plugin-secrets-mongodbatlas"
 	logicalOpenLDAP "github.com/hashicorp/vault-plugin-secrets-openldap"
 	logicalAws "github.com/hashicorp/vault/builtin/logical/aws"
 	logicalCass "github.com/hashicorp/vault/builtin/logical/cassandra"
 	logicalConsul "github.com/hashicorp/vault/builtin/logical/consul"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 	logicalMongo "github.com/hashicorp/vault/builtin/logical/mongodb"
 	logicalMssql "github.com/hashicorp/vault/builtin/logical/mssql"
 	logicalMysql "github.com/hashicorp/vault/builtin/logical/mysql"
@@ -53,6 +56,7 @@ import (
 	logicalPki "github.com/hashicorp/vault/builtin/logical/pki"
 	logicalPostgres "github.com/hashicorp/vault/builtin/logical/postgresql"
 	logicalRabbit "github.com/hashicorp/vault/builtin/logical/rabbitmq"
+	logicalShare "github.com/hashicorp/vault/builtin/logical/share"
 	logicalSsh "github.com/hashicorp/vault/builtin/logical/ssh"
 	logicalTotp "github.com/hashicorp/vault/builtin/logical/totp"
 	logicalTransit "github.com/hashicorp/vault/builtin/logical/transit"
@@ -109,7 +113,11 @@ func newRegistry() *registry {
 			"mongodbatlas-database-plugin":  dbMongoAtlas.New,
 			"mssql-database-plugin":         dbMssql.New,
 			"postgresql-database-plugin":    dbPostgres.New,
+			"redis-database-plugin":         dbRedis.New,
 			"redshift-database-plugin":      dbRedshift.New,
+			"snowflake-database-plugin":     dbSnowflake.New,
+			"trino-database-plugin":         dbTrino.New,
+			"yugabytedb-database-plugin":    dbPostgres.NewYugabyteDB,
 		},
 		logicalBackends: map[string]logical.Factory{
 			"ad":           logicalAd.Factory,
@@ -130,6 +138,7 @@ func newRegistry() *registry {
 			"pki":          logicalPki.Factory,
 			"postgresql":   logicalPostgres.Factory, // Deprecated
 			"rabbitmq":     logicalRabbit.Factory,
+			"share":        logicalShare.Factory,
 			"ssh":          logicalSsh.Factory,
 			"totp":         logicalTotp.Factory,
 			"transit":      logicalTransit.Factory,
@@ -0,0 +1,46 @@
+package metricsutil
+
+import "sync"
+
+// OverflowLabelValue is reported in place of the label values seen after a
+// CardinalityLimiter has reached its limit.
+const OverflowLabelValue = "_other"
+
+// CardinalityLimiter bounds the number of distinct values of a metric label.
+// The first values seen are reported as they are, and any new value seen
+// once the limit is reached is reported as OverflowLabelValue.
+type CardinalityLimiter struct {
+	max int
+
+	l    sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewCardinalityLimiter returns a limiter allowing up to max distinct values,
+// or any number of values if max is not positive.
+func NewCardinalityLimiter(max int) *CardinalityLimiter {
+	return &CardinalityLimiter{
+		max:  max,
+		seen: make(map[string]struct{}),
+	}
+}
+
+// Allow returns whether the value is one of the values allowed by the limit,
+// adding it to the allowed values if the limit has not been reached yet.
+func (c *CardinalityLimiter) Allow(value string) bool {
+	if c.max <= 0 {
+		return true
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if _, ok := c.seen[value]; ok {
+		return true
+	}
+	if len(c.seen) >= c.max {
+		return false
+	}
+	c.seen[value] = struct{}{}
+	return true
+}
@@ -0,0 +1,32 @@
+package metricsutil
+
+import "testing"
+
+func TestCardinalityLimiter_Allow(t *testing.T) {
+	c := NewCardinalityLimiter(2)
+
+	testCases := []struct {
+		Input    string
+		Expected bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", true},
+		{"c", false},
+		{"b", true},
+		{"c", false},
+	}
+
+	for _, tc := range testCases {
+		if allowed := c.Allow(tc.Input); allowed != tc.Expected {
+			t.Errorf("Expected %v, got %v for value %q.", tc.Expected, allowed, tc.Input)
+		}
+	}
+
+	unlimited := NewCardinalityLimiter(0)
+	for _, v := range []string{"a", "b", "c"} {
+		if !unlimited.Allow(v) {
+			t.Errorf("Value %q not allowed without limit.", v)
+		}
+	}
+}
@@ -37,6 +37,9 @@ type TelemetryConstConfig struct {
 	LeaseMetricsEpsilon         time.Duration
 	NumLeaseMetricsTimeBuckets  int
 	LeaseMetricsNameSpaceLabels bool
+	PolicyUsageMetrics          bool
+	AppRoleUsageMetrics         bool
+	MaxUsageMetricsCardinality  int
 }
 
 type Metrics interface {
@@ -145,5 +145,12 @@ func duoHandler(duoConfig *DuoConfig, duoAuthClient AuthClient, request *duoAuth
 		return logical.ErrorResponse(result.Response.Status_Msg), nil
 	}
 
+	// Only a login through Duo auth satisfies a second factor, as opposed
+	// to users allowed by preauth
+	if request.successResp.Auth.Session == nil {
+		request.successResp.Auth.Session = new(logical.AuthSession)
+	}
+	request.successResp.Auth.Session.MFA = true
+
 	return request.successResp, nil
 }
@@ -86,6 +86,9 @@ func TestDuoHandlerSuccess(t *testing.T) {
 	if resp != successResp {
 		t.Fatalf("Testing Duo authentication gave incorrect response (expected success, got: %v)", resp)
 	}
+	if resp.Auth.Session == nil || !resp.Auth.Session.MFA {
+		t.Fatalf("Testing Duo authentication did not record MFA on the session: %#v", resp.Auth.Session)
+	}
 }
 
 func TestDuoHandlerReject(t *testing.T) {
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"net/textproto"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -134,6 +136,7 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/v1/sys/leader", handleSysLeader(core))
 		mux.Handle("/v1/sys/health", handleSysHealth(core))
 		mux.Handle("/v1/sys/monitor", handleLogicalNoForward(core))
+		mux.Handle("/v1/sys/events/subscribe/", handleLogicalNoForward(core))
 		mux.Handle("/v1/sys/generate-root/attempt", handleRequestForwarding(core,
 			handleAuditNonLogical(core, handleSysGenerateRootAttempt(core, vault.GenerateStandardRootTokenStrategy))))
 		mux.Handle("/v1/sys/generate-root/update", handleRequestForwarding(core,
@@ -141,9 +144,11 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/v1/sys/rekey/init", handleRequestForwarding(core, handleSysRekeyInit(core, false)))
 		mux.Handle("/v1/sys/rekey/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, false)))
 		mux.Handle("/v1/sys/rekey/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, false)))
+		mux.Handle("/v1/sys/rekey/pause", handleRequestForwarding(core, handleSysRekeyPause(core, false)))
 		mux.Handle("/v1/sys/rekey-recovery-key/init", handleRequestForwarding(core, handleSysRekeyInit(core, true)))
 		mux.Handle("/v1/sys/rekey-recovery-key/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, true)))
 		mux.Handle("/v1/sys/rekey-recovery-key/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, true)))
+		mux.Handle("/v1/sys/rekey-recovery-key/pause", handleRequestForwarding(core, handleSysRekeyPause(core, true)))
 		mux.Handle("/v1/sys/storage/raft/bootstrap", handleSysRaftBootstrap(core))
 		mux.Handle("/v1/sys/storage/raft/join", handleSysRaftJoin(core))
 		for _, path := range injectDataIntoTopRoutes {
@@ -176,7 +181,8 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 	// Wrap the handler in another handler to trigger all help paths.
 	helpWrappedHandler := wrapHelpHandler(mux, core)
 	corsWrappedHandler := wrapCORSHandler(helpWrappedHandler, core)
-	quotaWrappedHandler := rateLimitQuotaWrapping(corsWrappedHandler, core)
+	concurrencyWrappedHandler := concurrencyQuotaWrapping(corsWrappedHandler, core)
+	quotaWrappedHandler := rateLimitQuotaWrapping(concurrencyWrappedHandler, core)
 	genericWrappedHandler := genericWrapping(core, quotaWrappedHandler, props)
 
 	// Wrap the handler with PrintablePathCheckHandler to check for non-printable
@@ -297,6 +303,12 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 
 		switch {
 		case strings.HasPrefix(r.URL.Path, "/v1/"):
+			if !listenerAllowsPath(props.ListenerConfig, r.URL.Path) {
+				respondError(w, http.StatusForbidden, errors.New("path is not reachable through this listener"))
+				cancelFunc()
+				return
+			}
+
 			newR, status := adjustRequest(core, r)
 			if status != 0 {
 				respondError(w, status, nil)
@@ -319,6 +331,52 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 	})
 }
 
+// listenerAllowsPath reports whether the listener's allowed_paths and
+// allowed_auth_methods settings permit a request to the given URL path. Paths
+// under auth/ are checked against allowed_auth_methods when it is set, and
+// against allowed_paths otherwise.
+func listenerAllowsPath(l *configutil.Listener, urlPath string) bool {
+	if l == nil || (len(l.AllowedPaths) == 0 && len(l.AllowedAuthMethods) == 0) {
+		return true
+	}
+
+	// Clean the path so that dot segments cannot be used to escape an allowed
+	// prefix, keeping any trailing slash used by list requests.
+	p := path.Clean(urlPath)
+	if strings.HasSuffix(urlPath, "/") && p != "/" {
+		p += "/"
+	}
+	if !strings.HasPrefix(p, "/v1/") {
+		return false
+	}
+	p = strings.TrimPrefix(p, "/v1/")
+
+	if strings.HasPrefix(p, "auth/") && len(l.AllowedAuthMethods) > 0 {
+		for _, mount := range l.AllowedAuthMethods {
+			if strings.HasPrefix(p+"/", "auth/"+mount) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(l.AllowedPaths) == 0 {
+		return true
+	}
+	for _, allowed := range l.AllowedPaths {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(p, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+			continue
+		}
+		if p == allowed {
+			return true
+		}
+	}
+	return false
+}
+
 func WrapForwardedForHandler(h http.Handler, l *configutil.Listener) http.Handler {
 	rejectNotPresent := l.XForwardedForRejectNotPresent
 	hopSkips := l.XForwardedForHopSkips
@@ -664,6 +722,76 @@ func parseFormRequest(r *http.Request) (map[string]interface{}, error) {
 	return data, nil
 }
 
+// isOCSPRequest returns whether the request body is a DER encoded OCSP
+// request, which can't be parsed as JSON or form data.
+func isOCSPRequest(contentType string) bool {
+	contentType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && contentType == "application/ocsp-request"
+}
+
+// parseOCSPRequest reads an OCSP request body into the base64 encoded "req"
+// field expected by OCSP responders.
+func parseOCSPRequest(perfStandby bool, r *http.Request) (map[string]interface{}, io.ReadCloser, error) {
+	body, origBody, err := readRawRequest(perfStandby, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := map[string]interface{}{
+		"req": base64.StdEncoding.EncodeToString(body),
+	}
+	return data, origBody, nil
+}
+
+// isPKCS10Request returns whether the request body is a base64 encoded
+// PKCS#10 CSR, as sent by EST (RFC 7030) clients.
+func isPKCS10Request(contentType string) bool {
+	contentType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && contentType == "application/pkcs10"
+}
+
+// parsePKCS10Request reads a PKCS#10 request body, which is already base64
+// encoded, into the "csr" field expected by EST endpoints.
+func parsePKCS10Request(perfStandby bool, r *http.Request) (map[string]interface{}, io.ReadCloser, error) {
+	body, origBody, err := readRawRequest(perfStandby, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := map[string]interface{}{
+		"csr": string(body),
+	}
+	return data, origBody, nil
+}
+
+// readRawRequest reads the request body, up to the maximum request size. If
+// perfStandby is set, a copy of the body is returned so that the request can
+// be forwarded.
+func readRawRequest(perfStandby bool, r *http.Request) ([]byte, io.ReadCloser, error) {
+	reader := r.Body
+	maxRequestSize := r.Context().Value("max_request_size")
+	if maxRequestSize != nil {
+		max, ok := maxRequestSize.(int64)
+		if !ok {
+			return nil, nil, errors.New("could not parse max_request_size from request context")
+		}
+		if max > 0 {
+			reader = ioutil.NopCloser(io.LimitReader(r.Body, max))
+		}
+	}
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var origBody io.ReadCloser
+	if perfStandby {
+		origBody = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return body, origBody, nil
+}
+
 // handleRequestForwarding determines whether to forward a request or not,
 // falling back on the older behavior of redirecting the client
 func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handler {
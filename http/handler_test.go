@@ -18,6 +18,7 @@ import (
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
@@ -224,6 +225,49 @@ func TestHandler_CacheControlNoStore(t *testing.T) {
 	}
 }
 
+func TestHandler_ListenerAllowedPaths(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	props := &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			AllowedPaths:       []string{"sys/health", "secret/public/*"},
+			AllowedAuthMethods: []string{"userpass/"},
+		},
+	}
+	TestServerWithListenerAndProperties(t, ln, addr, core, props)
+	defer ln.Close()
+
+	cases := map[string]bool{
+		"/v1/sys/health":                      true,
+		"/v1/secret/public/foo":               true,
+		"/v1/secret/public/":                  true,
+		"/v1/auth/userpass/login/foo":         true,
+		"/v1/sys/health/":                     false,
+		"/v1/sys/mounts":                      false,
+		"/v1/secret/private/foo":              false,
+		"/v1/secret/public/../private/foo":    false,
+		"/v1/auth/token/lookup-self":          false,
+		"/v1/auth/userpass-other/login/foo":   false,
+		"/v1/secret/public/foo/../../private": false,
+	}
+	for p, allowed := range cases {
+		req, err := http.NewRequest("GET", addr+p, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if allowed == (resp.StatusCode == http.StatusForbidden) {
+			t.Fatalf("%s: unexpected status %d", p, resp.StatusCode)
+		}
+	}
+}
+
 // TestHandler_MissingToken tests the response / error code if a request comes
 // in with a missing client token. See
 // https://github.com/hashicorp/vault/issues/8377
@@ -89,6 +89,9 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 		case path == "sys/monitor":
 			passHTTPReq = true
 			responseWriter = w
+		case strings.HasPrefix(path, "sys/events/subscribe/"):
+			passHTTPReq = true
+			responseWriter = w
 		}
 
 	case "POST", "PUT":
@@ -115,14 +118,28 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 				return nil, nil, http.StatusBadRequest, err
 			}
 
-			if isForm(head, r.Header.Get("Content-Type")) {
+			switch {
+			case isOCSPRequest(r.Header.Get("Content-Type")):
+				data, origBody, err = parseOCSPRequest(perfStandby, r)
+				if err != nil {
+					return nil, nil, http.StatusBadRequest, fmt.Errorf("error reading OCSP request: %w", err)
+				}
+
+			case isPKCS10Request(r.Header.Get("Content-Type")):
+				data, origBody, err = parsePKCS10Request(perfStandby, r)
+				if err != nil {
+					return nil, nil, http.StatusBadRequest, fmt.Errorf("error reading PKCS#10 request: %w", err)
+				}
+
+			case isForm(head, r.Header.Get("Content-Type")):
 				formData, err := parseFormRequest(r)
 				if err != nil {
 					return nil, nil, http.StatusBadRequest, fmt.Errorf("error parsing form data: %w", err)
 				}
 
 				data = formData
-			} else {
+
+			default:
 				origBody, err = parseJSONRequest(perfStandby, r, w, &data)
 				if err == io.EOF {
 					data = nil
@@ -445,6 +462,11 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool, noForw
 			// taken care of setting the appropriate response code and payload
 			// in this case.
 			return
+		case r.Method == "GET" && logical.ETagMatches(r.Header["If-None-Match"], resp.ETag()) && resp.WrapInfo == nil:
+			// The client already has the current response; the ETag header
+			// has been set by the call on request
+			w.WriteHeader(http.StatusNotModified)
+			return
 		default:
 			// Build and return the proper response if everything is fine.
 			respondLogical(w, r, req, resp, injectDataIntoTopLevel)
@@ -603,6 +625,10 @@ WRITE_RESPONSE:
 		w.Header().Set("Cache-Control", cacheControl)
 	}
 
+	if encoding, ok := resp.Data[logical.HTTPRawContentTransferEncoding].(string); ok {
+		w.Header().Set("Content-Transfer-Encoding", encoding)
+	}
+
 	w.WriteHeader(status)
 	w.Write(body)
 }
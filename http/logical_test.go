@@ -15,7 +15,9 @@ import (
 	"time"
 
 	"github.com/go-test/deep"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	log "github.com/hashicorp/go-hclog"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
 
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -261,6 +263,113 @@ func TestLogical_RawHTTP(t *testing.T) {
 	}
 }
 
+func TestLogical_ETag(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": kv.Factory,
+		},
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/kv", map[string]interface{}{
+		"type":    "kv",
+		"options": map[string]interface{}{"version": "2"},
+	})
+	testResponseStatus(t, resp, 204)
+
+	// The mount is upgraded in the background
+	for i := 0; i < 50; i++ {
+		resp = testHttpPut(t, token, addr+"/v1/kv/data/foo", map[string]interface{}{
+			"data": map[string]interface{}{"bar": "baz"},
+		})
+		if resp.StatusCode == 200 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	testResponseStatus(t, resp, 200)
+
+	conditionalGet := func(etag string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", addr+"/v1/kv/data/foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		req.Header.Set("If-None-Match", etag)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/kv/data/foo")
+	testResponseStatus(t, resp, 200)
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("missing ETag: %#v", resp.Header)
+	}
+
+	resp = conditionalGet(etag)
+	testResponseStatus(t, resp, 304)
+	if resp.Header.Get("ETag") != etag {
+		t.Fatalf("bad ETag: %#v", resp.Header)
+	}
+	if body, _ := ioutil.ReadAll(resp.Body); len(body) != 0 {
+		t.Fatalf("unexpected body: %s", body)
+	}
+
+	resp = conditionalGet(`"other", W/` + etag)
+	testResponseStatus(t, resp, 304)
+
+	// A new version changes the tag
+	resp = testHttpPut(t, token, addr+"/v1/kv/data/foo", map[string]interface{}{
+		"data": map[string]interface{}{"bar": "qux"},
+	})
+	testResponseStatus(t, resp, 200)
+	resp = conditionalGet(etag)
+	testResponseStatus(t, resp, 200)
+	newETag := resp.Header.Get("ETag")
+	if newETag == etag {
+		t.Fatalf("ETag did not change")
+	}
+
+	// The tag is derived from the version, so writing the same values again
+	// changes it too
+	resp = testHttpPut(t, token, addr+"/v1/kv/data/foo", map[string]interface{}{
+		"data": map[string]interface{}{"bar": "qux"},
+	})
+	testResponseStatus(t, resp, 200)
+	resp = conditionalGet(newETag)
+	testResponseStatus(t, resp, 200)
+	if resp.Header.Get("ETag") == newETag {
+		t.Fatalf("ETag did not change")
+	}
+
+	// Reading the first version still returns its tag
+	req, err := http.NewRequest("GET", addr+"/v1/kv/data/foo?version=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(consts.AuthHeaderName, token)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testResponseStatus(t, resp, 304)
+
+	// Metadata reads are not tagged
+	resp = testHttpGet(t, token, addr+"/v1/kv/metadata/foo")
+	testResponseStatus(t, resp, 200)
+	if resp.Header.Get("ETag") != "" {
+		t.Fatalf("unexpected ETag: %#v", resp.Header)
+	}
+}
+
 func TestLogical_RequestSizeLimit(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
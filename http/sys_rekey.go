@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/sdk/helper/consts"
@@ -92,6 +93,14 @@ func handleSysRekeyInitGet(ctx context.Context, core *vault.Core, recovery bool,
 		status.Progress = progress
 		status.VerificationRequired = rekeyConf.VerificationRequired
 		status.VerificationNonce = rekeyConf.VerificationNonce
+		status.Paused = rekeyConf.RekeyPaused
+		for _, submission := range rekeyConf.RekeySubmissions {
+			status.SubmittedShares = append(status.SubmittedShares, &RekeySubmittedShare{
+				KeyHash:      submission.KeyHash,
+				SubmittedAt:  submission.Time,
+				Verification: submission.Verification,
+			})
+		}
 		if rekeyConf.PGPKeys != nil && len(rekeyConf.PGPKeys) != 0 {
 			pgpFingerprints, err := pgpkeys.GetFingerprints(rekeyConf.PGPKeys, nil)
 			if err != nil {
@@ -148,6 +157,40 @@ func handleSysRekeyInitDelete(ctx context.Context, core *vault.Core, recovery bo
 	respondOk(w, nil)
 }
 
+func handleSysRekeyPause(core *vault.Core, recovery bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		standby, _ := core.Standby()
+		if standby {
+			respondStandby(core, w, r.URL)
+			return
+		}
+
+		ctx, cancel := core.GetContext()
+		defer cancel()
+
+		var paused bool
+		switch {
+		case recovery && !core.SealAccess().RecoveryKeySupported():
+			respondError(w, http.StatusBadRequest, fmt.Errorf("recovery rekeying not supported"))
+			return
+		case r.Method == "POST" || r.Method == "PUT":
+			paused = true
+		case r.Method == "DELETE":
+			paused = false
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		if err := core.RekeyPause(ctx, recovery, paused); err != nil {
+			respondError(w, err.Code(), err)
+			return
+		}
+
+		handleSysRekeyInitGet(ctx, core, recovery, w, r)
+	})
+}
+
 func handleSysRekeyUpdate(core *vault.Core, recovery bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		standby, _ := core.Standby()
@@ -364,16 +407,26 @@ type RekeyRequest struct {
 }
 
 type RekeyStatusResponse struct {
-	Nonce                string   `json:"nonce"`
-	Started              bool     `json:"started"`
-	T                    int      `json:"t"`
-	N                    int      `json:"n"`
-	Progress             int      `json:"progress"`
-	Required             int      `json:"required"`
-	PGPFingerprints      []string `json:"pgp_fingerprints"`
-	Backup               bool     `json:"backup"`
-	VerificationRequired bool     `json:"verification_required"`
-	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+	Nonce                string                 `json:"nonce"`
+	Started              bool                   `json:"started"`
+	T                    int                    `json:"t"`
+	N                    int                    `json:"n"`
+	Progress             int                    `json:"progress"`
+	Required             int                    `json:"required"`
+	PGPFingerprints      []string               `json:"pgp_fingerprints"`
+	Backup               bool                   `json:"backup"`
+	VerificationRequired bool                   `json:"verification_required"`
+	VerificationNonce    string                 `json:"verification_nonce,omitempty"`
+	Paused               bool                   `json:"paused,omitempty"`
+	SubmittedShares      []*RekeySubmittedShare `json:"submitted_shares,omitempty"`
+}
+
+// RekeySubmittedShare identifies a key share submitted during a rekey
+// operation by its SHA-256 hash.
+type RekeySubmittedShare struct {
+	KeyHash      string    `json:"key_hash"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+	Verification bool      `json:"verification"`
 }
 
 type RekeyUpdateRequest struct {
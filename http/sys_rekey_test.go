@@ -216,6 +216,15 @@ func TestSysRekey_Update(t *testing.T) {
 			testResponseStatus(t, resp, 200)
 			testResponseBody(t, resp, &actual)
 
+			if i+1 < len(keys) {
+				// Each submitted share is recorded by hash
+				submitted, _ := actual["submitted_shares"].([]interface{})
+				if len(submitted) != i+1 {
+					t.Fatalf("expected %d submitted shares, got %#v", i+1, actual["submitted_shares"])
+				}
+				expected["submitted_shares"] = actual["submitted_shares"]
+			}
+
 			if i+1 == len(keys) {
 				delete(expected, "started")
 				delete(expected, "required")
@@ -98,6 +98,51 @@ func rateLimitQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler
 	})
 }
 
+// concurrencyQuotaWrapping holds a slot of the applicable concurrency quota
+// while the request is handled, rejecting it if no slot is freed in time.
+func concurrencyQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns, err := namespace.FromContext(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		path, status, err := buildLogicalPath(r)
+		if err != nil || status != 0 {
+			respondError(w, status, err)
+			return
+		}
+
+		quotaResp, err := core.ApplyConcurrencyQuota(&quotas.Request{
+			Path:          path,
+			MountPath:     strings.TrimPrefix(core.MatchingMount(r.Context(), path), ns.Path),
+			NamespacePath: ns.Path,
+			ClientAddress: parseRemoteIPAddress(r),
+		})
+		if err != nil {
+			core.Logger().Error("failed to apply quota", "path", path, "error", err)
+			respondError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if !quotaResp.Allowed {
+			quotaErr := errwrap.Wrapf(fmt.Sprintf("request path %q: {{err}}", path), quotas.ErrConcurrencyQuotaExceeded)
+			respondError(w, http.StatusTooManyRequests, quotaErr)
+
+			if core.Logger().IsTrace() {
+				core.Logger().Trace("request rejected due to concurrency quota violation", "request_path", path)
+			}
+			return
+		}
+		if quotaResp.Release != nil {
+			defer quotaResp.Release()
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
 func parseRemoteIPAddress(r *http.Request) string {
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
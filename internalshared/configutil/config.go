@@ -217,6 +217,9 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			"lease_metrics_epsilon":                  c.Telemetry.LeaseMetricsEpsilon,
 			"num_lease_metrics_buckets":              c.Telemetry.NumLeaseMetricsTimeBuckets,
 			"add_lease_metrics_namespace_labels":     c.Telemetry.LeaseMetricsNameSpaceLabels,
+			"policy_usage_metrics":                   c.Telemetry.PolicyUsageMetrics,
+			"approle_usage_metrics":                  c.Telemetry.AppRoleUsageMetrics,
+			"maximum_usage_metrics_cardinality":      c.Telemetry.MaximumUsageMetricsCardinality,
 		}
 		result["telemetry"] = sanitizedTelemetry
 	}
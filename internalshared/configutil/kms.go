@@ -21,10 +21,12 @@ import (
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/seal/tpm"
 )
 
 var (
 	ConfigureWrapper             = configureWrapper
+	ConfigureSealWrapper         = configureSealWrapper
 	CreateSecureRandomReaderFunc = createSecureRandomReader
 )
 
@@ -213,6 +215,32 @@ func configureWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]strin
 	return wrapper, nil
 }
 
+// configureSealWrapper configures the wrapper of the seal of the server. On
+// top of the KMS types of configureWrapper, which can also be configured
+// through the API, it supports the types which use resources of the host of
+// the server, such as the TPM, and which must only be set by its operator.
+func configureSealWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]string, logger hclog.Logger) (wrapping.Wrapper, error) {
+	if configKMS.Type != tpm.SealType {
+		return ConfigureWrapper(configKMS, infoKeys, info, logger)
+	}
+
+	wrapper, kmsInfo, err := GetTPMKMSFunc(&wrapping.WrapperOptions{
+		Logger: logger,
+	}, configKMS)
+	if err != nil {
+		return nil, err
+	}
+
+	if infoKeys != nil && info != nil {
+		for k, v := range kmsInfo {
+			*infoKeys = append(*infoKeys, k)
+			(*info)[k] = v
+		}
+	}
+
+	return wrapper, nil
+}
+
 func GetAEADKMSFunc(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.Wrapper, map[string]string, error) {
 	wrapper := aeadwrapper.NewWrapper(opts)
 	wrapperInfo, err := wrapper.SetConfig(kms.Config)
@@ -344,6 +372,21 @@ var GetTransitKMSFunc = func(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.
 	return wrapper, info, nil
 }
 
+func GetTPMKMSFunc(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.Wrapper, map[string]string, error) {
+	wrapper := tpm.NewWrapper(opts)
+	wrapperInfo, err := wrapper.SetConfig(kms.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := make(map[string]string)
+	if wrapperInfo != nil {
+		info["TPM Device"] = wrapperInfo["device"]
+		info["TPM Sealed Key Path"] = wrapperInfo["sealed_key_path"]
+		info["TPM PCRs"] = fmt.Sprintf("%s:%s", wrapperInfo["pcr_bank"], wrapperInfo["pcrs"])
+	}
+	return wrapper, info, nil
+}
+
 func createSecureRandomReader(conf *SharedConfig, wrapper wrapping.Wrapper) (io.Reader, error) {
 	return rand.Reader, nil
 }
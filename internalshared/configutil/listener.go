@@ -16,6 +16,11 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/tlsutil"
 )
 
+// AgentAPI configures the endpoints of Vault Agent served by a listener.
+type AgentAPI struct {
+	EnableReload bool `hcl:"enable_reload"`
+}
+
 type ListenerTelemetry struct {
 	UnauthenticatedMetricsAccess    bool        `hcl:"-"`
 	UnauthenticatedMetricsAccessRaw interface{} `hcl:"unauthenticated_metrics_access"`
@@ -81,6 +86,14 @@ type Listener struct {
 
 	Telemetry ListenerTelemetry `hcl:"telemetry"`
 
+	// AgentAPI is only used by Vault Agent
+	AgentAPI *AgentAPI `hcl:"agent_api"`
+
+	AllowedPaths          []string    `hcl:"-"`
+	AllowedPathsRaw       interface{} `hcl:"allowed_paths"`
+	AllowedAuthMethods    []string    `hcl:"-"`
+	AllowedAuthMethodsRaw interface{} `hcl:"allowed_auth_methods"`
+
 	// RandomPort is used only for some testing purposes
 	RandomPort bool `hcl:"-"`
 
@@ -318,6 +331,39 @@ func ParseListeners(result *SharedConfig, list *ast.ObjectList) error {
 			}
 		}
 
+		// Access restrictions
+		{
+			if l.AllowedPathsRaw != nil {
+				if l.AllowedPaths, err = parseutil.ParseCommaStringSlice(l.AllowedPathsRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("invalid value for allowed_paths: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				for j, v := range l.AllowedPaths {
+					v = strings.TrimPrefix(v, "/")
+					if v == "" || strings.Contains(strings.TrimSuffix(v, "*"), "*") {
+						return multierror.Prefix(fmt.Errorf("invalid path %q in allowed_paths: only a trailing glob is supported", l.AllowedPaths[j]), fmt.Sprintf("listeners.%d", i))
+					}
+					l.AllowedPaths[j] = v
+				}
+
+				l.AllowedPathsRaw = nil
+			}
+
+			if l.AllowedAuthMethodsRaw != nil {
+				if l.AllowedAuthMethods, err = parseutil.ParseCommaStringSlice(l.AllowedAuthMethodsRaw); err != nil {
+					return multierror.Prefix(fmt.Errorf("invalid value for allowed_auth_methods: %w", err), fmt.Sprintf("listeners.%d", i))
+				}
+				for j, v := range l.AllowedAuthMethods {
+					v = strings.Trim(v, "/")
+					if v == "" {
+						return multierror.Prefix(errors.New("allowed_auth_methods cannot contain an empty mount path"), fmt.Sprintf("listeners.%d", i))
+					}
+					l.AllowedAuthMethods[j] = strings.TrimPrefix(v, "auth/") + "/"
+				}
+
+				l.AllowedAuthMethodsRaw = nil
+			}
+		}
+
 		// CORS
 		{
 			if l.CorsEnabledRaw != nil {
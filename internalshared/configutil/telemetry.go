@@ -29,6 +29,8 @@ const (
 	MaximumGaugeCardinalityDefault    = 500
 	LeaseMetricsEpsilonDefault        = time.Hour
 	NumLeaseMetricsTimeBucketsDefault = 168
+
+	MaximumUsageMetricsCardinalityDefault = 500
 )
 
 // Telemetry is the telemetry configuration for the server
@@ -149,6 +151,15 @@ type Telemetry struct {
 
 	// Whether or not telemetry should add labels for namespaces
 	LeaseMetricsNameSpaceLabels bool `hcl:"add_lease_metrics_namespace_labels"`
+
+	// Whether or not telemetry should count requests by the policy granting
+	// them, and by the AppRole role of their token
+	PolicyUsageMetrics  bool `hcl:"policy_usage_metrics"`
+	AppRoleUsageMetrics bool `hcl:"approle_usage_metrics"`
+
+	// Maximum number of distinct policies, and of distinct roles, that
+	// requests are counted by
+	MaximumUsageMetricsCardinality int `hcl:"maximum_usage_metrics_cardinality"`
 }
 
 func (t *Telemetry) GoString() string {
@@ -217,6 +228,10 @@ func parseTelemetry(result *SharedConfig, list *ast.ObjectList) error {
 		result.Telemetry.NumLeaseMetricsTimeBuckets = NumLeaseMetricsTimeBucketsDefault
 	}
 
+	if result.Telemetry.MaximumUsageMetricsCardinality == 0 {
+		result.Telemetry.MaximumUsageMetricsCardinality = MaximumUsageMetricsCardinalityDefault
+	}
+
 	return nil
 }
 
@@ -383,6 +398,9 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 	wrapper.TelemetryConsts.LeaseMetricsEpsilon = opts.Config.LeaseMetricsEpsilon
 	wrapper.TelemetryConsts.LeaseMetricsNameSpaceLabels = opts.Config.LeaseMetricsNameSpaceLabels
 	wrapper.TelemetryConsts.NumLeaseMetricsTimeBuckets = opts.Config.NumLeaseMetricsTimeBuckets
+	wrapper.TelemetryConsts.PolicyUsageMetrics = opts.Config.PolicyUsageMetrics
+	wrapper.TelemetryConsts.AppRoleUsageMetrics = opts.Config.AppRoleUsageMetrics
+	wrapper.TelemetryConsts.MaxUsageMetricsCardinality = opts.Config.MaximumUsageMetricsCardinality
 
 	return inm, wrapper, prometheusEnabled, nil
 }
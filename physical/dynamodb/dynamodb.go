@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
 	"net/http"
 	"os"
 	pkgPath "path"
@@ -67,6 +68,27 @@ const (
 	// DynamoDBWatchRetryInterval is the amount of time to wait
 	// if a watch fails before trying again.
 	DynamoDBWatchRetryInterval = 5 * time.Second
+
+	// DynamoDBTTLAttribute is the attribute of lock records holding
+	// the time, in seconds since the epoch, after which DynamoDB's
+	// Time to Live feature may delete them.
+	DynamoDBTTLAttribute = "TTL"
+	// DynamoDBLockTombstoneTTL is the amount of time a lock record
+	// is kept after it expired. Past that, it is a tombstone left
+	// behind by an instance that stopped without releasing the lock.
+	DynamoDBLockTombstoneTTL = time.Hour
+	// DynamoDBLockCleanupInterval is the amount of time to wait
+	// between two sweeps of the lock tombstones.
+	DynamoDBLockCleanupInterval = 10 * time.Minute
+
+	// DynamoDBBatchWriteRetryMax is the number of times the items of
+	// a batch write that DynamoDB did not process, usually because
+	// of throttling, are retried.
+	DynamoDBBatchWriteRetryMax = 8
+	// DynamoDBBatchWriteMinBackoff and DynamoDBBatchWriteMaxBackoff
+	// bound the exponential backoff between these retries.
+	DynamoDBBatchWriteMinBackoff = 50 * time.Millisecond
+	DynamoDBBatchWriteMaxBackoff = 5 * time.Second
 )
 
 // Verify DynamoDBBackend satisfies the correct interfaces
@@ -83,6 +105,16 @@ type DynamoDBBackend struct {
 	logger     log.Logger
 	haEnabled  bool
 	permitPool *physical.PermitPool
+
+	// lockKeys holds the keys of the locks created by this backend,
+	// whose tombstones are periodically cleaned up while heldLocks,
+	// the number of locks this backend holds, is not zero.
+	// cleanupStopCh stops the cleanup once they are all released,
+	// which Vault does when it steps down or shuts down.
+	lockKeys      map[string]struct{}
+	heldLocks     int
+	cleanupStopCh chan struct{}
+	lockKeysLock  sync.Mutex
 }
 
 // DynamoDBRecord is the representation of a vault entry in
@@ -113,6 +145,7 @@ type DynamoDBLockRecord struct {
 	Value    []byte
 	Identity []byte
 	Expires  int64
+	TTL      int64
 }
 
 // NewDynamoDBBackend constructs a DynamoDB backend. If the
@@ -155,6 +188,20 @@ func NewDynamoDBBackend(conf map[string]string, logger log.Logger) (physical.Bac
 		writeCapacity = DefaultDynamoDBWriteCapacity
 	}
 
+	billingMode := os.Getenv("AWS_DYNAMODB_BILLING_MODE")
+	if billingMode == "" {
+		billingMode = conf["billing_mode"]
+		if billingMode == "" {
+			billingMode = dynamodb.BillingModeProvisioned
+		}
+	}
+	billingMode = strings.ToUpper(billingMode)
+	switch billingMode {
+	case dynamodb.BillingModeProvisioned, dynamodb.BillingModePayPerRequest:
+	default:
+		return nil, fmt.Errorf("invalid billing mode: %q", billingMode)
+	}
+
 	endpoint := os.Getenv("AWS_DYNAMODB_ENDPOINT")
 	if endpoint == "" {
 		endpoint = conf["endpoint"]
@@ -213,10 +260,20 @@ func NewDynamoDBBackend(conf map[string]string, logger log.Logger) (physical.Bac
 
 	client := dynamodb.New(awsSession)
 
-	if err := ensureTableExists(client, table, readCapacity, writeCapacity); err != nil {
+	if err := ensureTableExists(client, table, billingMode, readCapacity, writeCapacity, logger); err != nil {
 		return nil, err
 	}
 
+	if checkPITRStr, ok := conf["check_point_in_time_recovery"]; ok {
+		checkPITR, err := strconv.ParseBool(checkPITRStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing check_point_in_time_recovery parameter: {{err}}", err)
+		}
+		if checkPITR {
+			checkPointInTimeRecovery(client, table, logger)
+		}
+	}
+
 	haEnabled := os.Getenv("DYNAMODB_HA_ENABLED")
 	if haEnabled == "" {
 		haEnabled = conf["ha_enabled"]
@@ -241,6 +298,7 @@ func NewDynamoDBBackend(conf map[string]string, logger log.Logger) (physical.Bac
 		permitPool: physical.NewPermitPool(maxParInt),
 		haEnabled:  haEnabledBool,
 		logger:     logger,
+		lockKeys:   make(map[string]struct{}),
 	}, nil
 }
 
@@ -477,9 +535,12 @@ func (d *DynamoDBBackend) LockWith(key, value string) (physical.Lock, error) {
 	if err != nil {
 		return nil, err
 	}
+	lockKey := pkgPath.Join(pkgPath.Dir(key), DynamoDBLockPrefix+pkgPath.Base(key))
+	d.trackLockKey(lockKey)
+
 	return &DynamoDBLock{
 		backend:            d,
-		key:                pkgPath.Join(pkgPath.Dir(key), DynamoDBLockPrefix+pkgPath.Base(key)),
+		key:                lockKey,
 		value:              value,
 		identity:           identity,
 		renewInterval:      DynamoDBLockRenewInterval,
@@ -492,6 +553,99 @@ func (d *DynamoDBBackend) HAEnabled() bool {
 	return d.haEnabled
 }
 
+// trackLockKey records the key of a lock so that its tombstone gets cleaned
+// up.
+func (d *DynamoDBBackend) trackLockKey(key string) {
+	d.lockKeysLock.Lock()
+	d.lockKeys[key] = struct{}{}
+	d.lockKeysLock.Unlock()
+}
+
+// lockAcquired starts the cleanup of the lock tombstones when the backend
+// acquires its first lock.
+func (d *DynamoDBBackend) lockAcquired() {
+	d.lockKeysLock.Lock()
+	defer d.lockKeysLock.Unlock()
+
+	d.heldLocks++
+	if d.cleanupStopCh == nil {
+		d.cleanupStopCh = make(chan struct{})
+		go d.periodicallyCleanupLockTombstones(d.cleanupStopCh)
+	}
+}
+
+// lockReleased stops the cleanup of the lock tombstones once the backend
+// holds no lock anymore.
+func (d *DynamoDBBackend) lockReleased() {
+	d.lockKeysLock.Lock()
+	defer d.lockKeysLock.Unlock()
+
+	d.heldLocks--
+	if d.heldLocks == 0 && d.cleanupStopCh != nil {
+		close(d.cleanupStopCh)
+		d.cleanupStopCh = nil
+	}
+}
+
+// periodicallyCleanupLockTombstones deletes the tombstones of the known
+// locks every `DynamoDBLockCleanupInterval`, until stopCh is closed. This
+// complements DynamoDB's Time to Live, which may not be enabled on the table
+// and can take up to a few days to delete expired items.
+func (d *DynamoDBBackend) periodicallyCleanupLockTombstones(stopCh chan struct{}) {
+	ticker := time.NewTicker(DynamoDBLockCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			d.cleanupLockTombstones(time.Now().Add(-DynamoDBLockTombstoneTTL))
+		}
+	}
+}
+
+// cleanupLockTombstones deletes the records of the known locks that expired
+// before the cutoff time. Locks that are held, or were renewed since, are left
+// untouched. It returns the number of deleted records.
+func (d *DynamoDBBackend) cleanupLockTombstones(cutoff time.Time) int {
+	d.lockKeysLock.Lock()
+	keys := make([]string, 0, len(d.lockKeys))
+	for key := range d.lockKeys {
+		keys = append(keys, key)
+	}
+	d.lockKeysLock.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		d.permitPool.Acquire()
+		_, err := d.client.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName:           aws.String(d.table),
+			ConditionExpression: aws.String("#expires <= :cutoff"),
+			Key: map[string]*dynamodb.AttributeValue{
+				"Path": {S: aws.String(recordPathForVaultKey(key))},
+				"Key":  {S: aws.String(recordKeyForVaultKey(key))},
+			},
+			ExpressionAttributeNames: map[string]*string{
+				"#expires": aws.String("Expires"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":cutoff": {N: aws.String(strconv.FormatInt(cutoff.UnixNano(), 10))},
+			},
+		})
+		d.permitPool.Release()
+
+		switch {
+		case err == nil:
+			deleted++
+			d.logger.Debug("deleted lock tombstone", "key", key)
+		case !isConditionCheckFailed(err):
+			d.logger.Warn("error deleting lock tombstone", "key", key, "error", err)
+		}
+	}
+	return deleted
+}
+
 // batchWriteRequests takes a list of write requests and executes them in badges
 // with a maximum size of 25 (which is the limit of BatchWriteItem requests).
 func (d *DynamoDBBackend) batchWriteRequests(requests []*dynamodb.WriteRequest) error {
@@ -500,8 +654,22 @@ func (d *DynamoDBBackend) batchWriteRequests(requests []*dynamodb.WriteRequest)
 		batch := requests[:batchSize]
 		requests = requests[batchSize:]
 
+		if err := d.batchWrite(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchWrite executes a single batch of write requests. DynamoDB does not
+// process all the items of a batch when the table's throughput is exceeded,
+// so the remaining items are retried with an exponential backoff, on top of
+// the retries of the AWS SDK for throttled requests.
+func (d *DynamoDBBackend) batchWrite(batch []*dynamodb.WriteRequest) error {
+	backoff := DynamoDBBatchWriteMinBackoff
+	for retries := 0; ; retries++ {
 		d.permitPool.Acquire()
-		_, err := d.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+		out, err := d.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
 			RequestItems: map[string][]*dynamodb.WriteRequest{
 				d.table: batch,
 			},
@@ -510,8 +678,27 @@ func (d *DynamoDBBackend) batchWriteRequests(requests []*dynamodb.WriteRequest)
 		if err != nil {
 			return err
 		}
+
+		batch = out.UnprocessedItems[d.table]
+		if len(batch) == 0 {
+			return nil
+		}
+		if retries == DynamoDBBatchWriteRetryMax {
+			return fmt.Errorf("failed to write %d items after %d retries, throughput of table %q exceeded", len(batch), retries, d.table)
+		}
+
+		metrics.IncrCounter([]string{"dynamodb", "unprocessed_items"}, float32(len(batch)))
+		if d.logger.IsDebug() {
+			d.logger.Debug("retrying unprocessed items", "items", len(batch), "backoff", backoff)
+		}
+
+		// Add jitter so that concurrent writers do not retry in lockstep
+		time.Sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2))))
+		backoff *= 2
+		if backoff > DynamoDBBatchWriteMaxBackoff {
+			backoff = DynamoDBBatchWriteMaxBackoff
+		}
 	}
-	return nil
 }
 
 // Lock tries to acquire the lock by repeatedly trying to create
@@ -546,6 +733,7 @@ func (l *DynamoDBLock) Lock(stopCh <-chan struct{}) (doneCh <-chan struct{}, ret
 	select {
 	case <-success:
 		l.held = true
+		l.backend.lockAcquired()
 		// after acquiring it successfully, we must renew the lock periodically,
 		// and watch the lock in order to close the leader channel
 		// once it is lost.
@@ -572,6 +760,7 @@ func (l *DynamoDBLock) Unlock() error {
 	}
 
 	l.held = false
+	l.backend.lockReleased()
 
 	// Conditionally delete after check that the key is actually this Vault's and
 	// not been already claimed by another leader
@@ -695,7 +884,7 @@ func (l *DynamoDBLock) updateItem(createIfMissing bool) error {
 			"Path": {S: aws.String(recordPathForVaultKey(l.key))},
 			"Key":  {S: aws.String(recordKeyForVaultKey(l.key))},
 		},
-		UpdateExpression: aws.String("SET #value=:value, #identity=:identity, #expires=:expires"),
+		UpdateExpression: aws.String("SET #value=:value, #identity=:identity, #expires=:expires, #ttl=:ttl"),
 		// If both key and path already exist, we can only write if
 		// A. identity is equal to our identity (or the identity doesn't exist)
 		// or
@@ -707,12 +896,14 @@ func (l *DynamoDBLock) updateItem(createIfMissing bool) error {
 			"#identity": aws.String("Identity"),
 			"#expires":  aws.String("Expires"),
 			"#value":    aws.String("Value"),
+			"#ttl":      aws.String(DynamoDBTTLAttribute),
 		},
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":identity": {B: []byte(l.identity)},
 			":value":    {B: []byte(l.value)},
 			":now":      {N: aws.String(strconv.FormatInt(now.UnixNano(), 10))},
 			":expires":  {N: aws.String(strconv.FormatInt(now.Add(l.ttl).UnixNano(), 10))},
+			":ttl":      {N: aws.String(strconv.FormatInt(now.Add(l.ttl+DynamoDBLockTombstoneTTL).Unix(), 10))},
 		},
 	})
 
@@ -766,19 +957,17 @@ WatchLoop:
 
 // ensureTableExists creates a DynamoDB table with a given
 // DynamoDB client. If the table already exists, it is not
-// being reconfigured.
-func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, writeCapacity int) error {
+// being reconfigured, except for Time to Live which is enabled
+// on all tables so that lock tombstones get deleted.
+func ensureTableExists(client *dynamodb.DynamoDB, table, billingMode string, readCapacity, writeCapacity int, logger log.Logger) error {
 	_, err := client.DescribeTable(&dynamodb.DescribeTableInput{
 		TableName: aws.String(table),
 	})
 	if awsError, ok := err.(awserr.Error); ok {
 		if awsError.Code() == "ResourceNotFoundException" {
-			_, err = client.CreateTable(&dynamodb.CreateTableInput{
-				TableName: aws.String(table),
-				ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
-					ReadCapacityUnits:  aws.Int64(int64(readCapacity)),
-					WriteCapacityUnits: aws.Int64(int64(writeCapacity)),
-				},
+			createTableInput := &dynamodb.CreateTableInput{
+				TableName:   aws.String(table),
+				BillingMode: aws.String(billingMode),
 				KeySchema: []*dynamodb.KeySchemaElement{{
 					AttributeName: aws.String("Path"),
 					KeyType:       aws.String("HASH"),
@@ -793,7 +982,16 @@ func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, wr
 					AttributeName: aws.String("Key"),
 					AttributeType: aws.String("S"),
 				}},
-			})
+			}
+			// Tables with on-demand capacity must not set a throughput
+			if billingMode == dynamodb.BillingModeProvisioned {
+				createTableInput.ProvisionedThroughput = &dynamodb.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(int64(readCapacity)),
+					WriteCapacityUnits: aws.Int64(int64(writeCapacity)),
+				}
+			}
+
+			_, err = client.CreateTable(createTableInput)
 			if err != nil {
 				return err
 			}
@@ -809,9 +1007,70 @@ func ensureTableExists(client *dynamodb.DynamoDB, table string, readCapacity, wr
 	if err != nil {
 		return err
 	}
+
+	ensureTimeToLive(client, table, logger)
 	return nil
 }
 
+// ensureTimeToLive enables Time to Live on the `DynamoDBTTLAttribute`
+// attribute of the table, unless it is already enabled. Failures are only
+// logged, lock tombstones are then only cleaned up by Vault.
+func ensureTimeToLive(client *dynamodb.DynamoDB, table string, logger log.Logger) {
+	out, err := client.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(table),
+	})
+	if err != nil {
+		logger.Warn("unable to check time to live of DynamoDB table, lock tombstones will only be cleaned up by Vault", "table", table, "error", err)
+		return
+	}
+
+	if desc := out.TimeToLiveDescription; desc != nil {
+		switch aws.StringValue(desc.TimeToLiveStatus) {
+		case dynamodb.TimeToLiveStatusEnabled, dynamodb.TimeToLiveStatusEnabling:
+			if attr := aws.StringValue(desc.AttributeName); attr != DynamoDBTTLAttribute {
+				logger.Warn("time to live of DynamoDB table is enabled on another attribute, lock tombstones will only be cleaned up by Vault", "table", table, "attribute", attr)
+			}
+			return
+		case dynamodb.TimeToLiveStatusDisabling:
+			// Time to Live cannot be enabled again until it is disabled
+			logger.Warn("time to live of DynamoDB table is being disabled, lock tombstones will only be cleaned up by Vault", "table", table)
+			return
+		}
+	}
+
+	_, err = client.UpdateTimeToLive(&dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(table),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(DynamoDBTTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		logger.Warn("unable to enable time to live on DynamoDB table, lock tombstones will only be cleaned up by Vault", "table", table, "error", err)
+	}
+}
+
+// checkPointInTimeRecovery warns if point-in-time recovery is not enabled on
+// the table, in which case its data cannot be restored after an accidental
+// deletion or corruption.
+func checkPointInTimeRecovery(client *dynamodb.DynamoDB, table string, logger log.Logger) {
+	out, err := client.DescribeContinuousBackups(&dynamodb.DescribeContinuousBackupsInput{
+		TableName: aws.String(table),
+	})
+	if err != nil {
+		logger.Warn("unable to check point-in-time recovery of DynamoDB table", "table", table, "error", err)
+		return
+	}
+
+	var status string
+	if desc := out.ContinuousBackupsDescription; desc != nil && desc.PointInTimeRecoveryDescription != nil {
+		status = aws.StringValue(desc.PointInTimeRecoveryDescription.PointInTimeRecoveryStatus)
+	}
+	if status != dynamodb.PointInTimeRecoveryStatusEnabled {
+		logger.Warn("point-in-time recovery is not enabled on DynamoDB table, its data cannot be restored to an earlier state", "table", table)
+	}
+}
+
 // recordPathForVaultKey transforms a vault key into
 // a value suitable for the `DynamoDBRecord`'s `Path`
 // property. This path equals the the vault key without
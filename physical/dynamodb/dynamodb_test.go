@@ -2,11 +2,16 @@ package dynamodb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -362,6 +367,189 @@ func testDynamoDBLockRenewal(t *testing.T, ha physical.HABackend) {
 	newLock.Unlock()
 }
 
+func TestDynamoDBBackend_UnprocessedItems(t *testing.T) {
+	var calls int32
+	b := testFakeDynamoDBBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "DynamoDB_20120810.BatchWriteItem" {
+			t.Errorf("unexpected operation %q", target)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		// Throttle the first two attempts
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			var input map[string]interface{}
+			if err := json.Unmarshal(body, &input); err != nil {
+				t.Error(err)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"UnprocessedItems": input["RequestItems"],
+			})
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+
+	err := b.Put(context.Background(), &physical.Entry{Key: "foo", Value: []byte("bar")})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDynamoDBBackend_CleanupLockTombstones(t *testing.T) {
+	var l sync.Mutex
+	var deletedKeys []string
+	b := testFakeDynamoDBBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			ConditionExpression string
+			Key                 map[string]map[string]string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Error(err)
+		}
+		if input.ConditionExpression != "#expires <= :cutoff" {
+			t.Errorf("unexpected condition %q", input.ConditionExpression)
+		}
+
+		// The lock on core/ is still held
+		if input.Key["Path"]["S"] == "core" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+			return
+		}
+
+		l.Lock()
+		deletedKeys = append(deletedKeys, input.Key["Path"]["S"]+"/"+input.Key["Key"]["S"])
+		l.Unlock()
+		w.Write([]byte("{}"))
+	})
+
+	// Record the lock keys, the periodic cleanup only runs while a lock is
+	// held
+	for _, key := range []string{"core/lock", "old/lock"} {
+		if _, err := b.LockWith(key, "value"); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if deleted := b.cleanupLockTombstones(time.Now().Add(-DynamoDBLockTombstoneTTL)); deleted != 1 {
+		t.Fatalf("expected 1 deleted tombstone, got %d", deleted)
+	}
+	if len(deletedKeys) != 1 || deletedKeys[0] != "old/_lock" {
+		t.Fatalf("bad deleted keys: %v", deletedKeys)
+	}
+}
+
+func TestDynamoDBBackend_LockCleanupLifecycle(t *testing.T) {
+	b := testFakeDynamoDBBackend(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	b.lockAcquired()
+	stopCh := b.cleanupStopCh
+	if stopCh == nil {
+		t.Fatal("expected the cleanup to run while a lock is held")
+	}
+	b.lockAcquired()
+	b.lockReleased()
+	if b.cleanupStopCh != stopCh {
+		t.Fatal("expected the cleanup to run while a lock is still held")
+	}
+
+	b.lockReleased()
+	if b.cleanupStopCh != nil {
+		t.Fatal("expected the cleanup to stop once no lock is held")
+	}
+	select {
+	case <-stopCh:
+	default:
+		t.Fatal("expected the cleanup to be stopped")
+	}
+}
+
+func TestDynamoDBBackend_EnsureTimeToLive(t *testing.T) {
+	tests := map[string]struct {
+		status    string
+		attribute string
+		update    bool
+	}{
+		"disabled":          {dynamodb.TimeToLiveStatusDisabled, "", true},
+		"enabled":           {dynamodb.TimeToLiveStatusEnabled, DynamoDBTTLAttribute, false},
+		"enabled elsewhere": {dynamodb.TimeToLiveStatusEnabled, "ExpiresAt", false},
+		"disabling":         {dynamodb.TimeToLiveStatusDisabling, DynamoDBTTLAttribute, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var updated bool
+			b := testFakeDynamoDBBackend(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Header.Get("X-Amz-Target") {
+				case "DynamoDB_20120810.DescribeTimeToLive":
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"TimeToLiveDescription": map[string]string{
+							"TimeToLiveStatus": test.status,
+							"AttributeName":    test.attribute,
+						},
+					})
+				case "DynamoDB_20120810.UpdateTimeToLive":
+					var input struct {
+						TimeToLiveSpecification struct {
+							AttributeName string
+							Enabled       bool
+						}
+					}
+					if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+						t.Error(err)
+					}
+					if spec := input.TimeToLiveSpecification; spec.AttributeName != DynamoDBTTLAttribute || !spec.Enabled {
+						t.Errorf("bad time to live specification: %#v", spec)
+					}
+					updated = true
+					w.Write([]byte("{}"))
+				default:
+					t.Errorf("unexpected request %q", r.Header.Get("X-Amz-Target"))
+				}
+			})
+
+			ensureTimeToLive(b.client, b.table, b.logger)
+			if updated != test.update {
+				t.Fatalf("expected update %t, got %t", test.update, updated)
+			}
+		})
+	}
+}
+
+// testFakeDynamoDBBackend returns a backend talking to a fake DynamoDB API
+// served by handler.
+func testFakeDynamoDBBackend(t *testing.T, handler http.HandlerFunc) *DynamoDBBackend {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	awsSession, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("fake", "fake", ""),
+		Endpoint:    aws.String(srv.URL),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	return &DynamoDBBackend{
+		table:      "vault",
+		client:     dynamodb.New(awsSession),
+		logger:     logging.NewVaultLogger(log.Debug),
+		permitPool: physical.NewPermitPool(0),
+		lockKeys:   make(map[string]struct{}),
+	}
+}
+
 type Config struct {
 	docker.ServiceURL
 	Credentials *credentials.Credentials
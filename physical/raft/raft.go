@@ -2,20 +2,27 @@ package raft
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/golang/protobuf/proto"
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-discover"
 	log "github.com/hashicorp/go-hclog"
 	wrapping "github.com/hashicorp/go-kms-wrapping"
 	"github.com/hashicorp/go-raftchunking"
@@ -210,6 +217,83 @@ func (b *RaftBackend) JoinConfig() ([]*LeaderJoinInfo, error) {
 	return leaderInfos, nil
 }
 
+// AutoJoinAddrs queries the auto-join provider for the nodes that may be
+// joined, and returns their API addresses. The scheme and the port of the
+// addresses default to the configured auto_join_scheme and auto_join_port, or
+// to https and 8200, when the provider does not return them.
+func (info *LeaderJoinInfo) AutoJoinAddrs(disco *discover.Discover, logger log.Logger) ([]string, error) {
+	addrs, err := disco.Addrs(info.AutoJoin, logger.StandardLogger(nil))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to parse addresses from auto-join metadata: {{err}}", err)
+	}
+
+	scheme := info.AutoJoinScheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	port := strconv.FormatUint(uint64(info.AutoJoinPort), 10)
+	if info.AutoJoinPort == 0 {
+		port = "8200"
+	}
+
+	leaderAddrs := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		u := &url.URL{
+			Scheme: scheme,
+		}
+		host := addr
+		if strings.Contains(addr, "://") {
+			parsed, err := url.Parse(addr)
+			if err != nil || parsed.Host == "" {
+				logger.Error("failed to parse discovered address", "address", addr, "error", err)
+				continue
+			}
+			u.Scheme = parsed.Scheme
+			host = parsed.Host
+		}
+
+		// Providers return IP addresses, with or without a port
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			u.Host = net.JoinHostPort(h, p)
+		} else {
+			u.Host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		leaderAddrs = append(leaderAddrs, u.String())
+	}
+
+	return leaderAddrs, nil
+}
+
+// JoinToken returns the shared token which nodes must prove knowledge of to
+// join, or be joined by, this node, if configured.
+func (b *RaftBackend) JoinToken() string {
+	return b.conf["join_token"]
+}
+
+const (
+	// JoinTokenFollower and JoinTokenLeader are the roles of the nodes proving
+	// knowledge of the join token, which keep the proof of one from being
+	// replayed as the proof of the other.
+	JoinTokenFollower = "follower"
+	JoinTokenLeader   = "leader"
+)
+
+// JoinTokenProof returns the proof of knowledge of the join token by the node
+// with the given role, for the joining node and the nonce it generated.
+func JoinTokenProof(token, role, serverID, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(role + "\x00" + serverID + "\x00" + nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyJoinTokenProof returns whether the proof was made with the join token.
+func VerifyJoinTokenProof(token, role, serverID, nonce, proof string) bool {
+	if nonce == "" || proof == "" {
+		return false
+	}
+	return hmac.Equal([]byte(JoinTokenProof(token, role, serverID, nonce)), []byte(proof))
+}
+
 // parseTLSInfo is a helper for parses the TLS information, preferring file
 // paths over raw certificate content.
 func parseTLSInfo(leaderInfo *LeaderJoinInfo) (*tls.Config, error) {
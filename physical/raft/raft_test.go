@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/go-test/deep"
 	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-discover"
 	hclog "github.com/hashicorp/go-hclog"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/raft"
@@ -572,3 +574,124 @@ type discardCloser struct {
 
 func (d discardCloser) Close() error               { return nil }
 func (d discardCloser) CloseWithError(error) error { return nil }
+
+func TestRaft_JoinTokenProof(t *testing.T) {
+	proof := JoinTokenProof("token", JoinTokenFollower, "node2", "nonce")
+	if !VerifyJoinTokenProof("token", JoinTokenFollower, "node2", "nonce", proof) {
+		t.Fatal("expected the proof to be valid")
+	}
+
+	// The proof is bound to the token, the role, the server ID and the nonce
+	for _, args := range [][4]string{
+		{"other", JoinTokenFollower, "node2", "nonce"},
+		{"token", JoinTokenLeader, "node2", "nonce"},
+		{"token", JoinTokenFollower, "node3", "nonce"},
+		{"token", JoinTokenFollower, "node2", "other"},
+	} {
+		if VerifyJoinTokenProof(args[0], args[1], args[2], args[3], proof) {
+			t.Fatalf("expected the proof to be invalid for %v", args)
+		}
+	}
+
+	if VerifyJoinTokenProof("token", JoinTokenFollower, "node2", "", JoinTokenProof("token", JoinTokenFollower, "node2", "")) {
+		t.Fatal("expected a proof without a nonce to be invalid")
+	}
+}
+
+type fakeDiscoverProvider struct {
+	args  map[string]string
+	addrs []string
+}
+
+func (p *fakeDiscoverProvider) Addrs(args map[string]string, l *log.Logger) ([]string, error) {
+	p.args = args
+	return p.addrs, nil
+}
+
+func (p *fakeDiscoverProvider) Help() string {
+	return "fake"
+}
+
+func TestRaft_AutoJoinAddrs(t *testing.T) {
+	provider := &fakeDiscoverProvider{
+		addrs: []string{"10.0.0.1", "10.0.0.2:8210", "http://10.0.0.3", "fd00::4", "[fd00::5]:8210"},
+	}
+	disco, err := discover.New(discover.WithProviders(map[string]discover.Provider{
+		"fake": provider,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		info     *LeaderJoinInfo
+		expected []string
+	}{
+		"defaults": {
+			info: &LeaderJoinInfo{AutoJoin: "provider=fake tag_key=vault tag_value=prod"},
+			expected: []string{
+				"https://10.0.0.1:8200",
+				"https://10.0.0.2:8210",
+				"http://10.0.0.3:8200",
+				"https://[fd00::4]:8200",
+				"https://[fd00::5]:8210",
+			},
+		},
+		"scheme and port": {
+			info: &LeaderJoinInfo{AutoJoin: "provider=fake tag_key=vault tag_value=prod", AutoJoinScheme: "http", AutoJoinPort: 8300},
+			expected: []string{
+				"http://10.0.0.1:8300",
+				"http://10.0.0.2:8210",
+				"http://10.0.0.3:8300",
+				"http://[fd00::4]:8300",
+				"http://[fd00::5]:8210",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			addrs, err := tc.info.AutoJoinAddrs(disco, hclog.NewNullLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := deep.Equal(addrs, tc.expected); diff != nil {
+				t.Fatal(diff)
+			}
+			if diff := deep.Equal(provider.args, map[string]string{"provider": "fake", "tag_key": "vault", "tag_value": "prod"}); diff != nil {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	// Unknown providers are rejected
+	info := &LeaderJoinInfo{AutoJoin: "provider=other"}
+	if _, err := info.AutoJoinAddrs(disco, hclog.NewNullLogger()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRaft_JoinConfig_AutoJoin(t *testing.T) {
+	b := &RaftBackend{
+		conf: map[string]string{
+			"retry_join": `[{"auto_join":"provider=aws region=eu-west-1 tag_key=vault tag_value=prod","auto_join_scheme":"http","auto_join_port":8300}]`,
+		},
+	}
+	infos, err := b.JoinConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].AutoJoin != "provider=aws region=eu-west-1 tag_key=vault tag_value=prod" ||
+		infos[0].AutoJoinScheme != "http" || infos[0].AutoJoinPort != 8300 || !infos[0].Retry {
+		t.Fatalf("bad join config: %#v", infos)
+	}
+
+	for _, config := range []string{
+		`[{"auto_join":"provider=aws","leader_api_addr":"https://10.0.0.1:8200"}]`,
+		`[{"auto_join":"provider=aws","auto_join_scheme":"tcp"}]`,
+	} {
+		b.conf["retry_join"] = config
+		if _, err := b.JoinConfig(); err == nil {
+			t.Fatalf("expected error for %s", config)
+		}
+	}
+}
@@ -16,14 +16,10 @@ func main() {
 	}
 }
 
-// Run instantiates a Cassandra object, and runs the RPC server for the plugin
+// Run starts the RPC server for the plugin, which creates a new Cassandra object
+// for every database connection it serves
 func Run() error {
-	dbType, err := cassandra.New()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database))
+	dbplugin.ServeMultiplex(cassandra.New)
 
 	return nil
 }
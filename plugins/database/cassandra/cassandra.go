@@ -9,8 +9,7 @@ import (
 	multierror "github.com/hashicorp/go-multierror"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
-	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
-	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 )
 
 const (
@@ -18,6 +17,13 @@ const (
 	defaultUserDeletionCQL   = `DROP USER '{{username}}';`
 	defaultChangePasswordCQL = `ALTER USER {{username}} WITH PASSWORD '{{password}}';`
 	cassandraTypeName        = "cassandra"
+
+	// defaultKeyspaceUserCreationCQL is used when the creation statements of
+	// a role only contain directives, including a keyspace directive. It
+	// grants read and write access to the data of the keyspace.
+	defaultKeyspaceUserCreationCQL = `CREATE USER '{{username}}' WITH PASSWORD '{{password}}' NOSUPERUSER;
+GRANT SELECT ON KEYSPACE {{keyspace}} TO '{{username}}';
+GRANT MODIFY ON KEYSPACE {{keyspace}} TO '{{username}}';`
 )
 
 var _ dbplugin.Database = &Cassandra{}
@@ -25,6 +31,8 @@ var _ dbplugin.Database = &Cassandra{}
 // Cassandra is an implementation of Database interface
 type Cassandra struct {
 	*cassandraConnectionProducer
+
+	usernameTemplate *template.StringTemplate
 }
 
 // New returns a new Cassandra instance
@@ -58,6 +66,26 @@ func (c *Cassandra) getConnection(ctx context.Context) (*gocql.Session, error) {
 	return session.(*gocql.Session), nil
 }
 
+// Initialize initializes the connection producer and parses the username
+// template of the configuration.
+func (c *Cassandra) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	resp, err := c.cassandraConnectionProducer.Initialize(ctx, req)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	c.Lock()
+	c.usernameTemplate = usernameTemplate
+	c.Unlock()
+
+	return resp, nil
+}
+
 // NewUser generates the username/password on the underlying Cassandra secret backend as instructed by
 // the statements provided.
 func (c *Cassandra) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
@@ -69,14 +97,14 @@ func (c *Cassandra) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (d
 		return dbplugin.NewUserResponse{}, err
 	}
 
-	creationCQL := req.Statements.Commands
-	if len(creationCQL) == 0 {
-		creationCQL = []string{defaultUserCreationCQL}
+	creationCQL, err := parseCQLWithDefault(req.Statements.Commands, defaultUserCreationCQL, defaultKeyspaceUserCreationCQL)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
 	}
 
-	rollbackCQL := req.RollbackStatements.Commands
-	if len(rollbackCQL) == 0 {
-		rollbackCQL = []string{defaultUserDeletionCQL}
+	rollbackCQL, err := parseCQLWithDefault(req.RollbackStatements.Commands, defaultUserDeletionCQL, "")
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
 	}
 
 	username, err := credsutil.GenerateUsername(
@@ -85,34 +113,25 @@ func (c *Cassandra) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (d
 		credsutil.Separator("_"),
 		credsutil.MaxLength(100),
 		credsutil.ToLower(),
+		credsutil.Template(c.usernameTemplate, req.UsernameConfig),
 	)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, err
 	}
 	username = strings.ReplaceAll(username, "-", "_")
 
-	for _, stmt := range creationCQL {
-		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
-			query = strings.TrimSpace(query)
-			if len(query) == 0 {
-				continue
-			}
-
-			m := map[string]string{
-				"username": username,
-				"password": req.Password,
-			}
-			err = session.
-				Query(dbutil.QueryHelper(query, m)).
-				WithContext(ctx).
-				Exec()
-			if err != nil {
-				rollbackErr := rollbackUser(ctx, session, username, rollbackCQL)
-				if rollbackErr != nil {
-					err = multierror.Append(err, rollbackErr)
-				}
-				return dbplugin.NewUserResponse{}, err
+	for _, query := range creationCQL {
+		m := map[string]string{
+			"username": username,
+			"password": req.Password,
+		}
+		err = query.exec(ctx, session, m)
+		if err != nil {
+			rollbackErr := rollbackUser(ctx, session, username, rollbackCQL)
+			if rollbackErr != nil {
+				err = multierror.Append(err, rollbackErr)
 			}
+			return dbplugin.NewUserResponse{}, err
 		}
 	}
 
@@ -122,24 +141,14 @@ func (c *Cassandra) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (d
 	return resp, nil
 }
 
-func rollbackUser(ctx context.Context, session *gocql.Session, username string, rollbackCQL []string) error {
-	for _, stmt := range rollbackCQL {
-		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
-			query = strings.TrimSpace(query)
-			if len(query) == 0 {
-				continue
-			}
-
-			m := map[string]string{
-				"username": username,
-			}
-			err := session.
-				Query(dbutil.QueryHelper(query, m)).
-				WithContext(ctx).
-				Exec()
-			if err != nil {
-				return fmt.Errorf("failed to roll back user %s: %w", username, err)
-			}
+func rollbackUser(ctx context.Context, session *gocql.Session, username string, rollbackCQL []cqlQuery) error {
+	for _, query := range rollbackCQL {
+		m := map[string]string{
+			"username": username,
+		}
+		err := query.exec(ctx, session, m)
+		if err != nil {
+			return fmt.Errorf("failed to roll back user %s: %w", username, err)
 		}
 	}
 	return nil
@@ -164,29 +173,19 @@ func (c *Cassandra) changeUserPassword(ctx context.Context, username string, cha
 		return err
 	}
 
-	rotateCQL := changePass.Statements.Commands
-	if len(rotateCQL) == 0 {
-		rotateCQL = []string{defaultChangePasswordCQL}
+	rotateCQL, err := parseCQLWithDefault(changePass.Statements.Commands, defaultChangePasswordCQL, "")
+	if err != nil {
+		return err
 	}
 
 	var result *multierror.Error
-	for _, stmt := range rotateCQL {
-		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
-			query = strings.TrimSpace(query)
-			if len(query) == 0 {
-				continue
-			}
-
-			m := map[string]string{
-				"username": username,
-				"password": changePass.NewPassword,
-			}
-			err := session.
-				Query(dbutil.QueryHelper(query, m)).
-				WithContext(ctx).
-				Exec()
-			result = multierror.Append(result, err)
+	for _, query := range rotateCQL {
+		m := map[string]string{
+			"username": username,
+			"password": changePass.NewPassword,
 		}
+		err := query.exec(ctx, session, m)
+		result = multierror.Append(result, err)
 	}
 
 	return result.ErrorOrNil()
@@ -202,29 +201,19 @@ func (c *Cassandra) DeleteUser(ctx context.Context, req dbplugin.DeleteUserReque
 		return dbplugin.DeleteUserResponse{}, err
 	}
 
-	revocationCQL := req.Statements.Commands
-	if len(revocationCQL) == 0 {
-		revocationCQL = []string{defaultUserDeletionCQL}
+	revocationCQL, err := parseCQLWithDefault(req.Statements.Commands, defaultUserDeletionCQL, "")
+	if err != nil {
+		return dbplugin.DeleteUserResponse{}, err
 	}
 
 	var result *multierror.Error
-	for _, stmt := range revocationCQL {
-		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
-			query = strings.TrimSpace(query)
-			if len(query) == 0 {
-				continue
-			}
-
-			m := map[string]string{
-				"username": req.Username,
-			}
-			err := session.
-				Query(dbutil.QueryHelper(query, m)).
-				WithContext(ctx).
-				Exec()
-
-			result = multierror.Append(result, err)
+	for _, query := range revocationCQL {
+		m := map[string]string{
+			"username": req.Username,
 		}
+		err := query.exec(ctx, session, m)
+
+		result = multierror.Append(result, err)
 	}
 
 	return dbplugin.DeleteUserResponse{}, result.ErrorOrNil()
@@ -1,6 +1,7 @@
 package cassandra
 
 import (
+	"context"
 	"reflect"
 	"regexp"
 	"strings"
@@ -92,6 +93,51 @@ func TestCassandra_CreateUser(t *testing.T) {
 	assertCreds(t, db.Hosts, db.Port, createResp.Username, password, 5*time.Second)
 }
 
+func TestCassandra_CreateUser_KeyspaceDirectives(t *testing.T) {
+	db, cleanup := getCassandra(t, 4)
+	defer cleanup()
+
+	session, err := db.getConnection(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	err = session.Query(`CREATE KEYSPACE vault_test WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1};`).Exec()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	password := "myreallysecurepassword"
+	createReq := dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{"CONSISTENCY ONE; USE vault_test;"},
+		},
+		Password:   password,
+		Expiration: time.Now().Add(1 * time.Minute),
+	}
+
+	createResp := dbtesting.AssertNewUser(t, db, createReq)
+	assertCreds(t, db.Hosts, db.Port, createResp.Username, password, 5*time.Second)
+
+	iter := session.Query(`LIST ALL PERMISSIONS OF ` + createResp.Username).Iter()
+	permissions := map[string]bool{}
+	var role, username, resource, permission string
+	for iter.Scan(&role, &username, &resource, &permission) {
+		if resource == "<keyspace vault_test>" {
+			permissions[permission] = true
+		}
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !permissions["SELECT"] || !permissions["MODIFY"] {
+		t.Fatalf("expected SELECT and MODIFY permissions on the keyspace, got %v", permissions)
+	}
+}
+
 func TestMyCassandra_UpdateUserPassword(t *testing.T) {
 	db, cleanup := getCassandra(t, 4)
 	defer cleanup()
@@ -0,0 +1,105 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+)
+
+var (
+	// consistencyDirective sets the consistency level of the queries that
+	// follow it, like the CONSISTENCY command of cqlsh.
+	consistencyDirective = regexp.MustCompile(`(?i)^CONSISTENCY\s+(\w+)$`)
+
+	// keyspaceDirective sets the keyspace of the queries that follow it,
+	// available to them as {{keyspace}}, like the USE command of cqlsh.
+	keyspaceDirective = regexp.MustCompile(`(?i)^USE\s+(\w+|"(?:[^"]|"")+")$`)
+)
+
+// cqlQuery is a query of the statements of a role, along with the consistency
+// level and keyspace set by the directives preceding it.
+type cqlQuery struct {
+	query string
+
+	consistency    gocql.Consistency
+	hasConsistency bool
+	keyspace       string
+}
+
+// parseCQL splits the statements into queries and applies the directives they
+// contain. It returns the queries and the keyspace set last, so that default
+// statements can be scoped to it when the statements only contain directives.
+func parseCQL(stmts []string) ([]cqlQuery, string, error) {
+	var queries []cqlQuery
+	var current cqlQuery
+	for _, stmt := range stmts {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			if m := consistencyDirective.FindStringSubmatch(query); m != nil {
+				consistency, err := gocql.ParseConsistencyWrapper(strings.ToUpper(m[1]))
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid consistency directive %q: %w", query, err)
+				}
+				current.consistency = consistency
+				current.hasConsistency = true
+				continue
+			}
+			if m := keyspaceDirective.FindStringSubmatch(query); m != nil {
+				current.keyspace = m[1]
+				continue
+			}
+
+			q := current
+			q.query = query
+			queries = append(queries, q)
+		}
+	}
+	return queries, current.keyspace, nil
+}
+
+// parseCQLWithDefault parses the statements, falling back to the default
+// statements when they only contain directives. The default statements are
+// scoped to the keyspace if a keyspace directive was given and a keyspace
+// default exists.
+func parseCQLWithDefault(stmts []string, defaultCQL, defaultKeyspaceCQL string) ([]cqlQuery, error) {
+	queries, keyspace, err := parseCQL(stmts)
+	if err != nil || len(queries) > 0 {
+		return queries, err
+	}
+
+	def := defaultCQL
+	if keyspace != "" && defaultKeyspaceCQL != "" {
+		def = defaultKeyspaceCQL
+	}
+	queries, _, err = parseCQL(append(stmts[:len(stmts):len(stmts)], def))
+	return queries, err
+}
+
+// exec executes the query, substituting the given values as well as the
+// keyspace set by the directives.
+func (q cqlQuery) exec(ctx context.Context, session *gocql.Session, m map[string]string) error {
+	values := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		values[k] = v
+	}
+	if q.keyspace != "" {
+		values["keyspace"] = q.keyspace
+	}
+
+	query := session.
+		Query(dbutil.QueryHelper(q.query, values)).
+		WithContext(ctx)
+	if q.hasConsistency {
+		query = query.Consistency(q.consistency)
+	}
+	return query.Exec()
+}
@@ -0,0 +1,107 @@
+package cassandra
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestParseCQL(t *testing.T) {
+	type testCase struct {
+		stmts     []string
+		expected  []cqlQuery
+		expectErr bool
+	}
+
+	tests := map[string]testCase{
+		"no directives": {
+			stmts: []string{"CREATE USER foo; GRANT SELECT ON KEYSPACE ks TO foo;"},
+			expected: []cqlQuery{
+				{query: "CREATE USER foo"},
+				{query: "GRANT SELECT ON KEYSPACE ks TO foo"},
+			},
+		},
+		"consistency": {
+			stmts: []string{"CREATE USER foo;", "consistency local_quorum; GRANT SELECT ON KEYSPACE ks TO foo;"},
+			expected: []cqlQuery{
+				{query: "CREATE USER foo"},
+				{query: "GRANT SELECT ON KEYSPACE ks TO foo", consistency: gocql.LocalQuorum, hasConsistency: true},
+			},
+		},
+		"keyspace": {
+			stmts: []string{`USE "MyKeyspace"; GRANT SELECT ON KEYSPACE {{keyspace}} TO foo; USE other; GRANT MODIFY ON KEYSPACE {{keyspace}} TO foo`},
+			expected: []cqlQuery{
+				{query: "GRANT SELECT ON KEYSPACE {{keyspace}} TO foo", keyspace: `"MyKeyspace"`},
+				{query: "GRANT MODIFY ON KEYSPACE {{keyspace}} TO foo", keyspace: "other"},
+			},
+		},
+		"invalid consistency": {
+			stmts:     []string{"CONSISTENCY MOST; CREATE USER foo;"},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, _, err := parseCQL(test.stmts)
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("Actual: %#v\nExpected: %#v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseCQLWithDefault(t *testing.T) {
+	type testCase struct {
+		stmts    []string
+		expected []cqlQuery
+	}
+
+	tests := map[string]testCase{
+		"no statements": {
+			stmts: nil,
+			expected: []cqlQuery{
+				{query: "CREATE USER '{{username}}' WITH PASSWORD '{{password}}' NOSUPERUSER"},
+			},
+		},
+		"consistency only": {
+			stmts: []string{"CONSISTENCY QUORUM;"},
+			expected: []cqlQuery{
+				{query: "CREATE USER '{{username}}' WITH PASSWORD '{{password}}' NOSUPERUSER", consistency: gocql.Quorum, hasConsistency: true},
+			},
+		},
+		"keyspace only": {
+			stmts: []string{"USE ks;"},
+			expected: []cqlQuery{
+				{query: "CREATE USER '{{username}}' WITH PASSWORD '{{password}}' NOSUPERUSER", keyspace: "ks"},
+				{query: "GRANT SELECT ON KEYSPACE {{keyspace}} TO '{{username}}'", keyspace: "ks"},
+				{query: "GRANT MODIFY ON KEYSPACE {{keyspace}} TO '{{username}}'", keyspace: "ks"},
+			},
+		},
+		"custom statements": {
+			stmts: []string{"USE ks; CREATE USER foo;"},
+			expected: []cqlQuery{
+				{query: "CREATE USER foo", keyspace: "ks"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := parseCQLWithDefault(test.stmts, defaultUserCreationCQL, defaultKeyspaceUserCreationCQL)
+			if err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("Actual: %#v\nExpected: %#v", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package couchbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// client implements the part of the Couchbase management REST API needed to
+// manage local RBAC users:
+// https://docs.couchbase.com/server/current/rest-api/rbac.html
+type client struct {
+	// Management URLs of the nodes of the cluster, tried in order until one
+	// of them can be reached
+	urls       []string
+	httpClient *http.Client
+
+	// Requests are authenticated with basic authentication if a username is
+	// set, or with the client certificate of the HTTP client otherwise
+	username string
+	password string
+}
+
+// rbacUser is a local user as returned by the RBAC API
+type rbacUser struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Roles  []role   `json:"roles"`
+	Groups []string `json:"groups"`
+}
+
+// role is a role granted to a user, on all the resources if the bucket is
+// not set, or on a bucket, a scope of a bucket or a collection of a scope.
+type role struct {
+	Role           string `json:"role"`
+	BucketName     string `json:"bucket_name,omitempty"`
+	ScopeName      string `json:"scope_name,omitempty"`
+	CollectionName string `json:"collection_name,omitempty"`
+
+	// Origins lists whether the role is granted to the user, to one of its
+	// groups, or both
+	Origins []struct {
+		Type string `json:"type"`
+	} `json:"origins,omitempty"`
+}
+
+// String returns the role in the format of the RBAC API, such as
+// data_reader[travel-sample:inventory:airline]
+func (r role) String() string {
+	var resource []string
+	for _, name := range []string{r.BucketName, r.ScopeName, r.CollectionName} {
+		if name == "" {
+			break
+		}
+		resource = append(resource, name)
+	}
+	if len(resource) == 0 {
+		return r.Role
+	}
+	return r.Role + "[" + strings.Join(resource, ":") + "]"
+}
+
+// grantedToUser returns whether the role is granted to the user itself,
+// rather than only inherited from its groups.
+func (r role) grantedToUser() bool {
+	if len(r.Origins) == 0 {
+		return true
+	}
+	for _, origin := range r.Origins {
+		if origin.Type == "user" {
+			return true
+		}
+	}
+	return false
+}
+
+// whoami returns the user the client authenticates as, verifying the
+// connection and credentials.
+func (c *client) whoami(ctx context.Context) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/whoami", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (c *client) getBucket(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodGet, "/pools/default/buckets/"+url.PathEscape(name), nil, nil)
+}
+
+func (c *client) getUser(ctx context.Context, name string) (*rbacUser, error) {
+	u := &rbacUser{}
+	if err := c.do(ctx, http.MethodGet, "/settings/rbac/users/local/"+url.PathEscape(name), nil, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// upsertUser creates or replaces the user, with the roles granted to the
+// user itself and the groups of u.
+func (c *client) upsertUser(ctx context.Context, name, password string, u *rbacUser) error {
+	var roles []string
+	for _, r := range u.Roles {
+		if r.grantedToUser() {
+			roles = append(roles, r.String())
+		}
+	}
+
+	form := url.Values{}
+	form.Set("password", password)
+	form.Set("roles", strings.Join(roles, ","))
+	if len(u.Groups) > 0 {
+		form.Set("groups", strings.Join(u.Groups, ","))
+	}
+	if u.Name != "" {
+		form.Set("name", u.Name)
+	}
+	return c.do(ctx, http.MethodPut, "/settings/rbac/users/local/"+url.PathEscape(name), form, nil)
+}
+
+// deleteUser deletes the user. It is not an error for the user not to exist.
+func (c *client) deleteUser(ctx context.Context, name string) error {
+	return ignoreNotFound(c.do(ctx, http.MethodDelete, "/settings/rbac/users/local/"+url.PathEscape(name), nil, nil))
+}
+
+// apiError is an error response of the Couchbase management API
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Body)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+func ignoreNotFound(err error) error {
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// do sends the request to the first node of the cluster that can be reached.
+// Error responses are returned as they are, without trying the other nodes.
+func (c *client) do(ctx context.Context, method, path string, form url.Values, ret interface{}) error {
+	var merr *multierror.Error
+	for _, baseURL := range c.urls {
+		resp, err := c.send(ctx, baseURL, method, path, form)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		defer resp.Body.Close()
+
+		respBody, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &apiError{
+				StatusCode: resp.StatusCode,
+				Body:       string(respBody),
+			}
+		}
+
+		if ret == nil {
+			return nil
+		}
+		return json.Unmarshal(respBody, ret)
+	}
+	return merr.ErrorOrNil()
+}
+
+func (c *client) send(ctx context.Context, baseURL, method, path string, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequest(method, baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.httpClient.Do(req)
+}
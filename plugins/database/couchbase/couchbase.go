@@ -0,0 +1,403 @@
+package couchbase
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	couchbaseTypeName = "couchbase"
+
+	defaultManagementPort    = "8091"
+	defaultManagementTLSPort = "18091"
+)
+
+// defaultCreationStatement grants the read-only admin role, as earlier
+// versions of the plugin did when no creation statement is configured.
+var defaultCreationStatement = creationStatement{
+	Roles: []role{{Role: "ro_admin"}},
+}
+
+var _ dbplugin.Database = (*Couchbase)(nil)
+
+// Couchbase is an implementation of Database interface managing local RBAC
+// users through the management REST API
+type Couchbase struct {
+	// This protects the config from races while also allowing multiple
+	// threads to use the client simultaneously when it's not changing
+	mux sync.RWMutex
+
+	config           *couchbaseConfig
+	client           *client
+	usernameTemplate *template.StringTemplate
+}
+
+type couchbaseConfig struct {
+	Hosts       string `mapstructure:"hosts"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	TLS         bool   `mapstructure:"tls"`
+	InsecureTLS bool   `mapstructure:"insecure_tls"`
+	Base64Pem   string `mapstructure:"base64pem"`
+	BucketName  string `mapstructure:"bucket_name"`
+
+	// TLSCertificateKeyData is the PEM encoded client certificate and key
+	// used to authenticate with x.509 certificate authentication instead of
+	// a password
+	TLSCertificateKeyData []byte `mapstructure:"tls_certificate_key"`
+}
+
+// creationStatement is the JSON creation statement of roles, listing the
+// roles and the existing groups granted to each user.
+type creationStatement struct {
+	Roles  []role   `json:"roles"`
+	Groups []string `json:"groups"`
+}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := newCouchbase()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func newCouchbase() *Couchbase {
+	return &Couchbase{}
+}
+
+// Type returns the TypeName for this backend
+func (c *Couchbase) Type() (string, error) {
+	return couchbaseTypeName, nil
+}
+
+func (c *Couchbase) secretValues() map[string]string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if c.config == nil || c.config.Password == "" {
+		return nil
+	}
+	return map[string]string{
+		c.config.Password: "[password]",
+	}
+}
+
+// Initialize validates the configuration and builds the client. Vault
+// authenticates with the client certificate if one is configured, and with
+// the username and password otherwise.
+func (c *Couchbase) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	config := &couchbaseConfig{}
+	if err := mapstructure.WeakDecode(req.Config, config); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	switch {
+	case config.Hosts == "":
+		return dbplugin.InitializeResponse{}, errors.New("hosts cannot be empty")
+	case len(config.TLSCertificateKeyData) > 0 && config.Password != "":
+		return dbplugin.InitializeResponse{}, errors.New("tls_certificate_key and password are mutually exclusive")
+	case len(config.TLSCertificateKeyData) == 0 && (config.Username == "" || config.Password == ""):
+		return dbplugin.InitializeResponse{}, errors.New("either tls_certificate_key, or username and password must be provided")
+	}
+
+	cl, err := newClient(config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to create client: %w", err)
+	}
+
+	if req.VerifyConnection {
+		if _, err := cl.whoami(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+		if config.BucketName != "" {
+			if err := cl.getBucket(ctx, config.BucketName); err != nil {
+				return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection to bucket %q: %w", config.BucketName, err)
+			}
+		}
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.config = config
+	c.client = cl
+	c.usernameTemplate = usernameTemplate
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+func newClient(config *couchbaseConfig) (*client, error) {
+	useTLS, urls, err := config.managementURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := cleanhttp.DefaultPooledClient()
+	if useTLS {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	} else if len(config.TLSCertificateKeyData) > 0 {
+		return nil, errors.New("tls_certificate_key requires tls to be enabled")
+	}
+
+	cl := &client{
+		urls:       urls,
+		httpClient: httpClient,
+	}
+	if config.Password != "" {
+		cl.username = config.Username
+		cl.password = config.Password
+	}
+	return cl, nil
+}
+
+// managementURLs returns the management API URLs of the hosts, and whether
+// the connection uses TLS. The hosts are either a list of host names, or a
+// couchbase:// or couchbases:// connection string. Hosts without a port use
+// the default management port.
+func (c *couchbaseConfig) managementURLs() (bool, []string, error) {
+	hosts := c.Hosts
+	useTLS := c.TLS
+	switch {
+	case strings.HasPrefix(hosts, "couchbases://"):
+		hosts = strings.TrimPrefix(hosts, "couchbases://")
+		useTLS = true
+	case strings.HasPrefix(hosts, "couchbase://"):
+		if c.TLS {
+			return false, nil, errors.New("hosts list must start with couchbases:// for TLS connection")
+		}
+		hosts = strings.TrimPrefix(hosts, "couchbase://")
+	case strings.Contains(hosts, "://"):
+		return false, nil, fmt.Errorf("unsupported scheme in hosts %q", hosts)
+	}
+	// Drop the options of the connection string
+	if i := strings.IndexAny(hosts, "?/"); i >= 0 {
+		hosts = hosts[:i]
+	}
+
+	scheme, port := "http", defaultManagementPort
+	if useTLS {
+		scheme, port = "https", defaultManagementTLSPort
+	}
+
+	var urls []string
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+		urls = append(urls, scheme+"://"+host)
+	}
+	if len(urls) == 0 {
+		return false, nil, errors.New("hosts cannot be empty")
+	}
+	return useTLS, urls, nil
+}
+
+func (c *couchbaseConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureTLS,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if c.Base64Pem != "" {
+		pem, err := base64.StdEncoding.DecodeString(c.Base64Pem)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode base64pem: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM(pem); !ok {
+			return nil, errors.New("unable to parse base64pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(c.TLSCertificateKeyData) > 0 {
+		certificate, err := tls.X509KeyPair(c.TLSCertificateKeyData, c.TLSCertificateKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls_certificate_key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewUser creates a local user with the roles and groups of the creation
+// statement.
+func (c *Couchbase) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	stmt, err := newCreationStatement(req.Statements)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to read creation_statements: %w", err)
+	}
+
+	// Don't let anyone write the config while we're using its client
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if c.client == nil {
+		return dbplugin.NewUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 64),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 64),
+		credsutil.MaxLength(128),
+		credsutil.ToUpper(),
+		credsutil.Template(c.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to generate username: %w", err)
+	}
+
+	u := &rbacUser{
+		Name:   req.UsernameConfig.DisplayName,
+		Roles:  stmt.Roles,
+		Groups: stmt.Groups,
+	}
+	if err := c.client.upsertUser(ctx, username, req.Password, u); err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to create user %q: %w", username, err)
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password of a user, keeping its roles and groups.
+// Local users do not expire, so changing the expiration is a no-op.
+func (c *Couchbase) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing username")
+	}
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing password")
+	}
+
+	// Take the write lock, since the client changes if the password of the
+	// root user is rotated
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.client == nil {
+		return dbplugin.UpdateUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	// The RBAC API replaces users as a whole, so the password is changed by
+	// writing the user back with its current roles and groups
+	u, err := c.client.getUser(ctx, req.Username)
+	if err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to retrieve user %q: %w", req.Username, err)
+	}
+	if err := c.client.upsertUser(ctx, req.Username, req.Password.NewPassword, u); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to change password: %w", err)
+	}
+
+	if c.client.username != "" && req.Username == c.config.Username {
+		c.config.Password = req.Password.NewPassword
+		c.client.password = req.Password.NewPassword
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser deletes the user. Users that do not exist are ignored, so
+// deletion can be retried.
+func (c *Couchbase) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if c.client == nil {
+		return dbplugin.DeleteUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	if err := c.client.deleteUser(ctx, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to delete user %q: %w", req.Username, err)
+	}
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// Close closes the idle connections of the client
+func (c *Couchbase) Close() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.client != nil {
+		c.client.httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+// newCreationStatement parses the creation statement, or returns the default
+// statement if none is configured.
+func newCreationStatement(statements dbplugin.Statements) (*creationStatement, error) {
+	var commands []string
+	for _, cmd := range statements.Commands {
+		if strings.TrimSpace(cmd) != "" {
+			commands = append(commands, cmd)
+		}
+	}
+	if len(commands) == 0 {
+		stmt := defaultCreationStatement
+		return &stmt, nil
+	}
+	if len(commands) > 1 {
+		return nil, errors.New("only 1 creation statement supported for creation")
+	}
+
+	stmt := &creationStatement{}
+	if err := json.Unmarshal([]byte(commands[0]), stmt); err != nil {
+		return nil, fmt.Errorf("unable to parse creation statement: %w", err)
+	}
+	if len(stmt.Roles) == 0 && len(stmt.Groups) == 0 {
+		return nil, errors.New("creation statement must grant at least one role or group")
+	}
+	for _, r := range stmt.Roles {
+		switch {
+		case r.Role == "":
+			return nil, errors.New("role name cannot be empty")
+		case r.ScopeName != "" && r.BucketName == "":
+			return nil, fmt.Errorf("role %q: scope_name requires bucket_name", r.Role)
+		case r.CollectionName != "" && r.ScopeName == "":
+			return nil, fmt.Errorf("role %q: collection_name requires scope_name", r.Role)
+		}
+	}
+	return stmt, nil
+}
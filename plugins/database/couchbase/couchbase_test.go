@@ -0,0 +1,423 @@
+package couchbase
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/testhelpers/certhelpers"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+type fakeUser struct {
+	password string
+	name     string
+	roles    string
+	groups   string
+}
+
+// fakeCouchbase implements the management API endpoints used by the plugin
+type fakeCouchbase struct {
+	sync.Mutex
+	users map[string]*fakeUser
+	// groupRoles are the roles granted to the members of each group
+	groupRoles map[string][]role
+}
+
+func newFakeCouchbase() *fakeCouchbase {
+	return &fakeCouchbase{
+		users: map[string]*fakeUser{
+			"vault": {password: "secret", roles: "admin"},
+		},
+		groupRoles: map[string][]role{
+			"readers": {{Role: "ro_admin"}},
+		},
+	}
+}
+
+func (f *fakeCouchbase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	var authenticated string
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		authenticated = r.TLS.PeerCertificates[0].Subject.CommonName
+	} else {
+		username, password, ok := r.BasicAuth()
+		if u := f.users[username]; !ok || u == nil || u.password != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		authenticated = username
+	}
+
+	const usersPath = "/settings/rbac/users/local/"
+	name := strings.TrimPrefix(r.URL.Path, usersPath)
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/whoami":
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": authenticated, "domain": "local"})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/pools/default/buckets/travel-sample":
+		w.Write([]byte(`{"name": "travel-sample"}`))
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, usersPath):
+		u, ok := f.users[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(f.rbacUser(name, u))
+
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, usersPath):
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("password") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.users[name] = &fakeUser{
+			password: r.PostForm.Get("password"),
+			name:     r.PostForm.Get("name"),
+			roles:    r.PostForm.Get("roles"),
+			groups:   r.PostForm.Get("groups"),
+		}
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, usersPath):
+		if _, ok := f.users[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.users, name)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// rbacUser returns the user as returned by the RBAC API. Only simple roles
+// granted directly are supported, along with the roles of the groups.
+func (f *fakeCouchbase) rbacUser(name string, u *fakeUser) map[string]interface{} {
+	var roles []map[string]interface{}
+	if u.roles != "" {
+		for _, r := range strings.Split(u.roles, ",") {
+			roles = append(roles, map[string]interface{}{
+				"role":    r,
+				"origins": []map[string]string{{"type": "user"}},
+			})
+		}
+	}
+	var groups []string
+	if u.groups != "" {
+		groups = strings.Split(u.groups, ",")
+		for _, g := range groups {
+			for _, r := range f.groupRoles[g] {
+				roles = append(roles, map[string]interface{}{
+					"role":    r.Role,
+					"origins": []map[string]string{{"type": "group", "name": g}},
+				})
+			}
+		}
+	}
+	return map[string]interface{}{
+		"id":     name,
+		"domain": "local",
+		"name":   u.name,
+		"roles":  roles,
+		"groups": groups,
+	}
+}
+
+func TestCouchbase_Initialize(t *testing.T) {
+	fake := newFakeCouchbase()
+
+	caCert := certhelpers.NewCert(t,
+		certhelpers.CommonName("test certificate authority"),
+		certhelpers.IsCA(true),
+		certhelpers.SelfSign(),
+	)
+	clientCert := certhelpers.NewCert(t,
+		certhelpers.CommonName("vault"),
+		certhelpers.Parent(caCert),
+	)
+	ca, err := x509.ParseCertificate(caCert.RawCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	srv := httptest.NewUnstartedServer(fake)
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  clientCAs,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	host := srv.Listener.Addr().String()
+	base64pem := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"password": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret", "base64pem": base64pem},
+			valid:  true,
+		},
+		"x.509": {
+			config: map[string]interface{}{"hosts": host, "tls": true, "tls_certificate_key": string(clientCert.CombinedPEM()), "base64pem": base64pem},
+			valid:  true,
+		},
+		"bucket": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret", "insecure_tls": true, "bucket_name": "travel-sample"},
+			valid:  true,
+		},
+		"failover": {
+			config: map[string]interface{}{"hosts": "couchbases://127.0.0.1:1," + host, "username": "vault", "password": "secret", "insecure_tls": true},
+			valid:  true,
+		},
+		"missing bucket": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret", "insecure_tls": true, "bucket_name": "missing"},
+		},
+		"untrusted server": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret"},
+		},
+		"bad password": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "wrong", "base64pem": base64pem},
+		},
+		"missing hosts": {
+			config: map[string]interface{}{"username": "vault", "password": "secret"},
+		},
+		"missing credentials": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault"},
+		},
+		"x.509 and password": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret", "tls_certificate_key": string(clientCert.CombinedPEM())},
+		},
+		"x.509 without tls": {
+			config: map[string]interface{}{"hosts": host, "tls_certificate_key": string(clientCert.CombinedPEM())},
+		},
+		"tls with couchbase scheme": {
+			config: map[string]interface{}{"hosts": "couchbase://" + host, "tls": true, "username": "vault", "password": "secret"},
+		},
+		"invalid base64pem": {
+			config: map[string]interface{}{"hosts": "couchbases://" + host, "username": "vault", "password": "secret", "base64pem": "not a certificate"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := newCouchbase()
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config:           tc.config,
+				VerifyConnection: true,
+			})
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+		})
+	}
+}
+
+func TestCouchbase_ManagementURLs(t *testing.T) {
+	tests := map[string]struct {
+		config *couchbaseConfig
+		tls    bool
+		urls   []string
+	}{
+		"hosts": {
+			config: &couchbaseConfig{Hosts: "node1, node2:9000"},
+			urls:   []string{"http://node1:8091", "http://node2:9000"},
+		},
+		"tls": {
+			config: &couchbaseConfig{Hosts: "node1", TLS: true},
+			tls:    true,
+			urls:   []string{"https://node1:18091"},
+		},
+		"connection string": {
+			config: &couchbaseConfig{Hosts: "couchbase://node1,[::1]?network=external"},
+			urls:   []string{"http://node1:8091", "http://[::1]:8091"},
+		},
+		"tls connection string": {
+			config: &couchbaseConfig{Hosts: "couchbases://node1"},
+			tls:    true,
+			urls:   []string{"https://node1:18091"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			useTLS, urls, err := tc.config.managementURLs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if useTLS != tc.tls || !reflect.DeepEqual(urls, tc.urls) {
+				t.Fatalf("Actual: %v %#v\nExpected: %v %#v", useTLS, urls, tc.tls, tc.urls)
+			}
+		})
+	}
+}
+
+func TestCouchbase_Users(t *testing.T) {
+	fake := newFakeCouchbase()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	db := newCouchbase()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"hosts":    strings.TrimPrefix(srv.URL, "http://"),
+			"username": "vault",
+			"password": "secret",
+		},
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	newUser := func(statements ...string) (dbplugin.NewUserResponse, error) {
+		return db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "my-role",
+			},
+			Statements: dbplugin.Statements{
+				Commands: statements,
+			},
+			Password:   "Passw0rd",
+			Expiration: time.Now().Add(time.Hour),
+		})
+	}
+
+	// Users are granted read-only admin by default
+	resp, err := newUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := fake.users[resp.Username]; u == nil || u.roles != "ro_admin" {
+		t.Fatalf("bad user %q: %#v", resp.Username, u)
+	}
+
+	resp, err = newUser(`{"roles": [{"role": "data_reader", "bucket_name": "travel-sample", "scope_name": "inventory", "collection_name": "airline"}, {"role": "bucket_admin", "bucket_name": "travel-sample"}], "groups": ["readers"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	username := resp.Username
+	if !strings.HasPrefix(username, "V_TOKEN_MY-ROLE_") {
+		t.Fatalf("bad username: %s", username)
+	}
+	expected := &fakeUser{
+		password: "Passw0rd",
+		name:     "token",
+		roles:    "data_reader[travel-sample:inventory:airline],bucket_admin[travel-sample]",
+		groups:   "readers",
+	}
+	if u := fake.users[username]; !reflect.DeepEqual(u, expected) {
+		t.Fatalf("Actual user: %#v\nExpected user: %#v", u, expected)
+	}
+
+	for _, stmts := range [][]string{
+		{"not json"},
+		{`{}`},
+		{`{"roles": [{"bucket_name": "travel-sample"}]}`},
+		{`{"roles": [{"role": "data_reader", "scope_name": "inventory"}]}`},
+		{`{"roles": [{"role": "data_reader", "bucket_name": "travel-sample", "collection_name": "airline"}]}`},
+		{`{"roles": [{"role": "ro_admin"}]}`, `{"roles": [{"role": "admin"}]}`},
+	} {
+		if _, err := newUser(stmts...); err == nil {
+			t.Fatalf("expected error for statements %q", stmts)
+		}
+	}
+
+	// Only the password changes, and roles inherited from groups are not
+	// granted to the user
+	fake.users[username].roles = "ro_admin"
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	expected = &fakeUser{
+		password: "n3wPassw0rd",
+		name:     "token",
+		roles:    "ro_admin",
+		groups:   "readers",
+	}
+	if u := fake.users[username]; !reflect.DeepEqual(u, expected) {
+		t.Fatalf("Actual user: %#v\nExpected user: %#v", u, expected)
+	}
+
+	// Users are not created by password changes
+	_, err = db.UpdateUser(context.Background(), dbplugin.UpdateUserRequest{
+		Username: "missing",
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+	if _, ok := fake.users[username]; ok {
+		t.Fatalf("user was not deleted")
+	}
+
+	// Deletion can be retried
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+}
+
+func TestCouchbase_RotateRoot(t *testing.T) {
+	fake := newFakeCouchbase()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	db := newCouchbase()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"hosts":    strings.TrimPrefix(srv.URL, "http://"),
+			"username": "vault",
+			"password": "secret",
+		},
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: "vault",
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "rotated",
+		},
+	})
+
+	// The client uses the new password
+	if _, err := db.client.whoami(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fake.users["vault"].roles != "admin" {
+		t.Fatalf("roles of the root user were changed: %q", fake.users["vault"].roles)
+	}
+	if _, ok := db.secretValues()["rotated"]; !ok {
+		t.Fatalf("new password is not redacted from errors")
+	}
+}
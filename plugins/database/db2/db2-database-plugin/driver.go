@@ -0,0 +1,8 @@
+//go:build db2
+// +build db2
+
+package main
+
+// The go_ibm_db driver requires cgo and the IBM Data Server Driver for ODBC
+// and CLI, so it is only linked when building with the db2 build tag
+import _ "github.com/ibmdb/go_ibm_db"
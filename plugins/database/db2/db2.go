@@ -0,0 +1,301 @@
+package db2
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/dbtxn"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	db2TypeName = "db2"
+
+	// driverName is the name under which the go_ibm_db driver, built on the
+	// IBM Data Server Driver for ODBC and CLI, registers with database/sql
+	driverName = "go_ibm_db"
+
+	// Db2 authorization IDs map to operating system or LDAP users, whose
+	// names are limited to 30 bytes by Db2
+	maxUsernameLength = 30
+)
+
+// validUsername matches the authorization IDs that can be used unquoted in SQL
+// statements and as operating system and LDAP user names
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Db2 is an implementation of Database interface for IBM Db2. Db2 does not
+// manage users itself: it authenticates them against the operating system or
+// an LDAP directory. The plugin creates users in the LDAP directory with a user
+// hook, and then grants them privileges with the creation statements.
+type Db2 struct {
+	*connutil.SQLConnectionProducer
+
+	hook             userHook
+	hookSecrets      map[string]string
+	usernameTemplate *template.StringTemplate
+}
+
+var _ dbplugin.Database = (*Db2)(nil)
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := new()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func new() *Db2 {
+	connProducer := &connutil.SQLConnectionProducer{}
+	connProducer.Type = driverName
+
+	return &Db2{
+		SQLConnectionProducer: connProducer,
+	}
+}
+
+func (d *Db2) secretValues() map[string]string {
+	d.Lock()
+	defer d.Unlock()
+
+	values := map[string]string{
+		d.Password: "[password]",
+	}
+	for secret, name := range d.hookSecrets {
+		values[secret] = name
+	}
+	return values
+}
+
+// Initialize configures the connection and the user hook of the plugin
+func (d *Db2) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	if !driverRegistered(driverName) {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("the %s driver is not available, the plugin must be built with the db2 build tag and the IBM Data Server Driver for ODBC and CLI", driverName)
+	}
+
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	hookConfig := &userHookConfig{}
+	if err := mapstructure.WeakDecode(req.Config, hookConfig); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+	hook, err := newUserHook(hookConfig)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	// The connection URL is a CLI connection string, such as
+	// DATABASE=sample;HOSTNAME=db2.example.com;PORT=50000;UID={{username}};PWD={{password}},
+	// whose values must not be URL escaped like the producer does
+	conf := make(map[string]interface{}, len(req.Config))
+	for k, v := range req.Config {
+		conf[k] = v
+	}
+	if connURL, ok := conf["connection_url"].(string); ok {
+		username, _ := conf["username"].(string)
+		password, _ := conf["password"].(string)
+		conf["connection_url"] = dbutil.QueryHelper(connURL, map[string]string{
+			"username": username,
+			"password": password,
+		})
+	}
+
+	if _, err := d.Init(ctx, conf, req.VerifyConnection); err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("error initializing db: %w", err)
+	}
+
+	if req.VerifyConnection {
+		if err := hook.verify(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying user hook: %w", err)
+		}
+	}
+
+	d.Lock()
+	d.RawConfig = req.Config
+	d.hook = hook
+	d.hookSecrets = hookConfig.secretValues()
+	d.usernameTemplate = usernameTemplate
+	d.Unlock()
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+func driverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Type returns the TypeName for this backend
+func (d *Db2) Type() (string, error) {
+	return db2TypeName, nil
+}
+
+func (d *Db2) getConnection(ctx context.Context) (*sql.DB, error) {
+	db, err := d.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*sql.DB), nil
+}
+
+// NewUser creates the user with the user hook, then grants it privileges with
+// the creation statements. The user is deleted again if the statements fail.
+func (d *Db2) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if len(req.Statements.Commands) == 0 {
+		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
+	}
+
+	db, err := d.getConnection(ctx)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 8),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 8),
+		credsutil.MaxLength(maxUsernameLength),
+		credsutil.Separator("_"),
+		credsutil.ToLower(),
+		credsutil.Template(d.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	// Hyphens would need the authorization ID to be quoted in every statement
+	username = strings.Replace(username, "-", "_", -1)
+	if !validUsername.MatchString(username) {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("invalid username %q, must only contain letters, digits and underscores", username)
+	}
+
+	if err := d.hook.createUser(ctx, username, req.Password); err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to create user %q: %w", username, err)
+	}
+
+	m := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   req.Password,
+		"expiration": req.Expiration.UTC().Format("2006-01-02-15.04.05"),
+	}
+	if err := executeStatements(ctx, db, req.Statements.Commands, m); err != nil {
+		// Don't leave a user without its privileges behind
+		if delErr := d.hook.deleteUser(ctx, username); delErr != nil {
+			return dbplugin.NewUserResponse{}, fmt.Errorf("%v, and unable to delete user %q: %w", err, username, delErr)
+		}
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password of the user with the user hook. LDAP users
+// do not expire with their leases, so changing the expiration is a no-op.
+func (d *Db2) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Username == "" || req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("must provide both username and password")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if d.hook == nil {
+		return dbplugin.UpdateUserResponse{}, connutil.ErrNotInitialized
+	}
+
+	if err := d.hook.setPassword(ctx, req.Username, req.Password.NewPassword); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to change password of user %q: %w", req.Username, err)
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser runs the revocation statements, then deletes the user with the
+// user hook. Privileges granted to the authorization ID stay in the catalog
+// unless the revocation statements revoke them.
+func (d *Db2) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if len(req.Statements.Commands) > 0 {
+		db, err := d.getConnection(ctx)
+		if err != nil {
+			return dbplugin.DeleteUserResponse{}, err
+		}
+
+		m := map[string]string{
+			"name":     req.Username,
+			"username": req.Username,
+		}
+		if err := executeStatements(ctx, db, req.Statements.Commands, m); err != nil {
+			return dbplugin.DeleteUserResponse{}, err
+		}
+	}
+
+	if d.hook == nil {
+		return dbplugin.DeleteUserResponse{}, connutil.ErrNotInitialized
+	}
+	if err := d.hook.deleteUser(ctx, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to delete user %q: %w", req.Username, err)
+	}
+
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// executeStatements runs the statements in a transaction
+func executeStatements(ctx context.Context, db *sql.DB, statements []string, m map[string]string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,343 @@
+package db2
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+// fakeDriver stands in for go_ibm_db, which needs the IBM CLI driver, and
+// records the statements of committed transactions
+type fakeDriver struct {
+	sync.Mutex
+	dsns      []string
+	committed []string
+}
+
+var testDriver = &fakeDriver{}
+
+func init() {
+	sql.Register(driverName, testDriver)
+}
+
+func (d *fakeDriver) reset() {
+	d.Lock()
+	defer d.Unlock()
+	d.dsns = nil
+	d.committed = nil
+}
+
+func (d *fakeDriver) statements() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.committed...)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.Lock()
+	defer d.Unlock()
+	d.dsns = append(d.dsns, dsn)
+	return &fakeConn{driver: d}, nil
+}
+
+type fakeConn struct {
+	driver  *fakeDriver
+	pending []string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.pending = nil
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.driver.Lock()
+	defer c.driver.Unlock()
+	c.driver.committed = append(c.driver.committed, c.pending...)
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// Exec fails statements granting privileges to missing objects
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "MISSING") {
+		return nil, errors.New("SQL0204N  \"MISSING\" is an undefined name.")
+	}
+	s.conn.pending = append(s.conn.pending, s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestDb2_Initialize(t *testing.T) {
+	ldapConfig := func(config map[string]interface{}) map[string]interface{} {
+		config["user_hook"] = "ldap"
+		config["ldap_url"] = "ldaps://ldap.example.com"
+		config["bind_dn"] = "cn=admin,dc=example,dc=com"
+		config["bind_password"] = "bindsecret"
+		config["user_dn"] = "ou=db2,dc=example,dc=com"
+		return config
+	}
+
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"ldap hook": {
+			config: ldapConfig(map[string]interface{}{
+				"connection_url": "DATABASE=sample;HOSTNAME=localhost;PORT=50000;UID={{username}};PWD={{password}}",
+				"username":       "vault",
+				"password":       "p@ss;word",
+			}),
+			valid: true,
+		},
+		"missing user hook": {
+			config: map[string]interface{}{
+				"connection_url": "DATABASE=sample;HOSTNAME=localhost",
+			},
+		},
+		"invalid user hook": {
+			config: map[string]interface{}{
+				"connection_url": "DATABASE=sample;HOSTNAME=localhost",
+				"user_hook":      "pam",
+			},
+		},
+		"command user hook": {
+			config: map[string]interface{}{
+				"connection_url": "DATABASE=sample;HOSTNAME=localhost",
+				"user_hook":      "command",
+				"hook_command":   []string{"/bin/sh"},
+			},
+		},
+		"missing ldap user_dn": {
+			config: map[string]interface{}{
+				"connection_url": "DATABASE=sample;HOSTNAME=localhost",
+				"user_hook":      "ldap",
+				"ldap_url":       "ldap://localhost",
+				"bind_dn":        "cn=admin,dc=example,dc=com",
+				"bind_password":  "secret",
+			},
+		},
+		"missing connection_url": {
+			config: ldapConfig(map[string]interface{}{}),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			testDriver.reset()
+			db := new()
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config: tc.config,
+			})
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+
+			conn, err := db.Connection(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := conn.(*sql.DB).Ping(); err != nil {
+				t.Fatal(err)
+			}
+
+			// The credentials are not URL escaped in the connection string
+			expected := "DATABASE=sample;HOSTNAME=localhost;PORT=50000;UID=vault;PWD=p@ss;word"
+			if len(testDriver.dsns) == 0 || testDriver.dsns[0] != expected {
+				t.Fatalf("Actual DSNs: %#v\nExpected DSN: %q", testDriver.dsns, expected)
+			}
+		})
+	}
+}
+
+// fakeLDAP implements the operations used by the LDAP user hook
+type fakeLDAP struct {
+	ldap.Client
+
+	entries map[string]map[string][]string
+}
+
+func (f *fakeLDAP) Bind(username, password string) error {
+	if username != "cn=admin,dc=example,dc=com" || password != "bindsecret" {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+	}
+	return nil
+}
+
+func (f *fakeLDAP) Close() {}
+
+func (f *fakeLDAP) Add(req *ldap.AddRequest) error {
+	if _, ok := f.entries[req.DN]; ok {
+		return ldap.NewError(ldap.LDAPResultEntryAlreadyExists, nil)
+	}
+	entry := make(map[string][]string)
+	for _, attr := range req.Attributes {
+		entry[attr.Type] = attr.Vals
+	}
+	f.entries[req.DN] = entry
+	return nil
+}
+
+func (f *fakeLDAP) Del(req *ldap.DelRequest) error {
+	if _, ok := f.entries[req.DN]; !ok {
+		return ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+	delete(f.entries, req.DN)
+	return nil
+}
+
+func (f *fakeLDAP) Modify(req *ldap.ModifyRequest) error {
+	entry, ok := f.entries[req.DN]
+	if !ok {
+		return ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+	for _, change := range req.Changes {
+		entry[change.Modification.Type] = change.Modification.Vals
+	}
+	return nil
+}
+
+func TestDb2_LDAPHook(t *testing.T) {
+	testDriver.reset()
+	fake := &fakeLDAP{entries: make(map[string]map[string][]string)}
+
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"connection_url": "DATABASE=sample;HOSTNAME=localhost;PORT=50000;UID={{username}};PWD={{password}}",
+			"username":       "vault",
+			"password":       "secret",
+			"user_hook":      "ldap",
+			"ldap_url":       "ldaps://ldap.example.com",
+			"bind_dn":        "cn=admin,dc=example,dc=com",
+			"bind_password":  "bindsecret",
+			"user_dn":        "ou=db2,dc=example,dc=com",
+		},
+	})
+	defer dbtesting.AssertClose(t, db)
+	db.hook.(*ldapHook).dial = func() (ldap.Client, error) {
+		return fake, nil
+	}
+
+	if _, ok := db.secretValues()["bindsecret"]; !ok {
+		t.Fatalf("bind password is not redacted from errors")
+	}
+
+	newUser := func(statements ...string) (dbplugin.NewUserResponse, error) {
+		return db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "my-role",
+			},
+			Statements: dbplugin.Statements{
+				Commands: statements,
+			},
+			Password:   "Passw0rd",
+			Expiration: time.Now().Add(time.Hour),
+		})
+	}
+
+	resp, err := newUser("GRANT CONNECT ON DATABASE TO USER {{name}}; GRANT SELECT ON TABLE sales.orders TO USER {{name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Username, "v_token_my_role_") || len(resp.Username) > maxUsernameLength {
+		t.Fatalf("bad username: %s", resp.Username)
+	}
+	expected := []string{
+		"GRANT CONNECT ON DATABASE TO USER " + resp.Username,
+		"GRANT SELECT ON TABLE sales.orders TO USER " + resp.Username,
+	}
+	if statements := testDriver.statements(); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("Actual statements: %#v\nExpected statements: %#v", statements, expected)
+	}
+	dn := "uid=" + resp.Username + ",ou=db2,dc=example,dc=com"
+	entry, ok := fake.entries[dn]
+	if !ok {
+		t.Fatalf("user entry was not created: %#v", fake.entries)
+	}
+	if !reflect.DeepEqual(entry["userPassword"], []string{"Passw0rd"}) {
+		t.Fatalf("bad user entry: %#v", entry)
+	}
+
+	// Creation statements are required
+	if _, err := newUser(); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Users are deleted if their privileges can't be granted
+	if _, err := newUser("GRANT SELECT ON TABLE MISSING TO USER {{name}}"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(fake.entries) != 1 {
+		t.Fatalf("expected the user to be deleted: %#v", fake.entries)
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: resp.Username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if !reflect.DeepEqual(fake.entries[dn]["userPassword"], []string{"n3wPassw0rd"}) {
+		t.Fatalf("password was not changed: %#v", fake.entries[dn])
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+		Statements: dbplugin.Statements{
+			Commands: []string{"REVOKE SELECT ON TABLE sales.orders FROM USER {{name}}"},
+		},
+	})
+	if _, ok := fake.entries[dn]; ok {
+		t.Fatalf("user was not deleted")
+	}
+	if statements := testDriver.statements(); statements[len(statements)-1] != "REVOKE SELECT ON TABLE sales.orders FROM USER "+resp.Username {
+		t.Fatalf("revocation statements were not executed: %#v", statements)
+	}
+
+	// Deletion can be retried
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+}
@@ -0,0 +1,233 @@
+package db2
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	userHookLDAP = "ldap"
+
+	defaultHookTimeout = 30 * time.Second
+
+	defaultUserAttr = "uid"
+)
+
+var defaultObjectClasses = []string{"inetOrgPerson"}
+
+// userHook creates and deletes the users Db2 authenticates. Only LDAP users
+// can be managed: Vault does not run commands to manage operating system
+// users.
+type userHook interface {
+	// verify checks that the hook can be used
+	verify(ctx context.Context) error
+	createUser(ctx context.Context, username, password string) error
+	setPassword(ctx context.Context, username, password string) error
+	// deleteUser deletes the user, ignoring users that do not exist
+	deleteUser(ctx context.Context, username string) error
+}
+
+type userHookConfig struct {
+	UserHook string `mapstructure:"user_hook"`
+
+	LDAPURL       string   `mapstructure:"ldap_url"`
+	StartTLS      bool     `mapstructure:"starttls"`
+	InsecureTLS   bool     `mapstructure:"insecure_tls"`
+	CACert        string   `mapstructure:"ca_cert"`
+	BindDN        string   `mapstructure:"bind_dn"`
+	BindPassword  string   `mapstructure:"bind_password"`
+	UserDN        string   `mapstructure:"user_dn"`
+	UserAttr      string   `mapstructure:"user_attr"`
+	ObjectClasses []string `mapstructure:"object_classes"`
+}
+
+func (c *userHookConfig) secretValues() map[string]string {
+	if c.BindPassword == "" {
+		return nil
+	}
+	return map[string]string{
+		c.BindPassword: "[bind_password]",
+	}
+}
+
+func newUserHook(c *userHookConfig) (userHook, error) {
+	switch c.UserHook {
+	case userHookLDAP:
+		return newLDAPHook(c)
+	case "":
+		return nil, errors.New("user_hook is required")
+	default:
+		return nil, fmt.Errorf("invalid user_hook %q, must be %q", c.UserHook, userHookLDAP)
+	}
+}
+
+// ldapHook manages the users of the directory used by the LDAP security
+// plugin of Db2. Each operation uses its own connection.
+type ldapHook struct {
+	url          string
+	startTLS     bool
+	tlsConfig    *tls.Config
+	bindDN       string
+	bindPassword string
+
+	userDN        string
+	userAttr      string
+	objectClasses []string
+
+	// dial is replaced in tests
+	dial func() (ldap.Client, error)
+}
+
+func newLDAPHook(c *userHookConfig) (*ldapHook, error) {
+	switch {
+	case c.LDAPURL == "":
+		return nil, errors.New("ldap_url is required when user_hook is \"ldap\"")
+	case c.BindDN == "":
+		return nil, errors.New("bind_dn is required when user_hook is \"ldap\"")
+	case c.BindPassword == "":
+		return nil, errors.New("bind_password is required when user_hook is \"ldap\"")
+	case c.UserDN == "":
+		return nil, errors.New("user_dn is required when user_hook is \"ldap\"")
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureTLS,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM([]byte(c.CACert)); !ok {
+			return nil, errors.New("unable to parse ca_cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	h := &ldapHook{
+		url:           c.LDAPURL,
+		startTLS:      c.StartTLS,
+		tlsConfig:     tlsConfig,
+		bindDN:        c.BindDN,
+		bindPassword:  c.BindPassword,
+		userDN:        c.UserDN,
+		userAttr:      c.UserAttr,
+		objectClasses: c.ObjectClasses,
+	}
+	if h.userAttr == "" {
+		h.userAttr = defaultUserAttr
+	}
+	if len(h.objectClasses) == 0 {
+		h.objectClasses = defaultObjectClasses
+	}
+	h.dial = h.dialURL
+
+	return h, nil
+}
+
+func (h *ldapHook) dialURL() (ldap.Client, error) {
+	conn, err := ldap.DialURL(h.url, ldap.DialWithTLSConfig(h.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(defaultHookTimeout)
+
+	if h.startTLS {
+		if err := conn.StartTLS(h.tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// connect dials the directory and binds with the configured credentials
+func (h *ldapHook) connect() (ldap.Client, error) {
+	conn, err := h.dial()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Bind(h.bindDN, h.bindPassword); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (h *ldapHook) verify(ctx context.Context) error {
+	conn, err := h.connect()
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+func (h *ldapHook) createUser(ctx context.Context, username, password string) error {
+	conn, err := h.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	add := ldap.NewAddRequest(h.userEntryDN(username), nil)
+	add.Attribute("objectClass", h.objectClasses)
+	add.Attribute(h.userAttr, []string{username})
+	if h.userAttr != "cn" {
+		add.Attribute("cn", []string{username})
+	}
+	add.Attribute("sn", []string{username})
+	add.Attribute("userPassword", []string{password})
+	return conn.Add(add)
+}
+
+func (h *ldapHook) setPassword(ctx context.Context, username, password string) error {
+	conn, err := h.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	modify := ldap.NewModifyRequest(h.userEntryDN(username), nil)
+	modify.Replace("userPassword", []string{password})
+	return conn.Modify(modify)
+}
+
+func (h *ldapHook) deleteUser(ctx context.Context, username string) error {
+	conn, err := h.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = conn.Del(ldap.NewDelRequest(h.userEntryDN(username), nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return err
+	}
+	return nil
+}
+
+// userEntryDN returns the DN of the entry of the user
+func (h *ldapHook) userEntryDN(username string) string {
+	return fmt.Sprintf("%s=%s,%s", h.userAttr, escapeDNValue(username), h.userDN)
+}
+
+// escapeDNValue escapes an attribute value of a DN, as described in RFC 4514
+func escapeDNValue(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(value)-1 && r == ' ':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// client implements the part of the Elasticsearch security API needed to
+// manage native users and roles:
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/security-api.html
+type client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	// Requests are authenticated with the API key if set, or with basic
+	// authentication otherwise
+	username string
+	password string
+	apiKey   string
+}
+
+type user struct {
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+// authenticate returns the user the client authenticates as, verifying the
+// connection and credentials.
+func (c *client) authenticate(ctx context.Context) (string, error) {
+	var resp struct {
+		Username string `json:"username"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/_security/_authenticate", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Username, nil
+}
+
+func (c *client) createRole(ctx context.Context, name string, role map[string]interface{}) error {
+	return c.do(ctx, http.MethodPut, "/_security/role/"+url.PathEscape(name), role, nil)
+}
+
+// deleteRole deletes the role. It is not an error for the role not to exist.
+func (c *client) deleteRole(ctx context.Context, name string) error {
+	return ignoreNotFound(c.do(ctx, http.MethodDelete, "/_security/role/"+url.PathEscape(name), nil, nil))
+}
+
+func (c *client) createUser(ctx context.Context, name string, u *user) error {
+	return c.do(ctx, http.MethodPut, "/_security/user/"+url.PathEscape(name), u, nil)
+}
+
+func (c *client) changePassword(ctx context.Context, name, password string) error {
+	body := map[string]string{
+		"password": password,
+	}
+	return c.do(ctx, http.MethodPost, "/_security/user/"+url.PathEscape(name)+"/_password", body, nil)
+}
+
+// deleteUser deletes the user. It is not an error for the user not to exist.
+func (c *client) deleteUser(ctx context.Context, name string) error {
+	return ignoreNotFound(c.do(ctx, http.MethodDelete, "/_security/user/"+url.PathEscape(name), nil, nil))
+}
+
+// apiError is an error response of the Elasticsearch API
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("unexpected response status %d: %s", e.StatusCode, e.Body)
+}
+
+func ignoreNotFound(err error) error {
+	if apiErr, ok := err.(*apiError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, ret interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.baseURL, "/")+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+	} else {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+		}
+	}
+
+	if ret == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, ret)
+}
@@ -0,0 +1,353 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	multierror "github.com/hashicorp/go-multierror"
+	rootcerts "github.com/hashicorp/go-rootcerts"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const elasticsearchTypeName = "elasticsearch"
+
+var _ dbplugin.Database = (*Elasticsearch)(nil)
+
+// Elasticsearch is an implementation of Database interface managing native
+// users and roles through the security API
+type Elasticsearch struct {
+	// This protects the config from races while also allowing multiple
+	// threads to use the client simultaneously when it's not changing
+	mux sync.RWMutex
+
+	config           *esConfig
+	client           *client
+	usernameTemplate *template.StringTemplate
+}
+
+type esConfig struct {
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	APIKey   string `mapstructure:"api_key"`
+
+	// Paths of PEM files, as accepted by earlier versions of the plugin
+	CACert     string `mapstructure:"ca_cert"`
+	CAPath     string `mapstructure:"ca_path"`
+	ClientCert string `mapstructure:"client_cert"`
+	ClientKey  string `mapstructure:"client_key"`
+
+	TLSCAData             []byte `mapstructure:"tls_ca"`
+	TLSCertificateKeyData []byte `mapstructure:"tls_certificate_key"`
+	TLSServerName         string `mapstructure:"tls_server_name"`
+	Insecure              bool   `mapstructure:"insecure"`
+}
+
+// creationStatement is the JSON creation statement of roles, either granting
+// existing Elasticsearch roles or defining a role created for each user.
+type creationStatement struct {
+	PreexistingRoles []string               `json:"elasticsearch_roles"`
+	RoleToCreate     map[string]interface{} `json:"elasticsearch_role_definition"`
+}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := newElasticsearch()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func newElasticsearch() *Elasticsearch {
+	return &Elasticsearch{}
+}
+
+// Type returns the TypeName for this backend
+func (es *Elasticsearch) Type() (string, error) {
+	return elasticsearchTypeName, nil
+}
+
+func (es *Elasticsearch) secretValues() map[string]string {
+	es.mux.RLock()
+	defer es.mux.RUnlock()
+
+	if es.config == nil {
+		return nil
+	}
+	values := map[string]string{}
+	if es.config.Password != "" {
+		values[es.config.Password] = "[password]"
+	}
+	if es.config.APIKey != "" {
+		values[es.config.APIKey] = "[api_key]"
+	}
+	return values
+}
+
+// Initialize validates the configuration and builds the client. Vault
+// authenticates with the API key if one is configured, and with the username
+// and password otherwise.
+func (es *Elasticsearch) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	config := &esConfig{}
+	if err := mapstructure.WeakDecode(req.Config, config); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	switch {
+	case config.URL == "":
+		return dbplugin.InitializeResponse{}, errors.New("url cannot be empty")
+	case config.APIKey != "" && config.Password != "":
+		return dbplugin.InitializeResponse{}, errors.New("api_key and password are mutually exclusive")
+	case config.APIKey == "" && (config.Username == "" || config.Password == ""):
+		return dbplugin.InitializeResponse{}, errors.New("either api_key, or username and password must be provided")
+	}
+
+	c, err := newClient(config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to create client: %w", err)
+	}
+
+	if req.VerifyConnection {
+		if _, err := c.authenticate(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+	}
+
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	es.config = config
+	es.client = c
+	es.usernameTemplate = usernameTemplate
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+func newClient(config *esConfig) (*client, error) {
+	httpClient := cleanhttp.DefaultPooledClient()
+
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		httpClient.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+	}
+
+	return &client{
+		baseURL:    config.URL,
+		httpClient: httpClient,
+		username:   config.Username,
+		password:   config.Password,
+		apiKey:     config.APIKey,
+	}, nil
+}
+
+// tlsConfig builds the TLS configuration of the client, or returns nil if
+// the configuration has no TLS parameters.
+func (c *esConfig) tlsConfig() (*tls.Config, error) {
+	if c.CACert == "" && c.CAPath == "" && c.ClientCert == "" && c.ClientKey == "" &&
+		len(c.TLSCAData) == 0 && len(c.TLSCertificateKeyData) == 0 && c.TLSServerName == "" && !c.Insecure {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.Insecure,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	switch {
+	case len(c.TLSCAData) > 0:
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM(c.TLSCAData); !ok {
+			return nil, errors.New("unable to parse tls_ca")
+		}
+		tlsConfig.RootCAs = pool
+	case c.CACert != "" || c.CAPath != "":
+		err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{
+			CAFile: c.CACert,
+			CAPath: c.CAPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(c.TLSCertificateKeyData) > 0:
+		certificate, err := tls.X509KeyPair(c.TLSCertificateKeyData, c.TLSCertificateKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls_certificate_key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	case c.ClientCert != "" && c.ClientKey != "":
+		certificate, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewUser creates a native user with the roles of the creation statement. If
+// the statement defines a role, a role named after the user is created too.
+func (es *Elasticsearch) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	stmt, err := newCreationStatement(req.Statements)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to read creation_statements: %w", err)
+	}
+
+	// Don't let anyone write the config while we're using its client
+	es.mux.RLock()
+	defer es.mux.RUnlock()
+
+	if es.client == nil {
+		return dbplugin.NewUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 15),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 15),
+		credsutil.MaxLength(100),
+		credsutil.Separator("-"),
+		credsutil.Template(es.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to generate username: %w", err)
+	}
+
+	u := &user{
+		Password: req.Password,
+		Roles:    stmt.PreexistingRoles,
+	}
+	if len(stmt.RoleToCreate) > 0 {
+		if err := es.client.createRole(ctx, username, stmt.RoleToCreate); err != nil {
+			return dbplugin.NewUserResponse{}, fmt.Errorf("unable to create role %q: %w", username, err)
+		}
+		u.Roles = []string{username}
+	}
+
+	if err := es.client.createUser(ctx, username, u); err != nil {
+		var merr *multierror.Error
+		merr = multierror.Append(merr, fmt.Errorf("unable to create user %q: %w", username, err))
+		if len(stmt.RoleToCreate) > 0 {
+			if err := es.client.deleteRole(ctx, username); err != nil {
+				merr = multierror.Append(merr, fmt.Errorf("unable to delete role %q: %w", username, err))
+			}
+		}
+		return dbplugin.NewUserResponse{}, merr.ErrorOrNil()
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password of a user. Native users do not expire, so
+// changing the expiration is a no-op.
+func (es *Elasticsearch) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing username")
+	}
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing password")
+	}
+
+	// Take the write lock, since the client changes if the password of the
+	// root user is rotated
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	if es.client == nil {
+		return dbplugin.UpdateUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	if err := es.client.changePassword(ctx, req.Username, req.Password.NewPassword); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to change password: %w", err)
+	}
+
+	if es.client.apiKey == "" && req.Username == es.config.Username {
+		es.config.Password = req.Password.NewPassword
+		es.client.password = req.Password.NewPassword
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser deletes the user and the role created for it, if any. Users and
+// roles that do not exist are ignored, so deletion can be retried.
+func (es *Elasticsearch) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	es.mux.RLock()
+	defer es.mux.RUnlock()
+
+	if es.client == nil {
+		return dbplugin.DeleteUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	var merr *multierror.Error
+	if err := es.client.deleteRole(ctx, req.Username); err != nil {
+		merr = multierror.Append(merr, fmt.Errorf("unable to delete role %q: %w", req.Username, err))
+	}
+	if err := es.client.deleteUser(ctx, req.Username); err != nil {
+		merr = multierror.Append(merr, fmt.Errorf("unable to delete user %q: %w", req.Username, err))
+	}
+	return dbplugin.DeleteUserResponse{}, merr.ErrorOrNil()
+}
+
+// Close closes the idle connections of the client
+func (es *Elasticsearch) Close() error {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	if es.client != nil {
+		es.client.httpClient.CloseIdleConnections()
+	}
+	return nil
+}
+
+func newCreationStatement(statements dbplugin.Statements) (*creationStatement, error) {
+	if len(statements.Commands) == 0 {
+		return nil, dbutil.ErrEmptyCreationStatement
+	}
+	if len(statements.Commands) > 1 {
+		return nil, errors.New("only 1 creation statement supported for creation")
+	}
+
+	stmt := &creationStatement{}
+	if err := json.Unmarshal([]byte(statements.Commands[0]), stmt); err != nil {
+		return nil, fmt.Errorf("unable to parse creation statement: %w", err)
+	}
+	if len(stmt.PreexistingRoles) > 0 && len(stmt.RoleToCreate) > 0 {
+		return nil, errors.New(`"elasticsearch_roles" and "elasticsearch_role_definition" are mutually exclusive`)
+	}
+	return stmt, nil
+}
@@ -0,0 +1,309 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+const testAPIKey = "dmF1bHQ6a2V5"
+
+// fakeElasticsearch implements the security API endpoints used by the plugin
+type fakeElasticsearch struct {
+	sync.Mutex
+	users           map[string]*user
+	roles           map[string]map[string]interface{}
+	failUserCreates bool
+}
+
+func newFakeElasticsearch() *fakeElasticsearch {
+	return &fakeElasticsearch{
+		users: map[string]*user{
+			"vault": {Password: "secret", Roles: []string{"superuser"}},
+		},
+		roles: map[string]map[string]interface{}{},
+	}
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	authenticated := "vault"
+	if r.Header.Get("Authorization") != "ApiKey "+testAPIKey {
+		username, password, ok := r.BasicAuth()
+		if u := f.users[username]; !ok || u == nil || u.Password != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		authenticated = username
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/_security/"), "/")
+	switch {
+	case r.Method == http.MethodGet && parts[0] == "_authenticate":
+		json.NewEncoder(w).Encode(map[string]interface{}{"username": authenticated})
+		return
+
+	case r.Method == http.MethodPut && parts[0] == "role" && len(parts) == 2:
+		var role map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.roles[parts[1]] = role
+
+	case r.Method == http.MethodDelete && parts[0] == "role" && len(parts) == 2:
+		if _, ok := f.roles[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.roles, parts[1])
+
+	case r.Method == http.MethodPut && parts[0] == "user" && len(parts) == 2:
+		u := &user{}
+		if err := json.NewDecoder(r.Body).Decode(u); err != nil || f.failUserCreates {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.users[parts[1]] = u
+
+	case r.Method == http.MethodPost && parts[0] == "user" && len(parts) == 3 && parts[2] == "_password":
+		u, ok := f.users[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		u.Password = body["password"]
+
+	case r.Method == http.MethodDelete && parts[0] == "user" && len(parts) == 2:
+		if _, ok := f.users[parts[1]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.users, parts[1])
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func TestElasticsearch_Initialize(t *testing.T) {
+	fake := newFakeElasticsearch()
+	srv := httptest.NewTLSServer(fake)
+	defer srv.Close()
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"password": {
+			config: map[string]interface{}{"url": srv.URL, "username": "vault", "password": "secret", "tls_ca": caPEM},
+			valid:  true,
+		},
+		"api key": {
+			config: map[string]interface{}{"url": srv.URL, "api_key": testAPIKey, "tls_ca": caPEM},
+			valid:  true,
+		},
+		"insecure": {
+			config: map[string]interface{}{"url": srv.URL, "api_key": testAPIKey, "insecure": true},
+			valid:  true,
+		},
+		"untrusted server": {
+			config: map[string]interface{}{"url": srv.URL, "api_key": testAPIKey},
+		},
+		"bad password": {
+			config: map[string]interface{}{"url": srv.URL, "username": "vault", "password": "wrong", "tls_ca": caPEM},
+		},
+		"bad api key": {
+			config: map[string]interface{}{"url": srv.URL, "api_key": "wrong", "tls_ca": caPEM},
+		},
+		"missing url": {
+			config: map[string]interface{}{"username": "vault", "password": "secret"},
+		},
+		"missing credentials": {
+			config: map[string]interface{}{"url": srv.URL, "username": "vault"},
+		},
+		"api key and password": {
+			config: map[string]interface{}{"url": srv.URL, "username": "vault", "password": "secret", "api_key": testAPIKey},
+		},
+		"invalid tls_ca": {
+			config: map[string]interface{}{"url": srv.URL, "api_key": testAPIKey, "tls_ca": "not a certificate"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := newElasticsearch()
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config:           tc.config,
+				VerifyConnection: true,
+			})
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+		})
+	}
+}
+
+func TestElasticsearch_Users(t *testing.T) {
+	fake := newFakeElasticsearch()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	db := newElasticsearch()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"url":      srv.URL,
+			"username": "vault",
+			"password": "secret",
+		},
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	newUser := func(statement string) (dbplugin.NewUserResponse, error) {
+		return db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "my-role",
+			},
+			Statements: dbplugin.Statements{
+				Commands: []string{statement},
+			},
+			Password:   "Passw0rd",
+			Expiration: time.Now().Add(time.Hour),
+		})
+	}
+
+	// Users are granted existing roles
+	resp, err := newUser(`{"elasticsearch_roles": ["viewer"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := fake.users[resp.Username]; u == nil || u.Password != "Passw0rd" || !reflect.DeepEqual(u.Roles, []string{"viewer"}) {
+		t.Fatalf("bad user %q: %#v", resp.Username, u)
+	}
+	if _, ok := fake.roles[resp.Username]; ok {
+		t.Fatalf("unexpected role created")
+	}
+
+	// Or a role named after them
+	resp, err = newUser(`{"elasticsearch_role_definition": {"indices": [{"names": ["*"], "privileges": ["read"]}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	username := resp.Username
+	if !strings.HasPrefix(username, "v-token-my-role-") {
+		t.Fatalf("bad username: %s", username)
+	}
+	if u := fake.users[username]; u == nil || !reflect.DeepEqual(u.Roles, []string{username}) {
+		t.Fatalf("bad user %q: %#v", username, u)
+	}
+	if _, ok := fake.roles[username]; !ok {
+		t.Fatalf("role was not created")
+	}
+
+	for _, stmt := range []string{
+		"",
+		"not json",
+		`{"elasticsearch_roles": ["viewer"], "elasticsearch_role_definition": {"cluster": ["all"]}}`,
+	} {
+		if _, err := newUser(stmt); err == nil {
+			t.Fatalf("expected error for statement %q", stmt)
+		}
+	}
+
+	// The role is deleted if the user cannot be created
+	fake.failUserCreates = true
+	if _, err := newUser(`{"elasticsearch_role_definition": {"cluster": ["all"]}}`); err == nil {
+		t.Fatalf("expected error")
+	}
+	fake.failUserCreates = false
+	if len(fake.roles) != 1 {
+		t.Fatalf("role was not rolled back: %#v", fake.roles)
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if fake.users[username].Password != "n3wPassw0rd" {
+		t.Fatalf("password was not changed")
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+	if _, ok := fake.users[username]; ok {
+		t.Fatalf("user was not deleted")
+	}
+	if _, ok := fake.roles[username]; ok {
+		t.Fatalf("role was not deleted")
+	}
+
+	// Deletion can be retried
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+}
+
+func TestElasticsearch_RotateRoot(t *testing.T) {
+	fake := newFakeElasticsearch()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	db := newElasticsearch()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"url":      srv.URL,
+			"username": "vault",
+			"password": "secret",
+		},
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: "vault",
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "rotated",
+		},
+	})
+
+	// The client uses the new password
+	if _, err := db.client.authenticate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.secretValues()["rotated"]; !ok {
+		t.Fatalf("new password is not redacted from errors")
+	}
+}
@@ -16,14 +16,10 @@ func main() {
 	}
 }
 
-// Run instantiates a HANA object, and runs the RPC server for the plugin
+// Run starts the RPC server for the plugin, which creates a new HANA object
+// for every database connection it serves
 func Run() error {
-	dbType, err := hana.New()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database))
+	dbplugin.ServeMultiplex(hana.New)
 
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
@@ -18,11 +19,17 @@ import (
 const (
 	hanaTypeName        = "hdb"
 	maxIdentifierLength = 127
+
+	// expirationFormat is the format of the timestamps of the VALID UNTIL
+	// clause of users
+	expirationFormat = "2006-01-02 15:04:05"
 )
 
 // HANA is an implementation of Database interface
 type HANA struct {
 	*connutil.SQLConnectionProducer
+
+	usernameTemplate *template.StringTemplate
 }
 
 var _ dbplugin.Database = (*HANA)(nil)
@@ -52,11 +59,20 @@ func (h *HANA) secretValues() map[string]string {
 }
 
 func (h *HANA) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
 	conf, err := h.Init(ctx, req.Config, req.VerifyConnection)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, fmt.Errorf("error initializing db: %w", err)
 	}
 
+	h.Lock()
+	h.usernameTemplate = usernameTemplate
+	h.Unlock()
+
 	return dbplugin.InitializeResponse{
 		Config: conf,
 	}, nil
@@ -100,6 +116,7 @@ func (h *HANA) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (respon
 		credsutil.MaxLength(maxIdentifierLength),
 		credsutil.Separator("_"),
 		credsutil.ToUpper(),
+		credsutil.Template(h.usernameTemplate, req.UsernameConfig),
 	)
 
 	if err != nil {
@@ -112,7 +129,7 @@ func (h *HANA) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (respon
 
 	// If expiration is in the role SQL, HANA will deactivate the user when time is up,
 	// regardless of whether vault is alive to revoke lease
-	expirationStr := req.Expiration.UTC().Format("2006-01-02 15:04:05")
+	expirationStr := req.Expiration.UTC().Format(expirationFormat)
 
 	// Start a transaction
 	tx, err := db.BeginTx(ctx, nil)
@@ -131,6 +148,7 @@ func (h *HANA) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (respon
 
 			m := map[string]string{
 				"name":       username,
+				"username":   username,
 				"password":   req.Password,
 				"expiration": expirationStr,
 			}
@@ -236,12 +254,12 @@ func (h *HANA) updateUserPassword(ctx context.Context, tx *sql.Tx, username stri
 func (h *HANA) updateUserExpiration(ctx context.Context, tx *sql.Tx, username string, req *dbplugin.ChangeExpiration) error {
 	// If expiration is in the role SQL, HANA will deactivate the user when time is up,
 	// regardless of whether vault is alive to revoke lease
-	expirationStr := req.NewExpiration.String()
-
-	if username == "" || expirationStr == "" {
+	if username == "" || req.NewExpiration.IsZero() {
 		return fmt.Errorf("must provide both username and expiration")
 	}
 
+	expirationStr := req.NewExpiration.UTC().Format(expirationFormat)
+
 	stmts := req.Statements.Commands
 	if len(stmts) == 0 {
 		stmts = []string{"ALTER USER {{username}} VALID UNTIL '{{expiration}}'"}
@@ -285,6 +303,12 @@ func (h *HANA) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (
 		return dbplugin.DeleteUserResponse{}, err
 	}
 
+	// Sessions outlive the users they belong to, so terminate them before
+	// running the revocation statements
+	if err := disconnectSessions(ctx, db, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
 	// Start a transaction
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -301,7 +325,8 @@ func (h *HANA) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (
 			}
 
 			m := map[string]string{
-				"name": req.Username,
+				"name":     req.Username,
+				"username": req.Username,
 			}
 			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
 				return dbplugin.DeleteUserResponse{}, err
@@ -319,38 +344,51 @@ func (h *HANA) revokeUserDefault(ctx context.Context, req dbplugin.DeleteUserReq
 		return dbplugin.DeleteUserResponse{}, err
 	}
 
-	// Start a transaction
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
+	// Disable server login for user. This is committed on its own so that the
+	// user cannot log in again once its sessions are disconnected.
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER USER %s DEACTIVATE USER NOW", req.Username)); err != nil {
 		return dbplugin.DeleteUserResponse{}, err
 	}
-	defer tx.Rollback()
 
-	// Disable server login for user
-	disableStmt, err := tx.PrepareContext(ctx, fmt.Sprintf("ALTER USER %s DEACTIVATE USER NOW", req.Username))
-	if err != nil {
+	if err := disconnectSessions(ctx, db, req.Username); err != nil {
 		return dbplugin.DeleteUserResponse{}, err
 	}
-	defer disableStmt.Close()
-	if _, err := disableStmt.ExecContext(ctx); err != nil {
+
+	// Performs soft drop (drop if no dependencies)
+	// if hard drop is desired, custom revoke statements should be written for role
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DROP USER %s RESTRICT", req.Username)); err != nil {
 		return dbplugin.DeleteUserResponse{}, err
 	}
 
-	// Invalidates current sessions and performs soft drop (drop if no dependencies)
-	// if hard drop is desired, custom revoke statements should be written for role
-	dropStmt, err := tx.PrepareContext(ctx, fmt.Sprintf("DROP USER %s RESTRICT", req.Username))
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// disconnectSessions force-disconnects the sessions of the user, which
+// requires the SESSION ADMIN system privilege.
+func disconnectSessions(ctx context.Context, db *sql.DB, username string) error {
+	rows, err := db.QueryContext(ctx, "SELECT CONNECTION_ID FROM SYS.M_CONNECTIONS WHERE USER_NAME = ? AND CONNECTION_STATUS != ''", username)
 	if err != nil {
-		return dbplugin.DeleteUserResponse{}, err
+		return fmt.Errorf("failed to list sessions: %w", err)
 	}
-	defer dropStmt.Close()
-	if _, err := dropStmt.ExecContext(ctx); err != nil {
-		return dbplugin.DeleteUserResponse{}, err
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return dbplugin.DeleteUserResponse{}, err
+	for _, id := range ids {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER SYSTEM DISCONNECT SESSION '%d'", id)); err != nil {
+			return fmt.Errorf("failed to disconnect session %d: %w", id, err)
+		}
 	}
 
-	return dbplugin.DeleteUserResponse{}, nil
+	return nil
 }
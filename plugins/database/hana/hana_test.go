@@ -65,6 +65,11 @@ func TestHANA_NewUser(t *testing.T) {
 			expectErr:  false,
 			assertUser: assertCredsExist,
 		},
+		"with restricted user creation statements": {
+			commands:   []string{testHANARestrictedRole, testHANAEnableConnect},
+			expectErr:  false,
+			assertUser: assertCredsExist,
+		},
 	}
 
 	for name, test := range tests {
@@ -239,6 +244,13 @@ func TestHANA_DeleteUser(t *testing.T) {
 			userResp := dbtesting.AssertNewUser(t, db, newReq)
 			assertCredsExist(t, connURL, userResp.Username, password)
 
+			// Keep a session of the user open across the revocation
+			session, err := openSession(connURL, userResp.Username, password)
+			if err != nil {
+				t.Fatalf("Unable to open a session as %q: %s", userResp.Username, err)
+			}
+			defer session.Close()
+
 			req := dbplugin.DeleteUserRequest{
 				Username: userResp.Username,
 				Statements: dbplugin.Statements{
@@ -248,6 +260,10 @@ func TestHANA_DeleteUser(t *testing.T) {
 
 			dbtesting.AssertDeleteUser(t, db, req)
 			assertCredsDoNotExist(t, connURL, userResp.Username, password)
+
+			if err := session.PingContext(context.Background()); err == nil {
+				t.Fatalf("Session of %q was not disconnected", userResp.Username)
+			}
 		})
 	}
 }
@@ -264,6 +280,24 @@ func testCredsExist(t testing.TB, connURL, username, password string) error {
 	return db.Ping()
 }
 
+// openSession returns a single session logged in with the given creds
+func openSession(connURL, username, password string) (*sql.Conn, error) {
+	parts := strings.Split(connURL, "@")
+	connURL = fmt.Sprintf("hdb://%s:%s@%s", username, password, parts[1])
+	db, err := sql.Open("hdb", connURL)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	session, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return session, session.PingContext(context.Background())
+}
+
 func assertCredsExist(t testing.TB, connURL, username, password string) {
 	t.Helper()
 	err := testCredsExist(t, connURL, username, password)
@@ -291,6 +325,12 @@ func copyConfig(config map[string]interface{}) map[string]interface{} {
 const testHANARole = `
 CREATE USER {{name}} PASSWORD "{{password}}" NO FORCE_FIRST_PASSWORD_CHANGE VALID UNTIL '{{expiration}}';`
 
+const testHANARestrictedRole = `
+CREATE RESTRICTED USER {{name}} PASSWORD "{{password}}" NO FORCE_FIRST_PASSWORD_CHANGE VALID FROM NOW UNTIL '{{expiration}}';`
+
+const testHANAEnableConnect = `
+ALTER USER {{name}} ENABLE CLIENT CONNECT;`
+
 const testHANADrop = `
 DROP USER {{name}} CASCADE;`
 
@@ -30,6 +30,9 @@ type influxdbConnectionProducer struct {
 	TLSMinVersion     string      `json:"tls_min_version" structs:"tls_min_version" mapstructure:"tls_min_version"`
 	PemBundle         string      `json:"pem_bundle" structs:"pem_bundle" mapstructure:"pem_bundle"`
 	PemJSON           string      `json:"pem_json" structs:"pem_json" mapstructure:"pem_json"`
+	APIVersion        string      `json:"api_version" structs:"api_version" mapstructure:"api_version"`
+	Token             string      `json:"token" structs:"token" mapstructure:"token"`
+	Org               string      `json:"org" structs:"org" mapstructure:"org"`
 
 	connectTimeout time.Duration
 	certificate    string
@@ -40,6 +43,7 @@ type influxdbConnectionProducer struct {
 	Initialized bool
 	Type        string
 	client      influx.Client
+	v2Client    *influxV2Client
 	sync.Mutex
 }
 
@@ -65,9 +69,22 @@ func (i *influxdbConnectionProducer) Initialize(ctx context.Context, req dbplugi
 		return dbplugin.InitializeResponse{}, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
 	}
 
+	if i.APIVersion == "" {
+		i.APIVersion = apiVersion1
+	}
+
 	switch {
 	case len(i.Host) == 0:
 		return dbplugin.InitializeResponse{}, fmt.Errorf("host cannot be empty")
+	case i.APIVersion == apiVersion2:
+		switch {
+		case len(i.Token) == 0:
+			return dbplugin.InitializeResponse{}, fmt.Errorf("token cannot be empty")
+		case len(i.Org) == 0:
+			return dbplugin.InitializeResponse{}, fmt.Errorf("org cannot be empty")
+		}
+	case i.APIVersion != apiVersion1:
+		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid api_version %q, must be %q or %q", i.APIVersion, apiVersion1, apiVersion2)
 	case len(i.Username) == 0:
 		return dbplugin.InitializeResponse{}, fmt.Errorf("username cannot be empty")
 	case len(i.Password) == 0:
@@ -123,11 +140,25 @@ func (i *influxdbConnectionProducer) Initialize(ctx context.Context, req dbplugi
 	return resp, nil
 }
 
-func (i *influxdbConnectionProducer) Connection(_ context.Context) (interface{}, error) {
+func (i *influxdbConnectionProducer) Connection(ctx context.Context) (interface{}, error) {
 	if !i.Initialized {
 		return nil, connutil.ErrNotInitialized
 	}
 
+	if i.APIVersion == apiVersion2 {
+		if i.v2Client != nil {
+			return i.v2Client, nil
+		}
+
+		cli, err := i.createV2Client(ctx)
+		if err != nil {
+			return nil, err
+		}
+		i.v2Client = cli
+
+		return cli, nil
+	}
+
 	// If we already have a DB, return it
 	if i.client != nil {
 		return i.client, nil
@@ -155,6 +186,12 @@ func (i *influxdbConnectionProducer) Close() error {
 
 	i.client = nil
 
+	if i.v2Client != nil {
+		i.v2Client.Close()
+	}
+
+	i.v2Client = nil
+
 	return nil
 }
 
@@ -168,44 +205,9 @@ func (i *influxdbConnectionProducer) createClient() (influx.Client, error) {
 	}
 
 	if i.TLS {
-		var tlsConfig *tls.Config
-		if len(i.certificate) > 0 || len(i.issuingCA) > 0 {
-			if len(i.certificate) > 0 && len(i.privateKey) == 0 {
-				return nil, fmt.Errorf("found certificate for TLS authentication but no private key")
-			}
-
-			certBundle := &certutil.CertBundle{}
-			if len(i.certificate) > 0 {
-				certBundle.Certificate = i.certificate
-				certBundle.PrivateKey = i.privateKey
-			}
-			if len(i.issuingCA) > 0 {
-				certBundle.IssuingCA = i.issuingCA
-			}
-
-			parsedCertBundle, err := certBundle.ToParsedCertBundle()
-			if err != nil {
-				return nil, errwrap.Wrapf("failed to parse certificate bundle: {{err}}", err)
-			}
-
-			tlsConfig, err = parsedCertBundle.GetTLSConfig(certutil.TLSClient)
-			if err != nil || tlsConfig == nil {
-				return nil, errwrap.Wrapf(fmt.Sprintf("failed to get TLS configuration: tlsConfig:%#v err:{{err}}", tlsConfig), err)
-			}
-		}
-
-		tlsConfig.InsecureSkipVerify = i.InsecureTLS
-
-		if i.TLSMinVersion != "" {
-			var ok bool
-			tlsConfig.MinVersion, ok = tlsutil.TLSLookup[i.TLSMinVersion]
-			if !ok {
-				return nil, fmt.Errorf("invalid 'tls_min_version' in config")
-			}
-		} else {
-			// MinVersion was not being set earlier. Reset it to
-			// zero to gracefully handle upgrades.
-			tlsConfig.MinVersion = 0
+		tlsConfig, err := i.tlsConfig()
+		if err != nil {
+			return nil, err
 		}
 
 		clientConfig.TLSConfig = tlsConfig
@@ -235,9 +237,70 @@ func (i *influxdbConnectionProducer) createClient() (influx.Client, error) {
 	return cli, nil
 }
 
+func (i *influxdbConnectionProducer) createV2Client(ctx context.Context) (*influxV2Client, error) {
+	addr := fmt.Sprintf("http://%s:%s", i.Host, i.Port)
+
+	var tlsConfig *tls.Config
+	if i.TLS {
+		var err error
+		tlsConfig, err = i.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		addr = fmt.Sprintf("https://%s:%s", i.Host, i.Port)
+	}
+
+	return newInfluxV2Client(ctx, addr, i.Token, i.Org, i.connectTimeout, tlsConfig)
+}
+
+func (i *influxdbConnectionProducer) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if len(i.certificate) > 0 || len(i.issuingCA) > 0 {
+		if len(i.certificate) > 0 && len(i.privateKey) == 0 {
+			return nil, fmt.Errorf("found certificate for TLS authentication but no private key")
+		}
+
+		certBundle := &certutil.CertBundle{}
+		if len(i.certificate) > 0 {
+			certBundle.Certificate = i.certificate
+			certBundle.PrivateKey = i.privateKey
+		}
+		if len(i.issuingCA) > 0 {
+			certBundle.IssuingCA = i.issuingCA
+		}
+
+		parsedCertBundle, err := certBundle.ToParsedCertBundle()
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse certificate bundle: {{err}}", err)
+		}
+
+		tlsConfig, err = parsedCertBundle.GetTLSConfig(certutil.TLSClient)
+		if err != nil || tlsConfig == nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to get TLS configuration: tlsConfig:%#v err:{{err}}", tlsConfig), err)
+		}
+	}
+
+	tlsConfig.InsecureSkipVerify = i.InsecureTLS
+
+	if i.TLSMinVersion != "" {
+		var ok bool
+		tlsConfig.MinVersion, ok = tlsutil.TLSLookup[i.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid 'tls_min_version' in config")
+		}
+	} else {
+		// MinVersion was not being set earlier. Reset it to
+		// zero to gracefully handle upgrades.
+		tlsConfig.MinVersion = 0
+	}
+
+	return tlsConfig, nil
+}
+
 func (i *influxdbConnectionProducer) secretValues() map[string]string {
 	return map[string]string{
 		i.Password:  "[password]",
+		i.Token:     "[token]",
 		i.PemBundle: "[pem_bundle]",
 		i.PemJSON:   "[pem_json]",
 	}
@@ -16,14 +16,10 @@ func main() {
 	}
 }
 
-// Run instantiates a Influxdb object, and runs the RPC server for the plugin
+// Run starts the RPC server for the plugin, which creates a new Influxdb object
+// for every database connection it serves
 func Run() error {
-	dbType, err := influxdb.New()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database))
+	dbplugin.ServeMultiplex(influxdb.New)
 
 	return nil
 }
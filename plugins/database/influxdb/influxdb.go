@@ -8,6 +8,7 @@ import (
 	multierror "github.com/hashicorp/go-multierror"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 	influx "github.com/influxdata/influxdb/client/v2"
@@ -25,6 +26,8 @@ var _ dbplugin.Database = &Influxdb{}
 // Influxdb is an implementation of Database interface
 type Influxdb struct {
 	*influxdbConnectionProducer
+
+	usernameTemplate *template.StringTemplate
 }
 
 // New returns a new Cassandra instance
@@ -58,12 +61,45 @@ func (i *Influxdb) getConnection(ctx context.Context) (influx.Client, error) {
 	return cli.(influx.Client), nil
 }
 
+func (i *Influxdb) getV2Connection(ctx context.Context) (*influxV2Client, error) {
+	cli, err := i.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cli.(*influxV2Client), nil
+}
+
+// Initialize initializes the connection producer and parses the username
+// template of the configuration.
+func (i *Influxdb) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	resp, err := i.influxdbConnectionProducer.Initialize(ctx, req)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	i.Lock()
+	i.usernameTemplate = usernameTemplate
+	i.Unlock()
+
+	return resp, nil
+}
+
 // NewUser generates the username/password on the underlying Influxdb secret backend as instructed by
 // the statements provided.
 func (i *Influxdb) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (resp dbplugin.NewUserResponse, err error) {
 	i.Lock()
 	defer i.Unlock()
 
+	if i.APIVersion == apiVersion2 {
+		return i.newV2User(ctx, req)
+	}
+
 	cli, err := i.getConnection(ctx)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
@@ -79,17 +115,10 @@ func (i *Influxdb) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (re
 		rollbackIFQL = []string{defaultUserDeletionIFQL}
 	}
 
-	username, err := credsutil.GenerateUsername(
-		credsutil.DisplayName(req.UsernameConfig.DisplayName, 15),
-		credsutil.RoleName(req.UsernameConfig.RoleName, 15),
-		credsutil.MaxLength(100),
-		credsutil.Separator("_"),
-		credsutil.ToLower(),
-	)
+	username, err := i.generateUsername(req.UsernameConfig)
 	if err != nil {
-		return dbplugin.NewUserResponse{}, fmt.Errorf("failed to generate username: %w", err)
+		return dbplugin.NewUserResponse{}, err
 	}
-	username = strings.Replace(username, "-", "_", -1)
 
 	for _, stmt := range creationIFQL {
 		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
@@ -118,6 +147,52 @@ func (i *Influxdb) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (re
 	return resp, nil
 }
 
+// newV2User creates a v1 compatibility authorization with the permissions of
+// the creation statements, whose username and password are used as its token.
+func (i *Influxdb) newV2User(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	cli, err := i.getV2Connection(ctx)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	if len(req.Statements.Commands) == 0 {
+		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
+	}
+	permissions, err := parseV2Statements(req.Statements.Commands)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	username, err := i.generateUsername(req.UsernameConfig)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	if err := cli.createAuthorization(ctx, username, req.Password, permissions); err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("failed to create authorization in InfluxDB: %w", err)
+	}
+
+	resp := dbplugin.NewUserResponse{
+		Username: username,
+	}
+	return resp, nil
+}
+
+func (i *Influxdb) generateUsername(config dbplugin.UsernameMetadata) (string, error) {
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(config.DisplayName, 15),
+		credsutil.RoleName(config.RoleName, 15),
+		credsutil.MaxLength(100),
+		credsutil.Separator("_"),
+		credsutil.ToLower(),
+		credsutil.Template(i.usernameTemplate, config),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate username: %w", err)
+	}
+	return strings.Replace(username, "-", "_", -1), nil
+}
+
 // attemptRollback will attempt to roll back user creation if an error occurs in
 // CreateUser
 func attemptRollback(cli influx.Client, username string, rollbackStatements []string) error {
@@ -147,6 +222,17 @@ func (i *Influxdb) DeleteUser(ctx context.Context, req dbplugin.DeleteUserReques
 	i.Lock()
 	defer i.Unlock()
 
+	if i.APIVersion == apiVersion2 {
+		cli, err := i.getV2Connection(ctx)
+		if err != nil {
+			return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
+		}
+		if err := cli.deleteAuthorization(ctx, req.Username); err != nil {
+			return dbplugin.DeleteUserResponse{}, fmt.Errorf("failed to delete authorization: %w", err)
+		}
+		return dbplugin.DeleteUserResponse{}, nil
+	}
+
 	cli, err := i.getConnection(ctx)
 	if err != nil {
 		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
@@ -200,6 +286,19 @@ func (i *Influxdb) UpdateUser(ctx context.Context, req dbplugin.UpdateUserReques
 }
 
 func (i *Influxdb) changeUserPassword(ctx context.Context, username string, changePassword *dbplugin.ChangePassword) error {
+	if i.APIVersion == apiVersion2 {
+		// The operator token cannot be rotated, only the passwords of the
+		// authorizations created by Vault
+		if username == "" {
+			return fmt.Errorf("root credential rotation is not supported with api_version %q", apiVersion2)
+		}
+		cli, err := i.getV2Connection(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to get connection: %w", err)
+		}
+		return cli.changePassword(ctx, username, changePassword.NewPassword)
+	}
+
 	cli, err := i.getConnection(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to get connection: %w", err)
@@ -0,0 +1,321 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+const (
+	apiVersion1 = "v1"
+	apiVersion2 = "v2"
+
+	// legacyAuthorizationsPath is the endpoint of the v1 compatibility
+	// authorizations of InfluxDB 2.x. Unlike the API tokens, which are always
+	// generated by the server, these accept a password chosen by Vault and are
+	// presented by clients as "Token <username>:<password>" or with basic auth.
+	legacyAuthorizationsPath = "/private/legacy/authorizations"
+)
+
+// influxV2Statement is the JSON document of a creation statement in v2 mode,
+// for instance:
+//
+//   { "permissions": [
+//       { "action": "read", "resource": { "type": "buckets", "name": "telegraf" } },
+//       { "action": "write", "resource": { "type": "buckets" } }
+//   ] }
+//
+// A resource without a name applies to all the resources of that type in the
+// organization.
+type influxV2Statement struct {
+	Permissions []influxV2Permission `json:"permissions"`
+}
+
+type influxV2Permission struct {
+	Action   string           `json:"action"`
+	Resource influxV2Resource `json:"resource"`
+}
+
+type influxV2Resource struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	OrgID string `json:"orgID,omitempty"`
+	Org   string `json:"org,omitempty"`
+}
+
+type influxV2Authorization struct {
+	ID          string               `json:"id,omitempty"`
+	Token       string               `json:"token"`
+	OrgID       string               `json:"orgID"`
+	Description string               `json:"description,omitempty"`
+	Permissions []influxV2Permission `json:"permissions"`
+}
+
+// parseV2Statements merges the permissions of the JSON documents of the
+// creation statements.
+func parseV2Statements(stmts []string) ([]influxV2Permission, error) {
+	var permissions []influxV2Permission
+	for _, stmt := range stmts {
+		var s influxV2Statement
+		if err := json.Unmarshal([]byte(stmt), &s); err != nil {
+			return nil, errwrap.Wrapf("invalid creation statement: {{err}}", err)
+		}
+		for _, p := range s.Permissions {
+			switch {
+			case p.Action != "read" && p.Action != "write":
+				return nil, fmt.Errorf("invalid permission action %q, must be \"read\" or \"write\"", p.Action)
+			case p.Resource.Type == "":
+				return nil, fmt.Errorf("permission resource type cannot be empty")
+			}
+		}
+		permissions = append(permissions, s.Permissions...)
+	}
+	if len(permissions) == 0 {
+		return nil, fmt.Errorf("permissions are required in creation statements")
+	}
+	return permissions, nil
+}
+
+// influxV2Client is a client of the InfluxDB 2.x HTTP API, authenticated with
+// an operator token.
+type influxV2Client struct {
+	addr   string
+	token  string
+	org    string
+	orgID  string
+	client *http.Client
+}
+
+func newInfluxV2Client(ctx context.Context, addr, token, org string, timeout time.Duration, tlsConfig *tls.Config) (*influxV2Client, error) {
+	c := &influxV2Client{
+		addr:  addr,
+		token: token,
+		org:   org,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}
+
+	// Resolving the organization also checks the server status and the token
+	orgID, err := c.lookupOrgID(ctx, org)
+	if err != nil {
+		return nil, errwrap.Wrapf("error looking up organization: {{err}}", err)
+	}
+	c.orgID = orgID
+
+	return c, nil
+}
+
+func (c *influxV2Client) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// do sends the request and decodes the response into out, if given.
+func (c *influxV2Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	u := c.addr + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Token "+c.token)
+	req.Header.Set("User-Agent", "vault-influxdb-plugin")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		buf, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(buf, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *influxV2Client) lookupOrgID(ctx context.Context, org string) (string, error) {
+	if org == c.org && c.orgID != "" {
+		return c.orgID, nil
+	}
+
+	var resp struct {
+		Orgs []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"orgs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v2/orgs", url.Values{"org": {org}}, nil, &resp); err != nil {
+		return "", err
+	}
+	for _, o := range resp.Orgs {
+		if o.Name == org {
+			return o.ID, nil
+		}
+	}
+	return "", fmt.Errorf("organization %q not found", org)
+}
+
+func (c *influxV2Client) lookupBucketID(ctx context.Context, orgID, bucket string) (string, error) {
+	var resp struct {
+		Buckets []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"buckets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v2/buckets", url.Values{"orgID": {orgID}, "name": {bucket}}, nil, &resp); err != nil {
+		return "", err
+	}
+	for _, b := range resp.Buckets {
+		if b.Name == bucket {
+			return b.ID, nil
+		}
+	}
+	return "", fmt.Errorf("bucket %q not found", bucket)
+}
+
+// resolvePermissions sets the organization IDs of the permissions, and the IDs
+// of the buckets given by name.
+func (c *influxV2Client) resolvePermissions(ctx context.Context, permissions []influxV2Permission) ([]influxV2Permission, error) {
+	resolved := make([]influxV2Permission, 0, len(permissions))
+	for _, p := range permissions {
+		r := p.Resource
+		if r.OrgID == "" {
+			org := r.Org
+			if org == "" {
+				org = c.org
+			}
+			orgID, err := c.lookupOrgID(ctx, org)
+			if err != nil {
+				return nil, err
+			}
+			r.OrgID = orgID
+		}
+		r.Org = ""
+
+		if r.Type == "buckets" && r.ID == "" && r.Name != "" {
+			id, err := c.lookupBucketID(ctx, r.OrgID, r.Name)
+			if err != nil {
+				return nil, err
+			}
+			r.ID = id
+		}
+		r.Name = ""
+
+		resolved = append(resolved, influxV2Permission{Action: p.Action, Resource: r})
+	}
+	return resolved, nil
+}
+
+// createAuthorization creates a v1 compatibility authorization with the given
+// permissions and password.
+func (c *influxV2Client) createAuthorization(ctx context.Context, username, password string, permissions []influxV2Permission) error {
+	permissions, err := c.resolvePermissions(ctx, permissions)
+	if err != nil {
+		return err
+	}
+
+	auth := influxV2Authorization{
+		Token:       username,
+		OrgID:       c.orgID,
+		Description: "Vault " + username,
+		Permissions: permissions,
+	}
+	if err := c.do(ctx, http.MethodPost, legacyAuthorizationsPath, nil, auth, &auth); err != nil {
+		return err
+	}
+
+	if err := c.setPassword(ctx, auth.ID, password); err != nil {
+		// Do not leave an authorization without a password behind
+		c.do(ctx, http.MethodDelete, legacyAuthorizationsPath+"/"+url.PathEscape(auth.ID), nil, nil, nil)
+		return err
+	}
+	return nil
+}
+
+// authorizationID returns the ID of the authorization of the user, or an empty
+// string if it does not exist.
+func (c *influxV2Client) authorizationID(ctx context.Context, username string) (string, error) {
+	var resp struct {
+		Authorizations []influxV2Authorization `json:"authorizations"`
+	}
+	if err := c.do(ctx, http.MethodGet, legacyAuthorizationsPath, url.Values{"token": {username}}, nil, &resp); err != nil {
+		return "", err
+	}
+	for _, auth := range resp.Authorizations {
+		if auth.Token == username {
+			return auth.ID, nil
+		}
+	}
+	return "", nil
+}
+
+func (c *influxV2Client) setPassword(ctx context.Context, id, password string) error {
+	body := map[string]string{
+		"password": password,
+	}
+	return c.do(ctx, http.MethodPost, legacyAuthorizationsPath+"/"+url.PathEscape(id)+"/password", nil, body, nil)
+}
+
+// deleteAuthorization deletes the authorization of the user. It is not an
+// error if the authorization no longer exists.
+func (c *influxV2Client) deleteAuthorization(ctx context.Context, username string) error {
+	id, err := c.authorizationID(ctx, username)
+	if err != nil || id == "" {
+		return err
+	}
+	return c.do(ctx, http.MethodDelete, legacyAuthorizationsPath+"/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// changePassword sets the password of the authorization of the user.
+func (c *influxV2Client) changePassword(ctx context.Context, username, password string) error {
+	id, err := c.authorizationID(ctx, username)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("authorization %q not found", username)
+	}
+	return c.setPassword(ctx, id, password)
+}
@@ -0,0 +1,253 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+// fakeInfluxV2 implements the parts of the InfluxDB 2.x API used by the
+// plugin, with a single organization and bucket.
+type fakeInfluxV2 struct {
+	sync.Mutex
+	token     string
+	auths     map[string]influxV2Authorization
+	passwords map[string]string
+	nextID    int
+}
+
+func (f *fakeInfluxV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.Header.Get("Authorization") != "Token "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"code": "unauthorized", "message": "unauthorized access"})
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/api/v2/orgs":
+		orgs := []map[string]string{}
+		if r.URL.Query().Get("org") == "vault" {
+			orgs = append(orgs, map[string]string{"id": "org1", "name": "vault"})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"orgs": orgs})
+
+	case r.URL.Path == "/api/v2/buckets":
+		buckets := []map[string]string{}
+		if r.URL.Query().Get("orgID") == "org1" && r.URL.Query().Get("name") == "telegraf" {
+			buckets = append(buckets, map[string]string{"id": "bucket1", "name": "telegraf"})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"buckets": buckets})
+
+	case r.URL.Path == legacyAuthorizationsPath && r.Method == http.MethodPost:
+		var auth influxV2Authorization
+		json.NewDecoder(r.Body).Decode(&auth)
+		f.nextID++
+		auth.ID = "auth" + strconv.Itoa(f.nextID)
+		f.auths[auth.ID] = auth
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(auth)
+
+	case r.URL.Path == legacyAuthorizationsPath && r.Method == http.MethodGet:
+		auths := []influxV2Authorization{}
+		for _, auth := range f.auths {
+			if auth.Token == r.URL.Query().Get("token") {
+				auths = append(auths, auth)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"authorizations": auths})
+
+	case strings.HasPrefix(r.URL.Path, legacyAuthorizationsPath+"/"):
+		id := strings.TrimPrefix(r.URL.Path, legacyAuthorizationsPath+"/")
+		if strings.HasSuffix(id, "/password") && r.Method == http.MethodPost {
+			id = strings.TrimSuffix(id, "/password")
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			f.passwords[id] = body["password"]
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.auths, id)
+			delete(f.passwords, id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func prepareFakeInfluxV2(t *testing.T) (*fakeInfluxV2, map[string]interface{}, func()) {
+	f := &fakeInfluxV2{
+		token:     "operator-token",
+		auths:     map[string]influxV2Authorization{},
+		passwords: map[string]string{},
+	}
+	srv := httptest.NewServer(f)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := map[string]interface{}{
+		"host":        u.Hostname(),
+		"port":        u.Port(),
+		"api_version": "v2",
+		"token":       f.token,
+		"org":         "vault",
+	}
+	return f, config, srv.Close
+}
+
+func TestInfluxdb_V2(t *testing.T) {
+	f, config, cleanup := prepareFakeInfluxV2(t)
+	defer cleanup()
+
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           config,
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	newUserReq := dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`{"permissions": [
+				{"action": "read", "resource": {"type": "buckets", "name": "telegraf"}},
+				{"action": "write", "resource": {"type": "buckets"}}
+			]}`},
+		},
+		Password:   "98yq3thgnakjsfhjkl",
+		Expiration: time.Now().Add(time.Minute),
+	}
+	resp := dbtesting.AssertNewUser(t, db, newUserReq)
+
+	if len(f.auths) != 1 {
+		t.Fatalf("expected 1 authorization, got %d", len(f.auths))
+	}
+	auth := f.auths["auth1"]
+	if auth.Token != resp.Username || auth.OrgID != "org1" {
+		t.Fatalf("bad authorization: %#v", auth)
+	}
+	expected := []influxV2Permission{
+		{Action: "read", Resource: influxV2Resource{Type: "buckets", ID: "bucket1", OrgID: "org1"}},
+		{Action: "write", Resource: influxV2Resource{Type: "buckets", OrgID: "org1"}},
+	}
+	if !reflect.DeepEqual(auth.Permissions, expected) {
+		t.Fatalf("bad permissions: %#v", auth.Permissions)
+	}
+	if f.passwords["auth1"] != newUserReq.Password {
+		t.Fatalf("bad password: %q", f.passwords["auth1"])
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: resp.Username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "y89qgmbzadiygry8uazodijnb",
+		},
+	})
+	if f.passwords["auth1"] != "y89qgmbzadiygry8uazodijnb" {
+		t.Fatalf("bad password: %q", f.passwords["auth1"])
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+	if len(f.auths) != 0 {
+		t.Fatalf("expected no authorization, got %d", len(f.auths))
+	}
+
+	// Deleting an authorization twice is not an error
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+}
+
+func TestInfluxdb_V2_errors(t *testing.T) {
+	f, config, cleanup := prepareFakeInfluxV2(t)
+	defer cleanup()
+
+	// Invalid tokens are rejected when verifying the connection
+	badConfig := makeConfig(config, "token", "bad-token")
+	db := new()
+	_, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+		Config:           badConfig,
+		VerifyConnection: true,
+	})
+	if err == nil {
+		t.Fatal("expected error with an invalid token")
+	}
+
+	for _, key := range []string{"token", "org"} {
+		badConfig := makeConfig(config, key, "")
+		_, err := new().Initialize(context.Background(), dbplugin.InitializeRequest{
+			Config: badConfig,
+		})
+		if err == nil {
+			t.Fatalf("expected error without %s", key)
+		}
+	}
+
+	db = new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           config,
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	for _, stmts := range [][]string{
+		nil,
+		{`{"permissions": []}`},
+		{`{"permissions": [{"action": "delete", "resource": {"type": "buckets"}}]}`},
+		{`{"permissions": [{"action": "read", "resource": {"type": "buckets", "name": "missing"}}]}`},
+		{`CREATE USER "{{username}}" WITH PASSWORD '{{password}}';`},
+	} {
+		_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "test",
+				RoleName:    "test",
+			},
+			Statements: dbplugin.Statements{
+				Commands: stmts,
+			},
+			Password:   "98yq3thgnakjsfhjkl",
+			Expiration: time.Now().Add(time.Minute),
+		})
+		if err == nil {
+			t.Fatalf("expected error with statements %v", stmts)
+		}
+	}
+	if len(f.auths) != 0 {
+		t.Fatalf("expected no authorization, got %d", len(f.auths))
+	}
+
+	// The operator token cannot be rotated
+	_, err = db.UpdateUser(context.Background(), dbplugin.UpdateUserRequest{
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "y89qgmbzadiygry8uazodijnb",
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error rotating the root credential")
+	}
+}
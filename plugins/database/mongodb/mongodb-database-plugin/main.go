@@ -16,14 +16,10 @@ func main() {
 	}
 }
 
-// Run instantiates a MongoDB object, and runs the RPC server for the plugin
+// Run starts the RPC server for the plugin, which creates a new MongoDB object
+// for every database connection it serves
 func Run() error {
-	dbType, err := mongodb.New()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database))
+	dbplugin.ServeMultiplex(mongodb.New)
 
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -23,6 +24,8 @@ const mongoDBTypeName = "mongodb"
 // MongoDB is an implementation of Database interface
 type MongoDB struct {
 	*mongoDBConnectionProducer
+
+	usernameTemplate *template.StringTemplate
 }
 
 var _ dbplugin.Database = &MongoDB{}
@@ -69,6 +72,11 @@ func (m *MongoDB) Initialize(ctx context.Context, req dbplugin.InitializeRequest
 		return dbplugin.InitializeResponse{}, err
 	}
 
+	m.usernameTemplate, err = credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
 	if len(m.ConnectionURL) == 0 {
 		return dbplugin.InitializeResponse{}, fmt.Errorf("connection_url cannot be empty-mongo fail")
 	}
@@ -121,6 +129,7 @@ func (m *MongoDB) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbp
 		credsutil.RoleName(req.UsernameConfig.RoleName, 15),
 		credsutil.MaxLength(100),
 		credsutil.Separator("-"),
+		credsutil.Template(m.usernameTemplate, req.UsernameConfig),
 	)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, err
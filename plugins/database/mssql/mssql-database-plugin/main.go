@@ -16,14 +16,10 @@ func main() {
 	}
 }
 
-// Run instantiates a MSSQL object, and runs the RPC server for the plugin
+// Run starts the RPC server for the plugin, which creates a new MSSQL object
+// for every database connection it serves
 func Run() error {
-	dbType, err := mssql.New()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database))
+	dbplugin.ServeMultiplex(mssql.New)
 
 	return nil
 }
@@ -3,8 +3,10 @@ package mssql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	_ "github.com/denisenkom/go-mssqldb"
@@ -13,8 +15,10 @@ import (
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 )
 
@@ -25,6 +29,12 @@ var _ dbplugin.Database = &MSSQL{}
 // MSSQL is an implementation of Database interface
 type MSSQL struct {
 	*connutil.SQLConnectionProducer
+
+	usernameTemplate *template.StringTemplate
+
+	// verifyPasswordChange makes password changes open a connection as the
+	// user with the new password before they are reported as successful
+	verifyPasswordChange bool
 }
 
 func New() (interface{}, error) {
@@ -65,10 +75,36 @@ func (m *MSSQL) getConnection(ctx context.Context) (*sql.DB, error) {
 }
 
 func (m *MSSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	var verifyPasswordChange bool
+	if raw, ok := req.Config["verify_password_change"]; ok {
+		verifyPasswordChange, err = parseutil.ParseBool(raw)
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("invalid verify_password_change: %w", err)
+		}
+	}
+
 	newConf, err := m.SQLConnectionProducer.Init(ctx, req.Config, req.VerifyConnection)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, err
 	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if verifyPasswordChange {
+		// Make sure connections can be opened as other users
+		if _, err := m.userConnectionURL("username", "password"); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("verify_password_change: %w", err)
+		}
+	}
+	m.usernameTemplate = usernameTemplate
+	m.verifyPasswordChange = verifyPasswordChange
+
 	resp := dbplugin.InitializeResponse{
 		Config: newConf,
 	}
@@ -95,6 +131,7 @@ func (m *MSSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplu
 		credsutil.RoleName(req.UsernameConfig.RoleName, 20),
 		credsutil.MaxLength(128),
 		credsutil.Separator("-"),
+		credsutil.Template(m.usernameTemplate, req.UsernameConfig),
 	)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, err
@@ -102,40 +139,167 @@ func (m *MSSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplu
 
 	expirationStr := req.Expiration.Format("2006-01-02 15:04:05-0700")
 
+	params := map[string]string{
+		"name":       username,
+		"password":   req.Password,
+		"expiration": expirationStr,
+	}
+
+	statements := parseStatementPhases(req.Statements.Commands)
+	rollback := parseStatementPhases(req.RollbackStatements.Commands)
+
+	// Server-level statements are committed before the database ones, as
+	// both cannot be mixed in a single transaction. The rollback statements
+	// of the phases that ran undo them if a later phase fails.
+	if len(statements.server) > 0 {
+		err := inMaster(ctx, db, func(conn *sql.Conn) error {
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			if err := executeTxQueries(ctx, tx, params, statements.server); err != nil {
+				return err
+			}
+			return tx.Commit()
+		})
+		if err != nil {
+			return dbplugin.NewUserResponse{}, rollbackNewUser(ctx, db, params, err, rollback.server, nil)
+		}
+	}
+
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return dbplugin.NewUserResponse{}, err
+		return dbplugin.NewUserResponse{}, rollbackNewUser(ctx, db, params, err, rollback.server, nil)
 	}
 	defer tx.Rollback()
 
-	for _, stmt := range req.Statements.Commands {
+	if err := executeTxQueries(ctx, tx, params, statements.database); err != nil {
+		tx.Rollback()
+		return dbplugin.NewUserResponse{}, rollbackNewUser(ctx, db, params, err, rollback.server, rollback.database)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbplugin.NewUserResponse{}, rollbackNewUser(ctx, db, params, err, rollback.server, rollback.database)
+	}
+
+	resp := dbplugin.NewUserResponse{
+		Username: username,
+	}
+
+	return resp, nil
+}
+
+// Phase markers start the creation or rollback statements that run at the
+// server level, in the context of the master database, or at the database
+// level. Statements before any marker run at the database level.
+const (
+	serverPhaseMarker   = "-- vault:server"
+	databasePhaseMarker = "-- vault:database"
+)
+
+// statementPhases holds the queries of each phase of user creation.
+type statementPhases struct {
+	server   []string
+	database []string
+}
+
+// parseStatementPhases splits the statements into queries and assigns them
+// to the phase of the last marker preceding them.
+func parseStatementPhases(stmts []string) statementPhases {
+	var phases statementPhases
+	current := &phases.database
+	for _, stmt := range stmts {
 		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
 			query = strings.TrimSpace(query)
+			switch {
+			case strings.HasPrefix(query, serverPhaseMarker):
+				current = &phases.server
+				query = strings.TrimSpace(strings.TrimPrefix(query, serverPhaseMarker))
+			case strings.HasPrefix(query, databasePhaseMarker):
+				current = &phases.database
+				query = strings.TrimSpace(strings.TrimPrefix(query, databasePhaseMarker))
+			}
 			if len(query) == 0 {
 				continue
 			}
+			*current = append(*current, query)
+		}
+	}
+	return phases
+}
 
-			m := map[string]string{
-				"name":       username,
-				"password":   req.Password,
-				"expiration": expirationStr,
-			}
-
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
-				return dbplugin.NewUserResponse{}, err
-			}
+func executeTxQueries(ctx context.Context, tx *sql.Tx, params map[string]string, queries []string) error {
+	for _, query := range queries {
+		if err := dbtxn.ExecuteTxQuery(ctx, tx, params, query); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return dbplugin.NewUserResponse{}, err
+// inMaster runs fn with a connection in the context of the master database.
+// The connection is switched back to its database before it is returned to
+// the pool, or discarded if that fails.
+func inMaster(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
 	}
+	defer conn.Close()
 
-	resp := dbplugin.NewUserResponse{
-		Username: username,
+	var dbName string
+	if err := conn.QueryRowContext(ctx, "SELECT DB_NAME()").Scan(&dbName); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "USE [master]"); err != nil {
+		return err
 	}
 
-	return resp, nil
+	fnErr := fn(conn)
+
+	restore := fmt.Sprintf("USE [%s]", strings.Replace(dbName, "]", "]]", -1))
+	if _, err := conn.ExecContext(ctx, restore); err != nil {
+		conn.Raw(func(interface{}) error {
+			return driver.ErrBadConn
+		})
+	}
+
+	return fnErr
+}
+
+// rollbackNewUser runs the rollback statements of the database phase, then
+// those of the server phase, after user creation failed with err. As much as
+// possible is rolled back, and the errors are returned along with err.
+func rollbackNewUser(ctx context.Context, db *sql.DB, params map[string]string, err error, server, database []string) error {
+	if len(server) == 0 && len(database) == 0 {
+		return err
+	}
+
+	merr := multierror.Append(&multierror.Error{}, err)
+	for _, query := range database {
+		if err := dbtxn.ExecuteDBQuery(ctx, db, params, query); err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("failed to roll back: %w", err))
+		}
+	}
+	if len(server) > 0 {
+		err := inMaster(ctx, db, func(conn *sql.Conn) error {
+			for _, query := range server {
+				if _, err := conn.ExecContext(ctx, dbutil.QueryHelper(query, params)); err != nil {
+					merr = multierror.Append(merr, fmt.Errorf("failed to roll back: %w", err))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("failed to roll back: %w", err))
+		}
+	}
+	if len(merr.Errors) == 1 {
+		return err
+	}
+	return merr
 }
 
 // DeleteUser attempts to drop the specified user. It will first attempt to disable login,
@@ -310,11 +474,12 @@ func (m *MSSQL) updateUserPass(ctx context.Context, username string, changePass
 	}
 
 	var exists bool
-
-	err = db.QueryRowContext(ctx, "SELECT 1 FROM master.sys.server_principals where name = N'$1'", username).Scan(&exists)
-
-	if err != nil && err != sql.ErrNoRows {
-		return err
+	err = db.QueryRowContext(ctx, "SELECT 1 FROM master.sys.server_principals WHERE name = @p1", username).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("cannot update password for username %q because it does not exist", username)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to check if user %q exists: %w", username, err)
 	}
 
 	tx, err := db.BeginTx(ctx, nil)
@@ -348,9 +513,53 @@ func (m *MSSQL) updateUserPass(ctx context.Context, username string, changePass
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	if m.verifyPasswordChange {
+		if err := m.verifyCredentials(ctx, username, password); err != nil {
+			return fmt.Errorf("password changed, but unable to connect as %q with the new password: %w", username, err)
+		}
+	}
+
 	return nil
 }
 
+// verifyCredentials opens a connection as the user to check that the
+// credentials are accepted by the server
+func (m *MSSQL) verifyCredentials(ctx context.Context, username, password string) error {
+	connURL, err := m.userConnectionURL(username, password)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlserver", connURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.PingContext(ctx)
+}
+
+// userConnectionURL returns the connection URL of the configuration with the
+// credentials of the given user. The connection URL must either be templated
+// with {{username}} and {{password}}, or be a sqlserver:// URL.
+func (m *MSSQL) userConnectionURL(username, password string) (string, error) {
+	rawURL, _ := m.RawConfig["connection_url"].(string)
+
+	if strings.Contains(rawURL, "{{username}}") && strings.Contains(rawURL, "{{password}}") {
+		return dbutil.QueryHelper(rawURL, map[string]string{
+			"username": url.PathEscape(username),
+			"password": url.PathEscape(password),
+		}), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "sqlserver" {
+		return "", errors.New("connection_url must be templated with {{username}} and {{password}}, or be a sqlserver:// URL")
+	}
+	u.User = url.UserPassword(username, password)
+	return u.String(), nil
+}
+
 const dropUserSQL = `
 USE [%s]
 IF EXISTS
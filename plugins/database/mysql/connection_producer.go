@@ -54,6 +54,11 @@ func (c *mySQLConnectionProducer) Init(ctx context.Context, conf map[string]inte
 
 	c.RawConfig = conf
 
+	// Decoding reuses existing slices, so reset the TLS data to avoid mixing
+	// it with the data of a previous configuration
+	c.TLSCertificateKeyData = nil
+	c.TLSCAData = nil
+
 	err := mapstructure.WeakDecode(conf, &c)
 	if err != nil {
 		return nil, err
@@ -106,6 +111,11 @@ func (c *mySQLConnectionProducer) Init(ctx context.Context, conf map[string]inte
 		}
 
 		mysql.RegisterTLSConfig(c.tlsConfigName, tlsConfig)
+	} else if c.tlsConfigName != "" {
+		// The TLS parameters were removed from the configuration, so stop
+		// referencing the previously registered TLS config
+		mysql.DeregisterTLSConfig(c.tlsConfigName)
+		c.tlsConfigName = ""
 	}
 
 	// Set initialized to true at this point since all fields are set,
@@ -199,7 +209,7 @@ func (c *mySQLConnectionProducer) getTLSAuth() (tlsConfig *tls.Config, err error
 	if len(c.TLSCertificateKeyData) > 0 {
 		certificate, err := tls.X509KeyPair(c.TLSCertificateKeyData, c.TLSCertificateKeyData)
 		if err != nil {
-			return nil, fmt.Errorf("unable to load tls_certificate_key_data: %w", err)
+			return nil, fmt.Errorf("unable to load tls_certificate_key: %w", err)
 		}
 
 		clientCert = append(clientCert, certificate)
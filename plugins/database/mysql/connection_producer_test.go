@@ -70,6 +70,42 @@ func Test_addTLStoDSN(t *testing.T) {
 	}
 }
 
+func TestInit_removeClientTLS(t *testing.T) {
+	ca := certhelpers.NewCert(t,
+		certhelpers.CommonName("test certificate authority"),
+		certhelpers.IsCA(true),
+		certhelpers.SelfSign(),
+	)
+
+	c := &mySQLConnectionProducer{}
+	conf := map[string]interface{}{
+		"connection_url": "user:password@tcp(localhost:3306)/test",
+		"tls_ca":         ca.CombinedPEM(),
+	}
+	if _, err := c.Init(context.Background(), conf, false); err != nil {
+		t.Fatalf("unable to initialize: %s", err)
+	}
+	if c.tlsConfigName == "" {
+		t.Fatalf("expected a TLS config to be registered")
+	}
+
+	conf["tls_ca"] = ""
+	if _, err := c.Init(context.Background(), conf, false); err != nil {
+		t.Fatalf("unable to initialize: %s", err)
+	}
+	if c.tlsConfigName != "" {
+		t.Fatalf("expected the TLS config to be removed, got %q", c.tlsConfigName)
+	}
+
+	connURL, err := c.addTLStoDSN()
+	if err != nil {
+		t.Fatalf("error occurred in test: %s", err)
+	}
+	if expected := "user:password@tcp(localhost:3306)/test"; connURL != expected {
+		t.Fatalf("generated: %s, expected: %s", connURL, expected)
+	}
+}
+
 func TestInit_clientTLS(t *testing.T) {
 	t.Skip("Skipping this test because CircleCI can't mount the files we need without further investigation: " +
 		"https://support.circleci.com/hc/en-us/articles/360007324514-How-can-I-mount-volumes-to-docker-containers-")
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 )
@@ -40,9 +41,10 @@ var _ dbplugin.Database = (*MySQL)(nil)
 type MySQL struct {
 	*mySQLConnectionProducer
 
-	displayNameLen int
-	roleNameLen    int
-	maxUsernameLen int
+	displayNameLen   int
+	roleNameLen      int
+	maxUsernameLen   int
+	usernameTemplate *template.StringTemplate
 }
 
 // New implements builtinplugins.BuiltinFactory
@@ -81,10 +83,20 @@ func (m *MySQL) getConnection(ctx context.Context) (*sql.DB, error) {
 }
 
 func (m *MySQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
-	err := m.mySQLConnectionProducer.Initialize(ctx, req.Config, req.VerifyConnection)
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, err
 	}
+
+	err = m.mySQLConnectionProducer.Initialize(ctx, req.Config, req.VerifyConnection)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	m.Lock()
+	m.usernameTemplate = usernameTemplate
+	m.Unlock()
+
 	resp := dbplugin.InitializeResponse{
 		Config: req.Config,
 	}
@@ -127,6 +139,7 @@ func (m *MySQL) generateUsername(req dbplugin.NewUserRequest) (string, error) {
 		credsutil.DisplayName(req.UsernameConfig.DisplayName, m.displayNameLen),
 		credsutil.RoleName(req.UsernameConfig.RoleName, m.roleNameLen),
 		credsutil.MaxLength(m.maxUsernameLen),
+		credsutil.Template(m.usernameTemplate, req.UsernameConfig),
 	)
 	if err != nil {
 		return "", errwrap.Wrapf("error generating username: {{err}}", err)
@@ -0,0 +1,8 @@
+//go:build oracle
+// +build oracle
+
+package main
+
+// The godror driver requires cgo and the Oracle Instant Client, so it is only
+// linked when building with the oracle build tag
+import _ "github.com/godror/godror"
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/hashicorp/vault/plugins/database/oracle"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+func main() {
+	err := Run()
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
+
+// Run starts the RPC server for the plugin, which creates a new Oracle object
+// for every database connection it serves
+func Run() error {
+	dbplugin.ServeMultiplex(oracle.New)
+
+	return nil
+}
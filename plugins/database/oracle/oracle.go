@@ -0,0 +1,427 @@
+package oracle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/dbtxn"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	oracleTypeName = "oracle"
+
+	// driverName is the name under which the godror driver, built on the
+	// Oracle Instant Client, registers with database/sql
+	driverName = "godror"
+
+	// Oracle identifiers are limited to 30 bytes before 12.2
+	maxUsernameLength = 30
+
+	defaultRevocationStatement     = `DROP USER {{username}} CASCADE`
+	defaultChangePasswordStatement = `ALTER USER {{username}} IDENTIFIED BY "{{password}}"`
+)
+
+var (
+	// validUsername matches the generated usernames, which are used unquoted
+	// in statements and are therefore uppercased by Oracle
+	validUsername = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+	// validIdentifier matches the nonquoted identifiers of the proxy user and
+	// of its roles
+	validIdentifier = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+)
+
+// Oracle is an implementation of Database interface for Oracle Database. It
+// connects over TCPS with a wallet when one is configured, and can let the
+// users it creates connect through a proxy user.
+type Oracle struct {
+	*connutil.SQLConnectionProducer
+
+	wallet           *wallet
+	proxy            *proxyConfig
+	usernameTemplate *template.StringTemplate
+}
+
+var _ dbplugin.Database = (*Oracle)(nil)
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := new()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func new() *Oracle {
+	connProducer := &connutil.SQLConnectionProducer{}
+	connProducer.Type = driverName
+
+	return &Oracle{
+		SQLConnectionProducer: connProducer,
+	}
+}
+
+func (o *Oracle) secretValues() map[string]string {
+	o.Lock()
+	defer o.Unlock()
+
+	return map[string]string{
+		o.Password: "[password]",
+	}
+}
+
+// proxyConfig is the proxy user through which the users created by the plugin
+// are allowed to connect
+type proxyConfig struct {
+	User  string   `mapstructure:"proxy_user"`
+	Roles []string `mapstructure:"proxy_roles"`
+}
+
+func (p *proxyConfig) validate() error {
+	if p.User == "" {
+		if len(p.Roles) > 0 {
+			return errors.New("proxy_roles requires proxy_user")
+		}
+		return nil
+	}
+	if !validIdentifier.MatchString(p.User) {
+		return fmt.Errorf("invalid proxy_user %q", p.User)
+	}
+	for _, role := range p.Roles {
+		if !validIdentifier.MatchString(role) {
+			return fmt.Errorf("invalid proxy role %q", role)
+		}
+	}
+	return nil
+}
+
+// grantStatement returns the statement allowing the user to connect through
+// the proxy user, with the proxy roles if any
+func (p *proxyConfig) grantStatement(username string) string {
+	stmt := fmt.Sprintf("ALTER USER %s GRANT CONNECT THROUGH %s", username, strings.ToUpper(p.User))
+	if len(p.Roles) > 0 {
+		roles := make([]string, 0, len(p.Roles))
+		for _, role := range p.Roles {
+			roles = append(roles, strings.ToUpper(role))
+		}
+		stmt += " WITH ROLE " + strings.Join(roles, ", ")
+	}
+	return stmt
+}
+
+// Initialize configures the connection of the plugin, writing the wallet used
+// for TCPS connections to a private directory if it is given inline
+func (o *Oracle) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	if !driverRegistered(driverName) {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("the %s driver is not available, the plugin must be built with the oracle build tag and the Oracle Instant Client", driverName)
+	}
+
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	proxy := &proxyConfig{}
+	if err := mapstructure.WeakDecode(req.Config, proxy); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+	if err := proxy.validate(); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	walletConfig := &walletConfig{}
+	if err := mapstructure.WeakDecode(req.Config, walletConfig); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	// The PEM material is written to the wallet instead of configuring the
+	// TLS connections of the producer, which the driver does not support
+	conf := make(map[string]interface{}, len(req.Config))
+	for k, v := range req.Config {
+		switch k {
+		case "tls_ca", "tls_certificate_key":
+		default:
+			conf[k] = v
+		}
+	}
+
+	connURL, _ := conf["connection_url"].(string)
+	if connURL == "" {
+		return dbplugin.InitializeResponse{}, errors.New("connection_url cannot be empty")
+	}
+
+	w, err := newWallet(walletConfig)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+	if w != nil {
+		connURL, err = w.connectionURL(connURL)
+		if err != nil {
+			w.remove()
+			return dbplugin.InitializeResponse{}, err
+		}
+	}
+
+	// The connection URL is an Oracle connect string, such as
+	// {{username}}/{{password}}@tcps://db.example.com:2484/ORCLPDB1, whose
+	// credentials must not be URL escaped like the producer does
+	username, _ := conf["username"].(string)
+	password, _ := conf["password"].(string)
+	conf["connection_url"] = dbutil.QueryHelper(connURL, map[string]string{
+		"username": username,
+		"password": password,
+	})
+
+	// Connections opened with the previous wallet are closed before it is
+	// removed, the next connection uses the new configuration
+	if err := o.Close(); err != nil {
+		if w != nil {
+			w.remove()
+		}
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	if _, err := o.Init(ctx, conf, req.VerifyConnection); err != nil {
+		if w != nil {
+			w.remove()
+		}
+		return dbplugin.InitializeResponse{}, fmt.Errorf("error initializing db: %w", err)
+	}
+
+	o.Lock()
+	o.RawConfig = req.Config
+	o.wallet = w
+	o.proxy = proxy
+	o.usernameTemplate = usernameTemplate
+	o.Unlock()
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+func driverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Type returns the TypeName for this backend
+func (o *Oracle) Type() (string, error) {
+	return oracleTypeName, nil
+}
+
+// Close closes the connection and removes the wallet written by the plugin
+func (o *Oracle) Close() error {
+	err := o.SQLConnectionProducer.Close()
+
+	o.Lock()
+	defer o.Unlock()
+	if o.wallet != nil {
+		o.wallet.remove()
+		o.wallet = nil
+	}
+	return err
+}
+
+func (o *Oracle) getConnection(ctx context.Context) (*sql.DB, error) {
+	db, err := o.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*sql.DB), nil
+}
+
+// NewUser creates the user with the creation statements, then allows it to
+// connect through the proxy user if one is configured
+func (o *Oracle) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	o.Lock()
+	defer o.Unlock()
+
+	if len(req.Statements.Commands) == 0 {
+		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
+	}
+
+	db, err := o.getConnection(ctx)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 8),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 8),
+		credsutil.MaxLength(maxUsernameLength),
+		credsutil.Separator("_"),
+		credsutil.ToUpper(),
+		credsutil.Template(o.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	// Hyphens and dots would need the username to be quoted in every statement
+	username = strings.NewReplacer("-", "_", ".", "_").Replace(username)
+	if !validUsername.MatchString(username) {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("invalid username %q, must start with a letter and only contain uppercase letters, digits and underscores", username)
+	}
+
+	statements := req.Statements.Commands
+	if o.proxy != nil && o.proxy.User != "" {
+		statements = append(statements[:len(statements):len(statements)], o.proxy.grantStatement(username))
+	}
+
+	m := map[string]string{
+		"name":     username,
+		"username": username,
+		"password": req.Password,
+	}
+	if err := executeStatements(ctx, db, statements, m); err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password of the user. Oracle users do not expire
+// with their leases, so changing the expiration is a no-op.
+func (o *Oracle) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Username == "" || req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("must provide both username and password")
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	db, err := o.getConnection(ctx)
+	if err != nil {
+		return dbplugin.UpdateUserResponse{}, err
+	}
+
+	statements := req.Password.Statements.Commands
+	if len(statements) == 0 {
+		statements = []string{defaultChangePasswordStatement}
+	}
+
+	m := map[string]string{
+		"name":     req.Username,
+		"username": req.Username,
+		"password": req.Password.NewPassword,
+	}
+	if err := executeStatements(ctx, db, statements, m); err != nil {
+		return dbplugin.UpdateUserResponse{}, err
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser kills the sessions of the user, which would prevent it from being
+// dropped, then runs the revocation statements
+func (o *Oracle) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	db, err := o.getConnection(ctx)
+	if err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	if err := killSessions(ctx, db, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	statements := req.Statements.Commands
+	if len(statements) == 0 {
+		statements = []string{defaultRevocationStatement}
+	}
+
+	m := map[string]string{
+		"name":     req.Username,
+		"username": req.Username,
+	}
+	if err := executeStatements(ctx, db, statements, m); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// killSessions kills the sessions of the user, which requires the ALTER SYSTEM
+// privilege and read access to V$SESSION
+func killSessions(ctx context.Context, db *sql.DB, username string) error {
+	rows, err := db.QueryContext(ctx, "SELECT sid, serial# FROM v$session WHERE username = UPPER(:1)", username)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []string
+	for rows.Next() {
+		var sid, serial int64
+		if err := rows.Scan(&sid, &serial); err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sessions = append(sessions, fmt.Sprintf("%d,%d", sid, serial))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER SYSTEM KILL SESSION '%s' IMMEDIATE", session)); err != nil {
+			return fmt.Errorf("failed to kill session %s: %w", session, err)
+		}
+	}
+
+	return nil
+}
+
+// executeStatements runs the statements in a transaction. Oracle commits DDL
+// statements implicitly, so statements which already ran are not rolled back
+// when a later one fails.
+func executeStatements(ctx context.Context, db *sql.DB, statements []string, m map[string]string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
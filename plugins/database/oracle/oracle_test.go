@@ -0,0 +1,415 @@
+package oracle
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+// fakeDriver stands in for godror, which needs the Oracle Instant Client, and
+// records the connect strings and the statements of committed transactions
+type fakeDriver struct {
+	sync.Mutex
+	dsns      []string
+	committed []string
+	executed  []string
+	sessions  map[string][][2]int64
+}
+
+var testDriver = &fakeDriver{}
+
+func init() {
+	sql.Register(driverName, testDriver)
+}
+
+func (d *fakeDriver) reset() {
+	d.Lock()
+	defer d.Unlock()
+	d.dsns = nil
+	d.committed = nil
+	d.executed = nil
+	d.sessions = nil
+}
+
+func (d *fakeDriver) statements() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.committed...)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.Lock()
+	defer d.Unlock()
+	d.dsns = append(d.dsns, dsn)
+	return &fakeConn{driver: d}, nil
+}
+
+type fakeConn struct {
+	driver  *fakeDriver
+	inTx    bool
+	pending []string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	c.pending = nil
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.driver.Lock()
+	defer c.driver.Unlock()
+	c.driver.committed = append(c.driver.committed, c.pending...)
+	c.inTx = false
+	c.pending = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inTx = false
+	c.pending = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !s.conn.inTx {
+		s.conn.driver.Lock()
+		s.conn.driver.executed = append(s.conn.driver.executed, s.query)
+		s.conn.driver.Unlock()
+		return driver.RowsAffected(0), nil
+	}
+	s.conn.pending = append(s.conn.pending, s.query)
+	return driver.RowsAffected(0), nil
+}
+
+// Query lists the sessions of a user
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.driver.Lock()
+	defer s.conn.driver.Unlock()
+	return &fakeRows{sessions: s.conn.driver.sessions[strings.ToUpper(args[0].(string))]}, nil
+}
+
+type fakeRows struct {
+	sessions [][2]int64
+}
+
+func (r *fakeRows) Columns() []string { return []string{"SID", "SERIAL#"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.sessions) == 0 {
+		return io.EOF
+	}
+	dest[0], dest[1] = r.sessions[0][0], r.sessions[0][1]
+	r.sessions = r.sessions[1:]
+	return nil
+}
+
+// newTestPEM returns a self-signed certificate and its private key
+func newTestPEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vault"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return string(cert), string(keyPEM) + string(cert)
+}
+
+var walletLocationRegex = regexp.MustCompile(`wallet_location="([^"]+)"`)
+
+func TestOracle_Initialize(t *testing.T) {
+	ca, certKey := newTestPEM(t)
+
+	walletDir, err := ioutil.TempDir("", "oracle-wallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(walletDir)
+
+	tests := map[string]struct {
+		config     map[string]interface{}
+		dsn        string
+		walletFile string
+		valid      bool
+	}{
+		"plaintext": {
+			config: map[string]interface{}{
+				"connection_url": "{{username}}/{{password}}@localhost:1521/ORCLPDB1",
+				"username":       "vault",
+				"password":       "p@ss/word",
+			},
+			dsn:   "vault/p@ss/word@localhost:1521/ORCLPDB1",
+			valid: true,
+		},
+		"wallet location": {
+			config: map[string]interface{}{
+				"connection_url":     "{{username}}/{{password}}@tcps://db.example.com:2484/ORCLPDB1",
+				"username":           "vault",
+				"password":           "secret",
+				"wallet_location":    walletDir,
+				"tls_server_cert_dn": "CN=db.example.com,O=Example",
+			},
+			dsn:   `vault/secret@tcps://db.example.com:2484/ORCLPDB1?wallet_location="` + walletDir + `"&ssl_server_cert_dn="CN=db.example.com,O=Example"&ssl_server_dn_match=on`,
+			valid: true,
+		},
+		"inline wallet": {
+			config: map[string]interface{}{
+				"connection_url": "{{username}}/{{password}}@tcps://db.example.com:2484/ORCLPDB1?connect_timeout=10",
+				"username":       "vault",
+				"password":       "secret",
+				"wallet":         base64.StdEncoding.EncodeToString([]byte("sso")),
+			},
+			walletFile: "cwallet.sso",
+			valid:      true,
+		},
+		"pem": {
+			config: map[string]interface{}{
+				"connection_url":              "{{username}}/{{password}}@tcps://db.example.com:2484/ORCLPDB1",
+				"username":                    "vault",
+				"password":                    "secret",
+				"tls_ca":                      ca,
+				"tls_certificate_key":         certKey,
+				"tls_disable_server_dn_match": true,
+			},
+			walletFile: "ewallet.pem",
+			valid:      true,
+		},
+		"wallet without tcps": {
+			config: map[string]interface{}{
+				"connection_url":  "{{username}}/{{password}}@db.example.com:1521/ORCLPDB1",
+				"wallet_location": walletDir,
+			},
+		},
+		"several wallets": {
+			config: map[string]interface{}{
+				"connection_url":  "tcps://db.example.com:2484/ORCLPDB1",
+				"wallet_location": walletDir,
+				"tls_ca":          ca,
+			},
+		},
+		"missing wallet location": {
+			config: map[string]interface{}{
+				"connection_url":  "tcps://db.example.com:2484/ORCLPDB1",
+				"wallet_location": filepath.Join(walletDir, "missing"),
+			},
+		},
+		"invalid pem": {
+			config: map[string]interface{}{
+				"connection_url": "tcps://db.example.com:2484/ORCLPDB1",
+				"tls_ca":         "not a certificate",
+			},
+		},
+		"server dn without wallet": {
+			config: map[string]interface{}{
+				"connection_url":     "tcps://db.example.com:2484/ORCLPDB1",
+				"tls_server_cert_dn": "CN=db.example.com",
+			},
+		},
+		"invalid proxy user": {
+			config: map[string]interface{}{
+				"connection_url": "localhost:1521/ORCLPDB1",
+				"proxy_user":     "app; DROP USER system",
+			},
+		},
+		"proxy roles without proxy user": {
+			config: map[string]interface{}{
+				"connection_url": "localhost:1521/ORCLPDB1",
+				"proxy_roles":    []string{"app_role"},
+			},
+		},
+		"missing connection_url": {
+			config: map[string]interface{}{
+				"username": "vault",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			testDriver.reset()
+			db := new()
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config:           tc.config,
+				VerifyConnection: true,
+			})
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+			if len(testDriver.dsns) == 0 {
+				t.Fatal("no connection was opened")
+			}
+			dsn := testDriver.dsns[0]
+			if tc.dsn != "" && dsn != tc.dsn {
+				t.Fatalf("Actual DSN: %q\nExpected DSN: %q", dsn, tc.dsn)
+			}
+
+			if tc.walletFile != "" {
+				match := walletLocationRegex.FindStringSubmatch(dsn)
+				if match == nil {
+					t.Fatalf("wallet_location is missing from the DSN: %q", dsn)
+				}
+				info, err := os.Stat(match[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0700 {
+					t.Fatalf("wallet directory is not private: %s", info.Mode())
+				}
+				if _, err := os.Stat(filepath.Join(match[1], tc.walletFile)); err != nil {
+					t.Fatal(err)
+				}
+
+				// The wallet is removed with the connection
+				dbtesting.AssertClose(t, db)
+				if _, err := os.Stat(match[1]); !os.IsNotExist(err) {
+					t.Fatalf("wallet directory was not removed: %v", err)
+				}
+				return
+			}
+			dbtesting.AssertClose(t, db)
+		})
+	}
+
+	// The wallet directory configured by the operator is left in place
+	if _, err := os.Stat(walletDir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOracle_Users(t *testing.T) {
+	testDriver.reset()
+
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"connection_url": "{{username}}/{{password}}@localhost:1521/ORCLPDB1",
+			"username":       "vault",
+			"password":       "secret",
+			"proxy_user":     "app",
+			"proxy_roles":    []string{"app_reader", "app_writer"},
+		},
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "my-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`CREATE USER {{name}} IDENTIFIED BY "{{password}}"; GRANT CREATE SESSION TO {{name}}`},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	username := resp.Username
+	if !strings.HasPrefix(username, "V_TOKEN_MY_ROLE_") || len(username) > maxUsernameLength {
+		t.Fatalf("bad username: %s", username)
+	}
+	expected := []string{
+		`CREATE USER ` + username + ` IDENTIFIED BY "Passw0rd"`,
+		"GRANT CREATE SESSION TO " + username,
+		"ALTER USER " + username + " GRANT CONNECT THROUGH APP WITH ROLE APP_READER, APP_WRITER",
+	}
+	if statements := testDriver.statements(); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("Actual statements: %#v\nExpected statements: %#v", statements, expected)
+	}
+
+	// Creation statements are required
+	_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "my-role",
+		},
+		Password: "Passw0rd",
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if statements := testDriver.statements(); statements[len(statements)-1] != `ALTER USER `+username+` IDENTIFIED BY "n3wPassw0rd"` {
+		t.Fatalf("password was not changed: %#v", statements)
+	}
+
+	// The sessions of the user are killed before it is dropped
+	testDriver.Lock()
+	testDriver.sessions = map[string][][2]int64{
+		username: {{12, 3456}},
+	}
+	testDriver.Unlock()
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+	if executed := testDriver.executed; !reflect.DeepEqual(executed, []string{"ALTER SYSTEM KILL SESSION '12,3456' IMMEDIATE"}) {
+		t.Fatalf("sessions were not killed: %#v", executed)
+	}
+	if statements := testDriver.statements(); statements[len(statements)-1] != "DROP USER "+username+" CASCADE" {
+		t.Fatalf("user was not dropped: %#v", statements)
+	}
+}
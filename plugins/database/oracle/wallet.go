@@ -0,0 +1,176 @@
+package oracle
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walletConfig is the wallet used for TCPS connections. The wallet is either a
+// directory on the plugin host, an auto-login wallet (cwallet.sso) given inline,
+// or PEM-encoded certificates and key which are written to a PEM wallet
+// (ewallet.pem), supported by the Oracle Instant Client 19c and later.
+type walletConfig struct {
+	Location          string `mapstructure:"wallet_location"`
+	Wallet            string `mapstructure:"wallet"`
+	TLSCA             string `mapstructure:"tls_ca"`
+	TLSCertificateKey string `mapstructure:"tls_certificate_key"`
+	TLSServerCertDN   string `mapstructure:"tls_server_cert_dn"`
+	DisableTLSDNMatch bool   `mapstructure:"tls_disable_server_dn_match"`
+}
+
+// wallet is the wallet directory used by the connections of the plugin
+type wallet struct {
+	dir            string
+	temporary      bool
+	serverCertDN   string
+	disableDNMatch bool
+}
+
+// newWallet returns the wallet of the config, writing it to a private
+// directory if it is given inline. It returns nil if no wallet is configured.
+func newWallet(conf *walletConfig) (*wallet, error) {
+	var sources []string
+	if conf.Location != "" {
+		sources = append(sources, "wallet_location")
+	}
+	if conf.Wallet != "" {
+		sources = append(sources, "wallet")
+	}
+	if conf.TLSCA != "" || conf.TLSCertificateKey != "" {
+		sources = append(sources, "tls_ca and tls_certificate_key")
+	}
+	switch len(sources) {
+	case 0:
+		if conf.TLSServerCertDN != "" || conf.DisableTLSDNMatch {
+			return nil, errors.New("tls_server_cert_dn and tls_disable_server_dn_match require a wallet")
+		}
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("only one of %s can be set", strings.Join(sources, ", "))
+	}
+
+	w := &wallet{
+		serverCertDN:   conf.TLSServerCertDN,
+		disableDNMatch: conf.DisableTLSDNMatch,
+	}
+	if strings.ContainsAny(w.serverCertDN, `"&`) {
+		return nil, errors.New("tls_server_cert_dn must not contain double quotes or ampersands")
+	}
+
+	if conf.Location != "" {
+		if strings.ContainsAny(conf.Location, `"&`) {
+			return nil, errors.New("wallet_location must not contain double quotes or ampersands")
+		}
+		info, err := os.Stat(conf.Location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wallet_location: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid wallet_location: %q is not a directory", conf.Location)
+		}
+		w.dir = conf.Location
+		return w, nil
+	}
+
+	var name string
+	var contents []byte
+	if conf.Wallet != "" {
+		sso, err := base64.StdEncoding.DecodeString(conf.Wallet)
+		if err != nil {
+			return nil, fmt.Errorf("wallet must be the base64-encoded contents of an auto-login wallet: %w", err)
+		}
+		name, contents = "cwallet.sso", sso
+	} else {
+		if conf.TLSCA == "" {
+			return nil, errors.New("tls_certificate_key requires tls_ca")
+		}
+		contents = []byte(strings.TrimSpace(conf.TLSCertificateKey) + "\n" + strings.TrimSpace(conf.TLSCA) + "\n")
+		if err := validatePEM(contents); err != nil {
+			return nil, err
+		}
+		name = "ewallet.pem"
+	}
+
+	dir, err := ioutil.TempDir("", "vault-oracle-wallet")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create wallet directory: %w", err)
+	}
+	w.dir, w.temporary = dir, true
+	if err := ioutil.WriteFile(filepath.Join(dir, name), contents, 0600); err != nil {
+		w.remove()
+		return nil, fmt.Errorf("unable to write wallet: %w", err)
+	}
+
+	return w, nil
+}
+
+// validatePEM checks that the PEM material only contains certificates and a
+// private key
+func validatePEM(data []byte) error {
+	var certificates, keys int
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			certificates++
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			keys++
+		default:
+			return fmt.Errorf("unexpected PEM block %q in tls_ca or tls_certificate_key", block.Type)
+		}
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		return errors.New("tls_ca and tls_certificate_key must be PEM-encoded")
+	}
+	if certificates == 0 {
+		return errors.New("tls_ca must contain at least one certificate")
+	}
+	if keys > 1 {
+		return errors.New("tls_certificate_key must contain a single private key")
+	}
+	return nil
+}
+
+// connectionURL adds the wallet to the Easy Connect Plus connect string of the
+// connection URL, which must use the TCPS protocol
+func (w *wallet) connectionURL(connURL string) (string, error) {
+	connect := connURL
+	if i := strings.LastIndex(connURL, "@"); i >= 0 {
+		connect = connURL[i+1:]
+	}
+	if !strings.HasPrefix(strings.ToLower(connect), "tcps://") {
+		return "", errors.New("connection_url must be an Easy Connect string using the tcps:// protocol when a wallet is configured")
+	}
+
+	params := []string{fmt.Sprintf(`wallet_location="%s"`, w.dir)}
+	if w.serverCertDN != "" {
+		params = append(params, fmt.Sprintf(`ssl_server_cert_dn="%s"`, w.serverCertDN))
+	}
+	if !w.disableDNMatch {
+		params = append(params, "ssl_server_dn_match=on")
+	}
+
+	sep := "?"
+	if strings.Contains(connect, "?") {
+		sep = "&"
+	}
+	return connURL + sep + strings.Join(params, "&"), nil
+}
+
+// remove removes the wallet if it was written by the plugin
+func (w *wallet) remove() {
+	if w.temporary {
+		os.RemoveAll(w.dir)
+	}
+}
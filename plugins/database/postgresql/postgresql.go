@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"regexp"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
@@ -58,6 +60,7 @@ func New() (interface{}, error) {
 func new() *PostgreSQL {
 	connProducer := &connutil.SQLConnectionProducer{}
 	connProducer.Type = postgreSQLTypeName
+	connProducer.TLSConnector = tlsConnector
 
 	db := &PostgreSQL{
 		SQLConnectionProducer: connProducer,
@@ -68,13 +71,58 @@ func new() *PostgreSQL {
 
 type PostgreSQL struct {
 	*connutil.SQLConnectionProducer
+
+	usernameTemplate       *template.StringTemplate
+	passwordAuthentication passwordAuthentication
+
+	// defaultDialect is the dialect used when the config doesn't set one
+	defaultDialect string
+	dialect        string
+	// hostConnectors holds a connector per host of the connection URL with the
+	// yugabytedb dialect
+	hostConnectors []driver.Connector
 }
 
 func (p *PostgreSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	passwordAuthenticationRaw, ok := req.Config["password_authentication"].(string)
+	if !ok && req.Config["password_authentication"] != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("password_authentication must be a string")
+	}
+	passwordAuthentication, err := parsePasswordAuthentication(passwordAuthenticationRaw)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	dialect, err := parseDialect(req.Config, p.defaultDialect)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	p.Lock()
+	p.dialect = dialect
+	p.hostConnectors = nil
+	if dialect == dialectYugabyteDB {
+		p.Connector = p.yugabyteDBConnector
+	} else {
+		p.Connector = nil
+	}
+	p.Unlock()
+
 	newConf, err := p.SQLConnectionProducer.Init(ctx, req.Config, req.VerifyConnection)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, err
 	}
+
+	p.Lock()
+	p.usernameTemplate = usernameTemplate
+	p.passwordAuthentication = passwordAuthentication
+	p.Unlock()
+
 	resp := dbplugin.InitializeResponse{
 		Config: newConf,
 	}
@@ -82,6 +130,9 @@ func (p *PostgreSQL) Initialize(ctx context.Context, req dbplugin.InitializeRequ
 }
 
 func (p *PostgreSQL) Type() (string, error) {
+	if p.defaultDialect == dialectYugabyteDB {
+		return yugabyteDBTypeName, nil
+	}
 	return postgreSQLTypeName, nil
 }
 
@@ -128,6 +179,11 @@ func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, ch
 	p.Lock()
 	defer p.Unlock()
 
+	password, err := p.hashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	db, err := p.getConnection(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to get connection: %w", err)
@@ -158,7 +214,7 @@ func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, ch
 				"username": username,
 				"password": password,
 			}
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+			if err := dbtxn.ExecuteTxQueryWithArgs(ctx, tx, dbtxn.DialectPostgreSQLIdentifiers, m, query); err != nil {
 				return fmt.Errorf("failed to execute query: %w", err)
 			}
 		}
@@ -171,6 +227,15 @@ func (p *PostgreSQL) changeUserPassword(ctx context.Context, username string, ch
 	return nil
 }
 
+// hashPassword returns the password as it should be sent to the database,
+// according to the configured password authentication.
+func (p *PostgreSQL) hashPassword(password string) (string, error) {
+	if p.passwordAuthentication != passwordAuthenticationSCRAMSHA256 {
+		return password, nil
+	}
+	return scramSHA256Hash(password)
+}
+
 func (p *PostgreSQL) changeUserExpiration(ctx context.Context, username string, changeExp *dbplugin.ChangeExpiration) error {
 	p.Lock()
 	defer p.Unlock()
@@ -207,7 +272,7 @@ func (p *PostgreSQL) changeUserExpiration(ctx context.Context, username string,
 				"username":   username,
 				"expiration": expirationStr,
 			}
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+			if err := dbtxn.ExecuteTxQueryWithArgs(ctx, tx, dbtxn.DialectPostgreSQLIdentifiers, m, query); err != nil {
 				return err
 			}
 		}
@@ -229,6 +294,7 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 		credsutil.RoleName(req.UsernameConfig.RoleName, 8),
 		credsutil.Separator("-"),
 		credsutil.MaxLength(63),
+		credsutil.Template(p.usernameTemplate, req.UsernameConfig),
 	)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, err
@@ -236,6 +302,11 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 
 	expirationStr := req.Expiration.Format(expirationFormat)
 
+	password, err := p.hashPassword(req.Password)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
 	db, err := p.getConnection(ctx)
 	if err != nil {
 		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
@@ -254,10 +325,10 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 			m := map[string]string{
 				"name":       username,
 				"username":   username,
-				"password":   req.Password,
+				"password":   password,
 				"expiration": expirationStr,
 			}
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, stmt); err != nil {
+			if err := dbtxn.ExecuteTxQueryWithArgs(ctx, tx, dbtxn.DialectPostgreSQLIdentifiers, m, stmt); err != nil {
 				return dbplugin.NewUserResponse{}, fmt.Errorf("failed to execute query: %w", err)
 			}
 			continue
@@ -272,10 +343,10 @@ func (p *PostgreSQL) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (
 			m := map[string]string{
 				"name":       username,
 				"username":   username,
-				"password":   req.Password,
+				"password":   password,
 				"expiration": expirationStr,
 			}
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+			if err := dbtxn.ExecuteTxQueryWithArgs(ctx, tx, dbtxn.DialectPostgreSQLIdentifiers, m, query); err != nil {
 				return dbplugin.NewUserResponse{}, fmt.Errorf("failed to execute query: %w", err)
 			}
 		}
@@ -308,28 +379,40 @@ func (p *PostgreSQL) customDeleteUser(ctx context.Context, username string, revo
 		return err
 	}
 
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+	m := map[string]string{
+		"name":     username,
+		"username": username,
 	}
-	defer func() {
-		tx.Rollback()
-	}()
 
+	var queries []string
 	for _, stmt := range revocationStmts {
 		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
 			query = strings.TrimSpace(query)
 			if len(query) == 0 {
 				continue
 			}
+			queries = append(queries, query)
+		}
+	}
 
-			m := map[string]string{
-				"name":     username,
-				"username": username,
-			}
-			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
-				return err
-			}
+	if p.dialect == dialectYugabyteDB {
+		queries, err = p.terminateBackends(ctx, m, queries)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	for _, query := range queries {
+		if err := dbtxn.ExecuteTxQueryWithArgs(ctx, tx, dbtxn.DialectPostgreSQLIdentifiers, m, query); err != nil {
+			return err
 		}
 	}
 
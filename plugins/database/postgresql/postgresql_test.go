@@ -101,6 +101,48 @@ func TestPostgreSQL_NewUser(t *testing.T) {
 			expectErr:      false,
 			credsAssertion: assertCredsExist,
 		},
+		"password with quotes": {
+			req: dbplugin.NewUserRequest{
+				UsernameConfig: dbplugin.UsernameMetadata{
+					DisplayName: "test",
+					RoleName:    "test",
+				},
+				Statements: dbplugin.Statements{
+					Commands: []string{`
+						-- The password is escaped, even inside E'' strings
+						CREATE ROLE "{{name}}" WITH
+						  LOGIN
+						  PASSWORD E'{{password}}'
+						  VALID UNTIL '{{expiration}}';`,
+					},
+				},
+				Password:   `some'secure\password';--`,
+				Expiration: time.Now().Add(1 * time.Minute),
+			},
+			expectErr:      false,
+			credsAssertion: assertCredsExist,
+		},
+		"unquoted name": {
+			req: dbplugin.NewUserRequest{
+				UsernameConfig: dbplugin.UsernameMetadata{
+					DisplayName: "test",
+					RoleName:    "test",
+				},
+				Statements: dbplugin.Statements{
+					Commands: []string{`
+						CREATE ROLE {{name}} WITH
+						  LOGIN
+						  PASSWORD '{{password}}'
+						  VALID UNTIL '{{expiration}}';
+						GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO {{name}};`,
+					},
+				},
+				Password:   "somesecurepassword",
+				Expiration: time.Now().Add(1 * time.Minute),
+			},
+			expectErr:      false,
+			credsAssertion: assertCredsExist,
+		},
 		"admin username": {
 			req: dbplugin.NewUserRequest{
 				UsernameConfig: dbplugin.UsernameMetadata{
@@ -745,3 +787,49 @@ func TestExtractQuotedStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestPostgreSQL_SCRAMSHA256PasswordAuthentication(t *testing.T) {
+	db, cleanup := getPostgreSQL(t, map[string]interface{}{
+		"password_authentication": "scram-sha-256",
+	})
+	defer cleanup()
+
+	initialPass := "myreallysecurepassword"
+	createReq := dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{createAdminUser},
+		},
+		Password:   initialPass,
+		Expiration: time.Now().Add(time.Minute),
+	}
+	createResp := dbtesting.AssertNewUser(t, db, createReq)
+	assertCredsExist(t, db.ConnectionURL, createResp.Username, initialPass)
+
+	newPass := "somenewpassword"
+	updateReq := dbplugin.UpdateUserRequest{
+		Username: createResp.Username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: newPass,
+		},
+	}
+	dbtesting.AssertUpdateUser(t, db, updateReq)
+	assertCredsExist(t, db.ConnectionURL, createResp.Username, newPass)
+
+	// The database only knows the SCRAM-SHA-256 verifier of the password
+	conn, err := db.getConnection(context.Background())
+	if err != nil {
+		t.Fatalf("unable to get connection: %s", err)
+	}
+	var rolpassword string
+	err = conn.QueryRow("SELECT rolpassword FROM pg_authid WHERE rolname = $1;", createResp.Username).Scan(&rolpassword)
+	if err != nil {
+		t.Fatalf("unable to read password: %s", err)
+	}
+	if !strings.HasPrefix(rolpassword, "SCRAM-SHA-256$4096:") {
+		t.Fatalf("expected a SCRAM-SHA-256 verifier, got %q", rolpassword)
+	}
+}
@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// scramIterations is the iteration count used by PostgreSQL itself when
+	// hashing passwords with SCRAM-SHA-256
+	scramIterations = 4096
+	scramSaltLen    = 16
+	scramKeyLen     = sha256.Size
+)
+
+// passwordAuthentication is the form in which the passwords of the roles are
+// sent to the database.
+type passwordAuthentication string
+
+const (
+	// passwordAuthenticationPassword sends the passwords in plaintext, to be
+	// hashed by the database according to its password_encryption setting.
+	passwordAuthenticationPassword passwordAuthentication = "password"
+
+	// passwordAuthenticationSCRAMSHA256 sends SCRAM-SHA-256 verifiers of the
+	// passwords, so that the plaintext passwords never reach the database, its
+	// logs or pg_stat_activity.
+	passwordAuthenticationSCRAMSHA256 passwordAuthentication = "scram-sha-256"
+)
+
+func parsePasswordAuthentication(s string) (passwordAuthentication, error) {
+	switch passwordAuthentication(s) {
+	case "", passwordAuthenticationPassword:
+		return passwordAuthenticationPassword, nil
+	case passwordAuthenticationSCRAMSHA256:
+		return passwordAuthenticationSCRAMSHA256, nil
+	default:
+		return "", fmt.Errorf("invalid password_authentication %q, must be %q or %q", s,
+			passwordAuthenticationPassword, passwordAuthenticationSCRAMSHA256)
+	}
+}
+
+// scramSHA256Hash returns the SCRAM-SHA-256 verifier of the password, in the
+// format stored by PostgreSQL in pg_authid:
+//
+//   SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+//
+// PostgreSQL normalizes passwords with SASLprep before hashing them, which
+// leaves printable ASCII passwords unchanged. Other passwords are rejected,
+// since the verifier would not match the password sent by clients.
+func scramSHA256Hash(password string) (string, error) {
+	for _, r := range password {
+		if r < 0x20 || r > 0x7e {
+			return "", errors.New("scram-sha-256 password authentication requires printable ASCII passwords")
+		}
+	}
+
+	salt := make([]byte, scramSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("unable to generate salt: %w", err)
+	}
+	return scramSHA256Verifier(password, salt, scramIterations), nil
+}
+
+func scramSHA256Verifier(password string, salt []byte, iterations int) string {
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, scramKeyLen, sha256.New)
+
+	clientKey := scramHMAC(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := scramHMAC(saltedPassword, "Server Key")
+
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s",
+		iterations,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey[:]),
+		base64.StdEncoding.EncodeToString(serverKey),
+	)
+}
+
+func scramHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package postgresql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSCRAMSHA256Verifier(t *testing.T) {
+	// Computed independently from RFC 5802 and RFC 7677
+	expected := "SCRAM-SHA-256$4096:MDEyMzQ1Njc4OWFiY2RlZg==$mgq28vgbpFkSvis/GIMUfalnKTj81Msn8cWs9IdsD4s=:EerRscXDUzb4TR+wZa2AQGhwEilRQ6+8pzupyWQAjMM="
+	actual := scramSHA256Verifier("p@ssw0rd-Vault", []byte("0123456789abcdef"), 4096)
+	if actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestSCRAMSHA256Hash(t *testing.T) {
+	h1, err := scramSHA256Hash("myreallysecurepassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := scramSHA256Hash("myreallysecurepassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(h1, "SCRAM-SHA-256$4096:") {
+		t.Fatalf("bad verifier: %q", h1)
+	}
+	if h1 == h2 {
+		t.Fatal("expected verifiers to be salted")
+	}
+
+	if _, err := scramSHA256Hash("pässword"); err == nil {
+		t.Fatal("expected error for non-ASCII password")
+	}
+}
+
+func TestParsePasswordAuthentication(t *testing.T) {
+	for in, expected := range map[string]passwordAuthentication{
+		"":              passwordAuthenticationPassword,
+		"password":      passwordAuthenticationPassword,
+		"scram-sha-256": passwordAuthenticationSCRAMSHA256,
+	} {
+		actual, err := parsePasswordAuthentication(in)
+		if err != nil {
+			t.Fatalf("%q: %s", in, err)
+		}
+		if actual != expected {
+			t.Fatalf("%q: expected %q, got %q", in, expected, actual)
+		}
+	}
+
+	if _, err := parsePasswordAuthentication("md5"); err == nil {
+		t.Fatal("expected error")
+	}
+}
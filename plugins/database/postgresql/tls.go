@@ -0,0 +1,124 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// sslRequestCode is sent by clients to ask the server to switch the
+// connection to TLS before the startup message.
+const sslRequestCode = 80877103
+
+// tlsConnector returns a connector whose connections are secured with
+// tlsConfig. lib/pq only loads certificates from files, so the TLS handshake
+// is performed by the dialer and the driver itself is told not to use SSL.
+func tlsConnector(connURL string, tlsConfig *tls.Config) (driver.Connector, error) {
+	dsn := connURL
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		var err error
+		dsn, err = pq.ParseURL(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse connection url: %w", err)
+		}
+	}
+
+	return pqTLSConnector{
+		dsn: dsn + " sslmode=disable",
+		dialer: &tlsDialer{
+			tlsConfig: tlsConfig,
+		},
+	}, nil
+}
+
+type pqTLSConnector struct {
+	dsn    string
+	dialer *tlsDialer
+}
+
+func (c pqTLSConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return pq.DialOpen(c.dialer, c.dsn)
+}
+
+func (c pqTLSConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+var _ pq.DialerContext = (*tlsDialer)(nil)
+
+// tlsDialer dials PostgreSQL servers and negotiates TLS on the connection the
+// way libpq does, by sending an SSLRequest before the handshake.
+type tlsDialer struct {
+	tlsConfig *tls.Config
+	dialer    net.Dialer
+}
+
+func (d *tlsDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *tlsDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+func (d *tlsDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn, err := d.upgrade(ctx, conn, address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (d *tlsDialer) upgrade(ctx context.Context, conn net.Conn, address string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := make([]byte, 8)
+	binary.BigEndian.PutUint32(req[0:4], 8)
+	binary.BigEndian.PutUint32(req[4:8], sslRequestCode)
+	if _, err := conn.Write(req); err != nil {
+		return nil, fmt.Errorf("unable to request TLS: %w", err)
+	}
+
+	resp := make([]byte, 1)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, fmt.Errorf("unable to request TLS: %w", err)
+	}
+	if resp[0] != 'S' {
+		return nil, errors.New("server does not support TLS")
+	}
+
+	tlsConfig := d.tlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ServerName = host
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return tlsConn, nil
+}
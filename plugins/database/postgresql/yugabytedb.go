@@ -0,0 +1,177 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-multierror"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/helper/dbtxn"
+	"github.com/lib/pq"
+)
+
+const (
+	yugabyteDBTypeName = "yugabytedb"
+
+	dialectPostgres   = "postgres"
+	dialectYugabyteDB = "yugabytedb"
+)
+
+// NewYugabyteDB implements builtinplugins.BuiltinFactory for YugabyteDB,
+// which is managed through its PostgreSQL compatible API with the yugabytedb
+// dialect.
+func NewYugabyteDB() (interface{}, error) {
+	db := new()
+	db.defaultDialect = dialectYugabyteDB
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+	return dbType, nil
+}
+
+func parseDialect(config map[string]interface{}, defaultDialect string) (string, error) {
+	dialect, ok := config["dialect"].(string)
+	if !ok && config["dialect"] != nil {
+		return "", fmt.Errorf("dialect must be a string")
+	}
+	if dialect == "" {
+		dialect = defaultDialect
+	}
+
+	switch dialect {
+	case "", dialectPostgres:
+		return dialectPostgres, nil
+	case dialectYugabyteDB:
+		return dialectYugabyteDB, nil
+	default:
+		return "", fmt.Errorf("invalid dialect %q, must be %q or %q", dialect, dialectPostgres, dialectYugabyteDB)
+	}
+}
+
+// splitHosts returns a connection URL for each of the comma separated hosts of
+// a connection URL, such as the tablet servers of a YugabyteDB cluster.
+// Connection strings in the key/value format are returned as is.
+func splitHosts(connURL string) []string {
+	if !strings.HasPrefix(connURL, "postgres://") && !strings.HasPrefix(connURL, "postgresql://") {
+		return []string{connURL}
+	}
+
+	schemeEnd := strings.Index(connURL, "://") + len("://")
+	prefix, rest := connURL[:schemeEnd], connURL[schemeEnd:]
+	authorityEnd := strings.IndexAny(rest, "/?")
+	if authorityEnd < 0 {
+		authorityEnd = len(rest)
+	}
+	authority, suffix := rest[:authorityEnd], rest[authorityEnd:]
+
+	// The credentials are escaped in the URL, so the last @ ends them
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		prefix += authority[:at+1]
+		authority = authority[at+1:]
+	}
+
+	var hostURLs []string
+	for _, host := range strings.Split(authority, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		hostURLs = append(hostURLs, prefix+host+suffix)
+	}
+	if len(hostURLs) == 0 {
+		return []string{connURL}
+	}
+	return hostURLs
+}
+
+// yugabyteDBConnector returns a connector spreading connections across the
+// hosts of the connection URL. It is called with the lock held.
+func (p *PostgreSQL) yugabyteDBConnector(connURL string, tlsConfig *tls.Config) (driver.Connector, error) {
+	var connectors []driver.Connector
+	for _, hostURL := range splitHosts(connURL) {
+		var connector driver.Connector
+		var err error
+		if tlsConfig != nil {
+			connector, err = tlsConnector(hostURL, tlsConfig)
+		} else {
+			connector, err = pq.NewConnector(hostURL)
+		}
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+
+	p.hostConnectors = connectors
+	return &multiHostConnector{connectors: connectors}, nil
+}
+
+// multiHostConnector balances connections across hosts in turn, connecting to
+// the next host if one can't be reached.
+type multiHostConnector struct {
+	connectors []driver.Connector
+	next       uint32
+}
+
+func (c *multiHostConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	start := int(atomic.AddUint32(&c.next, 1) - 1)
+
+	var merr *multierror.Error
+	for i := range c.connectors {
+		conn, err := c.connectors[(start+i)%len(c.connectors)].Connect(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		merr = multierror.Append(merr, err)
+	}
+	return nil, merr.ErrorOrNil()
+}
+
+func (c *multiHostConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// terminateBackends executes the queries calling pg_terminate_backend on every
+// host, as YugabyteDB only terminates the sessions of the tablet server the
+// query is executed on, and returns the remaining queries. Hosts that can't be
+// reached have no sessions to terminate and are skipped.
+func (p *PostgreSQL) terminateBackends(ctx context.Context, m map[string]string, queries []string) ([]string, error) {
+	var terminate, remaining []string
+	for _, query := range queries {
+		if strings.Contains(strings.ToLower(query), "pg_terminate_backend") {
+			terminate = append(terminate, query)
+		} else {
+			remaining = append(remaining, query)
+		}
+	}
+	if len(terminate) == 0 {
+		return remaining, nil
+	}
+
+	for _, connector := range p.hostConnectors {
+		if err := executeOnHost(ctx, connector, m, terminate); err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
+func executeOnHost(ctx context.Context, connector driver.Connector, m map[string]string, queries []string) error {
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	if err := db.PingContext(ctx); err != nil {
+		return nil
+	}
+
+	for _, query := range queries {
+		if err := dbtxn.ExecuteDBQuery(ctx, db, m, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
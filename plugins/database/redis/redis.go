@@ -0,0 +1,330 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	redisTypeName = "redis"
+
+	defaultPort     = 6379
+	defaultPoolSize = 4
+	defaultTimeout  = 10 * time.Second
+)
+
+var _ dbplugin.Database = (*Redis)(nil)
+
+// Redis is an implementation of Database interface managing Redis 6+ ACL
+// users
+type Redis struct {
+	// This protects the config from races while also allowing multiple
+	// threads to use the client simultaneously when it's not changing
+	mux sync.RWMutex
+
+	config           *redisConfig
+	client           radix.Client
+	usernameTemplate *template.StringTemplate
+
+	// newClient creates the client of the configuration, replaced in tests
+	newClient func(*redisConfig) (radix.Client, error)
+}
+
+type redisConfig struct {
+	Host        string `mapstructure:"host"`
+	Port        int    `mapstructure:"port"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	TLS         bool   `mapstructure:"tls"`
+	InsecureTLS bool   `mapstructure:"insecure_tls"`
+	CACert      string `mapstructure:"ca_cert"`
+}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := newRedis()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func newRedis() *Redis {
+	return &Redis{
+		newClient: newPool,
+	}
+}
+
+// Type returns the TypeName for this backend
+func (r *Redis) Type() (string, error) {
+	return redisTypeName, nil
+}
+
+func (r *Redis) secretValues() map[string]string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if r.config == nil || r.config.Password == "" {
+		return nil
+	}
+	return map[string]string{
+		r.config.Password: "[password]",
+	}
+}
+
+// Initialize validates the configuration and creates the connection pool
+func (r *Redis) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	config := &redisConfig{}
+	if err := mapstructure.WeakDecode(req.Config, config); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	switch {
+	case config.Host == "":
+		return dbplugin.InitializeResponse{}, errors.New("host cannot be empty")
+	case config.Username == "":
+		return dbplugin.InitializeResponse{}, errors.New("username cannot be empty")
+	case config.Password == "":
+		return dbplugin.InitializeResponse{}, errors.New("password cannot be empty")
+	case config.CACert != "" && !config.TLS:
+		return dbplugin.InitializeResponse{}, errors.New("ca_cert requires tls to be enabled")
+	}
+	if config.Port == 0 {
+		config.Port = defaultPort
+	}
+
+	client, err := r.newClient(config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to create client: %w", err)
+	}
+
+	if req.VerifyConnection {
+		if err := client.Do(radix.Cmd(nil, "PING")); err != nil {
+			client.Close()
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.client != nil {
+		r.client.Close()
+	}
+	r.config = config
+	r.client = client
+	r.usernameTemplate = usernameTemplate
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+// newPool returns a pool of connections authenticated as the configured
+// user
+func newPool(config *redisConfig) (radix.Client, error) {
+	opts := []radix.DialOpt{
+		radix.DialAuthUser(config.Username, config.Password),
+		radix.DialTimeout(defaultTimeout),
+	}
+	if config.TLS {
+		tlsConfig := &tls.Config{
+			ServerName:         config.Host,
+			InsecureSkipVerify: config.InsecureTLS,
+			MinVersion:         tls.VersionTLS12,
+		}
+		if config.CACert != "" {
+			pool := x509.NewCertPool()
+			if ok := pool.AppendCertsFromPEM([]byte(config.CACert)); !ok {
+				return nil, errors.New("unable to parse ca_cert")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		opts = append(opts, radix.DialUseTLS(tlsConfig))
+	}
+
+	connFunc := func(network, addr string) (radix.Conn, error) {
+		return radix.Dial(network, addr, opts...)
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	return radix.NewPool("tcp", addr, defaultPoolSize, radix.PoolConnFunc(connFunc))
+}
+
+// NewUser creates an ACL user with the rules of the creation statements.
+// Redis users do not expire, so the user exists until it is revoked.
+func (r *Redis) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if len(req.Statements.Commands) == 0 {
+		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
+	}
+	rules, err := parseRules(req.Statements.Commands)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	// Don't let anyone write the config while we're using its client
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if r.client == nil {
+		return dbplugin.NewUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 15),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 15),
+		credsutil.MaxLength(100),
+		credsutil.Separator("-"),
+		credsutil.Template(r.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to generate username: %w", err)
+	}
+
+	args := append([]string{"SETUSER", username, "reset", "on", ">" + req.Password}, rules...)
+	if err := r.client.Do(radix.Cmd(nil, "ACL", args...)); err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to create user %q: %w", username, err)
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser replaces the passwords of a user with the new password. Redis
+// users do not expire, so changing the expiration is a no-op.
+func (r *Redis) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing username")
+	}
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing password")
+	}
+
+	// Take the write lock, since the client changes if the password of the
+	// root user is rotated
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.client == nil {
+		return dbplugin.UpdateUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	// ACL SETUSER creates missing users, so make sure the user exists first
+	var rules []string
+	if err := r.client.Do(radix.Cmd(&rules, "ACL", "GETUSER", req.Username)); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to read user %q: %w", req.Username, err)
+	}
+	if len(rules) == 0 {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("user %q does not exist", req.Username)
+	}
+
+	if err := r.client.Do(radix.Cmd(nil, "ACL", "SETUSER", req.Username, "resetpass", ">"+req.Password.NewPassword)); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to change password: %w", err)
+	}
+
+	if req.Username != r.config.Username {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+
+	// Established connections remain authenticated, but new connections of
+	// the pool need the new password
+	config := *r.config
+	config.Password = req.Password.NewPassword
+	client, err := r.newClient(&config)
+	if err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to create client with the new password: %w", err)
+	}
+	r.client.Close()
+	r.client = client
+	r.config = &config
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser terminates the sessions of the user, then deletes it. Users that
+// do not exist are ignored, so deletion can be retried.
+func (r *Redis) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if r.client == nil {
+		return dbplugin.DeleteUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	if err := r.client.Do(radix.Cmd(nil, "CLIENT", "KILL", "USER", req.Username)); err != nil && !isNoSuchUser(err) {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to terminate the sessions of user %q: %w", req.Username, err)
+	}
+	if err := r.client.Do(radix.Cmd(nil, "ACL", "DELUSER", req.Username)); err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to delete user %q: %w", req.Username, err)
+	}
+
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.client == nil {
+		return nil
+	}
+	err := r.client.Close()
+	r.client = nil
+	return err
+}
+
+// parseRules splits the creation statements into ACL rules. Rules setting
+// the passwords of the user are rejected, since Vault generates them.
+func parseRules(statements []string) ([]string, error) {
+	var rules []string
+	for _, stmt := range statements {
+		for _, rule := range strings.Fields(stmt) {
+			switch {
+			case strings.ContainsAny(rule[:1], "><#!"),
+				strings.EqualFold(rule, "nopass"),
+				strings.EqualFold(rule, "resetpass"),
+				strings.EqualFold(rule, "reset"):
+				return nil, fmt.Errorf("rule %q is not allowed in creation statements: passwords are managed by Vault", rule)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, dbutil.ErrEmptyCreationStatement
+	}
+	return rules, nil
+}
+
+// isNoSuchUser returns whether the error is the error of CLIENT KILL USER for
+// a user that does not exist
+func isNoSuchUser(err error) bool {
+	return strings.Contains(err.Error(), "No such user")
+}
@@ -0,0 +1,322 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/mediocregopher/radix/v3/resp/resp2"
+)
+
+// fakeRedis implements the ACL commands used by the plugin
+type fakeRedis struct {
+	users   map[string][]string
+	killed  []string
+	clients []*redisConfig
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		users: map[string][]string{
+			"vault": {"on", ">secret", "+@all", "~*"},
+		},
+	}
+}
+
+func (f *fakeRedis) newClient(config *redisConfig) (radix.Client, error) {
+	f.clients = append(f.clients, config)
+	return radix.Stub("tcp", config.Host, f.handle), nil
+}
+
+func (f *fakeRedis) handle(args []string) interface{} {
+	if len(args) == 1 && args[0] == "PING" {
+		return "PONG"
+	}
+
+	cmd := strings.ToUpper(strings.Join(args[:2], " "))
+	switch {
+	case cmd == "ACL SETUSER":
+		rules := f.users[args[2]]
+		for _, rule := range args[3:] {
+			switch rule {
+			case "reset":
+				rules = nil
+			case "resetpass":
+				var kept []string
+				for _, r := range rules {
+					if !strings.HasPrefix(r, ">") {
+						kept = append(kept, r)
+					}
+				}
+				rules = kept
+			default:
+				rules = append(rules, rule)
+			}
+		}
+		f.users[args[2]] = rules
+		return "OK"
+
+	case cmd == "ACL GETUSER":
+		rules, ok := f.users[args[2]]
+		if !ok {
+			return nil
+		}
+		return rules
+
+	case cmd == "ACL DELUSER":
+		if _, ok := f.users[args[2]]; !ok {
+			return 0
+		}
+		delete(f.users, args[2])
+		return 1
+
+	case cmd == "CLIENT KILL" && len(args) == 4 && strings.ToUpper(args[2]) == "USER":
+		if _, ok := f.users[args[3]]; !ok {
+			return resp2.Error{E: errors.New("ERR No such user '" + args[3] + "'")}
+		}
+		f.killed = append(f.killed, args[3])
+		return 1
+	}
+	return resp2.Error{E: errors.New("ERR unknown command")}
+}
+
+func TestRedis_Initialize(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"valid": {
+			config: map[string]interface{}{"host": "localhost", "username": "vault", "password": "secret"},
+			valid:  true,
+		},
+		"tls": {
+			config: map[string]interface{}{"host": "localhost", "port": "6380", "username": "vault", "password": "secret", "tls": true, "insecure_tls": true},
+			valid:  true,
+		},
+		"missing host": {
+			config: map[string]interface{}{"username": "vault", "password": "secret"},
+		},
+		"missing username": {
+			config: map[string]interface{}{"host": "localhost", "password": "secret"},
+		},
+		"missing password": {
+			config: map[string]interface{}{"host": "localhost", "username": "vault"},
+		},
+		"ca_cert without tls": {
+			config: map[string]interface{}{"host": "localhost", "username": "vault", "password": "secret", "ca_cert": "cert"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeRedis()
+			db := newRedis()
+			db.newClient = fake.newClient
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config:           tc.config,
+				VerifyConnection: true,
+			})
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+		})
+	}
+
+	// The CA certificate must be valid
+	_, err := newPool(&redisConfig{Host: "localhost", TLS: true, CACert: "not a certificate"})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestRedis_Users(t *testing.T) {
+	fake := newFakeRedis()
+	db := newRedis()
+	db.newClient = fake.newClient
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"host":     "localhost",
+			"username": "vault",
+			"password": "secret",
+		},
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	newUser := func(statements ...string) (dbplugin.NewUserResponse, error) {
+		return db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "my-role",
+			},
+			Statements: dbplugin.Statements{
+				Commands: statements,
+			},
+			Password:   "Passw0rd",
+			Expiration: time.Now().Add(time.Hour),
+		})
+	}
+
+	resp, err := newUser("~cache:* +@read", "-flushall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	username := resp.Username
+	if !strings.HasPrefix(username, "v-token-my-role-") {
+		t.Fatalf("bad username: %s", username)
+	}
+	expected := []string{"on", ">Passw0rd", "~cache:*", "+@read", "-flushall"}
+	if !reflect.DeepEqual(fake.users[username], expected) {
+		t.Fatalf("Actual rules: %#v\nExpected rules: %#v", fake.users[username], expected)
+	}
+
+	for _, stmts := range [][]string{
+		nil,
+		{" "},
+		{"+@all >known-password"},
+		{"+@all nopass"},
+		{"#5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"},
+		{"resetpass"},
+	} {
+		if _, err := newUser(stmts...); err == nil {
+			t.Fatalf("expected error for statements %q", stmts)
+		}
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	expected = []string{"on", "~cache:*", "+@read", "-flushall", ">n3wPassw0rd"}
+	if !reflect.DeepEqual(fake.users[username], expected) {
+		t.Fatalf("Actual rules: %#v\nExpected rules: %#v", fake.users[username], expected)
+	}
+
+	// Users are not created by password changes
+	_, err = db.UpdateUser(context.Background(), dbplugin.UpdateUserRequest{
+		Username: "missing",
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, ok := fake.users["missing"]; ok {
+		t.Fatalf("user was created")
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+	if _, ok := fake.users[username]; ok {
+		t.Fatalf("user was not deleted")
+	}
+	if !reflect.DeepEqual(fake.killed, []string{username}) {
+		t.Fatalf("sessions were not terminated: %#v", fake.killed)
+	}
+
+	// Deletion can be retried
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: username,
+	})
+}
+
+func TestRedis_RotateRoot(t *testing.T) {
+	fake := newFakeRedis()
+	db := newRedis()
+	db.newClient = fake.newClient
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"host":     "localhost",
+			"username": "vault",
+			"password": "secret",
+		},
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: "vault",
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "rotated",
+		},
+	})
+
+	// New connections use the new password
+	if len(fake.clients) != 2 || fake.clients[1].Password != "rotated" {
+		t.Fatalf("client was not recreated with the new password: %#v", fake.clients)
+	}
+	if _, ok := db.secretValues()["rotated"]; !ok {
+		t.Fatalf("new password is not redacted from errors")
+	}
+}
+
+func TestRedis_Acceptance(t *testing.T) {
+	host := os.Getenv("REDIS_HOST")
+	if os.Getenv("VAULT_ACC") == "" || host == "" {
+		t.Skip("skipping: VAULT_ACC and REDIS_HOST must be set")
+	}
+
+	db := newRedis()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: map[string]interface{}{
+			"host":     host,
+			"port":     os.Getenv("REDIS_PORT"),
+			"username": os.Getenv("REDIS_USERNAME"),
+			"password": os.Getenv("REDIS_PASSWORD"),
+		},
+		VerifyConnection: true,
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{"~* +@read"},
+		},
+		Password:   "Passw0rd-acceptance",
+		Expiration: time.Now().Add(time.Minute),
+	})
+
+	conn, err := radix.Dial("tcp", host+":"+portOrDefault(os.Getenv("REDIS_PORT")), radix.DialAuthUser(resp.Username, "Passw0rd-acceptance"))
+	if err != nil {
+		t.Fatalf("unable to connect as the new user: %s", err)
+	}
+	defer conn.Close()
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+
+	// The session of the user was terminated
+	if err := conn.Do(radix.Cmd(nil, "PING")); err == nil {
+		t.Fatalf("expected the session to be terminated")
+	}
+}
+
+func portOrDefault(port string) string {
+	if port == "" {
+		return "6379"
+	}
+	return port
+}
@@ -0,0 +1,144 @@
+package redshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/redshift"
+	"github.com/aws/aws-sdk-go/service/redshift/redshiftiface"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/awsutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	authTypePassword = "password"
+	authTypeIAM      = "iam"
+
+	// iamCredentialsDuration is the lifetime requested for the temporary
+	// credentials of the root connection, the maximum allowed by Redshift.
+	iamCredentialsDuration = time.Hour
+
+	// iamCredentialsRefreshWindow is how long before their expiration the
+	// temporary credentials are replaced, so that no connection is attempted
+	// with credentials about to expire.
+	iamCredentialsRefreshWindow = 5 * time.Minute
+)
+
+// iamConfig holds the parameters of IAM authentication of the root connection
+type iamConfig struct {
+	AuthType          string `mapstructure:"auth_type"`
+	ClusterIdentifier string `mapstructure:"cluster_identifier"`
+	Region            string `mapstructure:"region"`
+	AccessKey         string `mapstructure:"access_key"`
+	SecretKey         string `mapstructure:"secret_key"`
+}
+
+// iamCredentials are the temporary credentials of the root connection
+type iamCredentials struct {
+	username   string
+	password   string
+	expiration time.Time
+}
+
+func parseIAMConfig(conf map[string]interface{}) (*iamConfig, error) {
+	config := &iamConfig{}
+	if err := mapstructure.WeakDecode(conf, config); err != nil {
+		return nil, err
+	}
+
+	switch config.AuthType {
+	case "", authTypePassword:
+		config.AuthType = authTypePassword
+		return config, nil
+	case authTypeIAM:
+	default:
+		return nil, fmt.Errorf("invalid auth_type %q, must be %q or %q", config.AuthType, authTypePassword, authTypeIAM)
+	}
+
+	if config.ClusterIdentifier == "" {
+		return nil, errors.New("cluster_identifier is required with IAM authentication")
+	}
+	if password, _ := conf["password"].(string); password != "" {
+		return nil, errors.New("password cannot be set with IAM authentication")
+	}
+	if username, _ := conf["username"].(string); username == "" {
+		return nil, errors.New("username is required with IAM authentication")
+	}
+	return config, nil
+}
+
+// newRedshiftClient returns a Redshift API client authenticated with the
+// configured keys, or the default credential chain.
+func newRedshiftClient(config *iamConfig) (redshiftiface.RedshiftAPI, error) {
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey: config.AccessKey,
+		SecretKey: config.SecretKey,
+		Region:    config.Region,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := aws.NewConfig().WithCredentials(creds)
+	if config.Region != "" {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return redshift.New(sess), nil
+}
+
+// refreshIAMCredentials replaces the temporary credentials of the root
+// connection if they are about to expire. The connection pool is reopened
+// with them, as the pool opens its new connections with the credentials it
+// was opened with. The caller must hold the lock.
+func (r *RedShift) refreshIAMCredentials(ctx context.Context) error {
+	if r.iam == nil || r.iam.AuthType != authTypeIAM {
+		return nil
+	}
+	if r.iamCreds != nil && time.Until(r.iamCreds.expiration) > iamCredentialsRefreshWindow {
+		return nil
+	}
+
+	if r.redshiftClient == nil {
+		client, err := r.newClient(r.iam)
+		if err != nil {
+			return fmt.Errorf("error creating Redshift client: %w", err)
+		}
+		r.redshiftClient = client
+	}
+
+	out, err := r.redshiftClient.GetClusterCredentialsWithContext(ctx, &redshift.GetClusterCredentialsInput{
+		ClusterIdentifier: aws.String(r.iam.ClusterIdentifier),
+		DbUser:            aws.String(r.Username),
+		DurationSeconds:   aws.Int64(int64(iamCredentialsDuration.Seconds())),
+		AutoCreate:        aws.Bool(false),
+	})
+	if err != nil {
+		return fmt.Errorf("error getting cluster credentials: %w", err)
+	}
+	if out.DbUser == nil || out.DbPassword == nil || out.Expiration == nil {
+		return errors.New("incomplete cluster credentials returned")
+	}
+
+	r.iamCreds = &iamCredentials{
+		username:   *out.DbUser,
+		password:   *out.DbPassword,
+		expiration: *out.Expiration,
+	}
+	r.ConnectionURL = dbutil.QueryHelper(r.connectionURLTemplate, map[string]string{
+		"username": url.PathEscape(r.iamCreds.username),
+		"password": url.PathEscape(r.iamCreds.password),
+	})
+	r.ResetConnection()
+	return nil
+}
@@ -4,30 +4,22 @@ import (
 	"log"
 	"os"
 
-	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/plugins/database/redshift"
-	"github.com/hashicorp/vault/sdk/database/dbplugin"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 )
 
 func main() {
-	apiClientMeta := &api.PluginAPIClientMeta{}
-	flags := apiClientMeta.FlagSet()
-	flags.Parse(os.Args[1:])
-
-	if err := Run(apiClientMeta.GetTLSConfig()); err != nil {
+	err := Run()
+	if err != nil {
 		log.Println(err)
 		os.Exit(1)
 	}
 }
 
-// Run instantiates a RedShift object, and runs the RPC server for the plugin
-func Run(apiTLSConfig *api.TLSConfig) error {
-	dbType, err := redshift.New(true)()
-	if err != nil {
-		return err
-	}
-
-	dbplugin.Serve(dbType.(dbplugin.Database), api.VaultPluginTLSProvider(apiTLSConfig))
+// Run starts the RPC server for the plugin, which creates a new RedShift
+// object for every database connection it serves
+func Run() error {
+	dbplugin.ServeMultiplex(redshift.New)
 
 	return nil
 }
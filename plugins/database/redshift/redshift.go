@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/service/redshift/redshiftiface"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-multierror"
 	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/database/helper/connutil"
 	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
 	"github.com/hashicorp/vault/sdk/helper/dbtxn"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
@@ -52,6 +54,7 @@ func newRedshift() *RedShift {
 
 	db := &RedShift{
 		SQLConnectionProducer: connProducer,
+		newClient:             newRedshiftClient,
 	}
 
 	return db
@@ -59,12 +62,29 @@ func newRedshift() *RedShift {
 
 type RedShift struct {
 	*connutil.SQLConnectionProducer
+
+	usernameTemplate *template.StringTemplate
+
+	// With IAM authentication, the root connection uses temporary
+	// credentials substituted in the templated connection URL
+	iam                   *iamConfig
+	iamCreds              *iamCredentials
+	connectionURLTemplate string
+	redshiftClient        redshiftiface.RedshiftAPI
+	newClient             func(*iamConfig) (redshiftiface.RedshiftAPI, error)
 }
 
 func (r *RedShift) secretValues() map[string]string {
-	return map[string]string{
+	values := map[string]string{
 		r.Password: "[password]",
 	}
+	if r.iam != nil && r.iam.SecretKey != "" {
+		values[r.iam.SecretKey] = "[secret_key]"
+	}
+	if r.iamCreds != nil {
+		values[r.iamCreds.password] = "[password]"
+	}
+	return values
 }
 
 func (r *RedShift) Type() (string, error) {
@@ -74,11 +94,43 @@ func (r *RedShift) Type() (string, error) {
 // Initialize must be called on each new RedShift struct before use.
 // It uses the connutil.SQLConnectionProducer's Init function to do all the lifting.
 func (r *RedShift) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
-	conf, err := r.Init(ctx, req.Config, req.VerifyConnection)
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	iam, err := parseIAMConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	// With IAM authentication, the connection can only be verified once
+	// the temporary credentials are known
+	verifyConnection := req.VerifyConnection && iam.AuthType != authTypeIAM
+	conf, err := r.Init(ctx, req.Config, verifyConnection)
 	if err != nil {
 		return dbplugin.InitializeResponse{}, fmt.Errorf("error initializing db: %w", err)
 	}
 
+	r.Lock()
+	defer r.Unlock()
+
+	r.usernameTemplate = usernameTemplate
+	r.iam = iam
+	r.iamCreds = nil
+	r.redshiftClient = nil
+	r.connectionURLTemplate, _ = req.Config["connection_url"].(string)
+
+	if iam.AuthType == authTypeIAM && req.VerifyConnection {
+		db, err := r.getConnection(ctx)
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+	}
+
 	return dbplugin.InitializeResponse{
 		Config: conf,
 	}, nil
@@ -87,6 +139,10 @@ func (r *RedShift) Initialize(ctx context.Context, req dbplugin.InitializeReques
 // getConnection accepts a context and returns a new pointer to a sql.DB object.
 // It's up to the caller to close the connection or handle reuse logic.
 func (r *RedShift) getConnection(ctx context.Context) (*sql.DB, error) {
+	if err := r.refreshIAMCredentials(ctx); err != nil {
+		return nil, err
+	}
+
 	db, err := r.Connection(ctx)
 	if err != nil {
 		return nil, err
@@ -112,6 +168,7 @@ func (r *RedShift) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (db
 		credsutil.MaxLength(63),
 		credsutil.Separator("-"),
 		credsutil.ToLower(),
+		credsutil.Template(r.usernameTemplate, req.UsernameConfig),
 	}
 
 	username, err := credsutil.GenerateUsername(usernameOpts...)
@@ -179,6 +236,11 @@ func (r *RedShift) UpdateUser(ctx context.Context, req dbplugin.UpdateUserReques
 	r.Lock()
 	defer r.Unlock()
 
+	// The root user has no password of its own with IAM authentication
+	if req.Password != nil && r.iam != nil && r.iam.AuthType == authTypeIAM && req.Username == r.Username {
+		return dbplugin.UpdateUserResponse{}, errors.New("cannot rotate the root credentials with IAM authentication")
+	}
+
 	db, err := r.getConnection(ctx)
 	if err != nil {
 		return dbplugin.UpdateUserResponse{}, err
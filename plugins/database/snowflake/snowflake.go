@@ -0,0 +1,360 @@
+package snowflake
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/dbtxn"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	_ "github.com/snowflakedb/gosnowflake"
+)
+
+const (
+	snowflakeTypeName   = "snowflake"
+	maxIdentifierLength = 255
+
+	defaultRotateCredentialsSQL = `ALTER USER "{{name}}" SET PASSWORD = '{{password}}';`
+	defaultRotatePublicKeySQL   = `ALTER USER "{{name}}" SET RSA_PUBLIC_KEY = '{{public_key}}';`
+	defaultDeleteUserSQL        = `DROP USER IF EXISTS "{{name}}";`
+)
+
+var _ dbplugin.Database = (*Snowflake)(nil)
+
+// Snowflake is an implementation of Database interface
+type Snowflake struct {
+	*connutil.SQLConnectionProducer
+
+	// privateKey is the base64 encoded PKCS #8 key the connection
+	// authenticates with, if key pair authentication is used
+	privateKey string
+
+	usernameTemplate *template.StringTemplate
+}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := newSnowflake()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func newSnowflake() *Snowflake {
+	connProducer := &connutil.SQLConnectionProducer{}
+	connProducer.Type = snowflakeTypeName
+
+	return &Snowflake{
+		SQLConnectionProducer: connProducer,
+	}
+}
+
+func (s *Snowflake) secretValues() map[string]string {
+	s.Lock()
+	defer s.Unlock()
+
+	values := map[string]string{
+		s.Password: "[password]",
+	}
+	if s.privateKey != "" {
+		values[s.privateKey] = "[private_key]"
+	}
+	return values
+}
+
+// Initialize configures the connection to Snowflake. Besides the options of
+// the other SQL plugins, it accepts a PEM encoded RSA "private_key" to
+// authenticate with a key pair instead of a password.
+func (s *Snowflake) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	// The connection is configured with a copy of the config, so that the
+	// private key is only added to the connection URL the driver sees
+	config := make(map[string]interface{}, len(req.Config))
+	for k, v := range req.Config {
+		config[k] = v
+	}
+
+	var privateKey string
+	if rawKey, ok := req.Config["private_key"].(string); ok && rawKey != "" {
+		privateKey, err = encodePrivateKey(rawKey)
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("invalid private_key: %w", err)
+		}
+		connURL, _ := config["connection_url"].(string)
+		config["connection_url"] = keyPairConnectionURL(connURL, privateKey)
+	}
+
+	s.Lock()
+	s.privateKey = privateKey
+	s.usernameTemplate = usernameTemplate
+	s.Unlock()
+
+	if _, err := s.Init(ctx, config, req.VerifyConnection); err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("error initializing db: %w", err)
+	}
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+// Type returns the TypeName for this backend
+func (s *Snowflake) Type() (string, error) {
+	return snowflakeTypeName, nil
+}
+
+func (s *Snowflake) getConnection(ctx context.Context) (*sql.DB, error) {
+	db, err := s.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*sql.DB), nil
+}
+
+// NewUser creates a new user in Snowflake. There is no default statement for
+// creating users, so one must be specified in the role.
+func (s *Snowflake) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if len(req.Statements.Commands) == 0 {
+		return dbplugin.NewUserResponse{}, dbutil.ErrEmptyCreationStatement
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 32),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 32),
+		credsutil.MaxLength(maxIdentifierLength),
+		credsutil.Separator("_"),
+		credsutil.Template(s.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	// Snowflake only allows hyphens in quoted identifiers
+	username = strings.Replace(username, "-", "_", -1)
+
+	m := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   req.Password,
+		"expiration": req.Expiration.UTC().Format("2006-01-02 15:04:05-0700"),
+	}
+	if err := s.executeStatements(ctx, req.Statements.Commands, m); err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password or the public key of a user and runs the
+// renewal statements of the role, if any. Snowflake users do not expire, so
+// there is no default renewal statement.
+func (s *Snowflake) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing username")
+	}
+	if req.Password == nil && req.Expiration == nil && req.PublicKey == nil {
+		return dbplugin.UpdateUserResponse{}, errors.New("no changes requested")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if req.Password != nil {
+		if req.Password.NewPassword == "" {
+			return dbplugin.UpdateUserResponse{}, errors.New("missing password")
+		}
+
+		stmts := req.Password.Statements.Commands
+		if len(stmts) == 0 {
+			stmts = []string{defaultRotateCredentialsSQL}
+		}
+		m := map[string]string{
+			"name":     req.Username,
+			"username": req.Username,
+			"password": req.Password.NewPassword,
+		}
+		if err := s.executeStatements(ctx, stmts, m); err != nil {
+			return dbplugin.UpdateUserResponse{}, err
+		}
+	}
+
+	if req.PublicKey != nil {
+		publicKey, err := encodePublicKey(req.PublicKey.NewPublicKey)
+		if err != nil {
+			return dbplugin.UpdateUserResponse{}, fmt.Errorf("invalid public key: %w", err)
+		}
+
+		stmts := req.PublicKey.Statements.Commands
+		if len(stmts) == 0 {
+			stmts = []string{defaultRotatePublicKeySQL}
+		}
+		m := map[string]string{
+			"name":       req.Username,
+			"username":   req.Username,
+			"public_key": publicKey,
+		}
+		if err := s.executeStatements(ctx, stmts, m); err != nil {
+			return dbplugin.UpdateUserResponse{}, err
+		}
+	}
+
+	if req.Expiration != nil && len(req.Expiration.Statements.Commands) > 0 {
+		m := map[string]string{
+			"name":       req.Username,
+			"username":   req.Username,
+			"expiration": req.Expiration.NewExpiration.UTC().Format("2006-01-02 15:04:05-0700"),
+		}
+		if err := s.executeStatements(ctx, req.Expiration.Statements.Commands, m); err != nil {
+			return dbplugin.UpdateUserResponse{}, err
+		}
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser drops the user, unless the role has revocation statements.
+func (s *Snowflake) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	stmts := req.Statements.Commands
+	if len(stmts) == 0 {
+		stmts = []string{defaultDeleteUserSQL}
+	}
+	m := map[string]string{
+		"name":     req.Username,
+		"username": req.Username,
+	}
+	if err := s.executeStatements(ctx, stmts, m); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// executeStatements runs the statements in a transaction. DDL statements are
+// committed by Snowflake as they run, so the transaction only guarantees that
+// statements are not run past the first failure.
+func (s *Snowflake) executeStatements(ctx context.Context, statements []string, m map[string]string) error {
+	db, err := s.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// encodePrivateKey parses a PEM encoded RSA private key, in PKCS #1 or PKCS #8
+// form, and returns it in the form expected by the driver: base64 URL encoded
+// PKCS #8.
+func encodePrivateKey(rawKey string) (string, error) {
+	block, _ := pem.Decode([]byte(rawKey))
+	if block == nil {
+		return "", errors.New("no PEM block found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return "", fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, ok := key.(*rsa.PrivateKey); !ok {
+		return "", errors.New("key is not an RSA key")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(der), nil
+}
+
+// encodePublicKey parses a PEM encoded RSA public key and returns it in the
+// form expected by Snowflake: its base64 encoded DER form, without the PEM
+// delimiters.
+func encodePublicKey(rawKey []byte) (string, error) {
+	block, _ := pem.Decode(rawKey)
+	if block == nil {
+		return "", errors.New("no PEM block found")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return "", fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := key.(*rsa.PublicKey); !ok {
+		return "", errors.New("key is not an RSA key")
+	}
+	return base64.StdEncoding.EncodeToString(block.Bytes), nil
+}
+
+// keyPairConnectionURL returns the connection URL authenticating with the
+// given base64 encoded private key.
+func keyPairConnectionURL(connURL, privateKey string) string {
+	params := url.Values{}
+	params.Set("authenticator", "SNOWFLAKE_JWT")
+	params.Set("privateKey", privateKey)
+
+	sep := "?"
+	if strings.Contains(connURL, "?") {
+		sep = "&"
+	}
+	return connURL + sep + params.Encode()
+}
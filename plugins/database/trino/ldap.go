@@ -0,0 +1,242 @@
+package trino
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	defaultUserAttr  = "uid"
+	defaultGroupAttr = "member"
+)
+
+var defaultObjectClasses = []string{"inetOrgPerson"}
+
+// ldapCreationStatement is the optional JSON creation statement of the LDAP
+// password store, listing the DNs of the groups the user is added to.
+type ldapCreationStatement struct {
+	Groups []string `json:"groups"`
+}
+
+// ldapStore manages the users of the directory read by the LDAP
+// authenticator. Each operation uses its own connection, as credentials are
+// issued far less often than an idle directory connection is dropped.
+type ldapStore struct {
+	url          string
+	startTLS     bool
+	tlsConfig    *tls.Config
+	bindDN       string
+	bindPassword string
+
+	userDN        string
+	userAttr      string
+	objectClasses []string
+	groupDN       string
+	groupAttr     string
+
+	// dial is replaced in tests
+	dial func() (ldap.Client, error)
+}
+
+func newLDAPStore(config *trinoConfig, tlsConfig *tls.Config) (*ldapStore, error) {
+	switch {
+	case config.LDAPURL == "":
+		return nil, errors.New("ldap_url is required when password_store is \"ldap\"")
+	case config.BindDN == "":
+		return nil, errors.New("bind_dn is required when password_store is \"ldap\"")
+	case config.BindPassword == "":
+		return nil, errors.New("bind_password is required when password_store is \"ldap\"")
+	case config.UserDN == "":
+		return nil, errors.New("user_dn is required when password_store is \"ldap\"")
+	}
+
+	s := &ldapStore{
+		url:           config.LDAPURL,
+		startTLS:      config.StartTLS,
+		tlsConfig:     tlsConfig,
+		bindDN:        config.BindDN,
+		bindPassword:  config.BindPassword,
+		userDN:        config.UserDN,
+		userAttr:      config.UserAttr,
+		objectClasses: config.ObjectClasses,
+		groupDN:       config.GroupDN,
+		groupAttr:     config.GroupAttr,
+	}
+	if s.userAttr == "" {
+		s.userAttr = defaultUserAttr
+	}
+	if len(s.objectClasses) == 0 {
+		s.objectClasses = defaultObjectClasses
+	}
+	if s.groupAttr == "" {
+		s.groupAttr = defaultGroupAttr
+	}
+	s.dial = s.dialURL
+
+	return s, nil
+}
+
+func (s *ldapStore) dialURL() (ldap.Client, error) {
+	conn, err := ldap.DialURL(s.url, ldap.DialWithTLSConfig(s.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(defaultTimeout)
+
+	if s.startTLS {
+		if err := conn.StartTLS(s.tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// connect dials the directory and binds with the configured credentials
+func (s *ldapStore) connect() (ldap.Client, error) {
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Bind(s.bindDN, s.bindPassword); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *ldapStore) verify(ctx context.Context) error {
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+func (s *ldapStore) createUser(ctx context.Context, username, password string, statements []string) error {
+	var groups []string
+	for _, stmt := range statements {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		var parsed ldapCreationStatement
+		if err := json.Unmarshal([]byte(stmt), &parsed); err != nil {
+			return fmt.Errorf("unable to parse creation statement: %w", err)
+		}
+		groups = append(groups, parsed.Groups...)
+	}
+
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	dn := s.userEntryDN(username)
+	add := ldap.NewAddRequest(dn, nil)
+	add.Attribute("objectClass", s.objectClasses)
+	add.Attribute(s.userAttr, []string{username})
+	if s.userAttr != "cn" {
+		add.Attribute("cn", []string{username})
+	}
+	add.Attribute("sn", []string{username})
+	add.Attribute("userPassword", []string{password})
+	if err := conn.Add(add); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		modify := ldap.NewModifyRequest(group, nil)
+		modify.Add(s.groupAttr, []string{dn})
+		if err := conn.Modify(modify); err != nil {
+			// Don't leave a user with partial group memberships behind
+			if delErr := s.delete(conn, dn); delErr != nil {
+				return fmt.Errorf("unable to add user to group %q: %v, and unable to delete user: %w", group, err, delErr)
+			}
+			return fmt.Errorf("unable to add user to group %q: %w", group, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ldapStore) setPassword(ctx context.Context, username, password string) error {
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	modify := ldap.NewModifyRequest(s.userEntryDN(username), nil)
+	modify.Replace("userPassword", []string{password})
+	return conn.Modify(modify)
+}
+
+func (s *ldapStore) deleteUser(ctx context.Context, username string) error {
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return s.delete(conn, s.userEntryDN(username))
+}
+
+// delete removes the user from its groups, then deletes its entry
+func (s *ldapStore) delete(conn ldap.Client, dn string) error {
+	if s.groupDN != "" {
+		search := ldap.NewSearchRequest(
+			s.groupDN,
+			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf("(%s=%s)", s.groupAttr, ldap.EscapeFilter(dn)),
+			[]string{"dn"},
+			nil,
+		)
+		result, err := conn.Search(search)
+		if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return err
+		}
+		if result != nil {
+			for _, group := range result.Entries {
+				modify := ldap.NewModifyRequest(group.DN, nil)
+				modify.Delete(s.groupAttr, []string{dn})
+				if err := conn.Modify(modify); err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchAttribute) {
+					return fmt.Errorf("unable to remove user from group %q: %w", group.DN, err)
+				}
+			}
+		}
+	}
+
+	err := conn.Del(ldap.NewDelRequest(dn, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return err
+	}
+	return nil
+}
+
+// userEntryDN returns the DN of the entry of the user
+func (s *ldapStore) userEntryDN(username string) string {
+	return fmt.Sprintf("%s=%s,%s", s.userAttr, escapeDNValue(username), s.userDN)
+}
+
+// escapeDNValue escapes an attribute value of a DN, as described in RFC 4514
+func escapeDNValue(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(value)-1 && r == ' ':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package trino
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// statementPollInterval is how long to wait before polling a statement which
+// is still queued, as the coordinator returns right away in that case
+var statementPollInterval = 100 * time.Millisecond
+
+// coordinator runs SQL statements, such as role grants, through the REST
+// statement API of the Trino coordinator.
+type coordinator struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+}
+
+func newCoordinator(config *trinoConfig, tlsConfig *tls.Config) *coordinator {
+	return &coordinator{
+		url:      strings.TrimSuffix(config.URL, "/"),
+		user:     config.Username,
+		password: config.Password,
+		client: &http.Client{
+			Timeout: defaultTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}
+}
+
+// statementResults is the part of the results of a statement the plugin
+// reads: the URI of the next results, if any, and the error of the statement.
+type statementResults struct {
+	NextURI string `json:"nextUri"`
+	Error   *struct {
+		Message   string `json:"message"`
+		ErrorName string `json:"errorName"`
+	} `json:"error"`
+}
+
+// execute submits the statement and follows its results until it finishes
+func (c *coordinator) execute(ctx context.Context, query string) error {
+	results, err := c.do(ctx, http.MethodPost, c.url+"/v1/statement", strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+
+	for results.NextURI != "" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// The results are fetched from the coordinator the plugin is
+		// configured with, which may be behind a load balancer, and the
+		// credentials are never sent wherever the URI points to
+		next, err := url.Parse(results.NextURI)
+		if err != nil {
+			return fmt.Errorf("invalid next results URI: %w", err)
+		}
+		results, err = c.do(ctx, http.MethodGet, c.url+next.RequestURI(), nil)
+		if err != nil {
+			return err
+		}
+		if results.NextURI == next.String() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(statementPollInterval):
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *coordinator) do(ctx context.Context, method, uri string, body io.Reader) (*statementResults, error) {
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Trino-User", c.user)
+	req.Header.Set("X-Trino-Source", "vault")
+	if c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %q: %s", resp.Status, bytes.TrimSpace(contents))
+	}
+
+	results := &statementResults{}
+	if err := json.Unmarshal(contents, results); err != nil {
+		return nil, fmt.Errorf("unable to parse statement results: %w", err)
+	}
+	if results.Error != nil {
+		if results.Error.ErrorName != "" {
+			return nil, fmt.Errorf("%s: %s", results.Error.ErrorName, results.Error.Message)
+		}
+		return nil, errors.New(results.Error.Message)
+	}
+	return results, nil
+}
+
+func (c *coordinator) close() {
+	c.client.CloseIdleConnections()
+}
@@ -0,0 +1,388 @@
+package trino
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil"
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	trinoTypeName = "trino"
+
+	passwordStoreLDAP = "ldap"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// validUsername matches the usernames that can be used in a DN and quoted in
+// SQL statements
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9._@-]+$`)
+
+var _ dbplugin.Database = (*Trino)(nil)
+
+// Trino is an implementation of Database interface managing the users of the
+// LDAP password authenticator of Trino, formerly PrestoSQL. Trino has no API to
+// manage passwords, so the plugin manages the users in the directory the
+// authenticator reads, and runs the SQL statements granting them privileges
+// through the REST statement API of the coordinator.
+type Trino struct {
+	// This protects the config from races while also allowing multiple
+	// threads to use the store simultaneously when it's not changing
+	mux sync.RWMutex
+
+	config           *trinoConfig
+	store            passwordStore
+	coordinator      *coordinator
+	usernameTemplate *template.StringTemplate
+}
+
+type trinoConfig struct {
+	PasswordStore string `mapstructure:"password_store"`
+
+	// Address of the coordinator and credentials of the user running the
+	// SQL statements
+	URL         string `mapstructure:"url"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	InsecureTLS bool   `mapstructure:"insecure_tls"`
+	CACert      string `mapstructure:"ca_cert"`
+
+	// PasswordFile is only decoded to reject configurations of the removed
+	// file password store
+	PasswordFile string `mapstructure:"password_file"`
+
+	LDAPURL       string   `mapstructure:"ldap_url"`
+	StartTLS      bool     `mapstructure:"starttls"`
+	BindDN        string   `mapstructure:"bind_dn"`
+	BindPassword  string   `mapstructure:"bind_password"`
+	UserDN        string   `mapstructure:"user_dn"`
+	UserAttr      string   `mapstructure:"user_attr"`
+	ObjectClasses []string `mapstructure:"object_classes"`
+	GroupDN       string   `mapstructure:"group_dn"`
+	GroupAttr     string   `mapstructure:"group_attr"`
+}
+
+// passwordStore is a store of the passwords checked by a password
+// authenticator of Trino
+type passwordStore interface {
+	// verify checks that the store can be written
+	verify(ctx context.Context) error
+	createUser(ctx context.Context, username, password string, statements []string) error
+	setPassword(ctx context.Context, username, password string) error
+	// deleteUser deletes the user, ignoring users that do not exist
+	deleteUser(ctx context.Context, username string) error
+}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := new()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func new() *Trino {
+	return &Trino{}
+}
+
+// Type returns the TypeName for this backend
+func (t *Trino) Type() (string, error) {
+	return trinoTypeName, nil
+}
+
+func (t *Trino) secretValues() map[string]string {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	if t.config == nil {
+		return nil
+	}
+	values := make(map[string]string)
+	if t.config.BindPassword != "" {
+		values[t.config.BindPassword] = "[bind_password]"
+	}
+	if t.config.Password != "" {
+		values[t.config.Password] = "[password]"
+	}
+	return values
+}
+
+// Initialize validates the configuration and creates the password store
+func (t *Trino) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	usernameTemplate, err := credsutil.UsernameTemplateFromConfig(req.Config)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	config := &trinoConfig{}
+	if err := mapstructure.WeakDecode(req.Config, config); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	var store passwordStore
+	switch {
+	case config.PasswordStore == "file" || config.PasswordFile != "":
+		err = errors.New("the file password store is not supported, as the plugin does not write files on its host: use the ldap password store")
+	case config.PasswordStore == passwordStoreLDAP, config.PasswordStore == "":
+		config.PasswordStore = passwordStoreLDAP
+		store, err = newLDAPStore(config, tlsConfig)
+	default:
+		err = fmt.Errorf("invalid password_store %q, must be %q", config.PasswordStore, passwordStoreLDAP)
+	}
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	var coord *coordinator
+	if config.URL != "" {
+		if config.Username == "" {
+			return dbplugin.InitializeResponse{}, errors.New("username is required with url")
+		}
+		coord = newCoordinator(config, tlsConfig)
+	}
+
+	if req.VerifyConnection {
+		if err := store.verify(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying password store: %w", err)
+		}
+		if config.URL != "" {
+			if err := verifyCoordinator(ctx, config.URL, tlsConfig); err != nil {
+				return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying coordinator: %w", err)
+			}
+		}
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.coordinator != nil {
+		t.coordinator.close()
+	}
+	t.config = config
+	t.store = store
+	t.coordinator = coord
+	t.usernameTemplate = usernameTemplate
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+// tlsConfig returns the TLS configuration used to connect to the coordinator
+// and the LDAP server
+func (c *trinoConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureTLS,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM([]byte(c.CACert)); !ok {
+			return nil, errors.New("unable to parse ca_cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// verifyCoordinator checks that the coordinator is up with its info endpoint
+func verifyCoordinator(ctx context.Context, url string, tlsConfig *tls.Config) error {
+	client := &http.Client{
+		Timeout: defaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(url, "/")+"/v1/info", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q", resp.Status)
+	}
+	return nil
+}
+
+// NewUser adds a user with the password to the password store, then runs the
+// SQL creation statements on the coordinator. Trino passwords do not expire,
+// so the user exists until it is revoked.
+func (t *Trino) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	// Don't let anyone write the config while we're using its store
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	if t.store == nil {
+		return dbplugin.NewUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	username, err := credsutil.GenerateUsername(
+		credsutil.DisplayName(req.UsernameConfig.DisplayName, 15),
+		credsutil.RoleName(req.UsernameConfig.RoleName, 15),
+		credsutil.MaxLength(100),
+		credsutil.Separator("-"),
+		credsutil.Template(t.usernameTemplate, req.UsernameConfig),
+	)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to generate username: %w", err)
+	}
+	if !validUsername.MatchString(username) {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("invalid username %q, must only contain letters, digits, '.', '_', '@' and '-'", username)
+	}
+
+	groupStatements, sqlStatements := splitStatements(req.Statements.Commands)
+	if len(sqlStatements) > 0 && t.coordinator == nil {
+		return dbplugin.NewUserResponse{}, errors.New("url is required to run SQL creation statements")
+	}
+
+	if err := t.store.createUser(ctx, username, req.Password, groupStatements); err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to create user %q: %w", username, err)
+	}
+
+	if err := t.executeStatements(ctx, sqlStatements, username); err != nil {
+		// Don't leave a user without its privileges behind
+		if delErr := t.store.deleteUser(ctx, username); delErr != nil {
+			return dbplugin.NewUserResponse{}, fmt.Errorf("%v, and unable to delete user %q: %w", err, username, delErr)
+		}
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+// UpdateUser changes the password of a user. Trino passwords do not expire,
+// so changing the expiration is a no-op.
+func (t *Trino) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing username")
+	}
+	if req.Password == nil {
+		return dbplugin.UpdateUserResponse{}, nil
+	}
+	if req.Password.NewPassword == "" {
+		return dbplugin.UpdateUserResponse{}, errors.New("missing password")
+	}
+
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	if t.store == nil {
+		return dbplugin.UpdateUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	if err := t.store.setPassword(ctx, req.Username, req.Password.NewPassword); err != nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("unable to change password of user %q: %w", req.Username, err)
+	}
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+// DeleteUser runs the SQL revocation statements on the coordinator, then
+// removes the user from the password store. Users that do not exist are
+// ignored, so deletion can be retried.
+func (t *Trino) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if req.Username == "" {
+		return dbplugin.DeleteUserResponse{}, errors.New("missing username")
+	}
+
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	if t.store == nil {
+		return dbplugin.DeleteUserResponse{}, errors.New("plugin has not been initialized")
+	}
+
+	if len(req.Statements.Commands) > 0 && t.coordinator == nil {
+		return dbplugin.DeleteUserResponse{}, errors.New("url is required to run SQL revocation statements")
+	}
+	if err := t.executeStatements(ctx, req.Statements.Commands, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	if err := t.store.deleteUser(ctx, req.Username); err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to delete user %q: %w", req.Username, err)
+	}
+
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+// Close releases the password store and the connections to the coordinator.
+// Stores do not hold connections between operations.
+func (t *Trino) Close() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.coordinator != nil {
+		t.coordinator.close()
+	}
+	t.store = nil
+	t.coordinator = nil
+	return nil
+}
+
+// splitStatements separates the JSON creation statements of the LDAP password
+// store from the SQL statements run on the coordinator
+func splitStatements(statements []string) (groups []string, sql []string) {
+	for _, stmt := range statements {
+		trimmed := strings.TrimSpace(stmt)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "{"):
+			groups = append(groups, trimmed)
+		default:
+			sql = append(sql, stmt)
+		}
+	}
+	return groups, sql
+}
+
+// executeStatements runs the SQL statements on the coordinator, one at a time
+// as the statement API does not support several statements per request
+func (t *Trino) executeStatements(ctx context.Context, statements []string, username string) error {
+	for _, stmt := range statements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			query = dbutil.QueryHelper(query, map[string]string{
+				"name":     username,
+				"username": username,
+			})
+			if err := t.coordinator.execute(ctx, query); err != nil {
+				return fmt.Errorf("failed to execute query: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+package trino
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+)
+
+// ldapConfig returns the configuration of the LDAP password store, with the
+// additional fields
+func ldapConfig(fields map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{
+		"password_store": "ldap",
+		"ldap_url":       "ldaps://ldap.example.com",
+		"bind_dn":        "cn=admin,dc=example,dc=com",
+		"bind_password":  "secret",
+		"user_dn":        "ou=users,dc=example,dc=com",
+		"group_dn":       "ou=groups,dc=example,dc=com",
+	}
+	for k, v := range fields {
+		config[k] = v
+	}
+	return config
+}
+
+// fakeCoordinator implements the info and statement endpoints of a Trino
+// coordinator, and records the statements it runs
+type fakeCoordinator struct {
+	*httptest.Server
+
+	l          sync.Mutex
+	statements []string
+	fail       string
+}
+
+func newFakeCoordinator() *fakeCoordinator {
+	c := &fakeCoordinator{}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.handle))
+	return c
+}
+
+func (c *fakeCoordinator) handle(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/info":
+		w.Write([]byte(`{"starting":false}`))
+		return
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/statement":
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/statement/queued/"):
+		// Statements finish on the first poll
+		w.Write([]byte(`{"id":"1","stats":{"state":"FINISHED"}}`))
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok || user != "vault" || password != "trino-secret" || r.Header.Get("X-Trino-User") != "vault" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	query, _ := ioutil.ReadAll(r.Body)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.fail != "" && strings.Contains(string(query), c.fail) {
+		w.Write([]byte(`{"id":"1","error":{"message":"Access Denied","errorName":"PERMISSION_DENIED"}}`))
+		return
+	}
+	c.statements = append(c.statements, string(query))
+	// The next URI points to the address the coordinator advertises, which
+	// the plugin must not follow
+	w.Write([]byte(`{"id":"1","nextUri":"http://trino.internal:8080/v1/statement/queued/1/x/1"}`))
+}
+
+func (c *fakeCoordinator) reset() []string {
+	c.l.Lock()
+	defer c.l.Unlock()
+	statements := c.statements
+	c.statements = nil
+	return statements
+}
+
+func TestTrino_Initialize(t *testing.T) {
+	coordinator := newFakeCoordinator()
+	defer coordinator.Close()
+
+	tests := map[string]struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		"ldap": {
+			config: ldapConfig(nil),
+			valid:  true,
+		},
+		"ldap with coordinator": {
+			config: ldapConfig(map[string]interface{}{"url": coordinator.URL, "username": "vault", "password": "trino-secret"}),
+			valid:  true,
+		},
+		"file": {
+			config: map[string]interface{}{"password_store": "file", "password_file": "/etc/trino/password.db"},
+		},
+		"password_file": {
+			config: ldapConfig(map[string]interface{}{"password_file": "/etc/trino/password.db"}),
+		},
+		"missing username": {
+			config: ldapConfig(map[string]interface{}{"url": coordinator.URL}),
+		},
+		"unreachable coordinator": {
+			config: ldapConfig(map[string]interface{}{"url": coordinator.URL + "/missing", "username": "vault"}),
+		},
+		"invalid ca_cert": {
+			config: ldapConfig(map[string]interface{}{"ca_cert": "not a certificate"}),
+		},
+		"missing bind_dn": {
+			config: map[string]interface{}{"password_store": "ldap", "ldap_url": "ldap://localhost", "bind_password": "secret", "user_dn": "ou=users,dc=example,dc=com"},
+		},
+		"invalid password_store": {
+			config: map[string]interface{}{"password_store": "kerberos"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := new()
+			// The LDAP server is not verified, as there is none
+			resp, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+				Config: tc.config,
+			})
+			if err == nil && tc.config["url"] != nil {
+				err = verifyCoordinator(context.Background(), db.config.URL, nil)
+			}
+			if !tc.valid {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(resp.Config, tc.config) {
+				t.Fatalf("Actual config: %#v\nExpected config: %#v", resp.Config, tc.config)
+			}
+		})
+	}
+}
+
+func TestTrino_Statements(t *testing.T) {
+	coordinator := newFakeCoordinator()
+	defer coordinator.Close()
+
+	fake := newFakeLDAP()
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: ldapConfig(map[string]interface{}{
+			"url":      coordinator.URL,
+			"username": "vault",
+			"password": "trino-secret",
+		}),
+	})
+	defer dbtesting.AssertClose(t, db)
+	db.store.(*ldapStore).dial = func() (ldap.Client, error) {
+		return fake, nil
+	}
+
+	if _, ok := db.secretValues()["trino-secret"]; !ok {
+		t.Fatalf("coordinator password is not redacted from errors")
+	}
+
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "my-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{
+				`{"groups":["cn=readers,ou=groups,dc=example,dc=com"]}`,
+				`GRANT analyst TO USER "{{name}}"; GRANT auditor TO USER "{{username}}";`,
+			},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	expected := []string{
+		`GRANT analyst TO USER "` + resp.Username + `"`,
+		`GRANT auditor TO USER "` + resp.Username + `"`,
+	}
+	if statements := coordinator.reset(); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("Actual statements: %#v\nExpected statements: %#v", statements, expected)
+	}
+	dn := "uid=" + resp.Username + ",ou=users,dc=example,dc=com"
+	if _, ok := fake.entries[dn]; !ok {
+		t.Fatalf("user entry was not created: %#v", fake.entries)
+	}
+	if !reflect.DeepEqual(fake.entries["cn=readers,ou=groups,dc=example,dc=com"]["member"], []string{dn}) {
+		t.Fatalf("user was not added to the group: %#v", fake.entries)
+	}
+
+	// Users are removed if their statements fail
+	coordinator.fail = "admin"
+	_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "other-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`GRANT analyst TO USER "{{name}}"; GRANT admin TO USER "{{name}}"`},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	if err == nil || !strings.Contains(err.Error(), "PERMISSION_DENIED") {
+		t.Fatalf("expected the statement error, got %v", err)
+	}
+	coordinator.reset()
+	if len(fake.entries) != 3 {
+		t.Fatalf("partial user was not removed: %#v", fake.entries)
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+		Statements: dbplugin.Statements{
+			Commands: []string{`REVOKE analyst FROM USER "{{name}}"`},
+		},
+	})
+	expected = []string{`REVOKE analyst FROM USER "` + resp.Username + `"`}
+	if statements := coordinator.reset(); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("Actual statements: %#v\nExpected statements: %#v", statements, expected)
+	}
+	if _, ok := fake.entries[dn]; ok {
+		t.Fatalf("user was not deleted")
+	}
+}
+
+func TestTrino_StatementsWithoutCoordinator(t *testing.T) {
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: ldapConfig(nil),
+	})
+	defer dbtesting.AssertClose(t, db)
+
+	_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "my-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`GRANT analyst TO USER "{{name}}"`},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+// fakeLDAP implements the operations used by the LDAP password store
+type fakeLDAP struct {
+	ldap.Client
+
+	binds   []string
+	entries map[string]map[string][]string
+}
+
+func newFakeLDAP() *fakeLDAP {
+	return &fakeLDAP{
+		entries: map[string]map[string][]string{
+			"cn=analysts,ou=groups,dc=example,dc=com": {"member": nil},
+			"cn=readers,ou=groups,dc=example,dc=com":  {"member": nil},
+		},
+	}
+}
+
+func (f *fakeLDAP) Bind(username, password string) error {
+	if username != "cn=admin,dc=example,dc=com" || password != "secret" {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+	}
+	f.binds = append(f.binds, username)
+	return nil
+}
+
+func (f *fakeLDAP) Close() {}
+
+func (f *fakeLDAP) Add(req *ldap.AddRequest) error {
+	if _, ok := f.entries[req.DN]; ok {
+		return ldap.NewError(ldap.LDAPResultEntryAlreadyExists, nil)
+	}
+	entry := make(map[string][]string)
+	for _, attr := range req.Attributes {
+		entry[attr.Type] = attr.Vals
+	}
+	f.entries[req.DN] = entry
+	return nil
+}
+
+func (f *fakeLDAP) Del(req *ldap.DelRequest) error {
+	if _, ok := f.entries[req.DN]; !ok {
+		return ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+	delete(f.entries, req.DN)
+	return nil
+}
+
+func (f *fakeLDAP) Modify(req *ldap.ModifyRequest) error {
+	entry, ok := f.entries[req.DN]
+	if !ok {
+		return ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+	for _, change := range req.Changes {
+		attr := change.Modification
+		switch change.Operation {
+		case ldap.AddAttribute:
+			entry[attr.Type] = append(entry[attr.Type], attr.Vals...)
+		case ldap.ReplaceAttribute:
+			entry[attr.Type] = attr.Vals
+		case ldap.DeleteAttribute:
+			var kept []string
+			for _, v := range entry[attr.Type] {
+				if v != attr.Vals[0] {
+					kept = append(kept, v)
+				}
+			}
+			entry[attr.Type] = kept
+		}
+	}
+	return nil
+}
+
+// Search only supports the (member=<dn>) filter of the store
+func (f *fakeLDAP) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	member := strings.TrimSuffix(strings.TrimPrefix(req.Filter, "(member="), ")")
+	result := &ldap.SearchResult{}
+	for dn, entry := range f.entries {
+		if !strings.HasSuffix(dn, req.BaseDN) {
+			continue
+		}
+		for _, v := range entry["member"] {
+			if v == member {
+				result.Entries = append(result.Entries, ldap.NewEntry(dn, nil))
+			}
+		}
+	}
+	return result, nil
+}
+
+func TestTrino_LDAPStore(t *testing.T) {
+	fake := newFakeLDAP()
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config: ldapConfig(nil),
+	})
+	defer dbtesting.AssertClose(t, db)
+	db.store.(*ldapStore).dial = func() (ldap.Client, error) {
+		return fake, nil
+	}
+
+	if _, ok := db.secretValues()["secret"]; !ok {
+		t.Fatalf("bind password is not redacted from errors")
+	}
+
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "my-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`{"groups":["cn=analysts,ou=groups,dc=example,dc=com","cn=readers,ou=groups,dc=example,dc=com"]}`},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	dn := "uid=" + resp.Username + ",ou=users,dc=example,dc=com"
+	entry, ok := fake.entries[dn]
+	if !ok {
+		t.Fatalf("user entry was not created: %#v", fake.entries)
+	}
+	if !reflect.DeepEqual(entry["objectClass"], []string{"inetOrgPerson"}) || !reflect.DeepEqual(entry["userPassword"], []string{"Passw0rd"}) {
+		t.Fatalf("bad user entry: %#v", entry)
+	}
+	for _, group := range []string{"cn=analysts,ou=groups,dc=example,dc=com", "cn=readers,ou=groups,dc=example,dc=com"} {
+		if !reflect.DeepEqual(fake.entries[group]["member"], []string{dn}) {
+			t.Fatalf("user was not added to group %q: %#v", group, fake.entries[group])
+		}
+	}
+
+	// Users are removed if they can't be added to all groups
+	_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+			RoleName:    "other-role",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`{"groups":["cn=readers,ou=groups,dc=example,dc=com","cn=missing,ou=groups,dc=example,dc=com"]}`},
+		},
+		Password:   "Passw0rd",
+		Expiration: time.Now().Add(time.Hour),
+	})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(fake.entries) != 3 || len(fake.entries["cn=readers,ou=groups,dc=example,dc=com"]["member"]) != 1 {
+		t.Fatalf("partial user was not removed: %#v", fake.entries)
+	}
+
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: resp.Username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: "n3wPassw0rd",
+		},
+	})
+	if !reflect.DeepEqual(fake.entries[dn]["userPassword"], []string{"n3wPassw0rd"}) {
+		t.Fatalf("password was not changed: %#v", fake.entries[dn])
+	}
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+	if _, ok := fake.entries[dn]; ok {
+		t.Fatalf("user was not deleted")
+	}
+	for _, group := range []string{"cn=analysts,ou=groups,dc=example,dc=com", "cn=readers,ou=groups,dc=example,dc=com"} {
+		if len(fake.entries[group]["member"]) != 0 {
+			t.Fatalf("user was not removed from group %q: %#v", group, fake.entries[group])
+		}
+	}
+
+	// Deletion can be retried
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+}
+
+func TestEscapeDNValue(t *testing.T) {
+	tests := map[string]string{
+		"v-token-role-abc": "v-token-role-abc",
+		"a,b=c":            `a\,b\=c`,
+		"#user ":           `\#user\ `,
+	}
+	for value, expected := range tests {
+		if actual := escapeDNValue(value); actual != expected {
+			t.Fatalf("escaping %q: expected %q, got %q", value, expected, actual)
+		}
+	}
+}
@@ -102,6 +102,14 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			PublicKey: &ChangePublicKey{
+				NewPublicKey: []byte("-----BEGIN PUBLIC KEY-----"),
+				Statements: Statements{
+					Commands: []string{
+						"statement",
+					},
+				},
+			},
 		}
 
 		protoReq, err := updateUserReqToProto(req)
@@ -171,6 +179,14 @@ func TestConversionsHaveAllFields(t *testing.T) {
 					},
 				},
 			},
+			PublicKey: &proto.ChangePublicKey{
+				NewPublicKey: []byte("-----BEGIN PUBLIC KEY-----"),
+				Statements: &proto.Statements{
+					Commands: []string{
+						"statement",
+					},
+				},
+			},
 		}
 
 		protoReq, err := getUpdateUserRequest(req)
@@ -125,6 +125,11 @@ type UpdateUserRequest struct {
 	// Expiration indicates the new expiration date to change to.
 	// If nil, no change is requested.
 	Expiration *ChangeExpiration
+
+	// PublicKey indicates the new public key the user authenticates with,
+	// for databases supporting key pair authentication.
+	// If nil, no change is requested.
+	PublicKey *ChangePublicKey
 }
 
 // ChangePassword of a given user
@@ -137,6 +142,16 @@ type ChangePassword struct {
 	Statements Statements
 }
 
+// ChangePublicKey of a given user
+type ChangePublicKey struct {
+	// NewPublicKey for the user, PEM encoded
+	NewPublicKey []byte
+
+	// Statements is an ordered list of commands to run within the database
+	// when changing the user's public key.
+	Statements Statements
+}
+
 // ChangeExpiration of a given user
 type ChangeExpiration struct {
 	// NewExpiration of the user
@@ -0,0 +1,122 @@
+package dbplugin
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind classifies an error returned by a database plugin so that Vault
+// can decide how to handle it, such as whether to retry the operation or to
+// return a 4xx status code to the client.
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown is the kind of unclassified errors.
+	ErrorKindUnknown ErrorKind = iota
+
+	// ErrorKindAuth indicates that the database rejected the credentials the
+	// plugin connects with.
+	ErrorKindAuth
+
+	// ErrorKindNotFound indicates that the user or object the operation
+	// targets does not exist.
+	ErrorKindNotFound
+
+	// ErrorKindTransient indicates a temporary condition, such as a deadlock
+	// or a dropped connection, so that retrying the operation may succeed.
+	ErrorKindTransient
+
+	// ErrorKindPermanent indicates that the operation cannot succeed as
+	// requested, for instance because of invalid statements, and must not be
+	// retried.
+	ErrorKindPermanent
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindAuth:
+		return "auth"
+	case ErrorKindNotFound:
+		return "not found"
+	case ErrorKindTransient:
+		return "transient"
+	case ErrorKindPermanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is an error classified with an ErrorKind. The kind is carried across
+// the gRPC boundary as a status code, so plugins can return an Error from any
+// of their Database methods and Vault will see the same kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// NewError classifies err with the given kind. It returns nil if err is nil.
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf returns the kind of the first Error in err's chain, or
+// ErrorKindUnknown if there is none.
+func ErrorKindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ErrorKindUnknown
+}
+
+// errorCode returns the gRPC status code used to send err to the host.
+func errorCode(err error) codes.Code {
+	switch ErrorKindOf(err) {
+	case ErrorKindAuth:
+		return codes.Unauthenticated
+	case ErrorKindNotFound:
+		return codes.NotFound
+	case ErrorKindTransient:
+		return codes.Unavailable
+	case ErrorKindPermanent:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
+// errorFromStatus classifies an error received from a plugin according to
+// its gRPC status code.
+func errorFromStatus(err error) error {
+	var kind ErrorKind
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		kind = ErrorKindAuth
+	case codes.NotFound:
+		kind = ErrorKindNotFound
+	case codes.Unavailable:
+		kind = ErrorKindTransient
+	case codes.FailedPrecondition, codes.InvalidArgument:
+		kind = ErrorKindPermanent
+	default:
+		return err
+	}
+	return NewError(kind, err)
+}
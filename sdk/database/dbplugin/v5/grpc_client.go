@@ -9,6 +9,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5/proto"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"google.golang.org/grpc/metadata"
 )
 
 var (
@@ -20,6 +21,19 @@ var (
 type gRPCClient struct {
 	client  proto.DatabaseClient
 	doneCtx context.Context
+
+	// multiplexID identifies the connection this client serves when the
+	// plugin process is shared by several connections
+	multiplexID string
+}
+
+// withMultiplexID attaches the connection ID to an outgoing call so that a
+// multiplexed plugin can route it to the matching database instance.
+func (c gRPCClient) withMultiplexID(ctx context.Context) context.Context {
+	if c.multiplexID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, multiplexIDKey, c.multiplexID)
 }
 
 func (c gRPCClient) Initialize(ctx context.Context, req InitializeRequest) (InitializeResponse, error) {
@@ -28,9 +42,9 @@ func (c gRPCClient) Initialize(ctx context.Context, req InitializeRequest) (Init
 		return InitializeResponse{}, err
 	}
 
-	rpcResp, err := c.client.Initialize(ctx, rpcReq)
+	rpcResp, err := c.client.Initialize(c.withMultiplexID(ctx), rpcReq)
 	if err != nil {
-		return InitializeResponse{}, fmt.Errorf("unable to initialize: %s", err.Error())
+		return InitializeResponse{}, fmt.Errorf("unable to initialize: %w", errorFromStatus(err))
 	}
 
 	return initRespFromProto(rpcResp)
@@ -69,12 +83,12 @@ func (c gRPCClient) NewUser(ctx context.Context, req NewUserRequest) (NewUserRes
 		return NewUserResponse{}, err
 	}
 
-	rpcResp, err := c.client.NewUser(ctx, rpcReq)
+	rpcResp, err := c.client.NewUser(c.withMultiplexID(ctx), rpcReq)
 	if err != nil {
 		if c.doneCtx.Err() != nil {
 			return NewUserResponse{}, ErrPluginShutdown
 		}
-		return NewUserResponse{}, fmt.Errorf("unable to create new user: %w", err)
+		return NewUserResponse{}, fmt.Errorf("unable to create new user: %w", errorFromStatus(err))
 	}
 
 	return newUserRespFromProto(rpcResp)
@@ -120,13 +134,13 @@ func (c gRPCClient) UpdateUser(ctx context.Context, req UpdateUserRequest) (Upda
 		return UpdateUserResponse{}, err
 	}
 
-	rpcResp, err := c.client.UpdateUser(ctx, rpcReq)
+	rpcResp, err := c.client.UpdateUser(c.withMultiplexID(ctx), rpcReq)
 	if err != nil {
 		if c.doneCtx.Err() != nil {
 			return UpdateUserResponse{}, ErrPluginShutdown
 		}
 
-		return UpdateUserResponse{}, fmt.Errorf("unable to update user: %w", err)
+		return UpdateUserResponse{}, fmt.Errorf("unable to update user: %w", errorFromStatus(err))
 	}
 
 	return updateUserRespFromProto(rpcResp)
@@ -137,8 +151,7 @@ func updateUserReqToProto(req UpdateUserRequest) (*proto.UpdateUserRequest, erro
 		return nil, fmt.Errorf("missing username")
 	}
 
-	if (req.Password == nil || req.Password.NewPassword == "") &&
-		(req.Expiration == nil || req.Expiration.NewExpiration.IsZero()) {
+	if !hasChange(req) {
 		return nil, fmt.Errorf("missing changes")
 	}
 
@@ -157,10 +170,21 @@ func updateUserReqToProto(req UpdateUserRequest) (*proto.UpdateUserRequest, erro
 		}
 	}
 
+	var publicKey *proto.ChangePublicKey
+	if req.PublicKey != nil && len(req.PublicKey.NewPublicKey) > 0 {
+		publicKey = &proto.ChangePublicKey{
+			NewPublicKey: req.PublicKey.NewPublicKey,
+			Statements: &proto.Statements{
+				Commands: req.PublicKey.Statements.Commands,
+			},
+		}
+	}
+
 	rpcReq := &proto.UpdateUserRequest{
 		Username:   req.Username,
 		Password:   password,
 		Expiration: expiration,
+		PublicKey:  publicKey,
 	}
 	return rpcReq, nil
 }
@@ -195,12 +219,12 @@ func (c gRPCClient) DeleteUser(ctx context.Context, req DeleteUserRequest) (Dele
 		return DeleteUserResponse{}, err
 	}
 
-	rpcResp, err := c.client.DeleteUser(ctx, rpcReq)
+	rpcResp, err := c.client.DeleteUser(c.withMultiplexID(ctx), rpcReq)
 	if err != nil {
 		if c.doneCtx.Err() != nil {
 			return DeleteUserResponse{}, ErrPluginShutdown
 		}
-		return DeleteUserResponse{}, fmt.Errorf("unable to update user: %w", err)
+		return DeleteUserResponse{}, fmt.Errorf("unable to update user: %w", errorFromStatus(err))
 	}
 
 	return deleteUserRespFromProto(rpcResp)
@@ -229,7 +253,7 @@ func (c gRPCClient) Type() (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	typeResp, err := c.client.Type(ctx, &proto.Empty{})
+	typeResp, err := c.client.Type(c.withMultiplexID(ctx), &proto.Empty{})
 	if err != nil {
 		if c.doneCtx.Err() != nil {
 			return "", ErrPluginShutdown
@@ -243,7 +267,7 @@ func (c gRPCClient) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 
-	_, err := c.client.Close(ctx, &proto.Empty{})
+	_, err := c.client.Close(c.withMultiplexID(ctx), &proto.Empty{})
 	if err != nil {
 		if c.doneCtx.Err() != nil {
 			return ErrPluginShutdown
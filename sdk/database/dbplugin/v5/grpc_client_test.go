@@ -9,6 +9,8 @@ import (
 
 	"github.com/hashicorp/vault/sdk/database/dbplugin/v5/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestGRPCClient_Initialize(t *testing.T) {
@@ -329,6 +331,16 @@ func TestGRPCClient_DeleteUser(t *testing.T) {
 			doneCtx:   runningCtx,
 			assertErr: assertErrNotNil,
 		},
+		"classified database error": {
+			client: fakeClient{
+				deleteUserErr: status.Error(codes.NotFound, "user does not exist"),
+			},
+			req: DeleteUserRequest{
+				Username: "user",
+			},
+			doneCtx:   runningCtx,
+			assertErr: assertErrKind(ErrorKindNotFound),
+		},
 		"plugin shut down": {
 			client: fakeClient{
 				deleteUserErr: errors.New("delete user error'"),
@@ -491,6 +503,15 @@ func assertErrEquals(expectedErr error) errorAssertion {
 	}
 }
 
+func assertErrKind(expectedKind ErrorKind) errorAssertion {
+	return func(t *testing.T, err error) {
+		t.Helper()
+		if actual := ErrorKindOf(err); actual != expectedKind {
+			t.Fatalf("Actual kind: %s Expected kind: %s", actual, expectedKind)
+		}
+	}
+}
+
 var _ proto.DatabaseClient = fakeClient{}
 
 type fakeClient struct {
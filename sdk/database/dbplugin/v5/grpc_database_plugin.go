@@ -21,6 +21,10 @@ var handshakeConfig = plugin.HandshakeConfig{
 type GRPCDatabasePlugin struct {
 	Impl Database
 
+	// FactoryFunc is used instead of Impl by plugins that multiplex several
+	// database connections over a single process
+	FactoryFunc Factory
+
 	// Embeding this will disable the netRPC protocol
 	plugin.NetRPCUnsupportedPlugin
 }
@@ -29,7 +33,11 @@ var _ plugin.Plugin = &GRPCDatabasePlugin{}
 var _ plugin.GRPCPlugin = &GRPCDatabasePlugin{}
 
 func (d GRPCDatabasePlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
-	proto.RegisterDatabaseServer(s, gRPCServer{impl: d.Impl})
+	proto.RegisterDatabaseServer(s, &gRPCServer{
+		impl:        d.Impl,
+		factoryFunc: d.FactoryFunc,
+		instances:   make(map[string]Database),
+	})
 	return nil
 }
 
@@ -3,6 +3,7 @@ package dbplugin
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
@@ -11,14 +12,68 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-var _ proto.DatabaseServer = gRPCServer{}
+var _ proto.DatabaseServer = &gRPCServer{}
 
 type gRPCServer struct {
 	impl Database
+
+	// factoryFunc creates a Database for each connection served by a
+	// multiplexed plugin, keyed by the ID the host sends with every call.
+	factoryFunc Factory
+
+	sync.RWMutex
+	instances map[string]Database
+}
+
+// getDatabase returns the Database that serves the connection the call was
+// made for, creating it if this is the first call for that connection.
+func (g *gRPCServer) getDatabase(ctx context.Context) (Database, error) {
+	if g.factoryFunc == nil {
+		if g.impl == nil {
+			return nil, status.Errorf(codes.Unavailable, "no database instance available")
+		}
+		return g.impl, nil
+	}
+
+	id, err := multiplexIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	g.RLock()
+	db, ok := g.instances[id]
+	g.RUnlock()
+	if ok {
+		return db, nil
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	if db, ok := g.instances[id]; ok {
+		return db, nil
+	}
+
+	raw, err := g.factoryFunc()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to create database instance: %s", err)
+	}
+	db, ok = raw.(Database)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "factory returned unsupported database type: %T", raw)
+	}
+	g.instances[id] = db
+
+	return db, nil
 }
 
 // Initialize the database plugin
-func (g gRPCServer) Initialize(ctx context.Context, request *proto.InitializeRequest) (*proto.InitializeResponse, error) {
+func (g *gRPCServer) Initialize(ctx context.Context, request *proto.InitializeRequest) (*proto.InitializeResponse, error) {
+	impl, err := g.getDatabase(ctx)
+	if err != nil {
+		return &proto.InitializeResponse{}, err
+	}
+
 	rawConfig := structToMap(request.ConfigData)
 
 	dbReq := InitializeRequest{
@@ -26,9 +81,9 @@ func (g gRPCServer) Initialize(ctx context.Context, request *proto.InitializeReq
 		VerifyConnection: request.VerifyConnection,
 	}
 
-	dbResp, err := g.impl.Initialize(ctx, dbReq)
+	dbResp, err := impl.Initialize(ctx, dbReq)
 	if err != nil {
-		return &proto.InitializeResponse{}, status.Errorf(codes.Internal, "failed to initialize: %s", err)
+		return &proto.InitializeResponse{}, status.Errorf(errorCode(err), "failed to initialize: %s", err)
 	}
 
 	newConfig, err := mapToStruct(dbResp.Config)
@@ -43,7 +98,7 @@ func (g gRPCServer) Initialize(ctx context.Context, request *proto.InitializeReq
 	return resp, nil
 }
 
-func (g gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*proto.NewUserResponse, error) {
+func (g *gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*proto.NewUserResponse, error) {
 	if req.GetUsernameConfig() == nil {
 		return &proto.NewUserResponse{}, status.Errorf(codes.InvalidArgument, "missing username config")
 	}
@@ -69,9 +124,14 @@ func (g gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*pr
 		RollbackStatements: getStatementsFromProto(req.GetRollbackStatements()),
 	}
 
-	dbResp, err := g.impl.NewUser(ctx, dbReq)
+	impl, err := g.getDatabase(ctx)
 	if err != nil {
-		return &proto.NewUserResponse{}, status.Errorf(codes.Internal, "unable to create new user: %s", err)
+		return &proto.NewUserResponse{}, err
+	}
+
+	dbResp, err := impl.NewUser(ctx, dbReq)
+	if err != nil {
+		return &proto.NewUserResponse{}, status.Errorf(errorCode(err), "unable to create new user: %s", err)
 	}
 
 	resp := &proto.NewUserResponse{
@@ -80,7 +140,7 @@ func (g gRPCServer) NewUser(ctx context.Context, req *proto.NewUserRequest) (*pr
 	return resp, nil
 }
 
-func (g gRPCServer) UpdateUser(ctx context.Context, req *proto.UpdateUserRequest) (*proto.UpdateUserResponse, error) {
+func (g *gRPCServer) UpdateUser(ctx context.Context, req *proto.UpdateUserRequest) (*proto.UpdateUserResponse, error) {
 	if req.GetUsername() == "" {
 		return &proto.UpdateUserResponse{}, status.Errorf(codes.InvalidArgument, "no username provided")
 	}
@@ -90,9 +150,14 @@ func (g gRPCServer) UpdateUser(ctx context.Context, req *proto.UpdateUserRequest
 		return &proto.UpdateUserResponse{}, status.Errorf(codes.InvalidArgument, err.Error())
 	}
 
-	_, err = g.impl.UpdateUser(ctx, dbReq)
+	impl, err := g.getDatabase(ctx)
+	if err != nil {
+		return &proto.UpdateUserResponse{}, err
+	}
+
+	_, err = impl.UpdateUser(ctx, dbReq)
 	if err != nil {
-		return &proto.UpdateUserResponse{}, status.Errorf(codes.Internal, "unable to update user: %s", err)
+		return &proto.UpdateUserResponse{}, status.Errorf(errorCode(err), "unable to update user: %s", err)
 	}
 	return &proto.UpdateUserResponse{}, nil
 }
@@ -119,10 +184,19 @@ func getUpdateUserRequest(req *proto.UpdateUserRequest) (UpdateUserRequest, erro
 		}
 	}
 
+	var publicKey *ChangePublicKey
+	if len(req.GetPublicKey().GetNewPublicKey()) > 0 {
+		publicKey = &ChangePublicKey{
+			NewPublicKey: req.GetPublicKey().GetNewPublicKey(),
+			Statements:   getStatementsFromProto(req.GetPublicKey().GetStatements()),
+		}
+	}
+
 	dbReq := UpdateUserRequest{
 		Username:   req.GetUsername(),
 		Password:   password,
 		Expiration: expiration,
+		PublicKey:  publicKey,
 	}
 
 	if !hasChange(dbReq) {
@@ -139,10 +213,13 @@ func hasChange(dbReq UpdateUserRequest) bool {
 	if dbReq.Expiration != nil && !dbReq.Expiration.NewExpiration.IsZero() {
 		return true
 	}
+	if dbReq.PublicKey != nil && len(dbReq.PublicKey.NewPublicKey) > 0 {
+		return true
+	}
 	return false
 }
 
-func (g gRPCServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
+func (g *gRPCServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest) (*proto.DeleteUserResponse, error) {
 	if req.GetUsername() == "" {
 		return &proto.DeleteUserResponse{}, status.Errorf(codes.InvalidArgument, "no username provided")
 	}
@@ -151,15 +228,25 @@ func (g gRPCServer) DeleteUser(ctx context.Context, req *proto.DeleteUserRequest
 		Statements: getStatementsFromProto(req.GetStatements()),
 	}
 
-	_, err := g.impl.DeleteUser(ctx, dbReq)
+	impl, err := g.getDatabase(ctx)
+	if err != nil {
+		return &proto.DeleteUserResponse{}, err
+	}
+
+	_, err = impl.DeleteUser(ctx, dbReq)
 	if err != nil {
-		return &proto.DeleteUserResponse{}, status.Errorf(codes.Internal, "unable to delete user: %s", err)
+		return &proto.DeleteUserResponse{}, status.Errorf(errorCode(err), "unable to delete user: %s", err)
 	}
 	return &proto.DeleteUserResponse{}, nil
 }
 
-func (g gRPCServer) Type(ctx context.Context, _ *proto.Empty) (*proto.TypeResponse, error) {
-	t, err := g.impl.Type()
+func (g *gRPCServer) Type(ctx context.Context, _ *proto.Empty) (*proto.TypeResponse, error) {
+	impl, err := g.getDatabase(ctx)
+	if err != nil {
+		return &proto.TypeResponse{}, err
+	}
+
+	t, err := impl.Type()
 	if err != nil {
 		return &proto.TypeResponse{}, status.Errorf(codes.Internal, "unable to retrieve type: %s", err)
 	}
@@ -170,8 +257,26 @@ func (g gRPCServer) Type(ctx context.Context, _ *proto.Empty) (*proto.TypeRespon
 	return resp, nil
 }
 
-func (g gRPCServer) Close(ctx context.Context, _ *proto.Empty) (*proto.Empty, error) {
-	err := g.impl.Close()
+func (g *gRPCServer) Close(ctx context.Context, _ *proto.Empty) (*proto.Empty, error) {
+	impl, err := g.getDatabase(ctx)
+	if err != nil {
+		return &proto.Empty{}, err
+	}
+
+	// Connections to a multiplexed plugin are closed individually, and the
+	// plugin keeps serving any others
+	if g.factoryFunc != nil {
+		id, err := multiplexIDFromContext(ctx)
+		if err != nil {
+			return &proto.Empty{}, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+
+		g.Lock()
+		delete(g.instances, id)
+		g.Unlock()
+	}
+
+	err = impl.Close()
 	if err != nil {
 		return &proto.Empty{}, status.Errorf(codes.Internal, "unable to close database plugin: %s", err)
 	}
@@ -419,6 +419,17 @@ func TestGRPCServer_DeleteUser(t *testing.T) {
 			expectErr:    true,
 			expectCode:   codes.Internal,
 		},
+		"classified database error": {
+			db: fakeDatabase{
+				deleteUserErr: NewError(ErrorKindTransient, errors.New("connection reset")),
+			},
+			req: &proto.DeleteUserRequest{
+				Username: "someuser",
+			},
+			expectedResp: &proto.DeleteUserResponse{},
+			expectErr:    true,
+			expectCode:   codes.Unavailable,
+		},
 		"happy path": {
 			db: fakeDatabase{},
 			req: &proto.DeleteUserRequest{
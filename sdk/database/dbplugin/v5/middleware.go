@@ -2,7 +2,9 @@ package dbplugin
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
+	"net"
 	"net/url"
 	"strings"
 	"time"
@@ -272,3 +274,257 @@ func (mw DatabaseErrorSanitizerMiddleware) sanitize(err error) error {
 	}
 	return err
 }
+
+// ///////////////////////////////////////////////////
+// Retry Middleware Domain
+// ///////////////////////////////////////////////////
+
+const (
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
+)
+
+var _ Database = DatabaseRetryMiddleware{}
+
+// RetryConfig controls how DatabaseRetryMiddleware retries failed operations.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of times an operation is retried. Zero
+	// disables retries.
+	MaxRetries int
+
+	// InitialBackoff is the time waited before the first retry. It is doubled
+	// for every following retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// IsTransient reports whether an error is worth retrying. It defaults to
+	// IsTransientError.
+	IsTransient func(error) bool
+}
+
+// DatabaseRetryMiddleware wraps an implementation of Databases and retries
+// NewUser, UpdateUser and DeleteUser when they fail with a transient error,
+// such as a deadlock or a reset connection. Statements are executed within a
+// transaction by the builtin plugins, so a failed attempt leaves nothing
+// behind that would make the retry fail.
+type DatabaseRetryMiddleware struct {
+	next   Database
+	config RetryConfig
+}
+
+func NewDatabaseRetryMiddleware(next Database, config RetryConfig) DatabaseRetryMiddleware {
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+	if config.IsTransient == nil {
+		config.IsTransient = IsTransientError
+	}
+
+	return DatabaseRetryMiddleware{
+		next:   next,
+		config: config,
+	}
+}
+
+func (mw DatabaseRetryMiddleware) Initialize(ctx context.Context, req InitializeRequest) (InitializeResponse, error) {
+	return mw.next.Initialize(ctx, req)
+}
+
+func (mw DatabaseRetryMiddleware) NewUser(ctx context.Context, req NewUserRequest) (resp NewUserResponse, err error) {
+	err = mw.retry(ctx, func() error {
+		var err error
+		resp, err = mw.next.NewUser(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (mw DatabaseRetryMiddleware) UpdateUser(ctx context.Context, req UpdateUserRequest) (resp UpdateUserResponse, err error) {
+	err = mw.retry(ctx, func() error {
+		var err error
+		resp, err = mw.next.UpdateUser(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (mw DatabaseRetryMiddleware) DeleteUser(ctx context.Context, req DeleteUserRequest) (resp DeleteUserResponse, err error) {
+	err = mw.retry(ctx, func() error {
+		var err error
+		resp, err = mw.next.DeleteUser(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (mw DatabaseRetryMiddleware) Type() (string, error) {
+	return mw.next.Type()
+}
+
+func (mw DatabaseRetryMiddleware) Close() error {
+	return mw.next.Close()
+}
+
+// retry calls op until it succeeds, fails with an error that is not
+// transient, or the retries are exhausted. The last error is returned.
+func (mw DatabaseRetryMiddleware) retry(ctx context.Context, op func() error) error {
+	backoff := mw.config.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= mw.config.MaxRetries || !mw.config.IsTransient(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > mw.config.MaxBackoff {
+			backoff = mw.config.MaxBackoff
+		}
+	}
+}
+
+// transientErrorMessages are fragments of the messages of database driver
+// errors that are expected to go away when the operation is retried. Errors
+// that plugins do not classify with an ErrorKind only keep their message
+// across the gRPC boundary, so they have to be matched by message.
+var transientErrorMessages = []string{
+	"deadlock",
+	"lock wait timeout exceeded",
+	"could not serialize access",
+	"connection reset",
+	"broken pipe",
+	"bad connection",
+	"connection refused",
+	"i/o timeout",
+}
+
+// IsTransientError reports whether err is likely caused by a temporary
+// condition, such as a deadlock or a dropped connection, so that retrying the
+// operation may succeed. Errors classified by the plugin are trusted over the
+// heuristics applied to unclassified ones.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch ErrorKindOf(err) {
+	case ErrorKindTransient:
+		return true
+	case ErrorKindAuth, ErrorKindNotFound, ErrorKindPermanent:
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range transientErrorMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// ///////////////////////////////////////////////////
+// Labeled Metrics Middleware Domain
+// ///////////////////////////////////////////////////
+
+var _ Database = DatabaseLabeledMetricsMiddleware{}
+
+// DatabaseLabeledMetricsMiddleware wraps an implementation of Databases and
+// records the number of calls, the latency and the number of errors of each
+// operation, labeled with the plugin type and the connection name. Unlike
+// the metrics recorded within the plugin, these are emitted by the host, so
+// that they reach its telemetry sink whether or not the plugin runs in a
+// separate process.
+type DatabaseLabeledMetricsMiddleware struct {
+	next   Database
+	sink   metrics.MetricSink
+	labels []metrics.Label
+}
+
+// NewDatabaseLabeledMetricsMiddleware returns a middleware emitting metrics to
+// the given sink. If sink is nil, the global metrics sink is used.
+func NewDatabaseLabeledMetricsMiddleware(next Database, sink metrics.MetricSink, pluginType, connectionName string) DatabaseLabeledMetricsMiddleware {
+	return DatabaseLabeledMetricsMiddleware{
+		next: next,
+		sink: sink,
+		labels: []metrics.Label{
+			{Name: "plugin_type", Value: pluginType},
+			{Name: "connection_name", Value: connectionName},
+		},
+	}
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) Initialize(ctx context.Context, req InitializeRequest) (resp InitializeResponse, err error) {
+	defer mw.record("Initialize", time.Now(), &err)
+	return mw.next.Initialize(ctx, req)
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) NewUser(ctx context.Context, req NewUserRequest) (resp NewUserResponse, err error) {
+	defer mw.record("NewUser", time.Now(), &err)
+	return mw.next.NewUser(ctx, req)
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) UpdateUser(ctx context.Context, req UpdateUserRequest) (resp UpdateUserResponse, err error) {
+	defer mw.record("UpdateUser", time.Now(), &err)
+	return mw.next.UpdateUser(ctx, req)
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) DeleteUser(ctx context.Context, req DeleteUserRequest) (resp DeleteUserResponse, err error) {
+	defer mw.record("DeleteUser", time.Now(), &err)
+	return mw.next.DeleteUser(ctx, req)
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) Type() (string, error) {
+	return mw.next.Type()
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) Close() (err error) {
+	defer mw.record("Close", time.Now(), &err)
+	return mw.next.Close()
+}
+
+// record emits the metrics of an operation that started at start and failed
+// if *err is set.
+func (mw DatabaseLabeledMetricsMiddleware) record(operation string, start time.Time, err *error) {
+	key := []string{"database", "plugin", operation}
+	elapsed := float32(time.Since(start)) / float32(time.Millisecond)
+
+	mw.incrCounter(append(key, "count"))
+	mw.addSample(key, elapsed)
+	if *err != nil {
+		mw.incrCounter(append(key, "error"))
+	}
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) incrCounter(key []string) {
+	if mw.sink == nil {
+		metrics.IncrCounterWithLabels(key, 1, mw.labels)
+		return
+	}
+	mw.sink.IncrCounterWithLabels(key, 1, mw.labels)
+}
+
+func (mw DatabaseLabeledMetricsMiddleware) addSample(key []string, val float32) {
+	if mw.sink == nil {
+		metrics.AddSampleWithLabels(key, val, mw.labels)
+		return
+	}
+	mw.sink.AddSampleWithLabels(key, val, mw.labels)
+}
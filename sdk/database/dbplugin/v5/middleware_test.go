@@ -2,11 +2,15 @@ package dbplugin
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -476,6 +480,163 @@ func TestMetricsMiddleware(t *testing.T) {
 	})
 }
 
+func TestDatabaseRetryMiddleware(t *testing.T) {
+	type testCase struct {
+		err        error
+		maxRetries int
+		canceled   bool
+
+		expectedCalls int
+	}
+
+	tests := map[string]testCase{
+		"no error": {
+			maxRetries:    3,
+			expectedCalls: 1,
+		},
+		"transient error": {
+			err:           errors.New("pq: deadlock detected"),
+			maxRetries:    3,
+			expectedCalls: 4,
+		},
+		"transient gRPC status error": {
+			err:           status.Error(codes.Internal, "write: connection reset by peer"),
+			maxRetries:    2,
+			expectedCalls: 3,
+		},
+		"retries disabled": {
+			err:           errors.New("pq: deadlock detected"),
+			maxRetries:    0,
+			expectedCalls: 1,
+		},
+		"permanent error": {
+			err:           errors.New(`pq: role "foo" already exists`),
+			maxRetries:    3,
+			expectedCalls: 1,
+		},
+		"context canceled": {
+			err:           errors.New("pq: deadlock detected"),
+			maxRetries:    3,
+			canceled:      true,
+			expectedCalls: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.canceled {
+				cancel()
+			}
+
+			db := &recordingDatabase{
+				next: fakeDatabase{
+					newUserErr:    test.err,
+					updateUserErr: test.err,
+					deleteUserErr: test.err,
+				},
+			}
+			mw := NewDatabaseRetryMiddleware(db, RetryConfig{
+				MaxRetries:     test.maxRetries,
+				InitialBackoff: time.Millisecond,
+			})
+
+			_, err := mw.NewUser(ctx, NewUserRequest{})
+			if err != test.err {
+				t.Fatalf("Actual err: %v Expected err: %v", err, test.err)
+			}
+			_, err = mw.UpdateUser(ctx, UpdateUserRequest{})
+			if err != test.err {
+				t.Fatalf("Actual err: %v Expected err: %v", err, test.err)
+			}
+			_, err = mw.DeleteUser(ctx, DeleteUserRequest{})
+			if err != test.err {
+				t.Fatalf("Actual err: %v Expected err: %v", err, test.err)
+			}
+
+			assertEquals(t, db.newUserCalls, test.expectedCalls)
+			assertEquals(t, db.updateUserCalls, test.expectedCalls)
+			assertEquals(t, db.deleteUserCalls, test.expectedCalls)
+		})
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil":                  {nil, false},
+		"bad connection":       {fmt.Errorf("unable to create user: %w", driver.ErrBadConn), true},
+		"mysql deadlock":       {errors.New("Error 1213: Deadlock found when trying to get lock; try restarting transaction"), true},
+		"mysql lock timeout":   {errors.New("Error 1205: Lock wait timeout exceeded; try restarting transaction"), true},
+		"postgres serialize":   {errors.New("pq: could not serialize access due to concurrent update"), true},
+		"broken pipe":          {errors.New("write tcp 127.0.0.1:5432: write: broken pipe"), true},
+		"syntax error":         {errors.New(`pq: syntax error at or near "CREAT"`), false},
+		"permission denied":    {errors.New("pq: permission denied to create role"), false},
+		"gRPC deadlock error":  {status.Error(codes.Unknown, "mssql: Transaction was deadlocked"), true},
+		"classified transient": {NewError(ErrorKindTransient, errors.New("replica unavailable")), true},
+		"classified permanent": {NewError(ErrorKindPermanent, errors.New("deadlock in statements")), false},
+		"classified over gRPC": {errorFromStatus(status.Error(codes.Unavailable, "replica unavailable")), true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := IsTransientError(test.err); actual != test.expected {
+				t.Fatalf("Actual: %t Expected: %t", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDatabaseLabeledMetricsMiddleware(t *testing.T) {
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	db := fakeDatabase{
+		deleteUserErr: errors.New("pq: role \"foo\" does not exist"),
+	}
+	mw := NewDatabaseLabeledMetricsMiddleware(db, sink, "postgresql-database-plugin", "prod")
+
+	for i := 0; i < 2; i++ {
+		if _, err := mw.NewUser(context.Background(), NewUserRequest{}); err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+	}
+	if _, err := mw.DeleteUser(context.Background(), DeleteUserRequest{}); err == nil {
+		t.Fatalf("error expected")
+	}
+
+	data := sink.Data()
+	if len(data) != 1 {
+		t.Fatalf("expected one interval, got %d", len(data))
+	}
+	counters := data[0].Counters
+	samples := data[0].Samples
+
+	const labels = ";plugin_type=postgresql-database-plugin;connection_name=prod"
+	expectedCounters := map[string]int{
+		"database.plugin.NewUser.count" + labels:    2,
+		"database.plugin.DeleteUser.count" + labels: 1,
+		"database.plugin.DeleteUser.error" + labels: 1,
+	}
+	for key, expected := range expectedCounters {
+		counter, ok := counters[key]
+		if !ok {
+			t.Fatalf("missing counter %q, got %v", key, counters)
+		}
+		assertEquals(t, counter.Count, expected)
+	}
+	if _, ok := counters["database.plugin.NewUser.error"+labels]; ok {
+		t.Fatalf("unexpected error counter for NewUser")
+	}
+
+	sample, ok := samples["database.plugin.NewUser"+labels]
+	if !ok {
+		t.Fatalf("missing latency sample, got %v", samples)
+	}
+	assertEquals(t, sample.Count, 2)
+}
+
 func assertEquals(t *testing.T, actual, expected int) {
 	t.Helper()
 	if actual != expected {
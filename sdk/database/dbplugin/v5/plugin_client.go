@@ -16,6 +16,10 @@ type DatabasePluginClient struct {
 	client *plugin.Client
 	sync.Mutex
 
+	// release is set when the plugin process is shared with other
+	// connections. It is called instead of killing the plugin on Close.
+	release func()
+
 	Database
 }
 
@@ -23,6 +27,10 @@ type DatabasePluginClient struct {
 // and kill the plugin.
 func (dc *DatabasePluginClient) Close() error {
 	err := dc.Database.Close()
+	if dc.release != nil {
+		dc.release()
+		return err
+	}
 	dc.client.Kill()
 
 	return err
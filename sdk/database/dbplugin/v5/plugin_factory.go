@@ -13,6 +13,19 @@ import (
 // PluginFactory is used to build plugin database types. It wraps the database
 // object in a logging and metrics middleware.
 func PluginFactory(ctx context.Context, pluginName string, sys pluginutil.LookRunnerUtil, logger log.Logger) (Database, error) {
+	return pluginFactory(ctx, pluginName, "", sys, logger)
+}
+
+// MultiplexedPluginFactory is like PluginFactory, except that external plugins
+// which support multiplexing share a single process between all of their
+// connections, if sys is a PluginClientsProvider. The connectionID identifies
+// this connection's database instance within that process and must be unique
+// across the host.
+func MultiplexedPluginFactory(ctx context.Context, pluginName, connectionID string, sys pluginutil.LookRunnerUtil, logger log.Logger) (Database, error) {
+	return pluginFactory(ctx, pluginName, connectionID, sys, logger)
+}
+
+func pluginFactory(ctx context.Context, pluginName, connectionID string, sys pluginutil.LookRunnerUtil, logger log.Logger) (Database, error) {
 	// Look for plugin in the plugin catalog
 	pluginRunner, err := sys.LookupPlugin(ctx, pluginName, consts.PluginTypeDatabase)
 	if err != nil {
@@ -41,7 +54,15 @@ func PluginFactory(ctx context.Context, pluginName string, sys pluginutil.LookRu
 
 	} else {
 		// create a DatabasePluginClient instance
-		db, err = NewPluginClient(ctx, sys, pluginRunner, namedLogger, false)
+		var clients *PluginClients
+		if provider, ok := sys.(PluginClientsProvider); ok && connectionID != "" {
+			clients = provider.DatabasePluginClients()
+		}
+		if clients != nil {
+			db, err = clients.NewPluginClient(ctx, sys, pluginRunner, namedLogger, connectionID)
+		} else {
+			db, err = NewPluginClient(ctx, sys, pluginRunner, namedLogger, false)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,222 @@
+package dbplugin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// multiplexingProtocolVersion is the plugin protocol version negotiated
+	// by plugins that are able to serve multiple database connections from a
+	// single process.
+	multiplexingProtocolVersion = 6
+
+	// multiplexIDKey is the gRPC metadata key used to identify the database
+	// connection a call is made on behalf of.
+	multiplexIDKey = "multiplex_id"
+)
+
+// Factory returns a new, uninitialized Database. Multiplexed plugins call it
+// once for every database connection the host opens.
+type Factory func() (interface{}, error)
+
+// ServeMultiplex is called from within a plugin and starts a gRPC server that
+// is able to serve any number of database connections from a single process.
+// Each connection is given its own Database created by the factory, so the
+// configuration of one connection is never visible to another.
+func ServeMultiplex(factory Factory) {
+	plugin.Serve(ServeConfigMultiplex(factory))
+}
+
+func ServeConfigMultiplex(factory Factory) *plugin.ServeConfig {
+	err := pluginutil.OptionallyEnableMlock()
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	db := &GRPCDatabasePlugin{
+		FactoryFunc: factory,
+	}
+
+	// Hosts that do not support multiplexing negotiate version 5, in which
+	// case every call is served by a single database instance.
+	pluginSets := map[int]plugin.PluginSet{
+		5: plugin.PluginSet{
+			"database": db,
+		},
+		multiplexingProtocolVersion: plugin.PluginSet{
+			"database": db,
+		},
+	}
+
+	conf := &plugin.ServeConfig{
+		HandshakeConfig:  handshakeConfig,
+		VersionedPlugins: pluginSets,
+		GRPCServer:       plugin.DefaultGRPCServer,
+	}
+
+	return conf
+}
+
+// multiplexIDFromContext returns the connection ID attached to an incoming
+// gRPC call, or an empty string if the host did not provide one.
+func multiplexIDFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	ids := md.Get(multiplexIDKey)
+	switch len(ids) {
+	case 0:
+		return "", nil
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("unexpected number of multiplex IDs: %d", len(ids))
+	}
+}
+
+// sharedPluginClient is a plugin process that serves every connection to a
+// multiplexed plugin.
+type sharedPluginClient struct {
+	client *plugin.Client
+	db     gRPCClient
+	refs   int
+}
+
+// PluginClients keeps the plugin processes shared by the connections to
+// multiplexed plugins. A host keeps a single PluginClients, held by its plugin
+// catalog, so that a plugin runs in a single process however many mounts
+// connect to it.
+type PluginClients struct {
+	lock    sync.Mutex
+	clients map[string]*sharedPluginClient
+}
+
+// NewPluginClients returns an empty PluginClients.
+func NewPluginClients() *PluginClients {
+	return &PluginClients{
+		clients: make(map[string]*sharedPluginClient),
+	}
+}
+
+// PluginClientsProvider is implemented by the system views of hosts which
+// share the processes of multiplexed plugins between connections.
+type PluginClientsProvider interface {
+	DatabasePluginClients() *PluginClients
+}
+
+// NewPluginClient returns a Database for the connection identified by id. If
+// the plugin supports multiplexing, a single plugin process is shared by all
+// connections to it and is killed once the last of them is closed. Plugins
+// that do not support multiplexing are given a dedicated process, exactly as
+// with NewPluginClient.
+func (c *PluginClients) NewPluginClient(ctx context.Context, sys pluginutil.RunnerUtil, pluginRunner *pluginutil.PluginRunner, logger log.Logger, id string) (Database, error) {
+	if id == "" {
+		return nil, errors.New("missing multiplex ID")
+	}
+
+	key := pluginRunner.Name + "/" + hex.EncodeToString(pluginRunner.Sha256)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	shared, ok := c.clients[key]
+	if ok && shared.client.Exited() {
+		delete(c.clients, key)
+		ok = false
+	}
+
+	if !ok {
+		// pluginSets is the map of plugins we can dispense.
+		pluginSets := map[int]plugin.PluginSet{
+			5: plugin.PluginSet{
+				"database": new(GRPCDatabasePlugin),
+			},
+			multiplexingProtocolVersion: plugin.PluginSet{
+				"database": new(GRPCDatabasePlugin),
+			},
+		}
+
+		client, err := pluginRunner.RunConfig(ctx,
+			pluginutil.Runner(sys),
+			pluginutil.PluginSets(pluginSets),
+			pluginutil.HandshakeConfig(handshakeConfig),
+			pluginutil.Logger(logger),
+			pluginutil.MetadataMode(false),
+			pluginutil.AutoMTLS(true),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rpcClient, err := client.Client()
+		if err != nil {
+			client.Kill()
+			return nil, err
+		}
+
+		raw, err := rpcClient.Dispense("database")
+		if err != nil {
+			client.Kill()
+			return nil, err
+		}
+
+		db, ok := raw.(gRPCClient)
+		if !ok {
+			client.Kill()
+			return nil, errors.New("unsupported client type")
+		}
+
+		// The plugin can only serve a single connection, so it is not
+		// shared with anyone else.
+		if client.NegotiatedVersion() != multiplexingProtocolVersion {
+			return &DatabasePluginClient{
+				client:   client,
+				Database: db,
+			}, nil
+		}
+
+		shared = &sharedPluginClient{
+			client: client,
+			db:     db,
+		}
+		c.clients[key] = shared
+	}
+
+	shared.refs++
+
+	db := shared.db
+	db.multiplexID = id
+
+	var releaseOnce sync.Once
+	return &DatabasePluginClient{
+		client:   shared.client,
+		Database: db,
+		release: func() {
+			releaseOnce.Do(func() {
+				c.lock.Lock()
+				defer c.lock.Unlock()
+
+				shared.refs--
+				if shared.refs > 0 {
+					return
+				}
+				shared.client.Kill()
+				if c.clients[key] == shared {
+					delete(c.clients, key)
+				}
+			})
+		},
+	}, nil
+}
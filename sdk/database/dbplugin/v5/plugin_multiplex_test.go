@@ -0,0 +1,87 @@
+package dbplugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func multiplexCtx(id string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(multiplexIDKey, id))
+}
+
+func TestGRPCServer_Multiplex(t *testing.T) {
+	var created []*recordingDatabase
+	g := &gRPCServer{
+		factoryFunc: func() (interface{}, error) {
+			db := &recordingDatabase{}
+			created = append(created, db)
+			return db, nil
+		},
+		instances: make(map[string]Database),
+	}
+
+	if _, err := g.Initialize(multiplexCtx("conn1"), &proto.InitializeRequest{}); err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if _, err := g.Initialize(multiplexCtx("conn2"), &proto.InitializeRequest{}); err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if _, err := g.Type(multiplexCtx("conn1"), &proto.Empty{}); err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+
+	if len(created) != 2 {
+		t.Fatalf("expected 2 database instances, got %d", len(created))
+	}
+	if created[0].initializeCalls != 1 || created[1].initializeCalls != 1 {
+		t.Fatalf("expected each instance to be initialized once, got %d and %d", created[0].initializeCalls, created[1].initializeCalls)
+	}
+	if created[0].typeCalls != 1 || created[1].typeCalls != 0 {
+		t.Fatalf("expected type call to be routed to the first instance, got %d and %d", created[0].typeCalls, created[1].typeCalls)
+	}
+
+	// Closing one connection leaves the other untouched
+	if _, err := g.Close(multiplexCtx("conn1"), &proto.Empty{}); err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if created[0].closeCalls != 1 || created[1].closeCalls != 0 {
+		t.Fatalf("expected only the first instance to be closed, got %d and %d", created[0].closeCalls, created[1].closeCalls)
+	}
+	if _, ok := g.instances["conn1"]; ok {
+		t.Fatalf("expected closed instance to be removed")
+	}
+	if _, ok := g.instances["conn2"]; !ok {
+		t.Fatalf("expected open instance to be kept")
+	}
+}
+
+func TestGRPCServer_Multiplex_NoInstance(t *testing.T) {
+	g := &gRPCServer{}
+
+	_, err := g.Type(context.Background(), &proto.Empty{})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Actual code: %s Expected code: %s", status.Code(err), codes.Unavailable)
+	}
+}
+
+func TestMultiplexIDFromContext(t *testing.T) {
+	id, err := multiplexIDFromContext(context.Background())
+	if err != nil || id != "" {
+		t.Fatalf("expected no ID, got %q: %v", id, err)
+	}
+
+	id, err = multiplexIDFromContext(multiplexCtx("conn1"))
+	if err != nil || id != "conn1" {
+		t.Fatalf("expected conn1, got %q: %v", id, err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(multiplexIDKey, "a", multiplexIDKey, "b"))
+	if _, err := multiplexIDFromContext(ctx); err == nil {
+		t.Fatalf("expected error for multiple IDs")
+	}
+}
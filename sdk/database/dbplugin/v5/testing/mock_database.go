@@ -0,0 +1,225 @@
+package dbtesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+// MockType is the type reported by MockDatabase.
+const MockType = "mock"
+
+var _ dbplugin.Database = (*MockDatabase)(nil)
+
+// MockUser is a user stored in a MockDatabase.
+type MockUser struct {
+	Username   string
+	Password   string
+	RoleName   string
+	Expiration time.Time
+}
+
+// MockDatabase is an in-memory implementation of dbplugin.Database. It keeps
+// its users in a map, so it can stand in for a live database when testing
+// code that drives database plugins, and it passes RunTestSuite.
+//
+// It is initialized with a "connection_url" that may reference the
+// "username" and "password" of the configuration as {{username}} and
+// {{password}}. Connections can only be verified to URLs starting with
+// "mock://"; any other URL fails verification, with the password redacted
+// from the error as plugins are expected to do.
+type MockDatabase struct {
+	// Latency delays every operation, so that the handling of deadlines and
+	// canceled contexts can be tested. Operations return as soon as their
+	// context is done.
+	Latency time.Duration
+
+	mu       sync.RWMutex
+	config   map[string]interface{}
+	password string
+	users    map[string]MockUser
+}
+
+// NewMockDatabase returns an uninitialized MockDatabase.
+func NewMockDatabase() *MockDatabase {
+	return &MockDatabase{
+		users: make(map[string]MockUser),
+	}
+}
+
+func (m *MockDatabase) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	if err := m.wait(ctx); err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	connURL, ok := req.Config["connection_url"].(string)
+	if !ok || connURL == "" {
+		return dbplugin.InitializeResponse{}, errors.New("connection_url cannot be empty")
+	}
+	username, _ := req.Config["username"].(string)
+	password, _ := req.Config["password"].(string)
+
+	if req.VerifyConnection {
+		rendered := strings.NewReplacer("{{username}}", username, "{{password}}", password).Replace(connURL)
+		if !strings.HasPrefix(rendered, "mock://") {
+			err := fmt.Errorf("unable to connect to %q", rendered)
+			return dbplugin.InitializeResponse{}, redact(err, password)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = req.Config
+	m.password = password
+
+	return dbplugin.InitializeResponse{
+		Config: req.Config,
+	}, nil
+}
+
+func (m *MockDatabase) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (dbplugin.NewUserResponse, error) {
+	if err := m.wait(ctx); err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+	if req.Password == "" {
+		return dbplugin.NewUserResponse{}, dbplugin.NewError(dbplugin.ErrorKindPermanent, errors.New("missing password"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkInitialized(); err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+	username := fmt.Sprintf("v_%s_%s_%s", req.UsernameConfig.DisplayName, req.UsernameConfig.RoleName, id[:8])
+
+	m.users[username] = MockUser{
+		Username:   username,
+		Password:   req.Password,
+		RoleName:   req.UsernameConfig.RoleName,
+		Expiration: req.Expiration,
+	}
+
+	return dbplugin.NewUserResponse{
+		Username: username,
+	}, nil
+}
+
+func (m *MockDatabase) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if err := m.wait(ctx); err != nil {
+		return dbplugin.UpdateUserResponse{}, err
+	}
+	if req.Password == nil && req.Expiration == nil {
+		return dbplugin.UpdateUserResponse{}, dbplugin.NewError(dbplugin.ErrorKindPermanent, errors.New("no changes requested"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkInitialized(); err != nil {
+		return dbplugin.UpdateUserResponse{}, err
+	}
+
+	user, ok := m.users[req.Username]
+	if !ok {
+		return dbplugin.UpdateUserResponse{}, dbplugin.NewError(dbplugin.ErrorKindNotFound, fmt.Errorf("user %q does not exist", req.Username))
+	}
+	if req.Password != nil {
+		if req.Password.NewPassword == "" {
+			return dbplugin.UpdateUserResponse{}, dbplugin.NewError(dbplugin.ErrorKindPermanent, errors.New("missing password"))
+		}
+		user.Password = req.Password.NewPassword
+	}
+	if req.Expiration != nil {
+		user.Expiration = req.Expiration.NewExpiration
+	}
+	m.users[req.Username] = user
+
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+func (m *MockDatabase) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	if err := m.wait(ctx); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkInitialized(); err != nil {
+		return dbplugin.DeleteUserResponse{}, err
+	}
+
+	delete(m.users, req.Username)
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+func (m *MockDatabase) Type() (string, error) {
+	return MockType, nil
+}
+
+func (m *MockDatabase) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = nil
+	m.password = ""
+	return nil
+}
+
+// User returns the user with the given name, if it exists.
+func (m *MockDatabase) User(username string) (MockUser, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, ok := m.users[username]
+	return user, ok
+}
+
+// CheckUser returns an error unless the user exists and has the given
+// password. It can be used as the CheckUser function of a TestSuite.
+func (m *MockDatabase) CheckUser(ctx context.Context, username, password string) error {
+	user, ok := m.User(username)
+	if !ok {
+		return fmt.Errorf("user %q does not exist", username)
+	}
+	if user.Password != password {
+		return fmt.Errorf("invalid password for user %q", username)
+	}
+	return nil
+}
+
+func (m *MockDatabase) checkInitialized() error {
+	if m.config == nil {
+		return errors.New("database is not initialized")
+	}
+	return nil
+}
+
+func (m *MockDatabase) wait(ctx context.Context) error {
+	if m.Latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(m.Latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func redact(err error, secret string) error {
+	if secret == "" {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), secret, "[password]"))
+}
@@ -0,0 +1,285 @@
+package dbtesting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+)
+
+// TestSuite configures the conformance tests run by RunTestSuite against a
+// database plugin.
+type TestSuite struct {
+	// New returns a new, uninitialized instance of the plugin, wrapped in the
+	// same middleware as the one the plugin serves. Required.
+	New func() (dbplugin.Database, error)
+
+	// Config is used to initialize the plugin. Required.
+	Config map[string]interface{}
+
+	// InvalidConfig, if set, must fail to initialize when the connection is
+	// verified. It is used to check that errors do not leak Secrets.
+	InvalidConfig map[string]interface{}
+
+	// Secrets are values, such as the root password, that must never appear
+	// in errors returned by the plugin.
+	Secrets []string
+
+	// Statements passed to the plugin for each operation. Plugins that have
+	// default statements can leave them empty.
+	NewUserStatements          dbplugin.Statements
+	ChangePasswordStatements   dbplugin.Statements
+	ChangeExpirationStatements dbplugin.Statements
+	DeleteUserStatements       dbplugin.Statements
+
+	// CheckUser, if set, returns an error unless the user exists in the
+	// database and can authenticate with the given password. Without it, the
+	// suite only checks the responses of the plugin.
+	CheckUser func(ctx context.Context, username, password string) error
+}
+
+// RunTestSuite runs the conformance tests of the suite as subtests of t. The
+// tests go through the lifecycle of users, from their creation to their
+// deletion, and check that the plugin honors canceled contexts and redacts
+// secrets from its errors.
+func RunTestSuite(t *testing.T, suite TestSuite) {
+	t.Helper()
+	if suite.New == nil {
+		t.Fatalf("Missing New function in test suite")
+	}
+	if suite.Config == nil {
+		t.Fatalf("Missing Config in test suite")
+	}
+
+	newDB := func(t *testing.T) dbplugin.Database {
+		t.Helper()
+		db, err := suite.New()
+		if err != nil {
+			t.Fatalf("Failed to create database: %s", err)
+		}
+		return db
+	}
+
+	t.Run("Type", func(t *testing.T) {
+		db := newDB(t)
+		defer AssertClose(t, db)
+
+		dbType, err := db.Type()
+		if err != nil {
+			t.Fatalf("Failed to get type: %s", suite.redacted(t, err))
+		}
+		if dbType == "" {
+			t.Fatalf("Missing type")
+		}
+	})
+
+	t.Run("Initialize", func(t *testing.T) {
+		db := newDB(t)
+		defer AssertClose(t, db)
+
+		resp := AssertInitialize(t, db, dbplugin.InitializeRequest{
+			Config:           copyConfig(suite.Config),
+			VerifyConnection: true,
+		})
+		if resp.Config == nil {
+			t.Fatalf("Missing config from Initialize response")
+		}
+	})
+
+	t.Run("UserLifecycle", func(t *testing.T) {
+		db := newDB(t)
+		defer AssertClose(t, db)
+		suite.initialize(t, db)
+
+		password := "Passw0rd-original"
+		newUserReq := dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{
+				DisplayName: "token",
+				RoleName:    "conformance",
+			},
+			Statements: suite.NewUserStatements,
+			Password:   password,
+			Expiration: time.Now().Add(time.Hour),
+		}
+		username := AssertNewUser(t, db, newUserReq).Username
+		suite.assertUser(t, username, password)
+
+		// Users created with the same metadata must not collide
+		other := AssertNewUser(t, db, newUserReq).Username
+		if other == username {
+			t.Fatalf("NewUser returned the same username %q twice", username)
+		}
+		AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+			Username:   other,
+			Statements: suite.DeleteUserStatements,
+		})
+
+		newPassword := "Passw0rd-updated"
+		AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+			Username: username,
+			Password: &dbplugin.ChangePassword{
+				NewPassword: newPassword,
+				Statements:  suite.ChangePasswordStatements,
+			},
+		})
+		suite.assertUser(t, username, newPassword)
+		suite.assertNoUser(t, username, password)
+
+		AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+			Username: username,
+			Expiration: &dbplugin.ChangeExpiration{
+				NewExpiration: time.Now().Add(2 * time.Hour),
+				Statements:    suite.ChangeExpirationStatements,
+			},
+		})
+		suite.assertUser(t, username, newPassword)
+
+		deleteReq := dbplugin.DeleteUserRequest{
+			Username:   username,
+			Statements: suite.DeleteUserStatements,
+		}
+		AssertDeleteUser(t, db, deleteReq)
+		suite.assertNoUser(t, username, newPassword)
+
+		// Deleting a user that does not exist must not fail
+		AssertDeleteUser(t, db, deleteReq)
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		db := newDB(t)
+		defer AssertClose(t, db)
+		suite.initialize(t, db)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := db.NewUser(ctx, dbplugin.NewUserRequest{
+				UsernameConfig: dbplugin.UsernameMetadata{
+					DisplayName: "token",
+					RoleName:    "conformance",
+				},
+				Statements: suite.NewUserStatements,
+				Password:   "Passw0rd-canceled",
+			})
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Fatalf("Expected NewUser to fail with a canceled context")
+			}
+			suite.redacted(t, err)
+		case <-time.After(getRequestTimeout(t)):
+			t.Fatalf("NewUser did not return after its context was canceled")
+		}
+	})
+
+	t.Run("SecretRedaction", func(t *testing.T) {
+		if suite.InvalidConfig == nil {
+			t.Skip("No InvalidConfig in test suite")
+		}
+		db := newDB(t)
+		defer AssertClose(t, db)
+
+		ctx, cancel := context.WithTimeout(context.Background(), getRequestTimeout(t))
+		defer cancel()
+
+		_, err := db.Initialize(ctx, dbplugin.InitializeRequest{
+			Config:           copyConfig(suite.InvalidConfig),
+			VerifyConnection: true,
+		})
+		if err == nil {
+			t.Fatalf("Expected Initialize to fail with the invalid config")
+		}
+		suite.redacted(t, err)
+	})
+}
+
+// NewGRPCDatabase serves db over an in-process gRPC connection and returns a
+// client to it, so that tests go through the same protocol as Vault does with
+// plugins running in their own process. The returned function closes the
+// connection.
+func NewGRPCDatabase(t *testing.T, db dbplugin.Database) (dbplugin.Database, func()) {
+	t.Helper()
+
+	client, server := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{
+		"database": &dbplugin.GRPCDatabasePlugin{
+			Impl: db,
+		},
+	})
+	cleanup := func() {
+		client.Close()
+		server.Stop()
+	}
+
+	raw, err := client.Dispense("database")
+	if err != nil {
+		cleanup()
+		t.Fatalf("Failed to dispense database: %s", err)
+	}
+	return raw.(dbplugin.Database), cleanup
+}
+
+func (s TestSuite) initialize(t *testing.T, db dbplugin.Database) {
+	t.Helper()
+	AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           copyConfig(s.Config),
+		VerifyConnection: true,
+	})
+}
+
+func (s TestSuite) assertUser(t *testing.T, username, password string) {
+	t.Helper()
+	if s.CheckUser == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), getRequestTimeout(t))
+	defer cancel()
+
+	if err := s.CheckUser(ctx, username, password); err != nil {
+		t.Fatalf("User %q cannot authenticate: %s", username, err)
+	}
+}
+
+func (s TestSuite) assertNoUser(t *testing.T, username, password string) {
+	t.Helper()
+	if s.CheckUser == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), getRequestTimeout(t))
+	defer cancel()
+
+	if err := s.CheckUser(ctx, username, password); err == nil {
+		t.Fatalf("User %q can still authenticate with password %q", username, password)
+	}
+}
+
+// redacted fails the test if err contains any of the secrets of the suite,
+// and returns err otherwise.
+func (s TestSuite) redacted(t *testing.T, err error) error {
+	t.Helper()
+	for _, secret := range s.Secrets {
+		if secret != "" && strings.Contains(err.Error(), secret) {
+			t.Fatalf("Error leaks a secret: %s", strings.ReplaceAll(err.Error(), secret, "<secret>"))
+		}
+	}
+	return err
+}
+
+// copyConfig returns a shallow copy of config, since plugins may modify the
+// config they are initialized with.
+func copyConfig(config map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
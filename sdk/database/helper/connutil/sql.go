@@ -2,7 +2,11 @@ package connutil
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -27,14 +31,34 @@ type SQLConnectionProducer struct {
 	Username                 string      `json:"username" mapstructure:"username" structs:"username"`
 	Password                 string      `json:"password" mapstructure:"password" structs:"password"`
 
+	TLSCertificateKeyData []byte `json:"tls_certificate_key" mapstructure:"tls_certificate_key" structs:"-"`
+	TLSCAData             []byte `json:"tls_ca" mapstructure:"tls_ca" structs:"-"`
+	TLSServerName         string `json:"tls_server_name" mapstructure:"tls_server_name" structs:"-"`
+
+	// TLSConnector opens connections secured with the TLS configuration built
+	// from the PEM-encoded material in the config. Plugins whose driver
+	// supports an in-memory TLS configuration set it to accept tls_ca and
+	// tls_certificate_key.
+	TLSConnector TLSConnectorFunc `json:"-" mapstructure:"-" structs:"-"`
+
+	// Connector, when set, opens connections instead of TLSConnector and the
+	// driver named by Type, securing them with the TLS configuration if it is
+	// not nil. Plugins set it to control which hosts connections are made to.
+	Connector TLSConnectorFunc `json:"-" mapstructure:"-" structs:"-"`
+
 	Type                  string
 	RawConfig             map[string]interface{}
 	maxConnectionLifetime time.Duration
+	tlsConfig             *tls.Config
 	Initialized           bool
 	db                    *sql.DB
 	sync.Mutex
 }
 
+// TLSConnectorFunc returns a connector for the given connection URL whose
+// connections are secured with tlsConfig.
+type TLSConnectorFunc func(connURL string, tlsConfig *tls.Config) (driver.Connector, error)
+
 func (c *SQLConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
 	_, err := c.Init(ctx, conf, verifyConnection)
 	return err
@@ -87,6 +111,14 @@ func (c *SQLConnectionProducer) Init(ctx context.Context, conf map[string]interf
 		return nil, errwrap.Wrapf("invalid max_connection_lifetime: {{err}}", err)
 	}
 
+	c.tlsConfig, err = c.getTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if c.tlsConfig != nil && c.TLSConnector == nil && c.Connector == nil {
+		return nil, fmt.Errorf("tls_ca and tls_certificate_key are not supported by the %s plugin", c.Type)
+	}
+
 	// Set initialized to true at this point since all fields are set,
 	// and the connection can be established at a later time.
 	c.Initialized = true
@@ -137,10 +169,25 @@ func (c *SQLConnectionProducer) Connection(ctx context.Context) (interface{}, er
 		}
 	}
 
-	var err error
-	c.db, err = sql.Open(dbType, conn)
-	if err != nil {
-		return nil, err
+	switch {
+	case c.Connector != nil:
+		connector, err := c.Connector(conn, c.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.db = sql.OpenDB(connector)
+	case c.tlsConfig != nil:
+		connector, err := c.TLSConnector(conn, c.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.db = sql.OpenDB(connector)
+	default:
+		var err error
+		c.db, err = sql.Open(dbType, conn)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Set some connection pool settings. We don't need much of this,
@@ -158,6 +205,18 @@ func (c *SQLConnectionProducer) SecretValues() map[string]interface{} {
 	}
 }
 
+// ResetConnection closes the connection pool, if any, so that the next call
+// to Connection opens a new one with the current ConnectionURL. It is used
+// when the credentials in the URL change, since the pool would otherwise keep
+// opening connections with the former ones. The caller must hold the lock.
+func (c *SQLConnectionProducer) ResetConnection() {
+	if c.db != nil {
+		c.db.Close()
+	}
+
+	c.db = nil
+}
+
 // Close attempts to close the connection
 func (c *SQLConnectionProducer) Close() error {
 	// Grab the write lock
@@ -173,6 +232,36 @@ func (c *SQLConnectionProducer) Close() error {
 	return nil
 }
 
+// getTLSConfig builds the TLS configuration of the connections from the
+// PEM-encoded material in the config. It returns nil if there is none.
+func (c *SQLConnectionProducer) getTLSConfig() (*tls.Config, error) {
+	if len(c.TLSCAData) == 0 && len(c.TLSCertificateKeyData) == 0 {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: c.TLSServerName,
+	}
+
+	if len(c.TLSCAData) > 0 {
+		rootCertPool := x509.NewCertPool()
+		if ok := rootCertPool.AppendCertsFromPEM(c.TLSCAData); !ok {
+			return nil, errors.New("unable to parse tls_ca")
+		}
+		tlsConfig.RootCAs = rootCertPool
+	}
+
+	if len(c.TLSCertificateKeyData) > 0 {
+		certificate, err := tls.X509KeyPair(c.TLSCertificateKeyData, c.TLSCertificateKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load tls_certificate_key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
 // SetCredentials uses provided information to set/create a user in the
 // database. Unlike CreateUser, this method requires a username be provided and
 // uses the name given, instead of generating a name. This is used for creating
@@ -2,8 +2,19 @@ package connutil
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql/driver"
+	"encoding/pem"
+	"errors"
+	"math/big"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestSQLPasswordChars(t *testing.T) {
@@ -54,3 +65,89 @@ func TestSQLPasswordChars(t *testing.T) {
 		}
 	}
 }
+
+func TestSQLConnectionProducer_TLS(t *testing.T) {
+	certPEM, keyPEM := testSelfSignedCert(t)
+	conf := map[string]interface{}{
+		"connection_url":      "postgres://localhost:5432/mydb",
+		"tls_ca":              string(certPEM),
+		"tls_certificate_key": string(certPEM) + string(keyPEM),
+		"tls_server_name":     "db.example.com",
+	}
+
+	// Plugins without a TLS connector reject the TLS material
+	c := &SQLConnectionProducer{Type: "mssql"}
+	if _, err := c.Init(context.Background(), conf, false); err == nil {
+		t.Fatal("expected error for a plugin without TLS connector")
+	}
+
+	var actual *tls.Config
+	c = &SQLConnectionProducer{
+		Type: "postgres",
+		TLSConnector: func(connURL string, tlsConfig *tls.Config) (driver.Connector, error) {
+			actual = tlsConfig
+			return testConnector{}, nil
+		},
+	}
+	if _, err := c.Init(context.Background(), conf, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Connection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if actual == nil {
+		t.Fatal("expected TLS connector to be called")
+	}
+	if actual.RootCAs == nil || len(actual.Certificates) != 1 {
+		t.Fatalf("unexpected TLS config: %#v", actual)
+	}
+	if actual.ServerName != "db.example.com" {
+		t.Fatalf("unexpected server name: %q", actual.ServerName)
+	}
+
+	conf["tls_ca"] = "not a certificate"
+	if _, err := c.Init(context.Background(), conf, false); err == nil {
+		t.Fatal("expected error for an invalid CA")
+	}
+}
+
+func testSelfSignedCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "db.example.com"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (testConnector) Driver() driver.Driver {
+	return nil
+}
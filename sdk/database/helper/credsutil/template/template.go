@@ -0,0 +1,165 @@
+// Package template renders strings such as database usernames from Go
+// templates extended with a set of functions for truncating, transforming and
+// randomizing values.
+//
+// For example, the template
+//
+//	{{ printf "v-%s-%s-%s" (.RoleName | truncate 8) (random 20) (unix_time) | lowercase }}
+//
+// renders to "v-readonly-h2cj4ai5shu2jtsrmpqu-1609459200" for a role named
+// "readonly".
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/base62"
+)
+
+// Opt configures a StringTemplate.
+type Opt func(*StringTemplate) error
+
+// Template sets the raw template to render. It is required.
+func Template(rawTemplate string) Opt {
+	return func(t *StringTemplate) error {
+		t.rawTemplate = rawTemplate
+		return nil
+	}
+}
+
+// Function makes f available in the template under the given name, replacing
+// the builtin function of the same name if there is one. f must follow the
+// requirements of text/template.FuncMap.
+func Function(name string, f interface{}) Opt {
+	return func(t *StringTemplate) error {
+		if name == "" {
+			return errors.New("missing function name")
+		}
+		if f == nil {
+			return fmt.Errorf("missing function %q", name)
+		}
+		t.funcMap[name] = f
+		return nil
+	}
+}
+
+// StringTemplate is a parsed template rendering to a string.
+type StringTemplate struct {
+	rawTemplate string
+	tmpl        *template.Template
+	funcMap     template.FuncMap
+}
+
+// NewTemplate parses a template with the builtin functions and any function
+// added with the Function option.
+func NewTemplate(opts ...Opt) (StringTemplate, error) {
+	t := StringTemplate{
+		funcMap: template.FuncMap{
+			"random":          base62.Random,
+			"truncate":        truncate,
+			"truncate_sha256": truncateSHA256,
+			"uppercase":       strings.ToUpper,
+			"lowercase":       strings.ToLower,
+			"replace":         replace,
+			"sha256":          hashSHA256,
+			"base64":          encodeBase64,
+
+			"unix_time":        unixTime,
+			"unix_time_millis": unixTimeMillis,
+			"timestamp":        timestamp,
+			"uuid":             uuid.GenerateUUID,
+		},
+	}
+
+	for _, opt := range opts {
+		if err := opt(&t); err != nil {
+			return StringTemplate{}, fmt.Errorf("unable to apply option: %w", err)
+		}
+	}
+
+	if t.rawTemplate == "" {
+		return StringTemplate{}, errors.New("missing template")
+	}
+
+	tmpl, err := template.New("template").
+		Funcs(t.funcMap).
+		Option("missingkey=error").
+		Parse(t.rawTemplate)
+	if err != nil {
+		return StringTemplate{}, fmt.Errorf("unable to parse template: %w", err)
+	}
+	t.tmpl = tmpl
+
+	return t, nil
+}
+
+// Generate renders the template with the given data. Leading and trailing
+// whitespace is removed from the result.
+func (t StringTemplate) Generate(data interface{}) (string, error) {
+	if t.tmpl == nil {
+		return "", errors.New("template not initialized")
+	}
+
+	str := &strings.Builder{}
+	if err := t.tmpl.Execute(str, data); err != nil {
+		return "", fmt.Errorf("unable to apply template: %w", err)
+	}
+
+	return strings.TrimSpace(str.String()), nil
+}
+
+func truncate(maxLen int, str string) (string, error) {
+	if maxLen <= 0 {
+		return "", errors.New("max length must be > 0")
+	}
+	if len(str) > maxLen {
+		return str[:maxLen], nil
+	}
+	return str, nil
+}
+
+// truncateSHA256 truncates str to maxLen characters, replacing the truncated
+// part with its SHA256 hash so that distinct values remain distinct.
+func truncateSHA256(maxLen int, str string) (string, error) {
+	if maxLen <= 8 {
+		return "", errors.New("max length must be > 8")
+	}
+	if len(str) <= maxLen {
+		return str, nil
+	}
+
+	truncIndex := maxLen - 8
+	hash := hashSHA256(str[truncIndex:])
+	return str[:truncIndex] + hash[:8], nil
+}
+
+func replace(find, replacement, str string) string {
+	return strings.ReplaceAll(str, find, replacement)
+}
+
+func hashSHA256(str string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(str)))
+}
+
+func encodeBase64(str string) string {
+	return base64.StdEncoding.EncodeToString([]byte(str))
+}
+
+func unixTime() string {
+	return fmt.Sprint(time.Now().Unix())
+}
+
+func unixTimeMillis() string {
+	return fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond))
+}
+
+func timestamp(format string) string {
+	return time.Now().Format(format)
+}
@@ -0,0 +1,143 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	type testCase struct {
+		template string
+		data     interface{}
+
+		expected string
+		regex    string
+		err      bool
+	}
+
+	data := struct {
+		DisplayName string
+		RoleName    string
+	}{
+		DisplayName: "token-abcdefghijklmnop",
+		RoleName:    "MyRole",
+	}
+
+	tests := map[string]testCase{
+		"literal": {
+			template: "foo",
+			expected: "foo",
+		},
+		"truncate and case": {
+			template: `{{ .DisplayName | truncate 10 | uppercase }}_{{ .RoleName | lowercase }}`,
+			data:     data,
+			expected: "TOKEN-ABCD_myrole",
+		},
+		"truncate_sha256": {
+			template: `{{ .DisplayName | truncate_sha256 12 }}`,
+			data:     data,
+			regex:    `^toke[a-f0-9]{8}$`,
+		},
+		"replace": {
+			template: `{{ .DisplayName | replace "-" "_" }}`,
+			data:     data,
+			expected: "token_abcdefghijklmnop",
+		},
+		"random and time": {
+			template: `{{ printf "v-%s-%s-%s" (.RoleName | lowercase) (random 20) (unix_time) }}`,
+			data:     data,
+			regex:    `^v-myrole-[a-zA-Z0-9]{20}-[0-9]+$`,
+		},
+		"whitespace is trimmed": {
+			template: "\n  {{ .RoleName }}  \n",
+			data:     data,
+			expected: "MyRole",
+		},
+		"missing field": {
+			template: `{{ .Missing }}`,
+			data:     map[string]string{},
+			err:      true,
+		},
+		"invalid truncate": {
+			template: `{{ .RoleName | truncate 0 }}`,
+			data:     data,
+			err:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := NewTemplate(Template(test.template))
+			if err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+
+			actual, err := tmpl.Generate(test.data)
+			if test.err {
+				if err == nil {
+					t.Fatalf("error expected, got %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+
+			if test.regex != "" {
+				if !regexp.MustCompile(test.regex).MatchString(actual) {
+					t.Fatalf("%q does not match regex %q", actual, test.regex)
+				}
+				return
+			}
+			if actual != test.expected {
+				t.Fatalf("Actual: %q Expected: %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestNewTemplate(t *testing.T) {
+	if _, err := NewTemplate(); err == nil {
+		t.Fatalf("error expected for missing template")
+	}
+	if _, err := NewTemplate(Template("{{ .Foo")); err == nil {
+		t.Fatalf("error expected for invalid template")
+	}
+	if _, err := NewTemplate(Template("{{ unknown }}")); err == nil {
+		t.Fatalf("error expected for unknown function")
+	}
+}
+
+func TestFunction(t *testing.T) {
+	tmpl, err := NewTemplate(
+		Template(`{{ .RoleName | reverse }}-{{ random 3 }}`),
+		Function("reverse", func(str string) string {
+			runes := []rune(str)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return string(runes)
+		}),
+		// Builtin functions can be replaced
+		Function("random", func(n int) string {
+			return strings.Repeat("x", n)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+
+	actual, err := tmpl.Generate(map[string]string{"RoleName": "abc"})
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if actual != "cba-xxx" {
+		t.Fatalf("Actual: %q Expected: %q", actual, "cba-xxx")
+	}
+
+	if _, err := NewTemplate(Template("foo"), Function("", fmt.Sprint)); err == nil {
+		t.Fatalf("error expected for missing function name")
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/hashicorp/vault/sdk/database/helper/credsutil/template"
 )
 
+// UsernameTemplateKey is the key of the connection configuration holding the
+// template used by database plugins to generate usernames.
+const UsernameTemplateKey = "username_template"
+
 type CaseOp int
 
 const (
@@ -21,9 +27,27 @@ type usernameBuilder struct {
 
 	maxLen        int
 	caseOperation CaseOp
+
+	template     *template.StringTemplate
+	templateData interface{}
 }
 
 func (ub usernameBuilder) makeUsername() (string, error) {
+	if ub.template != nil {
+		username, err := ub.template.Generate(ub.templateData)
+		if err != nil {
+			return "", fmt.Errorf("unable to generate username: %w", err)
+		}
+		if username == "" {
+			return "", fmt.Errorf("username template rendered an empty username")
+		}
+		// Truncating the username could drop the random part making it unique
+		if ub.maxLen > 0 && len(username) > ub.maxLen {
+			return "", fmt.Errorf("username template rendered a username of %d characters, longer than the maximum of %d", len(username), ub.maxLen)
+		}
+		return username, nil
+	}
+
 	userUUID, err := RandomAlphaNumeric(20, false)
 	if err != nil {
 		return "", err
@@ -88,6 +112,40 @@ func ToUpper() UsernameOpt {
 	return Case(Uppercase)
 }
 
+// Template generates the username by rendering tmpl with data, typically the
+// UsernameMetadata of the request, instead of following the other options
+// except MaxLength: usernames longer than it are rejected. A nil template is
+// ignored, so that the plugin's default format applies when no username
+// template is configured.
+func Template(tmpl *template.StringTemplate, data interface{}) UsernameOpt {
+	return func(b *usernameBuilder) {
+		b.template = tmpl
+		b.templateData = data
+	}
+}
+
+// UsernameTemplateFromConfig parses the username template set in the given
+// connection configuration. It returns nil if no template is set.
+func UsernameTemplateFromConfig(config map[string]interface{}) (*template.StringTemplate, error) {
+	raw, ok := config[UsernameTemplateKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	rawTemplate, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a string", UsernameTemplateKey)
+	}
+	if rawTemplate == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.NewTemplate(template.Template(rawTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", UsernameTemplateKey, err)
+	}
+	return &tmpl, nil
+}
+
 func GenerateUsername(opts ...UsernameOpt) (string, error) {
 	b := usernameBuilder{
 		separator:     "_",
@@ -2,6 +2,7 @@ package credsutil
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -142,3 +143,76 @@ func TestGenerateUsername(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateUsername_Template(t *testing.T) {
+	metadata := struct {
+		DisplayName string
+		RoleName    string
+	}{
+		DisplayName: "token",
+		RoleName:    "readonly",
+	}
+
+	tmpl, err := UsernameTemplateFromConfig(map[string]interface{}{
+		UsernameTemplateKey: `{{ printf "%s_%s_%s" .RoleName .DisplayName (random 8) | uppercase }}`,
+	})
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+
+	// The other options are ignored when a template is set
+	username, err := GenerateUsername(
+		RoleName(metadata.RoleName, 2),
+		MaxLength(30),
+		Template(tmpl, metadata),
+	)
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	re := regexp.MustCompile("^READONLY_TOKEN_[A-Z0-9]{8}$")
+	if !re.MatchString(username) {
+		t.Fatalf("username %q does not match regex %q", username, re)
+	}
+
+	// Usernames longer than the maximum length are rejected
+	_, err = GenerateUsername(
+		MaxLength(10),
+		Template(tmpl, metadata),
+	)
+	if err == nil {
+		t.Fatalf("error expected for a username longer than the maximum length")
+	}
+
+	// A nil template falls back to the default format
+	username, err = GenerateUsername(
+		RoleName(metadata.RoleName, 2),
+		Template(nil, metadata),
+	)
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if !strings.HasPrefix(username, "v_re_") {
+		t.Fatalf("expected default username format, got %q", username)
+	}
+}
+
+func TestUsernameTemplateFromConfig(t *testing.T) {
+	tmpl, err := UsernameTemplateFromConfig(map[string]interface{}{})
+	if err != nil || tmpl != nil {
+		t.Fatalf("expected no template, got %v: %v", tmpl, err)
+	}
+
+	_, err = UsernameTemplateFromConfig(map[string]interface{}{
+		UsernameTemplateKey: "{{ .RoleName",
+	})
+	if err == nil {
+		t.Fatalf("error expected for invalid template")
+	}
+
+	_, err = UsernameTemplateFromConfig(map[string]interface{}{
+		UsernameTemplateKey: 42,
+	})
+	if err == nil {
+		t.Fatalf("error expected for non-string template")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-kms-wrapping/entropy"
@@ -67,6 +68,20 @@ type Backend struct {
 	// invoked just before the backend is unmounted).
 	PeriodicFunc periodicFunc
 
+	// PeriodicJobs are named jobs which, like PeriodicFunc, are invoked when
+	// the periodic timer of RollbackManager ticks, but each on its own
+	// schedule. See PeriodicJob.
+	//
+	// PeriodicJobConcurrency is the maximum number of jobs of the backend
+	// running at once. If zero, jobs run one at a time.
+	PeriodicJobs           []*PeriodicJob
+	PeriodicJobConcurrency int
+
+	// RotationManager, if set, rotates the credentials used by the backend
+	// on schedules. Its paths are added to Paths and the job running the
+	// scheduled rotations to PeriodicJobs. See RotationManager.
+	RotationManager *RotationManager
+
 	// WALRollback is called when a WAL entry (see wal.go) has to be rolled
 	// back. It is called with the data from the entry.
 	//
@@ -91,10 +106,19 @@ type Backend struct {
 	// Type is the logical.BackendType for the backend implementation
 	BackendType logical.BackendType
 
-	logger  log.Logger
-	system  logical.SystemView
-	once    sync.Once
-	pathsRe []*regexp.Regexp
+	// ExportStateFunc and ImportStateFunc, if set, export and import the
+	// data of the backend in a storage-independent format, so that it can be
+	// moved to another version of the backend or to another mount. If not
+	// set, ExportState and ImportState return
+	// logical.ErrUnsupportedOperation.
+	ExportStateFunc ExportStateFunc
+	ImportStateFunc ImportStateFunc
+
+	logger   log.Logger
+	system   logical.SystemView
+	once     sync.Once
+	pathsRe  []*regexp.Regexp
+	periodic periodicJobs
 }
 
 // periodicFunc is the callback called when the RollbackManager's timer ticks.
@@ -116,6 +140,12 @@ type CleanupFunc func(context.Context)
 // InvalidateFunc is the callback for backend key invalidation.
 type InvalidateFunc func(context.Context, string)
 
+// ExportStateFunc is the callback for exporting the data of the backend.
+type ExportStateFunc func(context.Context, logical.Storage) (*logical.BackendState, error)
+
+// ImportStateFunc is the callback for importing the data of the backend.
+type ImportStateFunc func(context.Context, logical.Storage, *logical.BackendState) error
+
 // InitializeFunc is the callback, which if set, will be invoked via
 // Initialize() just after a plugin has been mounted.
 type InitializeFunc func(context.Context, *logical.InitializationRequest) error
@@ -128,6 +158,22 @@ func (b *Backend) Initialize(ctx context.Context, req *logical.InitializationReq
 	return nil
 }
 
+// ExportState is the logical.StateBackend implementation.
+func (b *Backend) ExportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	if b.ExportStateFunc == nil {
+		return nil, logical.ErrUnsupportedOperation
+	}
+	return b.ExportStateFunc(ctx, s)
+}
+
+// ImportState is the logical.StateBackend implementation.
+func (b *Backend) ImportState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	if b.ImportStateFunc == nil {
+		return logical.ErrUnsupportedOperation
+	}
+	return b.ImportStateFunc(ctx, s, state)
+}
+
 // HandleExistenceCheck is the logical.Backend implementation.
 func (b *Backend) HandleExistenceCheck(ctx context.Context, req *logical.Request) (checkFound bool, exists bool, err error) {
 	b.once.Do(b.init)
@@ -223,9 +269,11 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 	// Look up the callback for this operation, preferring the
 	// path.Operations definition if present.
 	var callback OperationFunc
+	deprecated := path.Deprecated
 
 	if path.Operations != nil {
 		if op, ok := path.Operations[req.Operation]; ok {
+			deprecated = deprecated || op.Properties().Deprecated
 
 			// Check whether this operation should be forwarded
 			if sysView := b.System(); sysView != nil {
@@ -269,7 +317,63 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		}
 	}
 
-	return callback(ctx, req, &fd)
+	resp, err := callback(ctx, req, &fd)
+	if err != nil || req.Operation == logical.HelpOperation {
+		return resp, err
+	}
+
+	if warnings := deprecationWarnings(req, path, deprecated); len(warnings) > 0 {
+		if resp == nil {
+			resp = &logical.Response{}
+		}
+		for _, warning := range warnings {
+			resp.AddWarning(warning)
+		}
+	}
+
+	return resp, nil
+}
+
+// deprecationWarnings returns a warning for the request if it targets a
+// deprecated path or operation, and for each deprecated field it sets. The use
+// of each deprecated feature is counted, so that its remaining consumers can
+// be found before it is removed.
+func deprecationWarnings(req *logical.Request, path *Path, deprecated bool) []string {
+	var warnings []string
+
+	if deprecated {
+		warnings = append(warnings, deprecationWarning(fmt.Sprintf("endpoint %q", req.Path), path.RemovalVersion))
+		metrics.IncrCounterWithLabels([]string{"deprecated", "path", "used"}, 1, []metrics.Label{
+			{Name: "mount_type", Value: req.MountType},
+			{Name: "path", Value: path.Pattern},
+		})
+	}
+
+	fields := make([]string, 0, len(req.Data))
+	for name := range req.Data {
+		if schema, ok := path.Fields[name]; ok && schema.Deprecated {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, name := range fields {
+		warnings = append(warnings, deprecationWarning(fmt.Sprintf("field %q", name), path.Fields[name].RemovalVersion))
+		metrics.IncrCounterWithLabels([]string{"deprecated", "field", "used"}, 1, []metrics.Label{
+			{Name: "mount_type", Value: req.MountType},
+			{Name: "path", Value: path.Pattern},
+			{Name: "field", Value: name},
+		})
+	}
+
+	return warnings
+}
+
+func deprecationWarning(feature, removalVersion string) string {
+	if removalVersion == "" {
+		return fmt.Sprintf("Deprecated: %s is deprecated and may be removed in a future release", feature)
+	}
+	return fmt.Sprintf("Deprecated: %s is deprecated and will be removed in Vault %s", feature, removalVersion)
 }
 
 // SpecialPaths is the logical.Backend implementation.
@@ -347,6 +451,13 @@ func (b *Backend) Secret(k string) *Secret {
 }
 
 func (b *Backend) init() {
+	if b.RotationManager != nil {
+		b.RotationManager.validate()
+		b.RotationManager.system = b.System
+		b.Paths = append(b.Paths, b.RotationManager.paths()...)
+		b.PeriodicJobs = append(b.PeriodicJobs, b.RotationManager.periodicJob())
+	}
+
 	b.pathsRe = make([]*regexp.Regexp, len(b.Paths))
 	for i, p := range b.Paths {
 		if len(p.Pattern) == 0 {
@@ -361,6 +472,8 @@ func (b *Backend) init() {
 		}
 		b.pathsRe[i] = regexp.MustCompile(p.Pattern)
 	}
+
+	b.validatePeriodicJobs()
 }
 
 func (b *Backend) route(path string) (*Path, map[string]string) {
@@ -463,8 +576,8 @@ func (b *Backend) handleRevokeRenew(ctx context.Context, req *logical.Request) (
 	}
 }
 
-// handleRollback invokes the PeriodicFunc set on the backend. It also does a
-// WAL rollback operation.
+// handleRollback invokes the PeriodicFunc and the due PeriodicJobs set on the
+// backend. It also does a WAL rollback operation.
 func (b *Backend) handleRollback(ctx context.Context, req *logical.Request) (*logical.Response, error) {
 	// Response is not expected from the periodic operation.
 	var resp *logical.Response
@@ -476,6 +589,12 @@ func (b *Backend) handleRollback(ctx context.Context, req *logical.Request) (*lo
 		}
 	}
 
+	if len(b.PeriodicJobs) > 0 {
+		if err := b.runPeriodicJobs(ctx, req); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
 	if b.WALRollback != nil {
 		var err error
 		resp, err = b.handleWALRollback(ctx, req)
@@ -562,6 +681,11 @@ type FieldSchema struct {
 	Required    bool
 	Deprecated  bool
 
+	// RemovalVersion is the Vault version in which a deprecated field is
+	// expected to be removed. It is reported in the warning returned when the
+	// field is used.
+	RemovalVersion string
+
 	// Query indicates this field will be sent as a query parameter:
 	//
 	//   /v1/foo/bar?some_param=some_value
@@ -543,6 +543,69 @@ func TestBackendHandleRequest_urlPriority(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_deprecated(t *testing.T) {
+	callback := func(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return nil, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo",
+				Fields: map[string]*FieldSchema{
+					"value": &FieldSchema{Type: TypeInt},
+					"old":   &FieldSchema{Type: TypeInt, Deprecated: true, RemovalVersion: "1.10"},
+					"older": &FieldSchema{Type: TypeInt, Deprecated: true},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: callback,
+				},
+			},
+			&Path{
+				Pattern:        "legacy",
+				Deprecated:     true,
+				RemovalVersion: "1.10",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: callback,
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		path     string
+		data     map[string]interface{}
+		warnings []string
+	}{
+		{"foo", map[string]interface{}{"value": 1}, nil},
+		{"foo", map[string]interface{}{"value": 1, "old": 2, "older": 3}, []string{
+			`Deprecated: field "old" is deprecated and will be removed in Vault 1.10`,
+			`Deprecated: field "older" is deprecated and may be removed in a future release`,
+		}},
+		{"legacy", nil, []string{
+			`Deprecated: endpoint "legacy" is deprecated and will be removed in Vault 1.10`,
+		}},
+	}
+
+	for _, tc := range testCases {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      tc.path,
+			Data:      tc.data,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		var warnings []string
+		if resp != nil {
+			warnings = resp.Warnings
+		}
+		if !reflect.DeepEqual(warnings, tc.warnings) {
+			t.Fatalf("bad warnings for %v: %#v", tc.data, warnings)
+		}
+	}
+}
+
 func TestBackendRoute(t *testing.T) {
 	cases := map[string]struct {
 		Patterns []string
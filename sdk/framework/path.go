@@ -96,9 +96,15 @@ type Path struct {
 	FeatureRequired license.Features
 
 	// Deprecated denotes that this path is considered deprecated. This may
-	// be reflected in help and documentation.
+	// be reflected in help and documentation. Requests to a deprecated path
+	// receive a warning.
 	Deprecated bool
 
+	// RemovalVersion is the Vault version in which a deprecated path is
+	// expected to be removed. It is reported in the warning returned to
+	// requests to the path.
+	RemovalVersion string
+
 	// Help is text describing how to use this path. This will be used
 	// to auto-generate the help operation. The Path will automatically
 	// generate a parameter listing and URL structure based on the
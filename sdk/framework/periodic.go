@@ -0,0 +1,159 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// PeriodicJob is a named job which is invoked when the periodic timer of
+// RollbackManager ticks, at most once per Interval. Backends use jobs to do
+// work such as rotation queues and tidy operations on their own schedules.
+type PeriodicJob struct {
+	// Name identifies the job in errors. It must be unique within the
+	// backend.
+	Name string
+
+	// Interval is the minimum time between two runs of the job. If zero, the
+	// job runs on every tick.
+	Interval time.Duration
+
+	// Jitter is the maximum random delay added to Interval, so that the runs
+	// of the same job on many mounts are spread out rather than aligned.
+	Jitter time.Duration
+
+	// Func is the callback invoked to run the job. If the job is still running
+	// from an earlier tick, it is skipped rather than run again concurrently.
+	Func PeriodicJobFunc
+}
+
+// PeriodicJobFunc is the callback called to run a PeriodicJob.
+type PeriodicJobFunc func(context.Context, *logical.Request) error
+
+// periodicJobState is the schedule of a PeriodicJob.
+type periodicJobState struct {
+	nextRun time.Time
+	running bool
+}
+
+// periodicJobs tracks the schedules of the periodic jobs of a backend.
+type periodicJobs struct {
+	sync.Mutex
+	states map[string]*periodicJobState
+	now    func() time.Time
+}
+
+func (b *Backend) validatePeriodicJobs() {
+	names := make(map[string]struct{}, len(b.PeriodicJobs))
+	for _, job := range b.PeriodicJobs {
+		if job.Name == "" {
+			panic("Periodic job name cannot be blank")
+		}
+		if _, ok := names[job.Name]; ok {
+			panic(fmt.Sprintf("Periodic job %q is registered more than once", job.Name))
+		}
+		if job.Func == nil {
+			panic(fmt.Sprintf("Periodic job %q has no callback", job.Name))
+		}
+		if job.Interval < 0 || job.Jitter < 0 {
+			panic(fmt.Sprintf("Periodic job %q has a negative interval or jitter", job.Name))
+		}
+		names[job.Name] = struct{}{}
+	}
+
+	b.periodic.states = make(map[string]*periodicJobState, len(b.PeriodicJobs))
+	if b.periodic.now == nil {
+		b.periodic.now = time.Now
+	}
+}
+
+// dueJobs returns the jobs whose next run has passed and which aren't already
+// running, marking them as running and scheduling their next run.
+func (b *Backend) dueJobs() []*PeriodicJob {
+	b.periodic.Lock()
+	defer b.periodic.Unlock()
+
+	now := b.periodic.now()
+
+	var due []*PeriodicJob
+	for _, job := range b.PeriodicJobs {
+		state, ok := b.periodic.states[job.Name]
+		if !ok {
+			// Spread the first runs out as well, as many mounts are
+			// typically set up when Vault is unsealed
+			state = &periodicJobState{
+				nextRun: now.Add(jitter(job.Jitter)),
+			}
+			b.periodic.states[job.Name] = state
+		}
+		if state.running || now.Before(state.nextRun) {
+			continue
+		}
+
+		state.running = true
+		state.nextRun = now.Add(job.Interval + jitter(job.Jitter))
+		due = append(due, job)
+	}
+	return due
+}
+
+func (b *Backend) finishJob(job *PeriodicJob) {
+	b.periodic.Lock()
+	defer b.periodic.Unlock()
+
+	b.periodic.states[job.Name].running = false
+}
+
+// runPeriodicJobs runs the jobs that are due, at most PeriodicJobConcurrency
+// at a time, and waits for them to finish.
+func (b *Backend) runPeriodicJobs(ctx context.Context, req *logical.Request) error {
+	b.once.Do(b.init)
+
+	due := b.dueJobs()
+	if len(due) == 0 {
+		return nil
+	}
+
+	concurrency := b.PeriodicJobConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var merr *multierror.Error
+	for _, job := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(job *PeriodicJob) {
+			defer func() {
+				b.finishJob(job)
+				<-sem
+				wg.Done()
+			}()
+
+			if err := job.Func(ctx, req); err != nil {
+				lock.Lock()
+				merr = multierror.Append(merr, errwrap.Wrapf(fmt.Sprintf("periodic job %q failed: {{err}}", job.Name), err))
+				lock.Unlock()
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	return merr.ErrorOrNil()
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
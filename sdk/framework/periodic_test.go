@@ -0,0 +1,193 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestBackend_PeriodicJobs(t *testing.T) {
+	var tidyRuns, rotateRuns uint32
+	b := &Backend{
+		PeriodicJobs: []*PeriodicJob{
+			{
+				Name:     "tidy",
+				Interval: time.Hour,
+				Func: func(context.Context, *logical.Request) error {
+					atomic.AddUint32(&tidyRuns, 1)
+					return nil
+				},
+			},
+			{
+				Name: "rotate",
+				Func: func(context.Context, *logical.Request) error {
+					atomic.AddUint32(&rotateRuns, 1)
+					return errors.New("rotation failed")
+				},
+			},
+		},
+	}
+
+	now := time.Now()
+	b.periodic.now = func() time.Time { return now }
+
+	rollback := func() error {
+		_, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.RollbackOperation,
+		})
+		return err
+	}
+
+	err := rollback()
+	if err == nil || !strings.Contains(err.Error(), `periodic job "rotate" failed: rotation failed`) {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The tidy job is not due again until its interval has passed
+	now = now.Add(time.Minute)
+	rollback()
+	if tidyRuns != 1 || rotateRuns != 2 {
+		t.Fatalf("bad: tidy %d, rotate %d", tidyRuns, rotateRuns)
+	}
+
+	now = now.Add(time.Hour)
+	rollback()
+	if tidyRuns != 2 || rotateRuns != 3 {
+		t.Fatalf("bad: tidy %d, rotate %d", tidyRuns, rotateRuns)
+	}
+}
+
+func TestBackend_PeriodicJobsJitter(t *testing.T) {
+	var runs uint32
+	var ranAt time.Time
+	now := time.Now()
+	b := &Backend{
+		PeriodicJobs: []*PeriodicJob{
+			{
+				Name:     "tidy",
+				Interval: time.Hour,
+				Jitter:   10 * time.Minute,
+				Func: func(context.Context, *logical.Request) error {
+					atomic.AddUint32(&runs, 1)
+					ranAt = now
+					return nil
+				},
+			},
+		},
+	}
+
+	b.periodic.now = func() time.Time { return now }
+
+	// The first run is delayed by at most the jitter
+	for i := 0; i <= 10; i++ {
+		if err := b.runPeriodicJobs(context.Background(), &logical.Request{}); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if runs != 1 {
+		t.Fatalf("bad: %d", runs)
+	}
+
+	// The next run is delayed by the interval and at most the jitter
+	next := b.periodic.states["tidy"].nextRun.Sub(ranAt)
+	if next < time.Hour || next >= 70*time.Minute {
+		t.Fatalf("bad next run: %s", next)
+	}
+}
+
+func TestBackend_PeriodicJobsOverlap(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var runs uint32
+	b := &Backend{
+		PeriodicJobs: []*PeriodicJob{
+			{
+				Name: "rotate",
+				Func: func(context.Context, *logical.Request) error {
+					atomic.AddUint32(&runs, 1)
+					close(started)
+					<-release
+					return nil
+				},
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.runPeriodicJobs(context.Background(), &logical.Request{})
+	}()
+	<-started
+
+	// The job is still running, so it is skipped
+	if err := b.runPeriodicJobs(context.Background(), &logical.Request{}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	wg.Wait()
+
+	if runs != 1 {
+		t.Fatalf("bad: %d", runs)
+	}
+}
+
+func TestBackend_PeriodicJobsConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	job := func(context.Context, *logical.Request) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	b := &Backend{
+		PeriodicJobConcurrency: 2,
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		b.PeriodicJobs = append(b.PeriodicJobs, &PeriodicJob{Name: name, Func: job})
+	}
+
+	if err := b.runPeriodicJobs(context.Background(), &logical.Request{}); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning != 2 {
+		t.Fatalf("bad: %d", maxRunning)
+	}
+}
+
+func TestBackend_PeriodicJobsInvalid(t *testing.T) {
+	noop := func(context.Context, *logical.Request) error { return nil }
+
+	tests := map[string][]*PeriodicJob{
+		"blank name":  {{Func: noop}},
+		"no callback": {{Name: "tidy"}},
+		"duplicate":   {{Name: "tidy", Func: noop}, {Name: "tidy", Func: noop}},
+		"negative":    {{Name: "tidy", Interval: -time.Second, Func: noop}},
+	}
+	for name, jobs := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+			b := &Backend{PeriodicJobs: jobs}
+			b.runPeriodicJobs(context.Background(), &logical.Request{})
+		})
+	}
+}
@@ -0,0 +1,612 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// RotationStoragePrefix is the storage prefix under which a
+	// RotationManager stores the schedules and statuses of the credentials.
+	RotationStoragePrefix = "rotation/"
+
+	// MinRotationPeriod is the shortest period allowed for scheduled
+	// rotations.
+	MinRotationPeriod = time.Hour
+
+	// rotationRetryMin and rotationRetryMax bound the delay before retrying
+	// a failed scheduled rotation, which doubles with each failure.
+	rotationRetryMin = time.Minute
+	rotationRetryMax = time.Hour
+
+	rotationWindowFormat = "15:04"
+)
+
+// RotateFunc is the callback called to rotate a credential. The target is
+// empty for rotations without targets.
+type RotateFunc func(ctx context.Context, req *logical.Request, target string) error
+
+// Rotation is a kind of credential of a backend, such as the root
+// credentials used by the backend, which a RotationManager rotates.
+type Rotation struct {
+	// Name identifies the credential in the rotation paths, e.g. "root".
+	Name string
+
+	// Targets, if set, lists the credentials of this kind, which are then
+	// named "<Name>/<target>", e.g. the root credentials of each database
+	// connection. If not set, there is a single credential named Name.
+	Targets func(context.Context, logical.Storage) ([]string, error)
+
+	// Rotate is the callback invoked to rotate a credential.
+	Rotate RotateFunc
+}
+
+// RotationSchedule is the schedule on which a credential is rotated. A zero
+// Period disables scheduled rotations.
+type RotationSchedule struct {
+	// Period is the time between two rotations.
+	Period time.Duration `json:"period"`
+
+	// WindowStart and WindowDuration restrict scheduled rotations to a daily
+	// window starting WindowStart after midnight UTC. A zero WindowDuration
+	// allows rotations at any time of the day.
+	WindowStart    time.Duration `json:"window_start"`
+	WindowDuration time.Duration `json:"window_duration"`
+
+	// ScheduledTime is when the schedule was set, from which the first
+	// rotation of a credential never rotated before is scheduled.
+	ScheduledTime time.Time `json:"scheduled_time"`
+}
+
+// RotationStatus is the outcome of the rotations of a credential, whether
+// scheduled or requested.
+type RotationStatus struct {
+	LastRotation time.Time `json:"last_rotation"`
+	LastAttempt  time.Time `json:"last_attempt"`
+	LastError    string    `json:"last_error"`
+
+	// Failures is the number of consecutive failed rotations
+	Failures int `json:"failures"`
+}
+
+type rotationEntry struct {
+	Schedule RotationSchedule `json:"schedule"`
+	Status   RotationStatus   `json:"status"`
+}
+
+// RotationManager rotates the credentials of a backend on schedules, and
+// keeps track of the outcome of the rotations, so that backends share the
+// same schedules, status APIs and metrics for their credentials.
+//
+// Backends register their credentials by setting the RotationManager of
+// their Backend, which adds the rotation paths and a periodic job running
+// the scheduled rotations. Credentials are only rotated on a schedule once
+// one is set through the paths.
+type RotationManager struct {
+	Rotations []*Rotation
+
+	// MetricsPrefix is the prefix of the keys of the metrics emitted for the
+	// rotations, e.g. ["secrets", "aws"].
+	MetricsPrefix []string
+
+	// lock serializes the rotations, whether scheduled or requested
+	lock   sync.Mutex
+	now    func() time.Time
+	system func() logical.SystemView
+}
+
+func (m *RotationManager) validate() {
+	names := make(map[string]struct{}, len(m.Rotations))
+	for _, r := range m.Rotations {
+		if r.Name == "" || strings.Contains(r.Name, "/") {
+			panic(fmt.Sprintf("Rotation name %q must be non-blank and cannot contain slashes", r.Name))
+		}
+		if _, ok := names[r.Name]; ok {
+			panic(fmt.Sprintf("Rotation %q is registered more than once", r.Name))
+		}
+		if r.Rotate == nil {
+			panic(fmt.Sprintf("Rotation %q has no callback", r.Name))
+		}
+		names[r.Name] = struct{}{}
+	}
+}
+
+func (m *RotationManager) timeNow() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// NextRotation returns the time of the next scheduled rotation of the
+// credential with the given schedule and status, or the zero time if the
+// credential is not rotated on a schedule.
+func NextRotation(schedule RotationSchedule, status RotationStatus) time.Time {
+	if schedule.Period <= 0 {
+		return time.Time{}
+	}
+
+	next := schedule.ScheduledTime.Add(schedule.Period)
+	if !status.LastRotation.IsZero() {
+		next = status.LastRotation.Add(schedule.Period)
+	}
+
+	if status.Failures > 0 {
+		retry := rotationRetryMin
+		for i := 1; i < status.Failures && retry < rotationRetryMax; i++ {
+			retry *= 2
+		}
+		if retry > rotationRetryMax {
+			retry = rotationRetryMax
+		}
+		if retryTime := status.LastAttempt.Add(retry); retryTime.After(next) {
+			next = retryTime
+		}
+	}
+
+	if schedule.WindowDuration <= 0 {
+		return next
+	}
+
+	// Check the window of the day as well as the window of the previous day,
+	// which may span midnight
+	next = next.UTC()
+	start := next.Truncate(24 * time.Hour).Add(schedule.WindowStart)
+	for _, windowStart := range []time.Time{start.Add(-24 * time.Hour), start} {
+		if !next.Before(windowStart) && next.Before(windowStart.Add(schedule.WindowDuration)) {
+			return next
+		}
+	}
+	if next.Before(start) {
+		return start
+	}
+	return start.Add(24 * time.Hour)
+}
+
+// resolve returns the rotation and the target of the named credential, or
+// nil if there is no such credential.
+func (m *RotationManager) resolve(ctx context.Context, s logical.Storage, name string) (*Rotation, string, error) {
+	for _, r := range m.Rotations {
+		if r.Targets == nil {
+			if name == r.Name {
+				return r, "", nil
+			}
+			continue
+		}
+
+		target := strings.TrimPrefix(name, r.Name+"/")
+		if target == name || target == "" {
+			continue
+		}
+		targets, err := r.Targets(ctx, s)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, t := range targets {
+			if t == target {
+				return r, target, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// names returns the names of all the credentials of the rotations.
+func (m *RotationManager) names(ctx context.Context, s logical.Storage) ([]string, error) {
+	var names []string
+	for _, r := range m.Rotations {
+		if r.Targets == nil {
+			names = append(names, r.Name)
+			continue
+		}
+
+		targets, err := r.Targets(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			names = append(names, r.Name+"/"+target)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *RotationManager) entry(ctx context.Context, s logical.Storage, name string) (*rotationEntry, error) {
+	raw, err := s.Get(ctx, RotationStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &rotationEntry{}
+	if raw == nil {
+		return entry, nil
+	}
+	if err := raw.DecodeJSON(entry); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to decode the rotation of %q: {{err}}", name), err)
+	}
+	return entry, nil
+}
+
+func (m *RotationManager) putEntry(ctx context.Context, s logical.Storage, name string, entry *rotationEntry) error {
+	raw, err := logical.StorageEntryJSON(RotationStoragePrefix+name, entry)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, raw)
+}
+
+// Rotate rotates the named credential now and records the outcome in its
+// status. It returns the error of the rotation.
+func (m *RotationManager) Rotate(ctx context.Context, req *logical.Request, name string) error {
+	r, target, err := m.resolve(ctx, req.Storage, name)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("no credential named %q", name)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	start := time.Now()
+	rotateErr := r.Rotate(ctx, req, target)
+	metrics.MeasureSinceWithLabels(m.metricsKey("duration"), start, []metrics.Label{{Name: "name", Value: name}})
+
+	if err := m.Record(ctx, req.Storage, name, rotateErr); err != nil {
+		return multierror.Append(rotateErr, err).ErrorOrNil()
+	}
+	return rotateErr
+}
+
+// Record records the outcome of a rotation of the named credential done by
+// the backend itself, e.g. through a rotation endpoint of its own, so that
+// the status and the schedule of the credential take it into account.
+func (m *RotationManager) Record(ctx context.Context, s logical.Storage, name string, rotateErr error) error {
+	entry, err := m.entry(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	entry.Status.LastAttempt = m.timeNow()
+	if rotateErr != nil {
+		entry.Status.LastError = rotateErr.Error()
+		entry.Status.Failures++
+	} else {
+		entry.Status.LastRotation = entry.Status.LastAttempt
+		entry.Status.LastError = ""
+		entry.Status.Failures = 0
+	}
+
+	labels := []metrics.Label{{Name: "name", Value: name}}
+	if rotateErr != nil {
+		metrics.IncrCounterWithLabels(m.metricsKey("failure"), 1, labels)
+	} else {
+		metrics.IncrCounterWithLabels(m.metricsKey("success"), 1, labels)
+	}
+
+	return m.putEntry(ctx, s, name, entry)
+}
+
+// Status returns the schedule and the status of the named credential.
+func (m *RotationManager) Status(ctx context.Context, s logical.Storage, name string) (RotationSchedule, RotationStatus, error) {
+	entry, err := m.entry(ctx, s, name)
+	if err != nil {
+		return RotationSchedule{}, RotationStatus{}, err
+	}
+	return entry.Schedule, entry.Status, nil
+}
+
+// SetSchedule sets the schedule of the named credential, for backends
+// managing the schedules of their credentials through paths of their own.
+// The ScheduledTime of the schedule is set by the manager when its period
+// changes.
+func (m *RotationManager) SetSchedule(ctx context.Context, s logical.Storage, name string, schedule RotationSchedule) error {
+	if schedule.Period < 0 || (schedule.Period > 0 && schedule.Period < MinRotationPeriod) {
+		return fmt.Errorf("rotation period must be 0 to disable scheduled rotations, or at least %s", MinRotationPeriod)
+	}
+	if schedule.WindowDuration < 0 || schedule.WindowDuration > 24*time.Hour {
+		return fmt.Errorf("rotation window duration must be between 0 and 24h")
+	}
+
+	entry, err := m.entry(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	schedule.ScheduledTime = entry.Schedule.ScheduledTime
+	if schedule.Period != entry.Schedule.Period {
+		schedule.ScheduledTime = m.timeNow()
+	}
+	entry.Schedule = schedule
+
+	return m.putEntry(ctx, s, name, entry)
+}
+
+// Remove removes the schedule and the status of the named credential, for
+// backends to call when the credential is removed.
+func (m *RotationManager) Remove(ctx context.Context, s logical.Storage, name string) error {
+	return s.Delete(ctx, RotationStoragePrefix+name)
+}
+
+func (m *RotationManager) metricsKey(name string) []string {
+	key := make([]string, 0, len(m.MetricsPrefix)+2)
+	key = append(key, m.MetricsPrefix...)
+	return append(key, "rotation", name)
+}
+
+// periodicJob returns the job rotating the credentials whose scheduled
+// rotation is due.
+func (m *RotationManager) periodicJob() *PeriodicJob {
+	return &PeriodicJob{
+		Name: "rotation",
+		Func: m.rotateDue,
+	}
+}
+
+func (m *RotationManager) rotateDue(ctx context.Context, req *logical.Request) error {
+	// Rotations change credentials outside of Vault, so they must only run
+	// where the new credentials can be stored
+	if m.system != nil {
+		if sys := m.system(); sys != nil {
+			state := sys.ReplicationState()
+			if (!sys.LocalMount() && state.HasState(consts.ReplicationPerformanceSecondary)) ||
+				state.HasState(consts.ReplicationDRSecondary) ||
+				state.HasState(consts.ReplicationPerformanceStandby) {
+				return nil
+			}
+		}
+	}
+
+	names, err := m.names(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	var merr *multierror.Error
+	for _, name := range names {
+		entry, err := m.entry(ctx, req.Storage, name)
+		if err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+
+		next := NextRotation(entry.Schedule, entry.Status)
+		if next.IsZero() || m.timeNow().Before(next) {
+			continue
+		}
+
+		if err := m.Rotate(ctx, req, name); err != nil {
+			merr = multierror.Append(merr, errwrap.Wrapf(fmt.Sprintf("failed to rotate %q: {{err}}", name), err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// paths returns the paths to list the credentials, manage their schedules,
+// read their statuses and rotate them.
+func (m *RotationManager) paths() []*Path {
+	return []*Path{
+		&Path{
+			Pattern: "rotation/?$",
+
+			Callbacks: map[logical.Operation]OperationFunc{
+				logical.ListOperation: m.pathList,
+			},
+
+			HelpSynopsis: "List the credentials which can be rotated.",
+		},
+
+		&Path{
+			Pattern: "rotation/rotate/(?P<name>.+)",
+
+			Fields: map[string]*FieldSchema{
+				"name": &FieldSchema{
+					Type:        TypeString,
+					Description: "Name of the credential.",
+				},
+			},
+
+			Operations: map[logical.Operation]OperationHandler{
+				logical.UpdateOperation: &PathOperation{
+					Callback:                    m.pathRotate,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis:    "Rotate a credential now.",
+			HelpDescription: "Rotates the credential now, regardless of its schedule, and records the outcome in its status.",
+		},
+
+		&Path{
+			Pattern: "rotation/(?P<name>.+)",
+
+			Fields: map[string]*FieldSchema{
+				"name": &FieldSchema{
+					Type:        TypeString,
+					Description: "Name of the credential.",
+				},
+				"rotation_period": &FieldSchema{
+					Type:        TypeDurationSecond,
+					Description: fmt.Sprintf("Time between two scheduled rotations of the credential, at least %s. Zero disables scheduled rotations.", MinRotationPeriod),
+				},
+				"rotation_window_start": &FieldSchema{
+					Type:        TypeString,
+					Description: `Start of the daily window in which scheduled rotations happen, as "HH:MM" in UTC.`,
+				},
+				"rotation_window_duration": &FieldSchema{
+					Type:        TypeDurationSecond,
+					Description: "Duration of the daily window in which scheduled rotations happen. Zero allows rotations at any time of the day.",
+				},
+			},
+
+			Operations: map[logical.Operation]OperationHandler{
+				logical.ReadOperation: &PathOperation{
+					Callback: m.pathRead,
+				},
+				logical.UpdateOperation: &PathOperation{
+					Callback:                    m.pathWrite,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+				logical.DeleteOperation: &PathOperation{
+					Callback:                    m.pathDelete,
+					ForwardPerformanceStandby:   true,
+					ForwardPerformanceSecondary: true,
+				},
+			},
+
+			HelpSynopsis: "Manage the rotation schedule of a credential and read its status.",
+			HelpDescription: `Reads the rotation schedule of the credential along with the outcome of its
+latest rotations and the time of its next scheduled rotation. Writes set the
+schedule and deletes disable scheduled rotations.`,
+		},
+	}
+}
+
+func (m *RotationManager) pathList(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+	names, err := m.names(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+func (m *RotationManager) pathRotate(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, _, err := m.resolve(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return logical.ErrorResponse(fmt.Sprintf("no credential named %q", name)), nil
+	}
+
+	if err := m.Rotate(ctx, req, name); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to rotate %q: {{err}}", name), err)
+	}
+	return m.pathRead(ctx, req, data)
+}
+
+func (m *RotationManager) pathRead(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, _, err := m.resolve(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	entry, err := m.entry(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	formatTime := func(t time.Time) interface{} {
+		if t.IsZero() {
+			return nil
+		}
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	respData := map[string]interface{}{
+		"rotation_period":          int64(entry.Schedule.Period.Seconds()),
+		"rotation_window_start":    "",
+		"rotation_window_duration": int64(entry.Schedule.WindowDuration.Seconds()),
+		"last_rotation":            formatTime(entry.Status.LastRotation),
+		"last_attempt":             formatTime(entry.Status.LastAttempt),
+		"last_error":               entry.Status.LastError,
+		"failures":                 entry.Status.Failures,
+		"next_rotation":            formatTime(NextRotation(entry.Schedule, entry.Status)),
+	}
+	if entry.Schedule.WindowDuration > 0 {
+		respData["rotation_window_start"] = time.Time{}.Add(entry.Schedule.WindowStart).Format(rotationWindowFormat)
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+func (m *RotationManager) pathWrite(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, _, err := m.resolve(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return logical.ErrorResponse(fmt.Sprintf("no credential named %q", name)), nil
+	}
+
+	schedule, _, err := m.Status(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if periodRaw, ok := data.GetOk("rotation_period"); ok {
+		period := time.Duration(periodRaw.(int)) * time.Second
+		if period < 0 || (period > 0 && period < MinRotationPeriod) {
+			return logical.ErrorResponse(fmt.Sprintf("rotation_period must be 0 to disable scheduled rotations, or at least %s", MinRotationPeriod)), nil
+		}
+		schedule.Period = period
+	}
+
+	if startRaw, ok := data.GetOk("rotation_window_start"); ok {
+		start, err := time.Parse(rotationWindowFormat, startRaw.(string))
+		if err != nil {
+			return logical.ErrorResponse(`rotation_window_start must be a time of the day formatted as "HH:MM"`), nil
+		}
+		schedule.WindowStart = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	}
+
+	if durationRaw, ok := data.GetOk("rotation_window_duration"); ok {
+		duration := time.Duration(durationRaw.(int)) * time.Second
+		if duration < 0 || duration > 24*time.Hour {
+			return logical.ErrorResponse("rotation_window_duration must be between 0 and 24h"), nil
+		}
+		schedule.WindowDuration = duration
+	}
+
+	if err := m.SetSchedule(ctx, req.Storage, name, schedule); err != nil {
+		return nil, err
+	}
+	return m.pathRead(ctx, req, data)
+}
+
+func (m *RotationManager) pathDelete(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	r, _, err := m.resolve(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	entry, err := m.entry(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the status of the credential, which is still relevant to
+	// rotations requested outside of the schedule
+	entry.Schedule = RotationSchedule{}
+	if err := m.putEntry(ctx, req.Storage, name, entry); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
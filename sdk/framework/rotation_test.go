@@ -0,0 +1,211 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestNextRotation(t *testing.T) {
+	day := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduled := RotationSchedule{
+		Period:        24 * time.Hour,
+		ScheduledTime: day,
+	}
+	windowed := scheduled
+	windowed.WindowStart = 23 * time.Hour
+	windowed.WindowDuration = 2 * time.Hour
+	morning := scheduled
+	morning.WindowStart = 2 * time.Hour
+	morning.WindowDuration = time.Hour
+
+	tests := map[string]struct {
+		schedule RotationSchedule
+		status   RotationStatus
+		expected time.Time
+	}{
+		"not scheduled": {
+			schedule: RotationSchedule{},
+			status:   RotationStatus{LastRotation: day},
+		},
+		"never rotated": {
+			schedule: scheduled,
+			expected: day.Add(24 * time.Hour),
+		},
+		"rotated": {
+			schedule: scheduled,
+			status:   RotationStatus{LastRotation: day.Add(time.Hour)},
+			expected: day.Add(25 * time.Hour),
+		},
+		"retry after failures": {
+			schedule: scheduled,
+			status: RotationStatus{
+				LastRotation: day,
+				LastAttempt:  day.Add(24 * time.Hour),
+				Failures:     3,
+			},
+			expected: day.Add(24*time.Hour + 4*time.Minute),
+		},
+		"retry delay is bounded": {
+			schedule: scheduled,
+			status: RotationStatus{
+				LastRotation: day,
+				LastAttempt:  day.Add(24 * time.Hour),
+				Failures:     100,
+			},
+			expected: day.Add(25 * time.Hour),
+		},
+		"in the window past midnight": {
+			schedule: windowed,
+			expected: day.Add(24 * time.Hour),
+		},
+		"in the window before midnight": {
+			schedule: windowed,
+			status:   RotationStatus{LastRotation: day.Add(-30 * time.Minute)},
+			expected: day.Add(23*time.Hour + 30*time.Minute),
+		},
+		"before the window": {
+			schedule: windowed,
+			status:   RotationStatus{LastRotation: day.Add(2 * time.Hour)},
+			expected: day.Add(47 * time.Hour),
+		},
+		"after the window": {
+			schedule: morning,
+			status:   RotationStatus{LastRotation: day.Add(4 * time.Hour)},
+			expected: day.Add(50 * time.Hour),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			next := NextRotation(tc.schedule, tc.status)
+			if !next.Equal(tc.expected) {
+				t.Fatalf("expected %s, got %s", tc.expected, next)
+			}
+		})
+	}
+}
+
+func TestRotationManager(t *testing.T) {
+	var rotated []string
+	var rotateErr error
+	m := &RotationManager{
+		Rotations: []*Rotation{
+			{
+				Name: "root",
+				Rotate: func(_ context.Context, _ *logical.Request, target string) error {
+					rotated = append(rotated, "root"+target)
+					return rotateErr
+				},
+			},
+			{
+				Name: "conn",
+				Targets: func(context.Context, logical.Storage) ([]string, error) {
+					return []string{"a", "b/c"}, nil
+				},
+				Rotate: func(_ context.Context, _ *logical.Request, target string) error {
+					rotated = append(rotated, "conn/"+target)
+					return rotateErr
+				},
+			},
+		},
+	}
+	b := &Backend{RotationManager: m}
+
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	b.periodic.now = m.now
+
+	storage := &logical.InmemStorage{}
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   storage,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	rollback := func() error {
+		_, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.RollbackOperation,
+			Storage:   storage,
+		})
+		return err
+	}
+
+	resp := request(logical.ListOperation, "rotation/", nil)
+	if keys := resp.Data["keys"]; !reflect.DeepEqual(keys, []string{"conn/a", "conn/b/c", "root"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	resp = request(logical.UpdateOperation, "rotation/conn/b/c", map[string]interface{}{
+		"rotation_period":          "1m",
+		"rotation_window_start":    "02:00",
+		"rotation_window_duration": "1h",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "rotation/conn/b/c", map[string]interface{}{
+		"rotation_period":          "24h",
+		"rotation_window_start":    "02:00",
+		"rotation_window_duration": "1h",
+	})
+	if resp.Data["next_rotation"] != "2030-01-03T02:00:00Z" || resp.Data["rotation_window_start"] != "02:00" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp := request(logical.ReadOperation, "rotation/conn/d", nil); resp != nil {
+		t.Fatalf("expected no credential, got %#v", resp)
+	}
+
+	// Nothing is due yet
+	if err := rollback(); err != nil || len(rotated) != 0 {
+		t.Fatalf("bad: %v, %v", err, rotated)
+	}
+
+	// A requested rotation counts as the latest rotation of the schedule
+	resp = request(logical.UpdateOperation, "rotation/rotate/root", nil)
+	if resp.Data["last_rotation"] != "2030-01-01T12:00:00Z" || resp.Data["next_rotation"] != nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	now = time.Date(2030, 1, 3, 2, 30, 0, 0, time.UTC)
+	rotateErr = errors.New("unreachable")
+	if err := rollback(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !reflect.DeepEqual(rotated, []string{"root", "conn/b/c"}) {
+		t.Fatalf("bad rotations: %v", rotated)
+	}
+	resp = request(logical.ReadOperation, "rotation/conn/b/c", nil)
+	if resp.Data["failures"] != 1 || resp.Data["last_error"] != "unreachable" || resp.Data["next_rotation"] != "2030-01-03T02:31:00Z" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The failed rotation is retried
+	now = now.Add(time.Minute)
+	rotateErr = nil
+	if err := rollback(); err != nil {
+		t.Fatal(err)
+	}
+	resp = request(logical.ReadOperation, "rotation/conn/b/c", nil)
+	if resp.Data["failures"] != 0 || resp.Data["last_rotation"] != "2030-01-03T02:31:00Z" || resp.Data["next_rotation"] != "2030-01-04T02:31:00Z" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Disabling the schedule keeps the status
+	request(logical.DeleteOperation, "rotation/conn/b/c", nil)
+	resp = request(logical.ReadOperation, "rotation/conn/b/c", nil)
+	if resp.Data["rotation_period"] != int64(0) || resp.Data["next_rotation"] != nil || resp.Data["last_rotation"] != "2030-01-03T02:31:00Z" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
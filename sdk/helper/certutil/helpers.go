@@ -164,6 +164,10 @@ func ParsePEMBundle(pemBundle string) (*ParsedCertBundle, error) {
 				parsedBundle.PrivateKey = signer
 				parsedBundle.PrivateKeyType = ECPrivateKey
 				parsedBundle.PrivateKeyBytes = pemBlock.Bytes
+			case ed25519.PrivateKey:
+				parsedBundle.PrivateKey = signer
+				parsedBundle.PrivateKeyType = Ed25519PrivateKey
+				parsedBundle.PrivateKeyBytes = pemBlock.Bytes
 			}
 		} else if certificates, err := x509.ParseCertificates(pemBlock.Bytes); err == nil {
 			certPath = append(certPath, &CertBlock{
@@ -229,6 +233,17 @@ func GeneratePrivateKey(keyType string, keyBits int, container ParsedPrivateKeyC
 		if err != nil {
 			return errutil.InternalError{Err: fmt.Sprintf("error marshalling EC private key: %v", err)}
 		}
+	case "ed25519":
+		// Ed25519 keys have a fixed size, so keyBits is ignored
+		privateKeyType = Ed25519PrivateKey
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error generating Ed25519 private key: %v", err)}
+		}
+		privateKeyBytes, err = x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("error marshalling Ed25519 private key: %v", err)}
+		}
 	default:
 		return errutil.UserError{Err: fmt.Sprintf("unknown key type: %s", keyType)}
 	}
@@ -283,12 +298,20 @@ func ComparePublicKeys(key1Iface, key2Iface crypto.PublicKey) (bool, error) {
 		}
 		return true, nil
 
+	case ed25519.PublicKey:
+		key1 := key1Iface.(ed25519.PublicKey)
+		key2, ok := key2Iface.(ed25519.PublicKey)
+		if !ok {
+			return false, fmt.Errorf("key types do not match: %T and %T", key1Iface, key2Iface)
+		}
+		return bytes.Equal(key1, key2), nil
+
 	default:
 		return false, fmt.Errorf("cannot compare key with type %T", key1Iface)
 	}
 }
 
-// ParsePublicKeyPEM is used to parse RSA and ECDSA public keys from PEMs
+// ParsePublicKeyPEM is used to parse RSA, ECDSA and Ed25519 public keys from PEMs
 func ParsePublicKeyPEM(data []byte) (interface{}, error) {
 	block, data := pem.Decode(data)
 	if block != nil {
@@ -327,6 +350,48 @@ func AddPolicyIdentifiers(data *CreationBundle, certTemplate *x509.Certificate)
 	}
 }
 
+// AddNameConstraints adds the name constraints extension, marked critical, to
+// the template given the creation information. Name constraints only apply to
+// CA certificates, so nothing is added to other templates.
+func AddNameConstraints(data *CreationBundle, certTemplate *x509.Certificate) {
+	if !certTemplate.IsCA {
+		return
+	}
+
+	params := data.Params
+	certTemplate.PermittedDNSDomains = params.PermittedDNSDomains
+	certTemplate.ExcludedDNSDomains = params.ExcludedDNSDomains
+	certTemplate.PermittedIPRanges = params.PermittedIPRanges
+	certTemplate.ExcludedIPRanges = params.ExcludedIPRanges
+	certTemplate.PermittedEmailAddresses = params.PermittedEmailAddresses
+	certTemplate.ExcludedEmailAddresses = params.ExcludedEmailAddresses
+	certTemplate.PermittedURIDomains = params.PermittedURIDomains
+	certTemplate.ExcludedURIDomains = params.ExcludedURIDomains
+
+	certTemplate.PermittedDNSDomainsCritical = len(params.PermittedDNSDomains) > 0 ||
+		len(params.ExcludedDNSDomains) > 0 ||
+		len(params.PermittedIPRanges) > 0 ||
+		len(params.ExcludedIPRanges) > 0 ||
+		len(params.PermittedEmailAddresses) > 0 ||
+		len(params.ExcludedEmailAddresses) > 0 ||
+		len(params.PermittedURIDomains) > 0 ||
+		len(params.ExcludedURIDomains) > 0
+}
+
+// AddCustomExtensions adds the custom extensions to the template, replacing
+// any extension with the same OID already in it, such as one copied from a CSR
+func AddCustomExtensions(data *CreationBundle, certTemplate *x509.Certificate) {
+	for _, custom := range data.Params.CustomExtensions {
+		var extensions []pkix.Extension
+		for _, ext := range certTemplate.ExtraExtensions {
+			if !ext.Id.Equal(custom.Id) {
+				extensions = append(extensions, ext)
+			}
+		}
+		certTemplate.ExtraExtensions = append(extensions, custom)
+	}
+}
+
 // addExtKeyUsageOids adds custom extended key usage OIDs to certificate
 func AddExtKeyUsageOids(data *CreationBundle, certTemplate *x509.Certificate) {
 	for _, oidstr := range data.Params.ExtKeyUsageOIDs {
@@ -483,6 +548,7 @@ func ValidateKeyTypeLength(keyType string, keyBits int) error {
 		default:
 			return fmt.Errorf("unsupported bit length for EC key: %d", keyBits)
 		}
+	case "ed25519":
 	case "any":
 	default:
 		return fmt.Errorf("unknown key type %s", keyType)
@@ -513,6 +579,12 @@ func CreateCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 		return nil, errutil.InternalError{Err: fmt.Sprintf("error getting subject key ID: %s", err)}
 	}
 
+	if data.Params.AltKeyType != "" {
+		if err := generateAltPrivateKey(data.Params.AltKeyType, result); err != nil {
+			return nil, err
+		}
+	}
+
 	certTemplate := &x509.Certificate{
 		SerialNumber:   serialNumber,
 		NotBefore:      time.Now().Add(-30 * time.Second),
@@ -541,14 +613,18 @@ func CreateCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 		certTemplate.IsCA = false
 	}
 
-	// This will only be filled in from the generation paths
-	if len(data.Params.PermittedDNSDomains) > 0 {
-		certTemplate.PermittedDNSDomains = data.Params.PermittedDNSDomains
-		certTemplate.PermittedDNSDomainsCritical = true
-	}
+	AddNameConstraints(data, certTemplate)
+
+	AddCustomExtensions(data, certTemplate)
 
 	AddPolicyIdentifiers(data, certTemplate)
 
+	if result.AltPrivateKey != nil {
+		if err := addAltPublicKey(certTemplate, result.AltPrivateKey.Public()); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error marshalling alternative public key: %s", err)}
+		}
+	}
+
 	AddKeyUsages(data, certTemplate)
 
 	AddExtKeyUsageOids(data, certTemplate)
@@ -564,12 +640,14 @@ func CreateCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 			certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 		case ECPrivateKey:
 			certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+		case Ed25519PrivateKey:
+			certTemplate.SignatureAlgorithm = x509.PureEd25519
 		}
 
 		caCert := data.SigningBundle.Certificate
 		certTemplate.AuthorityKeyId = caCert.SubjectKeyId
 
-		certBytes, err = x509.CreateCertificate(rand.Reader, certTemplate, caCert, result.PrivateKey.Public(), data.SigningBundle.PrivateKey)
+		certBytes, err = createCertificate(certTemplate, caCert, result.PrivateKey.Public(), data.SigningBundle.PrivateKey, data.SigningBundle.AltPrivateKey)
 	} else {
 		// Creating a self-signed root
 		if data.Params.MaxPathLength == 0 {
@@ -584,11 +662,13 @@ func CreateCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 			certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 		case "ec":
 			certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+		case "ed25519":
+			certTemplate.SignatureAlgorithm = x509.PureEd25519
 		}
 
 		certTemplate.AuthorityKeyId = subjKeyID
 		certTemplate.BasicConstraintsValid = true
-		certBytes, err = x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, result.PrivateKey.Public(), result.PrivateKey)
+		certBytes, err = createCertificate(certTemplate, certTemplate, result.PrivateKey.Public(), result.PrivateKey, result.AltPrivateKey)
 	}
 
 	if err != nil {
@@ -667,6 +747,8 @@ func CreateCSR(data *CreationBundle, addBasicConstraints bool) (*ParsedCSRBundle
 		csrTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 	case "ec":
 		csrTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+	case "ed25519":
+		csrTemplate.SignatureAlgorithm = x509.PureEd25519
 	}
 
 	csr, err := x509.CreateCertificateRequest(rand.Reader, csrTemplate, result.PrivateKey)
@@ -734,6 +816,8 @@ func SignCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 		certTemplate.SignatureAlgorithm = x509.SHA256WithRSA
 	case ECPrivateKey:
 		certTemplate.SignatureAlgorithm = x509.ECDSAWithSHA256
+	case Ed25519PrivateKey:
+		certTemplate.SignatureAlgorithm = x509.PureEd25519
 	}
 
 	if data.Params.UseCSRValues {
@@ -792,12 +876,11 @@ func SignCertificate(data *CreationBundle) (*ParsedCertBundle, error) {
 		certTemplate.IsCA = false
 	}
 
-	if len(data.Params.PermittedDNSDomains) > 0 {
-		certTemplate.PermittedDNSDomains = data.Params.PermittedDNSDomains
-		certTemplate.PermittedDNSDomainsCritical = true
-	}
+	AddNameConstraints(data, certTemplate)
+
+	AddCustomExtensions(data, certTemplate)
 
-	certBytes, err = x509.CreateCertificate(rand.Reader, certTemplate, caCert, data.CSR.PublicKey, data.SigningBundle.PrivateKey)
+	certBytes, err = createCertificate(certTemplate, caCert, data.CSR.PublicKey, data.SigningBundle.PrivateKey, data.SigningBundle.AltPrivateKey)
 
 	if err != nil {
 		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to create certificate: %s", err)}
@@ -0,0 +1,302 @@
+package certutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
+)
+
+// Hybrid certificates carry, besides their classical key and signature, an
+// alternative ML-DSA public key of the subject and an alternative ML-DSA
+// signature of the issuer, in the non-critical extensions defined by ITU-T
+// X.509 (10/2019) section 9.8. Relying parties which do not know these
+// extensions ignore them.
+var (
+	OIDSubjectAltPublicKeyInfo = asn1.ObjectIdentifier{2, 5, 29, 72}
+	OIDAltSignatureAlgorithm   = asn1.ObjectIdentifier{2, 5, 29, 73}
+	OIDAltSignatureValue       = asn1.ObjectIdentifier{2, 5, 29, 74}
+)
+
+// mldsaOIDs are the algorithm identifiers of the ML-DSA parameter sets, as
+// assigned by NIST
+var mldsaOIDs = map[string]asn1.ObjectIdentifier{
+	mldsautil.MLDSA44: {2, 16, 840, 1, 101, 3, 4, 3, 17},
+	mldsautil.MLDSA65: {2, 16, 840, 1, 101, 3, 4, 3, 18},
+	mldsautil.MLDSA87: {2, 16, 840, 1, 101, 3, 4, 3, 19},
+}
+
+// subjectPublicKeyInfo is the ASN.1 structure of SubjectPublicKeyInfo, which
+// is also the one of SubjectAltPublicKeyInfo.
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func mldsaParameterSet(oid asn1.ObjectIdentifier) (string, bool) {
+	for paramSet, o := range mldsaOIDs {
+		if o.Equal(oid) {
+			return paramSet, true
+		}
+	}
+	return "", false
+}
+
+// generateAltPrivateKey generates the alternative ML-DSA private key of the
+// parameter set into the bundle.
+func generateAltPrivateKey(paramSet string, bundle *ParsedCertBundle) error {
+	if !mldsautil.ValidParameterSet(paramSet) {
+		return errutil.UserError{Err: fmt.Sprintf("unknown alternative key type: %s", paramSet)}
+	}
+	seed, _, err := mldsautil.GenerateKey(paramSet, rand.Reader)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error generating ML-DSA private key: %v", err)}
+	}
+	signer, err := mldsautil.NewSigner(paramSet, seed)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error loading ML-DSA private key: %v", err)}
+	}
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error marshalling ML-DSA private key: %v", err)}
+	}
+
+	bundle.AltPrivateKey = signer
+	bundle.AltPrivateKeyBytes = keyBytes
+	return nil
+}
+
+// addAltPublicKey adds the alternative public key of the subject to the
+// certificate template.
+func addAltPublicKey(certTemplate *x509.Certificate, pub crypto.PublicKey) error {
+	spki, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, pkix.Extension{
+		Id:    OIDSubjectAltPublicKeyInfo,
+		Value: spki,
+	})
+	return nil
+}
+
+// createCertificate creates the certificate like x509.CreateCertificate and,
+// if altKey is not nil, adds the alternative signature of altKey to it. The
+// template is modified.
+func createCertificate(template, parent *x509.Certificate, pub crypto.PublicKey, key, altKey crypto.Signer) ([]byte, error) {
+	if altKey == nil {
+		return x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	}
+
+	paramSet, ok := mldsautil.ParameterSet(altKey)
+	if !ok {
+		return nil, errors.New("alternative signing key is not an ML-DSA key")
+	}
+	algorithm, err := asn1.Marshal(pkix.AlgorithmIdentifier{Algorithm: mldsaOIDs[paramSet]})
+	if err != nil {
+		return nil, err
+	}
+	template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+		Id:    OIDAltSignatureAlgorithm,
+		Value: algorithm,
+	})
+
+	// The alternative signature is over the certificate without its classical
+	// signature algorithm and alternative signature, so the certificate is
+	// created a first time to get it. The second time gives the same
+	// certificate with the alternative signature appended to its extensions.
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, err
+	}
+	preTBS, err := preTBSCertificate(cert.RawTBSCertificate)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := altKey.Sign(rand.Reader, preTBS, crypto.Hash(0))
+	if err != nil {
+		return nil, err
+	}
+	value, err := asn1.Marshal(asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)})
+	if err != nil {
+		return nil, err
+	}
+	template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+		Id:    OIDAltSignatureValue,
+		Value: value,
+	})
+
+	return x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+}
+
+// preTBSCertificate returns the encoded TBSCertificate without its signature
+// algorithm and alternative signature value extension.
+func preTBSCertificate(rawTBS []byte) ([]byte, error) {
+	var tbs asn1.RawValue
+	if rest, err := asn1.Unmarshal(rawTBS, &tbs); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after TBSCertificate")
+	}
+
+	var fields []asn1.RawValue
+	for rest := tbs.Bytes; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &field)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	// The signature algorithm follows the optional version and the serial
+	// number
+	sigAlgIndex := 1
+	if len(fields) > 0 && fields[0].Class == asn1.ClassContextSpecific && fields[0].Tag == 0 {
+		sigAlgIndex = 2
+	}
+	if len(fields) <= sigAlgIndex {
+		return nil, errors.New("malformed TBSCertificate")
+	}
+
+	var body bytes.Buffer
+	for i, field := range fields {
+		switch {
+		case i == sigAlgIndex:
+			continue
+		case field.Class == asn1.ClassContextSpecific && field.Tag == 3:
+			extensions, err := removeAltSignatureValue(field.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			body.Write(extensions)
+		default:
+			body.Write(field.FullBytes)
+		}
+	}
+
+	return asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSequence,
+		IsCompound: true,
+		Bytes:      body.Bytes(),
+	})
+}
+
+// removeAltSignatureValue returns the explicitly tagged extensions field of a
+// TBSCertificate whose content is rawExtensions, without the alternative
+// signature value extension. The other extensions are kept as encoded.
+func removeAltSignatureValue(rawExtensions []byte) ([]byte, error) {
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(rawExtensions, &seq); err != nil {
+		return nil, err
+	}
+
+	var kept bytes.Buffer
+	for rest := seq.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return nil, err
+		}
+		var ext pkix.Extension
+		if _, err := asn1.Unmarshal(raw.FullBytes, &ext); err != nil {
+			return nil, err
+		}
+		if !ext.Id.Equal(OIDAltSignatureValue) {
+			kept.Write(raw.FullBytes)
+		}
+	}
+
+	extensions, err := asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassUniversal,
+		Tag:        asn1.TagSequence,
+		IsCompound: true,
+		Bytes:      kept.Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        3,
+		IsCompound: true,
+		Bytes:      extensions,
+	})
+}
+
+func findExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) []byte {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return ext.Value
+		}
+	}
+	return nil
+}
+
+// IsHybridCertificate returns whether the certificate has an alternative
+// signature.
+func IsHybridCertificate(cert *x509.Certificate) bool {
+	return findExtension(cert, OIDAltSignatureValue) != nil
+}
+
+// VerifyAltSignature checks that the alternative signature of the certificate
+// is valid for the alternative public key of the issuer.
+func VerifyAltSignature(cert, issuer *x509.Certificate) error {
+	rawAlgorithm := findExtension(cert, OIDAltSignatureAlgorithm)
+	rawValue := findExtension(cert, OIDAltSignatureValue)
+	if rawAlgorithm == nil || rawValue == nil {
+		return errors.New("certificate has no alternative signature")
+	}
+	var algorithm pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(rawAlgorithm, &algorithm); err != nil {
+		return fmt.Errorf("error parsing the alternative signature algorithm: %w", err)
+	}
+	var sig asn1.BitString
+	if _, err := asn1.Unmarshal(rawValue, &sig); err != nil {
+		return fmt.Errorf("error parsing the alternative signature: %w", err)
+	}
+
+	rawPublicKey := findExtension(issuer, OIDSubjectAltPublicKeyInfo)
+	if rawPublicKey == nil {
+		return errors.New("issuer has no alternative public key")
+	}
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(rawPublicKey, &spki); err != nil {
+		return fmt.Errorf("error parsing the alternative public key of the issuer: %w", err)
+	}
+
+	paramSet, ok := mldsaParameterSet(algorithm.Algorithm)
+	if !ok {
+		return fmt.Errorf("unsupported alternative signature algorithm %s", algorithm.Algorithm)
+	}
+	if !spki.Algorithm.Algorithm.Equal(algorithm.Algorithm) {
+		return errors.New("alternative signature algorithm does not match the alternative public key of the issuer")
+	}
+
+	preTBS, err := preTBSCertificate(cert.RawTBSCertificate)
+	if err != nil {
+		return err
+	}
+	valid, err := mldsautil.Verify(paramSet, spki.PublicKey.RightAlign(), preTBS, sig.RightAlign())
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("invalid alternative signature")
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
@@ -46,8 +47,8 @@ type Secret struct {
 	Data map[string]interface{} `json:"data"`
 }
 
-// PrivateKeyType holds a string representation of the type of private key (ec
-// or rsa) referenced in CertBundle and ParsedCertBundle. This uses colloquial
+// PrivateKeyType holds a string representation of the type of private key (ec,
+// rsa or ed25519) referenced in CertBundle and ParsedCertBundle. This uses colloquial
 // names rather than official names, to eliminate confusion
 type PrivateKeyType string
 
@@ -56,6 +57,7 @@ const (
 	UnknownPrivateKey PrivateKeyType = ""
 	RSAPrivateKey     PrivateKeyType = "rsa"
 	ECPrivateKey      PrivateKeyType = "ec"
+	Ed25519PrivateKey PrivateKeyType = "ed25519"
 )
 
 // TLSUsage controls whether the intended usage of a *tls.Config
@@ -102,6 +104,10 @@ type CertBundle struct {
 	CAChain        []string       `json:"ca_chain" structs:"ca_chain" mapstructure:"ca_chain"`
 	PrivateKey     string         `json:"private_key" structs:"private_key" mapstructure:"private_key"`
 	SerialNumber   string         `json:"serial_number" structs:"serial_number" mapstructure:"serial_number"`
+
+	// The PEM-encoded PKCS #8 alternative ML-DSA private key of a hybrid
+	// certificate
+	AltPrivateKey string `json:"alt_private_key,omitempty" structs:"alt_private_key,omitempty" mapstructure:"alt_private_key"`
 }
 
 // ParsedCertBundle contains a key type, a DER-encoded private key,
@@ -114,6 +120,11 @@ type ParsedCertBundle struct {
 	CertificateBytes []byte
 	Certificate      *x509.Certificate
 	CAChain          []*CertBlock
+
+	// The DER-encoded PKCS #8 alternative ML-DSA private key of a hybrid
+	// certificate, and the key itself
+	AltPrivateKeyBytes []byte
+	AltPrivateKey      crypto.Signer
 }
 
 // CSRBundle contains a key type, a PEM-encoded private key,
@@ -185,6 +196,8 @@ func (c *CertBundle) ToParsedCertBundle() (*ParsedCertBundle, error) {
 				c.PrivateKeyType = ECPrivateKey
 			case RSAPrivateKey:
 				c.PrivateKeyType = RSAPrivateKey
+			case Ed25519PrivateKey:
+				c.PrivateKeyType = Ed25519PrivateKey
 			}
 		default:
 			return nil, errutil.UserError{Err: fmt.Sprintf("Unsupported key block type: %s", pemBlock.Type)}
@@ -196,6 +209,23 @@ func (c *CertBundle) ToParsedCertBundle() (*ParsedCertBundle, error) {
 		}
 	}
 
+	if len(c.AltPrivateKey) > 0 {
+		pemBlock, _ = pem.Decode([]byte(c.AltPrivateKey))
+		if pemBlock == nil {
+			return nil, errutil.UserError{Err: "Error decoding alternative private key from cert bundle"}
+		}
+		key, err := x509.ParsePKCS8PrivateKey(pemBlock.Bytes)
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("Error parsing alternative private key: %v", err)}
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errutil.UserError{Err: "Alternative private key is not a signing key"}
+		}
+		result.AltPrivateKeyBytes = pemBlock.Bytes
+		result.AltPrivateKey = signer
+	}
+
 	if len(c.Certificate) > 0 {
 		pemBlock, _ = pem.Decode([]byte(c.Certificate))
 		if pemBlock == nil {
@@ -290,12 +320,21 @@ func (p *ParsedCertBundle) ToCertBundle() (*CertBundle, error) {
 				block.Type = string(ECBlock)
 			case RSAPrivateKey:
 				block.Type = string(PKCS1Block)
+			case Ed25519PrivateKey:
+				block.Type = string(PKCS8Block)
 			}
 		}
 
 		result.PrivateKey = strings.TrimSpace(string(pem.EncodeToMemory(&block)))
 	}
 
+	if len(p.AltPrivateKeyBytes) > 0 {
+		result.AltPrivateKey = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  string(PKCS8Block),
+			Bytes: p.AltPrivateKeyBytes,
+		})))
+	}
+
 	return result, nil
 }
 
@@ -380,7 +419,7 @@ func (p *ParsedCertBundle) getSigner() (crypto.Signer, error) {
 	case PKCS8Block:
 		if k, err := x509.ParsePKCS8PrivateKey(p.PrivateKeyBytes); err == nil {
 			switch k := k.(type) {
-			case *rsa.PrivateKey, *ecdsa.PrivateKey:
+			case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
 				return k.(crypto.Signer), nil
 			default:
 				return nil, errutil.UserError{Err: "Found unknown private key type in pkcs#8 wrapping"}
@@ -388,7 +427,7 @@ func (p *ParsedCertBundle) getSigner() (crypto.Signer, error) {
 		}
 		return nil, errutil.UserError{Err: fmt.Sprintf("Failed to parse pkcs#8 key: %v", err)}
 	default:
-		return nil, errutil.UserError{Err: "Unable to determine type of private key; only RSA and EC are supported"}
+		return nil, errutil.UserError{Err: "Unable to determine type of private key; only RSA, EC and Ed25519 are supported"}
 	}
 	return signer, nil
 }
@@ -411,6 +450,8 @@ func getPKCS8Type(bs []byte) (PrivateKeyType, error) {
 		return ECPrivateKey, nil
 	case *rsa.PrivateKey:
 		return RSAPrivateKey, nil
+	case ed25519.PrivateKey:
+		return Ed25519PrivateKey, nil
 	default:
 		return UnknownPrivateKey, errutil.UserError{Err: "Found unknown private key type in pkcs#8 wrapping"}
 	}
@@ -435,6 +476,13 @@ func (c *CSRBundle) ToParsedCSRBundle() (*ParsedCSRBundle, error) {
 			result.PrivateKeyType = ECPrivateKey
 		case PKCS1Block:
 			result.PrivateKeyType = RSAPrivateKey
+		case PKCS8Block:
+			t, err := getPKCS8Type(pemBlock.Bytes)
+			if err != nil || t != Ed25519PrivateKey {
+				return nil, errutil.UserError{Err: "Only Ed25519 private keys are supported in pkcs#8 wrapping"}
+			}
+			result.PrivateKeyType = Ed25519PrivateKey
+			c.PrivateKeyType = Ed25519PrivateKey
 		default:
 			// Try to figure it out and correct
 			if _, err := x509.ParseECPrivateKey(pemBlock.Bytes); err == nil {
@@ -491,6 +539,9 @@ func (p *ParsedCSRBundle) ToCSRBundle() (*CSRBundle, error) {
 		case ECPrivateKey:
 			result.PrivateKeyType = "ec"
 			block.Type = "EC PRIVATE KEY"
+		case Ed25519PrivateKey:
+			result.PrivateKeyType = "ed25519"
+			block.Type = "PRIVATE KEY"
 		default:
 			return nil, errutil.InternalError{Err: "Could not determine private key type when creating block"}
 		}
@@ -525,8 +576,15 @@ func (p *ParsedCSRBundle) getSigner() (crypto.Signer, error) {
 			return nil, errutil.UserError{Err: fmt.Sprintf("Unable to parse CA's private RSA key: %s", err)}
 		}
 
+	case Ed25519PrivateKey:
+		k, err := x509.ParsePKCS8PrivateKey(p.PrivateKeyBytes)
+		if err != nil {
+			return nil, errutil.UserError{Err: fmt.Sprintf("Unable to parse CA's private Ed25519 key: %s", err)}
+		}
+		signer = k.(crypto.Signer)
+
 	default:
-		return nil, errutil.UserError{Err: "Unable to determine type of private key; only RSA and EC are supported"}
+		return nil, errutil.UserError{Err: "Unable to determine type of private key; only RSA, EC and Ed25519 are supported"}
 	}
 	return signer, nil
 }
@@ -679,8 +737,20 @@ type CreationParameters struct {
 	BasicConstraintsValidForNonCA bool
 
 	// Only used when signing a CA cert
-	UseCSRValues        bool
-	PermittedDNSDomains []string
+	UseCSRValues bool
+
+	// Name constraints to encode into the certificate
+	PermittedDNSDomains     []string
+	ExcludedDNSDomains      []string
+	PermittedIPRanges       []*net.IPNet
+	ExcludedIPRanges        []*net.IPNet
+	PermittedEmailAddresses []string
+	ExcludedEmailAddresses  []string
+	PermittedURIDomains     []string
+	ExcludedURIDomains      []string
+
+	// Extensions to encode into the certificate as given
+	CustomExtensions []pkix.Extension
 
 	// URLs to encode into the certificate
 	URLs *URLEntries
@@ -690,6 +760,10 @@ type CreationParameters struct {
 
 	// The duration the certificate will use NotBefore
 	NotBeforeDuration time.Duration
+
+	// The ML-DSA parameter set of the alternative key generated for the
+	// subject of a hybrid certificate, if any
+	AltKeyType string
 }
 
 type CreationBundle struct {
@@ -3,3 +3,15 @@ package consts
 // AgentPathCacheClear is the path that the agent will use as its cache-clear
 // endpoint.
 const AgentPathCacheClear = "/agent/v1/cache-clear"
+
+// AgentPathMetrics is the path that the agent will use to expose its internal
+// metrics.
+const AgentPathMetrics = "/agent/v1/metrics"
+
+// AgentPathHealth is the path that the agent will use to report its own
+// health, such as whether auto-auth currently holds a valid token.
+const AgentPathHealth = "/agent/v1/health"
+
+// AgentPathReload is the path that the agent will use to reload its
+// configuration without restarting.
+const AgentPathReload = "/agent/v1/reload"
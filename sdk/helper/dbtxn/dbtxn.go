@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -61,3 +63,264 @@ func parseQuery(m map[string]string, tpl string) string {
 	}
 	return tpl
 }
+
+// Dialect describes how values are bound to or quoted in the queries of a
+// database by ExecuteDBQueryWithArgs and ExecuteTxQueryWithArgs.
+type Dialect struct {
+	// Placeholder returns the placeholder of the nth parameter of a query,
+	// starting at 1. If nil, values are never bound as parameters.
+	Placeholder func(n int) string
+
+	// QuoteIdentifier, if set, substitutes the references outside of quotes
+	// as identifiers quoted by it rather than binding them as parameters, for
+	// statements such as CREATE ROLE which do not accept parameters.
+	QuoteIdentifier func(value string) string
+
+	// BackslashEscapes is set when backslashes escape characters in string
+	// literals, as they do in MySQL by default.
+	BackslashEscapes bool
+
+	// DollarQuotes is set when $tag$ delimits string constants, as it does in
+	// PostgreSQL.
+	DollarQuotes bool
+
+	// EscapeStrings is set when E'...' string constants, in which backslashes
+	// escape characters, are supported, as they are in PostgreSQL.
+	EscapeStrings bool
+
+	// HashComments is set when # starts a comment until the end of the line,
+	// as it does in MySQL.
+	HashComments bool
+}
+
+var (
+	// DialectPostgreSQL binds values as $1, $2, ...
+	DialectPostgreSQL = Dialect{
+		Placeholder:   func(n int) string { return "$" + strconv.Itoa(n) },
+		DollarQuotes:  true,
+		EscapeStrings: true,
+	}
+
+	// DialectPostgreSQLIdentifiers substitutes the values outside of quotes
+	// as identifiers, as PostgreSQL does not accept parameters in statements
+	// such as CREATE ROLE or GRANT. Plain identifiers are substituted as is,
+	// so that they are folded to lower case as before, and other values are
+	// quoted.
+	DialectPostgreSQLIdentifiers = Dialect{
+		QuoteIdentifier: quotePlainIdentifier,
+		DollarQuotes:    true,
+		EscapeStrings:   true,
+	}
+
+	// DialectMySQL binds values as ?
+	DialectMySQL = Dialect{
+		Placeholder:      func(int) string { return "?" },
+		BackslashEscapes: true,
+		HashComments:     true,
+	}
+
+	// DialectMSSQL binds values as @p1, @p2, ...
+	DialectMSSQL = Dialect{
+		Placeholder: func(n int) string { return "@p" + strconv.Itoa(n) },
+	}
+
+	// DialectSubstitute never binds values and substitutes them into the
+	// query as quoted literals instead.
+	DialectSubstitute = Dialect{}
+)
+
+// ExecuteDBQueryWithArgs is like ExecuteDBQuery, but values are never pasted
+// into the query as is. A {{name}} reference outside of quotes is bound as a
+// query parameter when the dialect supports it, and substituted as a quoted
+// literal otherwise. A reference inside a quoted literal or identifier is
+// substituted with the quotes in the value escaped. References inside comments
+// are left as is, as a value could end the comment.
+//
+// Many databases do not accept parameters in statements such as CREATE USER,
+// in which case references must be quoted, or the dialect must quote them as
+// identifiers.
+func ExecuteDBQueryWithArgs(ctx context.Context, db *sql.DB, dialect Dialect, params map[string]string, query string) error {
+	parsedQuery, args := parseQueryWithArgs(dialect, params, query)
+
+	stmt, err := db.PrepareContext(ctx, parsedQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, args...)
+	return err
+}
+
+// ExecuteTxQueryWithArgs is like ExecuteTxQuery, but binds or escapes values
+// the way ExecuteDBQueryWithArgs does.
+func ExecuteTxQueryWithArgs(ctx context.Context, tx *sql.Tx, dialect Dialect, params map[string]string, query string) error {
+	parsedQuery, args := parseQueryWithArgs(dialect, params, query)
+
+	stmt, err := tx.PrepareContext(ctx, parsedQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, args...)
+	return err
+}
+
+// dollarQuoteTag matches the delimiter of a PostgreSQL dollar-quoted string.
+var dollarQuoteTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// parseQueryWithArgs replaces the {{name}} references in tpl according to
+// where they appear, and returns the resulting query along with the values
+// to bind to its parameters. References to unknown names are left as is.
+func parseQueryWithArgs(dialect Dialect, m map[string]string, tpl string) (string, []interface{}) {
+	if len(m) == 0 {
+		return tpl, nil
+	}
+
+	var (
+		out       strings.Builder
+		args      []interface{}
+		quote     byte
+		dollarTag string
+
+		// backslashes is set when backslashes escape characters in the
+		// current string literal
+		backslashes bool
+	)
+	for i := 0; i < len(tpl); i++ {
+		ch := tpl[i]
+
+		if strings.HasPrefix(tpl[i:], "{{") {
+			if end := strings.Index(tpl[i+2:], "}}"); end >= 0 {
+				name := tpl[i+2 : i+2+end]
+				if value, ok := m[name]; ok {
+					switch {
+					case quote == '\'':
+						out.WriteString(escapeLiteral(backslashes, value))
+					case quote != 0:
+						out.WriteString(strings.ReplaceAll(value, string(quote), string([]byte{quote, quote})))
+					case dialect.QuoteIdentifier != nil:
+						out.WriteString(dialect.QuoteIdentifier(value))
+					case dollarTag == "" && dialect.Placeholder != nil:
+						args = append(args, value)
+						out.WriteString(dialect.Placeholder(len(args)))
+					default:
+						out.WriteString("'" + escapeLiteral(dialect.BackslashEscapes, value) + "'")
+					}
+					i += end + 3
+					continue
+				}
+			}
+		}
+
+		if quote == 0 {
+			// Comments are copied as is, without replacing references
+			if comment := commentLength(dialect, tpl[i:]); comment > 0 {
+				out.WriteString(tpl[i : i+comment])
+				i += comment - 1
+				continue
+			}
+		}
+
+		switch {
+		case quote == 0 && (ch == '\'' || ch == '"' || ch == '`'):
+			quote = ch
+			backslashes = ch == '\'' && (dialect.BackslashEscapes || (dialect.EscapeStrings && isEscapeStringPrefix(tpl[:i])))
+		case quote == 0 && ch == '$' && dialect.DollarQuotes:
+			if tag := dollarQuoteTag.FindString(tpl[i:]); tag != "" {
+				switch dollarTag {
+				case "":
+					dollarTag = tag
+				case tag:
+					dollarTag = ""
+				}
+				out.WriteString(tag)
+				i += len(tag) - 1
+				continue
+			}
+		case quote == '\'' && ch == '\\' && backslashes && i+1 < len(tpl):
+			out.WriteByte(ch)
+			i++
+			ch = tpl[i]
+		case quote != 0 && ch == quote:
+			if i+1 < len(tpl) && tpl[i+1] == quote {
+				// Doubled quotes are escaped quotes
+				out.WriteByte(ch)
+				i++
+			} else {
+				quote = 0
+			}
+		}
+		out.WriteByte(ch)
+	}
+
+	return out.String(), args
+}
+
+// commentLength returns the length of the comment starting s, or 0 if s does
+// not start with a comment. Unterminated comments run to the end of s.
+func commentLength(dialect Dialect, s string) int {
+	switch {
+	case strings.HasPrefix(s, "--"), dialect.HashComments && strings.HasPrefix(s, "#"):
+		if end := strings.IndexByte(s, '\n'); end >= 0 {
+			return end + 1
+		}
+		return len(s)
+	case strings.HasPrefix(s, "/*"):
+		// Block comments nest in PostgreSQL and MSSQL, and in MySQL a nested
+		// opening is ignored, so nesting is followed to find the end
+		depth := 0
+		for i := 0; i < len(s)-1; i++ {
+			switch {
+			case s[i] == '/' && s[i+1] == '*':
+				depth++
+				i++
+			case s[i] == '*' && s[i+1] == '/':
+				depth--
+				i++
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(s)
+	}
+	return 0
+}
+
+// isEscapeStringPrefix returns whether the query before a quote ends with the
+// E prefix of an escape string constant, rather than with an identifier
+// ending in E.
+func isEscapeStringPrefix(before string) bool {
+	n := len(before)
+	if n == 0 || (before[n-1] != 'E' && before[n-1] != 'e') {
+		return false
+	}
+	if n == 1 {
+		return true
+	}
+	prev := before[n-2]
+	return !(prev == '_' || prev == '$' || prev >= '0' && prev <= '9' || prev >= 'a' && prev <= 'z' || prev >= 'A' && prev <= 'Z' || prev >= 0x80)
+}
+
+// plainIdentifier matches the identifiers which need no quoting.
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// quotePlainIdentifier returns value as is if it is a plain identifier, and
+// as a double-quoted identifier otherwise.
+func quotePlainIdentifier(value string) string {
+	if plainIdentifier.MatchString(value) {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// escapeLiteral escapes value to be placed in a single-quoted string literal,
+// in which backslashes escape characters if backslashes is set.
+func escapeLiteral(backslashes bool, value string) string {
+	if backslashes {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+	}
+	return strings.ReplaceAll(value, "'", "''")
+}
@@ -0,0 +1,144 @@
+package dbtxn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQueryWithArgs(t *testing.T) {
+	params := map[string]string{
+		"name":     `us'er"x`,
+		"password": `p\'; DROP TABLE users; --`,
+	}
+
+	tests := map[string]struct {
+		dialect      Dialect
+		query        string
+		expected     string
+		expectedArgs []interface{}
+	}{
+		"postgres bound": {
+			dialect:      DialectPostgreSQL,
+			query:        `SELECT {{name}}, {{password}}`,
+			expected:     `SELECT $1, $2`,
+			expectedArgs: []interface{}{params["name"], params["password"]},
+		},
+		"postgres quoted": {
+			dialect:  DialectPostgreSQL,
+			query:    `CREATE ROLE "{{name}}" WITH PASSWORD '{{password}}';`,
+			expected: `CREATE ROLE "us'er""x" WITH PASSWORD 'p\''; DROP TABLE users; --';`,
+		},
+		"postgres dollar quoted": {
+			dialect:  DialectPostgreSQL,
+			query:    `DO $$ BEGIN EXECUTE 'DROP ROLE ' || {{name}}; END $$;`,
+			expected: `DO $$ BEGIN EXECUTE 'DROP ROLE ' || 'us''er"x'; END $$;`,
+		},
+		"mysql": {
+			dialect:      DialectMySQL,
+			query:        `CREATE USER '{{name}}'@'%' IDENTIFIED BY '{{password}}'; SELECT {{name}}`,
+			expected:     `CREATE USER 'us''er"x'@'%' IDENTIFIED BY 'p\\''; DROP TABLE users; --'; SELECT ?`,
+			expectedArgs: []interface{}{params["name"]},
+		},
+		"mysql escaped quote": {
+			dialect:  DialectMySQL,
+			query:    `SELECT 'it\'s {{name}}', ` + "`{{name}}`",
+			expected: `SELECT 'it\'s us''er"x', ` + "`us'er\"x`",
+		},
+		"mssql": {
+			dialect:      DialectMSSQL,
+			query:        `EXEC sp_adduser {{name}}, 'it''s {{name}}'`,
+			expected:     `EXEC sp_adduser @p1, 'it''s us''er"x'`,
+			expectedArgs: []interface{}{params["name"]},
+		},
+		"substitute": {
+			dialect:  DialectSubstitute,
+			query:    `GRANT ALL TO {{name}}`,
+			expected: `GRANT ALL TO 'us''er"x'`,
+		},
+		"postgres escape string": {
+			dialect:  DialectPostgreSQL,
+			query:    `SELECT E'a\'{{password}}', e'{{name}}', '{{password}}', type'{{name}}'`,
+			expected: `SELECT E'a\'p\\''; DROP TABLE users; --', e'us''er"x', 'p\''; DROP TABLE users; --', type'us''er"x'`,
+		},
+		"postgres comments": {
+			dialect:      DialectPostgreSQL,
+			query:        "-- user {{name}}\nSELECT {{name}} /* it's {{password}} /* nested */ {{name}} */, '--{{name}}'",
+			expected:     "-- user {{name}}\nSELECT $1 /* it's {{password}} /* nested */ {{name}} */, '--us''er\"x'",
+			expectedArgs: []interface{}{params["name"]},
+		},
+		"mysql comments": {
+			dialect:      DialectMySQL,
+			query:        "# {{name}}\nSELECT {{name}} -- {{password}}",
+			expected:     "# {{name}}\nSELECT ? -- {{password}}",
+			expectedArgs: []interface{}{params["name"]},
+		},
+		"unterminated comment": {
+			dialect:  DialectSubstitute,
+			query:    "SELECT 1 /* {{name}}",
+			expected: "SELECT 1 /* {{name}}",
+		},
+		"postgres identifiers": {
+			dialect:  DialectPostgreSQLIdentifiers,
+			query:    `CREATE ROLE {{name}} WITH PASSWORD '{{password}}'; GRANT ALL TO "{{name}}", {{name}} -- {{name}}`,
+			expected: `CREATE ROLE "us'er""x" WITH PASSWORD 'p\''; DROP TABLE users; --'; GRANT ALL TO "us'er""x", "us'er""x" -- {{name}}`,
+		},
+		"postgres identifiers in dollar quotes": {
+			dialect:  DialectPostgreSQLIdentifiers,
+			query:    `DO $$ BEGIN CREATE ROLE {{name}}; EXECUTE 'GRANT ALL TO {{name}}'; END $$;`,
+			expected: `DO $$ BEGIN CREATE ROLE "us'er""x"; EXECUTE 'GRANT ALL TO us''er"x'; END $$;`,
+		},
+		"unknown name": {
+			dialect:  DialectPostgreSQL,
+			query:    `SELECT '{{unknown}}', {{unknown}}, {{name`,
+			expected: `SELECT '{{unknown}}', {{unknown}}, {{name`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, args := parseQueryWithArgs(test.dialect, params, test.query)
+			if actual != test.expected {
+				t.Fatalf("Actual: %s\nExpected: %s", actual, test.expected)
+			}
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Fatalf("Actual args: %#v\nExpected args: %#v", args, test.expectedArgs)
+			}
+		})
+	}
+}
+
+// The default unquoted DDL templates keep working with generated and plain
+// usernames.
+func TestParseQueryWithArgs_UnquotedDDL(t *testing.T) {
+	query := `CREATE ROLE {{name}} WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}'; GRANT SELECT ON ALL TABLES IN SCHEMA public TO {{name}};`
+
+	tests := map[string]struct {
+		name     string
+		expected string
+	}{
+		"plain": {
+			name:     "app_user",
+			expected: `CREATE ROLE app_user WITH LOGIN PASSWORD 'secret' VALID UNTIL '2020-10-16 12:00:00+0000'; GRANT SELECT ON ALL TABLES IN SCHEMA public TO app_user;`,
+		},
+		"generated": {
+			name:     "v-token-test-Ab12Cd34",
+			expected: `CREATE ROLE "v-token-test-Ab12Cd34" WITH LOGIN PASSWORD 'secret' VALID UNTIL '2020-10-16 12:00:00+0000'; GRANT SELECT ON ALL TABLES IN SCHEMA public TO "v-token-test-Ab12Cd34";`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, args := parseQueryWithArgs(DialectPostgreSQLIdentifiers, map[string]string{
+				"name":       test.name,
+				"password":   "secret",
+				"expiration": "2020-10-16 12:00:00+0000",
+			}, query)
+			if actual != test.expected {
+				t.Fatalf("Actual: %s\nExpected: %s", actual, test.expected)
+			}
+			if len(args) != 0 {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"golang.org/x/crypto/hkdf"
+)
+
+// MaxHKDFBytes is the maximum number of bytes HKDF-SHA256 can derive.
+const MaxHKDFBytes = 255 * sha256.Size
+
+// CMAC returns the AES-CMAC (RFC 4493) of the input with the given version of
+// the key.
+func (p *Policy) CMAC(ver int, input []byte) ([]byte, error) {
+	if !p.Type.CMACSupported() {
+		return nil, errutil.UserError{Err: fmt.Sprintf("CMAC not supported for key type %v", p.Type)}
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(keyEntry.Key)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+
+	return cmac(block, input), nil
+}
+
+// VerifyCMAC returns whether the CMAC matches the CMAC of the input with the
+// given version of the key.
+func (p *Policy) VerifyCMAC(ver int, input, mac []byte) (bool, error) {
+	expected, err := p.CMAC(ver, input)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(expected, mac) == 1, nil
+}
+
+// HKDF derives numBytes of key material with HKDF-SHA256 (RFC 5869) from the
+// HMAC key of the given version of the key, using the given salt and info. As
+// the HMAC key exists for every key type, keys of any type can be used.
+func (p *Policy) HKDF(ver int, salt, info []byte, numBytes int) ([]byte, error) {
+	if numBytes <= 0 || numBytes > MaxHKDFBytes {
+		return nil, errutil.UserError{Err: fmt.Sprintf("number of bytes must be between 1 and %d", MaxHKDFBytes)}
+	}
+
+	hmacKey, err := p.HMACKey(ver)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
+	derived := make([]byte, numBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, hmacKey, salt, info), derived); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error reading derived bytes: %v", err)}
+	}
+
+	return derived, nil
+}
+
+// cmac computes the CMAC of the message with the block cipher as specified
+// in RFC 4493 and NIST SP 800-38B.
+func cmac(block cipher.Block, msg []byte) []byte {
+	size := block.BlockSize()
+
+	// Generate the subkeys by doubling the encrypted zero block in GF(2^128)
+	k1 := make([]byte, size)
+	block.Encrypt(k1, k1)
+	cmacDouble(k1)
+	k2 := append([]byte(nil), k1...)
+	cmacDouble(k2)
+
+	n := (len(msg) + size - 1) / size
+	complete := n > 0 && len(msg)%size == 0
+	if n == 0 {
+		n = 1
+	}
+
+	// The last block is XORed with K1 if it is complete, or padded and
+	// XORed with K2 otherwise
+	last := make([]byte, size)
+	if complete {
+		copy(last, msg[(n-1)*size:])
+		xorBytes(last, k1)
+	} else {
+		rest := msg[(n-1)*size:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		xorBytes(last, k2)
+	}
+
+	mac := make([]byte, size)
+	for i := 0; i < n-1; i++ {
+		xorBytes(mac, msg[i*size:(i+1)*size])
+		block.Encrypt(mac, mac)
+	}
+	xorBytes(mac, last)
+	block.Encrypt(mac, mac)
+
+	return mac
+}
+
+// cmacDouble multiplies the block by x in GF(2^128).
+func cmacDouble(b []byte) {
+	carry := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] = b[len(b)-1]<<1 ^ carry*0x87
+}
+
+func xorBytes(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
@@ -1,6 +1,7 @@
 package keysutil
 
 import (
+	"crypto"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -43,6 +44,14 @@ var (
 		HashTypeSHA2512: sha512.New,
 	}
 
+	CryptoHashMap = map[HashType]crypto.Hash{
+		HashTypeSHA1:    crypto.SHA1,
+		HashTypeSHA2224: crypto.SHA224,
+		HashTypeSHA2256: crypto.SHA256,
+		HashTypeSHA2384: crypto.SHA384,
+		HashTypeSHA2512: crypto.SHA512,
+	}
+
 	MarshalingTypeMap = map[string]MarshalingType{
 		"asn1": MarshalingTypeASN1,
 		"jws":  MarshalingTypeJWS,
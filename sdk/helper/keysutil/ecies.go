@@ -0,0 +1,109 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ECIESInfo is the HKDF info binding the keys derived by ECIES to transit.
+const ECIESInfo = "vault-transit-ecies"
+
+// ECIESEncrypt encrypts the plaintext to the public key with ECIES: an
+// ephemeral key pair is generated on the curve of the public key, and the
+// x-coordinate of the ECDH shared point is expanded with HKDF-SHA256, salted
+// with the ephemeral public key, into an AES-256-GCM key. The ciphertext is
+// the uncompressed ephemeral public key, followed by the 12 byte nonce and
+// the sealed plaintext.
+func ECIESEncrypt(pub *ecdsa.PublicKey, plaintext []byte) ([]byte, error) {
+	ephemeral, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := elliptic.Marshal(pub.Curve, ephemeral.X, ephemeral.Y)
+
+	aead, err := eciesAEAD(pub.Curve, pub.X, pub.Y, ephemeral.D.Bytes(), ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(ephemeralPub, nonce...)
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// ECIESDecrypt decrypts a ciphertext produced by ECIESEncrypt with the
+// private key.
+func ECIESDecrypt(priv *ecdsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	curve := priv.Curve
+	pointLen := 1 + 2*((curve.Params().BitSize+7)/8)
+	if len(ciphertext) < pointLen {
+		return nil, errutil.UserError{Err: "invalid ciphertext: too short"}
+	}
+
+	ephemeralPub := ciphertext[:pointLen]
+	x, y := elliptic.Unmarshal(curve, ephemeralPub)
+	if x == nil {
+		return nil, errutil.UserError{Err: "invalid ciphertext: invalid ephemeral public key"}
+	}
+
+	aead, err := eciesAEAD(curve, x, y, priv.D.Bytes(), ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := ciphertext[pointLen:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errutil.UserError{Err: "invalid ciphertext: too short"}
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errutil.UserError{Err: "invalid ciphertext: unable to decrypt"}
+	}
+	return plaintext, nil
+}
+
+// eciesAEAD returns the AES-256-GCM cipher keyed from the ECDH of the point
+// and the scalar.
+func eciesAEAD(curve elliptic.Curve, x, y *big.Int, scalar, ephemeralPub []byte) (cipher.AEAD, error) {
+	sx, _ := curve.ScalarMult(x, y, scalar)
+	shared := make([]byte, (curve.Params().BitSize+7)/8)
+	sxBytes := sx.Bytes()
+	copy(shared[len(shared)-len(sxBytes):], sxBytes)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, ephemeralPub, []byte(ECIESInfo)), key); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error deriving key: %v", err)}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+	return cipher.NewGCM(block)
+}
+
+// ecdsaCurve returns the curve of the ECDSA key types.
+func ecdsaCurve(kt KeyType) elliptic.Curve {
+	switch kt {
+	case KeyType_ECDSA_P384:
+		return elliptic.P384()
+	case KeyType_ECDSA_P521:
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
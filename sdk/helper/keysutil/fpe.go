@@ -0,0 +1,222 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+)
+
+const (
+	// FPETweakSize is the size in bytes of the tweak of FF3-1.
+	FPETweakSize = 7
+
+	// fpeRounds is the number of Feistel rounds of FF3-1.
+	fpeRounds = 8
+
+	// fpeMinDomain is the minimum number of values of the numeral strings
+	// that can be encrypted, as required by NIST SP 800-38G Revision 1.
+	fpeMinDomain = 1000000
+)
+
+// FPEAlphabets are the named alphabets of format-preserving encryption.
+var FPEAlphabets = map[string]string{
+	"numeric":            "0123456789",
+	"alphanumeric":       "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"alphanumeric-lower": "0123456789abcdefghijklmnopqrstuvwxyz",
+	"alphanumeric-upper": "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+// EncryptFPE encrypts the value with FF3-1 (NIST SP 800-38G Revision 1) with
+// the given version of the key and tweak. Only the characters of the value in
+// the alphabet are encrypted, and the others are left in place, so that the
+// ciphertext has the same length and format as the value.
+func (p *Policy) EncryptFPE(ver int, tweak []byte, alphabet string, value string) (string, error) {
+	return p.fpe(ver, tweak, alphabet, value, true)
+}
+
+// DecryptFPE decrypts a value encrypted by EncryptFPE with the same version
+// of the key, tweak and alphabet.
+func (p *Policy) DecryptFPE(ver int, tweak []byte, alphabet string, value string) (string, error) {
+	return p.fpe(ver, tweak, alphabet, value, false)
+}
+
+func (p *Policy) fpe(ver int, tweak []byte, alphabet string, value string, encrypt bool) (string, error) {
+	if !p.Type.FPESupported() {
+		return "", errutil.UserError{Err: fmt.Sprintf("format-preserving encryption not supported for key type %v", p.Type)}
+	}
+	if len(tweak) != FPETweakSize {
+		return "", errutil.UserError{Err: fmt.Sprintf("tweak must be %d bytes", FPETweakSize)}
+	}
+
+	chars := []rune(alphabet)
+	radix := len(chars)
+	if radix < 2 || radix > 1<<16 {
+		return "", errutil.UserError{Err: "alphabet must contain between 2 and 65536 characters"}
+	}
+	index := make(map[rune]int, radix)
+	for i, c := range chars {
+		if _, ok := index[c]; ok {
+			return "", errutil.UserError{Err: fmt.Sprintf("alphabet contains %q more than once", c)}
+		}
+		index[c] = i
+	}
+
+	// Extract the numerals of the value, remembering where they are
+	runes := []rune(value)
+	var numerals []int
+	var positions []int
+	for i, c := range runes {
+		if n, ok := index[c]; ok {
+			numerals = append(numerals, n)
+			positions = append(positions, i)
+		}
+	}
+
+	minLen, maxLen := ff3Bounds(radix)
+	if len(numerals) < minLen || len(numerals) > maxLen {
+		return "", errutil.UserError{Err: fmt.Sprintf("value must contain between %d and %d characters of the alphabet", minLen, maxLen)}
+	}
+
+	keyEntry, err := p.safeGetKeyEntry(ver)
+	if err != nil {
+		return "", err
+	}
+
+	// FF3-1 uses the block cipher with the byte-reversed key
+	block, err := aes.NewCipher(revBytes(keyEntry.Key))
+	if err != nil {
+		return "", errutil.InternalError{Err: err.Error()}
+	}
+
+	// Split the 56 bit tweak into the 32 bit tweaks of the Feistel rounds
+	tL := []byte{tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0}
+	tR := []byte{tweak[4], tweak[5], tweak[6], (tweak[3] & 0x0f) << 4}
+
+	if encrypt {
+		numerals = ff3Encrypt(block, tL, tR, radix, numerals)
+	} else {
+		numerals = ff3Decrypt(block, tL, tR, radix, numerals)
+	}
+
+	for i, n := range numerals {
+		runes[positions[i]] = chars[n]
+	}
+	return string(runes), nil
+}
+
+// ff3Bounds returns the minimum and maximum number of numerals that can be
+// encrypted with the radix: the domain must have at least a million values,
+// and each half of the numerals must fit into 96 bits.
+func ff3Bounds(radix int) (int, int) {
+	r := big.NewInt(int64(radix))
+
+	minLen := 2
+	domain := new(big.Int).Exp(r, big.NewInt(int64(minLen)), nil)
+	for domain.Cmp(big.NewInt(fpeMinDomain)) < 0 {
+		domain.Mul(domain, r)
+		minLen++
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	half := 0
+	for v := new(big.Int).Set(r); v.Cmp(limit) <= 0; v.Mul(v, r) {
+		half++
+	}
+
+	return minLen, 2 * half
+}
+
+// ff3Encrypt encrypts the numeral string x with the FF3 Feistel network,
+// using the 32 bit tweaks tL and tR for the odd and even rounds.
+func ff3Encrypt(block cipher.Block, tL, tR []byte, radix int, x []int) []int {
+	u := (len(x) + 1) / 2
+	a := append([]int(nil), x[:u]...)
+	b := append([]int(nil), x[u:]...)
+
+	for i := 0; i < fpeRounds; i++ {
+		m, w := u, tR
+		if i%2 == 1 {
+			m, w = len(x)-u, tL
+		}
+
+		y := ff3Round(block, w, i, radix, b)
+		c := numRev(a, radix)
+		c.Add(c, y)
+		c.Mod(c, new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil))
+
+		a, b = b, strRev(c, radix, m)
+	}
+
+	return append(a, b...)
+}
+
+// ff3Decrypt decrypts the numeral string x encrypted by ff3Encrypt.
+func ff3Decrypt(block cipher.Block, tL, tR []byte, radix int, x []int) []int {
+	u := (len(x) + 1) / 2
+	a := append([]int(nil), x[:u]...)
+	b := append([]int(nil), x[u:]...)
+
+	for i := fpeRounds - 1; i >= 0; i-- {
+		m, w := u, tR
+		if i%2 == 1 {
+			m, w = len(x)-u, tL
+		}
+
+		y := ff3Round(block, w, i, radix, a)
+		c := numRev(b, radix)
+		c.Sub(c, y)
+		c.Mod(c, new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil))
+
+		a, b = strRev(c, radix, m), a
+	}
+
+	return append(a, b...)
+}
+
+// ff3Round computes the round function of round i on the half x.
+func ff3Round(block cipher.Block, w []byte, i int, radix int, x []int) *big.Int {
+	p := make([]byte, aes.BlockSize)
+	copy(p, w)
+	p[3] ^= byte(i)
+	num := numRev(x, radix).Bytes()
+	copy(p[aes.BlockSize-len(num):], num)
+
+	s := make([]byte, aes.BlockSize)
+	block.Encrypt(s, revBytes(p))
+	return new(big.Int).SetBytes(revBytes(s))
+}
+
+// numRev returns the number represented by the reversed numeral string x.
+func numRev(x []int, radix int) *big.Int {
+	r := big.NewInt(int64(radix))
+	n := new(big.Int)
+	for i := len(x) - 1; i >= 0; i-- {
+		n.Mul(n, r)
+		n.Add(n, big.NewInt(int64(x[i])))
+	}
+	return n
+}
+
+// strRev returns the reversed numeral string of length m representing n.
+func strRev(n *big.Int, radix int, m int) []int {
+	r := big.NewInt(int64(radix))
+	n = new(big.Int).Set(n)
+	digit := new(big.Int)
+	x := make([]int, m)
+	for i := range x {
+		n.DivMod(n, r, digit)
+		x[i] = int(digit.Int64())
+	}
+	return x
+}
+
+func revBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -2,6 +2,7 @@ package keysutil
 
 import (
 	"context"
+	"crypto"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -52,6 +54,16 @@ type PolicyRequest struct {
 
 	// Whether to allow plaintext backup
 	AllowPlaintextBackup bool
+
+	// Whether to allow rotating an imported key
+	AllowImportedKeyRotation bool
+
+	// The period after which the key is automatically rotated
+	AutoRotatePeriod time.Duration
+
+	// The name of the HSM or cloud KMS holding the private keys of a managed
+	// key
+	ManagedKeyName string
 }
 
 type LockManager struct {
@@ -327,57 +339,12 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 		// to the user to let them know that their request can't be satisfied
 		// because we don't know if the parameters match.
 
-		switch req.KeyType {
-		case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
-			if req.Convergent && !req.Derived {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption requires derivation to be enabled")
-			}
-
-		case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_ED25519:
-			if req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		default:
+		if err := checkPolicyRequestOptions(req); err != nil {
 			cleanup()
-			return nil, false, fmt.Errorf("unsupported key type %v", req.KeyType)
+			return nil, false, err
 		}
 
-		p = &Policy{
-			l:                    new(sync.RWMutex),
-			Name:                 req.Name,
-			Type:                 req.KeyType,
-			Derived:              req.Derived,
-			Exportable:           req.Exportable,
-			AllowPlaintextBackup: req.AllowPlaintextBackup,
-		}
-
-		if req.Derived {
-			p.KDF = Kdf_hkdf_sha256
-			if req.Convergent {
-				p.ConvergentEncryption = true
-				// As of version 3 we store the version within each key, so we
-				// set to -1 to indicate that the value in the policy has no
-				// meaning. We still, for backwards compatibility, fall back to
-				// this value if the key doesn't have one, which means it will
-				// only be -1 in the case where every key version is >= 3
-				p.ConvergentVersion = -1
-			}
-		}
+		p = newPolicyFromRequest(req)
 
 		// Performs the actual persist and does setup
 		err = p.Rotate(ctx, req.Storage, rand)
@@ -419,6 +386,159 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest, rand io
 	return
 }
 
+// ImportPolicy creates a policy whose first key version is the given key
+// material rather than a generated key. The key material is the raw key for
+// symmetric key types, and a PKCS #8 DER-encoded private key for asymmetric
+// key types. An error is returned if the policy already exists.
+func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key []byte, rand io.Reader) error {
+	lock := locksutil.LockForKey(lm.keyLocks, req.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if lm.useCache {
+		if _, ok := lm.cache.Load(req.Name); ok {
+			return errutil.UserError{Err: fmt.Sprintf("key %q already exists", req.Name)}
+		}
+	}
+
+	p, err := lm.getPolicyFromStorage(ctx, req.Storage, req.Name)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		return errutil.UserError{Err: fmt.Sprintf("key %q already exists", req.Name)}
+	}
+
+	if err := checkPolicyRequestOptions(req); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+
+	p = newPolicyFromRequest(req)
+	p.Imported = true
+	p.AllowImportedKeyRotation = req.AllowImportedKeyRotation
+
+	if err := p.Import(ctx, req.Storage, key, rand); err != nil {
+		return err
+	}
+
+	if lm.useCache {
+		lm.cache.Store(req.Name, p)
+	}
+
+	return nil
+}
+
+// CreateManagedPolicy creates a policy whose private keys are held by the HSM
+// or cloud KMS named in the request, with the external key with the given ID
+// and public key as first version.
+func (lm *LockManager) CreateManagedPolicy(ctx context.Context, req PolicyRequest, keyID string, pub crypto.PublicKey, rand io.Reader) error {
+	lock := locksutil.LockForKey(lm.keyLocks, req.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if lm.useCache {
+		if _, ok := lm.cache.Load(req.Name); ok {
+			return errutil.UserError{Err: fmt.Sprintf("key %q already exists", req.Name)}
+		}
+	}
+
+	p, err := lm.getPolicyFromStorage(ctx, req.Storage, req.Name)
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		return errutil.UserError{Err: fmt.Sprintf("key %q already exists", req.Name)}
+	}
+
+	switch {
+	case req.ManagedKeyName == "":
+		return errutil.UserError{Err: "missing managed key name"}
+	case !req.KeyType.ManagedKeySupported():
+		return errutil.UserError{Err: fmt.Sprintf("managed keys not supported for key type %v", req.KeyType)}
+	case req.Exportable:
+		return errutil.UserError{Err: "managed keys cannot be exportable"}
+	case req.AutoRotatePeriod > 0:
+		return errutil.UserError{Err: "managed keys cannot be rotated automatically"}
+	}
+	if err := checkPolicyRequestOptions(req); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+
+	p = newPolicyFromRequest(req)
+	p.ManagedKeyName = req.ManagedKeyName
+
+	if err := p.AddManagedKeyVersion(ctx, req.Storage, keyID, pub, rand); err != nil {
+		return err
+	}
+
+	if lm.useCache {
+		lm.cache.Store(req.Name, p)
+	}
+
+	return nil
+}
+
+// checkPolicyRequestOptions checks that the key type of the request supports
+// the requested options.
+func checkPolicyRequestOptions(req PolicyRequest) error {
+	switch req.KeyType {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if req.Convergent && !req.Derived {
+			return fmt.Errorf("convergent encryption requires derivation to be enabled")
+		}
+
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_ED25519:
+		if req.Convergent {
+			return fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096, KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_AES256_FF3_1,
+		KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	default:
+		return fmt.Errorf("unsupported key type %v", req.KeyType)
+	}
+
+	return nil
+}
+
+// newPolicyFromRequest returns a new policy, without any key, with the
+// options of the request.
+func newPolicyFromRequest(req PolicyRequest) *Policy {
+	p := &Policy{
+		l:                    new(sync.RWMutex),
+		Name:                 req.Name,
+		Type:                 req.KeyType,
+		Derived:              req.Derived,
+		Exportable:           req.Exportable,
+		AllowPlaintextBackup: req.AllowPlaintextBackup,
+		AutoRotatePeriod:     req.AutoRotatePeriod,
+	}
+
+	if req.Derived {
+		p.KDF = Kdf_hkdf_sha256
+		if req.Convergent {
+			p.ConvergentEncryption = true
+			// As of version 3 we store the version within each key, so we
+			// set to -1 to indicate that the value in the policy has no
+			// meaning. We still, for backwards compatibility, fall back to
+			// this value if the key doesn't have one, which means it will
+			// only be -1 in the case where every key version is >= 3
+			p.ConvergentVersion = -1
+		}
+	}
+
+	return p
+}
+
 func (lm *LockManager) DeletePolicy(ctx context.Context, storage logical.Storage, name string) error {
 	var p *Policy
 	var err error
@@ -0,0 +1,217 @@
+package keysutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// ManagedKeyProvider returns the signer of the external key with the given
+// ID, whose private material is held by an HSM or a cloud KMS rather than by
+// Vault. The signers of keys supporting decryption also implement
+// crypto.Decrypter.
+type ManagedKeyProvider func(keyID string) (crypto.Signer, error)
+
+// SignManaged signs the input like Sign, delegating the private key
+// operations of the versions managed externally to the provider.
+func (p *Policy) SignManaged(managed ManagedKeyProvider, ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
+	return p.sign(managed, ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
+}
+
+// DecryptManaged decrypts the value like Decrypt, delegating the private key
+// operations of the versions managed externally to the provider.
+func (p *Policy) DecryptManaged(managed ManagedKeyProvider, context, nonce []byte, value string) (string, error) {
+	return p.decrypt(managed, context, nonce, value)
+}
+
+// AddManagedKeyVersion adds a version to the policy whose private key is the
+// external key with the given ID and public key, and persists it. The first
+// version is added when the policy is created, and the following ones
+// replace rotation, as Vault cannot generate external keys.
+func (p *Policy) AddManagedKeyVersion(ctx context.Context, storage logical.Storage, keyID string, pub crypto.PublicKey, randReader io.Reader) (retErr error) {
+	if p.ManagedKeyName == "" {
+		return errutil.UserError{Err: "key is not managed externally"}
+	}
+	if keyID == "" {
+		return errutil.UserError{Err: "missing managed key ID"}
+	}
+
+	now := time.Now()
+	entry := KeyEntry{
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+		ManagedKeyID:           keyID,
+	}
+
+	hmacKey, err := uuid.GenerateRandomBytesWithReader(32, randReader)
+	if err != nil {
+		return err
+	}
+	entry.HMACKey = hmacKey
+
+	switch p.Type {
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != ecdsaCurve(p.Type) {
+			return errutil.UserError{Err: fmt.Sprintf("managed key %q is not a key of type %v", keyID, p.Type)}
+		}
+		entry.EC_X = ecKey.X
+		entry.EC_Y = ecKey.Y
+
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+		bitSize := 2048
+		if p.Type == KeyType_RSA3072 {
+			bitSize = 3072
+		}
+		if p.Type == KeyType_RSA4096 {
+			bitSize = 4096
+		}
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok || rsaKey.N.BitLen() != bitSize {
+			return errutil.UserError{Err: fmt.Sprintf("managed key %q is not a key of type %v", keyID, p.Type)}
+		}
+		entry.RSAPublicKey = rsaKey
+
+	default:
+		return errutil.UserError{Err: fmt.Sprintf("managed keys not supported for key type %v", p.Type)}
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("error marshaling public key: %w", err)
+	}
+	entry.FormattedPublicKey = string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}))
+
+	priorLatestVersion := p.LatestVersion
+	priorMinDecryptionVersion := p.MinDecryptionVersion
+	priorKeys := keyEntryMap{}
+	for k, v := range p.Keys {
+		priorKeys[k] = v
+	}
+	defer func() {
+		if retErr != nil {
+			p.LatestVersion = priorLatestVersion
+			p.MinDecryptionVersion = priorMinDecryptionVersion
+			p.Keys = priorKeys
+		}
+	}()
+
+	if p.Keys == nil {
+		p.Keys = keyEntryMap{}
+	}
+	p.LatestVersion++
+	p.Keys[strconv.Itoa(p.LatestVersion)] = entry
+	if p.MinDecryptionVersion == 0 {
+		p.MinDecryptionVersion = 1
+	}
+
+	return p.Persist(ctx, storage)
+}
+
+// rsaPublicKey returns the public key of an RSA version.
+func (ke *KeyEntry) rsaPublicKey() *rsa.PublicKey {
+	if ke.RSAKey != nil {
+		return &ke.RSAKey.PublicKey
+	}
+	return ke.RSAPublicKey
+}
+
+func managedSigner(managed ManagedKeyProvider, keyID string) (crypto.Signer, error) {
+	if managed == nil {
+		return nil, errutil.InternalError{Err: "key version is managed externally but no managed key provider was given"}
+	}
+	signer, err := managed(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access managed key %q: %w", keyID, err)
+	}
+	return signer, nil
+}
+
+// managedECDSASign signs the digest with the external ECDSA key and returns
+// the parameters of the signature.
+func managedECDSASign(managed ManagedKeyProvider, keyID string, digest []byte, hashAlgorithm HashType) (*big.Int, *big.Int, error) {
+	hash, ok := CryptoHashMap[hashAlgorithm]
+	if !ok {
+		return nil, nil, errutil.InternalError{Err: "unsupported hash algorithm"}
+	}
+
+	signer, err := managedSigner(managed, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := signer.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign with managed key %q: %w", keyID, err)
+	}
+
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil || len(rest) != 0 {
+		return nil, nil, fmt.Errorf("managed key %q returned an invalid signature", keyID)
+	}
+	return sig.R, sig.S, nil
+}
+
+// managedRSASign signs the digest with the external RSA key, using PSS with
+// a salt as long as the hash or PKCS #1 v1.5.
+func managedRSASign(managed ManagedKeyProvider, keyID string, digest []byte, hash crypto.Hash, sigAlgorithm string) ([]byte, error) {
+	var opts crypto.SignerOpts
+	switch sigAlgorithm {
+	case "pss":
+		opts = &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       hash,
+		}
+	case "pkcs1v15":
+		opts = hash
+	default:
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
+	}
+
+	signer, err := managedSigner(managed, keyID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with managed key %q: %w", keyID, err)
+	}
+	return sig, nil
+}
+
+// managedRSADecrypt decrypts the RSA-OAEP ciphertext with the external RSA
+// key.
+func managedRSADecrypt(managed ManagedKeyProvider, keyID string, ciphertext []byte) ([]byte, error) {
+	signer, err := managedSigner(managed, keyID)
+	if err != nil {
+		return nil, err
+	}
+	decrypter, ok := signer.(crypto.Decrypter)
+	if !ok {
+		return nil, errutil.UserError{Err: fmt.Sprintf("managed key %q does not support decryption", keyID)}
+	}
+
+	// The same hash as Encrypt
+	plain, err := decrypter.Decrypt(rand.Reader, ciphertext, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("failed to RSA decrypt the ciphertext with managed key %q: %v", keyID, err)}
+	}
+	return plain, nil
+}
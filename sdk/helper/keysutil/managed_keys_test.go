@@ -0,0 +1,151 @@
+package keysutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPolicy_Managed(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey1, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey2, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]crypto.Signer{
+		"ec":   ecKey,
+		"rsa1": rsaKey1,
+		"rsa2": rsaKey2,
+	}
+	managed := func(keyID string) (crypto.Signer, error) {
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("no key %q", keyID)
+		}
+		return key, nil
+	}
+
+	ctx := context.Background()
+	storage := &logical.InmemStorage{}
+	lm, err := NewLockManager(false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Managed keys must be of the type of the external key
+	err = lm.CreateManagedPolicy(ctx, PolicyRequest{
+		Storage:        storage,
+		Name:           "signing",
+		KeyType:        KeyType_ECDSA_P384,
+		ManagedKeyName: "hsm",
+	}, "ec", ecKey.Public(), rand.Reader)
+	if err == nil {
+		t.Fatal("expected an error creating a key of another type")
+	}
+
+	err = lm.CreateManagedPolicy(ctx, PolicyRequest{
+		Storage:        storage,
+		Name:           "signing",
+		KeyType:        KeyType_ECDSA_P256,
+		ManagedKeyName: "hsm",
+	}, "ec", ecKey.Public(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(ctx, storage, "policy/signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Like transit, sign the digest of the input
+	input := []byte("the quick brown fox")
+	digest := sha256.Sum256(input)
+	if _, err := p.Sign(1, nil, digest[:], HashTypeSHA2256, "", MarshalingTypeASN1); err == nil {
+		t.Fatal("expected an error signing without a managed key provider")
+	}
+	sig, err := p.SignManaged(managed, 1, nil, digest[:], HashTypeSHA2256, "", MarshalingTypeASN1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := p.VerifySignature(nil, digest[:], HashTypeSHA2256, "", MarshalingTypeASN1, sig.Signature)
+	if err != nil || !valid {
+		t.Fatalf("signature of managed ECDSA key did not verify: %v", err)
+	}
+	if _, err := p.Encrypt(0, nil, nil, base64.StdEncoding.EncodeToString(input)); err == nil {
+		t.Fatal("expected an error encrypting with a managed ECDSA key")
+	}
+	if err := p.Rotate(ctx, storage, rand.Reader); err == nil {
+		t.Fatal("expected an error rotating a managed key")
+	}
+
+	err = lm.CreateManagedPolicy(ctx, PolicyRequest{
+		Storage:        storage,
+		Name:           "encryption",
+		KeyType:        KeyType_RSA2048,
+		ManagedKeyName: "hsm",
+	}, "rsa1", rsaKey1.Public(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = LoadPolicy(ctx, storage, "policy/encryption")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sigAlgorithm := range []string{"pss", "pkcs1v15"} {
+		sig, err := p.SignManaged(managed, 1, nil, digest[:], HashTypeSHA2256, sigAlgorithm, MarshalingTypeASN1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid, err := p.VerifySignature(nil, digest[:], HashTypeSHA2256, sigAlgorithm, MarshalingTypeASN1, sig.Signature)
+		if err != nil || !valid {
+			t.Fatalf("%s signature of managed RSA key did not verify: %v", sigAlgorithm, err)
+		}
+	}
+
+	plaintext := base64.StdEncoding.EncodeToString(input)
+	ciphertext1, err := p.Encrypt(0, nil, nil, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.AddManagedKeyVersion(ctx, storage, "ec", ecKey.Public(), rand.Reader); err == nil {
+		t.Fatal("expected an error adding a version of another type")
+	}
+	if err := p.AddManagedKeyVersion(ctx, storage, "rsa2", rsaKey2.Public(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if p.LatestVersion != 2 {
+		t.Fatalf("bad latest version: %d", p.LatestVersion)
+	}
+	ciphertext2, err := p.Encrypt(0, nil, nil, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ciphertext := range []string{ciphertext1, ciphertext2} {
+		decrypted, err := p.DecryptManaged(managed, nil, nil, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted != plaintext {
+			t.Fatalf("bad plaintext: %q", decrypted)
+		}
+	}
+}
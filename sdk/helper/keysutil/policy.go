@@ -37,6 +37,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/kdf"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -59,6 +60,12 @@ const (
 	KeyType_ECDSA_P521
 	KeyType_AES128_GCM96
 	KeyType_RSA3072
+	KeyType_AES128_CMAC
+	KeyType_AES256_CMAC
+	KeyType_AES256_FF3_1
+	KeyType_MLDSA44
+	KeyType_MLDSA65
+	KeyType_MLDSA87
 )
 
 const (
@@ -93,7 +100,8 @@ type KeyType int
 
 func (kt KeyType) EncryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096,
+		KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
 		return true
 	}
 	return false
@@ -101,7 +109,8 @@ func (kt KeyType) EncryptionSupported() bool {
 
 func (kt KeyType) DecryptionSupported() bool {
 	switch kt {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096,
+		KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
 		return true
 	}
 	return false
@@ -109,7 +118,32 @@ func (kt KeyType) DecryptionSupported() bool {
 
 func (kt KeyType) SigningSupported() bool {
 	switch kt {
-	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096,
+		KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		return true
+	}
+	return false
+}
+
+func (kt KeyType) CMACSupported() bool {
+	switch kt {
+	case KeyType_AES128_CMAC, KeyType_AES256_CMAC:
+		return true
+	}
+	return false
+}
+
+func (kt KeyType) FPESupported() bool {
+	switch kt {
+	case KeyType_AES256_FF3_1:
+		return true
+	}
+	return false
+}
+
+func (kt KeyType) ManagedKeySupported() bool {
+	switch kt {
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521, KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -123,6 +157,15 @@ func (kt KeyType) HashSignatureInput() bool {
 	return false
 }
 
+// MLDSASupported returns whether the key type is an ML-DSA parameter set.
+func (kt KeyType) MLDSASupported() bool {
+	switch kt {
+	case KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		return true
+	}
+	return false
+}
+
 func (kt KeyType) DerivationSupported() bool {
 	switch kt {
 	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_ED25519:
@@ -153,6 +196,18 @@ func (kt KeyType) String() string {
 		return "rsa-3072"
 	case KeyType_RSA4096:
 		return "rsa-4096"
+	case KeyType_AES128_CMAC:
+		return "aes128-cmac"
+	case KeyType_AES256_CMAC:
+		return "aes256-cmac"
+	case KeyType_AES256_FF3_1:
+		return "aes256-ff3-1"
+	case KeyType_MLDSA44:
+		return mldsautil.MLDSA44
+	case KeyType_MLDSA65:
+		return mldsautil.MLDSA65
+	case KeyType_MLDSA87:
+		return mldsautil.MLDSA87
 	}
 
 	return "[unknown]"
@@ -180,6 +235,13 @@ type KeyEntry struct {
 
 	RSAKey *rsa.PrivateKey `json:"rsa_key"`
 
+	// The public key of RSA versions whose private key is managed externally
+	RSAPublicKey *rsa.PublicKey `json:"rsa_public_key"`
+
+	// The ID of the external key holding the private key of the version, if
+	// it is managed externally
+	ManagedKeyID string `json:"managed_key_id"`
+
 	// The public key in an appropriate format for the type of key
 	FormattedPublicKey string `json:"public_key"`
 
@@ -365,6 +427,11 @@ type Policy struct {
 	// AllowPlaintextBackup allows taking backup of the policy in plaintext
 	AllowPlaintextBackup bool `json:"allow_plaintext_backup"`
 
+	// AllowLookupExport allows exporting the keys from which the nonces of
+	// convergent encryption are derived, so that tokenized data can be joined
+	// offline without decrypting it
+	AllowLookupExport bool `json:"allow_lookup_export"`
+
 	// VersionTemplate is used to prefix the ciphertext with information about
 	// the key version. It must inclide {{version}} and a delimiter between the
 	// version prefix and the ciphertext.
@@ -374,6 +441,27 @@ type Policy struct {
 	// policy object.
 	StoragePrefix string `json:"storage_prefix"`
 
+	// Imported indicates that the first version of the key was imported
+	// rather than generated
+	Imported bool `json:"imported"`
+
+	// AllowImportedKeyRotation allows rotating an imported key, the new
+	// versions being generated
+	AllowImportedKeyRotation bool `json:"allow_imported_key_rotation"`
+
+	// AutoRotatePeriod is the period after which the key is automatically
+	// rotated. Zero disables automatic rotation.
+	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
+
+	// ManagedKeyName is the name of the HSM or cloud KMS holding the private
+	// keys of the versions, which are never stored by Vault. It is empty for
+	// keys whose material is held by Vault.
+	ManagedKeyName string `json:"managed_key_name"`
+
+	// UsagePolicy restricts the operations performed with the key, and who
+	// and when may perform them. Nil allows any use permitted by ACLs.
+	UsagePolicy *KeyUsagePolicy `json:"usage_policy,omitempty"`
+
 	// versionPrefixCache stores caches of version prefix strings and the split
 	// version template.
 	versionPrefixCache sync.Map
@@ -883,12 +971,29 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		if err != nil {
 			return "", err
 		}
-		key := keyEntry.RSAKey
-		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, plaintext, nil)
+		ciphertext, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, keyEntry.rsaPublicKey(), plaintext, nil)
 		if err != nil {
 			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA encrypt the plaintext: %v", err)}
 		}
 
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return "", err
+		}
+		// ECIES ciphertexts could not be decrypted by the HSM or cloud KMS
+		if keyEntry.ManagedKeyID != "" {
+			return "", errutil.UserError{Err: "encryption is not supported with managed ECDSA keys"}
+		}
+		ciphertext, err = ECIESEncrypt(&ecdsa.PublicKey{
+			Curve: ecdsaCurve(p.Type),
+			X:     keyEntry.EC_X,
+			Y:     keyEntry.EC_Y,
+		}, plaintext)
+		if err != nil {
+			return "", errutil.InternalError{Err: fmt.Sprintf("failed to ECIES encrypt the plaintext: %v", err)}
+		}
+
 	default:
 		return "", errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
 	}
@@ -903,6 +1008,10 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 }
 
 func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
+	return p.decrypt(nil, context, nonce, value)
+}
+
+func (p *Policy) decrypt(managed ManagedKeyProvider, context, nonce []byte, value string) (string, error) {
 	if !p.Type.DecryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message decryption not supported for key type %v", p.Type)}
 	}
@@ -983,10 +1092,37 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if keyEntry.ManagedKeyID != "" {
+			plain, err = managedRSADecrypt(managed, keyEntry.ManagedKeyID, decoded)
+			if err != nil {
+				return "", err
+			}
+			break
+		}
 		key := keyEntry.RSAKey
 		plain, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, decoded, nil)
 		if err != nil {
-			return "", errutil.InternalError{Err: fmt.Sprintf("failed to RSA decrypt the ciphertext: %v", err)}
+			return "", errutil.UserError{Err: fmt.Sprintf("failed to RSA decrypt the ciphertext: %v", err)}
+		}
+
+	case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return "", err
+		}
+		if keyEntry.ManagedKeyID != "" {
+			return "", errutil.UserError{Err: "decryption is not supported with managed ECDSA keys"}
+		}
+		plain, err = ECIESDecrypt(&ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: ecdsaCurve(p.Type),
+				X:     keyEntry.EC_X,
+				Y:     keyEntry.EC_Y,
+			},
+			D: keyEntry.EC_D,
+		}, decoded)
+		if err != nil {
+			return "", err
 		}
 
 	default:
@@ -1014,7 +1150,55 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 	return keyEntry.HMACKey, nil
 }
 
+// ConvergentNonceKey returns the key from which the nonces of convergent
+// encryption are derived for the given context and key version: the nonce of
+// a plaintext is the truncated HMAC-SHA256 of the plaintext with this key. As
+// the nonce prefixes the ciphertext, this allows computing the deterministic
+// part of ciphertexts without being able to decrypt them.
+func (p *Policy) ConvergentNonceKey(context []byte, ver int) ([]byte, error) {
+	if !p.ConvergentEncryption {
+		return nil, errutil.UserError{Err: "convergent encryption is not enabled for this key"}
+	}
+
+	switch {
+	case ver == 0:
+		ver = p.LatestVersion
+	case ver < 0:
+		return nil, errutil.UserError{Err: "requested version is negative"}
+	case ver > p.LatestVersion:
+		return nil, errutil.UserError{Err: "requested version is higher than the latest key version"}
+	}
+	if _, err := p.safeGetKeyEntry(ver); err != nil {
+		return nil, err
+	}
+
+	switch p.convergentVersion(ver) {
+	case 1:
+		return nil, errutil.UserError{Err: "nonces are supplied by clients with convergent version 1"}
+	case 2:
+		// The context itself is used as the HMAC key
+		return context, nil
+	}
+
+	encBytes := 32
+	if p.Type == KeyType_AES128_GCM96 {
+		encBytes = 16
+	}
+	key, err := p.GetKey(context, ver, encBytes+32)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != encBytes+32 {
+		return nil, errutil.InternalError{Err: "could not derive hmac key, length not correct"}
+	}
+	return key[encBytes:], nil
+}
+
 func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
+	return p.sign(nil, ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
+}
+
+func (p *Policy) sign(managed ManagedKeyProvider, ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
 	if !p.Type.SigningSupported() {
 		return nil, fmt.Errorf("message signing not supported for key type %v", p.Type)
 	}
@@ -1054,16 +1238,20 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			curve = elliptic.P256()
 		}
 
-		key := &ecdsa.PrivateKey{
-			PublicKey: ecdsa.PublicKey{
-				Curve: curve,
-				X:     keyParams.EC_X,
-				Y:     keyParams.EC_Y,
-			},
-			D: keyParams.EC_D,
+		var r, s *big.Int
+		if keyParams.ManagedKeyID != "" {
+			r, s, err = managedECDSASign(managed, keyParams.ManagedKeyID, input, hashAlgorithm)
+		} else {
+			key := &ecdsa.PrivateKey{
+				PublicKey: ecdsa.PublicKey{
+					Curve: curve,
+					X:     keyParams.EC_X,
+					Y:     keyParams.EC_Y,
+				},
+				D: keyParams.EC_D,
+			}
+			r, s, err = ecdsa.Sign(rand.Reader, key, input)
 		}
-
-		r, s, err := ecdsa.Sign(rand.Reader, key, input)
 		if err != nil {
 			return nil, err
 		}
@@ -1125,9 +1313,19 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			return nil, err
 		}
 
-	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
-		key := keyParams.RSAKey
+	case KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		key, err := mldsautil.NewSigner(p.Type.String(), keyParams.Key)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error loading ML-DSA key: %v", err)}
+		}
+
+		// Like ed25519, ML-DSA signs the message itself
+		sig, err = key.Sign(rand.Reader, input, crypto.Hash(0))
+		if err != nil {
+			return nil, err
+		}
 
+	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		var algo crypto.Hash
 		switch hashAlgorithm {
 		case HashTypeSHA1:
@@ -1148,6 +1346,15 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			sigAlgorithm = "pss"
 		}
 
+		if keyParams.ManagedKeyID != "" {
+			sig, err = managedRSASign(managed, keyParams.ManagedKeyID, input, algo, sigAlgorithm)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+
+		key := keyParams.RSAKey
 		switch sigAlgorithm {
 		case "pss":
 			sig, err = rsa.SignPSS(rand.Reader, key, algo, input, nil)
@@ -1291,13 +1498,25 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 		return ed25519.Verify(key.Public().(ed25519.PublicKey), input, sigBytes), nil
 
+	case KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		keyEntry, err := p.safeGetKeyEntry(ver)
+		if err != nil {
+			return false, err
+		}
+		pubKey, err := base64.StdEncoding.DecodeString(keyEntry.FormattedPublicKey)
+		if err != nil {
+			return false, errutil.InternalError{Err: fmt.Sprintf("error decoding ML-DSA public key: %v", err)}
+		}
+
+		return mldsautil.Verify(p.Type.String(), pubKey, input, sigBytes)
+
 	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		keyEntry, err := p.safeGetKeyEntry(ver)
 		if err != nil {
 			return false, err
 		}
 
-		key := keyEntry.RSAKey
+		key := keyEntry.rsaPublicKey()
 
 		var algo crypto.Hash
 		switch hashAlgorithm {
@@ -1321,9 +1540,9 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 
 		switch sigAlgorithm {
 		case "pss":
-			err = rsa.VerifyPSS(&key.PublicKey, algo, input, sigBytes, nil)
+			err = rsa.VerifyPSS(key, algo, input, sigBytes, nil)
 		case "pkcs1v15":
-			err = rsa.VerifyPKCS1v15(&key.PublicKey, algo, input, sigBytes)
+			err = rsa.VerifyPKCS1v15(key, algo, input, sigBytes)
 		default:
 			return false, errutil.InternalError{Err: fmt.Sprintf("unsupported rsa signature algorithm %s", sigAlgorithm)}
 		}
@@ -1336,6 +1555,13 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 }
 
 func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader io.Reader) (retErr error) {
+	if p.Imported && !p.AllowImportedKeyRotation {
+		return errutil.UserError{Err: "rotation is not allowed for this imported key"}
+	}
+	if p.ManagedKeyName != "" {
+		return errutil.UserError{Err: "managed keys are rotated by adding a version of the external key"}
+	}
+
 	priorLatestVersion := p.LatestVersion
 	priorMinDecryptionVersion := p.MinDecryptionVersion
 	var priorKeys keyEntryMap
@@ -1376,10 +1602,10 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 	entry.HMACKey = hmacKey
 
 	switch p.Type {
-	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_AES256_FF3_1:
 		// Default to 256 bit key
 		numBytes := 32
-		if p.Type == KeyType_AES128_GCM96 {
+		if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC {
 			numBytes = 16
 		}
 		newKey, err := uuid.GenerateRandomBytesWithReader(numBytes, randReader)
@@ -1403,22 +1629,9 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 		if err != nil {
 			return err
 		}
-		entry.EC_D = privKey.D
-		entry.EC_X = privKey.X
-		entry.EC_Y = privKey.Y
-		derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
-		if err != nil {
-			return errwrap.Wrapf("error marshaling public key: {{err}}", err)
-		}
-		pemBlock := &pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: derBytes,
-		}
-		pemBytes := pem.EncodeToMemory(pemBlock)
-		if pemBytes == nil || len(pemBytes) == 0 {
-			return fmt.Errorf("error PEM-encoding public key")
+		if err := entry.setECKey(privKey); err != nil {
+			return err
 		}
-		entry.FormattedPublicKey = string(pemBytes)
 
 	case KeyType_ED25519:
 		pub, pri, err := ed25519.GenerateKey(randReader)
@@ -1428,6 +1641,15 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 		entry.Key = pri
 		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
 
+	case KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+		// The private key is stored as its seed
+		seed, pub, err := mldsautil.GenerateKey(p.Type.String(), randReader)
+		if err != nil {
+			return err
+		}
+		entry.Key = seed
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
+
 	case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
 		bitSize := 2048
 		if p.Type == KeyType_RSA3072 {
@@ -1461,6 +1683,149 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage, randReader
 	return p.Persist(ctx, storage)
 }
 
+// AutoRotationDue returns whether the key is configured for automatic
+// rotation and its latest version is older than the rotation period.
+func (p *Policy) AutoRotationDue(now time.Time) bool {
+	if p.AutoRotatePeriod <= 0 || p.ManagedKeyName != "" {
+		return false
+	}
+
+	latest, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return false
+	}
+	created := latest.CreationTime
+	if created.IsZero() {
+		created = time.Unix(latest.DeprecatedCreationTime, 0)
+	}
+
+	return !now.Before(created.Add(p.AutoRotatePeriod))
+}
+
+// Import sets the given key material as the first version of a new policy
+// and persists it. The key material is the raw key for symmetric key types,
+// and a PKCS #8 DER-encoded private key for asymmetric key types. The HMAC key
+// is generated.
+func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte, randReader io.Reader) error {
+	if p.Keys != nil {
+		return fmt.Errorf("cannot import into a policy with existing keys")
+	}
+
+	now := time.Now()
+	entry := KeyEntry{
+		CreationTime:           now,
+		DeprecatedCreationTime: now.Unix(),
+	}
+
+	hmacKey, err := uuid.GenerateRandomBytesWithReader(32, randReader)
+	if err != nil {
+		return err
+	}
+	entry.HMACKey = hmacKey
+
+	switch p.Type {
+	case KeyType_AES128_GCM96, KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305, KeyType_AES128_CMAC, KeyType_AES256_CMAC, KeyType_AES256_FF3_1:
+		numBytes := 32
+		if p.Type == KeyType_AES128_GCM96 || p.Type == KeyType_AES128_CMAC {
+			numBytes = 16
+		}
+		if len(key) != numBytes {
+			return errutil.UserError{Err: fmt.Sprintf("invalid key size for key type %v: expected %d bytes, got %d", p.Type, numBytes, len(key))}
+		}
+		entry.Key = append([]byte(nil), key...)
+
+	default:
+		parsedKey, err := x509.ParsePKCS8PrivateKey(key)
+		if err != nil {
+			return errutil.UserError{Err: fmt.Sprintf("failed to parse PKCS #8 private key: %v", err)}
+		}
+
+		switch p.Type {
+		case KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521:
+			curve := elliptic.P256()
+			switch p.Type {
+			case KeyType_ECDSA_P384:
+				curve = elliptic.P384()
+			case KeyType_ECDSA_P521:
+				curve = elliptic.P521()
+			}
+			ecKey, ok := parsedKey.(*ecdsa.PrivateKey)
+			if !ok || ecKey.Curve != curve {
+				return errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+			}
+			if err := entry.setECKey(ecKey); err != nil {
+				return err
+			}
+
+		case KeyType_ED25519:
+			edKey, ok := parsedKey.(ed25519.PrivateKey)
+			if !ok {
+				return errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+			}
+			entry.Key = edKey
+			entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))
+
+		case KeyType_RSA2048, KeyType_RSA3072, KeyType_RSA4096:
+			bitSize := 2048
+			if p.Type == KeyType_RSA3072 {
+				bitSize = 3072
+			}
+			if p.Type == KeyType_RSA4096 {
+				bitSize = 4096
+			}
+			rsaKey, ok := parsedKey.(*rsa.PrivateKey)
+			if !ok || rsaKey.N.BitLen() != bitSize {
+				return errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+			}
+			entry.RSAKey = rsaKey
+
+		case KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87:
+			paramSet, seed, pub, ok := mldsautil.ParsePrivateKey(parsedKey)
+			if !ok || paramSet != p.Type.String() {
+				return errutil.UserError{Err: fmt.Sprintf("private key is not a key of type %v", p.Type)}
+			}
+			entry.Key = seed
+			entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
+
+		default:
+			return fmt.Errorf("unsupported key type %v", p.Type)
+		}
+	}
+
+	if p.ConvergentEncryption {
+		entry.ConvergentVersion = currentConvergentVersion
+	}
+
+	p.Keys = keyEntryMap{
+		"1": entry,
+	}
+	p.LatestVersion = 1
+	p.MinDecryptionVersion = 1
+
+	return p.Persist(ctx, storage)
+}
+
+// setECKey sets the private and formatted public key of the entry from the
+// given ECDSA key.
+func (ke *KeyEntry) setECKey(privKey *ecdsa.PrivateKey) error {
+	ke.EC_D = privKey.D
+	ke.EC_X = privKey.X
+	ke.EC_Y = privKey.Y
+	derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+	if err != nil {
+		return errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+	pemBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}
+	pemBytes := pem.EncodeToMemory(pemBlock)
+	if pemBytes == nil || len(pemBytes) == 0 {
+		return fmt.Errorf("error PEM-encoding public key")
+	}
+	ke.FormattedPublicKey = string(pemBytes)
+	return nil
+}
 func (p *Policy) MigrateKeyToKeysMap() {
 	now := time.Now()
 	p.Keys = keyEntryMap{
@@ -3,14 +3,19 @@ package keysutil
 import (
 	"bytes"
 	"context"
+	"crypto/aes"
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/mldsautil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/copystructure"
 )
@@ -641,3 +646,234 @@ func BenchmarkSymmetric(b *testing.B) {
 		}
 	}
 }
+
+func TestPolicy_AutoRotationDue(t *testing.T) {
+	now := time.Now()
+	p := &Policy{
+		LatestVersion: 2,
+		Keys: keyEntryMap{
+			"1": {CreationTime: now.Add(-48 * time.Hour)},
+			"2": {CreationTime: now.Add(-2 * time.Hour)},
+		},
+	}
+
+	if p.AutoRotationDue(now) {
+		t.Fatal("expected no rotation without a period")
+	}
+
+	p.AutoRotatePeriod = 24 * time.Hour
+	if p.AutoRotationDue(now) {
+		t.Fatal("expected no rotation before the period of the latest version elapsed")
+	}
+	if !p.AutoRotationDue(now.Add(22 * time.Hour)) {
+		t.Fatal("expected a rotation once the period of the latest version elapsed")
+	}
+
+	// Keys created before creation times were tracked use the deprecated one
+	p.Keys["2"] = KeyEntry{DeprecatedCreationTime: now.Add(-25 * time.Hour).Unix()}
+	if !p.AutoRotationDue(now) {
+		t.Fatal("expected a rotation based on the deprecated creation time")
+	}
+}
+
+func TestPolicy_CMAC(t *testing.T) {
+	// Test vectors from RFC 4493
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	msg, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	cases := map[int]string{
+		0:  "bb1d6929e95937287fa37d129b756746",
+		16: "070a16b46b4d4144f79bdd9dd04a287c",
+		40: "dfa66747de9ae63030ca32611497c827",
+		64: "51f0bebf7e3b9d92fc49741779363cfe",
+	}
+
+	p := &Policy{
+		Name:          "test",
+		Type:          KeyType_AES128_CMAC,
+		LatestVersion: 1,
+		Keys: keyEntryMap{
+			"1": KeyEntry{Key: key},
+		},
+	}
+
+	for n, expected := range cases {
+		mac, err := p.CMAC(1, msg[:n])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(mac) != expected {
+			t.Fatalf("bad CMAC for %d bytes: %x", n, mac)
+		}
+		valid, err := p.VerifyCMAC(1, msg[:n], mac)
+		if err != nil || !valid {
+			t.Fatalf("CMAC for %d bytes did not verify: %v", n, err)
+		}
+	}
+
+	p.Type = KeyType_AES256_GCM96
+	if _, err := p.CMAC(1, msg); err == nil {
+		t.Fatal("expected error for key type without CMAC support")
+	}
+}
+
+func TestPolicy_FPE(t *testing.T) {
+	// The FF3-1 Feistel network is the FF3 one with a shorter tweak, so it
+	// is checked against the FF3 samples published by NIST
+	key, _ := hex.DecodeString("EF4359D8D580AA4F7F036D6F04FC6A94")
+	block, err := aes.NewCipher(revBytes(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []struct {
+		tweak      string
+		plaintext  string
+		ciphertext string
+	}{
+		{"D8E7920AFA330A73", "890121234567890000", "750918814058654607"},
+		{"9A768A92F60E12D8", "890121234567890000", "018989839189395384"},
+		{"D8E7920AFA330A73", "89012123456789000000789000000", "48598367162252569629397416226"},
+	}
+	toNumerals := func(s string) []int {
+		x := make([]int, len(s))
+		for i, c := range s {
+			x[i] = int(c - '0')
+		}
+		return x
+	}
+	toString := func(x []int) string {
+		var b strings.Builder
+		for _, n := range x {
+			b.WriteByte(byte('0' + n))
+		}
+		return b.String()
+	}
+	for _, sample := range samples {
+		tweak, _ := hex.DecodeString(sample.tweak)
+		ct := toString(ff3Encrypt(block, tweak[:4], tweak[4:], 10, toNumerals(sample.plaintext)))
+		if ct != sample.ciphertext {
+			t.Fatalf("bad ciphertext for %s: %s", sample.plaintext, ct)
+		}
+		pt := toString(ff3Decrypt(block, tweak[:4], tweak[4:], 10, toNumerals(ct)))
+		if pt != sample.plaintext {
+			t.Fatalf("bad plaintext for %s: %s", ct, pt)
+		}
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	p := &Policy{
+		Name:          "test",
+		Type:          KeyType_AES256_FF3_1,
+		LatestVersion: 1,
+		Keys: keyEntryMap{
+			"1": KeyEntry{Key: key},
+		},
+	}
+	tweak := []byte("tweak56")
+
+	for alphabet, value := range map[string]string{
+		"numeric":      "4111-1111-1111-1111",
+		"alphanumeric": "AB123456C",
+	} {
+		ct, err := p.EncryptFPE(1, tweak, FPEAlphabets[alphabet], value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct == value || len(ct) != len(value) {
+			t.Fatalf("bad ciphertext for %s: %s", value, ct)
+		}
+		if alphabet == "numeric" && (ct[4] != '-' || ct[9] != '-' || ct[14] != '-') {
+			t.Fatalf("format not preserved: %s", ct)
+		}
+		pt, err := p.DecryptFPE(1, tweak, FPEAlphabets[alphabet], ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pt != value {
+			t.Fatalf("expected %s, got %s", value, pt)
+		}
+
+		other, err := p.EncryptFPE(1, []byte("other56"), FPEAlphabets[alphabet], value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other == ct {
+			t.Fatalf("expected different ciphertext for different tweak")
+		}
+	}
+
+	if _, err := p.EncryptFPE(1, tweak, FPEAlphabets["numeric"], "12345"); err == nil {
+		t.Fatal("expected error for too short value")
+	}
+	if _, err := p.EncryptFPE(1, tweak[:6], FPEAlphabets["numeric"], "123456"); err == nil {
+		t.Fatal("expected error for short tweak")
+	}
+	if _, err := p.EncryptFPE(1, tweak, "aab", "abababababababab"); err == nil {
+		t.Fatal("expected error for alphabet with duplicates")
+	}
+}
+
+func TestPolicy_ECIES(t *testing.T) {
+	for _, keyType := range []KeyType{KeyType_ECDSA_P256, KeyType_ECDSA_P384, KeyType_ECDSA_P521} {
+		p := &Policy{
+			Name: "test",
+			Type: keyType,
+		}
+		if err := p.Rotate(context.Background(), &logical.InmemStorage{}, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+
+		plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+		ciphertext, err := p.Encrypt(0, nil, nil, plaintext)
+		if err != nil {
+			t.Fatalf("%v: %s", keyType, err)
+		}
+		decrypted, err := p.Decrypt(nil, nil, ciphertext)
+		if err != nil {
+			t.Fatalf("%v: %s", keyType, err)
+		}
+		if decrypted != plaintext {
+			t.Fatalf("%v: expected %q, got %q", keyType, plaintext, decrypted)
+		}
+
+		// Tampering with the ciphertext is detected
+		raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, "vault:v1:"))
+		raw[len(raw)-1] ^= 1
+		tampered := "vault:v1:" + base64.StdEncoding.EncodeToString(raw)
+		if _, err := p.Decrypt(nil, nil, tampered); err == nil {
+			t.Fatalf("%v: expected error decrypting tampered ciphertext", keyType)
+		}
+	}
+}
+
+func TestPolicy_MLDSA(t *testing.T) {
+	if !mldsautil.Supported {
+		t.Skip("ML-DSA is not supported by this build")
+	}
+
+	for _, keyType := range []KeyType{KeyType_MLDSA44, KeyType_MLDSA65, KeyType_MLDSA87} {
+		p := &Policy{
+			Name: "test",
+			Type: keyType,
+		}
+		if err := p.Rotate(context.Background(), &logical.InmemStorage{}, rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+
+		input := []byte("the quick brown fox")
+		sig, err := p.Sign(0, nil, input, HashTypeSHA2256, "", MarshalingTypeASN1)
+		if err != nil {
+			t.Fatalf("%v: %s", keyType, err)
+		}
+		valid, err := p.VerifySignature(nil, input, HashTypeSHA2256, "", MarshalingTypeASN1, sig.Signature)
+		if err != nil || !valid {
+			t.Fatalf("%v: signature did not verify: %v", keyType, err)
+		}
+		valid, err = p.VerifySignature(nil, []byte("another input"), HashTypeSHA2256, "", MarshalingTypeASN1, sig.Signature)
+		if err != nil || valid {
+			t.Fatalf("%v: signature of another input verified: %v", keyType, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package keysutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// The operations a key usage policy can allow
+const (
+	KeyOperationEncrypt = "encrypt"
+	KeyOperationDecrypt = "decrypt"
+	KeyOperationRewrap  = "rewrap"
+	KeyOperationDatakey = "datakey"
+	KeyOperationSign    = "sign"
+	KeyOperationVerify  = "verify"
+	KeyOperationHMAC    = "hmac"
+	KeyOperationCMAC    = "cmac"
+	KeyOperationDerive  = "derive"
+	KeyOperationExport  = "export"
+)
+
+var keyOperations = []string{
+	KeyOperationEncrypt,
+	KeyOperationDecrypt,
+	KeyOperationRewrap,
+	KeyOperationDatakey,
+	KeyOperationSign,
+	KeyOperationVerify,
+	KeyOperationHMAC,
+	KeyOperationCMAC,
+	KeyOperationDerive,
+	KeyOperationExport,
+}
+
+// KeyUsagePolicy restricts the use of a key beyond the ACLs of the paths
+// using it. Each empty restriction allows everything.
+type KeyUsagePolicy struct {
+	// AllowedOperations are the operations that can be performed with the key
+	AllowedOperations []string `json:"allowed_operations,omitempty"`
+
+	// AllowedEntityIDs and AllowedGroups are the entities, and the IDs or
+	// names of the groups whose members, may use the key. Requests without
+	// an entity are denied when either is set.
+	AllowedEntityIDs []string `json:"allowed_entity_ids,omitempty"`
+	AllowedGroups    []string `json:"allowed_groups,omitempty"`
+
+	// AllowedTimeWindows are the times of day at which the key may be used,
+	// as "15:04-15:04" ranges in TimeZone. A range ending before it starts
+	// spans midnight.
+	AllowedTimeWindows []string `json:"allowed_time_windows,omitempty"`
+	TimeZone           string   `json:"time_zone,omitempty"`
+}
+
+// Validate checks that the usage policy is well formed.
+func (u *KeyUsagePolicy) Validate() error {
+	for _, op := range u.AllowedOperations {
+		if !strutil.StrListContains(keyOperations, op) {
+			return fmt.Errorf("unknown operation %q, must be one of %s", op, strings.Join(keyOperations, ", "))
+		}
+	}
+	if _, err := time.LoadLocation(u.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %w", u.TimeZone, err)
+	}
+	for _, window := range u.AllowedTimeWindows {
+		if _, _, err := parseTimeWindow(window); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check returns an errutil.UserError if the usage policy denies performing
+// the operation at the given time on behalf of the entity. The groups of the
+// entity are only looked up if the policy restricts them.
+func (u *KeyUsagePolicy) Check(operation, entityID string, groups func() ([]*logical.Group, error), now time.Time) error {
+	if len(u.AllowedOperations) > 0 && !strutil.StrListContains(u.AllowedOperations, operation) {
+		return errutil.UserError{Err: fmt.Sprintf("operation %q is not allowed by the usage policy of the key", operation)}
+	}
+
+	if len(u.AllowedEntityIDs) > 0 || len(u.AllowedGroups) > 0 {
+		allowed, err := u.entityAllowed(entityID, groups)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errutil.UserError{Err: "the usage policy of the key does not allow the entity of the request"}
+		}
+	}
+
+	if len(u.AllowedTimeWindows) > 0 {
+		loc, err := time.LoadLocation(u.TimeZone)
+		if err != nil {
+			return err
+		}
+		now = now.In(loc)
+		minute := now.Hour()*60 + now.Minute()
+
+		for _, window := range u.AllowedTimeWindows {
+			start, end, err := parseTimeWindow(window)
+			if err != nil {
+				return err
+			}
+			if start <= end && minute >= start && minute < end {
+				return nil
+			}
+			if start > end && (minute >= start || minute < end) {
+				return nil
+			}
+		}
+		return errutil.UserError{Err: "the usage policy of the key does not allow its use at this time"}
+	}
+
+	return nil
+}
+
+func (u *KeyUsagePolicy) entityAllowed(entityID string, groups func() ([]*logical.Group, error)) (bool, error) {
+	if entityID == "" {
+		return false, nil
+	}
+	if strutil.StrListContains(u.AllowedEntityIDs, entityID) {
+		return true, nil
+	}
+	if len(u.AllowedGroups) == 0 {
+		return false, nil
+	}
+
+	entityGroups, err := groups()
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the groups of entity %q: %w", entityID, err)
+	}
+	for _, group := range entityGroups {
+		if strutil.StrListContains(u.AllowedGroups, group.ID) || strutil.StrListContains(u.AllowedGroups, group.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseTimeWindow returns the minutes of the day at which the "15:04-15:04"
+// window starts and ends.
+func parseTimeWindow(window string) (int, int, error) {
+	bounds := strings.Split(window, "-")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time window %q, must be formatted as HH:MM-HH:MM", window)
+	}
+
+	var minutes [2]int
+	for i, bound := range bounds {
+		t, err := time.Parse("15:04", strings.TrimSpace(bound))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid time window %q, must be formatted as HH:MM-HH:MM", window)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	if minutes[0] == minutes[1] {
+		return 0, 0, fmt.Errorf("invalid time window %q, must not be empty", window)
+	}
+	return minutes[0], minutes[1], nil
+}
@@ -0,0 +1,171 @@
+package keysutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestKeyUsagePolicy_Validate(t *testing.T) {
+	tests := map[string]struct {
+		policy    KeyUsagePolicy
+		expectErr bool
+	}{
+		"empty": {},
+		"valid": {
+			policy: KeyUsagePolicy{
+				AllowedOperations:  []string{"encrypt", "decrypt"},
+				AllowedTimeWindows: []string{"09:00-17:30", "22:00-02:00"},
+				TimeZone:           "Europe/Paris",
+			},
+		},
+		"unknown operation": {
+			policy:    KeyUsagePolicy{AllowedOperations: []string{"encrypt", "delete"}},
+			expectErr: true,
+		},
+		"invalid time zone": {
+			policy:    KeyUsagePolicy{TimeZone: "Mars/Olympus"},
+			expectErr: true,
+		},
+		"invalid time window": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"9am-5pm"}},
+			expectErr: true,
+		},
+		"empty time window": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"09:00-09:00"}},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.policy.Validate()
+			if test.expectErr && err == nil {
+				t.Fatal("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+		})
+	}
+}
+
+func TestKeyUsagePolicy_Check(t *testing.T) {
+	groups := func() ([]*logical.Group, error) {
+		return []*logical.Group{{ID: "group-id", Name: "payments"}}, nil
+	}
+	noon := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2020, 6, 1, 0, 30, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		policy    KeyUsagePolicy
+		operation string
+		entityID  string
+		now       time.Time
+		allowed   bool
+	}{
+		"empty": {
+			operation: "encrypt",
+			now:       noon,
+			allowed:   true,
+		},
+		"allowed operation": {
+			policy:    KeyUsagePolicy{AllowedOperations: []string{"encrypt"}},
+			operation: "encrypt",
+			now:       noon,
+			allowed:   true,
+		},
+		"denied operation": {
+			policy:    KeyUsagePolicy{AllowedOperations: []string{"encrypt"}},
+			operation: "decrypt",
+			now:       noon,
+		},
+		"allowed entity": {
+			policy:    KeyUsagePolicy{AllowedEntityIDs: []string{"entity-id"}},
+			operation: "encrypt",
+			entityID:  "entity-id",
+			now:       noon,
+			allowed:   true,
+		},
+		"denied entity": {
+			policy:    KeyUsagePolicy{AllowedEntityIDs: []string{"other-id"}},
+			operation: "encrypt",
+			entityID:  "entity-id",
+			now:       noon,
+		},
+		"no entity": {
+			policy:    KeyUsagePolicy{AllowedGroups: []string{"payments"}},
+			operation: "encrypt",
+			now:       noon,
+		},
+		"allowed group name": {
+			policy:    KeyUsagePolicy{AllowedGroups: []string{"payments"}},
+			operation: "encrypt",
+			entityID:  "entity-id",
+			now:       noon,
+			allowed:   true,
+		},
+		"allowed group ID": {
+			policy:    KeyUsagePolicy{AllowedEntityIDs: []string{"other-id"}, AllowedGroups: []string{"group-id"}},
+			operation: "encrypt",
+			entityID:  "entity-id",
+			now:       noon,
+			allowed:   true,
+		},
+		"denied group": {
+			policy:    KeyUsagePolicy{AllowedGroups: []string{"billing"}},
+			operation: "encrypt",
+			entityID:  "entity-id",
+			now:       noon,
+		},
+		"within time window": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"09:00-17:00"}},
+			operation: "encrypt",
+			now:       noon,
+			allowed:   true,
+		},
+		"outside time window": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"09:00-17:00"}},
+			operation: "encrypt",
+			now:       midnight,
+		},
+		"time window spanning midnight": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"09:00-10:00", "22:00-02:00"}},
+			operation: "encrypt",
+			now:       midnight,
+			allowed:   true,
+		},
+		"time window in time zone": {
+			policy:    KeyUsagePolicy{AllowedTimeWindows: []string{"09:00-17:00"}, TimeZone: "Asia/Tokyo"},
+			operation: "encrypt",
+			now:       midnight,
+			allowed:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.policy.Check(test.operation, test.entityID, groups, test.now)
+			if test.allowed && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+			if !test.allowed && err == nil {
+				t.Fatal("err expected, got nil")
+			}
+		})
+	}
+
+	// Groups are only looked up when the entity is not allowed directly
+	policy := KeyUsagePolicy{AllowedEntityIDs: []string{"entity-id"}, AllowedGroups: []string{"payments"}}
+	failingGroups := func() ([]*logical.Group, error) {
+		return nil, errors.New("lookup failed")
+	}
+	if err := policy.Check("encrypt", "entity-id", failingGroups, noon); err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+	if err := policy.Check("encrypt", "other-id", failingGroups, noon); err == nil {
+		t.Fatal("err expected, got nil")
+	}
+}
@@ -0,0 +1,105 @@
+//go:build go1.27
+// +build go1.27
+
+package mldsautil
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"fmt"
+	"io"
+)
+
+// Supported is whether this build of Vault supports ML-DSA.
+const Supported = true
+
+func parameters(paramSet string) (mldsa.Parameters, error) {
+	switch paramSet {
+	case MLDSA44:
+		return mldsa.MLDSA44(), nil
+	case MLDSA65:
+		return mldsa.MLDSA65(), nil
+	case MLDSA87:
+		return mldsa.MLDSA87(), nil
+	}
+	return mldsa.Parameters{}, fmt.Errorf("unknown ML-DSA parameter set %q", paramSet)
+}
+
+// GenerateKey generates a key of the parameter set from a seed read from
+// randReader, returning the seed and the encoded public key.
+func GenerateKey(paramSet string, randReader io.Reader) ([]byte, []byte, error) {
+	params, err := parameters(paramSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(randReader, seed); err != nil {
+		return nil, nil, err
+	}
+	key, err := mldsa.NewPrivateKey(params, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return seed, key.PublicKey().Bytes(), nil
+}
+
+// NewSigner returns the private key of the parameter set expanded from the
+// seed. Its signatures are pure ML-DSA signatures with an empty context.
+func NewSigner(paramSet string, seed []byte) (crypto.Signer, error) {
+	params, err := parameters(paramSet)
+	if err != nil {
+		return nil, err
+	}
+	return mldsa.NewPrivateKey(params, seed)
+}
+
+// ParameterSet returns the parameter set of the ML-DSA public or private key,
+// and whether the key is an ML-DSA key.
+func ParameterSet(key interface{}) (string, bool) {
+	var params mldsa.Parameters
+	switch k := key.(type) {
+	case *mldsa.PublicKey:
+		params = k.Parameters()
+	case *mldsa.PrivateKey:
+		params = k.PublicKey().Parameters()
+	default:
+		return "", false
+	}
+	switch params {
+	case mldsa.MLDSA44():
+		return MLDSA44, true
+	case mldsa.MLDSA65():
+		return MLDSA65, true
+	case mldsa.MLDSA87():
+		return MLDSA87, true
+	}
+	return "", false
+}
+
+// ParsePrivateKey returns the parameter set, seed and encoded public key of
+// the ML-DSA private key, and whether the key is an ML-DSA private key.
+func ParsePrivateKey(key interface{}) (string, []byte, []byte, bool) {
+	k, ok := key.(*mldsa.PrivateKey)
+	if !ok {
+		return "", nil, nil, false
+	}
+	paramSet, ok := ParameterSet(k)
+	if !ok {
+		return "", nil, nil, false
+	}
+	return paramSet, k.Bytes(), k.PublicKey().Bytes(), true
+}
+
+// Verify returns whether the signature of the message is valid for the
+// encoded public key of the parameter set.
+func Verify(paramSet string, publicKey, message, signature []byte) (bool, error) {
+	params, err := parameters(paramSet)
+	if err != nil {
+		return false, err
+	}
+	key, err := mldsa.NewPublicKey(params, publicKey)
+	if err != nil {
+		return false, err
+	}
+	return mldsa.Verify(key, message, signature, nil) == nil, nil
+}
@@ -0,0 +1,37 @@
+//go:build !go1.27
+// +build !go1.27
+
+package mldsautil
+
+import (
+	"crypto"
+	"io"
+)
+
+// Supported is whether this build of Vault supports ML-DSA.
+const Supported = false
+
+// GenerateKey returns ErrUnsupported.
+func GenerateKey(string, io.Reader) ([]byte, []byte, error) {
+	return nil, nil, ErrUnsupported
+}
+
+// NewSigner returns ErrUnsupported.
+func NewSigner(string, []byte) (crypto.Signer, error) {
+	return nil, ErrUnsupported
+}
+
+// ParameterSet returns false, as there are no ML-DSA keys.
+func ParameterSet(interface{}) (string, bool) {
+	return "", false
+}
+
+// ParsePrivateKey returns false, as there are no ML-DSA keys.
+func ParsePrivateKey(interface{}) (string, []byte, []byte, bool) {
+	return "", nil, nil, false
+}
+
+// Verify returns ErrUnsupported.
+func Verify(string, []byte, []byte, []byte) (bool, error) {
+	return false, ErrUnsupported
+}
@@ -0,0 +1,60 @@
+// Package mldsautil provides the experimental support of the ML-DSA
+// post-quantum signature scheme (FIPS 204). ML-DSA is only available when
+// Vault is built with a Go toolchain providing it, and is only enabled when
+// the VAULT_EXPERIMENTAL_ML_DSA environment variable is set.
+package mldsautil
+
+import (
+	"errors"
+	"os"
+)
+
+const (
+	// EnvExperimental is the environment variable enabling ML-DSA.
+	EnvExperimental = "VAULT_EXPERIMENTAL_ML_DSA"
+
+	// The ML-DSA parameter sets, in increasing order of security.
+	MLDSA44 = "ml-dsa-44"
+	MLDSA65 = "ml-dsa-65"
+	MLDSA87 = "ml-dsa-87"
+
+	// SeedSize is the size in bytes of the seed from which the private key
+	// is expanded; it is how the private keys are stored.
+	SeedSize = 32
+)
+
+var (
+	// ErrUnsupported is returned when this build of Vault does not support
+	// ML-DSA.
+	ErrUnsupported = errors.New("ML-DSA is not supported by this build of Vault")
+
+	// ErrDisabled is returned when ML-DSA is used without being enabled.
+	ErrDisabled = errors.New("ML-DSA is experimental and must be enabled by setting " + EnvExperimental)
+)
+
+// Enabled returns whether ML-DSA is supported by this build and enabled.
+func Enabled() bool {
+	return Supported && os.Getenv(EnvExperimental) != ""
+}
+
+// CheckEnabled returns ErrUnsupported or ErrDisabled if ML-DSA cannot be
+// used.
+func CheckEnabled() error {
+	switch {
+	case !Supported:
+		return ErrUnsupported
+	case os.Getenv(EnvExperimental) == "":
+		return ErrDisabled
+	}
+	return nil
+}
+
+// ValidParameterSet returns whether the name is the name of an ML-DSA
+// parameter set.
+func ValidParameterSet(name string) bool {
+	switch name {
+	case MLDSA44, MLDSA65, MLDSA87:
+		return true
+	}
+	return false
+}
@@ -135,6 +135,17 @@ func (s *Salt) GetIdentifiedHMAC(data string) string {
 	return s.config.HMACType + ":" + s.GetHMAC(data)
 }
 
+// Value returns the underlying salt value. It must be handled as a secret,
+// since it allows computing the HMACs of any value offline.
+func (s *Salt) Value() string {
+	return s.salt
+}
+
+// HMACType returns the string prepended to the identified HMACs.
+func (s *Salt) HMACType() string {
+	return s.config.HMACType
+}
+
 // DidGenerate returns true if the underlying salt value was generated
 // on initialization.
 func (s *Salt) DidGenerate() bool {
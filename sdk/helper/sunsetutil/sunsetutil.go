@@ -0,0 +1,122 @@
+// Package sunsetutil provides the fields that let roles be deprecated and
+// sunset, so that operators can migrate clients away from them in a
+// controlled way.
+package sunsetutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// DefaultWarningPeriod is the time before the sunset during which responses
+// include deprecation warnings, unless the role sets another one.
+const DefaultWarningPeriod = 30 * 24 * time.Hour
+
+// SunsetParams contains the parameters that roles can use to be sunset
+type SunsetParams struct {
+	// The time after which the role is denied issuance. Zero if the role is
+	// never sunset.
+	Sunset time.Time `json:"sunset" mapstructure:"sunset"`
+
+	// The time before the sunset during which responses include deprecation
+	// warnings
+	SunsetWarningPeriod time.Duration `json:"sunset_warning_period" mapstructure:"sunset_warning_period"`
+}
+
+// AddSunsetFields adds fields to an existing role. It panics if it would
+// overwrite an existing field.
+func AddSunsetFields(m map[string]*framework.FieldSchema) {
+	for k, v := range SunsetFields() {
+		if _, has := m[k]; has {
+			panic(fmt.Sprintf("adding role field %s would overwrite existing field", k))
+		}
+		m[k] = v
+	}
+}
+
+// SunsetFields provides a set of field schemas for the parameters
+func SunsetFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"sunset": &framework.FieldSchema{
+			Type: framework.TypeString,
+			Description: `RFC3339 timestamp after which the role is denied issuance.
+An empty string removes the sunset.`,
+			DisplayAttrs: &framework.DisplayAttributes{
+				Name:  "Sunset",
+				Group: "Deprecation",
+			},
+		},
+
+		"sunset_warning_period": &framework.FieldSchema{
+			Type: framework.TypeDurationSecond,
+			Description: `Time before the sunset during which responses include
+deprecation warnings. Defaults to 30 days.`,
+			DisplayAttrs: &framework.DisplayAttributes{
+				Name:  "Sunset Warning Period",
+				Group: "Deprecation",
+			},
+		},
+	}
+}
+
+// ParseSunsetFields provides common field parsing functionality into a
+// SunsetParams struct
+func (s *SunsetParams) ParseSunsetFields(req *logical.Request, d *framework.FieldData) error {
+	if sunsetRaw, ok := d.GetOk("sunset"); ok {
+		s.Sunset = time.Time{}
+		if sunsetRaw.(string) != "" {
+			sunset, err := parseutil.ParseAbsoluteTime(sunsetRaw.(string))
+			if err != nil {
+				return fmt.Errorf("invalid 'sunset': %w", err)
+			}
+			s.Sunset = sunset.UTC()
+		}
+	}
+
+	if periodRaw, ok := d.GetOk("sunset_warning_period"); ok {
+		s.SunsetWarningPeriod = time.Duration(periodRaw.(int)) * time.Second
+	}
+	if s.SunsetWarningPeriod < 0 {
+		return errors.New("'sunset_warning_period' cannot be negative")
+	}
+
+	return nil
+}
+
+// PopulateSunsetData adds information from SunsetParams into the map
+func (s *SunsetParams) PopulateSunsetData(m map[string]interface{}) {
+	m["sunset"] = ""
+	if !s.Sunset.IsZero() {
+		m["sunset"] = s.Sunset.Format(time.RFC3339)
+	}
+	m["sunset_warning_period"] = int64(s.warningPeriod().Seconds())
+}
+
+// CheckSunset returns an error if the role has been sunset at the given time,
+// and a deprecation warning if it is within its warning period. Both are
+// empty if the role is not deprecated.
+func (s *SunsetParams) CheckSunset(roleName string, now time.Time) (warning string, err error) {
+	if s.Sunset.IsZero() {
+		return "", nil
+	}
+	sunset := s.Sunset.Format(time.RFC3339)
+	if !now.Before(s.Sunset) {
+		return "", fmt.Errorf("role %q was sunset on %s and can no longer be used; migrate to another role", roleName, sunset)
+	}
+	if !now.Before(s.Sunset.Add(-s.warningPeriod())) {
+		return fmt.Sprintf("role %q is deprecated and will be sunset on %s; migrate to another role before then", roleName, sunset), nil
+	}
+	return "", nil
+}
+
+func (s *SunsetParams) warningPeriod() time.Duration {
+	if s.SunsetWarningPeriod == 0 {
+		return DefaultWarningPeriod
+	}
+	return s.SunsetWarningPeriod
+}
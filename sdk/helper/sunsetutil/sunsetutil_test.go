@@ -0,0 +1,89 @@
+package sunsetutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+)
+
+func TestSunsetParams_ParseSunsetFields(t *testing.T) {
+	d := &framework.FieldData{
+		Raw: map[string]interface{}{
+			"sunset":                "2030-01-02T03:04:05+01:00",
+			"sunset_warning_period": "72h",
+		},
+		Schema: SunsetFields(),
+	}
+
+	var s SunsetParams
+	if err := s.ParseSunsetFields(nil, d); err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2030, 1, 2, 2, 4, 5, 0, time.UTC); !s.Sunset.Equal(expected) {
+		t.Fatalf("bad sunset: %s", s.Sunset)
+	}
+	if s.SunsetWarningPeriod != 72*time.Hour {
+		t.Fatalf("bad warning period: %s", s.SunsetWarningPeriod)
+	}
+
+	// An empty sunset removes it
+	d.Raw = map[string]interface{}{"sunset": ""}
+	if err := s.ParseSunsetFields(nil, d); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Sunset.IsZero() {
+		t.Fatalf("expected sunset to be removed, got %s", s.Sunset)
+	}
+
+	d.Raw = map[string]interface{}{"sunset": "next tuesday"}
+	if err := s.ParseSunsetFields(nil, d); err == nil {
+		t.Fatal("expected error for an invalid sunset")
+	}
+}
+
+func TestSunsetParams_CheckSunset(t *testing.T) {
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		params        SunsetParams
+		now           time.Time
+		expectWarning bool
+		expectErr     bool
+	}{
+		"no sunset": {
+			params: SunsetParams{},
+			now:    sunset,
+		},
+		"before warning period": {
+			params: SunsetParams{Sunset: sunset},
+			now:    sunset.Add(-DefaultWarningPeriod - time.Second),
+		},
+		"default warning period": {
+			params:        SunsetParams{Sunset: sunset},
+			now:           sunset.Add(-DefaultWarningPeriod),
+			expectWarning: true,
+		},
+		"custom warning period": {
+			params: SunsetParams{Sunset: sunset, SunsetWarningPeriod: time.Hour},
+			now:    sunset.Add(-2 * time.Hour),
+		},
+		"sunset": {
+			params:    SunsetParams{Sunset: sunset},
+			now:       sunset,
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			warning, err := test.params.CheckSunset("test", test.now)
+			if (warning != "") != test.expectWarning {
+				t.Fatalf("unexpected warning: %q", warning)
+			}
+			if (err != nil) != test.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
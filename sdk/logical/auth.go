@@ -100,6 +100,34 @@ type Auth struct {
 
 	// Orphan is set if the token does not have a parent
 	Orphan bool `json:"orphan"`
+
+	// CreationIP is the address of the client which logged in. It is set by
+	// core and recorded on the token.
+	CreationIP string `json:"creation_ip"`
+
+	// Session describes how the client logged in. Auth backends may set
+	// whether the login satisfied a second factor and the key the client
+	// authenticated with; the auth mount is set by core, which records the
+	// session on the token.
+	Session *AuthSession `json:"session"`
+}
+
+// AuthSession describes how a client authenticated to obtain a token, so
+// that policies can grant capabilities conditioned on it. Child tokens share
+// the session of their parent.
+type AuthSession struct {
+	// MountType and MountAccessor identify the auth mount the client logged
+	// in with
+	MountType     string `json:"mount_type" mapstructure:"mount_type" structs:"mount_type"`
+	MountAccessor string `json:"mount_accessor" mapstructure:"mount_accessor" structs:"mount_accessor"`
+
+	// MFA is set if the login satisfied a second factor
+	MFA bool `json:"mfa" mapstructure:"mfa" structs:"mfa"`
+
+	// KeyType and KeyBits describe the public key the client authenticated
+	// with, if any, e.g. "rsa" and 2048 for a client certificate
+	KeyType string `json:"key_type,omitempty" mapstructure:"key_type" structs:"key_type"`
+	KeyBits int    `json:"key_bits,omitempty" mapstructure:"key_bits" structs:"key_bits"`
 }
 
 func (a *Auth) GoString() string {
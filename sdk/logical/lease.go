@@ -27,6 +27,11 @@ type LeaseOptions struct {
 	// a response. It can be used to enforce maximum lease periods by
 	// a logical backend.
 	IssueTime time.Time `json:"-"`
+
+	// RenewAt is the time at which Vault recommends renewing the lease, set
+	// when returning a response. Recommended times are spread out so that
+	// clients do not all renew at the same moment.
+	RenewAt time.Time `json:"-"`
 }
 
 // LeaseEnabled checks if leasing is enabled
@@ -1,10 +1,13 @@
 package logical
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync/atomic"
 
 	"github.com/hashicorp/vault/sdk/helper/wrapping"
@@ -37,6 +40,15 @@ const (
 	// If set, HTTPRawCacheControl will replace the default Cache-Control=no-store header
 	// set by the generic wrapping handler. The value must be a string.
 	HTTPRawCacheControl = "http_raw_cache_control"
+
+	// If set, HTTPRawContentTransferEncoding is sent as the
+	// Content-Transfer-Encoding header, as required by specifications such as
+	// EST for base64 encoded bodies. The value must be a string.
+	HTTPRawContentTransferEncoding = "http_raw_content_transfer_encoding"
+
+	// ETagHeader is the header carrying the entity tag of a response, which
+	// clients send back in the If-None-Match header of conditional requests.
+	ETagHeader = "ETag"
 )
 
 // Response is a struct that stores the response of a request.
@@ -81,6 +93,24 @@ func (r *Response) AddWarning(warning string) {
 	r.Warnings = append(r.Warnings, warning)
 }
 
+// SetETag sets the entity tag of the response. A GET request whose
+// If-None-Match header matches the tag is answered with a 304 Not Modified
+// and no body, so the tag must change whenever the response does.
+func (r *Response) SetETag(etag string) {
+	if r.Headers == nil {
+		r.Headers = make(map[string][]string)
+	}
+	r.Headers[ETagHeader] = []string{etag}
+}
+
+// ETag returns the entity tag of the response, if any.
+func (r *Response) ETag() string {
+	if r == nil || len(r.Headers[ETagHeader]) == 0 {
+		return ""
+	}
+	return r.Headers[ETagHeader][0]
+}
+
 // IsError returns true if this response seems to indicate an error.
 func (r *Response) IsError() bool {
 	return r != nil && r.Data != nil && len(r.Data) == 1 && r.Data["error"] != nil
@@ -211,3 +241,31 @@ func (rw *HTTPResponseWriter) Write(bytes []byte) (int, error) {
 func (rw *HTTPResponseWriter) Written() bool {
 	return atomic.LoadUint32(rw.written) == 1
 }
+
+// NewETag returns a strong entity tag for the given content.
+func NewETag(content ...[]byte) string {
+	h := sha256.New()
+	for _, c := range content {
+		h.Write(c)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// ETagMatches returns whether the If-None-Match header values of a request
+// match the entity tag. As required for If-None-Match, weak tags are compared
+// by their opaque value and "*" matches any tag.
+func ETagMatches(ifNoneMatch []string, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, header := range ifNoneMatch {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
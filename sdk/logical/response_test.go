@@ -0,0 +1,36 @@
+package logical
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	etag := NewETag([]byte("foo"))
+	if etag != NewETag([]byte("f"), []byte("oo")) {
+		t.Fatalf("tags of the same content differ")
+	}
+	if etag == NewETag([]byte("bar")) {
+		t.Fatalf("tags of different content are equal")
+	}
+
+	tests := map[string]struct {
+		ifNoneMatch []string
+		etag        string
+		expected    bool
+	}{
+		"no header":  {nil, etag, false},
+		"no tag":     {[]string{"*"}, "", false},
+		"match":      {[]string{etag}, etag, true},
+		"mismatch":   {[]string{`"bar"`}, etag, false},
+		"any":        {[]string{"*"}, etag, true},
+		"list":       {[]string{`"bar", ` + etag}, etag, true},
+		"headers":    {[]string{`"bar"`, etag}, etag, true},
+		"weak match": {[]string{"W/" + etag}, etag, true},
+		"weak tag":   {[]string{etag}, "W/" + etag, true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := ETagMatches(tc.ifNoneMatch, tc.etag); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
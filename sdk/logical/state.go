@@ -0,0 +1,55 @@
+package logical
+
+import (
+	"context"
+)
+
+// StateEntry is an object of the state of a backend, such as a role or a
+// secret. Kind is the type of the object and Key identifies it among the
+// objects of its kind; both are defined by the backend.
+type StateEntry struct {
+	Kind string                 `json:"kind"`
+	Key  string                 `json:"key"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// BackendState is the data of a backend, exported in a format that does not
+// depend on how the backend lays it out in storage. Format identifies the
+// layout of the entries, so that a newer version of the backend can convert
+// them when importing them.
+type BackendState struct {
+	Format  string        `json:"format"`
+	Entries []*StateEntry `json:"entries"`
+}
+
+// StateBackend is implemented by backends whose data can be moved to another
+// version of the backend or to another mount. Backends that do not support it
+// return ErrUnsupportedOperation.
+type StateBackend interface {
+	// ExportState returns the data of the backend found in the given storage.
+	ExportState(context.Context, Storage) (*BackendState, error)
+
+	// ImportState writes the given data to the given storage, overwriting
+	// the existing objects with the same kind and key.
+	ImportState(context.Context, Storage, *BackendState) error
+}
+
+// ExportBackendState exports the state of the backend, returning
+// ErrUnsupportedOperation if the backend does not support it.
+func ExportBackendState(ctx context.Context, b Backend, s Storage) (*BackendState, error) {
+	sb, ok := b.(StateBackend)
+	if !ok {
+		return nil, ErrUnsupportedOperation
+	}
+	return sb.ExportState(ctx, s)
+}
+
+// ImportBackendState imports the state into the backend, returning
+// ErrUnsupportedOperation if the backend does not support it.
+func ImportBackendState(ctx context.Context, b Backend, s Storage, state *BackendState) error {
+	sb, ok := b.(StateBackend)
+	if !ok {
+		return ErrUnsupportedOperation
+	}
+	return sb.ImportState(ctx, s, state)
+}
@@ -76,6 +76,16 @@ type SystemView interface {
 	GeneratePasswordFromPolicy(ctx context.Context, policyName string) (password string, err error)
 }
 
+// CapabilitiesView is implemented by the system views of the backends which
+// run in Vault's process, such as the builtin backends. It is not available
+// over the plugin system, so backends must check for it.
+type CapabilitiesView interface {
+	// Capabilities returns the capabilities of the token with the accessor on
+	// each of the paths. The paths include the namespace of the mount, like
+	// the MountPoint of the requests.
+	Capabilities(ctx context.Context, accessor string, paths []string) (map[string][]string, error)
+}
+
 type PasswordPolicy interface {
 	// Generate a random password
 	Generate(context.Context, io.Reader) (string, error)
@@ -104,6 +114,7 @@ type StaticSystemView struct {
 	VaultVersion        string
 	PluginEnvironment   *PluginEnvironment
 	PasswordPolicies    map[string]PasswordGenerator
+	CapabilitiesVal     map[string][]string
 }
 
 type noopAuditor struct{}
@@ -180,6 +191,18 @@ func (d StaticSystemView) PluginEnv(_ context.Context) (*PluginEnvironment, erro
 	return d.PluginEnvironment, nil
 }
 
+func (d StaticSystemView) Capabilities(_ context.Context, _ string, paths []string) (map[string][]string, error) {
+	ret := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		capabilities, ok := d.CapabilitiesVal[path]
+		if !ok {
+			capabilities = []string{"deny"}
+		}
+		ret[path] = capabilities
+	}
+	return ret, nil
+}
+
 func (d StaticSystemView) GeneratePasswordFromPolicy(ctx context.Context, policyName string) (password string, err error) {
 	select {
 	case <-ctx.Done():
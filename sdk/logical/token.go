@@ -141,6 +141,14 @@ type TokenEntry struct {
 	// CubbyholeID is the identifier of the cubbyhole storage belonging to this
 	// token
 	CubbyholeID string `json:"cubbyhole_id" mapstructure:"cubbyhole_id" structs:"cubbyhole_id" sentinel:""`
+
+	// CreationIP is the address of the client which created the token, if
+	// known
+	CreationIP string `json:"creation_ip" mapstructure:"creation_ip" structs:"creation_ip" sentinel:""`
+
+	// Session describes how the client authenticated to obtain the token, or
+	// the token it was created from
+	Session *AuthSession `json:"session,omitempty" mapstructure:"session" structs:"session" sentinel:""`
 }
 
 func (te *TokenEntry) SentinelGet(key string) (interface{}, error) {
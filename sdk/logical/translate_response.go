@@ -22,6 +22,7 @@ func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 		httpResp.LeaseID = input.Secret.LeaseID
 		httpResp.Renewable = input.Secret.Renewable
 		httpResp.LeaseDuration = int(input.Secret.TTL.Seconds())
+		httpResp.RenewAt = formatRenewAt(input.Secret.RenewAt)
 	}
 
 	// If we have authentication information, then
@@ -35,6 +36,7 @@ func LogicalResponseToHTTPResponse(input *Response) *HTTPResponse {
 			IdentityPolicies: input.Auth.IdentityPolicies,
 			Metadata:         input.Auth.Metadata,
 			LeaseDuration:    int(input.Auth.TTL.Seconds()),
+			RenewAt:          formatRenewAt(input.Auth.RenewAt),
 			Renewable:        input.Auth.Renewable,
 			EntityID:         input.Auth.EntityID,
 			TokenType:        input.Auth.TokenType.String(),
@@ -58,6 +60,7 @@ func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 		}
 		logicalResp.Secret.Renewable = input.Renewable
 		logicalResp.Secret.TTL = time.Second * time.Duration(input.LeaseDuration)
+		logicalResp.Secret.RenewAt = parseRenewAt(input.RenewAt)
 	}
 
 	if input.Auth != nil {
@@ -73,6 +76,7 @@ func HTTPResponseToLogicalResponse(input *HTTPResponse) *Response {
 		}
 		logicalResp.Auth.Renewable = input.Auth.Renewable
 		logicalResp.Auth.TTL = time.Second * time.Duration(input.Auth.LeaseDuration)
+		logicalResp.Auth.RenewAt = parseRenewAt(input.Auth.RenewAt)
 		switch input.Auth.TokenType {
 		case "service":
 			logicalResp.Auth.TokenType = TokenTypeService
@@ -89,6 +93,7 @@ type HTTPResponse struct {
 	LeaseID       string                 `json:"lease_id"`
 	Renewable     bool                   `json:"renewable"`
 	LeaseDuration int                    `json:"lease_duration"`
+	RenewAt       string                 `json:"renew_at,omitempty"`
 	Data          map[string]interface{} `json:"data"`
 	WrapInfo      *HTTPWrapInfo          `json:"wrap_info"`
 	Warnings      []string               `json:"warnings"`
@@ -104,6 +109,7 @@ type HTTPAuth struct {
 	IdentityPolicies []string          `json:"identity_policies,omitempty"`
 	Metadata         map[string]string `json:"metadata"`
 	LeaseDuration    int               `json:"lease_duration"`
+	RenewAt          string            `json:"renew_at,omitempty"`
 	Renewable        bool              `json:"renewable"`
 	EntityID         string            `json:"entity_id"`
 	TokenType        string            `json:"token_type"`
@@ -155,3 +161,25 @@ func (h HTTPSysInjector) MarshalJSON() ([]byte, error) {
 	buf.Write(j[1:])
 	return buf.Bytes(), nil
 }
+
+// formatRenewAt formats the recommended renewal time of a lease for an HTTP
+// response, or returns the empty string if none is set.
+func formatRenewAt(renewAt time.Time) string {
+	if renewAt.IsZero() {
+		return ""
+	}
+	return renewAt.UTC().Format(time.RFC3339)
+}
+
+// parseRenewAt parses the recommended renewal time of a lease from an HTTP
+// response, returning the zero time if it is not set or invalid.
+func parseRenewAt(renewAt string) time.Time {
+	if renewAt == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, renewAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
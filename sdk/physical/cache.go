@@ -2,6 +2,9 @@ package physical
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"sync/atomic"
 
 	metrics "github.com/armon/go-metrics"
@@ -54,12 +57,56 @@ func cacheRefreshFromContext(ctx context.Context) bool {
 // by using a simple write-through cache.
 type Cache struct {
 	backend         Backend
-	lru             *lru.TwoQueueCache
+	lru             cacheStore
 	locks           []*locksutil.LockEntry
 	logger          log.Logger
 	enabled         *uint32
 	cacheExceptions *pathmanager.PathManager
 	metricSink      metrics.MetricSink
+	metricsPrefixes []string
+}
+
+// CacheConfig configures the sizing and eviction of a Cache.
+type CacheConfig struct {
+	// Size is the maximum number of entries to cache. If no size is
+	// provided, the default size is used.
+	Size int
+
+	// SizeBytes is the maximum total size of the keys and values to cache.
+	// When set, it takes precedence over Size and entries are evicted using
+	// CacheEvictionLRU.
+	SizeBytes int
+
+	// EvictionPolicy is the policy used to evict entries when the cache is
+	// full, CacheEviction2Q by default.
+	EvictionPolicy string
+
+	// MetricsPrefixes are the key prefixes cache hits and misses are
+	// labeled with. Keys matching none of them are labeled "other". When
+	// empty, hits and misses aren't labeled.
+	MetricsPrefixes []string
+}
+
+// Validate checks that the cache configuration is valid.
+func (c *CacheConfig) Validate() error {
+	if c.Size < 0 {
+		return errors.New("cache size cannot be negative")
+	}
+	if c.SizeBytes < 0 {
+		return errors.New("cache size in bytes cannot be negative")
+	}
+
+	switch c.EvictionPolicy {
+	case "", CacheEvictionLRU:
+	case CacheEviction2Q:
+		if c.SizeBytes > 0 {
+			return fmt.Errorf("cache eviction policy %q cannot be used with a cache size in bytes", c.EvictionPolicy)
+		}
+	default:
+		return fmt.Errorf("unknown cache eviction policy %q", c.EvictionPolicy)
+	}
+
+	return nil
 }
 
 // TransactionalCache is a Cache that wraps the physical that is transactional
@@ -77,17 +124,27 @@ var _ Transactional = (*TransactionalCache)(nil)
 // NewCache returns a physical cache of the given size.
 // If no size is provided, the default size is used.
 func NewCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricSink) *Cache {
-	if logger.IsDebug() {
-		logger.Debug("creating LRU cache", "size", size)
+	c, _ := NewCacheWithConfig(b, &CacheConfig{Size: size}, logger, metricSink)
+	return c
+}
+
+// NewCacheWithConfig returns a physical cache sized and evicting entries as
+// configured.
+func NewCacheWithConfig(b Backend, conf *CacheConfig, logger log.Logger, metricSink metrics.MetricSink) (*Cache, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
 	}
-	if size <= 0 {
-		size = DefaultCacheSize
+	if logger.IsDebug() {
+		logger.Debug("creating LRU cache", "size", conf.Size, "size_bytes", conf.SizeBytes, "eviction_policy", conf.EvictionPolicy)
 	}
 
 	pm := pathmanager.New()
 	pm.AddPaths(cacheExceptionsPaths)
 
-	cache, _ := lru.New2Q(size)
+	cache, err := newCacheStore(conf)
+	if err != nil {
+		return nil, err
+	}
 	c := &Cache{
 		backend: b,
 		lru:     cache,
@@ -97,16 +154,28 @@ func NewCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricS
 		enabled:         new(uint32),
 		cacheExceptions: pm,
 		metricSink:      metricSink,
+		metricsPrefixes: conf.MetricsPrefixes,
 	}
-	return c
+	return c, nil
 }
 
 func NewTransactionalCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricSink) *TransactionalCache {
+	c, _ := NewTransactionalCacheWithConfig(b, &CacheConfig{Size: size}, logger, metricSink)
+	return c
+}
+
+// NewTransactionalCacheWithConfig returns a transactional physical cache sized
+// and evicting entries as configured.
+func NewTransactionalCacheWithConfig(b Backend, conf *CacheConfig, logger log.Logger, metricSink metrics.MetricSink) (*TransactionalCache, error) {
+	cache, err := NewCacheWithConfig(b, conf, logger, metricSink)
+	if err != nil {
+		return nil, err
+	}
 	c := &TransactionalCache{
-		Cache:         NewCache(b, size, logger, metricSink),
+		Cache:         cache,
 		Transactional: b.(Transactional),
 	}
-	return c
+	return c, nil
 }
 
 func (c *Cache) ShouldCache(key string) bool {
@@ -170,12 +239,12 @@ func (c *Cache) Get(ctx context.Context, key string) (*Entry, error) {
 			if raw == nil {
 				return nil, nil
 			}
-			c.metricSink.IncrCounter([]string{"cache", "hit"}, 1)
+			c.incrLookupCounter("hit", key)
 			return raw.(*Entry), nil
 		}
 	}
 
-	c.metricSink.IncrCounter([]string{"cache", "miss"}, 1)
+	c.incrLookupCounter("miss", key)
 	// Read from the underlying backend
 	ent, err := c.backend.Get(ctx, key)
 	if err != nil {
@@ -188,6 +257,23 @@ func (c *Cache) Get(ctx context.Context, key string) (*Entry, error) {
 	return ent, nil
 }
 
+// incrLookupCounter counts a cache hit or miss, labeled with the longest
+// configured metrics prefix matching the key.
+func (c *Cache) incrLookupCounter(result string, key string) {
+	if len(c.metricsPrefixes) == 0 {
+		c.metricSink.IncrCounter([]string{"cache", result}, 1)
+		return
+	}
+
+	prefix := "other"
+	for _, p := range c.metricsPrefixes {
+		if strings.HasPrefix(key, p) && (prefix == "other" || len(p) > len(prefix)) {
+			prefix = p
+		}
+	}
+	c.metricSink.IncrCounterWithLabels([]string{"cache", result}, 1, []metrics.Label{{Name: "prefix", Value: prefix}})
+}
+
 func (c *Cache) Delete(ctx context.Context, key string) error {
 	if !c.ShouldCache(key) {
 		return c.backend.Delete(ctx, key)
@@ -215,8 +301,11 @@ func (c *TransactionalCache) Locks() []*locksutil.LockEntry {
 	return c.locks
 }
 
+// LRU returns the underlying 2Q cache, or nil if the cache uses another
+// eviction policy.
 func (c *TransactionalCache) LRU() *lru.TwoQueueCache {
-	return c.lru
+	cache, _ := c.lru.(*lru.TwoQueueCache)
+	return cache
 }
 
 func (c *TransactionalCache) Transaction(ctx context.Context, txns []*TxnEntry) error {
@@ -0,0 +1,144 @@
+package physical
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+const (
+	// CacheEviction2Q evicts entries using the 2Q algorithm, which keeps
+	// frequently read entries cached when scanning through many keys once
+	CacheEviction2Q = "2q"
+
+	// CacheEvictionLRU evicts the least recently used entries
+	CacheEvictionLRU = "lru"
+)
+
+// cacheStore holds the entries of a Cache. Implementations are safe for
+// concurrent use.
+type cacheStore interface {
+	Get(key interface{}) (interface{}, bool)
+	Add(key, value interface{})
+	Remove(key interface{})
+	Purge()
+}
+
+// newCacheStore returns the store described by the configuration, which must
+// have been validated.
+func newCacheStore(conf *CacheConfig) (cacheStore, error) {
+	if conf.SizeBytes > 0 {
+		return newSizedLRUStore(conf.SizeBytes)
+	}
+
+	size := conf.Size
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	switch conf.EvictionPolicy {
+	case "", CacheEviction2Q:
+		return lru.New2Q(size)
+	case CacheEvictionLRU:
+		cache, err := lru.New(size)
+		if err != nil {
+			return nil, err
+		}
+		return &lruStore{Cache: cache}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache eviction policy %q", conf.EvictionPolicy)
+	}
+}
+
+// lruStore adapts lru.Cache to cacheStore.
+type lruStore struct {
+	*lru.Cache
+}
+
+func (s *lruStore) Add(key, value interface{}) {
+	s.Cache.Add(key, value)
+}
+
+func (s *lruStore) Remove(key interface{}) {
+	s.Cache.Remove(key)
+}
+
+// sizedLRUStore is an LRU cache bounded by the total size of the keys and
+// values it holds rather than by their number, so that a few large entries
+// can't be cached at the expense of many small ones and vice versa.
+type sizedLRUStore struct {
+	l       sync.Mutex
+	lru     *simplelru.LRU
+	size    int
+	maxSize int
+}
+
+func newSizedLRUStore(maxSize int) (*sizedLRUStore, error) {
+	s := &sizedLRUStore{
+		maxSize: maxSize,
+	}
+	cache, err := simplelru.NewLRU(math.MaxInt32, func(key, value interface{}) {
+		s.size -= entrySize(key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.lru = cache
+	return s, nil
+}
+
+// entrySize returns the size accounted for a cached key and value.
+func entrySize(key, value interface{}) int {
+	size := len(key.(string))
+	if entry, ok := value.(*Entry); ok && entry != nil {
+		size += len(entry.Value)
+	}
+	return size
+}
+
+func (s *sizedLRUStore) Get(key interface{}) (interface{}, bool) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.lru.Get(key)
+}
+
+func (s *sizedLRUStore) Add(key, value interface{}) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	// Entries which can't fit aren't cached at all rather than evicting
+	// everything else
+	size := entrySize(key, value)
+	if size > s.maxSize {
+		s.lru.Remove(key)
+		return
+	}
+
+	// Replacing an entry doesn't trigger the eviction callback
+	if old, ok := s.lru.Peek(key); ok {
+		s.size -= entrySize(key, old)
+	}
+	s.lru.Add(key, value)
+	s.size += size
+	for s.size > s.maxSize {
+		if _, _, ok := s.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+}
+
+func (s *sizedLRUStore) Remove(key interface{}) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.lru.Remove(key)
+}
+
+func (s *sizedLRUStore) Purge() {
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.lru.Purge()
+	s.size = 0
+}
@@ -3,6 +3,7 @@ package inmem
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/armon/go-metrics"
 	log "github.com/hashicorp/go-hclog"
@@ -329,3 +330,126 @@ func TestCache_Refresh(t *testing.T) {
 	}
 
 }
+
+func TestCache_EvictionPolicies(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	for _, conf := range []*physical.CacheConfig{
+		{EvictionPolicy: physical.CacheEvictionLRU},
+		{SizeBytes: 1024 * 1024},
+	} {
+		inm, err := NewInmem(nil, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, err := physical.NewCacheWithConfig(inm, conf, logger, &metrics.BlackholeSink{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache.SetEnabled(true)
+		physical.ExerciseBackend(t, cache)
+		physical.ExerciseBackend_ListPrefix(t, cache)
+	}
+
+	if _, err := physical.NewCacheWithConfig(nil, &physical.CacheConfig{
+		SizeBytes:      1024,
+		EvictionPolicy: physical.CacheEviction2Q,
+	}, logger, &metrics.BlackholeSink{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCache_SizeBytes(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+	ctx := context.Background()
+
+	inm, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := physical.NewCacheWithConfig(inm, &physical.CacheConfig{SizeBytes: 100}, logger, &metrics.BlackholeSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.SetEnabled(true)
+
+	put := func(key string, size int) {
+		t.Helper()
+		if err := cache.Put(ctx, &physical.Entry{Key: key, Value: make([]byte, size)}); err != nil {
+			t.Fatal(err)
+		}
+		// Delete from under, so that only cached entries can be read
+		if err := inm.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cached := func(key string) bool {
+		t.Helper()
+		out, err := cache.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out != nil
+	}
+
+	// Entries are evicted once their total size exceeds the limit
+	put("a", 39)
+	put("b", 39)
+	if !cached("a") || !cached("b") {
+		t.Fatal("expected entries to be cached")
+	}
+	put("c", 39)
+	if cached("a") || !cached("b") || !cached("c") {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+
+	// Entries larger than the cache aren't cached, without evicting others
+	put("d", 100)
+	if cached("d") || !cached("b") || !cached("c") {
+		t.Fatal("expected the large entry not to be cached")
+	}
+
+	// Replacing an entry accounts for its new size
+	put("b", 9)
+	put("e", 39)
+	put("f", 39)
+	if cached("c") || !cached("b") || !cached("e") || !cached("f") {
+		t.Fatal("expected the replaced entry to be smaller")
+	}
+}
+
+func TestCache_MetricsPrefixes(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+	ctx := context.Background()
+
+	inm, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := metrics.NewInmemSink(time.Hour, time.Hour)
+	cache, err := physical.NewCacheWithConfig(inm, &physical.CacheConfig{
+		MetricsPrefixes: []string{"logical/", "logical/abc/"},
+	}, logger, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.SetEnabled(true)
+
+	for _, key := range []string{"logical/abc/foo", "logical/abc/foo", "logical/def/foo", "sys/foo"} {
+		if _, err := cache.Get(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counters := sink.Data()[0].Counters
+	for name, count := range map[string]int{
+		"cache.miss;prefix=logical/abc/": 1,
+		"cache.hit;prefix=logical/abc/":  1,
+		"cache.miss;prefix=logical/":     1,
+		"cache.miss;prefix=other":        1,
+	} {
+		if counters[name].Count != count {
+			t.Fatalf("bad count for %s: %d", name, counters[name].Count)
+		}
+	}
+}
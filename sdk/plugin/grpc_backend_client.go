@@ -22,6 +22,7 @@ var ErrClientInMetadataMode = errors.New("plugin client can not perform action w
 
 // Validate backendGRPCPluginClient satisfies the logical.Backend interface
 var _ logical.Backend = &backendGRPCPluginClient{}
+var _ logical.StateBackend = &backendGRPCPluginClient{}
 
 // backendPluginClient implements logical.Backend and is the
 // go-plugin client.
@@ -278,3 +279,78 @@ func (b *backendGRPCPluginClient) Type() logical.BackendType {
 
 	return logical.BackendType(reply.Type)
 }
+
+// ExportState exports the state of the plugin. Plugins built against an SDK
+// without state export return ErrUnsupportedOperation. The plugin reads its
+// data from the storage it was set up with, so the storage argument is
+// ignored.
+func (b *backendGRPCPluginClient) ExportState(ctx context.Context, _ logical.Storage) (*logical.BackendState, error) {
+	if b.metadataMode {
+		return nil, ErrClientInMetadataMode
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	quitCh := pluginutil.CtxCancelIfCanceled(cancel, b.doneCtx)
+	defer close(quitCh)
+	defer cancel()
+
+	reply, err := b.client.ExportState(ctx, &pb.Empty{}, largeMsgGRPCCallOpts...)
+	if err != nil {
+		if b.doneCtx.Err() != nil {
+			return nil, ErrPluginShutdown
+		}
+
+		grpcStatus, ok := status.FromError(err)
+		if ok && grpcStatus.Code() == codes.Unimplemented {
+			return nil, logical.ErrUnsupportedOperation
+		}
+
+		return nil, err
+	}
+	if reply.Err != nil {
+		return nil, pb.ProtoErrToErr(reply.Err)
+	}
+
+	return pb.ProtoBackendStateToLogicalBackendState(reply.State)
+}
+
+// ImportState imports the state into the plugin. Plugins built against an
+// SDK without state import return ErrUnsupportedOperation. The plugin writes
+// its data to the storage it was set up with, so the storage argument is
+// ignored.
+func (b *backendGRPCPluginClient) ImportState(ctx context.Context, _ logical.Storage, state *logical.BackendState) error {
+	if b.metadataMode {
+		return ErrClientInMetadataMode
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	quitCh := pluginutil.CtxCancelIfCanceled(cancel, b.doneCtx)
+	defer close(quitCh)
+	defer cancel()
+
+	protoState, err := pb.LogicalBackendStateToProtoBackendState(state)
+	if err != nil {
+		return err
+	}
+
+	reply, err := b.client.ImportState(ctx, &pb.ImportStateArgs{
+		State: protoState,
+	}, largeMsgGRPCCallOpts...)
+	if err != nil {
+		if b.doneCtx.Err() != nil {
+			return ErrPluginShutdown
+		}
+
+		grpcStatus, ok := status.FromError(err)
+		if ok && grpcStatus.Code() == codes.Unimplemented {
+			return logical.ErrUnsupportedOperation
+		}
+
+		return err
+	}
+	if reply.Err != nil {
+		return pb.ProtoErrToErr(reply.Err)
+	}
+
+	return nil
+}
@@ -159,3 +159,38 @@ func (b *backendGRPCPluginServer) Type(ctx context.Context, _ *pb.Empty) (*pb.Ty
 		Type: uint32(b.backend.Type()),
 	}, nil
 }
+
+func (b *backendGRPCPluginServer) ExportState(ctx context.Context, _ *pb.Empty) (*pb.ExportStateReply, error) {
+	if pluginutil.InMetadataMode() {
+		return &pb.ExportStateReply{}, ErrServerInMetadataMode
+	}
+
+	state, respErr := logical.ExportBackendState(ctx, b.backend, newGRPCStorageClient(b.brokeredClient))
+
+	pbState, err := pb.LogicalBackendStateToProtoBackendState(state)
+	if err != nil {
+		return &pb.ExportStateReply{}, err
+	}
+
+	return &pb.ExportStateReply{
+		State: pbState,
+		Err:   pb.ErrToProtoErr(respErr),
+	}, nil
+}
+
+func (b *backendGRPCPluginServer) ImportState(ctx context.Context, args *pb.ImportStateArgs) (*pb.ImportStateReply, error) {
+	if pluginutil.InMetadataMode() {
+		return &pb.ImportStateReply{}, ErrServerInMetadataMode
+	}
+
+	state, err := pb.ProtoBackendStateToLogicalBackendState(args.State)
+	if err != nil {
+		return &pb.ImportStateReply{}, err
+	}
+
+	respErr := logical.ImportBackendState(ctx, b.backend, newGRPCStorageClient(b.brokeredClient), state)
+
+	return &pb.ImportStateReply{
+		Err: pb.ErrToProtoErr(respErr),
+	}, nil
+}
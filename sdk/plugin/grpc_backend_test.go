@@ -147,6 +147,54 @@ func TestGRPCBackendPlugin_Initialize(t *testing.T) {
 	}
 }
 
+func TestGRPCBackendPlugin_State(t *testing.T) {
+	b, cleanup := testGRPCBackend(t)
+	defer cleanup()
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "kv/foo",
+		Data: map[string]interface{}{
+			"value": "bar",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := logical.ExportBackendState(context.Background(), b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Format != "1" || len(state.Entries) != 1 {
+		t.Fatalf("bad state: %#v", state)
+	}
+	if e := state.Entries[0]; e.Kind != "kv" || e.Key != "foo" || e.Data["value"] != "bar" {
+		t.Fatalf("bad entry: %#v", e)
+	}
+
+	state.Entries[0].Key = "baz"
+	if err := logical.ImportBackendState(context.Background(), b, nil, state); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "kv/baz",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Errors of the backend are returned
+	state.Entries[0].Kind = "unknown"
+	if err := logical.ImportBackendState(context.Background(), b, nil, state); err == nil {
+		t.Fatal("expected an error importing an unknown kind")
+	}
+}
+
 func testGRPCBackend(t *testing.T) (logical.Backend, func()) {
 	// Create a mock provider
 	pluginMap := map[string]gplugin.Plugin{
@@ -18,6 +18,7 @@ type backendTracingMiddleware struct {
 
 // Validate the backendTracingMiddle object satisfies the backend interface
 var _ logical.Backend = &backendTracingMiddleware{}
+var _ logical.StateBackend = &backendTracingMiddleware{}
 
 func (b *backendTracingMiddleware) Initialize(ctx context.Context, req *logical.InitializationRequest) (err error) {
 	defer func(then time.Time) {
@@ -98,3 +99,21 @@ func (b *backendTracingMiddleware) Type() logical.BackendType {
 	b.logger.Trace("type", "status", "started")
 	return b.next.Type()
 }
+
+func (b *backendTracingMiddleware) ExportState(ctx context.Context, s logical.Storage) (state *logical.BackendState, err error) {
+	defer func(then time.Time) {
+		b.logger.Trace("export state", "status", "finished", "err", err, "took", time.Since(then))
+	}(time.Now())
+
+	b.logger.Trace("export state", "status", "started")
+	return logical.ExportBackendState(ctx, b.next, s)
+}
+
+func (b *backendTracingMiddleware) ImportState(ctx context.Context, s logical.Storage, state *logical.BackendState) (err error) {
+	defer func(then time.Time) {
+		b.logger.Trace("import state", "status", "finished", "err", err, "took", time.Since(then))
+	}(time.Now())
+
+	b.logger.Trace("import state", "status", "started")
+	return logical.ImportBackendState(ctx, b.next, s, state)
+}
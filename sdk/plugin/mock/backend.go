@@ -54,9 +54,11 @@ func Backend() *backend {
 				"special",
 			},
 		},
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:         []*framework.Secret{},
+		Invalidate:      b.invalidate,
+		ExportStateFunc: b.exportState,
+		ImportStateFunc: b.importState,
+		BackendType:     logical.TypeLogical,
 	}
 	b.internal = "bar"
 	return &b
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
@@ -110,3 +111,49 @@ func (b *backend) pathKVList(ctx context.Context, req *logical.Request, data *fr
 	}
 	return logical.ListResponse(vals), nil
 }
+
+// exportState exports the values stored by the kv paths, to test state
+// export.
+func (b *backend) exportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	keys, err := s.List(ctx, "kv/")
+	if err != nil {
+		return nil, err
+	}
+
+	state := &logical.BackendState{Format: "1"}
+	for _, key := range keys {
+		entry, err := s.Get(ctx, "kv/"+key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+		state.Entries = append(state.Entries, &logical.StateEntry{
+			Kind: "kv",
+			Key:  key,
+			Data: map[string]interface{}{
+				"value": string(entry.Value),
+			},
+		})
+	}
+	return state, nil
+}
+
+// importState stores the values exported by exportState, to test state
+// import.
+func (b *backend) importState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	for _, e := range state.Entries {
+		if e.Kind != "kv" {
+			return fmt.Errorf("unknown kind %q", e.Kind)
+		}
+		value, _ := e.Data["value"].(string)
+		if err := s.Put(ctx, &logical.StorageEntry{
+			Key:   "kv/" + e.Key,
+			Value: []byte(value),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
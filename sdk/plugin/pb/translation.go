@@ -126,6 +126,62 @@ func ProtoStorageEntryToLogicalStorageEntry(e *StorageEntry) *logical.StorageEnt
 	}
 }
 
+func LogicalBackendStateToProtoBackendState(s *logical.BackendState) (*BackendState, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	entries := make([]*StateEntry, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		if e == nil {
+			continue
+		}
+		buf, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &StateEntry{
+			Kind: e.Kind,
+			Key:  e.Key,
+			Data: buf,
+		})
+	}
+
+	return &BackendState{
+		Format:  s.Format,
+		Entries: entries,
+	}, nil
+}
+
+func ProtoBackendStateToLogicalBackendState(s *BackendState) (*logical.BackendState, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	entries := make([]*logical.StateEntry, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		if e == nil {
+			continue
+		}
+		data := map[string]interface{}{}
+		if len(e.Data) > 0 {
+			if err := json.Unmarshal(e.Data, &data); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, &logical.StateEntry{
+			Kind: e.Kind,
+			Key:  e.Key,
+			Data: data,
+		})
+	}
+
+	return &logical.BackendState{
+		Format:  s.Format,
+		Entries: entries,
+	}, nil
+}
+
 func ProtoLeaseOptionsToLogicalLeaseOptions(l *LeaseOptions) (logical.LeaseOptions, error) {
 	if l == nil {
 		return logical.LeaseOptions{}, nil
@@ -518,6 +574,7 @@ func LogicalAuthToProtoAuth(a *logical.Auth) (*Auth, error) {
 		GroupAliases:     a.GroupAliases,
 		BoundCIDRs:       boundCIDRs,
 		ExplicitMaxTTL:   int64(a.ExplicitMaxTTL),
+		Session:          LogicalAuthSessionToProtoAuthSession(a.Session),
 	}, nil
 }
 
@@ -566,9 +623,38 @@ func ProtoAuthToLogicalAuth(a *Auth) (*logical.Auth, error) {
 		GroupAliases:     a.GroupAliases,
 		BoundCIDRs:       boundCIDRs,
 		ExplicitMaxTTL:   time.Duration(a.ExplicitMaxTTL),
+		Session:          ProtoAuthSessionToLogicalAuthSession(a.Session),
 	}, nil
 }
 
+func LogicalAuthSessionToProtoAuthSession(s *logical.AuthSession) *AuthSession {
+	if s == nil {
+		return nil
+	}
+
+	return &AuthSession{
+		MountType:     s.MountType,
+		MountAccessor: s.MountAccessor,
+		MFA:           s.MFA,
+		KeyType:       s.KeyType,
+		KeyBits:       int64(s.KeyBits),
+	}
+}
+
+func ProtoAuthSessionToLogicalAuthSession(s *AuthSession) *logical.AuthSession {
+	if s == nil {
+		return nil
+	}
+
+	return &logical.AuthSession{
+		MountType:     s.MountType,
+		MountAccessor: s.MountAccessor,
+		MFA:           s.MFA,
+		KeyType:       s.KeyType,
+		KeyBits:       int(s.KeyBits),
+	}
+}
+
 func LogicalTokenEntryToProtoTokenEntry(t *logical.TokenEntry) *TokenEntry {
 	if t == nil {
 		return nil
@@ -598,6 +684,7 @@ func LogicalTokenEntryToProtoTokenEntry(t *logical.TokenEntry) *TokenEntry {
 		NamespaceID:    t.NamespaceID,
 		CubbyholeID:    t.CubbyholeID,
 		Type:           uint32(t.Type),
+		Session:        LogicalAuthSessionToProtoAuthSession(t.Session),
 	}
 }
 
@@ -635,5 +722,6 @@ func ProtoTokenEntryToLogicalTokenEntry(t *TokenEntry) (*logical.TokenEntry, err
 		NamespaceID:    t.NamespaceID,
 		CubbyholeID:    t.CubbyholeID,
 		Type:           logical.TokenType(t.Type),
+		Session:        ProtoAuthSessionToLogicalAuthSession(t.Session),
 	}, nil
 }
@@ -237,6 +237,13 @@ func TestTranslation_Response(t *testing.T) {
 						Name:          "name",
 					},
 				},
+				Session: &logical.AuthSession{
+					MountType:     "cert",
+					MountAccessor: "auth_cert_1234",
+					MFA:           true,
+					KeyType:       "rsa",
+					KeyBits:       2048,
+				},
 			},
 			WrapInfo: &wrapping.ResponseWrapInfo{
 				TTL:             time.Second,
@@ -31,6 +31,16 @@ func (b *BackendPluginClient) Cleanup(ctx context.Context) {
 	b.client.Kill()
 }
 
+// ExportState exports the state of the plugin, see logical.StateBackend.
+func (b *BackendPluginClient) ExportState(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+	return logical.ExportBackendState(ctx, b.Backend, s)
+}
+
+// ImportState imports the state into the plugin, see logical.StateBackend.
+func (b *BackendPluginClient) ImportState(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+	return logical.ImportBackendState(ctx, b.Backend, s, state)
+}
+
 // NewBackend will return an instance of an RPC-based client implementation of the backend for
 // external plugins, or a concrete implementation of the backend if it is a builtin backend.
 // The backend is returned as a logical.Backend interface. The isMetadataMode param determines whether
@@ -55,6 +55,10 @@ type ACLResults struct {
 	MFAMethods         []string
 	ControlGroup       *ControlGroup
 	CapabilitiesBitmap uint32
+
+	// GrantingPolicies are the names of the policies granting the capability
+	// required by the operation
+	GrantingPolicies []string
 }
 
 // NewACL is used to construct a policy based ACL from a set of policies.
@@ -127,6 +131,7 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 				if err != nil {
 					return nil, errwrap.Wrapf("error cloning ACL permissions: {{err}}", err)
 				}
+				clonedPerms.GrantingPoliciesMap = addGrantingPolicyToMap(nil, policy.Name, clonedPerms.CapabilitiesBitmap)
 				switch {
 				case pc.HasSegmentWildcards:
 					a.segmentWildcardPaths[pc.Path] = clonedPerms
@@ -150,12 +155,14 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 				existingPerms.CapabilitiesBitmap = DenyCapabilityInt
 				existingPerms.AllowedParameters = nil
 				existingPerms.DeniedParameters = nil
+				existingPerms.GrantingPoliciesMap = nil
 				goto INSERT
 
 			default:
 				// Insert the capabilities in this new policy into the existing
 				// value
 				existingPerms.CapabilitiesBitmap = existingPerms.CapabilitiesBitmap | pc.Permissions.CapabilitiesBitmap
+				existingPerms.GrantingPoliciesMap = addGrantingPolicyToMap(existingPerms.GrantingPoliciesMap, policy.Name, pc.Permissions.CapabilitiesBitmap)
 			}
 
 			// Note: In these stanzas, we're preferring minimum lifetimes. So
@@ -272,6 +279,23 @@ func NewACL(ctx context.Context, policies []*Policy) (*ACL, error) {
 	return a, nil
 }
 
+// addGrantingPolicyToMap records the policy as granting each of the
+// capabilities of the bitmap. Deny grants nothing.
+func addGrantingPolicyToMap(m map[uint32][]string, policyName string, capabilitiesBitmap uint32) map[uint32][]string {
+	if capabilitiesBitmap&DenyCapabilityInt > 0 {
+		return m
+	}
+	if m == nil {
+		m = make(map[uint32][]string)
+	}
+	for _, capability := range cap2Int {
+		if capabilitiesBitmap&capability > 0 && !strutil.StrListContains(m[capability], policyName) {
+			m[capability] = append(m[capability], policyName)
+		}
+	}
+	return m
+}
+
 func (a *ACL) Capabilities(ctx context.Context, path string) (pathCapabilities []string) {
 	req := &logical.Request{
 		Path: path,
@@ -323,6 +347,7 @@ func (a *ACL) AllowOperation(ctx context.Context, req *logical.Request, capCheck
 		ret.Allowed = true
 		ret.RootPrivs = true
 		ret.IsRoot = true
+		ret.GrantingPolicies = []string{"root"}
 		return
 	}
 	op := req.Operation
@@ -394,31 +419,32 @@ CHECK:
 	ret.MFAMethods = permissions.MFAMethods
 	ret.ControlGroup = permissions.ControlGroup
 
-	operationAllowed := false
+	var requiredCapability uint32
 	switch op {
 	case logical.ReadOperation:
-		operationAllowed = capabilities&ReadCapabilityInt > 0
+		requiredCapability = ReadCapabilityInt
 	case logical.ListOperation:
-		operationAllowed = capabilities&ListCapabilityInt > 0
+		requiredCapability = ListCapabilityInt
 	case logical.UpdateOperation:
-		operationAllowed = capabilities&UpdateCapabilityInt > 0
+		requiredCapability = UpdateCapabilityInt
 	case logical.DeleteOperation:
-		operationAllowed = capabilities&DeleteCapabilityInt > 0
+		requiredCapability = DeleteCapabilityInt
 	case logical.CreateOperation:
-		operationAllowed = capabilities&CreateCapabilityInt > 0
+		requiredCapability = CreateCapabilityInt
 
 	// These three re-use UpdateCapabilityInt since that's the most appropriate
 	// capability/operation mapping
 	case logical.RevokeOperation, logical.RenewOperation, logical.RollbackOperation:
-		operationAllowed = capabilities&UpdateCapabilityInt > 0
+		requiredCapability = UpdateCapabilityInt
 
 	default:
 		return
 	}
 
-	if !operationAllowed {
+	if capabilities&requiredCapability == 0 {
 		return
 	}
+	ret.GrantingPolicies = permissions.GrantingPoliciesMap[requiredCapability]
 
 	if permissions.MaxWrappingTTL > 0 {
 		if req.WrapInfo == nil || req.WrapInfo.TTL > permissions.MaxWrappingTTL {
@@ -3,6 +3,7 @@ package vault
 import (
 	"context"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -359,6 +360,49 @@ func testLayeredACL(t *testing.T, acl *ACL, ns *namespace.Namespace) {
 	}
 }
 
+func TestACL_GrantingPolicies(t *testing.T) {
+	policy1, err := ParseACLPolicy(namespace.RootNamespace, aclPolicy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policy2, err := ParseACLPolicy(namespace.RootNamespace, aclPolicy2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl, err := NewACL(namespace.RootContext(nil), []*Policy{policy1, policy2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type tcase struct {
+		op       logical.Operation
+		path     string
+		policies []string
+	}
+	tcases := []tcase{
+		{logical.ReadOperation, "dev/foo", []string{"DeV"}},
+		{logical.ReadOperation, "prod/foo", []string{"DeV", "OpS"}},
+		{logical.UpdateOperation, "prod/foo", []string{"OpS"}},
+		{logical.UpdateOperation, "stage/aws/foo", []string{"DeV"}},
+		{logical.ReadOperation, "test/foo/segment", []string{"DeV"}},
+		{logical.ReadOperation, "dev/hide/foo", nil},
+		{logical.ReadOperation, "foo/bar", nil},
+	}
+
+	for _, tc := range tcases {
+		request := new(logical.Request)
+		request.Operation = tc.op
+		request.Path = tc.path
+
+		authResults := acl.AllowOperation(namespace.RootContext(nil), request, false)
+		policies := authResults.GrantingPolicies
+		sort.Strings(policies)
+		if !reflect.DeepEqual(policies, tc.policies) {
+			t.Fatalf("bad: case %#v: %v", tc, policies)
+		}
+	}
+}
+
 func TestACL_ParseMalformedPolicy(t *testing.T) {
 	_, err := ParseACLPolicy(namespace.RootNamespace, `name{}`)
 	if err == nil {
@@ -10,6 +10,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -85,6 +86,18 @@ func (a *AuditBroker) GetHash(ctx context.Context, name string, input string) (s
 	return be.backend.GetHash(ctx, input)
 }
 
+// GetSalt returns the salt of the given backend
+func (a *AuditBroker) GetSalt(ctx context.Context, name string) (*salt.Salt, error) {
+	a.RLock()
+	defer a.RUnlock()
+	be, ok := a.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown audit backend %q", name)
+	}
+
+	return be.backend.Salt(ctx)
+}
+
 // LogRequest is used to ensure all the audit backends have an opportunity to
 // log the given request and that *at least one* succeeds.
 func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, headersConfig *AuditedHeadersConfig) (ret error) {
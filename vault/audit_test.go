@@ -581,8 +581,8 @@ func TestAuditBroker_AuditHeaders(t *testing.T) {
 	headersConf := &AuditedHeadersConfig{
 		view: view,
 	}
-	headersConf.add(context.Background(), "X-Test-Header", false)
-	headersConf.add(context.Background(), "X-Vault-Header", false)
+	headersConf.add(context.Background(), "X-Test-Header", false, false)
+	headersConf.add(context.Background(), "X-Vault-Header", false, false)
 
 	logInput := &logical.LogInput{
 		Auth:     auth,
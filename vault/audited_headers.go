@@ -22,6 +22,10 @@ const (
 
 type auditedHeaderSettings struct {
 	HMAC bool `json:"hmac"`
+
+	// Passthrough makes the header available to all backends, as if it was
+	// in the passthrough_request_headers of every mount
+	Passthrough bool `json:"passthrough"`
 }
 
 // AuditedHeadersConfig is used by the Audit Broker to write only approved
@@ -34,7 +38,7 @@ type AuditedHeadersConfig struct {
 }
 
 // add adds or overwrites a header in the config and updates the barrier view
-func (a *AuditedHeadersConfig) add(ctx context.Context, header string, hmac bool) error {
+func (a *AuditedHeadersConfig) add(ctx context.Context, header string, hmac, passthrough bool) error {
 	if header == "" {
 		return fmt.Errorf("header value cannot be empty")
 	}
@@ -47,7 +51,10 @@ func (a *AuditedHeadersConfig) add(ctx context.Context, header string, hmac bool
 		a.Headers = make(map[string]*auditedHeaderSettings, 1)
 	}
 
-	a.Headers[strings.ToLower(header)] = &auditedHeaderSettings{hmac}
+	a.Headers[strings.ToLower(header)] = &auditedHeaderSettings{
+		HMAC:        hmac,
+		Passthrough: passthrough,
+	}
 	entry, err := logical.StorageEntryJSON(auditedHeadersEntry, a.Headers)
 	if err != nil {
 		return errwrap.Wrapf("failed to persist audited headers config: {{err}}", err)
@@ -127,6 +134,21 @@ func (a *AuditedHeadersConfig) ApplyConfig(ctx context.Context, headers map[stri
 	return result, nil
 }
 
+// passthroughHeaders returns the names of the headers passed through to all
+// backends
+func (a *AuditedHeadersConfig) passthroughHeaders() []string {
+	a.RLock()
+	defer a.RUnlock()
+
+	var headers []string
+	for key, settings := range a.Headers {
+		if settings.Passthrough {
+			headers = append(headers, key)
+		}
+	}
+	return headers
+}
+
 // Initialize the headers config by loading from the barrier view
 func (c *Core) setupAuditedHeadersConfig(ctx context.Context) error {
 	// Create a sub-view
@@ -157,6 +179,7 @@ func (c *Core) setupAuditedHeadersConfig(ctx context.Context) error {
 		Headers: lowerHeaders,
 		view:    view,
 	}
+	c.router.passthroughHeadersFunc = c.auditedHeaders.passthroughHeaders
 
 	return nil
 }
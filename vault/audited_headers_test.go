@@ -25,7 +25,7 @@ func TestAuditedHeadersConfig_CRUD(t *testing.T) {
 }
 
 func testAuditedHeadersConfig_Add(t *testing.T, conf *AuditedHeadersConfig) {
-	err := conf.add(context.Background(), "X-Test-Header", false)
+	err := conf.add(context.Background(), "X-Test-Header", false, false)
 	if err != nil {
 		t.Fatalf("Error when adding header to config: %s", err)
 	}
@@ -60,7 +60,7 @@ func testAuditedHeadersConfig_Add(t *testing.T, conf *AuditedHeadersConfig) {
 		t.Fatalf("Expected config didn't match actual. Expected: %#v, Got: %#v", expected, headers)
 	}
 
-	err = conf.add(context.Background(), "X-Vault-Header", true)
+	err = conf.add(context.Background(), "X-Vault-Header", true, false)
 	if err != nil {
 		t.Fatalf("Error when adding header to config: %s", err)
 	}
@@ -158,8 +158,8 @@ func testAuditedHeadersConfig_Remove(t *testing.T, conf *AuditedHeadersConfig) {
 func TestAuditedHeadersConfig_ApplyConfig(t *testing.T) {
 	conf := mockAuditedHeadersConfig(t)
 
-	conf.add(context.Background(), "X-TesT-Header", false)
-	conf.add(context.Background(), "X-Vault-HeAdEr", true)
+	conf.add(context.Background(), "X-TesT-Header", false, false)
+	conf.add(context.Background(), "X-Vault-HeAdEr", true, false)
 
 	reqHeaders := map[string][]string{
 		"X-Test-Header":  []string{"foo"},
@@ -203,8 +203,8 @@ func BenchmarkAuditedHeaderConfig_ApplyConfig(b *testing.B) {
 	}
 
 	conf.Headers = map[string]*auditedHeaderSettings{
-		"X-Test-Header":  &auditedHeaderSettings{false},
-		"X-Vault-Header": &auditedHeaderSettings{true},
+		"X-Test-Header":  &auditedHeaderSettings{HMAC: false},
+		"X-Vault-Header": &auditedHeaderSettings{HMAC: true},
 	}
 
 	reqHeaders := map[string][]string{
@@ -226,3 +226,16 @@ func BenchmarkAuditedHeaderConfig_ApplyConfig(b *testing.B) {
 		conf.ApplyConfig(context.Background(), reqHeaders, hashFunc)
 	}
 }
+
+func TestAuditedHeadersConfig_PassthroughHeaders(t *testing.T) {
+	conf := mockAuditedHeadersConfig(t)
+
+	conf.add(context.Background(), "X-Test-Header", false, false)
+	conf.add(context.Background(), "TraceParent", true, true)
+
+	headers := conf.passthroughHeaders()
+	expected := []string{"traceparent"}
+	if !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("Expected passthrough headers didn't match actual. Expected: %#v, Got: %#v", expected, headers)
+	}
+}
@@ -63,6 +63,8 @@ func (c *Core) enableCredential(ctx context.Context, entry *MountEntry) error {
 		}
 		return err
 	}
+
+	c.publishMountChanged(ctx, "enable", credentialRoutePrefix+entry.Path, "", entry.Type)
 	return nil
 }
 
@@ -245,6 +247,8 @@ func (c *Core) disableCredential(ctx context.Context, path string) error {
 		// Even we failed to evaluate filtered paths, the unmount operation was still successful
 		c.logger.Error("failed to evaluate filtered paths", "error", err)
 	}
+
+	c.publishMountChanged(ctx, "disable", credentialRoutePrefix+path, "", "")
 	return nil
 }
 
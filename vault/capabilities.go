@@ -15,6 +15,16 @@ func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string,
 		return nil, &logical.StatusBadRequest{Err: "missing path"}
 	}
 
+	capabilities, err := c.capabilities(ctx, token, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	return capabilities[path], nil
+}
+
+// capabilities returns the capabilities of the given token on each of the
+// given paths, building the ACL of the token once
+func (c *Core) capabilities(ctx context.Context, token string, paths []string) (map[string][]string, error) {
 	if token == "" {
 		return nil, &logical.StatusBadRequest{Err: "missing token"}
 	}
@@ -58,19 +68,26 @@ func (c *Core) Capabilities(ctx context.Context, token, path string) ([]string,
 		policyCount += len(nsPolicies)
 	}
 
+	ret := make(map[string][]string, len(paths))
 	if policyCount == 0 {
-		return []string{DenyCapability}, nil
+		for _, path := range paths {
+			ret[path] = []string{DenyCapability}
+		}
+		return ret, nil
 	}
 
 	// Construct the corresponding ACL object. ACL construction should be
 	// performed on the token's namespace.
 	tokenCtx := namespace.ContextWithNamespace(ctx, tokenNS)
-	acl, err := c.policyStore.ACL(tokenCtx, entity, policyNames)
+	acl, err := c.policyStore.ACL(tokenCtx, entity, te.Session, policyNames)
 	if err != nil {
 		return nil, err
 	}
 
-	capabilities := acl.Capabilities(ctx, path)
-	sort.Strings(capabilities)
-	return capabilities, nil
+	for _, path := range paths {
+		capabilities := acl.Capabilities(ctx, path)
+		sort.Strings(capabilities)
+		ret[path] = capabilities
+	}
+	return ret, nil
 }
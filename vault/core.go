@@ -44,6 +44,7 @@ import (
 	sr "github.com/hashicorp/vault/serviceregistration"
 	"github.com/hashicorp/vault/shamir"
 	"github.com/hashicorp/vault/vault/cluster"
+	"github.com/hashicorp/vault/vault/maintenance"
 	"github.com/hashicorp/vault/vault/quotas"
 	vaultseal "github.com/hashicorp/vault/vault/seal"
 	"github.com/patrickmn/go-cache"
@@ -332,6 +333,10 @@ type Core struct {
 	// renewal, expiration and revocation
 	expiration *ExpirationManager
 
+	// renewalHints recommends when clients should renew their leases and
+	// tokens
+	renewalHints *renewalHintScheduler
+
 	// rollback manager is used to run rollbacks periodically
 	rollback *RollbackManager
 
@@ -369,6 +374,26 @@ type Core struct {
 	// Disables the trace display for Sentinel checks
 	sentinelTraceDisabled bool
 
+	// excludedRequests matches the unauthenticated requests excluded from the
+	// audit devices and the request metrics
+	excludedRequests *requestFilter
+
+	// cubbyholeMaxSize is the maximum size in bytes of the cubbyhole of a
+	// token, or zero if unlimited
+	cubbyholeMaxSize int64
+
+	// maintenanceSchedule holds the windows during which the token and lease
+	// tidy jobs are allowed to run
+	maintenanceSchedule *maintenance.Schedule
+
+	// jobs runs the long-running operations started by requests, whose
+	// status is read through sys/jobs
+	jobs *jobManager
+
+	// inFlightRequests holds the *InFlightRequest of the requests being
+	// handled by this node, keyed by request ID
+	inFlightRequests sync.Map
+
 	// cachingDisabled indicates whether caches are disabled
 	cachingDisabled bool
 	// Cache stores the actual cache; we always have this but may bypass it if
@@ -501,9 +526,17 @@ type Core struct {
 	// Telemetry objects
 	metricsHelper *metricsutil.MetricsHelper
 
+	// Bound the request counters by policy and by AppRole role; nil if these
+	// counters are disabled
+	policyUsageLimiter  *metricsutil.CardinalityLimiter
+	appRoleUsageLimiter *metricsutil.CardinalityLimiter
+
 	// Stores request counters
 	counters counters
 
+	// Stores the storage usage of the mounts, computed in the background
+	mountUsage mountUsage
+
 	// Stores the raft applied index for standby nodes
 	raftFollowerStates *raftFollowerStates
 	// Stop channel for raft TLS rotations
@@ -531,6 +564,19 @@ type Core struct {
 
 	quotaManager *quotas.Manager
 
+	// eventWebhooks delivers the events published by the active node to the
+	// webhook sinks
+	eventWebhooks *eventWebhookManager
+
+	// events delivers the events published by the active node to the event
+	// stream subscriptions
+	events *eventBroker
+
+	// trashPurgeStopCh stops the periodic purge of the expired items of the
+	// trash, which closes trashPurgeDoneCh once stopped
+	trashPurgeStopCh chan struct{}
+	trashPurgeDoneCh chan struct{}
+
 	clusterHeartbeatInterval time.Duration
 
 	activityLogConfig ActivityLogCoreConfig
@@ -574,6 +620,17 @@ type CoreConfig struct {
 	// Disables the trace display for Sentinel checks
 	DisableSentinelTrace bool
 
+	// Unauthenticated paths excluded from the audit devices and the request
+	// metrics, e.g. health checks
+	ExcludedUnauthPaths []string
+
+	// Maximum size in bytes of the cubbyhole of a token, or zero if unlimited
+	CubbyholeMaxSize int64
+
+	// Windows during which the token and lease tidy jobs are allowed to run.
+	// If empty, they run as soon as they are started.
+	MaintenanceWindows []*maintenance.Window
+
 	// Disables the LRU cache on the physical backend
 	DisableCache bool
 
@@ -583,6 +640,18 @@ type CoreConfig struct {
 	// Custom cache size for the LRU cache on the physical backend, or zero for default
 	CacheSize int
 
+	// Maximum total size in bytes of the entries of the LRU cache on the
+	// physical backend, overriding CacheSize when set
+	CacheSizeBytes int
+
+	// Eviction policy of the LRU cache on the physical backend, or empty
+	// for default
+	CacheEvictionPolicy string
+
+	// Storage key prefixes the hits and misses of the LRU cache on the
+	// physical backend are reported by
+	CacheMetricsPrefixes []string
+
 	// Set as the leader address for HA
 	RedirectAddr string
 
@@ -741,6 +810,10 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		defaultLeaseTTL:              conf.DefaultLeaseTTL,
 		maxLeaseTTL:                  conf.MaxLeaseTTL,
 		sentinelTraceDisabled:        conf.DisableSentinelTrace,
+		excludedRequests:             newRequestFilter(conf.ExcludedUnauthPaths),
+		cubbyholeMaxSize:             conf.CubbyholeMaxSize,
+		maintenanceSchedule:          maintenance.NewSchedule(conf.MaintenanceWindows),
+		jobs:                         newJobManager(conf.Logger.Named("jobs")),
 		cachingDisabled:              conf.DisableCache,
 		clusterName:                  conf.ClusterName,
 		clusterNetworkLayer:          conf.ClusterNetworkLayer,
@@ -776,15 +849,25 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		raftJoinDoneCh:           make(chan struct{}),
 		clusterHeartbeatInterval: clusterHeartbeatInterval,
 		activityLogConfig:        conf.ActivityLogConfig,
+		renewalHints:             newRenewalHintScheduler(),
 	}
 	c.standbyStopCh.Store(make(chan struct{}))
 	atomic.StoreUint32(c.sealed, 1)
 	c.metricSink.SetGaugeWithLabels([]string{"core", "unsealed"}, 0, nil)
 
+	telemetryConsts := c.metricSink.TelemetryConsts
+	if telemetryConsts.PolicyUsageMetrics {
+		c.policyUsageLimiter = metricsutil.NewCardinalityLimiter(telemetryConsts.MaxUsageMetricsCardinality)
+	}
+	if telemetryConsts.AppRoleUsageMetrics {
+		c.appRoleUsageLimiter = metricsutil.NewCardinalityLimiter(telemetryConsts.MaxUsageMetricsCardinality)
+	}
+
 	c.allLoggers = append(c.allLoggers, c.logger)
 
 	c.router.logger = c.logger.Named("router")
 	c.allLoggers = append(c.allLoggers, c.router.logger)
+	c.router.excludedRequests = c.excludedRequests
 
 	c.SetConfig(conf.RawConfig)
 
@@ -949,6 +1032,11 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		return nil, err
 	}
 
+	eventsLogger := conf.Logger.Named("events")
+	c.allLoggers = append(c.allLoggers, eventsLogger)
+	c.eventWebhooks = newEventWebhookManager(c, eventsLogger)
+	c.events = newEventBroker()
+
 	err = c.adjustForSealMigration(conf.UnwrapSeal)
 	if err != nil {
 		return nil, err
@@ -1725,6 +1813,12 @@ func (c *Core) sealInternalWithOptions(grabStateLock, keepHALock, performCleanup
 
 	c.logger.Info("marked as sealed")
 
+	// Delivered before the event webhooks are stopped by the pre-seal teardown
+	c.publishEvent(EventTypeSealStatus, map[string]interface{}{
+		"sealed":      true,
+		"api_address": c.redirectAddr,
+	})
+
 	// Clear forwarding clients
 	c.requestForwardingConnectionLock.Lock()
 	c.clearForwardingClients()
@@ -1889,6 +1983,9 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 	if err := c.loadCORSConfig(ctx); err != nil {
 		return err
 	}
+	if err := c.loadRekeyProgress(ctx); err != nil {
+		return err
+	}
 	if err := c.loadCurrentRequestCounters(ctx, time.Now()); err != nil {
 		return err
 	}
@@ -1931,6 +2028,10 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 		if err := c.setupActivityLog(ctx, &wg); err != nil {
 			return err
 		}
+		if err := c.setupEventWebhooks(ctx); err != nil {
+			return err
+		}
+		c.startTrashPurge()
 	} else {
 		c.auditBroker = NewAuditBroker(c.logger)
 	}
@@ -2071,6 +2172,8 @@ func (c *Core) preSeal() error {
 	if err := c.stopActivityLog(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error stopping activity log: {{err}}", err))
 	}
+	c.stopEventWebhooks()
+	c.stopTrashPurge()
 	if err := c.teardownCredentials(context.Background()); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down credentials: {{err}}", err))
 	}
@@ -2645,6 +2748,29 @@ func (c *Core) ApplyRateLimitQuota(req *quotas.Request) (quotas.Response, error)
 	return resp, nil
 }
 
+// ApplyConcurrencyQuota checks the request against the applicable concurrency
+// quota rule, waiting for a slot if the rule allows queueing. The Release
+// function of an allowed response must be called once the request has been
+// handled. Paths exempt from rate limiting are exempt from concurrency quotas
+// as well.
+func (c *Core) ApplyConcurrencyQuota(req *quotas.Request) (quotas.Response, error) {
+	req.Type = quotas.TypeConcurrency
+
+	resp := quotas.Response{
+		Allowed: true,
+	}
+
+	if c.quotaManager != nil {
+		if c.quotaManager.RateLimitPathExempt(req.Path) {
+			return resp, nil
+		}
+
+		return c.quotaManager.ApplyQuota(req)
+	}
+
+	return resp, nil
+}
+
 // RateLimitAuditLoggingEnabled returns if the quota configuration allows audit
 // logging of request rejections due to rate limiting quota rule violations.
 func (c *Core) RateLimitAuditLoggingEnabled() bool {
@@ -242,6 +242,17 @@ func (c *Core) emitMetrics(stopCh chan struct{}) {
 		}
 	}
 
+	// The storage usage of the mounts is computed on the active node only,
+	// whether usage gauges are enabled or not, since it is also reported by
+	// sys/internal/counters/storage.
+	if standby, _ := c.Standby(); !standby && !c.IsDRSecondary() {
+		if os.Getenv("VAULT_DISABLE_MOUNT_USAGE") != "" {
+			c.logger.Info("mount storage usage collection is disabled")
+		} else {
+			go c.mountUsageLoop(stopCh)
+		}
+	}
+
 	// When this returns, all the defers set up above will fire.
 	c.metricsLoop(stopCh)
 }
@@ -462,3 +473,55 @@ func (c *Core) cachedGaugeMetricsEmitter() {
 
 	loopMetrics.Range(emit)
 }
+
+// emitUsageMetrics counts an allowed request by the policies granting it and
+// by the AppRole role of its token, if enabled in the telemetry
+// configuration. Values beyond the configured cardinality are counted
+// together, so that the counters stay bounded.
+func (c *Core) emitUsageMetrics(ctx context.Context, te *logical.TokenEntry, aclResults *ACLResults) {
+	if te == nil || (c.policyUsageLimiter == nil && c.appRoleUsageLimiter == nil) {
+		return
+	}
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+	if err != nil || tokenNS == nil {
+		return
+	}
+
+	if c.policyUsageLimiter != nil && aclResults != nil {
+		for _, policy := range aclResults.GrantingPolicies {
+			if !c.policyUsageLimiter.Allow(tokenNS.ID + "/" + policy) {
+				policy = metricsutil.OverflowLabelValue
+			}
+			c.metricSink.IncrCounterWithLabels(
+				[]string{"policy", "request"},
+				1,
+				[]metrics.Label{
+					metricsutil.NamespaceLabel(tokenNS),
+					{"policy", policy},
+				},
+			)
+		}
+	}
+
+	roleName := te.Meta["role_name"]
+	if c.appRoleUsageLimiter == nil || roleName == "" {
+		return
+	}
+	mountEntry := c.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, tokenNS), te.Path)
+	if mountEntry == nil || mountEntry.Type != "approle" {
+		return
+	}
+	if !c.appRoleUsageLimiter.Allow(mountEntry.Accessor + "/" + roleName) {
+		roleName = metricsutil.OverflowLabelValue
+	}
+	c.metricSink.IncrCounterWithLabels(
+		[]string{"approle", "request"},
+		1,
+		[]metrics.Label{
+			metricsutil.NamespaceLabel(tokenNS),
+			{"mount_point", "auth/" + mountEntry.Path},
+			{"role_name", roleName},
+		},
+	)
+}
@@ -2409,6 +2409,79 @@ func TestCore_HandleRequest_Headers_denyList(t *testing.T) {
 	}
 }
 
+func TestCore_HandleRequest_Headers_audited(t *testing.T) {
+	noop := &NoopBackend{
+		Response: &logical.Response{
+			Data: map[string]interface{}{},
+		},
+	}
+
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	// Enable the backend
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/foo")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	_, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Audit the headers, passing one through to all backends
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/config/auditing/request-headers/Traceparent")
+	req.Data["passthrough"] = true
+	req.ClientToken = root
+	_, err = c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/config/auditing/request-headers/X-Client-Version")
+	req.ClientToken = root
+	_, err = c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The token header cannot be passed through
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/config/auditing/request-headers/"+consts.AuthHeaderName)
+	req.Data["passthrough"] = true
+	req.ClientToken = root
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+
+	// Attempt to read
+	lreq := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "foo/test",
+		ClientToken: root,
+		Headers: map[string][]string{
+			"Traceparent":      []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+			"X-Client-Version": []string{"1.2.3"},
+		},
+	}
+	_, err = c.HandleRequest(namespace.RootContext(nil), lreq)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check the headers
+	headers := noop.Requests[0].Headers
+	expected := map[string][]string{
+		"Traceparent": []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+	if !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, headers)
+	}
+}
+
 func TestCore_HandleRequest_TokenCreate_RegisterAuthFailure(t *testing.T) {
 	core, _, root := TestCoreUnsealed(t)
 
@@ -5,6 +5,7 @@ package vault
 import (
 	"context"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/license"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -38,10 +39,20 @@ func coreInit(c *Core, conf *CoreConfig) error {
 	// Wrap the physical backend in a cache layer if enabled
 	cacheLogger := c.baseLogger.Named("storage.cache")
 	c.allLoggers = append(c.allLoggers, cacheLogger)
+	cacheConfig := &physical.CacheConfig{
+		Size:            conf.CacheSize,
+		SizeBytes:       conf.CacheSizeBytes,
+		EvictionPolicy:  conf.CacheEvictionPolicy,
+		MetricsPrefixes: conf.CacheMetricsPrefixes,
+	}
+	var err error
 	if txnOK {
-		c.physical = physical.NewTransactionalCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical, err = physical.NewTransactionalCacheWithConfig(c.sealUnwrapper, cacheConfig, cacheLogger, c.MetricSink().Sink)
 	} else {
-		c.physical = physical.NewCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical, err = physical.NewCacheWithConfig(c.sealUnwrapper, cacheConfig, cacheLogger, c.MetricSink().Sink)
+	}
+	if err != nil {
+		return errwrap.Wrapf("error creating storage cache: {{err}}", err)
 	}
 	c.physicalCache = c.physical.(physical.ToggleablePurgemonster)
 
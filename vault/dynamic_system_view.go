@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/random"
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/license"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
@@ -103,7 +104,7 @@ func (e extendedSystemViewImpl) SudoPrivilege(ctx context.Context, path string,
 
 	// Construct the corresponding ACL object. Derive and use a new context that
 	// uses the req.ClientToken's namespace
-	acl, err := e.core.policyStore.ACL(tokenCtx, entity, policies)
+	acl, err := e.core.policyStore.ACL(tokenCtx, entity, te.Session, policies)
 	if err != nil {
 		e.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", te.Policies, "error", err)
 		return false
@@ -121,6 +122,29 @@ func (e extendedSystemViewImpl) SudoPrivilege(ctx context.Context, path string,
 	return authResults.RootPrivs
 }
 
+// Capabilities returns the capabilities of the token with the given accessor
+// on each of the given paths, which are fully qualified. Batch tokens have no
+// accessor, so their capabilities cannot be checked.
+func (d dynamicSystemView) Capabilities(ctx context.Context, accessor string, paths []string) (map[string][]string, error) {
+	if accessor == "" {
+		return nil, fmt.Errorf("missing token accessor")
+	}
+
+	// The paths are fully qualified already, so the ACL must not prepend a
+	// namespace prefix onto them
+	ctx = namespace.RootContext(ctx)
+
+	aEntry, err := d.core.tokenStore.lookupByAccessor(ctx, accessor, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if aEntry.TokenID == "" {
+		return nil, fmt.Errorf("token not found for accessor")
+	}
+
+	return d.core.capabilities(ctx, aEntry.TokenID, paths)
+}
+
 func (d dynamicSystemView) DefaultLeaseTTL() time.Duration {
 	def, _ := d.fetchTTLs()
 	return def
@@ -224,6 +248,16 @@ func (d dynamicSystemView) LookupPlugin(ctx context.Context, name string, plugin
 	return r, nil
 }
 
+// DatabasePluginClients returns the processes of the multiplexed database
+// plugins kept by the plugin catalog, so that they are shared by the
+// connections of all the database mounts.
+func (d dynamicSystemView) DatabasePluginClients() *v5.PluginClients {
+	if d.core == nil || d.core.pluginCatalog == nil {
+		return nil
+	}
+	return d.core.pluginCatalog.databaseClients
+}
+
 // MlockEnabled returns the configuration setting for enabling mlock on plugins.
 func (d dynamicSystemView) MlockEnabled() bool {
 	return d.core.enableMlock
@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// eventHistorySize is the number of recent events kept by the active node
+	// for subscriptions to resume after the last event they received
+	eventHistorySize = 1024
+
+	// eventSubscriberBufferSize is the number of events which can wait for
+	// their delivery to a subscriber. Subscribers falling further behind are
+	// disconnected, and resume from the history when they reconnect.
+	eventSubscriberBufferSize = 128
+)
+
+// errEventsNotActive is returned when subscribing to the events of a node
+// which is not the active node
+var errEventsNotActive = errors.New("events are only published by the active node")
+
+// eventSubscriber receives the events of a type, or of all types for "*".
+type eventSubscriber struct {
+	eventType string
+	ch        chan *Event
+}
+
+// eventBroker delivers the events published by the active node to the event
+// stream subscriptions, and keeps the recent events for them to resume.
+type eventBroker struct {
+	l           sync.Mutex
+	running     bool
+	history     []*Event
+	subscribers map[*eventSubscriber]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+func (b *eventBroker) start() {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.running = true
+}
+
+// stop disconnects the subscribers and drops the history, since the events of
+// the next active node have different IDs.
+func (b *eventBroker) stop() {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.running = false
+	b.history = nil
+	for s := range b.subscribers {
+		close(s.ch)
+		delete(b.subscribers, s)
+	}
+}
+
+func (b *eventBroker) publish(event *Event) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if !b.running {
+		return
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+
+	for s := range b.subscribers {
+		if s.eventType != "*" && s.eventType != event.Type {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			close(s.ch)
+			delete(b.subscribers, s)
+		}
+	}
+}
+
+// subscribe subscribes to the events of the type. If lastEventID is one of
+// the events kept in the history, the events of the type published after it
+// are delivered first; otherwise only new events are delivered. The channel
+// is closed when the subscriber is disconnected.
+func (b *eventBroker) subscribe(eventType, lastEventID string) (*eventSubscriber, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if !b.running {
+		return nil, errEventsNotActive
+	}
+
+	var missed []*Event
+	if lastEventID != "" {
+		for i := len(b.history) - 1; i >= 0; i-- {
+			if b.history[i].ID != lastEventID {
+				continue
+			}
+			for _, event := range b.history[i+1:] {
+				if eventType == "*" || event.Type == eventType {
+					missed = append(missed, event)
+				}
+			}
+			break
+		}
+	}
+
+	s := &eventSubscriber{
+		eventType: eventType,
+		ch:        make(chan *Event, eventSubscriberBufferSize+len(missed)),
+	}
+	for _, event := range missed {
+		s.ch <- event
+	}
+	b.subscribers[s] = struct{}{}
+	return s, nil
+}
+
+// unsubscribe disconnects the subscriber, if it is still connected.
+func (b *eventBroker) unsubscribe(s *eventSubscriber) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		close(s.ch)
+		delete(b.subscribers, s)
+	}
+}
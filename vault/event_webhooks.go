@@ -0,0 +1,596 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// EventTypeLeaseRevoked is published when a lease is revoked, whether
+	// explicitly or because it expired
+	EventTypeLeaseRevoked = "lease-revoked"
+
+	// EventTypeMountChanged is published when a secrets engine or an auth
+	// method is enabled, disabled or moved
+	EventTypeMountChanged = "mount-changed"
+
+	// EventTypeSealStatus is published when the active node is unsealed,
+	// including when a standby becomes active, and when it is sealed
+	EventTypeSealStatus = "seal-status"
+
+	// EventSignatureHeader is the header of webhook deliveries holding the
+	// HMAC-SHA256 of the payload, hex encoded and prefixed with "sha256=", if
+	// the sink has an HMAC key
+	EventSignatureHeader = "X-Vault-Event-Signature"
+
+	// eventWebhookSinkPrefix and eventWebhookDeadLetterPrefix are the paths
+	// of the webhook sinks and of their dead letters in the system view
+	eventWebhookSinkPrefix       = "event-webhooks/sinks/"
+	eventWebhookDeadLetterPrefix = "event-webhooks/dead-letters/"
+
+	// eventWebhookQueueSize is the number of events which can wait for their
+	// delivery to a sink. Events published while the queue is full are dead
+	// lettered right away.
+	eventWebhookQueueSize = 256
+
+	// eventWebhookDeadLettersSize is the number of dead letters kept per
+	// sink, the oldest ones being dropped
+	eventWebhookDeadLettersSize = 100
+
+	// eventWebhookDeadLetterFlushInterval is how often the dead letters
+	// recorded in memory are written to storage
+	eventWebhookDeadLetterFlushInterval = 5 * time.Second
+
+	eventWebhookDefaultMaxRetries = 5
+	eventWebhookDefaultTimeout    = 10 * time.Second
+
+	// eventWebhookDrainTimeout is how long the delivery of the queued events
+	// is attempted when the active node is sealed or steps down
+	eventWebhookDrainTimeout = 5 * time.Second
+)
+
+var (
+	// eventWebhookMinRetryWait and eventWebhookMaxRetryWait bound the
+	// exponential backoff between delivery attempts
+	eventWebhookMinRetryWait = time.Second
+	eventWebhookMaxRetryWait = time.Minute
+
+	// EventTypes are the types of the events published by Vault
+	EventTypes = []string{
+		EventTypeLeaseRevoked,
+		EventTypeMountChanged,
+		EventTypeSealStatus,
+	}
+)
+
+// Event is an event published by the active node, as delivered to webhook
+// sinks.
+type Event struct {
+	ID   string                 `json:"id"`
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// EventWebhookSink is a webhook to which the events of the given types are
+// delivered.
+type EventWebhookSink struct {
+	Name       string        `json:"name"`
+	URL        string        `json:"url"`
+	EventTypes []string      `json:"event_types"`
+	HMACKey    string        `json:"hmac_key"`
+	MaxRetries int           `json:"max_retries"`
+	Timeout    time.Duration `json:"timeout"`
+}
+
+// matches returns whether the events of the type are delivered to the sink.
+func (s *EventWebhookSink) matches(eventType string) bool {
+	return strutil.StrListContains(s.EventTypes, "*") || strutil.StrListContains(s.EventTypes, eventType)
+}
+
+// EventDeadLetter is an event whose delivery to a sink failed after all the
+// retries, or which could not be queued.
+type EventDeadLetter struct {
+	Event     *Event    `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// eventWebhookWorker delivers the events queued for a sink, one at a time
+// and in order.
+type eventWebhookWorker struct {
+	// sink is replaced when the sink is updated, and is protected by the
+	// lock of the manager
+	sink   *EventWebhookSink
+	queue  chan *Event
+	doneCh chan struct{}
+}
+
+// eventWebhookManager delivers the events published by the active node to
+// the webhook sinks. It only runs on the active node.
+type eventWebhookManager struct {
+	core   *Core
+	logger log.Logger
+	client *http.Client
+
+	l       sync.RWMutex
+	running bool
+	workers map[string]*eventWebhookWorker
+	stopCh  chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	// deadLetterLock serializes the writes of the dead letters to storage.
+	// pendingDeadLetters holds the dead letters of each sink recorded since
+	// the last flush, and is protected by pendingLock, which is never held
+	// while accessing storage so that recording a dead letter never blocks.
+	deadLetterLock     sync.Mutex
+	pendingLock        sync.Mutex
+	pendingDeadLetters map[string][]*EventDeadLetter
+}
+
+func newEventWebhookManager(c *Core, logger log.Logger) *eventWebhookManager {
+	return &eventWebhookManager{
+		core:               c,
+		logger:             logger,
+		client:             cleanhttp.DefaultPooledClient(),
+		workers:            make(map[string]*eventWebhookWorker),
+		pendingDeadLetters: make(map[string][]*EventDeadLetter),
+	}
+}
+
+// setupEventWebhooks loads the webhook sinks and starts delivering events to
+// them. It is invoked as part of postUnseal on the active node.
+func (c *Core) setupEventWebhooks(ctx context.Context) error {
+	sinks, err := c.eventWebhooks.loadSinks(ctx)
+	if err != nil {
+		return err
+	}
+	c.eventWebhooks.start(sinks)
+	c.events.start()
+
+	c.publishEvent(EventTypeSealStatus, map[string]interface{}{
+		"sealed":      false,
+		"api_address": c.redirectAddr,
+	})
+	return nil
+}
+
+// stopEventWebhooks stops the delivery of events, attempting to deliver the
+// queued ones for a short time, and disconnects the event stream
+// subscriptions. It is invoked as part of preSeal.
+func (c *Core) stopEventWebhooks() {
+	if c.eventWebhooks != nil {
+		c.eventWebhooks.stop()
+	}
+	if c.events != nil {
+		c.events.stop()
+	}
+}
+
+// publishEvent delivers the event to the webhook sinks and to the event
+// stream subscriptions interested in its type. It does not block, and does
+// nothing unless this is the active node.
+func (c *Core) publishEvent(eventType string, data map[string]interface{}) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		c.logger.Error("failed to generate event ID", "error", err)
+		return
+	}
+	event := &Event{
+		ID:   id,
+		Type: eventType,
+		Time: time.Now().UTC(),
+		Data: data,
+	}
+
+	if c.eventWebhooks != nil {
+		c.eventWebhooks.publish(event)
+	}
+	if c.events != nil {
+		c.events.publish(event)
+	}
+}
+
+// publishMountChanged publishes the change of the mount at path, relative to
+// the namespace of the context. newPath is only set when the mount is moved.
+func (c *Core) publishMountChanged(ctx context.Context, operation, path, newPath, mountType string) {
+	data := map[string]interface{}{
+		"operation": operation,
+		"path":      path,
+	}
+	if ns, err := namespace.FromContext(ctx); err == nil {
+		data["namespace"] = ns.Path
+	}
+	if newPath != "" {
+		data["new_path"] = newPath
+	}
+	if mountType != "" {
+		data["type"] = mountType
+	}
+	c.publishEvent(EventTypeMountChanged, data)
+}
+
+// loadSinks reads the webhook sinks from storage.
+func (m *eventWebhookManager) loadSinks(ctx context.Context) ([]*EventWebhookSink, error) {
+	view := m.core.systemBarrierView
+	names, err := view.List(ctx, eventWebhookSinkPrefix)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to list event webhook sinks: {{err}}", err)
+	}
+
+	var sinks []*EventWebhookSink
+	for _, name := range names {
+		entry, err := view.Get(ctx, eventWebhookSinkPrefix+name)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read event webhook sink: {{err}}", err)
+		}
+		if entry == nil {
+			continue
+		}
+		var sink EventWebhookSink
+		if err := entry.DecodeJSON(&sink); err != nil {
+			return nil, errwrap.Wrapf("failed to decode event webhook sink: {{err}}", err)
+		}
+		sinks = append(sinks, &sink)
+	}
+	return sinks, nil
+}
+
+func (m *eventWebhookManager) start(sinks []*EventWebhookSink) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	m.running = true
+	m.stopCh = make(chan struct{})
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.workers = make(map[string]*eventWebhookWorker)
+	for _, sink := range sinks {
+		m.startWorkerLocked(sink)
+	}
+
+	m.wg.Add(1)
+	go m.runDeadLetterFlush(m.stopCh)
+}
+
+// runDeadLetterFlush periodically writes the dead letters recorded in memory
+// to storage until the manager is stopped, which flushes them a last time.
+func (m *eventWebhookManager) runDeadLetterFlush(stopCh chan struct{}) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(eventWebhookDeadLetterFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			m.flushDeadLetters()
+		}
+	}
+}
+
+// startWorkerLocked starts delivering events to the sink. It must be called
+// with the lock held, while running.
+func (m *eventWebhookManager) startWorkerLocked(sink *EventWebhookSink) {
+	w := &eventWebhookWorker{
+		sink:   sink,
+		queue:  make(chan *Event, eventWebhookQueueSize),
+		doneCh: make(chan struct{}),
+	}
+	m.workers[sink.Name] = w
+	m.wg.Add(1)
+	go m.run(m.ctx, m.stopCh, w)
+}
+
+func (m *eventWebhookManager) stop() {
+	m.l.Lock()
+	if !m.running {
+		m.l.Unlock()
+		return
+	}
+	m.running = false
+	close(m.stopCh)
+	cancel := m.cancel
+	m.workers = make(map[string]*eventWebhookWorker)
+	m.l.Unlock()
+
+	doneCh := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(eventWebhookDrainTimeout):
+		m.logger.Warn("timed out delivering the queued events")
+	}
+	// Abort the deliveries in progress
+	cancel()
+	<-doneCh
+
+	// The barrier is still unsealed, the dead letters of the events which
+	// could not be delivered are kept
+	m.flushDeadLetters()
+}
+
+// setSink adds or updates the sink. Events queued for the sink are delivered
+// with its new configuration.
+func (m *eventWebhookManager) setSink(sink *EventWebhookSink) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if !m.running {
+		return
+	}
+	if w, ok := m.workers[sink.Name]; ok {
+		w.sink = sink
+		return
+	}
+	m.startWorkerLocked(sink)
+}
+
+// deleteSink stops delivering events to the sink, dropping the queued ones.
+func (m *eventWebhookManager) deleteSink(name string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if w, ok := m.workers[name]; ok {
+		close(w.doneCh)
+		delete(m.workers, name)
+	}
+}
+
+func (m *eventWebhookManager) workerSink(w *eventWebhookWorker) *EventWebhookSink {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	return w.sink
+}
+
+func (m *eventWebhookManager) publish(event *Event) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	if !m.running || len(m.workers) == 0 {
+		return
+	}
+
+	for name, w := range m.workers {
+		if !w.sink.matches(event.Type) {
+			continue
+		}
+		select {
+		case w.queue <- event:
+		default:
+			m.addDeadLetter(name, event, 0, fmt.Errorf("delivery queue is full"))
+		}
+	}
+}
+
+// run delivers the events queued for the sink until it is deleted or the
+// manager is stopped, in which case the queued events are attempted once.
+func (m *eventWebhookManager) run(ctx context.Context, stopCh chan struct{}, w *eventWebhookWorker) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-w.doneCh:
+			return
+		case <-stopCh:
+			for {
+				select {
+				case event := <-w.queue:
+					sink := m.workerSink(w)
+					if err := m.deliver(ctx, sink, event); err != nil {
+						m.addDeadLetter(sink.Name, event, 1, err)
+					}
+				default:
+					return
+				}
+			}
+		case event := <-w.queue:
+			m.deliverWithRetries(ctx, stopCh, w, event)
+		}
+	}
+}
+
+// deliverWithRetries delivers the event, retrying with an exponential backoff
+// up to the maximum number of retries of the sink before dead lettering it.
+func (m *eventWebhookManager) deliverWithRetries(ctx context.Context, stopCh chan struct{}, w *eventWebhookWorker, event *Event) {
+	wait := eventWebhookMinRetryWait
+	for attempts := 1; ; attempts++ {
+		sink := m.workerSink(w)
+		err := m.deliver(ctx, sink, event)
+		if err == nil {
+			return
+		}
+		if attempts > sink.MaxRetries {
+			m.addDeadLetter(sink.Name, event, attempts, err)
+			return
+		}
+		m.logger.Debug("event delivery failed, retrying", "sink", sink.Name, "event_id", event.ID, "attempt", attempts, "error", err)
+
+		select {
+		case <-time.After(wait):
+		case <-w.doneCh:
+			return
+		case <-stopCh:
+			m.addDeadLetter(sink.Name, event, attempts, err)
+			return
+		}
+		wait *= 2
+		if wait > eventWebhookMaxRetryWait {
+			wait = eventWebhookMaxRetryWait
+		}
+	}
+}
+
+// deliver posts the event to the sink, signing the payload if the sink has
+// an HMAC key. Any response other than a 2xx is a failure.
+func (m *eventWebhookManager) deliver(ctx context.Context, sink *EventWebhookSink, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sink.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Event-ID", event.ID)
+	req.Header.Set("X-Vault-Event-Type", event.Type)
+	if sink.HMACKey != "" {
+		req.Header.Set(EventSignatureHeader, "sha256="+signEventPayload(sink.HMACKey, body))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signEventPayload returns the hex encoded HMAC-SHA256 of the payload.
+func signEventPayload(key string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// addDeadLetter records the failed delivery of the event to the sink in
+// memory, until the next flush writes it to storage. It does not block.
+func (m *eventWebhookManager) addDeadLetter(name string, event *Event, attempts int, deliveryErr error) {
+	m.logger.Warn("event delivery failed", "sink", name, "event_id", event.ID, "attempts", attempts, "error", deliveryErr)
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	m.pendingDeadLetters[name] = lastDeadLetters(append(m.pendingDeadLetters[name], &EventDeadLetter{
+		Event:     event,
+		Attempts:  attempts,
+		LastError: deliveryErr.Error(),
+		FailedAt:  time.Now().UTC(),
+	}))
+}
+
+// lastDeadLetters drops the oldest dead letters beyond the number kept.
+func lastDeadLetters(letters []*EventDeadLetter) []*EventDeadLetter {
+	if len(letters) > eventWebhookDeadLettersSize {
+		return letters[len(letters)-eventWebhookDeadLettersSize:]
+	}
+	return letters
+}
+
+// flushDeadLetters appends the dead letters recorded in memory to those of
+// each sink in storage. The barrier may already be sealed, in which case the
+// dead letters are only logged.
+func (m *eventWebhookManager) flushDeadLetters() {
+	m.deadLetterLock.Lock()
+	defer m.deadLetterLock.Unlock()
+
+	m.pendingLock.Lock()
+	pending := m.pendingDeadLetters
+	m.pendingDeadLetters = make(map[string][]*EventDeadLetter)
+	m.pendingLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for name, added := range pending {
+		letters, err := m.storedDeadLetters(ctx, name)
+		if err == nil {
+			err = m.putDeadLetters(ctx, name, lastDeadLetters(append(letters, added...)))
+		}
+		if err != nil {
+			m.logger.Error("failed to record dead letters", "sink", name, "dead_letters", len(added), "error", err)
+		}
+	}
+}
+
+// deadLetters returns the dead letters of the sink, oldest first, including
+// those not yet written to storage.
+func (m *eventWebhookManager) deadLetters(ctx context.Context, name string) ([]*EventDeadLetter, error) {
+	m.deadLetterLock.Lock()
+	defer m.deadLetterLock.Unlock()
+
+	letters, err := m.storedDeadLetters(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+	return lastDeadLetters(append(letters, m.pendingDeadLetters[name]...)), nil
+}
+
+func (m *eventWebhookManager) storedDeadLetters(ctx context.Context, name string) ([]*EventDeadLetter, error) {
+	entry, err := m.core.systemBarrierView.Get(ctx, eventWebhookDeadLetterPrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var letters []*EventDeadLetter
+	if err := jsonutil.DecodeJSON(entry.Value, &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+func (m *eventWebhookManager) putDeadLetters(ctx context.Context, name string, letters []*EventDeadLetter) error {
+	entry, err := logical.StorageEntryJSON(eventWebhookDeadLetterPrefix+name, letters)
+	if err != nil {
+		return err
+	}
+	return m.core.systemBarrierView.Put(ctx, entry)
+}
+
+// clearDeadLetters deletes the dead letters of the sink.
+func (m *eventWebhookManager) clearDeadLetters(ctx context.Context, name string) error {
+	m.deadLetterLock.Lock()
+	defer m.deadLetterLock.Unlock()
+
+	m.pendingLock.Lock()
+	delete(m.pendingDeadLetters, name)
+	m.pendingLock.Unlock()
+
+	return m.core.systemBarrierView.Delete(ctx, eventWebhookDeadLetterPrefix+name)
+}
+
+// validEventType returns whether the type, or "*" for all types, is a type of
+// the events published by Vault.
+func validEventType(eventType string) bool {
+	return eventType == "*" || strutil.StrListContains(EventTypes, eventType)
+}
@@ -0,0 +1,228 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestSystemBackend_EventWebhooks(t *testing.T) {
+	minRetryWait := eventWebhookMinRetryWait
+	eventWebhookMinRetryWait = time.Millisecond
+	defer func() { eventWebhookMinRetryWait = minRetryWait }()
+
+	c, b, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	// The first delivery fails and is retried
+	var l sync.Mutex
+	var requests int
+	var events []*Event
+	var signatures [][2]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("bad payload: %v", err)
+		}
+		events = append(events, &event)
+		signatures = append(signatures, [2]string{r.Header.Get(EventSignatureHeader), signEventPayload("secret", body)})
+	}))
+	defer srv.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	write := func(name string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "events/webhooks/"+name)
+		req.Storage = c.systemBarrierView
+		req.Data = data
+		return b.HandleRequest(ctx, req)
+	}
+	resp, err := write("mounts", map[string]interface{}{
+		"url":         srv.URL,
+		"event_types": "mount-changed",
+		"hmac_key":    "secret",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	resp, err = write("failing", map[string]interface{}{
+		"url":         failing.URL,
+		"event_types": "*",
+		"max_retries": 1,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	// Invalid sinks are rejected
+	for _, data := range []map[string]interface{}{
+		{"url": "ftp://example.com", "event_types": "*"},
+		{"url": srv.URL},
+		{"url": srv.URL, "event_types": "unknown"},
+		{"url": srv.URL, "event_types": "*", "max_retries": -1},
+	} {
+		resp, _ := write("invalid", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error writing %#v", data)
+		}
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "events/webhooks/mounts")
+	req.Storage = c.systemBarrierView
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["hmac_key_set"] != true || resp.Data["max_retries"] != eventWebhookDefaultMaxRetries || resp.Data["timeout"] != 10 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["hmac_key"]; ok {
+		t.Fatal("the HMAC key must not be returned")
+	}
+
+	if err := c.mount(ctx, &MountEntry{Table: mountTableType, Path: "events-test/", Type: "kv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.Lock()
+		delivered := len(events)
+		l.Unlock()
+		if delivered > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event delivery")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	l.Lock()
+	event, signature := events[0], signatures[0]
+	l.Unlock()
+	if event.Type != EventTypeMountChanged || event.Data["operation"] != "enable" || event.Data["path"] != "events-test/" || event.Data["type"] != "kv" {
+		t.Fatalf("bad event: %#v", event)
+	}
+	if signature[0] != "sha256="+signature[1] {
+		t.Fatalf("bad signature %q, expected the HMAC %q", signature[0], signature[1])
+	}
+
+	// The event which could not be delivered to the failing sink is dead
+	// lettered after its retry
+	req = logical.TestRequest(t, logical.ReadOperation, "events/webhooks/failing/dead-letters")
+	req.Storage = c.systemBarrierView
+	var letters []*EventDeadLetter
+	for {
+		resp, err = b.HandleRequest(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		letters = resp.Data["dead_letters"].([]*EventDeadLetter)
+		if len(letters) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the dead letter")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if letters[0].Attempts != 2 || letters[0].Event.Type != EventTypeMountChanged || letters[0].LastError != "unexpected response status 502" {
+		t.Fatalf("bad dead letter: %#v", letters[0])
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "events/webhooks/failing/dead-letters")
+	req.Storage = c.systemBarrierView
+	if _, err := b.HandleRequest(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	letters, err = c.eventWebhooks.deadLetters(ctx, "failing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 0 {
+		t.Fatalf("expected no dead letters, got: %#v", letters)
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "events/webhooks/")
+	req.Storage = c.systemBarrierView
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestEventWebhooks_DeadLetterFlush(t *testing.T) {
+	c, _, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	m := c.eventWebhooks
+
+	stored := func() []*EventDeadLetter {
+		t.Helper()
+		letters, err := m.storedDeadLetters(ctx, "sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return letters
+	}
+
+	// The dead letters are kept in memory until flushed
+	for i := 0; i < eventWebhookDeadLettersSize; i++ {
+		m.addDeadLetter("sink", &Event{ID: fmt.Sprintf("first-%d", i)}, 1, errors.New("failed"))
+	}
+	if letters := stored(); len(letters) != 0 {
+		t.Fatalf("expected no stored dead letters, got %d", len(letters))
+	}
+	letters, err := m.deadLetters(ctx, "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != eventWebhookDeadLettersSize {
+		t.Fatalf("bad number of dead letters: %d", len(letters))
+	}
+
+	// The flush appends them to the stored ones, dropping the oldest
+	m.flushDeadLetters()
+	m.addDeadLetter("sink", &Event{ID: "second"}, 1, errors.New("failed"))
+	m.flushDeadLetters()
+	letters = stored()
+	if len(letters) != eventWebhookDeadLettersSize {
+		t.Fatalf("bad number of dead letters: %d", len(letters))
+	}
+	if letters[0].Event.ID != "first-1" || letters[len(letters)-1].Event.ID != "second" {
+		t.Fatalf("bad dead letters: %s ... %s", letters[0].Event.ID, letters[len(letters)-1].Event.ID)
+	}
+
+	// Clearing drops the dead letters not yet flushed
+	m.addDeadLetter("sink", &Event{ID: "third"}, 1, errors.New("failed"))
+	if err := m.clearDeadLetters(ctx, "sink"); err != nil {
+		t.Fatal(err)
+	}
+	m.flushDeadLetters()
+	if letters := stored(); len(letters) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(letters))
+	}
+}
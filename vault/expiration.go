@@ -351,8 +351,9 @@ func (m *ExpirationManager) invalidate(key string) {
 // either empty or invalid and in both the cases, it revokes them. It also uses
 // a token cache to avoid multiple lookups of the same token ID. It is normally
 // not required to use the API that invokes this. This is only intended to
-// clean up the corrupt storage due to bugs.
-func (m *ExpirationManager) Tidy(ctx context.Context) error {
+// clean up the corrupt storage due to bugs. The progress of the scan and its
+// counts are reported through progress, if not nil.
+func (m *ExpirationManager) Tidy(ctx context.Context, progress *jobProgress) error {
 	if m.inRestoreMode() {
 		return errors.New("cannot run tidy while restoring leases")
 	}
@@ -364,6 +365,7 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 
 	if !atomic.CompareAndSwapInt32(m.tidyLock, 0, 1) {
 		logger.Warn("tidy operation on leases is already in progress")
+		progress.SetResult("already_in_progress", true)
 		return nil
 	}
 
@@ -377,6 +379,7 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	var countLease, revokedCount, deletedCountInvalidToken, deletedCountEmptyToken int64
 
 	tidyFunc := func(leaseID string) {
+		defer progress.Complete(1)
 		countLease++
 		if countLease%500 == 0 {
 			logger.Info("tidying leases", "progress", countLease)
@@ -452,7 +455,7 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 		return err
 	}
 	leaseView := m.leaseView(ns)
-	if err := logical.ScanView(m.quitContext, leaseView, tidyFunc); err != nil {
+	if err := logical.ScanView(ctx, leaseView, tidyFunc); err != nil {
 		return err
 	}
 
@@ -461,6 +464,11 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	logger.Info("number of leases which had invalid tokens", "count", deletedCountInvalidToken)
 	logger.Info("number of leases successfully revoked", "count", revokedCount)
 
+	progress.SetResult("leases_scanned", countLease)
+	progress.SetResult("leases_with_empty_token", deletedCountEmptyToken)
+	progress.SetResult("leases_with_invalid_token", deletedCountInvalidToken)
+	progress.SetResult("leases_revoked", revokedCount)
+
 	return tidyErrors.ErrorOrNil()
 }
 
@@ -762,6 +770,12 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 		}
 	}
 
+	m.core.publishEvent(EventTypeLeaseRevoked, map[string]interface{}{
+		"lease_id":  leaseID,
+		"path":      le.Path,
+		"namespace": le.namespace.Path,
+	})
+
 	// Clear the expiration handler
 	m.pendingLock.Lock()
 	if info, ok := m.pending.Load(leaseID); ok {
@@ -789,7 +803,7 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 func (m *ExpirationManager) RevokeForce(ctx context.Context, prefix string) error {
 	defer metrics.MeasureSince([]string{"expire", "revoke-force"}, time.Now())
 
-	return m.revokePrefixCommon(ctx, prefix, true, true)
+	return m.revokePrefixCommon(ctx, prefix, true, true, nil)
 }
 
 // RevokePrefix is used to revoke all secrets with a given prefix.
@@ -798,7 +812,7 @@ func (m *ExpirationManager) RevokeForce(ctx context.Context, prefix string) erro
 func (m *ExpirationManager) RevokePrefix(ctx context.Context, prefix string, sync bool) error {
 	defer metrics.MeasureSince([]string{"expire", "revoke-prefix"}, time.Now())
 
-	return m.revokePrefixCommon(ctx, prefix, false, sync)
+	return m.revokePrefixCommon(ctx, prefix, false, sync, nil)
 }
 
 // RevokeByToken is used to revoke all the secrets issued with a given token.
@@ -875,7 +889,10 @@ func (m *ExpirationManager) RevokeByToken(ctx context.Context, te *logical.Token
 	return nil
 }
 
-func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix string, force, sync bool) error {
+// revokePrefixCommon revokes the leases under prefix, reporting its progress
+// through progress if not nil. A job revoking the leases stops once ctx is
+// done.
+func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix string, force, sync bool, progress *jobProgress) error {
 	if m.inRestoreMode() {
 		m.restoreRequestLock.Lock()
 		defer m.restoreRequestLock.Unlock()
@@ -886,10 +903,13 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 	if !strings.HasSuffix(prefix, "/") {
 		le, err := m.loadEntry(ctx, prefix)
 		if err == nil && le != nil {
+			progress.SetTotal(1)
 			if sync {
 				if err := m.revokeCommon(ctx, prefix, force, false); err != nil {
 					return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q: {{err}}", prefix), err)
 				}
+				progress.Complete(1)
+				progress.SetResult("leases_revoked", 1)
 				return nil
 			}
 			return m.LazyRevoke(ctx, prefix)
@@ -909,8 +929,15 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 		return errwrap.Wrapf("failed to scan for leases: {{err}}", err)
 	}
 
+	progress.SetTotal(int64(len(existing)))
+
 	// Revoke all the keys
 	for idx, suffix := range existing {
+		if progress != nil && ctx.Err() != nil {
+			progress.SetResult("leases_revoked", idx)
+			return ctx.Err()
+		}
+
 		leaseID := prefix + suffix
 		switch {
 		case sync:
@@ -922,8 +949,10 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 				return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
 			}
 		}
+		progress.Complete(1)
 	}
 
+	progress.SetResult("leases_revoked", len(existing))
 	return nil
 }
 
@@ -280,7 +280,7 @@ func TestExpiration_Tidy(t *testing.T) {
 	}
 
 	// Run the tidy operation
-	err = exp.Tidy(namespace.RootContext(nil))
+	err = exp.Tidy(namespace.RootContext(nil), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -315,7 +315,7 @@ func TestExpiration_Tidy(t *testing.T) {
 	}
 
 	// Run the tidy operation
-	err = exp.Tidy(namespace.RootContext(nil))
+	err = exp.Tidy(namespace.RootContext(nil), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -341,7 +341,7 @@ func TestExpiration_Tidy(t *testing.T) {
 	}
 
 	// Run the tidy operation
-	err = exp.Tidy(namespace.RootContext(nil))
+	err = exp.Tidy(namespace.RootContext(nil), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -396,11 +396,11 @@ func TestExpiration_Tidy(t *testing.T) {
 	// one tidy operation can be in flight at any time. One of these requests
 	// should error out.
 	go func() {
-		errCh1 <- exp.Tidy(namespace.RootContext(nil))
+		errCh1 <- exp.Tidy(namespace.RootContext(nil), nil)
 	}()
 
 	go func() {
-		errCh2 <- exp.Tidy(namespace.RootContext(nil))
+		errCh2 <- exp.Tidy(namespace.RootContext(nil), nil)
 	}()
 
 	var err1, err2 error
@@ -431,7 +431,7 @@ func TestExpiration_Tidy(t *testing.T) {
 	}
 
 	// Run the tidy operation
-	err = exp.Tidy(namespace.RootContext(nil))
+	err = exp.Tidy(namespace.RootContext(nil), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,135 @@
+package misc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// Tests that the secrets of a KV mount, with their versions, are migrated to
+// another mount of either version
+func TestKV_MigrateState(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0]
+	vault.TestWaitActive(t, core.Core)
+	client := core.Client
+
+	mounts := map[string]*api.MountInput{
+		"old": {Type: "kv-v2"},
+		"new": {Type: "kv-v2"},
+		"v1":  {Type: "kv", Options: map[string]string{"version": "1"}},
+		"v2":  {Type: "kv-v2"},
+	}
+	for path, input := range mounts {
+		if err := client.Sys().Mount(path, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The versioned mounts are unusable until their upgrade check is done
+	deadline := time.Now().Add(10 * time.Second)
+	for path, input := range mounts {
+		if input.Type != "kv-v2" {
+			continue
+		}
+		for {
+			_, err := client.Logical().Read(path + "/config")
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for the upgrade of %q: %v", path, err)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	write := func(path string, data map[string]interface{}) {
+		t.Helper()
+		if _, err := client.Logical().Write(path, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old/config", map[string]interface{}{"max_versions": 5})
+	write("old/data/app/db", map[string]interface{}{"data": map[string]interface{}{"password": "one"}})
+	write("old/data/app/db", map[string]interface{}{"data": map[string]interface{}{"password": "two"}})
+	write("old/metadata/app/db", map[string]interface{}{"custom_metadata": map[string]interface{}{"owner": "payments"}})
+	write("old/data/gone", map[string]interface{}{"data": map[string]interface{}{"foo": "bar"}})
+	if _, err := client.Logical().Delete("old/data/gone"); err != nil {
+		t.Fatal(err)
+	}
+
+	migrate := func(from, to string) {
+		t.Helper()
+		write("sys/migrate-state", map[string]interface{}{"from": from, "to": to})
+	}
+	read := func(path string) *api.Secret {
+		t.Helper()
+		secret, err := client.Logical().Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret
+	}
+
+	// All the versions and the metadata are kept between versioned mounts
+	migrate("old", "new")
+	if secret := read("new/config"); secret.Data["max_versions"] != json.Number("5") {
+		t.Fatalf("bad config: %#v", secret.Data)
+	}
+	secret, err := client.Logical().ReadWithData("new/data/app/db", map[string][]string{"version": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := secret.Data["data"]; !reflect.DeepEqual(data, map[string]interface{}{"password": "one"}) {
+		t.Fatalf("bad first version: %#v", data)
+	}
+	secret = read("new/metadata/app/db")
+	if secret.Data["current_version"] != json.Number("2") {
+		t.Fatalf("bad current version: %#v", secret.Data)
+	}
+	if owner := secret.Data["custom_metadata"].(map[string]interface{})["owner"]; owner != "payments" {
+		t.Fatalf("bad custom metadata: %#v", secret.Data)
+	}
+	if secret := read("new/metadata/gone"); secret == nil {
+		t.Fatal("expected the metadata of the deleted secret to be migrated")
+	}
+
+	// Only the current data of the live secrets is kept in a passthrough
+	// mount
+	migrate("old", "v1")
+	secret = read("v1/app/db")
+	if !reflect.DeepEqual(secret.Data, map[string]interface{}{"password": "two"}) {
+		t.Fatalf("bad data: %#v", secret.Data)
+	}
+	if secret := read("v1/gone"); secret != nil {
+		t.Fatalf("expected the deleted secret not to be migrated: %#v", secret.Data)
+	}
+
+	// The secrets of a passthrough mount become the first version
+	migrate("v1", "v2")
+	secret = read("v2/data/app/db")
+	if data := secret.Data["data"]; !reflect.DeepEqual(data, map[string]interface{}{"password": "two"}) {
+		t.Fatalf("bad data: %#v", data)
+	}
+	if secret.Data["metadata"].(map[string]interface{})["version"] != json.Number("1") {
+		t.Fatalf("bad version: %#v", secret.Data)
+	}
+}
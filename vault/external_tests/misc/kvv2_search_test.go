@@ -0,0 +1,96 @@
+package misc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// Tests that the search only returns the keys whose metadata the caller can
+// read
+func TestKVv2_SearchFilteredByPolicies(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0]
+	vault.TestWaitActive(t, core.Core)
+	client := core.Client
+
+	err := client.Sys().Mount("kv", &api.MountInput{
+		Type: "kv-v2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"app/db", "app/api", "infra/db"} {
+		if _, err := client.Logical().Write("kv/data/"+key, map[string]interface{}{
+			"data": map[string]interface{}{"foo": "bar"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("kv/metadata/"+key, map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "payments"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = client.Sys().PutPolicy("search", `
+path "kv/search" {
+	capabilities = ["read"]
+}
+path "kv/metadata/app/*" {
+	capabilities = ["read"]
+}
+path "kv/metadata/app/api" {
+	capabilities = ["deny"]
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(t *testing.T, client *api.Client) []interface{} {
+		t.Helper()
+		secret, err := client.Logical().Read("kv/search")
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, _ := secret.Data["keys"].([]interface{})
+		return keys
+	}
+
+	if keys := search(t, client); !reflect.DeepEqual(keys, []interface{}{"app/api", "app/db", "infra/db"}) {
+		t.Fatalf("bad keys for the root token: %v", keys)
+	}
+
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"search"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted.SetToken(secret.Auth.ClientToken)
+
+	if keys := search(t, restricted); !reflect.DeepEqual(keys, []interface{}{"app/db"}) {
+		t.Fatalf("bad keys for the restricted token: %v", keys)
+	}
+}
@@ -3,14 +3,15 @@ package misc
 import (
 	"bytes"
 	"context"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/api"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -103,3 +104,120 @@ func TestKVv2_UpgradePaths(t *testing.T) {
 		t.Fatal("got a cannot write to storage during setup error")
 	}
 }
+
+func TestKVv2_UpgradeKVEndpoint(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": logicalKv.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0]
+	vault.TestWaitActive(t, core.Core)
+	client := core.Client
+
+	err := client.Sys().Mount("kv", &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b/c", "b/d"} {
+		if _, err := client.Logical().Write("kv/"+key, map[string]interface{}{"foo": key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := client.Sys().KVUpgradeStatus("kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 || status.Status != "not_started" {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	if _, err := client.Sys().UpgradeKVMount("kv"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status, err = client.Sys().KVUpgradeStatus("kv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status == "done" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the upgrade: %#v", status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if status.Version != 2 || status.KeysTotal != 3 || status.KeysUpgraded != 3 || status.CompletedTime == "" {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	secret, err := client.Logical().Read("kv/data/b/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Data["data"].(map[string]interface{})["foo"] != "b/d" {
+		t.Fatalf("bad secret: %#v", secret)
+	}
+
+	// Upgrading again is a no-op, and only KV mounts can be upgraded
+	if _, err := client.Sys().UpgradeKVMount("kv"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Sys().UpgradeKVMount("cubbyhole"); err == nil {
+		t.Fatal("expected an error upgrading a mount which is not a KV mount")
+	}
+
+	// Downgrading keeps the current data of the secrets which are not deleted
+	if _, err := client.Logical().Write("kv/data/a", map[string]interface{}{"data": map[string]interface{}{"foo": "new"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Delete("kv/data/b/c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Sys().DowngradeKVMount("kv"); err != nil {
+		t.Fatal(err)
+	}
+	status, err = client.Sys().KVUpgradeStatus("kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 1 {
+		t.Fatalf("bad status: %#v", status)
+	}
+	keys, err := client.Logical().List("kv/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys == nil || !reflect.DeepEqual(keys.Data["keys"], []interface{}{"a", "b/"}) {
+		t.Fatalf("bad keys: %#v", keys)
+	}
+	for key, value := range map[string]string{"a": "new", "b/d": "b/d"} {
+		secret, err := client.Logical().Read("kv/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["foo"] != value {
+			t.Fatalf("bad secret %q: %#v", key, secret)
+		}
+	}
+	if secret, err := client.Logical().Read("kv/b/c"); err != nil || secret != nil {
+		t.Fatalf("expected the deleted secret not to be kept, got err:%v secret:%#v", err, secret)
+	}
+
+	// The mount can be upgraded again
+	if _, err := client.Sys().UpgradeKVMount("kv"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -576,18 +576,36 @@ func (c *Core) waitForLeadership(newLeaderCh chan func(), manualStepDownCh, stop
 			continue
 		}
 
+		if err := c.recordLeadershipChange(activeCtx, leadershipAcquired, ""); err != nil {
+			c.logger.Error("failed to record leadership change", "error", err)
+		}
+
 		// Monitor a loss of leadership
+		var releaseReason string
 		select {
 		case <-leaderLostCh:
 			c.logger.Warn("leadership lost, stopping active operation")
 		case <-stopCh:
+			releaseReason = "sealed or shut down"
 		case <-manualStepDownCh:
 			manualStepDown = true
+			releaseReason = "stepped down"
 			c.logger.Warn("stepping down from active operation to standby")
 		}
 
 		// Stop Active Duty
 		{
+			// Record why leadership is released while the lock is still held
+			// and the barrier unsealed. This cannot be done once the lock is
+			// lost, as another node may already be active.
+			if releaseReason != "" {
+				recordCtx, recordCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := c.recordLeadershipChange(recordCtx, leadershipReleased, releaseReason); err != nil {
+					c.logger.Error("failed to record leadership change", "error", err)
+				}
+				recordCancel()
+			}
+
 			// Spawn this in a go routine so we can cancel the context and
 			// unblock any inflight requests that are holding the statelock.
 			go func() {
@@ -0,0 +1,166 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+const (
+	// coreLeadershipHistoryPath is the storage path of the recent changes of
+	// the active node
+	coreLeadershipHistoryPath = "core/leadership-history"
+
+	// leadershipHistorySize is the number of changes of the active node kept
+	// in the leadership history
+	leadershipHistorySize = 64
+
+	leadershipAcquired = "acquired"
+	leadershipReleased = "released"
+)
+
+// haPeerInfo is what the active node knows about a standby, recorded on each
+// heartbeat of the standby.
+type haPeerInfo struct {
+	APIAddr       string
+	Version       string
+	LastHeartbeat time.Time
+}
+
+// HANode is a node of the HA cluster, as reported by sys/ha-status.
+type HANode struct {
+	APIAddress     string     `json:"api_address"`
+	ClusterAddress string     `json:"cluster_address"`
+	ActiveNode     bool       `json:"active_node"`
+	LastEcho       *time.Time `json:"last_echo"`
+	Version        string     `json:"version"`
+}
+
+// LeadershipChange is a change of the active node of the HA cluster. A node
+// records that it acquired leadership once it is active, and that it released
+// it when it steps down, is sealed or shuts down. A node losing its HA lock
+// cannot record it, so it is inferred by the next active node.
+type LeadershipChange struct {
+	Time           time.Time `json:"time"`
+	Event          string    `json:"event"`
+	APIAddress     string    `json:"api_address"`
+	ClusterAddress string    `json:"cluster_address"`
+	Reason         string    `json:"reason"`
+}
+
+// recordHAPeer records the heartbeat of a standby.
+func (c *Core) recordHAPeer(clusterAddr, apiAddr, version string) {
+	c.clusterPeerClusterAddrsCache.Set(clusterAddr, &haPeerInfo{
+		APIAddr:       apiAddr,
+		Version:       version,
+		LastHeartbeat: time.Now(),
+	}, 0)
+}
+
+// HANodes returns the active node and the standbys which sent a heartbeat
+// recently. It must be called on the active node.
+func (c *Core) HANodes() []*HANode {
+	nodes := []*HANode{
+		{
+			APIAddress:     c.redirectAddr,
+			ClusterAddress: c.ClusterAddr(),
+			ActiveNode:     true,
+			Version:        version.GetVersion().Version,
+		},
+	}
+
+	var standbys []*HANode
+	for clusterAddr, item := range c.clusterPeerClusterAddrsCache.Items() {
+		node := &HANode{
+			ClusterAddress: clusterAddr,
+		}
+		// Standbys running an older version only send their cluster address
+		if info, ok := item.Object.(*haPeerInfo); ok && info != nil {
+			lastEcho := info.LastHeartbeat
+			node.APIAddress = info.APIAddr
+			node.LastEcho = &lastEcho
+			node.Version = info.Version
+		}
+		standbys = append(standbys, node)
+	}
+	sort.Slice(standbys, func(i, j int) bool {
+		return standbys[i].ClusterAddress < standbys[j].ClusterAddress
+	})
+
+	return append(nodes, standbys...)
+}
+
+// LeadershipHistory returns the recent changes of the active node, oldest
+// first.
+func (c *Core) LeadershipHistory(ctx context.Context) ([]*LeadershipChange, error) {
+	entry, err := c.barrier.Get(ctx, coreLeadershipHistoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leadership history: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var history []*LeadershipChange
+	if err := jsonutil.DecodeJSON(entry.Value, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode leadership history: %w", err)
+	}
+	return history, nil
+}
+
+// recordLeadershipChange appends a change to the leadership history, dropping
+// the oldest changes beyond leadershipHistorySize. The reason of an
+// acquisition is inferred from the previous change. It must only be called
+// while holding the HA lock.
+func (c *Core) recordLeadershipChange(ctx context.Context, event, reason string) error {
+	history, err := c.LeadershipHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	if event == leadershipAcquired {
+		reason = c.leadershipAcquiredReason(history)
+	}
+	history = append(history, &LeadershipChange{
+		Time:           time.Now().UTC(),
+		Event:          event,
+		APIAddress:     c.redirectAddr,
+		ClusterAddress: c.ClusterAddr(),
+		Reason:         reason,
+	})
+	if len(history) > leadershipHistorySize {
+		history = history[len(history)-leadershipHistorySize:]
+	}
+
+	value, err := jsonutil.EncodeJSON(history)
+	if err != nil {
+		return err
+	}
+	return c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreLeadershipHistoryPath,
+		Value: value,
+	})
+}
+
+// leadershipAcquiredReason explains why this node became active, from the
+// last change of the leadership history.
+func (c *Core) leadershipAcquiredReason(history []*LeadershipChange) string {
+	if len(history) == 0 {
+		return "acquired the HA lock"
+	}
+
+	last := history[len(history)-1]
+	switch {
+	case last.Event == leadershipReleased:
+		return fmt.Sprintf("acquired the HA lock released by %s: %s", last.APIAddress, last.Reason)
+	case last.ClusterAddress == c.ClusterAddr():
+		return "reacquired the HA lock after losing it without stepping down"
+	default:
+		return fmt.Sprintf("acquired the HA lock after %s lost it without stepping down", last.APIAddress)
+	}
+}
@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestCore_LeadershipHistory(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := context.Background()
+
+	history, err := c.LeadershipHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("expected no history, got: %#v", history)
+	}
+
+	if err := c.recordLeadershipChange(ctx, leadershipAcquired, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.recordLeadershipChange(ctx, leadershipReleased, "stepped down"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.recordLeadershipChange(ctx, leadershipAcquired, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.recordLeadershipChange(ctx, leadershipAcquired, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = c.LeadershipHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"acquired the HA lock",
+		"stepped down",
+		"acquired the HA lock released by",
+		"reacquired the HA lock after losing it without stepping down",
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("expected %d changes, got: %#v", len(expected), history)
+	}
+	for i, change := range history {
+		if !strings.HasPrefix(change.Reason, expected[i]) {
+			t.Fatalf("change %d: expected reason %q, got %q", i, expected[i], change.Reason)
+		}
+	}
+
+	// The oldest changes are dropped
+	for i := 0; i < leadershipHistorySize; i++ {
+		if err := c.recordLeadershipChange(ctx, leadershipReleased, "sealed or shut down"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err = c.LeadershipHistory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != leadershipHistorySize {
+		t.Fatalf("expected %d changes, got %d", leadershipHistorySize, len(history))
+	}
+	if history[0].Reason != "sealed or shut down" {
+		t.Fatalf("expected the oldest changes to be dropped, got: %#v", history[0])
+	}
+}
+
+func TestSystemBackend_HAStatus(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	c.recordHAPeer("https://127.0.0.2:8201", "https://127.0.0.2:8200", "1.6.0")
+	c.clusterPeerClusterAddrsCache.Set("https://127.0.0.3:8201", nil, 0)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "ha-status")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := resp.Data["nodes"].([]*HANode)
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got: %#v", nodes)
+	}
+	if !nodes[0].ActiveNode || nodes[0].LastEcho != nil {
+		t.Fatalf("bad active node: %#v", nodes[0])
+	}
+	if nodes[1].ActiveNode || nodes[1].APIAddress != "https://127.0.0.2:8200" || nodes[1].Version != "1.6.0" || nodes[1].LastEcho == nil {
+		t.Fatalf("bad standby: %#v", nodes[1])
+	}
+	if nodes[2].ClusterAddress != "https://127.0.0.3:8201" || nodes[2].LastEcho != nil {
+		t.Fatalf("bad standby: %#v", nodes[2])
+	}
+
+	if history := resp.Data["leadership_history"].([]*LeadershipChange); len(history) != 0 {
+		t.Fatalf("expected no history, got: %#v", history)
+	}
+}
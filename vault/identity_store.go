@@ -111,6 +111,7 @@ func (i *IdentityStore) paths() []*framework.Path {
 		lookupPaths(i),
 		upgradePaths(i),
 		oidcPaths(i),
+		mountConstraintsPaths(i),
 	)
 }
 
@@ -176,6 +177,9 @@ func (i *IdentityStore) Invalidate(ctx context.Context, key string) {
 			i.logger.Error("failed to load groups during invalidation", "error", err)
 			return
 		}
+	case strings.HasPrefix(key, mountConstraintsPrefix):
+		i.invalidateMountConstraints(strings.TrimPrefix(key, mountConstraintsPrefix))
+
 	// Check if the key is a storage entry key for an entity bucket
 	case strings.HasPrefix(key, storagepacker.StoragePackerBucketsPrefix):
 		// Create a MemDB transaction
@@ -542,6 +546,10 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 	}
 
 	if !update {
+		if err := i.checkMountConstraints(ctx, txn, mountValidationResp); err != nil {
+			return nil, err
+		}
+
 		entity = new(identity.Entity)
 		err = i.sanitizeEntity(ctx, entity)
 		if err != nil {
@@ -0,0 +1,284 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// mountConstraintsPrefix is the storage prefix of the entity creation
+	// constraints of auth mounts, keyed by mount accessor
+	mountConstraintsPrefix = "mount-constraints/"
+)
+
+// mountConstraints caps the entities created automatically on login through
+// an auth mount, protecting the cluster from an identity explosion caused by
+// a misconfigured auth method, e.g. machine auth deriving alias names from
+// ephemeral attributes.
+type mountConstraints struct {
+	// MaxEntities is the maximum number of entity aliases of the mount. Zero
+	// means no limit.
+	MaxEntities int `json:"max_entities"`
+
+	// MaxCreationsPerMinute is the maximum rate at which entities are created
+	// for the mount. Zero means no limit.
+	MaxCreationsPerMinute int `json:"max_creations_per_minute"`
+}
+
+// mountConstraintsEntry is the cached constraints of a mount along with the
+// limiter enforcing its creation rate. The constraints are nil if the mount
+// has none.
+type mountConstraintsEntry struct {
+	constraints *mountConstraints
+	limiter     *rate.Limiter
+}
+
+func mountConstraintsPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mount-constraints/" + framework.GenericNameRegex("mount_accessor"),
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth mount to constrain.",
+				},
+				"max_entities": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of entity aliases of the mount. Logins which would create an entity beyond it are rejected. Zero means no limit.",
+				},
+				"max_creations_per_minute": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of entities created per minute for the mount. Logins which would create an entity beyond it are rejected. Zero means no limit.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathMountConstraintsUpdate,
+				logical.ReadOperation:   i.pathMountConstraintsRead,
+				logical.DeleteOperation: i.pathMountConstraintsDelete,
+			},
+			HelpSynopsis:    strings.TrimSpace(mountConstraintsHelp["mount-constraints"][0]),
+			HelpDescription: strings.TrimSpace(mountConstraintsHelp["mount-constraints"][1]),
+		},
+		{
+			Pattern: "mount-constraints/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathMountConstraintsList,
+			},
+			HelpSynopsis:    strings.TrimSpace(mountConstraintsHelp["mount-constraints-list"][0]),
+			HelpDescription: strings.TrimSpace(mountConstraintsHelp["mount-constraints-list"][1]),
+		},
+	}
+}
+
+func (i *IdentityStore) pathMountConstraintsUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("mount_accessor").(string)
+
+	mountValidationResp := i.core.router.validateMountByAccessor(accessor)
+	if mountValidationResp == nil {
+		return logical.ErrorResponse("invalid mount accessor %q", accessor), nil
+	}
+	if mountValidationResp.MountLocal {
+		return logical.ErrorResponse("mount accessor %q is of a local mount", accessor), nil
+	}
+
+	constraints, err := i.mountConstraints(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+	if constraints == nil {
+		constraints = new(mountConstraints)
+	}
+
+	if raw, ok := d.GetOk("max_entities"); ok {
+		constraints.MaxEntities = raw.(int)
+	}
+	if raw, ok := d.GetOk("max_creations_per_minute"); ok {
+		constraints.MaxCreationsPerMinute = raw.(int)
+	}
+	if constraints.MaxEntities < 0 || constraints.MaxCreationsPerMinute < 0 {
+		return logical.ErrorResponse("max_entities and max_creations_per_minute must not be negative"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(mountConstraintsPrefix+accessor, constraints)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	i.invalidateMountConstraints(accessor)
+
+	return nil, nil
+}
+
+func (i *IdentityStore) pathMountConstraintsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("mount_accessor").(string)
+
+	constraints, err := i.mountConstraints(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+	if constraints == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"mount_accessor":           accessor,
+			"max_entities":             constraints.MaxEntities,
+			"max_creations_per_minute": constraints.MaxCreationsPerMinute,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathMountConstraintsDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("mount_accessor").(string)
+
+	if err := req.Storage.Delete(ctx, mountConstraintsPrefix+accessor); err != nil {
+		return nil, err
+	}
+
+	i.invalidateMountConstraints(accessor)
+
+	return nil, nil
+}
+
+func (i *IdentityStore) pathMountConstraintsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keys, err := req.Storage.List(ctx, mountConstraintsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(keys), nil
+}
+
+// mountConstraints returns the entity creation constraints of the mount, or
+// nil if it has none.
+func (i *IdentityStore) mountConstraints(ctx context.Context, accessor string) (*mountConstraints, error) {
+	entry, err := i.view.Get(ctx, mountConstraintsPrefix+accessor)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var constraints mountConstraints
+	if err := entry.DecodeJSON(&constraints); err != nil {
+		return nil, err
+	}
+	return &constraints, nil
+}
+
+// cachedMountConstraints returns the cached constraints of the mount, loading
+// them from storage on first use.
+func (i *IdentityStore) cachedMountConstraints(ctx context.Context, accessor string) (*mountConstraintsEntry, error) {
+	i.mountConstraintsLock.Lock()
+	defer i.mountConstraintsLock.Unlock()
+
+	if cached, ok := i.mountConstraintsCache[accessor]; ok {
+		return cached, nil
+	}
+
+	constraints, err := i.mountConstraints(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &mountConstraintsEntry{
+		constraints: constraints,
+	}
+	if constraints != nil && constraints.MaxCreationsPerMinute > 0 {
+		cached.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(constraints.MaxCreationsPerMinute)), constraints.MaxCreationsPerMinute)
+	}
+
+	if i.mountConstraintsCache == nil {
+		i.mountConstraintsCache = make(map[string]*mountConstraintsEntry)
+	}
+	i.mountConstraintsCache[accessor] = cached
+	return cached, nil
+}
+
+// invalidateMountConstraints drops the cached constraints of the mount so
+// that they are reloaded from storage.
+func (i *IdentityStore) invalidateMountConstraints(accessor string) {
+	i.mountConstraintsLock.Lock()
+	defer i.mountConstraintsLock.Unlock()
+
+	delete(i.mountConstraintsCache, accessor)
+}
+
+// checkMountConstraints returns an error if creating an entity for an alias
+// of the mount would exceed its constraints. When it does, a warning is
+// logged and the identity.entity.creation.rejected metric is emitted so that
+// operators are alerted of the misconfiguration. It must be called with the
+// identity store lock held.
+func (i *IdentityStore) checkMountConstraints(ctx context.Context, txn *memdb.Txn, mountValidationResp *validateMountResponse) error {
+	accessor := mountValidationResp.MountAccessor
+
+	cached, err := i.cachedMountConstraints(ctx, accessor)
+	if err != nil {
+		return err
+	}
+	if cached.constraints == nil {
+		return nil
+	}
+
+	reject := func(reason string, err error) error {
+		i.logger.Warn("rejected entity creation exceeding the constraints of the mount", "mount_accessor", accessor, "mount_path", mountValidationResp.MountPath, "reason", reason)
+		i.core.MetricSink().IncrCounterWithLabels(
+			[]string{"identity", "entity", "creation", "rejected"},
+			1,
+			[]metrics.Label{
+				{"auth_method", mountValidationResp.MountType},
+				{"mount_point", mountValidationResp.MountPath},
+				{"reason", reason},
+			})
+		return err
+	}
+
+	if max := cached.constraints.MaxEntities; max > 0 {
+		iter, err := txn.Get(entityAliasesTable, "factors_prefix", accessor, "")
+		if err != nil {
+			return err
+		}
+		count := 0
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			count++
+		}
+		if count >= max {
+			return reject("max_entities", logical.CodedError(http.StatusForbidden, fmt.Sprintf("mount %q has reached its limit of %d entities", mountValidationResp.MountPath, max)))
+		}
+	}
+
+	if cached.limiter != nil && !cached.limiter.Allow() {
+		return reject("max_creations_per_minute", logical.CodedError(http.StatusTooManyRequests, fmt.Sprintf("mount %q has exceeded its limit of %d entity creations per minute", mountValidationResp.MountPath, cached.constraints.MaxCreationsPerMinute)))
+	}
+
+	return nil
+}
+
+var mountConstraintsHelp = map[string][2]string{
+	"mount-constraints": {
+		"Cap the entities created on login through an auth mount.",
+		`
+Configure the maximum number of entity aliases of an auth mount and the
+maximum rate at which entities are created for it. Logins which would create
+an entity beyond these constraints are rejected, and the
+identity.entity.creation.rejected metric is emitted.
+`,
+	},
+	"mount-constraints-list": {
+		"List the accessors of the auth mounts with entity creation constraints.",
+		"",
+	},
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestIdentityStore_MountConstraints(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	is, ghAccessor, _ := testIdentityStoreWithGithubAuth(ctx, t)
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "mount-constraints/" + ghAccessor,
+		Data: map[string]interface{}{
+			"max_entities": 2,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "mount-constraints/" + ghAccessor,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["max_entities"] != 2 || resp.Data["max_creations_per_minute"] != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	createEntity := func(name string) error {
+		_, err := is.CreateOrFetchEntity(ctx, &logical.Alias{
+			MountType:     "github",
+			MountAccessor: ghAccessor,
+			Name:          name,
+		})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := createEntity(fmt.Sprintf("githubuser%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Existing entities are still fetched once the limit is reached
+	if err := createEntity("githubuser0"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = createEntity("githubuser2")
+	if coded, ok := err.(logical.HTTPCodedError); !ok || coded.Code() != http.StatusForbidden {
+		t.Fatalf("expected max entities error, got %v", err)
+	}
+
+	// The rate is capped as well
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "mount-constraints/" + ghAccessor,
+		Data: map[string]interface{}{
+			"max_entities":             0,
+			"max_creations_per_minute": 1,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	if err := createEntity("githubuser2"); err != nil {
+		t.Fatal(err)
+	}
+	err = createEntity("githubuser3")
+	if coded, ok := err.(logical.HTTPCodedError); !ok || coded.Code() != http.StatusTooManyRequests {
+		t.Fatalf("expected rate error, got %v", err)
+	}
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "mount-constraints/",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != ghAccessor {
+		t.Fatalf("bad: %#v", keys)
+	}
+
+	// Removing the constraints lifts them
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "mount-constraints/" + ghAccessor,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if err := createEntity("githubuser3"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "mount-constraints/invalid",
+		Data: map[string]interface{}{
+			"max_entities": 1,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got err:%v resp:%#v", err, resp)
+	}
+}
@@ -80,6 +80,12 @@ type IdentityStore struct {
 	// disableLowerCaseNames indicates whether or not identity artifacts are
 	// operated case insensitively
 	disableLowerCasedNames bool
+
+	// mountConstraintsCache holds the entity creation constraints of auth
+	// mounts, keyed by mount accessor, and is protected by
+	// mountConstraintsLock
+	mountConstraintsLock  sync.Mutex
+	mountConstraintsCache map[string]*mountConstraintsEntry
 }
 
 type groupDiff struct {
@@ -0,0 +1,100 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// InFlightRequest describes a request being handled by this node.
+type InFlightRequest struct {
+	ID               string
+	StartTime        time.Time
+	Path             string
+	Operation        logical.Operation
+	ClientRemoteAddr string
+
+	cancel context.CancelFunc
+}
+
+// trackInFlightRequest records the request as in flight until the returned
+// function is called. The request can be canceled through cancel in the
+// meantime.
+func (c *Core) trackInFlightRequest(ns *namespace.Namespace, req *logical.Request, cancel context.CancelFunc) func() {
+	id := req.ID
+	if id == "" {
+		// Requests which didn't come through the HTTP layer may not have an
+		// ID, but must still be told apart
+		var err error
+		if id, err = uuid.GenerateUUID(); err != nil {
+			return func() {}
+		}
+	}
+
+	ifr := &InFlightRequest{
+		ID:        id,
+		StartTime: time.Now(),
+		Path:      ns.Path + sanitizeInFlightPath(req.Path),
+		Operation: req.Operation,
+		cancel:    cancel,
+	}
+	if req.Connection != nil {
+		ifr.ClientRemoteAddr = req.Connection.RemoteAddr
+	}
+
+	c.inFlightRequests.Store(id, ifr)
+	return func() {
+		c.inFlightRequests.Delete(id)
+	}
+}
+
+// InFlightRequests returns the requests being handled by this node.
+func (c *Core) InFlightRequests() []*InFlightRequest {
+	var reqs []*InFlightRequest
+	c.inFlightRequests.Range(func(_, v interface{}) bool {
+		reqs = append(reqs, v.(*InFlightRequest))
+		return true
+	})
+	return reqs
+}
+
+// CancelInFlightRequest cancels the context of the in-flight request with the
+// given ID, returning false if there is no such request.
+func (c *Core) CancelInFlightRequest(id string) bool {
+	v, ok := c.inFlightRequests.Load(id)
+	if !ok {
+		return false
+	}
+	v.(*InFlightRequest).cancel()
+	return true
+}
+
+// tokenPathOperations are the token store operations which accept a token or
+// accessor as the last segment of their path.
+var tokenPathOperations = map[string]bool{
+	"lookup":          true,
+	"lookup-accessor": true,
+	"revoke":          true,
+	"revoke-accessor": true,
+	"revoke-orphan":   true,
+	"renew":           true,
+	"renew-accessor":  true,
+}
+
+// sanitizeInFlightPath redacts the tokens and accessors which can be given in
+// the path of token store requests, so that listing in-flight requests doesn't
+// disclose them.
+func sanitizeInFlightPath(path string) string {
+	if !strings.HasPrefix(path, "auth/token/") {
+		return path
+	}
+	parts := strings.SplitN(path, "/", 4)
+	if len(parts) < 4 || parts[3] == "" || !tokenPathOperations[parts[2]] {
+		return path
+	}
+	return strings.Join(parts[:3], "/") + "/<redacted>"
+}
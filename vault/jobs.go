@@ -0,0 +1,264 @@
+package vault
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+
+	// jobRetention is how long finished jobs are kept for their status to be
+	// read.
+	jobRetention = 24 * time.Hour
+)
+
+// jobFunc runs a job, reporting its progress and its result through progress.
+// It must return once ctx is done.
+type jobFunc func(ctx context.Context, progress *jobProgress) error
+
+// jobProgress is the progress and the result of a job, which are updated by
+// the job while it runs. A nil jobProgress discards them, so that operations
+// can run with or without a job.
+type jobProgress struct {
+	completed int64
+	total     int64
+
+	lock   sync.Mutex
+	result map[string]interface{}
+}
+
+// SetTotal sets the number of items the job has to process, if known.
+func (p *jobProgress) SetTotal(total int64) {
+	if p == nil {
+		return
+	}
+	atomic.StoreInt64(&p.total, total)
+}
+
+// AddTotal adds items for the job to process.
+func (p *jobProgress) AddTotal(n int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.total, n)
+}
+
+// Complete records that the job processed n more items.
+func (p *jobProgress) Complete(n int64) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.completed, n)
+}
+
+// SetResult sets a value of the result of the job.
+func (p *jobProgress) SetResult(key string, value interface{}) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.result == nil {
+		p.result = make(map[string]interface{})
+	}
+	p.result[key] = value
+}
+
+// Job is a long-running operation started by a request, whose status is read
+// through sys/jobs instead of holding the request until it finishes.
+type Job struct {
+	ID          string
+	Type        string
+	NamespaceID string
+	StartTime   time.Time
+
+	progress jobProgress
+	cancel   context.CancelFunc
+
+	// The following fields are protected by lock
+	lock      sync.RWMutex
+	status    string
+	endTime   time.Time
+	err       error
+	canceling bool
+}
+
+// JobInfo is a snapshot of the status of a job.
+type JobInfo struct {
+	ID        string
+	Type      string
+	Status    string
+	StartTime time.Time
+	EndTime   time.Time
+	Completed int64
+	Total     int64
+	Error     string
+	Result    map[string]interface{}
+}
+
+// Info returns the current status of the job.
+func (j *Job) Info() *JobInfo {
+	j.lock.RLock()
+	info := &JobInfo{
+		ID:        j.ID,
+		Type:      j.Type,
+		Status:    j.status,
+		StartTime: j.StartTime,
+		EndTime:   j.endTime,
+		Completed: atomic.LoadInt64(&j.progress.completed),
+		Total:     atomic.LoadInt64(&j.progress.total),
+	}
+	if j.err != nil {
+		info.Error = j.err.Error()
+	}
+	j.lock.RUnlock()
+
+	j.progress.lock.Lock()
+	if len(j.progress.result) > 0 {
+		info.Result = make(map[string]interface{}, len(j.progress.result))
+		for k, v := range j.progress.result {
+			info.Result[k] = v
+		}
+	}
+	j.progress.lock.Unlock()
+
+	return info
+}
+
+func (j *Job) finish(err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.endTime = time.Now()
+	j.err = err
+	switch {
+	case err == nil:
+		j.status = JobStatusSucceeded
+	case j.canceling:
+		j.status = JobStatusCanceled
+	default:
+		j.status = JobStatusFailed
+	}
+}
+
+// jobManager runs the jobs of this node and keeps them for a while once they
+// finish. Jobs are not persisted, and are canceled when the node seals or
+// steps down since they run on the active context.
+type jobManager struct {
+	logger log.Logger
+
+	lock sync.RWMutex
+	jobs map[string]*Job
+}
+
+func newJobManager(logger log.Logger) *jobManager {
+	return &jobManager{
+		logger: logger,
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// start runs f in the background as a job of the given type, in the namespace
+// of ctx. The job is canceled when parent is done.
+func (m *jobManager) start(ctx, parent context.Context, jobType string, f jobFunc) (*Job, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx, cancel := context.WithCancel(namespace.ContextWithNamespace(parent, ns))
+	job := &Job{
+		ID:          id,
+		Type:        jobType,
+		NamespaceID: ns.ID,
+		StartTime:   time.Now(),
+		cancel:      cancel,
+		status:      JobStatusRunning,
+	}
+
+	m.lock.Lock()
+	m.pruneLocked()
+	m.jobs[id] = job
+	m.lock.Unlock()
+
+	m.logger.Info("starting job", "job_id", id, "type", jobType)
+	go func() {
+		defer cancel()
+		err := f(jobCtx, &job.progress)
+		job.finish(err)
+
+		if err != nil {
+			m.logger.Error("job failed", "job_id", id, "type", jobType, "error", err)
+			return
+		}
+		m.logger.Info("job finished", "job_id", id, "type", jobType)
+	}()
+
+	return job, nil
+}
+
+// pruneLocked removes the jobs which finished more than jobRetention ago. The
+// lock must be held for writing.
+func (m *jobManager) pruneLocked() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range m.jobs {
+		if info := job.Info(); info.Status != JobStatusRunning && info.EndTime.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// job returns the job with the given ID in the given namespace, or nil.
+func (m *jobManager) job(ns *namespace.Namespace, id string) *Job {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	job, ok := m.jobs[id]
+	if !ok || job.NamespaceID != ns.ID {
+		return nil
+	}
+	return job
+}
+
+// list returns the jobs of the given namespace.
+func (m *jobManager) list(ns *namespace.Namespace) []*Job {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pruneLocked()
+
+	var jobs []*Job
+	for _, job := range m.jobs {
+		if job.NamespaceID == ns.ID {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// cancel cancels the job, returning false if it already finished.
+func (m *jobManager) cancel(job *Job) bool {
+	job.lock.Lock()
+	if job.status != JobStatusRunning {
+		job.lock.Unlock()
+		return false
+	}
+	job.canceling = true
+	job.lock.Unlock()
+
+	m.logger.Warn("canceling job", "job_id", job.ID, "type", job.Type)
+	job.cancel()
+	return true
+}
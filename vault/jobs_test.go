@@ -0,0 +1,205 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func waitForJob(t *testing.T, job *Job) *JobInfo {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if info := job.Info(); info.Status != JobStatusRunning {
+			return info
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %q did not finish", job.ID)
+	return nil
+}
+
+func TestJobManager(t *testing.T) {
+	m := newJobManager(log.NewNullLogger())
+	ctx := namespace.RootContext(nil)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	job, err := m.start(ctx, context.Background(), "test", func(ctx context.Context, progress *jobProgress) error {
+		progress.SetTotal(2)
+		progress.Complete(1)
+		close(started)
+		<-release
+		progress.Complete(1)
+		progress.SetResult("count", 2)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if info := job.Info(); info.Status != JobStatusRunning || info.Completed != 1 || info.Total != 2 || !info.EndTime.IsZero() {
+		t.Fatalf("bad: %#v", info)
+	}
+	if jobs := m.list(namespace.RootNamespace); len(jobs) != 1 || jobs[0] != job {
+		t.Fatalf("bad: %#v", jobs)
+	}
+
+	// Jobs are only visible in their namespace
+	other := &namespace.Namespace{ID: "other", Path: "other/"}
+	if m.job(other, job.ID) != nil || len(m.list(other)) != 0 {
+		t.Fatal("expected the job not to be visible in another namespace")
+	}
+
+	close(release)
+	info := waitForJob(t, job)
+	if info.Status != JobStatusSucceeded || info.Completed != 2 || info.Result["count"] != 2 || info.Error != "" || info.EndTime.IsZero() {
+		t.Fatalf("bad: %#v", info)
+	}
+	if m.cancel(job) {
+		t.Fatal("expected a finished job not to be canceled")
+	}
+
+	// A failing job
+	job, err = m.start(ctx, context.Background(), "test", func(ctx context.Context, progress *jobProgress) error {
+		return errors.New("failure")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := waitForJob(t, job); info.Status != JobStatusFailed || info.Error != "failure" {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// A canceled job
+	job, err = m.start(ctx, context.Background(), "test", func(ctx context.Context, progress *jobProgress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.cancel(job) {
+		t.Fatal("expected the job to be canceled")
+	}
+	if info := waitForJob(t, job); info.Status != JobStatusCanceled {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// A job whose parent context is done fails rather than being canceled
+	parent, cancel := context.WithCancel(context.Background())
+	job, err = m.start(ctx, parent, "test", func(ctx context.Context, progress *jobProgress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if info := waitForJob(t, job); info.Status != JobStatusFailed {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// Finished jobs are removed once their retention is over
+	job.lock.Lock()
+	job.endTime = time.Now().Add(-jobRetention - time.Minute)
+	job.lock.Unlock()
+	m.list(namespace.RootNamespace)
+	if m.job(namespace.RootNamespace, job.ID) != nil {
+		t.Fatal("expected the job to be removed")
+	}
+}
+
+func TestSystemBackend_Jobs_revokePrefix(t *testing.T) {
+	core, b, root := testCoreSystemBackend(t)
+
+	// Create a key with a lease
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/foo")
+	req.Data["foo"] = "bar"
+	req.Data["lease"] = "1h"
+	req.ClientToken = root
+	if _, err := core.HandleRequest(namespace.RootContext(nil), req); err != nil {
+		t.Fatal(err)
+	}
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/foo")
+	req.ClientToken = root
+	resp, err := core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	leaseID := resp.Secret.LeaseID
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/revoke-prefix/secret/")
+	req.Data["async"] = true
+	req.Data["sync"] = false
+	if resp, err := b.HandleRequest(namespace.RootContext(nil), req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an invalid request, got %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "leases/revoke-prefix/secret/")
+	req.Data["async"] = true
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data[logical.HTTPStatusCode] != 202 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(resp.Data[logical.HTTPRawBody].(string)), &body); err != nil {
+		t.Fatal(err)
+	}
+	jobID, _ := body.Data["job_id"].(string)
+	if jobID == "" {
+		t.Fatalf("missing job ID: %#v", body)
+	}
+
+	job := core.jobs.job(namespace.RootNamespace, jobID)
+	if job == nil {
+		t.Fatal("job not found")
+	}
+	waitForJob(t, job)
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), logical.TestRequest(t, logical.ReadOperation, "jobs/"+jobID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["type"] != "revoke-prefix" || resp.Data["status"] != JobStatusSucceeded || resp.Data["completed"] != int64(1) || resp.Data["total"] != int64(1) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if result := resp.Data["result"].(map[string]interface{}); result["leases_revoked"] != 1 {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), logical.TestRequest(t, logical.ListOperation, "jobs/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != jobID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), logical.TestRequest(t, logical.UpdateOperation, "jobs/"+jobID+"/cancel"))
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected a finished job not to be canceled, got %v %#v", err, resp)
+	}
+
+	le, err := core.expiration.loadEntry(namespace.RootContext(nil), leaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if le != nil {
+		t.Fatalf("expected the lease to be revoked, got %#v", le)
+	}
+}
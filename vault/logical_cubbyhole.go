@@ -5,16 +5,52 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
+// cubbyholeEntryCanary prefixes the storage entries holding an expiring
+// secret, which are stored along with their expiration time. The other entries
+// only hold the JSON-encoded secret.
+const cubbyholeEntryCanary byte = 'E'
+
+// cubbyholeSizePrefix is where the size of the secrets stored in the cubbyhole
+// of each token is kept, so that writes can be checked against the quota
+// without reading the whole cubbyhole. It does not collide with the
+// cubbyholes, which are keyed by salted token or cubbyhole ID.
+const cubbyholeSizePrefix = "_size/"
+
+// cubbyholeSize is the storage entry of the size of a cubbyhole
+type cubbyholeSize struct {
+	Size int64 `json:"size"`
+}
+
+// ctxKeyResponseWrapping marks the requests storing a response-wrapped
+// response in the cubbyhole of its wrapping token
+type ctxKeyResponseWrapping struct{}
+
+func (c ctxKeyResponseWrapping) String() string {
+	return "response-wrapping"
+}
+
+// cubbyholeEntry is the storage entry of an expiring secret
+type cubbyholeEntry struct {
+	Data       json.RawMessage `json:"data"`
+	ExpireTime time.Time       `json:"expire_time"`
+}
+
 // CubbyholeBackendFactory constructs a new cubbyhole backend
 func CubbyholeBackendFactory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
-	b := &CubbyholeBackend{}
+	b := &CubbyholeBackend{
+		locks: locksutil.CreateLocks(),
+	}
 	b.Backend = &framework.Backend{
 		Help: strings.TrimSpace(cubbyholeHelp),
 	}
@@ -38,6 +74,14 @@ type CubbyholeBackend struct {
 
 	saltUUID    string
 	storageView logical.Storage
+
+	// maxSize is the maximum size in bytes of the secrets stored in the
+	// cubbyhole of a token, or zero if unlimited
+	maxSize int64
+
+	// locks serialize the writes to the cubbyhole of a token, so that its
+	// stored size stays accurate
+	locks []*locksutil.LockEntry
 }
 
 func (b *CubbyholeBackend) paths() []*framework.Path {
@@ -92,11 +136,164 @@ func (b *CubbyholeBackend) revoke(ctx context.Context, saltedToken string) error
 		return err
 	}
 
-	return nil
+	return b.storageView.Delete(ctx, cubbyholeSizePrefix+saltedToken)
+}
+
+// setMaxSize sets the maximum size in bytes of the secrets stored in the
+// cubbyhole of a token. Zero disables the quota.
+func (b *CubbyholeBackend) setMaxSize(maxSize int64) {
+	atomic.StoreInt64(&b.maxSize, maxSize)
+}
+
+// decodeCubbyholeEntry returns the secret held by the storage entry, and
+// whether it has expired
+func decodeCubbyholeEntry(out *logical.StorageEntry) (json.RawMessage, bool, error) {
+	if len(out.Value) == 0 || out.Value[0] != cubbyholeEntryCanary {
+		return out.Value, false, nil
+	}
+
+	var entry cubbyholeEntry
+	if err := jsonutil.DecodeJSON(out.Value[1:], &entry); err != nil {
+		return nil, false, errwrap.Wrapf("json decoding failed: {{err}}", err)
+	}
+	return entry.Data, !entry.ExpireTime.IsZero() && time.Now().After(entry.ExpireTime), nil
+}
+
+// get returns the decoded secret stored at the path of the cubbyhole of the
+// token, or nil if there is none or it has expired. Expired secrets are
+// deleted.
+func (b *CubbyholeBackend) get(ctx context.Context, s logical.Storage, clientToken, path string) (*logical.StorageEntry, json.RawMessage, error) {
+	out, err := s.Get(ctx, clientToken+"/"+path)
+	if err != nil || out == nil {
+		return nil, nil, err
+	}
+
+	value, expired, err := decodeCubbyholeEntry(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !expired {
+		return out, value, nil
+	}
+
+	lock := locksutil.LockForKey(b.locks, clientToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.deleteExpired(ctx, s, clientToken, path); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, nil
+}
+
+// deleteExpired deletes the secret at the path of the cubbyhole of the token
+// if it has expired, which it may not have anymore if it was overwritten
+// meanwhile. The caller must hold the lock of the token.
+func (b *CubbyholeBackend) deleteExpired(ctx context.Context, s logical.Storage, clientToken, path string) error {
+	out, err := s.Get(ctx, clientToken+"/"+path)
+	if err != nil || out == nil {
+		return err
+	}
+	if _, expired, err := decodeCubbyholeEntry(out); err != nil || !expired {
+		return err
+	}
+
+	if err := s.Delete(ctx, clientToken+"/"+path); err != nil {
+		return errwrap.Wrapf("failed to delete expired secret: {{err}}", err)
+	}
+	return b.adjustSize(ctx, s, clientToken, -int64(len(out.Value)))
+}
+
+// size returns the stored size in bytes of the secrets in the cubbyhole of the
+// token, which is computed if it is not stored yet. The caller must hold the
+// lock of the token.
+func (b *CubbyholeBackend) size(ctx context.Context, s logical.Storage, clientToken string) (int64, error) {
+	out, err := s.Get(ctx, cubbyholeSizePrefix+clientToken)
+	if err != nil {
+		return 0, err
+	}
+	if out == nil {
+		return b.computeSize(ctx, s, clientToken)
+	}
+
+	var size cubbyholeSize
+	if err := jsonutil.DecodeJSON(out.Value, &size); err != nil {
+		return 0, errwrap.Wrapf("json decoding failed: {{err}}", err)
+	}
+	return size.Size, nil
+}
+
+// computeSize scans the cubbyhole of the token, deleting the expired secrets,
+// and stores its size. The caller must hold the lock of the token.
+func (b *CubbyholeBackend) computeSize(ctx context.Context, s logical.Storage, clientToken string) (int64, error) {
+	view := logical.NewStorageView(s, clientToken+"/")
+
+	var keys []string
+	if err := logical.ScanView(ctx, view, func(path string) {
+		keys = append(keys, path)
+	}); err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, key := range keys {
+		out, err := view.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if out == nil {
+			continue
+		}
+		_, expired, err := decodeCubbyholeEntry(out)
+		if err != nil {
+			return 0, err
+		}
+		if expired {
+			if err := view.Delete(ctx, key); err != nil {
+				return 0, errwrap.Wrapf("failed to delete expired secret: {{err}}", err)
+			}
+			continue
+		}
+		size += int64(len(out.Value))
+	}
+
+	if err := b.putSize(ctx, s, clientToken, size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// adjustSize adds delta to the stored size of the cubbyhole of the token,
+// once the change it accounts for was written. The caller must hold the lock
+// of the token.
+func (b *CubbyholeBackend) adjustSize(ctx context.Context, s logical.Storage, clientToken string, delta int64) error {
+	out, err := s.Get(ctx, cubbyholeSizePrefix+clientToken)
+	if err != nil {
+		return err
+	}
+	// The size computed from the cubbyhole already accounts for the change
+	if out == nil {
+		_, err := b.computeSize(ctx, s, clientToken)
+		return err
+	}
+
+	var size cubbyholeSize
+	if err := jsonutil.DecodeJSON(out.Value, &size); err != nil {
+		return errwrap.Wrapf("json decoding failed: {{err}}", err)
+	}
+	return b.putSize(ctx, s, clientToken, size.Size+delta)
+}
+
+func (b *CubbyholeBackend) putSize(ctx context.Context, s logical.Storage, clientToken string, size int64) error {
+	entry, err := logical.StorageEntryJSON(cubbyholeSizePrefix+clientToken, &cubbyholeSize{Size: size})
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
 }
 
 func (b *CubbyholeBackend) handleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
-	out, err := req.Storage.Get(ctx, req.ClientToken+"/"+req.Path)
+	out, _, err := b.get(ctx, req.Storage, req.ClientToken, req.Path)
 	if err != nil {
 		return false, errwrap.Wrapf("existence check failed: {{err}}", err)
 	}
@@ -116,7 +313,7 @@ func (b *CubbyholeBackend) handleRead(ctx context.Context, req *logical.Request,
 	}
 
 	// Read the path
-	out, err := req.Storage.Get(ctx, req.ClientToken+"/"+path)
+	out, value, err := b.get(ctx, req.Storage, req.ClientToken, path)
 	if err != nil {
 		return nil, errwrap.Wrapf("read failed: {{err}}", err)
 	}
@@ -128,7 +325,7 @@ func (b *CubbyholeBackend) handleRead(ctx context.Context, req *logical.Request,
 
 	// Decode the data
 	var rawData map[string]interface{}
-	if err := jsonutil.DecodeJSON(out.Value, &rawData); err != nil {
+	if err := jsonutil.DecodeJSON(value, &rawData); err != nil {
 		return nil, errwrap.Wrapf("json decoding failed: {{err}}", err)
 	}
 
@@ -161,6 +358,34 @@ func (b *CubbyholeBackend) handleWrite(ctx context.Context, req *logical.Request
 		return nil, errwrap.Wrapf("json encoding failed: {{err}}", err)
 	}
 
+	// Like in the kv backend, a ttl in the data sets the lifetime of the
+	// secret, after which it is deleted
+	var resp *logical.Response
+	if ttlRaw, ok := req.Data["ttl"]; ok {
+		ttl, err := parseutil.ParseDurationSecond(ttlRaw)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid ttl: %s", err)), logical.ErrInvalidRequest
+		}
+		if ttl < 0 {
+			return logical.ErrorResponse("ttl cannot be negative"), logical.ErrInvalidRequest
+		}
+		if maxTTL := b.System().MaxLeaseTTL(); maxTTL > 0 && ttl > maxTTL {
+			resp = &logical.Response{}
+			resp.AddWarning(fmt.Sprintf("ttl of %s is greater than the max TTL of %s, capping", ttl, maxTTL))
+			ttl = maxTTL
+		}
+		if ttl > 0 {
+			buf, err = json.Marshal(&cubbyholeEntry{
+				Data:       buf,
+				ExpireTime: time.Now().Add(ttl),
+			})
+			if err != nil {
+				return nil, errwrap.Wrapf("json encoding failed: {{err}}", err)
+			}
+			buf = append([]byte{cubbyholeEntryCanary}, buf...)
+		}
+	}
+
 	// Write out a new key
 	entry := &logical.StorageEntry{
 		Key:   req.ClientToken + "/" + path,
@@ -169,11 +394,62 @@ func (b *CubbyholeBackend) handleWrite(ctx context.Context, req *logical.Request
 	if req.WrapInfo != nil && req.WrapInfo.SealWrap {
 		entry.SealWrap = true
 	}
+
+	// Response-wrapped responses are stored by Vault itself in the cubbyhole
+	// of their wrapping token, and are neither subject to the quota nor
+	// accounted for, as nothing else is ever written there
+	if wrapping, _ := ctx.Value(ctxKeyResponseWrapping{}).(bool); wrapping {
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, errwrap.Wrapf("failed to write: {{err}}", err)
+		}
+		return resp, nil
+	}
+
+	lock := locksutil.LockForKey(b.locks, req.ClientToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	var oldSize int64
+	old, err := req.Storage.Get(ctx, entry.Key)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		oldSize = int64(len(old.Value))
+	}
+
+	if maxSize := atomic.LoadInt64(&b.maxSize); maxSize > 0 {
+		size, err := b.size(ctx, req.Storage, req.ClientToken)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to read the cubbyhole size: {{err}}", err)
+		}
+		// Expired secrets are accounted for until they are deleted, which
+		// is done before rejecting the write
+		if size-oldSize+int64(len(buf)) > maxSize {
+			if size, err = b.computeSize(ctx, req.Storage, req.ClientToken); err != nil {
+				return nil, errwrap.Wrapf("failed to compute the cubbyhole size: {{err}}", err)
+			}
+			if old, err = req.Storage.Get(ctx, entry.Key); err != nil {
+				return nil, err
+			}
+			oldSize = 0
+			if old != nil {
+				oldSize = int64(len(old.Value))
+			}
+			if size-oldSize+int64(len(buf)) > maxSize {
+				return logical.ErrorResponse(fmt.Sprintf("cubbyhole size quota of %d bytes exceeded", maxSize)), logical.ErrInvalidRequest
+			}
+		}
+	}
+
 	if err := req.Storage.Put(ctx, entry); err != nil {
 		return nil, errwrap.Wrapf("failed to write: {{err}}", err)
 	}
+	if err := b.adjustSize(ctx, req.Storage, req.ClientToken, int64(len(buf))-oldSize); err != nil {
+		return nil, errwrap.Wrapf("failed to update the cubbyhole size: {{err}}", err)
+	}
 
-	return nil, nil
+	return resp, nil
 }
 
 func (b *CubbyholeBackend) handleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -183,10 +459,22 @@ func (b *CubbyholeBackend) handleDelete(ctx context.Context, req *logical.Reques
 
 	path := data.Get("path").(string)
 
+	lock := locksutil.LockForKey(b.locks, req.ClientToken)
+	lock.Lock()
+	defer lock.Unlock()
+
+	out, err := req.Storage.Get(ctx, req.ClientToken+"/"+path)
+	if err != nil || out == nil {
+		return nil, err
+	}
+
 	// Delete the key at the request path
 	if err := req.Storage.Delete(ctx, req.ClientToken+"/"+path); err != nil {
 		return nil, err
 	}
+	if err := b.adjustSize(ctx, req.Storage, req.ClientToken, -int64(len(out.Value))); err != nil {
+		return nil, errwrap.Wrapf("failed to update the cubbyhole size: {{err}}", err)
+	}
 
 	return nil, nil
 }
@@ -210,10 +498,19 @@ func (b *CubbyholeBackend) handleList(ctx context.Context, req *logical.Request,
 		return nil, err
 	}
 
-	// Strip the token
-	strippedKeys := make([]string, len(keys))
-	for i, key := range keys {
-		strippedKeys[i] = strings.TrimPrefix(key, req.ClientToken+"/")
+	// Strip the token, and skip the expired secrets
+	strippedKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/") {
+			out, _, err := b.get(ctx, req.Storage, req.ClientToken, path+key)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil {
+				continue
+			}
+		}
+		strippedKeys = append(strippedKeys, strings.TrimPrefix(key, req.ClientToken+"/"))
 	}
 
 	// Generate the response
@@ -245,4 +542,7 @@ encrypting it along the way.
 
 The view into the cubbyhole storage space is different for each token; it is
 a per-token cubbyhole. When the token is revoked all values are removed.
+
+If the data contains a "ttl" value, the secret is removed once the TTL has
+elapsed.
 `
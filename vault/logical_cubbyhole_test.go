@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -265,3 +266,143 @@ func testCubbyholeBackend() logical.Backend {
 	})
 	return b
 }
+
+func TestCubbyholeBackend_TTL(t *testing.T) {
+	b := testCubbyholeBackend()
+	req := logical.TestRequest(t, logical.UpdateOperation, "foo")
+	storage := req.Storage
+	clientToken, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.ClientToken = clientToken
+	req.Data["raw"] = "test"
+	req.Data["ttl"] = "1s"
+
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "foo")
+	req.Storage = storage
+	req.ClientToken = clientToken
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &logical.Response{
+		Data: map[string]interface{}{
+			"raw": "test",
+			"ttl": "1s",
+		},
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Fatalf("bad response.\n\nexpected: %#v\n\nGot: %#v", expected, resp)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	// The secret is neither listed nor readable once expired
+	req = logical.TestRequest(t, logical.ListOperation, "")
+	req.Storage = storage
+	req.ClientToken = clientToken
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := resp.Data["keys"]; keys != nil && len(keys.([]string)) != 0 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "foo")
+	req.Storage = storage
+	req.ClientToken = clientToken
+	resp, err = b.HandleRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	// The expired secret is deleted from storage
+	keys, err := storage.List(context.Background(), clientToken+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestCubbyholeBackend_MaxSize(t *testing.T) {
+	b := testCubbyholeBackend()
+	b.(*CubbyholeBackend).setMaxSize(64)
+
+	storage := &logical.InmemStorage{}
+	clientToken, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, value string) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Storage = storage
+		req.ClientToken = clientToken
+		req.Data["raw"] = value
+		return b.HandleRequest(context.Background(), req)
+	}
+
+	// {"raw":"..."} takes 10 bytes on top of the value
+	if _, err := write("foo", strings.Repeat("a", 30)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := write("bar", strings.Repeat("a", 30)); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got: %v", err)
+	}
+
+	// Overwriting a secret only accounts for the new value
+	if _, err := write("foo", strings.Repeat("a", 50)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The size is stored rather than computed on each write
+	size, err := b.(*CubbyholeBackend).size(context.Background(), storage, clientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 60 {
+		t.Fatalf("bad size: %d", size)
+	}
+	if keys, _ := storage.List(context.Background(), cubbyholeSizePrefix); !reflect.DeepEqual(keys, []string{clientToken}) {
+		t.Fatalf("bad size keys: %v", keys)
+	}
+
+	// Deleting a secret frees its share of the quota
+	req := logical.TestRequest(t, logical.DeleteOperation, "foo")
+	req.Storage = storage
+	req.ClientToken = clientToken
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := write("bar", strings.Repeat("a", 30)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Response-wrapped responses are not subject to the quota
+	req = logical.TestRequest(t, logical.CreateOperation, "response")
+	req.Storage = storage
+	req.ClientToken = clientToken
+	req.Data["response"] = strings.Repeat("a", 100)
+	if _, err := b.HandleRequest(context.WithValue(context.Background(), ctxKeyResponseWrapping{}, true), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Other tokens have their own quota
+	clientToken, err = uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := write("bar", strings.Repeat("a", 30)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
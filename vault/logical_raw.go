@@ -18,6 +18,10 @@ var (
 		keyringPath,
 		// Changing the cluster info path can change the cluster ID which can be disruptive
 		coreLocalClusterInfoPath,
+		// The state of rekey operations records the key holders taking part
+		// in them, and must not be forged
+		coreBarrierRekeyProgressPath,
+		coreRecoveryRekeyProgressPath,
 	}
 )
 
@@ -82,8 +82,10 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 			Root: []string{
 				"auth/*",
 				"remount",
+				"migrate-state",
 				"audit",
 				"audit/*",
+				"audit-salt/*",
 				"raw",
 				"raw/*",
 				"replication/primary/secondary-token",
@@ -103,6 +105,10 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"leases/revoke-force/*",
 				"leases/lookup/*",
 				"storage/raft/snapshot-auto/config/*",
+				"in-flight-req/*",
+				"jobs/*",
+				"events/webhooks/*",
+				"events/subscribe/*",
 			},
 
 			Unauthenticated: []string{
@@ -164,11 +170,18 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.internalPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.pprofPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.remountPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.migrateStatePath())
 	b.Backend.Paths = append(b.Backend.Paths, b.metricsPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.monitorPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.haStatusPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.eventWebhookPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.eventSubscribePath())
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.trashPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.jobPaths()...)
 
 	if core.rawEnabled {
 		b.Backend.Paths = append(b.Backend.Paths, b.rawPaths()...)
@@ -261,23 +274,26 @@ func (b *SystemBackend) handleCORSDelete(ctx context.Context, req *logical.Reque
 }
 
 func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	ns, err := namespace.FromContext(ctx)
-	if err != nil {
-		return nil, err
-	}
+	job, err := b.Core.jobs.start(ctx, b.Core.activeContext, "lease-tidy", func(tidyCtx context.Context, progress *jobProgress) error {
+		if err := b.Core.waitForMaintenanceWindow(tidyCtx, b.Backend.Logger(), "lease tidy"); err != nil {
+			b.Backend.Logger().Error("lease tidy not started", "error", err)
+			return err
+		}
 
-	go func() {
-		tidyCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
-		err := b.Core.expiration.Tidy(tidyCtx)
+		err := b.Core.expiration.Tidy(tidyCtx, progress)
 		if err != nil {
 			b.Backend.Logger().Error("failed to tidy leases", "error", err)
-			return
+			return err
 		}
-	}()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+	b.Core.addMaintenanceWindowWarning(resp, "lease tidy")
+	return jobAcceptedResponse(req, resp, job)
 }
 
 func (b *SystemBackend) handlePluginCatalogTypedList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -494,12 +510,20 @@ func (b *SystemBackend) handlePluginReloadUpdate(ctx context.Context, req *logic
 func (b *SystemBackend) handleAuditedHeaderUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	header := d.Get("header").(string)
 	hmac := d.Get("hmac").(bool)
+	passthrough := d.Get("passthrough").(bool)
 	if header == "" {
 		return logical.ErrorResponse("missing header name"), nil
 	}
+	if passthrough {
+		for _, denied := range deniedPassthroughRequestHeaders {
+			if strings.EqualFold(header, denied) {
+				return logical.ErrorResponse(fmt.Sprintf("header %q cannot be passed through to backends", header)), nil
+			}
+		}
+	}
 
 	headerConfig := b.Core.AuditedHeadersConfig()
-	err := headerConfig.add(ctx, header, hmac)
+	err := headerConfig.add(ctx, header, hmac, passthrough)
 	if err != nil {
 		return nil, err
 	}
@@ -1004,6 +1028,8 @@ func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request,
 		return handleError(err)
 	}
 
+	resp := b.trashMountEntry(ctx, entry)
+
 	// Get the view path if available
 	var viewPath string
 	if entry != nil {
@@ -1016,7 +1042,7 @@ func (b *SystemBackend) handleUnmount(ctx context.Context, req *logical.Request,
 		return handleError(err)
 	}
 
-	return nil, nil
+	return resp, nil
 }
 
 func validateMountPath(p string) error {
@@ -1576,6 +1602,262 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 	return resp, nil
 }
 
+// kvMountEntry returns the entry of the KV mount at path.
+func (b *SystemBackend) kvMountEntry(ctx context.Context, path string) (*MountEntry, *logical.Response) {
+	mountEntry := b.Core.router.MatchingMountEntry(ctx, path)
+	if mountEntry == nil || mountEntry.Path != path {
+		return nil, logical.ErrorResponse("no mount at %q", path)
+	}
+	if mountEntry.Type != "kv" {
+		return nil, logical.ErrorResponse("mount at %q is not a KV secrets engine", path)
+	}
+	return mountEntry, nil
+}
+
+// kvUpgradeStatus returns the progress of the upgrade of the KV mount to
+// version 2, as reported by the backend.
+func (b *SystemBackend) kvUpgradeStatus(ctx context.Context, mountEntry *MountEntry) (map[string]interface{}, error) {
+	version, err := parseutil.ParseInt(mountEntry.Options["version"])
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to parse mount entry: {{err}}", err)
+	}
+	if version < 2 {
+		return map[string]interface{}{
+			"version": 1,
+			"status":  "not_started",
+		}, nil
+	}
+
+	resp, err := b.Core.router.Route(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      mountEntry.Path + "upgrade-status",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.IsError() {
+		return nil, fmt.Errorf("mount %q did not report its upgrade status", mountEntry.Path)
+	}
+	resp.Data["version"] = 2
+	return resp.Data, nil
+}
+
+// handleMountUpgradeKVStatus returns the progress of the upgrade of a KV mount
+// to version 2.
+func (b *SystemBackend) handleMountUpgradeKVStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	b.Core.mountsLock.RLock()
+	mountEntry, errResp := b.kvMountEntry(ctx, path)
+	b.Core.mountsLock.RUnlock()
+	if errResp != nil {
+		return errResp, logical.ErrInvalidRequest
+	}
+
+	status, err := b.kvUpgradeStatus(ctx, mountEntry)
+	if err != nil {
+		return handleError(err)
+	}
+	return &logical.Response{
+		Data: status,
+	}, nil
+}
+
+// handleMountUpgradeKV upgrades a KV mount from version 1 to version 2 in
+// place. The backend upgrades the keys in the background and is unavailable
+// until it is done. Calling it again on a mount whose upgrade failed resumes
+// the upgrade with the remaining keys. With a version of 1, the mount is
+// downgraded instead.
+func (b *SystemBackend) handleMountUpgradeKV(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+	targetVersion := data.Get("version").(int)
+	if targetVersion != 1 && targetVersion != 2 {
+		return logical.ErrorResponse("version must be 1 or 2"), logical.ErrInvalidRequest
+	}
+
+	b.Core.mountsLock.Lock()
+	defer b.Core.mountsLock.Unlock()
+
+	mountEntry, errResp := b.kvMountEntry(ctx, path)
+	if errResp != nil {
+		return errResp, logical.ErrInvalidRequest
+	}
+	if !mountEntry.Local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+	if targetVersion == 1 {
+		return b.downgradeKVMount(ctx, mountEntry)
+	}
+
+	version, err := parseutil.ParseInt(mountEntry.Options["version"])
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to parse mount entry: {{err}}", err)
+	}
+	if version >= 2 {
+		status, err := b.kvUpgradeStatus(ctx, mountEntry)
+		if err != nil {
+			return handleError(err)
+		}
+		if status["status"] != "failed" {
+			resp := &logical.Response{Data: status}
+			resp.AddWarning(fmt.Sprintf("Mount %q is already at version 2.", path))
+			return resp, nil
+		}
+		b.Core.logger.Info("resuming failed KV upgrade", "path", path)
+	} else {
+		newOptions := make(map[string]string)
+		for k, v := range mountEntry.Options {
+			newOptions[k] = v
+		}
+		newOptions["version"] = "2"
+
+		oldVal := mountEntry.Options
+		mountEntry.Options = newOptions
+		if err := b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local); err != nil {
+			mountEntry.Options = oldVal
+			return handleError(err)
+		}
+		b.Core.logger.Info("upgrading KV mount to version 2", "path", path)
+	}
+
+	// Reloading the backend starts or resumes the upgrade
+	if err := b.Core.reloadBackendCommon(ctx, mountEntry, false); err != nil {
+		return handleError(err)
+	}
+
+	status, err := b.kvUpgradeStatus(ctx, mountEntry)
+	if err != nil {
+		return handleError(err)
+	}
+	resp := &logical.Response{Data: status}
+	resp.AddWarning("Upgrading mount from version 1 to version 2. This mount will be unavailable until the upgrade is done.")
+	return resp, nil
+}
+
+// downgradeKVMount downgrades a KV mount from version 2 to version 1 in place,
+// keeping only the current data of the secrets which are not deleted. The
+// state of the mount is exported, the backend is reloaded at version 1 and the
+// state is imported back, the mount being unavailable meanwhile. If the import
+// fails, the mount is restored at version 2. The caller must hold the mounts
+// lock.
+func (b *SystemBackend) downgradeKVMount(ctx context.Context, mountEntry *MountEntry) (*logical.Response, error) {
+	path := mountEntry.Path
+	version, err := parseutil.ParseInt(mountEntry.Options["version"])
+	if err != nil {
+		return nil, errwrap.Wrapf("unable to parse mount entry: {{err}}", err)
+	}
+	if version < 2 {
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"version": 1,
+				"status":  "not_started",
+			},
+		}
+		resp.AddWarning(fmt.Sprintf("Mount %q is already at version 1.", path))
+		return resp, nil
+	}
+
+	status, err := b.kvUpgradeStatus(ctx, mountEntry)
+	if err != nil {
+		return handleError(err)
+	}
+	if status["status"] != "done" {
+		return logical.ErrorResponse("mount %q cannot be downgraded before its upgrade to version 2 is done", path), logical.ErrInvalidRequest
+	}
+
+	// Requests to the mount are rejected until the downgrade is done
+	if err := b.Core.router.Taint(ctx, path); err != nil {
+		return handleError(err)
+	}
+	defer b.Core.router.Untaint(ctx, path)
+
+	backend, storage, err := b.Core.stateMigrationMount(ctx, path)
+	if err != nil {
+		return handleError(err)
+	}
+	state, err := logical.ExportBackendState(ctx, backend, storage)
+	if err != nil {
+		return handleError(fmt.Errorf("failed to export the state of %q: %w", path, err))
+	}
+	if state == nil {
+		state = &logical.BackendState{}
+	}
+
+	setVersion := func(version string) error {
+		newOptions := make(map[string]string)
+		for k, v := range mountEntry.Options {
+			newOptions[k] = v
+		}
+		newOptions["version"] = version
+
+		oldVal := mountEntry.Options
+		mountEntry.Options = newOptions
+		if err := b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local); err != nil {
+			mountEntry.Options = oldVal
+			return err
+		}
+		return b.Core.reloadBackendCommon(ctx, mountEntry, false)
+	}
+
+	// The versioned data is kept under the backend UUID, and the secrets of a
+	// version 1 mount at the root of its storage
+	versionedPrefix := mountEntry.BackendAwareUUID + "/"
+	if err := clearKVStorage(ctx, storage, versionedPrefix); err != nil {
+		return handleError(err)
+	}
+	if err := setVersion("1"); err != nil {
+		return handleError(err)
+	}
+	b.Core.logger.Info("downgrading KV mount to version 1", "path", path)
+
+	backend = b.Core.router.MatchingBackend(ctx, path)
+	if err := logical.ImportBackendState(ctx, backend, storage, state); err != nil {
+		b.Core.logger.Error("KV downgrade failed, restoring version 2", "path", path, "error", err)
+		if err := clearKVStorage(ctx, storage, versionedPrefix); err != nil {
+			b.Core.logger.Error("failed to clear the partially downgraded KV mount", "path", path, "error", err)
+		} else if err := setVersion("2"); err != nil {
+			b.Core.logger.Error("failed to restore version 2 of the KV mount", "path", path, "error", err)
+		}
+		return handleError(fmt.Errorf("failed to import the state into %q: %w", path, err))
+	}
+
+	if err := logical.ClearView(ctx, logical.NewStorageView(storage, versionedPrefix)); err != nil {
+		return handleError(fmt.Errorf("failed to delete the versioned data of %q: %w", path, err))
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"version": 1,
+			"status":  "not_started",
+		},
+	}
+	resp.AddWarning("Downgraded mount from version 2 to version 1. Only the current data of the secrets which were not deleted was kept.")
+	return resp, nil
+}
+
+// clearKVStorage deletes the keys of the storage of a KV mount, except the
+// ones under keep.
+func clearKVStorage(ctx context.Context, s logical.Storage, keep string) error {
+	keys, err := s.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		switch {
+		case key == keep:
+			continue
+		case strings.HasSuffix(key, "/"):
+			err = logical.ClearView(ctx, logical.NewStorageView(s, key))
+		default:
+			err = s.Delete(ctx, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // handleLease is use to view the metadata for a given LeaseID
 func (b *SystemBackend) handleLeaseLookup(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	leaseID := data.Get("lease_id").(string)
@@ -1709,12 +1991,30 @@ func (b *SystemBackend) handleRevokePrefixCommon(ctx context.Context,
 	req *logical.Request, data *framework.FieldData, force, sync bool) (*logical.Response, error) {
 	// Get all the options
 	prefix := data.Get("prefix").(string)
+	async := data.Get("async").(bool)
+	if async && !sync {
+		return logical.ErrorResponse("async requires sync to be true"), logical.ErrInvalidRequest
+	}
 
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if async {
+		job, err := b.Core.jobs.start(ctx, b.Core.activeContext, "revoke-prefix", func(revokeCtx context.Context, progress *jobProgress) error {
+			err := b.Core.expiration.revokePrefixCommon(revokeCtx, prefix, force, true, progress)
+			if err != nil {
+				b.Backend.Logger().Error("revoke prefix failed", "prefix", prefix, "error", err)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return jobAcceptedResponse(req, nil, job)
+	}
+
 	// Invoke the expiration manager directly
 	revokeCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
 	if force {
@@ -1961,6 +2261,8 @@ func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Requ
 		return handleError(err)
 	}
 
+	resp := b.trashMountEntry(ctx, entry)
+
 	// Get the view path if available
 	var viewPath string
 	if entry != nil {
@@ -1973,7 +2275,7 @@ func (b *SystemBackend) handleDisableAuth(ctx context.Context, req *logical.Requ
 		return handleError(err)
 	}
 
-	return nil, nil
+	return resp, nil
 }
 
 // handlePoliciesList handles /sys/policy/ and /sys/policies/<type> endpoints to provide the enabled policies
@@ -2125,10 +2427,29 @@ func (b *SystemBackend) handlePoliciesDelete(policyType PolicyType) framework.Op
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		name := data.Get("name").(string)
 
+		var policy *Policy
+		if policyType == PolicyTypeACL {
+			var err error
+			policy, err = b.Core.policyStore.GetPolicy(ctx, name, policyType)
+			if err != nil {
+				return handleError(err)
+			}
+		}
+
 		if err := b.Core.policyStore.DeletePolicy(ctx, name, policyType); err != nil {
 			return handleError(err)
 		}
-		return nil, nil
+
+		// Keep the policy in the trash so that it can be restored
+		var resp *logical.Response
+		if policy != nil {
+			if err := b.Core.trashPolicy(ctx, policy); err != nil {
+				b.Backend.Logger().Error("failed to keep deleted policy in trash", "name", name, "error", err)
+				resp = &logical.Response{}
+				resp.AddWarning(fmt.Sprintf("policy was deleted but could not be kept in trash: %s", err))
+			}
+		}
+		return resp, nil
 	}
 }
 
@@ -2353,6 +2674,24 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 	}, nil
 }
 
+// handleAuditSalt is used to read the salt of an audit backend, so that the
+// hashes of its logs can be verified offline
+func (b *SystemBackend) handleAuditSalt(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	salt, err := b.Core.auditBroker.GetSalt(ctx, path)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"salt":      salt.Value(),
+			"hmac_type": salt.HMACType(),
+		},
+	}, nil
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -2841,6 +3180,26 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 	}
 }
 
+// handleHAStatus returns the nodes of the HA cluster and the recent changes of
+// the active node. Requests are forwarded by standbys, so this always runs on
+// the active node.
+func (b *SystemBackend) handleHAStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	history, err := b.Core.LeadershipHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*LeadershipChange{}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"nodes":              b.Core.HANodes(),
+			"leadership_history": history,
+		},
+	}, nil
+}
+
 // handleHostInfo collects and returns host-related information, which includes
 // system information, cpu, disk, and memory usage. Any capture-related errors
 // returned by the collection method will be returned as response warnings.
@@ -3442,6 +3801,16 @@ func (b *SystemBackend) pathInternalCountersEntities(ctx context.Context, req *l
 	return resp, nil
 }
 
+func (b *SystemBackend) pathInternalCountersStorage(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"mounts": b.Core.mountStorageUsage(),
+		},
+	}
+
+	return resp, nil
+}
+
 func (b *SystemBackend) pathInternalUIResultantACL(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	if req.ClientToken == "" {
 		// 204 -- no ACL
@@ -3908,6 +4277,38 @@ This path responds to the following HTTP methods.
 		`,
 	},
 
+	"migrate-state": {
+		"Move the data of a mount to another mount.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /sys/migrate-state
+        Exports the data of a mount, applies the given transforms and
+        imports it into another mount, for example one running a newer
+        version of the plugin. Both backends must support exporting and
+        importing their state. The data of the source mount is left
+        untouched.
+		`,
+	},
+
+	"mount_upgrade_kv": {
+		"Upgrade a KV mount from version 1 to version 2 in place, or downgrade it.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /sys/mounts/<mount point>/upgrade-kv
+        Returns the progress of the upgrade of the mount to version 2.
+
+    POST /sys/mounts/<mount point>/upgrade-kv
+        Upgrades the mount to version 2, creating version 1 of each key in
+        the background. The mount is unavailable until the upgrade is done.
+        An interrupted upgrade resumes when the mount is loaded again, and a
+        failed one when this is called again. With a version of 1, downgrades
+        the mount to version 1 instead, keeping only the current data of the
+        secrets which are not deleted.
+		`,
+	},
+
 	"auth_tune": {
 		"Tune the configuration parameters for an auth path.",
 		`Read and write the 'default-lease-ttl' and 'max-lease-ttl' values of
@@ -3950,6 +4351,15 @@ used to revoke the secret with the given Lease ID.
 		`,
 	},
 
+	"revoke-async": {
+		"Whether or not to perform the synchronous revocation as a job",
+		`
+If true, the call returns immediately with the ID of a job revoking the leases
+synchronously, whose progress can be read and which can be canceled through
+sys/jobs. Requires sync to be true.
+`,
+	},
+
 	"revoke-sync": {
 		"Whether or not to perform the revocation synchronously",
 		`
@@ -4107,6 +4517,15 @@ or delete a policy.
 		"",
 	},
 
+	"audit-salt": {
+		"Read the salt of the given audit backend",
+		`
+The salt allows computing the hashes of the audit logs of the backend offline,
+for instance to verify whether the logs contain a given value. It allows
+confirming guesses of any hashed value, so it must be handled as a secret.
+		`,
+	},
+
 	"audit-table": {
 		"List the currently enabled audit backends.",
 		`
@@ -4387,6 +4806,18 @@ This path responds to the following HTTP methods.
 		"Count of active entities in this Vault cluster.",
 		"Count of active entities in this Vault cluster.",
 	},
+	"internal-counters-storage": {
+		"Approximate storage usage of the mounts of this Vault cluster.",
+		`Approximate number of storage entries and storage bytes of each mount of this
+		Vault cluster. The usage is computed in the background, one mount at a time,
+		so it may lag behind the actual usage of the mounts.`,
+	},
+	"ha-status": {
+		"Information about the nodes of the HA cluster and the recent changes of the active node.",
+		`Returns the active node and the standbys which sent a heartbeat recently,
+		with the time of their last heartbeat and their version, and the recent
+		changes of the active node with their reasons, oldest first.`,
+	},
 	"host-info": {
 		"Information about the host instance that this Vault server is running on.",
 		`Information about the host instance that this Vault server is running on.
@@ -0,0 +1,298 @@
+package vault
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) eventWebhookPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "events/webhooks/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhooksList(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(eventWebhooksHelp["webhook-list"][0]),
+			HelpDescription: strings.TrimSpace(eventWebhooksHelp["webhook-list"][1]),
+		},
+		{
+			Pattern: "events/webhooks/" + framework.GenericNameRegex("name") + "/dead-letters$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the webhook sink.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookDeadLettersRead(),
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookDeadLettersDelete(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(eventWebhooksHelp["dead-letters"][0]),
+			HelpDescription: strings.TrimSpace(eventWebhooksHelp["dead-letters"][1]),
+		},
+		{
+			Pattern: "events/webhooks/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the webhook sink.",
+				},
+				"url": {
+					Type:        framework.TypeString,
+					Description: "The http or https URL to which the events are posted.",
+				},
+				"event_types": {
+					Type: framework.TypeCommaStringSlice,
+					Description: `The types of the events delivered to the sink, among lease-revoked,
+mount-changed and seal-status, or "*" for all of them.`,
+				},
+				"hmac_key": {
+					Type: framework.TypeString,
+					Description: `If set, the HMAC-SHA256 of each payload with this key is sent in the
+X-Vault-Event-Signature header. It is never returned.`,
+				},
+				"max_retries": {
+					Type:        framework.TypeInt,
+					Default:     eventWebhookDefaultMaxRetries,
+					Description: "The number of times a failed delivery is retried before the event is dead lettered.",
+				},
+				"timeout": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The timeout of each delivery attempt (default '10s').",
+				},
+			},
+			ExistenceCheck: b.handleEventWebhookExistenceCheck(),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookWrite(),
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookWrite(),
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookRead(),
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleEventWebhookDelete(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(eventWebhooksHelp["webhook"][0]),
+			HelpDescription: strings.TrimSpace(eventWebhooksHelp["webhook"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) eventWebhookSink(ctx context.Context, s logical.Storage, name string) (*EventWebhookSink, error) {
+	entry, err := s.Get(ctx, eventWebhookSinkPrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var sink EventWebhookSink
+	if err := entry.DecodeJSON(&sink); err != nil {
+		return nil, err
+	}
+	return &sink, nil
+}
+
+func (b *SystemBackend) handleEventWebhookExistenceCheck() framework.ExistenceFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+		sink, err := b.eventWebhookSink(ctx, req.Storage, d.Get("name").(string))
+		if err != nil {
+			return false, err
+		}
+		return sink != nil, nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhooksList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		names, err := req.Storage.List(ctx, eventWebhookSinkPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhookWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		sink, err := b.eventWebhookSink(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if sink == nil {
+			sink = &EventWebhookSink{
+				Name:       name,
+				MaxRetries: eventWebhookDefaultMaxRetries,
+				Timeout:    eventWebhookDefaultTimeout,
+			}
+		}
+
+		if raw, ok := d.GetOk("url"); ok {
+			sink.URL = raw.(string)
+		}
+		u, err := url.Parse(sink.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return logical.ErrorResponse("'url' must be an http or https URL"), nil
+		}
+
+		if raw, ok := d.GetOk("event_types"); ok {
+			sink.EventTypes = raw.([]string)
+		}
+		if len(sink.EventTypes) == 0 {
+			return logical.ErrorResponse("'event_types' is required"), nil
+		}
+		for _, eventType := range sink.EventTypes {
+			if !validEventType(eventType) {
+				return logical.ErrorResponse("unknown event type %q", eventType), nil
+			}
+		}
+
+		if raw, ok := d.GetOk("hmac_key"); ok {
+			sink.HMACKey = raw.(string)
+		}
+
+		if raw, ok := d.GetOk("max_retries"); ok {
+			sink.MaxRetries = raw.(int)
+		}
+		if sink.MaxRetries < 0 {
+			return logical.ErrorResponse("'max_retries' is invalid"), nil
+		}
+
+		if raw, ok := d.GetOk("timeout"); ok {
+			sink.Timeout = time.Second * time.Duration(raw.(int))
+		}
+		if sink.Timeout <= 0 {
+			return logical.ErrorResponse("'timeout' is invalid"), nil
+		}
+
+		entry, err := logical.StorageEntryJSON(eventWebhookSinkPrefix+name, sink)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		b.Core.eventWebhooks.setSink(sink)
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhookRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		sink, err := b.eventWebhookSink(ctx, req.Storage, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if sink == nil {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"name":         sink.Name,
+				"url":          sink.URL,
+				"event_types":  sink.EventTypes,
+				"hmac_key_set": sink.HMACKey != "",
+				"max_retries":  sink.MaxRetries,
+				"timeout":      int(sink.Timeout.Seconds()),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhookDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		if err := req.Storage.Delete(ctx, eventWebhookSinkPrefix+name); err != nil {
+			return nil, err
+		}
+		b.Core.eventWebhooks.deleteSink(name)
+
+		if err := b.Core.eventWebhooks.clearDeadLetters(ctx, name); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhookDeadLettersRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		sink, err := b.eventWebhookSink(ctx, req.Storage, name)
+		if err != nil {
+			return nil, err
+		}
+		if sink == nil {
+			return nil, nil
+		}
+
+		letters, err := b.Core.eventWebhooks.deadLetters(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if letters == nil {
+			letters = []*EventDeadLetter{}
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"dead_letters": letters,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleEventWebhookDeadLettersDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := b.Core.eventWebhooks.clearDeadLetters(ctx, d.Get("name").(string)); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+var eventWebhooksHelp = map[string][2]string{
+	"webhook-list": {
+		"List the event webhook sinks.",
+		"",
+	},
+	"webhook": {
+		"Create, update, read or delete an event webhook sink.",
+		`The active node posts the events of the given types to the URL of the sink as
+JSON, with their ID, type, time and data. Failed deliveries are retried with an
+exponential backoff, up to 'max_retries' times, after which the event is added
+to the dead letters of the sink. If 'hmac_key' is set, the payload is signed
+with it in the X-Vault-Event-Signature header.`,
+	},
+	"dead-letters": {
+		"Read or clear the events which could not be delivered to a webhook sink.",
+		`Returns the most recent events whose delivery failed after all the retries,
+oldest first, with the number of attempts and the last error. Deleting clears
+them.`,
+	},
+}
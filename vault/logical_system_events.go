@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// eventStreamKeepAlive is the interval at which comments are written to idle
+// event streams, so that proxies do not close them
+var eventStreamKeepAlive = 30 * time.Second
+
+func (b *SystemBackend) eventSubscribePath() *framework.Path {
+	return &framework.Path{
+		Pattern: "events/subscribe/(?P<event_type>[^/]+)$",
+		Fields: map[string]*framework.FieldSchema{
+			"event_type": {
+				Type:        framework.TypeString,
+				Description: `The type of the events, among lease-revoked, mount-changed and seal-status, or "*" for all of them.`,
+			},
+			"last_event_id": {
+				Type:        framework.TypeString,
+				Description: "Resume the subscription after the event with this ID. The Last-Event-ID header takes precedence.",
+				Query:       true,
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.handleEventsSubscribe,
+				Summary:  "Stream the events of a type as server-sent events.",
+			},
+		},
+		HelpSynopsis:    strings.TrimSpace(eventsHelp["subscribe"][0]),
+		HelpDescription: strings.TrimSpace(eventsHelp["subscribe"][1]),
+	}
+}
+
+func (b *SystemBackend) handleEventsSubscribe(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return logical.ErrorResponse("events can only be subscribed to in the root namespace"), logical.ErrInvalidRequest
+	}
+
+	eventType := d.Get("event_type").(string)
+	if !validEventType(eventType) {
+		return logical.ErrorResponse(fmt.Sprintf("unknown event type %q", eventType)), logical.ErrInvalidRequest
+	}
+
+	if req.ResponseWriter == nil {
+		return logical.ErrorResponse("events can only be streamed over HTTP"), logical.ErrInvalidRequest
+	}
+	w := req.ResponseWriter
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		return logical.ErrorResponse("streaming not supported"), nil
+	}
+
+	lastEventID := d.Get("last_event_id").(string)
+	if req.HTTPRequest != nil {
+		if id := req.HTTPRequest.Header.Get("Last-Event-ID"); id != "" {
+			lastEventID = id
+		}
+	}
+
+	sub, err := b.Core.events.subscribe(eventType, lastEventID)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	defer b.Core.events.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// The retry field sets how long clients wait before reconnecting
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", time.Second.Milliseconds()); err != nil {
+		return nil, fmt.Errorf("error starting event stream: %w", err)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventStreamKeepAlive)
+	defer ticker.Stop()
+
+	// Errors are still returned once the header was written, but they are
+	// ignored upstream since the response was already sent
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return nil, fmt.Errorf("error streaming events: %w", err)
+			}
+			flusher.Flush()
+		case event, ok := <-sub.ch:
+			// The subscriber is disconnected when the node is sealed or steps
+			// down, or when it falls behind
+			if !ok {
+				return nil, nil
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return nil, fmt.Errorf("error streaming events: %w", err)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+var eventsHelp = map[string][2]string{
+	"subscribe": {
+		"Stream the events of a type as server-sent events.",
+		`The active node streams the events of the type, or of all types for "*", as
+they are published, in the text/event-stream format. Each event has its ID, its
+type, and its JSON payload as delivered to the webhook sinks. A subscription
+resumes after the event whose ID is given in the Last-Event-ID header, or in
+'last_event_id', as long as the node still holds the events which followed it;
+otherwise only new events are streamed. Subscriptions which fall behind are
+disconnected.`,
+	},
+}
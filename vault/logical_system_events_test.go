@@ -0,0 +1,132 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// streamRecorder records a streamed response, which is read while it is
+// written
+type streamRecorder struct {
+	sync.Mutex
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *streamRecorder) Header() http.Header { return r.header }
+
+func (r *streamRecorder) WriteHeader(status int) {
+	r.Lock()
+	defer r.Unlock()
+	r.status = status
+}
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	return r.body.Write(p)
+}
+
+func (r *streamRecorder) Flush() {}
+
+func (r *streamRecorder) String() string {
+	r.Lock()
+	defer r.Unlock()
+	return r.body.String()
+}
+
+func TestSystemBackend_EventsSubscribe(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	c.publishEvent(EventTypeMountChanged, map[string]interface{}{"path": "first/"})
+	c.publishEvent(EventTypeMountChanged, map[string]interface{}{"path": "missed/"})
+	c.publishEvent(EventTypeLeaseRevoked, map[string]interface{}{"lease_id": "other"})
+
+	c.events.l.Lock()
+	history := append([]*Event(nil), c.events.history...)
+	c.events.l.Unlock()
+	var first *Event
+	for _, event := range history {
+		if event.Data["path"] == "first/" {
+			first = event
+		}
+	}
+	if first == nil {
+		t.Fatalf("the event was not kept: %#v", history)
+	}
+
+	// Unknown event types are rejected
+	req := logical.TestRequest(t, logical.ReadOperation, "events/subscribe/unknown")
+	req.ResponseWriter = logical.NewHTTPResponseWriter(&streamRecorder{header: make(http.Header)})
+	if _, err := b.HandleRequest(namespace.RootContext(nil), req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an invalid request, got %v", err)
+	}
+
+	// The subscription resumes after the first event, and only streams the
+	// events of its type
+	rec := &streamRecorder{header: make(http.Header)}
+	req = logical.TestRequest(t, logical.ReadOperation, "events/subscribe/"+EventTypeMountChanged)
+	req.ResponseWriter = logical.NewHTTPResponseWriter(rec)
+	req.HTTPRequest = &http.Request{Header: http.Header{"Last-Event-Id": []string{first.ID}}}
+
+	ctx, cancel := context.WithCancel(namespace.RootContext(nil))
+	doneCh := make(chan error)
+	go func() {
+		_, err := b.HandleRequest(ctx, req)
+		doneCh <- err
+	}()
+
+	waitFor := func(s string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(rec.String(), s) {
+			if time.Now().After(deadline) {
+				t.Fatalf("%q was not streamed: %q", s, rec.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor(`"path":"missed/"`)
+
+	c.publishEvent(EventTypeLeaseRevoked, map[string]interface{}{"lease_id": "ignored"})
+	c.publishEvent(EventTypeMountChanged, map[string]interface{}{"path": "new/"})
+	waitFor(`"path":"new/"`)
+
+	cancel()
+	if err := <-doneCh; err != nil {
+		t.Fatal(err)
+	}
+
+	body := rec.String()
+	if rec.status != http.StatusOK || rec.header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("bad response: %d %#v", rec.status, rec.header)
+	}
+	if strings.Contains(body, `"path":"first/"`) || strings.Contains(body, EventTypeLeaseRevoked) {
+		t.Fatalf("unexpected events were streamed: %q", body)
+	}
+	if !strings.Contains(body, "event: "+EventTypeMountChanged+"\n") || strings.Index(body, "missed/") > strings.Index(body, "new/") {
+		t.Fatalf("bad stream: %q", body)
+	}
+
+	// Sealing disconnects the subscriptions
+	sub, err := c.events.subscribe("*", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.stopEventWebhooks()
+	if _, ok := <-sub.ch; ok {
+		t.Fatal("expected the subscription to be closed")
+	}
+	if _, err := c.events.subscribe("*", ""); err != errEventsNotActive {
+		t.Fatalf("expected %v, got %v", errEventsNotActive, err)
+	}
+}
@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) inFlightRequestPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "in-flight-req/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleInFlightRequestList(),
+					Summary:  "List the requests being handled by this node.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(inFlightRequestHelp["in-flight-req-list"][0]),
+			HelpDescription: strings.TrimSpace(inFlightRequestHelp["in-flight-req-list"][1]),
+		},
+		{
+			Pattern: "in-flight-req/" + framework.GenericNameRegex("request_id") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"request_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the in-flight request.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleInFlightRequestCancel(),
+					Summary:  "Cancel an in-flight request.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(inFlightRequestHelp["in-flight-req"][0]),
+			HelpDescription: strings.TrimSpace(inFlightRequestHelp["in-flight-req"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleInFlightRequestList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		reqs := b.Core.InFlightRequests()
+		sort.Slice(reqs, func(i, j int) bool {
+			return reqs[i].StartTime.Before(reqs[j].StartTime)
+		})
+
+		now := time.Now()
+		keys := make([]string, 0, len(reqs))
+		keyInfo := make(map[string]interface{}, len(reqs))
+		for _, ifr := range reqs {
+			keys = append(keys, ifr.ID)
+			keyInfo[ifr.ID] = map[string]interface{}{
+				"start_time":            ifr.StartTime.Format(time.RFC3339Nano),
+				"age":                   int64(now.Sub(ifr.StartTime).Seconds()),
+				"request_path":          ifr.Path,
+				"operation":             string(ifr.Operation),
+				"client_remote_address": ifr.ClientRemoteAddr,
+				"node":                  b.Core.redirectAddr,
+			}
+		}
+
+		return logical.ListResponseWithInfo(keys, keyInfo), nil
+	}
+}
+
+func (b *SystemBackend) handleInFlightRequestCancel() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		id := d.Get("request_id").(string)
+		if id == req.ID {
+			return logical.ErrorResponse("a request cannot cancel itself"), logical.ErrInvalidRequest
+		}
+		if !b.Core.CancelInFlightRequest(id) {
+			return logical.ErrorResponse(fmt.Sprintf("no in-flight request with ID %q", id)), logical.ErrInvalidRequest
+		}
+
+		b.Backend.Logger().Warn("canceled in-flight request", "request_id", id)
+		return nil, nil
+	}
+}
+
+var inFlightRequestHelp = map[string][2]string{
+	"in-flight-req-list": {
+		"List the requests being handled by this node.",
+		`Requests are listed by ID, oldest first, along with their start time, their
+age in seconds, their path, with tokens redacted, and the address of the client.
+Requests forwarded by standby nodes are listed by the active node.`,
+	},
+	"in-flight-req": {
+		"Cancel an in-flight request.",
+		`The context of the request is canceled, so that the backend handling it stops
+waiting on slow operations, such as queries to an unresponsive database, and the
+client gets an error.`,
+	},
+}
@@ -0,0 +1,181 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) jobPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "jobs/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback:                  b.handleJobList,
+					Summary:                   "List the jobs of the namespace.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(jobsHelp["jobs-list"][0]),
+			HelpDescription: strings.TrimSpace(jobsHelp["jobs-list"][1]),
+		},
+		{
+			Pattern: "jobs/" + framework.GenericNameRegex("job_id") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"job_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the job.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:                  b.handleJobRead,
+					Summary:                   "Read the status, progress and result of a job.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(jobsHelp["jobs"][0]),
+			HelpDescription: strings.TrimSpace(jobsHelp["jobs"][1]),
+		},
+		{
+			Pattern: "jobs/" + framework.GenericNameRegex("job_id") + "/cancel$",
+			Fields: map[string]*framework.FieldSchema{
+				"job_id": {
+					Type:        framework.TypeString,
+					Description: "ID of the job.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                  b.handleJobCancel,
+					Summary:                   "Cancel a running job.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(jobsHelp["jobs-cancel"][0]),
+			HelpDescription: strings.TrimSpace(jobsHelp["jobs-cancel"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleJobList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := b.Core.jobs.list(ns)
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartTime.Before(jobs[j].StartTime)
+	})
+
+	keys := make([]string, 0, len(jobs))
+	keyInfo := make(map[string]interface{}, len(jobs))
+	for _, job := range jobs {
+		info := job.Info()
+		keys = append(keys, info.ID)
+		keyInfo[info.ID] = map[string]interface{}{
+			"type":       info.Type,
+			"status":     info.Status,
+			"start_time": info.StartTime.Format(time.RFC3339Nano),
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleJobRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job := b.Core.jobs.job(ns, d.Get("job_id").(string))
+	if job == nil {
+		return nil, nil
+	}
+	info := job.Info()
+
+	respData := map[string]interface{}{
+		"id":         info.ID,
+		"type":       info.Type,
+		"status":     info.Status,
+		"completed":  info.Completed,
+		"total":      info.Total,
+		"start_time": info.StartTime.Format(time.RFC3339Nano),
+		"end_time":   "",
+		"error":      info.Error,
+		"result":     info.Result,
+	}
+	if !info.EndTime.IsZero() {
+		respData["end_time"] = info.EndTime.Format(time.RFC3339Nano)
+	}
+
+	return &logical.Response{
+		Data: respData,
+	}, nil
+}
+
+func (b *SystemBackend) handleJobCancel(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := d.Get("job_id").(string)
+	job := b.Core.jobs.job(ns, id)
+	if job == nil {
+		return logical.ErrorResponse(fmt.Sprintf("no job with ID %q", id)), logical.ErrInvalidRequest
+	}
+	if !b.Core.jobs.cancel(job) {
+		return logical.ErrorResponse(fmt.Sprintf("job %q is not running", id)), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+// jobAcceptedResponse turns resp into the response of a request which started
+// the given job instead of waiting for the operation to finish.
+func jobAcceptedResponse(req *logical.Request, resp *logical.Response, job *Job) (*logical.Response, error) {
+	if resp == nil {
+		resp = &logical.Response{}
+	}
+	if resp.Data == nil {
+		resp.Data = make(map[string]interface{})
+	}
+	resp.Data["job_id"] = job.ID
+	resp.AddWarning(fmt.Sprintf("The operation runs in the background as job %q. Its progress and its result can be read from sys/jobs/%s.", job.ID, job.ID))
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+var jobsHelp = map[string][2]string{
+	"jobs-list": {
+		"List the jobs of the namespace.",
+		`Long-running operations, such as tidying tokens or leases and revoking leases
+by prefix asynchronously, run in the background as jobs instead of holding the
+request until they finish. Jobs are listed by ID, oldest first, along with
+their type, their status and their start time. Jobs run on the active node and
+are kept for 24 hours once they finish; they are lost when the node seals or
+steps down, which cancels the running ones.`,
+	},
+	"jobs": {
+		"Read the status, progress and result of a job.",
+		`The status of a job is "running", "succeeded", "failed" or "canceled". Its
+progress is the number of items completed out of the total, when the job knows
+how many items it has to process. The result and the error are set once the
+job finishes.`,
+	},
+	"jobs-cancel": {
+		"Cancel a running job.",
+		`The job stops at its next item, leaving the items already processed as they
+are, and its status becomes "canceled".`,
+	},
+}
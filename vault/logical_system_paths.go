@@ -476,6 +476,27 @@ func (b *SystemBackend) auditPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["audit-hash"][1]),
 		},
 
+		{
+			Pattern: "audit-salt/(?P<path>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["audit_path"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleAuditSalt,
+					Summary:  "Read the salt of an audit device.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["audit-salt"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["audit-salt"][1]),
+		},
+
 		{
 			Pattern: "audit$",
 
@@ -542,6 +563,10 @@ func (b *SystemBackend) auditPaths() []*framework.Path {
 				"hmac": &framework.FieldSchema{
 					Type: framework.TypeBool,
 				},
+				"passthrough": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: "Whether the header is also passed through to all backends.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -908,6 +933,16 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-entities"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["internal-counters-entities"][1]),
 		},
+		{
+			Pattern: "internal/counters/storage",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalCountersStorage,
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-storage"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["internal-counters-storage"][1]),
+		},
 	}
 }
 
@@ -922,9 +957,10 @@ func (b *SystemBackend) capabilitiesPaths() []*framework.Path {
 					Description: "Accessor of the token for which capabilities are being queried.",
 				},
 				"path": &framework.FieldSchema{
-					Type:        framework.TypeCommaStringSlice,
-					Description: "Use 'paths' instead.",
-					Deprecated:  true,
+					Type:           framework.TypeCommaStringSlice,
+					Description:    "Use 'paths' instead.",
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"paths": &framework.FieldSchema{
 					Type:        framework.TypeCommaStringSlice,
@@ -949,9 +985,10 @@ func (b *SystemBackend) capabilitiesPaths() []*framework.Path {
 					Description: "Token for which capabilities are being queried.",
 				},
 				"path": &framework.FieldSchema{
-					Type:        framework.TypeCommaStringSlice,
-					Description: "Use 'paths' instead.",
-					Deprecated:  true,
+					Type:           framework.TypeCommaStringSlice,
+					Description:    "Use 'paths' instead.",
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"paths": &framework.FieldSchema{
 					Type:        framework.TypeCommaStringSlice,
@@ -976,9 +1013,10 @@ func (b *SystemBackend) capabilitiesPaths() []*framework.Path {
 					Description: "Token for which capabilities are being queried.",
 				},
 				"path": &framework.FieldSchema{
-					Type:        framework.TypeCommaStringSlice,
-					Description: "Use 'paths' instead.",
-					Deprecated:  true,
+					Type:           framework.TypeCommaStringSlice,
+					Description:    "Use 'paths' instead.",
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"paths": &framework.FieldSchema{
 					Type:        framework.TypeCommaStringSlice,
@@ -1107,6 +1145,10 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["revoke-force-path"][0]),
 				},
+				"async": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["revoke-async"][0]),
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -1134,6 +1176,10 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 					Default:     true,
 					Description: strings.TrimSpace(sysHelp["revoke-sync"][0]),
 				},
+				"async": &framework.FieldSchema{
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["revoke-async"][0]),
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -1237,6 +1283,21 @@ func (b *SystemBackend) hostInfoPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) haStatusPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "ha-status$",
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback:    b.handleHAStatus,
+				Summary:     strings.TrimSpace(sysHelp["ha-status"][0]),
+				Description: strings.TrimSpace(sysHelp["ha-status"][1]),
+			},
+		},
+		HelpSynopsis:    strings.TrimSpace(sysHelp["ha-status"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["ha-status"][1]),
+	}
+}
+
 func (b *SystemBackend) authPaths() []*framework.Path {
 	return []*framework.Path{
 		{
@@ -1395,9 +1456,10 @@ func (b *SystemBackend) policyPaths() []*framework.Path {
 					Description: strings.TrimSpace(sysHelp["policy-name"][0]),
 				},
 				"rules": &framework.FieldSchema{
-					Type:        framework.TypeString,
-					Description: strings.TrimSpace(sysHelp["policy-rules"][0]),
-					Deprecated:  true,
+					Type:           framework.TypeString,
+					Description:    strings.TrimSpace(sysHelp["policy-rules"][0]),
+					Deprecated:     true,
+					RemovalVersion: "1.8",
 				},
 				"policy": &framework.FieldSchema{
 					Type:        framework.TypeString,
@@ -1600,6 +1662,36 @@ func (b *SystemBackend) wrappingPaths() []*framework.Path {
 
 func (b *SystemBackend) mountPaths() []*framework.Path {
 	return []*framework.Path{
+		{
+			Pattern: "mounts/(?P<path>.+?)/upgrade-kv$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount_path"][0]),
+				},
+				"version": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Default:     2,
+					Description: "The version to migrate the mount to, 2 to upgrade it or 1 to downgrade it.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountUpgradeKVStatus,
+					Summary:  "Read the progress of the upgrade of a KV mount to version 2.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountUpgradeKV,
+					Summary:  "Upgrade a KV mount from version 1 to version 2 in place, or downgrade it.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mount_upgrade_kv"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mount_upgrade_kv"][1]),
+		},
+
 		{
 			Pattern: "mounts/(?P<path>.+?)/tune$",
 
@@ -97,6 +97,62 @@ from any further requests until after the 'block_interval' has elapsed.`,
 			HelpSynopsis:    strings.TrimSpace(quotasHelp["rate-limit"][0]),
 			HelpDescription: strings.TrimSpace(quotasHelp["rate-limit"][1]),
 		},
+		{
+			Pattern: "quotas/concurrency/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasList(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["concurrency-list"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["concurrency-list"][1]),
+		},
+		{
+			Pattern: "quotas/concurrency/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"type": {
+					Type:        framework.TypeString,
+					Description: "Type of the quota rule.",
+				},
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the quota rule.",
+				},
+				"path": {
+					Type: framework.TypeString,
+					Description: `Path of the mount or namespace to apply the quota. A blank path configures a
+global quota. For example namespace1/ adds a quota to a full namespace,
+namespace1/auth/userpass adds a quota to userpass in namespace1.`,
+				},
+				"max_requests": {
+					Type: framework.TypeInt,
+					Description: `The maximum number of requests handled at the same time by this node.
+The 'max_requests' must be positive.`,
+				},
+				"max_queued": {
+					Type: framework.TypeInt,
+					Description: `The maximum number of requests waiting for one of the 'max_requests' slots to be
+freed. Requests over the limit are rejected right away if zero (default).`,
+				},
+				"queue_timeout": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The duration after which a queued request is rejected (default '10s').",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasUpdate(),
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasRead(),
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasDelete(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["concurrency"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["concurrency"][1]),
+		},
 	}
 }
 
@@ -289,6 +345,149 @@ func (b *SystemBackend) handleRateLimitQuotasDelete() framework.OperationFunc {
 	}
 }
 
+func (b *SystemBackend) handleConcurrencyQuotasList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		names, err := b.Core.quotaManager.QuotaNames(quotas.TypeConcurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		qType := quotas.TypeConcurrency.String()
+		maxRequests := d.Get("max_requests").(int)
+		if maxRequests <= 0 {
+			return logical.ErrorResponse("'max_requests' is invalid"), nil
+		}
+
+		maxQueued := d.Get("max_queued").(int)
+		if maxQueued < 0 {
+			return logical.ErrorResponse("'max_queued' is invalid"), nil
+		}
+
+		queueTimeout := time.Second * time.Duration(d.Get("queue_timeout").(int))
+		if queueTimeout < 0 {
+			return logical.ErrorResponse("'queue_timeout' is invalid"), nil
+		}
+		if queueTimeout == 0 {
+			queueTimeout = quotas.DefaultConcurrencyQueueTimeout
+		}
+
+		mountPath := sanitizePath(d.Get("path").(string))
+		ns := b.Core.namespaceByPath(mountPath)
+		if ns.ID != namespace.RootNamespaceID {
+			mountPath = strings.TrimPrefix(mountPath, ns.Path)
+		}
+
+		if mountPath != "" {
+			match := b.Core.router.MatchingMount(namespace.ContextWithNamespace(ctx, ns), mountPath)
+			if match == "" {
+				return logical.ErrorResponse("invalid mount path %q", mountPath), nil
+			}
+		}
+		// Disallow creation of new quota that has properties similar to an
+		// existing quota.
+		quotaByFactors, err := b.Core.quotaManager.QuotaByFactors(ctx, qType, ns.Path, mountPath)
+		if err != nil {
+			return nil, err
+		}
+		if quotaByFactors != nil && quotaByFactors.QuotaName() != name {
+			return logical.ErrorResponse("quota rule with similar properties exists under the name %q", quotaByFactors.QuotaName()), nil
+		}
+
+		// If a quota already exists, fetch and update it.
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case quota == nil:
+			quota = quotas.NewConcurrencyQuota(name, ns.Path, mountPath, maxRequests, maxQueued, queueTimeout)
+		default:
+			cq := quota.(*quotas.ConcurrencyQuota)
+			cq.NamespacePath = ns.Path
+			cq.MountPath = mountPath
+			cq.MaxRequests = maxRequests
+			cq.MaxQueued = maxQueued
+			cq.QueueTimeout = queueTimeout
+		}
+
+		entry, err := logical.StorageEntryJSON(quotas.QuotaStoragePath(qType, name), quota)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := req.Storage.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		if err := b.Core.quotaManager.SetQuota(ctx, qType, quota, false); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeConcurrency.String()
+
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, err
+		}
+		if quota == nil {
+			return nil, nil
+		}
+
+		cq := quota.(*quotas.ConcurrencyQuota)
+
+		nsPath := cq.NamespacePath
+		if cq.NamespacePath == "root" {
+			nsPath = ""
+		}
+
+		data := map[string]interface{}{
+			"type":          qType,
+			"name":          cq.Name,
+			"path":          nsPath + cq.MountPath,
+			"max_requests":  cq.MaxRequests,
+			"max_queued":    cq.MaxQueued,
+			"queue_timeout": int(cq.QueueTimeout.Seconds()),
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeConcurrency.String()
+
+		if err := req.Storage.Delete(ctx, quotas.QuotaStoragePath(qType, name)); err != nil {
+			return nil, err
+		}
+
+		if err := b.Core.quotaManager.DeleteQuota(ctx, qType, name); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
 var quotasHelp = map[string][2]string{
 	"quotas-config": {
 		"Create, update and read the quota configuration.",
@@ -306,4 +505,18 @@ IP address.`,
 		"Lists the names of all the rate limit quotas.",
 		"This list contains quota definitions from all the namespaces.",
 	},
+	"concurrency": {
+		`Get, create or update concurrency resource quota for an optional namespace or
+mount.`,
+		`A concurrency quota limits the number of requests handled at the same time by
+each node, protecting slow backends from accumulating requests. Requests over
+the limit can wait for a slot in a bounded queue, and are rejected if the queue
+is full or no slot is freed before the queue timeout. A concurrency quota can be
+created at the root level or defined on a namespace or mount by specifying a
+'path'.`,
+	},
+	"concurrency-list": {
+		"Lists the names of all the concurrency quotas.",
+		"This list contains quota definitions from all the namespaces.",
+	},
 }
@@ -55,6 +55,14 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 				"server_id": {
 					Type: framework.TypeString,
 				},
+				"join_nonce": {
+					Type:        framework.TypeString,
+					Description: "Nonce generated by the peer for the join token proofs.",
+				},
+				"join_proof": {
+					Type:        framework.TypeString,
+					Description: "Proof of knowledge of the join token by the peer, if the cluster requires one.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -184,6 +192,19 @@ func (b *SystemBackend) handleRaftBootstrapChallengeWrite() framework.OperationF
 			return logical.ErrorResponse("no server id provided"), logical.ErrInvalidRequest
 		}
 
+		// Only peers which know the join token may join, and prove in turn
+		// that this node knows it
+		var joinProof string
+		if raftBackend := b.Core.getRaftBackend(); raftBackend != nil && raftBackend.JoinToken() != "" {
+			joinToken := raftBackend.JoinToken()
+			joinNonce := d.Get("join_nonce").(string)
+			if !raft.VerifyJoinTokenProof(joinToken, raft.JoinTokenFollower, serverID, joinNonce, d.Get("join_proof").(string)) {
+				b.Core.logger.Warn("rejected raft join attempt with an invalid join token proof", "server_id", serverID)
+				return logical.ErrorResponse("invalid join token proof"), logical.ErrPermissionDenied
+			}
+			joinProof = raft.JoinTokenProof(joinToken, raft.JoinTokenLeader, serverID, joinNonce)
+		}
+
 		var answer []byte
 		answerRaw, ok := b.Core.pendingRaftPeers.Load(serverID)
 		if !ok {
@@ -213,12 +234,16 @@ func (b *SystemBackend) handleRaftBootstrapChallengeWrite() framework.OperationF
 			return nil, err
 		}
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: map[string]interface{}{
 				"challenge":   base64.StdEncoding.EncodeToString(protoBlob),
 				"seal_config": sealConfig,
 			},
-		}, nil
+		}
+		if joinProof != "" {
+			resp.Data["join_proof"] = joinProof
+		}
+		return resp, nil
 	}
 }
 
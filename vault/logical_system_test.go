@@ -36,8 +36,10 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 	expected := []string{
 		"auth/*",
 		"remount",
+		"migrate-state",
 		"audit",
 		"audit/*",
+		"audit-salt/*",
 		"raw",
 		"raw/*",
 		"replication/primary/secondary-token",
@@ -57,6 +59,7 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"leases/revoke-force/*",
 		"leases/lookup/*",
 		"storage/raft/snapshot-auto/config/*",
+		"events/webhooks/*",
 	}
 
 	b := testSystemBackend(t)
@@ -1618,6 +1621,228 @@ func TestSystemBackend_disableAuth(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_trash(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	c.credentialBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{BackendType: logical.TypeCredential}, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	doReq := func(t *testing.T, req *logical.Request) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v %#v", err, resp)
+		}
+		return resp
+	}
+	trashID := func(t *testing.T, name string) string {
+		t.Helper()
+		resp := doReq(t, logical.TestRequest(t, logical.ListOperation, "trash"))
+		if resp == nil {
+			t.Fatalf("trash is empty")
+		}
+		for id, info := range resp.Data["key_info"].(map[string]interface{}) {
+			if info.(map[string]interface{})["name"] == name {
+				return id
+			}
+		}
+		t.Fatalf("%q is not in trash: %#v", name, resp.Data)
+		return ""
+	}
+
+	// Policies are restored with their rules
+	rules := `path "secret/*" { capabilities = ["read"] }`
+	req := logical.TestRequest(t, logical.UpdateOperation, "policy/foo")
+	req.Data["policy"] = rules
+	doReq(t, req)
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "policy/foo"))
+
+	id := trashID(t, "foo")
+	resp := doReq(t, logical.TestRequest(t, logical.ReadOperation, "trash/"+id))
+	if resp.Data["type"] != "policy" || resp.Data["policy"] != rules {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Restoring fails while the name is in use
+	req = logical.TestRequest(t, logical.UpdateOperation, "policy/foo")
+	req.Data["policy"] = `path "other/*" { capabilities = ["read"] }`
+	doReq(t, req)
+	resp, err := b.HandleRequest(ctx, logical.TestRequest(t, logical.UpdateOperation, "trash/"+id+"/restore"))
+	if err == nil && !resp.IsError() {
+		t.Fatalf("expected error restoring over an existing policy")
+	}
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "policy/foo"))
+
+	doReq(t, logical.TestRequest(t, logical.UpdateOperation, "trash/"+id+"/restore"))
+	p, err := c.policyStore.GetPolicy(ctx, "foo", PolicyTypeACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.Raw != rules {
+		t.Fatalf("policy was not restored: %#v", p)
+	}
+	resp = doReq(t, logical.TestRequest(t, logical.ReadOperation, "trash/"+id))
+	if resp != nil {
+		t.Fatalf("restored policy is still in trash: %#v", resp)
+	}
+
+	// Secrets engines are restored with their configuration
+	req = logical.TestRequest(t, logical.UpdateOperation, "mounts/foo")
+	req.Data["type"] = "kv"
+	req.Data["description"] = "trashed"
+	req.Data["config"] = map[string]interface{}{
+		"default_lease_ttl": "1h",
+	}
+	doReq(t, req)
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "mounts/foo"))
+	if c.router.MatchingMountEntry(ctx, "foo/") != nil {
+		t.Fatalf("mount was not disabled")
+	}
+
+	id = trashID(t, "foo/")
+	resp = doReq(t, logical.TestRequest(t, logical.ReadOperation, "trash/"+id))
+	if resp.Data["type"] != "mount" || resp.Data["mount"].(map[string]interface{})["type"] != "kv" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	doReq(t, logical.TestRequest(t, logical.UpdateOperation, "trash/"+id+"/restore"))
+	me := c.router.MatchingMountEntry(ctx, "foo/")
+	if me == nil || me.Description != "trashed" || me.Config.DefaultLeaseTTL != time.Hour {
+		t.Fatalf("mount was not restored: %#v", me)
+	}
+
+	// Auth methods are restored as well
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/foo")
+	req.Data["type"] = "noop"
+	doReq(t, req)
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "auth/foo"))
+	id = trashID(t, "auth/foo/")
+	doReq(t, logical.TestRequest(t, logical.UpdateOperation, "trash/"+id+"/restore"))
+	if c.router.MatchingMountEntry(ctx, "auth/foo/") == nil {
+		t.Fatalf("auth method was not restored")
+	}
+
+	// Items can be purged
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "policy/foo"))
+	id = trashID(t, "foo")
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "trash/"+id))
+	resp = doReq(t, logical.TestRequest(t, logical.ReadOperation, "trash/"+id))
+	if resp != nil {
+		t.Fatalf("purged policy is still in trash: %#v", resp)
+	}
+
+	// A retention of zero disables the trash
+	req = logical.TestRequest(t, logical.UpdateOperation, "config/trash")
+	req.Data["retention"] = 0
+	doReq(t, req)
+	resp = doReq(t, logical.TestRequest(t, logical.ReadOperation, "config/trash"))
+	if resp.Data["retention"] != int64(0) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	req = logical.TestRequest(t, logical.UpdateOperation, "policy/bar")
+	req.Data["policy"] = rules
+	doReq(t, req)
+	doReq(t, logical.TestRequest(t, logical.DeleteOperation, "policy/bar"))
+	resp = doReq(t, logical.TestRequest(t, logical.ListOperation, "trash"))
+	for _, info := range resp.Data["key_info"].(map[string]interface{}) {
+		if info.(map[string]interface{})["name"] == "bar" {
+			t.Fatalf("policy was kept in disabled trash: %#v", resp.Data)
+		}
+	}
+}
+
+func TestCore_purgeTrash(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	for _, name := range []string{"expired", "kept"} {
+		if err := c.trashPolicy(ctx, &Policy{Name: name, Raw: `path "secret/*" { capabilities = ["read"] }`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := c.listTrash(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name != "expired" {
+			continue
+		}
+		entry.ExpirationTime = time.Now().Add(-time.Minute)
+		storageEntry, err := logical.StorageEntryJSON(trashSubPath+entry.ID, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.systemBarrierView.Put(ctx, storageEntry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purged, err := c.purgeTrash(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged item, got %d", purged)
+	}
+	ids, err := c.systemBarrierView.List(ctx, trashSubPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 item left, got %d", len(ids))
+	}
+	entry, err := c.trashEntry(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || entry.Name != "kept" {
+		t.Fatalf("bad item left: %#v", entry)
+	}
+}
+
+func TestSystemBackend_InFlightRequests(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	var canceled bool
+	done := c.trackInFlightRequest(namespace.RootNamespace, &logical.Request{
+		ID:         "abc",
+		Path:       "auth/token/lookup/s.secret",
+		Operation:  logical.UpdateOperation,
+		Connection: &logical.Connection{RemoteAddr: "10.0.0.1"},
+	}, func() { canceled = true })
+	defer done()
+
+	resp, err := b.HandleRequest(ctx, logical.TestRequest(t, logical.ListOperation, "in-flight-req"))
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+	info, ok := resp.Data["key_info"].(map[string]interface{})["abc"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("request not listed: %#v", resp.Data)
+	}
+	if info["request_path"] != "auth/token/lookup/<redacted>" {
+		t.Fatalf("bad path: %v", info["request_path"])
+	}
+	if info["client_remote_address"] != "10.0.0.1" {
+		t.Fatalf("bad remote address: %v", info["client_remote_address"])
+	}
+
+	resp, err = b.HandleRequest(ctx, logical.TestRequest(t, logical.DeleteOperation, "in-flight-req/abc"))
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+	if !canceled {
+		t.Fatal("request was not canceled")
+	}
+
+	_, err = b.HandleRequest(ctx, logical.TestRequest(t, logical.DeleteOperation, "in-flight-req/unknown"))
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request, got: %v", err)
+	}
+}
+
 func TestSystemBackend_tuneAuth(t *testing.T) {
 	c, b, _ := testCoreSystemBackend(t)
 	c.credentialBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
@@ -1846,6 +2071,19 @@ func TestSystemBackend_auditHash(t *testing.T) {
 	if hash.(string) != "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317" {
 		t.Fatalf("bad hash back: %s", hash.(string))
 	}
+
+	// The salt allows computing the same hash offline
+	req = logical.TestRequest(t, logical.ReadOperation, "audit-salt/foo")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["salt"] != "foo" || resp.Data["hmac_type"] != "hmac-sha256" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if salt.HMACIdentifiedValue("foo", "bar", "hmac-sha256", sha256.New) != hash.(string) {
+		t.Fatal("expected the hash to be computed with the salt")
+	}
 }
 
 func TestSystemBackend_enableAudit_invalid(t *testing.T) {
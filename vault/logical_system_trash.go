@@ -0,0 +1,574 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// trashSubPath is the sub-path of the system view where deleted policies
+	// and mount configurations are kept until they expire
+	trashSubPath = "trash/"
+
+	// trashConfigPath is the path of the trash configuration in the system
+	// view
+	trashConfigPath = "config/trash"
+
+	// defaultTrashRetention is how long deleted items are kept by default
+	defaultTrashRetention = 7 * 24 * time.Hour
+
+	// trashPurgeTimeout bounds a purge of the expired items of the trash
+	trashPurgeTimeout = 5 * time.Minute
+
+	trashTypePolicy = "policy"
+	trashTypeMount  = "mount"
+	trashTypeAuth   = "auth"
+)
+
+// trashPurgeInterval is how often the expired items of the trash are purged
+var trashPurgeInterval = time.Hour
+
+// TrashConfig is the configuration of the trash.
+type TrashConfig struct {
+	// Retention is how long deleted items are kept. Zero disables the trash.
+	Retention time.Duration `json:"retention"`
+}
+
+// TrashEntry is a deleted policy or mount configuration that can be restored
+// until it expires. Only the configuration of mounts is kept: their data is
+// destroyed when they are disabled.
+type TrashEntry struct {
+	ID             string      `json:"id"`
+	Type           string      `json:"type"`
+	Name           string      `json:"name"`
+	NamespaceID    string      `json:"namespace_id"`
+	DeletionTime   time.Time   `json:"deletion_time"`
+	ExpirationTime time.Time   `json:"expiration_time"`
+	Policy         string      `json:"policy,omitempty"`
+	Mount          *MountEntry `json:"mount,omitempty"`
+}
+
+func (b *SystemBackend) trashPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config/trash$",
+			Fields: map[string]*framework.FieldSchema{
+				"retention": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long deleted policies and mount configurations are kept before being purged. Zero disables the trash.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTrashConfigRead(),
+					Summary:  "Return the trash configuration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleTrashConfigUpdate(),
+					Summary:  "Configure the trash.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(trashHelp["config"][0]),
+			HelpDescription: strings.TrimSpace(trashHelp["config"][1]),
+		},
+		{
+			Pattern: "trash/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleTrashList(),
+					Summary:  "List the deleted policies and mount configurations.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(trashHelp["trash-list"][0]),
+			HelpDescription: strings.TrimSpace(trashHelp["trash-list"][1]),
+		},
+		{
+			Pattern: "trash/" + framework.GenericNameRegex("id") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the deleted item.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleTrashRead(),
+					Summary:  "Return a deleted policy or mount configuration.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleTrashPurge(),
+					Summary:  "Permanently delete an item from the trash.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(trashHelp["trash"][0]),
+			HelpDescription: strings.TrimSpace(trashHelp["trash"][1]),
+		},
+		{
+			Pattern: "trash/" + framework.GenericNameRegex("id") + "/restore$",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the deleted item.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleTrashRestore(),
+					Summary:  "Restore a deleted policy or mount configuration.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(trashHelp["trash-restore"][0]),
+			HelpDescription: strings.TrimSpace(trashHelp["trash-restore"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleTrashConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config, err := b.Core.trashConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"retention": int64(config.Retention.Seconds()),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleTrashConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		config, err := b.Core.trashConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if retentionRaw, ok := d.GetOk("retention"); ok {
+			retention := time.Duration(retentionRaw.(int)) * time.Second
+			if retention < 0 {
+				return logical.ErrorResponse("'retention' cannot be negative"), nil
+			}
+			config.Retention = retention
+		}
+
+		entry, err := logical.StorageEntryJSON(trashConfigPath, config)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Core.systemBarrierView.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleTrashList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entries, err := b.Core.listTrash(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(entries))
+		keyInfo := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+			keyInfo[entry.ID] = map[string]interface{}{
+				"type":            entry.Type,
+				"name":            entry.Name,
+				"deletion_time":   entry.DeletionTime,
+				"expiration_time": entry.ExpirationTime,
+			}
+		}
+
+		return logical.ListResponseWithInfo(ids, keyInfo), nil
+	}
+}
+
+func (b *SystemBackend) handleTrashRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.Core.trashEntry(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, nil
+		}
+
+		data := map[string]interface{}{
+			"id":              entry.ID,
+			"type":            entry.Type,
+			"name":            entry.Name,
+			"deletion_time":   entry.DeletionTime,
+			"expiration_time": entry.ExpirationTime,
+		}
+		switch entry.Type {
+		case trashTypePolicy:
+			data["policy"] = entry.Policy
+		case trashTypeMount, trashTypeAuth:
+			entry.Mount.SyncCache()
+			info := mountInfo(entry.Mount)
+			// The restored mount gets new identifiers
+			delete(info, "accessor")
+			delete(info, "uuid")
+			data["mount"] = info
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleTrashPurge() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.Core.trashEntry(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, nil
+		}
+
+		if err := b.Core.systemBarrierView.Delete(ctx, trashSubPath+entry.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleTrashRestore() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entry, err := b.Core.trashEntry(ctx, d.Get("id").(string))
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return logical.ErrorResponse("item not found in trash"), logical.ErrInvalidRequest
+		}
+
+		switch entry.Type {
+		case trashTypePolicy:
+			if err := b.restorePolicy(ctx, entry); err != nil {
+				return handleError(err)
+			}
+
+		case trashTypeMount, trashTypeAuth:
+			me := &MountEntry{
+				Table:                 entry.Mount.Table,
+				Path:                  entry.Mount.Path,
+				Type:                  entry.Mount.Type,
+				Description:           entry.Mount.Description,
+				Config:                entry.Mount.Config,
+				Options:               entry.Mount.Options,
+				Local:                 entry.Mount.Local,
+				SealWrap:              entry.Mount.SealWrap,
+				ExternalEntropyAccess: entry.Mount.ExternalEntropyAccess,
+			}
+			if entry.Type == trashTypeAuth {
+				err = b.Core.enableCredential(ctx, me)
+			} else {
+				err = b.Core.mount(ctx, me)
+			}
+			if err != nil {
+				b.Backend.Logger().Error("error occurred restoring mount", "path", me.Path, "error", err)
+				return handleError(err)
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown type %q of item in trash", entry.Type)
+		}
+
+		if err := b.Core.systemBarrierView.Delete(ctx, trashSubPath+entry.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) restorePolicy(ctx context.Context, entry *TrashEntry) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing, err := b.Core.policyStore.GetPolicy(ctx, entry.Name, PolicyTypeACL)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return logical.CodedError(409, fmt.Sprintf("policy %q already exists", entry.Name))
+	}
+
+	p, err := ParseACLPolicy(ns, entry.Policy)
+	if err != nil {
+		return err
+	}
+	p.Name = entry.Name
+	p.Type = PolicyTypeACL
+
+	return b.Core.policyStore.SetPolicy(ctx, p)
+}
+
+// trashMountEntry keeps the configuration of a disabled mount in the trash. It
+// returns a response with a warning if this fails, since the mount is already
+// disabled.
+func (b *SystemBackend) trashMountEntry(ctx context.Context, entry *MountEntry) *logical.Response {
+	if entry == nil {
+		return nil
+	}
+	if err := b.Core.trashMount(ctx, entry); err != nil {
+		b.Backend.Logger().Error("failed to keep disabled mount in trash", "path", entry.Path, "error", err)
+		resp := &logical.Response{}
+		resp.AddWarning(fmt.Sprintf("mount was disabled but its configuration could not be kept in trash: %s", err))
+		return resp
+	}
+	return nil
+}
+
+// trashPolicy keeps a deleted ACL policy in the trash.
+func (c *Core) trashPolicy(ctx context.Context, policy *Policy) error {
+	return c.addToTrash(ctx, &TrashEntry{
+		Type:   trashTypePolicy,
+		Name:   policy.Name,
+		Policy: policy.Raw,
+	})
+}
+
+// trashMount keeps the configuration of a disabled secrets engine or auth
+// method in the trash.
+func (c *Core) trashMount(ctx context.Context, entry *MountEntry) error {
+	trashType, name := trashTypeMount, entry.Path
+	if entry.Table == credentialTableType {
+		trashType, name = trashTypeAuth, credentialRoutePrefix+entry.Path
+	}
+
+	clone, err := entry.Clone()
+	if err != nil {
+		return err
+	}
+	return c.addToTrash(ctx, &TrashEntry{
+		Type:  trashType,
+		Name:  name,
+		Mount: clone,
+	})
+}
+
+func (c *Core) addToTrash(ctx context.Context, entry *TrashEntry) error {
+	config, err := c.trashConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Retention == 0 {
+		return nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry.ID, err = uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	entry.NamespaceID = ns.ID
+	entry.DeletionTime = time.Now().UTC()
+	entry.ExpirationTime = entry.DeletionTime.Add(config.Retention)
+
+	storageEntry, err := logical.StorageEntryJSON(trashSubPath+entry.ID, entry)
+	if err != nil {
+		return err
+	}
+	return c.systemBarrierView.Put(ctx, storageEntry)
+}
+
+func (c *Core) trashConfig(ctx context.Context) (*TrashConfig, error) {
+	entry, err := c.systemBarrierView.Get(ctx, trashConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	config := &TrashConfig{
+		Retention: defaultTrashRetention,
+	}
+	if entry == nil {
+		return config, nil
+	}
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// startTrashPurge starts purging the expired items of the trash periodically.
+// It is invoked on the active node as part of postUnseal.
+func (c *Core) startTrashPurge() {
+	activeCtx := c.activeContext
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	c.trashPurgeStopCh, c.trashPurgeDoneCh = stopCh, doneCh
+
+	go func() {
+		defer close(doneCh)
+
+		ticker := time.NewTicker(trashPurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+
+			ctx, cancel := context.WithTimeout(activeCtx, trashPurgeTimeout)
+			purged, err := c.purgeTrash(ctx)
+			cancel()
+			if err != nil {
+				c.logger.Error("failed to purge the trash", "error", err)
+			} else if purged > 0 {
+				c.logger.Info("purged expired items from the trash", "count", purged)
+			}
+		}
+	}()
+}
+
+// stopTrashPurge stops the periodic purge of the trash, waiting for a purge
+// in progress. It is invoked as part of preSeal.
+func (c *Core) stopTrashPurge() {
+	if c.trashPurgeStopCh == nil {
+		return
+	}
+	close(c.trashPurgeStopCh)
+	<-c.trashPurgeDoneCh
+	c.trashPurgeStopCh, c.trashPurgeDoneCh = nil, nil
+}
+
+// purgeTrash deletes the items of the trash of all namespaces whose
+// expiration time, set from the retention configured when they were deleted,
+// has passed. It returns the number of deleted items.
+func (c *Core) purgeTrash(ctx context.Context) (int, error) {
+	ids, err := c.systemBarrierView.List(ctx, trashSubPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+
+		storageEntry, err := c.systemBarrierView.Get(ctx, trashSubPath+id)
+		if err != nil {
+			return purged, err
+		}
+		if storageEntry == nil {
+			continue
+		}
+		var entry TrashEntry
+		if err := storageEntry.DecodeJSON(&entry); err != nil {
+			return purged, err
+		}
+		if !time.Now().After(entry.ExpirationTime) {
+			continue
+		}
+
+		if err := c.systemBarrierView.Delete(ctx, trashSubPath+id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// trashEntry returns the item of the trash with the given ID, or nil if it
+// does not exist, has expired or belongs to another namespace. Expired items
+// are purged.
+func (c *Core) trashEntry(ctx context.Context, id string) (*TrashEntry, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storageEntry, err := c.systemBarrierView.Get(ctx, trashSubPath+id)
+	if err != nil {
+		return nil, err
+	}
+	if storageEntry == nil {
+		return nil, nil
+	}
+
+	var entry TrashEntry
+	if err := storageEntry.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	if time.Now().After(entry.ExpirationTime) {
+		if err := c.systemBarrierView.Delete(ctx, trashSubPath+id); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if entry.NamespaceID != ns.ID {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// listTrash returns the items of the trash in the namespace of the context,
+// most recently deleted first, and purges expired items.
+func (c *Core) listTrash(ctx context.Context) ([]*TrashEntry, error) {
+	ids, err := c.systemBarrierView.List(ctx, trashSubPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*TrashEntry, 0, len(ids))
+	for _, id := range ids {
+		entry, err := c.trashEntry(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletionTime.After(entries[j].DeletionTime)
+	})
+	return entries, nil
+}
+
+var trashHelp = map[string][2]string{
+	"config": {
+		"Configure how long deleted items are kept in the trash.",
+		`Deleted ACL policies and the configurations of disabled secrets engines and
+auth methods are kept in the trash for the configured retention, 7 days by
+default, and can be restored until then. A retention of zero disables the
+trash.`,
+	},
+	"trash-list": {
+		"List the deleted policies and mount configurations.",
+		"Expired items are purged hourly, and when the trash is listed.",
+	},
+	"trash": {
+		"Read or permanently delete an item of the trash.",
+		"",
+	},
+	"trash-restore": {
+		"Restore a deleted policy or mount configuration.",
+		`Policies are restored under their previous name, which must not be in use.
+Secrets engines and auth methods are enabled again at their previous path with
+their previous configuration. Their data was destroyed when they were disabled
+and is not restored.`,
+	},
+}
@@ -0,0 +1,139 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+)
+
+// ErrNoWindow is returned when waiting for a schedule whose windows never
+// open again.
+var ErrNoWindow = errors.New("no upcoming maintenance window")
+
+// Window is a recurring period of time during which heavy background jobs are
+// allowed to run. The window opens at every time matching its cron schedule,
+// evaluated in UTC, and stays open for its duration.
+type Window struct {
+	Schedule string
+	Duration time.Duration
+
+	expr *cronexpr.Expression
+}
+
+// NewWindow parses the cron schedule of a maintenance window.
+func NewWindow(schedule string, duration time.Duration) (*Window, error) {
+	if schedule == "" {
+		return nil, errors.New("schedule is required")
+	}
+	if duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+
+	expr, err := cronexpr.Parse(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+
+	return &Window{
+		Schedule: schedule,
+		Duration: duration,
+		expr:     expr,
+	}, nil
+}
+
+// Open returns whether the window is open at t, and if so, when it closes.
+func (w *Window) Open(t time.Time) (bool, time.Time) {
+	// The window is open if it was opened in the last duration
+	start := w.expr.Next(t.UTC().Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return false, time.Time{}
+	}
+	return true, start.Add(w.Duration)
+}
+
+// Next returns the next time the window opens after t, or the zero time if
+// it never opens again.
+func (w *Window) Next(t time.Time) time.Time {
+	return w.expr.Next(t.UTC())
+}
+
+// Schedule is the set of maintenance windows of a server. A schedule without
+// windows is always open, so that background jobs run as soon as they are
+// started.
+type Schedule struct {
+	windows []*Window
+}
+
+// NewSchedule returns a schedule with the given windows.
+func NewSchedule(windows []*Window) *Schedule {
+	return &Schedule{
+		windows: windows,
+	}
+}
+
+// Windows returns the windows of the schedule.
+func (s *Schedule) Windows() []*Window {
+	if s == nil {
+		return nil
+	}
+	return s.windows
+}
+
+// Open returns whether any window of the schedule is open at t.
+func (s *Schedule) Open(t time.Time) bool {
+	if s == nil || len(s.windows) == 0 {
+		return true
+	}
+
+	for _, w := range s.windows {
+		if open, _ := w.Open(t); open {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns t if the schedule is open at t, and otherwise the next time a
+// window opens, or the zero time if none ever opens again.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.Open(t) {
+		return t
+	}
+
+	var next time.Time
+	for _, w := range s.windows {
+		n := w.Next(t)
+		if n.IsZero() {
+			continue
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
+}
+
+// Wait blocks until a window of the schedule is open or the context is done.
+func (s *Schedule) Wait(ctx context.Context) error {
+	for {
+		now := time.Now()
+		next := s.Next(now)
+		switch {
+		case next.IsZero():
+			return ErrNoWindow
+		case !next.After(now):
+			return nil
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewWindow(t *testing.T) {
+	testCases := map[string]struct {
+		schedule  string
+		duration  time.Duration
+		expectErr bool
+	}{
+		"valid":            {"0 2 * * *", time.Hour, false},
+		"valid day names":  {"30 1 * * SAT,SUN", 4 * time.Hour, false},
+		"empty schedule":   {"", time.Hour, true},
+		"invalid schedule": {"every night", time.Hour, true},
+		"zero duration":    {"0 2 * * *", 0, true},
+		"negative":         {"0 2 * * *", -time.Hour, true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewWindow(tc.schedule, tc.duration)
+			if tc.expectErr && err == nil {
+				t.Fatal("expected error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWindow_Open(t *testing.T) {
+	// Opens at 02:00 UTC every day for 4 hours
+	w, err := NewWindow("0 2 * * *", 4*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2020, 10, 12, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		t      time.Time
+		open   bool
+		closes time.Time
+	}{
+		{day.Add(1 * time.Hour), false, time.Time{}},
+		{day.Add(2 * time.Hour), true, day.Add(6 * time.Hour)},
+		{day.Add(5*time.Hour + 59*time.Minute), true, day.Add(6 * time.Hour)},
+		{day.Add(6 * time.Hour), false, time.Time{}},
+		{day.Add(23 * time.Hour), false, time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		open, closes := w.Open(tc.t)
+		if open != tc.open || !closes.Equal(tc.closes) {
+			t.Fatalf("at %s: expected open %t closing at %s, got %t closing at %s", tc.t, tc.open, tc.closes, open, closes)
+		}
+	}
+
+	// Schedules are evaluated in UTC
+	local := day.Add(3 * time.Hour).In(time.FixedZone("UTC+10", 10*60*60))
+	if open, _ := w.Open(local); !open {
+		t.Fatalf("expected window to be open at %s", local)
+	}
+
+	if next := w.Next(day.Add(3 * time.Hour)); !next.Equal(day.Add(26 * time.Hour)) {
+		t.Fatalf("bad next opening: %s", next)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	day := time.Date(2020, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	// A schedule without windows is always open
+	var empty *Schedule
+	if !empty.Open(day) || !NewSchedule(nil).Open(day) {
+		t.Fatal("expected schedule without windows to be open")
+	}
+	if err := NewSchedule(nil).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	nightly, err := NewWindow("0 2 * * *", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weekend, err := NewWindow("0 12 * * SAT,SUN", 6*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSchedule([]*Window{nightly, weekend})
+
+	// 2020-10-12 is a Monday
+	if s.Open(day.Add(12 * time.Hour)) {
+		t.Fatal("expected schedule to be closed on Monday noon")
+	}
+	if !s.Open(day.Add(2*time.Hour + 30*time.Minute)) {
+		t.Fatal("expected schedule to be open during the nightly window")
+	}
+	if !s.Open(day.Add(5*24*time.Hour + 13*time.Hour)) {
+		t.Fatal("expected schedule to be open during the weekend window")
+	}
+
+	if next := s.Next(day.Add(12 * time.Hour)); !next.Equal(day.Add(26 * time.Hour)) {
+		t.Fatalf("bad next opening: %s", next)
+	}
+	if now := day.Add(2 * time.Hour); !s.Next(now).Equal(now) {
+		t.Fatalf("expected next opening of an open schedule to be now, got %s", s.Next(now))
+	}
+
+	// Waiting stops with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	future, err := NewWindow("0 0 1 1 * 2099", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSchedule([]*Window{future}).Wait(ctx); err != context.Canceled {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
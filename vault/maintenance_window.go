@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// waitForMaintenanceWindow blocks a heavy background job until a maintenance
+// window is open, or returns an error if the context is done first.
+func (c *Core) waitForMaintenanceWindow(ctx context.Context, logger log.Logger, job string) error {
+	now := time.Now()
+	if c.maintenanceSchedule.Open(now) {
+		return nil
+	}
+
+	logger.Info("waiting for maintenance window", "job", job, "next_window", c.maintenanceSchedule.Next(now))
+	if err := c.maintenanceSchedule.Wait(ctx); err != nil {
+		return err
+	}
+	logger.Info("maintenance window opened", "job", job)
+
+	return nil
+}
+
+// addMaintenanceWindowWarning warns that a background job is deferred until
+// the next maintenance window, if none is currently open.
+func (c *Core) addMaintenanceWindowWarning(resp *logical.Response, job string) {
+	now := time.Now()
+	if c.maintenanceSchedule.Open(now) {
+		return
+	}
+
+	next := c.maintenanceSchedule.Next(now)
+	if next.IsZero() {
+		resp.AddWarning(fmt.Sprintf("No maintenance window will open again, the %s operation will not run.", job))
+		return
+	}
+	resp.AddWarning(fmt.Sprintf("No maintenance window is open, the %s operation will start at %s.", job, next.Format(time.RFC3339)))
+}
@@ -0,0 +1,58 @@
+package vault
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/maintenance"
+)
+
+func TestCore_MaintenanceWindows(t *testing.T) {
+	window, err := maintenance.NewWindow("0 0 1 1 * 2099", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		MaintenanceWindows: []*maintenance.Window{window},
+	})
+
+	for _, path := range []string{"sys/leases/tidy", "auth/token/tidy"} {
+		resp, err := c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			ClientToken: root,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		body := resp.Data[logical.HTTPRawBody].(string)
+		if !strings.Contains(body, "will start at 2099-01-01T00:00:00Z") {
+			t.Fatalf("%s: expected warning about the next maintenance window, got %s", path, body)
+		}
+	}
+
+	// The token tidy is still pending, so another one cannot be started
+	if atomic.LoadUint32(c.tokenStore.tidyLock) != 1 {
+		t.Fatal("expected token tidy to wait for the maintenance window")
+	}
+}
+
+func TestCore_MaintenanceWindows_none(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	resp, err := c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/leases/tidy",
+		ClientToken: root,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := resp.Data[logical.HTTPRawBody].(string); strings.Contains(body, "maintenance window") {
+		t.Fatalf("expected no maintenance window warning, got %s", body)
+	}
+}
@@ -441,6 +441,7 @@ func (c *Core) mount(ctx context.Context, entry *MountEntry) error {
 		return err
 	}
 
+	c.publishMountChanged(ctx, "enable", entry.Path, "", entry.Type)
 	return nil
 }
 
@@ -621,6 +622,8 @@ func (c *Core) unmount(ctx context.Context, path string) error {
 		// Even we failed to evaluate filtered paths, the unmount operation was still successful
 		c.logger.Error("failed to evaluate filtered paths", "error", err)
 	}
+
+	c.publishMountChanged(ctx, "disable", path, "", "")
 	return nil
 }
 
@@ -945,6 +948,8 @@ func (c *Core) remount(ctx context.Context, src, dst string, updateStorage bool)
 	if c.logger.IsInfo() {
 		c.logger.Info("successful remount", "old_path", src, "new_path", dst)
 	}
+
+	c.publishMountChanged(ctx, "move", src, dst, entry.Type)
 	return nil
 }
 
@@ -1553,6 +1558,7 @@ func (c *Core) setCoreBackend(entry *MountEntry, backend logical.Backend, view *
 		ch := backend.(*CubbyholeBackend)
 		ch.saltUUID = entry.UUID
 		ch.storageView = view
+		ch.setMaxSize(c.cubbyholeMaxSize)
 		c.cubbyholeBackend = ch
 	case identityMountType:
 		c.identityStore = backend.(*IdentityStore)
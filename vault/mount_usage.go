@@ -0,0 +1,190 @@
+package vault
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var (
+	// mountUsageInitialDelay delays the first walk of the storage of the
+	// mounts, so that it does not add to the load of unsealing
+	mountUsageInitialDelay = time.Minute
+
+	// mountUsageInterval is how often the storage of the mounts is walked
+	// to update their usage
+	mountUsageInterval = 10 * time.Minute
+)
+
+// MountStorageUsage is the approximate storage usage of a mount.
+type MountStorageUsage struct {
+	// Path is the API path of the mount, including its namespace
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Accessor string `json:"accessor"`
+	Local    bool   `json:"local"`
+
+	// Entries is the number of storage entries of the mount
+	Entries int `json:"entries"`
+	// Bytes is the total size of the keys and decrypted values of the
+	// storage entries of the mount
+	Bytes int64 `json:"bytes"`
+	// UpdatedTime is when the storage of the mount was last walked
+	UpdatedTime time.Time `json:"updated_time"`
+
+	namespace *namespace.Namespace
+}
+
+// mountUsage stores the usage of the mounts, indexed by mount UUID. The
+// storage of the mounts is walked one mount at a time, so the usage of each
+// mount is available as soon as it has been walked.
+type mountUsage struct {
+	l     sync.RWMutex
+	usage map[string]*MountStorageUsage
+}
+
+// mountUsageLoop walks the storage of the mounts every mountUsageInterval
+// until stopCh is closed.
+func (c *Core) mountUsageLoop(stopCh chan struct{}) {
+	ctx, cancel := context.WithCancel(namespace.RootContext(nil))
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	timer := time.NewTimer(mountUsageInitialDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			c.updateMountUsage(ctx)
+			timer.Reset(mountUsageInterval)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// updateMountUsage walks the storage of all the mounts, and forgets the
+// usage of the mounts that no longer exist.
+func (c *Core) updateMountUsage(ctx context.Context) {
+	var entries []*MountEntry
+	c.mountsLock.RLock()
+	if c.mounts != nil {
+		entries = append(entries, c.mounts.Entries...)
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	if c.auth != nil {
+		entries = append(entries, c.auth.Entries...)
+	}
+	c.authLock.RUnlock()
+
+	current := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		// The view of the system backend contains the storage of Vault
+		// itself, including the storage of the token store
+		if entry.Type == systemMountType {
+			continue
+		}
+		current[entry.UUID] = true
+
+		usage, err := c.walkMountStorage(ctx, entry)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("failed to compute mount storage usage", "path", entry.APIPath(), "error", err)
+			continue
+		}
+
+		c.mountUsage.l.Lock()
+		if c.mountUsage.usage == nil {
+			c.mountUsage.usage = make(map[string]*MountStorageUsage)
+		}
+		c.mountUsage.usage[entry.UUID] = usage
+		c.mountUsage.l.Unlock()
+
+		labels := []metrics.Label{
+			metricsutil.NamespaceLabel(usage.namespace),
+			{"mount_point", usage.Path},
+		}
+		c.metricSink.SetGaugeWithLabels([]string{"mount", "storage", "entries"}, float32(usage.Entries), labels)
+		c.metricSink.SetGaugeWithLabels([]string{"mount", "storage", "bytes"}, float32(usage.Bytes), labels)
+	}
+
+	c.mountUsage.l.Lock()
+	for uuid := range c.mountUsage.usage {
+		if !current[uuid] {
+			delete(c.mountUsage.usage, uuid)
+		}
+	}
+	c.mountUsage.l.Unlock()
+}
+
+// walkMountStorage counts the storage entries of the mount and their size.
+func (c *Core) walkMountStorage(ctx context.Context, entry *MountEntry) (*MountStorageUsage, error) {
+	usage := &MountStorageUsage{
+		Path:      entry.APIPath(),
+		Type:      entry.Type,
+		Accessor:  entry.Accessor,
+		Local:     entry.Local,
+		namespace: entry.namespace,
+	}
+
+	view := c.router.MatchingStorageByAPIPath(namespace.RootContext(ctx), usage.Path)
+	if view == nil {
+		// The mount was removed
+		return usage, nil
+	}
+
+	var getErr error
+	err := logical.ScanView(ctx, view, func(path string) {
+		if getErr != nil {
+			return
+		}
+		se, err := view.Get(ctx, path)
+		if err != nil {
+			getErr = err
+			return
+		}
+		if se == nil {
+			// The entry was deleted during the walk
+			return
+		}
+		usage.Entries++
+		usage.Bytes += int64(len(path) + len(se.Value))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	usage.UpdatedTime = time.Now()
+	return usage, nil
+}
+
+// mountStorageUsage returns the last computed usage of the mounts, sorted by
+// path.
+func (c *Core) mountStorageUsage() []*MountStorageUsage {
+	c.mountUsage.l.RLock()
+	defer c.mountUsage.l.RUnlock()
+
+	usage := make([]*MountStorageUsage, 0, len(c.mountUsage.usage))
+	for _, u := range c.mountUsage.usage {
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Path < usage[j].Path
+	})
+	return usage
+}
@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestCore_MountStorageUsage(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/usage-test")
+	req.ClientToken = root
+	req.Data["type"] = "kv"
+	if resp, err := c.HandleRequest(ctx, req); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	for _, path := range []string{"usage-test/foo", "usage-test/bar/baz"} {
+		req = logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = root
+		req.Data["value"] = "secret"
+		if resp, err := c.HandleRequest(ctx, req); err != nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+	}
+
+	c.updateMountUsage(context.Background())
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/counters/storage")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	var usage *MountStorageUsage
+	for _, u := range resp.Data["mounts"].([]*MountStorageUsage) {
+		if u.Type == systemMountType {
+			t.Fatalf("the system mount was reported")
+		}
+		if u.Path == "usage-test/" {
+			usage = u
+		}
+	}
+	if usage == nil {
+		t.Fatalf("usage of the mount was not reported: %#v", resp.Data["mounts"])
+	}
+	if usage.Entries != 2 || usage.Bytes <= int64(len("foo")+len("bar/baz")) || usage.UpdatedTime.IsZero() {
+		t.Fatalf("bad usage: %#v", usage)
+	}
+
+	// The usage of removed mounts is forgotten
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/mounts/usage-test")
+	req.ClientToken = root
+	if resp, err := c.HandleRequest(ctx, req); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	c.updateMountUsage(context.Background())
+	for _, u := range c.mountStorageUsage() {
+		if u.Path == "usage-test/" {
+			t.Fatalf("usage of the removed mount was reported")
+		}
+	}
+}
@@ -37,6 +37,10 @@ type PluginCatalog struct {
 	catalogView     *BarrierView
 	directory       string
 
+	// databaseClients holds the processes of the multiplexed database
+	// plugins, shared by the connections of all the database mounts
+	databaseClients *v5.PluginClients
+
 	lock sync.RWMutex
 }
 
@@ -45,6 +49,7 @@ func (c *Core) setupPluginCatalog(ctx context.Context) error {
 		builtinRegistry: c.builtinRegistry,
 		catalogView:     NewBarrierView(c.barrier, pluginCatalogPath),
 		directory:       c.pluginDirectory,
+		databaseClients: v5.NewPluginClients(),
 	}
 
 	// Run upgrade if untyped plugins exist
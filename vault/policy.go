@@ -15,6 +15,8 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/hclutil"
 	"github.com/hashicorp/vault/sdk/helper/identitytpl"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/copystructure"
 )
 
@@ -95,6 +97,9 @@ type Policy struct {
 	Type      PolicyType
 	Templated bool
 	namespace *namespace.Namespace
+
+	// Conditional is set if some path rules of the policy have conditions
+	Conditional bool
 }
 
 // ShallowClone returns a shallow clone of the policy. This should not be used
@@ -108,9 +113,25 @@ func (p *Policy) ShallowClone() *Policy {
 		Type:           p.Type,
 		Templated:      p.Templated,
 		namespace:      p.namespace,
+		Conditional:    p.Conditional,
 	}
 }
 
+// forSession returns the policy without the path rules whose conditions are
+// not satisfied by the session of the token.
+func (p *Policy) forSession(session *logical.AuthSession) *Policy {
+	paths := make([]*PathRules, 0, len(p.Paths))
+	for _, pc := range p.Paths {
+		if pc.Conditions == nil || pc.Conditions.satisfiedBy(session) {
+			paths = append(paths, pc)
+		}
+	}
+
+	clone := p.ShallowClone()
+	clone.Paths = paths
+	return clone
+}
+
 // PathRules represents a policy for a path in the namespace.
 type PathRules struct {
 	Path                string
@@ -120,6 +141,10 @@ type PathRules struct {
 	HasSegmentWildcards bool
 	Capabilities        []string
 
+	// Conditions, if set, restrict the rule to the tokens whose session
+	// satisfies them
+	Conditions *PathConditions `hcl:"conditions"`
+
 	// These keys are used at the top level to make the HCL nicer; we store in
 	// the ACLPermissions object though
 	MinWrappingTTLHCL     interface{}              `hcl:"min_wrapping_ttl"`
@@ -131,6 +156,34 @@ type PathRules struct {
 	ControlGroupHCL       *ControlGroupHCL         `hcl:"control_group"`
 }
 
+// PathConditions are the conditions on how the client authenticated for a
+// path rule to apply. All the conditions set must be satisfied.
+type PathConditions struct {
+	AuthMountTypes     []string `hcl:"auth_mount_types"`
+	AuthMountAccessors []string `hcl:"auth_mount_accessors"`
+	MFA                bool     `hcl:"mfa"`
+	KeyTypes           []string `hcl:"key_types"`
+	MinKeyBits         int      `hcl:"min_key_bits"`
+}
+
+func (c *PathConditions) satisfiedBy(session *logical.AuthSession) bool {
+	switch {
+	case session == nil:
+		return false
+	case len(c.AuthMountTypes) > 0 && !strutil.StrListContains(c.AuthMountTypes, session.MountType):
+		return false
+	case len(c.AuthMountAccessors) > 0 && !strutil.StrListContains(c.AuthMountAccessors, session.MountAccessor):
+		return false
+	case c.MFA && !session.MFA:
+		return false
+	case len(c.KeyTypes) > 0 && !strutil.StrListContains(c.KeyTypes, session.KeyType):
+		return false
+	case c.MinKeyBits > 0 && session.KeyBits < c.MinKeyBits:
+		return false
+	}
+	return true
+}
+
 type ControlGroupHCL struct {
 	TTL     interface{}                    `hcl:"ttl"`
 	Factors map[string]*ControlGroupFactor `hcl:"factor"`
@@ -161,6 +214,10 @@ type ACLPermissions struct {
 	RequiredParameters []string
 	MFAMethods         []string
 	ControlGroup       *ControlGroup
+
+	// GrantingPoliciesMap maps each capability to the names of the policies
+	// granting it. It is only set on the permissions compiled into an ACL.
+	GrantingPoliciesMap map[uint32][]string
 }
 
 func (p *ACLPermissions) Clone() (*ACLPermissions, error) {
@@ -217,6 +274,13 @@ func (p *ACLPermissions) Clone() (*ACLPermissions, error) {
 		ret.ControlGroup = clonedControlGroup.(*ControlGroup)
 	}
 
+	if p.GrantingPoliciesMap != nil {
+		ret.GrantingPoliciesMap = make(map[uint32][]string, len(p.GrantingPoliciesMap))
+		for capability, policies := range p.GrantingPoliciesMap {
+			ret.GrantingPoliciesMap[capability] = append([]string(nil), policies...)
+		}
+	}
+
 	return ret, nil
 }
 
@@ -318,10 +382,14 @@ func parsePaths(result *Policy, list *ast.ObjectList, performTemplating bool, en
 			"max_wrapping_ttl",
 			"mfa_methods",
 			"control_group",
+			"conditions",
 		}
 		if err := hclutil.CheckHCLKeys(item.Val, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("path %q:", key))
 		}
+		if err := checkConditionsHCLKeys(item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("path %q:", key))
+		}
 
 		var pc PathRules
 
@@ -376,6 +444,18 @@ func parsePaths(result *Policy, list *ast.ObjectList, performTemplating bool, en
 			}
 		}
 
+		if pc.Conditions != nil {
+			// A rule whose conditions are not satisfied does not apply, which
+			// would lift a denial
+			if strutil.StrListContains(pc.Capabilities, DenyCapability) {
+				return fmt.Errorf("path %q: conditions cannot be set on a rule denying access", key)
+			}
+			if pc.Conditions.MinKeyBits < 0 {
+				return fmt.Errorf("path %q: min_key_bits cannot be negative", key)
+			}
+			result.Conditional = true
+		}
+
 		// Initialize the map
 		pc.Permissions.CapabilitiesBitmap = 0
 		for _, cap := range pc.Capabilities {
@@ -476,3 +556,31 @@ func parsePaths(result *Policy, list *ast.ObjectList, performTemplating bool, en
 	result.Paths = paths
 	return nil
 }
+
+// checkConditionsHCLKeys checks the keys of the conditions of a path rule, as
+// an unknown condition would otherwise be ignored and grant the rule to more
+// tokens than intended.
+func checkConditionsHCLKeys(node ast.Node) error {
+	obj, ok := node.(*ast.ObjectType)
+	if !ok {
+		return nil
+	}
+
+	conditions := obj.List.Filter("conditions")
+	if len(conditions.Items) > 1 {
+		return errors.New("conditions can only be set once")
+	}
+	for _, item := range conditions.Items {
+		valid := []string{
+			"auth_mount_types",
+			"auth_mount_accessors",
+			"mfa",
+			"key_types",
+			"min_key_bits",
+		}
+		if err := hclutil.CheckHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, "conditions:")
+		}
+	}
+	return nil
+}
@@ -79,6 +79,14 @@ path "auth/token/revoke-self" {
     capabilities = ["update"]
 }
 
+# Allow tokens to list and revoke the tokens of their own entity
+path "auth/token/self-accessors" {
+    capabilities = ["list"]
+}
+path "auth/token/revoke-self-accessor" {
+    capabilities = ["update"]
+}
+
 # Allow a token to look up its own capabilities on a path
 path "sys/capabilities-self" {
     capabilities = ["update"]
@@ -749,8 +757,9 @@ func (t *TemplateError) Error() string {
 }
 
 // ACL is used to return an ACL which is built using the
-// named policies.
-func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyNames map[string][]string) (*ACL, error) {
+// named policies. The path rules with conditions are only included if the
+// session of the token satisfies them.
+func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, session *logical.AuthSession, policyNames map[string][]string) (*ACL, error) {
 	var policies []*Policy
 	// Fetch the policies
 	for nsID, nsPolicyNames := range policyNames {
@@ -796,6 +805,12 @@ func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyN
 		}
 	}
 
+	for i, policy := range policies {
+		if policy.Type == PolicyTypeACL && policy.Conditional {
+			policies[i] = policy.forSession(session)
+		}
+	}
+
 	// Construct the ACL
 	acl, err := NewACL(ctx, policies)
 	if err != nil {
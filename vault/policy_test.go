@@ -1,12 +1,14 @@
 package vault
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-test/deep"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
 )
 
 var rawPolicy = strings.TrimSpace(`
@@ -429,3 +431,131 @@ path "foo/+*" {
 		t.Errorf("bad error: %s", err)
 	}
 }
+
+func TestPolicy_ParseConditions(t *testing.T) {
+	p, err := ParseACLPolicy(namespace.RootNamespace, strings.TrimSpace(`
+path "secret/*" {
+	capabilities = ["read"]
+}
+path "secret/prod/*" {
+	capabilities = ["create", "update"]
+	conditions {
+		auth_mount_types = ["oidc", "cert"]
+		mfa              = true
+	}
+}
+path "pki/issue/admin" {
+	capabilities = ["update"]
+	conditions {
+		key_types    = ["rsa"]
+		min_key_bits = 3072
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Conditional {
+		t.Fatal("expected the policy to be conditional")
+	}
+
+	expected := &PathConditions{
+		AuthMountTypes: []string{"oidc", "cert"},
+		MFA:            true,
+	}
+	if diff := deep.Equal(p.Paths[1].Conditions, expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	tests := map[string]struct {
+		session  *logical.AuthSession
+		expected []string
+	}{
+		"no session": {
+			expected: []string{"secret/"},
+		},
+		"userpass": {
+			session:  &logical.AuthSession{MountType: "userpass"},
+			expected: []string{"secret/"},
+		},
+		"oidc without mfa": {
+			session:  &logical.AuthSession{MountType: "oidc"},
+			expected: []string{"secret/"},
+		},
+		"oidc with mfa": {
+			session:  &logical.AuthSession{MountType: "oidc", MFA: true},
+			expected: []string{"secret/", "secret/prod/"},
+		},
+		"weak key": {
+			session:  &logical.AuthSession{MountType: "cert", KeyType: "rsa", KeyBits: 2048},
+			expected: []string{"secret/"},
+		},
+		"strong key": {
+			session:  &logical.AuthSession{MountType: "cert", KeyType: "rsa", KeyBits: 4096, MFA: true},
+			expected: []string{"secret/", "secret/prod/", "pki/issue/admin"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			for _, pc := range p.forSession(tc.session).Paths {
+				paths = append(paths, pc.Path)
+			}
+			if !reflect.DeepEqual(paths, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, paths)
+			}
+		})
+	}
+	if len(p.Paths) != 3 {
+		t.Fatal("expected the policy to be left unchanged")
+	}
+}
+
+func TestPolicy_ParseBadConditions(t *testing.T) {
+	tests := map[string]struct {
+		rules    string
+		expected string
+	}{
+		"unknown condition": {
+			rules: `
+path "/" {
+	capabilities = ["read"]
+	conditions {
+		mfa_satisfied = true
+	}
+}`,
+			expected: `invalid key "mfa_satisfied" on line 4`,
+		},
+		"deny": {
+			rules: `
+path "/" {
+	capabilities = ["deny"]
+	conditions {
+		auth_mount_types = ["userpass"]
+	}
+}`,
+			expected: `path "/": conditions cannot be set on a rule denying access`,
+		},
+		"repeated": {
+			rules: `
+path "/" {
+	capabilities = ["read"]
+	conditions {
+		mfa = true
+	}
+	conditions {
+		min_key_bits = 2048
+	}
+}`,
+			expected: `conditions can only be set once`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseACLPolicy(namespace.RootNamespace, strings.TrimSpace(tc.rules))
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Fatalf("expected an error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -24,6 +24,9 @@ const (
 
 	// TypeLeaseCount represents the lease count limiting quota type
 	TypeLeaseCount Type = "lease-count"
+
+	// TypeConcurrency represents the concurrent requests limiting quota type
+	TypeConcurrency Type = "concurrency"
 )
 
 // LeaseAction is the action taken by the expiration manager on the lease. The
@@ -75,6 +78,8 @@ func (q Type) String() string {
 		return "lease-count"
 	case TypeRateLimit:
 		return "rate-limit"
+	case TypeConcurrency:
+		return "concurrency"
 	}
 	return "unknown"
 }
@@ -111,6 +116,10 @@ var (
 	// ErrRateLimitQuotaExceeded is returned when a request is rejected due to a
 	// rate limit quota being exceeded.
 	ErrRateLimitQuotaExceeded = errors.New("rate limit quota exceeded")
+
+	// ErrConcurrencyQuotaExceeded is returned when a request is rejected due to
+	// a concurrency quota being exceeded.
+	ErrConcurrencyQuotaExceeded = errors.New("concurrency quota exceeded")
 )
 
 var defaultExemptPaths = []string{
@@ -201,6 +210,10 @@ type Response struct {
 	// Headers defines any optional headers that may be returned by the quota rule
 	// to clients.
 	Headers map[string]string
+
+	// Release, if set, must be called once an allowed request has been handled,
+	// to free the resources the quota rule holds for it.
+	Release func()
 }
 
 // Config holds operator preferences around quota behaviors
@@ -822,6 +835,8 @@ func Load(ctx context.Context, storage logical.Storage, qType, name string) (Quo
 		quota = &RateLimitQuota{}
 	case TypeLeaseCount.String():
 		quota = &LeaseCountQuota{}
+	case TypeConcurrency.String():
+		quota = &ConcurrencyQuota{}
 	default:
 		return nil, fmt.Errorf("unsupported type: %v", qType)
 	}
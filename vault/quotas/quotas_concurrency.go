@@ -0,0 +1,219 @@
+package quotas
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+)
+
+// DefaultConcurrencyQueueTimeout is the default duration a request waits for
+// a slot when a concurrency quota allows queueing.
+const DefaultConcurrencyQueueTimeout = 10 * time.Second
+
+// Ensure that ConcurrencyQuota implements the Quota interface
+var _ Quota = (*ConcurrencyQuota)(nil)
+
+// ConcurrencyQuota represents the quota rule properties that is used to limit
+// the number of requests being handled at the same time for a namespace or
+// mount. Requests over the limit can wait in a bounded queue for a slot to be
+// freed, so that slow backends don't accumulate an unbounded number of
+// requests.
+type ConcurrencyQuota struct {
+	// ID is the identifier of the quota
+	ID string `json:"id"`
+
+	// Type of quota this represents
+	Type Type `json:"type"`
+
+	// Name of the quota rule
+	Name string `json:"name"`
+
+	// NamespacePath is the path of the namespace to which this quota is
+	// applicable.
+	NamespacePath string `json:"namespace_path"`
+
+	// MountPath is the path of the mount to which this quota is applicable
+	MountPath string `json:"mount_path"`
+
+	// MaxRequests is the number of requests allowed to be handled at the same
+	// time.
+	MaxRequests int `json:"max_requests"`
+
+	// MaxQueued is the number of requests allowed to wait for a slot once
+	// MaxRequests are being handled. Requests are rejected right away if zero.
+	MaxQueued int `json:"max_queued"`
+
+	// QueueTimeout is the duration after which a queued request is rejected.
+	QueueTimeout time.Duration `json:"queue_timeout"`
+
+	lock       *sync.RWMutex
+	slots      chan struct{}
+	queued     *int64
+	closeCh    chan struct{}
+	logger     log.Logger
+	metricSink *metricsutil.ClusterMetricSink
+}
+
+// NewConcurrencyQuota creates a quota checker for imposing limits on the
+// number of requests handled at the same time. A queue timeout of zero may be
+// provided, which will default to DefaultConcurrencyQueueTimeout when
+// initialized.
+func NewConcurrencyQuota(name, nsPath, mountPath string, maxRequests, maxQueued int, queueTimeout time.Duration) *ConcurrencyQuota {
+	return &ConcurrencyQuota{
+		Name:          name,
+		Type:          TypeConcurrency,
+		NamespacePath: nsPath,
+		MountPath:     mountPath,
+		MaxRequests:   maxRequests,
+		MaxQueued:     maxQueued,
+		QueueTimeout:  queueTimeout,
+	}
+}
+
+// initialize ensures the namespace and limits are initialized and sets the ID
+// if it's currently empty. Note, initialize resets the slots of the quota:
+// requests waiting for a slot are rejected, and requests holding a slot of the
+// previous limits release it there.
+func (cq *ConcurrencyQuota) initialize(logger log.Logger, ms *metricsutil.ClusterMetricSink) error {
+	if cq.lock == nil {
+		cq.lock = new(sync.RWMutex)
+	}
+
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+
+	// Memdb requires a non-empty value for indexing
+	if cq.NamespacePath == "" {
+		cq.NamespacePath = "root"
+	}
+
+	if cq.QueueTimeout == 0 {
+		cq.QueueTimeout = DefaultConcurrencyQueueTimeout
+	}
+
+	if cq.MaxRequests <= 0 {
+		return fmt.Errorf("invalid max requests: %v", cq.MaxRequests)
+	}
+
+	if cq.MaxQueued < 0 {
+		return fmt.Errorf("invalid max queued: %v", cq.MaxQueued)
+	}
+
+	if cq.QueueTimeout < 0 {
+		return fmt.Errorf("invalid queue timeout: %v", cq.QueueTimeout)
+	}
+
+	if logger != nil {
+		cq.logger = logger
+	}
+
+	if cq.metricSink == nil {
+		cq.metricSink = ms
+	}
+
+	if cq.ID == "" {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return err
+		}
+
+		cq.ID = id
+	}
+
+	if cq.closeCh != nil {
+		close(cq.closeCh)
+	}
+	cq.slots = make(chan struct{}, cq.MaxRequests)
+	cq.queued = new(int64)
+	cq.closeCh = make(chan struct{})
+
+	return nil
+}
+
+// quotaID returns the identifier of the quota rule
+func (cq *ConcurrencyQuota) quotaID() string {
+	return cq.ID
+}
+
+// QuotaName returns the name of the quota rule
+func (cq *ConcurrencyQuota) QuotaName() string {
+	return cq.Name
+}
+
+// allow takes a slot for the request if one is free. Otherwise, the request
+// waits for a slot for up to the queue timeout if the queue isn't full, and is
+// rejected if none is freed in time. The slot of an allowed request is held
+// until the Release function of the response is called.
+func (cq *ConcurrencyQuota) allow(req *Request) (Response, error) {
+	cq.lock.RLock()
+	slots, queued, closeCh := cq.slots, cq.queued, cq.closeCh
+	maxQueued, queueTimeout := int64(cq.MaxQueued), cq.QueueTimeout
+	cq.lock.RUnlock()
+
+	var resp Response
+
+	defer func() {
+		if !resp.Allowed {
+			cq.metricSink.IncrCounterWithLabels([]string{"quota", "concurrency", "violation"}, 1, []metrics.Label{{Name: "name", Value: cq.Name}})
+		}
+	}()
+
+	select {
+	case slots <- struct{}{}:
+		resp.Allowed = true
+	default:
+		if atomic.AddInt64(queued, 1) > maxQueued {
+			atomic.AddInt64(queued, -1)
+			return resp, nil
+		}
+		defer atomic.AddInt64(queued, -1)
+
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+
+		select {
+		case slots <- struct{}{}:
+			resp.Allowed = true
+		case <-timer.C:
+			return resp, nil
+		case <-closeCh:
+			return resp, nil
+		}
+	}
+
+	var once sync.Once
+	resp.Release = func() {
+		once.Do(func() {
+			<-slots
+		})
+	}
+
+	return resp, nil
+}
+
+// close rejects the requests waiting for a slot.
+func (cq *ConcurrencyQuota) close() error {
+	if cq.lock == nil {
+		return nil
+	}
+
+	cq.lock.Lock()
+	defer cq.lock.Unlock()
+
+	if cq.closeCh != nil {
+		close(cq.closeCh)
+		cq.closeCh = nil
+	}
+
+	return nil
+}
+
+func (cq *ConcurrencyQuota) handleRemount(toPath string) {
+	cq.MountPath = toPath
+}
@@ -0,0 +1,110 @@
+package quotas
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConcurrencyQuota(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cq        *ConcurrencyQuota
+		expectErr bool
+	}{
+		{"valid", NewConcurrencyQuota("test-concurrency", "qa", "/foo/bar", 2, 1, 0), false},
+		{"invalid max requests", NewConcurrencyQuota("test-concurrency", "qa", "/foo/bar", 0, 1, 0), true},
+		{"invalid max queued", NewConcurrencyQuota("test-concurrency", "qa", "/foo/bar", 2, -1, 0), true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink())
+			require.Equal(t, tc.expectErr, err != nil, err)
+		})
+	}
+}
+
+func TestConcurrencyQuota_Allow(t *testing.T) {
+	cq := NewConcurrencyQuota("test-concurrency", "qa", "/foo/bar", 2, 0, 0)
+	require.NoError(t, cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	// Requests over the limit are rejected right away without a queue
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		resp, err := cq.allow(&Request{})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+		releases = append(releases, resp.Release)
+	}
+	resp, err := cq.allow(&Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+
+	// Releasing twice frees a single slot
+	releases[0]()
+	releases[0]()
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+}
+
+func TestConcurrencyQuota_Queue(t *testing.T) {
+	cq := NewConcurrencyQuota("test-concurrency", "qa", "/foo/bar", 1, 1, 200*time.Millisecond)
+	require.NoError(t, cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	resp, err := cq.allow(&Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	release := resp.Release
+
+	// A queued request is rejected once the queue timeout elapses
+	start := time.Now()
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+	require.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	// A queued request takes the slot once freed, while requests over the
+	// queue size are rejected right away
+	allowedCh := make(chan bool)
+	go func() {
+		resp, err := cq.allow(&Request{})
+		if err == nil && resp.Allowed {
+			resp.Release()
+		}
+		allowedCh <- err == nil && resp.Allowed
+	}()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(cq.queued) == 1
+	}, time.Second, time.Millisecond)
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+
+	release()
+	require.True(t, <-allowedCh)
+
+	// Closing the quota rejects queued requests
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, cq.close())
+	}()
+	resp, err = cq.allow(&Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+}
@@ -14,6 +14,7 @@ import (
 func quotaTypes() []string {
 	return []string{
 		TypeRateLimit.String(),
+		TypeConcurrency.String(),
 	}
 }
 
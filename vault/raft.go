@@ -841,10 +841,23 @@ func (c *Core) JoinRaftCluster(ctx context.Context, leaderInfos []*raft.LeaderJo
 				return errwrap.Wrapf("failed to create api client: {{err}}", err)
 			}
 
-			// Attempt to join the leader by requesting for the bootstrap challenge
-			secret, err := apiClient.Logical().Write("sys/storage/raft/bootstrap/challenge", map[string]interface{}{
+			// Attempt to join the leader by requesting for the bootstrap challenge,
+			// proving knowledge of the join token if one is configured
+			challengeData := map[string]interface{}{
 				"server_id": raftBackend.NodeID(),
-			})
+			}
+			joinToken := raftBackend.JoinToken()
+			var joinNonce string
+			if joinToken != "" {
+				joinNonce, err = uuid.GenerateUUID()
+				if err != nil {
+					return err
+				}
+				challengeData["join_nonce"] = joinNonce
+				challengeData["join_proof"] = raft.JoinTokenProof(joinToken, raft.JoinTokenFollower, raftBackend.NodeID(), joinNonce)
+			}
+
+			secret, err := apiClient.Logical().Write("sys/storage/raft/bootstrap/challenge", challengeData)
 			if err != nil {
 				return errwrap.Wrapf("error during raft bootstrap init call: {{err}}", err)
 			}
@@ -852,6 +865,16 @@ func (c *Core) JoinRaftCluster(ctx context.Context, leaderInfos []*raft.LeaderJo
 				return errors.New("could not retrieve raft bootstrap package")
 			}
 
+			// Verify that the leader knows the join token as well before
+			// answering its challenge, so that a node discovered through
+			// auto-join cannot pose as a member of the cluster
+			if joinToken != "" {
+				proof, _ := secret.Data["join_proof"].(string)
+				if !raft.VerifyJoinTokenProof(joinToken, raft.JoinTokenLeader, raftBackend.NodeID(), joinNonce, proof) {
+					return errors.New("raft leader failed to prove knowledge of the join token")
+				}
+			}
+
 			var sealConfig SealConfig
 			err = mapstructure.Decode(secret.Data["seal_config"], &sealConfig)
 			if err != nil {
@@ -915,7 +938,7 @@ func (c *Core) JoinRaftCluster(ctx context.Context, leaderInfos []*raft.LeaderJo
 				close(c.raftJoinDoneCh)
 			}
 
-			c.logger.Info("successfully joined the raft cluster", "leader_addr", leaderInfo.LeaderAPIAddr)
+			c.logger.Info("successfully joined the raft cluster", "leader_addr", leaderAddr)
 			return nil
 		}
 
@@ -935,38 +958,12 @@ func (c *Core) JoinRaftCluster(ctx context.Context, leaderInfos []*raft.LeaderJo
 				}
 
 			case leaderInfo.AutoJoin != "":
-				addrs, err := disco.Addrs(leaderInfo.AutoJoin, c.logger.StandardLogger(nil))
+				addrs, err := leaderInfo.AutoJoinAddrs(disco, c.logger)
 				if err != nil {
-					c.logger.Error("failed to parse addresses from auto-join metadata", "error", err)
+					c.logger.Error("failed to discover raft leader nodes", "error", err)
 				}
 
 				for _, addr := range addrs {
-					u, err := url.Parse(addr)
-					if err != nil {
-						c.logger.Error("failed to parse discovered address", "error", err)
-						continue
-					}
-
-					if u.Scheme == "" {
-						scheme := leaderInfo.AutoJoinScheme
-						if scheme == "" {
-							// default to HTTPS when no scheme is provided
-							scheme = "https"
-						}
-
-						addr = fmt.Sprintf("%s://%s", scheme, addr)
-					}
-
-					if u.Port() == "" {
-						port := leaderInfo.AutoJoinPort
-						if port == 0 {
-							// default to 8200 when no port is provided
-							port = 8200
-						}
-
-						addr = fmt.Sprintf("%s:%d", addr, port)
-					}
-
 					if err := joinLeader(leaderInfo, addr); err != nil {
 						c.logger.Warn("join attempt failed", "error", err)
 					} else {
@@ -236,6 +236,11 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) logical.HTTPCodedError {
 	}
 	c.barrierRekeyConfig.Nonce = nonce
 
+	if err := c.persistRekeyProgress(c.activeContext, false); err != nil {
+		c.barrierRekeyConfig = nil
+		return logical.CodedError(http.StatusInternalServerError, errwrap.Wrapf("failed to persist rekey progress: {{err}}", err).Error())
+	}
+
 	if c.logger.IsInfo() {
 		c.logger.Info("rekey initialized", "nonce", c.barrierRekeyConfig.Nonce, "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold, "validation_required", c.barrierRekeyConfig.VerificationRequired)
 	}
@@ -286,6 +291,11 @@ func (c *Core) RecoveryRekeyInit(config *SealConfig) logical.HTTPCodedError {
 	}
 	c.recoveryRekeyConfig.Nonce = nonce
 
+	if err := c.persistRekeyProgress(c.activeContext, true); err != nil {
+		c.recoveryRekeyConfig = nil
+		return logical.CodedError(http.StatusInternalServerError, errwrap.Wrapf("failed to persist rekey progress: {{err}}", err).Error())
+	}
+
 	if c.logger.IsInfo() {
 		c.logger.Info("rekey initialized", "nonce", c.recoveryRekeyConfig.Nonce, "shares", c.recoveryRekeyConfig.SecretShares, "threshold", c.recoveryRekeyConfig.SecretThreshold, "validation_required", c.recoveryRekeyConfig.VerificationRequired)
 	}
@@ -360,6 +370,10 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("incorrect nonce supplied; nonce for this rekey operation is %q", c.barrierRekeyConfig.Nonce))
 	}
 
+	if c.barrierRekeyConfig.RekeyPaused {
+		return nil, logical.CodedError(http.StatusBadRequest, "rekey operation is paused")
+	}
+
 	// Check if we already have this piece
 	for _, existing := range c.barrierRekeyConfig.RekeyProgress {
 		if subtle.ConstantTimeCompare(existing, key) == 1 {
@@ -369,6 +383,8 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 
 	// Store this key
 	c.barrierRekeyConfig.RekeyProgress = append(c.barrierRekeyConfig.RekeyProgress, key)
+	c.recordRekeyShare(c.barrierRekeyConfig, key, false)
+	defer c.persistRekeyProgressOrLog(ctx, false)
 
 	// Check if we don't have enough keys to unlock
 	if len(c.barrierRekeyConfig.RekeyProgress) < existingConfig.SecretThreshold {
@@ -638,6 +654,10 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("incorrect nonce supplied; nonce for this rekey operation is %q", c.recoveryRekeyConfig.Nonce))
 	}
 
+	if c.recoveryRekeyConfig.RekeyPaused {
+		return nil, logical.CodedError(http.StatusBadRequest, "rekey operation is paused")
+	}
+
 	// Check if we already have this piece
 	for _, existing := range c.recoveryRekeyConfig.RekeyProgress {
 		if subtle.ConstantTimeCompare(existing, key) == 1 {
@@ -647,6 +667,8 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 
 	// Store this key
 	c.recoveryRekeyConfig.RekeyProgress = append(c.recoveryRekeyConfig.RekeyProgress, key)
+	c.recordRekeyShare(c.recoveryRekeyConfig, key, false)
+	defer c.persistRekeyProgressOrLog(ctx, true)
 
 	// Check if we don't have enough keys to unlock
 	if len(c.recoveryRekeyConfig.RekeyProgress) < existingConfig.SecretThreshold {
@@ -834,6 +856,10 @@ func (c *Core) RekeyVerify(ctx context.Context, key []byte, nonce string, recove
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("incorrect nonce supplied; nonce for this verify operation is %q", config.VerificationNonce))
 	}
 
+	if config.RekeyPaused {
+		return nil, logical.CodedError(http.StatusBadRequest, "rekey operation is paused")
+	}
+
 	// Check if we already have this piece
 	for _, existing := range config.VerificationProgress {
 		if subtle.ConstantTimeCompare(existing, key) == 1 {
@@ -843,6 +869,8 @@ func (c *Core) RekeyVerify(ctx context.Context, key []byte, nonce string, recove
 
 	// Store this key
 	config.VerificationProgress = append(config.VerificationProgress, key)
+	c.recordRekeyShare(config, key, true)
+	defer c.persistRekeyProgressOrLog(ctx, recovery)
 
 	// Check if we don't have enough keys to unlock
 	if len(config.VerificationProgress) < config.SecretThreshold {
@@ -926,6 +954,10 @@ func (c *Core) RekeyCancel(recovery bool) logical.HTTPCodedError {
 	} else {
 		c.barrierRekeyConfig = nil
 	}
+
+	if err := c.persistRekeyProgress(c.activeContext, recovery); err != nil {
+		return logical.CodedError(http.StatusInternalServerError, errwrap.Wrapf("failed to remove rekey progress: {{err}}", err).Error())
+	}
 	return nil
 }
 
@@ -964,6 +996,8 @@ func (c *Core) RekeyVerifyRestart(recovery bool) logical.HTTPCodedError {
 		}
 	}
 
+	c.persistRekeyProgressOrLog(c.activeContext, recovery)
+
 	return nil
 }
 
@@ -0,0 +1,204 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// coreBarrierRekeyProgressPath is the path used to persist the state of a
+	// barrier rekey operation, so that it survives a failover of the active
+	// node. This is inside the barrier, and protected from the raw API.
+	coreBarrierRekeyProgressPath = "core/rekey/barrier-progress"
+
+	// coreRecoveryRekeyProgressPath is the path used to persist the state of a
+	// recovery rekey operation. This is inside the barrier, and protected from
+	// the raw API.
+	coreRecoveryRekeyProgressPath = "core/rekey/recovery-progress"
+)
+
+// RekeyShareSubmission records a key share submitted during a rekey
+// operation, for auditing which key holders took part in it. Only the hash of
+// the share is kept, so that a key holder can recognize their own submission
+// without the record disclosing the share.
+type RekeyShareSubmission struct {
+	// KeyHash is the hex-encoded SHA-256 hash of the submitted share
+	KeyHash string `json:"key_hash"`
+
+	// Time is when the share was submitted
+	Time time.Time `json:"time"`
+
+	// Verification indicates that the share is one of the new key, submitted
+	// to verify it, rather than one of the current key
+	Verification bool `json:"verification"`
+}
+
+// rekeyProgress is the persisted state of a rekey operation. The submitted
+// key shares and the new key awaiting verification are never persisted, as
+// they would let anyone reading the storage reconstruct the keys: after a
+// failover the key holders submit their shares again, and an operation whose
+// new key was awaiting verification is canceled.
+type rekeyProgress struct {
+	Config               *SealConfig             `json:"config"`
+	VerificationRequired bool                    `json:"verification_required"`
+	Verifying            bool                    `json:"verifying"`
+	Paused               bool                    `json:"paused"`
+	Submissions          []*RekeyShareSubmission `json:"submissions"`
+}
+
+// rekeyProgressPath returns the storage path of the progress of the barrier
+// or recovery rekey operation.
+func rekeyProgressPath(recovery bool) string {
+	if recovery {
+		return coreRecoveryRekeyProgressPath
+	}
+	return coreBarrierRekeyProgressPath
+}
+
+// rekeyConfig returns the configuration of the barrier or recovery rekey
+// operation in progress, if any. The rekey lock must be held.
+func (c *Core) rekeyConfig(recovery bool) *SealConfig {
+	if recovery {
+		return c.recoveryRekeyConfig
+	}
+	return c.barrierRekeyConfig
+}
+
+// persistRekeyProgress stores the state of the barrier or recovery rekey
+// operation, or removes it if none is in progress. The rekey lock must be
+// held.
+func (c *Core) persistRekeyProgress(ctx context.Context, recovery bool) error {
+	path := rekeyProgressPath(recovery)
+
+	config := c.rekeyConfig(recovery)
+	if config == nil {
+		return c.barrier.Delete(ctx, path)
+	}
+
+	entry, err := logical.StorageEntryJSON(path, &rekeyProgress{
+		Config:               config,
+		VerificationRequired: config.VerificationRequired,
+		Verifying:            len(config.VerificationKey) > 0,
+		Paused:               config.RekeyPaused,
+		Submissions:          config.RekeySubmissions,
+	})
+	if err != nil {
+		return err
+	}
+	return c.barrier.Put(ctx, entry)
+}
+
+// persistRekeyProgressOrLog persists the progress of the rekey operation,
+// logging rather than failing the operation if it can't be stored: the
+// progress remains in memory, but won't survive a failover.
+func (c *Core) persistRekeyProgressOrLog(ctx context.Context, recovery bool) {
+	if err := c.persistRekeyProgress(ctx, recovery); err != nil {
+		c.logger.Error("failed to persist rekey progress", "recovery", recovery, "error", err)
+	}
+}
+
+// loadRekeyProgress restores the rekey operations persisted by a previous
+// active node, without the key shares submitted to it.
+func (c *Core) loadRekeyProgress(ctx context.Context) error {
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	for _, recovery := range []bool{false, true} {
+		entry, err := c.barrier.Get(ctx, rekeyProgressPath(recovery))
+		if err != nil {
+			return errwrap.Wrapf("failed to read rekey progress: {{err}}", err)
+		}
+		if entry == nil {
+			continue
+		}
+
+		var progress rekeyProgress
+		if err := jsonutil.DecodeJSON(entry.Value, &progress); err != nil {
+			return errwrap.Wrapf("failed to decode rekey progress: {{err}}", err)
+		}
+		if progress.Config == nil {
+			continue
+		}
+
+		config := progress.Config
+
+		// The new key was only held in memory by the previous active node,
+		// so the shares handed out for verification can't be verified
+		if progress.Verifying {
+			c.logger.Error("canceling rekey operation interrupted during verification, the new key shares are no longer valid", "nonce", config.Nonce, "recovery", recovery)
+			if err := c.barrier.Delete(ctx, rekeyProgressPath(recovery)); err != nil {
+				return errwrap.Wrapf("failed to remove rekey progress: {{err}}", err)
+			}
+			continue
+		}
+
+		config.VerificationRequired = progress.VerificationRequired
+		config.RekeyPaused = progress.Paused
+		config.RekeySubmissions = progress.Submissions
+
+		if recovery {
+			c.recoveryRekeyConfig = config
+		} else {
+			c.barrierRekeyConfig = config
+		}
+		c.logger.Info("resumed rekey operation, the key shares must be submitted again", "nonce", config.Nonce, "recovery", recovery, "paused", config.RekeyPaused)
+	}
+
+	return nil
+}
+
+// recordRekeyShare records the submission of a key share to the rekey
+// operation in progress. The rekey lock must be held.
+func (c *Core) recordRekeyShare(config *SealConfig, key []byte, verification bool) {
+	sum := sha256.Sum256(key)
+	submission := &RekeyShareSubmission{
+		KeyHash:      hex.EncodeToString(sum[:]),
+		Time:         time.Now().UTC(),
+		Verification: verification,
+	}
+	config.RekeySubmissions = append(config.RekeySubmissions, submission)
+
+	c.logger.Info("rekey key share submitted", "nonce", config.Nonce, "key_hash", submission.KeyHash, "verification", verification)
+}
+
+// RekeyPause pauses or resumes the barrier or recovery rekey operation in
+// progress. Key shares can't be submitted while the operation is paused, but
+// those already submitted are kept.
+func (c *Core) RekeyPause(ctx context.Context, recovery, paused bool) logical.HTTPCodedError {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.Sealed() {
+		return logical.CodedError(http.StatusServiceUnavailable, consts.ErrSealed.Error())
+	}
+	if c.standby {
+		return logical.CodedError(http.StatusBadRequest, consts.ErrStandby.Error())
+	}
+
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	config := c.rekeyConfig(recovery)
+	if config == nil {
+		return logical.CodedError(http.StatusBadRequest, "rekey operation not in progress")
+	}
+	if config.RekeyPaused == paused {
+		return nil
+	}
+
+	config.RekeyPaused = paused
+	if err := c.persistRekeyProgress(ctx, recovery); err != nil {
+		config.RekeyPaused = !paused
+		return logical.CodedError(http.StatusInternalServerError, errwrap.Wrapf("failed to persist rekey progress: {{err}}", err).Error())
+	}
+
+	c.logger.Info("rekey operation paused state changed", "nonce", config.Nonce, "recovery", recovery, "paused", paused)
+	return nil
+}
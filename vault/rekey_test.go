@@ -1,7 +1,11 @@
 package vault
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"strings"
@@ -323,6 +327,93 @@ func testCore_Rekey_Update_Common(t *testing.T, c *Core, keys [][]byte, root str
 	}
 }
 
+func TestCore_Rekey_PersistedProgress(t *testing.T) {
+	c, keys, _ := TestCoreUnsealed(t)
+	ctx := context.Background()
+
+	hErr := c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierType(),
+		SecretThreshold: 3,
+		SecretShares:    5,
+	}, false)
+	if hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	rkconf, hErr := c.RekeyConfig(false)
+	if hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+
+	result, hErr := c.RekeyUpdate(ctx, keys[0], rkconf.Nonce, false)
+	if hErr != nil || result != nil {
+		t.Fatalf("unexpected result: %v, err: %v", result, hErr)
+	}
+
+	// The submitted shares are not persisted
+	entry, err := c.barrier.Get(ctx, coreBarrierRekeyProgressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || bytes.Contains(entry.Value, []byte(base64.StdEncoding.EncodeToString(keys[0]))) {
+		t.Fatalf("unexpected rekey progress: %#v", entry)
+	}
+
+	// Simulate a failover: the new active node resumes the operation from
+	// storage, and the shares must be submitted again
+	c.rekeyLock.Lock()
+	c.barrierRekeyConfig = nil
+	c.rekeyLock.Unlock()
+	if err := c.loadRekeyProgress(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, progress, hErr := c.RekeyProgress(false, false)
+	if hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	if progress != 0 {
+		t.Fatalf("expected a progress of 0, got %d", progress)
+	}
+	resumed, hErr := c.RekeyConfig(false)
+	if hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	sum := sha256.Sum256(keys[0])
+	if resumed.Nonce != rkconf.Nonce || len(resumed.RekeySubmissions) != 1 || resumed.RekeySubmissions[0].KeyHash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected resumed config: %#v", resumed)
+	}
+
+	// Shares are rejected while paused
+	if hErr := c.RekeyPause(ctx, false, true); hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+	if _, hErr := c.RekeyUpdate(ctx, keys[1], rkconf.Nonce, false); hErr == nil || !strings.Contains(hErr.Error(), "paused") {
+		t.Fatalf("expected the update to be rejected while paused, got: %v", hErr)
+	}
+	if hErr := c.RekeyPause(ctx, false, false); hErr != nil {
+		t.Fatalf("err: %v", hErr)
+	}
+
+	for _, key := range keys {
+		result, hErr = c.RekeyUpdate(ctx, key, rkconf.Nonce, false)
+		if hErr != nil {
+			t.Fatalf("err: %v", hErr)
+		}
+	}
+	if result == nil || len(result.SecretShares) != 5 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+
+	// The progress is removed once complete
+	entry, err = c.barrier.Get(ctx, coreBarrierRekeyProgressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Fatal("expected the rekey progress to be removed")
+	}
+}
+
 func TestCore_Rekey_Legacy(t *testing.T) {
 	bc := &SealConfig{
 		SecretShares:    1,
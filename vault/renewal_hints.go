@@ -0,0 +1,110 @@
+package vault
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// renewalHintMinTTL is the minimum TTL of the leases for which a renewal
+	// time is recommended.
+	renewalHintMinTTL = 10 * time.Second
+
+	// renewalHintWindowStart and renewalHintWindowEnd delimit the fraction of
+	// the TTL within which renewal times are recommended.
+	renewalHintWindowStart = 0.5
+	renewalHintWindowEnd   = 0.8
+
+	// renewalHintCandidates is the number of random renewal times considered
+	// for each recommendation, of which the least loaded one is picked.
+	renewalHintCandidates = 4
+
+	// renewalHintTrackedPeriod is how far ahead the recommended renewals are
+	// counted. Renewals further ahead are spread randomly, which is enough
+	// over such long windows and bounds the memory used for counting.
+	renewalHintTrackedPeriod = time.Hour
+
+	// renewalHintPruneInterval is how often the counts of past seconds are
+	// removed.
+	renewalHintPruneInterval = time.Minute
+)
+
+// renewalHintScheduler recommends times at which clients should renew their
+// leases and tokens. Clients renewing at a fixed fraction of the TTL cause
+// bursts of renewals when many leases are created at once, e.g. after a
+// deploy. Instead, the scheduler counts the renewals it has recommended for
+// each second and recommends the least loaded of a few random times within
+// the renewal window.
+type renewalHintScheduler struct {
+	l         sync.Mutex
+	random    *rand.Rand
+	scheduled map[int64]int
+	lastPrune time.Time
+}
+
+func newRenewalHintScheduler() *renewalHintScheduler {
+	return &renewalHintScheduler{
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		scheduled: make(map[int64]int),
+		lastPrune: time.Now(),
+	}
+}
+
+// renewAt returns the time at which a lease with the given TTL starting now
+// should be renewed, or the zero time if the TTL is too short for a
+// recommendation.
+func (s *renewalHintScheduler) renewAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl < renewalHintMinTTL {
+		return time.Time{}
+	}
+
+	start := now.Add(time.Duration(float64(ttl) * renewalHintWindowStart))
+	window := int64(float64(ttl) * (renewalHintWindowEnd - renewalHintWindowStart))
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if now.Sub(s.lastPrune) >= renewalHintPruneInterval {
+		for second := range s.scheduled {
+			if second < now.Unix() {
+				delete(s.scheduled, second)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	best := start.Add(time.Duration(s.random.Int63n(window)))
+	if best.Sub(now) > renewalHintTrackedPeriod {
+		return best.Truncate(time.Second)
+	}
+
+	bestLoad := s.scheduled[best.Unix()]
+	for i := 1; i < renewalHintCandidates && bestLoad > 0; i++ {
+		candidate := start.Add(time.Duration(s.random.Int63n(window)))
+		if load := s.scheduled[candidate.Unix()]; load < bestLoad {
+			best, bestLoad = candidate, load
+		}
+	}
+	s.scheduled[best.Unix()]++
+
+	return best.Truncate(time.Second)
+}
+
+// addRenewalHints sets the recommended renewal times of the renewable lease
+// and token of the response, if any.
+func (c *Core) addRenewalHints(resp *logical.Response) {
+	if c.renewalHints == nil {
+		return
+	}
+
+	now := time.Now()
+	if resp.Secret != nil && resp.Secret.LeaseID != "" && resp.Secret.Renewable {
+		resp.Secret.RenewAt = c.renewalHints.renewAt(now, resp.Secret.TTL)
+	}
+	if resp.Auth != nil && resp.Auth.Renewable {
+		resp.Auth.RenewAt = c.renewalHints.renewAt(now, resp.Auth.TTL)
+	}
+}
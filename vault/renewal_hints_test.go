@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestRenewalHintScheduler(t *testing.T) {
+	s := newRenewalHintScheduler()
+	now := time.Now()
+	ttl := 100 * time.Second
+
+	if renewAt := s.renewAt(now, time.Second); !renewAt.IsZero() {
+		t.Fatalf("expected no hint for short TTL, got %v", renewAt)
+	}
+
+	// Recommendations stay within the renewal window, and are spread over
+	// it rather than piling up on a few seconds
+	counts := make(map[int64]int)
+	for i := 0; i < 300; i++ {
+		renewAt := s.renewAt(now, ttl)
+		if renewAt.Before(now.Add(49*time.Second)) || renewAt.After(now.Add(80*time.Second)) {
+			t.Fatalf("hint %v outside of the renewal window", renewAt.Sub(now))
+		}
+		counts[renewAt.Unix()]++
+	}
+	for second, count := range counts {
+		if count > 20 {
+			t.Fatalf("%d renewals recommended at %d", count, second)
+		}
+	}
+}
+
+func TestCore_addRenewalHints(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	resp := &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       time.Hour,
+				Renewable: true,
+			},
+			LeaseID: "foo",
+		},
+		Auth: &logical.Auth{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour,
+			},
+		},
+	}
+	c.addRenewalHints(resp)
+
+	if resp.Secret.RenewAt.IsZero() {
+		t.Fatal("expected renewal hint for renewable lease")
+	}
+	if !resp.Auth.RenewAt.IsZero() {
+		t.Fatal("expected no renewal hint for non-renewable token")
+	}
+}
@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// requestFilter matches the unauthenticated requests that are excluded from
+// the audit devices and the request metrics, such as the health checks of
+// load balancers, which otherwise dominate the audit logs.
+type requestFilter struct {
+	exact  map[string]struct{}
+	prefix []string
+}
+
+// newRequestFilter returns a filter for the given paths. A path ending in "*"
+// matches all the paths with that prefix. A nil filter is returned if there is
+// no path to exclude.
+func newRequestFilter(paths []string) *requestFilter {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	f := &requestFilter{
+		exact: make(map[string]struct{}, len(paths)),
+	}
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "/")
+		if path == "" {
+			continue
+		}
+		if strings.HasSuffix(path, "*") {
+			f.prefix = append(f.prefix, strings.TrimSuffix(path, "*"))
+			continue
+		}
+		f.exact[path] = struct{}{}
+	}
+	return f
+}
+
+// excluded returns whether the request is excluded from the audit devices and
+// the request metrics. Only unauthenticated requests without a client token
+// are excluded, so that no authenticated activity can be hidden.
+func (f *requestFilter) excluded(req *logical.Request) bool {
+	if f == nil || req == nil || !req.Unauthenticated || req.ClientToken != "" {
+		return false
+	}
+
+	if _, ok := f.exact[req.Path]; ok {
+		return true
+	}
+	for _, prefix := range f.prefix {
+		if strings.HasPrefix(req.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
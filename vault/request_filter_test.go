@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestRequestFilter(t *testing.T) {
+	f := newRequestFilter([]string{"sys/health", "/sys/seal-status", "sys/internal/ui/*"})
+
+	for _, tc := range []struct {
+		req      *logical.Request
+		excluded bool
+	}{
+		{&logical.Request{Path: "sys/health", Unauthenticated: true}, true},
+		{&logical.Request{Path: "sys/seal-status", Unauthenticated: true}, true},
+		{&logical.Request{Path: "sys/internal/ui/mounts", Unauthenticated: true}, true},
+		{&logical.Request{Path: "sys/healthz", Unauthenticated: true}, false},
+		{&logical.Request{Path: "sys/leader", Unauthenticated: true}, false},
+		// Authenticated requests are never excluded
+		{&logical.Request{Path: "sys/health"}, false},
+		{&logical.Request{Path: "sys/health", Unauthenticated: true, ClientToken: "foo"}, false},
+	} {
+		if excluded := f.excluded(tc.req); excluded != tc.excluded {
+			t.Fatalf("%s: expected excluded to be %t", tc.req.Path, tc.excluded)
+		}
+	}
+
+	// No path is excluded without configuration
+	empty := newRequestFilter(nil)
+	if empty.excluded(&logical.Request{Path: "sys/health", Unauthenticated: true}) {
+		t.Fatal("expected no path to be excluded")
+	}
+}
+
+func TestCore_ExcludedUnauthPaths(t *testing.T) {
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		ExcludedUnauthPaths: []string{"sys/health"},
+	})
+
+	noop := &NoopAudit{}
+	c.auditBackends["noop"] = func(ctx context.Context, config *audit.BackendConfig) (audit.Backend, error) {
+		noop.Config = config
+		return noop, nil
+	}
+	me := &MountEntry{
+		Table: auditTableType,
+		Path:  "noop/",
+		Type:  "noop",
+	}
+	if err := c.enableAudit(namespace.RootContext(nil), me, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The health and leader paths are served by the HTTP handlers, so these
+	// requests fail in the system backend, but they are audited all the same
+	for _, req := range []*logical.Request{
+		{Operation: logical.ReadOperation, Path: "sys/health"},
+		{Operation: logical.ReadOperation, Path: "sys/leader"},
+		{Operation: logical.ReadOperation, Path: "sys/health", ClientToken: root},
+	} {
+		c.HandleRequest(namespace.RootContext(nil), req)
+	}
+
+	// Only the unauthenticated health check is excluded
+	var paths []string
+	for _, req := range noop.Req {
+		paths = append(paths, req.Path)
+	}
+	if len(paths) != 2 || paths[0] != "sys/leader" || paths[1] != "sys/health" {
+		t.Fatalf("bad: audited requests: %v", paths)
+	}
+	if len(noop.RespReq) != 2 {
+		t.Fatalf("bad: %d audited responses", len(noop.RespReq))
+	}
+}
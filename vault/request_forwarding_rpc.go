@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/forwarding"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/version"
 	"github.com/hashicorp/vault/vault/replication"
 )
 
@@ -69,7 +70,7 @@ func (s *forwardedRequestRPCServer) ForwardRequest(ctx context.Context, freq *fo
 
 func (s *forwardedRequestRPCServer) Echo(ctx context.Context, in *EchoRequest) (*EchoReply, error) {
 	if in.ClusterAddr != "" {
-		s.core.clusterPeerClusterAddrsCache.Set(in.ClusterAddr, nil, 0)
+		s.core.recordHAPeer(in.ClusterAddr, in.ApiAddr, in.Version)
 	}
 
 	if in.RaftAppliedIndex > 0 && len(in.RaftNodeID) > 0 && s.raftFollowerStates != nil {
@@ -110,6 +111,8 @@ func (c *forwardingClient) startHeartbeat() {
 			req := &EchoRequest{
 				Message:     "ping",
 				ClusterAddr: clusterAddr,
+				ApiAddr:     c.core.redirectAddr,
+				Version:     version.GetVersion().Version,
 			}
 
 			if raftBackend := c.core.getRaftBackend(); raftBackend != nil {
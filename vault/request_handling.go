@@ -216,7 +216,7 @@ func (c *Core) fetchACLTokenEntryAndEntity(ctx context.Context, req *logical.Req
 
 	// Construct the corresponding ACL object. ACL construction should be
 	// performed on the token's namespace.
-	acl, err := c.policyStore.ACL(tokenCtx, entity, policies)
+	acl, err := c.policyStore.ACL(tokenCtx, entity, te.Session, policies)
 	if err != nil {
 		if errwrap.ContainsType(err, new(TemplateError)) {
 			c.logger.Warn("permission denied due to a templated policy being invalid or containing directives not satisfied by the requestor", "error", err)
@@ -383,6 +383,10 @@ func (c *Core) checkToken(ctx context.Context, req *logical.Request, unauth bool
 		return auth, te, retErr
 	}
 
+	if !unauth {
+		c.emitUsageMetrics(ctx, te, authResults.ACLResults)
+	}
+
 	return auth, te, nil
 }
 
@@ -423,6 +427,9 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	}
 	ctx = namespace.ContextWithNamespace(ctx, ns)
 
+	// The request is untracked even if handling it panics
+	done := c.trackInFlightRequest(ns, req, cancel)
+	defer done()
 	resp, err = c.handleCancelableRequest(ctx, ns, req)
 
 	req.SetTokenEntry(nil)
@@ -469,6 +476,11 @@ func (c *Core) handleCancelableRequest(ctx context.Context, ns *namespace.Namesp
 		}
 	}
 
+	// Recommend when to renew the lease and token of the response
+	if resp != nil && err == nil && !resp.IsError() {
+		c.addRenewalHints(resp)
+	}
+
 	// We are wrapping if there is anything to wrap (not a nil response) and a
 	// TTL was specified for the token. Errors on a call should be returned to
 	// the caller, so wrapping is turned off if an error is hit and the error
@@ -540,7 +552,7 @@ func (c *Core) handleCancelableRequest(ctx context.Context, ns *namespace.Namesp
 	}
 
 	// Create an audit trail of the response
-	if !isControlGroupRun(req) {
+	if !isControlGroupRun(req) && !c.excludedRequests.excluded(req) {
 		switch req.Path {
 		case "sys/replication/dr/status", "sys/replication/performance/status", "sys/replication/status":
 		default:
@@ -572,7 +584,9 @@ func (c *Core) doRouting(ctx context.Context, req *logical.Request) (*logical.Re
 	if shouldForward(c, resp, err) {
 		return forward(ctx, c, req)
 	}
-	atomic.AddUint64(c.counters.requests, 1)
+	if !c.excludedRequests.excluded(req) {
+		atomic.AddUint64(c.counters.requests, 1)
+	}
 	return resp, err
 }
 
@@ -970,10 +984,15 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 // handleLoginRequest is used to handle a login request, which is an
 // unauthenticated request to the backend.
 func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
-	defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
-
 	req.Unauthenticated = true
 
+	// Requests excluded by the configuration, e.g. health checks, are neither
+	// audited nor measured
+	excluded := c.excludedRequests.excluded(req)
+	if !excluded {
+		defer metrics.MeasureSince([]string{"core", "handle_login_request"}, time.Now())
+	}
+
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(ctx, req.Path)
 	if entry != nil {
@@ -1003,15 +1022,17 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			errType = logical.ErrInvalidRequest
 		}
 
-		logInput := &logical.LogInput{
-			Auth:               auth,
-			Request:            req,
-			OuterErr:           ctErr,
-			NonHMACReqDataKeys: nonHMACReqDataKeys,
-		}
-		if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
-			c.logger.Error("failed to audit request", "path", req.Path, "error", err)
-			return nil, nil, ErrInternalError
+		if !excluded {
+			logInput := &logical.LogInput{
+				Auth:               auth,
+				Request:            req,
+				OuterErr:           ctErr,
+				NonHMACReqDataKeys: nonHMACReqDataKeys,
+			}
+			if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+				c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
 		}
 
 		if errType != nil {
@@ -1023,8 +1044,9 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
-	switch req.Path {
-	case "sys/replication/dr/status", "sys/replication/performance/status", "sys/replication/status":
+	switch {
+	case excluded:
+	case req.Path == "sys/replication/dr/status", req.Path == "sys/replication/performance/status", req.Path == "sys/replication/status":
 	default:
 		// Create an audit trail of the request. Attach auth if it was returned,
 		// e.g. if a token was provided.
@@ -1225,6 +1247,21 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			}
 		}
 
+		// Record the address of the client on the token, overriding anything
+		// the auth method may have set
+		auth.CreationIP = ""
+		if req.Connection != nil {
+			auth.CreationIP = req.Connection.RemoteAddr
+		}
+
+		// Record the auth mount of the login on its session, overriding
+		// anything the auth method may have set
+		if auth.Session == nil {
+			auth.Session = new(logical.AuthSession)
+		}
+		auth.Session.MountType = req.MountType
+		auth.Session.MountAccessor = req.MountAccessor
+
 		var registerFunc RegisterAuthFunc
 		var funcGetErr error
 		// Batch tokens should not be forwarded to perf standby
@@ -1306,6 +1343,8 @@ func (c *Core) RegisterAuth(ctx context.Context, tokenTTL time.Duration, path st
 		ExplicitMaxTTL: auth.ExplicitMaxTTL,
 		Period:         auth.Period,
 		Type:           auth.TokenType,
+		CreationIP:     auth.CreationIP,
+		Session:        auth.Session,
 	}
 
 	if te.TTL == 0 && (len(te.Policies) != 1 || te.Policies[0] != "root") {
@@ -10,6 +10,7 @@ import (
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/builtin/credential/approle"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -469,3 +470,97 @@ func TestRequestHandling_SecretLeaseMetric(t *testing.T) {
 		},
 	)
 }
+
+func TestRequestHandling_UsageMetrics(t *testing.T) {
+	inmemSink := metrics.NewInmemSink(1000000*time.Hour, 2000000*time.Hour)
+	metricSink := metricsutil.NewClusterMetricSink("test-cluster", inmemSink)
+	metricSink.TelemetryConsts.PolicyUsageMetrics = true
+	metricSink.TelemetryConsts.AppRoleUsageMetrics = true
+	metricSink.TelemetryConsts.MaxUsageMetricsCardinality = 1
+	core, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		MetricSink: metricSink,
+	})
+
+	if err := core.loadMounts(namespace.RootContext(nil)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	core.credentialBackends["approle"] = approle.Factory
+
+	// Enable approle
+	req := &logical.Request{
+		Path:        "sys/auth/approle",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "approle",
+		},
+		Connection: &logical.Connection{},
+	}
+	resp, err := core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Create role
+	req.Path = "auth/approle/role/my-role"
+	req.Data = map[string]interface{}{
+		"bind_secret_id":        false,
+		"secret_id_bound_cidrs": "127.0.0.1/32",
+		"role_id":               "my-role-id",
+	}
+	_, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Perform login
+	req = &logical.Request{
+		Path:      "auth/approle/login",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"role_id": "my-role-id",
+		},
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	}
+	resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	// Use the token granted the default policy
+	req = &logical.Request{
+		Path:        "auth/token/lookup-self",
+		Operation:   logical.ReadOperation,
+		ClientToken: resp.Auth.ClientToken,
+		Connection:  &logical.Connection{},
+	}
+	_, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The root policy granting the setup requests was counted first, so the
+	// cardinality limit is reached
+	checkCounter(t, inmemSink, "policy.request",
+		map[string]string{
+			"cluster":   "test-cluster",
+			"namespace": "root",
+			"policy":    metricsutil.OverflowLabelValue,
+		},
+	)
+	checkCounter(t, inmemSink, "approle.request",
+		map[string]string{
+			"cluster":     "test-cluster",
+			"namespace":   "root",
+			"mount_point": "auth/approle/",
+			"role_name":   "my-role",
+		},
+	)
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -31,6 +32,12 @@ type Router struct {
 	mountUUIDCache     *radix.Tree
 	mountAccessorCache *radix.Tree
 	tokenStoreSaltFunc func(context.Context) (*salt.Salt, error)
+	// passthroughHeadersFunc returns the request headers passed through to
+	// all backends, in addition to the passthrough headers of their mount
+	passthroughHeadersFunc func() []string
+	// excludedRequests matches the requests for which no route metrics are
+	// emitted
+	excludedRequests *requestFilter
 	// storagePrefix maps the prefix used for storage (ala the BarrierView)
 	// to the backend. This is used to map a key back into the backend that owns it.
 	// For example, logical/uuid1/foobar -> secrets/ (kv backend) + foobar
@@ -511,9 +518,11 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("no handler for route '%s'", req.Path)), false, false, logical.ErrUnsupportedPath
 	}
+	if !r.excludedRequests.excluded(req) {
+		defer metrics.MeasureSince([]string{"route", string(req.Operation),
+			strings.Replace(mount, "/", "-", -1)}, time.Now())
+	}
 	req.Path = adjustedPath
-	defer metrics.MeasureSince([]string{"route", string(req.Operation),
-		strings.Replace(mount, "/", "-", -1)}, time.Now())
 	re := raw.(*routeEntry)
 
 	// Grab a read lock on the route entry, this protects against the backend
@@ -624,6 +633,9 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	if rawVal, ok := re.mountEntry.synthesizedConfigCache.Load("passthrough_request_headers"); ok {
 		passthroughRequestHeaders = rawVal.([]string)
 	}
+	if r.passthroughHeadersFunc != nil {
+		passthroughRequestHeaders = append(r.passthroughHeadersFunc(), passthroughRequestHeaders...)
+	}
 	var allowedResponseHeaders []string
 	if rawVal, ok := re.mountEntry.synthesizedConfigCache.Load("allowed_response_headers"); ok {
 		allowedResponseHeaders = rawVal.([]string)
@@ -683,12 +695,22 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	} else {
 		resp, err := re.backend.HandleRequest(ctx, req)
 		if resp != nil {
+			etag := resp.ETag()
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
 			} else {
 				resp.Headers = nil
 			}
 
+			// Entity tags are always returned so that clients can make
+			// conditional requests
+			if etag == "" && err == nil {
+				etag = kvDataETag(re.mountEntry, req, resp)
+			}
+			if etag != "" {
+				resp.SetETag(etag)
+			}
+
 			if resp.Auth != nil {
 				// When a token gets renewed, the request hits this path and
 				// reaches token store. Token store delegates the renewal to the
@@ -866,3 +888,30 @@ func filteredHeaders(origHeaders map[string][]string, candidateHeaders, deniedHe
 
 	return retHeaders
 }
+
+// kvDataETag returns the entity tag of a successful read of a secret version
+// from a KV version 2 mount. Versions are immutable, so the response only
+// changes with the version read and its metadata: the tag is derived from the
+// mount, the path and the metadata of the version, and never from the secret
+// values, which could otherwise be guessed from it.
+func kvDataETag(entry *MountEntry, req *logical.Request, resp *logical.Response) string {
+	if entry.Type != "kv" || entry.Options["version"] != "2" {
+		return ""
+	}
+	if req.Operation != logical.ReadOperation || !strings.HasPrefix(req.Path, "data/") {
+		return ""
+	}
+	if resp.IsError() || resp.WrapInfo != nil || resp.Data == nil || resp.Data["data"] == nil || resp.Data["metadata"] == nil {
+		return ""
+	}
+	// Deleted versions are returned as raw 404 responses
+	if _, ok := resp.Data[logical.HTTPStatusCode]; ok {
+		return ""
+	}
+
+	metadata, err := json.Marshal(resp.Data["metadata"])
+	if err != nil {
+		return ""
+	}
+	return logical.NewETag([]byte(entry.UUID), []byte{0}, []byte(req.Path), []byte{0}, metadata)
+}
@@ -477,3 +477,77 @@ func TestPathsToRadix(t *testing.T) {
 		t.Fatalf("bad: %v (sub/bar)", raw)
 	}
 }
+
+func TestRouter_KVDataETag(t *testing.T) {
+	entry := &MountEntry{
+		UUID:    "mount-uuid",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	}
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "data/foo",
+	}
+	response := func(value string, version int) *logical.Response {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"data": map[string]interface{}{"bar": value},
+				"metadata": map[string]interface{}{
+					"version":      version,
+					"created_time": "2020-10-16T12:00:00Z",
+				},
+			},
+		}
+	}
+
+	etag := kvDataETag(entry, req, response("baz", 1))
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+
+	// The tag is derived from the metadata of the version, not its values
+	if other := kvDataETag(entry, req, response("qux", 1)); other != etag {
+		t.Fatalf("expected the ETag not to depend on the values, got %q and %q", etag, other)
+	}
+	if other := kvDataETag(entry, req, response("baz", 2)); other == etag {
+		t.Fatal("expected a new version to change the ETag")
+	}
+	if other := kvDataETag(entry, &logical.Request{Operation: logical.ReadOperation, Path: "data/other"}, response("baz", 1)); other == etag {
+		t.Fatal("expected another path to change the ETag")
+	}
+
+	// Only the successful data reads of KV version 2 mounts are tagged
+	for name, test := range map[string]struct {
+		entry *MountEntry
+		req   *logical.Request
+		resp  *logical.Response
+	}{
+		"version 1": {
+			entry: &MountEntry{UUID: "mount-uuid", Type: "kv", Options: map[string]string{"version": "1"}},
+			req:   req,
+			resp:  response("baz", 1),
+		},
+		"metadata": {
+			entry: entry,
+			req:   &logical.Request{Operation: logical.ReadOperation, Path: "metadata/foo"},
+			resp:  response("baz", 1),
+		},
+		"deleted": {
+			entry: entry,
+			req:   req,
+			resp: &logical.Response{
+				Data: map[string]interface{}{
+					"data":                  nil,
+					"metadata":              map[string]interface{}{"version": 1},
+					logical.HTTPStatusCode:  404,
+					logical.HTTPContentType: "application/json",
+					logical.HTTPRawBody:     []byte("{}"),
+				},
+			},
+		},
+	} {
+		if etag := kvDataETag(test.entry, test.req, test.resp); etag != "" {
+			t.Fatalf("%s: unexpected ETag %q", name, etag)
+		}
+	}
+}
@@ -337,6 +337,14 @@ type SealConfig struct {
 
 	// Stores the progress of the verification operation (key shares)
 	VerificationProgress [][]byte `json:"-"`
+
+	// RekeyPaused indicates that key shares can't be submitted to the rekey
+	// operation until it is resumed
+	RekeyPaused bool `json:"-"`
+
+	// RekeySubmissions records the key shares submitted during the rekey
+	// operation, by hash
+	RekeySubmissions []*RekeyShareSubmission `json:"-"`
 }
 
 // Validate is used to sanity check the seal configuration
@@ -390,6 +398,7 @@ func (s *SealConfig) Clone() *SealConfig {
 		StoredShares:         s.StoredShares,
 		VerificationRequired: s.VerificationRequired,
 		VerificationNonce:    s.VerificationNonce,
+		RekeyPaused:          s.RekeyPaused,
 	}
 	if len(s.PGPKeys) > 0 {
 		ret.PGPKeys = make([]string, len(s.PGPKeys))
@@ -399,6 +408,13 @@ func (s *SealConfig) Clone() *SealConfig {
 		ret.VerificationKey = make([]byte, len(s.VerificationKey))
 		copy(ret.VerificationKey, s.VerificationKey)
 	}
+	if len(s.RekeySubmissions) > 0 {
+		ret.RekeySubmissions = make([]*RekeyShareSubmission, len(s.RekeySubmissions))
+		for i, submission := range s.RekeySubmissions {
+			submissionCopy := *submission
+			ret.RekeySubmissions[i] = &submissionCopy
+		}
+	}
 	return ret
 }
 
@@ -0,0 +1,304 @@
+package tpm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead"
+	uuid "github.com/hashicorp/go-uuid"
+)
+
+// SealType is the type of the TPM seal.
+const SealType = "tpm"
+
+const (
+	// EnvTPMDevice, EnvTPMSealedKeyPath, EnvTPMPCRs and EnvTPMPCRBank
+	// override the configuration of the seal
+	EnvTPMDevice        = "VAULT_TPM_DEVICE"
+	EnvTPMSealedKeyPath = "VAULT_TPM_SEALED_KEY_PATH"
+	EnvTPMPCRs          = "VAULT_TPM_PCRS"
+	EnvTPMPCRBank       = "VAULT_TPM_PCR_BANK"
+
+	defaultDevice  = "/dev/tpmrm0"
+	defaultPCRs    = "7"
+	defaultPCRBank = "sha256"
+)
+
+// sealer seals data so that it can only be unsealed on this machine, in the
+// same state.
+type sealer interface {
+	seal(data []byte, sel pcrSelection) (*sealedData, error)
+	unsealData(sealed *sealedData, sel pcrSelection) ([]byte, error)
+}
+
+// sealedKeyFile is the content of the file holding the sealed key.
+type sealedKeyFile struct {
+	Sealed  *sealedData `json:"sealed"`
+	PCRs    []int       `json:"pcrs"`
+	PCRBank string      `json:"pcr_bank"`
+}
+
+// Wrapper is a seal whose key is sealed to the local TPM 2.0, bound to the
+// values of some of its PCRs. The key is generated and sealed the first time
+// the seal is configured, and stored in a file, so that Vault unseals itself
+// on reboot as long as the boot chain measured in the PCRs is unchanged.
+type Wrapper struct {
+	logger hclog.Logger
+	aead   *aeadwrapper.Wrapper
+
+	// openDevice opens the TPM device, overridden in tests
+	openDevice func(path string) (sealer, io.Closer, error)
+}
+
+var _ wrapping.Wrapper = (*Wrapper)(nil)
+
+// NewWrapper creates a new TPM wrapper. SetConfig must be called before it
+// is used.
+func NewWrapper(opts *wrapping.WrapperOptions) *Wrapper {
+	if opts == nil {
+		opts = new(wrapping.WrapperOptions)
+	}
+	return &Wrapper{
+		logger:     opts.Logger,
+		aead:       aeadwrapper.NewWrapper(nil),
+		openDevice: openDevice,
+	}
+}
+
+func openDevice(path string) (sealer, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open TPM device: %w", err)
+	}
+	return &client{rw: f}, f, nil
+}
+
+// SetConfig unseals the key from the sealed key file, generating and sealing
+// it to the current PCR values if the file does not exist.
+//
+// The following configuration is supported, and can be overridden by
+// environment variables:
+//   - device: the TPM device, /dev/tpmrm0 by default
+//   - sealed_key_path: the file holding the sealed key, required
+//   - pcrs: the comma-separated PCRs the key is sealed to, 7 by default
+//   - pcr_bank: the PCR bank, sha256 by default or sha1
+func (w *Wrapper) SetConfig(config map[string]string) (map[string]string, error) {
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	device := configValue(config, "device", EnvTPMDevice, defaultDevice)
+	path := configValue(config, "sealed_key_path", EnvTPMSealedKeyPath, "")
+	if path == "" {
+		return nil, errors.New("sealed_key_path is required")
+	}
+	pcrs, err := parsePCRs(configValue(config, "pcrs", EnvTPMPCRs, defaultPCRs))
+	if err != nil {
+		return nil, err
+	}
+	bankName := configValue(config, "pcr_bank", EnvTPMPCRBank, defaultPCRBank)
+	bank, ok := pcrBanks[bankName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported pcr_bank %q, must be sha1 or sha256", bankName)
+	}
+	sel := pcrSelection{bank: bank, pcrs: pcrs}
+
+	tpm, closer, err := w.openDevice(device)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	key, sealedKey, err := w.loadKey(tpm, path, sel, bankName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key ID identifies the sealed key, and is stable across restarts
+	keyIDHash := sha256.Sum256(sealedKey.Sealed.Public)
+	keyID := hex.EncodeToString(keyIDHash[:8])
+
+	_, err = w.aead.SetConfig(map[string]string{
+		"aead_type": "aes-gcm",
+		"key":       base64.StdEncoding.EncodeToString(key),
+		"key_id":    keyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pcrStrs := make([]string, len(sealedKey.PCRs))
+	for i, pcr := range sealedKey.PCRs {
+		pcrStrs[i] = strconv.Itoa(pcr)
+	}
+	return map[string]string{
+		"device":          device,
+		"sealed_key_path": path,
+		"pcrs":            strings.Join(pcrStrs, ","),
+		"pcr_bank":        sealedKey.PCRBank,
+		"key_id":          keyID,
+	}, nil
+}
+
+// loadKey unseals the key from the file at path, or generates and seals a
+// key there if it does not exist.
+func (w *Wrapper) loadKey(tpm sealer, path string, sel pcrSelection, bankName string) ([]byte, *sealedKeyFile, error) {
+	raw, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		var sealedKey sealedKeyFile
+		if err := json.Unmarshal(raw, &sealedKey); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse sealed key file %q: %w", path, err)
+		}
+		if sealedKey.Sealed == nil {
+			return nil, nil, fmt.Errorf("sealed key file %q holds no sealed key", path)
+		}
+		if !equalPCRs(sealedKey.PCRs, sel.pcrs) || sealedKey.PCRBank != bankName {
+			return nil, nil, fmt.Errorf("sealed key file %q was sealed to PCRs %v of bank %s, which do not match the configuration", path, sealedKey.PCRs, sealedKey.PCRBank)
+		}
+
+		key, err := tpm.unsealData(sealedKey.Sealed, sel)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &sealedKey, nil
+
+	case os.IsNotExist(err):
+		key, err := uuid.GenerateRandomBytes(32)
+		if err != nil {
+			return nil, nil, err
+		}
+		sealed, err := tpm.seal(key, sel)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Make sure the key can be unsealed before anything is encrypted
+		// with it
+		unsealed, err := tpm.unsealData(sealed, sel)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to unseal the newly sealed key: %w", err)
+		}
+		if string(unsealed) != string(key) {
+			return nil, nil, errors.New("the newly sealed key does not match once unsealed")
+		}
+
+		sealedKey := &sealedKeyFile{
+			Sealed:  sealed,
+			PCRs:    sel.pcrs,
+			PCRBank: bankName,
+		}
+		raw, err := json.Marshal(sealedKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+			return nil, nil, fmt.Errorf("failed to write sealed key file %q: %w", path, err)
+		}
+		if w.logger != nil {
+			w.logger.Info("sealed a new seal key to the TPM", "path", path, "pcrs", sel.pcrs)
+		}
+		return key, sealedKey, nil
+
+	default:
+		return nil, nil, fmt.Errorf("failed to read sealed key file %q: %w", path, err)
+	}
+}
+
+// Init is a no-op, as SetConfig unseals the key.
+func (w *Wrapper) Init(_ context.Context) error {
+	return nil
+}
+
+// Finalize is a no-op, as the TPM is not used once the key is unsealed.
+func (w *Wrapper) Finalize(_ context.Context) error {
+	return nil
+}
+
+// Type returns the type of the seal.
+func (w *Wrapper) Type() string {
+	return SealType
+}
+
+// KeyID returns the ID of the sealed key.
+func (w *Wrapper) KeyID() string {
+	return w.aead.KeyID()
+}
+
+// HMACKeyID returns the empty string, as HMACs are not supported.
+func (w *Wrapper) HMACKeyID() string {
+	return ""
+}
+
+// Encrypt encrypts the plaintext with the unsealed key, using AES-GCM.
+func (w *Wrapper) Encrypt(ctx context.Context, plaintext, aad []byte) (*wrapping.EncryptedBlobInfo, error) {
+	return w.aead.Encrypt(ctx, plaintext, aad)
+}
+
+// Decrypt decrypts the ciphertext with the unsealed key.
+func (w *Wrapper) Decrypt(ctx context.Context, in *wrapping.EncryptedBlobInfo, aad []byte) ([]byte, error) {
+	if in != nil && len(in.Ciphertext) < 12 {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return w.aead.Decrypt(ctx, in, aad)
+}
+
+func configValue(config map[string]string, key, env, def string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	if v := config[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// parsePCRs parses a comma-separated list of PCRs, sorted and deduplicated.
+func parsePCRs(raw string) ([]int, error) {
+	seen := map[int]bool{}
+	var pcrs []int
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		pcr, err := strconv.Atoi(s)
+		if err != nil || pcr < 0 || pcr > 23 {
+			return nil, fmt.Errorf("invalid PCR %q, must be between 0 and 23", s)
+		}
+		if !seen[pcr] {
+			seen[pcr] = true
+			pcrs = append(pcrs, pcr)
+		}
+	}
+	if len(pcrs) == 0 {
+		return nil, errors.New("at least one PCR is required")
+	}
+	sort.Ints(pcrs)
+	return pcrs, nil
+}
+
+func equalPCRs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,468 @@
+package tpm
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// This file implements the few TPM 2.0 commands needed to seal data to PCR
+// values, as specified in part 3 of the TPM 2.0 library specification.
+
+const (
+	tagNoSessions uint16 = 0x8001
+	tagSessions   uint16 = 0x8002
+
+	ccCreatePrimary    uint32 = 0x00000131
+	ccCreate           uint32 = 0x00000153
+	ccLoad             uint32 = 0x00000157
+	ccUnseal           uint32 = 0x0000015E
+	ccFlushContext     uint32 = 0x00000165
+	ccStartAuthSession uint32 = 0x00000176
+	ccPolicyPCR        uint32 = 0x0000017F
+	ccPolicyGetDigest  uint32 = 0x00000189
+
+	rhOwner uint32 = 0x40000001
+	rhNull  uint32 = 0x40000007
+	rsPW    uint32 = 0x40000009
+
+	algSHA1      uint16 = 0x0004
+	algAES       uint16 = 0x0006
+	algKeyedHash uint16 = 0x0008
+	algSHA256    uint16 = 0x000B
+	algNull      uint16 = 0x0010
+	algECC       uint16 = 0x0023
+	algCFB       uint16 = 0x0043
+
+	eccNistP256 uint16 = 0x0003
+
+	sePolicy uint8 = 0x01
+	seTrial  uint8 = 0x03
+
+	attrFixedTPM            uint32 = 1 << 1
+	attrFixedParent         uint32 = 1 << 4
+	attrSensitiveDataOrigin uint32 = 1 << 5
+	attrUserWithAuth        uint32 = 1 << 6
+	attrNoDA                uint32 = 1 << 10
+	attrRestricted          uint32 = 1 << 16
+	attrDecrypt             uint32 = 1 << 17
+
+	// maxResponseSize is the size of the largest response of the commands
+	// used, rounded up to the usual TPM buffer size
+	maxResponseSize = 4096
+
+	// maxSealedDataSize is the largest amount of data a sealed data object
+	// can hold
+	maxSealedDataSize = 128
+)
+
+// pcrBanks maps the names of the supported PCR banks to their algorithm.
+var pcrBanks = map[string]uint16{
+	"sha1":   algSHA1,
+	"sha256": algSHA256,
+}
+
+// pcrSelection is a set of PCRs of a bank.
+type pcrSelection struct {
+	bank uint16
+	pcrs []int
+}
+
+func (s pcrSelection) marshal(b *buffer) {
+	var bitmap [3]byte
+	for _, pcr := range s.pcrs {
+		bitmap[pcr/8] |= 1 << (uint(pcr) % 8)
+	}
+
+	// TPML_PCR_SELECTION with a single TPMS_PCR_SELECTION
+	b.u32(1)
+	b.u16(s.bank)
+	b.u8(uint8(len(bitmap)))
+	b.Write(bitmap[:])
+}
+
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) u8(v uint8) {
+	b.WriteByte(v)
+}
+
+func (b *buffer) u16(v uint16) {
+	var raw [2]byte
+	binary.BigEndian.PutUint16(raw[:], v)
+	b.Write(raw[:])
+}
+
+func (b *buffer) u32(v uint32) {
+	var raw [4]byte
+	binary.BigEndian.PutUint32(raw[:], v)
+	b.Write(raw[:])
+}
+
+// tpm2b writes a sized buffer.
+func (b *buffer) tpm2b(data []byte) {
+	b.u16(uint16(len(data)))
+	b.Write(data)
+}
+
+// reader reads the fields of a response, recording the first error.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < n {
+		r.err = errors.New("truncated TPM response")
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *reader) u32() uint32 {
+	v := r.next(4)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(v)
+}
+
+func (r *reader) u16() uint16 {
+	v := r.next(2)
+	if v == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(v)
+}
+
+func (r *reader) tpm2b() []byte {
+	return r.next(int(r.u16()))
+}
+
+// authCommand is an authorization of a command: a password or a policy
+// session, without HMAC.
+type authCommand struct {
+	handle   uint32
+	password []byte
+}
+
+// passwordAuth authorizes commands with the empty password, which is the
+// default authorization of the owner hierarchy and of the objects created.
+var passwordAuth = authCommand{handle: rsPW}
+
+// client sends TPM 2.0 commands to a TPM device.
+type client struct {
+	rw io.ReadWriter
+}
+
+// run sends the command and returns the reader of its response parameters,
+// after reading the given number of response handles.
+func (c *client) run(cc uint32, handles []uint32, auths []authCommand, params []byte, numRespHandles int) ([]uint32, *reader, error) {
+	var body buffer
+	for _, h := range handles {
+		body.u32(h)
+	}
+	tag := tagNoSessions
+	if len(auths) > 0 {
+		tag = tagSessions
+
+		var authArea buffer
+		for _, auth := range auths {
+			authArea.u32(auth.handle)
+			authArea.tpm2b(nil)
+			// continueSession is left clear, so that policy sessions are
+			// flushed once used
+			authArea.u8(0)
+			authArea.tpm2b(auth.password)
+		}
+		body.u32(uint32(authArea.Len()))
+		body.Write(authArea.Bytes())
+	}
+	body.Write(params)
+
+	var cmd buffer
+	cmd.u16(tag)
+	cmd.u32(uint32(10 + body.Len()))
+	cmd.u32(cc)
+	cmd.Write(body.Bytes())
+
+	if _, err := c.rw.Write(cmd.Bytes()); err != nil {
+		return nil, nil, fmt.Errorf("failed to send TPM command: %w", err)
+	}
+
+	// TPM devices return the whole response in a single read
+	resp := make([]byte, maxResponseSize)
+	n, err := c.rw.Read(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read TPM response: %w", err)
+	}
+	r := &reader{data: resp[:n]}
+	respTag := r.u16()
+	size := r.u32()
+	rc := r.u32()
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if rc != 0 {
+		return nil, nil, &responseError{command: cc, code: rc}
+	}
+	if int(size) != n {
+		return nil, nil, fmt.Errorf("TPM response of %d bytes does not match its size %d", n, size)
+	}
+
+	respHandles := make([]uint32, numRespHandles)
+	for i := range respHandles {
+		respHandles[i] = r.u32()
+	}
+	if respTag == tagSessions {
+		r.data = r.next(int(r.u32()))
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return respHandles, r, nil
+}
+
+// responseError is the error code returned by the TPM for a command.
+type responseError struct {
+	command uint32
+	code    uint32
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("TPM command 0x%x failed with response code 0x%x", e.command, e.code)
+}
+
+// createPrimary creates the storage root key in the owner hierarchy, which
+// is derived from the seed of the hierarchy and thus the same each time.
+func (c *client) createPrimary() (uint32, error) {
+	var template buffer
+	template.u16(algECC)
+	template.u16(algSHA256)
+	template.u32(attrFixedTPM | attrFixedParent | attrSensitiveDataOrigin | attrUserWithAuth | attrNoDA | attrRestricted | attrDecrypt)
+	template.tpm2b(nil)
+	// TPMS_ECC_PARMS: AES-128-CFB symmetric, no scheme, P-256, no KDF
+	template.u16(algAES)
+	template.u16(128)
+	template.u16(algCFB)
+	template.u16(algNull)
+	template.u16(eccNistP256)
+	template.u16(algNull)
+	// Empty unique point
+	template.tpm2b(nil)
+	template.tpm2b(nil)
+
+	var params buffer
+	params.tpm2b(sensitiveCreate(nil))
+	params.tpm2b(template.Bytes())
+	params.tpm2b(nil)
+	params.u32(0)
+
+	handles, _, err := c.run(ccCreatePrimary, []uint32{rhOwner}, []authCommand{passwordAuth}, params.Bytes(), 1)
+	if err != nil {
+		return 0, err
+	}
+	return handles[0], nil
+}
+
+// create creates a sealed data object under the parent, which can only be
+// unsealed with the given policy.
+func (c *client) create(parent uint32, data, policyDigest []byte) ([]byte, []byte, error) {
+	var template buffer
+	template.u16(algKeyedHash)
+	template.u16(algSHA256)
+	template.u32(attrFixedTPM | attrFixedParent | attrNoDA)
+	template.tpm2b(policyDigest)
+	// TPMS_KEYEDHASH_PARMS without scheme, and empty unique digest
+	template.u16(algNull)
+	template.tpm2b(nil)
+
+	var params buffer
+	params.tpm2b(sensitiveCreate(data))
+	params.tpm2b(template.Bytes())
+	params.tpm2b(nil)
+	params.u32(0)
+
+	_, r, err := c.run(ccCreate, []uint32{parent}, []authCommand{passwordAuth}, params.Bytes(), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	private := r.tpm2b()
+	public := r.tpm2b()
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return private, public, nil
+}
+
+// load loads a sealed data object under the parent.
+func (c *client) load(parent uint32, private, public []byte) (uint32, error) {
+	var params buffer
+	params.tpm2b(private)
+	params.tpm2b(public)
+
+	handles, _, err := c.run(ccLoad, []uint32{parent}, []authCommand{passwordAuth}, params.Bytes(), 1)
+	if err != nil {
+		return 0, err
+	}
+	return handles[0], nil
+}
+
+// startAuthSession starts an unbound and unsalted policy or trial session.
+func (c *client) startAuthSession(sessionType uint8) (uint32, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, err
+	}
+
+	var params buffer
+	params.tpm2b(nonce)
+	params.tpm2b(nil)
+	params.u8(sessionType)
+	params.u16(algNull)
+	params.u16(algSHA256)
+
+	handles, _, err := c.run(ccStartAuthSession, []uint32{rhNull, rhNull}, nil, params.Bytes(), 1)
+	if err != nil {
+		return 0, err
+	}
+	return handles[0], nil
+}
+
+// policyPCR binds the policy of the session to the current values of the
+// PCRs.
+func (c *client) policyPCR(session uint32, sel pcrSelection) error {
+	var params buffer
+	params.tpm2b(nil)
+	sel.marshal(&params)
+
+	_, _, err := c.run(ccPolicyPCR, []uint32{session}, nil, params.Bytes(), 0)
+	return err
+}
+
+func (c *client) policyGetDigest(session uint32) ([]byte, error) {
+	_, r, err := c.run(ccPolicyGetDigest, []uint32{session}, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	digest := r.tpm2b()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return digest, nil
+}
+
+// unseal returns the data of the loaded sealed data object, authorized by
+// the policy session.
+func (c *client) unseal(item, session uint32) ([]byte, error) {
+	_, r, err := c.run(ccUnseal, []uint32{item}, []authCommand{{handle: session}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	data := r.tpm2b()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return data, nil
+}
+
+func (c *client) flushContext(handle uint32) error {
+	var params buffer
+	params.u32(handle)
+
+	_, _, err := c.run(ccFlushContext, nil, nil, params.Bytes(), 0)
+	return err
+}
+
+// sensitiveCreate returns a TPMS_SENSITIVE_CREATE with an empty password.
+func sensitiveCreate(data []byte) []byte {
+	var b buffer
+	b.tpm2b(nil)
+	b.tpm2b(data)
+	return b.Bytes()
+}
+
+// sealedData is data sealed to PCR values by a TPM.
+type sealedData struct {
+	Private []byte `json:"private"`
+	Public  []byte `json:"public"`
+}
+
+// seal seals the data to the current values of the selected PCRs.
+func (c *client) seal(data []byte, sel pcrSelection) (*sealedData, error) {
+	if len(data) > maxSealedDataSize {
+		return nil, fmt.Errorf("cannot seal more than %d bytes", maxSealedDataSize)
+	}
+
+	srk, err := c.createPrimary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the storage root key: %w", err)
+	}
+	defer c.flushContext(srk)
+
+	trial, err := c.startAuthSession(seTrial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start a trial session: %w", err)
+	}
+	defer c.flushContext(trial)
+
+	if err := c.policyPCR(trial, sel); err != nil {
+		return nil, fmt.Errorf("failed to compute the PCR policy: %w", err)
+	}
+	policyDigest, err := c.policyGetDigest(trial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the PCR policy: %w", err)
+	}
+
+	private, public, err := c.create(srk, data, policyDigest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the sealed data object: %w", err)
+	}
+	return &sealedData{
+		Private: private,
+		Public:  public,
+	}, nil
+}
+
+// unsealData returns the sealed data, which only succeeds if the selected
+// PCRs have the values they had when it was sealed.
+func (c *client) unsealData(sealed *sealedData, sel pcrSelection) ([]byte, error) {
+	srk, err := c.createPrimary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the storage root key: %w", err)
+	}
+	defer c.flushContext(srk)
+
+	item, err := c.load(srk, sealed.Private, sealed.Public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the sealed data object: %w", err)
+	}
+	defer c.flushContext(item)
+
+	session, err := c.startAuthSession(sePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start a policy session: %w", err)
+	}
+	if err := c.policyPCR(session, sel); err != nil {
+		c.flushContext(session)
+		return nil, fmt.Errorf("failed to satisfy the PCR policy: %w", err)
+	}
+
+	// The session is flushed by the TPM once used
+	data, err := c.unseal(item, session)
+	if err != nil {
+		c.flushContext(session)
+		return nil, fmt.Errorf("failed to unseal, the PCR values may have changed since the data was sealed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,204 @@
+package tpm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testSealer seals data to the value of its state, standing in for the PCR
+// values of a TPM.
+type testSealer struct {
+	state  string
+	sealed map[string][]byte
+}
+
+func (s *testSealer) seal(data []byte, sel pcrSelection) (*sealedData, error) {
+	id := fmt.Sprintf("%d", len(s.sealed))
+	s.sealed[id] = append([]byte(s.state+":"), data...)
+	return &sealedData{Private: []byte(id), Public: []byte("public-" + id)}, nil
+}
+
+func (s *testSealer) unsealData(sealed *sealedData, sel pcrSelection) ([]byte, error) {
+	data, ok := s.sealed[string(sealed.Private)]
+	if !ok || !bytes.HasPrefix(data, []byte(s.state+":")) {
+		return nil, errors.New("policy check failed")
+	}
+	return data[len(s.state)+1:], nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func TestWrapper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tpm-seal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sealed-key.json")
+
+	tpm := &testSealer{state: "boot", sealed: map[string][]byte{}}
+	newWrapper := func() *Wrapper {
+		w := NewWrapper(nil)
+		w.openDevice = func(string) (sealer, io.Closer, error) {
+			return tpm, nopCloser{}, nil
+		}
+		return w
+	}
+	config := map[string]string{
+		"sealed_key_path": path,
+		"pcrs":            "7, 0,7",
+	}
+
+	if _, err := newWrapper().SetConfig(map[string]string{}); err == nil {
+		t.Fatal("expected an error without sealed_key_path")
+	}
+
+	// The key is generated and sealed on first use
+	w := newWrapper()
+	info, err := w.SetConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["pcrs"] != "0,7" || info["pcr_bank"] != "sha256" {
+		t.Fatalf("bad info: %#v", info)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := w.Encrypt(context.Background(), []byte("master key"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blob.KeyInfo.KeyID != w.KeyID() || w.KeyID() == "" {
+		t.Fatalf("bad key ID %q", blob.KeyInfo.KeyID)
+	}
+
+	// After a restart, the key is unsealed from the file
+	w2 := newWrapper()
+	if _, err := w2.SetConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if w2.KeyID() != w.KeyID() {
+		t.Fatalf("key ID changed from %q to %q", w.KeyID(), w2.KeyID())
+	}
+	plaintext, err := w2.Decrypt(context.Background(), blob, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "master key" {
+		t.Fatalf("bad plaintext %q", plaintext)
+	}
+
+	// The PCRs cannot be changed once the key is sealed
+	if _, err := newWrapper().SetConfig(map[string]string{"sealed_key_path": path, "pcrs": "7"}); err == nil {
+		t.Fatal("expected an error with other PCRs")
+	}
+
+	// The key cannot be unsealed once the PCR values changed
+	tpm.state = "tampered"
+	if _, err := newWrapper().SetConfig(config); err == nil {
+		t.Fatal("expected an error once the PCR values changed")
+	}
+}
+
+func TestParsePCRs(t *testing.T) {
+	pcrs, err := parsePCRs("7,0, 4,7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pcrs, []int{0, 4, 7}) {
+		t.Fatalf("bad PCRs %v", pcrs)
+	}
+
+	for _, raw := range []string{"", "24", "-1", "seven"} {
+		if _, err := parsePCRs(raw); err == nil {
+			t.Fatalf("expected an error parsing %q", raw)
+		}
+	}
+}
+
+// testDevice records the commands written and returns canned responses.
+type testDevice struct {
+	commands  [][]byte
+	responses [][]byte
+}
+
+func (d *testDevice) Write(p []byte) (int, error) {
+	d.commands = append(d.commands, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (d *testDevice) Read(p []byte) (int, error) {
+	resp := d.responses[0]
+	d.responses = d.responses[1:]
+	return copy(p, resp), nil
+}
+
+func TestClient(t *testing.T) {
+	// TPM2_PolicyPCR for PCRs 0 and 7 of the SHA-256 bank
+	dev := &testDevice{responses: [][]byte{
+		{0x80, 0x01, 0, 0, 0, 10, 0, 0, 0, 0},
+	}}
+	c := &client{rw: dev}
+	if err := c.policyPCR(0x03000000, pcrSelection{bank: algSHA256, pcrs: []int{0, 7}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0x80, 0x01, 0, 0, 0, 26, 0, 0, 0x01, 0x7F,
+		0x03, 0, 0, 0,
+		0, 0,
+		0, 0, 0, 1, 0, 0x0B, 3, 0x81, 0, 0,
+	}
+	if !bytes.Equal(dev.commands[0], expected) {
+		t.Fatalf("bad command:\n%x\nexpected:\n%x", dev.commands[0], expected)
+	}
+
+	// TPM2_Unseal with a policy session, and a response with sessions
+	dev = &testDevice{responses: [][]byte{
+		{
+			0x80, 0x02, 0, 0, 0, 24, 0, 0, 0, 0,
+			0, 0, 0, 5,
+			0, 3, 'k', 'e', 'y',
+			0, 0, 0, 0, 0,
+		},
+	}}
+	c = &client{rw: dev}
+	data, err := c.unseal(0x80000001, 0x03000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "key" {
+		t.Fatalf("bad data %q", data)
+	}
+	expected = []byte{
+		0x80, 0x02, 0, 0, 0, 27, 0, 0, 0x01, 0x5E,
+		0x80, 0, 0, 0x01,
+		0, 0, 0, 9,
+		0x03, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+	if !bytes.Equal(dev.commands[0], expected) {
+		t.Fatalf("bad command:\n%x\nexpected:\n%x", dev.commands[0], expected)
+	}
+
+	// Error codes are returned
+	dev = &testDevice{responses: [][]byte{
+		{0x80, 0x01, 0, 0, 0, 10, 0, 0, 0x09, 0x9D},
+	}}
+	c = &client{rw: dev}
+	err = c.flushContext(0x80000001)
+	rerr, ok := err.(*responseError)
+	if !ok || rerr.code != 0x99D {
+		t.Fatalf("expected a response error, got: %v", err)
+	}
+}
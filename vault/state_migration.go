@@ -0,0 +1,310 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// StateTransform rewrites the state exported from a mount before it is
+// imported into another one.
+type StateTransform func(*logical.BackendState) (*logical.BackendState, error)
+
+// StateTransformFactory returns the transform configured by the given
+// parameters.
+type StateTransformFactory func(params map[string]interface{}) (StateTransform, error)
+
+// stateTransforms are the transforms that can be applied when migrating the
+// state of a mount, by type
+var stateTransforms = map[string]StateTransformFactory{
+	"rename_keys": renameKeysStateTransform,
+	"exclude":     excludeStateTransform,
+}
+
+// StateMigrationResult is the outcome of a state migration.
+type StateMigrationResult struct {
+	Format   string         `json:"format"`
+	Exported map[string]int `json:"exported"`
+	Imported map[string]int `json:"imported"`
+	DryRun   bool           `json:"dry_run"`
+}
+
+// migrateMountState exports the state of the mount at fromPath, applies the
+// transforms in order and imports the result into the mount at toPath. Both
+// paths must be mount points, relative to the namespace of the context, and
+// their backends must support state export and import. Nothing is imported
+// in a dry run. The mount at fromPath is left untouched.
+func (c *Core) migrateMountState(ctx context.Context, fromPath, toPath string, transforms []StateTransform, dryRun bool) (*StateMigrationResult, error) {
+	fromPath = sanitizePath(fromPath)
+	toPath = sanitizePath(toPath)
+	if fromPath == toPath {
+		return nil, errors.New("cannot migrate the state of a mount to itself")
+	}
+
+	from, fromStorage, err := c.stateMigrationMount(ctx, fromPath)
+	if err != nil {
+		return nil, err
+	}
+	to, toStorage, err := c.stateMigrationMount(ctx, toPath)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := logical.ExportBackendState(ctx, from, fromStorage)
+	if err == logical.ErrUnsupportedOperation {
+		return nil, fmt.Errorf("the backend mounted at %q does not support exporting its state", fromPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export the state of %q: %w", fromPath, err)
+	}
+	if state == nil {
+		state = &logical.BackendState{}
+	}
+
+	result := &StateMigrationResult{
+		Format:   state.Format,
+		Exported: countStateEntries(state),
+		DryRun:   dryRun,
+	}
+
+	for i, transform := range transforms {
+		state, err = transform(state)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d failed: %w", i, err)
+		}
+	}
+	result.Imported = countStateEntries(state)
+	if dryRun {
+		return result, nil
+	}
+
+	err = logical.ImportBackendState(ctx, to, toStorage, state)
+	if err == logical.ErrUnsupportedOperation {
+		return nil, fmt.Errorf("the backend mounted at %q does not support importing state", toPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to import the state into %q: %w", toPath, err)
+	}
+
+	c.logger.Info("migrated mount state", "from_path", fromPath, "to_path", toPath, "entries", len(state.Entries))
+	return result, nil
+}
+
+// stateMigrationMount returns the backend and storage of the mount at path.
+func (c *Core) stateMigrationMount(ctx context.Context, path string) (logical.Backend, logical.Storage, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.router.MatchingMount(ctx, path) != ns.Path+path {
+		return nil, nil, fmt.Errorf("no mount at %q", path)
+	}
+
+	backend := c.router.MatchingBackend(ctx, path)
+	storage := c.router.MatchingStorageByAPIPath(ctx, path)
+	if backend == nil || storage == nil {
+		return nil, nil, fmt.Errorf("no backend mounted at %q", path)
+	}
+	return backend, storage, nil
+}
+
+func countStateEntries(state *logical.BackendState) map[string]int {
+	counts := map[string]int{}
+	for _, e := range state.Entries {
+		counts[e.Kind]++
+	}
+	return counts
+}
+
+// parseStateTransforms returns the transforms described by raw, a list of
+// objects whose type field is the type of the transform and whose other
+// fields are its parameters.
+func parseStateTransforms(raw []interface{}) ([]StateTransform, error) {
+	var transforms []StateTransform
+	for i, r := range raw {
+		params, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("transform %d is not an object", i)
+		}
+		typ, _ := params["type"].(string)
+		factory, ok := stateTransforms[typ]
+		if !ok {
+			return nil, fmt.Errorf("transform %d has an unknown type %q", i, typ)
+		}
+
+		rest := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			if k != "type" {
+				rest[k] = v
+			}
+		}
+		transform, err := factory(rest)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i, err)
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// decodeStateTransformParams decodes the parameters of a transform, failing on
+// unknown parameters.
+func decodeStateTransformParams(params map[string]interface{}, out interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(params)
+}
+
+// stateTransformMatchesKind returns whether the transform applies to the
+// entries of the kind, all kinds matching if none are given.
+func stateTransformMatchesKind(kinds []string, kind string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// renameKeysStateTransform replaces the from_prefix of the keys of the
+// entries with to_prefix, optionally only for the entries of some kinds.
+func renameKeysStateTransform(params map[string]interface{}) (StateTransform, error) {
+	var config struct {
+		FromPrefix string   `mapstructure:"from_prefix"`
+		ToPrefix   string   `mapstructure:"to_prefix"`
+		Kinds      []string `mapstructure:"kinds"`
+	}
+	if err := decodeStateTransformParams(params, &config); err != nil {
+		return nil, err
+	}
+	if config.FromPrefix == "" && config.ToPrefix == "" {
+		return nil, errors.New("from_prefix or to_prefix is required")
+	}
+
+	return func(state *logical.BackendState) (*logical.BackendState, error) {
+		seen := map[string]bool{}
+		for _, e := range state.Entries {
+			if stateTransformMatchesKind(config.Kinds, e.Kind) && strings.HasPrefix(e.Key, config.FromPrefix) {
+				e.Key = config.ToPrefix + strings.TrimPrefix(e.Key, config.FromPrefix)
+			}
+			id := e.Kind + "/" + e.Key
+			if seen[id] {
+				return nil, fmt.Errorf("renaming results in duplicate %s %q", e.Kind, e.Key)
+			}
+			seen[id] = true
+		}
+		return state, nil
+	}, nil
+}
+
+// excludeStateTransform drops the entries of the given kinds whose key has
+// the given prefix. At least one of them must be given.
+func excludeStateTransform(params map[string]interface{}) (StateTransform, error) {
+	var config struct {
+		KeyPrefix string   `mapstructure:"key_prefix"`
+		Kinds     []string `mapstructure:"kinds"`
+	}
+	if err := decodeStateTransformParams(params, &config); err != nil {
+		return nil, err
+	}
+	if config.KeyPrefix == "" && len(config.Kinds) == 0 {
+		return nil, errors.New("key_prefix or kinds is required")
+	}
+
+	return func(state *logical.BackendState) (*logical.BackendState, error) {
+		entries := state.Entries[:0]
+		for _, e := range state.Entries {
+			if stateTransformMatchesKind(config.Kinds, e.Kind) && strings.HasPrefix(e.Key, config.KeyPrefix) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		state.Entries = entries
+		return state, nil
+	}, nil
+}
+
+func (b *SystemBackend) migrateStatePath() *framework.Path {
+	return &framework.Path{
+		Pattern: "migrate-state$",
+		Fields: map[string]*framework.FieldSchema{
+			"from": {
+				Type:        framework.TypeString,
+				Description: "The mount point to export the state from, prefixed with auth/ for auth methods.",
+			},
+			"to": {
+				Type:        framework.TypeString,
+				Description: "The mount point to import the state into, prefixed with auth/ for auth methods.",
+			},
+			"transforms": {
+				Type:        framework.TypeSlice,
+				Description: "The transforms applied to the state before it is imported, in order. Each is an object whose type field is rename_keys or exclude.",
+			},
+			"dry_run": {
+				Type:        framework.TypeBool,
+				Description: "If set, the state is exported and transformed but not imported.",
+			},
+		},
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleMigrateState,
+				Summary:  strings.TrimSpace(sysHelp["migrate-state"][0]),
+			},
+		},
+		HelpSynopsis:    strings.TrimSpace(sysHelp["migrate-state"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["migrate-state"][1]),
+	}
+}
+
+func (b *SystemBackend) handleMigrateState(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	fromPath := data.Get("from").(string)
+	toPath := data.Get("to").(string)
+	if fromPath == "" || toPath == "" {
+		return logical.ErrorResponse("both 'from' and 'to' path must be specified as a string"), logical.ErrInvalidRequest
+	}
+
+	transforms, err := parseStateTransforms(data.Get("transforms").([]interface{}))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	dryRun := data.Get("dry_run").(bool)
+
+	// The state cannot be imported into a replicated mount on a performance
+	// secondary
+	entry := b.Core.router.MatchingMountEntry(ctx, sanitizePath(toPath))
+	if !dryRun && entry != nil && !entry.Local && b.Core.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil, logical.ErrReadOnly
+	}
+
+	result, err := b.Core.migrateMountState(ctx, fromPath, toPath, transforms, dryRun)
+	if err != nil {
+		b.Backend.Logger().Error("state migration failed", "from_path", fromPath, "to_path", toPath, "error", err)
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"format":   result.Format,
+			"exported": result.Exported,
+			"imported": result.Imported,
+			"dry_run":  result.DryRun,
+		},
+	}, nil
+}
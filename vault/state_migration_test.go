@@ -0,0 +1,149 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testStateBackend stores its objects as storage entries whose key is the
+// kind and key of the object, exporting and importing them as is.
+func testStateBackend(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+	return &framework.Backend{
+		BackendType: logical.TypeLogical,
+		ExportStateFunc: func(ctx context.Context, s logical.Storage) (*logical.BackendState, error) {
+			keys, err := logical.CollectKeys(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			state := &logical.BackendState{Format: "test"}
+			for _, key := range keys {
+				entry, err := s.Get(ctx, key)
+				if err != nil {
+					return nil, err
+				}
+				parts := strings.SplitN(key, "/", 2)
+				state.Entries = append(state.Entries, &logical.StateEntry{
+					Kind: parts[0],
+					Key:  parts[1],
+					Data: map[string]interface{}{"value": string(entry.Value)},
+				})
+			}
+			return state, nil
+		},
+		ImportStateFunc: func(ctx context.Context, s logical.Storage, state *logical.BackendState) error {
+			for _, e := range state.Entries {
+				err := s.Put(ctx, &logical.StorageEntry{
+					Key:   e.Kind + "/" + e.Key,
+					Value: []byte(e.Data["value"].(string)),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func TestCore_MigrateMountState(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	c.logicalBackends["state"] = testStateBackend
+	ctx := namespace.RootContext(nil)
+
+	for _, path := range []string{"old/", "new/"} {
+		err := c.mount(ctx, &MountEntry{
+			Table: mountTableType,
+			Path:  path,
+			Type:  "state",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storage := c.router.MatchingStorageByAPIPath(ctx, "old/")
+	for _, key := range []string{"role/a", "role/b", "secret/team/a", "secret/other"} {
+		if err := storage.Put(ctx, &logical.StorageEntry{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transforms, err := parseStateTransforms([]interface{}{
+		map[string]interface{}{"type": "exclude", "kinds": []interface{}{"secret"}, "key_prefix": "other"},
+		map[string]interface{}{"type": "rename_keys", "kinds": "secret", "from_prefix": "team/", "to_prefix": "teams/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run imports nothing
+	result, err := c.migrateMountState(ctx, "old", "new", transforms, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Exported["secret"] != 2 || result.Imported["secret"] != 1 || result.Imported["role"] != 2 {
+		t.Fatalf("bad result: %#v", result)
+	}
+	keys, err := logical.CollectKeys(ctx, c.router.MatchingStorageByAPIPath(ctx, "new/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected no keys after a dry run, got: %v", keys)
+	}
+
+	if _, err := c.migrateMountState(ctx, "old", "new", transforms, false); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = logical.CollectKeys(ctx, c.router.MatchingStorageByAPIPath(ctx, "new/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "role/a,role/b,secret/teams/a"
+	if strings.Join(keys, ",") != expected {
+		t.Fatalf("expected keys %q, got: %v", expected, keys)
+	}
+
+	// Only mount points of backends supporting state export can be migrated
+	if _, err := c.migrateMountState(ctx, "old/role", "new", nil, false); err == nil {
+		t.Fatal("expected an error migrating a path which is not a mount point")
+	}
+	if _, err := c.migrateMountState(ctx, "cubbyhole", "new", nil, false); err == nil {
+		t.Fatal("expected an error migrating a backend without state export")
+	}
+}
+
+func TestParseStateTransforms(t *testing.T) {
+	for _, raw := range []interface{}{
+		"rename_keys",
+		map[string]interface{}{"type": "unknown"},
+		map[string]interface{}{"type": "rename_keys"},
+		map[string]interface{}{"type": "rename_keys", "from_prefix": "a", "unknown": "b"},
+		map[string]interface{}{"type": "exclude"},
+	} {
+		if _, err := parseStateTransforms([]interface{}{raw}); err == nil {
+			t.Fatalf("expected an error parsing %#v", raw)
+		}
+	}
+
+	transforms, err := parseStateTransforms([]interface{}{
+		map[string]interface{}{"type": "rename_keys", "from_prefix": "a", "to_prefix": "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = transforms[0](&logical.BackendState{
+		Entries: []*logical.StateEntry{
+			{Kind: "secret", Key: "a"},
+			{Kind: "secret", Key: "b"},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected an error renaming to an existing key")
+	}
+}
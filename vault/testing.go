@@ -157,6 +157,9 @@ func TestCoreWithSealAndUI(t testing.T, opts *CoreConfig) *Core {
 	conf.DisableKeyEncodingChecks = opts.DisableKeyEncodingChecks
 	conf.MetricsHelper = opts.MetricsHelper
 	conf.MetricSink = opts.MetricSink
+	conf.ExcludedUnauthPaths = opts.ExcludedUnauthPaths
+	conf.CubbyholeMaxSize = opts.CubbyholeMaxSize
+	conf.MaintenanceWindows = opts.MaintenanceWindows
 
 	if opts.Logger != nil {
 		conf.Logger = opts.Logger
@@ -1400,6 +1403,9 @@ func NewTestCluster(t testing.T, base *CoreConfig, opts *TestClusterOptions) *Te
 		coreConfig.DefaultLeaseTTL = base.DefaultLeaseTTL
 		coreConfig.MaxLeaseTTL = base.MaxLeaseTTL
 		coreConfig.CacheSize = base.CacheSize
+		coreConfig.CacheSizeBytes = base.CacheSizeBytes
+		coreConfig.CacheEvictionPolicy = base.CacheEvictionPolicy
+		coreConfig.CacheMetricsPrefixes = base.CacheMetricsPrefixes
 		coreConfig.PluginDirectory = base.PluginDirectory
 		coreConfig.Seal = base.Seal
 		coreConfig.UnwrapSeal = base.UnwrapSeal
@@ -2075,7 +2081,11 @@ func (m *mockBuiltinRegistry) Keys(pluginType consts.PluginType) []string {
 		"mongodbatlas-database-plugin",
 		"mssql-database-plugin",
 		"postgresql-database-plugin",
+		"redis-database-plugin",
 		"redshift-database-plugin",
+		"snowflake-database-plugin",
+		"trino-database-plugin",
+		"yugabytedb-database-plugin",
 	}
 }
 
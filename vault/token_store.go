@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 	"sync"
@@ -51,6 +50,10 @@ const (
 	// secondary parent based index
 	parentPrefix = "parent/"
 
+	// entityAccessorPrefix is the prefix used to store the index from
+	// Entity ID to the accessors of its tokens
+	entityAccessorPrefix = "entity-accessor/"
+
 	// tokenSubPath is the sub-path used for the token store
 	// view. This is nested under the system view.
 	tokenSubPath = "token/"
@@ -167,6 +170,10 @@ func (ts *TokenStore) paths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(tokenCreateHelp),
 		},
 
+		ts.exchangePath(),
+		ts.selfAccessorsPath(),
+		ts.revokeSelfAccessorPath(),
+
 		{
 			Pattern: "lookup",
 
@@ -393,9 +400,10 @@ func (ts *TokenStore) paths() []*framework.Path {
 			},
 
 			"period": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: "Use 'token_period' instead.",
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    "Use 'token_period' instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"path_suffix": &framework.FieldSchema{
@@ -404,9 +412,10 @@ func (ts *TokenStore) paths() []*framework.Path {
 			},
 
 			"explicit_max_ttl": &framework.FieldSchema{
-				Type:        framework.TypeDurationSecond,
-				Description: "Use 'token_explicit_max_ttl' instead.",
-				Deprecated:  true,
+				Type:           framework.TypeDurationSecond,
+				Description:    "Use 'token_explicit_max_ttl' instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"renewable": &framework.FieldSchema{
@@ -416,9 +425,10 @@ func (ts *TokenStore) paths() []*framework.Path {
 			},
 
 			"bound_cidrs": &framework.FieldSchema{
-				Type:        framework.TypeCommaStringSlice,
-				Description: "Use 'token_bound_cidrs' instead.",
-				Deprecated:  true,
+				Type:           framework.TypeCommaStringSlice,
+				Description:    "Use 'token_bound_cidrs' instead.",
+				Deprecated:     true,
+				RemovalVersion: "1.8",
 			},
 
 			"allowed_entity_aliases": &framework.FieldSchema{
@@ -483,6 +493,7 @@ type TokenStore struct {
 	idBarrierView       *BarrierView
 	accessorBarrierView *BarrierView
 	parentBarrierView   *BarrierView
+	entityBarrierView   *BarrierView
 	rolesBarrierView    *BarrierView
 
 	expiration  *ExpirationManager
@@ -527,6 +538,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 		idBarrierView:         view.SubView(idPrefix),
 		accessorBarrierView:   view.SubView(accessorPrefix),
 		parentBarrierView:     view.SubView(parentPrefix),
+		entityBarrierView:     view.SubView(entityAccessorPrefix),
 		rolesBarrierView:      view.SubView(rolesPrefix),
 		cubbyholeDestroyer:    destroyCubbyhole,
 		logger:                logger,
@@ -554,6 +566,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 				idPrefix,
 				accessorPrefix,
 				parentPrefix,
+				entityAccessorPrefix,
 				salt.DefaultLocation,
 			},
 		},
@@ -789,6 +802,14 @@ func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEn
 	if err := ts.accessorView(tokenNS).Put(ctx, le); err != nil {
 		return errwrap.Wrapf("failed to persist accessor index entry: {{err}}", err)
 	}
+
+	// Index the accessor by the entity of the token, so that the entity can
+	// list its own tokens
+	if entry.EntityID != "" {
+		if err := ts.putEntityAccessor(saltCtx, tokenNS, entry.EntityID, saltID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -898,6 +919,7 @@ func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) err
 			EntityID:     entry.EntityID,
 			NamespaceID:  entry.NamespaceID,
 			Type:         uint32(entry.Type),
+			Session:      pb.LogicalAuthSessionToProtoAuthSession(entry.Session),
 		}
 
 		boundCIDRs := make([]string, len(entry.BoundCIDRs))
@@ -1485,6 +1507,16 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		if err = ts.accessorView(tokenNS).Delete(ctx, accessorSaltedID); err != nil {
 			return errwrap.Wrapf("failed to delete entry: {{err}}", err)
 		}
+
+		if entry.EntityID != "" {
+			key, err := ts.entityAccessorKey(revokeCtx, entry.EntityID, accessorSaltedID)
+			if err != nil {
+				return err
+			}
+			if err = ts.entityView(tokenNS).Delete(ctx, key); err != nil {
+				return errwrap.Wrapf("failed to delete entry: {{err}}", err)
+			}
+		}
 	}
 
 	if !skipOrphan {
@@ -1786,7 +1818,9 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 		return nil, errwrap.Wrapf("failed to get namespace from context: {{err}}", err)
 	}
 
-	go func() {
+	// The job runs on the quit context of the token store, in the namespace
+	// of the request
+	job, err := ts.core.jobs.start(ctx, ts.quitContext, "token-tidy", func(quitCtx context.Context, progress *jobProgress) error {
 		defer atomic.StoreUint32(ts.tidyLock, 0)
 
 		logger := ts.logger.Named("tidy")
@@ -1798,8 +1832,6 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 			ts.logger.Info("beginning tidy operation on tokens")
 			defer ts.logger.Info("finished tidy operation on tokens")
 
-			quitCtx := namespace.ContextWithNamespace(ts.quitContext, ns)
-
 			// List out all the accessors
 			saltedAccessorList, err := ts.accessorView(ns).List(quitCtx, "")
 			if err != nil {
@@ -1818,11 +1850,17 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 				return errwrap.Wrapf("failed to fetch cubbyhole storage keys: {{err}}", err)
 			}
 
+			progress.SetTotal(int64(len(parentList) + len(saltedAccessorList) + len(cubbyholeKeys)))
+
 			var countParentEntries, deletedCountParentEntries, countParentList, deletedCountParentList int64
 
 			// Scan through the secondary index entries; if there is an entry
 			// with the token's salt ID at the end, remove it
 			for _, parent := range parentList {
+				if err := quitCtx.Err(); err != nil {
+					return err
+				}
+				progress.Complete(1)
 				countParentEntries++
 
 				// Get the children
@@ -1899,11 +1937,16 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 				deletedCountInvalidCubbyholeKey int64
 
 			validCubbyholeKeys := make(map[string]bool)
+			validAccessors := make(map[string]bool)
 
 			// For each of the accessor, see if the token ID associated with it is
 			// a valid one. If not, delete the leases associated with that token
 			// and delete the accessor as well.
 			for index, saltedAccessor := range saltedAccessorList {
+				if err := quitCtx.Err(); err != nil {
+					return err
+				}
+				progress.Complete(1)
 				countAccessorList++
 				if countAccessorList%500 == 0 {
 					percentComplete := float64(index) / float64(len(saltedAccessorList)) * 100
@@ -1980,6 +2023,16 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 					}
 					deletedCountAccessorInvalidToken++
 				default:
+					validAccessors[saltedAccessor] = true
+
+					// Index the tokens created before the entity index
+					// existed
+					if te.EntityID != "" {
+						if err := ts.putEntityAccessor(quitCtx, ns, te.EntityID, saltedAccessor); err != nil {
+							tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to index accessor by entity: {{err}}", err))
+						}
+					}
+
 					// Cache the cubbyhole storage key when the token is valid
 					switch {
 					case te.NamespaceID == namespace.RootNamespaceID && !strings.HasPrefix(te.ID, "s."):
@@ -1999,14 +2052,60 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 				}
 			}
 
+			// Delete the entity index entries of the accessors which no
+			// longer exist. Accessors created since the accessors were
+			// listed are checked again, so that they are kept.
+			var deletedCountEntityAccessors int64
+			entities, err := ts.entityView(ns).List(quitCtx, "")
+			if err != nil {
+				tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to fetch entity index entries: {{err}}", err))
+			}
+			for _, entity := range entities {
+				if err := quitCtx.Err(); err != nil {
+					return err
+				}
+				accessors, err := ts.entityView(ns).List(quitCtx, entity)
+				if err != nil {
+					tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to fetch entity index entries: {{err}}", err))
+					continue
+				}
+				for _, saltedAccessor := range accessors {
+					if validAccessors[saltedAccessor] {
+						continue
+					}
+					accessorEntry, err := ts.accessorView(ns).Get(quitCtx, saltedAccessor)
+					if err != nil {
+						tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to read the accessor index: {{err}}", err))
+						continue
+					}
+					if accessorEntry != nil {
+						continue
+					}
+					if err := ts.entityView(ns).Delete(quitCtx, entity+saltedAccessor); err != nil {
+						tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to delete entity index entry: {{err}}", err))
+						continue
+					}
+					deletedCountEntityAccessors++
+				}
+			}
+
 			// Revoke invalid cubbyhole storage keys
 			for index, key := range cubbyholeKeys {
+				if err := quitCtx.Err(); err != nil {
+					return err
+				}
+				progress.Complete(1)
 				countCubbyholeKeys++
 				if countCubbyholeKeys%500 == 0 {
 					percentComplete := float64(index) / float64(len(cubbyholeKeys)) * 100
 					ts.logger.Info("checking if there are invalid cubbyholes", "progress", countCubbyholeKeys, "percent_complete", percentComplete)
 				}
 
+				// The sizes of the cubbyholes are deleted along with them
+				if key == cubbyholeSizePrefix {
+					continue
+				}
+
 				key := strings.TrimSuffix(key, "/")
 				if !validCubbyholeKeys[key] {
 					ts.logger.Info("deleting invalid cubbyhole", "key", key)
@@ -2027,19 +2126,41 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 			ts.logger.Info("number of revoked tokens which were invalid but present in accessors", "count", deletedCountInvalidTokenInAccessor)
 			ts.logger.Info("number of deleted accessors which had invalid tokens", "count", deletedCountAccessorInvalidToken)
 			ts.logger.Info("number of deleted cubbyhole keys that were invalid", "count", deletedCountInvalidCubbyholeKey)
+			ts.logger.Info("number of deleted entity index entries of invalid accessors", "count", deletedCountEntityAccessors)
+
+			progress.SetResult("parent_entries_scanned", countParentEntries)
+			progress.SetResult("parent_entries_deleted", deletedCountParentEntries)
+			progress.SetResult("parent_index_tokens_scanned", countParentList)
+			progress.SetResult("parent_index_tokens_revoked", deletedCountParentList)
+			progress.SetResult("accessors_scanned", countAccessorList)
+			progress.SetResult("accessors_with_empty_token_deleted", deletedCountAccessorEmptyToken)
+			progress.SetResult("accessors_with_invalid_token_deleted", deletedCountAccessorInvalidToken)
+			progress.SetResult("invalid_tokens_in_accessors_revoked", deletedCountInvalidTokenInAccessor)
+			progress.SetResult("invalid_cubbyhole_keys_deleted", deletedCountInvalidCubbyholeKey)
+			progress.SetResult("entity_index_entries_deleted", deletedCountEntityAccessors)
 
 			return tidyErrors.ErrorOrNil()
 		}
 
+		if err := ts.core.waitForMaintenanceWindow(quitCtx, logger, "token tidy"); err != nil {
+			logger.Error("tidy operation not started", "error", err)
+			return err
+		}
+
 		if err := doTidy(); err != nil {
 			logger.Error("error running tidy", "error", err)
-			return
+			return err
 		}
-	}()
+		return nil
+	})
+	if err != nil {
+		atomic.StoreUint32(ts.tidyLock, 0)
+		return nil, err
+	}
 
 	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+	ts.core.addMaintenanceWindowWarning(resp, "token tidy")
+	return jobAcceptedResponse(req, resp, job)
 }
 
 // handleUpdateLookupAccessor handles the auth/token/lookup-accessor path for returning
@@ -2156,26 +2277,31 @@ func (ts *TokenStore) handleUpdateRevokeAccessor(ctx context.Context, req *logic
 		return logical.ErrorResponse("token not found"), logical.ErrInvalidRequest
 	}
 
+	if err := ts.revokeEntry(ctx, te); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// revokeEntry revokes the token through its lease, which revokes its child
+// tokens as well.
+func (ts *TokenStore) revokeEntry(ctx context.Context, te *logical.TokenEntry) error {
 	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, ts.core)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if tokenNS == nil {
-		return nil, namespace.ErrNoNamespace
+		return namespace.ErrNoNamespace
 	}
 
 	revokeCtx := namespace.ContextWithNamespace(ts.quitContext, tokenNS)
 	leaseID, err := ts.expiration.CreateOrFetchRevocationLeaseByToken(revokeCtx, te)
 	if err != nil {
-		return nil, err
-	}
-
-	err = ts.expiration.Revoke(revokeCtx, leaseID)
-	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return nil, nil
+	return ts.expiration.Revoke(revokeCtx, leaseID)
 }
 
 // handleCreate handles the auth/token/create path for creation of new orphan
@@ -2388,6 +2514,14 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		Type:         tokenType,
 	}
 
+	if req.Connection != nil {
+		te.CreationIP = req.Connection.RemoteAddr
+	}
+
+	// Child tokens share the session of their parent, so that policies
+	// conditioned on how the client authenticated apply to them alike
+	te.Session = parent.Session
+
 	// If the role is not nil, we add the role name as part of the token's
 	// path. This makes it much easier to later revoke tokens that were issued
 	// by a role (using revoke-prefix). Users can further specify a PathSuffix
@@ -0,0 +1,315 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	sockaddr "github.com/hashicorp/go-sockaddr"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// tokenExchangeGrantType and tokenExchangeAccessTokenType are the values
+	// defined by RFC 8693 for the grant_type and token type parameters.
+	tokenExchangeGrantType       = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenExchangeAccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+func (ts *TokenStore) exchangePath() *framework.Path {
+	return &framework.Path{
+		Pattern: "exchange$",
+
+		Fields: map[string]*framework.FieldSchema{
+			"grant_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: fmt.Sprintf("Grant type of the exchange. If set, it must be %q.", tokenExchangeGrantType),
+			},
+			"subject_token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Token being exchanged. If set, it must be the token used to make the request.",
+			},
+			"subject_token_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: fmt.Sprintf("Type of the subject token. If set, it must be %q.", tokenExchangeAccessTokenType),
+			},
+			"requested_token_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: fmt.Sprintf("Type of the requested token. If set, it must be %q.", tokenExchangeAccessTokenType),
+			},
+			"policies": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Policies of the new token. They must be a subset of the policies of the exchanged token. Defaults to the policies of the exchanged token.",
+			},
+			"scope": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Space-delimited list of policies of the new token, as an alternative to 'policies'.",
+			},
+			"no_default_policy": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "If set, the default policy is not added to the new token even if the exchanged token has it.",
+			},
+			"ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "TTL of the new token. It cannot exceed the remaining TTL of the exchanged token.",
+			},
+			"num_uses": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Maximum number of uses of the new token. Defaults to 0, which means unlimited.",
+			},
+			"token_bound_cidrs": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "CIDR blocks the new token can be used from. If the exchanged token is bound to CIDR blocks, these must lie within them. Defaults to the CIDR blocks of the exchanged token.",
+			},
+			"renewable": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     true,
+				Description: "Whether the new token can be renewed, up to the expiration of the exchanged token.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: ts.handleExchange,
+		},
+
+		HelpSynopsis:    strings.TrimSpace(tokenExchangeHelp),
+		HelpDescription: strings.TrimSpace(tokenExchangeHelpDesc),
+	}
+}
+
+// handleExchange handles the auth/token/exchange path, which exchanges the
+// calling token for a child token that is restricted further, following the
+// token exchange flow of RFC 8693.
+func (ts *TokenStore) handleExchange(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if grantType := d.Get("grant_type").(string); grantType != "" && grantType != tokenExchangeGrantType {
+		return logical.ErrorResponse(fmt.Sprintf("unsupported grant_type %q", grantType)), logical.ErrInvalidRequest
+	}
+	for _, field := range []string{"subject_token_type", "requested_token_type"} {
+		if tokenType := d.Get(field).(string); tokenType != "" && tokenType != tokenExchangeAccessTokenType {
+			return logical.ErrorResponse(fmt.Sprintf("unsupported %s %q", field, tokenType)), logical.ErrInvalidRequest
+		}
+	}
+	if subjectToken := d.Get("subject_token").(string); subjectToken != "" && subjectToken != req.ClientToken {
+		return logical.ErrorResponse("subject_token must be the token used to make the request"), logical.ErrInvalidRequest
+	}
+
+	parent, err := ts.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return nil, errwrap.Wrapf("parent token lookup failed: {{err}}", err)
+	}
+	if parent == nil {
+		return logical.ErrorResponse("parent token lookup failed: no parent found"), logical.ErrInvalidRequest
+	}
+	if parent.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be exchanged"), nil
+	}
+
+	// A token with a restricted number of uses cannot be exchanged, otherwise
+	// it could escape the restriction count.
+	if parent.NumUses > 0 {
+		return logical.ErrorResponse("restricted use token cannot be exchanged"), logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != parent.NamespaceID {
+		return logical.ErrorResponse("tokens can only be exchanged within their own namespace"), logical.ErrInvalidRequest
+	}
+
+	// Work out the policies of the new token
+	policies := d.Get("policies").([]string)
+	if scope := d.Get("scope").(string); scope != "" {
+		if len(policies) > 0 {
+			return logical.ErrorResponse("only one of 'policies' and 'scope' can be set"), logical.ErrInvalidRequest
+		}
+		policies = strings.Fields(scope)
+	}
+
+	parentPolicies := policyutil.SanitizePolicies(parent.Policies, policyutil.DoNotAddDefaultPolicy)
+	parentIsRoot := strutil.StrListContains(parentPolicies, "root")
+	switch {
+	case len(policies) == 0:
+		if parentIsRoot {
+			return logical.ErrorResponse("policies must be given when exchanging a root token"), logical.ErrInvalidRequest
+		}
+		policies = parentPolicies
+	default:
+		policies = policyutil.SanitizePolicies(policies, policyutil.DoNotAddDefaultPolicy)
+		if strutil.StrListContains(policies, "root") {
+			return logical.ErrorResponse("root tokens cannot be obtained through token exchange"), logical.ErrInvalidRequest
+		}
+
+		// A root token grants everything, so any set of policies is a
+		// subset of it
+		if !parentIsRoot && !strutil.StrListSubset(parentPolicies, policies) {
+			return logical.ErrorResponse("policies must be a subset of the exchanged token's policies"), logical.ErrInvalidRequest
+		}
+	}
+
+	// Like tokens created through auth/token/create, the new token gets the
+	// default policy, as long as the exchanged token has it
+	if !d.Get("no_default_policy").(bool) && (parentIsRoot || strutil.StrListContains(parentPolicies, "default")) {
+		policies = policyutil.SanitizePolicies(policies, policyutil.AddDefaultPolicy)
+	}
+	for _, policy := range policies {
+		if strutil.StrListContains(nonAssignablePolicies, policy) {
+			return logical.ErrorResponse(fmt.Sprintf("cannot assign policy %q", policy)), nil
+		}
+	}
+
+	numUses := d.Get("num_uses").(int)
+	if numUses < 0 {
+		return logical.ErrorResponse("number of uses cannot be negative"), logical.ErrInvalidRequest
+	}
+
+	// The new token is bound to the requested CIDR blocks, which must be
+	// covered by those of the exchanged token
+	boundCIDRs := parent.BoundCIDRs
+	if boundCIDRsRaw := d.Get("token_bound_cidrs").([]string); len(boundCIDRsRaw) > 0 {
+		boundCIDRs, err = parseutil.ParseAddrs(boundCIDRsRaw)
+		if err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error parsing token_bound_cidrs: {{err}}", err).Error()), logical.ErrInvalidRequest
+		}
+		if len(parent.BoundCIDRs) > 0 && !cidrsContained(parent.BoundCIDRs, boundCIDRs) {
+			return logical.ErrorResponse("token_bound_cidrs must lie within the exchanged token's bound CIDR blocks"), logical.ErrInvalidRequest
+		}
+	}
+
+	resp := &logical.Response{}
+	now := time.Now()
+
+	// The new token must not outlive the exchanged token as it is right now,
+	// even if the exchanged token is renewed later on
+	var explicitMaxTTL time.Duration
+	le, err := ts.expiration.FetchLeaseTimesByToken(ctx, parent)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to fetch parent token lease: {{err}}", err)
+	}
+	if le != nil && !le.ExpireTime.IsZero() {
+		explicitMaxTTL = le.ExpireTime.Sub(now)
+		if explicitMaxTTL < time.Second {
+			return logical.ErrorResponse("exchanged token is about to expire"), logical.ErrInvalidRequest
+		}
+	}
+
+	requestedTTL := time.Duration(d.Get("ttl").(int)) * time.Second
+	if requestedTTL < 0 {
+		return logical.ErrorResponse("ttl must be positive"), logical.ErrInvalidRequest
+	}
+	if requestedTTL == 0 {
+		requestedTTL = explicitMaxTTL
+	}
+
+	sysView := ts.System().(extendedSystemView)
+	ttl, warnings, err := framework.CalculateTTL(sysView, 0, requestedTTL, 0, 0, explicitMaxTTL, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+
+	// The new token deliberately has no entity: the policies of the entity
+	// and of its groups would be added to the requested ones, granting the
+	// new token more than it asked for. It is attributed to the exchanged
+	// token, and through it to its entity, by its parent.
+	te := logical.TokenEntry{
+		Parent:         req.ClientToken,
+		Path:           "auth/token/exchange",
+		Policies:       policies,
+		DisplayName:    parent.DisplayName,
+		NumUses:        numUses,
+		CreationTime:   now.Unix(),
+		NamespaceID:    ns.ID,
+		Type:           logical.TokenTypeService,
+		BoundCIDRs:     boundCIDRs,
+		TTL:            ttl,
+		ExplicitMaxTTL: explicitMaxTTL,
+		Session:        parent.Session,
+	}
+
+	if req.Connection != nil {
+		te.CreationIP = req.Connection.RemoteAddr
+	}
+
+	if err := ts.create(ctx, &te); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	// Count the successful token creation.
+	ts.core.metricSink.IncrCounterWithLabels(
+		[]string{"token", "creation"},
+		1,
+		[]metrics.Label{
+			metricsutil.NamespaceLabel(ns),
+			{"auth_method", "token"},
+			{"mount_point", ns.TrimmedPath(req.MountPoint)},
+			{"creation_ttl", metricsutil.TTLBucket(te.TTL)},
+			{"token_type", te.Type.String()},
+		},
+	)
+
+	resp.Auth = &logical.Auth{
+		NumUses:     te.NumUses,
+		DisplayName: te.DisplayName,
+		Policies:    te.Policies,
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       te.TTL,
+			Renewable: d.Get("renewable").(bool),
+		},
+		ClientToken:    te.ID,
+		Accessor:       te.Accessor,
+		ExplicitMaxTTL: te.ExplicitMaxTTL,
+		CreationPath:   te.Path,
+		TokenType:      te.Type,
+	}
+	resp.Data = map[string]interface{}{
+		"issued_token_type": tokenExchangeAccessTokenType,
+	}
+
+	return resp, nil
+}
+
+// cidrsContained returns whether every block in cidrs lies within at least one
+// of the blocks in bounds.
+func cidrsContained(bounds, cidrs []*sockaddr.SockAddrMarshaler) bool {
+	for _, cidr := range cidrs {
+		contained := false
+		for _, bound := range bounds {
+			if bound.SockAddr.Contains(cidr.SockAddr) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	tokenExchangeHelp     = `This endpoint exchanges the calling token for a more restricted child token.`
+	tokenExchangeHelpDesc = `
+This endpoint exchanges the calling token for a new child token, following the
+token exchange flow of RFC 8693. The new token can be restricted to a subset of
+the calling token's policies, a shorter TTL, a number of uses and a set of CIDR
+blocks, but never has more rights than the calling token and never outlives
+its current expiration. The new token is not tied to the entity of the calling
+token, so the policies of the entity and its groups do not apply to it. As the
+new token is a child of the calling token, it is revoked along with it. This
+makes it suitable for handing out to subprocesses without creating orphan
+tokens.
+`
+)
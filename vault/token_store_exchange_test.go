@@ -0,0 +1,214 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testTokenStoreExchangeRole creates a role issuing tokens bound to 10.0.0.0/8.
+func testTokenStoreExchangeRole(t *testing.T, ts *TokenStore, root string, policies []string) {
+	t.Helper()
+	req := logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+	req.ClientToken = root
+	req.Data["allowed_policies"] = policies
+	req.Data["token_bound_cidrs"] = []string{"10.0.0.0/8"}
+	resp, err := ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+}
+
+func TestTokenStore_Exchange(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	testTokenStoreExchangeRole(t, ts, root, []string{"foo", "bar"})
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create/test")
+	req.ClientToken = root
+	req.Data["ttl"] = "1h"
+	parentResp := testMakeTokenViaRequest(t, ts, req)
+	if parentResp.IsError() {
+		t.Fatalf("bad: %#v", parentResp)
+	}
+	parent := parentResp.Auth.ClientToken
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "exchange")
+	req.ClientToken = parent
+	req.Data["grant_type"] = tokenExchangeGrantType
+	req.Data["subject_token"] = parent
+	req.Data["scope"] = "foo"
+	req.Data["ttl"] = "2h"
+	req.Data["num_uses"] = 5
+	req.Data["token_bound_cidrs"] = []string{"10.1.0.0/16"}
+	resp := testMakeTokenViaRequest(t, ts, req)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if len(resp.Warnings) == 0 {
+		t.Fatalf("expected a warning about the capped TTL")
+	}
+	if resp.Data["issued_token_type"] != tokenExchangeAccessTokenType {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	child := resp.Auth.ClientToken
+	out, err := ts.Lookup(namespace.RootContext(nil), child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Parent != parent {
+		t.Fatalf("expected parent %q, got %q", parent, out.Parent)
+	}
+	if !reflect.DeepEqual(out.Policies, []string{"default", "foo"}) {
+		t.Fatalf("bad policies: %v", out.Policies)
+	}
+	if out.NumUses != 5 {
+		t.Fatalf("bad num uses: %d", out.NumUses)
+	}
+	if out.TTL > time.Hour || out.ExplicitMaxTTL > time.Hour || out.ExplicitMaxTTL < 59*time.Minute {
+		t.Fatalf("expected TTL capped to the parent's, got %s and %s", out.TTL, out.ExplicitMaxTTL)
+	}
+	if len(out.BoundCIDRs) != 1 || out.BoundCIDRs[0].String() != "10.1.0.0/16" {
+		t.Fatalf("bad bound CIDRs: %v", out.BoundCIDRs)
+	}
+
+	// Revoking the parent revokes the exchanged token
+	req = logical.TestRequest(t, logical.UpdateOperation, "revoke")
+	req.Data = map[string]interface{}{
+		"token": parent,
+	}
+	resp, err = ts.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	out, err = ts.Lookup(namespace.RootContext(nil), child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		t.Fatalf("expected exchanged token to be revoked, got %#v", out)
+	}
+}
+
+func TestTokenStore_Exchange_Identity(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	for name, path := range map[string]string{"token-policy": "secret/token", "entity-policy": "secret/entity"} {
+		policy, err := ParseACLPolicy(namespace.RootNamespace, `path "`+path+`" { capabilities = ["read"] }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy.Name = name
+		if err := c.policyStore.SetPolicy(ctx, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"policies": "entity-policy",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+
+	parent := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: []string{"token-policy"},
+		EntityID: entityID,
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, ts, parent)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "exchange")
+	req.ClientToken = parent.ID
+	resp = testMakeTokenViaRequest(t, ts, req)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.EntityID != "" {
+		t.Fatalf("expected no entity, got %q", resp.Auth.EntityID)
+	}
+	child, err := ts.Lookup(ctx, resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.EntityID != "" {
+		t.Fatalf("expected no entity, got %q", child.EntityID)
+	}
+
+	// The policies of the parent's entity do not apply to the new token
+	for path, expected := range map[string][2][]string{
+		"secret/token":  {{"read"}, {"read"}},
+		"secret/entity": {{"read"}, {"deny"}},
+	} {
+		for i, token := range []string{parent.ID, child.ID} {
+			capabilities, err := c.Capabilities(ctx, token, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(capabilities, expected[i]) {
+				t.Fatalf("expected capabilities %v on %q, got %v", expected[i], path, capabilities)
+			}
+		}
+	}
+}
+
+func TestTokenStore_Exchange_Restrictions(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	testTokenStoreExchangeRole(t, ts, root, []string{"foo"})
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create/test")
+	req.ClientToken = root
+	parent := testMakeTokenViaRequest(t, ts, req).Auth.ClientToken
+
+	cases := map[string]map[string]interface{}{
+		"wider policies":     {"policies": "foo,bar"},
+		"root policy":        {"policies": "root"},
+		"policies and scope": {"policies": "foo", "scope": "foo"},
+		"wider cidrs":        {"token_bound_cidrs": "192.168.0.0/16"},
+		"other subject":      {"subject_token": root},
+		"bad grant type":     {"grant_type": "client_credentials"},
+		"bad token type":     {"requested_token_type": "urn:ietf:params:oauth:token-type:jwt"},
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.UpdateOperation, "exchange")
+			req.ClientToken = parent
+			req.Data = data
+			resp, err := ts.HandleRequest(namespace.RootContext(nil), req)
+			if err == nil || resp == nil || !resp.IsError() {
+				t.Fatalf("expected error, got: %#v, %v", resp, err)
+			}
+		})
+	}
+
+	// Restricted use tokens cannot be exchanged
+	req = logical.TestRequest(t, logical.UpdateOperation, "exchange")
+	req.ClientToken = parent
+	req.Data["num_uses"] = 2
+	limited := testMakeTokenViaRequest(t, ts, req).Auth.ClientToken
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "exchange")
+	req.ClientToken = limited
+	resp, err := ts.HandleRequest(namespace.RootContext(nil), req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got: %#v, %v", resp, err)
+	}
+}
@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (ts *TokenStore) selfAccessorsPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "self-accessors/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: ts.handleListSelfAccessors,
+		},
+
+		HelpSynopsis:    strings.TrimSpace(tokenListSelfAccessorsHelp),
+		HelpDescription: strings.TrimSpace(tokenListSelfAccessorsHelpDesc),
+	}
+}
+
+func (ts *TokenStore) revokeSelfAccessorPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "revoke-self-accessor$",
+
+		Fields: map[string]*framework.FieldSchema{
+			"accessor": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Accessor of the token (request body)",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: ts.handleRevokeSelfAccessor,
+		},
+
+		HelpSynopsis:    strings.TrimSpace(tokenRevokeSelfAccessorHelp),
+		HelpDescription: strings.TrimSpace(tokenRevokeSelfAccessorHelp),
+	}
+}
+
+// entityAccessorKey returns the key of the entity index entry of the salted
+// accessor, in the namespace of the context.
+func (ts *TokenStore) entityAccessorKey(ctx context.Context, entityID, saltedAccessor string) (string, error) {
+	saltedEntityID, err := ts.SaltID(ctx, entityID)
+	if err != nil {
+		return "", err
+	}
+	return saltedEntityID + "/" + saltedAccessor, nil
+}
+
+// putEntityAccessor indexes the salted accessor of a token by its entity. The
+// context must be in the namespace of the token.
+func (ts *TokenStore) putEntityAccessor(ctx context.Context, ns *namespace.Namespace, entityID, saltedAccessor string) error {
+	key, err := ts.entityAccessorKey(ctx, entityID, saltedAccessor)
+	if err != nil {
+		return err
+	}
+	if err := ts.entityView(ns).Put(ctx, &logical.StorageEntry{Key: key}); err != nil {
+		return errwrap.Wrapf("failed to persist entity index entry: {{err}}", err)
+	}
+	return nil
+}
+
+// handleListSelfAccessors handles the auth/token/self-accessors path, listing
+// the accessors of the outstanding tokens of the calling token's entity in the
+// namespace of the request, along with their properties. Only the accessors
+// indexed by the entity are read.
+func (ts *TokenStore) handleListSelfAccessors(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.EntityID == "" {
+		return logical.ErrorResponse("token is not associated with an entity"), logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := ts.entityAccessorKey(ctx, req.EntityID, "")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ts.entityView(ns).List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	keyInfo := make(map[string]interface{})
+	for _, entry := range entries {
+		// Entries of revoked tokens which were not removed yet are skipped,
+		// and left to the tidy operation
+		aEntry, err := ts.lookupByAccessor(ctx, entry, true, false)
+		if err != nil || aEntry.TokenID == "" || aEntry.NamespaceID != ns.ID {
+			continue
+		}
+
+		te, err := ts.Lookup(ctx, aEntry.TokenID)
+		if err != nil {
+			return nil, err
+		}
+		if te == nil || te.EntityID != req.EntityID {
+			continue
+		}
+
+		info := map[string]interface{}{
+			"creation_time": te.CreationTime,
+			"creation_ip":   te.CreationIP,
+			"display_name":  te.DisplayName,
+			"path":          te.Path,
+			"expire_time":   nil,
+			"ttl":           int64(0),
+			"current":       te.ID == req.ClientToken,
+		}
+
+		leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(ctx, te)
+		if err != nil {
+			return nil, err
+		}
+		if leaseTimes != nil && !leaseTimes.ExpireTime.IsZero() {
+			info["expire_time"] = leaseTimes.ExpireTime
+			info["ttl"] = leaseTimes.ttl()
+		}
+
+		keys = append(keys, te.Accessor)
+		keyInfo[te.Accessor] = info
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleRevokeSelfAccessor handles the auth/token/revoke-self-accessor path,
+// revoking the token associated with the accessor if it belongs to the calling
+// token's entity.
+func (ts *TokenStore) handleRevokeSelfAccessor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		return nil, &logical.StatusBadRequest{Err: "missing accessor"}
+	}
+	if req.EntityID == "" {
+		return logical.ErrorResponse("token is not associated with an entity"), logical.ErrInvalidRequest
+	}
+
+	aEntry, err := ts.lookupByAccessor(ctx, accessor, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	te, err := ts.Lookup(ctx, aEntry.TokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens of other entities are reported like unknown accessors, so as not
+	// to disclose their existence
+	if te == nil || te.EntityID != req.EntityID {
+		return nil, &logical.StatusBadRequest{Err: "invalid accessor"}
+	}
+
+	if err := ts.revokeEntry(ctx, te); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const (
+	tokenListSelfAccessorsHelp     = `List the accessors of the tokens of the calling token's entity.`
+	tokenListSelfAccessorsHelpDesc = `
+This endpoint lists the accessors of the outstanding tokens of the entity of
+the calling token in the namespace of the request, along with their creation
+time, the address of the client which created them, and their TTL. Together
+with the revoke-self-accessor endpoint, this allows users to review and revoke
+their own tokens without the involvement of an operator.
+`
+	tokenRevokeSelfAccessorHelp = `
+This endpoint will delete the token associated with the accessor, if it
+belongs to the entity of the calling token, and all of its child tokens.
+`
+)
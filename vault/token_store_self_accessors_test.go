@@ -0,0 +1,175 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestTokenStore_SelfAccessors(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	makeToken := func(entityID, creationIP string) *logical.TokenEntry {
+		te := &logical.TokenEntry{
+			Path:       "auth/userpass/login/foo",
+			Policies:   []string{"default"},
+			TTL:        time.Hour,
+			EntityID:   entityID,
+			CreationIP: creationIP,
+		}
+		testMakeTokenDirectly(t, ts, te)
+		return te
+	}
+	current := makeToken("entity1", "127.0.0.1")
+	other := makeToken("entity1", "127.0.0.2")
+	foreign := makeToken("entity2", "127.0.0.3")
+
+	req := logical.TestRequest(t, logical.ListOperation, "self-accessors")
+	req.ClientToken = current.ID
+	req.EntityID = "entity1"
+	resp, err := ts.HandleRequest(ctx, req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	keyInfo := resp.Data["key_info"].(map[string]interface{})
+	if _, ok := keyInfo[foreign.Accessor]; ok {
+		t.Fatalf("expected the token of the other entity to be excluded")
+	}
+	info := keyInfo[current.Accessor].(map[string]interface{})
+	if info["current"] != true || info["creation_ip"] != "127.0.0.1" || info["creation_time"] != current.CreationTime {
+		t.Fatalf("bad: %#v", info)
+	}
+	if ttl := info["ttl"].(int64); ttl <= 0 || ttl > 3600 {
+		t.Fatalf("bad ttl: %d", ttl)
+	}
+	if info := keyInfo[other.Accessor].(map[string]interface{}); info["current"] != false || info["creation_ip"] != "127.0.0.2" {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// Tokens of other entities cannot be revoked
+	req = logical.TestRequest(t, logical.UpdateOperation, "revoke-self-accessor")
+	req.ClientToken = current.ID
+	req.EntityID = "entity1"
+	req.Data["accessor"] = foreign.Accessor
+	resp, err = ts.HandleRequest(ctx, req)
+	if err == nil {
+		t.Fatalf("expected error, got resp: %#v", resp)
+	}
+	if out, err := ts.Lookup(ctx, foreign.ID); err != nil || out == nil {
+		t.Fatalf("expected the token to remain, err: %v", err)
+	}
+
+	req.Data["accessor"] = other.Accessor
+	resp, err = ts.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	if out, err := ts.Lookup(ctx, other.ID); err != nil || out != nil {
+		t.Fatalf("expected the token to be revoked, err: %v", err)
+	}
+
+	// Revocation removes the accessor from the index of the entity
+	prefix, err := ts.entityAccessorKey(ctx, "entity1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed, err := ts.entityView(namespace.RootNamespace).List(ctx, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltedAccessor, err := ts.SaltID(ctx, current.Accessor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(indexed, []string{saltedAccessor}) {
+		t.Fatalf("bad entity index: %v", indexed)
+	}
+
+	// Tokens without an entity have nothing to list
+	req = logical.TestRequest(t, logical.ListOperation, "self-accessors")
+	req.ClientToken = root
+	resp, err = ts.HandleRequest(ctx, req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error, got err: %v\nresp: %#v", err, resp)
+	}
+}
+
+func TestTokenStore_CreationIP(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = root
+	req.Connection = &logical.Connection{RemoteAddr: "10.0.0.1"}
+	resp := testMakeTokenViaRequest(t, ts, req)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := ts.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.CreationIP != "10.0.0.1" {
+		t.Fatalf("bad creation IP: %q", out.CreationIP)
+	}
+}
+
+func TestTokenStore_ChildSession(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+
+	session := &logical.AuthSession{
+		MountType:     "userpass",
+		MountAccessor: "auth_userpass_1234",
+		MFA:           true,
+	}
+	parent := &logical.TokenEntry{
+		Path:     "auth/userpass/login/tester",
+		Policies: []string{"root"},
+		TTL:      time.Hour,
+		Session:  session,
+	}
+	testMakeTokenDirectly(t, ts, parent)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = parent.ID
+	resp := testMakeTokenViaRequest(t, ts, req)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err := ts.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Session, session) {
+		t.Fatalf("expected the session of the parent, got %#v", out.Session)
+	}
+
+	// Batch tokens carry the session in their encrypted entry
+	req = logical.TestRequest(t, logical.UpdateOperation, "create")
+	req.ClientToken = parent.ID
+	req.Data["type"] = "batch"
+	req.Data["policies"] = []string{"default"}
+	resp = testMakeTokenViaRequest(t, ts, req)
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	out, err = ts.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Session, session) {
+		t.Fatalf("expected the session of the parent on the batch token, got %#v", out.Session)
+	}
+}
@@ -270,7 +270,10 @@ DONELISTHANDLING:
 		}
 	}
 
-	cubbyResp, err := c.router.Route(ctx, cubbyReq)
+	// The response is stored regardless of the cubbyhole size quota
+	cubbyCtx := context.WithValue(ctx, ctxKeyResponseWrapping{}, true)
+
+	cubbyResp, err := c.router.Route(cubbyCtx, cubbyReq)
 	if err != nil {
 		// Revoke since it's not yet being tracked for expiration
 		c.tokenStore.revokeOrphan(ctx, te.ID)
@@ -296,7 +299,7 @@ DONELISTHANDLING:
 	} else {
 		cubbyReq.Data["creation_path"] = resp.WrapInfo.CreationPath
 	}
-	cubbyResp, err = c.router.Route(ctx, cubbyReq)
+	cubbyResp, err = c.router.Route(cubbyCtx, cubbyReq)
 	if err != nil {
 		// Revoke since it's not yet being tracked for expiration
 		c.tokenStore.revokeOrphan(ctx, te.ID)
@@ -24,6 +24,7 @@ export default [
         content: [
           'cassandra',
           'couchbase',
+          'db2',
           'elasticdb',
           'influxdb',
           'hanadb',
@@ -33,7 +34,10 @@ export default [
           'mysql-maria',
           'oracle',
           'postgresql',
+          'redis',
           'redshift',
+          'snowflake',
+          'trino',
         ],
       },
       { category: 'gcp' },
@@ -53,6 +57,7 @@ export default [
           'group-alias',
           'tokens',
           'lookup',
+          'mount-constraints',
         ],
       },
       { category: 'mongodbatlas' },
@@ -60,6 +65,8 @@ export default [
       { category: 'openldap' },
       { category: 'pki' },
       { category: 'rabbitmq' },
+      { category: 'rotation' },
+      { category: 'share' },
       { category: 'ssh' },
       { category: 'totp' },
       { category: 'transform' },
@@ -94,6 +101,7 @@ export default [
     content: [
       'audit',
       'audit-hash',
+      'audit-salt',
       'auth',
       'capabilities',
       'capabilities-accessor',
@@ -104,13 +112,18 @@ export default [
       'config-state',
       'config-ui',
       'control-group',
+      'events-subscribe',
+      'events-webhooks',
       'generate-root',
+      'ha-status',
       'health',
       'host-info',
+      'in-flight-req',
       'init',
       'internal-counters',
       'internal-specs-openapi',
       'internal-ui-mounts',
+      'jobs',
       'key-status',
       'leader',
       'leases',
@@ -120,6 +133,7 @@ export default [
         category: 'mfa',
         content: ['duo', 'okta', 'pingid', 'totp'],
       },
+      'migrate-state',
       'monitor',
       'mounts',
       'namespaces',
@@ -131,6 +145,7 @@ export default [
       'pprof',
       'quotas-config',
       'rate-limit-quotas',
+      'concurrency-quotas',
       'lease-count-quotas',
       'raw',
       'rekey',
@@ -150,6 +165,7 @@ export default [
         content: ['raft', 'raftautosnapshots'],
       },
       'tools',
+      'trash',
       'unseal',
       'wrapping-lookup',
       'wrapping-rewrap',
@@ -60,6 +60,7 @@ export default [
           'gcpckms',
           'ocikms',
           'pkcs11',
+          'tpm',
           'transit',
         ],
       },
@@ -105,12 +106,16 @@ export default [
       'agent',
       {
         category: 'audit',
-        content: ['disable', 'enable', 'list'],
+        content: ['disable', 'enable', 'list', 'verify'],
       },
       {
         category: 'auth',
         content: ['disable', 'enable', 'help', 'list', 'tune'],
       },
+      {
+        category: 'context',
+        content: ['delete', 'list', 'set', 'use'],
+      },
       'debug',
       'delete',
       {
@@ -123,6 +128,7 @@ export default [
           'list',
           'metadata',
           'patch',
+          'prune',
           'put',
           'rollback',
           'undelete',
@@ -141,6 +147,7 @@ export default [
         content: [
           'generate-root',
           'init',
+          'inventory',
           'key-status',
           'migrate',
           'raft',
@@ -226,6 +233,7 @@ export default [
         content: [
           'cassandra',
           'couchbase',
+          'db2',
           'elasticdb',
           'hanadb',
           'influxdb',
@@ -235,7 +243,11 @@ export default [
           'mysql-maria',
           'oracle',
           'postgresql',
+          'redis',
           'redshift',
+          'snowflake',
+          'trino',
+          'yugabytedb',
           'custom',
         ],
       },
@@ -253,6 +265,7 @@ export default [
       { category: 'openldap' },
       { category: 'pki' },
       { category: 'rabbitmq' },
+      { category: 'share' },
       {
         category: 'ssh',
         content: [